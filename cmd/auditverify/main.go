@@ -0,0 +1,86 @@
+// Command auditverify checks the write-once audit log for tampering. It
+// reads the bucket configured by AUDIT_LOG_BUCKET and AUDIT_LOG_PREFIX
+// through the storage backend configured for the server.
+//
+//	go run ./cmd/auditverify
+//	go run ./cmd/auditverify -anchor 1042:3f6c...
+//
+// It prints the number of records and the hash of the last one. Keep that
+// hash somewhere the log's writers cannot change: passing it back later
+// with -anchor proves no record up to it was removed from the end of the
+// log, which the hash chain alone cannot show. It exits with status 1 when
+// the log fails verification.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"go-server/internal/auditlog"
+	"go-server/internal/config"
+	"go-server/internal/storage"
+)
+
+func main() {
+	anchor := flag.String("anchor", "", "SEQ:HASH of a record verified earlier, which must still be in the log")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("❌ Failed to load configuration: %v", err)
+	}
+	stores, err := storage.NewStores(cfg.Storage, []string{cfg.Audit.Bucket})
+	if err != nil {
+		log.Fatalf("❌ Failed to open storage: %v", err)
+	}
+	store := stores[cfg.Audit.Bucket]
+
+	ctx := context.Background()
+	report, err := auditlog.Verify(ctx, store, cfg.Audit.Prefix)
+	var tampered *auditlog.TamperError
+	if errors.As(err, &tampered) {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		fmt.Fprintf(os.Stderr, "   %d records before it verified\n", report.Records)
+		os.Exit(1)
+	}
+	if err != nil {
+		log.Fatalf("❌ Verification failed: %v", err)
+	}
+
+	if *anchor != "" {
+		if err := checkAnchor(ctx, store, cfg.Audit.Prefix, *anchor, report); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	fmt.Printf("✅ %d audit records verified in %s/%s\n", report.Records, cfg.Audit.Bucket, cfg.Audit.Prefix)
+	fmt.Printf("HEAD=%d:%s\n", report.Records, report.Head)
+}
+
+// checkAnchor checks that the record an earlier run reported as the head
+// is still in the verified log with the same hash
+func checkAnchor(ctx context.Context, store storage.Store, prefix, anchor string, report auditlog.VerifyReport) error {
+	seqText, hash, ok := strings.Cut(anchor, ":")
+	seq, err := strconv.ParseUint(seqText, 10, 64)
+	if !ok || err != nil || seq == 0 {
+		return fmt.Errorf("invalid anchor %q, want SEQ:HASH", anchor)
+	}
+	if seq > report.Records {
+		return fmt.Errorf("audit log ends at record %d but record %d was verified before: records were removed", report.Records, seq)
+	}
+	record, err := auditlog.Read(ctx, store, prefix, seq)
+	if err != nil {
+		return err
+	}
+	if record.Hash != hash {
+		return fmt.Errorf("record %d no longer has the anchored hash: the log was rewritten", seq)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+// Command breakglass manages the offline root key for emergency access and
+// issues break-glass tokens signed with it.
+//
+//	go run ./cmd/breakglass keygen -out root.key
+//	go run ./cmd/breakglass issue -key root.key -operator alice -reason "SSO outage" -ttl 1h
+//
+// keygen prints the public key to set as BREAK_GLASS_PUBLIC_KEY on servers.
+// Keep the private key offline; anyone holding it can mint admin access.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"go-server/internal/breakglass"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	switch os.Args[1] {
+	case "keygen":
+		keygen(os.Args[2:])
+	case "issue":
+		issue(os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: breakglass keygen -out FILE")
+	fmt.Fprintln(os.Stderr, "       breakglass issue -key FILE -operator NAME -reason TEXT [-ttl 1h]")
+	os.Exit(2)
+}
+
+func keygen(args []string) {
+	flags := flag.NewFlagSet("keygen", flag.ExitOnError)
+	out := flags.String("out", "", "file to write the private root key to")
+	flags.Parse(args)
+	if *out == "" {
+		usage()
+	}
+
+	publicKey, privateKey, err := breakglass.GenerateKey()
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	// O_EXCL so an existing root key is never overwritten
+	file, err := os.OpenFile(*out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		log.Fatalf("❌ Failed to create key file: %v", err)
+	}
+	if _, err := fmt.Fprintln(file, privateKey); err != nil {
+		log.Fatalf("❌ Failed to write key file: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		log.Fatalf("❌ Failed to write key file: %v", err)
+	}
+
+	fmt.Printf("🔑 Private root key written to %s. Store it offline.\n", *out)
+	fmt.Printf("BREAK_GLASS_PUBLIC_KEY=%s\n", publicKey)
+}
+
+func issue(args []string) {
+	flags := flag.NewFlagSet("issue", flag.ExitOnError)
+	keyFile := flags.String("key", "", "file holding the private root key")
+	operator := flags.String("operator", "", "person the token is issued to")
+	reason := flags.String("reason", "", "why emergency access is needed")
+	ttl := flags.Duration("ttl", time.Hour, "how long the token is valid for")
+	flags.Parse(args)
+	if *keyFile == "" {
+		usage()
+	}
+
+	encoded, err := os.ReadFile(*keyFile)
+	if err != nil {
+		log.Fatalf("❌ Failed to read key file: %v", err)
+	}
+	rootKey, err := breakglass.ParsePrivateKey(string(encoded))
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	token, err := breakglass.Issue(rootKey, *operator, *reason, time.Now(), *ttl)
+	if err != nil {
+		log.Fatalf("❌ Failed to issue token: %v", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "⚠️  Break-glass token for %s, valid for %s. All administrators are alerted when it is used.\n", *operator, *ttl)
+	fmt.Fprintf(os.Stderr, "   Send it in the %s header.\n", breakglass.Header)
+	fmt.Println(token)
+}
@@ -0,0 +1,63 @@
+// Command errcodes checks that every error code written by the server is
+// registered in the errors catalog, and prints the catalog for the API docs.
+//
+//	go run ./cmd/errcodes ./internal
+//	go run ./cmd/errcodes -docs markdown > docs/error-codes.md
+//
+// The check exits with status 1 when it finds unregistered codes or codes
+// written with a status other than the registered one.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"go-server/internal/errors"
+	"go-server/internal/errors/codecheck"
+)
+
+func main() {
+	docs := flag.String("docs", "", "print the catalog as markdown or json instead of checking")
+	flag.Parse()
+
+	switch *docs {
+	case "":
+	case "markdown":
+		if err := errors.WriteCatalogMarkdown(os.Stdout); err != nil {
+			log.Fatalf("Failed to write catalog: %v", err)
+		}
+		return
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(errors.Catalog()); err != nil {
+			log.Fatalf("Failed to write catalog: %v", err)
+		}
+		return
+	default:
+		fmt.Fprintln(os.Stderr, "usage: errcodes [-docs markdown|json] [DIR...]")
+		os.Exit(2)
+	}
+
+	roots := flag.Args()
+	if len(roots) == 0 {
+		roots = []string{"./internal"}
+	}
+	failed := false
+	for _, root := range roots {
+		issues, err := codecheck.Check(root)
+		if err != nil {
+			log.Fatalf("Failed to check %s: %v", root, err)
+		}
+		for _, issue := range issues {
+			fmt.Fprintln(os.Stderr, issue)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,81 @@
+// Command kms manages the master keys of the key management service set by
+// KMS_BACKEND.
+//
+//	go run ./cmd/kms keygen -out kms-keys.json
+//	go run ./cmd/kms rotate
+//
+// keygen writes a keyfile for the local backend, for development. rotate
+// makes a new master key version current; values encrypted before keep
+// decrypting, so nothing needs to be re-encrypted. With the local backend,
+// restart every server sharing the keyfile afterwards so they encrypt with
+// the new version.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"go-server/internal/config"
+	"go-server/internal/kms"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	switch os.Args[1] {
+	case "keygen":
+		keygen(os.Args[2:])
+	case "rotate":
+		rotate()
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: kms keygen -out FILE")
+	fmt.Fprintln(os.Stderr, "       kms rotate")
+	os.Exit(2)
+}
+
+func keygen(args []string) {
+	flags := flag.NewFlagSet("keygen", flag.ExitOnError)
+	out := flags.String("out", "", "file to write the keyfile to")
+	flags.Parse(args)
+	if *out == "" {
+		usage()
+	}
+
+	if err := kms.CreateKeyFile(*out); err != nil {
+		log.Fatalf("❌ Failed to create keyfile: %v", err)
+	}
+	fmt.Printf("🔑 Keyfile written to %s. It holds the master keys in the clear: use it for development only.\n", *out)
+	fmt.Printf("KMS_BACKEND=local\nKMS_KEY_FILE=%s\n", *out)
+}
+
+func rotate() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("❌ Failed to load configuration: %v", err)
+	}
+	if cfg.KMS.Backend == "" {
+		log.Fatalf("❌ No KMS configured; set KMS_BACKEND")
+	}
+	provider, err := kms.New(cfg.KMS.Provider())
+	if err != nil {
+		log.Fatalf("❌ Failed to open the KMS: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := provider.Rotate(ctx); err != nil {
+		log.Fatalf("❌ Failed to rotate the master key: %v", err)
+	}
+	fmt.Printf("✅ Rotated the %s master key\n", cfg.KMS.Backend)
+}
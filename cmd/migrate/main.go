@@ -0,0 +1,328 @@
+// Command migrate applies the SQL migrations in MIGRATION_PATH to the
+// database the server is configured for, so deploys can run them as a
+// step of their own instead of relying on AutoMigrate at startup (turn
+// that off with DB_AUTO_MIGRATE=false).
+//
+//	go run ./cmd/migrate up                  # expand migrations only
+//	go run ./cmd/migrate up -phase contract  # everything, once the old build is gone
+//	go run ./cmd/migrate down -steps 1
+//	go run ./cmd/migrate status
+//	go run ./cmd/migrate create add_post_summary
+//	go run ./cmd/migrate create -contract drop_post_legacy_body
+//	go run ./cmd/migrate seed -env staging
+//	go run ./cmd/migrate seed -fixtures fixtures/demo.yaml,fixtures/blog
+//
+// up refuses a contract migration that drops schema this build still
+// uses. down runs the down files of the newest applied migrations; roll
+// back only once no running build needs what they added. status exits
+// with status 1 while migrations are pending or this build's schema is
+// missing, so it can gate a deploy.
+//
+// up and down take -lock to hold the migration lock in Redis, so deploy
+// steps started together on several hosts apply each migration once; the
+// servers hold the same lock around AutoMigrate.
+//
+// seed runs the seeds of package seed for the environment given by -env
+// or GO_ENV, and up does too with -seed. The administrator is taken from
+// SEED_ADMIN_EMAIL, SEED_ADMIN_USERNAME and SEED_ADMIN_PASSWORD, which
+// like other secrets can come from a file or secret manager. -fixtures
+// adds the YAML or JSON fixture files, or directories of them, given as
+// a comma-separated list (see package fixtures), as a seed of their own
+// for the environment.
+//
+// The SQL migrations are written for PostgreSQL. With DB_DRIVER=mysql,
+// sqlite or memory only seed runs; the server creates the tables with
+// AutoMigrate.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"go-server/internal/auth"
+	"go-server/internal/config"
+	"go-server/internal/cryptopolicy"
+	"go-server/internal/database"
+	"go-server/internal/database/fixtures"
+	"go-server/internal/database/repositories"
+	"go-server/internal/database/schema"
+	seeds "go-server/internal/database/seed"
+	"go-server/internal/logger"
+	"go-server/internal/secrets"
+
+	"gorm.io/gorm"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	switch os.Args[1] {
+	case "up":
+		up(os.Args[2:])
+	case "down":
+		down(os.Args[2:])
+	case "status":
+		status(os.Args[2:])
+	case "create":
+		create(os.Args[2:])
+	case "seed":
+		seed(os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: migrate up [-phase expand|contract] [-seed] [-fixtures PATHS] [-lock]")
+	fmt.Fprintln(os.Stderr, "       migrate down [-steps N] [-lock]")
+	fmt.Fprintln(os.Stderr, "       migrate status")
+	fmt.Fprintln(os.Stderr, "       migrate create [-contract] NAME")
+	fmt.Fprintln(os.Stderr, "       migrate seed [-env ENV] [-force] [-fixtures PATHS]")
+	os.Exit(2)
+}
+
+func up(args []string) {
+	flags := flag.NewFlagSet("up", flag.ExitOnError)
+	phase := flags.String("phase", string(schema.PhaseExpand), "expand stops before the first contract migration; contract runs everything")
+	withSeeds := flags.Bool("seed", false, "run the seeds for GO_ENV afterwards")
+	fixturePaths := flags.String("fixtures", "", "comma-separated fixture files or directories to seed with -seed")
+	lock := flags.Bool("lock", false, "hold the migration lock in Redis")
+	flags.Parse(args)
+	if *phase != string(schema.PhaseExpand) && *phase != string(schema.PhaseContract) {
+		usage()
+	}
+
+	ctx := context.Background()
+	conn := connect(ctx)
+	runner := conn.runner()
+	if *lock {
+		runner = conn.lock(ctx, runner)
+	}
+	applied, err := runner.Apply(ctx, schema.Phase(*phase))
+	for _, m := range applied {
+		fmt.Printf("⬆️  %s\n", m)
+	}
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	fmt.Printf("✅ Applied %d migration(s)\n", len(applied))
+
+	if *withSeeds {
+		conn.seed(ctx, environment(), false, *fixturePaths)
+	}
+}
+
+func down(args []string) {
+	flags := flag.NewFlagSet("down", flag.ExitOnError)
+	steps := flags.Int("steps", 1, "number of migrations to roll back")
+	lock := flags.Bool("lock", false, "hold the migration lock in Redis")
+	flags.Parse(args)
+	if *steps < 1 {
+		usage()
+	}
+
+	ctx := context.Background()
+	conn := connect(ctx)
+	runner := conn.runner()
+	if *lock {
+		runner = conn.lock(ctx, runner)
+	}
+	rolledBack, err := runner.Rollback(ctx, *steps)
+	for _, m := range rolledBack {
+		fmt.Printf("⬇️  %s\n", m)
+	}
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	fmt.Printf("✅ Rolled back %d migration(s)\n", len(rolledBack))
+}
+
+func status(args []string) {
+	flags := flag.NewFlagSet("status", flag.ExitOnError)
+	flags.Parse(args)
+
+	ctx := context.Background()
+	runner := connect(ctx).runner()
+	statuses, err := runner.Status(ctx)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	pending := 0
+	for _, s := range statuses {
+		switch {
+		case s.Missing:
+			fmt.Printf("❓ %-45s %-8s applied %s, files missing\n", s.Migration, s.Phase, s.AppliedAt.Format(time.RFC3339))
+		case s.Applied:
+			fmt.Printf("✅ %-45s %-8s applied %s\n", s.Migration, s.Phase, s.AppliedAt.Format(time.RFC3339))
+		default:
+			fmt.Printf("⏳ %-45s %-8s pending\n", s.Migration, s.Phase)
+			pending++
+		}
+	}
+
+	report, err := runner.Check(ctx)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	for _, missing := range report.Missing {
+		fmt.Printf("❌ This build needs %s\n", missing)
+	}
+	for _, blocked := range report.Blocked {
+		fmt.Printf("🚧 Blocked until this build is replaced: %s\n", blocked)
+	}
+	if pending > 0 || !report.Compatible() {
+		os.Exit(1)
+	}
+}
+
+func create(args []string) {
+	flags := flag.NewFlagSet("create", flag.ExitOnError)
+	contract := flags.Bool("contract", false, "create a contract migration, run after the previous build is gone")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		usage()
+	}
+
+	phase := schema.PhaseExpand
+	if *contract {
+		phase = schema.PhaseContract
+	}
+	up, down, err := schema.Create(database.NewDatabaseConfig().MigrationPath, flags.Arg(0), phase)
+	if err != nil {
+		log.Fatalf("❌ Failed to create migration: %v", err)
+	}
+	fmt.Printf("📝 Created %s\n📝 Created %s\n", up, down)
+}
+
+func seed(args []string) {
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	env := flags.String("env", environment(), "environment whose seeds run")
+	force := flags.Bool("force", false, "run seeds whose data has not changed too")
+	fixturePaths := flags.String("fixtures", "", "comma-separated fixture files or directories to seed too")
+	flags.Parse(args)
+
+	ctx := context.Background()
+	connect(ctx).seed(ctx, *env, *force, *fixturePaths)
+}
+
+// environment is GO_ENV, development by default
+func environment() string {
+	if env := os.Getenv("GO_ENV"); env != "" {
+		return env
+	}
+	return seeds.EnvDevelopment
+}
+
+// connection is the configured database
+type connection struct {
+	cfg      *config.Config
+	dbConfig *database.DatabaseConfig
+	secrets  secrets.Provider
+	manager  *database.DatabaseManager
+	db       *gorm.DB
+}
+
+// connect opens the configured database
+func connect(ctx context.Context) *connection {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("❌ Failed to load configuration: %v", err)
+	}
+	provider, err := secrets.New(cfg.Secrets.Provider())
+	if err != nil {
+		log.Fatalf("❌ Failed to open the secrets provider: %v", err)
+	}
+
+	dbConfig := database.NewDatabaseConfig()
+	if err := dbConfig.ResolveSecrets(ctx, provider); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	manager := database.NewDatabaseManager(dbConfig)
+	if err := manager.ConnectGorm(); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	return &connection{cfg: cfg, dbConfig: dbConfig, secrets: provider, manager: manager, db: manager.GormDB}
+}
+
+// lock connects to Redis and makes runner hold the migration lock
+func (c *connection) lock(ctx context.Context, runner *schema.Runner) *schema.Runner {
+	if err := c.manager.ConnectRedis(ctx); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	return runner.WithLocker(repositories.NewCacheRepository(c.manager.RedisClient))
+}
+
+// runner loads the migrations
+func (c *connection) runner() *schema.Runner {
+	if !c.dbConfig.UsesPostgres() {
+		log.Fatalf("❌ The SQL migrations are written for PostgreSQL; with DB_DRIVER=%s the server creates its tables with AutoMigrate", c.dbConfig.Driver)
+	}
+	migrations, err := schema.Load(c.dbConfig.MigrationPath)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	return schema.NewRunner(c.db, migrations, logger.NewServerLogger()).WithModels(database.Models()...)
+}
+
+// seed runs the seeds for env, and the fixture files in fixturePaths,
+// and prints what each did
+func (c *connection) seed(ctx context.Context, env string, force bool, fixturePaths string) {
+	// The administrator's password is hashed as the server would
+	if c.cfg.Crypto.Restricted() {
+		if err := cryptopolicy.Enable(); err != nil {
+			log.Fatalf("❌ Failed to enable fips crypto mode: %v", err)
+		}
+	}
+	if err := auth.UsePasswordScheme(c.cfg.Crypto.PasswordScheme()); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	seedConfig := seeds.DefaultConfig(env)
+	for name, value := range map[string]*string{
+		"SEED_ADMIN_EMAIL":    &seedConfig.AdminEmail,
+		"SEED_ADMIN_USERNAME": &seedConfig.AdminUsername,
+		"SEED_ADMIN_PASSWORD": &seedConfig.AdminPassword,
+	} {
+		resolved, err := secrets.Resolve(ctx, c.secrets, name, *value)
+		if err != nil {
+			log.Fatalf("❌ Failed to resolve %s: %v", name, err)
+		}
+		*value = resolved
+	}
+
+	all := seeds.Defaults(seedConfig)
+	if fixturePaths != "" {
+		set, err := fixtures.ReadFiles(strings.Split(fixturePaths, ",")...)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		if err := set.Validate(); err != nil {
+			log.Fatalf("❌ Invalid fixtures:\n%v", err)
+		}
+		all = append(all, fixtures.Seed(fixturePaths, set, logger.NewServerLogger(), env))
+	}
+
+	seeder := seeds.NewSeeder(c.db, logger.NewServerLogger(), all...)
+	results, err := seeder.Run(ctx, env, force)
+	for _, result := range results {
+		switch result.Outcome {
+		case seeds.OutcomeApplied:
+			fmt.Printf("🌱 %s\n", result.Seed)
+		case seeds.OutcomeUnchanged:
+			fmt.Printf("✔️  %s unchanged\n", result.Seed)
+		default:
+			fmt.Printf("⏭️  %s skipped: %s\n", result.Seed, result.Reason)
+		}
+	}
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	fmt.Printf("✅ Seeded the %s environment\n", env)
+}
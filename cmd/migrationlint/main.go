@@ -0,0 +1,42 @@
+// Command migrationlint checks that SQL migrations follow the
+// expand/contract rules needed for blue/green deploys: expand migrations
+// only add, destructive changes wait for a contract migration, and
+// renames are split into add, backfill and drop.
+//
+//	go run ./cmd/migrationlint ./migrations
+//
+// It exits with status 1 when a migration breaks a rule. See the schema
+// package for the rules.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"go-server/internal/database/schema"
+)
+
+func main() {
+	flag.Parse()
+
+	dirs := flag.Args()
+	if len(dirs) == 0 {
+		dirs = []string{"./migrations"}
+	}
+	failed := false
+	for _, dir := range dirs {
+		migrations, err := schema.Load(dir)
+		if err != nil {
+			log.Fatalf("Failed to load %s: %v", dir, err)
+		}
+		for _, issue := range schema.Lint(migrations) {
+			fmt.Fprintln(os.Stderr, issue)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,32 @@
+// Command mockgen writes mocks of interfaces for unit tests, see package
+// mockgen. It is run by go:generate directives next to the interfaces:
+//
+//	//go:generate go run go-server/cmd/mockgen -source user_service.go -out mocks/user_service.go UserStore UserCache
+//
+// and so by go generate ./internal/... after an interface changes.
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+
+	"go-server/internal/mockgen"
+)
+
+func main() {
+	opts, out, err := mockgen.ParseArgs(".", os.Args[1:])
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	code, err := mockgen.Generate(opts)
+	if err != nil {
+		log.Fatalf("❌ Failed to generate mocks: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(out), 0o755); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if err := os.WriteFile(out, code, 0o644); err != nil {
+		log.Fatalf("❌ Failed to write mocks: %v", err)
+	}
+}
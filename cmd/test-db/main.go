@@ -15,9 +15,9 @@ func main() {
 
 	// Load database configuration
 	dbConfig := database.NewDatabaseConfig()
-	log.Printf("📋 Database Config: PostgreSQL=%s:%d/%s, Redis=%s:%d", 
-		dbConfig.PostgresHost, dbConfig.PostgresPort, dbConfig.PostgresDB,
-		dbConfig.RedisHost, dbConfig.RedisPort)
+	log.Printf("📋 Database Config: Driver=%s, PostgreSQL=%s:%d/%s, SQLite=%s, Redis=%s:%d", 
+		dbConfig.Driver, dbConfig.PostgresHost, dbConfig.PostgresPort, dbConfig.PostgresDB,
+		dbConfig.SQLitePath, dbConfig.RedisHost, dbConfig.RedisPort)
 
 	// Create database manager
 	dbManager := database.NewDatabaseManager(dbConfig)
@@ -42,6 +42,8 @@ func main() {
 	// Test PostgreSQL connection
 	if dbManager.PostgresPool != nil {
 		log.Println("✅ PostgreSQL connection pool is active")
+	} else if !dbConfig.UsesPostgres() {
+		log.Printf("⏭️ No PostgreSQL connection pool with the %s driver", dbConfig.Driver)
 	} else {
 		log.Println("❌ PostgreSQL connection pool is nil")
 	}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"go-server/internal/testrunner"
+	"go-server/internal/testrunner/soak"
 	"go-server/internal/testrunner/types"
 )
 
@@ -22,12 +23,25 @@ func main() {
 func parseFlags() *types.TestConfig {
 	config := &types.TestConfig{}
 
-	flag.StringVar(&config.TestType, "type", "all", "Test type: unit, integration, e2e, performance, benchmark, coverage, lint, postman, all")
+	flag.StringVar(&config.TestType, "type", "all", "Test type: unit, integration, e2e, performance, benchmark, coverage, lint, postman, seed, all")
 	flag.BoolVar(&config.Verbose, "v", false, "Verbose output")
 	flag.BoolVar(&config.Coverage, "coverage", false, "Generate coverage report")
 	flag.BoolVar(&config.Benchmark, "bench", false, "Run benchmarks")
 	flag.StringVar(&config.OutputDir, "output", "test-results", "Output directory")
 	flag.DurationVar(&config.Timeout, "timeout", 5*time.Minute, "Test timeout")
+	flag.BoolVar(&config.Seed, "seed", false, "Seed the test database before running the tests")
+
+	// Soak profile for -type performance -soak
+	config.SoakConfig = soak.DefaultConfig()
+	flag.BoolVar(&config.Soak, "soak", false, "Run a long soak test against a running server instead of the load tests")
+	flag.StringVar(&config.SoakConfig.BaseURL, "soak-url", config.SoakConfig.BaseURL, "Server under soak test")
+	flag.DurationVar(&config.SoakConfig.Duration, "soak-duration", config.SoakConfig.Duration, "Soak test duration")
+	flag.DurationVar(&config.SoakConfig.SampleInterval, "soak-interval", config.SoakConfig.SampleInterval, "How often metrics are sampled")
+	flag.DurationVar(&config.SoakConfig.Warmup, "soak-warmup", config.SoakConfig.Warmup, "Startup period excluded from the trends")
+	flag.IntVar(&config.SoakConfig.RPS, "soak-rps", config.SoakConfig.RPS, "Requests per second")
+	flag.Float64Var(&config.SoakConfig.MaxMemorySlope, "soak-max-memory-slope", config.SoakConfig.MaxMemorySlope, "Allowed heap growth in MB per hour")
+	flag.Float64Var(&config.SoakConfig.MaxGoroutineSlope, "soak-max-goroutine-slope", config.SoakConfig.MaxGoroutineSlope, "Allowed goroutine growth per hour")
+	flag.Float64Var(&config.SoakConfig.MaxLatencyDrift, "soak-max-latency-drift", config.SoakConfig.MaxLatencyDrift, "Allowed ratio of the last to the first p95 latency")
 
 	flag.Parse()
 
@@ -0,0 +1,414 @@
+// Package abuse spots clients misbehaving in ways the rate limiter does not
+// catch on its own: scanners announcing themselves in the user agent,
+// request rates no person or well-behaved integration reaches, and bursts
+// of rejected input probing for weaknesses. Offending IPs are banned for a
+// while; the ban list is consulted by the rate limiter, and every ban is
+// logged and stored as an audit record.
+package abuse
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/models"
+	"go-server/internal/logger"
+	"go-server/internal/security"
+)
+
+// Ban reasons
+const (
+	ReasonUserAgent          = "suspicious_user_agent"
+	ReasonRequestRate        = "request_rate"
+	ReasonValidationFailures = "validation_failures"
+)
+
+// DefaultSuspiciousAgents are user agent fragments of common vulnerability
+// scanners, used when the configuration lists none
+var DefaultSuspiciousAgents = []string{
+	"sqlmap", "nikto", "nmap", "masscan", "zgrab", "nuclei",
+	"dirbuster", "gobuster", "wpscan", "acunetix", "havij",
+}
+
+// Store persists the bans as an audit trail
+type Store interface {
+	CreateBan(ctx context.Context, ban *models.AbuseBan) error
+	ListActiveBans(ctx context.Context, now time.Time) ([]models.AbuseBan, error)
+}
+
+// Config holds the detection thresholds
+type Config struct {
+	// SuspiciousAgents are matched case-insensitively anywhere in the
+	// User-Agent header
+	SuspiciousAgents []string
+	// MaxRequestsPerSecond is the most requests an IP may make in one
+	// second; zero disables the check
+	MaxRequestsPerSecond int
+	// MaxValidationFailures is the most rejected requests an IP may make
+	// in FailureWindow; zero disables the check
+	MaxValidationFailures int
+	FailureWindow         time.Duration
+	BanDuration           time.Duration
+}
+
+// Stats is a snapshot of the detector's counters
+type Stats struct {
+	Bans         int64            `json:"bans"`
+	BansByReason map[string]int64 `json:"bans_by_reason"`
+	ActiveBans   int              `json:"active_bans"`
+	// Blocked counts requests refused because the client was banned
+	Blocked int64 `json:"blocked"`
+}
+
+// rateWindow counts an IP's requests in the current second
+type rateWindow struct {
+	second time.Time
+	count  int
+}
+
+// Detector applies the heuristics and keeps the ban list. It implements
+// security.BanList.
+type Detector struct {
+	store  Store
+	config Config
+	clock  clock.Clock
+	logger logger.Logger
+
+	mutex        sync.Mutex
+	bans         map[string]time.Time
+	rates        map[string]*rateWindow
+	failures     map[string][]time.Time
+	banCount     int64
+	bansByReason map[string]int64
+	blocked      int64
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+var _ security.BanList = (*Detector)(nil)
+
+// NewDetector creates a detector
+func NewDetector(store Store, config Config, logger logger.Logger) *Detector {
+	if len(config.SuspiciousAgents) == 0 {
+		config.SuspiciousAgents = DefaultSuspiciousAgents
+	}
+	agents := make([]string, 0, len(config.SuspiciousAgents))
+	for _, agent := range config.SuspiciousAgents {
+		if agent = strings.ToLower(strings.TrimSpace(agent)); agent != "" {
+			agents = append(agents, agent)
+		}
+	}
+	config.SuspiciousAgents = agents
+	if config.FailureWindow <= 0 {
+		config.FailureWindow = 5 * time.Minute
+	}
+	if config.BanDuration <= 0 {
+		config.BanDuration = 15 * time.Minute
+	}
+	return &Detector{
+		store:        store,
+		config:       config,
+		clock:        clock.New(),
+		logger:       logger,
+		bans:         make(map[string]time.Time),
+		rates:        make(map[string]*rateWindow),
+		failures:     make(map[string][]time.Time),
+		bansByReason: make(map[string]int64),
+	}
+}
+
+// WithClock sets the time source for the rate windows and ban expiry
+func (d *Detector) WithClock(c clock.Clock) *Detector {
+	d.clock = clock.OrDefault(c)
+	return d
+}
+
+// BannedUntil implements security.BanList. Each positive answer counts as
+// a blocked request.
+func (d *Detector) BannedUntil(ip string) (time.Time, bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	until, ok := d.bans[ip]
+	if !ok {
+		return time.Time{}, false
+	}
+	if !d.clock.Now().Before(until) {
+		delete(d.bans, ip)
+		return time.Time{}, false
+	}
+	d.blocked++
+	return until, true
+}
+
+// Inspect checks a request's user agent and its client's request rate,
+// banning the client if either is suspicious. The client is identified by
+// security.GetClientIP, which only believes forwarding headers set by a
+// trusted proxy.
+func (d *Detector) Inspect(r *http.Request) {
+	ip := security.GetClientIP(r)
+
+	if agent := d.suspiciousAgent(r.UserAgent()); agent != "" {
+		d.ban(r.Context(), ip, ReasonUserAgent, fmt.Sprintf("user agent matched %q", agent))
+		return
+	}
+
+	if d.config.MaxRequestsPerSecond <= 0 {
+		return
+	}
+	d.mutex.Lock()
+	second := d.clock.Now().Truncate(time.Second)
+	window, ok := d.rates[ip]
+	if !ok || !window.second.Equal(second) {
+		window = &rateWindow{second: second}
+		d.rates[ip] = window
+	}
+	window.count++
+	// Ban once, on the request crossing the threshold
+	exceeded := window.count == d.config.MaxRequestsPerSecond+1
+	d.mutex.Unlock()
+
+	if exceeded {
+		d.ban(r.Context(), ip, ReasonRequestRate, fmt.Sprintf("more than %d requests in one second", d.config.MaxRequestsPerSecond))
+	}
+}
+
+// RecordValidationFailure counts a request from ip rejected as invalid,
+// banning the client once it has made too many in the failure window
+func (d *Detector) RecordValidationFailure(ctx context.Context, ip string) {
+	if d.config.MaxValidationFailures <= 0 {
+		return
+	}
+	d.mutex.Lock()
+	now := d.clock.Now()
+	recent := clock.PruneBefore(d.failures[ip], now.Add(-d.config.FailureWindow))
+	recent = append(recent, now)
+	exceeded := len(recent) > d.config.MaxValidationFailures
+	if exceeded {
+		delete(d.failures, ip)
+	} else {
+		d.failures[ip] = recent
+	}
+	d.mutex.Unlock()
+
+	if exceeded {
+		d.ban(ctx, ip, ReasonValidationFailures, fmt.Sprintf("more than %d rejected requests in %s", d.config.MaxValidationFailures, d.config.FailureWindow))
+	}
+}
+
+// Lift ends an IP's ban early, e.g. after an administrator lifted the
+// stored ban
+func (d *Detector) Lift(ip string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	delete(d.bans, ip)
+	delete(d.failures, ip)
+	delete(d.rates, ip)
+}
+
+// Load restores the bans in force from the store
+func (d *Detector) Load(ctx context.Context) error {
+	now := d.clock.Now()
+	bans, err := d.store.ListActiveBans(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	for _, ban := range bans {
+		if ban.ExpiresAt.After(d.bans[ban.IP]) {
+			d.bans[ban.IP] = ban.ExpiresAt
+		}
+	}
+	return nil
+}
+
+// Stats returns a snapshot of the ban counters
+func (d *Detector) Stats() Stats {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	now := d.clock.Now()
+	stats := Stats{
+		Bans:         d.banCount,
+		BansByReason: make(map[string]int64, len(d.bansByReason)),
+		Blocked:      d.blocked,
+	}
+	for reason, count := range d.bansByReason {
+		stats.BansByReason[reason] = count
+	}
+	for _, until := range d.bans {
+		if now.Before(until) {
+			stats.ActiveBans++
+		}
+	}
+	return stats
+}
+
+// Sweep drops expired bans and counters that no longer matter
+func (d *Detector) Sweep() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	now := d.clock.Now()
+	for ip, until := range d.bans {
+		if !now.Before(until) {
+			delete(d.bans, ip)
+		}
+	}
+	second := now.Truncate(time.Second)
+	for ip, window := range d.rates {
+		if window.second.Before(second) {
+			delete(d.rates, ip)
+		}
+	}
+	cutoff := now.Add(-d.config.FailureWindow)
+	for ip, failures := range d.failures {
+		if recent := clock.PruneBefore(failures, cutoff); len(recent) == 0 {
+			delete(d.failures, ip)
+		} else {
+			d.failures[ip] = recent
+		}
+	}
+}
+
+// Start loads the stored bans and sweeps expired state every minute until
+// Stop is called
+func (d *Detector) Start(ctx context.Context) {
+	if err := d.Load(ctx); err != nil {
+		d.logger.Error("Failed to load abuse bans: %v", err)
+	}
+	ctx, d.cancel = context.WithCancel(ctx)
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				d.Sweep()
+			}
+		}
+	}()
+
+	d.logger.Info("Abuse detector started")
+}
+
+// Stop stops the periodic sweep
+func (d *Detector) Stop() {
+	if d.cancel != nil {
+		d.cancel()
+	}
+	d.wg.Wait()
+	d.logger.Info("Abuse detector stopped")
+}
+
+// Middleware inspects each request and counts those rejected with 400 or
+// 422 as validation failures. It must run before the rate limiter so a
+// request that triggers a ban is refused too.
+func (d *Detector) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			d.Inspect(r)
+
+			recorder := &statusRecorder{ResponseWriter: w}
+			next.ServeHTTP(recorder, r)
+
+			if recorder.statusCode == http.StatusBadRequest || recorder.statusCode == http.StatusUnprocessableEntity {
+				d.RecordValidationFailure(r.Context(), security.GetClientIP(r))
+			}
+		})
+	}
+}
+
+// ban bans ip for the ban duration and records it, unless it is already
+// banned. The audit record is written even if the request is cancelled.
+func (d *Detector) ban(ctx context.Context, ip, reason, detail string) {
+	d.mutex.Lock()
+	now := d.clock.Now()
+	if current, ok := d.bans[ip]; ok && now.Before(current) {
+		d.mutex.Unlock()
+		return
+	}
+	expiresAt := now.Add(d.config.BanDuration)
+	d.bans[ip] = expiresAt
+	d.banCount++
+	d.bansByReason[reason]++
+	d.mutex.Unlock()
+
+	d.logger.Warn("Banned %s until %s: %s (%s)", ip, expiresAt.Format(time.RFC3339), reason, detail)
+	record := &models.AbuseBan{
+		IP:        ip,
+		Reason:    reason,
+		Detail:    detail,
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+	}
+	if err := d.store.CreateBan(context.WithoutCancel(ctx), record); err != nil {
+		d.logger.Error("Failed to record abuse ban of %s: %v", ip, err)
+	}
+}
+
+// suspiciousAgent returns the configured fragment found in agent, if any
+func (d *Detector) suspiciousAgent(agent string) string {
+	agent = strings.ToLower(agent)
+	for _, fragment := range d.config.SuspiciousAgents {
+		if strings.Contains(agent, fragment) {
+			return fragment
+		}
+	}
+	return ""
+}
+
+// statusRecorder captures the response status
+type statusRecorder struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (sr *statusRecorder) WriteHeader(code int) {
+	if sr.statusCode == 0 {
+		sr.statusCode = code
+	}
+	sr.ResponseWriter.WriteHeader(code)
+}
+
+func (sr *statusRecorder) Write(b []byte) (int, error) {
+	if sr.statusCode == 0 {
+		sr.statusCode = http.StatusOK
+	}
+	return sr.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher for streamed responses
+func (sr *statusRecorder) Flush() {
+	http.NewResponseController(sr.ResponseWriter).Flush()
+}
+
+// Hijack implements http.Hijacker, which WebSocket upgrades assert
+func (sr *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(sr.ResponseWriter).Hijack()
+	if err == nil && sr.statusCode == 0 {
+		sr.statusCode = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (sr *statusRecorder) Unwrap() http.ResponseWriter {
+	return sr.ResponseWriter
+}
@@ -0,0 +1,253 @@
+package abuse
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/dbtest"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/logger"
+	"go-server/internal/realtime"
+	"go-server/internal/security"
+
+	"gorm.io/gorm"
+)
+
+type testAbuse struct {
+	detector *Detector
+	repo     *repositories.AbuseRepository
+	clock    *clock.Fake
+}
+
+func newTestAbuse(t *testing.T, config Config) *testAbuse {
+	t.Helper()
+	db := dbtest.Open(t, &models.AbuseBan{})
+	fake := clock.NewFake(time.Date(2026, 5, 4, 12, 0, 0, 0, time.UTC))
+	repo := repositories.NewAbuseRepository(db)
+	return &testAbuse{
+		detector: NewDetector(repo, config, logger.NewServerLogger()).WithClock(fake),
+		repo:     repo,
+		clock:    fake,
+	}
+}
+
+func request(ip, agent string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/api/posts", nil)
+	r.RemoteAddr = ip + ":51234"
+	r.Header.Set("User-Agent", agent)
+	return r
+}
+
+func (ta *testAbuse) bans(t *testing.T, ip string) []models.AbuseBan {
+	t.Helper()
+	bans, err := ta.repo.ListBans(context.Background(), ip, 0, 100)
+	if err != nil {
+		t.Fatalf("ListBans failed: %v", err)
+	}
+	return bans
+}
+
+func TestDetector_SuspiciousUserAgent(t *testing.T) {
+	ta := newTestAbuse(t, Config{BanDuration: 10 * time.Minute})
+
+	ta.detector.Inspect(request("203.0.113.7", "Mozilla/5.0 (compatible; curl)"))
+	if _, banned := ta.detector.BannedUntil("203.0.113.7"); banned {
+		t.Fatal("Ordinary user agent should not be banned")
+	}
+
+	ta.detector.Inspect(request("203.0.113.7", "sqlmap/1.8#stable (https://sqlmap.org)"))
+	until, banned := ta.detector.BannedUntil("203.0.113.7")
+	if !banned || !until.Equal(ta.clock.Now().Add(10*time.Minute)) {
+		t.Fatalf("Expected a ban until %s, got %s banned %v", ta.clock.Now().Add(10*time.Minute), until, banned)
+	}
+
+	// Further requests while banned are not audited again
+	ta.detector.Inspect(request("203.0.113.7", "sqlmap/1.8#stable (https://sqlmap.org)"))
+	bans := ta.bans(t, "203.0.113.7")
+	if len(bans) != 1 || bans[0].Reason != ReasonUserAgent {
+		t.Fatalf("Expected one user agent ban recorded, got %+v", bans)
+	}
+
+	ta.clock.Advance(10 * time.Minute)
+	if _, banned := ta.detector.BannedUntil("203.0.113.7"); banned {
+		t.Error("Ban should expire after the ban duration")
+	}
+}
+
+func TestDetector_RequestRate(t *testing.T) {
+	ta := newTestAbuse(t, Config{MaxRequestsPerSecond: 3})
+
+	for i := 0; i < 3; i++ {
+		ta.detector.Inspect(request("198.51.100.2", "client/1.0"))
+	}
+	ta.clock.Advance(time.Second)
+	for i := 0; i < 3; i++ {
+		ta.detector.Inspect(request("198.51.100.2", "client/1.0"))
+	}
+	if _, banned := ta.detector.BannedUntil("198.51.100.2"); banned {
+		t.Fatal("Requests within the limit each second should not be banned")
+	}
+
+	ta.detector.Inspect(request("198.51.100.2", "client/1.0"))
+	if _, banned := ta.detector.BannedUntil("198.51.100.2"); !banned {
+		t.Fatal("Exceeding the per-second limit should ban the client")
+	}
+	if bans := ta.bans(t, "198.51.100.2"); len(bans) != 1 || bans[0].Reason != ReasonRequestRate {
+		t.Errorf("Expected one request rate ban recorded, got %+v", bans)
+	}
+}
+
+func TestDetector_IgnoresForwardingHeadersFromClients(t *testing.T) {
+	ta := newTestAbuse(t, Config{MaxRequestsPerSecond: 2})
+
+	// A client talking to the server directly cannot spread its requests
+	// over made-up addresses, nor get another address banned
+	for i := 0; i < 3; i++ {
+		r := request("198.51.100.2", "client/1.0")
+		r.Header.Set("X-Forwarded-For", fmt.Sprintf("203.0.113.%d", i))
+		r.Header.Set("X-Real-IP", "203.0.113.50")
+		ta.detector.Inspect(r)
+	}
+	if _, banned := ta.detector.BannedUntil("198.51.100.2"); !banned {
+		t.Error("Expected the client's own address to be banned")
+	}
+	for _, ip := range []string{"203.0.113.0", "203.0.113.50"} {
+		if _, banned := ta.detector.BannedUntil(ip); banned {
+			t.Errorf("Forged address %s should not be banned", ip)
+		}
+	}
+}
+
+func TestDetector_ValidationFailures(t *testing.T) {
+	ta := newTestAbuse(t, Config{MaxValidationFailures: 2, FailureWindow: time.Minute})
+	handler := ta.detector.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	send := func() {
+		handler.ServeHTTP(httptest.NewRecorder(), request("192.0.2.9", "client/1.0"))
+	}
+
+	send()
+	send()
+	ta.clock.Advance(time.Minute)
+	send()
+	send()
+	if _, banned := ta.detector.BannedUntil("192.0.2.9"); banned {
+		t.Fatal("Failures outside the window should not count")
+	}
+
+	send()
+	if _, banned := ta.detector.BannedUntil("192.0.2.9"); !banned {
+		t.Fatal("Too many failures in the window should ban the client")
+	}
+	if bans := ta.bans(t, "192.0.2.9"); len(bans) != 1 || bans[0].Reason != ReasonValidationFailures {
+		t.Errorf("Expected one validation failure ban recorded, got %+v", bans)
+	}
+}
+
+func TestDetector_MiddlewarePassesWebSocketUpgrades(t *testing.T) {
+	ta := newTestAbuse(t, Config{})
+	server := httptest.NewServer(ta.detector.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := realtime.Upgrade(w, r, 1024)
+		if err != nil {
+			return
+		}
+		conn.Close(realtime.CloseNormal, "")
+	})))
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/ws", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	req.Write(conn)
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		t.Fatalf("Reading handshake failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Errorf("Expected 101 through the middleware, got %d", resp.StatusCode)
+	}
+}
+
+func TestDetector_RateLimiterRefusesBannedClients(t *testing.T) {
+	ta := newTestAbuse(t, Config{BanDuration: time.Minute})
+	limiter := security.NewRateLimiter(security.RateLimitConfig{
+		RequestsPerMinute: 100,
+		WindowDuration:    time.Minute,
+		CleanupInterval:   time.Minute,
+		Clock:             ta.clock,
+		Bans:              ta.detector,
+	})
+	handler := ta.detector.Middleware()(security.RateLimitMiddleware(limiter)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, request("203.0.113.50", "Nikto/2.5.0"))
+	if rec.Code != http.StatusForbidden || rec.Header().Get("Retry-After") != "60" {
+		t.Fatalf("Expected the triggering request to be refused with Retry-After 60, got %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, request("203.0.113.51", "client/1.0"))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Other clients should be served, got %d", rec.Code)
+	}
+
+	stats := ta.detector.Stats()
+	if stats.Bans != 1 || stats.BansByReason[ReasonUserAgent] != 1 || stats.ActiveBans != 1 || stats.Blocked != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestDetector_LoadAndLift(t *testing.T) {
+	ta := newTestAbuse(t, Config{})
+	ctx := context.Background()
+	now := ta.clock.Now()
+	for _, ban := range []models.AbuseBan{
+		{IP: "192.0.2.1", Reason: ReasonRequestRate, ExpiresAt: now.Add(time.Hour)},
+		{IP: "192.0.2.2", Reason: ReasonRequestRate, ExpiresAt: now.Add(-time.Hour)},
+	} {
+		ban := ban
+		if err := ta.repo.CreateBan(ctx, &ban); err != nil {
+			t.Fatalf("CreateBan failed: %v", err)
+		}
+	}
+
+	if err := ta.detector.Load(ctx); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if _, banned := ta.detector.BannedUntil("192.0.2.1"); !banned {
+		t.Error("Stored ban in force should be restored")
+	}
+	if _, banned := ta.detector.BannedUntil("192.0.2.2"); banned {
+		t.Error("Expired stored ban should not be restored")
+	}
+
+	if err := ta.repo.LiftBans(ctx, "192.0.2.1", 1, now); err != nil {
+		t.Fatalf("LiftBans failed: %v", err)
+	}
+	ta.detector.Lift("192.0.2.1")
+	if _, banned := ta.detector.BannedUntil("192.0.2.1"); banned {
+		t.Error("Lifted ban should no longer apply")
+	}
+	if err := ta.repo.LiftBans(ctx, "192.0.2.1", 1, now); err != gorm.ErrRecordNotFound {
+		t.Errorf("Expected ErrRecordNotFound lifting again, got %v", err)
+	}
+}
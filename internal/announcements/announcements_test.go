@@ -0,0 +1,143 @@
+package announcements
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/models"
+	"go-server/internal/logger"
+)
+
+type fakeStore struct {
+	announcements []models.Announcement
+	loads         int
+	err           error
+}
+
+func (fs *fakeStore) ListActiveDeprecations(ctx context.Context, now time.Time) ([]models.Announcement, error) {
+	fs.loads++
+	if fs.err != nil {
+		return nil, fs.err
+	}
+	return append([]models.Announcement(nil), fs.announcements...), nil
+}
+
+func deprecation(id uint, prefix string, published time.Time, sunset *time.Time) models.Announcement {
+	a := models.Announcement{Kind: models.AnnouncementDeprecation, Title: "Deprecated", PathPrefix: prefix, PublishedAt: published, SunsetAt: sunset}
+	a.ID = id
+	return a
+}
+
+func serve(d *Deprecations, path string) http.Header {
+	rec := httptest.NewRecorder()
+	d.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec.Header()
+}
+
+func TestDeprecations_SetsHeadersOnAffectedPaths(t *testing.T) {
+	published := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	store := &fakeStore{announcements: []models.Announcement{
+		deprecation(1, "/api/v1", published, nil),
+		deprecation(2, "/api/v1/posts/", published.Add(24*time.Hour), &sunset),
+	}}
+	d := NewDeprecations(store, logger.NewServerLogger()).WithClock(clock.NewFake(published.Add(48 * time.Hour)))
+
+	header := serve(d, "/api/v1/posts/7")
+	if got := header.Get("Deprecation"); got != "@1767312000" {
+		t.Errorf("Deprecation = %q, want the most specific announcement's date", got)
+	}
+	if got := header.Get("Sunset"); got != "Wed, 01 Jul 2026 00:00:00 GMT" {
+		t.Errorf("Sunset = %q", got)
+	}
+	links := header.Values("Link")
+	if len(links) != 2 || !strings.Contains(links[0], "</api/announcements/2>") || !strings.Contains(links[1], `rel="deprecation"`) {
+		t.Errorf("Link = %q, want both announcements", links)
+	}
+
+	header = serve(d, "/api/v1")
+	if header.Get("Deprecation") != "@1767225600" || header.Get("Sunset") != "" || len(header.Values("Link")) != 1 {
+		t.Errorf("Unexpected headers for /api/v1: %v", header)
+	}
+	for _, path := range []string{"/api/v10/posts", "/api/v2/posts", "/health"} {
+		if header := serve(d, path); header.Get("Deprecation") != "" || header.Get("Link") != "" {
+			t.Errorf("%s should not be deprecated: %v", path, header)
+		}
+	}
+}
+
+func TestDeprecations_CachesAndInvalidates(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	store := &fakeStore{}
+	d := NewDeprecations(store, logger.NewServerLogger()).WithClock(fake).WithRefreshInterval(time.Minute)
+
+	serve(d, "/api/v1/posts")
+	serve(d, "/api/v1/posts")
+	if store.loads != 1 {
+		t.Fatalf("Loaded %d times, want 1 within the refresh interval", store.loads)
+	}
+
+	store.announcements = []models.Announcement{deprecation(1, "/api/v1", fake.Now(), nil)}
+	d.Invalidate()
+	if header := serve(d, "/api/v1/posts"); header.Get("Deprecation") == "" || store.loads != 2 {
+		t.Errorf("Invalidate should reload the deprecations: %d loads, headers %v", store.loads, header)
+	}
+
+	// A failed reload keeps the previous deprecations
+	store.err = errors.New("database down")
+	fake.Advance(time.Minute)
+	if header := serve(d, "/api/v1/posts"); header.Get("Deprecation") == "" || store.loads != 3 {
+		t.Errorf("A failed reload should keep serving the cached deprecations: %v", header)
+	}
+
+	// Expired deprecations stop applying before the next reload
+	expires := fake.Now().Add(10 * time.Second)
+	store.err = nil
+	store.announcements[0].ExpiresAt = &expires
+	d.Invalidate()
+	serve(d, "/api/v1/posts")
+	fake.Advance(20 * time.Second)
+	if header := serve(d, "/api/v1/posts"); header.Get("Deprecation") != "" {
+		t.Errorf("Expired deprecation still applied: %v", header)
+	}
+}
+
+func TestWriteRSS(t *testing.T) {
+	sunset := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	status := models.Announcement{Kind: models.AnnouncementStatus, Title: "Degraded <search>", Body: "Search is slow & flaky", PublishedAt: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)}
+	status.ID = 3
+	list := []models.Announcement{status, deprecation(2, "/api/v1", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), &sunset)}
+
+	var buf bytes.Buffer
+	if err := WriteRSS(&buf, Channel{Title: "API announcements", BaseURL: "https://api.example.com"}, list); err != nil {
+		t.Fatalf("WriteRSS() error = %v", err)
+	}
+
+	var doc rssDocument
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Feed is not valid XML: %v\n%s", err, buf.String())
+	}
+	if doc.Version != "2.0" || len(doc.Channel.Items) != 2 {
+		t.Fatalf("Unexpected feed %+v", doc)
+	}
+	first := doc.Channel.Items[0]
+	if first.Title != "Degraded <search>" || first.Link != "https://api.example.com/api/announcements/3" || first.Category != "status" {
+		t.Errorf("Unexpected item %+v", first)
+	}
+	if doc.Channel.LastBuildDate != "Sun, 01 Feb 2026 00:00:00 +0000" {
+		t.Errorf("LastBuildDate = %q", doc.Channel.LastBuildDate)
+	}
+	if !strings.Contains(doc.Channel.Items[1].Description, "/api/v1 stops working on") {
+		t.Errorf("Deprecation item lacks the sunset: %q", doc.Channel.Items[1].Description)
+	}
+}
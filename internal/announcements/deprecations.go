@@ -0,0 +1,141 @@
+// Package announcements tells API clients about the service's status and
+// changes to the API. Besides the announcements feed, responses from an
+// endpoint with an active deprecation carry Deprecation (RFC 9745) and
+// Sunset (RFC 8594) headers and a Link to the announcement, so clients
+// learn about it from the calls they already make.
+package announcements
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/models"
+	"go-server/internal/logger"
+)
+
+// defaultRefreshInterval is how long the active deprecations are cached
+const defaultRefreshInterval = time.Minute
+
+// DeprecationStore lists the active deprecations
+type DeprecationStore interface {
+	ListActiveDeprecations(ctx context.Context, now time.Time) ([]models.Announcement, error)
+}
+
+// Deprecations adds deprecation headers to responses from affected
+// endpoints. The deprecations are cached and reloaded periodically, so
+// requests do not query the database.
+type Deprecations struct {
+	store    DeprecationStore
+	interval time.Duration
+	clock    clock.Clock
+	logger   logger.Logger
+
+	mutex    sync.Mutex
+	active   []models.Announcement
+	loadedAt time.Time
+}
+
+// NewDeprecations creates the deprecation header middleware
+func NewDeprecations(store DeprecationStore, logger logger.Logger) *Deprecations {
+	return &Deprecations{
+		store:    store,
+		interval: defaultRefreshInterval,
+		clock:    clock.New(),
+		logger:   logger,
+	}
+}
+
+// WithRefreshInterval sets how long the deprecations are cached
+func (d *Deprecations) WithRefreshInterval(interval time.Duration) *Deprecations {
+	if interval > 0 {
+		d.interval = interval
+	}
+	return d
+}
+
+// WithClock sets the time source for caching and expiry
+func (d *Deprecations) WithClock(c clock.Clock) *Deprecations {
+	d.clock = clock.OrDefault(c)
+	return d
+}
+
+// Invalidate reloads the deprecations on the next request, e.g. after an
+// administrator changed them
+func (d *Deprecations) Invalidate() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.loadedAt = time.Time{}
+}
+
+// Middleware sets the deprecation headers before the handler runs
+func (d *Deprecations) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			d.Apply(w, r)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Apply sets the headers of the deprecations affecting r's path. The most
+// specific deprecation supplies Deprecation and Sunset; every one is linked.
+func (d *Deprecations) Apply(w http.ResponseWriter, r *http.Request) {
+	matched := false
+	for _, announcement := range d.current(r.Context()) {
+		if !pathAffected(r.URL.Path, announcement.PathPrefix) {
+			continue
+		}
+		header := w.Header()
+		if !matched {
+			header.Set("Deprecation", fmt.Sprintf("@%d", announcement.PublishedAt.Unix()))
+			if announcement.SunsetAt != nil {
+				header.Set("Sunset", announcement.SunsetAt.UTC().Format(http.TimeFormat))
+			}
+			matched = true
+		}
+		header.Add("Link", fmt.Sprintf(`</api/announcements/%d>; rel="deprecation"; type="application/json"`, announcement.ID))
+	}
+}
+
+// current returns the active deprecations, most specific path first,
+// reloading them once the cache is stale. A failed reload keeps serving
+// the previous list until the next interval.
+func (d *Deprecations) current(ctx context.Context) []models.Announcement {
+	now := d.clock.Now()
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if now.Sub(d.loadedAt) >= d.interval {
+		active, err := d.store.ListActiveDeprecations(ctx, now)
+		if err != nil {
+			d.logger.Error("Failed to load API deprecations: %v", err)
+		} else {
+			sort.SliceStable(active, func(i, j int) bool {
+				return len(active[i].PathPrefix) > len(active[j].PathPrefix)
+			})
+			d.active = active
+		}
+		d.loadedAt = now
+	}
+
+	// Drop deprecations that expired since the last reload
+	current := make([]models.Announcement, 0, len(d.active))
+	for _, announcement := range d.active {
+		if announcement.IsActive(now) {
+			current = append(current, announcement)
+		}
+	}
+	return current
+}
+
+// pathAffected reports whether path is prefix or below it
+func pathAffected(path, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return prefix != "" && (path == prefix || strings.HasPrefix(path, prefix+"/"))
+}
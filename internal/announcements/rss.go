@@ -0,0 +1,91 @@
+package announcements
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+
+	"go-server/internal/database/models"
+)
+
+// RSSContentType is the media type of the announcements feed
+const RSSContentType = "application/rss+xml; charset=utf-8"
+
+// Channel describes the RSS feed
+type Channel struct {
+	Title       string
+	Description string
+	// BaseURL is the absolute URL of the API, e.g. https://api.example.com
+	BaseURL string
+}
+
+type rssDocument struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Description string  `xml:"description"`
+	Category    string  `xml:"category"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+// WriteRSS renders announcements as an RSS 2.0 feed
+func WriteRSS(w io.Writer, channel Channel, announcements []models.Announcement) error {
+	doc := rssDocument{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:       channel.Title,
+			Link:        channel.BaseURL + "/api/announcements",
+			Description: channel.Description,
+			Items:       make([]rssItem, len(announcements)),
+		},
+	}
+	var latest time.Time
+	for i, announcement := range announcements {
+		link := fmt.Sprintf("%s/api/announcements/%d", channel.BaseURL, announcement.ID)
+		description := announcement.Body
+		if announcement.SunsetAt != nil && announcement.PathPrefix != "" {
+			description += fmt.Sprintf("\n\n%s stops working on %s.", announcement.PathPrefix, announcement.SunsetAt.UTC().Format(time.RFC1123))
+		}
+		doc.Channel.Items[i] = rssItem{
+			Title:       announcement.Title,
+			Link:        link,
+			Description: description,
+			Category:    announcement.Kind,
+			GUID:        rssGUID{Value: link, IsPermaLink: true},
+			PubDate:     announcement.PublishedAt.UTC().Format(time.RFC1123Z),
+		}
+		if announcement.PublishedAt.After(latest) {
+			latest = announcement.PublishedAt
+		}
+	}
+	if !latest.IsZero() {
+		doc.Channel.LastBuildDate = latest.UTC().Format(time.RFC1123Z)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return encoder.Encode(doc)
+}
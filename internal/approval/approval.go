@@ -0,0 +1,274 @@
+// Package approval implements the two-person rule for destructive admin
+// actions. An action is registered once with the permission needed to
+// approve it and a function that carries it out. Submitting the action then
+// either runs it straight away or, when approvals are required, parks it
+// until a different administrator approves it within the TTL. Every step is
+// recorded as an approval event.
+package approval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/logger"
+	"go-server/internal/rbac"
+)
+
+// Actions that go through approval
+const (
+	ActionSetUserRole = "users.set_role"
+	ActionPurgeUsers  = "trash.purge_users"
+	ActionPurgePosts  = "trash.purge_posts"
+	ActionFlushCache  = "cache.flush"
+)
+
+// Approval errors
+var (
+	ErrUnknownAction = errors.New("unknown approval action")
+	ErrNotPending    = errors.New("approval request has already been decided")
+	ErrExpired       = errors.New("approval request has expired")
+	ErrSelfApproval  = errors.New("approval requests must be approved by a different administrator")
+	ErrForbidden     = errors.New("not permitted to decide this approval request")
+)
+
+// Executor carries out an approved action. params is the JSON document the
+// action was submitted with; the returned value is stored as its result.
+type Executor func(ctx context.Context, params json.RawMessage) (interface{}, error)
+
+// Config holds approval workflow configuration
+type Config struct {
+	// Required makes submitted actions wait for a second administrator
+	Required bool
+	// TTL is how long a request may wait for approval
+	TTL time.Duration
+}
+
+type action struct {
+	permission rbac.Permission
+	execute    Executor
+}
+
+// Service submits, approves and rejects sensitive admin actions
+type Service struct {
+	repo   *repositories.ApprovalRepository
+	config Config
+	logger logger.Logger
+	clock  clock.Clock
+
+	mutex   sync.RWMutex
+	actions map[string]action
+}
+
+// NewService creates a new approval service
+func NewService(repo *repositories.ApprovalRepository, config Config, logger logger.Logger) *Service {
+	if config.TTL <= 0 {
+		config.TTL = time.Hour
+	}
+	return &Service{
+		repo:    repo,
+		config:  config,
+		logger:  logger,
+		clock:   clock.New(),
+		actions: make(map[string]action),
+	}
+}
+
+// WithClock sets the time source used for expiry
+func (s *Service) WithClock(c clock.Clock) *Service {
+	s.clock = clock.OrDefault(c)
+	return s
+}
+
+// Required reports whether actions wait for a second administrator
+func (s *Service) Required() bool {
+	return s.config.Required
+}
+
+// Register makes an action available for submission. Approvers need
+// permission; the action runs execute once approved.
+func (s *Service) Register(name string, permission rbac.Permission, execute Executor) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.actions[name] = action{permission: permission, execute: execute}
+}
+
+func (s *Service) lookup(name string) (action, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	a, ok := s.actions[name]
+	if !ok {
+		return action{}, fmt.Errorf("%w: %s", ErrUnknownAction, name)
+	}
+	return a, nil
+}
+
+// Submit requests an action on behalf of requesterID. If approvals are not
+// required it runs at once and the returned request is already executed or
+// failed; otherwise it is pending until approved, rejected or expired.
+func (s *Service) Submit(ctx context.Context, name string, params interface{}, summary string, requesterID uint, reason string) (*models.ApprovalRequest, error) {
+	a, err := s.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode approval params: %w", err)
+	}
+
+	now := s.clock.Now()
+	request := &models.ApprovalRequest{
+		Action:        name,
+		Params:        string(encoded),
+		Summary:       summary,
+		Reason:        reason,
+		Status:        models.ApprovalStatusPending,
+		RequestedByID: requesterID,
+		ExpiresAt:     now.Add(s.config.TTL),
+		CreatedAt:     now,
+	}
+	event := &models.ApprovalEvent{Event: models.ApprovalEventRequested, ActorID: &requesterID, Detail: reason, CreatedAt: now}
+	if err := s.repo.CreateRequest(ctx, request, event); err != nil {
+		return nil, fmt.Errorf("failed to store approval request: %w", err)
+	}
+	s.logger.Info("Approval %d requested: %s by user %d", request.ID, summary, requesterID)
+
+	if s.config.Required {
+		return request, nil
+	}
+	return s.execute(ctx, request, a, nil)
+}
+
+// Approve confirms a pending request and runs its action. The approver must
+// not be the requester and must hold the action's permission.
+func (s *Service) Approve(ctx context.Context, id uint, approver *models.User) (*models.ApprovalRequest, error) {
+	request, a, err := s.decidable(ctx, id, approver)
+	if err != nil {
+		return nil, err
+	}
+	return s.execute(ctx, request, a, &approver.ID)
+}
+
+// Reject declines a pending request so its action never runs
+func (s *Service) Reject(ctx context.Context, id uint, approver *models.User, reason string) (*models.ApprovalRequest, error) {
+	request, _, err := s.decidable(ctx, id, approver)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	event := &models.ApprovalEvent{Event: models.ApprovalEventRejected, ActorID: &approver.ID, Detail: reason, CreatedAt: now}
+	moved, err := s.repo.Transition(ctx, id, models.ApprovalStatusPending, map[string]interface{}{
+		"status":        models.ApprovalStatusRejected,
+		"decided_by_id": approver.ID,
+		"decided_at":    now,
+	}, event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reject approval request: %w", err)
+	}
+	if !moved {
+		return nil, ErrNotPending
+	}
+
+	s.logger.Info("Approval %d rejected by user %d", id, approver.ID)
+	request.Status = models.ApprovalStatusRejected
+	request.DecidedByID = &approver.ID
+	request.DecidedAt = &now
+	return request, nil
+}
+
+// ExpireStale marks pending requests past their TTL as expired
+func (s *Service) ExpireStale(ctx context.Context) (int64, error) {
+	return s.repo.ExpirePending(ctx, s.clock.Now())
+}
+
+// decidable loads a pending request that approver may decide
+func (s *Service) decidable(ctx context.Context, id uint, approver *models.User) (*models.ApprovalRequest, action, error) {
+	request, err := s.repo.GetRequest(ctx, id)
+	if err != nil {
+		return nil, action{}, err
+	}
+	a, err := s.lookup(request.Action)
+	if err != nil {
+		return nil, action{}, err
+	}
+	if !rbac.UserCan(approver, a.permission) {
+		return nil, action{}, ErrForbidden
+	}
+	if approver.ID == request.RequestedByID {
+		return nil, action{}, ErrSelfApproval
+	}
+	if !request.IsPending() {
+		return nil, action{}, ErrNotPending
+	}
+	if !s.clock.Now().Before(request.ExpiresAt) {
+		if _, err := s.ExpireStale(ctx); err != nil {
+			s.logger.Error("Failed to expire approval requests: %v", err)
+		}
+		return nil, action{}, ErrExpired
+	}
+	return request, a, nil
+}
+
+// execute claims a pending request, runs its action and records the outcome.
+// approverID is nil when approvals are not required.
+func (s *Service) execute(ctx context.Context, request *models.ApprovalRequest, a action, approverID *uint) (*models.ApprovalRequest, error) {
+	now := s.clock.Now()
+	updates := map[string]interface{}{"status": models.ApprovalStatusExecuted, "decided_at": now}
+	if approverID != nil {
+		updates["decided_by_id"] = *approverID
+		approved := &models.ApprovalEvent{Event: models.ApprovalEventApproved, ActorID: approverID, CreatedAt: now}
+		// Claim the request first so two approvers cannot both run it
+		moved, err := s.repo.Transition(ctx, request.ID, models.ApprovalStatusPending,
+			map[string]interface{}{"status": models.ApprovalStatusApproved, "decided_by_id": *approverID, "decided_at": now}, approved)
+		if err != nil {
+			return nil, fmt.Errorf("failed to approve request: %w", err)
+		}
+		if !moved {
+			return nil, ErrNotPending
+		}
+		request.Status = models.ApprovalStatusApproved
+	}
+
+	result, runErr := a.execute(ctx, json.RawMessage(request.Params))
+	event := &models.ApprovalEvent{Event: models.ApprovalEventExecuted, ActorID: approverID, CreatedAt: s.clock.Now()}
+	if runErr != nil {
+		updates["status"] = models.ApprovalStatusFailed
+		updates["error"] = truncate(runErr.Error(), 500)
+		event.Event = models.ApprovalEventFailed
+		event.Detail = truncate(runErr.Error(), 500)
+	} else if encoded, err := json.Marshal(result); err == nil {
+		updates["result"] = string(encoded)
+	}
+
+	if _, err := s.repo.Transition(ctx, request.ID, request.Status, updates, event); err != nil {
+		s.logger.Error("Failed to record outcome of approval %d: %v", request.ID, err)
+	}
+
+	request.Status = updates["status"].(string)
+	request.DecidedByID = approverID
+	request.DecidedAt = &now
+	if runErr != nil {
+		request.Error = updates["error"].(string)
+		s.logger.Error("Approval %d (%s) failed: %v", request.ID, request.Action, runErr)
+		return request, runErr
+	}
+	if encoded, ok := updates["result"].(string); ok {
+		request.Result = encoded
+	}
+	s.logger.Info("Approval %d (%s) executed", request.ID, request.Action)
+	return request, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,159 @@
+package approval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/dbtest"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/logger"
+	"go-server/internal/rbac"
+)
+
+type flushCounter struct {
+	runs int
+}
+
+func (fc *flushCounter) execute(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	fc.runs++
+	return map[string]int{"runs": fc.runs}, nil
+}
+
+func newTestService(t *testing.T, required bool) (*Service, *repositories.ApprovalRepository, *clock.Fake, *flushCounter) {
+	t.Helper()
+	db := dbtest.Open(t, &models.ApprovalRequest{}, &models.ApprovalEvent{})
+
+	repo := repositories.NewApprovalRepository(db)
+	fake := clock.NewFake(time.Now())
+	svc := NewService(repo, Config{Required: required, TTL: time.Hour}, logger.NewServerLogger()).WithClock(fake)
+	counter := &flushCounter{}
+	svc.Register(ActionFlushCache, rbac.SystemConfigure, counter.execute)
+	return svc, repo, fake, counter
+}
+
+func admin(id uint) *models.User {
+	return &models.User{BaseModel: models.BaseModel{ID: id}, Role: rbac.RoleAdmin}
+}
+
+func eventNames(t *testing.T, repo *repositories.ApprovalRepository, id uint) []string {
+	t.Helper()
+	events, err := repo.ListEvents(context.Background(), id)
+	if err != nil {
+		t.Fatalf("ListEvents failed: %v", err)
+	}
+	names := make([]string, len(events))
+	for i, event := range events {
+		names[i] = event.Event
+	}
+	return names
+}
+
+func TestService_SecondAdminMustApprove(t *testing.T) {
+	svc, repo, _, counter := newTestService(t, true)
+	ctx := context.Background()
+
+	request, err := svc.Submit(ctx, ActionFlushCache, nil, "Flush cache", 1, "stale sessions")
+	if err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	if !request.IsPending() || counter.runs != 0 {
+		t.Fatalf("Action must wait for approval, status %s, runs %d", request.Status, counter.runs)
+	}
+
+	if _, err := svc.Approve(ctx, request.ID, admin(1)); !errors.Is(err, ErrSelfApproval) {
+		t.Errorf("Expected ErrSelfApproval, got %v", err)
+	}
+	support := &models.User{BaseModel: models.BaseModel{ID: 3}, Role: rbac.RoleSupport}
+	if _, err := svc.Approve(ctx, request.ID, support); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden, got %v", err)
+	}
+
+	approved, err := svc.Approve(ctx, request.ID, admin(2))
+	if err != nil {
+		t.Fatalf("Approve failed: %v", err)
+	}
+	if approved.Status != models.ApprovalStatusExecuted || counter.runs != 1 {
+		t.Fatalf("Status = %s, runs = %d", approved.Status, counter.runs)
+	}
+	if _, err := svc.Approve(ctx, request.ID, admin(3)); !errors.Is(err, ErrNotPending) {
+		t.Errorf("Second approval: expected ErrNotPending, got %v", err)
+	}
+	if counter.runs != 1 {
+		t.Errorf("Action ran %d times, want once", counter.runs)
+	}
+
+	want := []string{models.ApprovalEventRequested, models.ApprovalEventApproved, models.ApprovalEventExecuted}
+	if got := eventNames(t, repo, request.ID); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Audit events = %v, want %v", got, want)
+	}
+}
+
+func TestService_ExpiredRequestsCannotBeApproved(t *testing.T) {
+	svc, repo, fake, counter := newTestService(t, true)
+	ctx := context.Background()
+
+	request, err := svc.Submit(ctx, ActionFlushCache, nil, "Flush cache", 1, "")
+	if err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+
+	fake.Advance(2 * time.Hour)
+	if _, err := svc.Approve(ctx, request.ID, admin(2)); !errors.Is(err, ErrExpired) {
+		t.Fatalf("Expected ErrExpired, got %v", err)
+	}
+	if counter.runs != 0 {
+		t.Error("Expired request must not run")
+	}
+	stored, _ := repo.GetRequest(ctx, request.ID)
+	if stored.Status != models.ApprovalStatusExpired {
+		t.Errorf("Status = %s, want expired", stored.Status)
+	}
+}
+
+func TestService_Reject(t *testing.T) {
+	svc, repo, _, counter := newTestService(t, true)
+	ctx := context.Background()
+
+	request, _ := svc.Submit(ctx, ActionFlushCache, nil, "Flush cache", 1, "")
+	rejected, err := svc.Reject(ctx, request.ID, admin(2), "not during peak hours")
+	if err != nil {
+		t.Fatalf("Reject failed: %v", err)
+	}
+	if rejected.Status != models.ApprovalStatusRejected || counter.runs != 0 {
+		t.Errorf("Status = %s, runs = %d", rejected.Status, counter.runs)
+	}
+	if _, err := svc.Approve(ctx, request.ID, admin(3)); !errors.Is(err, ErrNotPending) {
+		t.Errorf("Approving a rejected request: expected ErrNotPending, got %v", err)
+	}
+	if got := eventNames(t, repo, request.ID); len(got) != 2 || got[1] != models.ApprovalEventRejected {
+		t.Errorf("Audit events = %v", got)
+	}
+}
+
+func TestService_RunsImmediatelyWhenNotRequired(t *testing.T) {
+	svc, repo, _, counter := newTestService(t, false)
+
+	request, err := svc.Submit(context.Background(), ActionFlushCache, nil, "Flush cache", 1, "")
+	if err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	if request.Status != models.ApprovalStatusExecuted || counter.runs != 1 {
+		t.Fatalf("Status = %s, runs = %d", request.Status, counter.runs)
+	}
+	if request.Result != `{"runs":1}` {
+		t.Errorf("Result = %s", request.Result)
+	}
+	if got := eventNames(t, repo, request.ID); len(got) != 2 {
+		t.Errorf("Direct execution must still be audited, events = %v", got)
+	}
+
+	if _, err := svc.Submit(context.Background(), "unknown", nil, "", 1, ""); !errors.Is(err, ErrUnknownAction) {
+		t.Errorf("Expected ErrUnknownAction, got %v", err)
+	}
+}
@@ -0,0 +1,249 @@
+// Package auditlog keeps a tamper-evident audit log in write-once object
+// storage. Each record is its own object, written so it can never be
+// replaced and, on S3 with Object Lock, never deleted before its retention
+// date. Records are hash-chained: each carries the hash of the one before
+// it, so editing, removing or reordering a record breaks the chain from
+// that point on, which Verify detects.
+//
+// Records are keyed by their zero-padded sequence number, so listing the
+// prefix returns them in order. Several servers may append to the same
+// log: a writer that loses the race for a sequence number reloads the head
+// of the chain and tries again.
+package auditlog
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/logger"
+	"go-server/internal/outbox"
+	"go-server/internal/storage"
+)
+
+// GenesisHash is the previous hash of the first record
+var GenesisHash = strings.Repeat("0", sha256.Size*2)
+
+// maxAppendAttempts bounds how often Append retries after other writers
+// took the next sequence number
+const maxAppendAttempts = 5
+
+// Record is one entry of the audit log
+type Record struct {
+	Seq           uint64          `json:"seq"`
+	EventID       uint            `json:"event_id,omitempty"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregate_type,omitempty"`
+	AggregateID   string          `json:"aggregate_id,omitempty"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	RecordedAt    time.Time       `json:"recorded_at"`
+	Payload       json.RawMessage `json:"payload,omitempty"`
+	PrevHash      string          `json:"prev_hash"`
+	Hash          string          `json:"hash"`
+}
+
+// ComputeHash returns the hex SHA-256 of the record's JSON encoding with
+// Hash left empty. PrevHash is part of it, which chains the records.
+func (r Record) ComputeHash() string {
+	r.Hash = ""
+	data, err := json.Marshal(r)
+	if err != nil {
+		// Only an invalid payload fails to encode, and Append rejects those
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Key returns the object key of the record with sequence number seq
+func Key(prefix string, seq uint64) string {
+	return fmt.Sprintf("%s%020d.json", prefix, seq)
+}
+
+// parseKey returns the sequence number a key names, and false for keys
+// that do not name a record
+func parseKey(prefix, key string) (uint64, bool) {
+	name := strings.TrimPrefix(key, prefix)
+	if len(name) != 25 || !strings.HasSuffix(name, ".json") {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(strings.TrimSuffix(name, ".json"), 10, 64)
+	return seq, err == nil
+}
+
+// Log appends records to the audit log
+type Log struct {
+	store     storage.WriteOnceStore
+	prefix    string
+	retention time.Duration
+	clock     clock.Clock
+	logger    logger.Logger
+
+	mu sync.Mutex
+	// head is the last record written, nil before the first; loaded says
+	// whether it has been read from the store yet
+	head   *Record
+	loaded bool
+}
+
+// NewLog creates an audit log under prefix in store. Records are locked
+// for retention after they are written; zero leaves it to the bucket.
+func NewLog(store storage.WriteOnceStore, prefix string, retention time.Duration, logger logger.Logger) *Log {
+	return &Log{
+		store:     store,
+		prefix:    prefix,
+		retention: retention,
+		clock:     clock.New(),
+		logger:    logger,
+	}
+}
+
+// WithClock overrides the clock used to timestamp records
+func (l *Log) WithClock(c clock.Clock) *Log {
+	l.clock = clock.OrDefault(c)
+	return l
+}
+
+// Append adds record to the end of the chain, filling in its sequence
+// number, hashes and RecordedAt, and returns it as written
+func (l *Log) Append(ctx context.Context, record Record) (*Record, error) {
+	if len(record.Payload) > 0 && !json.Valid(record.Payload) {
+		return nil, errors.New("audit record payload is not valid JSON")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for attempt := 0; attempt < maxAppendAttempts; attempt++ {
+		if !l.loaded {
+			head, err := l.loadHead(ctx)
+			if err != nil {
+				return nil, err
+			}
+			l.head, l.loaded = head, true
+		}
+
+		record.Seq, record.PrevHash = 1, GenesisHash
+		if l.head != nil {
+			record.Seq, record.PrevHash = l.head.Seq+1, l.head.Hash
+		}
+		record.RecordedAt = l.clock.Now().UTC()
+		record.OccurredAt = record.OccurredAt.UTC()
+		record.Hash = record.ComputeHash()
+
+		data, err := json.Marshal(record)
+		if err != nil {
+			return nil, err
+		}
+		var retainUntil time.Time
+		if l.retention > 0 {
+			retainUntil = record.RecordedAt.Add(l.retention)
+		}
+		err = l.store.PutOnce(ctx, Key(l.prefix, record.Seq), data, "application/json", retainUntil)
+		if errors.Is(err, storage.ErrExists) {
+			// Another writer appended first; continue from its record
+			l.logger.Debug("Audit record sequence number taken by another writer", "seq", record.Seq)
+			l.loaded = false
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to write audit record %d: %w", record.Seq, err)
+		}
+		written := record
+		l.head = &written
+		return &written, nil
+	}
+	return nil, fmt.Errorf("failed to append audit record: sequence number still taken after %d attempts", maxAppendAttempts)
+}
+
+// Head returns the last record written, loading it from the store if this
+// log has not written one yet; nil if the log is empty
+func (l *Log) Head(ctx context.Context) (*Record, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.loaded {
+		head, err := l.loadHead(ctx)
+		if err != nil {
+			return nil, err
+		}
+		l.head, l.loaded = head, true
+	}
+	return l.head, nil
+}
+
+// loadHead reads the record with the highest sequence number
+func (l *Log) loadHead(ctx context.Context) (*Record, error) {
+	var last string
+	var lastSeq uint64
+	err := l.store.List(ctx, l.prefix, func(object storage.Object) error {
+		if seq, ok := parseKey(l.prefix, object.Key); ok && seq >= lastSeq {
+			last, lastSeq = object.Key, seq
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit records: %w", err)
+	}
+	if last == "" {
+		return nil, nil
+	}
+	return readRecord(ctx, l.store, last)
+}
+
+// Read returns the record with sequence number seq, or storage.ErrNotFound
+func Read(ctx context.Context, store storage.Store, prefix string, seq uint64) (*Record, error) {
+	return readRecord(ctx, store, Key(prefix, seq))
+}
+
+// readRecord reads and decodes the record stored at key
+func readRecord(ctx context.Context, store storage.Store, key string) (*Record, error) {
+	body, err := store.Open(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit record %s: %w", key, err)
+	}
+	defer body.Close()
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(body); err != nil {
+		return nil, fmt.Errorf("failed to read audit record %s: %w", key, err)
+	}
+	var record Record
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		return nil, fmt.Errorf("audit record %s is not valid JSON: %w", key, err)
+	}
+	return &record, nil
+}
+
+// OutboxPublisher returns a publisher recording every event the outbox
+// relay delivers. A redelivery of the last event recorded is skipped; the
+// relay delivers at least once, so an event can still appear twice if it
+// is redelivered after later ones, which Verify does not count as
+// tampering.
+func (l *Log) OutboxPublisher() outbox.Publisher {
+	return outbox.PublisherFunc(func(ctx context.Context, envelope outbox.Envelope) error {
+		head, err := l.Head(ctx)
+		if err != nil {
+			return err
+		}
+		if head != nil && head.EventID == envelope.ID {
+			return nil
+		}
+		_, err = l.Append(ctx, Record{
+			EventID:       envelope.ID,
+			Type:          envelope.Type,
+			AggregateType: envelope.AggregateType,
+			AggregateID:   envelope.AggregateID,
+			OccurredAt:    envelope.OccurredAt,
+			Payload:       envelope.Payload,
+		})
+		return err
+	})
+}
@@ -0,0 +1,147 @@
+package auditlog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/logger"
+	"go-server/internal/outbox"
+	"go-server/internal/storage"
+)
+
+const prefix = "events/"
+
+func newLog(store storage.WriteOnceStore) *Log {
+	return NewLog(store, prefix, time.Hour, logger.NewServerLogger()).
+		WithClock(clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)))
+}
+
+func publish(t *testing.T, l *Log, id uint, eventType string) {
+	t.Helper()
+	err := l.OutboxPublisher().Publish(context.Background(), outbox.Envelope{
+		ID:            id,
+		Type:          eventType,
+		AggregateType: "user",
+		AggregateID:   "7",
+		OccurredAt:    time.Date(2026, 3, 1, 11, 0, 0, 0, time.UTC),
+		Payload:       json.RawMessage(`{"id": 7, "note": "<b>"}`),
+	})
+	if err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+}
+
+func TestLog_AppendAndVerify(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewLocalStore(t.TempDir(), "audit")
+	log := newLog(store)
+
+	publish(t, log, 1, "user.registered")
+	publish(t, log, 2, "user.updated")
+	publish(t, log, 2, "user.updated") // redelivered
+	publish(t, log, 3, "user.deleted")
+
+	report, err := Verify(ctx, store, prefix)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	head, _ := log.Head(ctx)
+	if report.Records != 3 || head == nil || report.Head != head.Hash {
+		t.Errorf("Expected 3 records ending at the log's head, got %+v and %+v", report, head)
+	}
+
+	// A log opened later continues the chain
+	reopened := newLog(store)
+	record, err := reopened.Append(ctx, Record{Type: "manual.note"})
+	if err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if record.Seq != 4 || record.PrevHash != report.Head {
+		t.Errorf("Expected record 4 chained to the head, got %+v", record)
+	}
+	if report, err := Verify(ctx, store, prefix); err != nil || report.Records != 4 {
+		t.Errorf("Expected 4 verified records, got %+v, %v", report, err)
+	}
+}
+
+func TestLog_ConcurrentWriters(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewLocalStore(t.TempDir(), "audit")
+	first, second := newLog(store), newLog(store)
+
+	// second loads the head before first appends, so its next write collides
+	if _, err := second.Head(ctx); err != nil {
+		t.Fatalf("Head failed: %v", err)
+	}
+	if _, err := first.Append(ctx, Record{Type: "a"}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	record, err := second.Append(ctx, Record{Type: "b"})
+	if err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if record.Seq != 2 {
+		t.Errorf("Expected the losing writer to append record 2, got %d", record.Seq)
+	}
+	if _, err := Verify(ctx, store, prefix); err != nil {
+		t.Errorf("Verify failed: %v", err)
+	}
+}
+
+func TestVerify_DetectsTampering(t *testing.T) {
+	tampers := map[string]func(t *testing.T, dir string){
+		"edited": func(t *testing.T, dir string) {
+			path := filepath.Join(dir, "audit", Key(prefix, 2))
+			data, _ := os.ReadFile(path)
+			os.Chmod(path, 0o644)
+			if err := os.WriteFile(path, []byte(strings.Replace(string(data), "user.updated", "user.viewed", 1)), 0o644); err != nil {
+				t.Fatalf("Failed to edit record: %v", err)
+			}
+		},
+		"removed": func(t *testing.T, dir string) {
+			if err := os.Remove(filepath.Join(dir, "audit", Key(prefix, 2))); err != nil {
+				t.Fatalf("Failed to remove record: %v", err)
+			}
+		},
+		"rehashed": func(t *testing.T, dir string) {
+			// An edit with a recomputed hash breaks the next record's link
+			path := filepath.Join(dir, "audit", Key(prefix, 2))
+			data, _ := os.ReadFile(path)
+			var record Record
+			json.Unmarshal(data, &record)
+			record.Type = "user.viewed"
+			record.Hash = record.ComputeHash()
+			data, _ = json.Marshal(record)
+			os.Chmod(path, 0o644)
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				t.Fatalf("Failed to edit record: %v", err)
+			}
+		},
+	}
+	// Records verified before the break
+	verified := map[string]uint64{"edited": 1, "removed": 1, "rehashed": 2}
+	for name, tamper := range tampers {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			store := storage.NewLocalStore(dir, "audit")
+			log := newLog(store)
+			publish(t, log, 1, "user.registered")
+			publish(t, log, 2, "user.updated")
+			publish(t, log, 3, "user.deleted")
+
+			tamper(t, dir)
+			report, err := Verify(context.Background(), store, prefix)
+			var tampered *TamperError
+			if !errors.As(err, &tampered) || report.Records != verified[name] {
+				t.Errorf("Expected tampering detected after record %d, got %+v, %v", verified[name], report, err)
+			}
+		})
+	}
+}
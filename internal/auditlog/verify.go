@@ -0,0 +1,73 @@
+package auditlog
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"go-server/internal/storage"
+)
+
+// TamperError reports the first record at which the chain is broken
+type TamperError struct {
+	Key    string
+	Seq    uint64
+	Reason string
+}
+
+func (e *TamperError) Error() string {
+	return fmt.Sprintf("audit log tampered at record %d (%s): %s", e.Seq, e.Key, e.Reason)
+}
+
+// VerifyReport summarises a verified log
+type VerifyReport struct {
+	Records uint64
+	// Head is the hash of the last record, GenesisHash for an empty log.
+	// Recording it somewhere else lets a later Verify detect records
+	// removed from the end, which the chain alone cannot.
+	Head string
+}
+
+// Verify walks the log under prefix and checks that the records are
+// numbered without gaps, that each hash matches its record and that each
+// record carries the hash of the one before. It returns a *TamperError for
+// the first record that fails, or for an object under prefix that is not
+// a record.
+func Verify(ctx context.Context, store storage.Store, prefix string) (VerifyReport, error) {
+	report := VerifyReport{Head: GenesisHash}
+
+	var keys []string
+	err := store.List(ctx, prefix, func(object storage.Object) error {
+		keys = append(keys, object.Key)
+		return nil
+	})
+	if err != nil {
+		return report, fmt.Errorf("failed to list audit records: %w", err)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		expected := report.Records + 1
+		seq, ok := parseKey(prefix, key)
+		if !ok {
+			return report, &TamperError{Key: key, Seq: expected, Reason: "object is not an audit record"}
+		}
+		if seq != expected {
+			return report, &TamperError{Key: key, Seq: expected, Reason: fmt.Sprintf("record %d is missing", expected)}
+		}
+		record, err := readRecord(ctx, store, key)
+		if err != nil {
+			return report, &TamperError{Key: key, Seq: seq, Reason: err.Error()}
+		}
+		switch {
+		case record.Seq != seq:
+			return report, &TamperError{Key: key, Seq: seq, Reason: fmt.Sprintf("record claims sequence number %d", record.Seq)}
+		case record.PrevHash != report.Head:
+			return report, &TamperError{Key: key, Seq: seq, Reason: "previous hash does not match the record before"}
+		case record.ComputeHash() != record.Hash:
+			return report, &TamperError{Key: key, Seq: seq, Reason: "hash does not match the record's contents"}
+		}
+		report.Records, report.Head = seq, record.Hash
+	}
+	return report, nil
+}
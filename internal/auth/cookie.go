@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-server/internal/idgen"
+)
+
+// Cookie transport headers. Browser clients opt in to the cookie transport
+// per request with TransportHeader, and echo the CSRF cookie in CSRFHeader on
+// requests that change state.
+const (
+	TransportHeader = "X-Auth-Transport"
+	TransportCookie = "cookie"
+	CSRFHeader      = "X-CSRF-Token"
+)
+
+// ErrCSRFTokenInvalid is returned for a request authenticated by the session
+// cookie that does not echo its CSRF token
+var ErrCSRFTokenInvalid = errors.New("CSRF token missing or invalid")
+
+// csrfTokenBytes is the entropy of a CSRF token
+const csrfTokenBytes = 32
+
+// CookieConfig holds how session cookies are set
+type CookieConfig struct {
+	// Name is the HttpOnly cookie holding the access token
+	Name string
+	// CSRFName is the cookie holding the CSRF token, readable by scripts
+	CSRFName string
+	Domain   string
+	Path     string
+	// SameSite is strict, lax or none; none requires Secure
+	SameSite string
+	Secure   bool
+}
+
+// ParseSameSite converts a SameSite setting to its http.SameSite mode
+func ParseSameSite(value string) (http.SameSite, error) {
+	switch strings.ToLower(value) {
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "", "lax":
+		return http.SameSiteLaxMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	}
+	return 0, fmt.Errorf("invalid SameSite mode %q: must be strict, lax or none", value)
+}
+
+// SessionCookies carries access tokens in an HttpOnly cookie instead of the
+// response body, so browser applications never hold them where scripts can
+// read them. Since browsers send the cookie on requests other sites trigger,
+// requests that change state must also echo the CSRF cookie in CSRFHeader
+// (the double-submit pattern).
+type SessionCookies struct {
+	config   CookieConfig
+	sameSite http.SameSite
+	tokens   idgen.TokenSource
+}
+
+// NewSessionCookies creates the cookie transport
+func NewSessionCookies(config CookieConfig) (*SessionCookies, error) {
+	sameSite, err := ParseSameSite(config.SameSite)
+	if err != nil {
+		return nil, err
+	}
+	if sameSite == http.SameSiteNoneMode && !config.Secure {
+		return nil, fmt.Errorf("SameSite=None cookies must be Secure")
+	}
+	if config.Name == "" {
+		config.Name = "session"
+	}
+	if config.CSRFName == "" {
+		config.CSRFName = "csrf_token"
+	}
+	if config.Path == "" {
+		config.Path = "/"
+	}
+	return &SessionCookies{
+		config:   config,
+		sameSite: sameSite,
+		tokens:   idgen.NewRandom(),
+	}, nil
+}
+
+// WithTokenSource sets how CSRF tokens are generated
+func (sc *SessionCookies) WithTokenSource(tokens idgen.TokenSource) *SessionCookies {
+	sc.tokens = tokens
+	return sc
+}
+
+// Requested reports whether a client asked for the cookie transport
+func (sc *SessionCookies) Requested(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get(TransportHeader), TransportCookie)
+}
+
+// Set stores response's token in the session cookie with a fresh CSRF
+// token, and removes the token from the response body
+func (sc *SessionCookies) Set(w http.ResponseWriter, response *AuthResponse) error {
+	csrf, err := sc.tokens.Token(csrfTokenBytes)
+	if err != nil {
+		return fmt.Errorf("failed to generate CSRF token: %w", err)
+	}
+	http.SetCookie(w, sc.cookie(sc.config.Name, response.Token, response.ExpiresAt, true))
+	http.SetCookie(w, sc.cookie(sc.config.CSRFName, csrf, response.ExpiresAt, false))
+	response.Token = ""
+	return nil
+}
+
+// Clear expires both cookies, e.g. on logout
+func (sc *SessionCookies) Clear(w http.ResponseWriter) {
+	for _, name := range []string{sc.config.Name, sc.config.CSRFName} {
+		cookie := sc.cookie(name, "", time.Unix(0, 0), name == sc.config.Name)
+		cookie.MaxAge = -1
+		http.SetCookie(w, cookie)
+	}
+}
+
+// Token returns the access token in a request's session cookie
+func (sc *SessionCookies) Token(r *http.Request) string {
+	cookie, err := r.Cookie(sc.config.Name)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+// VerifyCSRF checks a request authenticated by the session cookie against
+// cross-site request forgery. Safe methods pass; others must echo the CSRF
+// cookie in CSRFHeader, which other sites can neither read nor set.
+func (sc *SessionCookies) VerifyCSRF(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	cookie, err := r.Cookie(sc.config.CSRFName)
+	if err != nil || cookie.Value == "" {
+		return false
+	}
+	header := r.Header.Get(CSRFHeader)
+	return subtle.ConstantTimeCompare([]byte(header), []byte(cookie.Value)) == 1
+}
+
+func (sc *SessionCookies) cookie(name, value string, expires time.Time, httpOnly bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Domain:   sc.config.Domain,
+		Path:     sc.config.Path,
+		Expires:  expires,
+		Secure:   sc.config.Secure,
+		HttpOnly: httpOnly,
+		SameSite: sc.sameSite,
+	}
+}
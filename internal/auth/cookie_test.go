@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-server/internal/idgen"
+)
+
+func TestNewSessionCookies_Validation(t *testing.T) {
+	if _, err := NewSessionCookies(CookieConfig{SameSite: "loose"}); err == nil {
+		t.Error("Unknown SameSite mode should fail")
+	}
+	if _, err := NewSessionCookies(CookieConfig{SameSite: "none"}); err == nil {
+		t.Error("SameSite=None without Secure should fail")
+	}
+	if _, err := NewSessionCookies(CookieConfig{SameSite: "None", Secure: true}); err != nil {
+		t.Errorf("SameSite=None with Secure should be accepted, got %v", err)
+	}
+}
+
+func TestSessionCookies_SetAndVerify(t *testing.T) {
+	cookies, err := NewSessionCookies(CookieConfig{Domain: "example.com", SameSite: "strict", Secure: true})
+	if err != nil {
+		t.Fatalf("NewSessionCookies failed: %v", err)
+	}
+	cookies.WithTokenSource(idgen.NewSequence("csrf"))
+
+	w := httptest.NewRecorder()
+	expires := time.Now().Add(time.Hour)
+	response := &AuthResponse{Token: "jwt-token", ExpiresAt: expires}
+	if err := cookies.Set(w, response); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if response.Token != "" {
+		t.Error("The token should be removed from the response body")
+	}
+
+	set := w.Result().Cookies()
+	if len(set) != 2 {
+		t.Fatalf("Expected the session and CSRF cookies, got %d", len(set))
+	}
+	session, csrf := set[0], set[1]
+	if session.Name != "session" || session.Value != "jwt-token" || !session.HttpOnly || !session.Secure ||
+		session.SameSite != http.SameSiteStrictMode || session.Domain != "example.com" || session.Path != "/" {
+		t.Errorf("Unexpected session cookie %+v", session)
+	}
+	if csrf.Name != "csrf_token" || csrf.Value != "csrf-1" || csrf.HttpOnly {
+		t.Errorf("The CSRF cookie should be readable by scripts, got %+v", csrf)
+	}
+
+	request := func(method, header string) *http.Request {
+		r := httptest.NewRequest(method, "/api/posts", nil)
+		r.AddCookie(&http.Cookie{Name: session.Name, Value: session.Value})
+		r.AddCookie(&http.Cookie{Name: csrf.Name, Value: csrf.Value})
+		if header != "" {
+			r.Header.Set(CSRFHeader, header)
+		}
+		return r
+	}
+	if got := cookies.Token(request(http.MethodGet, "")); got != "jwt-token" {
+		t.Errorf("Token = %q, want jwt-token", got)
+	}
+	if !cookies.VerifyCSRF(request(http.MethodGet, "")) {
+		t.Error("Safe methods should not need the CSRF header")
+	}
+	if cookies.VerifyCSRF(request(http.MethodPost, "")) {
+		t.Error("POST without the CSRF header should fail")
+	}
+	if cookies.VerifyCSRF(request(http.MethodDelete, "csrf-2")) {
+		t.Error("A mismatched CSRF header should fail")
+	}
+	if !cookies.VerifyCSRF(request(http.MethodPost, "csrf-1")) {
+		t.Error("POST echoing the CSRF cookie should pass")
+	}
+}
+
+func TestSessionCookies_Clear(t *testing.T) {
+	cookies, _ := NewSessionCookies(CookieConfig{Name: "sid", Path: "/api"})
+	w := httptest.NewRecorder()
+	cookies.Clear(w)
+
+	set := w.Result().Cookies()
+	if len(set) != 2 || set[0].Name != "sid" || set[1].Name != "csrf_token" {
+		t.Fatalf("Expected both cookies cleared, got %+v", set)
+	}
+	for _, cookie := range set {
+		if cookie.MaxAge >= 0 || cookie.Value != "" || cookie.Path != "/api" {
+			t.Errorf("Cookie %s should be expired, got %+v", cookie.Name, cookie)
+		}
+	}
+}
+
+func TestSessionCookies_Requested(t *testing.T) {
+	cookies, _ := NewSessionCookies(CookieConfig{})
+	r := httptest.NewRequest(http.MethodPost, "/api/auth/login", nil)
+	if cookies.Requested(r) {
+		t.Error("Clients should opt in to the cookie transport")
+	}
+	r.Header.Set(TransportHeader, "Cookie")
+	if !cookies.Requested(r) {
+		t.Error("X-Auth-Transport: cookie should request the cookie transport")
+	}
+}
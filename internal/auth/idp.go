@@ -0,0 +1,242 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+// ErrUnknownSubject is returned for a valid external token whose subject
+// is not linked to a local user and may not be linked or provisioned
+var ErrUnknownSubject = errors.New("external identity is not linked to a user")
+
+// externalAlgorithms are the signing algorithms accepted from identity
+// providers. HMAC is excluded: a provider never shares its secret.
+var externalAlgorithms = []string{"RS256", "RS384", "RS512", "ES256", "ES384", AlgorithmEdDSA}
+
+var usernameUnsafe = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+
+// IdPConfig describes an external identity provider whose tokens are
+// accepted in place of our own
+type IdPConfig struct {
+	// Issuer must match the token's iss claim exactly
+	Issuer string
+	// Audience must be among the token's aud claims
+	Audience string
+	JWKSURL  string
+	// LinkByEmail links a new subject to the local user with the same
+	// email, when the provider says the email is verified
+	LinkByEmail bool
+	// AutoProvision creates a local user for a new subject with a verified
+	// email no local user has
+	AutoProvision bool
+}
+
+// ExternalClaims are the claims read from an identity provider's tokens
+type ExternalClaims struct {
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	PreferredUsername string `json:"preferred_username"`
+	GivenName         string `json:"given_name"`
+	FamilyName        string `json:"family_name"`
+	jwt.RegisteredClaims
+}
+
+// IdPValidator authenticates users with tokens issued by an external
+// identity provider, mapping each provider subject to a local user
+type IdPValidator struct {
+	config       IdPConfig
+	keys         *RemoteKeySet
+	userRepo     *repositories.UserRepository
+	identityRepo *repositories.ExternalIdentityRepository
+	clock        clock.Clock
+}
+
+// NewIdPValidator creates a validator for the provider's tokens
+func NewIdPValidator(config IdPConfig, keys *RemoteKeySet, userRepo *repositories.UserRepository, identityRepo *repositories.ExternalIdentityRepository) *IdPValidator {
+	return &IdPValidator{
+		config:       config,
+		keys:         keys,
+		userRepo:     userRepo,
+		identityRepo: identityRepo,
+		clock:        clock.New(),
+	}
+}
+
+// WithClock sets the time source for token expiry
+func (v *IdPValidator) WithClock(c clock.Clock) *IdPValidator {
+	v.clock = clock.OrDefault(c)
+	v.keys.WithClock(c)
+	return v
+}
+
+// Issues reports whether a token claims to come from this provider. The
+// token is not verified; use it only to pick a validator.
+func (v *IdPValidator) Issues(tokenString string) bool {
+	claims := jwt.RegisteredClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(tokenString, &claims); err != nil {
+		return false
+	}
+	return claims.Issuer == v.config.Issuer
+}
+
+// ParseToken verifies a token's signature, issuer, audience and expiry
+func (v *IdPValidator) ParseToken(ctx context.Context, tokenString string) (*ExternalClaims, error) {
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, fmt.Errorf("token has no key ID")
+		}
+		key, algorithms, err := v.keys.Key(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+		for _, algorithm := range algorithms {
+			if algorithm == token.Method.Alg() {
+				return key, nil
+			}
+		}
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	claims := &ExternalClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, keyFunc,
+		jwt.WithValidMethods(externalAlgorithms),
+		jwt.WithIssuer(v.config.Issuer),
+		jwt.WithAudience(v.config.Audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(v.clock.Now))
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid || claims.Subject == "" {
+		return nil, fmt.Errorf("invalid token")
+	}
+	return claims, nil
+}
+
+// ValidateToken verifies an external token and returns the local user it
+// maps to, linking or provisioning one as configured
+func (v *IdPValidator) ValidateToken(ctx context.Context, tokenString string) (*models.User, error) {
+	claims, err := v.ParseToken(ctx, tokenString)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+
+	user, err := v.resolveUser(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, fmt.Errorf("user account is deactivated")
+	}
+	return user, nil
+}
+
+// resolveUser returns the user linked to the token's subject. A new
+// subject is linked by verified email or provisioned only when the
+// configuration allows it; an unverified email is never trusted, since the
+// provider may let anyone claim any address.
+func (v *IdPValidator) resolveUser(ctx context.Context, claims *ExternalClaims) (*models.User, error) {
+	now := v.clock.Now()
+	identity, err := v.identityRepo.GetBySubject(ctx, claims.Issuer, claims.Subject)
+	if err == nil {
+		if err := v.identityRepo.Touch(ctx, identity.ID, now); err != nil {
+			return nil, fmt.Errorf("failed to record sign-in: %w", err)
+		}
+		user, err := v.userRepo.GetUserByID(ctx, identity.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("user not found: %w", err)
+		}
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to look up external identity: %w", err)
+	}
+
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, ErrUnknownSubject
+	}
+	identity = &models.ExternalIdentity{
+		Issuer:     claims.Issuer,
+		Subject:    claims.Subject,
+		Email:      claims.Email,
+		LastSeenAt: now,
+	}
+
+	existing, err := v.userRepo.GetUserByEmail(ctx, claims.Email)
+	switch {
+	case err == nil:
+		if !v.config.LinkByEmail {
+			return nil, ErrUnknownSubject
+		}
+		identity.UserID = existing.ID
+		if err := v.identityRepo.Create(ctx, identity); err != nil {
+			return nil, fmt.Errorf("failed to link external identity: %w", err)
+		}
+		return existing, nil
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, fmt.Errorf("failed to look up user: %w", err)
+	case !v.config.AutoProvision:
+		return nil, ErrUnknownSubject
+	}
+
+	user, err := v.newUser(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.identityRepo.CreateWithUser(ctx, user, identity); err != nil {
+		return nil, fmt.Errorf("failed to provision user: %w", err)
+	}
+	return user, nil
+}
+
+// newUser builds a user for a provisioned identity. It has a random
+// password, so it can only sign in through the provider until one is set.
+func (v *IdPValidator) newUser(ctx context.Context, claims *ExternalClaims) (*models.User, error) {
+	password, err := GenerateRandomString(32)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	username := usernameUnsafe.ReplaceAllString(claims.PreferredUsername, "_")
+	if username == "" {
+		username = usernameUnsafe.ReplaceAllString(strings.SplitN(claims.Email, "@", 2)[0], "_")
+	}
+	if len(username) > 20 {
+		username = username[:20]
+	}
+	if len(username) < 3 {
+		username = ""
+	} else if _, err := v.userRepo.GetUserByUsername(ctx, username); err == nil {
+		username = ""
+	}
+	if username == "" {
+		sum := sha256.Sum256([]byte(claims.Issuer + "\x00" + claims.Subject))
+		username = "user_" + hex.EncodeToString(sum[:6])
+	}
+
+	return &models.User{
+		Email:     claims.Email,
+		Username:  username,
+		Password:  hash,
+		FirstName: claims.GivenName,
+		LastName:  claims.FamilyName,
+		IsActive:  true,
+	}, nil
+}
@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/dbtest"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testIssuer = "https://idp.example.com/"
+
+type testIdP struct {
+	validator *IdPValidator
+	userRepo  *repositories.UserRepository
+	keys      []*SigningKey
+	fetches   atomic.Int32
+	clock     *clock.Fake
+}
+
+func newTestIdP(t *testing.T, config IdPConfig) *testIdP {
+	t.Helper()
+	db := dbtest.Open(t, &models.User{}, &models.ExternalIdentity{}, &models.OutboxEvent{})
+
+	idp := &testIdP{userRepo: repositories.NewUserRepository(db), clock: clock.NewFake(time.Now())}
+	idp.rotate(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idp.fetches.Add(1)
+		set := JWKSet{}
+		for _, key := range idp.keys {
+			jwk, _ := key.JWK()
+			set.Keys = append(set.Keys, jwk)
+		}
+		json.NewEncoder(w).Encode(set)
+	}))
+	t.Cleanup(server.Close)
+
+	config.Issuer = testIssuer
+	config.Audience = "go-server-api"
+	keys := NewRemoteKeySet(server.URL, server.Client(), time.Hour)
+	idp.validator = NewIdPValidator(config, keys, idp.userRepo, repositories.NewExternalIdentityRepository(db)).WithClock(idp.clock)
+	return idp
+}
+
+// rotate makes the provider sign with a new key
+func (idp *testIdP) rotate(t *testing.T) {
+	key, err := GenerateKey(AlgorithmEdDSA)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	idp.keys = append(idp.keys, key)
+}
+
+func (idp *testIdP) token(t *testing.T, claims *ExternalClaims) string {
+	t.Helper()
+	key := idp.keys[len(idp.keys)-1]
+	if claims.Issuer == "" {
+		claims.Issuer = testIssuer
+	}
+	if claims.Audience == nil {
+		claims.Audience = jwt.ClaimStrings{"go-server-api"}
+	}
+	claims.ExpiresAt = jwt.NewNumericDate(idp.clock.Now().Add(time.Hour))
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.ID
+	signed, err := token.SignedString(key.signKey)
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	return signed
+}
+
+func external(subject, email string, verified bool) *ExternalClaims {
+	return &ExternalClaims{
+		Email:             email,
+		EmailVerified:     verified,
+		PreferredUsername: "alice.smith",
+		RegisteredClaims:  jwt.RegisteredClaims{Subject: subject},
+	}
+}
+
+func TestIdPValidator_VerifiesIssuerAudienceAndKeys(t *testing.T) {
+	idp := newTestIdP(t, IdPConfig{AutoProvision: true})
+	ctx := context.Background()
+
+	claims := external("sub-1", "alice@example.com", true)
+	claims.Audience = jwt.ClaimStrings{"another-api"}
+	if _, err := idp.validator.ValidateToken(ctx, idp.token(t, claims)); err == nil {
+		t.Error("Tokens for another audience should be rejected")
+	}
+
+	claims = external("sub-1", "alice@example.com", true)
+	claims.Issuer = "https://evil.example.com/"
+	token := idp.token(t, claims)
+	if idp.validator.Issues(token) {
+		t.Error("Tokens from another issuer should not be routed to the provider")
+	}
+	if _, err := idp.validator.ValidateToken(ctx, token); err == nil {
+		t.Error("Tokens from another issuer should be rejected")
+	}
+
+	local, _ := NewJWTManager("secret", time.Hour).GenerateToken(1, "alice", "alice@example.com", false)
+	if idp.validator.Issues(local) {
+		t.Error("Our own tokens should not be routed to the provider")
+	}
+
+	token = idp.token(t, external("sub-1", "alice@example.com", true))
+	if !idp.validator.Issues(token) {
+		t.Error("The provider's tokens should be routed to it")
+	}
+	if _, err := idp.validator.ValidateToken(ctx, token); err != nil {
+		t.Fatalf("Valid token rejected: %v", err)
+	}
+
+	// The provider rotates its key; the unknown kid triggers one refetch
+	idp.rotate(t)
+	before := idp.fetches.Load()
+	idp.clock.Advance(jwksRefetchInterval)
+	if _, err := idp.validator.ValidateToken(ctx, idp.token(t, external("sub-1", "alice@example.com", true))); err != nil {
+		t.Errorf("Token signed with the rotated key rejected: %v", err)
+	}
+	if fetched := idp.fetches.Load() - before; fetched != 1 {
+		t.Errorf("Expected one JWKS fetch for the new key, got %d", fetched)
+	}
+
+	// Tokens naming keys the provider never published
+	unpublished, _ := GenerateKey(AlgorithmEdDSA)
+	idp.keys = append(idp.keys, unpublished)
+	forged := idp.token(t, external("sub-1", "alice@example.com", true))
+	idp.keys = idp.keys[:len(idp.keys)-1]
+	before = idp.fetches.Load()
+	for i := 0; i < 3; i++ {
+		if _, err := idp.validator.ValidateToken(ctx, forged); err == nil {
+			t.Error("Tokens signed with an unpublished key should be rejected")
+		}
+	}
+	if fetched := idp.fetches.Load() - before; fetched != 0 {
+		t.Errorf("Unknown kids should not refetch within %s, got %d fetches", jwksRefetchInterval, fetched)
+	}
+}
+
+func TestIdPValidator_MapsSubjectsToUsers(t *testing.T) {
+	ctx := context.Background()
+
+	idp := newTestIdP(t, IdPConfig{})
+	existing := &models.User{Email: "bob@example.com", Username: "bob", Password: "x", IsActive: true}
+	if err := idp.userRepo.CreateUser(ctx, existing); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	if _, err := idp.validator.ValidateToken(ctx, idp.token(t, external("sub-bob", "bob@example.com", true))); !errors.Is(err, ErrUnknownSubject) {
+		t.Errorf("Subjects should not be linked unless configured, got %v", err)
+	}
+
+	// Same database, now allowing links and provisioning
+	idp = newTestIdP(t, IdPConfig{LinkByEmail: true, AutoProvision: true})
+
+	if _, err := idp.validator.ValidateToken(ctx, idp.token(t, external("sub-bob", "bob@example.com", false))); !errors.Is(err, ErrUnknownSubject) {
+		t.Errorf("Unverified emails must not link accounts, got %v", err)
+	}
+	user, err := idp.validator.ValidateToken(ctx, idp.token(t, external("sub-bob", "bob@example.com", true)))
+	if err != nil || user.ID != existing.ID {
+		t.Fatalf("Expected the subject to link to the user with its verified email, got %+v, %v", user, err)
+	}
+	// Once linked, the email no longer matters
+	user, err = idp.validator.ValidateToken(ctx, idp.token(t, external("sub-bob", "", false)))
+	if err != nil || user.ID != existing.ID {
+		t.Errorf("Expected the linked user, got %+v, %v", user, err)
+	}
+
+	provisioned, err := idp.validator.ValidateToken(ctx, idp.token(t, external("sub-alice", "alice@example.com", true)))
+	if err != nil {
+		t.Fatalf("Expected a user to be provisioned: %v", err)
+	}
+	if provisioned.Email != "alice@example.com" || provisioned.Username != "alice_smith" {
+		t.Errorf("Unexpected provisioned user %+v", provisioned)
+	}
+	again, err := idp.validator.ValidateToken(ctx, idp.token(t, external("sub-alice", "alice@example.com", true)))
+	if err != nil || again.ID != provisioned.ID {
+		t.Errorf("Expected the provisioned user on the next sign-in, got %+v, %v", again, err)
+	}
+
+	// Another subject asking for a taken username gets a generated one
+	other, err := idp.validator.ValidateToken(ctx, idp.token(t, external("sub-other", "other@example.com", true)))
+	if err != nil || other.Username == "alice_smith" || other.Username == "" {
+		t.Errorf("Expected a generated username, got %+v, %v", other, err)
+	}
+}
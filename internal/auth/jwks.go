@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"go-server/internal/clock"
+)
+
+// jwksRefetchInterval limits how often the key set is fetched outside its
+// TTL, so tokens with forged kids cannot flood the identity provider and an
+// unreachable provider is not retried on every request
+const jwksRefetchInterval = 30 * time.Second
+
+// maxJWKSSize bounds the key set document
+const maxJWKSSize = 1 << 20
+
+type remoteKey struct {
+	key        interface{}
+	algorithms []string
+}
+
+// RemoteKeySet fetches and caches an identity provider's JWKS. Keys are
+// refetched after the cache TTL, or sooner when a token names a key the
+// cache does not have, which is how a provider's rotation is picked up.
+type RemoteKeySet struct {
+	url    string
+	client *http.Client
+	ttl    time.Duration
+	clock  clock.Clock
+
+	mutex       sync.Mutex
+	keys        map[string]remoteKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+}
+
+// NewRemoteKeySet creates a key set fetched from url
+func NewRemoteKeySet(url string, client *http.Client, ttl time.Duration) *RemoteKeySet {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+	return &RemoteKeySet{url: url, client: client, ttl: ttl, clock: clock.New()}
+}
+
+// WithClock sets the time source for the cache
+func (ks *RemoteKeySet) WithClock(c clock.Clock) *RemoteKeySet {
+	ks.clock = clock.OrDefault(c)
+	return ks
+}
+
+// Key returns the key with ID kid and the algorithms it verifies. If the
+// provider cannot be reached, keys already cached keep verifying.
+func (ks *RemoteKeySet) Key(ctx context.Context, kid string) (interface{}, []string, error) {
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+
+	key, known := ks.keys[kid]
+	now := ks.clock.Now()
+	stale := !known || ks.fetchedAt.IsZero() || now.Sub(ks.fetchedAt) >= ks.ttl
+	if stale && (ks.attemptedAt.IsZero() || now.Sub(ks.attemptedAt) >= jwksRefetchInterval) {
+		ks.attemptedAt = now
+		if err := ks.fetch(ctx); err != nil && !known {
+			return nil, nil, err
+		}
+		key, known = ks.keys[kid]
+	}
+	if !known {
+		return nil, nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key.key, key.algorithms, nil
+}
+
+// fetch replaces the cached keys; ks.mutex must be held
+func (ks *RemoteKeySet) fetch(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ks.url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := ks.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch JWKS: status %d", resp.StatusCode)
+	}
+
+	var set JWKSet
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxJWKSSize)).Decode(&set); err != nil {
+		return fmt.Errorf("invalid JWKS: %w", err)
+	}
+	keys := make(map[string]remoteKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, algorithms, err := jwk.PublicKey()
+		if err != nil {
+			// Skip keys of types we do not support rather than the whole set
+			continue
+		}
+		keys[jwk.KeyID] = remoteKey{key: key, algorithms: algorithms}
+	}
+
+	ks.keys = keys
+	ks.fetchedAt = ks.clock.Now()
+	return nil
+}
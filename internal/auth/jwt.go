@@ -1,19 +1,30 @@
 package auth
 
 import (
-	"crypto/rand"
-	"encoding/hex"
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
+	"go-server/internal/clock"
+	"go-server/internal/cryptopolicy"
+	"go-server/internal/idgen"
+
 	"github.com/golang-jwt/jwt/v5"
-	"golang.org/x/crypto/bcrypt"
 )
 
-// JWTManager handles JWT token operations
+// JWTManager handles JWT token operations. It holds a ring of signing
+// keys: the newest key whose NotBefore has passed signs new tokens, keys
+// not active yet already verify, and a replaced key keeps verifying for a
+// grace period after its successor takes over.
 type JWTManager struct {
-	secretKey     []byte
+	keyMutex sync.RWMutex
+	// keys is ordered by NotBefore; keys[0] is always usable for signing
+	keys        []*SigningKey
+	gracePeriod time.Duration
+
 	tokenDuration time.Duration
+	clock         clock.Clock
 }
 
 // Claims represents JWT claims
@@ -22,46 +33,103 @@ type Claims struct {
 	Username string `json:"username"`
 	Email    string `json:"email"`
 	IsAdmin  bool   `json:"is_admin"`
+
+	// SessionID binds a user token to the login session it was issued
+	// for, so the token stops working when the session ends
+	SessionID string `json:"sid,omitempty"`
+
+	// ServiceAccountID is set instead of UserID on service account tokens,
+	// which only grant Scopes within OrganizationID
+	ServiceAccountID uint     `json:"service_account_id,omitempty"`
+	OrganizationID   uint     `json:"organization_id,omitempty"`
+	Scopes           []string `json:"scopes,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// NewJWTManager creates a new JWT manager
+// NewJWTManager creates a new JWT manager signing HS256 tokens with secretKey
 func NewJWTManager(secretKey string, tokenDuration time.Duration) *JWTManager {
+	return NewJWTManagerWithKey(NewHMACKey(secretKey), tokenDuration)
+}
+
+// NewJWTManagerWithKey creates a new JWT manager signing tokens with key,
+// e.g. an RS256 or EdDSA key whose public half is served in the JWKS
+func NewJWTManagerWithKey(key *SigningKey, tokenDuration time.Duration) *JWTManager {
+	key.NotBefore = time.Time{}
 	return &JWTManager{
-		secretKey:     []byte(secretKey),
+		keys:          []*SigningKey{key},
+		gracePeriod:   tokenDuration,
 		tokenDuration: tokenDuration,
+		clock:         clock.New(),
 	}
 }
 
+// WithGracePeriod sets how long a replaced key keeps verifying tokens. It
+// defaults to the token duration, so sessions are not cut off by a
+// rotation; longer-lived service account tokens must be reissued.
+func (jm *JWTManager) WithGracePeriod(grace time.Duration) *JWTManager {
+	jm.gracePeriod = grace
+	return jm
+}
+
+// WithClock sets the time source used for issuing and validating tokens
+func (jm *JWTManager) WithClock(c clock.Clock) *JWTManager {
+	jm.clock = clock.OrDefault(c)
+	return jm
+}
+
 // GenerateToken generates a JWT token for a user
 func (jm *JWTManager) GenerateToken(userID uint, username, email string, isAdmin bool) (string, error) {
+	return jm.GenerateTokenForSession(userID, username, email, isAdmin, "")
+}
+
+// GenerateTokenForSession generates a JWT token for a user bound to the
+// login session sessionID
+func (jm *JWTManager) GenerateTokenForSession(userID uint, username, email string, isAdmin bool, sessionID string) (string, error) {
+	now := jm.clock.Now()
 	claims := &Claims{
-		UserID:   userID,
-		Username: username,
-		Email:    email,
-		IsAdmin:  isAdmin,
+		UserID:    userID,
+		Username:  username,
+		Email:     email,
+		IsAdmin:   isAdmin,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(jm.tokenDuration)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(now.Add(jm.tokenDuration)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 			Issuer:    "go-server",
 			Subject:   fmt.Sprintf("%d", userID),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jm.secretKey)
+	return jm.sign(claims)
+}
+
+// GenerateServiceAccountToken generates a token limited to scopes for a
+// service account, valid for ttl
+func (jm *JWTManager) GenerateServiceAccountToken(accountID, organizationID uint, name string, scopes []string, ttl time.Duration) (string, error) {
+	now := jm.clock.Now()
+	claims := &Claims{
+		Username:         name,
+		ServiceAccountID: accountID,
+		OrganizationID:   organizationID,
+		Scopes:           scopes,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    "go-server",
+			Subject:   fmt.Sprintf("sa:%d", accountID),
+		},
+	}
+
+	return jm.sign(claims)
 }
 
 // ValidateToken validates a JWT token and returns claims
 func (jm *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return jm.secretKey, nil
-	})
-
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, jm.keyFunc,
+		jwt.WithValidMethods([]string{AlgorithmHS256, AlgorithmRS256, AlgorithmEdDSA}),
+		jwt.WithTimeFunc(jm.clock.Now))
 	if err != nil {
 		return nil, err
 	}
@@ -73,39 +141,150 @@ func (jm *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
 	return nil, fmt.Errorf("invalid token")
 }
 
-// RefreshToken generates a new token with extended expiration
-func (jm *JWTManager) RefreshToken(tokenString string) (string, error) {
-	claims, err := jm.ValidateToken(tokenString)
-	if err != nil {
-		return "", err
+// keyFunc picks the verification key named by the token's kid header.
+// Tokens issued before key IDs were introduced have none and are checked
+// against every HMAC key.
+func (jm *JWTManager) keyFunc(token *jwt.Token) (interface{}, error) {
+	keys := jm.verificationKeys()
+	if kid, _ := token.Header["kid"].(string); kid != "" {
+		for _, key := range keys {
+			if key.ID != kid {
+				continue
+			}
+			if token.Method.Alg() != key.Algorithm {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+			if err := cryptopolicy.Allow(key.policyAlgorithm()); err != nil {
+				return nil, err
+			}
+			return key.verifyKey, nil
+		}
+		return nil, fmt.Errorf("unknown signing key %q", kid)
 	}
 
-	// Generate new token with extended expiration
-	return jm.GenerateToken(claims.UserID, claims.Username, claims.Email, claims.IsAdmin)
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	set := jwt.VerificationKeySet{}
+	for _, key := range keys {
+		if key.Algorithm == AlgorithmHS256 {
+			set.Keys = append(set.Keys, key.verifyKey.([]byte))
+		}
+	}
+	return set, nil
 }
 
-// HashPassword hashes a password using bcrypt
-func HashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", err
+// sign signs claims with the current key, naming it in the kid header
+func (jm *JWTManager) sign(claims *Claims) (string, error) {
+	key := jm.signingKey()
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.signKey)
+}
+
+// RotateKey makes secret the HS256 signing key from now on. Rotating to
+// the current key is a no-op, so a secrets watcher may deliver it
+// repeatedly.
+func (jm *JWTManager) RotateKey(secret string) {
+	key := NewHMACKey(secret)
+	if jm.signingKey().ID == key.ID {
+		return
 	}
-	return string(hashedPassword), nil
+	key.NotBefore = jm.clock.Now()
+	jm.AddKey(key)
 }
 
-// CheckPasswordHash verifies a password against its hash
-func CheckPasswordHash(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+// AddKey adds a key that signs tokens from its NotBefore on. A key with a
+// future NotBefore is a scheduled rotation: it verifies tokens and is
+// published in the JWKS right away, so every replica and verifier has it
+// before any replica starts signing with it. Adding a key already in the
+// ring updates its NotBefore.
+func (jm *JWTManager) AddKey(key *SigningKey) {
+	jm.keyMutex.Lock()
+	defer jm.keyMutex.Unlock()
+
+	keys := make([]*SigningKey, 0, len(jm.keys)+1)
+	for _, existing := range jm.keys {
+		if existing.ID != key.ID {
+			keys = append(keys, existing)
+		}
+	}
+	keys = append(keys, key)
+	sort.SliceStable(keys, func(i, j int) bool { return keys[i].NotBefore.Before(keys[j].NotBefore) })
+	jm.keys = jm.prune(keys, jm.clock.Now())
 }
 
-// GenerateRandomString generates a random string of specified length
-func GenerateRandomString(length int) (string, error) {
-	bytes := make([]byte, length)
-	if _, err := rand.Read(bytes); err != nil {
+// prune drops keys whose grace period has ended
+func (jm *JWTManager) prune(keys []*SigningKey, now time.Time) []*SigningKey {
+	for len(keys) > 1 && !keys[1].NotBefore.After(now) && now.After(keys[1].NotBefore.Add(jm.gracePeriod)) {
+		keys = keys[1:]
+	}
+	return keys
+}
+
+// signingKey returns the key new tokens are signed with
+func (jm *JWTManager) signingKey() *SigningKey {
+	jm.keyMutex.RLock()
+	defer jm.keyMutex.RUnlock()
+	now := jm.clock.Now()
+	current := jm.keys[0]
+	for _, key := range jm.keys[1:] {
+		if key.NotBefore.After(now) {
+			break
+		}
+		current = key
+	}
+	return current
+}
+
+// verificationKeys returns the keys that verify tokens: the signing key,
+// keys scheduled to sign later, and replaced keys within their grace period
+func (jm *JWTManager) verificationKeys() []*SigningKey {
+	jm.keyMutex.RLock()
+	defer jm.keyMutex.RUnlock()
+	now := jm.clock.Now()
+	keys := make([]*SigningKey, 0, len(jm.keys))
+	for i, key := range jm.keys {
+		if i+1 < len(jm.keys) {
+			successor := jm.keys[i+1].NotBefore
+			if !successor.After(now) && now.After(successor.Add(jm.gracePeriod)) {
+				continue
+			}
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// JWKS returns the public keys that verify tokens, for other services to
+// validate them. HMAC keys are secret and never included.
+func (jm *JWTManager) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range jm.verificationKeys() {
+		if jwk, ok := key.JWK(); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	return set
+}
+
+// RefreshToken generates a new token with extended expiration
+func (jm *JWTManager) RefreshToken(tokenString string) (string, error) {
+	claims, err := jm.ValidateToken(tokenString)
+	if err != nil {
 		return "", err
 	}
-	return hex.EncodeToString(bytes), nil
+	if claims.ServiceAccountID != 0 {
+		return "", fmt.Errorf("service account tokens cannot be refreshed")
+	}
+
+	// Generate new token with extended expiration
+	return jm.GenerateTokenForSession(claims.UserID, claims.Username, claims.Email, claims.IsAdmin, claims.SessionID)
+}
+
+// GenerateRandomString generates a hex string from the specified number of random bytes
+func GenerateRandomString(length int) (string, error) {
+	return idgen.Default.Token(length)
 }
 
 // GenerateAPIKey generates a secure API key
@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/logger"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestJWTManager_ExpiryWithFakeClock(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	jm := NewJWTManager("test-secret", time.Hour).WithClock(fake)
+
+	token, err := jm.GenerateToken(1, "alice", "alice@example.com", false)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	fake.Advance(59 * time.Minute)
+	if _, err := jm.ValidateToken(token); err != nil {
+		t.Errorf("Token should still be valid: %v", err)
+	}
+
+	fake.Advance(2 * time.Minute)
+	if _, err := jm.ValidateToken(token); err == nil {
+		t.Error("Token should be expired")
+	}
+}
+
+func TestJWTManager_RotateKey(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	jm := NewJWTManager("old-secret", time.Hour).WithClock(fake).WithGracePeriod(30 * time.Minute)
+
+	oldToken, _ := jm.GenerateToken(1, "alice", "alice@example.com", false)
+	jm.RotateKey("new-secret")
+	// Repeating the rotation must not replace the old key with the new one
+	jm.RotateKey("new-secret")
+	newToken, _ := jm.GenerateToken(1, "alice", "alice@example.com", false)
+
+	if _, err := NewJWTManager("new-secret", time.Hour).WithClock(fake).ValidateToken(newToken); err != nil {
+		t.Errorf("New tokens should be signed with the new key: %v", err)
+	}
+	if _, err := jm.ValidateToken(oldToken); err != nil {
+		t.Errorf("Tokens signed before the rotation should still validate: %v", err)
+	}
+
+	// Move the old key out of its grace period without expiring the token
+	fake.Advance(31 * time.Minute)
+	if _, err := jm.ValidateToken(oldToken); err == nil {
+		t.Error("The replaced key should stop validating after its grace period")
+	}
+	if _, err := jm.ValidateToken(newToken); err != nil {
+		t.Errorf("New token should validate: %v", err)
+	}
+}
+
+func TestJWTManager_KeyIDAndLegacyTokens(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	jm := NewJWTManager("secret", time.Hour).WithClock(fake)
+
+	token, _ := jm.GenerateToken(1, "alice", "alice@example.com", false)
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+	if err != nil {
+		t.Fatalf("Failed to parse token: %v", err)
+	}
+	if kid := parsed.Header["kid"]; kid != NewHMACKey("secret").ID {
+		t.Errorf("Expected the kid header to name the signing key, got %v", kid)
+	}
+
+	// Tokens issued before key IDs were introduced carry no kid
+	legacy, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, parsed.Claims).SignedString([]byte("secret"))
+	if _, err := jm.ValidateToken(legacy); err != nil {
+		t.Errorf("Tokens without a kid should validate against the HMAC keys: %v", err)
+	}
+	forged, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, parsed.Claims).SignedString([]byte("other"))
+	if _, err := jm.ValidateToken(forged); err == nil {
+		t.Error("Tokens signed with an unknown secret should be rejected")
+	}
+}
+
+func TestJWTManager_AsymmetricKeys(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmRS256, AlgorithmEdDSA} {
+		t.Run(algorithm, func(t *testing.T) {
+			key, err := GenerateKey(algorithm)
+			if err != nil {
+				t.Fatalf("Failed to generate key: %v", err)
+			}
+			jm := NewJWTManagerWithKey(key, time.Hour)
+
+			token, err := jm.GenerateToken(1, "alice", "alice@example.com", false)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			if _, err := jm.ValidateToken(token); err != nil {
+				t.Errorf("Token should validate: %v", err)
+			}
+
+			set := jm.JWKS()
+			if len(set.Keys) != 1 || set.Keys[0].KeyID != key.ID || set.Keys[0].Algorithm != algorithm {
+				t.Errorf("Expected the JWKS to publish the key, got %+v", set)
+			}
+
+			// A token naming the key but signed with HMAC must not verify
+			claims := &Claims{UserID: 1, RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			}}
+			confused := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+			confused.Header["kid"] = key.ID
+			signed, _ := confused.SignedString([]byte(set.Keys[0].N + set.Keys[0].X))
+			if _, err := jm.ValidateToken(signed); err == nil {
+				t.Error("A token using another algorithm than its key should be rejected")
+			}
+		})
+	}
+
+	if jwks := NewJWTManager("secret", time.Hour).JWKS(); len(jwks.Keys) != 0 {
+		t.Errorf("HMAC keys must never be published, got %+v", jwks)
+	}
+}
+
+func TestKeyRotator_ScheduledRotation(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	first, _ := GenerateKey(AlgorithmEdDSA)
+	jm := NewJWTManagerWithKey(first, time.Hour).WithClock(fake)
+	rotator := NewKeyRotator(jm, AlgorithmEdDSA, 24*time.Hour, time.Hour, logger.NewServerLogger()).WithClock(fake)
+
+	oldToken, _ := jm.GenerateToken(1, "alice", "alice@example.com", false)
+	next, err := rotator.Rotate()
+	if err != nil {
+		t.Fatalf("Failed to rotate: %v", err)
+	}
+
+	// The new key is published before it signs
+	if jwks := jm.JWKS(); len(jwks.Keys) != 2 {
+		t.Errorf("Expected both keys in the JWKS, got %d", len(jwks.Keys))
+	}
+	if key := jm.signingKey(); key.ID != first.ID {
+		t.Errorf("The new key should not sign before its lead time, got %s", key.ID)
+	}
+
+	fake.Advance(time.Hour + time.Second)
+	if key := jm.signingKey(); key.ID != next.ID {
+		t.Errorf("The new key should sign after its lead time, got %s", key.ID)
+	}
+	if _, err := jm.ValidateToken(oldToken); err == nil {
+		t.Error("Old token should have expired")
+	}
+
+	newToken, _ := jm.GenerateToken(1, "alice", "alice@example.com", false)
+	fake.Advance(30 * time.Minute)
+	if _, err := jm.ValidateToken(newToken); err != nil {
+		t.Errorf("New token should validate: %v", err)
+	}
+	fake.Advance(31 * time.Minute)
+	if jwks := jm.JWKS(); len(jwks.Keys) != 1 || jwks.Keys[0].KeyID != next.ID {
+		t.Errorf("The replaced key should leave the JWKS after its grace period, got %+v", jwks)
+	}
+}
@@ -0,0 +1,288 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"time"
+
+	"go-server/internal/cryptopolicy"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Signing algorithms
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// minRSABits is the smallest RSA key accepted for signing
+const minRSABits = 2048
+
+// SigningKey is a key tokens are signed and verified with. Its ID is sent
+// in the token's kid header and derived from the key itself, so every
+// replica configured with the same key agrees on it.
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	// NotBefore is when the key starts signing tokens. It verifies tokens
+	// and is published in the JWKS as soon as it is added, so replicas and
+	// verifiers know it before the first token signed with it arrives.
+	NotBefore time.Time
+
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// NewHMACKey creates an HS256 key from a shared secret. HMAC keys are
+// never published in the JWKS.
+func NewHMACKey(secret string) *SigningKey {
+	sum := sha256.Sum256([]byte(secret))
+	return &SigningKey{
+		ID:        "hs-" + hex.EncodeToString(sum[:8]),
+		Algorithm: AlgorithmHS256,
+		method:    jwt.SigningMethodHS256,
+		signKey:   []byte(secret),
+		verifyKey: []byte(secret),
+	}
+}
+
+// NewRSAKey creates an RS256 key
+func NewRSAKey(key *rsa.PrivateKey) (*SigningKey, error) {
+	if key.N.BitLen() < minRSABits {
+		return nil, fmt.Errorf("RSA signing keys must be at least %d bits", minRSABits)
+	}
+	k := &SigningKey{
+		Algorithm: AlgorithmRS256,
+		method:    jwt.SigningMethodRS256,
+		signKey:   key,
+		verifyKey: &key.PublicKey,
+	}
+	k.ID = k.thumbprint()
+	return k, nil
+}
+
+// NewEd25519Key creates an EdDSA key
+func NewEd25519Key(key ed25519.PrivateKey) *SigningKey {
+	k := &SigningKey{
+		Algorithm: AlgorithmEdDSA,
+		method:    jwt.SigningMethodEdDSA,
+		signKey:   key,
+		verifyKey: key.Public(),
+	}
+	k.ID = k.thumbprint()
+	return k
+}
+
+// policyAlgorithm is the key's algorithm as the crypto policy names it
+func (k *SigningKey) policyAlgorithm() string {
+	switch k.Algorithm {
+	case AlgorithmHS256:
+		return cryptopolicy.HMACSHA256
+	case AlgorithmRS256:
+		return cryptopolicy.RSASHA256
+	case AlgorithmEdDSA:
+		return cryptopolicy.Ed25519
+	}
+	return k.Algorithm
+}
+
+// GenerateKey creates a random key for the algorithm
+func GenerateKey(algorithm string) (*SigningKey, error) {
+	switch algorithm {
+	case AlgorithmHS256:
+		secret, err := GenerateRandomString(32)
+		if err != nil {
+			return nil, err
+		}
+		return NewHMACKey(secret), nil
+	case AlgorithmRS256:
+		key, err := rsa.GenerateKey(rand.Reader, minRSABits)
+		if err != nil {
+			return nil, err
+		}
+		return NewRSAKey(key)
+	case AlgorithmEdDSA:
+		if err := cryptopolicy.Allow(cryptopolicy.Ed25519); err != nil {
+			return nil, err
+		}
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return NewEd25519Key(key), nil
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+}
+
+// ParsePrivateKeyPEM creates a key from a PEM encoded RSA (PKCS #1 or
+// PKCS #8) or Ed25519 (PKCS #8) private key
+func ParsePrivateKeyPEM(data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM private key found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return NewRSAKey(key)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		return NewRSAKey(key)
+	case ed25519.PrivateKey:
+		if err := cryptopolicy.Allow(cryptopolicy.Ed25519); err != nil {
+			return nil, err
+		}
+		return NewEd25519Key(key), nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", parsed)
+	}
+}
+
+// JWK is a public key in JSON Web Key format
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	KeyID     string `json:"kid,omitempty"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC and OKP (Ed25519)
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+}
+
+// JWKSet is the document served at /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK returns the key's public half, or false for HMAC keys, which have
+// none
+func (k *SigningKey) JWK() (JWK, bool) {
+	switch public := k.verifyKey.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			KeyType:   "RSA",
+			Use:       "sig",
+			Algorithm: k.Algorithm,
+			KeyID:     k.ID,
+			N:         base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+			E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+		}, true
+	case ed25519.PublicKey:
+		return JWK{
+			KeyType:   "OKP",
+			Use:       "sig",
+			Algorithm: k.Algorithm,
+			KeyID:     k.ID,
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(public),
+		}, true
+	default:
+		return JWK{}, false
+	}
+}
+
+// thumbprint returns the RFC 7638 JWK thumbprint of the public key
+func (k *SigningKey) thumbprint() string {
+	jwk, _ := k.JWK()
+	// The required members in lexicographic order, without whitespace
+	var members interface{}
+	if jwk.KeyType == "RSA" {
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.KeyType, jwk.N}
+	} else {
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Curve, jwk.KeyType, jwk.X}
+	}
+	data, _ := json.Marshal(members)
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// PublicKey decodes the key and returns the algorithms it may verify. A
+// key naming its algorithm verifies only that one.
+func (jwk JWK) PublicKey() (interface{}, []string, error) {
+	var key interface{}
+	var algorithms []string
+	switch jwk.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid RSA modulus: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, nil, fmt.Errorf("invalid RSA exponent")
+		}
+		public := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if public.N.BitLen() < minRSABits {
+			return nil, nil, fmt.Errorf("RSA keys must be at least %d bits", minRSABits)
+		}
+		key, algorithms = public, []string{"RS256", "RS384", "RS512"}
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Curve {
+		case "P-256":
+			curve, algorithms = elliptic.P256(), []string{"ES256"}
+		case "P-384":
+			curve, algorithms = elliptic.P384(), []string{"ES384"}
+		default:
+			return nil, nil, fmt.Errorf("unsupported EC curve %q", jwk.Curve)
+		}
+		x, errX := base64.RawURLEncoding.DecodeString(jwk.X)
+		y, errY := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if errX != nil || errY != nil {
+			return nil, nil, fmt.Errorf("invalid EC point")
+		}
+		public := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(public.X, public.Y) {
+			return nil, nil, fmt.Errorf("EC point is not on curve %s", jwk.Curve)
+		}
+		key = public
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if jwk.Curve != "Ed25519" || err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, nil, fmt.Errorf("invalid Ed25519 key")
+		}
+		key, algorithms = ed25519.PublicKey(x), []string{AlgorithmEdDSA}
+	default:
+		return nil, nil, fmt.Errorf("unsupported key type %q", jwk.KeyType)
+	}
+
+	if jwk.Algorithm != "" {
+		for _, algorithm := range algorithms {
+			if algorithm == jwk.Algorithm {
+				return key, []string{algorithm}, nil
+			}
+		}
+		return nil, nil, fmt.Errorf("key type %s cannot be used with %s", jwk.KeyType, jwk.Algorithm)
+	}
+	return key, algorithms, nil
+}
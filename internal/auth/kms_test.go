@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-server/internal/kms"
+)
+
+func TestNewKMSKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	if err := kms.CreateKeyFile(path); err != nil {
+		t.Fatalf("CreateKeyFile failed: %v", err)
+	}
+	provider, err := kms.OpenLocalProvider(path)
+	if err != nil {
+		t.Fatalf("OpenLocalProvider failed: %v", err)
+	}
+
+	key, err := NewKMSKey(context.Background(), provider)
+	if err != nil {
+		t.Fatalf("NewKMSKey failed: %v", err)
+	}
+	jm := NewJWTManagerWithKey(key, time.Hour)
+	token, err := jm.GenerateToken(7, "kms", "kms@example.com", false)
+	if err != nil {
+		t.Fatalf("GenerateToken failed: %v", err)
+	}
+	if claims, err := jm.ValidateToken(token); err != nil || claims.UserID != 7 {
+		t.Errorf("Expected the KMS-signed token to validate, got %+v, %v", claims, err)
+	}
+
+	// Replicas using the same KMS key agree on its ID and publish it
+	again, _ := NewKMSKey(context.Background(), provider)
+	if again.ID != key.ID {
+		t.Errorf("Expected a stable key ID, got %s and %s", key.ID, again.ID)
+	}
+	if jwk, ok := key.JWK(); !ok || jwk.KeyType != "RSA" || jwk.KeyID != key.ID {
+		t.Errorf("Expected the KMS key's public half in the JWKS, got %+v", jwk)
+	}
+}
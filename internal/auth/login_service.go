@@ -2,13 +2,15 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"go-server/internal/clock"
+	"go-server/internal/cryptopolicy"
 	"go-server/internal/database/models"
 	"go-server/internal/database/repositories"
-
-	"golang.org/x/crypto/bcrypt"
+	"go-server/internal/idgen"
 )
 
 // LoginService handles login operations
@@ -17,6 +19,9 @@ type LoginService struct {
 	cacheRepo   *repositories.CacheRepository
 	jwtManager  *JWTManager
 	sessionRepo *repositories.SessionRepository
+	clock       clock.Clock
+	tokens      idgen.TokenSource
+	outbox      *repositories.OutboxRepository
 }
 
 // NewLoginService creates a new login service
@@ -31,9 +36,24 @@ func NewLoginService(
 		cacheRepo:   cacheRepo,
 		sessionRepo: sessionRepo,
 		jwtManager:  jwtManager,
+		clock:       clock.New(),
+		tokens:      idgen.Default,
 	}
 }
 
+// WithClock sets the time source used for login timestamps
+func (ls *LoginService) WithClock(c clock.Clock) *LoginService {
+	ls.clock = clock.OrDefault(c)
+	return ls
+}
+
+// WithOutbox records failed logins to existing accounts as
+// auth.login_failed events, e.g. for security teams' webhooks
+func (ls *LoginService) WithOutbox(outbox *repositories.OutboxRepository) *LoginService {
+	ls.outbox = outbox
+	return ls
+}
+
 // Login authenticates a user and returns an auth response
 func (ls *LoginService) Login(ctx context.Context, req *LoginRequest, ipAddress, userAgent string) (*AuthResponse, error) {
 	// Get user by email
@@ -44,51 +64,62 @@ func (ls *LoginService) Login(ctx context.Context, req *LoginRequest, ipAddress,
 
 	// Check if user is active
 	if !user.IsActive {
+		ls.recordFailure(ctx, user, models.LoginFailedDeactivated, ipAddress, userAgent)
 		return nil, fmt.Errorf("account is deactivated")
 	}
 
 	// Verify password
-	if err := ls.verifyPassword(req.Password, user.Password); err != nil {
+	if err := verifyPasswordHash(req.Password, user.Password); err != nil {
+		ls.recordFailure(ctx, user, models.LoginFailedPassword, ipAddress, userAgent)
+		if errors.Is(err, cryptopolicy.ErrNotApproved) {
+			// The hash predates restricted crypto mode; the user must reset the password
+			return nil, fmt.Errorf("invalid credentials: %w", err)
+		}
 		return nil, fmt.Errorf("invalid credentials")
 	}
 
-	// Generate JWT token
-	token, err := ls.jwtManager.GenerateToken(user.ID, user.Username, user.Email, user.IsAdmin)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate token: %w", err)
-	}
-
 	// Generate session token
 	sessionToken, err := ls.generateSessionToken()
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate session token: %w", err)
+		return nil, internalErrorf("failed to generate session token: %w", err)
 	}
 
-	// Create session
+	// Create session; the repository sets its expiry from the session policy
 	session := &models.Session{
 		UserID:    user.ID,
 		Token:     sessionToken,
-		ExpiresAt: time.Now().Add(24 * time.Hour), // 24 hour session
 		IPAddress: ipAddress,
 		UserAgent: userAgent,
 		IsActive:  true,
 	}
 
 	if err := ls.sessionRepo.CreateSession(ctx, session); err != nil {
-		return nil, fmt.Errorf("failed to create session: %w", err)
+		return nil, internalErrorf("failed to create session: %w", err)
+	}
+
+	// Generate JWT token bound to the session
+	token, err := ls.jwtManager.GenerateTokenForSession(user.ID, user.Username, user.Email, user.IsAdmin, sessionToken)
+	if err != nil {
+		return nil, internalErrorf("failed to generate token: %w", err)
 	}
 
-	// Update last login
-	now := time.Now()
+	// Update last login, upgrading the password hash to the current scheme
+	now := ls.clock.Now()
 	user.LastLogin = &now
-	if err := ls.userRepo.UpdateUser(ctx, user); err != nil {
+	rehashed := ""
+	if PasswordNeedsRehash(user.Password) {
+		if hash, err := HashPassword(req.Password); err == nil {
+			user.Password = hash
+			rehashed = hash
+		}
+	}
+	if err := ls.userRepo.RecordLogin(ctx, user.ID, now, rehashed); err != nil {
 		// Log error but don't fail login
 		fmt.Printf("Warning: failed to update last login: %v\n", err)
 	}
 
 	// Cache user session
-	cacheKey := fmt.Sprintf("user:%d", user.ID)
-	if err := ls.cacheRepo.Set(ctx, cacheKey, user, 30*time.Minute); err != nil {
+	if err := ls.cacheRepo.SetUserCache(ctx, user, 30*time.Minute); err != nil {
 		// Log error but don't fail login
 		fmt.Printf("Warning: failed to cache user: %v\n", err)
 	}
@@ -104,14 +135,38 @@ func (ls *LoginService) Login(ctx context.Context, req *LoginRequest, ipAddress,
 	}, nil
 }
 
-// verifyPassword verifies a password against a hash
-func (ls *LoginService) verifyPassword(password, hash string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+// recordFailure records a failed login to user's account. A failure to
+// record it does not change the login's outcome.
+func (ls *LoginService) recordFailure(ctx context.Context, user *models.User, reason, ipAddress, userAgent string) {
+	if ls.outbox == nil {
+		return
+	}
+	event, err := models.NewOutboxEvent(models.EventLoginFailed, "user", user.ID, models.LoginFailedPayload{
+		UserID:    user.ID,
+		PublicID:  user.PublicID,
+		Email:     user.Email,
+		Reason:    reason,
+		IPAddress: ipAddress,
+		UserAgent: userAgent,
+	})
+	if err == nil {
+		err = ls.outbox.Append(ctx, event)
+	}
+	if err != nil {
+		fmt.Printf("Warning: failed to record failed login: %v\n", err)
+	}
+}
+
+// WithTokenSource sets the source used to generate session tokens
+func (ls *LoginService) WithTokenSource(tokens idgen.TokenSource) *LoginService {
+	if tokens == nil {
+		tokens = idgen.Default
+	}
+	ls.tokens = tokens
+	return ls
 }
 
-// generateSessionToken generates a random session token
+// generateSessionToken generates an unguessable session token
 func (ls *LoginService) generateSessionToken() (string, error) {
-	// This would generate a secure random token
-	// For now, return a simple implementation
-	return fmt.Sprintf("session_%d", time.Now().UnixNano()), nil
+	return ls.tokens.Token(32)
 }
@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"go-server/internal/cryptopolicy"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password hash schemes
+const (
+	PasswordBcrypt = "bcrypt"
+	PasswordPBKDF2 = "pbkdf2-sha256"
+)
+
+// PBKDF2 hashes are $pbkdf2-sha256$i=<iterations>$<salt>$<key> with
+// unpadded base64, the PHC string format. The iteration count follows
+// OWASP's advice for PBKDF2-HMAC-SHA-256.
+const (
+	pbkdf2Iterations = 600000
+	pbkdf2SaltSize   = 16
+	pbkdf2KeySize    = 32
+)
+
+// passwordScheme is the scheme set by UsePasswordScheme
+var passwordScheme atomic.Value
+
+// UsePasswordScheme makes HashPassword hash new passwords with scheme.
+// Restricted crypto mode always uses PBKDF2.
+func UsePasswordScheme(scheme string) error {
+	switch scheme {
+	case PasswordBcrypt, PasswordPBKDF2:
+	default:
+		return fmt.Errorf("unsupported password hash scheme: %s", scheme)
+	}
+	passwordScheme.Store(scheme)
+	return nil
+}
+
+// currentPasswordScheme is the scheme new hashes use
+func currentPasswordScheme() string {
+	if cryptopolicy.Restricted() {
+		return PasswordPBKDF2
+	}
+	if scheme, ok := passwordScheme.Load().(string); ok {
+		return scheme
+	}
+	return PasswordBcrypt
+}
+
+// HashPassword hashes a password with the current scheme, bcrypt unless
+// UsePasswordScheme or the crypto policy chose PBKDF2
+func HashPassword(password string) (string, error) {
+	if currentPasswordScheme() == PasswordBcrypt {
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return "", err
+		}
+		return string(hashedPassword), nil
+	}
+
+	salt := make([]byte, pbkdf2SaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key, err := cryptopolicy.PBKDF2(password, salt, pbkdf2Iterations, pbkdf2KeySize)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("$%s$i=%d$%s$%s", PasswordPBKDF2, pbkdf2Iterations,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// CheckPasswordHash verifies a password against its hash
+func CheckPasswordHash(password, hash string) bool {
+	return verifyPasswordHash(password, hash) == nil
+}
+
+// verifyPasswordHash verifies a password against a hash in either scheme.
+// Restricted crypto mode refuses bcrypt hashes with
+// cryptopolicy.ErrNotApproved; their users must reset their password.
+func verifyPasswordHash(password, hash string) error {
+	if !strings.HasPrefix(hash, "$"+PasswordPBKDF2+"$") {
+		if err := cryptopolicy.Allow(cryptopolicy.Bcrypt); err != nil {
+			return err
+		}
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	}
+
+	iterations, salt, key, err := parsePBKDF2Hash(hash)
+	if err != nil {
+		return err
+	}
+	derived, err := cryptopolicy.PBKDF2(password, salt, iterations, len(key))
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(derived, key) != 1 {
+		return fmt.Errorf("password does not match")
+	}
+	return nil
+}
+
+// PasswordNeedsRehash reports whether hash is in another scheme than new
+// hashes, or weaker, so it should be replaced once the password is known
+func PasswordNeedsRehash(hash string) bool {
+	iterations, _, _, err := parsePBKDF2Hash(hash)
+	if currentPasswordScheme() == PasswordBcrypt {
+		return err == nil
+	}
+	return err != nil || iterations < pbkdf2Iterations
+}
+
+// parsePBKDF2Hash splits a PBKDF2 hash into its parameters
+func parsePBKDF2Hash(hash string) (iterations int, salt, key []byte, err error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 5 || parts[0] != "" || parts[1] != PasswordPBKDF2 || !strings.HasPrefix(parts[2], "i=") {
+		return 0, nil, nil, fmt.Errorf("malformed %s hash", PasswordPBKDF2)
+	}
+	iterations, err = strconv.Atoi(strings.TrimPrefix(parts[2], "i="))
+	if err != nil || iterations < 1 {
+		return 0, nil, nil, fmt.Errorf("malformed %s hash", PasswordPBKDF2)
+	}
+	salt, err = base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("malformed %s hash", PasswordPBKDF2)
+	}
+	key, err = base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil || len(key) == 0 {
+		return 0, nil, nil, fmt.Errorf("malformed %s hash", PasswordPBKDF2)
+	}
+	return iterations, salt, key, nil
+}
@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPasswordSchemes(t *testing.T) {
+	legacy, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatalf("HashPassword failed: %v", err)
+	}
+	if !strings.HasPrefix(legacy, "$2") || PasswordNeedsRehash(legacy) {
+		t.Fatalf("Expected a current bcrypt hash by default, got %q", legacy)
+	}
+
+	if err := UsePasswordScheme("md5-crypt"); err == nil {
+		t.Error("Expected an unknown scheme refused")
+	}
+	if err := UsePasswordScheme(PasswordPBKDF2); err != nil {
+		t.Fatalf("UsePasswordScheme failed: %v", err)
+	}
+	t.Cleanup(func() { UsePasswordScheme(PasswordBcrypt) })
+
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatalf("HashPassword failed: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$pbkdf2-sha256$i=600000$") {
+		t.Fatalf("Expected a PBKDF2 hash, got %q", hash)
+	}
+	if !CheckPasswordHash("correct horse", hash) || CheckPasswordHash("battery staple", hash) {
+		t.Error("Expected the PBKDF2 hash to match only its password")
+	}
+	if PasswordNeedsRehash(hash) {
+		t.Error("Expected a current PBKDF2 hash kept")
+	}
+
+	// Hashes in the old scheme still verify, and are due an upgrade
+	if !CheckPasswordHash("correct horse", legacy) || !PasswordNeedsRehash(legacy) {
+		t.Error("Expected the bcrypt hash to verify and need a rehash")
+	}
+	weak := "$pbkdf2-sha256$i=1000$" + strings.SplitN(hash, "$", 4)[3]
+	if !PasswordNeedsRehash(weak) {
+		t.Error("Expected a hash with fewer iterations to need a rehash")
+	}
+	for _, malformed := range []string{"$pbkdf2-sha256$i=0$c2FsdA$a2V5", "$pbkdf2-sha256$600000$c2FsdA$a2V5", "$pbkdf2-sha256$i=1$c2FsdA$"} {
+		if CheckPasswordHash("correct horse", malformed) {
+			t.Errorf("Expected malformed hash %q refused", malformed)
+		}
+	}
+}
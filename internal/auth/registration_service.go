@@ -6,32 +6,67 @@ import (
 
 	"go-server/internal/database/models"
 	"go-server/internal/database/repositories"
+	"go-server/internal/idgen"
+	"go-server/internal/usernames"
 
-	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 // RegistrationService handles user registration operations
 type RegistrationService struct {
 	userRepo    *repositories.UserRepository
 	cacheRepo   *repositories.CacheRepository
+	sessionRepo *repositories.SessionRepository
+	txManager   *repositories.TxManager
 	jwtManager  *JWTManager
+	usernames   *usernames.Policy
+	tokens      idgen.TokenSource
 }
 
 // NewRegistrationService creates a new registration service
 func NewRegistrationService(
 	userRepo *repositories.UserRepository,
 	cacheRepo *repositories.CacheRepository,
+	sessionRepo *repositories.SessionRepository,
 	jwtManager *JWTManager,
+	txManager *repositories.TxManager,
 ) *RegistrationService {
 	return &RegistrationService{
-		userRepo:   userRepo,
-		cacheRepo:  cacheRepo,
-		jwtManager: jwtManager,
+		userRepo:    userRepo,
+		cacheRepo:   cacheRepo,
+		sessionRepo: sessionRepo,
+		txManager:   txManager,
+		jwtManager:  jwtManager,
+		tokens:      idgen.Default,
 	}
 }
 
-// Register creates a new user account
-func (rs *RegistrationService) Register(ctx context.Context, req *RegisterRequest) (*AuthResponse, error) {
+// WithTokenSource sets the source used to generate session tokens
+func (rs *RegistrationService) WithTokenSource(tokens idgen.TokenSource) *RegistrationService {
+	if tokens == nil {
+		tokens = idgen.Default
+	}
+	rs.tokens = tokens
+	return rs
+}
+
+// WithUsernamePolicy refuses usernames the policy does not allow with a
+// *usernames.Violation
+func (rs *RegistrationService) WithUsernamePolicy(policy *usernames.Policy) *RegistrationService {
+	rs.usernames = policy
+	return rs
+}
+
+// Register creates a new user account and signs it in. The user and its
+// first session are written in one transaction, so a failure leaves
+// neither behind.
+func (rs *RegistrationService) Register(ctx context.Context, req *RegisterRequest, ipAddress, userAgent string) (*AuthResponse, error) {
+	if rs.usernames != nil {
+		if err := rs.usernames.Check(req.Username); err != nil {
+			return nil, err
+		}
+	}
+
 	// Check if email already exists
 	existingUser, _ := rs.userRepo.GetUserByEmail(ctx, req.Email)
 	if existingUser != nil {
@@ -45,9 +80,14 @@ func (rs *RegistrationService) Register(ctx context.Context, req *RegisterReques
 	}
 
 	// Hash password
-	hashedPassword, err := rs.hashPassword(req.Password)
+	hashedPassword, err := HashPassword(req.Password)
 	if err != nil {
-		return nil, fmt.Errorf("failed to hash password: %w", err)
+		return nil, internalErrorf("failed to hash password: %w", err)
+	}
+
+	sessionToken, err := rs.tokens.Token(32)
+	if err != nil {
+		return nil, internalErrorf("failed to generate session token: %w", err)
 	}
 
 	// Create user
@@ -61,14 +101,34 @@ func (rs *RegistrationService) Register(ctx context.Context, req *RegisterReques
 		IsAdmin:   false,
 	}
 
-	if err := rs.userRepo.CreateUser(ctx, user); err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
-	}
+	var token string
+	err = rs.txManager.InTransaction(ctx, func(tx *gorm.DB) error {
+		if err := rs.userRepo.WithTx(tx).RegisterUser(ctx, user); err != nil {
+			return internalErrorf("failed to create user: %w", err)
+		}
 
-	// Generate JWT token
-	token, err := rs.jwtManager.GenerateToken(user.ID, user.Username, user.Email, user.IsAdmin)
+		// The repository sets the session's expiry from the session policy
+		session := &models.Session{
+			UserID:    user.ID,
+			Token:     sessionToken,
+			IPAddress: ipAddress,
+			UserAgent: userAgent,
+			IsActive:  true,
+		}
+		if err := rs.sessionRepo.WithTx(tx).CreateSession(ctx, session); err != nil {
+			return internalErrorf("failed to create session: %w", err)
+		}
+
+		// Generate JWT token bound to the session
+		var err error
+		token, err = rs.jwtManager.GenerateTokenForSession(user.ID, user.Username, user.Email, user.IsAdmin, sessionToken)
+		if err != nil {
+			return internalErrorf("failed to generate token: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate token: %w", err)
+		return nil, err
 	}
 
 	// Get token expiration
@@ -78,11 +138,6 @@ func (rs *RegistrationService) Register(ctx context.Context, req *RegisterReques
 		Token:     token,
 		User:      user,
 		ExpiresAt: claims.ExpiresAt.Time,
+		SessionID: sessionToken,
 	}, nil
 }
-
-// hashPassword hashes a password using bcrypt
-func (rs *RegistrationService) hashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(bytes), err
-}
@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-server/internal/database/dbtest"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/usernames"
+
+	"gorm.io/gorm"
+)
+
+func newRegistrationService(t *testing.T, tables ...interface{}) (*RegistrationService, *gorm.DB) {
+	t.Helper()
+	db := dbtest.Open(t, tables...)
+	service := NewRegistrationService(repositories.NewUserRepository(db), nil, repositories.NewSessionRepository(db),
+		NewJWTManager("test-secret", time.Hour), repositories.NewTxManager(db))
+	return service, db
+}
+
+func TestRegistrationService_UsernamePolicy(t *testing.T) {
+	service, db := newRegistrationService(t, &models.User{}, &models.OutboxEvent{}, &models.Session{})
+	service.WithUsernamePolicy(usernames.Default())
+
+	ctx := context.Background()
+	_, err := service.Register(ctx, &RegisterRequest{Email: "a@example.com", Username: "adm1n", Password: "secret123"}, "", "")
+	var violation *usernames.Violation
+	if !errors.As(err, &violation) || violation.Reason != usernames.ReasonImpersonation {
+		t.Fatalf("Expected an impersonation violation, got %v", err)
+	}
+	var count int64
+	db.Model(&models.User{}).Count(&count)
+	if count != 0 {
+		t.Errorf("A refused username should not create an account, got %d users", count)
+	}
+
+	if _, err := service.Register(ctx, &RegisterRequest{Email: "a@example.com", Username: "alice", Password: "secret123"}, "", ""); err != nil {
+		t.Errorf("An allowed username should register, got %v", err)
+	}
+}
+
+func TestRegistrationService_CreatesSession(t *testing.T) {
+	service, db := newRegistrationService(t, &models.User{}, &models.OutboxEvent{}, &models.Session{})
+
+	resp, err := service.Register(context.Background(),
+		&RegisterRequest{Email: "a@example.com", Username: "alice", Password: "secret123"}, "203.0.113.7", "test-agent")
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	var session models.Session
+	if err := db.Where("token = ?", resp.SessionID).First(&session).Error; err != nil {
+		t.Fatalf("Expected the registration to create a session, got %v", err)
+	}
+	if session.UserID != resp.User.ID || session.IPAddress != "203.0.113.7" || session.UserAgent != "test-agent" {
+		t.Errorf("Expected the session to belong to the new user, got %+v", session)
+	}
+	claims, err := service.jwtManager.ValidateToken(resp.Token)
+	if err != nil || claims.SessionID != resp.SessionID {
+		t.Errorf("Expected the token to be bound to the session, got %+v, %v", claims, err)
+	}
+}
+
+func TestRegistrationService_RollsBackWithoutSession(t *testing.T) {
+	// Without a sessions table the session write fails after the user's
+	service, db := newRegistrationService(t, &models.User{}, &models.OutboxEvent{})
+
+	_, err := service.Register(context.Background(),
+		&RegisterRequest{Email: "a@example.com", Username: "alice", Password: "secret123"}, "", "")
+	if err == nil {
+		t.Fatal("Expected the registration to fail")
+	}
+	var users, events int64
+	db.Model(&models.User{}).Count(&users)
+	db.Model(&models.OutboxEvent{}).Count(&events)
+	if users != 0 || events != 0 {
+		t.Errorf("Expected the user and its event to be rolled back, got %d users and %d events", users, events)
+	}
+}
@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+
+	"go-server/internal/reporting"
+)
+
+// internalError marks a failure of the server itself, such as a database or
+// token-signing error, as opposed to a rejected login or token. Only these
+// are sent to the error tracker.
+type internalError struct {
+	err error
+}
+
+func (e *internalError) Error() string { return e.err.Error() }
+
+func (e *internalError) Unwrap() error { return e.err }
+
+// internalErrorf formats an error like fmt.Errorf and marks it as internal
+func internalErrorf(format string, args ...any) error {
+	return &internalError{err: fmt.Errorf(format, args...)}
+}
+
+// report sends internal failures of an auth operation to the reporter
+func (as *AuthService) report(ctx context.Context, operation string, userID uint, err error) {
+	var internal *internalError
+	if as.reporter == nil || !stderrors.As(err, &internal) {
+		return
+	}
+	as.reporter.Report(ctx, reporting.Report{
+		Err:    err,
+		UserID: userID,
+		Tags:   map[string]string{"component": "auth", "operation": operation},
+	})
+}
@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"go-server/internal/reporting"
+)
+
+func TestAuthService_ReportsOnlyInternalFailures(t *testing.T) {
+	var reports []reporting.Report
+	as := (&AuthService{}).WithReporter(reporting.ReporterFunc(func(ctx context.Context, report reporting.Report) {
+		reports = append(reports, report)
+	}))
+
+	cause := errors.New("connection refused")
+	as.report(context.Background(), "login", 0, fmt.Errorf("invalid credentials"))
+	as.report(context.Background(), "login", 0, nil)
+	as.report(context.Background(), "logout", 9, internalErrorf("failed to delete session: %w", cause))
+
+	if len(reports) != 1 {
+		t.Fatalf("Expected 1 report, got %d", len(reports))
+	}
+	report := reports[0]
+	if !errors.Is(report.Err, cause) || report.UserID != 9 || report.Tags["operation"] != "logout" {
+		t.Errorf("Report = %+v", report)
+	}
+}
@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/logger"
+)
+
+// KeyRotator generates a new signing key every interval. Each key is added
+// lead before it starts signing, so verifiers fetching the JWKS pick it up
+// before they see tokens signed with it.
+//
+// Generated keys live only in this process. Run it on deployments with a
+// single replica, or where replicas verify against each other's JWKS;
+// otherwise rotate a shared key through the secrets watcher instead.
+type KeyRotator struct {
+	manager   *JWTManager
+	algorithm string
+	interval  time.Duration
+	lead      time.Duration
+	logger    logger.Logger
+	clock     clock.Clock
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewKeyRotator creates a rotator adding algorithm keys to manager
+func NewKeyRotator(manager *JWTManager, algorithm string, interval, lead time.Duration, logger logger.Logger) *KeyRotator {
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	if lead < 0 || lead >= interval {
+		lead = interval / 4
+	}
+	return &KeyRotator{
+		manager:   manager,
+		algorithm: algorithm,
+		interval:  interval,
+		lead:      lead,
+		logger:    logger,
+		clock:     clock.New(),
+	}
+}
+
+// WithClock sets the time source for the rotation schedule
+func (kr *KeyRotator) WithClock(c clock.Clock) *KeyRotator {
+	kr.clock = clock.OrDefault(c)
+	return kr
+}
+
+// Rotate generates a key that starts signing after the lead time
+func (kr *KeyRotator) Rotate() (*SigningKey, error) {
+	key, err := GenerateKey(kr.algorithm)
+	if err != nil {
+		return nil, err
+	}
+	key.NotBefore = kr.clock.Now().Add(kr.lead)
+	kr.manager.AddKey(key)
+	kr.logger.Info("Added JWT signing key %s, signing from %s", key.ID, key.NotBefore.Format(time.RFC3339))
+	return key, nil
+}
+
+// Start rotates the key every interval
+func (kr *KeyRotator) Start(ctx context.Context) {
+	ctx, kr.cancel = context.WithCancel(ctx)
+
+	kr.wg.Add(1)
+	go func() {
+		defer kr.wg.Done()
+		ticker := time.NewTicker(kr.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := kr.Rotate(); err != nil {
+					kr.logger.Error("Failed to rotate JWT signing key: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop halts the rotation schedule
+func (kr *KeyRotator) Stop() {
+	if kr.cancel != nil {
+		kr.cancel()
+	}
+	kr.wg.Wait()
+}
@@ -3,8 +3,12 @@ package auth
 import (
 	"context"
 
+	"go-server/internal/clock"
 	"go-server/internal/database/models"
 	"go-server/internal/database/repositories"
+	"go-server/internal/idgen"
+	"go-server/internal/reporting"
+	"go-server/internal/usernames"
 )
 
 // AuthService handles authentication operations
@@ -12,6 +16,7 @@ type AuthService struct {
 	loginService      *LoginService
 	registrationService *RegistrationService
 	sessionService    *SessionService
+	reporter          reporting.ErrorReporter
 }
 
 // NewAuthService creates a new authentication service
@@ -20,37 +25,89 @@ func NewAuthService(
 	cacheRepo *repositories.CacheRepository,
 	sessionRepo *repositories.SessionRepository,
 	jwtManager *JWTManager,
+	txManager *repositories.TxManager,
 ) *AuthService {
 	return &AuthService{
 		loginService: NewLoginService(userRepo, cacheRepo, sessionRepo, jwtManager),
-		registrationService: NewRegistrationService(userRepo, cacheRepo, jwtManager),
+		registrationService: NewRegistrationService(userRepo, cacheRepo, sessionRepo, jwtManager, txManager),
 		sessionService: NewSessionService(userRepo, cacheRepo, sessionRepo, jwtManager),
 	}
 }
 
+// WithClock sets the time source used by the login flow and session checks
+func (as *AuthService) WithClock(c clock.Clock) *AuthService {
+	as.loginService.WithClock(c)
+	as.sessionService.WithClock(c)
+	return as
+}
+
+// WithSessionNotifier sets the notifier told about revoked sessions
+func (as *AuthService) WithSessionNotifier(notifier SessionNotifier) *AuthService {
+	as.sessionService.WithNotifier(notifier)
+	return as
+}
+
+// WithTokenSource sets the source used to generate session tokens
+func (as *AuthService) WithTokenSource(tokens idgen.TokenSource) *AuthService {
+	as.loginService.WithTokenSource(tokens)
+	as.registrationService.WithTokenSource(tokens)
+	return as
+}
+
+// WithUsernamePolicy sets the policy registration checks usernames against
+func (as *AuthService) WithUsernamePolicy(policy *usernames.Policy) *AuthService {
+	as.registrationService.WithUsernamePolicy(policy)
+	return as
+}
+
+// WithReporter sets the reporter told about internal failures, such as
+// database or token-signing errors; rejected credentials are not reported
+func (as *AuthService) WithReporter(reporter reporting.ErrorReporter) *AuthService {
+	as.reporter = reporter
+	return as
+}
+
 // Login authenticates a user and returns an auth response
 func (as *AuthService) Login(ctx context.Context, req *LoginRequest, ipAddress, userAgent string) (*AuthResponse, error) {
-	return as.loginService.Login(ctx, req, ipAddress, userAgent)
+	resp, err := as.loginService.Login(ctx, req, ipAddress, userAgent)
+	as.report(ctx, "login", 0, err)
+	return resp, err
 }
 
-// Register creates a new user account
-func (as *AuthService) Register(ctx context.Context, req *RegisterRequest) (*AuthResponse, error) {
-	return as.registrationService.Register(ctx, req)
+// Register creates a new user account and signs it in
+func (as *AuthService) Register(ctx context.Context, req *RegisterRequest, ipAddress, userAgent string) (*AuthResponse, error) {
+	resp, err := as.registrationService.Register(ctx, req, ipAddress, userAgent)
+	as.report(ctx, "register", 0, err)
+	return resp, err
 }
 
 // Logout invalidates a user session
 func (as *AuthService) Logout(ctx context.Context, userID uint, sessionID string) error {
-	return as.sessionService.Logout(ctx, userID, sessionID)
+	err := as.sessionService.Logout(ctx, userID, sessionID)
+	as.report(ctx, "logout", userID, err)
+	return err
 }
 
 // ValidateToken validates a JWT token and returns the user
 func (as *AuthService) ValidateToken(ctx context.Context, tokenString string) (*models.User, error) {
-	return as.sessionService.ValidateToken(ctx, tokenString)
+	user, err := as.sessionService.ValidateToken(ctx, tokenString)
+	as.report(ctx, "validate_token", 0, err)
+	return user, err
+}
+
+// Authenticate validates a JWT token and returns the user and the login
+// session it is bound to, if any
+func (as *AuthService) Authenticate(ctx context.Context, tokenString string) (*models.User, string, error) {
+	user, sessionID, err := as.sessionService.Authenticate(ctx, tokenString)
+	as.report(ctx, "validate_token", 0, err)
+	return user, sessionID, err
 }
 
 // RefreshToken refreshes a JWT token
 func (as *AuthService) RefreshToken(ctx context.Context, tokenString string) (*AuthResponse, error) {
-	return as.sessionService.RefreshToken(ctx, tokenString)
+	resp, err := as.sessionService.RefreshToken(ctx, tokenString)
+	as.report(ctx, "refresh_token", 0, err)
+	return resp, err
 }
 
 // CleanupExpiredSessions removes expired sessions
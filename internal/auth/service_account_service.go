@@ -0,0 +1,246 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/idgen"
+)
+
+// APIKeyPrefix marks service account API keys so they are recognisable in
+// logs and by secret scanners
+const APIKeyPrefix = "gsa_"
+
+// DefaultServiceAccountTokenDuration is how long scoped tokens are valid
+const DefaultServiceAccountTokenDuration = 15 * time.Minute
+
+// Service account errors
+var (
+	ErrInvalidCredential      = errors.New("invalid service account credential")
+	ErrServiceAccountInactive = errors.New("service account is disabled")
+	ErrScopeNotGranted        = errors.New("scope not granted to service account")
+)
+
+var scopePattern = regexp.MustCompile(`^[a-z][a-z0-9_.-]*(:[a-z0-9_.-]+)*$`)
+
+// ValidScope checks that a scope is well formed, e.g. posts:read
+func ValidScope(scope string) bool {
+	return len(scope) <= 64 && scopePattern.MatchString(scope)
+}
+
+// ServiceAccountToken is a short-lived token limited to some of an account's scopes
+type ServiceAccountToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Scopes    []string  `json:"scopes"`
+}
+
+// ServiceAccountService issues and checks service account credentials.
+// Service accounts never log in: they authenticate with an API key or a
+// client certificate and exchange it for a scoped token.
+type ServiceAccountService struct {
+	repo          *repositories.ServiceAccountRepository
+	jwtManager    *JWTManager
+	clock         clock.Clock
+	tokens        idgen.TokenSource
+	tokenDuration time.Duration
+}
+
+// NewServiceAccountService creates a new service account service
+func NewServiceAccountService(repo *repositories.ServiceAccountRepository, jwtManager *JWTManager) *ServiceAccountService {
+	return &ServiceAccountService{
+		repo:          repo,
+		jwtManager:    jwtManager,
+		clock:         clock.New(),
+		tokens:        idgen.Default,
+		tokenDuration: DefaultServiceAccountTokenDuration,
+	}
+}
+
+// WithClock sets the time source used for expiry and usage times
+func (ss *ServiceAccountService) WithClock(c clock.Clock) *ServiceAccountService {
+	ss.clock = clock.OrDefault(c)
+	return ss
+}
+
+// WithTokenSource sets the source used to generate API keys
+func (ss *ServiceAccountService) WithTokenSource(tokens idgen.TokenSource) *ServiceAccountService {
+	ss.tokens = tokens
+	return ss
+}
+
+// WithTokenDuration sets how long scoped tokens are valid
+func (ss *ServiceAccountService) WithTokenDuration(d time.Duration) *ServiceAccountService {
+	if d > 0 {
+		ss.tokenDuration = d
+	}
+	return ss
+}
+
+// CreateAPIKey creates a key for an account. The key itself is returned only
+// here; just its hash is stored.
+func (ss *ServiceAccountService) CreateAPIKey(ctx context.Context, account *models.ServiceAccount, expiresAt *time.Time) (string, *models.ServiceAccountCredential, error) {
+	secret, err := ss.tokens.Token(32)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+	key := APIKeyPrefix + secret
+
+	credential := &models.ServiceAccountCredential{
+		ServiceAccountID: account.ID,
+		Kind:             models.CredentialKindKey,
+		Hash:             hashCredential([]byte(key)),
+		Hint:             key[:len(APIKeyPrefix)+8],
+		ExpiresAt:        expiresAt,
+		CreatedAt:        ss.clock.Now(),
+	}
+	if err := ss.repo.CreateCredential(ctx, credential); err != nil {
+		return "", nil, fmt.Errorf("failed to store API key: %w", err)
+	}
+	return key, credential, nil
+}
+
+// RegisterCertificate registers a PEM client certificate for an account. The
+// certificate is pinned by fingerprint and expires with it.
+func (ss *ServiceAccountService) RegisterCertificate(ctx context.Context, account *models.ServiceAccount, certPEM []byte) (*models.ServiceAccountCredential, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("certificate must be PEM encoded")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate: %w", err)
+	}
+	now := ss.clock.Now()
+	if !now.Before(cert.NotAfter) {
+		return nil, fmt.Errorf("certificate has expired")
+	}
+
+	notAfter := cert.NotAfter
+	credential := &models.ServiceAccountCredential{
+		ServiceAccountID: account.ID,
+		Kind:             models.CredentialKindCertificate,
+		Hash:             hashCredential(cert.Raw),
+		Hint:             cert.Subject.String(),
+		ExpiresAt:        &notAfter,
+		CreatedAt:        now,
+	}
+	if err := ss.repo.CreateCredential(ctx, credential); err != nil {
+		return nil, fmt.Errorf("failed to store certificate: %w", err)
+	}
+	return credential, nil
+}
+
+// AuthenticateAPIKey returns the account an API key belongs to
+func (ss *ServiceAccountService) AuthenticateAPIKey(ctx context.Context, key string) (*models.ServiceAccount, error) {
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		return nil, ErrInvalidCredential
+	}
+	return ss.authenticate(ctx, hashCredential([]byte(key)))
+}
+
+// AuthenticateCertificate returns the account a client certificate belongs
+// to. The TLS handshake has already proven possession of its private key.
+func (ss *ServiceAccountService) AuthenticateCertificate(ctx context.Context, cert *x509.Certificate) (*models.ServiceAccount, error) {
+	return ss.authenticate(ctx, hashCredential(cert.Raw))
+}
+
+func (ss *ServiceAccountService) authenticate(ctx context.Context, hash string) (*models.ServiceAccount, error) {
+	credential, err := ss.repo.GetCredentialByHash(ctx, hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up credential: %w", err)
+	}
+	now := ss.clock.Now()
+	if credential == nil || !credential.Usable(now) {
+		return nil, ErrInvalidCredential
+	}
+
+	account, err := ss.activeAccount(ctx, credential.ServiceAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ss.repo.TouchServiceAccount(ctx, account.ID, credential.ID, now); err != nil {
+		return nil, fmt.Errorf("failed to record credential use: %w", err)
+	}
+	return account, nil
+}
+
+// activeAccount loads an account that has not been deleted or disabled
+func (ss *ServiceAccountService) activeAccount(ctx context.Context, id uint) (*models.ServiceAccount, error) {
+	account, err := ss.repo.GetServiceAccountByID(ctx, id)
+	if err != nil {
+		return nil, ErrInvalidCredential
+	}
+	if account.DisabledAt != nil {
+		return nil, ErrServiceAccountInactive
+	}
+	return account, nil
+}
+
+// IssueToken issues a scoped token for an authenticated account. scopes must
+// be a subset of the account's; none requests all of them.
+func (ss *ServiceAccountService) IssueToken(account *models.ServiceAccount, scopes []string) (*ServiceAccountToken, error) {
+	if len(scopes) == 0 {
+		scopes = account.ScopeList()
+	}
+	for _, scope := range scopes {
+		if !account.HasScope(scope) {
+			return nil, fmt.Errorf("%w: %s", ErrScopeNotGranted, scope)
+		}
+	}
+
+	expiresAt := ss.clock.Now().Add(ss.tokenDuration)
+	token, err := ss.jwtManager.GenerateServiceAccountToken(account.ID, account.OrganizationID, account.Name, scopes, ss.tokenDuration)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	return &ServiceAccountToken{Token: token, ExpiresAt: expiresAt, Scopes: scopes}, nil
+}
+
+// ValidateToken checks a scoped token and returns the principal it grants.
+// Disabling or deleting the account invalidates its outstanding tokens.
+func (ss *ServiceAccountService) ValidateToken(ctx context.Context, tokenString string) (*Principal, error) {
+	claims, err := ss.jwtManager.ValidateToken(tokenString)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+	if claims.ServiceAccountID == 0 {
+		return nil, fmt.Errorf("invalid token: not a service account token")
+	}
+	account, err := ss.activeAccount(ctx, claims.ServiceAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	principal := AccountPrincipal(account)
+	principal.Scopes = claims.Scopes
+	return principal, nil
+}
+
+// AccountPrincipal returns the principal for a service account with all of its scopes
+func AccountPrincipal(account *models.ServiceAccount) *Principal {
+	return &Principal{
+		Kind:           PrincipalServiceAccount,
+		ID:             account.ID,
+		Name:           account.Name,
+		OrganizationID: account.OrganizationID,
+		Scopes:         account.ScopeList(),
+	}
+}
+
+// hashCredential returns the stored form of a key or certificate
+func hashCredential(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,180 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/dbtest"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+)
+
+func newServiceAccountTest(t *testing.T) (*ServiceAccountService, *repositories.ServiceAccountRepository, *clock.Fake, *models.ServiceAccount) {
+	t.Helper()
+	db := dbtest.Open(t, &models.ServiceAccount{}, &models.ServiceAccountCredential{})
+
+	repo := repositories.NewServiceAccountRepository(db)
+	fake := clock.NewFake(time.Now())
+	svc := NewServiceAccountService(repo, NewJWTManager("test-secret", time.Hour).WithClock(fake)).WithClock(fake)
+
+	account := &models.ServiceAccount{OrganizationID: 1, Name: "deploy-bot", CreatedByID: 1}
+	account.SetScopes([]string{"posts:read", "posts:write"})
+	if err := repo.CreateServiceAccount(context.Background(), account); err != nil {
+		t.Fatalf("Failed to create service account: %v", err)
+	}
+	return svc, repo, fake, account
+}
+
+func TestServiceAccount_APIKeyExchangeForScopedToken(t *testing.T) {
+	svc, _, _, account := newServiceAccountTest(t)
+	ctx := context.Background()
+
+	key, credential, err := svc.CreateAPIKey(ctx, account, nil)
+	if err != nil {
+		t.Fatalf("CreateAPIKey failed: %v", err)
+	}
+	if credential.Hash == key || len(credential.Hint) >= len(key) {
+		t.Fatal("The key itself must not be stored")
+	}
+
+	authenticated, err := svc.AuthenticateAPIKey(ctx, key)
+	if err != nil {
+		t.Fatalf("AuthenticateAPIKey failed: %v", err)
+	}
+	if authenticated.ID != account.ID {
+		t.Fatalf("Authenticated wrong account %d", authenticated.ID)
+	}
+
+	token, err := svc.IssueToken(authenticated, []string{"posts:read"})
+	if err != nil {
+		t.Fatalf("IssueToken failed: %v", err)
+	}
+	principal, err := svc.ValidateToken(ctx, token.Token)
+	if err != nil {
+		t.Fatalf("ValidateToken failed: %v", err)
+	}
+	if !principal.HasScope("posts:read") || principal.HasScope("posts:write") {
+		t.Errorf("Token scopes = %v, want only posts:read", principal.Scopes)
+	}
+	if got := principal.AuditLabel(); got != fmt.Sprintf("service_account:%d", account.ID) {
+		t.Errorf("AuditLabel = %q", got)
+	}
+
+	if _, err := svc.IssueToken(authenticated, []string{"users:delete"}); !errors.Is(err, ErrScopeNotGranted) {
+		t.Errorf("Expected ErrScopeNotGranted, got %v", err)
+	}
+}
+
+func TestServiceAccount_RejectsRevokedExpiredAndWrongKeys(t *testing.T) {
+	svc, repo, fake, account := newServiceAccountTest(t)
+	ctx := context.Background()
+
+	if _, err := svc.AuthenticateAPIKey(ctx, APIKeyPrefix+"not-a-key"); !errors.Is(err, ErrInvalidCredential) {
+		t.Errorf("Unknown key: expected ErrInvalidCredential, got %v", err)
+	}
+
+	expiresAt := fake.Now().Add(time.Hour)
+	expiring, _, err := svc.CreateAPIKey(ctx, account, &expiresAt)
+	if err != nil {
+		t.Fatalf("CreateAPIKey failed: %v", err)
+	}
+	fake.Advance(2 * time.Hour)
+	if _, err := svc.AuthenticateAPIKey(ctx, expiring); !errors.Is(err, ErrInvalidCredential) {
+		t.Errorf("Expired key: expected ErrInvalidCredential, got %v", err)
+	}
+
+	revoked, credential, err := svc.CreateAPIKey(ctx, account, nil)
+	if err != nil {
+		t.Fatalf("CreateAPIKey failed: %v", err)
+	}
+	if ok, err := repo.RevokeCredential(ctx, account.ID, credential.ID, fake.Now()); err != nil || !ok {
+		t.Fatalf("RevokeCredential = %v, %v", ok, err)
+	}
+	if _, err := svc.AuthenticateAPIKey(ctx, revoked); !errors.Is(err, ErrInvalidCredential) {
+		t.Errorf("Revoked key: expected ErrInvalidCredential, got %v", err)
+	}
+}
+
+func TestServiceAccount_DisablingInvalidatesTokens(t *testing.T) {
+	svc, repo, fake, account := newServiceAccountTest(t)
+	ctx := context.Background()
+
+	token, err := svc.IssueToken(account, nil)
+	if err != nil {
+		t.Fatalf("IssueToken failed: %v", err)
+	}
+
+	disabledAt := fake.Now()
+	account.DisabledAt = &disabledAt
+	if err := repo.UpdateServiceAccount(ctx, account); err != nil {
+		t.Fatalf("UpdateServiceAccount failed: %v", err)
+	}
+	if _, err := svc.ValidateToken(ctx, token.Token); !errors.Is(err, ErrServiceAccountInactive) {
+		t.Errorf("Expected ErrServiceAccountInactive, got %v", err)
+	}
+}
+
+func TestServiceAccount_CertificateAuthentication(t *testing.T) {
+	svc, _, fake, account := newServiceAccountTest(t)
+	ctx := context.Background()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "deploy-bot"},
+		NotBefore:    fake.Now().Add(-time.Hour),
+		NotAfter:     fake.Now().Add(24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+
+	if _, err := svc.AuthenticateCertificate(ctx, cert); !errors.Is(err, ErrInvalidCredential) {
+		t.Errorf("Unregistered certificate: expected ErrInvalidCredential, got %v", err)
+	}
+
+	credential, err := svc.RegisterCertificate(ctx, account, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	if err != nil {
+		t.Fatalf("RegisterCertificate failed: %v", err)
+	}
+	if credential.Hint != "CN=deploy-bot" {
+		t.Errorf("Hint = %q, want CN=deploy-bot", credential.Hint)
+	}
+	if authenticated, err := svc.AuthenticateCertificate(ctx, cert); err != nil || authenticated.ID != account.ID {
+		t.Errorf("AuthenticateCertificate = %v, %v", authenticated, err)
+	}
+
+	fake.Advance(25 * time.Hour)
+	if _, err := svc.AuthenticateCertificate(ctx, cert); !errors.Is(err, ErrInvalidCredential) {
+		t.Errorf("Expired certificate: expected ErrInvalidCredential, got %v", err)
+	}
+}
+
+func TestSessionService_RejectsServiceAccountTokens(t *testing.T) {
+	jm := NewJWTManager("test-secret", time.Hour)
+	token, err := jm.GenerateServiceAccountToken(7, 1, "deploy-bot", []string{"posts:read"}, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	ss := NewSessionService(nil, nil, nil, jm)
+	if _, err := ss.ValidateToken(context.Background(), token); err == nil {
+		t.Error("Service account token must not authenticate a user")
+	}
+}
@@ -2,18 +2,40 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
+	"go-server/internal/clock"
 	"go-server/internal/database/models"
 	"go-server/internal/database/repositories"
+
+	"gorm.io/gorm"
 )
 
+// ErrSessionExpired is returned for a token whose login session has ended,
+// by logout, the idle timeout or the maximum lifetime; refreshing the token
+// does not help and the user must log in again
+var ErrSessionExpired = errors.New("session expired")
+
+// activityInterval is how stale a session's last activity may get before a
+// request records it, so busy clients do not write on every request
+const activityInterval = time.Minute
+
+// SessionNotifier is told when sessions are revoked so connected clients can react
+type SessionNotifier interface {
+	// SessionRevoked reports a revoked session; an empty sessionID means all of the user's sessions
+	SessionRevoked(userID uint, sessionID string)
+}
+
 // SessionService handles session management operations
 type SessionService struct {
 	userRepo    *repositories.UserRepository
 	cacheRepo   *repositories.CacheRepository
 	sessionRepo *repositories.SessionRepository
 	jwtManager  *JWTManager
+	notifier    SessionNotifier
+	clock       clock.Clock
 }
 
 // NewSessionService creates a new session service
@@ -28,14 +50,27 @@ func NewSessionService(
 		cacheRepo:   cacheRepo,
 		sessionRepo: sessionRepo,
 		jwtManager:  jwtManager,
+		clock:       clock.New(),
 	}
 }
 
+// WithClock sets the time source used to record session activity
+func (ss *SessionService) WithClock(c clock.Clock) *SessionService {
+	ss.clock = clock.OrDefault(c)
+	return ss
+}
+
+// WithNotifier sets the notifier told about revoked sessions
+func (ss *SessionService) WithNotifier(notifier SessionNotifier) *SessionService {
+	ss.notifier = notifier
+	return ss
+}
+
 // Logout invalidates a user session
 func (ss *SessionService) Logout(ctx context.Context, userID uint, sessionID string) error {
 	// Delete session from database
 	if err := ss.sessionRepo.DeleteSession(ctx, userID, sessionID); err != nil {
-		return fmt.Errorf("failed to delete session: %w", err)
+		return internalErrorf("failed to delete session: %w", err)
 	}
 
 	// Delete session from cache
@@ -44,43 +79,105 @@ func (ss *SessionService) Logout(ctx context.Context, userID uint, sessionID str
 		fmt.Printf("Warning: failed to delete session from cache: %v\n", err)
 	}
 
+	if ss.notifier != nil {
+		ss.notifier.SessionRevoked(userID, sessionID)
+	}
+
 	return nil
 }
 
 // ValidateToken validates a JWT token and returns the user
 func (ss *SessionService) ValidateToken(ctx context.Context, tokenString string) (*models.User, error) {
+	user, _, err := ss.Authenticate(ctx, tokenString)
+	return user, err
+}
+
+// Authenticate validates a JWT token and returns the user and the login
+// session the token is bound to, which is empty for tokens issued without
+// one. A bound token is only valid while its session is, and each use
+// extends the session's idle deadline.
+func (ss *SessionService) Authenticate(ctx context.Context, tokenString string) (*models.User, string, error) {
 	// Validate JWT token
 	claims, err := ss.jwtManager.ValidateToken(tokenString)
 	if err != nil {
-		return nil, fmt.Errorf("invalid token: %w", err)
+		return nil, "", fmt.Errorf("invalid token: %w", err)
+	}
+	if claims.ServiceAccountID != 0 {
+		return nil, "", fmt.Errorf("invalid token: service account tokens do not authenticate users")
 	}
 
 	// Get user from database
-	user, err := ss.userRepo.GetUserByID(ctx, claims.UserID)
+	user, err := ss.loadUser(ctx, claims.UserID)
 	if err != nil {
-		return nil, fmt.Errorf("user not found: %w", err)
+		return nil, "", fmt.Errorf("user not found: %w", err)
+	}
+
+	if claims.SessionID != "" {
+		if err := ss.checkSession(ctx, user.ID, claims.SessionID); err != nil {
+			return nil, "", err
+		}
 	}
 
 	// Check if user is still active
 	if !user.IsActive {
-		return nil, fmt.Errorf("user account is deactivated")
+		return nil, "", fmt.Errorf("user account is deactivated")
 	}
 
-	return user, nil
+	return user, claims.SessionID, nil
+}
+
+// loadUser loads a token's user. Tokens issued to an account since merged
+// into another resolve to the account it was merged into, whose sessions
+// the merge took over.
+func (ss *SessionService) loadUser(ctx context.Context, id uint) (*models.User, error) {
+	user, err := ss.userRepo.GetUserByID(ctx, id)
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, err
+	}
+	targetID, ok, aliasErr := ss.userRepo.ResolveUserAlias(ctx, id)
+	if aliasErr != nil || !ok {
+		return nil, err
+	}
+	return ss.userRepo.GetUserByID(ctx, targetID)
+}
+
+// checkSession confirms a token's session is still live and records the
+// activity
+func (ss *SessionService) checkSession(ctx context.Context, userID uint, sessionID string) error {
+	session, err := ss.sessionRepo.GetSessionByToken(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSessionExpired
+		}
+		return internalErrorf("failed to load session: %w", err)
+	}
+	if session.UserID != userID {
+		return ErrSessionExpired
+	}
+
+	now := ss.clock.Now()
+	if session.LastActivityAt == nil || now.Sub(*session.LastActivityAt) >= activityInterval {
+		if err := ss.sessionRepo.UpdateSessionLastActivity(ctx, sessionID); err != nil {
+			// Log error but don't fail the request
+			fmt.Printf("Warning: failed to record session activity: %v\n", err)
+		}
+	}
+	return nil
 }
 
 // RefreshToken refreshes a JWT token
 func (ss *SessionService) RefreshToken(ctx context.Context, tokenString string) (*AuthResponse, error) {
 	// Validate current token
-	user, err := ss.ValidateToken(ctx, tokenString)
+	user, sessionID, err := ss.Authenticate(ctx, tokenString)
 	if err != nil {
 		return nil, err
 	}
 
-	// Generate new token
-	newToken, err := ss.jwtManager.GenerateToken(user.ID, user.Username, user.Email, user.IsAdmin)
+	// Generate new token, still bound to the session so refreshing cannot
+	// outlive it
+	newToken, err := ss.jwtManager.GenerateTokenForSession(user.ID, user.Username, user.Email, user.IsAdmin, sessionID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate new token: %w", err)
+		return nil, internalErrorf("failed to generate new token: %w", err)
 	}
 
 	// Get new token expiration
@@ -90,6 +187,7 @@ func (ss *SessionService) RefreshToken(ctx context.Context, tokenString string)
 		Token:     newToken,
 		User:      user,
 		ExpiresAt: claims.ExpiresAt.Time,
+		SessionID: sessionID,
 	}, nil
 }
 
@@ -105,5 +203,11 @@ func (ss *SessionService) GetUserSessions(ctx context.Context, userID uint) ([]m
 
 // DeleteAllUserSessions deletes all sessions for a user
 func (ss *SessionService) DeleteAllUserSessions(ctx context.Context, userID uint) error {
-	return ss.sessionRepo.DeleteUserSessions(ctx, userID)
+	if err := ss.sessionRepo.DeleteUserSessions(ctx, userID); err != nil {
+		return err
+	}
+	if ss.notifier != nil {
+		ss.notifier.SessionRevoked(userID, "")
+	}
+	return nil
 }
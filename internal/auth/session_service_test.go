@@ -0,0 +1,207 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database"
+	"go-server/internal/database/dbtest"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+)
+
+type testSessions struct {
+	service *SessionService
+	repo    *repositories.SessionRepository
+	jwt     *JWTManager
+	clock   *clock.Fake
+	user    *models.User
+}
+
+func newTestSessions(t *testing.T, policy models.SessionPolicy) *testSessions {
+	t.Helper()
+	db := dbtest.Open(t, &models.User{}, &models.Session{})
+
+	fake := clock.NewFake(time.Now())
+	user := &models.User{Username: "sessions", Email: "sessions@example.com", Password: "x", IsActive: true}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	repo := repositories.NewSessionRepository(db).WithClock(fake).WithPolicy(policy)
+	jm := NewJWTManager("test-secret", 24*time.Hour).WithClock(fake)
+	return &testSessions{
+		service: NewSessionService(repositories.NewUserRepository(db), nil, repo, jm).WithClock(fake),
+		repo:    repo,
+		jwt:     jm,
+		clock:   fake,
+		user:    user,
+	}
+}
+
+// login creates a session the way LoginService does and returns its token
+func (ts *testSessions) login(t *testing.T, sessionID string) string {
+	t.Helper()
+	session := &models.Session{UserID: ts.user.ID, Token: sessionID, IsActive: true}
+	if err := ts.repo.CreateSession(context.Background(), session); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	token, err := ts.jwt.GenerateTokenForSession(ts.user.ID, ts.user.Username, ts.user.Email, false, sessionID)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	return token
+}
+
+func TestSessionPolicy_ExpiresAt(t *testing.T) {
+	created := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	policy := models.SessionPolicy{IdleTimeout: 30 * time.Minute, MaxLifetime: 8 * time.Hour}
+
+	if got := policy.ExpiresAt(created, created.Add(time.Hour)); !got.Equal(created.Add(90 * time.Minute)) {
+		t.Errorf("Activity should slide the expiry, got %s", got)
+	}
+	if got := policy.ExpiresAt(created, created.Add(7*time.Hour+45*time.Minute)); !got.Equal(created.Add(8 * time.Hour)) {
+		t.Errorf("Expiry should be capped at the maximum lifetime, got %s", got)
+	}
+	if got := (models.SessionPolicy{MaxLifetime: 8 * time.Hour}).ExpiresAt(created, created.Add(time.Hour)); !got.Equal(created.Add(8 * time.Hour)) {
+		t.Errorf("Without an idle timeout the session should last its lifetime, got %s", got)
+	}
+}
+
+func TestSessionService_IdleTimeout(t *testing.T) {
+	ts := newTestSessions(t, models.SessionPolicy{IdleTimeout: 30 * time.Minute, MaxLifetime: 8 * time.Hour})
+	ctx := context.Background()
+	token := ts.login(t, "idle-session")
+
+	// Requests every 20 minutes keep the session alive well past the idle timeout
+	for i := 0; i < 4; i++ {
+		ts.clock.Advance(20 * time.Minute)
+		if _, sessionID, err := ts.service.Authenticate(ctx, token); err != nil || sessionID != "idle-session" {
+			t.Fatalf("Active session rejected after %d requests: %q, %v", i, sessionID, err)
+		}
+	}
+
+	ts.clock.Advance(31 * time.Minute)
+	if _, _, err := ts.service.Authenticate(ctx, token); !errors.Is(err, ErrSessionExpired) {
+		t.Errorf("Expected ErrSessionExpired after idling, got %v", err)
+	}
+	if _, err := ts.service.RefreshToken(ctx, token); !errors.Is(err, ErrSessionExpired) {
+		t.Errorf("Refreshing must not revive an expired session, got %v", err)
+	}
+}
+
+func TestSessionService_MaxLifetime(t *testing.T) {
+	ts := newTestSessions(t, models.SessionPolicy{IdleTimeout: 30 * time.Minute, MaxLifetime: 2 * time.Hour})
+	ctx := context.Background()
+	token := ts.login(t, "long-session")
+
+	for elapsed := 20 * time.Minute; elapsed < 2*time.Hour; elapsed += 20 * time.Minute {
+		ts.clock.Advance(20 * time.Minute)
+		if _, _, err := ts.service.Authenticate(ctx, token); err != nil {
+			t.Fatalf("Session rejected after %s: %v", elapsed, err)
+		}
+	}
+	session, err := ts.repo.GetSessionByToken(ctx, "long-session")
+	if err != nil {
+		t.Fatalf("Failed to load session: %v", err)
+	}
+	if want := session.CreatedAt.Add(2 * time.Hour); !session.ExpiresAt.Equal(want) {
+		t.Errorf("Expected expiry capped at %s, got %s", want, session.ExpiresAt)
+	}
+
+	ts.clock.Advance(20 * time.Minute)
+	if _, _, err := ts.service.Authenticate(ctx, token); !errors.Is(err, ErrSessionExpired) {
+		t.Errorf("Expected ErrSessionExpired past the maximum lifetime, got %v", err)
+	}
+
+	// A shorter policy applies to sessions created under a longer one
+	ts.repo.WithPolicy(models.SessionPolicy{MaxLifetime: 10 * time.Minute})
+	other := ts.login(t, "other-session")
+	ts.repo.WithPolicy(models.SessionPolicy{MaxLifetime: 5 * time.Minute})
+	ts.clock.Advance(6 * time.Minute)
+	if _, _, err := ts.service.Authenticate(ctx, other); !errors.Is(err, ErrSessionExpired) {
+		t.Errorf("Expected the shortened lifetime to apply, got %v", err)
+	}
+}
+
+func TestSessionService_UnboundTokens(t *testing.T) {
+	ts := newTestSessions(t, models.DefaultSessionPolicy())
+	token, err := ts.jwt.GenerateToken(ts.user.ID, ts.user.Username, ts.user.Email, false)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	ts.clock.Advance(12 * time.Hour)
+	if _, sessionID, err := ts.service.Authenticate(context.Background(), token); err != nil || sessionID != "" {
+		t.Errorf("Tokens without a session should only be limited by their expiry: %q, %v", sessionID, err)
+	}
+}
+
+func TestSessionService_MergedAccount(t *testing.T) {
+	db := dbtest.Open(t, database.Models()...)
+	ctx := context.Background()
+	users := repositories.NewUserRepository(db)
+	sessions := repositories.NewSessionRepository(db)
+	jm := NewJWTManager("test-secret", time.Hour)
+	service := NewSessionService(users, nil, sessions, jm)
+
+	source := &models.User{Username: "oauth_dup", Email: "dup@example.com", Password: "x", FirstName: "Ada", Role: "moderator", IsActive: true}
+	target := &models.User{Username: "ada", Email: "ada@example.com", Password: "x", IsActive: true}
+	for _, user := range []*models.User{source, target} {
+		if err := db.Create(user).Error; err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+	post := &models.Post{Title: "Hello", Content: "World", AuthorID: source.ID}
+	if err := db.Create(post).Error; err != nil {
+		t.Fatalf("Failed to create post: %v", err)
+	}
+	if err := sessions.CreateSession(ctx, &models.Session{UserID: source.ID, Token: "source-session", IsActive: true}); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	token, err := jm.GenerateTokenForSession(source.ID, source.Username, source.Email, false, "source-session")
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	if _, err := users.MergeUsers(ctx, target.ID, target.ID, nil, false); !errors.Is(err, repositories.ErrMergeSameUser) {
+		t.Errorf("Expected ErrMergeSameUser, got %v", err)
+	}
+
+	report, err := users.MergeUsers(ctx, source.ID, target.ID, &target.ID, true)
+	if err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+	if report.Moved["posts"] != 1 || report.Moved["sessions"] != 1 || report.DroppedRole != "moderator" || len(report.ProfileFields) != 1 {
+		t.Errorf("Unexpected dry-run report %+v", report)
+	}
+	var stored models.Post
+	db.First(&stored, post.ID)
+	if stored.AuthorID != source.ID {
+		t.Error("A dry run must not change anything")
+	}
+	if user, _, err := service.Authenticate(ctx, token); err != nil || user.ID != source.ID {
+		t.Fatalf("The source should still authenticate after a dry run: %v", err)
+	}
+
+	if _, err := users.MergeUsers(ctx, source.ID, target.ID, &target.ID, false); err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	db.First(&stored, post.ID)
+	if stored.AuthorID != target.ID {
+		t.Errorf("Posts should move to the target, got author %d", stored.AuthorID)
+	}
+	merged, err := users.GetUserByID(ctx, target.ID)
+	if err != nil || merged.FirstName != "Ada" || merged.Role != "" {
+		t.Errorf("The target should gain empty profile fields but not staff roles, got %+v, %v", merged, err)
+	}
+	if _, err := users.GetUserByID(ctx, source.ID); err == nil {
+		t.Error("The source account should be deleted")
+	}
+
+	user, sessionID, err := service.Authenticate(ctx, token)
+	if err != nil || user.ID != target.ID || sessionID != "source-session" {
+		t.Errorf("Tokens of the source should resolve to the target: %v, %q, %v", user, sessionID, err)
+	}
+}
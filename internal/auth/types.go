@@ -9,14 +9,14 @@ import (
 // LoginRequest represents a login request
 type LoginRequest struct {
 	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=6"`
+	Password string `json:"password" validate:"required,min=6" sanitize:"-"`
 }
 
 // RegisterRequest represents a registration request
 type RegisterRequest struct {
 	Email     string `json:"email" validate:"required,email"`
 	Username  string `json:"username" validate:"required,min=3,max=20"`
-	Password  string `json:"password" validate:"required,min=6"`
+	Password  string `json:"password" validate:"required,min=6" sanitize:"-"`
 	FirstName string `json:"first_name" validate:"max=50"`
 	LastName  string `json:"last_name" validate:"max=50"`
 }
@@ -36,8 +36,8 @@ type TokenRefreshRequest struct {
 
 // PasswordChangeRequest represents a password change request
 type PasswordChangeRequest struct {
-	CurrentPassword string `json:"current_password" validate:"required"`
-	NewPassword     string `json:"new_password" validate:"required,min=6"`
+	CurrentPassword string `json:"current_password" validate:"required" sanitize:"-"`
+	NewPassword     string `json:"new_password" validate:"required,min=6" sanitize:"-"`
 }
 
 // ProfileUpdateRequest represents a profile update request
@@ -0,0 +1,221 @@
+// Package bootreport collects what a server started with into a single
+// report: its build, configuration profile, listening addresses, the state
+// of each subsystem and of the database migrations. Once the server is
+// ready the report is logged as one JSON line and written to a file, so
+// orchestrators and operators read one record instead of piecing the
+// startup together from the log.
+package bootreport
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"go-server/internal/atomicfile"
+	"go-server/internal/clock"
+	"go-server/internal/logger"
+)
+
+// Status is the state a subsystem started in
+type Status string
+
+// Subsystem states
+const (
+	StatusEnabled  Status = "enabled"
+	StatusDisabled Status = "disabled"
+	// StatusDegraded subsystems started without something they use, e.g.
+	// the cache while Redis is unavailable
+	StatusDegraded Status = "degraded"
+)
+
+// Build describes the running binary
+type Build struct {
+	// Release is the configured release, e.g. "go-server@1.4.2"
+	Release   string `json:"release,omitempty"`
+	GoVersion string `json:"go_version,omitempty"`
+	// Revision, Time and Modified come from the version control
+	// information Go stamps into binaries built from a checkout
+	Revision string `json:"revision,omitempty"`
+	Time     string `json:"time,omitempty"`
+	Modified bool   `json:"modified,omitempty"`
+}
+
+// Profile describes the configuration the server loaded
+type Profile struct {
+	Environment string `json:"environment,omitempty"`
+	// ConfigFile is the config file read, if any
+	ConfigFile string `json:"config_file,omitempty"`
+	LogLevel   string `json:"log_level,omitempty"`
+	LogFormat  string `json:"log_format,omitempty"`
+}
+
+// Listener is an address the server accepts connections on
+type Listener struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+// Subsystem is the state a part of the server started in
+type Subsystem struct {
+	Name   string `json:"name"`
+	Status Status `json:"status"`
+	// Detail says what the subsystem is connected to or why it is not
+	// fully enabled
+	Detail string `json:"detail,omitempty"`
+}
+
+// Migrations describes how the database schema was brought up to date
+type Migrations struct {
+	// Mode is "automigrate" when the server created its tables itself and
+	// "external" when cmd/migrate applies the SQL migrations
+	Mode string `json:"mode"`
+	// Tables counts the tables AutoMigrate brought up to date
+	Tables int `json:"tables,omitempty"`
+	// Locked reports whether the migration lock was held
+	Locked   bool          `json:"locked"`
+	Duration time.Duration `json:"duration_ns,omitempty"`
+}
+
+// Report is what a server started with
+type Report struct {
+	StartedAt time.Time `json:"started_at"`
+	// ReadyAt is when the server started serving; zero until it is ready
+	ReadyAt    time.Time   `json:"ready_at,omitempty"`
+	Host       string      `json:"host,omitempty"`
+	PID        int         `json:"pid"`
+	Build      Build       `json:"build"`
+	Profile    Profile     `json:"profile"`
+	Listeners  []Listener  `json:"listeners"`
+	Subsystems []Subsystem `json:"subsystems"`
+	Migrations *Migrations `json:"migrations,omitempty"`
+}
+
+// Recorder collects the report while the server starts. Its methods are
+// safe for concurrent use, and do nothing on a nil Recorder, so components
+// record into one only if they were given it.
+type Recorder struct {
+	clock clock.Clock
+
+	mu     sync.Mutex
+	report Report
+}
+
+// NewRecorder starts a report for a server with profile, reading the build
+// from the binary. c may be nil to use the system clock.
+func NewRecorder(release string, profile Profile, c clock.Clock) *Recorder {
+	c = clock.OrDefault(c)
+	host, _ := os.Hostname()
+	build := ReadBuild()
+	build.Release = release
+	return &Recorder{
+		clock: c,
+		report: Report{
+			StartedAt:  c.Now(),
+			Host:       host,
+			PID:        os.Getpid(),
+			Build:      build,
+			Profile:    profile,
+			Listeners:  []Listener{},
+			Subsystems: []Subsystem{},
+		},
+	}
+}
+
+// ReadBuild reads the Go version and version control information stamped
+// into the binary; it is empty when the binary has none, e.g. under go test
+func ReadBuild() Build {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return Build{}
+	}
+	build := Build{GoVersion: info.GoVersion}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			build.Revision = setting.Value
+		case "vcs.time":
+			build.Time = setting.Value
+		case "vcs.modified":
+			build.Modified = setting.Value == "true"
+		}
+	}
+	return build
+}
+
+// Subsystem records the state a subsystem started in, replacing any
+// earlier record of it
+func (r *Recorder) Subsystem(name string, status Status, detail string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.report.Subsystems {
+		if r.report.Subsystems[i].Name == name {
+			r.report.Subsystems[i] = Subsystem{Name: name, Status: status, Detail: detail}
+			return
+		}
+	}
+	r.report.Subsystems = append(r.report.Subsystems, Subsystem{Name: name, Status: status, Detail: detail})
+}
+
+// Listen records an address the server accepts connections on
+func (r *Recorder) Listen(name, address string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.report.Listeners = append(r.report.Listeners, Listener{Name: name, Address: address})
+}
+
+// Migrated records how the database schema was brought up to date
+func (r *Recorder) Migrated(migrations Migrations) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.report.Migrations = &migrations
+}
+
+// Report returns a copy of the report so far
+func (r *Recorder) Report() Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	report := r.report
+	report.Listeners = append([]Listener{}, r.report.Listeners...)
+	report.Subsystems = append([]Subsystem{}, r.report.Subsystems...)
+	if r.report.Migrations != nil {
+		migrations := *r.report.Migrations
+		report.Migrations = &migrations
+	}
+	return report
+}
+
+// Ready marks the server ready, logs the report as a single line and
+// writes it to path, unless path is empty. A report that cannot be written
+// is logged and returned, but the server keeps running.
+func (r *Recorder) Ready(log logger.Logger, path string) (Report, error) {
+	r.mu.Lock()
+	r.report.ReadyAt = r.clock.Now()
+	r.mu.Unlock()
+
+	report := r.Report()
+	data, err := json.Marshal(report)
+	if err != nil {
+		return report, fmt.Errorf("failed to encode boot report: %w", err)
+	}
+	log.Info("Boot report %s", data)
+	if path == "" {
+		return report, nil
+	}
+	if err := atomicfile.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		log.Error("Failed to write boot report to %s: %v", path, err)
+		return report, fmt.Errorf("failed to write boot report: %w", err)
+	}
+	return report, nil
+}
@@ -0,0 +1,58 @@
+package bootreport
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/logger"
+)
+
+func TestRecorder_Ready(t *testing.T) {
+	fake := clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	recorder := NewRecorder("go-server@2.0.0", Profile{Environment: "production", LogLevel: "info"}, fake)
+	recorder.Listen("http", ":8080")
+	recorder.Subsystem("redis", StatusEnabled, "localhost:6379")
+	recorder.Subsystem("jobs", StatusDisabled, "")
+	recorder.Subsystem("redis", StatusDegraded, "connection refused")
+	recorder.Migrated(Migrations{Mode: "automigrate", Tables: 3, Locked: true})
+
+	fake.Advance(2 * time.Second)
+	path := filepath.Join(t.TempDir(), "boot.json")
+	report, err := recorder.Ready(logger.NewServerLogger(), path)
+	if err != nil {
+		t.Fatalf("Ready failed: %v", err)
+	}
+	if report.ReadyAt.Sub(report.StartedAt) != 2*time.Second || report.Build.Release != "go-server@2.0.0" || report.PID != os.Getpid() {
+		t.Errorf("Unexpected report %+v", report)
+	}
+	if len(report.Subsystems) != 2 || report.Subsystems[0] != (Subsystem{Name: "redis", Status: StatusDegraded, Detail: "connection refused"}) {
+		t.Errorf("Expected the later redis record to replace the first, got %+v", report.Subsystems)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("The report should be written: %v", err)
+	}
+	var written Report
+	if err := json.Unmarshal(data, &written); err != nil {
+		t.Fatalf("The report should be JSON: %v", err)
+	}
+	if written.Profile.Environment != "production" || len(written.Listeners) != 1 || written.Migrations == nil || !written.Migrations.Locked {
+		t.Errorf("Unexpected written report %s", data)
+	}
+
+	if _, err := recorder.Ready(logger.NewServerLogger(), filepath.Join(path, "missing", "boot.json")); err == nil {
+		t.Error("Expected an unwritable path to fail")
+	}
+}
+
+func TestRecorder_Nil(t *testing.T) {
+	var recorder *Recorder
+	recorder.Subsystem("redis", StatusEnabled, "")
+	recorder.Listen("http", ":8080")
+	recorder.Migrated(Migrations{Mode: "external"})
+}
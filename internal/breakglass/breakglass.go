@@ -0,0 +1,236 @@
+// Package breakglass grants emergency administrator access when normal
+// sign-in is unavailable, e.g. every admin account is locked out or the
+// identity provider is down. Tokens are signed offline with a root key by
+// cmd/breakglass and are only verified here against its public half, so no
+// secret that can mint them lives on the servers.
+//
+// Tokens expire on their own and may not outlive the configured maximum
+// TTL. Every request made with one is recorded, and all administrators are
+// notified the first time a token is used.
+package breakglass
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/cryptopolicy"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/logger"
+	"go-server/internal/notify"
+	"go-server/internal/rbac"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Header carries a break-glass token on a request
+const Header = "X-Break-Glass-Token"
+
+// NotificationType is the type of the alert sent to administrators
+const NotificationType = "security.break_glass"
+
+// Break-glass errors
+var (
+	ErrInvalidToken = errors.New("invalid break-glass token")
+	ErrTTLTooLong   = errors.New("break-glass token is valid for longer than allowed")
+	ErrRevoked      = errors.New("break-glass token has been revoked")
+)
+
+// Config holds break-glass configuration
+type Config struct {
+	// MaxTTL caps the lifetime of accepted tokens, however they were signed
+	MaxTTL time.Duration
+}
+
+// AdminStore lists the staff to alert
+type AdminStore interface {
+	ListStaff(ctx context.Context) ([]models.User, error)
+}
+
+// Notifier delivers alerts to administrators
+type Notifier interface {
+	Dispatch(ctx context.Context, userID uint, notification notify.Notification) (*models.NotificationDelivery, error)
+}
+
+// Access describes a request made with a break-glass token
+type Access struct {
+	Method    string
+	Path      string
+	IPAddress string
+	UserAgent string
+}
+
+// Service verifies break-glass tokens and audits their use
+type Service struct {
+	publicKey ed25519.PublicKey
+	repo      *repositories.BreakGlassRepository
+	config    Config
+	logger    logger.Logger
+	clock     clock.Clock
+	admins    AdminStore
+	notifier  Notifier
+}
+
+// NewService creates a new break-glass service trusting tokens signed by
+// the private half of publicKey
+func NewService(publicKey ed25519.PublicKey, repo *repositories.BreakGlassRepository, config Config, logger logger.Logger) *Service {
+	if config.MaxTTL <= 0 {
+		config.MaxTTL = 4 * time.Hour
+	}
+	return &Service{
+		publicKey: publicKey,
+		repo:      repo,
+		config:    config,
+		logger:    logger,
+		clock:     clock.New(),
+	}
+}
+
+// WithClock sets the time source used for expiry
+func (s *Service) WithClock(c clock.Clock) *Service {
+	s.clock = clock.OrDefault(c)
+	return s
+}
+
+// WithAlerts notifies every administrator in admins through notifier when a
+// token is first used
+func (s *Service) WithAlerts(admins AdminStore, notifier Notifier) *Service {
+	s.admins = admins
+	s.notifier = notifier
+	return s
+}
+
+// Verify checks a token's signature and lifetime without recording its use
+func (s *Service) Verify(token string) (*Claims, error) {
+	// Tokens are Ed25519 signed, which restricted crypto mode refuses
+	if err := cryptopolicy.Allow(cryptopolicy.Ed25519); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return s.publicKey, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(Issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithTimeFunc(s.clock.Now),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if claims.ID == "" || strings.TrimSpace(claims.Subject) == "" || strings.TrimSpace(claims.Reason) == "" {
+		return nil, fmt.Errorf("%w: missing token ID, operator or reason", ErrInvalidToken)
+	}
+	if claims.IssuedAt == nil || claims.ExpiresAt.Sub(claims.IssuedAt.Time) > s.config.MaxTTL {
+		return nil, ErrTTLTooLong
+	}
+	return claims, nil
+}
+
+// Authenticate verifies a token and records access as made with it. The
+// first use of a token alerts all administrators.
+func (s *Service) Authenticate(ctx context.Context, token string, access Access) (*models.BreakGlassGrant, error) {
+	claims, err := s.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	grant := &models.BreakGlassGrant{
+		TokenID:   claims.ID,
+		Operator:  truncate(claims.Subject, 100),
+		Reason:    truncate(claims.Reason, 500),
+		IssuedAt:  claims.IssuedAt.Time,
+		ExpiresAt: claims.ExpiresAt.Time,
+		CreatedAt: now,
+	}
+	record := &models.BreakGlassAccess{
+		Method:    access.Method,
+		Path:      truncate(access.Path, 500),
+		IPAddress: truncate(access.IPAddress, 45),
+		UserAgent: truncate(access.UserAgent, 255),
+		CreatedAt: now,
+	}
+	stored, first, err := s.repo.RecordAccess(ctx, grant, record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record break-glass access: %w", err)
+	}
+	if stored.IsRevoked() {
+		s.logger.Warn("Revoked break-glass grant %d presented by %s from %s", stored.ID, stored.Operator, access.IPAddress)
+		return nil, ErrRevoked
+	}
+
+	s.logger.Warn("BREAK-GLASS %s %s by %s (grant %d) from %s: %s",
+		access.Method, access.Path, stored.Operator, stored.ID, access.IPAddress, stored.Reason)
+	if first {
+		s.alert(ctx, stored, access)
+	}
+	return stored, nil
+}
+
+// Revoke refuses a grant's token from now on
+func (s *Service) Revoke(ctx context.Context, grantID uint, revokedBy *models.User) (bool, error) {
+	revoked, err := s.repo.RevokeGrant(ctx, grantID, revokedBy.ID, s.clock.Now())
+	if err == nil && revoked {
+		s.logger.Warn("Break-glass grant %d revoked by user %d", grantID, revokedBy.ID)
+	}
+	return revoked, err
+}
+
+// alert notifies every administrator that a grant was used for the first time
+func (s *Service) alert(ctx context.Context, grant *models.BreakGlassGrant, access Access) {
+	if s.admins == nil || s.notifier == nil {
+		return
+	}
+	staff, err := s.admins.ListStaff(ctx)
+	if err != nil {
+		s.logger.Error("Failed to list administrators for break-glass alert: %v", err)
+		return
+	}
+
+	notification := notify.Notification{
+		Type:  NotificationType,
+		Title: "Break-glass access used",
+		Body: fmt.Sprintf("%s used emergency administrator access from %s, valid until %s. Reason: %s",
+			grant.Operator, access.IPAddress, grant.ExpiresAt.UTC().Format(time.RFC3339), grant.Reason),
+		Data: map[string]string{
+			"grant_id":   fmt.Sprintf("%d", grant.ID),
+			"operator":   grant.Operator,
+			"expires_at": grant.ExpiresAt.UTC().Format(time.RFC3339),
+		},
+		Channel:   notify.ChannelEmail,
+		Reference: fmt.Sprintf("break_glass:%d", grant.ID),
+	}
+	for i := range staff {
+		if rbac.RoleOf(&staff[i]) != rbac.RoleAdmin {
+			continue
+		}
+		if _, err := s.notifier.Dispatch(ctx, staff[i].ID, notification); err != nil {
+			s.logger.Error("Failed to alert admin %d of break-glass grant %d: %v", staff[i].ID, grant.ID, err)
+		}
+	}
+}
+
+// User is the administrator a grant acts as. It has no database row, so its
+// ID is zero; audit records name the operator through its username.
+func User(grant *models.BreakGlassGrant) *models.User {
+	return &models.User{
+		Username: "break-glass:" + grant.Operator,
+		IsAdmin:  true,
+		Role:     rbac.RoleAdmin,
+		IsActive: true,
+	}
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
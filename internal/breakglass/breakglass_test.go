@@ -0,0 +1,167 @@
+package breakglass
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/dbtest"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/logger"
+	"go-server/internal/notify"
+	"go-server/internal/rbac"
+)
+
+type staticAdmins []models.User
+
+func (sa staticAdmins) ListStaff(ctx context.Context) ([]models.User, error) {
+	return sa, nil
+}
+
+type recordingNotifier struct {
+	alerted []uint
+}
+
+func (rn *recordingNotifier) Dispatch(ctx context.Context, userID uint, notification notify.Notification) (*models.NotificationDelivery, error) {
+	rn.alerted = append(rn.alerted, userID)
+	return &models.NotificationDelivery{}, nil
+}
+
+type fixture struct {
+	service  *Service
+	repo     *repositories.BreakGlassRepository
+	clock    *clock.Fake
+	rootKey  ed25519.PrivateKey
+	notifier *recordingNotifier
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	db := dbtest.Open(t, &models.BreakGlassGrant{}, &models.BreakGlassAccess{})
+
+	publicText, privateText, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	publicKey, err := ParsePublicKey(publicText)
+	if err != nil {
+		t.Fatalf("ParsePublicKey failed: %v", err)
+	}
+	rootKey, err := ParsePrivateKey(privateText)
+	if err != nil {
+		t.Fatalf("ParsePrivateKey failed: %v", err)
+	}
+
+	repo := repositories.NewBreakGlassRepository(db)
+	fake := clock.NewFake(time.Now())
+	notifier := &recordingNotifier{}
+	admins := staticAdmins{
+		{BaseModel: models.BaseModel{ID: 1}, Role: rbac.RoleAdmin},
+		{BaseModel: models.BaseModel{ID: 2}, Role: rbac.RoleSupport},
+		{BaseModel: models.BaseModel{ID: 3}, IsAdmin: true},
+	}
+	service := NewService(publicKey, repo, Config{MaxTTL: 4 * time.Hour}, logger.NewServerLogger()).
+		WithClock(fake).
+		WithAlerts(admins, notifier)
+	return &fixture{service: service, repo: repo, clock: fake, rootKey: rootKey, notifier: notifier}
+}
+
+func (f *fixture) issue(t *testing.T, ttl time.Duration) string {
+	t.Helper()
+	token, err := Issue(f.rootKey, "alice", "SSO outage", f.clock.Now(), ttl)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	return token
+}
+
+var access = Access{Method: "GET", Path: "/api/admin/staff", IPAddress: "203.0.113.7"}
+
+func TestAuthenticate_RecordsEveryUseAndAlertsOnce(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	token := f.issue(t, time.Hour)
+
+	grant, err := f.service.Authenticate(ctx, token, access)
+	if err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	if grant.Operator != "alice" || grant.Reason != "SSO outage" {
+		t.Errorf("Grant = %+v", grant)
+	}
+	if _, err := f.service.Authenticate(ctx, token, access); err != nil {
+		t.Fatalf("Second use failed: %v", err)
+	}
+
+	if fmt.Sprint(f.notifier.alerted) != "[1 3]" {
+		t.Errorf("Alerted %v, want only the admins once", f.notifier.alerted)
+	}
+	accesses, _ := f.repo.ListAccesses(ctx, grant.ID, 0, 10)
+	if len(accesses) != 2 || accesses[0].IPAddress != "203.0.113.7" {
+		t.Errorf("Accesses = %+v", accesses)
+	}
+	stored, _ := f.repo.GetGrant(ctx, grant.ID)
+	if stored.UseCount != 2 {
+		t.Errorf("UseCount = %d, want 2", stored.UseCount)
+	}
+
+	user := User(grant)
+	if !rbac.UserCan(user, rbac.SystemConfigure) || user.ID != 0 {
+		t.Errorf("Break-glass user should be an admin without a user row: %+v", user)
+	}
+}
+
+func TestAuthenticate_ExpiresAutomatically(t *testing.T) {
+	f := newFixture(t)
+	token := f.issue(t, time.Hour)
+
+	f.clock.Advance(time.Hour + time.Second)
+	if _, err := f.service.Authenticate(context.Background(), token, access); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected ErrInvalidToken for expired token, got %v", err)
+	}
+}
+
+func TestAuthenticate_RejectsOverlongAndForeignTokens(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	if _, err := f.service.Authenticate(ctx, f.issue(t, 24*time.Hour), access); !errors.Is(err, ErrTTLTooLong) {
+		t.Errorf("Expected ErrTTLTooLong, got %v", err)
+	}
+
+	_, otherKey, _ := GenerateKey()
+	foreignKey, _ := ParsePrivateKey(otherKey)
+	forged, _ := Issue(foreignKey, "mallory", "testing", f.clock.Now(), time.Hour)
+	if _, err := f.service.Authenticate(ctx, forged, access); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected ErrInvalidToken for foreign key, got %v", err)
+	}
+	if len(f.notifier.alerted) != 0 {
+		t.Error("Rejected tokens must not be recorded or alerted")
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	token := f.issue(t, time.Hour)
+
+	grant, err := f.service.Authenticate(ctx, token, access)
+	if err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	admin := &models.User{BaseModel: models.BaseModel{ID: 1}, Role: rbac.RoleAdmin}
+	if revoked, err := f.service.Revoke(ctx, grant.ID, admin); err != nil || !revoked {
+		t.Fatalf("Revoke = %v, %v", revoked, err)
+	}
+	if _, err := f.service.Authenticate(ctx, token, access); !errors.Is(err, ErrRevoked) {
+		t.Errorf("Expected ErrRevoked, got %v", err)
+	}
+	if revoked, _ := f.service.Revoke(ctx, grant.ID, admin); revoked {
+		t.Error("Revoking twice should report false")
+	}
+}
@@ -0,0 +1,152 @@
+// Package broker publishes outbox events to a message broker, Kafka
+// through its REST Proxy or NATS, and reads them back as a consumer group
+// whose messages run as jobs, see jobs.Pool.Consume.
+//
+// Each event type has its own topic, the prefix followed by the type, e.g.
+// "events.post.published". Kafka messages are keyed by aggregate, so the
+// events of one post or user keep their order within a partition.
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-server/internal/jobs"
+	"go-server/internal/outbox"
+)
+
+// Drivers
+const (
+	DriverNone  = "none"
+	DriverKafka = "kafka"
+	DriverNATS  = "nats"
+)
+
+// JobTypePrefix starts the job type of consumed events; a post.published
+// event runs as a "broker.post.published" job
+const JobTypePrefix = "broker."
+
+// Config holds message broker configuration
+type Config struct {
+	// Driver is none, kafka or nats
+	Driver string
+	// URL is the Kafka REST Proxy's base URL, or the NATS server's
+	// nats:// or tls:// address; credentials may be given in it
+	URL string
+	// TopicPrefix starts each event type's topic
+	TopicPrefix string
+	// Group is the consumer group this server's workers join
+	Group   string
+	Timeout time.Duration
+}
+
+// Producer writes messages to a broker
+type Producer interface {
+	// Produce writes value to topic, returning once the broker has it
+	Produce(ctx context.Context, topic, key string, value []byte) error
+	// Ping checks the broker is reachable
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+// NewProducer creates a producer for the configured driver; it returns
+// nil when no broker is configured
+func NewProducer(config Config) (Producer, error) {
+	switch config.Driver {
+	case "", DriverNone:
+		return nil, nil
+	case DriverKafka:
+		producer, err := NewKafkaProducer(config)
+		if err != nil {
+			return nil, err
+		}
+		return producer, nil
+	case DriverNATS:
+		producer, err := NewNATSProducer(config)
+		if err != nil {
+			return nil, err
+		}
+		return producer, nil
+	default:
+		return nil, fmt.Errorf("unknown broker driver %q", config.Driver)
+	}
+}
+
+// NewConsumer creates a consumer of the events of the given types for the
+// configured driver; it returns nil when no broker is configured
+func NewConsumer(config Config, eventTypes ...string) (jobs.Consumer, error) {
+	topics := make([]string, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		topics = append(topics, config.TopicPrefix+eventType)
+	}
+	switch config.Driver {
+	case "", DriverNone:
+		return nil, nil
+	case DriverKafka:
+		consumer, err := NewKafkaConsumer(config, topics)
+		if err != nil {
+			return nil, err
+		}
+		return consumer, nil
+	case DriverNATS:
+		consumer, err := NewNATSConsumer(config, topics)
+		if err != nil {
+			return nil, err
+		}
+		return consumer, nil
+	default:
+		return nil, fmt.Errorf("unknown broker driver %q", config.Driver)
+	}
+}
+
+// OutboxPublisher returns a publisher writing relayed events to the
+// producer as JSON envelopes
+func OutboxPublisher(producer Producer, topicPrefix string) outbox.Publisher {
+	return outbox.PublisherFunc(func(ctx context.Context, envelope outbox.Envelope) error {
+		data, err := json.Marshal(envelope)
+		if err != nil {
+			return fmt.Errorf("failed to encode event: %w", err)
+		}
+		key := envelope.AggregateType + ":" + envelope.AggregateID
+		return producer.Produce(ctx, topicPrefix+envelope.Type, key, data)
+	})
+}
+
+// Route returns the job type of a consumed event, for jobs.Pool.Consume:
+// JobTypePrefix followed by its event type
+func Route(topicPrefix string) func(jobs.Message) string {
+	return func(message jobs.Message) string {
+		eventType, ok := strings.CutPrefix(message.Topic, topicPrefix)
+		if !ok || eventType == "" {
+			return ""
+		}
+		return JobTypePrefix + eventType
+	}
+}
+
+// DecodeEnvelope returns the event a consumed message's job carries
+func DecodeEnvelope(job *jobs.Job) (outbox.Envelope, error) {
+	var message jobs.Message
+	if err := job.Decode(&message); err != nil {
+		return outbox.Envelope{}, fmt.Errorf("failed to decode message: %w", err)
+	}
+	var envelope outbox.Envelope
+	if err := json.Unmarshal(message.Value, &envelope); err != nil {
+		return outbox.Envelope{}, fmt.Errorf("malformed event on %s: %w", message.Topic, err)
+	}
+	return envelope, nil
+}
+
+// Health reports the broker's state for health checks
+func Health(ctx context.Context, producer Producer) string {
+	if producer == nil {
+		return "not configured"
+	}
+	if err := producer.Ping(ctx); err != nil {
+		return "unhealthy: " + err.Error()
+	}
+	return "healthy"
+}
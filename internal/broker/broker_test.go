@@ -0,0 +1,332 @@
+package broker
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-server/internal/database/models"
+	"go-server/internal/jobs"
+	"go-server/internal/outbox"
+)
+
+// fakeRESTProxy serves the parts of the Kafka REST Proxy v2 API the
+// producer and consumer use, keeping each topic's records in memory
+type fakeRESTProxy struct {
+	mu        sync.Mutex
+	records   map[string][]map[string]any
+	committed []map[string]any
+	instances int
+	expired   bool
+}
+
+func (fp *fakeRESTProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+	if user, password, _ := r.BasicAuth(); user != "app" || password != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var body map[string]any
+	json.NewDecoder(r.Body).Decode(&body)
+	instance := fmt.Sprintf("/consumers/workers/instances/%d", fp.instances)
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/topics":
+		json.NewEncoder(w).Encode([]string{"events.post.published"})
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/topics/"):
+		if r.Header.Get("Content-Type") != kafkaBinaryContentType {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		topic := strings.TrimPrefix(r.URL.Path, "/topics/")
+		for _, record := range body["records"].([]any) {
+			record := record.(map[string]any)
+			record["topic"] = topic
+			record["partition"] = 0
+			record["offset"] = len(fp.records[topic])
+			fp.records[topic] = append(fp.records[topic], record)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"offsets": []map[string]any{{"partition": 0, "offset": len(fp.records[topic]) - 1}}})
+	case r.Method == http.MethodPost && r.URL.Path == "/consumers/workers":
+		fp.instances++
+		fp.expired = false
+		json.NewEncoder(w).Encode(map[string]string{"base_uri": "http://" + r.Host + fmt.Sprintf("/consumers/workers/instances/%d", fp.instances)})
+	case fp.expired || !strings.HasPrefix(r.URL.Path, instance):
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(kafkaError{Code: 40403, Message: "Consumer instance not found."})
+	case r.Method == http.MethodDelete && r.URL.Path == instance:
+		w.WriteHeader(http.StatusNoContent)
+	case r.URL.Path == instance+"/subscription":
+		w.WriteHeader(http.StatusNoContent)
+	case r.URL.Path == instance+"/records":
+		var records []map[string]any
+		for _, topicRecords := range fp.records {
+			records = append(records, topicRecords...)
+		}
+		fp.records = make(map[string][]map[string]any)
+		json.NewEncoder(w).Encode(records)
+	case r.URL.Path == instance+"/offsets":
+		for _, offset := range body["offsets"].([]any) {
+			fp.committed = append(fp.committed, offset.(map[string]any))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestKafka_ProduceAndConsume(t *testing.T) {
+	ctx := context.Background()
+	proxy := &fakeRESTProxy{records: make(map[string][]map[string]any)}
+	server := httptest.NewServer(proxy)
+	defer server.Close()
+	config := Config{
+		Driver:      DriverKafka,
+		URL:         strings.Replace(server.URL, "http://", "http://app:secret@", 1),
+		TopicPrefix: "events.",
+		Group:       "workers",
+	}
+
+	producer, err := NewProducer(config)
+	if err != nil {
+		t.Fatalf("NewProducer failed: %v", err)
+	}
+	if health := Health(ctx, producer); health != "healthy" {
+		t.Errorf("Expected a healthy broker, got %q", health)
+	}
+	publisher := OutboxPublisher(producer, config.TopicPrefix)
+	for id := uint(1); id <= 2; id++ {
+		envelope := outbox.Envelope{ID: id, Type: models.EventPostPublished, AggregateType: "post", AggregateID: "7", Payload: []byte(`{"id":7}`)}
+		if err := publisher.Publish(ctx, envelope); err != nil {
+			t.Fatalf("Publish failed: %v", err)
+		}
+	}
+
+	consumer, err := NewConsumer(config, models.EventPostPublished)
+	if err != nil {
+		t.Fatalf("NewConsumer failed: %v", err)
+	}
+	messages, err := consumer.Fetch(ctx)
+	if err != nil || len(messages) != 2 {
+		t.Fatalf("Expected both events, got %+v, %v", messages, err)
+	}
+	if messages[0].Key != "post:7" || messages[1].Offset != 1 {
+		t.Errorf("Unexpected messages %+v", messages)
+	}
+	if jobType := Route(config.TopicPrefix)(messages[0]); jobType != "broker.post.published" {
+		t.Errorf("Unexpected job type %q", jobType)
+	}
+	job, _ := jobs.NewJob("broker.post.published", messages[1], time.Now())
+	if envelope, err := DecodeEnvelope(job); err != nil || envelope.ID != 2 || string(envelope.Payload) != `{"id":7}` {
+		t.Errorf("Expected the second envelope, got %+v, %v", envelope, err)
+	}
+
+	if err := consumer.Commit(ctx, messages); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if len(proxy.committed) != 1 || proxy.committed[0]["offset"] != float64(1) {
+		t.Errorf("Expected the partition's last offset committed, got %v", proxy.committed)
+	}
+
+	// An expired instance is replaced on the next fetch
+	proxy.mu.Lock()
+	proxy.expired = true
+	proxy.mu.Unlock()
+	if _, err := consumer.Fetch(ctx); err == nil {
+		t.Error("Expected the fetch from an expired instance to fail")
+	}
+	if _, err := consumer.Fetch(ctx); err != nil || proxy.instances != 2 {
+		t.Errorf("Expected the consumer to rejoin, got %d instances, %v", proxy.instances, err)
+	}
+	if err := consumer.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+
+	config.URL = server.URL
+	producer, _ = NewProducer(config)
+	if health := Health(ctx, producer); !strings.HasPrefix(health, "unhealthy") {
+		t.Errorf("Expected unauthenticated requests to fail, got %q", health)
+	}
+}
+
+// fakeNATS speaks enough of the NATS protocol for the producer and
+// consumer, delivering each message to one member of a queue group
+type fakeNATS struct {
+	listener net.Listener
+	mu       sync.Mutex
+	// subscribers holds the connections and sids subscribed to each subject
+	subscribers map[string][]natsSubscriber
+	connects    []string
+}
+
+type natsSubscriber struct {
+	conn net.Conn
+	sid  string
+}
+
+func newFakeNATS(t *testing.T) *fakeNATS {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	fn := &fakeNATS{listener: listener, subscribers: make(map[string][]natsSubscriber)}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go fn.serve(conn)
+		}
+	}()
+	return fn
+}
+
+func (fn *fakeNATS) serve(conn net.Conn) {
+	defer conn.Close()
+	io.WriteString(conn, "INFO {\"server_id\":\"fake\"}\r\n")
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := readNATSLine(reader)
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		fn.mu.Lock()
+		switch fields[0] {
+		case "CONNECT":
+			fn.connects = append(fn.connects, strings.TrimPrefix(line, "CONNECT "))
+		case "PING":
+			io.WriteString(conn, "PONG\r\n")
+		case "SUB":
+			fn.subscribers[fields[1]] = append(fn.subscribers[fields[1]], natsSubscriber{conn: conn, sid: fields[3]})
+		case "PUB":
+			var size int
+			fmt.Sscan(fields[2], &size)
+			payload := make([]byte, size+2)
+			io.ReadFull(reader, payload)
+			if subscribers := fn.subscribers[fields[1]]; len(subscribers) > 0 {
+				subscriber := subscribers[0]
+				fmt.Fprintf(subscriber.conn, "MSG %s %s %d\r\n%s", fields[1], subscriber.sid, size, payload)
+			}
+		}
+		fn.mu.Unlock()
+	}
+}
+
+func TestNATS_ProduceAndConsume(t *testing.T) {
+	ctx := context.Background()
+	server := newFakeNATS(t)
+	config := Config{
+		Driver:      DriverNATS,
+		URL:         "nats://s3cret@" + server.listener.Addr().String(),
+		TopicPrefix: "events.",
+		Group:       "workers",
+		Timeout:     time.Second,
+	}
+
+	consumer, err := NewConsumer(config, models.EventPostPublished, models.EventUserRegistered)
+	if err != nil {
+		t.Fatalf("NewConsumer failed: %v", err)
+	}
+	defer consumer.Close()
+	fetched := make(chan []jobs.Message)
+	fetchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		messages, _ := consumer.Fetch(fetchCtx)
+		fetched <- messages
+	}()
+	waitFor(t, func() bool {
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		return len(server.subscribers["events.user.registered"]) == 1
+	})
+
+	producer, err := NewProducer(config)
+	if err != nil {
+		t.Fatalf("NewProducer failed: %v", err)
+	}
+	defer producer.Close()
+	envelope := outbox.Envelope{ID: 9, Type: models.EventUserRegistered, AggregateType: "user", AggregateID: "3", Payload: []byte(`{"id":3}`)}
+	if err := OutboxPublisher(producer, config.TopicPrefix).Publish(ctx, envelope); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+
+	select {
+	case messages := <-fetched:
+		if len(messages) != 1 || messages[0].Topic != "events.user.registered" {
+			t.Fatalf("Expected the user.registered event, got %+v", messages)
+		}
+		job, _ := jobs.NewJob(Route(config.TopicPrefix)(messages[0]), messages[0], time.Now())
+		if decoded, err := DecodeEnvelope(job); err != nil || decoded.ID != 9 || job.Type != "broker.user.registered" {
+			t.Errorf("Unexpected event %+v, %v", decoded, err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("The message was not fetched")
+	}
+
+	server.mu.Lock()
+	connects := strings.Join(server.connects, "\n")
+	server.mu.Unlock()
+	if !strings.Contains(connects, `"auth_token":"s3cret"`) {
+		t.Errorf("Expected the token sent on connect, got %s", connects)
+	}
+
+	// A lost connection is dialed again
+	producer.(*NATSProducer).conn.conn.Close()
+	waitFor(t, func() bool { return producer.(*NATSProducer).conn.lost() })
+	if err := producer.Ping(ctx); err != nil {
+		t.Errorf("Expected the producer to reconnect, got %v", err)
+	}
+
+	server.listener.Close()
+	producer.Close()
+	if health := Health(ctx, producer); !strings.HasPrefix(health, "unhealthy") {
+		t.Errorf("Expected an unreachable server to be unhealthy, got %q", health)
+	}
+}
+
+func TestNewProducer_Drivers(t *testing.T) {
+	if producer, err := NewProducer(Config{Driver: DriverNone}); producer != nil || err != nil {
+		t.Errorf("Expected no producer without a broker, got %v, %v", producer, err)
+	}
+	if health := Health(context.Background(), nil); health != "not configured" {
+		t.Errorf("Unexpected health %q", health)
+	}
+	for _, config := range []Config{
+		{Driver: "rabbitmq"},
+		{Driver: DriverKafka, URL: "nats://localhost"},
+		{Driver: DriverNATS, URL: "http://localhost"},
+	} {
+		if _, err := NewProducer(config); err == nil {
+			t.Errorf("Expected %+v to be rejected", config)
+		}
+	}
+	if _, err := NewConsumer(Config{Driver: DriverNATS, URL: "nats://localhost"}, "post.published"); err == nil {
+		t.Error("Expected a consumer without a group to be rejected")
+	}
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	for i := 0; i < 200; i++ {
+		if condition() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("Timed out waiting")
+}
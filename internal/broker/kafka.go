@@ -0,0 +1,307 @@
+package broker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-server/internal/jobs"
+)
+
+// REST Proxy v2 content types
+const (
+	kafkaContentType       = "application/vnd.kafka.v2+json"
+	kafkaBinaryContentType = "application/vnd.kafka.binary.v2+json"
+)
+
+// kafkaFetchWait is how long the proxy holds a fetch open for records
+const kafkaFetchWait = time.Second
+
+// kafkaError is an error response from the REST Proxy
+type kafkaError struct {
+	Status  int    `json:"-"`
+	Code    int    `json:"error_code"`
+	Message string `json:"message"`
+}
+
+func (e *kafkaError) Error() string {
+	return fmt.Sprintf("kafka rest proxy: %d %s (%d)", e.Status, e.Message, e.Code)
+}
+
+// kafkaClient calls a Confluent REST Proxy's v2 API, which speaks the
+// Kafka protocol for us
+type kafkaClient struct {
+	base *url.URL
+	user *url.Userinfo
+	http *http.Client
+}
+
+func newKafkaClient(config Config) (*kafkaClient, error) {
+	base, err := url.Parse(strings.TrimSuffix(config.URL, "/"))
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf("invalid kafka rest proxy URL %q", config.URL)
+	}
+	user := base.User
+	base.User = nil
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &kafkaClient{base: base, user: user, http: &http.Client{Timeout: timeout + kafkaFetchWait}}, nil
+}
+
+// url returns the proxy URL of the path segments
+func (c *kafkaClient) url(segments ...string) string {
+	escaped := make([]string, len(segments))
+	for i, segment := range segments {
+		escaped[i] = url.PathEscape(segment)
+	}
+	return c.base.String() + "/" + strings.Join(escaped, "/")
+}
+
+// do sends body, if any, as JSON of contentType and decodes the response
+// into out, if any
+func (c *kafkaClient) do(ctx context.Context, method, target, contentType, accept string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", accept)
+	if c.user != nil {
+		password, _ := c.user.Password()
+		req.SetBasicAuth(c.user.Username(), password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		failure := &kafkaError{Status: resp.StatusCode}
+		if err := json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(failure); err != nil {
+			failure.Message = http.StatusText(resp.StatusCode)
+		}
+		return failure
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// KafkaProducer produces messages to Kafka through a REST Proxy
+type KafkaProducer struct {
+	client *kafkaClient
+}
+
+// NewKafkaProducer creates a producer for the REST Proxy at config.URL
+func NewKafkaProducer(config Config) (*KafkaProducer, error) {
+	client, err := newKafkaClient(config)
+	if err != nil {
+		return nil, err
+	}
+	return &KafkaProducer{client: client}, nil
+}
+
+// Produce writes the message to the topic, partitioned by key
+func (kp *KafkaProducer) Produce(ctx context.Context, topic, key string, value []byte) error {
+	type record struct {
+		Key   []byte `json:"key,omitempty"`
+		Value []byte `json:"value"`
+	}
+	request := struct {
+		Records []record `json:"records"`
+	}{Records: []record{{Value: value}}}
+	if key != "" {
+		request.Records[0].Key = []byte(key)
+	}
+
+	var response struct {
+		Offsets []struct {
+			Partition int32  `json:"partition"`
+			Offset    int64  `json:"offset"`
+			Error     string `json:"error"`
+		} `json:"offsets"`
+	}
+	if err := kp.client.do(ctx, http.MethodPost, kp.client.url("topics", topic), kafkaBinaryContentType, kafkaContentType, request, &response); err != nil {
+		return fmt.Errorf("failed to produce to %s: %w", topic, err)
+	}
+	for _, offset := range response.Offsets {
+		if offset.Error != "" {
+			return fmt.Errorf("failed to produce to %s: %s", topic, offset.Error)
+		}
+	}
+	return nil
+}
+
+// Ping lists the topics
+func (kp *KafkaProducer) Ping(ctx context.Context) error {
+	return kp.client.do(ctx, http.MethodGet, kp.client.url("topics"), "", kafkaContentType, nil, nil)
+}
+
+// Close does nothing; requests hold no connection open
+func (kp *KafkaProducer) Close() error {
+	return nil
+}
+
+// KafkaConsumer reads topics through a REST Proxy consumer instance in the
+// consumer group, created on the first fetch and again if the proxy
+// expires it. It is used by one goroutine at a time.
+type KafkaConsumer struct {
+	client *kafkaClient
+	group  string
+	topics []string
+	// instance is the consumer instance's URL, empty until created
+	instance string
+}
+
+// NewKafkaConsumer creates a consumer of the topics for the REST Proxy at
+// config.URL, in consumer group config.Group
+func NewKafkaConsumer(config Config, topics []string) (*KafkaConsumer, error) {
+	client, err := newKafkaClient(config)
+	if err != nil {
+		return nil, err
+	}
+	if config.Group == "" {
+		return nil, errors.New("kafka consumer requires a consumer group")
+	}
+	return &KafkaConsumer{client: client, group: config.Group, topics: topics}, nil
+}
+
+// Fetch returns the records the proxy has, waiting up to a second for some
+func (kc *KafkaConsumer) Fetch(ctx context.Context) ([]jobs.Message, error) {
+	if kc.instance == "" {
+		if err := kc.join(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	var records []struct {
+		Topic     string `json:"topic"`
+		Key       []byte `json:"key"`
+		Value     []byte `json:"value"`
+		Partition int32  `json:"partition"`
+		Offset    int64  `json:"offset"`
+	}
+	target := kc.instance + "/records?timeout=" + strconv.FormatInt(kafkaFetchWait.Milliseconds(), 10)
+	if err := kc.client.do(ctx, http.MethodGet, target, "", kafkaBinaryContentType, nil, &records); err != nil {
+		kc.forgetExpired(err)
+		return nil, fmt.Errorf("failed to fetch records: %w", err)
+	}
+
+	messages := make([]jobs.Message, 0, len(records))
+	for _, record := range records {
+		messages = append(messages, jobs.Message{
+			Topic:     record.Topic,
+			Key:       string(record.Key),
+			Value:     record.Value,
+			Partition: record.Partition,
+			Offset:    record.Offset,
+		})
+	}
+	return messages, nil
+}
+
+// Commit commits the highest offset of each partition among messages,
+// which the proxy records as read
+func (kc *KafkaConsumer) Commit(ctx context.Context, messages []jobs.Message) error {
+	if kc.instance == "" {
+		return errors.New("failed to commit offsets: consumer instance expired")
+	}
+	type position struct {
+		Topic     string `json:"topic"`
+		Partition int32  `json:"partition"`
+		Offset    int64  `json:"offset"`
+	}
+	type partition struct {
+		topic  string
+		number int32
+	}
+	var request struct {
+		Offsets []position `json:"offsets"`
+	}
+	positions := make(map[partition]int) // Index in request.Offsets
+	for _, message := range messages {
+		key := partition{message.Topic, message.Partition}
+		if i, seen := positions[key]; seen {
+			request.Offsets[i].Offset = max(request.Offsets[i].Offset, message.Offset)
+			continue
+		}
+		positions[key] = len(request.Offsets)
+		request.Offsets = append(request.Offsets, position{Topic: message.Topic, Partition: message.Partition, Offset: message.Offset})
+	}
+
+	if err := kc.client.do(ctx, http.MethodPost, kc.instance+"/offsets", kafkaContentType, kafkaContentType, request, nil); err != nil {
+		kc.forgetExpired(err)
+		return fmt.Errorf("failed to commit offsets: %w", err)
+	}
+	return nil
+}
+
+// Close deletes the consumer instance, so the group rebalances at once
+func (kc *KafkaConsumer) Close() error {
+	if kc.instance == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), kc.client.http.Timeout)
+	defer cancel()
+	err := kc.client.do(ctx, http.MethodDelete, kc.instance, kafkaContentType, kafkaContentType, nil, nil)
+	kc.instance = ""
+	return err
+}
+
+// join creates a consumer instance in the group and subscribes it
+func (kc *KafkaConsumer) join(ctx context.Context) error {
+	request := map[string]string{
+		"format":             "binary",
+		"auto.offset.reset":  "earliest",
+		"auto.commit.enable": "false",
+	}
+	var response struct {
+		BaseURI string `json:"base_uri"`
+	}
+	if err := kc.client.do(ctx, http.MethodPost, kc.client.url("consumers", kc.group), kafkaContentType, kafkaContentType, request, &response); err != nil {
+		return fmt.Errorf("failed to join consumer group %s: %w", kc.group, err)
+	}
+	if response.BaseURI == "" {
+		return fmt.Errorf("failed to join consumer group %s: no consumer instance", kc.group)
+	}
+	instance := strings.TrimSuffix(response.BaseURI, "/")
+
+	subscription := map[string][]string{"topics": kc.topics}
+	if err := kc.client.do(ctx, http.MethodPost, instance+"/subscription", kafkaContentType, kafkaContentType, subscription, nil); err != nil {
+		kc.client.do(ctx, http.MethodDelete, instance, kafkaContentType, kafkaContentType, nil, nil)
+		return fmt.Errorf("failed to subscribe: %w", err)
+	}
+	kc.instance = instance
+	return nil
+}
+
+// forgetExpired drops the consumer instance if the proxy no longer has it,
+// so the next fetch joins the group again
+func (kc *KafkaConsumer) forgetExpired(err error) {
+	var failure *kafkaError
+	if errors.As(err, &failure) && failure.Status == http.StatusNotFound {
+		kc.instance = ""
+	}
+}
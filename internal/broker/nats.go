@@ -0,0 +1,430 @@
+package broker
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go-server/internal/jobs"
+)
+
+// natsFetchLimit bounds the messages one fetch returns
+const natsFetchLimit = 100
+
+// natsConn is a connection speaking the NATS client protocol, see
+// https://docs.nats.io/reference/reference-protocols/nats-protocol
+type natsConn struct {
+	conn    net.Conn
+	timeout time.Duration
+	// messages receives the messages of the connection's subscriptions
+	messages chan<- jobs.Message
+
+	writeMu sync.Mutex
+	pongs   chan struct{}
+	// done is closed once the connection is lost, err saying why
+	done     chan struct{}
+	err      error
+	failOnce sync.Once
+}
+
+// natsConnect is the CONNECT message opening a session
+type natsConnect struct {
+	Verbose     bool   `json:"verbose"`
+	Pedantic    bool   `json:"pedantic"`
+	TLSRequired bool   `json:"tls_required"`
+	Name        string `json:"name"`
+	Lang        string `json:"lang"`
+	Version     string `json:"version"`
+	User        string `json:"user,omitempty"`
+	Pass        string `json:"pass,omitempty"`
+	AuthToken   string `json:"auth_token,omitempty"`
+}
+
+// parseNATSURL parses a nats:// or tls:// server address
+func parseNATSURL(raw string) (*url.URL, error) {
+	address, err := url.Parse(raw)
+	if err != nil || (address.Scheme != "nats" && address.Scheme != "tls") || address.Hostname() == "" {
+		return nil, fmt.Errorf("invalid nats URL %q", raw)
+	}
+	return address, nil
+}
+
+// dialNATS connects and authenticates to the server at config.URL
+func dialNATS(ctx context.Context, config Config, messages chan<- jobs.Message) (*natsConn, error) {
+	address, err := parseNATSURL(config.URL)
+	if err != nil {
+		return nil, err
+	}
+	host := address.Host
+	if address.Port() == "" {
+		host = net.JoinHostPort(address.Hostname(), "4222")
+	}
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to nats: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+	reader := bufio.NewReader(conn)
+
+	// The server greets with INFO before any TLS handshake
+	line, err := readNATSLine(reader)
+	if err != nil || !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return nil, fmt.Errorf("failed to connect to nats: unexpected greeting %q: %v", line, err)
+	}
+	secure := address.Scheme == "tls"
+	if secure {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: address.Hostname(), MinVersion: tls.VersionTLS12})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to connect to nats: %w", err)
+		}
+		conn = tlsConn
+		reader = bufio.NewReader(conn)
+	}
+
+	connect := natsConnect{TLSRequired: secure, Name: "go-server", Lang: "go", Version: "1.0.0"}
+	if user := address.User; user != nil {
+		if password, ok := user.Password(); ok {
+			connect.User, connect.Pass = user.Username(), password
+		} else {
+			connect.AuthToken = user.Username()
+		}
+	}
+	data, _ := json.Marshal(connect)
+	if _, err := conn.Write([]byte("CONNECT " + string(data) + "\r\nPING\r\n")); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to connect to nats: %w", err)
+	}
+	// The PONG confirms the server accepted the session
+	for {
+		line, err := readNATSLine(reader)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to connect to nats: %w", err)
+		}
+		if strings.HasPrefix(line, "-ERR") {
+			conn.Close()
+			return nil, fmt.Errorf("nats refused the connection: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+		if line == "PONG" {
+			break
+		}
+	}
+	conn.SetDeadline(time.Time{})
+
+	c := &natsConn{
+		conn:     conn,
+		timeout:  timeout,
+		messages: messages,
+		pongs:    make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	go c.read(reader)
+	return c, nil
+}
+
+// readNATSLine reads a protocol line without its CRLF
+func readNATSLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+// read handles the server's messages until the connection is lost
+func (c *natsConn) read(reader *bufio.Reader) {
+	for {
+		line, err := readNATSLine(reader)
+		if err != nil {
+			c.fail(err)
+			return
+		}
+		switch {
+		case strings.HasPrefix(line, "MSG "):
+			// MSG <subject> <sid> [reply-to] <#bytes>
+			fields := strings.Fields(line)
+			if len(fields) < 4 {
+				c.fail(fmt.Errorf("malformed nats message %q", line))
+				return
+			}
+			size, err := strconv.Atoi(fields[len(fields)-1])
+			if err != nil || size < 0 {
+				c.fail(fmt.Errorf("malformed nats message %q", line))
+				return
+			}
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(reader, payload); err != nil {
+				c.fail(err)
+				return
+			}
+			if c.messages != nil {
+				select {
+				case c.messages <- jobs.Message{Topic: fields[1], Value: payload[:size]}:
+				case <-c.done:
+					return
+				}
+			}
+		case line == "PING":
+			if err := c.write([]byte("PONG\r\n")); err != nil {
+				c.fail(err)
+				return
+			}
+		case line == "PONG":
+			select {
+			case c.pongs <- struct{}{}:
+			default:
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			c.fail(fmt.Errorf("nats: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR"))))
+			return
+		}
+	}
+}
+
+// fail records why the connection was lost and closes it
+func (c *natsConn) fail(err error) {
+	c.failOnce.Do(func() {
+		c.err = err
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+// lost reports whether the connection has been lost
+func (c *natsConn) lost() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *natsConn) write(data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	_, err := c.conn.Write(data)
+	return err
+}
+
+// flush waits until the server has processed everything written so far
+func (c *natsConn) flush(ctx context.Context) error {
+	select {
+	case <-c.pongs: // A late reply to an earlier flush
+	default:
+	}
+	if err := c.write([]byte("PING\r\n")); err != nil {
+		c.fail(err)
+		return err
+	}
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+	select {
+	case <-c.pongs:
+		return nil
+	case <-c.done:
+		return c.err
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return errors.New("nats did not answer in time")
+	}
+}
+
+func (c *natsConn) close() {
+	c.fail(errors.New("connection closed"))
+}
+
+// validSubject reports whether subject can be published or subscribed to
+func validSubject(subject string) bool {
+	return subject != "" && !strings.ContainsAny(subject, " \t\r\n")
+}
+
+// NATSProducer publishes to NATS subjects, reconnecting as needed. NATS
+// has no message keys, so the key is not sent.
+type NATSProducer struct {
+	config Config
+	mu     sync.Mutex
+	conn   *natsConn
+}
+
+// NewNATSProducer creates a producer for the server at config.URL; it
+// connects on first use
+func NewNATSProducer(config Config) (*NATSProducer, error) {
+	if _, err := parseNATSURL(config.URL); err != nil {
+		return nil, err
+	}
+	return &NATSProducer{config: config}, nil
+}
+
+// Produce publishes value on the topic's subject and waits for the server
+// to have it
+func (np *NATSProducer) Produce(ctx context.Context, topic, key string, value []byte) error {
+	if !validSubject(topic) {
+		return fmt.Errorf("invalid nats subject %q", topic)
+	}
+	np.mu.Lock()
+	defer np.mu.Unlock()
+	conn, err := np.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	message := make([]byte, 0, len(topic)+len(value)+32)
+	message = fmt.Appendf(message, "PUB %s %d\r\n", topic, len(value))
+	message = append(message, value...)
+	message = append(message, "\r\n"...)
+	if err := conn.write(message); err != nil {
+		conn.fail(err)
+		return fmt.Errorf("failed to publish to %s: %w", topic, err)
+	}
+	if err := conn.flush(ctx); err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", topic, err)
+	}
+	return nil
+}
+
+// Ping round-trips to the server
+func (np *NATSProducer) Ping(ctx context.Context) error {
+	np.mu.Lock()
+	defer np.mu.Unlock()
+	conn, err := np.connect(ctx)
+	if err != nil {
+		return err
+	}
+	return conn.flush(ctx)
+}
+
+// Close closes the connection
+func (np *NATSProducer) Close() error {
+	np.mu.Lock()
+	defer np.mu.Unlock()
+	if np.conn != nil {
+		np.conn.close()
+		np.conn = nil
+	}
+	return nil
+}
+
+// connect returns the open connection, dialing a new one if it was lost.
+// The caller holds np.mu.
+func (np *NATSProducer) connect(ctx context.Context) (*natsConn, error) {
+	if np.conn != nil && !np.conn.lost() {
+		return np.conn, nil
+	}
+	conn, err := dialNATS(ctx, np.config, nil)
+	if err != nil {
+		return nil, err
+	}
+	np.conn = conn
+	return conn, nil
+}
+
+// NATSConsumer reads subjects as a member of the queue group config.Group,
+// so each message goes to one member. Core NATS delivers at most once: no
+// message is redelivered, and those published while the consumer is
+// disconnected are lost. It is used by one goroutine at a time.
+type NATSConsumer struct {
+	config   Config
+	subjects []string
+	conn     *natsConn
+	messages chan jobs.Message
+}
+
+// NewNATSConsumer creates a consumer of the subjects for the server at
+// config.URL; it connects on the first fetch
+func NewNATSConsumer(config Config, subjects []string) (*NATSConsumer, error) {
+	if _, err := parseNATSURL(config.URL); err != nil {
+		return nil, err
+	}
+	if !validSubject(config.Group) {
+		return nil, fmt.Errorf("invalid nats queue group %q", config.Group)
+	}
+	for _, subject := range subjects {
+		if !validSubject(subject) {
+			return nil, fmt.Errorf("invalid nats subject %q", subject)
+		}
+	}
+	return &NATSConsumer{config: config, subjects: subjects, messages: make(chan jobs.Message, natsFetchLimit)}, nil
+}
+
+// Fetch waits for a message, then returns it with any others received
+func (nc *NATSConsumer) Fetch(ctx context.Context) ([]jobs.Message, error) {
+	if nc.conn == nil || nc.conn.lost() {
+		if err := nc.subscribe(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	var messages []jobs.Message
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-nc.conn.done:
+		return nil, fmt.Errorf("lost nats connection: %w", nc.conn.err)
+	case message := <-nc.messages:
+		messages = append(messages, message)
+	}
+	for len(messages) < natsFetchLimit {
+		select {
+		case message := <-nc.messages:
+			messages = append(messages, message)
+		default:
+			return messages, nil
+		}
+	}
+	return messages, nil
+}
+
+// Commit does nothing; core NATS does not acknowledge messages
+func (nc *NATSConsumer) Commit(ctx context.Context, messages []jobs.Message) error {
+	return nil
+}
+
+// Close closes the connection, leaving the queue group
+func (nc *NATSConsumer) Close() error {
+	if nc.conn != nil {
+		nc.conn.close()
+		nc.conn = nil
+	}
+	return nil
+}
+
+// subscribe connects and joins the queue group on every subject
+func (nc *NATSConsumer) subscribe(ctx context.Context) error {
+	conn, err := dialNATS(ctx, nc.config, nc.messages)
+	if err != nil {
+		return err
+	}
+
+	var subscriptions strings.Builder
+	for i, subject := range nc.subjects {
+		fmt.Fprintf(&subscriptions, "SUB %s %s %d\r\n", subject, nc.config.Group, i+1)
+	}
+	if err := conn.write([]byte(subscriptions.String())); err != nil {
+		conn.close()
+		return fmt.Errorf("failed to subscribe: %w", err)
+	}
+	if err := conn.flush(ctx); err != nil {
+		conn.close()
+		return fmt.Errorf("failed to subscribe: %w", err)
+	}
+	nc.conn = conn
+	return nil
+}
@@ -0,0 +1,131 @@
+package calendar
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/dbtest"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+)
+
+func TestCalendar_WriteTo(t *testing.T) {
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.FixedZone("CET", 3600))
+	cal := &Calendar{
+		Name:            "Editorial calendar",
+		RefreshInterval: 90 * time.Minute,
+		Stamp:           start,
+		Events: []Event{{
+			UID:         "post-1@example.com",
+			Summary:     "Publish: Salt; pepper, and more",
+			Description: "Line one\nLine two " + strings.Repeat("é", 60),
+			Start:       start,
+			End:         start.Add(time.Hour),
+			Categories:  []string{"Scheduled post"},
+		}},
+	}
+
+	var buf bytes.Buffer
+	if _, err := cal.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"REFRESH-INTERVAL;VALUE=DURATION:PT1H30M\r\n",
+		"DTSTART:20260301T080000Z\r\n",
+		"DTEND:20260301T090000Z\r\n",
+		`SUMMARY:Publish: Salt\; pepper\, and more` + "\r\n",
+		"DESCRIPTION:Line one\\nLine two ",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Output lacks %q:\n%s", want, out)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n")
+	var unfolded []string
+	for _, line := range lines {
+		if len(line) > maxLineOctets {
+			t.Errorf("Line exceeds %d octets: %q", maxLineOctets, line)
+		}
+		if strings.HasPrefix(line, " ") {
+			unfolded[len(unfolded)-1] += line[1:]
+			continue
+		}
+		unfolded = append(unfolded, line)
+	}
+	want := "DESCRIPTION:Line one\\nLine two " + strings.Repeat("é", 60)
+	found := false
+	for _, line := range unfolded {
+		found = found || line == want
+	}
+	if !found {
+		t.Errorf("Unfolded output lacks the full description:\n%s", strings.Join(unfolded, "\n"))
+	}
+}
+
+func TestLinkSigner(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	signer := NewLinkSigner("0123456789abcdef0123456789abcdef", 0).WithClock(fake)
+
+	// Feed links carry the user and, without a TTL, never expire
+	query, expiresAt := signer.Sign(42)
+	if query.Get("user") != "42" || query.Get("expires") != "0" || !expiresAt.IsZero() {
+		t.Errorf("Unexpected feed link %v expiring %v", query, expiresAt)
+	}
+	if userID, err := signer.Verify(query); err != nil || userID != 42 {
+		t.Fatalf("Verify() = %d, %v; want 42", userID, err)
+	}
+}
+
+func TestFeed_Build(t *testing.T) {
+	db := dbtest.Open(t, &models.User{}, &models.Post{}, &models.MaintenanceWindow{})
+	ctx := context.Background()
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	author := &models.User{Username: "editor", Email: "editor@example.com", Password: "x", IsActive: true}
+	db.Create(author)
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+	posts := []*models.Post{
+		{Title: "Next week", Slug: "next-week", Content: "x", Status: "draft", AuthorID: author.ID, ScheduledAt: at(7 * 24 * time.Hour)},
+		{Title: "Unscheduled", Slug: "unscheduled", Content: "x", Status: "draft", AuthorID: author.ID},
+		{Title: "Too far", Slug: "too-far", Content: "x", Status: "draft", AuthorID: author.ID, ScheduledAt: at(365 * 24 * time.Hour)},
+		{Title: "Already out", Slug: "already-out", Content: "x", Status: "published", AuthorID: author.ID, ScheduledAt: at(time.Hour), PublishedAt: at(0)},
+	}
+	for _, post := range posts {
+		if err := db.Create(post).Error; err != nil {
+			t.Fatalf("Failed to create post: %v", err)
+		}
+	}
+	maintenance := repositories.NewMaintenanceRepository(db)
+	maintenance.CreateWindow(ctx, &models.MaintenanceWindow{Title: "Database upgrade", StartsAt: now.Add(time.Hour), EndsAt: now.Add(3 * time.Hour)})
+	maintenance.CreateWindow(ctx, &models.MaintenanceWindow{Title: "Long ago", StartsAt: now.Add(-90 * 24 * time.Hour), EndsAt: now.Add(-89 * 24 * time.Hour)})
+
+	feed := NewFeed(repositories.NewPostRepository(db), maintenance, 30*24*time.Hour, 90*24*time.Hour, "example.com").
+		WithClock(clock.NewFake(now))
+	cal, err := feed.Build(ctx)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if len(cal.Events) != 2 {
+		t.Fatalf("Build() returned %d events, want 2: %+v", len(cal.Events), cal.Events)
+	}
+	post, window := cal.Events[0], cal.Events[1]
+	if post.Summary != "Publish: Next week" || post.UID != fmt.Sprintf("post-%d@example.com", posts[0].ID) ||
+		!post.Start.Equal(*posts[0].ScheduledAt) || post.Categories[0] != CategoryScheduledPost {
+		t.Errorf("Unexpected post event %+v", post)
+	}
+	if window.Summary != "Maintenance: Database upgrade" || !window.End.Equal(now.Add(3*time.Hour)) {
+		t.Errorf("Unexpected maintenance event %+v", window)
+	}
+}
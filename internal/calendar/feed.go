@@ -0,0 +1,108 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/models"
+)
+
+// scheduledPostDuration is how long a scheduled publication is shown for;
+// publishing is instant, but calendars need an end to draw the event
+const scheduledPostDuration = 30 * time.Minute
+
+// Event categories
+const (
+	CategoryScheduledPost = "Scheduled post"
+	CategoryMaintenance   = "Maintenance"
+)
+
+// PostStore lists scheduled drafts
+type PostStore interface {
+	ListScheduledPosts(ctx context.Context, from, to time.Time) ([]models.Post, error)
+}
+
+// WindowStore lists maintenance windows
+type WindowStore interface {
+	ListWindows(ctx context.Context, from, to time.Time) ([]models.MaintenanceWindow, error)
+}
+
+// Feed builds the editorial calendar from the posts and windows between
+// lookback before now and horizon after it
+type Feed struct {
+	posts    PostStore
+	windows  WindowStore
+	lookback time.Duration
+	horizon  time.Duration
+	domain   string
+	clock    clock.Clock
+}
+
+// NewFeed creates a feed builder; domain qualifies event UIDs so they stay
+// unique in subscribers' calendars
+func NewFeed(posts PostStore, windows WindowStore, lookback, horizon time.Duration, domain string) *Feed {
+	return &Feed{
+		posts:    posts,
+		windows:  windows,
+		lookback: lookback,
+		horizon:  horizon,
+		domain:   domain,
+		clock:    clock.New(),
+	}
+}
+
+// WithClock sets the time source for the feed's range and stamp
+func (f *Feed) WithClock(c clock.Clock) *Feed {
+	f.clock = clock.OrDefault(c)
+	return f
+}
+
+// Build returns the calendar of scheduled posts and maintenance windows
+func (f *Feed) Build(ctx context.Context) (*Calendar, error) {
+	now := f.clock.Now()
+	from, to := now.Add(-f.lookback), now.Add(f.horizon)
+
+	posts, err := f.posts.ListScheduledPosts(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled posts: %w", err)
+	}
+	windows, err := f.windows.ListWindows(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list maintenance windows: %w", err)
+	}
+
+	calendar := &Calendar{
+		Name:            "Editorial calendar",
+		RefreshInterval: time.Hour,
+		Stamp:           now,
+		Events:          make([]Event, 0, len(posts)+len(windows)),
+	}
+	for _, post := range posts {
+		description := "Draft by " + post.Author.Username
+		if excerpt := strings.TrimSpace(post.Excerpt); excerpt != "" {
+			description += "\n\n" + excerpt
+		}
+		calendar.Events = append(calendar.Events, Event{
+			UID:         fmt.Sprintf("post-%d@%s", post.ID, f.domain),
+			Summary:     "Publish: " + post.Title,
+			Description: description,
+			Start:       *post.ScheduledAt,
+			End:         post.ScheduledAt.Add(scheduledPostDuration),
+			Categories:  []string{CategoryScheduledPost},
+		})
+	}
+	for _, window := range windows {
+		calendar.Events = append(calendar.Events, Event{
+			UID:         fmt.Sprintf("maintenance-%d@%s", window.ID, f.domain),
+			Summary:     "Maintenance: " + window.Title,
+			Description: window.Description,
+			Start:       window.StartsAt,
+			End:         window.EndsAt,
+			Categories:  []string{CategoryMaintenance},
+		})
+	}
+	return calendar, nil
+}
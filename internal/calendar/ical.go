@@ -0,0 +1,161 @@
+// Package calendar publishes the editorial calendar, scheduled posts and
+// maintenance windows, as an iCalendar (RFC 5545) feed. Calendar apps
+// subscribe with a signed URL since they cannot send an Authorization
+// header; the link names the user and is rechecked on every fetch, so
+// staff who lose access stop receiving updates.
+package calendar
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLineOctets is the longest content line RFC 5545 allows before folding
+const maxLineOctets = 75
+
+// timeFormat is the UTC date-time form of RFC 5545
+const timeFormat = "20060102T150405Z"
+
+// Event is a calendar entry
+type Event struct {
+	// UID identifies the event across feed refreshes
+	UID         string
+	Summary     string
+	Description string
+	URL         string
+	Start       time.Time
+	End         time.Time
+	Categories  []string
+}
+
+// Calendar is a named list of events
+type Calendar struct {
+	Name string
+	// RefreshInterval suggests how often subscribers fetch the feed
+	RefreshInterval time.Duration
+	// Stamp is when the feed was generated
+	Stamp  time.Time
+	Events []Event
+}
+
+// ContentType is the media type of a rendered calendar
+const ContentType = "text/calendar; charset=utf-8"
+
+// WriteTo renders the calendar in iCalendar format
+func (c *Calendar) WriteTo(w io.Writer) (int64, error) {
+	cw := &contentWriter{w: bufio.NewWriter(w)}
+	cw.line("BEGIN", "VCALENDAR")
+	cw.line("VERSION", "2.0")
+	cw.line("PRODID", "-//go-server//Editorial Calendar//EN")
+	cw.line("CALSCALE", "GREGORIAN")
+	cw.line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		cw.line("X-WR-CALNAME", escapeText(c.Name))
+	}
+	if c.RefreshInterval > 0 {
+		interval := durationValue(c.RefreshInterval)
+		cw.line("REFRESH-INTERVAL;VALUE=DURATION", interval)
+		cw.line("X-PUBLISHED-TTL", interval)
+	}
+
+	for _, event := range c.Events {
+		cw.line("BEGIN", "VEVENT")
+		cw.line("UID", event.UID)
+		cw.line("DTSTAMP", c.Stamp.UTC().Format(timeFormat))
+		cw.line("DTSTART", event.Start.UTC().Format(timeFormat))
+		if !event.End.IsZero() {
+			cw.line("DTEND", event.End.UTC().Format(timeFormat))
+		}
+		cw.line("SUMMARY", escapeText(event.Summary))
+		if event.Description != "" {
+			cw.line("DESCRIPTION", escapeText(event.Description))
+		}
+		if event.URL != "" {
+			cw.line("URL", event.URL)
+		}
+		if len(event.Categories) > 0 {
+			categories := make([]string, len(event.Categories))
+			for i, category := range event.Categories {
+				categories[i] = escapeText(category)
+			}
+			cw.line("CATEGORIES", strings.Join(categories, ","))
+		}
+		cw.line("END", "VEVENT")
+	}
+
+	cw.line("END", "VCALENDAR")
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+	return cw.n, cw.err
+}
+
+// contentWriter writes folded CRLF content lines, keeping the first error
+type contentWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+// line writes "name:value", folding it into continuation lines that start
+// with a space so no line exceeds maxLineOctets, without splitting a
+// UTF-8 sequence
+func (cw *contentWriter) line(name, value string) {
+	if cw.err != nil {
+		return
+	}
+	text := name + ":" + value
+	limit := maxLineOctets
+	for len(text) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		cw.write(text[:cut] + "\r\n ")
+		text = text[cut:]
+		// The leading space of a continuation line counts towards its length
+		limit = maxLineOctets - 1
+	}
+	cw.write(text + "\r\n")
+}
+
+func (cw *contentWriter) write(s string) {
+	if cw.err != nil {
+		return
+	}
+	n, err := cw.w.WriteString(s)
+	cw.n += int64(n)
+	cw.err = err
+}
+
+// durationValue formats a DURATION value such as PT1H30M, to the minute
+func durationValue(d time.Duration) string {
+	minutes := int64(d.Round(time.Minute) / time.Minute)
+	if minutes < 1 {
+		minutes = 1
+	}
+	value := "PT"
+	if hours := minutes / 60; hours > 0 {
+		value += strconv.FormatInt(hours, 10) + "H"
+	}
+	if minutes%60 > 0 {
+		value += strconv.FormatInt(minutes%60, 10) + "M"
+	}
+	return value
+}
+
+// escapeText escapes a TEXT value
+func escapeText(text string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	).Replace(text)
+}
@@ -0,0 +1,14 @@
+package calendar
+
+import (
+	"time"
+
+	"go-server/internal/security"
+)
+
+// NewLinkSigner creates a signer of feed links, which carry the user they
+// were issued to and are valid for ttl; a zero ttl issues links that do not
+// expire, which only rotating secret revokes
+func NewLinkSigner(secret string, ttl time.Duration) *security.LinkSigner {
+	return security.NewLinkSigner("calendar-feed", "user", secret, ttl)
+}
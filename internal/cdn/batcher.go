@@ -0,0 +1,191 @@
+package cdn
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go-server/internal/jobs"
+	"go-server/internal/logger"
+)
+
+// BatcherConfig holds purge batching configuration
+type BatcherConfig struct {
+	BatchSize     int
+	FlushInterval time.Duration
+	MaxAttempts   int
+	Backoff       jobs.Backoff
+}
+
+// Batcher coalesces purge requests so bursts of content changes become a few
+// provider calls, and retries failed purges with backoff
+type Batcher struct {
+	purger Purger
+	config BatcherConfig
+	logger logger.Logger
+
+	mutex sync.Mutex
+	urls  pendingSet
+	tags  pendingSet
+	full  chan struct{}
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// pendingSet is an insertion-ordered set of queued purge targets
+type pendingSet struct {
+	items []string
+	seen  map[string]struct{}
+}
+
+func (ps *pendingSet) add(values []string) {
+	if ps.seen == nil {
+		ps.seen = make(map[string]struct{})
+	}
+	for _, value := range values {
+		if _, ok := ps.seen[value]; ok {
+			continue
+		}
+		ps.seen[value] = struct{}{}
+		ps.items = append(ps.items, value)
+	}
+}
+
+func (ps *pendingSet) take() []string {
+	items := ps.items
+	ps.items, ps.seen = nil, nil
+	return items
+}
+
+// NewBatcher creates a new purge batcher
+func NewBatcher(purger Purger, config BatcherConfig, logger logger.Logger) *Batcher {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 30
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = 5 * time.Second
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 3
+	}
+	if config.Backoff.Base <= 0 {
+		config.Backoff = jobs.Backoff{Base: time.Second, Max: 30 * time.Second}
+	}
+
+	return &Batcher{
+		purger: purger,
+		config: config,
+		logger: logger,
+		full:   make(chan struct{}, 1),
+	}
+}
+
+// Add queues URLs for the next purge, ignoring ones already queued
+func (b *Batcher) Add(urls ...string) {
+	b.mutex.Lock()
+	b.urls.add(urls)
+	full := len(b.urls.items) >= b.config.BatchSize
+	b.mutex.Unlock()
+	b.signalIfFull(full)
+}
+
+// AddTags queues surrogate keys for the next purge, ignoring ones already queued
+func (b *Batcher) AddTags(tags ...string) {
+	b.mutex.Lock()
+	b.tags.add(tags)
+	full := len(b.tags.items) >= b.config.BatchSize
+	b.mutex.Unlock()
+	b.signalIfFull(full)
+}
+
+func (b *Batcher) signalIfFull(full bool) {
+	if !full {
+		return
+	}
+	select {
+	case b.full <- struct{}{}:
+	default:
+	}
+}
+
+// Start begins flushing queued purges in the background
+func (b *Batcher) Start(ctx context.Context) {
+	ctx, b.cancel = context.WithCancel(ctx)
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+
+		ticker := time.NewTicker(b.config.FlushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-b.full:
+			}
+			b.Flush(ctx)
+		}
+	}()
+}
+
+// Stop halts background flushing and makes a final attempt to purge queued URLs
+func (b *Batcher) Stop() {
+	if b.cancel != nil {
+		b.cancel()
+	}
+	b.wg.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	b.Flush(ctx)
+}
+
+// Flush purges every queued URL and tag now, returning the number that could not be purged
+func (b *Batcher) Flush(ctx context.Context) int {
+	b.mutex.Lock()
+	urls := b.urls.take()
+	tags := b.tags.take()
+	b.mutex.Unlock()
+
+	return b.flush(ctx, "urls", urls, b.purger.PurgeURLs) +
+		b.flush(ctx, "tags", tags, b.purger.PurgeTags)
+}
+
+func (b *Batcher) flush(ctx context.Context, kind string, items []string, purge func(context.Context, []string) error) int {
+	failed := 0
+	for start := 0; start < len(items); start += b.config.BatchSize {
+		end := start + b.config.BatchSize
+		if end > len(items) {
+			end = len(items)
+		}
+		if err := b.purgeWithRetry(ctx, items[start:end], purge); err != nil {
+			failed += end - start
+			b.logger.Error("CDN purge failed", kind, end-start, "error", err.Error())
+		}
+	}
+	return failed
+}
+
+func (b *Batcher) purgeWithRetry(ctx context.Context, items []string, purge func(context.Context, []string) error) error {
+	var err error
+	for attempt := 1; attempt <= b.config.MaxAttempts; attempt++ {
+		if err = purge(ctx, items); err == nil {
+			return nil
+		}
+		if attempt == b.config.MaxAttempts {
+			break
+		}
+
+		b.logger.Warn("CDN purge attempt failed", "attempt", attempt, "error", err.Error())
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(b.config.Backoff.Delay(attempt)):
+		}
+	}
+	return err
+}
@@ -0,0 +1,174 @@
+package cdn
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"go-server/internal/database/models"
+	"go-server/internal/jobs"
+	"go-server/internal/logger"
+	"go-server/internal/outbox"
+)
+
+type recordingPurger struct {
+	mutex    sync.Mutex
+	failures int
+	batches  [][]string
+	tags     [][]string
+}
+
+func (rp *recordingPurger) PurgeURLs(ctx context.Context, urls []string) error {
+	return rp.record(&rp.batches, urls)
+}
+
+func (rp *recordingPurger) PurgeTags(ctx context.Context, tags []string) error {
+	return rp.record(&rp.tags, tags)
+}
+
+func (rp *recordingPurger) record(into *[][]string, items []string) error {
+	rp.mutex.Lock()
+	defer rp.mutex.Unlock()
+	if rp.failures > 0 {
+		rp.failures--
+		return errors.New("provider unavailable")
+	}
+	*into = append(*into, append([]string(nil), items...))
+	return nil
+}
+
+func TestCloudflarePurger_SplitsRequests(t *testing.T) {
+	var requests [][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/zones/zone-1/purge_cache" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("Missing bearer token")
+		}
+		var body struct {
+			Files []string `json:"files"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		requests = append(requests, body.Files)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	urls := make([]string, 45)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("https://example.com/api/posts/%d", i)
+	}
+
+	purger := NewCloudflarePurger("zone-1", "secret").WithEndpoint(server.URL)
+	if err := purger.PurgeURLs(context.Background(), urls); err != nil {
+		t.Fatalf("PurgeURLs failed: %v", err)
+	}
+	if len(requests) != 2 || len(requests[0]) != 30 || len(requests[1]) != 15 {
+		t.Errorf("Expected requests of 30 and 15 URLs, got %d requests", len(requests))
+	}
+}
+
+func TestFastlyPurger_ReportsFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/purge/example.com/api/posts" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	purger := NewFastlyPurger("service-1", "key").WithEndpoint(server.URL)
+	if err := purger.PurgeURLs(context.Background(), []string{"https://example.com/api/posts"}); err == nil {
+		t.Error("Expected an error for a rejected purge")
+	}
+}
+
+func TestFastlyPurger_PurgesSurrogateKeys(t *testing.T) {
+	var keys string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/service/service-1/purge" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		keys = r.Header.Get("Surrogate-Key")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	purger := NewFastlyPurger("service-1", "key").WithEndpoint(server.URL)
+	if err := purger.PurgeTags(context.Background(), []string{"post:1", "posts"}); err != nil {
+		t.Fatalf("PurgeTags failed: %v", err)
+	}
+	if keys != "post:1 posts" {
+		t.Errorf("Expected space-separated keys, got %q", keys)
+	}
+}
+
+func TestBatcher_DeduplicatesAndRetries(t *testing.T) {
+	purger := &recordingPurger{failures: 1}
+	batcher := NewBatcher(purger, BatcherConfig{
+		BatchSize:   10,
+		MaxAttempts: 2,
+		Backoff:     jobs.Backoff{Base: time.Millisecond},
+	}, logger.NewServerLogger())
+
+	batcher.Add("https://example.com/a", "https://example.com/b")
+	batcher.Add("https://example.com/a")
+
+	if failed := batcher.Flush(context.Background()); failed != 0 {
+		t.Fatalf("Expected purge to succeed on retry, %d URLs failed", failed)
+	}
+	if len(purger.batches) != 1 || len(purger.batches[0]) != 2 {
+		t.Errorf("Expected a single batch of 2 URLs, got %v", purger.batches)
+	}
+}
+
+func TestInvalidator_PurgesPostTags(t *testing.T) {
+	purger := &recordingPurger{}
+	batcher := NewBatcher(purger, BatcherConfig{}, logger.NewServerLogger())
+	invalidator := NewInvalidator(batcher, "https://example.com")
+
+	payload, _ := json.Marshal(models.PostEventPayload{ID: 7, AuthorID: 3})
+	invalidator.Publish(context.Background(), outbox.Envelope{Type: models.EventPostUpdated, Payload: payload})
+	invalidator.Publish(context.Background(), outbox.Envelope{Type: models.EventPostDeleted, Payload: payload})
+	batcher.Flush(context.Background())
+
+	expected := []string{"post:7", "posts", "author:3"}
+	if len(purger.tags) != 1 || fmt.Sprint(purger.tags[0]) != fmt.Sprint(expected) {
+		t.Errorf("Expected one purge of %v, got %v", expected, purger.tags)
+	}
+	if len(purger.batches) != 0 {
+		t.Errorf("Expected no URL purges, got %v", purger.batches)
+	}
+}
+
+func TestInvalidator_PurgesPostURLs(t *testing.T) {
+	purger := &recordingPurger{}
+	batcher := NewBatcher(purger, BatcherConfig{}, logger.NewServerLogger())
+	invalidator := NewInvalidator(batcher, "https://example.com/").WithURLPurging(true)
+
+	payload, _ := json.Marshal(models.PostEventPayload{ID: 7, Slug: "hello world"})
+	err := invalidator.Publish(context.Background(), outbox.Envelope{
+		Type:    models.EventPostUpdated,
+		Payload: payload,
+	})
+	if err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	batcher.Flush(context.Background())
+
+	expected := []string{
+		"https://example.com/api/posts",
+		"https://example.com/api/posts/7",
+		"https://example.com/api/posts/hello%20world",
+	}
+	if len(purger.batches) != 1 || fmt.Sprint(purger.batches[0]) != fmt.Sprint(expected) {
+		t.Errorf("Expected %v, got %v", expected, purger.batches)
+	}
+}
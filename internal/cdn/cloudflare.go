@@ -0,0 +1,94 @@
+package cdn
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// cloudflareMaxItems is the number of URLs or tags Cloudflare accepts per purge request
+const cloudflareMaxItems = 30
+
+// CloudflarePurger purges URLs through the Cloudflare zone purge API
+type CloudflarePurger struct {
+	zoneID   string
+	apiToken string
+	endpoint string
+	client   *http.Client
+}
+
+// NewCloudflarePurger creates a new Cloudflare purger
+func NewCloudflarePurger(zoneID, apiToken string) *CloudflarePurger {
+	return &CloudflarePurger{
+		zoneID:   zoneID,
+		apiToken: apiToken,
+		endpoint: "https://api.cloudflare.com/client/v4",
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// WithEndpoint overrides the API base URL
+func (cp *CloudflarePurger) WithEndpoint(endpoint string) *CloudflarePurger {
+	cp.endpoint = endpoint
+	return cp
+}
+
+// WithHTTPClient overrides the HTTP client used for API calls
+func (cp *CloudflarePurger) WithHTTPClient(client *http.Client) *CloudflarePurger {
+	cp.client = client
+	return cp
+}
+
+// PurgeURLs purges the URLs, splitting them into requests of at most 30 files
+func (cp *CloudflarePurger) PurgeURLs(ctx context.Context, urls []string) error {
+	return cp.purgeChunked(ctx, "files", urls)
+}
+
+// PurgeTags purges responses carrying any of the Cache-Tag values
+func (cp *CloudflarePurger) PurgeTags(ctx context.Context, tags []string) error {
+	return cp.purgeChunked(ctx, "tags", tags)
+}
+
+func (cp *CloudflarePurger) purgeChunked(ctx context.Context, field string, items []string) error {
+	for start := 0; start < len(items); start += cloudflareMaxItems {
+		end := start + cloudflareMaxItems
+		if end > len(items) {
+			end = len(items)
+		}
+		if err := cp.purge(ctx, map[string][]string{field: items[start:end]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cp *CloudflarePurger) purge(ctx context.Context, body map[string][]string) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/zones/%s/purge_cache", cp.endpoint, cp.zoneID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+cp.apiToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := cp.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cloudflare purge failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("cloudflare purge failed: status %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+	}
+	return nil
+}
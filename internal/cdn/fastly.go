@@ -0,0 +1,95 @@
+package cdn
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// fastlyMaxKeys is the number of surrogate keys Fastly accepts per purge request
+const fastlyMaxKeys = 256
+
+// FastlyPurger purges URLs and surrogate keys through the Fastly purge API
+type FastlyPurger struct {
+	serviceID string
+	apiToken  string
+	endpoint  string
+	client    *http.Client
+}
+
+// NewFastlyPurger creates a new Fastly purger; serviceID is needed for surrogate key purges
+func NewFastlyPurger(serviceID, apiToken string) *FastlyPurger {
+	return &FastlyPurger{
+		serviceID: serviceID,
+		apiToken:  apiToken,
+		endpoint:  "https://api.fastly.com",
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// WithEndpoint overrides the API base URL
+func (fp *FastlyPurger) WithEndpoint(endpoint string) *FastlyPurger {
+	fp.endpoint = endpoint
+	return fp
+}
+
+// WithHTTPClient overrides the HTTP client used for API calls
+func (fp *FastlyPurger) WithHTTPClient(client *http.Client) *FastlyPurger {
+	fp.client = client
+	return fp
+}
+
+// PurgeURLs purges each URL; Fastly's single-URL purge has no batch form
+func (fp *FastlyPurger) PurgeURLs(ctx context.Context, urls []string) error {
+	for _, url := range urls {
+		if err := fp.purgeURL(ctx, url); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PurgeTags purges responses carrying any of the surrogate keys, up to 256 keys per request
+func (fp *FastlyPurger) PurgeTags(ctx context.Context, tags []string) error {
+	for start := 0; start < len(tags); start += fastlyMaxKeys {
+		end := start + fastlyMaxKeys
+		if end > len(tags) {
+			end = len(tags)
+		}
+		url := fmt.Sprintf("%s/service/%s/purge", fp.endpoint, fp.serviceID)
+		if err := fp.post(ctx, url, strings.Join(tags[start:end], " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (fp *FastlyPurger) purgeURL(ctx context.Context, url string) error {
+	target := strings.TrimPrefix(strings.TrimPrefix(url, "https://"), "http://")
+	return fp.post(ctx, fp.endpoint+"/purge/"+target, "")
+}
+
+func (fp *FastlyPurger) post(ctx context.Context, url, surrogateKeys string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Fastly-Key", fp.apiToken)
+	req.Header.Set("Accept", "application/json")
+	if surrogateKeys != "" {
+		req.Header.Set("Surrogate-Key", surrogateKeys)
+	}
+
+	resp, err := fp.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("fastly purge failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fastly purge failed: status %d", resp.StatusCode)
+	}
+	return nil
+}
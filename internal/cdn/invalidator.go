@@ -0,0 +1,78 @@
+package cdn
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"go-server/internal/database/models"
+	"go-server/internal/httpcache"
+	"go-server/internal/outbox"
+)
+
+// Invalidator turns content events into CDN purges. It implements outbox.Publisher
+// so it can subscribe to the outbox relay alongside other consumers.
+type Invalidator struct {
+	batcher *Batcher
+	baseURL string
+	byURL   bool
+}
+
+// NewInvalidator creates a new invalidator that purges by surrogate key
+func NewInvalidator(batcher *Batcher, baseURL string) *Invalidator {
+	return &Invalidator{
+		batcher: batcher,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// WithURLPurging purges individual URLs instead of surrogate keys, for CDN plans
+// without tag support. Lists and feeds other than /api/posts are not covered.
+func (inv *Invalidator) WithURLPurging(enabled bool) *Invalidator {
+	inv.byURL = enabled
+	return inv
+}
+
+// Publish queues purges for the content affected by the event
+func (inv *Invalidator) Publish(ctx context.Context, envelope outbox.Envelope) error {
+	switch envelope.Type {
+	case models.EventPostPublished, models.EventPostUpdated, models.EventPostDeleted:
+		var post models.PostEventPayload
+		if err := json.Unmarshal(envelope.Payload, &post); err != nil {
+			return fmt.Errorf("failed to decode %s payload: %w", envelope.Type, err)
+		}
+		if inv.byURL {
+			inv.batcher.Add(PostURLs(inv.baseURL, post)...)
+		} else {
+			inv.batcher.AddTags(PostTags(post)...)
+		}
+	}
+	return nil
+}
+
+// PostTags returns the surrogate keys of every response that may embed the post:
+// its detail page, post lists, and pages keyed on its author
+func PostTags(post models.PostEventPayload) []string {
+	return []string{
+		httpcache.PostKey(post.ID),
+		httpcache.PostListKey,
+		httpcache.AuthorKey(post.AuthorID),
+	}
+}
+
+// PostURLs returns every public URL that may serve a cached copy of the post
+func PostURLs(baseURL string, post models.PostEventPayload) []string {
+	urls := []string{
+		baseURL + "/api/posts",
+		fmt.Sprintf("%s/api/posts/%d", baseURL, post.ID),
+	}
+	if post.Slug != "" {
+		urls = append(urls, baseURL+"/api/posts/"+url.PathEscape(post.Slug))
+	}
+	if post.PublicID != "" {
+		urls = append(urls, baseURL+"/api/posts/"+post.PublicID)
+	}
+	return urls
+}
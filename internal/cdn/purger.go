@@ -0,0 +1,62 @@
+// Package cdn invalidates cached public content at the edge when the
+// underlying records change.
+package cdn
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go-server/internal/config"
+	"go-server/internal/logger"
+)
+
+// Supported CDN providers
+const (
+	ProviderNone       = "none"
+	ProviderCloudflare = "cloudflare"
+	ProviderFastly     = "fastly"
+)
+
+// Purger invalidates cached content at a CDN
+type Purger interface {
+	PurgeURLs(ctx context.Context, urls []string) error
+	// PurgeTags invalidates every response tagged with any of the surrogate keys
+	PurgeTags(ctx context.Context, tags []string) error
+}
+
+// NewPurger builds the purger for the configured provider
+func NewPurger(cfg config.CDNConfig, logger logger.Logger) (Purger, error) {
+	switch strings.ToLower(cfg.Provider) {
+	case "", ProviderNone:
+		return NewLogPurger(logger), nil
+	case ProviderCloudflare:
+		return NewCloudflarePurger(cfg.CloudflareZoneID, cfg.CloudflareAPIToken), nil
+	case ProviderFastly:
+		return NewFastlyPurger(cfg.FastlyServiceID, cfg.FastlyAPIToken), nil
+	default:
+		return nil, fmt.Errorf("unsupported CDN provider: %s", cfg.Provider)
+	}
+}
+
+// LogPurger records purge requests without contacting a CDN, for local development
+type LogPurger struct {
+	logger logger.Logger
+}
+
+// NewLogPurger creates a new log-only purger
+func NewLogPurger(logger logger.Logger) *LogPurger {
+	return &LogPurger{logger: logger}
+}
+
+// PurgeURLs logs the URLs that would have been purged
+func (lp *LogPurger) PurgeURLs(ctx context.Context, urls []string) error {
+	lp.logger.Debug("CDN purge skipped (no provider configured)", "urls", strings.Join(urls, ","))
+	return nil
+}
+
+// PurgeTags logs the surrogate keys that would have been purged
+func (lp *LogPurger) PurgeTags(ctx context.Context, tags []string) error {
+	lp.logger.Debug("CDN tag purge skipped (no provider configured)", "tags", strings.Join(tags, ","))
+	return nil
+}
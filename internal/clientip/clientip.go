@@ -0,0 +1,107 @@
+// Package clientip works out which client a request came from, believing
+// forwarding headers only from the reverse proxies in front of the server.
+package clientip
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+)
+
+var (
+	trustedProxiesMutex sync.RWMutex
+	trustedProxies      []netip.Prefix
+)
+
+// ParseTrustedProxies parses the addresses and CIDR ranges of the reverse
+// proxies in front of the server, e.g. "10.0.0.0/8" or "127.0.0.1"
+func ParseTrustedProxies(entries []string) ([]netip.Prefix, error) {
+	proxies := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+			}
+			proxies = append(proxies, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		proxies = append(proxies, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+	return proxies, nil
+}
+
+// SetTrustedProxies sets the reverse proxies whose X-Forwarded-For and
+// X-Real-IP headers Get believes; with none it uses the peer address alone
+func SetTrustedProxies(proxies []netip.Prefix) {
+	trustedProxiesMutex.Lock()
+	defer trustedProxiesMutex.Unlock()
+	trustedProxies = proxies
+}
+
+// Get returns the IP of the client making the request. Forwarding
+// headers are only read when the request comes from a trusted proxy, as
+// any other client can set them to dodge a ban or get another IP banned.
+// X-Forwarded-For is read from the right, skipping the trusted proxies' own
+// hops, so addresses the client prepended are never taken.
+func Get(r *http.Request) string {
+	peer := Peer(r)
+	if !isTrustedProxy(peer) {
+		return peer
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if _, err := netip.ParseAddr(hop); err != nil {
+			break
+		}
+		if i == 0 || !isTrustedProxy(hop) {
+			return hop
+		}
+	}
+
+	if xri := strings.TrimSpace(r.Header.Get("X-Real-IP")); xri != "" {
+		if _, err := netip.ParseAddr(xri); err == nil {
+			return xri
+		}
+	}
+	return peer
+}
+
+// Peer returns the IP of the connection's peer, which is a proxy's for
+// requests that came through one
+func Peer(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return ip
+}
+
+func isTrustedProxy(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	trustedProxiesMutex.RLock()
+	defer trustedProxiesMutex.RUnlock()
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
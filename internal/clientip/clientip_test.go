@@ -0,0 +1,44 @@
+package clientip
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	// httptest requests come from 192.0.2.1
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Forwarded-For", "203.0.113.9, 198.51.100.7")
+	if got := Get(req); got != "192.0.2.1" {
+		t.Errorf("Without trusted proxies Get() = %v, want the peer", got)
+	}
+
+	proxies, err := ParseTrustedProxies([]string{"192.0.2.1"})
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies failed: %v", err)
+	}
+	SetTrustedProxies(proxies)
+	t.Cleanup(func() { SetTrustedProxies(nil) })
+	if got := Get(req); got != "198.51.100.7" {
+		t.Errorf("Behind a trusted proxy Get() = %v, want its last hop", got)
+	}
+	if got := Peer(req); got != "192.0.2.1" {
+		t.Errorf("Peer() = %v, want 192.0.2.1", got)
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.1.2.3/8", "::ffff:127.0.0.1"})
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies failed: %v", err)
+	}
+	if len(proxies) != 2 || proxies[0].String() != "10.0.0.0/8" || proxies[1].String() != "127.0.0.1/32" {
+		t.Errorf("Unexpected proxies %v", proxies)
+	}
+
+	for _, entry := range []string{"10.0.0.0/33", "proxy.internal", ""} {
+		if _, err := ParseTrustedProxies([]string{entry}); err == nil {
+			t.Errorf("ParseTrustedProxies(%q) should fail", entry)
+		}
+	}
+}
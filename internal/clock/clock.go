@@ -0,0 +1,92 @@
+// Package clock provides a time source abstraction so that expiry and
+// cleanup logic can be driven deterministically in tests.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock defines the contract for reading the current time.
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	Until(t time.Time) time.Duration
+}
+
+// systemClock reads time from the operating system.
+type systemClock struct{}
+
+// New returns a Clock backed by the system time.
+func New() Clock {
+	return systemClock{}
+}
+
+// Now returns the current system time.
+func (systemClock) Now() time.Time { return time.Now() }
+
+// Since returns the time elapsed since t.
+func (systemClock) Since(t time.Time) time.Duration { return time.Since(t) }
+
+// Until returns the duration until t.
+func (systemClock) Until(t time.Time) time.Duration { return time.Until(t) }
+
+// OrDefault returns c, or the system clock when c is nil.
+func OrDefault(c Clock) Clock {
+	if c == nil {
+		return New()
+	}
+	return c
+}
+
+// PruneBefore drops the times at or before cutoff from the front of a
+// sorted list, as sliding windows of recent events do.
+func PruneBefore(times []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(times) && !times[i].After(cutoff) {
+		i++
+	}
+	return times[i:]
+}
+
+// Fake is a manually controlled Clock for tests.
+type Fake struct {
+	mu  sync.RWMutex
+	now time.Time
+}
+
+// NewFake creates a fake clock frozen at the given time.
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+// Now returns the fake clock's current time.
+func (f *Fake) Now() time.Time {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.now
+}
+
+// Since returns the fake time elapsed since t.
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+// Until returns the fake duration until t.
+func (f *Fake) Until(t time.Time) time.Duration {
+	return t.Sub(f.Now())
+}
+
+// Advance moves the fake clock forward by d.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+// Set moves the fake clock to t.
+func (f *Fake) Set(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = t
+}
@@ -0,0 +1,55 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := NewFake(start)
+
+	if !fake.Now().Equal(start) {
+		t.Errorf("Expected %v, got %v", start, fake.Now())
+	}
+
+	fake.Advance(time.Hour)
+	if got := fake.Since(start); got != time.Hour {
+		t.Errorf("Expected 1h since start, got %v", got)
+	}
+	if got := fake.Until(start.Add(2 * time.Hour)); got != time.Hour {
+		t.Errorf("Expected 1h until deadline, got %v", got)
+	}
+
+	later := start.Add(48 * time.Hour)
+	fake.Set(later)
+	if !fake.Now().Equal(later) {
+		t.Errorf("Expected %v after Set, got %v", later, fake.Now())
+	}
+}
+
+func TestOrDefault(t *testing.T) {
+	if OrDefault(nil) == nil {
+		t.Error("OrDefault(nil) should return the system clock")
+	}
+
+	fake := NewFake(time.Now())
+	if OrDefault(fake) != fake {
+		t.Error("OrDefault should return the provided clock")
+	}
+}
+
+func TestPruneBefore(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	times := []time.Time{start, start.Add(time.Second), start.Add(2 * time.Second)}
+
+	if got := PruneBefore(times, start.Add(time.Second)); len(got) != 1 || !got[0].Equal(times[2]) {
+		t.Errorf("Expected only the time after the cutoff, got %v", got)
+	}
+	if got := PruneBefore(times, start.Add(-time.Second)); len(got) != 3 {
+		t.Errorf("Expected all times after an earlier cutoff, got %v", got)
+	}
+	if got := PruneBefore(times, start.Add(time.Hour)); len(got) != 0 {
+		t.Errorf("Expected no times after a later cutoff, got %v", got)
+	}
+}
@@ -0,0 +1,327 @@
+// Package compress gzips responses for clients that accept it, keeping
+// the caching headers true to what was sent:
+//
+//   - Every response that could have been compressed carries
+//     Vary: Accept-Encoding, whether or not this one was, so a shared cache
+//     never hands a gzipped body to a client that cannot read it.
+//   - A compressed response is a different set of bytes from the one its
+//     ETag was computed for, so a strong ETag is weakened. If-None-Match
+//     uses the weak comparison and still matches both variants; If-Range
+//     and If-Match need a strong match and so never accept the compressed
+//     one.
+//   - A 304 to a client that accepts gzip carries the weakened ETag too, as
+//     it may stand in for a compressed 200.
+//   - Byte ranges count bytes of the identity body, so partial responses,
+//     responses with a Content-Range and responses a handler already
+//     encoded are left alone, and a compressed response drops the handler's
+//     Accept-Ranges and Content-Length, which described the identity body.
+//   - Cache-Control: no-transform is honoured.
+package compress
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go-server/internal/config"
+	"go-server/internal/httpcache"
+)
+
+// Options controls which responses are compressed
+type Options struct {
+	// MinSize is the smallest body compressed, in bytes; below it gzip's
+	// framing costs more than it saves
+	MinSize int
+	// Level is the gzip compression level
+	Level int
+}
+
+// OptionsFromConfig builds options from the compression configuration
+func OptionsFromConfig(cfg config.CompressionConfig) Options {
+	return Options{MinSize: cfg.MinSize, Level: cfg.Level}
+}
+
+// Handler compresses the responses of next. Bodies are buffered up to
+// MinSize to decide; larger bodies and flushed streams are compressed as
+// they are written.
+func Handler(next http.Handler, opts Options) http.Handler {
+	if _, err := gzip.NewWriterLevel(io.Discard, opts.Level); err != nil {
+		opts.Level = gzip.DefaultCompression
+	}
+	writers := &sync.Pool{New: func() interface{} {
+		gz, _ := gzip.NewWriterLevel(io.Discard, opts.Level)
+		return gz
+	}}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cw := &responseWriter{
+			ResponseWriter: w,
+			opts:           opts,
+			writers:        writers,
+			accepts:        AcceptsGzip(r.Header.Get("Accept-Encoding")),
+			head:           r.Method == http.MethodHead,
+		}
+		next.ServeHTTP(cw, r)
+		cw.Close()
+	})
+}
+
+// AcceptsGzip reports whether an Accept-Encoding header admits gzip,
+// named or through *, with a non-zero quality
+func AcceptsGzip(acceptEncoding string) bool {
+	gzipQ, anyQ := -1.0, -1.0
+	for _, member := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(member, ";")
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			name, value, ok := strings.Cut(param, "=")
+			if !ok || !strings.EqualFold(strings.TrimSpace(name), "q") {
+				continue
+			}
+			if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				q = parsed
+			}
+		}
+		switch strings.ToLower(strings.TrimSpace(coding)) {
+		case "gzip", "x-gzip":
+			gzipQ = q
+		case "*":
+			anyQ = q
+		}
+	}
+	if gzipQ >= 0 {
+		return gzipQ > 0
+	}
+	return anyQ > 0
+}
+
+// Compressible reports whether a media type is text that gzip shrinks.
+// Images, archives and event streams are not: the first two are already
+// compressed and proxies hold back compressed events.
+func Compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch mediaType {
+	case "text/event-stream":
+		return false
+	case "application/json", "application/javascript", "application/xml",
+		"application/x-ndjson", "application/graphql-response+json", "image/svg+xml":
+		return true
+	}
+	return strings.HasPrefix(mediaType, "text/") ||
+		strings.HasSuffix(mediaType, "+json") ||
+		strings.HasSuffix(mediaType, "+xml")
+}
+
+// responseWriter holds the status and the start of the body until it can
+// tell whether to compress, then passes the rest through a gzip.Writer or
+// straight on
+type responseWriter struct {
+	http.ResponseWriter
+	opts    Options
+	writers *sync.Pool
+	accepts bool
+	head    bool
+
+	status  int
+	decided bool
+	encoded bool
+	gz      *gzip.Writer
+	buf     []byte
+}
+
+func (cw *responseWriter) WriteHeader(status int) {
+	if cw.status != 0 {
+		return
+	}
+	if status < http.StatusOK {
+		// Informational responses precede the real one
+		cw.ResponseWriter.WriteHeader(status)
+		return
+	}
+	cw.status = status
+	if !bodyAllowed(status) {
+		cw.decide(0)
+	}
+}
+
+func (cw *responseWriter) Write(p []byte) (int, error) {
+	if cw.status == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.decided {
+		return cw.write(p)
+	}
+	cw.buf = append(cw.buf, p...)
+	if len(cw.buf) < cw.opts.MinSize && contentLength(cw.Header()) < 0 {
+		return len(p), nil
+	}
+	if err := cw.start(-1); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close decides on a body shorter than MinSize and ends the gzip stream
+func (cw *responseWriter) Close() error {
+	if cw.status == 0 {
+		return nil
+	}
+	if !cw.decided {
+		size := len(cw.buf)
+		if cw.head && size == 0 {
+			// A HEAD response describes the GET body, which the handler did not write
+			size = -1
+		}
+		if err := cw.start(size); err != nil {
+			return err
+		}
+	}
+	if cw.gz == nil {
+		return nil
+	}
+	err := cw.gz.Close()
+	cw.gz.Reset(io.Discard)
+	cw.writers.Put(cw.gz)
+	cw.gz = nil
+	return err
+}
+
+// Flush implements http.Flusher. A stream flushed before MinSize is
+// compressed, as its length is unknown.
+func (cw *responseWriter) Flush() {
+	if cw.status == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if !cw.decided {
+		if err := cw.start(-1); err != nil {
+			return
+		}
+	}
+	if cw.gz != nil {
+		cw.gz.Flush()
+	}
+	http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+// Hijack implements http.Hijacker
+func (cw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(cw.ResponseWriter).Hijack()
+}
+
+// Unwrap lets http.NewResponseController reach the underlying writer
+func (cw *responseWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// start decides for a body of size bytes, or of unknown size if negative,
+// and writes what was buffered
+func (cw *responseWriter) start(size int) error {
+	header := cw.Header()
+	if _, set := header["Content-Type"]; !set && len(cw.buf) > 0 {
+		// Sniff the identity body as net/http would, before it is compressed
+		header.Set("Content-Type", http.DetectContentType(cw.buf))
+	}
+	cw.decide(size)
+	buffered := cw.buf
+	cw.buf = nil
+	if len(buffered) == 0 {
+		return nil
+	}
+	_, err := cw.write(buffered)
+	return err
+}
+
+// decide sets the encoding headers and sends the status
+func (cw *responseWriter) decide(size int) {
+	cw.decided = true
+	header := cw.Header()
+	switch {
+	case cw.status == http.StatusNotModified:
+		// The client's copy may be either variant
+		httpcache.AddVary(header, "Accept-Encoding")
+		if cw.accepts {
+			weaken(header)
+		}
+	case cw.eligible():
+		httpcache.AddVary(header, "Accept-Encoding")
+		if size < 0 {
+			size = contentLength(header)
+		}
+		if cw.accepts && size != 0 && (size < 0 || size >= cw.opts.MinSize) {
+			cw.encoded = true
+			header.Set("Content-Encoding", "gzip")
+			header.Del("Content-Length")
+			header.Del("Accept-Ranges")
+			weaken(header)
+			if !cw.head {
+				cw.gz = cw.writers.Get().(*gzip.Writer)
+				cw.gz.Reset(cw.ResponseWriter)
+			}
+		}
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+}
+
+// eligible reports whether the response may be compressed at all, for any
+// client
+func (cw *responseWriter) eligible() bool {
+	header := cw.Header()
+	switch {
+	case !bodyAllowed(cw.status), cw.status == http.StatusPartialContent:
+		return false
+	case header.Get("Content-Encoding") != "", header.Get("Content-Range") != "":
+		return false
+	case hasDirective(header.Get("Cache-Control"), "no-transform"):
+		return false
+	}
+	return Compressible(header.Get("Content-Type"))
+}
+
+func (cw *responseWriter) write(p []byte) (int, error) {
+	switch {
+	case cw.gz != nil:
+		return cw.gz.Write(p)
+	case cw.encoded:
+		// A HEAD response has no body
+		return len(p), nil
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// weaken turns the response's ETag into a weak one
+func weaken(header http.Header) {
+	if etag := header.Get("ETag"); etag != "" {
+		header.Set("ETag", httpcache.WeakETag(etag))
+	}
+}
+
+func bodyAllowed(status int) bool {
+	return status >= http.StatusOK && status != http.StatusNoContent && status != http.StatusNotModified
+}
+
+// contentLength returns the declared body length, or -1
+func contentLength(header http.Header) int {
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || length < 0 {
+		return -1
+	}
+	return length
+}
+
+func hasDirective(cacheControl, directive string) bool {
+	for _, d := range strings.Split(cacheControl, ",") {
+		name, _, _ := strings.Cut(strings.TrimSpace(d), "=")
+		if strings.EqualFold(name, directive) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,410 @@
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-server/internal/httpcache"
+)
+
+var largeJSON = `{"posts": [` + strings.Repeat(`{"title": "Hello, world"},`, 100) + `{}]}`
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := map[string]bool{
+		"":                      false,
+		"gzip":                  true,
+		"GZIP":                  true,
+		"deflate, gzip;q=0.5":   true,
+		"br, x-gzip":            true,
+		"gzip;q=0":              false,
+		"gzip; q=0.0, *":        false,
+		"*":                     true,
+		"*;q=0":                 false,
+		"identity":              false,
+		"br;q=1.0, gzip;q=0.01": true,
+	}
+	for header, want := range tests {
+		if got := AcceptsGzip(header); got != want {
+			t.Errorf("AcceptsGzip(%q) = %v, want %v", header, got, want)
+		}
+	}
+}
+
+func TestCompressible(t *testing.T) {
+	tests := map[string]bool{
+		"application/json":                  true,
+		"application/json; charset=utf-8":   true,
+		"application/problem+json":          true,
+		"application/atom+xml":              true,
+		"text/html; charset=utf-8":          true,
+		"image/svg+xml":                     true,
+		"text/event-stream":                 false,
+		"image/png":                         false,
+		"application/octet-stream":          false,
+		"application/gzip":                  false,
+		"":                                  false,
+		"not a media type; charset=":        false,
+		"application/graphql-response+json": true,
+	}
+	for contentType, want := range tests {
+		if got := Compressible(contentType); got != want {
+			t.Errorf("Compressible(%q) = %v, want %v", contentType, got, want)
+		}
+	}
+}
+
+// response is what a conformance case expects of the compressed response
+type response struct {
+	status       int
+	encoding     string
+	vary         string
+	etag         string
+	acceptRanges string
+	body         string
+}
+
+func TestHandler_Conformance(t *testing.T) {
+	jsonHandler := func(status int, headers map[string]string, body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			for name, value := range headers {
+				w.Header().Set(name, value)
+			}
+			w.WriteHeader(status)
+			io.WriteString(w, body)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		handler http.HandlerFunc
+		want    response
+	}{
+		{
+			name:    "compresses for a client accepting gzip",
+			headers: map[string]string{"Accept-Encoding": "gzip, br"},
+			handler: jsonHandler(http.StatusOK, map[string]string{"ETag": `"v1"`, "Accept-Ranges": "bytes"}, largeJSON),
+			want:    response{status: http.StatusOK, encoding: "gzip", vary: "Accept-Encoding", etag: `W/"v1"`, body: largeJSON},
+		},
+		{
+			name:    "keeps a weak ETag as it is",
+			headers: map[string]string{"Accept-Encoding": "gzip"},
+			handler: jsonHandler(http.StatusOK, map[string]string{"ETag": `W/"post-7-1"`}, largeJSON),
+			want:    response{status: http.StatusOK, encoding: "gzip", vary: "Accept-Encoding", etag: `W/"post-7-1"`, body: largeJSON},
+		},
+		{
+			name:    "varies but keeps the identity body for other clients",
+			handler: jsonHandler(http.StatusOK, map[string]string{"ETag": `"v1"`, "Accept-Ranges": "bytes"}, largeJSON),
+			want:    response{status: http.StatusOK, vary: "Accept-Encoding", etag: `"v1"`, acceptRanges: "bytes", body: largeJSON},
+		},
+		{
+			name:    "honours a zero quality",
+			headers: map[string]string{"Accept-Encoding": "gzip;q=0, identity"},
+			handler: jsonHandler(http.StatusOK, map[string]string{"ETag": `"v1"`}, largeJSON),
+			want:    response{status: http.StatusOK, vary: "Accept-Encoding", etag: `"v1"`, body: largeJSON},
+		},
+		{
+			name:    "leaves bodies below the minimum size",
+			headers: map[string]string{"Accept-Encoding": "gzip"},
+			handler: jsonHandler(http.StatusOK, map[string]string{"ETag": `"v1"`}, `{"ok": true}`),
+			want:    response{status: http.StatusOK, vary: "Accept-Encoding", etag: `"v1"`, body: `{"ok": true}`},
+		},
+		{
+			name:    "decides by a declared length",
+			headers: map[string]string{"Accept-Encoding": "gzip"},
+			handler: jsonHandler(http.StatusOK, map[string]string{"Content-Length": "12"}, `{"ok": true}`),
+			want:    response{status: http.StatusOK, vary: "Accept-Encoding", body: `{"ok": true}`},
+		},
+		{
+			name:    "compresses error responses",
+			headers: map[string]string{"Accept-Encoding": "gzip"},
+			handler: jsonHandler(http.StatusNotFound, nil, largeJSON),
+			want:    response{status: http.StatusNotFound, encoding: "gzip", vary: "Accept-Encoding", body: largeJSON},
+		},
+		{
+			name:    "neither compresses nor varies incompressible types",
+			headers: map[string]string{"Accept-Encoding": "gzip"},
+			handler: jsonHandler(http.StatusOK, map[string]string{"Content-Type": "image/png", "ETag": `"v1"`}, largeJSON),
+			want:    response{status: http.StatusOK, etag: `"v1"`, body: largeJSON},
+		},
+		{
+			name:    "leaves a body the handler encoded",
+			headers: map[string]string{"Accept-Encoding": "gzip"},
+			handler: jsonHandler(http.StatusOK, map[string]string{"Content-Encoding": "br", "ETag": `"v1"`}, largeJSON),
+			want:    response{status: http.StatusOK, encoding: "br", etag: `"v1"`, body: largeJSON},
+		},
+		{
+			name:    "honours no-transform",
+			headers: map[string]string{"Accept-Encoding": "gzip"},
+			handler: jsonHandler(http.StatusOK, map[string]string{"Cache-Control": "public, no-transform", "ETag": `"v1"`}, largeJSON),
+			want:    response{status: http.StatusOK, etag: `"v1"`, body: largeJSON},
+		},
+		{
+			name:    "leaves partial responses",
+			headers: map[string]string{"Accept-Encoding": "gzip", "Range": "bytes=0-9"},
+			handler: jsonHandler(http.StatusPartialContent, map[string]string{"Content-Range": "bytes 0-9/2000", "ETag": `"v1"`}, largeJSON[:10]),
+			want:    response{status: http.StatusPartialContent, etag: `"v1"`, body: largeJSON[:10]},
+		},
+		{
+			name:    "weakens the ETag of a 304 to a client accepting gzip",
+			headers: map[string]string{"Accept-Encoding": "gzip"},
+			handler: jsonHandler(http.StatusNotModified, map[string]string{"ETag": `"v1"`}, ""),
+			want:    response{status: http.StatusNotModified, vary: "Accept-Encoding", etag: `W/"v1"`},
+		},
+		{
+			name:    "keeps the ETag of a 304 to other clients",
+			handler: jsonHandler(http.StatusNotModified, map[string]string{"ETag": `"v1"`}, ""),
+			want:    response{status: http.StatusNotModified, vary: "Accept-Encoding", etag: `"v1"`},
+		},
+		{
+			name:    "sends no body with a 204",
+			headers: map[string]string{"Accept-Encoding": "gzip"},
+			handler: jsonHandler(http.StatusNoContent, nil, ""),
+			want:    response{status: http.StatusNoContent},
+		},
+		{
+			name:    "describes the compressed GET for HEAD",
+			method:  http.MethodHead,
+			headers: map[string]string{"Accept-Encoding": "gzip"},
+			handler: jsonHandler(http.StatusOK, map[string]string{"ETag": `"v1"`}, ""),
+			want:    response{status: http.StatusOK, encoding: "gzip", vary: "Accept-Encoding", etag: `W/"v1"`},
+		},
+		{
+			name:    "adds to an existing Vary",
+			headers: map[string]string{"Accept-Encoding": "gzip"},
+			handler: jsonHandler(http.StatusOK, map[string]string{"Vary": "Origin"}, largeJSON),
+			want:    response{status: http.StatusOK, encoding: "gzip", vary: "Origin, Accept-Encoding", body: largeJSON},
+		},
+		{
+			name:    "leaves Vary: *",
+			headers: map[string]string{"Accept-Encoding": "gzip"},
+			handler: jsonHandler(http.StatusOK, map[string]string{"Vary": "*"}, largeJSON),
+			want:    response{status: http.StatusOK, encoding: "gzip", vary: "*", body: largeJSON},
+		},
+		{
+			name:    "sniffs the type of the identity body",
+			headers: map[string]string{"Accept-Encoding": "gzip"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, "<!DOCTYPE html><html>"+strings.Repeat("<p>Hello</p>", 200)+"</html>")
+			},
+			want: response{status: http.StatusOK, encoding: "gzip", vary: "Accept-Encoding",
+				body: "<!DOCTYPE html><html>" + strings.Repeat("<p>Hello</p>", 200) + "</html>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			r := httptest.NewRequest(method, "/api/posts", nil)
+			for name, value := range tt.headers {
+				r.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+			Handler(tt.handler, Options{MinSize: 256, Level: gzip.BestSpeed}).ServeHTTP(w, r)
+
+			checkResponse(t, w, tt.want)
+		})
+	}
+}
+
+func checkResponse(t *testing.T, w *httptest.ResponseRecorder, want response) {
+	t.Helper()
+	header := w.Result().Header
+	if w.Code != want.status {
+		t.Errorf("Expected status %d, got %d", want.status, w.Code)
+	}
+	if got := header.Get("Content-Encoding"); got != want.encoding {
+		t.Errorf("Expected Content-Encoding %q, got %q", want.encoding, got)
+	}
+	if got := strings.Join(header.Values("Vary"), ", "); got != want.vary {
+		t.Errorf("Expected Vary %q, got %q", want.vary, got)
+	}
+	if got := header.Get("ETag"); got != want.etag {
+		t.Errorf("Expected ETag %q, got %q", want.etag, got)
+	}
+	if got := header.Get("Accept-Ranges"); got != want.acceptRanges {
+		t.Errorf("Expected Accept-Ranges %q, got %q", want.acceptRanges, got)
+	}
+	if want.encoding == "gzip" && header.Get("Content-Length") != "" {
+		t.Errorf("Expected no Content-Length for a compressed body, got %q", header.Get("Content-Length"))
+	}
+	if got := decode(t, w); got != want.body {
+		t.Errorf("Expected body %.40q, got %.40q", want.body, got)
+	}
+}
+
+// decode returns the identity body of a response
+func decode(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	if w.Header().Get("Content-Encoding") != "gzip" || w.Body.Len() == 0 {
+		return w.Body.String()
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(w.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("Body is not gzip: %v", err)
+	}
+	body, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("Body does not decompress: %v", err)
+	}
+	return string(body)
+}
+
+func TestHandler_ConditionalRequests(t *testing.T) {
+	// A resource with httpcache validators, strengthened to show how a
+	// compressed variant's ETag is compared
+	updated := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	validators := httpcache.Validators{ETag: `"post-7"`, LastModified: updated}
+	handler := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if httpcache.Write(w, r, httpcache.Policy{MaxAge: time.Minute}, validators) {
+			return
+		}
+		io.WriteString(w, largeJSON)
+	}), Options{MinSize: 256, Level: gzip.DefaultCompression})
+
+	get := func(headers ...string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/api/posts/7", nil)
+		for i := 0; i < len(headers); i += 2 {
+			r.Header.Set(headers[i], headers[i+1])
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	compressed := get("Accept-Encoding", "gzip")
+	etag := compressed.Header().Get("ETag")
+	if etag != `W/"post-7"` {
+		t.Fatalf("Expected the compressed variant's ETag weakened, got %q", etag)
+	}
+
+	// If-None-Match compares weakly, so the compressed variant's ETag
+	// revalidates either variant
+	for _, acceptEncoding := range []string{"gzip", "identity"} {
+		w := get("Accept-Encoding", acceptEncoding, "If-None-Match", etag)
+		if w.Code != http.StatusNotModified {
+			t.Errorf("Accept-Encoding %s: expected 304, got %d", acceptEncoding, w.Code)
+		}
+		if w.Header().Get("Vary") != "Accept-Encoding" {
+			t.Errorf("Accept-Encoding %s: expected the 304 to vary as the 200 does, got %q", acceptEncoding, w.Header().Get("Vary"))
+		}
+		if w.Body.Len() != 0 {
+			t.Errorf("Accept-Encoding %s: expected no body with the 304", acceptEncoding)
+		}
+	}
+	if w := get("Accept-Encoding", "gzip", "If-None-Match", `"post-6"`); w.Code != http.StatusOK || w.Header().Get("Content-Encoding") != "gzip" {
+		t.Errorf("Expected a changed resource sent compressed, got %d %q", w.Code, w.Header().Get("Content-Encoding"))
+	}
+}
+
+func TestHandler_Ranges(t *testing.T) {
+	content := []byte(largeJSON)
+	modified := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	handler := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"export-1"`)
+		http.ServeContent(w, r, "export.json", modified, bytes.NewReader(content))
+	}), Options{MinSize: 256, Level: gzip.DefaultCompression})
+
+	serve := func(headers ...string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/exports/1", nil)
+		r.Header.Set("Accept-Encoding", "gzip")
+		for i := 0; i < len(headers); i += 2 {
+			r.Header.Set(headers[i], headers[i+1])
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	// A range is served from the identity body, uncompressed, with the
+	// strong ETag it was counted against
+	w := serve("Range", "bytes=10-19")
+	if w.Code != http.StatusPartialContent {
+		t.Fatalf("Expected 206, got %d", w.Code)
+	}
+	if w.Header().Get("Content-Encoding") != "" || w.Body.String() != largeJSON[10:20] {
+		t.Errorf("Expected identity bytes 10-19, got %q encoded %q", w.Body, w.Header().Get("Content-Encoding"))
+	}
+	if w.Header().Get("ETag") != `"export-1"` {
+		t.Errorf("Expected the strong ETag on a range, got %q", w.Header().Get("ETag"))
+	}
+
+	// The full body is compressed and stops offering ranges of it
+	w = serve()
+	if w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("Accept-Ranges") != "" {
+		t.Errorf("Expected a compressed body without Accept-Ranges, got %q, %q",
+			w.Header().Get("Content-Encoding"), w.Header().Get("Accept-Ranges"))
+	}
+	weak := w.Header().Get("ETag")
+
+	// If-Range needs a strong match: a client resuming the compressed
+	// variant gets the whole body again rather than identity bytes spliced
+	// onto gzip ones
+	w = serve("Range", "bytes=10-19", "If-Range", weak)
+	if w.Code != http.StatusOK || decode(t, w) != largeJSON {
+		t.Errorf("Expected the full body for a weak If-Range, got %d", w.Code)
+	}
+	w = serve("Range", "bytes=10-19", "If-Range", `"export-1"`)
+	if w.Code != http.StatusPartialContent || w.Body.String() != largeJSON[10:20] {
+		t.Errorf("Expected the range for a strong If-Range, got %d", w.Code)
+	}
+}
+
+func TestHandler_Streaming(t *testing.T) {
+	lines := make(chan string)
+	flushed := make(chan struct{})
+	done := make(chan struct{})
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/export", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+
+	go func() {
+		defer close(done)
+		Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			for line := range lines {
+				io.WriteString(w, line)
+				http.NewResponseController(w).Flush()
+				flushed <- struct{}{}
+			}
+		}), Options{MinSize: 1024, Level: gzip.DefaultCompression}).ServeHTTP(w, r)
+	}()
+
+	// A flushed line below MinSize is sent compressed, and can be read
+	// before the stream ends
+	first := "{\"n\": 1}\n"
+	lines <- first
+	<-flushed
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Expected a stream of unknown length compressed, got %q", w.Header().Get("Content-Encoding"))
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(w.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("Flushed body is not gzip: %v", err)
+	}
+	got := make([]byte, len(first))
+	if _, err := io.ReadFull(gz, got); err != nil || string(got) != first {
+		t.Errorf("Expected the first line readable, got %q, %v", got, err)
+	}
+
+	lines <- "{\"n\": 2}\n"
+	<-flushed
+	close(lines)
+	<-done
+	if got := decode(t, w); got != first+"{\"n\": 2}\n" {
+		t.Errorf("Unexpected streamed body %q", got)
+	}
+}
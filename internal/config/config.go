@@ -1,17 +1,73 @@
 package config
 
 import (
+	"crypto/ed25519"
+	"encoding/base64"
 	"fmt"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
+
+	"go-server/internal/clientip"
+	"go-server/internal/cors"
+	"go-server/internal/cryptopolicy"
+	"go-server/internal/errors"
+	"go-server/internal/geoip"
+	"go-server/internal/kms"
+	"go-server/internal/logger"
+	"go-server/internal/reporting"
+	"go-server/internal/secheaders"
+	"go-server/internal/secrets"
 )
 
 // Config holds all application configuration
 type Config struct {
-	Server   ServerConfig
-	Logging  LoggingConfig
-	Security SecurityConfig
+	Server     ServerConfig
+	Logging    LoggingConfig
+	Security   SecurityConfig
+	Retention  RetentionConfig
+	Jobs       JobsConfig
+	Scheduler  SchedulerConfig
+	API        APIConfig
+	HTTPCache  HTTPCacheConfig
+	Compress   CompressionConfig
+	Outbox     OutboxConfig
+	CDN        CDNConfig
+	Webhooks   WebhooksConfig
+	Realtime   RealtimeConfig
+	Storage    StorageConfig
+	Uploads    UploadsConfig
+	Email      EmailConfig
+	GraphQL    GraphQLConfig
+	Notify     NotificationsConfig
+	Approvals  ApprovalsConfig
+	BreakGlass BreakGlassConfig
+	Reporting  ReportingConfig
+	Runbook    RunbookConfig
+	Reports    ReportsConfig
+	Warmup     WarmupConfig
+	Secrets    SecretsConfig
+	JWT        JWTConfig
+	IdP        IdPConfig
+	Deploy     DeployConfig
+	Session    SessionConfig
+	Calendar   CalendarConfig
+	Usage      UsageConfig
+	Abuse      AbuseConfig
+	UserIndex  UserIndexConfig
+	Usernames  UsernamesConfig
+	Accounts   AccountsConfig
+	Audit      AuditConfig
+	KMS        KMSConfig
+	Crypto     CryptoConfig
+	Deps       DependenciesConfig
+	// File is the config file loaded, empty when there was none
+	File string
+	// Broker is the message broker outbox events are published to
+	Broker BrokerConfig
 }
 
 // ServerConfig holds server-related configuration
@@ -21,6 +77,18 @@ type ServerConfig struct {
 	WriteTimeout    time.Duration
 	IdleTimeout     time.Duration
 	ShutdownTimeout time.Duration
+	// RequestTimeout cancels a request's context and answers 504 once a
+	// handler has run this long; zero disables it
+	RequestTimeout time.Duration
+	// RouteTimeouts overrides RequestTimeout for path prefixes, see
+	// ParseRouteTimeouts
+	RouteTimeouts string
+	// RoutePanicThreshold is how many panics a route may have in a minute
+	// before it answers 503 until re-enabled; zero never disables routes
+	RoutePanicThreshold int
+	// BootReportFile is where the boot report is written once the server
+	// is ready; empty only logs it
+	BootReportFile string
 }
 
 // LoggingConfig holds logging-related configuration
@@ -35,7 +103,19 @@ type SecurityConfig struct {
 	RateLimitRPS   int
 	RateLimitBurst int
 	EnableCORS     bool
-	CORSOrigins    []string
+	// CORSOrigins lists origins, *.example.com patterns and regex: entries,
+	// see cors.MatchOrigin; CORS_ORIGINS separates them with commas
+	CORSOrigins []string
+	// CORSAllowCredentials sends credentials to explicitly listed origins
+	CORSAllowCredentials bool
+	CORSMaxAge           time.Duration
+	// CORSRouteGroups overrides the policy for path prefixes, see cors.ParseGroups
+	CORSRouteGroups string
+	// GeoIPDatabase is the CSV range database locating client IPs, see geoip.Load
+	GeoIPDatabase string
+	// RateLimitMultipliers scales rate limits by the client's ASN or country,
+	// see geoip.ParseRules; it needs GeoIPDatabase
+	RateLimitMultipliers string
 
 	// Input validation
 	EnableInputValidation bool
@@ -44,11 +124,649 @@ type SecurityConfig struct {
 
 	// Security headers
 	EnableSecurityHeaders bool
+	// ContentSecurityPolicy is the policy of API responses, see
+	// secheaders.ParsePolicy; the docs pages have their own
 	ContentSecurityPolicy string
+	// HSTSMaxAge is sent in Strict-Transport-Security on HTTPS requests;
+	// zero disables the header
+	HSTSMaxAge            time.Duration
+	HSTSIncludeSubDomains bool
+	HSTSPreload           bool
+
+	// TrustedProxies lists the addresses and CIDR ranges of the reverse
+	// proxies in front of the server, whose X-Forwarded-For and X-Real-IP
+	// headers are believed, see clientip.ParseTrustedProxies;
+	// TRUSTED_PROXIES separates them with commas
+	TrustedProxies []string
+}
+
+// RetentionConfig holds data retention configuration
+type RetentionConfig struct {
+	// SoftDeleteRetention is how long soft-deleted records are kept before they may be purged
+	SoftDeleteRetention time.Duration
+}
+
+// JobsConfig holds background job configuration
+type JobsConfig struct {
+	Enabled      bool
+	QueueName    string
+	Concurrency  int
+	PollInterval time.Duration
+	MaxAttempts  int
+}
+
+// SchedulerConfig holds scheduled task configuration
+type SchedulerConfig struct {
+	Enabled                bool
+	LockTTL                time.Duration
+	SessionCleanupSchedule string
+	TrashPurgeSchedule     string
+	OutboxPurgeSchedule    string
+}
+
+// APIConfig holds public API surface configuration
+type APIConfig struct {
+	// PublicIDs exposes ULID/UUIDv7 identifiers in URLs instead of sequential keys
+	PublicIDs      bool
+	PublicIDFormat string
+	// DocsEnabled serves the generated OpenAPI spec at /openapi.json and the viewers at /docs
+	DocsEnabled bool
+	// SchemaValidation checks requests and responses against the spec:
+	// strict rejects mismatches (development), log only logs them (production)
+	SchemaValidation string
+	// ErrorFormat is json for the APIError body or problem for RFC 7807
+	// application/problem+json responses
+	ErrorFormat string
+	// ProblemTypeBase prefixes the type URI of problem responses
+	ProblemTypeBase string
+}
+
+// HTTPCacheConfig holds caching header configuration for public content
+type HTTPCacheConfig struct {
+	MaxAge               time.Duration
+	SMaxAge              time.Duration
+	StaleWhileRevalidate time.Duration
+	StaleIfError         time.Duration
+}
+
+// CompressionConfig holds response compression configuration
+type CompressionConfig struct {
+	Enabled bool
+	// MinSize is the smallest response body compressed, in bytes
+	MinSize int
+	// Level is the gzip level: -1 for the default, -2 for Huffman only, or 0-9
+	Level int
+}
+
+// OutboxConfig holds transactional outbox relay configuration
+type OutboxConfig struct {
+	Enabled       bool
+	PollInterval  time.Duration
+	BatchSize     int
+	ChannelPrefix string
+	// Retention is how long published events are kept before being pruned
+	Retention time.Duration
+}
+
+// BrokerConfig holds Kafka or NATS configuration
+type BrokerConfig struct {
+	// Driver is none, kafka or nats
+	Driver string
+	// URL is the Kafka REST Proxy's http(s) URL or the NATS server's
+	// nats:// or tls:// address, with any credentials
+	URL string
+	// TopicPrefix starts each event type's topic, e.g. events.post.published
+	TopicPrefix string
+	// ConsumerGroup is the group this server's consumers join
+	ConsumerGroup string
+	Timeout       time.Duration
+}
+
+// CDNConfig holds edge cache purge configuration
+type CDNConfig struct {
+	// Provider is none, cloudflare or fastly
+	Provider string
+	// BaseURL is the public origin whose URLs are purged, e.g. https://www.example.com
+	BaseURL            string
+	CloudflareZoneID   string
+	CloudflareAPIToken string
+	FastlyServiceID    string
+	FastlyAPIToken     string
+	// PurgeByURL purges individual URLs for CDN plans without surrogate key support
+	PurgeByURL    bool
+	BatchSize     int
+	FlushInterval time.Duration
+	MaxAttempts   int
+}
+
+// WebhooksConfig holds outbound webhook delivery configuration
+type WebhooksConfig struct {
+	Enabled bool
+	Timeout time.Duration
+	// AllowHTTP permits plain-http callback URLs; leave off outside development
+	AllowHTTP bool
+}
+
+// RealtimeConfig holds WebSocket configuration
+type RealtimeConfig struct {
+	Enabled        bool
+	AllowedOrigins []string
+	PingInterval   time.Duration
+	MaxMessageSize int64
+	SendBuffer     int
+	// SSEHeartbeatInterval is how often idle event streams receive a keep-alive comment
+	SSEHeartbeatInterval time.Duration
+	// HistorySize is how many recent messages are kept for Last-Event-ID resume
+	HistorySize int
+	// EventStore is memory or redis; redis keeps messages across restarts in
+	// per-channel streams capped at StreamMaxLen and expired after EventRetention
+	EventStore     string
+	StreamMaxLen   int64
+	EventRetention time.Duration
+	// PresenceTTL is how long a heartbeat keeps a user online and TypingTTL
+	// how long a typing indicator lasts
+	PresenceTTL time.Duration
+	TypingTTL   time.Duration
+	// MaxConnections and MaxConnectionsPerUser cap open connections; 0 means unlimited
+	MaxConnections        int
+	MaxConnectionsPerUser int
+	// SlowConsumerPolicy is disconnect, drop_newest or drop_oldest
+	SlowConsumerPolicy string
+	// MaxDroppedMessages disconnects a client under a drop policy once it has lost more messages; 0 means never
+	MaxDroppedMessages int
+}
+
+// StorageConfig holds file storage and lifecycle configuration
+type StorageConfig struct {
+	// Backend is local or s3
+	Backend string
+	// Bucket is the default bucket for uploads
+	Bucket    string
+	LocalRoot string
+	// Endpoint is the S3-compatible service URL; empty means AWS in Region
+	Endpoint        string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	UsePathStyle    bool
+	// LifecyclePolicyFile is a JSON file of per-bucket policies; empty uses the defaults
+	LifecyclePolicyFile string
+	LifecycleSchedule   string
+	OrphanSchedule      string
+	// OrphanGracePeriod skips objects uploaded too recently to have an attachment yet
+	OrphanGracePeriod time.Duration
+	DeleteOrphans     bool
+}
+
+// UploadsConfig holds resumable upload configuration
+type UploadsConfig struct {
+	Enabled bool
+	MaxSize int64
+	// Expiry is how long an incomplete upload can be resumed after its last chunk
+	Expiry time.Duration
+}
+
+// EmailConfig holds transactional email configuration
+type EmailConfig struct {
+	// Transport is log or smtp
+	Transport     string
+	From          string
+	AppName       string
+	DefaultLocale string
+	SMTPHost      string
+	SMTPPort      int
+	SMTPUsername  string
+	SMTPPassword  string
+	// PreviewsEnabled mounts the unauthenticated /debug/emails preview endpoint; development only
+	PreviewsEnabled bool
+	// WebhookSecret authenticates bounce and complaint notifications
+	WebhookSecret string
+	WelcomeEmails bool
+
+	// LinkBaseURL is the origin of the pages opening links in emails, such
+	// as email change confirmations
+	LinkBaseURL string
+	// EmailChangeConfirmTTL is how long a new address has to confirm an
+	// email change, and EmailChangeRevertTTL how long the old address can
+	// undo it
+	EmailChangeConfirmTTL time.Duration
+	EmailChangeRevertTTL  time.Duration
+
+	// ReplyDomain receives reply-by-email replies; empty disables them.
+	// ReplySecret signs the reply addresses.
+	ReplyDomain string
+	ReplySecret string
+	// Inbound webhook credentials, one per provider that forwards replies
+	MailgunSigningKey     string
+	SendGridInboundSecret string
+	SESTopicARN           string
+}
+
+// GraphQLConfig holds GraphQL endpoint configuration
+type GraphQLConfig struct {
+	Enabled bool
+	// MaxDepth and MaxComplexity bound queries before execution; zero disables a limit
+	MaxDepth      int
+	MaxComplexity int
+}
+
+// NotificationsConfig holds push, SMS and email notification configuration
+type NotificationsConfig struct {
+	Enabled bool
+	// DefaultChannel is the first channel tried: push, sms or email
+	DefaultChannel string
+	// Rules overrides the per-channel retry and fallback rules, written as
+	// "channel:attempts[:fallback]" pairs, e.g. "push:3:sms,sms:2:email"
+	Rules             string
+	TwilioAccountSID  string
+	TwilioAuthToken   string
+	TwilioFrom        string
+	TwilioCallbackURL string
+	FCMProjectID      string
+	// FCMCredentialsFile is the path to a service account key with FCM access
+	FCMCredentialsFile string
+	// APNsKeyFile is the path to the .p8 signing key
+	APNsKeyFile    string
+	APNsKeyID      string
+	APNsTeamID     string
+	APNsTopic      string
+	APNsProduction bool
+	// WebhookSecret authenticates relayed delivery status callbacks
+	WebhookSecret string
+	// DigestSchedule is the cron expression for sending due digests; empty disables digests
+	DigestSchedule string
+	// DigestFrequency is the default for users without a preference:
+	// immediate, hourly, daily or weekly
+	DigestFrequency string
+}
+
+// ApprovalsConfig holds the two-person rule for destructive admin actions
+type ApprovalsConfig struct {
+	// Required makes purges, role changes and cache flushes wait for a
+	// second administrator to approve them
+	Required bool
+	// TTL is how long a request may wait for approval before it expires
+	TTL time.Duration
+}
+
+// BreakGlassConfig holds emergency break-glass access configuration
+type BreakGlassConfig struct {
+	// PublicKey is the base64 Ed25519 public half of the offline root key
+	// that signs break-glass tokens; empty disables break-glass access
+	PublicKey string
+	// MaxTTL caps how long any break-glass token may be valid for
+	MaxTTL time.Duration
+}
+
+// RunbookConfig holds the limits on operational runbook actions
+type RunbookConfig struct {
+	// MaxRuns is how many times each action may run per Window
+	MaxRuns int
+	Window  time.Duration
+}
+
+// ReportsConfig holds the configuration of reports rendered for
+// administrators
+type ReportsConfig struct {
+	// Bucket is the storage bucket rendered reports are written to
+	Bucket string
+	// LinkSecret signs download links; empty disables downloads
+	LinkSecret string
+	// LinkTTL is how long a download link works
+	LinkTTL time.Duration
+	// Retention is how long a rendered report is kept
+	Retention time.Duration
+	// MaxPeriod bounds the period one report covers
+	MaxPeriod     time.Duration
+	PurgeSchedule string
+}
+
+// WarmupConfig holds the startup cache warmup configuration
+type WarmupConfig struct {
+	// Skip makes the server ready without warming the cache
+	Skip bool
+	// Budget bounds how long warming may delay readiness
+	Budget      time.Duration
+	TopPosts    int
+	ActiveUsers int
+	TTL         time.Duration
+}
+
+// JWTConfig holds how access tokens are signed
+type JWTConfig struct {
+	// Algorithm is HS256, RS256 or EdDSA; asymmetric keys are published at
+	// /.well-known/jwks.json
+	Algorithm string
+	// PrivateKeyFile is a PEM private key for RS256 or EdDSA. Without one a
+	// key is generated at startup.
+	PrivateKeyFile string
+	// RotationInterval generates a new key this often; zero disables
+	// scheduled rotation
+	RotationInterval time.Duration
+	// RotationLead is how long a new key is published before it signs
+	RotationLead time.Duration
+	// GracePeriod is how long a replaced key keeps verifying tokens; zero
+	// means the token lifetime
+	GracePeriod time.Duration
+	// KMS signs tokens with the KMS signing key, which never leaves the
+	// key management service; it requires the RS256 algorithm
+	KMS bool
+}
+
+// SessionConfig holds how long login sessions last
+type SessionConfig struct {
+	// IdleTimeout ends a session this long after its last request; zero
+	// disables the idle limit
+	IdleTimeout time.Duration
+	// MaxLifetime ends a session this long after login however active it
+	// is; zero disables the absolute limit
+	MaxLifetime time.Duration
+	// CookiesEnabled lets browser clients sending X-Auth-Transport: cookie
+	// hold their token in an HttpOnly cookie, see auth.SessionCookies
+	CookiesEnabled bool
+	CookieName     string
+	CookieDomain   string
+	CookiePath     string
+	// CookieSameSite is strict, lax or none; none requires CookieSecure
+	CookieSameSite string
+	CookieSecure   bool
+}
+
+// CalendarConfig holds the editorial calendar feed configuration
+type CalendarConfig struct {
+	// FeedSecret signs feed links; empty disables the feed
+	FeedSecret string
+	// LinkTTL is how long a feed link works; zero issues links that only
+	// rotating FeedSecret revokes
+	LinkTTL time.Duration
+	// Lookback and Horizon are how far into the past and future the feed reaches
+	Lookback time.Duration
+	Horizon  time.Duration
+}
+
+// UsageConfig holds feature usage telemetry configuration
+type UsageConfig struct {
+	Enabled bool
+	// SampleRate is the fraction of requests counted, between 0 and 1
+	SampleRate    float64
+	FlushInterval time.Duration
+}
+
+// AbuseConfig holds the abuse detection heuristics' thresholds
+type AbuseConfig struct {
+	Enabled bool
+	// SuspiciousAgents are User-Agent fragments banned on sight; empty
+	// uses the detector's list of common scanners
+	SuspiciousAgents      []string
+	MaxRequestsPerSecond  int
+	MaxValidationFailures int
+	FailureWindow         time.Duration
+	BanDuration           time.Duration
+}
+
+// UserIndexConfig holds the Redis email and username index configuration
+type UserIndexConfig struct {
+	Enabled bool
+	TTL     time.Duration
+	// NegativeTTL is how long lookups for missing accounts are cached
+	NegativeTTL time.Duration
+}
+
+// UsernamesConfig extends the username policy's defaults, see
+// usernames.Policy
+type UsernamesConfig struct {
+	// Reserved are names kept for the service besides the built-in ones
+	Reserved []string
+	// BlockedWords are refused anywhere in a username besides the
+	// built-in ones
+	BlockedWords []string
+}
+
+// AccountsConfig holds account lifecycle configuration
+type AccountsConfig struct {
+	// ReactivationCooldown is how long users must wait after deactivating
+	// their account before they can reactivate it themselves
+	ReactivationCooldown time.Duration
+}
+
+// AuditConfig holds the write-once audit log, which copies every domain
+// event from the outbox into hash-chained objects in a storage bucket
+type AuditConfig struct {
+	Enabled bool
+	// Bucket holds the log; with the s3 backend it should have Object Lock
+	// enabled
+	Bucket string
+	Prefix string
+	// Retention is how long each record is locked against deletion; zero
+	// leaves it to the bucket's default retention
+	Retention time.Duration
+}
+
+// KMSConfig holds the key management service that signs tokens and
+// encrypts fields such as webhook secrets; an empty Backend disables it
+type KMSConfig struct {
+	// Backend is local, aws or gcp
+	Backend string
+	// KeyFile is the local backend's keyfile, see kms.LocalProvider
+	KeyFile string
+	// DataKeyLifetime is how long one data key encrypts fields before a
+	// new one is wrapped by the KMS
+	DataKeyLifetime time.Duration
+
+	AWSRegion          string
+	AWSKeyID           string
+	AWSSigningKeyID    string
+	AWSEndpoint        string
+	AWSAccessKeyID     string
+	AWSSecretAccessKey string
+	AWSSessionToken    string
+
+	GCPKeyName           string
+	GCPSigningKeyVersion string
+	GCPEndpoint          string
+	// GCPAccessToken is optional; without it the workload's token is
+	// fetched from the metadata server
+	GCPAccessToken string
+}
+
+// Provider returns the KMS config for kms.New
+func (c KMSConfig) Provider() kms.Config {
+	return kms.Config{
+		Backend: c.Backend,
+		KeyFile: c.KeyFile,
+		AWS: kms.AWSConfig{
+			Region:          c.AWSRegion,
+			KeyID:           c.AWSKeyID,
+			SigningKeyID:    c.AWSSigningKeyID,
+			Endpoint:        c.AWSEndpoint,
+			AccessKeyID:     c.AWSAccessKeyID,
+			SecretAccessKey: c.AWSSecretAccessKey,
+			SessionToken:    c.AWSSessionToken,
+		},
+		GCP: kms.GCPConfig{
+			KeyName:           c.GCPKeyName,
+			SigningKeyVersion: c.GCPSigningKeyVersion,
+			Endpoint:          c.GCPEndpoint,
+			AccessToken:       c.GCPAccessToken,
+		},
+	}
+}
+
+// DependenciesConfig holds the scheduled check of the running binary's
+// modules against vulnerability and end-of-life feeds, see package
+// depwatch. Each feed is an http(s) URL, or a file:// URL or path of an
+// offline mirror.
+type DependenciesConfig struct {
+	// Schedule is the cron expression of the check; empty disables it
+	Schedule string
+	// VulnDB is the Go vulnerability database, in the layout vuln.go.dev
+	// serves; empty skips vulnerabilities
+	VulnDB string
+	// EOLFeed lists Go release cycles in the endoflife.date format; empty
+	// skips the Go release check
+	EOLFeed string
+	// ModuleEOLFeed lists modules that are no longer maintained; empty
+	// skips the module check
+	ModuleEOLFeed string
+	// EOLWarning is how long before an end of life it is reported
+	EOLWarning time.Duration
+	Timeout    time.Duration
+}
+
+// Password hash schemes, see auth.UsePasswordScheme
+const (
+	passwordBcrypt = "bcrypt"
+	passwordPBKDF2 = "pbkdf2-sha256"
+)
+
+// CryptoConfig holds the crypto policy, see package cryptopolicy
+type CryptoConfig struct {
+	// Mode is standard or fips. In fips mode only approved algorithms are
+	// used, and the server refuses to start if its configuration needs
+	// another. Binaries built with the fips tag only run in fips mode.
+	Mode string
+	// PasswordHash is the scheme new password hashes use, bcrypt or
+	// pbkdf2-sha256; empty picks the mode's default. Hashes in the other
+	// scheme are upgraded as their users log in, so switching to
+	// pbkdf2-sha256 ahead of fips mode spares most users a password reset.
+	PasswordHash string
 }
 
-// Load loads configuration from environment variables with defaults
+// Restricted reports whether the fips mode is configured
+func (c CryptoConfig) Restricted() bool {
+	return c.Mode == cryptopolicy.ModeFIPS
+}
+
+// PasswordScheme is the password hash scheme in force
+func (c CryptoConfig) PasswordScheme() string {
+	if c.PasswordHash != "" {
+		return c.PasswordHash
+	}
+	if c.Restricted() {
+		return passwordPBKDF2
+	}
+	return passwordBcrypt
+}
+
+// IdPConfig holds the external identity provider whose tokens are
+// accepted, set per environment; an empty Issuer disables it
+type IdPConfig struct {
+	Issuer   string
+	Audience string
+	JWKSURL  string
+	// JWKSCacheTTL is how long the provider's keys are cached; unknown key
+	// IDs trigger an earlier refetch
+	JWKSCacheTTL time.Duration
+	// LinkByEmail links a new subject to the existing user with its
+	// verified email
+	LinkByEmail bool
+	// AutoProvision creates users for new subjects with a verified email
+	AutoProvision bool
+}
+
+// DeployConfig holds the post-deploy health reporting to an external
+// deploy controller, which decides whether to roll the release back
+type DeployConfig struct {
+	// ControllerURL receives health reports; empty disables them
+	ControllerURL    string
+	ControllerSecret string
+	// BakePeriod is how long after startup the release is watched
+	BakePeriod     time.Duration
+	ReportInterval time.Duration
+	// ErrorBudget is the server error rate allowed above the baseline
+	ErrorBudget     float64
+	MaxLatencyRatio float64
+	MinRequests     int
+	// BaselineErrorRate and BaselineP95 describe the previous release
+	BaselineErrorRate float64
+	BaselineP95       time.Duration
+}
+
+// SecretsConfig holds where secrets are loaded from, see the secrets package
+type SecretsConfig struct {
+	// Dir holds one file per secret, as Docker and Kubernetes mount them
+	Dir string
+	// Backend is an optional secret manager: vault or aws
+	Backend string
+	// RefreshInterval is how often rotating secrets such as the JWT key are re-read
+	RefreshInterval time.Duration
+
+	VaultAddress   string
+	VaultToken     string
+	VaultMount     string
+	VaultPath      string
+	VaultNamespace string
+
+	AWSRegion          string
+	AWSSecretID        string
+	AWSEndpoint        string
+	AWSAccessKeyID     string
+	AWSSecretAccessKey string
+	AWSSessionToken    string
+}
+
+// Provider returns the secrets config for secrets.New
+func (c SecretsConfig) Provider() secrets.Config {
+	return secrets.Config{
+		Dir:     c.Dir,
+		Backend: c.Backend,
+		Vault: secrets.VaultConfig{
+			Address:   c.VaultAddress,
+			Token:     c.VaultToken,
+			Mount:     c.VaultMount,
+			Path:      c.VaultPath,
+			Namespace: c.VaultNamespace,
+		},
+		AWS: secrets.AWSConfig{
+			Region:          c.AWSRegion,
+			SecretID:        c.AWSSecretID,
+			Endpoint:        c.AWSEndpoint,
+			AccessKeyID:     c.AWSAccessKeyID,
+			SecretAccessKey: c.AWSSecretAccessKey,
+			SessionToken:    c.AWSSessionToken,
+		},
+	}
+}
+
+// ReportingConfig holds error tracker configuration
+type ReportingConfig struct {
+	// SentryDSN is the Sentry project DSN; empty disables error reporting
+	SentryDSN   string
+	Environment string
+	// Release identifies the deployed build in reports, e.g. "go-server@1.4.2"
+	Release   string
+	QueueSize int
+}
+
+// fileValues holds the config file being loaded and fileErr the first
+// *_FILE variable that could not be read; loadMutex serialises loads so the
+// getters below read the right file
+var (
+	loadMutex  sync.Mutex
+	fileValues map[string]string
+	fileErr    error
+)
+
+// Load loads configuration from environment variables with defaults. If
+// CONFIG_FILE names a config file, it supplies the variables not set in
+// the environment.
 func Load() (*Config, error) {
+	return LoadFile(os.Getenv("CONFIG_FILE"))
+}
+
+// LoadFile loads configuration like Load with the config file at path;
+// an empty path reads the environment only
+func LoadFile(path string) (*Config, error) {
+	values, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	loadMutex.Lock()
+	defer loadMutex.Unlock()
+	fileValues, fileErr = values, nil
+	defer func() { fileValues, fileErr = nil, nil }()
+
 	config := &Config{
 		Server: ServerConfig{
 			Port:            getEnv("PORT", "8080"),
@@ -56,6 +774,11 @@ func Load() (*Config, error) {
 			WriteTimeout:    getDurationEnv("WRITE_TIMEOUT", 30*time.Second),
 			IdleTimeout:     getDurationEnv("IDLE_TIMEOUT", 120*time.Second),
 			ShutdownTimeout: getDurationEnv("SHUTDOWN_TIMEOUT", 10*time.Second),
+			RequestTimeout:  getDurationEnv("REQUEST_TIMEOUT", 20*time.Second),
+			RouteTimeouts:   getEnv("REQUEST_TIMEOUT_ROUTES", DefaultRouteTimeouts),
+
+			RoutePanicThreshold: getIntEnv("ROUTE_PANIC_THRESHOLD", 5),
+			BootReportFile:      getEnv("BOOT_REPORT_FILE", ""),
 		},
 		Logging: LoggingConfig{
 			Level:  getEnv("LOG_LEVEL", "info"),
@@ -68,6 +791,12 @@ func Load() (*Config, error) {
 			EnableCORS:     getBoolEnv("ENABLE_CORS", true),
 			CORSOrigins:    getStringSliceEnv("CORS_ORIGINS", []string{"*"}),
 
+			CORSAllowCredentials: getBoolEnv("CORS_ALLOW_CREDENTIALS", false),
+			CORSMaxAge:           getDurationEnv("CORS_MAX_AGE", 24*time.Hour),
+			CORSRouteGroups:      getEnv("CORS_ROUTE_GROUPS", ""),
+			GeoIPDatabase:        getEnv("GEOIP_DATABASE", ""),
+			RateLimitMultipliers: getEnv("RATE_LIMIT_MULTIPLIERS", ""),
+
 			// Input validation
 			EnableInputValidation: getBoolEnv("ENABLE_INPUT_VALIDATION", true),
 			MaxStringLength:       getIntEnv("MAX_STRING_LENGTH", 1000),
@@ -76,8 +805,323 @@ func Load() (*Config, error) {
 			// Security headers
 			EnableSecurityHeaders: getBoolEnv("ENABLE_SECURITY_HEADERS", true),
 			ContentSecurityPolicy: getEnv("CONTENT_SECURITY_POLICY", "default-src 'self'"),
+			HSTSMaxAge:            getDurationEnv("HSTS_MAX_AGE", 180*24*time.Hour),
+			HSTSIncludeSubDomains: getBoolEnv("HSTS_INCLUDE_SUBDOMAINS", false),
+			HSTSPreload:           getBoolEnv("HSTS_PRELOAD", false),
+
+			TrustedProxies: getStringSliceEnv("TRUSTED_PROXIES", nil),
+		},
+		Retention: RetentionConfig{
+			SoftDeleteRetention: getDurationEnv("SOFT_DELETE_RETENTION", 30*24*time.Hour),
+		},
+		Jobs: JobsConfig{
+			Enabled:      getBoolEnv("JOBS_ENABLED", true),
+			QueueName:    getEnv("JOBS_QUEUE", "default"),
+			Concurrency:  getIntEnv("JOBS_CONCURRENCY", 4),
+			PollInterval: getDurationEnv("JOBS_POLL_INTERVAL", time.Second),
+			MaxAttempts:  getIntEnv("JOBS_MAX_ATTEMPTS", 5),
+		},
+		Scheduler: SchedulerConfig{
+			Enabled:                getBoolEnv("SCHEDULER_ENABLED", true),
+			LockTTL:                getDurationEnv("SCHEDULER_LOCK_TTL", 5*time.Minute),
+			SessionCleanupSchedule: getEnv("SCHEDULE_SESSION_CLEANUP", "0 3 * * *"),
+			TrashPurgeSchedule:     getEnv("SCHEDULE_TRASH_PURGE", "0 4 * * 0"),
+			OutboxPurgeSchedule:    getEnv("SCHEDULE_OUTBOX_PURGE", "30 4 * * *"),
+		},
+		API: APIConfig{
+			PublicIDs:      getBoolEnv("PUBLIC_IDS_ENABLED", false),
+			PublicIDFormat: getEnv("PUBLIC_ID_FORMAT", "uuidv7"),
+			DocsEnabled:    getBoolEnv("API_DOCS_ENABLED", true),
+
+			SchemaValidation: getEnv("API_SCHEMA_VALIDATION", "off"),
+			ErrorFormat:      getEnv("API_ERROR_FORMAT", "json"),
+			ProblemTypeBase:  getEnv("API_PROBLEM_TYPE_BASE", "/problems/"),
+		},
+		HTTPCache: HTTPCacheConfig{
+			MaxAge:               getDurationEnv("HTTP_CACHE_MAX_AGE", time.Minute),
+			SMaxAge:              getDurationEnv("HTTP_CACHE_S_MAXAGE", 5*time.Minute),
+			StaleWhileRevalidate: getDurationEnv("HTTP_CACHE_STALE_WHILE_REVALIDATE", 30*time.Second),
+			StaleIfError:         getDurationEnv("HTTP_CACHE_STALE_IF_ERROR", 0),
+		},
+		Compress: CompressionConfig{
+			Enabled: getBoolEnv("COMPRESSION_ENABLED", true),
+			MinSize: getIntEnv("COMPRESSION_MIN_SIZE", 1024),
+			Level:   getIntEnv("COMPRESSION_LEVEL", -1),
+		},
+		Outbox: OutboxConfig{
+			Enabled:       getBoolEnv("OUTBOX_ENABLED", true),
+			PollInterval:  getDurationEnv("OUTBOX_POLL_INTERVAL", 2*time.Second),
+			BatchSize:     getIntEnv("OUTBOX_BATCH_SIZE", 100),
+			ChannelPrefix: getEnv("OUTBOX_CHANNEL_PREFIX", "events"),
+			Retention:     getDurationEnv("OUTBOX_RETENTION", 7*24*time.Hour),
+		},
+		CDN: CDNConfig{
+			Provider:           getEnv("CDN_PROVIDER", "none"),
+			BaseURL:            getEnv("CDN_BASE_URL", ""),
+			CloudflareZoneID:   getEnv("CLOUDFLARE_ZONE_ID", ""),
+			CloudflareAPIToken: getEnv("CLOUDFLARE_API_TOKEN", ""),
+			FastlyServiceID:    getEnv("FASTLY_SERVICE_ID", ""),
+			FastlyAPIToken:     getEnv("FASTLY_API_TOKEN", ""),
+			PurgeByURL:         getBoolEnv("CDN_PURGE_BY_URL", false),
+			BatchSize:          getIntEnv("CDN_PURGE_BATCH_SIZE", 30),
+			FlushInterval:      getDurationEnv("CDN_PURGE_FLUSH_INTERVAL", 5*time.Second),
+			MaxAttempts:        getIntEnv("CDN_PURGE_MAX_ATTEMPTS", 3),
+		},
+		Webhooks: WebhooksConfig{
+			Enabled:   getBoolEnv("WEBHOOKS_ENABLED", true),
+			Timeout:   getDurationEnv("WEBHOOK_TIMEOUT", 10*time.Second),
+			AllowHTTP: getBoolEnv("WEBHOOK_ALLOW_HTTP", false),
+		},
+		Realtime: RealtimeConfig{
+			Enabled:        getBoolEnv("REALTIME_ENABLED", true),
+			AllowedOrigins: getStringSliceEnv("REALTIME_ALLOWED_ORIGINS", []string{"*"}),
+			PingInterval:   getDurationEnv("REALTIME_PING_INTERVAL", 30*time.Second),
+			MaxMessageSize: getInt64Env("REALTIME_MAX_MESSAGE_SIZE", 4096),
+			SendBuffer:     getIntEnv("REALTIME_SEND_BUFFER", 32),
+
+			SSEHeartbeatInterval: getDurationEnv("SSE_HEARTBEAT_INTERVAL", 15*time.Second),
+			HistorySize:          getIntEnv("REALTIME_HISTORY_SIZE", 256),
+			EventStore:           getEnv("REALTIME_EVENT_STORE", "memory"),
+			StreamMaxLen:         getInt64Env("REALTIME_STREAM_MAX_LEN", 1000),
+			EventRetention:       getDurationEnv("REALTIME_EVENT_RETENTION", 24*time.Hour),
+			PresenceTTL:          getDurationEnv("PRESENCE_TTL", 60*time.Second),
+			TypingTTL:            getDurationEnv("PRESENCE_TYPING_TTL", 5*time.Second),
+
+			MaxConnections:        getIntEnv("REALTIME_MAX_CONNECTIONS", 10000),
+			MaxConnectionsPerUser: getIntEnv("REALTIME_MAX_CONNECTIONS_PER_USER", 5),
+			SlowConsumerPolicy:    getEnv("REALTIME_SLOW_CONSUMER_POLICY", "disconnect"),
+			MaxDroppedMessages:    getIntEnv("REALTIME_MAX_DROPPED_MESSAGES", 100),
+		},
+		Storage: StorageConfig{
+			Backend:             getEnv("STORAGE_BACKEND", "local"),
+			Bucket:              getEnv("STORAGE_BUCKET", "uploads"),
+			LocalRoot:           getEnv("STORAGE_LOCAL_ROOT", "./data"),
+			Endpoint:            getEnv("S3_ENDPOINT", ""),
+			Region:              getEnv("S3_REGION", "us-east-1"),
+			AccessKeyID:         getEnv("S3_ACCESS_KEY_ID", ""),
+			SecretAccessKey:     getEnv("S3_SECRET_ACCESS_KEY", ""),
+			UsePathStyle:        getBoolEnv("S3_USE_PATH_STYLE", false),
+			LifecyclePolicyFile: getEnv("STORAGE_LIFECYCLE_POLICY_FILE", ""),
+			LifecycleSchedule:   getEnv("SCHEDULE_STORAGE_LIFECYCLE", "0 2 * * *"),
+			OrphanSchedule:      getEnv("SCHEDULE_STORAGE_ORPHANS", "0 5 * * 0"),
+			OrphanGracePeriod:   getDurationEnv("STORAGE_ORPHAN_GRACE_PERIOD", 24*time.Hour),
+			DeleteOrphans:       getBoolEnv("STORAGE_DELETE_ORPHANS", false),
+		},
+		Uploads: UploadsConfig{
+			Enabled: getBoolEnv("UPLOADS_ENABLED", true),
+			MaxSize: getInt64Env("UPLOAD_MAX_SIZE", 1<<30),
+			Expiry:  getDurationEnv("UPLOAD_EXPIRY", 24*time.Hour),
+		},
+		Email: EmailConfig{
+			Transport:       getEnv("EMAIL_TRANSPORT", "log"),
+			From:            getEnv("EMAIL_FROM", "Go Server <no-reply@localhost>"),
+			AppName:         getEnv("EMAIL_APP_NAME", "Go Server"),
+			DefaultLocale:   getEnv("EMAIL_DEFAULT_LOCALE", "en"),
+			SMTPHost:        getEnv("SMTP_HOST", "localhost"),
+			SMTPPort:        getIntEnv("SMTP_PORT", 587),
+			SMTPUsername:    getEnv("SMTP_USERNAME", ""),
+			SMTPPassword:    getEnv("SMTP_PASSWORD", ""),
+			PreviewsEnabled: getBoolEnv("EMAIL_PREVIEWS_ENABLED", false),
+			WebhookSecret:   getEnv("EMAIL_WEBHOOK_SECRET", ""),
+			WelcomeEmails:   getBoolEnv("EMAIL_WELCOME_ENABLED", true),
+
+			LinkBaseURL:           getEnv("EMAIL_LINK_BASE_URL", "http://localhost:8080"),
+			EmailChangeConfirmTTL: getDurationEnv("EMAIL_CHANGE_CONFIRM_TTL", 24*time.Hour),
+			EmailChangeRevertTTL:  getDurationEnv("EMAIL_CHANGE_REVERT_TTL", 7*24*time.Hour),
+
+			ReplyDomain:           getEnv("EMAIL_REPLY_DOMAIN", ""),
+			ReplySecret:           getEnv("EMAIL_REPLY_SECRET", ""),
+			MailgunSigningKey:     getEnv("EMAIL_MAILGUN_SIGNING_KEY", ""),
+			SendGridInboundSecret: getEnv("EMAIL_SENDGRID_INBOUND_SECRET", ""),
+			SESTopicARN:           getEnv("EMAIL_SES_TOPIC_ARN", ""),
+		},
+		GraphQL: GraphQLConfig{
+			Enabled:       getBoolEnv("GRAPHQL_ENABLED", true),
+			MaxDepth:      getIntEnv("GRAPHQL_MAX_DEPTH", 8),
+			MaxComplexity: getIntEnv("GRAPHQL_MAX_COMPLEXITY", 1000),
+		},
+		Notify: NotificationsConfig{
+			Enabled:            getBoolEnv("NOTIFY_ENABLED", true),
+			DefaultChannel:     getEnv("NOTIFY_DEFAULT_CHANNEL", "push"),
+			Rules:              getEnv("NOTIFY_CHANNEL_RULES", ""),
+			TwilioAccountSID:   getEnv("TWILIO_ACCOUNT_SID", ""),
+			TwilioAuthToken:    getEnv("TWILIO_AUTH_TOKEN", ""),
+			TwilioFrom:         getEnv("TWILIO_FROM", ""),
+			TwilioCallbackURL:  getEnv("TWILIO_STATUS_CALLBACK_URL", ""),
+			FCMProjectID:       getEnv("FCM_PROJECT_ID", ""),
+			FCMCredentialsFile: getEnv("FCM_CREDENTIALS_FILE", ""),
+			APNsKeyFile:        getEnv("APNS_KEY_FILE", ""),
+			APNsKeyID:          getEnv("APNS_KEY_ID", ""),
+			APNsTeamID:         getEnv("APNS_TEAM_ID", ""),
+			APNsTopic:          getEnv("APNS_TOPIC", ""),
+			APNsProduction:     getBoolEnv("APNS_PRODUCTION", false),
+			WebhookSecret:      getEnv("NOTIFY_WEBHOOK_SECRET", ""),
+			DigestSchedule:     getEnv("NOTIFY_DIGEST_SCHEDULE", "*/15 * * * *"),
+			DigestFrequency:    getEnv("NOTIFY_DIGEST_FREQUENCY", "daily"),
+		},
+		Approvals: ApprovalsConfig{
+			Required: getBoolEnv("APPROVALS_REQUIRED", false),
+			TTL:      getDurationEnv("APPROVALS_TTL", time.Hour),
+		},
+		BreakGlass: BreakGlassConfig{
+			PublicKey: getEnv("BREAK_GLASS_PUBLIC_KEY", ""),
+			MaxTTL:    getDurationEnv("BREAK_GLASS_MAX_TTL", 4*time.Hour),
+		},
+		Reporting: ReportingConfig{
+			SentryDSN:   getEnv("SENTRY_DSN", ""),
+			Environment: getEnv("SENTRY_ENVIRONMENT", "development"),
+			Release:     getEnv("APP_RELEASE", "go-server@1.0.0"),
+			QueueSize:   getIntEnv("SENTRY_QUEUE_SIZE", 100),
+		},
+		Runbook: RunbookConfig{
+			MaxRuns: getIntEnv("RUNBOOK_MAX_RUNS", 3),
+			Window:  getDurationEnv("RUNBOOK_WINDOW", 10*time.Minute),
+		},
+		Reports: ReportsConfig{
+			Bucket:        getEnv("REPORTS_BUCKET", "reports"),
+			LinkSecret:    getEnv("REPORTS_LINK_SECRET", ""),
+			LinkTTL:       getDurationEnv("REPORTS_LINK_TTL", 15*time.Minute),
+			Retention:     getDurationEnv("REPORTS_RETENTION", 7*24*time.Hour),
+			MaxPeriod:     getDurationEnv("REPORTS_MAX_PERIOD", 366*24*time.Hour),
+			PurgeSchedule: getEnv("SCHEDULE_REPORTS_PURGE", "30 * * * *"),
+		},
+		Warmup: WarmupConfig{
+			Skip:        getBoolEnv("CACHE_WARMUP_SKIP", false),
+			Budget:      getDurationEnv("CACHE_WARMUP_BUDGET", 15*time.Second),
+			TopPosts:    getIntEnv("CACHE_WARMUP_TOP_POSTS", 50),
+			ActiveUsers: getIntEnv("CACHE_WARMUP_ACTIVE_USERS", 100),
+			TTL:         getDurationEnv("CACHE_WARMUP_TTL", 30*time.Minute),
+		},
+		Secrets: SecretsConfig{
+			Dir:                getEnv("SECRETS_DIR", "/run/secrets"),
+			Backend:            getEnv("SECRETS_BACKEND", ""),
+			RefreshInterval:    getDurationEnv("SECRETS_REFRESH_INTERVAL", time.Minute),
+			VaultAddress:       getEnv("VAULT_ADDR", ""),
+			VaultToken:         getEnv("VAULT_TOKEN", ""),
+			VaultMount:         getEnv("VAULT_KV_MOUNT", "secret"),
+			VaultPath:          getEnv("VAULT_SECRET_PATH", "go-server"),
+			VaultNamespace:     getEnv("VAULT_NAMESPACE", ""),
+			AWSRegion:          getEnv("AWS_REGION", ""),
+			AWSSecretID:        getEnv("AWS_SECRET_ID", ""),
+			AWSEndpoint:        getEnv("AWS_SECRETS_MANAGER_ENDPOINT", ""),
+			AWSAccessKeyID:     getEnv("AWS_ACCESS_KEY_ID", ""),
+			AWSSecretAccessKey: getEnv("AWS_SECRET_ACCESS_KEY", ""),
+			AWSSessionToken:    getEnv("AWS_SESSION_TOKEN", ""),
+		},
+		JWT: JWTConfig{
+			Algorithm:        getEnv("JWT_ALGORITHM", "HS256"),
+			PrivateKeyFile:   getEnv("JWT_PRIVATE_KEY_FILE", ""),
+			RotationInterval: getDurationEnv("JWT_KEY_ROTATION_INTERVAL", 0),
+			RotationLead:     getDurationEnv("JWT_KEY_ROTATION_LEAD", time.Hour),
+			GracePeriod:      getDurationEnv("JWT_KEY_GRACE_PERIOD", 0),
+			KMS:              getBoolEnv("JWT_KMS_SIGNING", false),
+		},
+		Deploy: DeployConfig{
+			ControllerURL:     getEnv("DEPLOY_CONTROLLER_URL", ""),
+			ControllerSecret:  getEnv("DEPLOY_CONTROLLER_SECRET", ""),
+			BakePeriod:        getDurationEnv("DEPLOY_BAKE_PERIOD", 15*time.Minute),
+			ReportInterval:    getDurationEnv("DEPLOY_REPORT_INTERVAL", 30*time.Second),
+			ErrorBudget:       getFloatEnv("DEPLOY_ERROR_BUDGET", 0.01),
+			MaxLatencyRatio:   getFloatEnv("DEPLOY_MAX_LATENCY_RATIO", 1.5),
+			MinRequests:       getIntEnv("DEPLOY_MIN_REQUESTS", 100),
+			BaselineErrorRate: getFloatEnv("DEPLOY_BASELINE_ERROR_RATE", 0),
+			BaselineP95:       getDurationEnv("DEPLOY_BASELINE_P95", 0),
+		},
+		Session: SessionConfig{
+			IdleTimeout:    getDurationEnv("SESSION_IDLE_TIMEOUT", 2*time.Hour),
+			MaxLifetime:    getDurationEnv("SESSION_MAX_LIFETIME", 24*time.Hour),
+			CookiesEnabled: getBoolEnv("SESSION_COOKIES_ENABLED", false),
+			CookieName:     getEnv("SESSION_COOKIE_NAME", "session"),
+			CookieDomain:   getEnv("SESSION_COOKIE_DOMAIN", ""),
+			CookiePath:     getEnv("SESSION_COOKIE_PATH", "/"),
+			CookieSameSite: getEnv("SESSION_COOKIE_SAMESITE", "lax"),
+			CookieSecure:   getBoolEnv("SESSION_COOKIE_SECURE", true),
+		},
+		Usage: UsageConfig{
+			Enabled:       getBoolEnv("USAGE_TELEMETRY_ENABLED", false),
+			SampleRate:    getFloatEnv("USAGE_SAMPLE_RATE", 0.1),
+			FlushInterval: getDurationEnv("USAGE_FLUSH_INTERVAL", time.Minute),
+		},
+		UserIndex: UserIndexConfig{
+			Enabled:     getBoolEnv("USER_INDEX_ENABLED", true),
+			TTL:         getDurationEnv("USER_INDEX_TTL", time.Hour),
+			NegativeTTL: getDurationEnv("USER_INDEX_NEGATIVE_TTL", 30*time.Second),
+		},
+		Usernames: UsernamesConfig{
+			Reserved:     getStringSliceEnv("USERNAME_RESERVED", nil),
+			BlockedWords: getStringSliceEnv("USERNAME_BLOCKED_WORDS", nil),
+		},
+		Accounts: AccountsConfig{
+			ReactivationCooldown: getDurationEnv("USER_REACTIVATION_COOLDOWN", 24*time.Hour),
+		},
+		Audit: AuditConfig{
+			Enabled:   getBoolEnv("AUDIT_LOG_ENABLED", false),
+			Bucket:    getEnv("AUDIT_LOG_BUCKET", "audit"),
+			Prefix:    getEnv("AUDIT_LOG_PREFIX", "events/"),
+			Retention: getDurationEnv("AUDIT_LOG_RETENTION", 7*365*24*time.Hour),
+		},
+		KMS: KMSConfig{
+			Backend:              getEnv("KMS_BACKEND", ""),
+			KeyFile:              getEnv("KMS_KEY_FILE", ""),
+			DataKeyLifetime:      getDurationEnv("KMS_DATA_KEY_LIFETIME", time.Hour),
+			AWSRegion:            getEnv("AWS_REGION", ""),
+			AWSKeyID:             getEnv("KMS_AWS_KEY_ID", ""),
+			AWSSigningKeyID:      getEnv("KMS_AWS_SIGNING_KEY_ID", ""),
+			AWSEndpoint:          getEnv("KMS_AWS_ENDPOINT", ""),
+			AWSAccessKeyID:       getEnv("AWS_ACCESS_KEY_ID", ""),
+			AWSSecretAccessKey:   getEnv("AWS_SECRET_ACCESS_KEY", ""),
+			AWSSessionToken:      getEnv("AWS_SESSION_TOKEN", ""),
+			GCPKeyName:           getEnv("KMS_GCP_KEY_NAME", ""),
+			GCPSigningKeyVersion: getEnv("KMS_GCP_SIGNING_KEY_VERSION", ""),
+			GCPEndpoint:          getEnv("KMS_GCP_ENDPOINT", ""),
+			GCPAccessToken:       getEnv("KMS_GCP_ACCESS_TOKEN", ""),
+		},
+		Crypto: CryptoConfig{
+			Mode:         getEnv("CRYPTO_MODE", cryptopolicy.DefaultMode()),
+			PasswordHash: getEnv("PASSWORD_HASH", ""),
+		},
+		Deps: DependenciesConfig{
+			Schedule:      getEnv("DEPENDENCY_CHECK_SCHEDULE", "0 6 * * *"),
+			VulnDB:        getEnv("DEPENDENCY_VULN_DB", "https://vuln.go.dev"),
+			EOLFeed:       getEnv("DEPENDENCY_EOL_FEED", "https://endoflife.date/api/go.json"),
+			ModuleEOLFeed: getEnv("DEPENDENCY_MODULE_EOL_FEED", ""),
+			EOLWarning:    getDurationEnv("DEPENDENCY_EOL_WARNING", 90*24*time.Hour),
+			Timeout:       getDurationEnv("DEPENDENCY_CHECK_TIMEOUT", 30*time.Second),
+		},
+		Abuse: AbuseConfig{
+			Enabled:               getBoolEnv("ABUSE_DETECTION_ENABLED", false),
+			SuspiciousAgents:      getStringSliceEnv("ABUSE_SUSPICIOUS_AGENTS", nil),
+			MaxRequestsPerSecond:  getIntEnv("ABUSE_MAX_REQUESTS_PER_SECOND", 50),
+			MaxValidationFailures: getIntEnv("ABUSE_MAX_VALIDATION_FAILURES", 20),
+			FailureWindow:         getDurationEnv("ABUSE_FAILURE_WINDOW", 5*time.Minute),
+			BanDuration:           getDurationEnv("ABUSE_BAN_DURATION", 15*time.Minute),
+		},
+		Calendar: CalendarConfig{
+			FeedSecret: getEnv("CALENDAR_FEED_SECRET", ""),
+			LinkTTL:    getDurationEnv("CALENDAR_LINK_TTL", 90*24*time.Hour),
+			Lookback:   getDurationEnv("CALENDAR_LOOKBACK", 30*24*time.Hour),
+			Horizon:    getDurationEnv("CALENDAR_HORIZON", 180*24*time.Hour),
+		},
+		IdP: IdPConfig{
+			Issuer:        getEnv("IDP_ISSUER", ""),
+			Audience:      getEnv("IDP_AUDIENCE", ""),
+			JWKSURL:       getEnv("IDP_JWKS_URL", ""),
+			JWKSCacheTTL:  getDurationEnv("IDP_JWKS_CACHE_TTL", time.Hour),
+			LinkByEmail:   getBoolEnv("IDP_LINK_BY_EMAIL", false),
+			AutoProvision: getBoolEnv("IDP_AUTO_PROVISION", false),
+		},
+		File: path,
+		Broker: BrokerConfig{
+			Driver:        getEnv("BROKER_DRIVER", "none"),
+			URL:           getEnv("BROKER_URL", ""),
+			TopicPrefix:   getEnv("BROKER_TOPIC_PREFIX", "events."),
+			ConsumerGroup: getEnv("BROKER_CONSUMER_GROUP", "go-server"),
+			Timeout:       getDurationEnv("BROKER_TIMEOUT", 10*time.Second),
 		},
 	}
+	if fileErr != nil {
+		return nil, fileErr
+	}
 
 	if err := config.Validate(); err != nil {
 		return nil, fmt.Errorf("configuration validation failed: %w", err)
@@ -108,6 +1152,22 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("shutdown timeout must be positive")
 	}
 
+	if c.Server.RequestTimeout < 0 {
+		return fmt.Errorf("request timeout cannot be negative")
+	}
+
+	if c.Server.RequestTimeout >= c.Server.WriteTimeout {
+		return fmt.Errorf("request timeout must be shorter than the write timeout, or the 504 cannot be sent")
+	}
+
+	if _, err := ParseRouteTimeouts(c.Server.RouteTimeouts); err != nil {
+		return err
+	}
+
+	if c.Server.RoutePanicThreshold < 0 {
+		return fmt.Errorf("route panic threshold cannot be negative")
+	}
+
 	if c.Security.MaxRequestSize <= 0 {
 		return fmt.Errorf("max request size must be positive")
 	}
@@ -120,6 +1180,384 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("rate limit burst must be positive")
 	}
 
+	rules, err := geoip.ParseRules(c.Security.RateLimitMultipliers)
+	if err != nil {
+		return err
+	}
+	if len(rules) > 0 && c.Security.GeoIPDatabase == "" {
+		return fmt.Errorf("rate limit multipliers need a GeoIP database")
+	}
+
+	if _, err := secheaders.ParsePolicy(c.Security.ContentSecurityPolicy); err != nil {
+		return fmt.Errorf("invalid content security policy: %w", err)
+	}
+	if c.Security.HSTSMaxAge < 0 {
+		return fmt.Errorf("HSTS max age cannot be negative")
+	}
+	if c.Security.HSTSPreload && (!c.Security.HSTSIncludeSubDomains || c.Security.HSTSMaxAge < 365*24*time.Hour) {
+		return fmt.Errorf("HSTS preload needs HSTS_INCLUDE_SUBDOMAINS and a max age of at least a year")
+	}
+
+	if _, err := clientip.ParseTrustedProxies(c.Security.TrustedProxies); err != nil {
+		return err
+	}
+
+	if c.Logging.Level != "" {
+		if _, err := logger.ParseLevel(c.Logging.Level); err != nil {
+			return err
+		}
+	}
+
+	if c.Retention.SoftDeleteRetention < 0 {
+		return fmt.Errorf("soft delete retention cannot be negative")
+	}
+
+	if c.Jobs.Concurrency < 0 || c.Jobs.MaxAttempts < 0 || c.Jobs.PollInterval < 0 {
+		return fmt.Errorf("job settings cannot be negative")
+	}
+
+	if c.API.PublicIDFormat != "" && c.API.PublicIDFormat != "uuidv7" && c.API.PublicIDFormat != "ulid" {
+		return fmt.Errorf("public id format must be uuidv7 or ulid")
+	}
+
+	switch c.API.SchemaValidation {
+	case "", "off", "log", "strict":
+	default:
+		return fmt.Errorf("schema validation must be off, log or strict")
+	}
+
+	if c.HTTPCache.MaxAge < 0 || c.HTTPCache.SMaxAge < 0 ||
+		c.HTTPCache.StaleWhileRevalidate < 0 || c.HTTPCache.StaleIfError < 0 {
+		return fmt.Errorf("http cache durations cannot be negative")
+	}
+
+	if c.Compress.MinSize < 0 {
+		return fmt.Errorf("compression minimum size cannot be negative")
+	}
+
+	if c.Compress.Level < -2 || c.Compress.Level > 9 {
+		return fmt.Errorf("compression level must be between -2 and 9")
+	}
+
+	if c.Outbox.PollInterval < 0 || c.Outbox.BatchSize < 0 || c.Outbox.Retention < 0 {
+		return fmt.Errorf("outbox settings cannot be negative")
+	}
+
+	switch c.Broker.Driver {
+	case "", "none":
+	case "kafka", "nats":
+		schemes := []string{"http://", "https://"}
+		if c.Broker.Driver == "nats" {
+			schemes = []string{"nats://", "tls://"}
+		}
+		if !strings.HasPrefix(c.Broker.URL, schemes[0]) && !strings.HasPrefix(c.Broker.URL, schemes[1]) {
+			return fmt.Errorf("%s broker requires a %s or %s BROKER_URL", c.Broker.Driver, schemes[0], schemes[1])
+		}
+		if c.Broker.ConsumerGroup == "" || strings.ContainsAny(c.Broker.TopicPrefix+c.Broker.ConsumerGroup, " \t\r\n") {
+			return fmt.Errorf("broker consumer group is required, and it and the topic prefix cannot contain spaces")
+		}
+		if c.Broker.Timeout <= 0 {
+			return fmt.Errorf("broker timeout must be positive")
+		}
+	default:
+		return fmt.Errorf("broker driver must be none, kafka or nats")
+	}
+
+	switch c.CDN.Provider {
+	case "", "none":
+	case "cloudflare":
+		if c.CDN.CloudflareZoneID == "" || c.CDN.CloudflareAPIToken == "" {
+			return fmt.Errorf("cloudflare CDN requires a zone ID and API token")
+		}
+	case "fastly":
+		if c.CDN.FastlyServiceID == "" || c.CDN.FastlyAPIToken == "" {
+			return fmt.Errorf("fastly CDN requires a service ID and API token")
+		}
+	default:
+		return fmt.Errorf("unsupported CDN provider: %s", c.CDN.Provider)
+	}
+
+	if c.CDN.BatchSize < 0 || c.CDN.FlushInterval < 0 || c.CDN.MaxAttempts < 0 {
+		return fmt.Errorf("CDN purge settings cannot be negative")
+	}
+
+	if c.Webhooks.Timeout < 0 {
+		return fmt.Errorf("webhook timeout cannot be negative")
+	}
+
+	if c.Realtime.PingInterval < 0 || c.Realtime.MaxMessageSize < 0 || c.Realtime.SendBuffer < 0 ||
+		c.Realtime.SSEHeartbeatInterval < 0 || c.Realtime.HistorySize < 0 ||
+		c.Realtime.MaxConnections < 0 || c.Realtime.MaxConnectionsPerUser < 0 || c.Realtime.MaxDroppedMessages < 0 ||
+		c.Realtime.StreamMaxLen < 0 || c.Realtime.EventRetention < 0 ||
+		c.Realtime.PresenceTTL < 0 || c.Realtime.TypingTTL < 0 {
+		return fmt.Errorf("realtime settings cannot be negative")
+	}
+	switch c.Realtime.SlowConsumerPolicy {
+	case "", "disconnect", "drop_newest", "drop_oldest":
+	default:
+		return fmt.Errorf("invalid realtime slow consumer policy: %s", c.Realtime.SlowConsumerPolicy)
+	}
+	switch c.Realtime.EventStore {
+	case "", "memory", "redis":
+	default:
+		return fmt.Errorf("invalid realtime event store: %s", c.Realtime.EventStore)
+	}
+
+	switch c.Storage.Backend {
+	case "", "local":
+	case "s3":
+		if c.Storage.Bucket == "" || c.Storage.AccessKeyID == "" || c.Storage.SecretAccessKey == "" {
+			return fmt.Errorf("s3 storage requires a bucket and credentials")
+		}
+	default:
+		return fmt.Errorf("unsupported storage backend: %s", c.Storage.Backend)
+	}
+
+	if c.Storage.OrphanGracePeriod < 0 {
+		return fmt.Errorf("storage orphan grace period cannot be negative")
+	}
+
+	if c.Uploads.MaxSize < 0 || c.Uploads.Expiry < 0 {
+		return fmt.Errorf("upload settings cannot be negative")
+	}
+
+	switch c.Email.Transport {
+	case "", "log", "smtp":
+	default:
+		return fmt.Errorf("unsupported email transport: %s", c.Email.Transport)
+	}
+	if c.Email.EmailChangeConfirmTTL < 0 || c.Email.EmailChangeRevertTTL < 0 {
+		return fmt.Errorf("email change link lifetimes cannot be negative")
+	}
+	if c.Email.LinkBaseURL != "" {
+		if link, err := url.Parse(c.Email.LinkBaseURL); err != nil || (link.Scheme != "http" && link.Scheme != "https") || link.Host == "" {
+			return fmt.Errorf("email link base URL must be an http or https URL: %q", c.Email.LinkBaseURL)
+		}
+	}
+	if c.Email.ReplyDomain != "" {
+		if len(c.Email.ReplySecret) < 32 {
+			return fmt.Errorf("email reply secret must be at least 32 characters")
+		}
+		if c.Email.MailgunSigningKey == "" && c.Email.SendGridInboundSecret == "" && c.Email.SESTopicARN == "" {
+			return fmt.Errorf("reply-by-email requires an inbound provider credential")
+		}
+	}
+
+	if c.GraphQL.MaxDepth < 0 || c.GraphQL.MaxComplexity < 0 {
+		return fmt.Errorf("GraphQL limits cannot be negative")
+	}
+
+	switch c.Notify.DefaultChannel {
+	case "", "push", "sms", "email", "in_app":
+	default:
+		return fmt.Errorf("unsupported notification channel: %s", c.Notify.DefaultChannel)
+	}
+
+	switch c.Notify.DigestFrequency {
+	case "", "immediate", "hourly", "daily", "weekly":
+	default:
+		return fmt.Errorf("unsupported digest frequency: %s", c.Notify.DigestFrequency)
+	}
+
+	if c.Approvals.Required && c.Approvals.TTL <= 0 {
+		return fmt.Errorf("approval TTL must be positive when approvals are required")
+	}
+
+	if err := cors.ValidateOrigins(c.Security.CORSOrigins); err != nil {
+		return err
+	}
+	if _, err := cors.ParseGroups(c.Security.CORSRouteGroups, cors.Policy{}); err != nil {
+		return err
+	}
+	if err := cors.ValidateOrigins(c.Realtime.AllowedOrigins); err != nil {
+		return fmt.Errorf("realtime: %w", err)
+	}
+
+	if _, err := errors.ParseFormat(c.API.ErrorFormat); err != nil {
+		return err
+	}
+
+	if c.BreakGlass.PublicKey != "" {
+		key, err := base64.StdEncoding.DecodeString(c.BreakGlass.PublicKey)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return fmt.Errorf("break-glass public key must be a base64 Ed25519 public key")
+		}
+		if c.BreakGlass.MaxTTL <= 0 {
+			return fmt.Errorf("break-glass max TTL must be positive")
+		}
+	}
+
+	if c.Runbook.MaxRuns < 0 || c.Runbook.Window < 0 {
+		return fmt.Errorf("runbook limits cannot be negative")
+	}
+
+	if c.Reports.LinkSecret != "" && len(c.Reports.LinkSecret) < 32 {
+		return fmt.Errorf("reports link secret must be at least 32 characters")
+	}
+	if c.Reports.LinkTTL < 0 || c.Reports.Retention < 0 || c.Reports.MaxPeriod < 0 {
+		return fmt.Errorf("reports durations cannot be negative")
+	}
+
+	if c.Warmup.Budget < 0 || c.Warmup.TopPosts < 0 || c.Warmup.ActiveUsers < 0 || c.Warmup.TTL < 0 {
+		return fmt.Errorf("cache warmup settings cannot be negative")
+	}
+
+	switch c.JWT.Algorithm {
+	case "", "HS256", "RS256", "EdDSA":
+	default:
+		return fmt.Errorf("unsupported JWT algorithm: %s", c.JWT.Algorithm)
+	}
+	if c.JWT.PrivateKeyFile != "" && (c.JWT.Algorithm == "" || c.JWT.Algorithm == "HS256") {
+		return fmt.Errorf("a JWT private key file requires the RS256 or EdDSA algorithm")
+	}
+	if c.JWT.RotationInterval < 0 || c.JWT.RotationLead < 0 || c.JWT.GracePeriod < 0 {
+		return fmt.Errorf("JWT key rotation settings cannot be negative")
+	}
+	if c.JWT.RotationInterval > 0 && c.JWT.RotationLead >= c.JWT.RotationInterval {
+		return fmt.Errorf("JWT key rotation lead must be shorter than the rotation interval")
+	}
+	if c.JWT.KMS {
+		if c.KMS.Backend == "" {
+			return fmt.Errorf("JWT signing with the KMS requires a KMS backend")
+		}
+		if c.JWT.Algorithm != "RS256" || c.JWT.PrivateKeyFile != "" || c.JWT.RotationInterval > 0 {
+			return fmt.Errorf("JWT signing with the KMS requires RS256 without a private key file or scheduled rotation")
+		}
+	}
+
+	if c.Deploy.ControllerURL != "" {
+		if u, err := url.Parse(c.Deploy.ControllerURL); err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+			return fmt.Errorf("deploy controller URL must be an http(s) URL")
+		}
+	}
+	if c.Deploy.BakePeriod < 0 || c.Deploy.ReportInterval < 0 || c.Deploy.ErrorBudget < 0 || c.Deploy.MaxLatencyRatio < 0 ||
+		c.Deploy.MinRequests < 0 || c.Deploy.BaselineErrorRate < 0 || c.Deploy.BaselineP95 < 0 {
+		return fmt.Errorf("deploy health settings cannot be negative")
+	}
+
+	for name, feed := range map[string]string{
+		"vulnerability database":  c.Deps.VulnDB,
+		"end-of-life feed":        c.Deps.EOLFeed,
+		"module end-of-life feed": c.Deps.ModuleEOLFeed,
+	} {
+		if u, err := url.Parse(feed); feed != "" && (err != nil || (u.Scheme != "" && u.Scheme != "https" && u.Scheme != "http" && u.Scheme != "file")) {
+			return fmt.Errorf("dependency %s must be an http(s) or file URL, or a path", name)
+		}
+	}
+	if c.Deps.EOLWarning < 0 || c.Deps.Timeout < 0 {
+		return fmt.Errorf("dependency check durations cannot be negative")
+	}
+
+	if c.Session.IdleTimeout < 0 || c.Session.MaxLifetime < 0 {
+		return fmt.Errorf("session timeouts cannot be negative")
+	}
+	if c.Session.IdleTimeout > 0 && c.Session.MaxLifetime > 0 && c.Session.IdleTimeout > c.Session.MaxLifetime {
+		return fmt.Errorf("session idle timeout cannot exceed the maximum lifetime")
+	}
+	if c.Session.CookiesEnabled {
+		switch strings.ToLower(c.Session.CookieSameSite) {
+		case "strict", "lax":
+		case "none":
+			if !c.Session.CookieSecure {
+				return fmt.Errorf("SameSite=None session cookies require SESSION_COOKIE_SECURE")
+			}
+		default:
+			return fmt.Errorf("session cookie SameSite must be strict, lax or none")
+		}
+	}
+
+	if c.Calendar.FeedSecret != "" && len(c.Calendar.FeedSecret) < 32 {
+		return fmt.Errorf("calendar feed secret must be at least 32 characters")
+	}
+	if c.Calendar.LinkTTL < 0 || c.Calendar.Lookback < 0 || c.Calendar.Horizon < 0 {
+		return fmt.Errorf("calendar durations cannot be negative")
+	}
+
+	if c.Usage.SampleRate < 0 || c.Usage.SampleRate > 1 {
+		return fmt.Errorf("usage sample rate must be between 0 and 1")
+	}
+	if c.Usage.FlushInterval < 0 {
+		return fmt.Errorf("usage flush interval cannot be negative")
+	}
+
+	if c.UserIndex.TTL < 0 || c.UserIndex.NegativeTTL < 0 {
+		return fmt.Errorf("user index TTLs cannot be negative")
+	}
+	if c.Accounts.ReactivationCooldown < 0 {
+		return fmt.Errorf("reactivation cool-down cannot be negative")
+	}
+	if c.Audit.Enabled && c.Audit.Bucket == "" {
+		return fmt.Errorf("audit log bucket is required when the audit log is enabled")
+	}
+	if c.Audit.Retention < 0 {
+		return fmt.Errorf("audit log retention cannot be negative")
+	}
+	if c.KMS.Backend != "" {
+		// The local keyfile is read when the provider is opened, not here
+		if c.KMS.Backend == kms.BackendLocal {
+			if c.KMS.KeyFile == "" {
+				return fmt.Errorf("the local KMS backend requires KMS_KEY_FILE")
+			}
+		} else if _, err := kms.New(c.KMS.Provider()); err != nil {
+			return fmt.Errorf("invalid KMS configuration: %w", err)
+		}
+	}
+	if c.KMS.DataKeyLifetime < 0 {
+		return fmt.Errorf("KMS data key lifetime cannot be negative")
+	}
+
+	switch c.Crypto.Mode {
+	case "", cryptopolicy.ModeStandard, cryptopolicy.ModeFIPS:
+	default:
+		return fmt.Errorf("crypto mode must be standard or fips")
+	}
+	if cryptopolicy.BuiltRestricted() && !c.Crypto.Restricted() {
+		return fmt.Errorf("this binary was built with the fips tag and only runs in fips crypto mode")
+	}
+	switch c.Crypto.PasswordHash {
+	case "", passwordBcrypt, passwordPBKDF2:
+	default:
+		return fmt.Errorf("password hash must be bcrypt or pbkdf2-sha256")
+	}
+	if c.Crypto.Restricted() {
+		if err := cryptopolicy.Verify(c.CryptoUses()); err != nil {
+			return fmt.Errorf("fips crypto mode: %w", err)
+		}
+	}
+
+	if c.Abuse.MaxRequestsPerSecond < 0 || c.Abuse.MaxValidationFailures < 0 {
+		return fmt.Errorf("abuse detection thresholds cannot be negative")
+	}
+	if c.Abuse.FailureWindow < 0 || c.Abuse.BanDuration < 0 {
+		return fmt.Errorf("abuse detection durations cannot be negative")
+	}
+
+	if c.IdP.Issuer != "" {
+		// go-server is the issuer of our own tokens
+		if c.IdP.Issuer == "go-server" {
+			return fmt.Errorf("identity provider issuer must differ from the server's own")
+		}
+		if c.IdP.Audience == "" {
+			return fmt.Errorf("identity provider audience is required")
+		}
+		if u, err := url.Parse(c.IdP.JWKSURL); err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+			return fmt.Errorf("identity provider JWKS URL must be an http(s) URL")
+		}
+		if c.IdP.JWKSCacheTTL < 0 {
+			return fmt.Errorf("identity provider JWKS cache TTL cannot be negative")
+		}
+	}
+
+	if _, err := secrets.New(c.Secrets.Provider()); err != nil {
+		return fmt.Errorf("invalid secrets configuration: %w", err)
+	}
+
+	if c.Reporting.SentryDSN != "" {
+		if _, err := reporting.ParseDSN(c.Reporting.SentryDSN); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -130,15 +1568,28 @@ func (c *Config) GetServerAddress() string {
 
 // Helper functions for environment variable parsing
 
+// lookupEnv returns the variable from the environment, or the file named by
+// key_FILE, falling back to the config file being loaded
+func lookupEnv(key string) string {
+	value, ok, err := secrets.LookupEnv(key)
+	if err != nil && fileErr == nil {
+		fileErr = err
+	}
+	if ok {
+		return value
+	}
+	return fileValues[key]
+}
+
 func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
 
 func getIntEnv(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key); value != "" {
 		if intValue, err := strconv.Atoi(value); err == nil {
 			return intValue
 		}
@@ -147,7 +1598,7 @@ func getIntEnv(key string, defaultValue int) int {
 }
 
 func getInt64Env(key string, defaultValue int64) int64 {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key); value != "" {
 		if intValue, err := strconv.ParseInt(value, 10, 64); err == nil {
 			return intValue
 		}
@@ -155,8 +1606,17 @@ func getInt64Env(key string, defaultValue int64) int64 {
 	return defaultValue
 }
 
+func getFloatEnv(key string, defaultValue float64) float64 {
+	if value := lookupEnv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
 func getBoolEnv(key string, defaultValue bool) bool {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key); value != "" {
 		if boolValue, err := strconv.ParseBool(value); err == nil {
 			return boolValue
 		}
@@ -165,7 +1625,7 @@ func getBoolEnv(key string, defaultValue bool) bool {
 }
 
 func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
 			return duration
 		}
@@ -173,11 +1633,18 @@ func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
 	return defaultValue
 }
 
+// getStringSliceEnv reads a comma-separated list, which is also how list
+// values from a config file arrive. Blank items are dropped; a value with
+// no items leaves the default.
 func getStringSliceEnv(key string, defaultValue []string) []string {
-	if value := os.Getenv(key); value != "" {
-		// Simple comma-separated values parsing
-		// In production, you might want more sophisticated parsing
-		return []string{value}
+	var items []string
+	for _, item := range strings.Split(lookupEnv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
-	return defaultValue
+	if len(items) == 0 {
+		return defaultValue
+	}
+	return items
 }
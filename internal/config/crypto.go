@@ -0,0 +1,82 @@
+package config
+
+import "go-server/internal/cryptopolicy"
+
+// CryptoUses lists the algorithms the configuration puts to use, for the
+// crypto report and the fips mode's startup check. Algorithms the fips
+// mode swaps for approved ones, such as MD5 checksums, are only listed in
+// standard mode.
+func (c *Config) CryptoUses() []cryptopolicy.Use {
+	restricted := c.Crypto.Restricted()
+	use := cryptopolicy.NewUse
+
+	password := cryptopolicy.Bcrypt
+	if c.Crypto.PasswordScheme() == passwordPBKDF2 {
+		password = cryptopolicy.PBKDF2SHA256
+	}
+	tokens := cryptopolicy.HMACSHA256
+	switch c.JWT.Algorithm {
+	case "RS256":
+		tokens = cryptopolicy.RSASHA256
+	case "EdDSA":
+		tokens = cryptopolicy.Ed25519
+	}
+
+	uses := []cryptopolicy.Use{
+		use("password hashing", password),
+		use("access token signatures", tokens),
+		use("session and API key digests", cryptopolicy.SHA256),
+		use("webhook signatures", cryptopolicy.HMACSHA256),
+		use("WebSocket handshake", cryptopolicy.SHA1),
+		use("upload checksums", cryptopolicy.SHA1),
+		use("upload checksums", cryptopolicy.SHA256),
+	}
+	if !restricted {
+		uses = append(uses, use("upload checksums", cryptopolicy.MD5))
+	}
+
+	if c.KMS.Backend != "" {
+		uses = append(uses, use("field encryption", cryptopolicy.AES256GCM))
+	}
+	if c.BreakGlass.PublicKey != "" {
+		uses = append(uses, use("break-glass tokens", cryptopolicy.Ed25519))
+	}
+	if c.Storage.Backend == "s3" {
+		uses = append(uses, use("S3 request signatures", cryptopolicy.HMACSHA256))
+	}
+	if c.Audit.Enabled {
+		uses = append(uses, use("audit log chain", cryptopolicy.SHA256))
+		if c.Storage.Backend == "s3" {
+			checksum := cryptopolicy.SHA256
+			if !restricted {
+				checksum = cryptopolicy.MD5
+			}
+			uses = append(uses, use("audit log object checksums", checksum))
+		}
+	}
+	if c.Calendar.FeedSecret != "" {
+		uses = append(uses, use("calendar feed links", cryptopolicy.HMACSHA256))
+	}
+	if c.Email.ReplyDomain != "" {
+		uses = append(uses, use("reply-by-email addresses", cryptopolicy.HMACSHA256))
+	}
+	if c.Email.MailgunSigningKey != "" {
+		uses = append(uses, use("Mailgun webhook signatures", cryptopolicy.HMACSHA256))
+	}
+	if c.Email.SESTopicARN != "" {
+		uses = append(uses, use("SES notification signatures", cryptopolicy.RSASHA256))
+		if !restricted {
+			uses = append(uses, use("SES notification signatures", cryptopolicy.RSASHA1))
+		}
+	}
+	if c.Notify.TwilioAuthToken != "" {
+		uses = append(uses, use("Twilio callback signatures", cryptopolicy.HMACSHA1))
+	}
+	if c.Notify.FCMCredentialsFile != "" {
+		uses = append(uses, use("FCM service account assertions", cryptopolicy.RSASHA256))
+	}
+	if c.Notify.APNsKeyFile != "" {
+		uses = append(uses, use("APNs provider tokens", cryptopolicy.ECDSAP256))
+	}
+	return uses
+}
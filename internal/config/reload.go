@@ -0,0 +1,201 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"go-server/internal/logger"
+)
+
+// Reloadable is the part of the configuration that can change without a
+// restart. Everything else is read once at startup.
+type Reloadable struct {
+	LogLevel              string
+	RateLimitRPS          int
+	RateLimitBurst        int
+	CORSOrigins           []string
+	CORSAllowCredentials  bool
+	CORSMaxAge            time.Duration
+	CORSRouteGroups       string
+	RateLimitMultipliers  string
+	ContentSecurityPolicy string
+}
+
+// Reloadable returns the settings that can be reloaded
+func (c *Config) Reloadable() Reloadable {
+	return Reloadable{
+		LogLevel:              c.Logging.Level,
+		RateLimitRPS:          c.Security.RateLimitRPS,
+		RateLimitBurst:        c.Security.RateLimitBurst,
+		CORSOrigins:           c.Security.CORSOrigins,
+		CORSAllowCredentials:  c.Security.CORSAllowCredentials,
+		CORSMaxAge:            c.Security.CORSMaxAge,
+		CORSRouteGroups:       c.Security.CORSRouteGroups,
+		RateLimitMultipliers:  c.Security.RateLimitMultipliers,
+		ContentSecurityPolicy: c.Security.ContentSecurityPolicy,
+	}
+}
+
+// withReloadable returns a copy of the config with r's settings applied
+func (c *Config) withReloadable(r Reloadable) *Config {
+	next := *c
+	next.Logging.Level = r.LogLevel
+	next.Security.RateLimitRPS = r.RateLimitRPS
+	next.Security.RateLimitBurst = r.RateLimitBurst
+	next.Security.CORSOrigins = r.CORSOrigins
+	next.Security.CORSAllowCredentials = r.CORSAllowCredentials
+	next.Security.CORSMaxAge = r.CORSMaxAge
+	next.Security.CORSRouteGroups = r.CORSRouteGroups
+	next.Security.RateLimitMultipliers = r.RateLimitMultipliers
+	next.Security.ContentSecurityPolicy = r.ContentSecurityPolicy
+	return &next
+}
+
+// ReloadFunc applies a reloaded configuration, e.g. by changing the log
+// level or swapping the CORS policy
+type ReloadFunc func(cfg *Config) error
+
+// Watcher reloads the configuration on SIGHUP or when the config file
+// changes, swapping in the new reloadable settings atomically. A reload
+// that fails to load or validate keeps the current configuration.
+type Watcher struct {
+	path     string
+	interval time.Duration
+	logger   logger.Logger
+	current  atomic.Pointer[Config]
+	appliers []ReloadFunc
+
+	// reloadMutex serialises reloads from the signal and the file poller
+	reloadMutex sync.Mutex
+	modTime     time.Time
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewWatcher creates a watcher for the config file at path, which may be
+// empty to reload from the environment on SIGHUP only
+func NewWatcher(path string, initial *Config, logger logger.Logger) *Watcher {
+	w := &Watcher{path: path, interval: 5 * time.Second, logger: logger}
+	w.current.Store(initial)
+	w.modTime = w.fileModTime()
+	return w
+}
+
+// WithInterval sets how often the config file is checked for changes
+func (w *Watcher) WithInterval(interval time.Duration) *Watcher {
+	w.interval = interval
+	return w
+}
+
+// OnReload registers a function applying reloaded settings; appliers run
+// in registration order after the new configuration is swapped in
+func (w *Watcher) OnReload(apply ReloadFunc) *Watcher {
+	w.appliers = append(w.appliers, apply)
+	return w
+}
+
+// Current returns the configuration in effect
+func (w *Watcher) Current() *Config {
+	return w.current.Load()
+}
+
+// Reload loads the configuration again and applies any changed reloadable
+// settings, reporting whether anything changed
+func (w *Watcher) Reload() (bool, error) {
+	w.reloadMutex.Lock()
+	defer w.reloadMutex.Unlock()
+
+	loaded, err := LoadFile(w.path)
+	if err != nil {
+		return false, err
+	}
+
+	current := w.Current()
+	settings := loaded.Reloadable()
+	next := current.withReloadable(settings)
+	if !reflect.DeepEqual(loaded, next) {
+		w.logger.Warn("Configuration changes other than log level, rate limits and CORS need a restart")
+	}
+	if reflect.DeepEqual(current.Reloadable(), settings) {
+		return false, nil
+	}
+
+	w.current.Store(next)
+	for _, apply := range w.appliers {
+		if err := apply(next); err != nil {
+			w.logger.Error("Failed to apply reloaded configuration", "error", err.Error())
+		}
+	}
+	w.logger.Info("Configuration reloaded", "log_level", settings.LogLevel, "rate_limit_rps", settings.RateLimitRPS)
+	return true, nil
+}
+
+// Start reloads on SIGHUP and polls the config file for changes
+func (w *Watcher) Start(ctx context.Context) {
+	ctx, w.cancel = context.WithCancel(ctx)
+
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		defer signal.Stop(hangups)
+
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hangups:
+				w.reload("signal")
+			case <-ticker.C:
+				if w.path == "" {
+					continue
+				}
+				if modTime := w.fileModTime(); !modTime.Equal(w.modTime) {
+					w.modTime = modTime
+					w.reload("file change")
+				}
+			}
+		}
+	}()
+
+	w.logger.Info("Configuration watcher started", "path", w.path)
+}
+
+// Stop halts the watcher
+func (w *Watcher) Stop() {
+	if w.cancel != nil {
+		w.cancel()
+	}
+	w.wg.Wait()
+}
+
+func (w *Watcher) reload(trigger string) {
+	if _, err := w.Reload(); err != nil {
+		w.logger.Error("Failed to reload configuration", "trigger", trigger, "error", err.Error())
+	}
+}
+
+// fileModTime returns the config file's modification time, or the zero
+// time if there is no file
+func (w *Watcher) fileModTime() time.Time {
+	if w.path == "" {
+		return time.Time{}
+	}
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
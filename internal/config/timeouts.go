@@ -0,0 +1,38 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultRouteTimeouts leaves the WebSocket and Server-Sent Events streams,
+// which stay open for as long as the client listens, without a timeout
+const DefaultRouteTimeouts = "/ws=0;/events=0"
+
+// ParseRouteTimeouts parses request timeouts for path prefixes written as
+// "prefix=duration" separated by semicolons, e.g.
+// "/api/admin/reports=2m;/ws=0". A zero duration disables the timeout.
+func ParseRouteTimeouts(spec string) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration)
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		prefix, value, ok := strings.Cut(entry, "=")
+		prefix = strings.TrimSpace(prefix)
+		if !ok || !strings.HasPrefix(prefix, "/") {
+			return nil, fmt.Errorf("invalid route timeout %q", entry)
+		}
+		if _, exists := timeouts[prefix]; exists {
+			return nil, fmt.Errorf("route timeout for %s is set twice", prefix)
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || timeout < 0 {
+			return nil, fmt.Errorf("invalid duration in route timeout %q", entry)
+		}
+		timeouts[prefix] = timeout
+	}
+	return timeouts, nil
+}
@@ -0,0 +1,432 @@
+// Package cors is the server's single CORS implementation. An Engine holds
+// a default policy plus optional policies for route groups, chosen by the
+// longest matching path prefix, so e.g. /api/admin can admit only the admin
+// console with credentials while public endpoints admit any origin.
+//
+// The engine counts preflight requests per route group and requests from
+// origins it refused, so misconfigured or hostile origins show up in the
+// metrics rather than only in browser consoles.
+package cors
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultGroup names the default policy in metrics
+const DefaultGroup = "default"
+
+// maxTrackedOrigins bounds the denied-origin counters so a flood of forged
+// Origin headers cannot grow them without limit
+const maxTrackedOrigins = 100
+
+// otherOrigins counts denied origins beyond maxTrackedOrigins
+const otherOrigins = "other"
+
+// regexPrefix marks an allowed origin entry as a regular expression
+const regexPrefix = "regex:"
+
+// originRegexps caches compiled regex entries; entries come from the
+// configuration, so the cache stays small
+var originRegexps sync.Map
+
+// Policy is the CORS policy for a set of routes
+type Policy struct {
+	// AllowedOrigins lists origins, see MatchOrigin; "*" admits any origin
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	ExposedHeaders []string
+	// AllowCredentials lets browsers send cookies and authorization to
+	// origins listed explicitly or by a subdomain pattern. Origins admitted
+	// only by "*" never get credentials.
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight response
+	MaxAge time.Duration
+}
+
+// DefaultPolicy admits any origin without credentials
+func DefaultPolicy() Policy {
+	return Policy{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "HEAD", "PATCH"},
+		AllowedHeaders: []string{
+			"Accept",
+			"Accept-Language",
+			"Content-Language",
+			"Content-Type",
+			"Authorization",
+			"X-Requested-With",
+			"X-Request-ID",
+			"X-Auth-Transport",
+			"X-CSRF-Token",
+		},
+		ExposedHeaders: []string{
+			"X-Request-ID",
+			"X-RateLimit-Limit",
+			"X-RateLimit-Remaining",
+			"X-RateLimit-Reset",
+		},
+		MaxAge: 24 * time.Hour,
+	}
+}
+
+// Group is a policy for every path under Prefix
+type Group struct {
+	Prefix string
+	Policy Policy
+}
+
+// Stats is a snapshot of the engine's counters
+type Stats struct {
+	Preflights        int64            `json:"preflights"`
+	PreflightsByGroup map[string]int64 `json:"preflights_by_group"`
+	Denied            int64            `json:"denied"`
+	DeniedOrigins     map[string]int64 `json:"denied_origins"`
+}
+
+// Engine applies CORS policies to requests
+type Engine struct {
+	// rules guards policy and groups, which Reconfigure may swap while
+	// requests are being served
+	rules  sync.RWMutex
+	policy Policy
+	groups []Group
+
+	mutex             sync.Mutex
+	preflights        int64
+	preflightsByGroup map[string]int64
+	denied            int64
+	deniedOrigins     map[string]int64
+}
+
+// NewEngine creates an engine applying policy to routes without a group
+func NewEngine(policy Policy) *Engine {
+	return &Engine{
+		policy:            policy,
+		preflightsByGroup: make(map[string]int64),
+		deniedOrigins:     make(map[string]int64),
+	}
+}
+
+// WithGroup applies policy to every path under prefix instead of the default
+func (e *Engine) WithGroup(prefix string, policy Policy) *Engine {
+	e.rules.Lock()
+	defer e.rules.Unlock()
+	e.groups = sortGroups(append(e.groups, Group{Prefix: prefix, Policy: policy}))
+	return e
+}
+
+// WithGroups adds several route groups
+func (e *Engine) WithGroups(groups []Group) *Engine {
+	for _, group := range groups {
+		e.WithGroup(group.Prefix, group.Policy)
+	}
+	return e
+}
+
+// Reconfigure replaces the default policy and route groups in one step,
+// e.g. when the configuration is reloaded. The counters are kept.
+func (e *Engine) Reconfigure(policy Policy, groups []Group) {
+	sorted := sortGroups(append([]Group(nil), groups...))
+	e.rules.Lock()
+	defer e.rules.Unlock()
+	e.policy = policy
+	e.groups = sorted
+}
+
+// sortGroups orders groups longest prefix first so the most specific wins
+func sortGroups(groups []Group) []Group {
+	sort.SliceStable(groups, func(i, j int) bool {
+		return len(groups[i].Prefix) > len(groups[j].Prefix)
+	})
+	return groups
+}
+
+// Middleware answers preflight requests and adds CORS headers to the rest
+func (e *Engine) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if e.Handle(w, r) {
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Handle sets the CORS headers for r and reports whether it was a preflight
+// request that has been answered
+func (e *Engine) Handle(w http.ResponseWriter, r *http.Request) bool {
+	name, policy := e.policyFor(r.URL.Path)
+	origin := r.Header.Get("Origin")
+	allowed, explicit := policy.admits(origin)
+	preflight := r.Method == http.MethodOptions
+
+	// The headers depend on the origin, so caches must not hand one
+	// origin's response to another
+	addVary(w.Header(), "Origin")
+	if preflight {
+		addVary(w.Header(), "Access-Control-Request-Method", "Access-Control-Request-Headers")
+	}
+
+	if preflight {
+		e.countPreflight(name)
+	}
+	if origin != "" && !allowed {
+		e.countDenied(origin)
+		if preflight {
+			w.WriteHeader(http.StatusForbidden)
+			return true
+		}
+		return false
+	}
+
+	if allowed {
+		policy.setHeaders(w, origin, explicit, preflight)
+	}
+	if preflight {
+		w.WriteHeader(http.StatusOK)
+	}
+	return preflight
+}
+
+// Allowed reports whether r's origin may access its route. Requests without
+// an Origin header are not cross-origin and are always allowed.
+func (e *Engine) Allowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	_, policy := e.policyFor(r.URL.Path)
+	allowed, _ := policy.admits(origin)
+	return allowed
+}
+
+// Stats returns a snapshot of the preflight and denied-origin counters
+func (e *Engine) Stats() Stats {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	stats := Stats{
+		Preflights:        e.preflights,
+		PreflightsByGroup: make(map[string]int64, len(e.preflightsByGroup)),
+		Denied:            e.denied,
+		DeniedOrigins:     make(map[string]int64, len(e.deniedOrigins)),
+	}
+	for group, count := range e.preflightsByGroup {
+		stats.PreflightsByGroup[group] = count
+	}
+	for origin, count := range e.deniedOrigins {
+		stats.DeniedOrigins[origin] = count
+	}
+	return stats
+}
+
+// policyFor returns the name and policy of the group covering path
+func (e *Engine) policyFor(path string) (string, Policy) {
+	e.rules.RLock()
+	defer e.rules.RUnlock()
+	for _, group := range e.groups {
+		if strings.HasPrefix(path, group.Prefix) {
+			return group.Prefix, group.Policy
+		}
+	}
+	return DefaultGroup, e.policy
+}
+
+func (e *Engine) countPreflight(group string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.preflights++
+	e.preflightsByGroup[group]++
+}
+
+func (e *Engine) countDenied(origin string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.denied++
+	if _, tracked := e.deniedOrigins[origin]; !tracked && len(e.deniedOrigins) >= maxTrackedOrigins {
+		origin = otherOrigins
+	}
+	e.deniedOrigins[origin]++
+}
+
+// admits reports whether the policy allows origin and whether it is listed
+// explicitly or by a pattern rather than admitted by "*". An empty origin
+// is admitted only by "*".
+func (p Policy) admits(origin string) (allowed, explicit bool) {
+	for _, allowedOrigin := range p.AllowedOrigins {
+		if allowedOrigin == "*" {
+			allowed = true
+		} else if origin != "" && MatchOrigin(allowedOrigin, origin) {
+			return true, true
+		}
+	}
+	return allowed, false
+}
+
+// MatchOrigin reports whether origin matches an allowed origin entry. An
+// entry is "*", an exact origin such as https://app.example.com, a
+// subdomain pattern or a regular expression. The pattern
+// https://*.example.com admits any subdomain of example.com over https and
+// *.example.com any subdomain over any scheme; neither admits example.com
+// itself. A regex: entry such as regex:https://pr-[0-9]+\.preview\.example\.com
+// must match the whole origin. Comparison ignores case.
+func MatchOrigin(allowed, origin string) bool {
+	if allowed == "*" {
+		return true
+	}
+	if strings.HasPrefix(allowed, regexPrefix) {
+		re, err := originRegexp(allowed)
+		return err == nil && re.MatchString(origin)
+	}
+	allowed, origin = strings.ToLower(allowed), strings.ToLower(origin)
+	if !strings.Contains(allowed, "*.") {
+		return allowed == origin
+	}
+
+	scheme, pattern, hasScheme := strings.Cut(allowed, "://")
+	if !hasScheme {
+		scheme, pattern = "", allowed
+	}
+	originScheme, host, ok := strings.Cut(origin, "://")
+	if !ok || (hasScheme && originScheme != scheme) {
+		return false
+	}
+	suffix := strings.TrimPrefix(pattern, "*")
+	return strings.HasPrefix(pattern, "*.") && strings.HasSuffix(host, suffix) && len(host) > len(suffix)
+}
+
+// originRegexp compiles a regex: entry, anchored at both ends
+func originRegexp(entry string) (*regexp.Regexp, error) {
+	if cached, ok := originRegexps.Load(entry); ok {
+		return cached.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile("(?i)^(?:" + strings.TrimPrefix(entry, regexPrefix) + ")$")
+	if err != nil {
+		return nil, err
+	}
+	originRegexps.Store(entry, re)
+	return re, nil
+}
+
+// ValidateOrigins checks allowed origin entries, so a typo is reported at
+// startup rather than as browsers being refused
+func ValidateOrigins(origins []string) error {
+	for _, origin := range origins {
+		switch {
+		case origin == "*":
+		case strings.HasPrefix(origin, regexPrefix):
+			if _, err := originRegexp(origin); err != nil {
+				return fmt.Errorf("invalid CORS origin regex %q: %v", origin, err)
+			}
+		case strings.HasPrefix(origin, "*."):
+			if strings.ContainsAny(origin[2:], "*/:") {
+				return fmt.Errorf("invalid CORS origin pattern %q", origin)
+			}
+		default:
+			u, err := url.Parse(strings.Replace(origin, "://*.", "://wildcard.", 1))
+			if err != nil || u.Scheme == "" || u.Host == "" || strings.Contains(u.Host, "*") ||
+				(u.Path != "" && u.Path != "/") || u.RawQuery != "" {
+				return fmt.Errorf("invalid CORS origin %q: want scheme://host[:port]", origin)
+			}
+		}
+	}
+	return nil
+}
+
+// addVary adds names to the Vary header unless already listed
+func addVary(header http.Header, names ...string) {
+	existing := strings.ToLower(strings.Join(header.Values("Vary"), ","))
+	for _, name := range names {
+		listed := false
+		for _, value := range strings.Split(existing, ",") {
+			if strings.TrimSpace(value) == strings.ToLower(name) {
+				listed = true
+				break
+			}
+		}
+		if !listed {
+			header.Add("Vary", name)
+		}
+	}
+}
+
+// setHeaders writes the CORS response headers. Preflight responses also
+// describe the allowed methods and headers and how long to cache them.
+func (p Policy) setHeaders(w http.ResponseWriter, origin string, explicit, preflight bool) {
+	header := w.Header()
+	if explicit {
+		header.Set("Access-Control-Allow-Origin", origin)
+		if p.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+	} else {
+		header.Set("Access-Control-Allow-Origin", "*")
+	}
+
+	if !preflight {
+		if len(p.ExposedHeaders) > 0 {
+			header.Set("Access-Control-Expose-Headers", strings.Join(p.ExposedHeaders, ", "))
+		}
+		return
+	}
+	if len(p.AllowedMethods) > 0 {
+		header.Set("Access-Control-Allow-Methods", strings.Join(p.AllowedMethods, ", "))
+	}
+	if len(p.AllowedHeaders) > 0 {
+		header.Set("Access-Control-Allow-Headers", strings.Join(p.AllowedHeaders, ", "))
+	}
+	if p.MaxAge > 0 {
+		header.Set("Access-Control-Max-Age", strconv.Itoa(int(p.MaxAge/time.Second)))
+	}
+}
+
+// ParseGroups parses route group policies written as
+// "prefix|origin,origin[|credentials]" separated by semicolons, e.g.
+// "/api/admin|https://admin.example.com|credentials;/api/public|*". Each
+// group starts from base and overrides its origins and credentials.
+func ParseGroups(spec string, base Policy) ([]Group, error) {
+	var groups []Group
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, "|")
+		if len(parts) < 2 || len(parts) > 3 || !strings.HasPrefix(parts[0], "/") {
+			return nil, fmt.Errorf("invalid CORS route group %q", entry)
+		}
+
+		policy := base
+		policy.AllowedOrigins = nil
+		for _, origin := range strings.Split(parts[1], ",") {
+			if origin = strings.TrimSpace(origin); origin != "" {
+				policy.AllowedOrigins = append(policy.AllowedOrigins, origin)
+			}
+		}
+		if len(policy.AllowedOrigins) == 0 {
+			return nil, fmt.Errorf("CORS route group %q lists no origins", entry)
+		}
+		if err := ValidateOrigins(policy.AllowedOrigins); err != nil {
+			return nil, err
+		}
+		policy.AllowCredentials = false
+		if len(parts) == 3 {
+			if strings.TrimSpace(parts[2]) != "credentials" {
+				return nil, fmt.Errorf("invalid option in CORS route group %q", entry)
+			}
+			policy.AllowCredentials = true
+		}
+		groups = append(groups, Group{Prefix: strings.TrimSpace(parts[0]), Policy: policy})
+	}
+	return groups, nil
+}
@@ -0,0 +1,222 @@
+package cors
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func serve(engine *Engine, method, path, origin string) *httptest.ResponseRecorder {
+	handler := engine.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	req := httptest.NewRequest(method, path, nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func newTestEngine() *Engine {
+	admin := DefaultPolicy()
+	admin.AllowedOrigins = []string{"https://admin.example.com"}
+	admin.AllowCredentials = true
+	return NewEngine(DefaultPolicy()).WithGroup("/api/admin", admin)
+}
+
+func TestEngine_DefaultPolicyAdmitsAnyOrigin(t *testing.T) {
+	rec := serve(newTestEngine(), http.MethodGet, "/api/posts", "https://blog.example.org")
+
+	if rec.Code != http.StatusTeapot {
+		t.Fatalf("Request should reach the handler, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Allow-Origin = %q, want *", got)
+	}
+	if rec.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Error("Wildcard origins must never receive credentials")
+	}
+	if rec.Header().Get("Access-Control-Expose-Headers") == "" {
+		t.Error("Expected exposed headers on actual requests")
+	}
+}
+
+func TestEngine_RouteGroupWithCredentials(t *testing.T) {
+	engine := newTestEngine()
+
+	rec := serve(engine, http.MethodOptions, "/api/admin/staff", "https://admin.example.com")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Preflight status = %d, want 200", rec.Code)
+	}
+	if rec.Header().Get("Access-Control-Allow-Origin") != "https://admin.example.com" ||
+		rec.Header().Get("Access-Control-Allow-Credentials") != "true" {
+		t.Errorf("Credentialed origin headers missing: %v", rec.Header())
+	}
+	if rec.Header().Get("Access-Control-Max-Age") != "86400" || rec.Header().Get("Access-Control-Allow-Methods") == "" {
+		t.Errorf("Preflight headers missing: %v", rec.Header())
+	}
+
+	rec = serve(engine, http.MethodOptions, "/api/admin/staff", "https://evil.example.net")
+	if rec.Code != http.StatusForbidden || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("Denied preflight: status %d, headers %v", rec.Code, rec.Header())
+	}
+	rec = serve(engine, http.MethodGet, "/api/admin/staff", "https://evil.example.net")
+	if rec.Code != http.StatusTeapot || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("Denied request: status %d, headers %v", rec.Code, rec.Header())
+	}
+}
+
+func TestEngine_Reconfigure(t *testing.T) {
+	engine := newTestEngine()
+	serve(engine, http.MethodOptions, "/api/posts", "https://blog.example.org")
+
+	restricted := DefaultPolicy()
+	restricted.AllowedOrigins = []string{"https://app.example.com"}
+	engine.Reconfigure(restricted, nil)
+
+	if rec := serve(engine, http.MethodOptions, "/api/posts", "https://blog.example.org"); rec.Code != http.StatusForbidden {
+		t.Errorf("Origin dropped by the new policy should be refused, got %d", rec.Code)
+	}
+	if rec := serve(engine, http.MethodOptions, "/api/admin/staff", "https://admin.example.com"); rec.Code != http.StatusForbidden {
+		t.Errorf("Route groups should be replaced too, got %d", rec.Code)
+	}
+	if rec := serve(engine, http.MethodGet, "/api/posts", "https://app.example.com"); rec.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" {
+		t.Errorf("New origin should be admitted, got headers %v", rec.Header())
+	}
+	if engine.Stats().Preflights != 3 {
+		t.Errorf("Counters should survive a reconfigure, got %d preflights", engine.Stats().Preflights)
+	}
+}
+
+func TestEngine_Stats(t *testing.T) {
+	engine := newTestEngine()
+	serve(engine, http.MethodOptions, "/api/posts", "https://a.example")
+	serve(engine, http.MethodOptions, "/api/admin/users", "https://admin.example.com")
+	serve(engine, http.MethodOptions, "/api/admin/users", "https://evil.example.net")
+	serve(engine, http.MethodGet, "/api/admin/users", "https://evil.example.net")
+
+	stats := engine.Stats()
+	if stats.Preflights != 3 || stats.PreflightsByGroup[DefaultGroup] != 1 || stats.PreflightsByGroup["/api/admin"] != 2 {
+		t.Errorf("Preflight counts = %+v", stats)
+	}
+	if stats.Denied != 2 || stats.DeniedOrigins["https://evil.example.net"] != 2 {
+		t.Errorf("Denied counts = %+v", stats)
+	}
+
+	for i := 0; i < maxTrackedOrigins+10; i++ {
+		serve(engine, http.MethodGet, "/api/admin/users", fmt.Sprintf("https://%d.example.net", i))
+	}
+	stats = engine.Stats()
+	if len(stats.DeniedOrigins) > maxTrackedOrigins+1 || stats.DeniedOrigins[otherOrigins] == 0 {
+		t.Errorf("Denied origins should be bounded, got %d entries", len(stats.DeniedOrigins))
+	}
+}
+
+func TestParseGroups(t *testing.T) {
+	groups, err := ParseGroups("/api/admin|https://a.example.com, https://b.example.com|credentials; /api/public|*", DefaultPolicy())
+	if err != nil {
+		t.Fatalf("ParseGroups failed: %v", err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("Expected 2 groups, got %d", len(groups))
+	}
+	if groups[0].Prefix != "/api/admin" || len(groups[0].Policy.AllowedOrigins) != 2 || !groups[0].Policy.AllowCredentials {
+		t.Errorf("Admin group = %+v", groups[0])
+	}
+	if groups[1].Policy.AllowCredentials || groups[1].Policy.AllowedOrigins[0] != "*" {
+		t.Errorf("Public group = %+v", groups[1])
+	}
+
+	for _, spec := range []string{"api|*", "/api|", "/api|*|cookies", "/api"} {
+		if _, err := ParseGroups(spec, DefaultPolicy()); err == nil {
+			t.Errorf("Expected error for %q", spec)
+		}
+	}
+}
+
+func TestMatchOrigin(t *testing.T) {
+	tests := []struct {
+		allowed, origin string
+		want            bool
+	}{
+		{"*", "https://anything.example", true},
+		{"https://app.example.com", "https://app.example.com", true},
+		{"https://app.example.com", "HTTPS://App.Example.com", true},
+		{"https://app.example.com", "http://app.example.com", false},
+		{"https://*.example.com", "https://app.example.com", true},
+		{"https://*.example.com", "https://a.b.example.com", true},
+		{"https://*.example.com", "https://example.com", false},
+		{"https://*.example.com", "http://app.example.com", false},
+		{"https://*.example.com", "https://app.example.com.evil.net", false},
+		{"https://*.example.com", "https://evilexample.com", false},
+		{"*.example.com", "http://app.example.com", true},
+		{"*.example.com", "app.example.com", false},
+	}
+	for _, tt := range tests {
+		if got := MatchOrigin(tt.allowed, tt.origin); got != tt.want {
+			t.Errorf("MatchOrigin(%q, %q) = %v, want %v", tt.allowed, tt.origin, got, tt.want)
+		}
+	}
+}
+
+func TestEngine_SubdomainPatternWithVary(t *testing.T) {
+	policy := DefaultPolicy()
+	policy.AllowedOrigins = []string{"https://*.example.com"}
+	policy.AllowCredentials = true
+	engine := NewEngine(policy)
+
+	rec := serve(engine, http.MethodGet, "/api/posts", "https://app.example.com")
+	if rec.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" ||
+		rec.Header().Get("Access-Control-Allow-Credentials") != "true" {
+		t.Errorf("Pattern origin headers missing: %v", rec.Header())
+	}
+	if rec.Header().Get("Vary") != "Origin" {
+		t.Errorf("Vary = %q, want Origin", rec.Header().Values("Vary"))
+	}
+
+	rec = serve(engine, http.MethodOptions, "/api/posts", "https://app.example.com")
+	if vary := rec.Header().Values("Vary"); len(vary) != 3 {
+		t.Errorf("Preflight Vary = %v, want Origin and the request headers", vary)
+	}
+
+	rec = serve(engine, http.MethodGet, "/api/posts", "https://example.org")
+	if rec.Header().Get("Access-Control-Allow-Origin") != "" || rec.Header().Get("Vary") != "Origin" {
+		t.Errorf("Denied origin headers: %v", rec.Header())
+	}
+}
+
+func TestMatchOrigin_Regex(t *testing.T) {
+	const allowed = `regex:https://pr-[0-9]+\.preview\.example\.com`
+	for origin, want := range map[string]bool{
+		"https://pr-42.preview.example.com":                true,
+		"HTTPS://PR-42.preview.example.com":                true,
+		"https://pr-x.preview.example.com":                 false,
+		"https://pr-42.preview.example.com.evil.net":       false,
+		"http://evil.net/https://pr-1.preview.example.com": false,
+	} {
+		if got := MatchOrigin(allowed, origin); got != want {
+			t.Errorf("MatchOrigin(%q) = %v, want %v", origin, got, want)
+		}
+	}
+	if MatchOrigin("regex:(", "(") {
+		t.Error("An invalid regex must not match")
+	}
+}
+
+func TestValidateOrigins(t *testing.T) {
+	valid := []string{"*", "https://app.example.com", "http://localhost:3000", "https://*.example.com", "*.example.com", `regex:https://.*\.example\.com`}
+	if err := ValidateOrigins(valid); err != nil {
+		t.Errorf("ValidateOrigins(%q) = %v", valid, err)
+	}
+	for _, origin := range []string{"app.example.com", "https://app.example.com/path", "https://a*.example.com", "*.example.com/x", "regex:("} {
+		if err := ValidateOrigins([]string{origin}); err == nil {
+			t.Errorf("ValidateOrigins(%q) should fail", origin)
+		}
+	}
+	if _, err := ParseGroups("/api/admin|admin.example.com", DefaultPolicy()); err == nil {
+		t.Error("ParseGroups should reject invalid origins")
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !fips
+
+package cryptopolicy
+
+// buildRestricted keeps binaries built with the fips tag restricted
+const buildRestricted = false
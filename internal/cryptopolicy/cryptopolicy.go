@@ -0,0 +1,120 @@
+// Package cryptopolicy restricts the server's cryptography to algorithms
+// approved for FIPS 140-3 deployments. In restricted mode bcrypt, MD5,
+// Ed25519 and SHA-1 signatures are refused wherever the server would use
+// them, TLS is limited to approved cipher suites and curves, and the
+// startup self-test must pass.
+//
+// Restricted mode is turned on by Enable, from CRYPTO_MODE=fips, and is
+// always on in binaries built with the fips tag:
+//
+//	go build -tags fips ./cmd/server
+//
+// The policy decides which algorithms the server picks. Whether their
+// implementations are a validated module is up to the Go toolchain, see
+// ModuleEnabled.
+package cryptopolicy
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// Modes of the policy
+const (
+	ModeStandard = "standard"
+	ModeFIPS     = "fips"
+)
+
+// Algorithms the server uses, as named in reports and errors
+const (
+	SHA1         = "SHA-1"
+	SHA256       = "SHA-256"
+	MD5          = "MD5"
+	HMACSHA1     = "HMAC-SHA-1"
+	HMACSHA256   = "HMAC-SHA-256"
+	AES256GCM    = "AES-256-GCM"
+	RSASHA1      = "RSA-PKCS1v15-SHA-1"
+	RSASHA256    = "RSA-PKCS1v15-SHA-256"
+	ECDSAP256    = "ECDSA-P256-SHA-256"
+	Ed25519      = "Ed25519"
+	Bcrypt       = "bcrypt"
+	PBKDF2SHA256 = "PBKDF2-HMAC-SHA-256"
+)
+
+// approved are the algorithms allowed in restricted mode. SHA-1 stays
+// approved for uses other than signatures, such as HMAC, checksums and the
+// WebSocket handshake (NIST SP 800-131A).
+var approved = map[string]bool{
+	SHA1:         true,
+	SHA256:       true,
+	HMACSHA1:     true,
+	HMACSHA256:   true,
+	AES256GCM:    true,
+	RSASHA256:    true,
+	ECDSAP256:    true,
+	PBKDF2SHA256: true,
+}
+
+// ErrNotApproved is returned for an algorithm restricted mode refuses
+var ErrNotApproved = errors.New("algorithm not approved by the crypto policy")
+
+var restricted atomic.Bool
+
+func init() {
+	restricted.Store(buildRestricted)
+}
+
+// BuiltRestricted reports whether the binary was built with the fips tag,
+// which keeps it in restricted mode whatever its configuration
+func BuiltRestricted() bool {
+	return buildRestricted
+}
+
+// DefaultMode is the mode used when none is configured
+func DefaultMode() string {
+	if buildRestricted {
+		return ModeFIPS
+	}
+	return ModeStandard
+}
+
+// Restricted reports whether only approved algorithms may be used
+func Restricted() bool {
+	return restricted.Load()
+}
+
+// Mode returns the mode in force
+func Mode() string {
+	if Restricted() {
+		return ModeFIPS
+	}
+	return ModeStandard
+}
+
+// Enable runs the self-test and, if it passes, restricts the process to
+// approved algorithms and http.DefaultTransport to the TLS allowlist.
+// There is no way back. Binaries built with the fips tag start restricted
+// but should still call it at startup for the self-test.
+func Enable() error {
+	if err := SelfTest(); err != nil {
+		return err
+	}
+	restricted.Store(true)
+	configureDefaultTransport()
+	return nil
+}
+
+// Approved reports whether algorithm is allowed in restricted mode
+func Approved(algorithm string) bool {
+	return approved[algorithm]
+}
+
+// Allow returns ErrNotApproved if algorithm may not be used in the mode in
+// force
+func Allow(algorithm string) error {
+	if Restricted() && !Approved(algorithm) {
+		return fmt.Errorf("%w: %s", ErrNotApproved, algorithm)
+	}
+	return nil
+}
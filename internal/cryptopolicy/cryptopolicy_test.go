@@ -0,0 +1,103 @@
+package cryptopolicy
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// restrict enables restricted mode for the test and undoes it afterwards
+func restrict(t *testing.T) {
+	t.Helper()
+	transport := http.DefaultTransport.(*http.Transport)
+	previous := transport.TLSClientConfig
+	t.Cleanup(func() {
+		restricted.Store(buildRestricted)
+		transport.TLSClientConfig = previous
+	})
+	if err := Enable(); err != nil {
+		t.Fatalf("Enable failed: %v", err)
+	}
+}
+
+func TestSelfTest(t *testing.T) {
+	if err := SelfTest(); err != nil {
+		t.Fatalf("Expected the self-test to pass, got %v", err)
+	}
+}
+
+func TestAllow(t *testing.T) {
+	if buildRestricted {
+		t.Skip("built with the fips tag")
+	}
+	if err := Allow(MD5); err != nil || Mode() != ModeStandard {
+		t.Fatalf("Expected MD5 allowed in standard mode, got %v", err)
+	}
+
+	restrict(t)
+	if Mode() != ModeFIPS {
+		t.Errorf("Expected fips mode, got %s", Mode())
+	}
+	for _, algorithm := range []string{MD5, Bcrypt, Ed25519, RSASHA1} {
+		if err := Allow(algorithm); !errors.Is(err, ErrNotApproved) {
+			t.Errorf("Expected %s refused, got %v", algorithm, err)
+		}
+	}
+	for _, algorithm := range []string{SHA1, HMACSHA1, SHA256, AES256GCM, RSASHA256, PBKDF2SHA256} {
+		if err := Allow(algorithm); err != nil {
+			t.Errorf("Expected %s allowed, got %v", algorithm, err)
+		}
+	}
+}
+
+func TestTLSConfig(t *testing.T) {
+	base := &tls.Config{ServerName: "example.com"}
+	restrict(t)
+
+	config := TLSConfig(base)
+	if config == base || config.ServerName != "example.com" {
+		t.Fatalf("Expected a copy of the base config, got %+v", config)
+	}
+	if config.MinVersion != tls.VersionTLS12 || config.MaxVersion != maxTLSVersion() {
+		t.Errorf("Expected TLS 1.2 to %s, got %x to %x", tls.VersionName(maxTLSVersion()), config.MinVersion, config.MaxVersion)
+	}
+	for _, suite := range config.CipherSuites {
+		if name := tls.CipherSuiteName(suite); !strings.Contains(name, "_GCM_") || !strings.HasPrefix(name, "TLS_ECDHE_") {
+			t.Errorf("Expected only ECDHE AES-GCM suites, got %s", name)
+		}
+	}
+	if transport := http.DefaultTransport.(*http.Transport); transport.TLSClientConfig == nil ||
+		len(transport.TLSClientConfig.CipherSuites) != len(cipherSuites) {
+		t.Errorf("Expected the default transport restricted, got %+v", transport.TLSClientConfig)
+	}
+
+	report := NewReport(nil)
+	if report.Mode != ModeFIPS || len(report.TLS.CipherSuites) != len(cipherSuites) || len(report.TLS.Curves) != len(curves) {
+		t.Errorf("Expected the report to list the allowlist, got %+v", report)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	uses := []Use{
+		NewUse("password hashing", PBKDF2SHA256),
+		NewUse("break-glass tokens", Ed25519),
+		NewUse("upload checksums", MD5),
+	}
+	err := Verify(uses)
+	if !errors.Is(err, ErrNotApproved) {
+		t.Fatalf("Expected ErrNotApproved, got %v", err)
+	}
+	for _, purpose := range []string{"break-glass tokens", "upload checksums"} {
+		if !strings.Contains(err.Error(), purpose) {
+			t.Errorf("Expected %q in %v", purpose, err)
+		}
+	}
+	if strings.Contains(err.Error(), "password hashing") {
+		t.Errorf("Expected approved uses left out, got %v", err)
+	}
+	if err := Verify(uses[:1]); err != nil {
+		t.Errorf("Expected approved uses to pass, got %v", err)
+	}
+}
@@ -0,0 +1,22 @@
+//go:build go1.24
+
+package cryptopolicy
+
+import (
+	"crypto/fips140"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+)
+
+// ModuleEnabled reports whether the standard library's crypto runs as the
+// Go FIPS 140-3 module, turned on with GODEBUG=fips140=on or by building
+// with GOFIPS140
+func ModuleEnabled() bool {
+	return fips140.Enabled()
+}
+
+// PBKDF2 derives a keyLength-byte key from password with
+// PBKDF2-HMAC-SHA-256
+func PBKDF2(password string, salt []byte, iterations, keyLength int) ([]byte, error) {
+	return pbkdf2.Key(sha256.New, password, salt, iterations, keyLength)
+}
@@ -0,0 +1,25 @@
+//go:build !go1.24
+
+package cryptopolicy
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// ModuleEnabled reports whether the standard library's crypto runs as the
+// Go FIPS 140-3 module, which toolchains before Go 1.24 do not have
+func ModuleEnabled() bool {
+	return false
+}
+
+// PBKDF2 derives a keyLength-byte key from password with
+// PBKDF2-HMAC-SHA-256
+func PBKDF2(password string, salt []byte, iterations, keyLength int) ([]byte, error) {
+	if iterations < 1 || keyLength < 1 {
+		return nil, fmt.Errorf("pbkdf2: invalid iterations or key length")
+	}
+	return pbkdf2.Key([]byte(password), salt, iterations, keyLength, sha256.New), nil
+}
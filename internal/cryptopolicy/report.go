@@ -0,0 +1,53 @@
+package cryptopolicy
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Use is one purpose the server puts an algorithm to
+type Use struct {
+	Purpose   string `json:"purpose"`
+	Algorithm string `json:"algorithm"`
+	Approved  bool   `json:"approved"`
+}
+
+// NewUse records that purpose uses algorithm
+func NewUse(purpose, algorithm string) Use {
+	return Use{Purpose: purpose, Algorithm: algorithm, Approved: Approved(algorithm)}
+}
+
+// Verify returns an error naming every use of an algorithm restricted mode
+// refuses, so a configuration relying on one fails at startup rather than
+// on first use
+func Verify(uses []Use) error {
+	var errs []error
+	for _, use := range uses {
+		if !use.Approved {
+			errs = append(errs, fmt.Errorf("%s uses %s: %w", use.Purpose, use.Algorithm, ErrNotApproved))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Report lists the algorithms in use and the policy they are held to
+type Report struct {
+	Mode string `json:"mode"`
+	// BuildTag is whether the binary was built with the fips tag
+	BuildTag bool `json:"fips_build_tag"`
+	// Module is whether the Go FIPS 140-3 module is enabled
+	Module     bool      `json:"fips140_module"`
+	Algorithms []Use     `json:"algorithms"`
+	TLS        TLSReport `json:"tls"`
+}
+
+// NewReport describes the policy in force and uses
+func NewReport(uses []Use) Report {
+	return Report{
+		Mode:       Mode(),
+		BuildTag:   BuiltRestricted(),
+		Module:     ModuleEnabled(),
+		Algorithms: uses,
+		TLS:        newTLSReport(),
+	}
+}
@@ -0,0 +1,58 @@
+package cryptopolicy
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// knownAnswers are published test vectors of the approved algorithms the
+// server relies on: FIPS 180-2, RFC 4231 test case 2, the GCM
+// specification's test case 14 and RFC 7914's PBKDF2 vector
+var knownAnswers = []struct {
+	algorithm string
+	run       func() ([]byte, error)
+	want      string
+}{
+	{SHA256, func() ([]byte, error) {
+		sum := sha256.Sum256([]byte("abc"))
+		return sum[:], nil
+	}, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+	{HMACSHA256, func() ([]byte, error) {
+		mac := hmac.New(sha256.New, []byte("Jefe"))
+		mac.Write([]byte("what do ya want for nothing?"))
+		return mac.Sum(nil), nil
+	}, "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"},
+	{AES256GCM, func() ([]byte, error) {
+		block, err := aes.NewCipher(make([]byte, 32))
+		if err != nil {
+			return nil, err
+		}
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		return gcm.Seal(nil, make([]byte, gcm.NonceSize()), make([]byte, 16), nil), nil
+	}, "cea7403d4d606b6e074ec5d3baf39d18d0d1c8a799996bf0265b98b5d48ab919"},
+	{PBKDF2SHA256, func() ([]byte, error) {
+		return PBKDF2("passwd", []byte("salt"), 1, 64)
+	}, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"},
+}
+
+// SelfTest checks the approved algorithms against known answers, so a
+// broken or substituted implementation stops the server from starting
+func SelfTest() error {
+	for _, test := range knownAnswers {
+		got, err := test.run()
+		if err != nil {
+			return fmt.Errorf("crypto self-test of %s failed: %w", test.algorithm, err)
+		}
+		if hex.EncodeToString(got) != test.want {
+			return fmt.Errorf("crypto self-test of %s failed: wrong answer", test.algorithm)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package cryptopolicy
+
+import (
+	"crypto/tls"
+	"net/http"
+)
+
+// cipherSuites are the TLS 1.2 suites allowed in restricted mode
+var cipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+}
+
+// curves are the key exchange groups allowed in restricted mode
+var curves = []tls.CurveID{tls.CurveP256, tls.CurveP384}
+
+// maxTLSVersion is the newest TLS version allowed in restricted mode.
+// crypto/tls cannot be told which TLS 1.3 suites to offer and would
+// negotiate ChaCha20-Poly1305, so TLS 1.3 waits for the FIPS module, under
+// which crypto/tls only offers AES-GCM.
+func maxTLSVersion() uint16 {
+	if ModuleEnabled() {
+		return tls.VersionTLS13
+	}
+	return tls.VersionTLS12
+}
+
+// TLSConfig returns a copy of config, limited to the approved versions,
+// cipher suites and curves in restricted mode. A nil config starts from
+// crypto/tls's defaults.
+func TLSConfig(config *tls.Config) *tls.Config {
+	if config == nil {
+		config = &tls.Config{}
+	} else {
+		config = config.Clone()
+	}
+	if !Restricted() {
+		return config
+	}
+	config.MinVersion = tls.VersionTLS12
+	config.MaxVersion = maxTLSVersion()
+	config.CipherSuites = append([]uint16(nil), cipherSuites...)
+	config.CurvePreferences = append([]tls.CurveID(nil), curves...)
+	return config
+}
+
+// configureDefaultTransport restricts the TLS of http.DefaultTransport,
+// which every outbound client without a transport of its own uses
+func configureDefaultTransport() {
+	if transport, ok := http.DefaultTransport.(*http.Transport); ok {
+		transport.TLSClientConfig = TLSConfig(transport.TLSClientConfig)
+	}
+}
+
+// TLSReport describes the TLS settings in force. Empty lists mean
+// crypto/tls's defaults.
+type TLSReport struct {
+	MinVersion   string   `json:"min_version"`
+	MaxVersion   string   `json:"max_version"`
+	CipherSuites []string `json:"cipher_suites,omitempty"`
+	Curves       []string `json:"curves,omitempty"`
+}
+
+func newTLSReport() TLSReport {
+	config := TLSConfig(nil)
+	report := TLSReport{
+		MinVersion: tls.VersionName(tls.VersionTLS12),
+		MaxVersion: tls.VersionName(tls.VersionTLS13),
+	}
+	if config.MaxVersion != 0 {
+		report.MaxVersion = tls.VersionName(config.MaxVersion)
+	}
+	for _, suite := range config.CipherSuites {
+		report.CipherSuites = append(report.CipherSuites, tls.CipherSuiteName(suite))
+	}
+	for _, curve := range config.CurvePreferences {
+		report.Curves = append(report.Curves, curve.String())
+	}
+	return report
+}
@@ -1,14 +1,46 @@
 package database
 
 import (
+	"context"
 	"fmt"
+	"net"
 	"os"
 	"strconv"
+	"strings"
 	"time"
+
+	"go-server/internal/database/repositories"
+	"go-server/internal/secrets"
+
+	"github.com/go-sql-driver/mysql"
 )
 
+// Storage drivers
+const (
+	// DriverPostgres stores data in PostgreSQL, through pgxpool and GORM
+	DriverPostgres = "postgres"
+	// DriverSQLite stores data in the SQLite file at SQLitePath, so the
+	// server runs as a single binary without a database server
+	DriverSQLite = "sqlite"
+	// DriverMemory stores data in an in-memory SQLite database that lives
+	// as long as the process, e.g. for demos and tests
+	DriverMemory = "memory"
+	// DriverMySQL stores data in MySQL 5.7+ or MariaDB 10.3+, for
+	// deployments that cannot run PostgreSQL
+	DriverMySQL = "mysql"
+)
+
+// mysqlMaxLifetime bounds how long a MySQL connection is reused when
+// DB_CONN_MAX_LIFETIME leaves it unbounded; see ConnectGorm
+const mysqlMaxLifetime = 3 * time.Minute
+
 // DatabaseConfig holds database configuration
 type DatabaseConfig struct {
+	// Driver is the storage backend: postgres, mysql, sqlite or memory
+	Driver string
+	// SQLitePath is the database file of the sqlite driver
+	SQLitePath string
+
 	// PostgreSQL configuration
 	PostgresHost     string
 	PostgresPort     int
@@ -16,12 +48,47 @@ type DatabaseConfig struct {
 	PostgresPassword string
 	PostgresDB       string
 	PostgresSSLMode  string
+	// PostgresReplicas are the read replicas, as host or host:port; they
+	// share the primary's credentials and database name
+	PostgresReplicas     []string
+	ReplicaCheckInterval time.Duration
+
+	// MySQL configuration, also used for MariaDB
+	MySQLHost     string
+	MySQLPort     int
+	MySQLUser     string
+	MySQLPassword string
+	MySQLDB       string
+	// MySQLTLS is the driver's tls setting: false, true, skip-verify or
+	// preferred
+	MySQLTLS string
 
 	// Redis configuration
 	RedisHost     string
 	RedisPort     int
 	RedisPassword string
 	RedisDB       int
+	// RedisDegradation keeps the server running without the cache while
+	// Redis is unavailable, including at startup; see
+	// repositories.CacheDegradation
+	RedisDegradation      bool
+	RedisFailureThreshold int
+	RedisProbeInterval    time.Duration
+	// RedisMemoryGuard shortens cache TTLs and pauses caching as Redis
+	// nears its memory limit, so session keys are not evicted; see
+	// repositories.CachePressure
+	RedisMemoryGuard    bool
+	RedisMemoryHigh     float64
+	RedisMemoryCritical float64
+	RedisTTLFactor      float64
+	// RedisMaxMemory is the limit in bytes to assume when Redis reports none
+	RedisMaxMemory   int64
+	RedisMemoryCheck time.Duration
+	// RedisLocalCache keeps hot users and published posts in process in
+	// front of Redis, invalidated over pub/sub; see repositories.LocalCache
+	RedisLocalCache bool
+	RedisLocalSize  int
+	RedisLocalTTL   time.Duration
 
 	// Connection settings
 	MaxConnections  int
@@ -29,13 +96,26 @@ type DatabaseConfig struct {
 	ConnMaxLifetime time.Duration
 	ConnMaxIdleTime time.Duration
 
+	// Query logging
+	// SlowQueryThreshold is how long a query runs before it is logged as
+	// slow; 0 turns slow query logging off
+	SlowQueryThreshold time.Duration
+	// LogQueries logs every query; parameters are redacted either way
+	LogQueries bool
+
 	// Migration settings
 	MigrationPath string
+	// AutoMigrate creates and alters tables from the models at startup.
+	// Turn it off when deploys run cmd/migrate instead.
+	AutoMigrate bool
 }
 
 // NewDatabaseConfig creates a new database configuration from environment variables
 func NewDatabaseConfig() *DatabaseConfig {
 	return &DatabaseConfig{
+		Driver:     strings.ToLower(getEnv("DB_DRIVER", DriverPostgres)),
+		SQLitePath: getEnv("SQLITE_PATH", "dev.db"),
+
 		// PostgreSQL defaults
 		PostgresHost:     getEnv("POSTGRES_HOST", "localhost"),
 		PostgresPort:     getEnvAsInt("POSTGRES_PORT", 5432),
@@ -44,21 +124,119 @@ func NewDatabaseConfig() *DatabaseConfig {
 		PostgresDB:       getEnv("POSTGRES_DB", "go_server"),
 		PostgresSSLMode:  getEnv("POSTGRES_SSLMODE", "disable"),
 
+		PostgresReplicas:     getEnvAsList("POSTGRES_REPLICA_HOSTS"),
+		ReplicaCheckInterval: getEnvAsDuration("POSTGRES_REPLICA_CHECK_INTERVAL", 10*time.Second),
+
+		// MySQL defaults
+		MySQLHost:     getEnv("MYSQL_HOST", "localhost"),
+		MySQLPort:     getEnvAsInt("MYSQL_PORT", 3306),
+		MySQLUser:     getEnv("MYSQL_USER", "root"),
+		MySQLPassword: getEnv("MYSQL_PASSWORD", "password"),
+		MySQLDB:       getEnv("MYSQL_DB", "go_server"),
+		MySQLTLS:      strings.ToLower(getEnv("MYSQL_TLS", "false")),
+
 		// Redis defaults
 		RedisHost:     getEnv("REDIS_HOST", "localhost"),
 		RedisPort:     getEnvAsInt("REDIS_PORT", 6379),
 		RedisPassword: getEnv("REDIS_PASSWORD", ""),
 		RedisDB:       getEnvAsInt("REDIS_DB", 0),
 
+		RedisDegradation:      getEnvAsBool("REDIS_DEGRADATION_ENABLED", false),
+		RedisFailureThreshold: getEnvAsInt("REDIS_FAILURE_THRESHOLD", 3),
+		RedisProbeInterval:    getEnvAsDuration("REDIS_PROBE_INTERVAL", 5*time.Second),
+
+		RedisMemoryGuard:    getEnvAsBool("REDIS_MEMORY_GUARD_ENABLED", false),
+		RedisMemoryHigh:     getEnvAsFloat("REDIS_MEMORY_HIGH_WATERMARK", 0.75),
+		RedisMemoryCritical: getEnvAsFloat("REDIS_MEMORY_CRITICAL_WATERMARK", 0.9),
+		RedisTTLFactor:      getEnvAsFloat("REDIS_MEMORY_TTL_FACTOR", 0.25),
+		RedisMaxMemory:      int64(getEnvAsInt("REDIS_MAX_MEMORY", 0)),
+		RedisMemoryCheck:    getEnvAsDuration("REDIS_MEMORY_CHECK_INTERVAL", 30*time.Second),
+
+		RedisLocalCache: getEnvAsBool("CACHE_LOCAL_ENABLED", false),
+		RedisLocalSize:  getEnvAsInt("CACHE_LOCAL_SIZE", 10000),
+		RedisLocalTTL:   getEnvAsDuration("CACHE_LOCAL_TTL", 30*time.Second),
+
 		// Connection settings
 		MaxConnections:  getEnvAsInt("DB_MAX_CONNECTIONS", 25),
 		MaxIdleConns:    getEnvAsInt("DB_MAX_IDLE_CONNS", 5),
 		ConnMaxLifetime: getEnvAsDuration("DB_CONN_MAX_LIFETIME", 5*time.Minute),
 		ConnMaxIdleTime: getEnvAsDuration("DB_CONN_MAX_IDLE_TIME", 1*time.Minute),
 
+		// Query logging
+		SlowQueryThreshold: getEnvAsDuration("DB_SLOW_QUERY_THRESHOLD", 200*time.Millisecond),
+		LogQueries:         getEnvAsBool("DB_LOG_QUERIES", false),
+
 		// Migration settings
 		MigrationPath: getEnv("MIGRATION_PATH", "migrations"),
+		AutoMigrate:   getEnvAsBool("DB_AUTO_MIGRATE", true),
+	}
+}
+
+// Validate checks the storage driver and the cache settings
+func (c *DatabaseConfig) Validate() error {
+	if c.RedisMemoryGuard {
+		if c.RedisMemoryHigh <= 0 || c.RedisMemoryHigh >= c.RedisMemoryCritical || c.RedisMemoryCritical > 1 {
+			return fmt.Errorf("redis memory watermarks must satisfy 0 < high < critical <= 1")
+		}
+		if c.RedisTTLFactor <= 0 || c.RedisTTLFactor > 1 {
+			return fmt.Errorf("REDIS_MEMORY_TTL_FACTOR must be in (0, 1]")
+		}
+		if c.RedisMaxMemory < 0 || c.RedisMemoryCheck < 0 {
+			return fmt.Errorf("redis memory guard settings cannot be negative")
+		}
+	}
+
+	if c.RedisLocalSize < 0 || c.RedisLocalTTL < 0 {
+		return fmt.Errorf("local cache settings cannot be negative")
+	}
+
+	switch c.Driver {
+	case DriverPostgres, DriverMemory:
+		return nil
+	case DriverSQLite:
+		if c.SQLitePath == "" {
+			return fmt.Errorf("SQLITE_PATH is required with DB_DRIVER=sqlite")
+		}
+		return nil
+	case DriverMySQL:
+		if c.MySQLDB == "" {
+			return fmt.Errorf("MYSQL_DB is required with DB_DRIVER=mysql")
+		}
+		switch c.MySQLTLS {
+		case "false", "true", "skip-verify", "preferred":
+			return nil
+		}
+		return fmt.Errorf("invalid MYSQL_TLS %q: must be false, true, skip-verify or preferred", c.MySQLTLS)
+	default:
+		return fmt.Errorf("unknown DB_DRIVER %q: must be postgres, mysql, sqlite or memory", c.Driver)
+	}
+}
+
+// UsesPostgres reports whether the driver is PostgreSQL. Read replicas
+// and the pgxpool connection only exist with PostgreSQL.
+func (c *DatabaseConfig) UsesPostgres() bool {
+	return c.Driver == DriverPostgres
+}
+
+// UsesSQLite reports whether the driver is SQLite, on file or in memory
+func (c *DatabaseConfig) UsesSQLite() bool {
+	return c.Driver == DriverSQLite || c.Driver == DriverMemory
+}
+
+// ResolveSecrets replaces the database passwords with those held by the
+// secrets provider, e.g. from POSTGRES_PASSWORD_FILE or a secret manager
+func (c *DatabaseConfig) ResolveSecrets(ctx context.Context, provider secrets.Provider) error {
+	var err error
+	if c.PostgresPassword, err = secrets.Resolve(ctx, provider, "POSTGRES_PASSWORD", c.PostgresPassword); err != nil {
+		return fmt.Errorf("failed to resolve PostgreSQL password: %w", err)
 	}
+	if c.MySQLPassword, err = secrets.Resolve(ctx, provider, "MYSQL_PASSWORD", c.MySQLPassword); err != nil {
+		return fmt.Errorf("failed to resolve MySQL password: %w", err)
+	}
+	if c.RedisPassword, err = secrets.Resolve(ctx, provider, "REDIS_PASSWORD", c.RedisPassword); err != nil {
+		return fmt.Errorf("failed to resolve Redis password: %w", err)
+	}
+	return nil
 }
 
 // GetPostgresDSN returns the PostgreSQL connection string
@@ -67,11 +245,88 @@ func (c *DatabaseConfig) GetPostgresDSN() string {
 		c.PostgresHost, c.PostgresPort, c.PostgresUser, c.PostgresPassword, c.PostgresDB, c.PostgresSSLMode)
 }
 
+// GetMySQLDSN returns the MySQL connection string. Times are read and
+// written in UTC, and text is utf8mb4 compared byte for byte, as
+// PostgreSQL compares it.
+func (c *DatabaseConfig) GetMySQLDSN() string {
+	config := mysql.NewConfig()
+	config.User = c.MySQLUser
+	config.Passwd = c.MySQLPassword
+	config.Net = "tcp"
+	config.Addr = net.JoinHostPort(c.MySQLHost, strconv.Itoa(c.MySQLPort))
+	config.DBName = c.MySQLDB
+	config.TLSConfig = c.MySQLTLS
+	config.Collation = "utf8mb4_bin"
+	config.ParseTime = true
+	config.Loc = time.UTC
+	return config.FormatDSN()
+}
+
+// GetSQLiteDSN returns the SQLite connection string of the sqlite or
+// memory driver. Transactions take the write lock when they begin and
+// wait for it rather than failing, and foreign keys are enforced as
+// PostgreSQL enforces them.
+func (c *DatabaseConfig) GetSQLiteDSN() string {
+	options := "_busy_timeout=5000&_txlock=immediate&_foreign_keys=1"
+	if c.Driver == DriverMemory {
+		// The memdb VFS shares one database between the pool's connections
+		return "file:/go-server?vfs=memdb&" + options
+	}
+	separator := "?"
+	if strings.Contains(c.SQLitePath, "?") {
+		separator = "&"
+	}
+	return c.SQLitePath + separator + "_journal_mode=WAL&" + options
+}
+
+// GetReplicaDSN returns the connection string of a read replica given as
+// host or host:port
+func (c *DatabaseConfig) GetReplicaDSN(replica string) string {
+	host, port := replica, c.PostgresPort
+	if h, p, err := net.SplitHostPort(replica); err == nil {
+		if n, err := strconv.Atoi(p); err == nil {
+			host, port = h, n
+		}
+	}
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		host, port, c.PostgresUser, c.PostgresPassword, c.PostgresDB, c.PostgresSSLMode)
+}
+
 // GetRedisAddr returns the Redis address
 func (c *DatabaseConfig) GetRedisAddr() string {
 	return fmt.Sprintf("%s:%d", c.RedisHost, c.RedisPort)
 }
 
+// CacheDegradation returns the cache degradation settings, and false when
+// REDIS_DEGRADATION_ENABLED is off
+func (c *DatabaseConfig) CacheDegradation() (repositories.CacheDegradation, bool) {
+	return repositories.CacheDegradation{
+		FailureThreshold: c.RedisFailureThreshold,
+		ProbeInterval:    c.RedisProbeInterval,
+	}, c.RedisDegradation
+}
+
+// CachePressure returns the cache memory guard settings, and false when
+// REDIS_MEMORY_GUARD_ENABLED is off
+func (c *DatabaseConfig) CachePressure() (repositories.CachePressure, bool) {
+	return repositories.CachePressure{
+		HighWatermark:     c.RedisMemoryHigh,
+		CriticalWatermark: c.RedisMemoryCritical,
+		TTLFactor:         c.RedisTTLFactor,
+		MaxMemory:         c.RedisMaxMemory,
+		CheckInterval:     c.RedisMemoryCheck,
+	}, c.RedisMemoryGuard
+}
+
+// LocalCache returns the in-process cache tier settings, and false when
+// CACHE_LOCAL_ENABLED is off
+func (c *DatabaseConfig) LocalCache() (repositories.LocalCache, bool) {
+	return repositories.LocalCache{
+		Size: c.RedisLocalSize,
+		TTL:  c.RedisLocalTTL,
+	}, c.RedisLocalCache
+}
+
 // Helper functions
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -89,6 +344,34 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvAsList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
 func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
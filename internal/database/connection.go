@@ -2,15 +2,19 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"log"
 
+	"go-server/internal/bootreport"
+	"go-server/internal/database/replicas"
+	"go-server/internal/database/repositories"
+
 	"github.com/go-redis/redis/v8"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
 // DatabaseManager manages database connections
@@ -18,7 +22,13 @@ type DatabaseManager struct {
 	PostgresPool *pgxpool.Pool
 	GormDB       *gorm.DB
 	RedisClient  *redis.Client
-	Config       *DatabaseConfig
+	// Replicas routes GormDB's reads to the read replicas; nil without any
+	Replicas *replicas.Resolver
+	// Queries logs GormDB's slow and failed queries
+	Queries *QueryLogger
+	Config  *DatabaseConfig
+	// boot records the connections in the boot report
+	boot *bootreport.Recorder
 }
 
 // NewDatabaseManager creates a new database manager
@@ -28,6 +38,22 @@ func NewDatabaseManager(config *DatabaseConfig) *DatabaseManager {
 	}
 }
 
+// WithBootReport records the connections in boot instead of logging each
+func (dm *DatabaseManager) WithBootReport(boot *bootreport.Recorder) *DatabaseManager {
+	dm.boot = boot
+	return dm
+}
+
+// connected records a connection in the boot report, or logs line without
+// one
+func (dm *DatabaseManager) connected(name, detail, line string) {
+	if dm.boot == nil {
+		log.Println(line)
+		return
+	}
+	dm.boot.Subsystem(name, bootreport.StatusEnabled, detail)
+}
+
 // ConnectPostgres establishes PostgreSQL connection using pgxpool
 func (dm *DatabaseManager) ConnectPostgres(ctx context.Context) error {
 	config, err := pgxpool.ParseConfig(dm.Config.GetPostgresDSN())
@@ -52,24 +78,31 @@ func (dm *DatabaseManager) ConnectPostgres(ctx context.Context) error {
 		return fmt.Errorf("failed to ping postgres: %w", err)
 	}
 
-	log.Println("✅ PostgreSQL connected successfully")
+	dm.connected("postgres", fmt.Sprintf("%s:%d/%s", dm.Config.PostgresHost, dm.Config.PostgresPort, dm.Config.PostgresDB),
+		"✅ PostgreSQL connected successfully")
 	return nil
 }
 
-// ConnectGorm establishes GORM connection for ORM operations
+// ConnectGorm establishes GORM connection for ORM operations, to the
+// database of the configured driver
 func (dm *DatabaseManager) ConnectGorm() error {
-	var dialector gorm.Dialector
+	if err := dm.Config.Validate(); err != nil {
+		return err
+	}
 
-	// Use PostgreSQL in production, SQLite in development
-	if dm.Config.PostgresHost != "localhost" || dm.Config.PostgresDB != "go_server" {
+	var dialector gorm.Dialector
+	switch {
+	case dm.Config.UsesPostgres():
 		dialector = postgres.Open(dm.Config.GetPostgresDSN())
-	} else {
-		// Use SQLite for development
-		dialector = sqlite.Open("dev.db")
+	case dm.Config.Driver == DriverMySQL:
+		dialector = openMySQL(dm.Config.GetMySQLDSN())
+	default:
+		dialector = sqlite.Open(dm.Config.GetSQLiteDSN())
 	}
 
+	queries := NewQueryLogger(dm.Config.SlowQueryThreshold, dm.Config.LogQueries)
 	db, err := gorm.Open(dialector, &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		Logger: queries,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to connect to database: %w", err)
@@ -81,13 +114,78 @@ func (dm *DatabaseManager) ConnectGorm() error {
 		return fmt.Errorf("failed to get underlying sql.DB: %w", err)
 	}
 
+	dm.configurePool(sqlDB)
+	if dm.Config.Driver == DriverMySQL && dm.Config.ConnMaxLifetime <= 0 {
+		// MySQL closes connections idle longer than its wait_timeout, and
+		// the pool would hand them out broken
+		sqlDB.SetConnMaxLifetime(mysqlMaxLifetime)
+	}
+	if dm.Config.UsesSQLite() {
+		if err := db.Use(repositories.UTCTimes{}); err != nil {
+			sqlDB.Close()
+			return fmt.Errorf("failed to register the SQLite time conversion: %w", err)
+		}
+	}
+	if dm.Config.Driver == DriverMemory {
+		// The in-memory database goes away with its last connection, so
+		// one connection is kept open for the life of the process
+		sqlDB.SetMaxIdleConns(max(dm.Config.MaxIdleConns, 1))
+		sqlDB.SetConnMaxLifetime(0)
+		sqlDB.SetConnMaxIdleTime(0)
+	}
+
+	if dm.Config.UsesPostgres() && len(dm.Config.PostgresReplicas) > 0 {
+		if err := dm.connectReplicas(db); err != nil {
+			sqlDB.Close()
+			return err
+		}
+	}
+
+	dm.GormDB = db
+	dm.Queries = queries
+	dm.connected("database", dm.Config.Driver, fmt.Sprintf("✅ GORM connected successfully (%s)", dm.Config.Driver))
+	return nil
+}
+
+// configurePool applies the connection settings to a pool
+func (dm *DatabaseManager) configurePool(sqlDB *sql.DB) {
 	sqlDB.SetMaxOpenConns(dm.Config.MaxConnections)
 	sqlDB.SetMaxIdleConns(dm.Config.MaxIdleConns)
 	sqlDB.SetConnMaxLifetime(dm.Config.ConnMaxLifetime)
 	sqlDB.SetConnMaxIdleTime(dm.Config.ConnMaxIdleTime)
+}
 
-	dm.GormDB = db
-	log.Println("✅ GORM connected successfully")
+// connectReplicas opens a pool to each read replica and routes db's reads
+// to them. A replica that is down at startup starts out of rotation rather
+// than failing the connection.
+func (dm *DatabaseManager) connectReplicas(db *gorm.DB) error {
+	var pools []replicas.Replica
+	for _, host := range dm.Config.PostgresReplicas {
+		replicaDB, err := gorm.Open(postgres.Open(dm.Config.GetReplicaDSN(host)), &gorm.Config{})
+		if err == nil {
+			var sqlDB *sql.DB
+			if sqlDB, err = replicaDB.DB(); err == nil {
+				dm.configurePool(sqlDB)
+				pools = append(pools, replicas.Replica{Name: host, DB: sqlDB})
+				continue
+			}
+		}
+		for _, pool := range pools {
+			pool.DB.Close()
+		}
+		return fmt.Errorf("failed to open read replica %s: %w", host, err)
+	}
+
+	resolver := replicas.NewResolver(pools, dm.Config.ReplicaCheckInterval)
+	if err := db.Use(resolver); err != nil {
+		resolver.Close()
+		return fmt.Errorf("failed to route reads to replicas: %w", err)
+	}
+	resolver.Check(context.Background())
+	resolver.Start(context.Background())
+	dm.Replicas = resolver
+	dm.connected("read_replicas", fmt.Sprintf("%d replica(s)", len(pools)),
+		fmt.Sprintf("✅ Routing reads to %d read replica(s)", len(pools)))
 	return nil
 }
 
@@ -101,18 +199,27 @@ func (dm *DatabaseManager) ConnectRedis(ctx context.Context) error {
 
 	// Test connection
 	if err := dm.RedisClient.Ping(ctx).Err(); err != nil {
+		if dm.Config.RedisDegradation {
+			// The client reconnects on its own; the cache runs degraded until then
+			log.Printf("⚠️ Redis is unavailable, starting without the cache: %v", err)
+			dm.boot.Subsystem("redis", bootreport.StatusDegraded, err.Error())
+			return nil
+		}
 		return fmt.Errorf("failed to connect to redis: %w", err)
 	}
 
-	log.Println("✅ Redis connected successfully")
+	dm.connected("redis", dm.Config.GetRedisAddr(), "✅ Redis connected successfully")
 	return nil
 }
 
-// ConnectAll establishes all database connections
+// ConnectAll establishes all database connections. The pgxpool
+// connection is only opened with the postgres driver.
 func (dm *DatabaseManager) ConnectAll(ctx context.Context) error {
 	// Connect to PostgreSQL
-	if err := dm.ConnectPostgres(ctx); err != nil {
-		return fmt.Errorf("postgres connection failed: %w", err)
+	if dm.Config.UsesPostgres() {
+		if err := dm.ConnectPostgres(ctx); err != nil {
+			return fmt.Errorf("postgres connection failed: %w", err)
+		}
 	}
 
 	// Connect to GORM
@@ -144,6 +251,13 @@ func (dm *DatabaseManager) Close() error {
 		}
 	}
 
+	if dm.Replicas != nil {
+		dm.Replicas.Stop()
+		if err := dm.Replicas.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close read replica connections: %w", err))
+		}
+	}
+
 	if dm.RedisClient != nil {
 		if err := dm.RedisClient.Close(); err != nil {
 			errs = append(errs, fmt.Errorf("failed to close redis connection: %w", err))
@@ -158,12 +272,45 @@ func (dm *DatabaseManager) Close() error {
 	return nil
 }
 
+// ResetPools drops the pooled connections so the next queries dial fresh
+// ones, e.g. after a database failover left the pools holding connections
+// to the old primary. Redis reconnects on its own and is only pinged. It
+// returns the health of each connection afterwards.
+func (dm *DatabaseManager) ResetPools(ctx context.Context) map[string]string {
+	if dm.PostgresPool != nil {
+		dm.PostgresPool.Reset()
+	}
+
+	// The in-memory database would go away with its connections
+	if dm.GormDB != nil && dm.Config.Driver != DriverMemory {
+		if sqlDB, err := dm.GormDB.DB(); err == nil {
+			// Shrinking the idle pool to zero closes every idle connection;
+			// connections busy with a query are kept
+			sqlDB.SetMaxIdleConns(0)
+			sqlDB.SetMaxIdleConns(dm.Config.MaxIdleConns)
+		}
+	}
+
+	if dm.Replicas != nil {
+		for _, replica := range dm.Replicas.Replicas() {
+			replica.DB.SetMaxIdleConns(0)
+			replica.DB.SetMaxIdleConns(dm.Config.MaxIdleConns)
+		}
+		dm.Replicas.Check(ctx)
+	}
+
+	log.Println("🔄 Database connection pools reset")
+	return dm.HealthCheck(ctx)
+}
+
 // HealthCheck performs health checks on all connections
 func (dm *DatabaseManager) HealthCheck(ctx context.Context) map[string]string {
 	health := make(map[string]string)
 
 	// Check PostgreSQL
-	if dm.PostgresPool != nil {
+	if !dm.Config.UsesPostgres() {
+		health["postgres"] = "not used (" + dm.Config.Driver + ")"
+	} else if dm.PostgresPool != nil {
 		if err := dm.PostgresPool.Ping(ctx); err != nil {
 			health["postgres"] = "unhealthy: " + err.Error()
 		} else {
@@ -188,6 +335,13 @@ func (dm *DatabaseManager) HealthCheck(ctx context.Context) map[string]string {
 		health["gorm"] = "not connected"
 	}
 
+	// Check read replicas, as last seen by their health check
+	if dm.Replicas != nil {
+		for name, state := range dm.Replicas.Health() {
+			health["replica:"+name] = state
+		}
+	}
+
 	// Check Redis
 	if dm.RedisClient != nil {
 		if err := dm.RedisClient.Ping(ctx).Err(); err != nil {
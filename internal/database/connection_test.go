@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+)
+
+func TestDatabaseConfig_Validate(t *testing.T) {
+	for _, test := range []struct {
+		driver, path string
+		valid        bool
+	}{
+		{DriverPostgres, "", true},
+		{DriverSQLite, "data/server.db", true},
+		{DriverSQLite, "", false},
+		{DriverMemory, "", true},
+		{"oracle", "", false},
+	} {
+		config := &DatabaseConfig{Driver: test.driver, SQLitePath: test.path}
+		if err := config.Validate(); (err == nil) != test.valid {
+			t.Errorf("Validate(%q, %q) = %v, expected valid %v", test.driver, test.path, err, test.valid)
+		}
+	}
+}
+
+func TestDatabaseConfig_Driver(t *testing.T) {
+	t.Setenv("DB_DRIVER", "SQLite")
+	t.Setenv("SQLITE_PATH", "/var/lib/go-server/data.db?_cache_size=-20000")
+	config := NewDatabaseConfig()
+	if config.Driver != DriverSQLite || config.UsesPostgres() {
+		t.Fatalf("Expected the sqlite driver, got %q", config.Driver)
+	}
+	expected := "/var/lib/go-server/data.db?_cache_size=-20000&_journal_mode=WAL&_busy_timeout=5000&_txlock=immediate&_foreign_keys=1"
+	if dsn := config.GetSQLiteDSN(); dsn != expected {
+		t.Errorf("Expected %s, got %s", expected, dsn)
+	}
+}
+
+func TestConnectAll_Memory(t *testing.T) {
+	ctx := context.Background()
+	config := NewDatabaseConfig()
+	config.Driver = DriverMemory
+	// Nothing listens here; with degradation Redis is optional
+	config.RedisHost, config.RedisPort = "127.0.0.1", 1
+	config.RedisDegradation = true
+	config.MaxIdleConns = 0
+
+	manager := NewDatabaseManager(config)
+	if err := manager.ConnectAll(ctx); err != nil {
+		t.Fatalf("ConnectAll failed: %v", err)
+	}
+	defer manager.Close()
+	if manager.PostgresPool != nil || manager.Replicas != nil {
+		t.Error("Expected no PostgreSQL connections with the memory driver")
+	}
+
+	migrations := NewMigrationManager(config)
+	migrations.SetupMigration(manager.GormDB)
+	if err := migrations.Up(); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	version, err := migrations.Version()
+	if err != nil || version != fmt.Sprintf("Tables: %d", len(Models())) {
+		t.Errorf("Expected a table per model, got %q, %v", version, err)
+	}
+
+	users := repositories.NewUserRepository(manager.GormDB)
+	if err := users.CreateUser(ctx, &models.User{Email: "ada@example.com", Username: "ada", Password: "hash"}); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	// The database outlives idle connections and a pool reset
+	manager.ResetPools(ctx)
+	if _, err := users.GetUserByEmail(ctx, "ada@example.com"); err != nil {
+		t.Errorf("Expected the user after a pool reset, got %v", err)
+	}
+
+	health := repositories.NewRepositoryManager(manager.PostgresPool, manager.GormDB, nil).HealthCheck(ctx)
+	if health["postgres"] != "not used (sqlite)" || health["gorm"] != "healthy" {
+		t.Errorf("Expected a healthy sqlite database, got %v", health)
+	}
+}
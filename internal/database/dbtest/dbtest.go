@@ -0,0 +1,45 @@
+// Package dbtest opens throwaway SQLite databases for tests.
+package dbtest
+
+import (
+	"fmt"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// Open opens an in-memory database of the test's own and migrates models
+// into it. The database goes away with its last connection, which is
+// closed when the test ends.
+func Open(t testing.TB, models ...any) *gorm.DB {
+	t.Helper()
+	return open(t, t.Name(), models)
+}
+
+// OpenNamed is Open for tests using several databases, told apart by name
+func OpenNamed(t testing.TB, name string, models ...any) *gorm.DB {
+	t.Helper()
+	return open(t, t.Name()+"_"+name, models)
+}
+
+func open(t testing.TB, name string, models []any) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", name)
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	if len(models) > 0 {
+		if err := db.AutoMigrate(models...); err != nil {
+			t.Fatalf("Failed to migrate: %v", err)
+		}
+	}
+	return db
+}
@@ -0,0 +1,232 @@
+// Package fixtures loads users, organizations, posts and comments described
+// in YAML or JSON files, for the seed command and for tests:
+//
+//	users:
+//	  ada:
+//	    email: ada@example.com
+//	    username: ada
+//	    password: correct-horse
+//	organizations:
+//	  analytical:
+//	    name: Analytical Engines
+//	    slug: analytical
+//	    owner: ada
+//	    members: {grace: admin}
+//	posts:
+//	  welcome:
+//	    title: Welcome
+//	    slug: welcome
+//	    content: Hello
+//	    status: published
+//	    author: ada
+//	comments:
+//	  first:
+//	    post: welcome
+//	    author: grace
+//	    body: Nice
+//
+// Records are named, and refer to each other by name. Each record's ID,
+// and public ID where it has one, derive from its table and name, so a
+// test can refer to fixtures.ID("users", "ada") without looking it up and
+// the same files give the same IDs on every database. The IDs start at
+// 2^20, clear of the IDs a fresh database's sequences hand out.
+//
+// Records are validated as a request creating them would be, through the
+// models' validate tags, before anything is written, and are created
+// through the service layer and repositories. Records whose ID exists are
+// left alone, so loading files again only adds what is missing.
+package fixtures
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Tables, as named in fixture files and by ID
+const (
+	TableUsers         = "users"
+	TableOrganizations = "organizations"
+	TablePosts         = "posts"
+	TableComments      = "comments"
+)
+
+// User is a user record. Password is hashed when the user is created.
+type User struct {
+	Email     string `json:"email" yaml:"email"`
+	Username  string `json:"username" yaml:"username"`
+	Password  string `json:"password" yaml:"password"`
+	FirstName string `json:"first_name,omitempty" yaml:"first_name,omitempty"`
+	LastName  string `json:"last_name,omitempty" yaml:"last_name,omitempty"`
+	Role      string `json:"role,omitempty" yaml:"role,omitempty"`
+	Admin     bool   `json:"admin,omitempty" yaml:"admin,omitempty"`
+	Inactive  bool   `json:"inactive,omitempty" yaml:"inactive,omitempty"`
+}
+
+// Organization is an organization record. Owner is a user, who becomes
+// its owner member; Members maps other users to their roles.
+type Organization struct {
+	Name    string            `json:"name" yaml:"name"`
+	Slug    string            `json:"slug" yaml:"slug"`
+	Owner   string            `json:"owner" yaml:"owner"`
+	Members map[string]string `json:"members,omitempty" yaml:"members,omitempty"`
+}
+
+// Post is a post record written by the user Author. A published post
+// without PublishedAt is published when it is loaded.
+type Post struct {
+	Title       string     `json:"title" yaml:"title"`
+	Slug        string     `json:"slug" yaml:"slug"`
+	Content     string     `json:"content" yaml:"content"`
+	Excerpt     string     `json:"excerpt,omitempty" yaml:"excerpt,omitempty"`
+	Status      string     `json:"status,omitempty" yaml:"status,omitempty"`
+	Author      string     `json:"author" yaml:"author"`
+	PublishedAt *time.Time `json:"published_at,omitempty" yaml:"published_at,omitempty"`
+}
+
+// Comment is a comment record by the user Author on the post Post
+type Comment struct {
+	Post   string `json:"post" yaml:"post"`
+	Author string `json:"author" yaml:"author"`
+	Body   string `json:"body" yaml:"body"`
+}
+
+// Set is the records of one or more fixture files, by table and name
+type Set struct {
+	Users         map[string]User         `json:"users,omitempty" yaml:"users,omitempty"`
+	Organizations map[string]Organization `json:"organizations,omitempty" yaml:"organizations,omitempty"`
+	Posts         map[string]Post         `json:"posts,omitempty" yaml:"posts,omitempty"`
+	Comments      map[string]Comment      `json:"comments,omitempty" yaml:"comments,omitempty"`
+}
+
+// Parse parses a fixture file, as YAML or, if name ends in .json, JSON.
+// Unknown fields are errors, so a misspelt field is not silently dropped.
+func Parse(name string, data []byte) (*Set, error) {
+	set := &Set{}
+	if strings.EqualFold(filepath.Ext(name), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(set); err != nil {
+			return nil, fmt.Errorf("invalid fixture file %s: %w", name, err)
+		}
+		return set, nil
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(set); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid fixture file %s: %w", name, err)
+	}
+	return set, nil
+}
+
+// ReadFiles reads fixture files into one set. A directory stands for the
+// .yaml, .yml and .json files in it. A record name used twice in a table
+// is an error.
+func ReadFiles(paths ...string) (*Set, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixtures: %w", err)
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixtures: %w", err)
+		}
+		for _, entry := range entries {
+			switch strings.ToLower(filepath.Ext(entry.Name())) {
+			case ".yaml", ".yml", ".json":
+				if !entry.IsDir() {
+					files = append(files, filepath.Join(path, entry.Name()))
+				}
+			}
+		}
+	}
+
+	set := &Set{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixtures: %w", err)
+		}
+		parsed, err := Parse(file, data)
+		if err != nil {
+			return nil, err
+		}
+		if err := set.merge(parsed); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	return set, nil
+}
+
+// merge adds other's records to the set
+func (s *Set) merge(other *Set) error {
+	var err error
+	if s.Users, err = mergeTable(TableUsers, s.Users, other.Users); err != nil {
+		return err
+	}
+	if s.Organizations, err = mergeTable(TableOrganizations, s.Organizations, other.Organizations); err != nil {
+		return err
+	}
+	if s.Posts, err = mergeTable(TablePosts, s.Posts, other.Posts); err != nil {
+		return err
+	}
+	s.Comments, err = mergeTable(TableComments, s.Comments, other.Comments)
+	return err
+}
+
+func mergeTable[T any](table string, into, from map[string]T) (map[string]T, error) {
+	if into == nil && len(from) > 0 {
+		into = make(map[string]T, len(from))
+	}
+	for name, record := range from {
+		if _, ok := into[name]; ok {
+			return nil, fmt.Errorf("%s.%s is defined twice", table, name)
+		}
+		into[name] = record
+	}
+	return into, nil
+}
+
+// ID returns the ID of the record name of table
+func ID(table, name string) uint {
+	hash := fnv.New32a()
+	hash.Write([]byte(table + "/" + name))
+	return uint(hash.Sum32()%(1<<30-1<<20)) + 1<<20
+}
+
+// PublicID returns the public ID of the record name of table: a UUID,
+// version 8, derived from its table and name
+func PublicID(table, name string) string {
+	sum := sha256.Sum256([]byte(table + "/" + name))
+	sum[6] = sum[6]&0x0f | 0x80
+	sum[8] = sum[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// names returns a table's record names in order, so records are created,
+// and errors reported, in the same order on every run
+func names[T any](table map[string]T) []string {
+	sorted := make([]string, 0, len(table))
+	for name := range table {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
@@ -0,0 +1,172 @@
+package fixtures
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/dbtest"
+	"go-server/internal/database/models"
+	"go-server/internal/database/seed"
+	"go-server/internal/logger"
+
+	"gorm.io/gorm"
+)
+
+func newFixtureDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	return dbtest.Open(t, &models.User{}, &models.Post{}, &models.Comment{},
+		&models.Organization{}, &models.OrganizationMember{}, &models.OutboxEvent{})
+}
+
+func TestLoadFiles(t *testing.T) {
+	ctx := context.Background()
+	db := newFixtureDB(t)
+
+	set, err := LoadFiles(ctx, db, logger.NewServerLogger(), "testdata")
+	if err != nil {
+		t.Fatalf("LoadFiles failed: %v", err)
+	}
+	if len(set.Users) != 3 || len(set.Posts) != 2 || len(set.Comments) != 1 {
+		t.Fatalf("Expected both files read, got %+v", set)
+	}
+
+	var ada models.User
+	if err := db.First(&ada, ID(TableUsers, "ada")).Error; err != nil {
+		t.Fatalf("Expected ada at her fixture ID: %v", err)
+	}
+	if ada.PublicID != PublicID(TableUsers, "ada") || ada.Password == "correct-horse-battery" || !ada.IsAdmin || ada.Role != "admin" {
+		t.Errorf("Unexpected user %+v", ada)
+	}
+
+	var babbage models.User
+	db.First(&babbage, ID(TableUsers, "babbage"))
+	if babbage.IsActive {
+		t.Error("Expected an inactive user to stay inactive")
+	}
+
+	var comment models.Comment
+	if err := db.First(&comment, ID(TableComments, "first")).Error; err != nil {
+		t.Fatalf("Expected the comment: %v", err)
+	}
+	if comment.PostID != ID(TablePosts, "welcome") || comment.AuthorID != ID(TableUsers, "grace") {
+		t.Errorf("Expected the comment's references resolved, got %+v", comment)
+	}
+
+	var welcome, notes models.Post
+	db.First(&welcome, ID(TablePosts, "welcome"))
+	db.First(&notes, ID(TablePosts, "notes"))
+	if !welcome.IsPublished() || !welcome.PublishedAt.Equal(time.Date(2026, 1, 2, 9, 0, 0, 0, time.UTC)) || !notes.IsDraft() {
+		t.Errorf("Expected a published post and a draft, got %+v and %+v", welcome, notes)
+	}
+
+	var members []models.OrganizationMember
+	db.Where("organization_id = ?", ID(TableOrganizations, "analytical")).Order("role DESC").Find(&members)
+	if len(members) != 2 || members[0].UserID != ada.ID || members[0].Role != models.OrgRoleOwner || members[1].Role != models.OrgRoleAdmin {
+		t.Errorf("Expected ada as owner and grace as admin, got %+v", members)
+	}
+
+	// Loading again leaves the records, and edits to them, alone
+	db.Model(&models.Post{}).Where("id = ?", welcome.ID).Update("title", "Edited")
+	result, err := NewLoader(db, logger.NewServerLogger()).Load(ctx, set)
+	if err != nil || result.Created != 0 || result.Existing != 7 {
+		t.Fatalf("Expected every record found existing, got %+v, %v", result, err)
+	}
+	db.First(&welcome, welcome.ID)
+	if welcome.Title != "Edited" {
+		t.Errorf("Expected the edit kept, got %q", welcome.Title)
+	}
+}
+
+func TestSet_Validate(t *testing.T) {
+	set, err := Parse("broken.yaml", []byte(`
+users:
+  ada: {email: not-an-email, username: ada, password: secret, role: wizard}
+organizations:
+  acme: {name: Acme, slug: acme, owner: ada, members: {ada: admin, bob: member}}
+posts:
+  hello: {title: Hello, slug: hello, content: Hi, status: live, author: ada}
+comments:
+  reply: {post: goodbye, author: ada, body: Bye}
+`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	err = set.Validate()
+	if err == nil {
+		t.Fatal("Expected the set to be invalid")
+	}
+	for _, problem := range []string{
+		"users.ada: Email",
+		`users.ada: unknown role "wizard"`,
+		"organizations.acme: the owner ada is a member already",
+		`organizations.acme: members refers to "bob", which is not defined`,
+		"posts.hello: Status",
+		`comments.reply: post refers to "goodbye", which is not defined`,
+	} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("Expected %q among the problems, got:\n%v", problem, err)
+		}
+	}
+
+	db := newFixtureDB(t)
+	if _, err := NewLoader(db, logger.NewServerLogger()).Load(context.Background(), set); err == nil {
+		t.Fatal("Expected an invalid set not to load")
+	}
+	var count int64
+	db.Model(&models.User{}).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected nothing written, got %d users", count)
+	}
+}
+
+func TestParse_RejectsUnknownFields(t *testing.T) {
+	if _, err := Parse("typo.yaml", []byte("users:\n  ada: {emial: ada@example.com}\n")); err == nil {
+		t.Error("Expected a misspelt YAML field to be rejected")
+	}
+	if _, err := Parse("typo.json", []byte(`{"post": {}}`)); err == nil {
+		t.Error("Expected an unknown JSON table to be rejected")
+	}
+	if _, err := ReadFiles("testdata", "testdata/blog.yaml"); err == nil || !strings.Contains(err.Error(), "defined twice") {
+		t.Errorf("Expected a record defined twice to be rejected, got %v", err)
+	}
+}
+
+func TestIDs(t *testing.T) {
+	if ID(TableUsers, "ada") != ID(TableUsers, "ada") || ID(TableUsers, "ada") == ID(TablePosts, "ada") {
+		t.Error("Expected IDs to depend on the table and name only")
+	}
+	if id := ID(TableUsers, "ada"); id < 1<<20 || id >= 1<<30 {
+		t.Errorf("Expected the ID in [2^20, 2^30), got %d", id)
+	}
+	publicID := PublicID(TablePosts, "welcome")
+	if len(publicID) != 36 || publicID[14] != '8' || publicID != PublicID(TablePosts, "welcome") {
+		t.Errorf("Expected a stable version 8 UUID, got %s", publicID)
+	}
+}
+
+func TestSeed(t *testing.T) {
+	ctx := context.Background()
+	db := newFixtureDB(t)
+	set, err := ReadFiles("testdata/blog.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seeder := seed.NewSeeder(db, logger.NewServerLogger(), Seed("blog", set, logger.NewServerLogger(), seed.EnvDevelopment)).
+		WithClock(clock.NewFake(time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)))
+	results, err := seeder.Run(ctx, seed.EnvDevelopment, false)
+	if err != nil || len(results) != 1 || results[0].Outcome != seed.OutcomeApplied {
+		t.Fatalf("Expected the fixtures applied, got %+v, %v", results, err)
+	}
+	results, _ = seeder.Run(ctx, seed.EnvDevelopment, false)
+	if results[0].Outcome != seed.OutcomeUnchanged {
+		t.Errorf("Expected unchanged fixtures to be skipped, got %+v", results)
+	}
+	results, _ = seeder.Run(ctx, seed.EnvProduction, false)
+	if results[0].Outcome != seed.OutcomeSkipped {
+		t.Errorf("Expected the fixtures skipped in production, got %+v", results)
+	}
+}
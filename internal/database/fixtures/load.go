@@ -0,0 +1,184 @@
+package fixtures
+
+import (
+	"context"
+	"fmt"
+
+	"go-server/internal/auth"
+	"go-server/internal/clock"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/database/seed"
+	"go-server/internal/logger"
+	"go-server/internal/services"
+
+	"gorm.io/gorm"
+)
+
+// Result counts the records a load created and those it found existing
+type Result struct {
+	Created  int `json:"created"`
+	Existing int `json:"existing"`
+}
+
+// Loader writes fixture sets to a database
+type Loader struct {
+	db     *gorm.DB
+	logger logger.Logger
+	clock  clock.Clock
+}
+
+// NewLoader creates a new loader writing to db
+func NewLoader(db *gorm.DB, logger logger.Logger) *Loader {
+	return &Loader{db: db, logger: logger, clock: clock.New()}
+}
+
+// WithClock sets the time source for published_at
+func (l *Loader) WithClock(c clock.Clock) *Loader {
+	l.clock = clock.OrDefault(c)
+	return l
+}
+
+// Load validates set and creates its records in one transaction: users,
+// then organizations, posts and comments. Records whose ID exists, even
+// soft-deleted, are left as they are.
+func (l *Loader) Load(ctx context.Context, set *Set) (Result, error) {
+	var result Result
+	if err := set.Validate(); err != nil {
+		return result, fmt.Errorf("invalid fixtures: %w", err)
+	}
+	err := l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result = Result{}
+		return l.load(ctx, tx, set, &result)
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	l.logger.Info("Loaded fixtures: %d created, %d existing", result.Created, result.Existing)
+	return result, nil
+}
+
+func (l *Loader) load(ctx context.Context, tx *gorm.DB, set *Set, result *Result) error {
+	// exists reports whether the record's row is there, counting it if so
+	exists := func(model interface{}, id uint) (bool, error) {
+		var count int64
+		if err := tx.Unscoped().Model(model).Where("id = ?", id).Count(&count).Error; err != nil {
+			return false, err
+		}
+		if count > 0 {
+			result.Existing++
+		}
+		return count > 0, nil
+	}
+
+	users := services.NewUserService(repositories.NewUserRepository(tx), nil, l.logger)
+	for _, name := range names(set.Users) {
+		user := set.Users[name].model(name)
+		if found, err := exists(user, user.ID); err != nil {
+			return err
+		} else if found {
+			continue
+		}
+		password, err := auth.HashPassword(user.Password)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", TableUsers, name, err)
+		}
+		user.Password = password
+		if err := users.CreateUser(ctx, user); err != nil {
+			return fmt.Errorf("%s.%s: %w", TableUsers, name, err)
+		}
+		// GORM writes the column default, active, in place of false
+		if set.Users[name].Inactive {
+			if err := tx.Model(user).Update("is_active", false).Error; err != nil {
+				return fmt.Errorf("%s.%s: %w", TableUsers, name, err)
+			}
+		}
+		result.Created++
+	}
+
+	organizations := repositories.NewOrganizationRepository(tx)
+	for _, name := range names(set.Organizations) {
+		organization := set.Organizations[name]
+		model := organization.model(name)
+		if found, err := exists(model, model.ID); err != nil {
+			return err
+		} else if found {
+			continue
+		}
+		if err := organizations.CreateOrganization(ctx, model); err != nil {
+			return fmt.Errorf("%s.%s: %w", TableOrganizations, name, err)
+		}
+		for _, member := range names(organization.Members) {
+			err := organizations.SaveMember(ctx, &models.OrganizationMember{
+				OrganizationID: model.ID,
+				UserID:         ID(TableUsers, member),
+				Role:           organization.Members[member],
+			})
+			if err != nil {
+				return fmt.Errorf("%s.%s: member %s: %w", TableOrganizations, name, member, err)
+			}
+		}
+		result.Created++
+	}
+
+	posts := repositories.NewPostRepository(tx)
+	for _, name := range names(set.Posts) {
+		post := set.Posts[name].model(name)
+		if found, err := exists(post, post.ID); err != nil {
+			return err
+		} else if found {
+			continue
+		}
+		if post.Status == "published" && post.PublishedAt == nil {
+			now := l.clock.Now()
+			post.PublishedAt = &now
+		}
+		if err := posts.CreatePost(ctx, post); err != nil {
+			return fmt.Errorf("%s.%s: %w", TablePosts, name, err)
+		}
+		result.Created++
+	}
+
+	comments := repositories.NewCommentRepository(tx)
+	for _, name := range names(set.Comments) {
+		comment := set.Comments[name].model(name)
+		if found, err := exists(comment, comment.ID); err != nil {
+			return err
+		} else if found {
+			continue
+		}
+		if err := comments.CreateComment(ctx, comment); err != nil {
+			return fmt.Errorf("%s.%s: %w", TableComments, name, err)
+		}
+		result.Created++
+	}
+	return nil
+}
+
+// LoadFiles reads, validates and loads fixture files, e.g. in a test's
+// setup, and returns the set so the test can refer to its records
+func LoadFiles(ctx context.Context, db *gorm.DB, logger logger.Logger, paths ...string) (*Set, error) {
+	set, err := ReadFiles(paths...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := NewLoader(db, logger).Load(ctx, set); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// Seed returns a seed loading set, for the seed command. Like other seeds
+// it runs again only when the set changes, and then only adds the records
+// that are missing.
+func Seed(name string, set *Set, logger logger.Logger, environments ...string) seed.Seed {
+	return seed.Seed{
+		Name:         "fixtures:" + name,
+		Environments: environments,
+		Data:         set,
+		Insert: func(ctx context.Context, tx *gorm.DB) error {
+			_, err := NewLoader(tx, logger).Load(ctx, set)
+			return err
+		},
+	}
+}
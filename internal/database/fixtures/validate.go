@@ -0,0 +1,156 @@
+package fixtures
+
+import (
+	"errors"
+	"fmt"
+
+	"go-server/internal/database/models"
+	"go-server/internal/rbac"
+	"go-server/internal/security"
+)
+
+// Validate checks every record as a request creating it would be checked,
+// and that the records it refers to exist. It reports every problem at
+// once, each prefixed with the record, e.g. posts.welcome.
+func (s *Set) Validate() error {
+	var problems []error
+	report := func(table, name, format string, args ...interface{}) {
+		problems = append(problems, fmt.Errorf("%s.%s: %s", table, name, fmt.Sprintf(format, args...)))
+	}
+	check := func(table, name string, model interface{}) {
+		for _, failure := range security.ValidateStruct(model) {
+			report(table, name, "%s", failure.Message)
+		}
+	}
+	refer := func(table, name, field string, ok bool, target string) {
+		if !ok {
+			report(table, name, "%s refers to %q, which is not defined", field, target)
+		}
+	}
+
+	ids := make(map[string]map[uint]string)
+	unique := func(table, name string) {
+		if ids[table] == nil {
+			ids[table] = make(map[uint]string)
+		}
+		id := ID(table, name)
+		if other, ok := ids[table][id]; ok {
+			report(table, name, "has the same ID as %s.%s; rename one of them", table, other)
+		}
+		ids[table][id] = name
+	}
+
+	for _, name := range names(s.Users) {
+		unique(TableUsers, name)
+		user := s.Users[name].model(name)
+		check(TableUsers, name, user)
+		if !user.IsValid() {
+			report(TableUsers, name, "email, username and password are required")
+		}
+		if !rbac.ValidRole(user.Role) {
+			report(TableUsers, name, "unknown role %q", user.Role)
+		}
+	}
+	for _, name := range names(s.Organizations) {
+		unique(TableOrganizations, name)
+		organization := s.Organizations[name]
+		check(TableOrganizations, name, organization.model(name))
+		if organization.Slug == "" {
+			report(TableOrganizations, name, "Slug is required")
+		}
+		_, ok := s.Users[organization.Owner]
+		refer(TableOrganizations, name, "owner", ok, organization.Owner)
+		for _, member := range names(organization.Members) {
+			_, ok := s.Users[member]
+			refer(TableOrganizations, name, "members", ok, member)
+			if member == organization.Owner {
+				report(TableOrganizations, name, "the owner %s is a member already", member)
+			}
+			switch role := organization.Members[member]; role {
+			case models.OrgRoleAdmin, models.OrgRoleMember:
+			default:
+				report(TableOrganizations, name, "member %s has role %q, not admin or member", member, role)
+			}
+		}
+	}
+	for _, name := range names(s.Posts) {
+		unique(TablePosts, name)
+		post := s.Posts[name]
+		author, ok := s.Users[post.Author]
+		refer(TablePosts, name, "author", ok, post.Author)
+		model := post.model(name)
+		if ok {
+			// The author is validated as a user of its own
+			model.Author = *author.model(post.Author)
+		}
+		check(TablePosts, name, model)
+	}
+	for _, name := range names(s.Comments) {
+		unique(TableComments, name)
+		comment := s.Comments[name]
+		_, ok := s.Posts[comment.Post]
+		refer(TableComments, name, "post", ok, comment.Post)
+		_, ok = s.Users[comment.Author]
+		refer(TableComments, name, "author", ok, comment.Author)
+		if comment.Body == "" {
+			report(TableComments, name, "Body is required")
+		}
+	}
+	return errors.Join(problems...)
+}
+
+// model returns the user the record creates, with its password unhashed
+func (u User) model(name string) *models.User {
+	return &models.User{
+		BaseModel: models.BaseModel{ID: ID(TableUsers, name)},
+		PublicID:  PublicID(TableUsers, name),
+		Email:     u.Email,
+		Username:  u.Username,
+		Password:  u.Password,
+		FirstName: u.FirstName,
+		LastName:  u.LastName,
+		IsActive:  !u.Inactive,
+		IsAdmin:   u.Admin,
+		Role:      u.Role,
+	}
+}
+
+// model returns the organization the record creates
+func (o Organization) model(name string) *models.Organization {
+	return &models.Organization{
+		BaseModel: models.BaseModel{ID: ID(TableOrganizations, name)},
+		Name:      o.Name,
+		Slug:      o.Slug,
+		OwnerID:   ID(TableUsers, o.Owner),
+	}
+}
+
+// model returns the post the record creates, drafts by default
+func (p Post) model(name string) *models.Post {
+	status := p.Status
+	if status == "" {
+		status = "draft"
+	}
+	return &models.Post{
+		BaseModel:   models.BaseModel{ID: ID(TablePosts, name)},
+		PublicID:    PublicID(TablePosts, name),
+		Title:       p.Title,
+		Slug:        p.Slug,
+		Content:     p.Content,
+		Excerpt:     p.Excerpt,
+		Status:      status,
+		AuthorID:    ID(TableUsers, p.Author),
+		PublishedAt: p.PublishedAt,
+	}
+}
+
+// model returns the comment the record creates
+func (c Comment) model(name string) *models.Comment {
+	return &models.Comment{
+		BaseModel: models.BaseModel{ID: ID(TableComments, name)},
+		PostID:    ID(TablePosts, c.Post),
+		AuthorID:  ID(TableUsers, c.Author),
+		Body:      c.Body,
+		Source:    models.CommentSourceWeb,
+	}
+}
@@ -1,10 +1,15 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"strings"
+	"time"
 
+	"go-server/internal/bootreport"
 	"go-server/internal/database/models"
+	"go-server/internal/database/schema"
 
 	"gorm.io/gorm"
 )
@@ -13,6 +18,8 @@ import (
 type MigrationManager struct {
 	db     *gorm.DB
 	config *DatabaseConfig
+	locker schema.Locker
+	boot   *bootreport.Recorder
 }
 
 // NewMigrationManager creates a new migration manager
@@ -22,32 +29,117 @@ func NewMigrationManager(config *DatabaseConfig) *MigrationManager {
 	}
 }
 
+// Models returns every model the server reads and writes, in the order
+// their tables are created
+func Models() []interface{} {
+	return []interface{}{
+		&models.User{},
+		&models.Post{},
+		&models.Session{},
+		&models.OutboxEvent{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.Attachment{},
+		&models.EmailDelivery{},
+		&models.DeviceToken{},
+		&models.NotificationDelivery{},
+		&models.NotificationPreference{},
+		&models.NotificationDigestItem{},
+		&models.Organization{},
+		&models.OrganizationMember{},
+		&models.ServiceAccount{},
+		&models.ServiceAccountCredential{},
+		&models.ApprovalRequest{},
+		&models.ApprovalEvent{},
+		&models.BreakGlassGrant{},
+		&models.BreakGlassAccess{},
+		&models.RunbookRun{},
+		&models.ExternalIdentity{},
+		&models.Comment{},
+		&models.MaintenanceWindow{},
+		&models.Announcement{},
+		&models.FeatureUsage{},
+		&models.AbuseBan{},
+		&models.UserAlias{},
+		&models.LegalHold{},
+		&models.LegalHoldEvent{},
+		&models.SeedRun{},
+		&models.Report{},
+	}
+}
+
+// WithLocker makes Up hold the migration lock, so servers starting
+// together migrate one at a time
+func (mm *MigrationManager) WithLocker(locker schema.Locker) *MigrationManager {
+	mm.locker = locker
+	return mm
+}
+
+// WithBootReport records the migrations in boot instead of logging them
+func (mm *MigrationManager) WithBootReport(boot *bootreport.Recorder) *MigrationManager {
+	mm.boot = boot
+	return mm
+}
+
 // SetupMigration initializes the migration system
 func (mm *MigrationManager) SetupMigration(db *gorm.DB) error {
 	mm.db = db
 	return nil
 }
 
-// Up runs all pending migrations using GORM AutoMigrate
+// Up runs all pending migrations using GORM AutoMigrate, unless
+// DB_AUTO_MIGRATE turned it off in favour of cmd/migrate
 func (mm *MigrationManager) Up() error {
 	if mm.db == nil {
 		return fmt.Errorf("migration not initialized, call SetupMigration first")
 	}
+	if mm.config != nil && !mm.config.AutoMigrate {
+		if mm.boot == nil {
+			log.Println("⏭️ Skipping AutoMigrate (DB_AUTO_MIGRATE=false); run cmd/migrate up")
+		}
+		mm.boot.Migrated(bootreport.Migrations{Mode: "external"})
+		return nil
+	}
 
-	log.Println("🔄 Running database migrations...")
+	ran := false
+	err := schema.Locked(context.Background(), mm.locker, func(ctx context.Context) error {
+		ran = true
+		return mm.autoMigrate(mm.locker != nil)
+	})
+	if err != nil && !ran {
+		// Redis is unavailable: migrate unlocked, as servers did before
+		// the lock, rather than not start
+		log.Printf("⚠️ Running migrations without the migration lock: %v", err)
+		return mm.autoMigrate(false)
+	}
+	return err
+}
+
+// autoMigrate creates and alters the tables of every model; locked says
+// whether the migration lock is held
+func (mm *MigrationManager) autoMigrate(locked bool) error {
+	if mm.boot == nil {
+		log.Println("🔄 Running database migrations...")
+	}
+	started := time.Now()
 
 	// Auto-migrate all models
-	err := mm.db.AutoMigrate(
-		&models.User{},
-		&models.Post{},
-		&models.Session{},
-	)
+	tables := Models()
+	err := mm.db.AutoMigrate(tables...)
 
 	if err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
-	log.Println("✅ Database migrations completed successfully")
+	if mm.boot == nil {
+		log.Println("✅ Database migrations completed successfully")
+	}
+	mm.boot.Migrated(bootreport.Migrations{
+		Mode:     "automigrate",
+		Tables:   len(tables),
+		Locked:   locked,
+		Duration: time.Since(started),
+	})
 	return nil
 }
 
@@ -60,11 +152,12 @@ func (mm *MigrationManager) Down() error {
 	log.Println("⚠️  Dropping all tables...")
 
 	// Drop tables in reverse order to handle foreign key constraints
-	err := mm.db.Migrator().DropTable(
-		&models.Session{},
-		&models.Post{},
-		&models.User{},
-	)
+	all := Models()
+	reversed := make([]interface{}, 0, len(all))
+	for i := len(all) - 1; i >= 0; i-- {
+		reversed = append(reversed, all[i])
+	}
+	err := mm.db.Migrator().DropTable(reversed...)
 
 	if err != nil {
 		return fmt.Errorf("failed to drop tables: %w", err)
@@ -87,7 +180,7 @@ func (mm *MigrationManager) Force() error {
 		return err
 	}
 
-	return mm.Up()
+	return mm.autoMigrate(false)
 }
 
 // Version returns migration status (simplified for GORM)
@@ -96,13 +189,20 @@ func (mm *MigrationManager) Version() (string, error) {
 		return "", fmt.Errorf("migration not initialized, call SetupMigration first")
 	}
 
-	// Check if tables exist
-	var count int64
-	err := mm.db.Raw("SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = 'public'").Scan(&count).Error
+	// Check if tables exist, in whichever schema the dialect uses
+	tables, err := mm.db.Migrator().GetTables()
 	if err != nil {
 		return "", fmt.Errorf("failed to check tables: %w", err)
 	}
 
+	count := 0
+	for _, table := range tables {
+		// SQLite keeps its own bookkeeping tables next to ours
+		if !strings.HasPrefix(table, "sqlite_") {
+			count++
+		}
+	}
+
 	return fmt.Sprintf("Tables: %d", count), nil
 }
 
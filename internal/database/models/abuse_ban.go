@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// AbuseBan is the audit record of a client IP temporarily banned by the
+// abuse detector
+type AbuseBan struct {
+	ID uint   `json:"id" gorm:"primaryKey"`
+	IP string `json:"ip" gorm:"size:64;not null;index"`
+	// Reason names the heuristic that triggered, e.g. request_rate
+	Reason    string    `json:"reason" gorm:"size:50;not null;index"`
+	Detail    string    `json:"detail" gorm:"size:500"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+	// LiftedAt is set when an administrator ends the ban early
+	LiftedAt   *time.Time `json:"lifted_at,omitempty"`
+	LiftedByID *uint      `json:"lifted_by_id,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// IsActive reports whether the ban still applies at t
+func (b *AbuseBan) IsActive(t time.Time) bool {
+	return b.LiftedAt == nil && t.Before(b.ExpiresAt)
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Announcement kinds
+const (
+	AnnouncementStatus      = "status"
+	AnnouncementDeprecation = "deprecation"
+	AnnouncementNotice      = "notice"
+)
+
+// Announcement is a notice to API clients about the service's status or a
+// change to the API. Deprecations name the endpoints they affect, and
+// responses from those endpoints link to the announcement.
+type Announcement struct {
+	BaseModel
+	Kind  string `json:"kind" gorm:"size:20;not null;index"`
+	Title string `json:"title" gorm:"size:200;not null"`
+	Body  string `json:"body" gorm:"type:text"`
+	// PathPrefix is the endpoint path a deprecation affects, e.g. /api/v1/posts
+	PathPrefix string `json:"path_prefix,omitempty" gorm:"size:255"`
+	// SunsetAt is when a deprecated endpoint stops working
+	SunsetAt    *time.Time `json:"sunset_at,omitempty"`
+	PublishedAt time.Time  `json:"published_at" gorm:"not null;index"`
+	// ExpiresAt hides the announcement once it no longer applies
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" gorm:"index"`
+	CreatedByID uint       `json:"-"`
+}
+
+// TableName returns the table name for Announcement
+func (Announcement) TableName() string {
+	return "announcements"
+}
+
+// IsActive checks if the announcement is published and not expired at t
+func (a *Announcement) IsActive(t time.Time) bool {
+	return !a.PublishedAt.After(t) && (a.ExpiresAt == nil || a.ExpiresAt.After(t))
+}
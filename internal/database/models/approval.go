@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+// Approval request statuses
+const (
+	ApprovalStatusPending  = "pending"
+	ApprovalStatusApproved = "approved" // approved and running
+	ApprovalStatusExecuted = "executed"
+	ApprovalStatusFailed   = "failed"
+	ApprovalStatusRejected = "rejected"
+	ApprovalStatusExpired  = "expired"
+)
+
+// Approval audit events
+const (
+	ApprovalEventRequested = "requested"
+	ApprovalEventApproved  = "approved"
+	ApprovalEventRejected  = "rejected"
+	ApprovalEventExpired   = "expired"
+	ApprovalEventExecuted  = "executed"
+	ApprovalEventFailed    = "failed"
+)
+
+// ApprovalRequest is a destructive admin action waiting for, or decided by,
+// a second administrator
+type ApprovalRequest struct {
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	Action string `json:"action" gorm:"size:64;not null;index"`
+	// Params and Result are JSON documents
+	Params        string     `json:"-" gorm:"type:text;not null"`
+	Result        string     `json:"-" gorm:"type:text"`
+	Summary       string     `json:"summary" gorm:"size:255"`
+	Reason        string     `json:"reason,omitempty" gorm:"size:500"`
+	Status        string     `json:"status" gorm:"size:16;not null;index"`
+	RequestedByID uint       `json:"requested_by_id" gorm:"not null;index"`
+	DecidedByID   *uint      `json:"decided_by_id,omitempty"`
+	Error         string     `json:"error,omitempty" gorm:"size:500"`
+	ExpiresAt     time.Time  `json:"expires_at" gorm:"not null"`
+	DecidedAt     *time.Time `json:"decided_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for ApprovalRequest
+func (ApprovalRequest) TableName() string {
+	return "approval_requests"
+}
+
+// IsPending reports whether the request is still waiting for a decision
+func (ar *ApprovalRequest) IsPending() bool {
+	return ar.Status == ApprovalStatusPending
+}
+
+// ApprovalEvent is one audit record in an approval request's history
+type ApprovalEvent struct {
+	ID                uint      `json:"id" gorm:"primaryKey"`
+	ApprovalRequestID uint      `json:"approval_request_id" gorm:"not null;index"`
+	Event             string    `json:"event" gorm:"size:16;not null"`
+	ActorID           *uint     `json:"actor_id,omitempty"`
+	Detail            string    `json:"detail,omitempty" gorm:"size:500"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for ApprovalEvent
+func (ApprovalEvent) TableName() string {
+	return "approval_events"
+}
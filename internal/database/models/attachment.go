@@ -0,0 +1,17 @@
+package models
+
+// Attachment records a stored file and the object holding its contents
+type Attachment struct {
+	BaseModel
+	UserID      uint   `json:"user_id" gorm:"not null;index"`
+	Bucket      string `json:"bucket" gorm:"size:63;not null;uniqueIndex:idx_attachments_bucket_key"`
+	ObjectKey   string `json:"object_key" gorm:"size:1024;not null;uniqueIndex:idx_attachments_bucket_key"`
+	Filename    string `json:"filename" gorm:"size:255;not null"`
+	ContentType string `json:"content_type" gorm:"size:255"`
+	Size        int64  `json:"size"`
+}
+
+// TableName returns the table name for Attachment
+func (Attachment) TableName() string {
+	return "attachments"
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// BreakGlassGrant records an emergency access token the first time it is
+// presented. Tokens are signed offline, so this is the server's only record
+// of who was granted access, why, and until when.
+type BreakGlassGrant struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	TokenID     string     `json:"token_id" gorm:"size:64;not null;uniqueIndex"`
+	Operator    string     `json:"operator" gorm:"size:100;not null"`
+	Reason      string     `json:"reason" gorm:"size:500;not null"`
+	IssuedAt    time.Time  `json:"issued_at" gorm:"not null"`
+	ExpiresAt   time.Time  `json:"expires_at" gorm:"not null"`
+	UseCount    int64      `json:"use_count" gorm:"not null;default:0"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	RevokedByID *uint      `json:"revoked_by_id,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// TableName returns the table name for BreakGlassGrant
+func (BreakGlassGrant) TableName() string {
+	return "break_glass_grants"
+}
+
+// IsRevoked reports whether an administrator has revoked the grant
+func (bg *BreakGlassGrant) IsRevoked() bool {
+	return bg.RevokedAt != nil
+}
+
+// BreakGlassAccess is one request made with a break-glass token
+type BreakGlassAccess struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	GrantID   uint      `json:"grant_id" gorm:"not null;index"`
+	Method    string    `json:"method" gorm:"size:10;not null"`
+	Path      string    `json:"path" gorm:"size:500;not null"`
+	IPAddress string    `json:"ip_address" gorm:"size:45"`
+	UserAgent string    `json:"user_agent" gorm:"size:255"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for BreakGlassAccess
+func (BreakGlassAccess) TableName() string {
+	return "break_glass_accesses"
+}
@@ -0,0 +1,25 @@
+package models
+
+// Comment sources
+const (
+	CommentSourceWeb   = "web"
+	CommentSourceEmail = "email"
+)
+
+// Comment is a reply to a post, written on the site or sent by email
+type Comment struct {
+	BaseModel
+	PostID   uint   `json:"post_id" gorm:"not null;index"`
+	AuthorID uint   `json:"author_id" gorm:"not null;index"`
+	Author   User   `json:"author,omitempty" gorm:"foreignKey:AuthorID"`
+	Body     string `json:"body" gorm:"type:text;not null"`
+	Source   string `json:"source" gorm:"size:16;not null;default:'web'"`
+	// MessageID is the Message-ID of the email a comment came from, so a
+	// provider redelivering the email does not post it twice
+	MessageID string `json:"-" gorm:"size:255;index"`
+}
+
+// TableName returns the table name for Comment
+func (Comment) TableName() string {
+	return "comments"
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Device platforms
+const (
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+	PlatformWeb     = "web"
+)
+
+// DeviceToken is a push notification token registered by a user's device.
+// iOS tokens are delivered through APNs, Android and web tokens through FCM.
+type DeviceToken struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	UserID     uint      `json:"-" gorm:"not null;index"`
+	Platform   string    `json:"platform" gorm:"size:16;not null"`
+	Token      string    `json:"token" gorm:"size:512;not null;uniqueIndex"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for DeviceToken
+func (DeviceToken) TableName() string {
+	return "device_tokens"
+}
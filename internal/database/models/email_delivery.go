@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Email delivery statuses
+const (
+	EmailStatusQueued     = "queued"
+	EmailStatusSent       = "sent"
+	EmailStatusFailed     = "failed"
+	EmailStatusBounced    = "bounced"
+	EmailStatusComplained = "complained"
+)
+
+// EmailDelivery records a transactional email and what happened to it
+type EmailDelivery struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	UserID    *uint  `json:"user_id,omitempty" gorm:"index"`
+	Recipient string `json:"recipient" gorm:"size:254;not null;index"`
+	Template  string `json:"template" gorm:"size:100;not null"`
+	Locale    string `json:"locale" gorm:"size:16"`
+	Subject   string `json:"subject" gorm:"size:255"`
+	// Reference deduplicates sends triggered by the same event, e.g. "outbox:42"
+	Reference string     `json:"reference,omitempty" gorm:"size:100;index"`
+	Status    string     `json:"status" gorm:"size:20;not null;index"`
+	MessageID string     `json:"message_id" gorm:"size:255;index"`
+	Error     string     `json:"error,omitempty" gorm:"type:text"`
+	SentAt    *time.Time `json:"sent_at,omitempty"`
+	BouncedAt *time.Time `json:"bounced_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for EmailDelivery
+func (EmailDelivery) TableName() string {
+	return "email_deliveries"
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// ExternalIdentity links a subject at an external identity provider to a
+// local user, so tokens the provider issues authenticate that user
+type ExternalIdentity struct {
+	ID     uint `json:"id" gorm:"primaryKey"`
+	UserID uint `json:"user_id" gorm:"not null;index"`
+	// Issuer and Subject are the token's iss and sub claims, which together
+	// identify the account at the provider
+	Issuer     string    `json:"issuer" gorm:"size:255;not null;uniqueIndex:idx_external_identities_subject"`
+	Subject    string    `json:"subject" gorm:"size:255;not null;uniqueIndex:idx_external_identities_subject"`
+	Email      string    `json:"email,omitempty" gorm:"size:254"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for ExternalIdentity
+func (ExternalIdentity) TableName() string {
+	return "external_identities"
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// FeatureUsage counts one account's requests to one API feature on one day
+type FeatureUsage struct {
+	ID uint `json:"-" gorm:"primaryKey"`
+	// Day is the UTC date, at midnight
+	Day time.Time `json:"day" gorm:"not null;uniqueIndex:idx_feature_usage_daily_key,priority:1"`
+	// UserID is the calling account, zero for anonymous requests
+	UserID  uint   `json:"user_id" gorm:"not null;uniqueIndex:idx_feature_usage_daily_key,priority:2"`
+	Feature string `json:"feature" gorm:"size:200;not null;uniqueIndex:idx_feature_usage_daily_key,priority:3;index"`
+	// Requests estimates the requests made, scaled up from the samples
+	Requests  int64     `json:"requests" gorm:"not null;default:0"`
+	Samples   int64     `json:"samples" gorm:"not null;default:0"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for FeatureUsage
+func (FeatureUsage) TableName() string {
+	return "feature_usage_daily"
+}
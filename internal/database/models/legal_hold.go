@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// Legal hold subject types
+const (
+	LegalHoldSubjectUser = "user"
+	LegalHoldSubjectPost = "post"
+)
+
+// Legal hold audit record types, besides the domain events of held subjects
+const (
+	LegalHoldEventPlaced   = "legal_hold.placed"
+	LegalHoldEventReleased = "legal_hold.released"
+)
+
+// LegalHold preserves an account or post for litigation or an
+// investigation. Deleting a held record only soft-deletes it, retention
+// jobs leave it in place, and its events are copied to the append-only
+// legal hold audit log. A hold on an account also covers its posts.
+type LegalHold struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	SubjectType string `json:"subject_type" gorm:"size:16;not null;index:idx_legal_holds_subject"`
+	SubjectID   uint   `json:"subject_id" gorm:"not null;index:idx_legal_holds_subject"`
+	Reason      string `json:"reason" gorm:"size:500;not null"`
+	// CaseReference identifies the matter the hold is for, e.g. a docket number
+	CaseReference string     `json:"case_reference,omitempty" gorm:"size:100"`
+	PlacedByID    uint       `json:"placed_by_id" gorm:"not null"`
+	ReleasedAt    *time.Time `json:"released_at,omitempty"`
+	ReleasedByID  *uint      `json:"released_by_id,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// TableName returns the table name for LegalHold
+func (LegalHold) TableName() string {
+	return "legal_holds"
+}
+
+// IsActive reports whether the hold has not been released
+func (h *LegalHold) IsActive() bool {
+	return h.ReleasedAt == nil
+}
+
+// LegalHoldEvent is a record in the append-only legal hold audit log: a
+// hold being placed or released, or a domain event about a held subject.
+// Records are never updated or deleted.
+type LegalHoldEvent struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	SubjectType string `json:"subject_type" gorm:"size:16;not null;index:idx_legal_hold_events_subject;uniqueIndex:idx_legal_hold_events_outbox,priority:2"`
+	SubjectID   uint   `json:"subject_id" gorm:"not null;index:idx_legal_hold_events_subject;uniqueIndex:idx_legal_hold_events_outbox,priority:3"`
+	EventType   string `json:"event_type" gorm:"size:100;not null"`
+	// OutboxEventID is the domain event copied, which makes a redelivered
+	// event a no-op; nil for hold records
+	OutboxEventID *uint     `json:"outbox_event_id,omitempty" gorm:"uniqueIndex:idx_legal_hold_events_outbox,priority:1"`
+	HoldID        *uint     `json:"hold_id,omitempty" gorm:"index"`
+	ActorID       *uint     `json:"actor_id,omitempty"`
+	Payload       string    `json:"payload" gorm:"type:text;not null"`
+	OccurredAt    time.Time `json:"occurred_at" gorm:"not null"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for LegalHoldEvent
+func (LegalHoldEvent) TableName() string {
+	return "legal_hold_events"
+}
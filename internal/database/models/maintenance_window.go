@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// MaintenanceWindow is a planned period of downtime or degraded service
+type MaintenanceWindow struct {
+	BaseModel
+	Title       string    `json:"title" gorm:"size:200;not null"`
+	Description string    `json:"description" gorm:"type:text"`
+	StartsAt    time.Time `json:"starts_at" gorm:"not null;index"`
+	EndsAt      time.Time `json:"ends_at" gorm:"not null;index"`
+	CreatedByID uint      `json:"created_by_id"`
+}
+
+// TableName returns the table name for MaintenanceWindow
+func (MaintenanceWindow) TableName() string {
+	return "maintenance_windows"
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Notification delivery statuses
+const (
+	NotificationStatusQueued    = "queued"
+	NotificationStatusSent      = "sent"
+	NotificationStatusDelivered = "delivered"
+	NotificationStatusFailed    = "failed"
+)
+
+// NotificationDelivery records a notification sent on one channel. A
+// notification that falls back to another channel gets a delivery per channel.
+type NotificationDelivery struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	UserID    uint   `json:"user_id" gorm:"not null;index"`
+	Channel   string `json:"channel" gorm:"size:16;not null"`
+	Type      string `json:"type" gorm:"size:100;not null"`
+	Reference string `json:"reference,omitempty" gorm:"size:100;index"`
+	// Provider and ProviderMessageID identify the send in status callbacks
+	Provider          string `json:"provider,omitempty" gorm:"size:32"`
+	ProviderMessageID string `json:"provider_message_id,omitempty" gorm:"size:255;index"`
+	Status            string `json:"status" gorm:"size:20;not null;index"`
+	Attempts          int    `json:"attempts"`
+	Error             string `json:"error,omitempty" gorm:"type:text"`
+	// Payload holds the notification content so retries and fallbacks can resend it
+	Payload     string     `json:"-" gorm:"type:text"`
+	SentAt      *time.Time `json:"sent_at,omitempty"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for NotificationDelivery
+func (NotificationDelivery) TableName() string {
+	return "notification_deliveries"
+}
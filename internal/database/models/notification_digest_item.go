@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// NotificationDigestItem is a digestible notification held until the user's
+// next digest
+type NotificationDigestItem struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	UserID    uint   `json:"user_id" gorm:"not null;index"`
+	Type      string `json:"type" gorm:"size:100;not null"`
+	Title     string `json:"title" gorm:"size:255"`
+	Body      string `json:"body" gorm:"type:text"`
+	Reference string `json:"reference,omitempty" gorm:"size:100"`
+	// DigestedAt is set once the item went out in a digest
+	DigestedAt *time.Time `json:"digested_at,omitempty" gorm:"index"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// TableName returns the table name for NotificationDigestItem
+func (NotificationDigestItem) TableName() string {
+	return "notification_digest_items"
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Digest frequencies
+const (
+	DigestFrequencyImmediate = "immediate"
+	DigestFrequencyHourly    = "hourly"
+	DigestFrequencyDaily     = "daily"
+	DigestFrequencyWeekly    = "weekly"
+)
+
+// NotificationPreference holds a user's digest settings. Users without a
+// row get the configured default frequency and the email channel.
+type NotificationPreference struct {
+	UserID uint `json:"-" gorm:"primaryKey;autoIncrement:false"`
+	// DigestFrequency is how often digestible notifications are summarized;
+	// immediate sends them individually as they happen
+	DigestFrequency string `json:"digest_frequency" gorm:"size:16;not null"`
+	// DigestChannel is the channel the summary is sent on
+	DigestChannel string     `json:"digest_channel" gorm:"size:16;not null"`
+	LastDigestAt  *time.Time `json:"last_digest_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for NotificationPreference
+func (NotificationPreference) TableName() string {
+	return "notification_preferences"
+}
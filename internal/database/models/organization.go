@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Organization member roles
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleAdmin  = "admin"
+	OrgRoleMember = "member"
+)
+
+// Organization groups users and owns shared resources such as service accounts
+type Organization struct {
+	BaseModel
+	Name    string `json:"name" gorm:"size:100;not null" validate:"required,max=100"`
+	Slug    string `json:"slug" gorm:"size:100;uniqueIndex;not null"`
+	OwnerID uint   `json:"owner_id" gorm:"not null;index"`
+}
+
+// TableName returns the table name for Organization
+func (Organization) TableName() string {
+	return "organizations"
+}
+
+// OrganizationMember is a user's membership of an organization
+type OrganizationMember struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	OrganizationID uint      `json:"organization_id" gorm:"not null;uniqueIndex:idx_organization_members_org_user"`
+	UserID         uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_organization_members_org_user;index"`
+	Role           string    `json:"role" gorm:"size:16;not null"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for OrganizationMember
+func (OrganizationMember) TableName() string {
+	return "organization_members"
+}
+
+// CanManage reports whether the member may administer the organization
+func (m *OrganizationMember) CanManage() bool {
+	return m.Role == OrgRoleOwner || m.Role == OrgRoleAdmin
+}
@@ -0,0 +1,113 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Domain event types recorded in the outbox
+const (
+	EventUserRegistered  = "user.registered"
+	EventUserUpdated     = "user.updated"
+	EventUserDeleted     = "user.deleted"
+	EventUserMerged      = "user.merged"
+	EventUserDeactivated = "user.deactivated"
+	EventUserReactivated = "user.reactivated"
+	EventPostPublished   = "post.published"
+	EventPostUpdated     = "post.updated"
+	EventPostDeleted     = "post.deleted"
+	EventLoginFailed     = "auth.login_failed"
+)
+
+// UserEventPayload is the payload of user.updated, user.deleted,
+// user.deactivated and user.reactivated events.
+// The previous email and username are set when an update changed them.
+type UserEventPayload struct {
+	ID               uint   `json:"id"`
+	PublicID         string `json:"public_id"`
+	Email            string `json:"email"`
+	Username         string `json:"username"`
+	PreviousEmail    string `json:"previous_email,omitempty"`
+	PreviousUsername string `json:"previous_username,omitempty"`
+}
+
+// UserMergedPayload is the payload of user.merged events. The source
+// account was deleted and its data moved to the target.
+type UserMergedPayload struct {
+	SourceID       uint   `json:"source_id"`
+	SourcePublicID string `json:"source_public_id"`
+	TargetID       uint   `json:"target_id"`
+	TargetPublicID string `json:"target_public_id"`
+}
+
+// PostEventPayload is the payload of post lifecycle events
+type PostEventPayload struct {
+	ID          uint       `json:"id"`
+	PublicID    string     `json:"public_id"`
+	Slug        string     `json:"slug"`
+	Title       string     `json:"title"`
+	AuthorID    uint       `json:"author_id"`
+	Status      string     `json:"status"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+}
+
+// Reasons a login failed
+const (
+	LoginFailedPassword    = "invalid_password"
+	LoginFailedDeactivated = "account_deactivated"
+)
+
+// LoginFailedPayload is the payload of auth.login_failed events, recorded
+// when a login to an existing account fails
+type LoginFailedPayload struct {
+	UserID    uint   `json:"user_id"`
+	PublicID  string `json:"public_id"`
+	Email     string `json:"email"`
+	Reason    string `json:"reason"`
+	IPAddress string `json:"ip_address,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+}
+
+// OutboxEvent is a domain event written in the same transaction as the change
+// that produced it, waiting to be relayed to subscribers
+type OutboxEvent struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	EventType     string     `json:"event_type" gorm:"size:100;not null;index"`
+	AggregateType string     `json:"aggregate_type" gorm:"size:50;not null"`
+	AggregateID   string     `json:"aggregate_id" gorm:"size:64;not null"`
+	Payload       string     `json:"payload" gorm:"type:text;not null"`
+	SchemaVersion int        `json:"schema_version" gorm:"not null;default:1"`
+	Attempts      int        `json:"attempts" gorm:"default:0"`
+	LastError     string     `json:"last_error,omitempty" gorm:"type:text"`
+	AvailableAt   time.Time  `json:"available_at" gorm:"not null;index"`
+	PublishedAt   *time.Time `json:"published_at,omitempty" gorm:"index"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// TableName returns the table name for OutboxEvent
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
+
+// NewOutboxEvent builds an outbox event with a JSON-encoded payload
+func NewOutboxEvent(eventType, aggregateType string, aggregateID uint, payload any) (*OutboxEvent, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s payload: %w", eventType, err)
+	}
+	now := time.Now()
+	return &OutboxEvent{
+		EventType:     eventType,
+		AggregateType: aggregateType,
+		AggregateID:   fmt.Sprintf("%d", aggregateID),
+		Payload:       string(data),
+		AvailableAt:   now,
+		CreatedAt:     now,
+	}, nil
+}
+
+// IsPublished checks if the event has been relayed
+func (e *OutboxEvent) IsPublished() bool {
+	return e.PublishedAt != nil
+}
@@ -7,6 +7,7 @@ import (
 // Post represents a blog post or article
 type Post struct {
 	BaseModel
+	PublicID    string     `json:"public_id" gorm:"uniqueIndex;size:36"`
 	Title       string     `json:"title" gorm:"not null" validate:"required,min=1,max=200"`
 	Slug        string     `json:"slug" gorm:"uniqueIndex;not null" validate:"required"`
 	Content     string     `json:"content" gorm:"type:text" validate:"required"`
@@ -15,7 +16,12 @@ type Post struct {
 	AuthorID    uint       `json:"author_id" gorm:"not null"`
 	Author      User       `json:"author" gorm:"foreignKey:AuthorID"`
 	PublishedAt *time.Time `json:"published_at,omitempty"`
+	// ScheduledAt is when the editors plan to publish a draft
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty" gorm:"index"`
 	ViewCount   int        `json:"view_count" gorm:"default:0"`
+	// Version counts the edits to the post, so an edit made from a stale
+	// copy is refused rather than overwriting newer ones
+	Version uint `json:"version" gorm:"not null;default:1"`
 }
 
 // TableName returns the table name for Post
@@ -28,6 +34,11 @@ func (p *Post) IsPublished() bool {
 	return p.Status == "published" && p.PublishedAt != nil
 }
 
+// IsScheduled checks if the post is a draft with a planned publication time
+func (p *Post) IsScheduled() bool {
+	return p.IsDraft() && p.ScheduledAt != nil
+}
+
 // IsDraft checks if the post is a draft
 func (p *Post) IsDraft() bool {
 	return p.Status == "draft"
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"sync"
+	"time"
+
+	"go-server/internal/idgen"
+
+	"gorm.io/gorm"
+)
+
+var (
+	publicIDMutex  sync.RWMutex
+	publicIDFormat = idgen.FormatUUIDv7
+)
+
+// SetPublicIDFormat sets the format used for newly generated public identifiers
+func SetPublicIDFormat(format idgen.PublicIDFormat) {
+	publicIDMutex.Lock()
+	defer publicIDMutex.Unlock()
+	publicIDFormat = format
+}
+
+// NewPublicID generates a public identifier in the configured format
+func NewPublicID() string {
+	publicIDMutex.RLock()
+	defer publicIDMutex.RUnlock()
+	return idgen.NewPublicID(publicIDFormat, time.Now())
+}
+
+// BeforeCreate assigns a public identifier to new users
+func (u *User) BeforeCreate(tx *gorm.DB) error {
+	if u.PublicID == "" {
+		u.PublicID = NewPublicID()
+	}
+	return nil
+}
+
+// BeforeCreate assigns a public identifier to new posts
+func (p *Post) BeforeCreate(tx *gorm.DB) error {
+	if p.PublicID == "" {
+		p.PublicID = NewPublicID()
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Report types
+const (
+	ReportUserGrowth      = "user_growth"
+	ReportContentStats    = "content_stats"
+	ReportSecuritySummary = "security_summary"
+)
+
+// Report formats
+const (
+	ReportFormatCSV = "csv"
+	ReportFormatPDF = "pdf"
+)
+
+// Report statuses. A failed attempt that will be retried leaves the report
+// pending with its error; expired reports have had their file deleted.
+const (
+	ReportStatusPending   = "pending"
+	ReportStatusRunning   = "running"
+	ReportStatusCompleted = "completed"
+	ReportStatusFailed    = "failed"
+	ReportStatusExpired   = "expired"
+)
+
+// Report is an administrator's request for a report and, once the job
+// rendering it has run, where the file is stored
+type Report struct {
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	Type   string `json:"type" gorm:"size:32;not null;index"`
+	Format string `json:"format" gorm:"size:8;not null"`
+	// PeriodStart and PeriodEnd bound the activity the report covers; the
+	// end is exclusive
+	PeriodStart   time.Time `json:"period_start" gorm:"not null"`
+	PeriodEnd     time.Time `json:"period_end" gorm:"not null"`
+	Status        string    `json:"status" gorm:"size:16;not null;index"`
+	RequestedByID uint      `json:"requested_by_id" gorm:"not null;index"`
+	// ObjectKey locates the file in the reports bucket
+	ObjectKey   string     `json:"-" gorm:"size:255"`
+	Size        int64      `json:"size,omitempty"`
+	Attempts    int        `json:"attempts" gorm:"not null;default:0"`
+	Error       string     `json:"error,omitempty" gorm:"size:500"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	// ExpiresAt is when the file is deleted
+	ExpiresAt *time.Time `json:"expires_at,omitempty" gorm:"index"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for Report
+func (Report) TableName() string {
+	return "reports"
+}
+
+// Filename names the report's file for downloads, e.g.
+// user_growth-2024-03-01-2024-03-31.csv
+func (r *Report) Filename() string {
+	// The period end is exclusive; name the last day covered
+	last := r.PeriodEnd.Add(-time.Nanosecond)
+	return fmt.Sprintf("%s-%s-%s.%s", r.Type, r.PeriodStart.Format("2006-01-02"), last.Format("2006-01-02"), r.Format)
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Runbook run statuses
+const (
+	RunbookStatusRunning   = "running"
+	RunbookStatusSucceeded = "succeeded"
+	RunbookStatusFailed    = "failed"
+)
+
+// RunbookRun is the audit record of one operational action run from the
+// admin runbook endpoints
+type RunbookRun struct {
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	Action string `json:"action" gorm:"size:64;not null;index;uniqueIndex:idx_runbook_runs_idempotency"`
+	// IdempotencyKey makes a retried request return this run instead of
+	// running the action again; nil when the client sent no key
+	IdempotencyKey *string `json:"idempotency_key,omitempty" gorm:"size:128;uniqueIndex:idx_runbook_runs_idempotency"`
+	// Params and Result are JSON documents
+	Params string `json:"-" gorm:"type:text;not null"`
+	Result string `json:"-" gorm:"type:text"`
+	Status string `json:"status" gorm:"size:16;not null;index"`
+	// Actor is the principal's audit label, e.g. user:42 or break_glass:3
+	Actor      string     `json:"actor" gorm:"size:64;not null;index"`
+	Error      string     `json:"error,omitempty" gorm:"size:500"`
+	StartedAt  time.Time  `json:"started_at" gorm:"not null"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// TableName returns the table name for RunbookRun
+func (RunbookRun) TableName() string {
+	return "runbook_runs"
+}
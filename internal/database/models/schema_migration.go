@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// SchemaMigration records a SQL migration applied from the migrations
+// directory
+type SchemaMigration struct {
+	Version   int       `json:"version" gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `json:"name" gorm:"size:255;not null"`
+	Phase     string    `json:"phase" gorm:"size:16;not null"`
+	AppliedAt time.Time `json:"applied_at" gorm:"not null"`
+}
+
+// TableName returns the table name for SchemaMigration
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
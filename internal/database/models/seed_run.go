@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// SeedRun records a seed applied by package seed, with the checksum of its
+// data so a changed seed runs again
+type SeedRun struct {
+	Name      string    `json:"name" gorm:"primaryKey;size:100"`
+	Checksum  string    `json:"checksum" gorm:"size:64;not null"`
+	AppliedAt time.Time `json:"applied_at" gorm:"not null"`
+}
+
+// TableName returns the table name for SeedRun
+func (SeedRun) TableName() string {
+	return "seed_runs"
+}
@@ -14,6 +14,41 @@ type Session struct {
 	IPAddress string    `json:"ip_address"`
 	UserAgent string    `json:"user_agent"`
 	IsActive  bool      `json:"is_active" gorm:"default:true"`
+	// LastActivityAt is when the session last made a request; nil on
+	// sessions that have not been used since login
+	LastActivityAt *time.Time `json:"last_activity_at,omitempty"`
+}
+
+// SessionPolicy limits how long a session lasts. ExpiresAt slides forward
+// by IdleTimeout on every request but never past MaxLifetime after login;
+// a zero limit is not enforced.
+type SessionPolicy struct {
+	IdleTimeout time.Duration
+	MaxLifetime time.Duration
+}
+
+// DefaultSessionPolicy returns the policy used when none is configured
+func DefaultSessionPolicy() SessionPolicy {
+	return SessionPolicy{IdleTimeout: 2 * time.Hour, MaxLifetime: 24 * time.Hour}
+}
+
+// ExpiresAt returns when a session created at createdAt and last active at
+// lastActivity expires
+func (p SessionPolicy) ExpiresAt(createdAt, lastActivity time.Time) time.Time {
+	var expires time.Time
+	if p.IdleTimeout > 0 {
+		expires = lastActivity.Add(p.IdleTimeout)
+	}
+	if p.MaxLifetime > 0 {
+		if absolute := createdAt.Add(p.MaxLifetime); expires.IsZero() || absolute.Before(expires) {
+			expires = absolute
+		}
+	}
+	if expires.IsZero() {
+		// Neither limit is enforced; the session lasts until logout
+		expires = createdAt.AddDate(100, 0, 0)
+	}
+	return expires
 }
 
 // TableName returns the table name for Session
@@ -23,7 +58,12 @@ func (Session) TableName() string {
 
 // IsExpired checks if the session is expired
 func (s *Session) IsExpired() bool {
-	return time.Now().After(s.ExpiresAt)
+	return s.IsExpiredAt(time.Now())
+}
+
+// IsExpiredAt checks if the session is expired at the given time
+func (s *Session) IsExpiredAt(now time.Time) bool {
+	return now.After(s.ExpiresAt)
 }
 
 // IsValid checks if the session is valid (active and not expired)
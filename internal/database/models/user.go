@@ -17,6 +17,7 @@ type BaseModel struct {
 // User represents a user in the system
 type User struct {
 	BaseModel
+	PublicID  string     `json:"public_id" gorm:"uniqueIndex;size:36"`
 	Email     string     `json:"email" gorm:"uniqueIndex;not null" validate:"required,email"`
 	Username  string     `json:"username" gorm:"uniqueIndex;not null" validate:"required,min=3,max=20"`
 	Password  string     `json:"-" gorm:"not null"` // Hidden from JSON
@@ -24,7 +25,31 @@ type User struct {
 	LastName  string     `json:"last_name" validate:"max=50"`
 	IsActive  bool       `json:"is_active" gorm:"default:true"`
 	IsAdmin   bool       `json:"is_admin" gorm:"default:false"`
+	Role      string     `json:"role,omitempty" gorm:"size:20;not null;default:''"` // Staff role, see package rbac
 	LastLogin *time.Time `json:"last_login,omitempty"`
+
+	// Version counts the updates to the user, so an update made from a
+	// stale copy is refused rather than overwriting newer changes
+	Version uint `json:"version" gorm:"not null;default:1"`
+
+	// DeactivatedAt is when IsActive was last cleared, and DeactivatedByID
+	// who cleared it: the user themselves or a member of staff
+	DeactivatedAt   *time.Time `json:"deactivated_at,omitempty"`
+	DeactivatedByID *uint      `json:"-"`
+
+	// PhoneNumber is an E.164 number used for SMS notifications
+	PhoneNumber string `json:"phone_number,omitempty" gorm:"size:20"`
+
+	// A requested email change waits in PendingEmail until it is confirmed
+	// from the new address; see package emailchange
+	PendingEmail          string     `json:"pending_email,omitempty" gorm:"size:255"`
+	PendingEmailExpiresAt *time.Time `json:"pending_email_expires_at,omitempty"`
+	EmailConfirmHash      string     `json:"-" gorm:"size:64;index"`
+	// PreviousEmail is the address replaced by the last confirmed change,
+	// which the revert link sent to it can restore until EmailRevertExpiresAt
+	PreviousEmail        string     `json:"-" gorm:"size:255"`
+	EmailRevertHash      string     `json:"-" gorm:"size:64;index"`
+	EmailRevertExpiresAt *time.Time `json:"-"`
 }
 
 // TableName returns the table name for User
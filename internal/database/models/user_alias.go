@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// UserAlias records that the account with AliasID was merged into UserID,
+// so references to the old ID, such as tokens issued before the merge,
+// resolve to the surviving account
+type UserAlias struct {
+	AliasID    uint      `json:"alias_id" gorm:"primaryKey;autoIncrement:false"`
+	UserID     uint      `json:"user_id" gorm:"not null;index"`
+	MergedByID *uint     `json:"merged_by_id,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for UserAlias
+func (UserAlias) TableName() string {
+	return "user_aliases"
+}
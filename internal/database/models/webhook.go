@@ -0,0 +1,93 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	_ "go-server/internal/kms" // registers the kms serializer
+)
+
+// WebhookAllEvents subscribes a webhook to every event type
+const WebhookAllEvents = "*"
+
+// Webhook is an integrator-registered callback URL for domain events
+type Webhook struct {
+	BaseModel
+	UserID      uint   `json:"user_id" gorm:"not null;index"`
+	URL         string `json:"url" gorm:"size:2048;not null" validate:"required,url"`
+	Secret      string `json:"-" gorm:"size:1024;not null;serializer:kms"` // Only returned when the webhook is created; encrypted at rest with a KMS
+	Events      string `json:"-" gorm:"size:1024;not null"`                // Comma-separated event types
+	Description string `json:"description" gorm:"size:255"`
+	IsActive    bool   `json:"is_active" gorm:"default:true"`
+	// OrganizationID scopes the webhook to an organization: it is managed
+	// by the organization's owners and admins and only receives events
+	// about its members. Nil for a user's own webhook.
+	OrganizationID *uint `json:"organization_id,omitempty" gorm:"index"`
+	// Filter is an expression events must match, see webhooks.ParseFilter
+	Filter string `json:"filter,omitempty" gorm:"size:1024"`
+	// Fields are the comma-separated payload fields delivered; empty
+	// delivers the whole payload
+	Fields string `json:"-" gorm:"size:1024"`
+}
+
+// TableName returns the table name for Webhook
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+// EventList returns the subscribed event types
+func (w *Webhook) EventList() []string {
+	if w.Events == "" {
+		return []string{}
+	}
+	return strings.Split(w.Events, ",")
+}
+
+// SetEvents stores the subscribed event types
+func (w *Webhook) SetEvents(events []string) {
+	w.Events = strings.Join(events, ",")
+}
+
+// FieldList returns the selected payload fields, empty for all of them
+func (w *Webhook) FieldList() []string {
+	if w.Fields == "" {
+		return []string{}
+	}
+	return strings.Split(w.Fields, ",")
+}
+
+// SetFields stores the selected payload fields
+func (w *Webhook) SetFields(fields []string) {
+	w.Fields = strings.Join(fields, ",")
+}
+
+// Subscribes checks if the webhook wants events of the given type
+func (w *Webhook) Subscribes(eventType string) bool {
+	for _, event := range w.EventList() {
+		if event == WebhookAllEvents || event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery records one attempt to deliver an event to a webhook
+type WebhookDelivery struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	WebhookID    uint      `json:"webhook_id" gorm:"not null;index"`
+	EventID      uint      `json:"event_id" gorm:"index"`
+	EventType    string    `json:"event_type" gorm:"size:100;not null"`
+	Payload      string    `json:"-" gorm:"type:text;not null"`
+	Attempt      int       `json:"attempt"`
+	StatusCode   int       `json:"status_code"`
+	ResponseBody string    `json:"response_body,omitempty" gorm:"type:text"`
+	Error        string    `json:"error,omitempty" gorm:"type:text"`
+	DurationMs   int64     `json:"duration_ms"`
+	Succeeded    bool      `json:"succeeded" gorm:"index"`
+	CreatedAt    time.Time `json:"created_at" gorm:"index"`
+}
+
+// TableName returns the table name for WebhookDelivery
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/migrator"
+	"gorm.io/gorm/schema"
+)
+
+// mysqlTableOptions are the options of the tables AutoMigrate creates:
+// utf8mb4 text compared byte for byte, so that unique emails, slugs and
+// tokens are case sensitive as they are in PostgreSQL
+const mysqlTableOptions = "ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin"
+
+// mysqlIndexedStringSize is the length of an indexed string column without
+// an explicit size, the longest a utf8mb4 column can be and still fit a
+// 767 byte index key
+const mysqlIndexedStringSize = 191
+
+// mysqlDialector is the GORM MySQL dialector adapted to the models, which
+// are written for PostgreSQL: a string without a size is text there,
+// which MySQL cannot index.
+type mysqlDialector struct {
+	mysql.Dialector
+}
+
+// openMySQL returns the dialector for a MySQL or MariaDB DSN
+func openMySQL(dsn string) gorm.Dialector {
+	return mysqlDialector{Dialector: *mysql.Open(dsn).(*mysql.Dialector)}
+}
+
+// DataTypeOf gives indexed strings without a size a varchar type. The
+// GORM dialector does so for index and unique tags, but not uniqueIndex.
+func (d mysqlDialector) DataTypeOf(field *schema.Field) string {
+	if field.DataType == schema.String && field.Size == 0 && isIndexed(field) {
+		sized := *field
+		sized.Size = mysqlIndexedStringSize
+		return d.Dialector.DataTypeOf(&sized)
+	}
+	return d.Dialector.DataTypeOf(field)
+}
+
+// Migrator returns the GORM MySQL migrator with this dialector's column
+// types, creating tables with mysqlTableOptions unless the caller set
+// others
+func (d mysqlDialector) Migrator(db *gorm.DB) gorm.Migrator {
+	if _, ok := db.Get("gorm:table_options"); !ok {
+		db = db.Set("gorm:table_options", mysqlTableOptions)
+	}
+	return mysql.Migrator{
+		Migrator: migrator.Migrator{
+			Config: migrator.Config{DB: db, Dialector: d},
+		},
+		Dialector: d.Dialector,
+	}
+}
+
+// isIndexed reports whether a field is part of an index
+func isIndexed(field *schema.Field) bool {
+	for _, tag := range []string{"INDEX", "UNIQUEINDEX", "UNIQUE"} {
+		if _, ok := field.TagSettings[tag]; ok {
+			return true
+		}
+	}
+	return field.PrimaryKey
+}
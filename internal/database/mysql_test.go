@@ -0,0 +1,87 @@
+package database
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+func TestDatabaseConfig_MySQL(t *testing.T) {
+	t.Setenv("DB_DRIVER", "mysql")
+	t.Setenv("MYSQL_HOST", "db.internal")
+	t.Setenv("MYSQL_PORT", "3307")
+	t.Setenv("MYSQL_USER", "app")
+	t.Setenv("MYSQL_PASSWORD", "p@ss/word")
+	t.Setenv("MYSQL_TLS", "Skip-Verify")
+	config := NewDatabaseConfig()
+	if err := config.Validate(); err != nil || config.UsesPostgres() || config.UsesSQLite() {
+		t.Fatalf("Expected a valid mysql driver, got %q, %v", config.Driver, err)
+	}
+	expected := "app:p@ss/word@tcp(db.internal:3307)/go_server?collation=utf8mb4_bin&parseTime=true&tls=skip-verify"
+	if dsn := config.GetMySQLDSN(); dsn != expected {
+		t.Errorf("Expected %s, got %s", expected, dsn)
+	}
+
+	config.MySQLTLS = "require"
+	if err := config.Validate(); err == nil {
+		t.Error("Expected an invalid MYSQL_TLS to be rejected")
+	}
+	config.MySQLTLS, config.MySQLDB = "false", ""
+	if err := config.Validate(); err == nil {
+		t.Error("Expected MYSQL_DB to be required")
+	}
+}
+
+func TestMySQLDialector_DataTypeOf(t *testing.T) {
+	dialector := mysqlDialector{Dialector: mysql.Dialector{Config: &mysql.Config{}}}
+	indexed := 0
+	for _, model := range Models() {
+		s, err := schema.Parse(model, &sync.Map{}, schema.NamingStrategy{})
+		if err != nil {
+			t.Fatalf("Failed to parse %T: %v", model, err)
+		}
+		for _, field := range s.Fields {
+			if field.DataType != schema.String || field.Size != 0 || field.DBName == "" {
+				continue
+			}
+			dataType := dialector.DataTypeOf(field)
+			if isIndexed(field) {
+				indexed++
+				if dataType != "varchar(191)" {
+					t.Errorf("Expected the indexed %s.%s to be varchar(191), got %s", s.Table, field.DBName, dataType)
+				}
+			} else if dataType != "longtext" && !strings.HasPrefix(dataType, "varchar") {
+				t.Errorf("Unexpected type %s of %s.%s", dataType, s.Table, field.DBName)
+			}
+		}
+	}
+	if indexed == 0 {
+		t.Error("Expected the models to have indexed strings without a size")
+	}
+}
+
+func TestMySQLDialector_TableOptions(t *testing.T) {
+	dialector := mysqlDialector{Dialector: mysql.Dialector{Config: &mysql.Config{
+		DSN:                       "app:secret@tcp(127.0.0.1:1)/go_server",
+		SkipInitializeWithVersion: true,
+	}}}
+	db, err := gorm.Open(dialector, &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		t.Cleanup(func() { sqlDB.Close() })
+	}
+
+	if options, _ := db.Migrator().(mysql.Migrator).DB.Get("gorm:table_options"); options != mysqlTableOptions {
+		t.Errorf("Expected the binary collation table options, got %v", options)
+	}
+	custom := "ENGINE=InnoDB"
+	if options, _ := db.Set("gorm:table_options", custom).Migrator().(mysql.Migrator).DB.Get("gorm:table_options"); options != custom {
+		t.Errorf("Expected the caller's table options kept, got %v", options)
+	}
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+)
+
+// PoolStats is the state of the connection pools, for the metrics
+// endpoint. Waits count the connections asked for while the pool had
+// none free, and WaitDuration the total time spent waiting for them.
+type PoolStats struct {
+	Postgres *PgxPoolStats           `json:"postgres,omitempty"`
+	Gorm     *SQLPoolStats           `json:"gorm,omitempty"`
+	Replicas map[string]SQLPoolStats `json:"replicas,omitempty"`
+	Queries  *QueryStats             `json:"queries,omitempty"`
+}
+
+// PgxPoolStats is the state of the pgxpool connection pool
+type PgxPoolStats struct {
+	MaxConns     int32  `json:"max_conns"`
+	TotalConns   int32  `json:"total_conns"`
+	InUse        int32  `json:"in_use"`
+	Idle         int32  `json:"idle"`
+	Acquires     int64  `json:"acquires"`
+	WaitCount    int64  `json:"wait_count"`
+	WaitDuration string `json:"wait_duration"`
+	// CanceledAcquires counts the waits given up, e.g. on a timeout
+	CanceledAcquires int64 `json:"canceled_acquires"`
+}
+
+// SQLPoolStats is the state of a database/sql connection pool
+type SQLPoolStats struct {
+	MaxOpen      int    `json:"max_open"`
+	Open         int    `json:"open"`
+	InUse        int    `json:"in_use"`
+	Idle         int    `json:"idle"`
+	WaitCount    int64  `json:"wait_count"`
+	WaitDuration string `json:"wait_duration"`
+	// Closed counts the connections closed for the idle and lifetime
+	// limits
+	Closed int64 `json:"closed"`
+}
+
+func sqlPoolStats(db *sql.DB) SQLPoolStats {
+	stats := db.Stats()
+	return SQLPoolStats{
+		MaxOpen:      stats.MaxOpenConnections,
+		Open:         stats.OpenConnections,
+		InUse:        stats.InUse,
+		Idle:         stats.Idle,
+		WaitCount:    stats.WaitCount,
+		WaitDuration: stats.WaitDuration.Round(time.Millisecond).String(),
+		Closed:       stats.MaxIdleClosed + stats.MaxIdleTimeClosed + stats.MaxLifetimeClosed,
+	}
+}
+
+// PoolStats returns the state of the open connection pools and the query
+// counters
+func (dm *DatabaseManager) PoolStats() PoolStats {
+	var stats PoolStats
+	if dm.PostgresPool != nil {
+		stat := dm.PostgresPool.Stat()
+		stats.Postgres = &PgxPoolStats{
+			MaxConns:         stat.MaxConns(),
+			TotalConns:       stat.TotalConns(),
+			InUse:            stat.AcquiredConns(),
+			Idle:             stat.IdleConns(),
+			Acquires:         stat.AcquireCount(),
+			WaitCount:        stat.EmptyAcquireCount(),
+			WaitDuration:     stat.EmptyAcquireWaitTime().Round(time.Millisecond).String(),
+			CanceledAcquires: stat.CanceledAcquireCount(),
+		}
+	}
+	if dm.GormDB != nil {
+		if sqlDB, err := dm.GormDB.DB(); err == nil {
+			gorm := sqlPoolStats(sqlDB)
+			stats.Gorm = &gorm
+		}
+	}
+	if dm.Replicas != nil {
+		stats.Replicas = make(map[string]SQLPoolStats)
+		for _, replica := range dm.Replicas.Replicas() {
+			stats.Replicas[replica.Name] = sqlPoolStats(replica.DB)
+		}
+	}
+	if dm.Queries != nil {
+		queries := dm.Queries.Stats()
+		stats.Queries = &queries
+	}
+	return stats
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"go-server/internal/database/models"
+)
+
+// BackfillPublicIDs assigns public identifiers to existing users and posts that predate the column
+func (mm *MigrationManager) BackfillPublicIDs(ctx context.Context, batchSize int) (int64, error) {
+	if mm.db == nil {
+		return 0, fmt.Errorf("migration not initialized, call SetupMigration first")
+	}
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	var total int64
+	for _, table := range []string{models.User{}.TableName(), models.Post{}.TableName()} {
+		count, err := mm.backfillTable(ctx, table, batchSize)
+		total += count
+		if err != nil {
+			return total, fmt.Errorf("failed to backfill %s: %w", table, err)
+		}
+		log.Printf("✅ Backfilled %d public IDs in %s", count, table)
+	}
+
+	return total, nil
+}
+
+// backfillTable assigns public identifiers to rows in a table in batches
+func (mm *MigrationManager) backfillTable(ctx context.Context, table string, batchSize int) (int64, error) {
+	var total int64
+
+	for {
+		var ids []uint
+		err := mm.db.WithContext(ctx).
+			Table(table).
+			Where("public_id IS NULL OR public_id = ''").
+			Order("id").
+			Limit(batchSize).
+			Pluck("id", &ids).Error
+		if err != nil {
+			return total, err
+		}
+		if len(ids) == 0 {
+			return total, nil
+		}
+
+		for _, id := range ids {
+			err := mm.db.WithContext(ctx).
+				Table(table).
+				Where("id = ?", id).
+				Update("public_id", models.NewPublicID()).Error
+			if err != nil {
+				return total, err
+			}
+			total++
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+)
+
+// QueryLogger is the GORM logger of the server's connections. It logs
+// queries slower than its threshold, and failed queries, and counts them
+// for the metrics endpoint. Bound parameters never reach the log: queries
+// are logged with their placeholders, as passwords, tokens and personal
+// data are bound as parameters.
+type QueryLogger struct {
+	slowThreshold time.Duration
+	level         gormlogger.LogLevel
+	// all logs every query, not only slow and failed ones
+	all bool
+
+	stats *queryCounters
+}
+
+type queryCounters struct {
+	queries atomic.Int64
+	slow    atomic.Int64
+	failed  atomic.Int64
+}
+
+// QueryStats counts the queries run through a QueryLogger
+type QueryStats struct {
+	Queries       int64  `json:"queries"`
+	Slow          int64  `json:"slow"`
+	Failed        int64  `json:"failed"`
+	SlowThreshold string `json:"slow_threshold"`
+}
+
+// NewQueryLogger creates a logger for queries slower than slowThreshold,
+// which 0 turns off, or for every query with all
+func NewQueryLogger(slowThreshold time.Duration, all bool) *QueryLogger {
+	return &QueryLogger{
+		slowThreshold: slowThreshold,
+		level:         gormlogger.Warn,
+		all:           all,
+		stats:         &queryCounters{},
+	}
+}
+
+// LogMode implements gormlogger.Interface, e.g. for db.Debug(), which
+// logs every query of a session
+func (l *QueryLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	logger := *l
+	logger.level = level
+	logger.all = l.all || level >= gormlogger.Info
+	return &logger
+}
+
+// Info implements gormlogger.Interface
+func (l *QueryLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Info {
+		log.Printf("ℹ️ "+msg, data...)
+	}
+}
+
+// Warn implements gormlogger.Interface
+func (l *QueryLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		log.Printf("⚠️ "+msg, data...)
+	}
+}
+
+// Error implements gormlogger.Interface
+func (l *QueryLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Error {
+		log.Printf("❌ "+msg, data...)
+	}
+}
+
+// Trace implements gormlogger.Interface, counting and logging a query
+// once it has run. A record that was not found is not a failure.
+func (l *QueryLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	l.stats.queries.Add(1)
+	failed := err != nil && !errors.Is(err, gorm.ErrRecordNotFound)
+	slow := l.slowThreshold > 0 && elapsed >= l.slowThreshold
+	if failed {
+		l.stats.failed.Add(1)
+	}
+	if slow {
+		l.stats.slow.Add(1)
+	}
+	if l.level <= gormlogger.Silent {
+		return
+	}
+
+	switch {
+	case failed && l.level >= gormlogger.Error:
+		sql, rows := fc()
+		log.Printf("❌ Query failed after %s at %s (%d rows): %v: %s", elapsed, utils.FileWithLineNum(), rows, err, sql)
+	case slow && l.level >= gormlogger.Warn:
+		sql, rows := fc()
+		log.Printf("🐢 Slow query took %s at %s (%d rows): %s", elapsed, utils.FileWithLineNum(), rows, sql)
+	case l.all:
+		sql, rows := fc()
+		log.Printf("🔎 Query took %s (%d rows): %s", elapsed, rows, sql)
+	}
+}
+
+// ParamsFilter implements gorm.ParamsFilter, leaving the bound parameters
+// out of logged queries
+func (l *QueryLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
+
+// Stats returns the query counters
+func (l *QueryLogger) Stats() QueryStats {
+	return QueryStats{
+		Queries:       l.stats.queries.Load(),
+		Slow:          l.stats.slow.Load(),
+		Failed:        l.stats.failed.Load(),
+		SlowThreshold: l.slowThreshold.String(),
+	}
+}
@@ -0,0 +1,123 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"go-server/internal/database/models"
+
+	"gorm.io/gorm"
+)
+
+// captureLog returns the standard logger's output for the rest of the test
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return &buf
+}
+
+func newMemoryManager(t *testing.T, threshold time.Duration, all bool) *DatabaseManager {
+	t.Helper()
+	config := NewDatabaseConfig()
+	config.Driver = DriverMemory
+	config.SlowQueryThreshold = threshold
+	config.LogQueries = all
+	manager := NewDatabaseManager(config)
+	if err := manager.ConnectGorm(); err != nil {
+		t.Fatalf("ConnectGorm failed: %v", err)
+	}
+	t.Cleanup(func() { manager.Close() })
+	if err := manager.GormDB.AutoMigrate(&models.User{}); err != nil {
+		t.Fatalf("AutoMigrate failed: %v", err)
+	}
+	return manager
+}
+
+func TestQueryLogger_RedactsParameters(t *testing.T) {
+	manager := newMemoryManager(t, time.Nanosecond, false)
+	output := captureLog(t)
+
+	user := &models.User{Email: "ada@example.com", Username: "ada", Password: "hunter2-secret-hash"}
+	if err := manager.GormDB.Create(user).Error; err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	logged := output.String()
+	if !strings.Contains(logged, "Slow query") || !strings.Contains(logged, "INSERT INTO") {
+		t.Fatalf("Expected the insert logged as slow, got %q", logged)
+	}
+	for _, value := range []string{"hunter2-secret-hash", "ada@example.com"} {
+		if strings.Contains(logged, value) {
+			t.Errorf("Expected %q redacted, got %q", value, logged)
+		}
+	}
+	if !strings.Contains(logged, "?") {
+		t.Errorf("Expected the placeholders logged, got %q", logged)
+	}
+	if stats := manager.Queries.Stats(); stats.Slow == 0 || stats.Slow != stats.Queries || stats.Failed != 0 {
+		t.Errorf("Expected every query counted as slow, got %+v", stats)
+	}
+}
+
+func TestQueryLogger_Failures(t *testing.T) {
+	manager := newMemoryManager(t, time.Hour, false)
+	output := captureLog(t)
+
+	var user models.User
+	if err := manager.GormDB.Where("email = ?", "nobody@example.com").First(&user).Error; err != gorm.ErrRecordNotFound {
+		t.Fatalf("Expected no user, got %v", err)
+	}
+	if output.Len() != 0 || manager.Queries.Stats().Failed != 0 {
+		t.Errorf("Expected a missing record not to count as a failure, got %q", output.String())
+	}
+
+	if err := manager.GormDB.Exec("SELECT * FROM missing WHERE token = ?", "tok_secret").Error; err == nil {
+		t.Fatal("Expected the query to fail")
+	}
+	if logged := output.String(); !strings.Contains(logged, "Query failed") || strings.Contains(logged, "tok_secret") {
+		t.Errorf("Expected the failure logged without its parameter, got %q", logged)
+	}
+	if stats := manager.Queries.Stats(); stats.Failed != 1 || stats.Slow != 0 {
+		t.Errorf("Expected one failed query, got %+v", stats)
+	}
+
+	// Debug sessions log every query, still redacted
+	output.Reset()
+	manager.GormDB.Debug().Where("email = ?", "ada@example.com").Find(&[]models.User{})
+	if logged := output.String(); !strings.Contains(logged, "Query took") || strings.Contains(logged, "ada@example.com") {
+		t.Errorf("Expected the debug query logged without its parameter, got %q", logged)
+	}
+}
+
+func TestDatabaseManager_PoolStats(t *testing.T) {
+	manager := newMemoryManager(t, 0, false)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	conn, err := manager.GormDB.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	held, err := conn.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer held.Close()
+
+	stats := manager.PoolStats()
+	if stats.Postgres != nil || stats.Replicas != nil {
+		t.Errorf("Expected no PostgreSQL pools with the memory driver, got %+v", stats)
+	}
+	if stats.Gorm == nil || stats.Gorm.InUse != 1 || stats.Gorm.MaxOpen != manager.Config.MaxConnections {
+		t.Errorf("Expected one connection in use, got %+v", stats.Gorm)
+	}
+	if stats.Queries == nil || stats.Queries.Queries == 0 || stats.Queries.SlowThreshold != "0s" {
+		t.Errorf("Expected the migration's queries counted, got %+v", stats.Queries)
+	}
+}
@@ -0,0 +1,215 @@
+// Package replicas routes read-only queries to Postgres read replicas. It is
+// a GORM plugin: queries made outside a transaction, without a locking
+// clause and without WithPrimary in their context go to a healthy replica,
+// chosen round-robin; everything else, and every read while no replica is
+// healthy, goes to the primary. A background check takes replicas that
+// stop answering out of rotation and puts them back once they recover.
+package replicas
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultCheckInterval is the health check interval used when none is
+// configured
+const DefaultCheckInterval = 10 * time.Second
+
+type primaryKey struct{}
+
+// WithPrimary returns a context whose queries all go to the primary, for
+// reads that must see a write just made
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// UsesPrimary checks if WithPrimary pinned ctx to the primary
+func UsesPrimary(ctx context.Context) bool {
+	pinned, _ := ctx.Value(primaryKey{}).(bool)
+	return pinned
+}
+
+// Replica is a connection pool to one read replica
+type Replica struct {
+	Name string
+	DB   *sql.DB
+}
+
+type replica struct {
+	Replica
+	healthy atomic.Bool
+}
+
+// Resolver is the GORM plugin routing reads to replicas
+type Resolver struct {
+	replicas []*replica
+	next     atomic.Uint64
+	interval time.Duration
+	ping     func(ctx context.Context, db *sql.DB) error
+
+	started atomic.Bool
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+// NewResolver creates a resolver over replicas, all assumed healthy until
+// checked. A non-positive interval means DefaultCheckInterval.
+func NewResolver(replicas []Replica, interval time.Duration) *Resolver {
+	if interval <= 0 {
+		interval = DefaultCheckInterval
+	}
+	r := &Resolver{
+		interval: interval,
+		ping: func(ctx context.Context, db *sql.DB) error {
+			return db.PingContext(ctx)
+		},
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	for _, rep := range replicas {
+		state := &replica{Replica: rep}
+		state.healthy.Store(true)
+		r.replicas = append(r.replicas, state)
+	}
+	return r
+}
+
+// Name implements gorm.Plugin
+func (r *Resolver) Name() string {
+	return "replicas"
+}
+
+// Initialize implements gorm.Plugin, registering the routing callbacks
+func (r *Resolver) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Query().Before("gorm:query").Register("replicas:query", r.route); err != nil {
+		return err
+	}
+	return db.Callback().Row().Before("gorm:row").Register("replicas:row", r.route)
+}
+
+// route points a read statement at a replica when it may run on one
+func (r *Resolver) route(db *gorm.DB) {
+	stmt := db.Statement
+	if _, inTx := stmt.ConnPool.(gorm.TxCommitter); inTx {
+		return
+	}
+	if _, locking := stmt.Clauses["FOR"]; locking {
+		return
+	}
+	if stmt.Context != nil && UsesPrimary(stmt.Context) {
+		return
+	}
+	// Raw SQL read through Row or Scan may still write, e.g. with RETURNING
+	if query := strings.TrimSpace(stmt.SQL.String()); query != "" && !strings.HasPrefix(strings.ToUpper(query), "SELECT") {
+		return
+	}
+	if pool := r.pick(); pool != nil {
+		stmt.ConnPool = pool
+	}
+}
+
+// pick returns the next healthy replica, or nil if there is none
+func (r *Resolver) pick() *sql.DB {
+	n := uint64(len(r.replicas))
+	if n == 0 {
+		return nil
+	}
+	start := r.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		if rep := r.replicas[(start+i)%n]; rep.healthy.Load() {
+			return rep.DB
+		}
+	}
+	return nil
+}
+
+// Check pings every replica once, taking failing ones out of rotation and
+// returning recovered ones to it
+func (r *Resolver) Check(ctx context.Context) {
+	for _, rep := range r.replicas {
+		checkCtx, cancel := context.WithTimeout(ctx, r.interval)
+		err := r.ping(checkCtx, rep.DB)
+		cancel()
+		healthy := err == nil
+		if rep.healthy.Swap(healthy) == healthy {
+			continue
+		}
+		if healthy {
+			log.Printf("✅ Read replica %s recovered, routing reads to it again", rep.Name)
+		} else {
+			log.Printf("⚠️ Read replica %s is unhealthy, routing its reads elsewhere: %v", rep.Name, err)
+		}
+	}
+}
+
+// Start runs the health check every interval until ctx is done or Stop is
+// called
+func (r *Resolver) Start(ctx context.Context) {
+	if r.started.Swap(true) {
+		return
+	}
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-r.stop:
+				return
+			case <-ticker.C:
+				r.Check(ctx)
+			}
+		}
+	}()
+}
+
+// Stop stops the health check started by Start and waits for it to exit
+func (r *Resolver) Stop() {
+	r.once.Do(func() { close(r.stop) })
+	if r.started.Load() {
+		<-r.done
+	}
+}
+
+// Replicas returns the replicas the resolver routes to
+func (r *Resolver) Replicas() []Replica {
+	replicas := make([]Replica, len(r.replicas))
+	for i, rep := range r.replicas {
+		replicas[i] = rep.Replica
+	}
+	return replicas
+}
+
+// Health reports the state of each replica by name
+func (r *Resolver) Health() map[string]string {
+	health := make(map[string]string, len(r.replicas))
+	for _, rep := range r.replicas {
+		if rep.healthy.Load() {
+			health[rep.Name] = "healthy"
+		} else {
+			health[rep.Name] = "unhealthy"
+		}
+	}
+	return health
+}
+
+// Close closes the replica connection pools
+func (r *Resolver) Close() error {
+	var firstErr error
+	for _, rep := range r.replicas {
+		if err := rep.DB.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,148 @@
+package replicas
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"go-server/internal/database/dbtest"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type note struct {
+	ID   uint
+	Body string
+}
+
+// openNotes opens a database holding one note whose body names it
+func openNotes(t *testing.T, name string) *gorm.DB {
+	t.Helper()
+	db := dbtest.OpenNamed(t, name, &note{})
+	if err := db.Create(&note{ID: 1, Body: name}).Error; err != nil {
+		t.Fatalf("Failed to seed: %v", err)
+	}
+	return db
+}
+
+func sqlDB(t *testing.T, db *gorm.DB) *sql.DB {
+	t.Helper()
+	pool, err := db.DB()
+	if err != nil {
+		t.Fatalf("Failed to get sql.DB: %v", err)
+	}
+	return pool
+}
+
+func newRouted(t *testing.T) (*gorm.DB, *Resolver) {
+	t.Helper()
+	primary := openNotes(t, "primary")
+	replica := openNotes(t, "replica")
+	resolver := NewResolver([]Replica{{Name: "replica", DB: sqlDB(t, replica)}}, 0)
+	if err := primary.Use(resolver); err != nil {
+		t.Fatalf("Use failed: %v", err)
+	}
+	return primary, resolver
+}
+
+func readBody(t *testing.T, db *gorm.DB) string {
+	t.Helper()
+	var n note
+	if err := db.First(&n, 1).Error; err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	return n.Body
+}
+
+func TestResolver_Routing(t *testing.T) {
+	db, _ := newRouted(t)
+	ctx := context.Background()
+
+	if got := readBody(t, db.WithContext(ctx)); got != "replica" {
+		t.Errorf("Expected reads to go to the replica, got %s", got)
+	}
+	if got := readBody(t, db.WithContext(WithPrimary(ctx))); got != "primary" {
+		t.Errorf("Expected WithPrimary reads to go to the primary, got %s", got)
+	}
+	if got := readBody(t, db.Clauses(clause.Locking{Strength: "UPDATE"})); got != "primary" {
+		t.Errorf("Expected locking reads to go to the primary, got %s", got)
+	}
+	var count int64
+	if err := db.Model(&note{}).Where("body = ?", "replica").Count(&count).Error; err != nil || count != 1 {
+		t.Errorf("Expected counts to go to the replica, got %d, %v", count, err)
+	}
+	var body string
+	if err := db.Raw("SELECT body FROM notes WHERE id = ?", 1).Row().Scan(&body); err != nil || body != "replica" {
+		t.Errorf("Expected raw selects to go to the replica, got %s, %v", body, err)
+	}
+
+	// Writes, and reads in a transaction, go to the primary
+	if err := db.Create(&note{ID: 2, Body: "written"}).Error; err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var n note
+		return tx.First(&n, 2).Error
+	})
+	if err != nil {
+		t.Errorf("Expected the transaction to read its own write, got %v", err)
+	}
+	var n note
+	if err := db.First(&n, 2).Error; !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Expected the write not to reach the replica, got %v", err)
+	}
+}
+
+func TestResolver_Failback(t *testing.T) {
+	db, resolver := newRouted(t)
+	ctx := context.Background()
+	down := errors.New("connection refused")
+	var failing bool
+	resolver.ping = func(ctx context.Context, db *sql.DB) error {
+		if failing {
+			return down
+		}
+		return nil
+	}
+
+	failing = true
+	resolver.Check(ctx)
+	if got := readBody(t, db); got != "primary" {
+		t.Errorf("Expected reads to fall back to the primary, got %s", got)
+	}
+	if health := resolver.Health(); health["replica"] != "unhealthy" {
+		t.Errorf("Expected the replica to be reported unhealthy, got %v", health)
+	}
+
+	failing = false
+	resolver.Check(ctx)
+	if got := readBody(t, db); got != "replica" {
+		t.Errorf("Expected reads to return to the recovered replica, got %s", got)
+	}
+	if health := resolver.Health(); health["replica"] != "healthy" {
+		t.Errorf("Expected the replica to be reported healthy, got %v", health)
+	}
+}
+
+func TestResolver_RoundRobin(t *testing.T) {
+	primary := openNotes(t, "primary")
+	first := openNotes(t, "first")
+	second := openNotes(t, "second")
+	resolver := NewResolver([]Replica{
+		{Name: "first", DB: sqlDB(t, first)},
+		{Name: "second", DB: sqlDB(t, second)},
+	}, 0)
+	if err := primary.Use(resolver); err != nil {
+		t.Fatalf("Use failed: %v", err)
+	}
+
+	seen := map[string]int{}
+	for i := 0; i < 4; i++ {
+		seen[readBody(t, primary)]++
+	}
+	if seen["first"] != 2 || seen["second"] != 2 {
+		t.Errorf("Expected reads to alternate between replicas, got %v", seen)
+	}
+}
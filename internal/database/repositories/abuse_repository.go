@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go-server/internal/database/models"
+	"gorm.io/gorm"
+)
+
+// AbuseRepository handles abuse ban database operations
+type AbuseRepository struct {
+	db *gorm.DB
+}
+
+// NewAbuseRepository creates a new abuse ban repository
+func NewAbuseRepository(db *gorm.DB) *AbuseRepository {
+	return &AbuseRepository{db: db}
+}
+
+// CreateBan records a ban
+func (ar *AbuseRepository) CreateBan(ctx context.Context, ban *models.AbuseBan) error {
+	return ar.db.WithContext(ctx).Create(ban).Error
+}
+
+// ListActiveBans retrieves the bans in force at now, so they survive a restart
+func (ar *AbuseRepository) ListActiveBans(ctx context.Context, now time.Time) ([]models.AbuseBan, error) {
+	var bans []models.AbuseBan
+	err := ar.db.WithContext(ctx).
+		Where("lifted_at IS NULL AND expires_at > ?", now).
+		Order("expires_at ASC").
+		Find(&bans).Error
+	return bans, err
+}
+
+// ListBans retrieves bans, newest first, optionally only those of one IP
+func (ar *AbuseRepository) ListBans(ctx context.Context, ip string, offset, limit int) ([]models.AbuseBan, error) {
+	var bans []models.AbuseBan
+	query := ar.db.WithContext(ctx)
+	if ip != "" {
+		query = query.Where("ip = ?", ip)
+	}
+	err := query.
+		Order("created_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&bans).Error
+	return bans, err
+}
+
+// LiftBans ends the IP's bans in force at now, returning
+// gorm.ErrRecordNotFound if there are none
+func (ar *AbuseRepository) LiftBans(ctx context.Context, ip string, liftedByID uint, now time.Time) error {
+	result := ar.db.WithContext(ctx).
+		Model(&models.AbuseBan{}).
+		Where("ip = ? AND lifted_at IS NULL AND expires_at > ?", ip, now).
+		Updates(map[string]interface{}{"lifted_at": now, "lifted_by_id": liftedByID})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go-server/internal/database/models"
+	"gorm.io/gorm"
+)
+
+// AnnouncementRepository handles announcement database operations
+type AnnouncementRepository struct {
+	db *gorm.DB
+}
+
+// NewAnnouncementRepository creates a new announcement repository
+func NewAnnouncementRepository(db *gorm.DB) *AnnouncementRepository {
+	return &AnnouncementRepository{db: db}
+}
+
+// CreateAnnouncement creates a new announcement
+func (ar *AnnouncementRepository) CreateAnnouncement(ctx context.Context, announcement *models.Announcement) error {
+	return ar.db.WithContext(ctx).Create(announcement).Error
+}
+
+// GetAnnouncement retrieves an announcement by ID
+func (ar *AnnouncementRepository) GetAnnouncement(ctx context.Context, id uint) (*models.Announcement, error) {
+	var announcement models.Announcement
+	if err := ar.db.WithContext(ctx).First(&announcement, id).Error; err != nil {
+		return nil, err
+	}
+	return &announcement, nil
+}
+
+// ListActiveAnnouncements retrieves announcements published and not
+// expired at now, newest first, optionally only those of kind
+func (ar *AnnouncementRepository) ListActiveAnnouncements(ctx context.Context, now time.Time, kind string, offset, limit int) ([]models.Announcement, error) {
+	var announcements []models.Announcement
+	query := ar.active(ctx, now)
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	err := query.
+		Order("published_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&announcements).Error
+	return announcements, err
+}
+
+// ListActiveDeprecations retrieves every active deprecation with an
+// affected path
+func (ar *AnnouncementRepository) ListActiveDeprecations(ctx context.Context, now time.Time) ([]models.Announcement, error) {
+	var announcements []models.Announcement
+	err := ar.active(ctx, now).
+		Where("kind = ? AND path_prefix <> ''", models.AnnouncementDeprecation).
+		Order("published_at DESC, id DESC").
+		Find(&announcements).Error
+	return announcements, err
+}
+
+// DeleteAnnouncement soft deletes an announcement, returning
+// gorm.ErrRecordNotFound if it does not exist
+func (ar *AnnouncementRepository) DeleteAnnouncement(ctx context.Context, id uint) error {
+	result := ar.db.WithContext(ctx).Delete(&models.Announcement{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// active scopes a query to announcements published and not expired at now
+func (ar *AnnouncementRepository) active(ctx context.Context, now time.Time) *gorm.DB {
+	return ar.db.WithContext(ctx).
+		Where("published_at <= ?", now).
+		Where("expires_at IS NULL OR expires_at > ?", now)
+}
@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go-server/internal/database/models"
+	"gorm.io/gorm"
+)
+
+// ApprovalRepository handles approval request and audit event database operations
+type ApprovalRepository struct {
+	db *gorm.DB
+}
+
+// NewApprovalRepository creates a new approval repository
+func NewApprovalRepository(db *gorm.DB) *ApprovalRepository {
+	return &ApprovalRepository{db: db}
+}
+
+// CreateRequest stores a new approval request together with its first audit event
+func (ar *ApprovalRepository) CreateRequest(ctx context.Context, request *models.ApprovalRequest, event *models.ApprovalEvent) error {
+	return ar.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(request).Error; err != nil {
+			return err
+		}
+		event.ApprovalRequestID = request.ID
+		return tx.Create(event).Error
+	})
+}
+
+// GetRequest retrieves an approval request by ID
+func (ar *ApprovalRepository) GetRequest(ctx context.Context, id uint) (*models.ApprovalRequest, error) {
+	var request models.ApprovalRequest
+	err := ar.db.WithContext(ctx).First(&request, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// ListRequests retrieves approval requests, newest first, optionally filtered by status
+func (ar *ApprovalRepository) ListRequests(ctx context.Context, status string, offset, limit int) ([]models.ApprovalRequest, error) {
+	var requests []models.ApprovalRequest
+	query := ar.db.WithContext(ctx)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("created_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&requests).Error
+	return requests, err
+}
+
+// Transition moves a request out of from into the status set in updates and
+// records event, all or nothing. It returns false if the request was no
+// longer in from, e.g. because another administrator decided it first.
+func (ar *ApprovalRepository) Transition(ctx context.Context, id uint, from string, updates map[string]interface{}, event *models.ApprovalEvent) (bool, error) {
+	moved := false
+	err := ar.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.ApprovalRequest{}).
+			Where("id = ? AND status = ?", id, from).
+			Updates(updates)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		moved = true
+		event.ApprovalRequestID = id
+		return tx.Create(event).Error
+	})
+	return moved, err
+}
+
+// ExpirePending marks pending requests whose TTL has passed as expired and
+// records an expiry event for each
+func (ar *ApprovalRepository) ExpirePending(ctx context.Context, now time.Time) (int64, error) {
+	var expired int64
+	err := ar.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		if err := tx.Model(&models.ApprovalRequest{}).
+			Where("status = ? AND expires_at <= ?", models.ApprovalStatusPending, now).
+			Pluck("id", &ids).Error; err != nil || len(ids) == 0 {
+			return err
+		}
+		result := tx.Model(&models.ApprovalRequest{}).
+			Where("id IN ? AND status = ?", ids, models.ApprovalStatusPending).
+			Updates(map[string]interface{}{"status": models.ApprovalStatusExpired, "decided_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		expired = result.RowsAffected
+
+		events := make([]models.ApprovalEvent, len(ids))
+		for i, id := range ids {
+			events[i] = models.ApprovalEvent{ApprovalRequestID: id, Event: models.ApprovalEventExpired, CreatedAt: now}
+		}
+		return tx.Create(&events).Error
+	})
+	return expired, err
+}
+
+// ListEvents retrieves an approval request's audit history, oldest first
+func (ar *ApprovalRepository) ListEvents(ctx context.Context, requestID uint) ([]models.ApprovalEvent, error) {
+	var events []models.ApprovalEvent
+	err := ar.db.WithContext(ctx).
+		Where("approval_request_id = ?", requestID).
+		Order("created_at, id").
+		Find(&events).Error
+	return events, err
+}
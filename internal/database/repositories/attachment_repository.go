@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"context"
+
+	"go-server/internal/database/models"
+	"gorm.io/gorm"
+)
+
+// AttachmentRepository handles attachment database operations
+type AttachmentRepository struct {
+	db *gorm.DB
+}
+
+// NewAttachmentRepository creates a new attachment repository
+func NewAttachmentRepository(db *gorm.DB) *AttachmentRepository {
+	return &AttachmentRepository{db: db}
+}
+
+// CreateAttachment creates a new attachment
+func (ar *AttachmentRepository) CreateAttachment(ctx context.Context, attachment *models.Attachment) error {
+	return ar.db.WithContext(ctx).Create(attachment).Error
+}
+
+// GetAttachmentByID retrieves an attachment by ID
+func (ar *AttachmentRepository) GetAttachmentByID(ctx context.Context, id uint) (*models.Attachment, error) {
+	var attachment models.Attachment
+	err := ar.db.WithContext(ctx).First(&attachment, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// DeleteAttachment soft deletes an attachment
+func (ar *AttachmentRepository) DeleteAttachment(ctx context.Context, id uint) error {
+	return ar.db.WithContext(ctx).Delete(&models.Attachment{}, id).Error
+}
+
+// ExistingObjectKeys returns which of the keys in a bucket are referenced by
+// an attachment. Soft-deleted attachments still count so that restoring one
+// from the trash finds its file intact.
+func (ar *AttachmentRepository) ExistingObjectKeys(ctx context.Context, bucket string, keys []string) (map[string]bool, error) {
+	existing := make(map[string]bool, len(keys))
+	if len(keys) == 0 {
+		return existing, nil
+	}
+
+	var found []string
+	err := ar.db.WithContext(ctx).Unscoped().
+		Model(&models.Attachment{}).
+		Where("bucket = ? AND object_key IN ?", bucket, keys).
+		Pluck("object_key", &found).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range found {
+		existing[key] = true
+	}
+	return existing, nil
+}
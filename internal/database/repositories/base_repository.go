@@ -0,0 +1,190 @@
+package repositories
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Scope narrows or orders a query, as gorm's Scopes take
+type Scope func(db *gorm.DB) *gorm.DB
+
+// Where returns a scope adding a condition
+func Where(query interface{}, args ...interface{}) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(query, args...)
+	}
+}
+
+// OrderBy returns a scope ordering the results
+func OrderBy(order string) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Order(order)
+	}
+}
+
+// Paginate returns a scope selecting a page of results
+func Paginate(offset, limit int) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Offset(offset).Limit(limit)
+	}
+}
+
+// BaseRepository implements the create, read, list, count and trash
+// operations every model repository needs, for models with a soft-delete
+// column. Repositories embed one per model and keep the operations that
+// record events, check versions or take other business rules themselves.
+//
+// Preloads are loaded with every record read. Scopes narrow every read and
+// count, e.g. to live sessions, but not writes or the trash, which works
+// on deleted records whatever their scope.
+type BaseRepository[T any] struct {
+	db       *gorm.DB
+	preloads []string
+	scopes   []Scope
+}
+
+// NewBaseRepository creates a new repository of T
+func NewBaseRepository[T any](db *gorm.DB) *BaseRepository[T] {
+	return &BaseRepository[T]{db: db}
+}
+
+// WithPreloads loads associations with every record read
+func (r *BaseRepository[T]) WithPreloads(associations ...string) *BaseRepository[T] {
+	r.preloads = append(r.preloads, associations...)
+	return r
+}
+
+// WithScopes narrows every read and count
+func (r *BaseRepository[T]) WithScopes(scopes ...Scope) *BaseRepository[T] {
+	r.scopes = append(r.scopes, scopes...)
+	return r
+}
+
+// WithTx returns a copy of the repository working in tx
+func (r *BaseRepository[T]) WithTx(tx *gorm.DB) *BaseRepository[T] {
+	bound := *r
+	bound.db = tx
+	return &bound
+}
+
+// DB returns the repository's database, for queries of its own
+func (r *BaseRepository[T]) DB(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx)
+}
+
+// scoped returns a query with the repository's scopes and those given
+func (r *BaseRepository[T]) scoped(ctx context.Context, scopes []Scope) *gorm.DB {
+	db := r.db.WithContext(ctx)
+	for _, scope := range r.scopes {
+		db = scope(db)
+	}
+	for _, scope := range scopes {
+		db = scope(db)
+	}
+	return db
+}
+
+// reading returns a scoped query that loads the preloads too
+func (r *BaseRepository[T]) reading(ctx context.Context, scopes []Scope) *gorm.DB {
+	db := r.scoped(ctx, scopes)
+	for _, association := range r.preloads {
+		db = db.Preload(association)
+	}
+	return db
+}
+
+// Create creates a record
+func (r *BaseRepository[T]) Create(ctx context.Context, record *T) error {
+	return r.db.WithContext(ctx).Create(record).Error
+}
+
+// Save updates every field of a record, or creates it if it has no ID
+func (r *BaseRepository[T]) Save(ctx context.Context, record *T) error {
+	return r.db.WithContext(ctx).Save(record).Error
+}
+
+// GetByID retrieves a record by ID
+func (r *BaseRepository[T]) GetByID(ctx context.Context, id uint) (*T, error) {
+	var record T
+	if err := r.reading(ctx, nil).First(&record, id).Error; err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// FindOne retrieves the first record the scopes select, failing with
+// gorm.ErrRecordNotFound if there is none
+func (r *BaseRepository[T]) FindOne(ctx context.Context, scopes ...Scope) (*T, error) {
+	var record T
+	if err := r.reading(ctx, scopes).First(&record).Error; err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Find retrieves the records the scopes select
+func (r *BaseRepository[T]) Find(ctx context.Context, scopes ...Scope) ([]T, error) {
+	var records []T
+	err := r.reading(ctx, scopes).Find(&records).Error
+	return records, err
+}
+
+// List retrieves a page of the records the scopes select
+func (r *BaseRepository[T]) List(ctx context.Context, offset, limit int, scopes ...Scope) ([]T, error) {
+	return r.Find(ctx, append(scopes, Paginate(offset, limit))...)
+}
+
+// Count returns the number of records the scopes select
+func (r *BaseRepository[T]) Count(ctx context.Context, scopes ...Scope) (int64, error) {
+	var count int64
+	err := r.scoped(ctx, scopes).Model(new(T)).Count(&count).Error
+	return count, err
+}
+
+// Delete soft deletes a record
+func (r *BaseRepository[T]) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(new(T), id).Error
+}
+
+// ListDeleted retrieves soft-deleted records with pagination, most
+// recently deleted first
+func (r *BaseRepository[T]) ListDeleted(ctx context.Context, offset, limit int) ([]T, error) {
+	var records []T
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&records).Error
+	return records, err
+}
+
+// CountDeleted returns the number of soft-deleted records
+func (r *BaseRepository[T]) CountDeleted(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Model(new(T)).
+		Where("deleted_at IS NOT NULL").
+		Count(&count).Error
+	return count, err
+}
+
+// Restore clears the soft-delete marker on a record, failing with
+// gorm.ErrRecordNotFound if it is not deleted
+func (r *BaseRepository[T]) Restore(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).
+		Unscoped().
+		Model(new(T)).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/dbtest"
+	"go-server/internal/database/models"
+
+	"gorm.io/gorm"
+)
+
+func TestBaseRepository(t *testing.T) {
+	ctx := context.Background()
+	db := dbtest.Open(t, &models.User{}, &models.Post{}, &models.Session{})
+	author := &models.User{Email: "ada@example.com", Username: "ada", Password: "hash"}
+	if err := NewBaseRepository[models.User](db).Create(ctx, author); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	posts := NewBaseRepository[models.Post](db).
+		WithPreloads("Author").
+		WithScopes(Where("status <> ?", "archived"))
+	for i, status := range []string{"draft", "published", "published", "archived"} {
+		post := &models.Post{Title: fmt.Sprintf("Post %d", i), Slug: fmt.Sprintf("post-%d", i), Content: "Body", Status: status, AuthorID: author.ID}
+		if err := posts.Create(ctx, post); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	post, err := posts.GetByID(ctx, 2)
+	if err != nil || post.Author.Username != "ada" {
+		t.Fatalf("Expected post 2 with its author, got %+v, %v", post, err)
+	}
+	if _, err := posts.GetByID(ctx, 4); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Expected the scope to hide the archived post, got %v", err)
+	}
+	if _, err := posts.FindOne(ctx, Where("slug = ?", "missing")); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Expected ErrRecordNotFound, got %v", err)
+	}
+
+	page, err := posts.List(ctx, 1, 1, Where("status = ?", "published"), OrderBy("id DESC"))
+	if err != nil || len(page) != 1 || page[0].ID != 2 || page[0].Author.ID != author.ID {
+		t.Errorf("Expected the second published post, newest first, got %+v, %v", page, err)
+	}
+	if count, err := posts.Count(ctx); err != nil || count != 3 {
+		t.Errorf("Expected 3 posts in scope, got %d, %v", count, err)
+	}
+	if count, err := posts.Count(ctx, Where("status = ?", "published")); err != nil || count != 2 {
+		t.Errorf("Expected 2 published posts, got %d, %v", count, err)
+	}
+
+	post.Title = "Renamed"
+	if err := posts.Save(ctx, post); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := posts.Delete(ctx, 1); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	deleted, err := posts.ListDeleted(ctx, 0, 10)
+	if err != nil || len(deleted) != 1 || deleted[0].ID != 1 {
+		t.Fatalf("Expected post 1 in the trash, got %+v, %v", deleted, err)
+	}
+	if count, _ := posts.CountDeleted(ctx); count != 1 {
+		t.Errorf("Expected 1 deleted post, got %d", count)
+	}
+	if err := posts.Restore(ctx, 1); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if err := posts.Restore(ctx, 1); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Expected restoring a live post to fail, got %v", err)
+	}
+	if renamed, _ := posts.GetByID(ctx, 2); renamed.Title != "Renamed" {
+		t.Errorf("Expected the saved title, got %q", renamed.Title)
+	}
+}
+
+func TestBaseRepository_WithTx(t *testing.T) {
+	ctx := context.Background()
+	db := dbtest.Open(t, &models.User{}, &models.Post{}, &models.Session{})
+	users := NewBaseRepository[models.User](db)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := users.WithTx(tx).Create(ctx, &models.User{Email: "ada@example.com", Username: "ada", Password: "hash"}); err != nil {
+			return err
+		}
+		return errors.New("roll back")
+	})
+	if err == nil {
+		t.Fatal("Expected the transaction to fail")
+	}
+	if count, _ := users.Count(ctx); count != 0 {
+		t.Errorf("Expected the user rolled back, got %d users", count)
+	}
+}
+
+func TestSessionRepository_ScopeFollowsClock(t *testing.T) {
+	ctx := context.Background()
+	db := dbtest.Open(t, &models.User{}, &models.Post{}, &models.Session{})
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(now)
+	sessions := NewSessionRepository(db).
+		WithPolicy(models.SessionPolicy{IdleTimeout: time.Hour}).
+		WithClock(fake)
+	if err := sessions.CreateSession(ctx, &models.Session{UserID: 1, Token: "token"}); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	bound := sessions.WithTx(db)
+	if count, _ := bound.CountActiveSessions(ctx, 1); count != 1 {
+		t.Fatalf("Expected a live session, got %d", count)
+	}
+
+	// Reads through a copy bound to a transaction see the same clock
+	fake.Advance(2 * time.Hour)
+	if _, err := bound.GetSessionByToken(ctx, "token"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Expected the idle session to have expired, got %v", err)
+	}
+	if count, _ := sessions.CountActiveSessions(ctx, 1); count != 0 {
+		t.Errorf("Expected no live sessions, got %d", count)
+	}
+}
@@ -0,0 +1,98 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go-server/internal/database/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BreakGlassRepository handles break-glass grant and access audit database operations
+type BreakGlassRepository struct {
+	db *gorm.DB
+}
+
+// NewBreakGlassRepository creates a new break-glass repository
+func NewBreakGlassRepository(db *gorm.DB) *BreakGlassRepository {
+	return &BreakGlassRepository{db: db}
+}
+
+// RecordAccess stores a request made with a break-glass token. The grant is
+// created on the token's first use, which is reported by the returned bool.
+// The stored grant is returned so callers can check whether it was revoked.
+func (br *BreakGlassRepository) RecordAccess(ctx context.Context, grant *models.BreakGlassGrant, access *models.BreakGlassAccess) (*models.BreakGlassGrant, bool, error) {
+	var stored models.BreakGlassGrant
+	first := false
+	err := br.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(grant)
+		if result.Error != nil {
+			return result.Error
+		}
+		first = result.RowsAffected == 1
+
+		if err := tx.Where("token_id = ?", grant.TokenID).First(&stored).Error; err != nil {
+			return err
+		}
+		if stored.IsRevoked() {
+			return nil
+		}
+
+		if err := tx.Model(&stored).Updates(map[string]interface{}{
+			"use_count":    gorm.Expr("use_count + 1"),
+			"last_used_at": access.CreatedAt,
+		}).Error; err != nil {
+			return err
+		}
+		access.GrantID = stored.ID
+		return tx.Create(access).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return &stored, first, nil
+}
+
+// GetGrant retrieves a break-glass grant by ID
+func (br *BreakGlassRepository) GetGrant(ctx context.Context, id uint) (*models.BreakGlassGrant, error) {
+	var grant models.BreakGlassGrant
+	err := br.db.WithContext(ctx).First(&grant, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &grant, nil
+}
+
+// ListGrants retrieves break-glass grants, newest first
+func (br *BreakGlassRepository) ListGrants(ctx context.Context, offset, limit int) ([]models.BreakGlassGrant, error) {
+	var grants []models.BreakGlassGrant
+	err := br.db.WithContext(ctx).
+		Order("created_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&grants).Error
+	return grants, err
+}
+
+// ListAccesses retrieves the requests made with a grant, oldest first
+func (br *BreakGlassRepository) ListAccesses(ctx context.Context, grantID uint, offset, limit int) ([]models.BreakGlassAccess, error) {
+	var accesses []models.BreakGlassAccess
+	err := br.db.WithContext(ctx).
+		Where("grant_id = ?", grantID).
+		Order("created_at, id").
+		Offset(offset).
+		Limit(limit).
+		Find(&accesses).Error
+	return accesses, err
+}
+
+// RevokeGrant revokes a grant so its token is refused even before it
+// expires. It returns false if the grant does not exist or was already revoked.
+func (br *BreakGlassRepository) RevokeGrant(ctx context.Context, id, revokedByID uint, now time.Time) (bool, error) {
+	result := br.db.WithContext(ctx).
+		Model(&models.BreakGlassGrant{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{"revoked_at": now, "revoked_by_id": revokedByID})
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,286 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go-server/internal/clock"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrCacheDegraded is returned by cache operations that cannot be skipped,
+// such as FlushAll, while Redis is unavailable
+var ErrCacheDegraded = errors.New("cache is degraded: redis is unavailable")
+
+// CacheDegradation configures how a CacheRepository behaves when Redis is
+// unavailable. Reads become misses, so callers fall back to the database,
+// and writes are skipped. Deletes are remembered and replayed when Redis
+// returns, so entries changed during the outage are not served stale.
+type CacheDegradation struct {
+	// FailureThreshold is how many consecutive Redis failures switch the
+	// repository to degraded mode
+	FailureThreshold int
+	// ProbeInterval is how often a degraded repository pings Redis to
+	// recover
+	ProbeInterval time.Duration
+	// MaxPendingDeletes bounds the deletes remembered for replay; further
+	// deletes are dropped and counted
+	MaxPendingDeletes int
+}
+
+// DefaultCacheDegradation returns the default degradation settings
+func DefaultCacheDegradation() CacheDegradation {
+	return CacheDegradation{
+		FailureThreshold:  3,
+		ProbeInterval:     5 * time.Second,
+		MaxPendingDeletes: 10000,
+	}
+}
+
+// CacheStats is a snapshot of a cache repository's degradation counters
+type CacheStats struct {
+	Degraded      bool       `json:"degraded"`
+	DegradedSince *time.Time `json:"degraded_since,omitempty"`
+	// Degradations and Recoveries count switches into and out of degraded mode
+	Degradations int64 `json:"degradations"`
+	Recoveries   int64 `json:"recoveries"`
+	// Failures counts Redis errors absorbed instead of returned
+	Failures int64 `json:"failures"`
+	// FallbackReads counts reads answered as misses
+	FallbackReads int64 `json:"fallback_reads"`
+	// SkippedWrites counts writes not made
+	SkippedWrites  int64 `json:"skipped_writes"`
+	PendingDeletes int   `json:"pending_deletes"`
+	// LostDeletes counts deletes dropped because too many were pending
+	LostDeletes int64 `json:"lost_deletes"`
+	// Memory is the memory guard's state, see CachePressure
+	Memory *MemoryStats `json:"memory,omitempty"`
+	// Local is the in-process tier's state, see LocalCache
+	Local *LocalCacheStats `json:"local,omitempty"`
+}
+
+// cacheHealth tracks whether Redis is usable
+type cacheHealth struct {
+	config CacheDegradation
+	clock  clock.Clock
+
+	degraded atomic.Bool
+	failures atomic.Int64 // consecutive
+
+	mu        sync.Mutex
+	since     time.Time
+	nextProbe time.Time
+	probing   bool
+	keys      map[string]struct{}
+	prefixes  map[string]struct{}
+
+	degradations  atomic.Int64
+	recoveries    atomic.Int64
+	absorbed      atomic.Int64
+	fallbackReads atomic.Int64
+	skippedWrites atomic.Int64
+	lostDeletes   atomic.Int64
+}
+
+// WithDegradation makes Redis failures non-fatal, see CacheDegradation
+func (cr *CacheRepository) WithDegradation(config CacheDegradation) *CacheRepository {
+	defaults := DefaultCacheDegradation()
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = defaults.FailureThreshold
+	}
+	if config.ProbeInterval <= 0 {
+		config.ProbeInterval = defaults.ProbeInterval
+	}
+	if config.MaxPendingDeletes <= 0 {
+		config.MaxPendingDeletes = defaults.MaxPendingDeletes
+	}
+	cr.health = &cacheHealth{
+		config:   config,
+		clock:    cr.clock,
+		keys:     make(map[string]struct{}),
+		prefixes: make(map[string]struct{}),
+	}
+	return cr
+}
+
+// WithClock overrides the clock used to schedule recovery probes
+func (cr *CacheRepository) WithClock(c clock.Clock) *CacheRepository {
+	cr.clock = clock.OrDefault(c)
+	if cr.health != nil {
+		cr.health.clock = cr.clock
+	}
+	return cr
+}
+
+// Degraded reports whether the repository is skipping Redis
+func (cr *CacheRepository) Degraded() bool {
+	return cr.health != nil && cr.health.degraded.Load()
+}
+
+// Stats returns a snapshot of the degradation counters, the memory guard's
+// state and the in-process tier's counters
+func (cr *CacheRepository) Stats() CacheStats {
+	var memory *MemoryStats
+	if m, ok := cr.MemoryStats(); ok {
+		memory = &m
+	}
+	var local *LocalCacheStats
+	if l, ok := cr.LocalStats(); ok {
+		local = &l
+	}
+	h := cr.health
+	if h == nil {
+		return CacheStats{Memory: memory, Local: local}
+	}
+	stats := CacheStats{
+		Memory:        memory,
+		Local:         local,
+		Degraded:      h.degraded.Load(),
+		Degradations:  h.degradations.Load(),
+		Recoveries:    h.recoveries.Load(),
+		Failures:      h.absorbed.Load(),
+		FallbackReads: h.fallbackReads.Load(),
+		SkippedWrites: h.skippedWrites.Load(),
+		LostDeletes:   h.lostDeletes.Load(),
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if stats.Degraded {
+		since := h.since
+		stats.DegradedSince = &since
+	}
+	stats.PendingDeletes = len(h.keys) + len(h.prefixes)
+	return stats
+}
+
+// skip reports whether an operation should skip Redis. A degraded
+// repository probes Redis once per interval and recovers when it answers
+// and the remembered deletes are replayed.
+func (cr *CacheRepository) skip(ctx context.Context) bool {
+	h := cr.health
+	if h == nil || !h.degraded.Load() {
+		return false
+	}
+
+	h.mu.Lock()
+	if h.probing || h.clock.Now().Before(h.nextProbe) {
+		h.mu.Unlock()
+		return true
+	}
+	h.probing = true
+	keys := make([]string, 0, len(h.keys))
+	for key := range h.keys {
+		keys = append(keys, key)
+	}
+	prefixes := make([]string, 0, len(h.prefixes))
+	for prefix := range h.prefixes {
+		prefixes = append(prefixes, prefix)
+	}
+	h.mu.Unlock()
+
+	err := cr.client.Ping(ctx).Err()
+	if err == nil {
+		err = cr.replay(ctx, keys, prefixes)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.probing = false
+	if err != nil {
+		h.nextProbe = h.clock.Now().Add(h.config.ProbeInterval)
+		return true
+	}
+	for _, key := range keys {
+		delete(h.keys, key)
+	}
+	for _, prefix := range prefixes {
+		delete(h.prefixes, prefix)
+	}
+	if len(h.keys) > 0 || len(h.prefixes) > 0 {
+		// Deletes made during the probe are replayed by the next one
+		h.nextProbe = time.Time{}
+		return true
+	}
+	h.failures.Store(0)
+	h.degraded.Store(false)
+	h.recoveries.Add(1)
+	log.Printf("Redis is available again after %v; cache restored", h.clock.Now().Sub(h.since).Round(time.Second))
+	return false
+}
+
+// replay deletes the keys and prefixes remembered while degraded
+func (cr *CacheRepository) replay(ctx context.Context, keys, prefixes []string) error {
+	for start := 0; start < len(keys); start += 500 {
+		end := min(start+500, len(keys))
+		if err := cr.client.Del(ctx, keys[start:end]...).Err(); err != nil {
+			return err
+		}
+	}
+	for _, prefix := range prefixes {
+		if _, err := cr.deletePrefix(ctx, prefix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// absorb records the outcome of a Redis call and reports whether err is an
+// availability failure to hide from the caller. Misses, Redis command
+// errors and cancelled requests are returned as they are. A failed delete
+// degrades the repository at once, so reads cannot return the entry it
+// should have removed before the delete is replayed.
+func (cr *CacheRepository) absorb(ctx context.Context, err error, isDelete bool) bool {
+	h := cr.health
+	if h == nil {
+		return false
+	}
+	var commandErr redis.Error
+	if err == nil || errors.Is(err, redis.Nil) || errors.As(err, &commandErr) {
+		if h.failures.Load() != 0 {
+			h.failures.Store(0)
+		}
+		return false
+	}
+	if ctx.Err() != nil {
+		return false
+	}
+
+	h.absorbed.Add(1)
+	if (h.failures.Add(1) >= int64(h.config.FailureThreshold) || isDelete) && !h.degraded.Load() {
+		h.mu.Lock()
+		if !h.degraded.Load() {
+			now := h.clock.Now()
+			h.since = now
+			h.nextProbe = now.Add(h.config.ProbeInterval)
+			h.degraded.Store(true)
+			h.degradations.Add(1)
+			log.Printf("Redis is unavailable, serving without the cache: %v", err)
+		}
+		h.mu.Unlock()
+	}
+	return true
+}
+
+// remember records a delete to replay on recovery
+func (cr *CacheRepository) remember(key string, prefix bool) {
+	h := cr.health
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	pending := h.keys
+	if prefix {
+		pending = h.prefixes
+	}
+	if _, ok := pending[key]; ok {
+		return
+	}
+	if len(h.keys)+len(h.prefixes) >= h.config.MaxPendingDeletes {
+		h.lostDeletes.Add(1)
+		return
+	}
+	pending[key] = struct{}{}
+}
@@ -0,0 +1,148 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrCacheMiss is returned by the typed cache reads for an entry that is
+// absent, unreadable or unavailable while Redis is degraded
+var ErrCacheMiss = errors.New("cache miss")
+
+// Schema versions of the cached values. Bump one when the JSON of its type
+// changes in a way older servers cannot read, e.g. a renamed or retyped
+// field: entries in the old shape are then never read and expire on their
+// own, and servers of both versions can run side by side during a deploy.
+const (
+	userCacheVersion = 2
+	postCacheVersion = 1
+	listCacheVersion = 1
+)
+
+// CacheKey names a typed cache entry. Its version is part of the Redis key,
+// so values of different schema versions never collide.
+type CacheKey struct {
+	Name    string
+	Version int
+	// Class is how readily the entry is given up under memory pressure
+	Class CacheClass
+	// Local entries are hot enough to keep in process too, see LocalCache
+	Local bool
+}
+
+// String returns the Redis key, e.g. user:7:v1
+func (k CacheKey) String() string {
+	return fmt.Sprintf("%s:v%d", k.Name, k.Version)
+}
+
+// SetJSON stores value as JSON under key
+func SetJSON[T any](ctx context.Context, cr *CacheRepository, key CacheKey, value T, expiration time.Duration) error {
+	expiration, ok := cr.admit(key.Class, expiration)
+	if !ok {
+		return nil
+	}
+	return putJSON(ctx, cr, key, value, expiration)
+}
+
+// putJSON writes value as JSON under key with an admitted expiration
+func putJSON[T any](ctx context.Context, cr *CacheRepository, key CacheKey, value T, expiration time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", key, err)
+	}
+	generation := localGeneration(cr, key)
+	if err := cr.write(ctx, key.String(), data, expiration); err != nil {
+		return err
+	}
+	localPut(cr, key, value, generation)
+	return nil
+}
+
+// GetJSON reads a value stored by SetJSON, from the in-process tier when
+// the key is Local and the tier holds it. An entry that no longer decodes
+// into T is deleted and reported as ErrCacheMiss, so a schema change that
+// was not given a new version costs a database read rather than an error.
+func GetJSON[T any](ctx context.Context, cr *CacheRepository, key CacheKey) (*T, error) {
+	if value, ok := localGet[T](cr, key); ok {
+		return value, nil
+	}
+	generation := localGeneration(cr, key)
+	data, err := cr.Get(ctx, key.String())
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrCacheMiss
+	}
+	if err != nil {
+		return nil, err
+	}
+	var value T
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		cr.Delete(ctx, key.String())
+		return nil, ErrCacheMiss
+	}
+	if key.Local {
+		localPut(cr, key, value, generation)
+	}
+	return &value, nil
+}
+
+// Cached is a value stored by SetCached, with what probabilistic early
+// expiration needs to know about it
+type Cached[T any] struct {
+	Value T `json:"value"`
+	// ExpiresAt is when the entry expires from Redis
+	ExpiresAt time.Time `json:"expires_at"`
+	// Delta is how long computing the value took
+	Delta time.Duration `json:"delta"`
+}
+
+// RefreshEarly reports whether a reader at now should recompute the value
+// before it expires, given r drawn uniformly from (0, 1]. This is XFetch
+// (Vattani et al., "Optimal Probabilistic Cache Stampede Prevention"):
+// the chance rises as expiry nears, and sooner for values that are slow
+// to compute, so one reader usually refreshes a hot key while the others
+// still read it, rather than all of them missing at once. A beta above 1
+// refreshes earlier. An entry with no Delta is refreshed at expiry only.
+func (c *Cached[T]) RefreshEarly(now time.Time, beta, r float64) bool {
+	head := time.Duration(-float64(c.Delta) * beta * math.Log(r))
+	return !now.Add(head).Before(c.ExpiresAt)
+}
+
+// SetCached stores value as JSON under key together with delta, how long
+// computing it took
+func SetCached[T any](ctx context.Context, cr *CacheRepository, key CacheKey, value T, delta, expiration time.Duration) error {
+	expiration, ok := cr.admit(key.Class, expiration)
+	if !ok {
+		return nil
+	}
+	// The expiry recorded is the one Redis applies, shortened or not
+	entry := Cached[T]{Value: value, ExpiresAt: cr.clock.Now().Add(expiration), Delta: delta}
+	return putJSON(ctx, cr, key, entry, expiration)
+}
+
+// GetCached reads an entry stored by SetCached, with GetJSON's handling of
+// misses and undecodable entries
+func GetCached[T any](ctx context.Context, cr *CacheRepository, key CacheKey) (*Cached[T], error) {
+	return GetJSON[Cached[T]](ctx, cr, key)
+}
+
+// UserCacheKey is the cache key of a user
+func UserCacheKey(userID uint) CacheKey {
+	return CacheKey{Name: fmt.Sprintf("user:%d", userID), Version: userCacheVersion, Local: true}
+}
+
+// PostCacheKey is the cache key of a post. Only published posts are kept
+// in process, see SetPostCache.
+func PostCacheKey(postID uint) CacheKey {
+	return CacheKey{Name: fmt.Sprintf("post:%d", postID), Version: postCacheVersion, Local: true}
+}
+
+// ListCacheKey is the cache key of a named list
+func ListCacheKey(listKey string) CacheKey {
+	return CacheKey{Name: "list:" + listKey, Version: listCacheVersion, Class: CacheBestEffort}
+}
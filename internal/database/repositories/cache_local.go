@@ -0,0 +1,343 @@
+package repositories
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go-server/internal/events"
+	"go-server/internal/idgen"
+	"go-server/internal/logger"
+)
+
+// LocalCache configures the in-process tier of a CacheRepository. Hot
+// entries, those whose CacheKey is Local such as users and published
+// posts, are also kept in a bounded LRU in each server, so repeated reads
+// skip the Redis round trip. Deleting an entry publishes an invalidation
+// that every server subscribed with StartInvalidation applies to its own
+// tier. Pub/sub delivers at most once, and nothing while a server is
+// disconnected, so the tier is emptied whenever the subscription is
+// re-established and entries are only kept for TTL, which bounds how long
+// a lost invalidation can leave a server serving a stale entry.
+//
+// Writes do not invalidate other servers' tiers: callers changing an
+// entity delete its entry, as they already do for Redis.
+type LocalCache struct {
+	// Size bounds the entries kept; the least recently used go first
+	Size int
+	// TTL is how long an entry is kept, however long it has in Redis
+	TTL time.Duration
+	// Channel is the pub/sub channel invalidations are published on
+	Channel string
+	// Bus carries the invalidations; optional, defaults to Redis pub/sub
+	// on the cache's client
+	Bus events.Bus
+}
+
+// DefaultLocalCache returns the default in-process tier settings
+func DefaultLocalCache() LocalCache {
+	return LocalCache{
+		Size:    10000,
+		TTL:     30 * time.Second,
+		Channel: "cache:invalidate",
+	}
+}
+
+// LocalCacheStats is a snapshot of the in-process tier's counters
+type LocalCacheStats struct {
+	Entries int   `json:"entries"`
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+	// Evictions counts entries dropped to make room
+	Evictions int64 `json:"evictions"`
+	// Invalidations counts invalidations applied from other servers, and
+	// LostInvalidations those this server could not publish
+	Invalidations     int64 `json:"invalidations"`
+	LostInvalidations int64 `json:"lost_invalidations"`
+}
+
+// invalidation is the message published when entries are deleted. An
+// empty Key and Prefix invalidates every entry.
+type invalidation struct {
+	Origin string `json:"origin"`
+	Key    string `json:"key,omitempty"`
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// localEntry is an entry of the in-process tier
+type localEntry struct {
+	key       string
+	value     any
+	expiresAt time.Time
+}
+
+// localTier is a bounded LRU of decoded cache entries
+type localTier struct {
+	config LocalCache
+	// origin identifies this server's invalidations, which it has already
+	// applied
+	origin string
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // most recently used first
+	// generation counts removals, so a value read from Redis before an
+	// invalidation is not kept after it
+	generation uint64
+
+	hits          atomic.Int64
+	misses        atomic.Int64
+	evictions     atomic.Int64
+	invalidations atomic.Int64
+	lost          atomic.Int64
+}
+
+// WithLocalCache keeps hot entries in process too, see LocalCache
+func (cr *CacheRepository) WithLocalCache(config LocalCache) *CacheRepository {
+	defaults := DefaultLocalCache()
+	if config.Size <= 0 {
+		config.Size = defaults.Size
+	}
+	if config.TTL <= 0 {
+		config.TTL = defaults.TTL
+	}
+	if config.Channel == "" {
+		config.Channel = defaults.Channel
+	}
+	if config.Bus == nil {
+		config.Bus = events.NewRedisBus(cr.client, logger.NewServerLogger())
+	}
+	cr.local = &localTier{
+		config:  config,
+		origin:  idgen.Default.NewID(),
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+	return cr
+}
+
+// LocalStats returns the in-process tier's counters, and false without one
+func (cr *CacheRepository) LocalStats() (LocalCacheStats, bool) {
+	l := cr.local
+	if l == nil {
+		return LocalCacheStats{}, false
+	}
+	l.mu.Lock()
+	entries := len(l.entries)
+	l.mu.Unlock()
+	return LocalCacheStats{
+		Entries:           entries,
+		Hits:              l.hits.Load(),
+		Misses:            l.misses.Load(),
+		Evictions:         l.evictions.Load(),
+		Invalidations:     l.invalidations.Load(),
+		LostInvalidations: l.lost.Load(),
+	}, true
+}
+
+// get returns the live entry under key
+func (l *localTier) get(key string, now time.Time) (any, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	element, ok := l.entries[key]
+	if !ok {
+		l.misses.Add(1)
+		return nil, false
+	}
+	entry := element.Value.(*localEntry)
+	if !now.Before(entry.expiresAt) {
+		l.order.Remove(element)
+		delete(l.entries, key)
+		l.misses.Add(1)
+		return nil, false
+	}
+	l.order.MoveToFront(element)
+	l.hits.Add(1)
+	return entry.value, true
+}
+
+// snapshot returns the current generation, to pass to put
+func (l *localTier) snapshot() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.generation
+}
+
+// put stores value under key, evicting the least recently used entries
+// beyond the size. The value is dropped if anything was removed since
+// generation, as it may be what was removed.
+func (l *localTier) put(key string, value any, now time.Time, generation uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if generation != l.generation {
+		return
+	}
+	expiresAt := now.Add(l.config.TTL)
+	if element, ok := l.entries[key]; ok {
+		entry := element.Value.(*localEntry)
+		entry.value, entry.expiresAt = value, expiresAt
+		l.order.MoveToFront(element)
+		return
+	}
+	l.entries[key] = l.order.PushFront(&localEntry{key: key, value: value, expiresAt: expiresAt})
+	for l.order.Len() > l.config.Size {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.entries, oldest.Value.(*localEntry).key)
+		l.evictions.Add(1)
+	}
+}
+
+// remove drops the entry under key
+func (l *localTier) remove(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.generation++
+	if element, ok := l.entries[key]; ok {
+		l.order.Remove(element)
+		delete(l.entries, key)
+	}
+}
+
+// removePrefix drops every entry whose key starts with prefix; an empty
+// prefix empties the tier
+func (l *localTier) removePrefix(prefix string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.generation++
+	if prefix == "" {
+		l.entries = make(map[string]*list.Element)
+		l.order.Init()
+		return
+	}
+	for key, element := range l.entries {
+		if strings.HasPrefix(key, prefix) {
+			l.order.Remove(element)
+			delete(l.entries, key)
+		}
+	}
+}
+
+// localGet returns the in-process copy of a Local entry
+func localGet[T any](cr *CacheRepository, key CacheKey) (*T, bool) {
+	if cr.local == nil || !key.Local {
+		return nil, false
+	}
+	value, ok := cr.local.get(key.String(), cr.clock.Now())
+	if !ok {
+		return nil, false
+	}
+	typed, ok := value.(T)
+	if !ok {
+		return nil, false
+	}
+	return &typed, true
+}
+
+// localGeneration returns the generation to pass to localPut after
+// reading a Local entry from Redis
+func localGeneration(cr *CacheRepository, key CacheKey) uint64 {
+	if cr.local == nil || !key.Local {
+		return 0
+	}
+	return cr.local.snapshot()
+}
+
+// localPut keeps a copy of a Local entry read or written at generation in
+// process. Writing an entry that is not Local drops any copy, e.g. of a
+// post that is no longer published.
+func localPut[T any](cr *CacheRepository, key CacheKey, value T, generation uint64) {
+	if cr.local == nil {
+		return
+	}
+	if !key.Local {
+		cr.local.remove(key.String())
+		return
+	}
+	cr.local.put(key.String(), value, cr.clock.Now(), generation)
+}
+
+// DeleteKey removes a typed entry from cache. A Local entry is dropped
+// from every server's in-process tier too.
+func (cr *CacheRepository) DeleteKey(ctx context.Context, key CacheKey) error {
+	if cr.local != nil {
+		cr.local.remove(key.String())
+		if key.Local {
+			cr.publishInvalidation(ctx, invalidation{Key: key.String()})
+		}
+	}
+	return cr.Delete(ctx, key.String())
+}
+
+// invalidatePrefix drops entries starting with prefix from every server's
+// in-process tier
+func (cr *CacheRepository) invalidatePrefix(ctx context.Context, prefix string) {
+	if cr.local == nil {
+		return
+	}
+	cr.local.removePrefix(prefix)
+	cr.publishInvalidation(ctx, invalidation{Prefix: prefix})
+}
+
+// publishInvalidation tells the other servers to drop entries. One that
+// cannot be published is counted; the other servers' copies then expire
+// after the tier's TTL.
+func (cr *CacheRepository) publishInvalidation(ctx context.Context, message invalidation) {
+	if cr.Degraded() {
+		cr.local.lost.Add(1)
+		return
+	}
+	message.Origin = cr.local.origin
+	data, err := json.Marshal(message)
+	if err == nil {
+		err = cr.local.config.Bus.Publish(ctx, events.Event{Topic: cr.local.config.Channel, Payload: data})
+	}
+	if err != nil {
+		cr.local.lost.Add(1)
+		log.Printf("Failed to publish cache invalidation: %v", err)
+	}
+}
+
+// applyInvalidation applies an invalidation published by another server
+func (cr *CacheRepository) applyInvalidation(payload []byte) {
+	var message invalidation
+	if err := json.Unmarshal(payload, &message); err != nil {
+		log.Printf("Ignoring malformed cache invalidation: %v", err)
+		return
+	}
+	if message.Origin == cr.local.origin {
+		return
+	}
+	cr.local.invalidations.Add(1)
+	if message.Key != "" {
+		cr.local.remove(message.Key)
+		return
+	}
+	cr.local.removePrefix(message.Prefix)
+}
+
+// StartInvalidation subscribes to the invalidations published by other
+// servers until ctx is done. It does nothing without WithLocalCache.
+func (cr *CacheRepository) StartInvalidation(ctx context.Context) {
+	l := cr.local
+	if l == nil {
+		return
+	}
+	err := l.config.Bus.Subscribe(ctx, events.Subscriber{
+		Pattern: l.config.Channel,
+		Handle: func(ctx context.Context, event events.Event) error {
+			cr.applyInvalidation(event.Payload)
+			return nil
+		},
+		// Invalidations may have been lost, so drop every entry
+		Missed: func() { l.removePrefix("") },
+	})
+	if err != nil {
+		log.Printf("Failed to subscribe to cache invalidations: %v", err)
+	}
+}
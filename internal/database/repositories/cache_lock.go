@@ -0,0 +1,233 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"go-server/internal/idgen"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Lock errors
+var (
+	// ErrLockHeld is returned when acquiring a lock another holder has
+	ErrLockHeld = errors.New("lock is held by another holder")
+	// ErrLockLost is returned when a lock expired or was taken over
+	// before its holder renewed or released it
+	ErrLockLost = errors.New("lock was lost")
+)
+
+// lockRetryInterval is how often WithLockWait retries a held lock
+const lockRetryInterval = 250 * time.Millisecond
+
+// The lock scripts compare the holder's value before touching the key, so
+// a holder whose lock expired cannot renew or release the next holder's.
+// The lock and its fencing counter share a hash tag, so they live on one
+// node of a Redis cluster.
+const (
+	// acquireLockSource takes KEYS[1] for ARGV[2] milliseconds and returns
+	// the next fencing token from KEYS[2], or 0 when the lock is held
+	acquireLockSource = `if redis.call("EXISTS", KEYS[1]) == 1 then
+	return 0
+end
+local token = redis.call("INCR", KEYS[2])
+redis.call("SET", KEYS[1], ARGV[1] .. ":" .. token, "PX", ARGV[2])
+return token`
+	// renewLockSource extends KEYS[1] to ARGV[2] milliseconds if it still
+	// holds ARGV[1]
+	renewLockSource = `if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`
+	// releaseLockSource deletes KEYS[1] if it still holds ARGV[1]
+	releaseLockSource = `if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`
+)
+
+var (
+	acquireLockScript = redis.NewScript(acquireLockSource)
+	renewLockScript   = redis.NewScript(renewLockSource)
+	releaseLockScript = redis.NewScript(releaseLockSource)
+)
+
+// Lock is a distributed lock held in Redis until it is released or its
+// TTL passes without a renewal.
+//
+// Each acquisition of a name gets a larger fencing token. A holder paused
+// past its TTL, e.g. by a long GC or a network partition, may still act
+// as if it held the lock; work that must not be done twice can pass the
+// token to the store it writes, which rejects tokens older than the
+// largest it has seen.
+type Lock struct {
+	cr    *CacheRepository
+	name  string
+	value string
+	token int64
+	ttl   time.Duration
+}
+
+// lockKey is the Redis key of the lock called name
+func lockKey(name string) string {
+	return "lock:{" + name + "}"
+}
+
+// Name returns the lock's name
+func (l *Lock) Name() string {
+	return l.name
+}
+
+// Token returns the lock's fencing token
+func (l *Lock) Token() int64 {
+	return l.token
+}
+
+// AcquireLock takes the lock called name for ttl, failing with
+// ErrLockHeld while another holder has it. Names should be fixed, e.g.
+// "schema:migrate": each name keeps a fencing counter that never expires.
+// Unlike cache operations, lock operations fail while Redis is
+// unavailable, whether or not the repository degrades.
+func (cr *CacheRepository) AcquireLock(ctx context.Context, name string, ttl time.Duration) (*Lock, error) {
+	if ttl < time.Millisecond {
+		return nil, fmt.Errorf("lock %s: ttl must be at least a millisecond", name)
+	}
+	key := lockKey(name)
+	owner := idgen.Default.NewID()
+	token, err := acquireLockScript.Run(ctx, cr.client, []string{key, key + ":fence"}, owner, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lock %s: %w", name, err)
+	}
+	if token == 0 {
+		return nil, ErrLockHeld
+	}
+	return &Lock{
+		cr:    cr,
+		name:  name,
+		value: owner + ":" + strconv.FormatInt(token, 10),
+		token: token,
+		ttl:   ttl,
+	}, nil
+}
+
+// Renew extends the lock by its TTL, failing with ErrLockLost if it has
+// expired or been taken over
+func (l *Lock) Renew(ctx context.Context) error {
+	renewed, err := renewLockScript.Run(ctx, l.cr.client, []string{lockKey(l.name)}, l.value, l.ttl.Milliseconds()).Int64()
+	if err != nil {
+		return fmt.Errorf("failed to renew lock %s: %w", l.name, err)
+	}
+	if renewed == 0 {
+		return ErrLockLost
+	}
+	return nil
+}
+
+// Release gives up the lock, failing with ErrLockLost if it had already
+// expired or been taken over
+func (l *Lock) Release(ctx context.Context) error {
+	released, err := releaseLockScript.Run(ctx, l.cr.client, []string{lockKey(l.name)}, l.value).Int64()
+	if err != nil {
+		return fmt.Errorf("failed to release lock %s: %w", l.name, err)
+	}
+	if released == 0 {
+		return ErrLockLost
+	}
+	return nil
+}
+
+// WithLock runs fn while holding the lock called name, failing with
+// ErrLockHeld when another holder has it. See Lock.Hold.
+func (cr *CacheRepository) WithLock(ctx context.Context, name string, ttl time.Duration, fn func(ctx context.Context, token int64) error) error {
+	lock, err := cr.AcquireLock(ctx, name, ttl)
+	if err != nil {
+		return err
+	}
+	return lock.Hold(ctx, fn)
+}
+
+// WithLockWait runs fn while holding the lock called name, waiting for
+// another holder to release it or until ctx is done. See Lock.Hold.
+func (cr *CacheRepository) WithLockWait(ctx context.Context, name string, ttl time.Duration, fn func(ctx context.Context, token int64) error) error {
+	for {
+		lock, err := cr.AcquireLock(ctx, name, ttl)
+		if err == nil {
+			return lock.Hold(ctx, fn)
+		}
+		if !errors.Is(err, ErrLockHeld) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("gave up waiting for lock %s: %w", name, ctx.Err())
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
+
+// Hold runs fn, renewing the lock every third of its TTL, and releases
+// the lock when fn returns. Should the lock be lost, fn's context is
+// cancelled and Hold's error wraps ErrLockLost; a renewal that fails
+// because Redis did not answer is retried until the lock would have
+// expired.
+func (l *Lock) Hold(ctx context.Context, fn func(ctx context.Context, token int64) error) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	renewer := make(chan struct{})
+	go func() {
+		defer close(renewer)
+		ticker := time.NewTicker(l.ttl / 3)
+		defer ticker.Stop()
+		deadline := l.cr.clock.Now().Add(l.ttl)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			err := l.Renew(ctx)
+			switch {
+			case err == nil:
+				deadline = l.cr.clock.Now().Add(l.ttl)
+			case ctx.Err() != nil:
+				return
+			case errors.Is(err, ErrLockLost) || !l.cr.clock.Now().Before(deadline):
+				cancel(fmt.Errorf("%w: %s", ErrLockLost, l.name))
+				return
+			default:
+				log.Printf("Failed to renew lock %s, retrying: %v", l.name, err)
+			}
+		}
+	}()
+
+	err := fn(ctx, l.token)
+	lost := context.Cause(ctx)
+	if !errors.Is(lost, ErrLockLost) {
+		lost = nil
+	}
+	cancel(nil)
+	<-renewer
+
+	if lost != nil {
+		return errors.Join(err, lost)
+	}
+	if releaseErr := l.Release(context.WithoutCancel(ctx)); releaseErr != nil {
+		// It expires after its TTL
+		log.Printf("Failed to release lock %s: %v", l.name, releaseErr)
+	}
+	return err
+}
+
+// TryLock claims key for ttl without a fencing token, returning false if
+// it is already claimed. The claim is never released, so it suits keys
+// naming a single occurrence of some work, as the scheduler's do; the
+// repository satisfies scheduler.Locker.
+func (cr *CacheRepository) TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return cr.client.SetNX(ctx, lockKey(key), "1", ttl).Result()
+}
@@ -0,0 +1,387 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go-server/internal/reporting"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// CacheClass is how readily a cache entry is given up when Redis runs
+// short of memory
+type CacheClass int
+
+// Cache classes, from the most to the least expendable
+const (
+	// CacheStandard entries, such as users and posts, are written with
+	// shorter TTLs under high pressure and not written under critical
+	// pressure
+	CacheStandard CacheClass = iota
+	// CacheBestEffort entries, such as lists, are not written under any
+	// pressure
+	CacheBestEffort
+	// CacheCritical entries, such as sessions, are always written with
+	// their own TTL
+	CacheCritical
+)
+
+// MemoryPressure is how close Redis is to its memory limit
+type MemoryPressure int32
+
+// Memory pressure levels
+const (
+	MemoryNormal MemoryPressure = iota
+	MemoryHigh
+	MemoryCritical
+)
+
+// String returns the level's name
+func (p MemoryPressure) String() string {
+	switch p {
+	case MemoryHigh:
+		return "high"
+	case MemoryCritical:
+		return "critical"
+	default:
+		return "normal"
+	}
+}
+
+// pressureMargin is how far below a watermark usage must fall before the
+// pressure drops, so usage hovering at a watermark does not flap
+const pressureMargin = 0.05
+
+// CachePressure configures the memory guard of a CacheRepository. Redis
+// evicts keys once it reaches maxmemory, and session keys are as likely
+// to go as cached users unless the cache gives way first. The guard reads
+// Redis's memory use periodically; as it crosses the high watermark,
+// standard entries are written with shorter TTLs and best-effort entries
+// are no longer written, and past the critical watermark, or as soon as
+// Redis evicts anything, only critical entries are written. Each rise in
+// pressure is reported as an alert.
+type CachePressure struct {
+	// HighWatermark and CriticalWatermark are fractions of the memory
+	// limit
+	HighWatermark     float64
+	CriticalWatermark float64
+	// TTLFactor scales the TTL of standard entries under high pressure
+	TTLFactor float64
+	// MaxMemory is the limit in bytes when Redis reports none, e.g. on
+	// managed instances hiding maxmemory; without either, only evictions
+	// raise the pressure
+	MaxMemory int64
+	// CheckInterval is how often StartMemoryGuard reads Redis's memory use
+	CheckInterval time.Duration
+}
+
+// DefaultCachePressure returns the default memory guard settings
+func DefaultCachePressure() CachePressure {
+	return CachePressure{
+		HighWatermark:     0.75,
+		CriticalWatermark: 0.9,
+		TTLFactor:         0.25,
+		CheckInterval:     30 * time.Second,
+	}
+}
+
+// MemoryStats is a snapshot of the memory guard
+type MemoryStats struct {
+	Pressure  string    `json:"pressure"`
+	CheckedAt time.Time `json:"checked_at,omitempty"`
+	// UsedMemory and MaxMemory are in bytes; MaxMemory is 0 when the limit
+	// is unknown
+	UsedMemory int64   `json:"used_memory"`
+	MaxMemory  int64   `json:"max_memory"`
+	Usage      float64 `json:"usage"`
+	// Policy is Redis's maxmemory-policy
+	Policy      string `json:"policy,omitempty"`
+	EvictedKeys int64  `json:"evicted_keys"`
+	// ShortenedWrites and PausedWrites count writes made with a shorter
+	// TTL and writes not made because of the pressure
+	ShortenedWrites int64 `json:"shortened_writes"`
+	PausedWrites    int64 `json:"paused_writes"`
+}
+
+// cachePressure tracks Redis's memory use
+type cachePressure struct {
+	config   CachePressure
+	reporter reporting.ErrorReporter
+
+	level     atomic.Int32
+	shortened atomic.Int64
+	paused    atomic.Int64
+
+	mu      sync.Mutex
+	last    MemoryStats
+	checked bool
+}
+
+// WithMemoryGuard adapts writes to Redis's memory use, see CachePressure.
+// Alerts go to reporter, which may be nil.
+func (cr *CacheRepository) WithMemoryGuard(config CachePressure, reporter reporting.ErrorReporter) *CacheRepository {
+	defaults := DefaultCachePressure()
+	if config.HighWatermark <= 0 {
+		config.HighWatermark = defaults.HighWatermark
+	}
+	if config.CriticalWatermark <= 0 {
+		config.CriticalWatermark = defaults.CriticalWatermark
+	}
+	if config.TTLFactor <= 0 {
+		config.TTLFactor = defaults.TTLFactor
+	}
+	if config.CheckInterval <= 0 {
+		config.CheckInterval = defaults.CheckInterval
+	}
+	cr.pressure = &cachePressure{config: config, reporter: reporter}
+	return cr
+}
+
+// Pressure returns the memory pressure of the last check
+func (cr *CacheRepository) Pressure() MemoryPressure {
+	if cr.pressure == nil {
+		return MemoryNormal
+	}
+	return MemoryPressure(cr.pressure.level.Load())
+}
+
+// MemoryStats returns the memory guard's last reading and counters, and
+// false without a guard
+func (cr *CacheRepository) MemoryStats() (MemoryStats, bool) {
+	p := cr.pressure
+	if p == nil {
+		return MemoryStats{}, false
+	}
+	p.mu.Lock()
+	stats := p.last
+	p.mu.Unlock()
+	stats.Pressure = MemoryPressure(p.level.Load()).String()
+	stats.ShortenedWrites = p.shortened.Load()
+	stats.PausedWrites = p.paused.Load()
+	return stats, true
+}
+
+// admit returns the TTL to write an entry of class with, and false when
+// it should not be written at all
+func (cr *CacheRepository) admit(class CacheClass, expiration time.Duration) (time.Duration, bool) {
+	p := cr.pressure
+	if p == nil || class == CacheCritical {
+		return expiration, true
+	}
+	switch MemoryPressure(p.level.Load()) {
+	case MemoryHigh:
+		if class == CacheBestEffort {
+			p.paused.Add(1)
+			return 0, false
+		}
+		if expiration > 0 {
+			p.shortened.Add(1)
+			expiration = max(time.Duration(float64(expiration)*p.config.TTLFactor), time.Second)
+		}
+		return expiration, true
+	case MemoryCritical:
+		p.paused.Add(1)
+		return 0, false
+	}
+	return expiration, true
+}
+
+// CheckMemory reads Redis's memory use and eviction count and updates the
+// pressure. Raising it is logged and reported; lowering it is logged.
+func (cr *CacheRepository) CheckMemory(ctx context.Context) (MemoryStats, error) {
+	p := cr.pressure
+	if p == nil {
+		return MemoryStats{}, nil
+	}
+
+	var memory, stats *redis.StringCmd
+	_, err := cr.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		memory = pipe.Info(ctx, "memory")
+		stats = pipe.Info(ctx, "stats")
+		return nil
+	})
+	if err != nil {
+		return MemoryStats{}, fmt.Errorf("failed to read redis memory info: %w", err)
+	}
+	info := parseInfo(memory.Val())
+	for key, value := range parseInfo(stats.Val()) {
+		info[key] = value
+	}
+
+	reading := MemoryStats{
+		CheckedAt:   cr.clock.Now(),
+		UsedMemory:  infoInt(info, "used_memory"),
+		MaxMemory:   infoInt(info, "maxmemory"),
+		Policy:      info["maxmemory_policy"],
+		EvictedKeys: infoInt(info, "evicted_keys"),
+	}
+	if reading.MaxMemory == 0 {
+		reading.MaxMemory = p.config.MaxMemory
+	}
+	if reading.MaxMemory > 0 {
+		reading.Usage = float64(reading.UsedMemory) / float64(reading.MaxMemory)
+	}
+
+	p.mu.Lock()
+	previous, checked := p.last, p.checked
+	p.last, p.checked = reading, true
+	p.mu.Unlock()
+
+	evicted := int64(0)
+	if checked && reading.EvictedKeys > previous.EvictedKeys {
+		evicted = reading.EvictedKeys - previous.EvictedKeys
+	}
+	if !checked || reading.Policy != previous.Policy {
+		if risk := policyRisk(reading.Policy); risk != "" {
+			log.Printf("⚠️ Redis maxmemory-policy is %s: %s", reading.Policy, risk)
+		}
+	}
+
+	from := MemoryPressure(p.level.Load())
+	to := p.config.pressure(from, reading.Usage)
+	if evicted > 0 {
+		to = MemoryCritical
+	}
+	p.level.Store(int32(to))
+	reading.Pressure = to.String()
+
+	switch {
+	case to > from:
+		message := fmt.Sprintf("Redis memory pressure is %s: %s of %s used (%.0f%%), %d keys evicted since the last check",
+			to, formatBytes(reading.UsedMemory), formatBytes(reading.MaxMemory), reading.Usage*100, evicted)
+		log.Printf("⚠️ %s; %s", message, to.effect())
+		if p.reporter != nil {
+			level := reporting.LevelWarning
+			if to == MemoryCritical {
+				level = reporting.LevelError
+			}
+			p.reporter.Report(ctx, reporting.Report{
+				Err:   errors.New(message),
+				Level: level,
+				Tags:  map[string]string{"alert": "redis_memory", "pressure": to.String(), "policy": reading.Policy},
+			})
+		}
+	case to < from:
+		log.Printf("Redis memory pressure is back to %s (%.0f%% used); %s", to, reading.Usage*100, to.effect())
+	}
+	return reading, nil
+}
+
+// pressure returns the level for usage, a fraction of the memory limit,
+// given the current level
+func (c CachePressure) pressure(current MemoryPressure, usage float64) MemoryPressure {
+	critical, high := c.CriticalWatermark, c.HighWatermark
+	// Dropping below a level takes usage a margin under its watermark
+	if current >= MemoryCritical {
+		critical -= pressureMargin
+	}
+	if current >= MemoryHigh {
+		high -= pressureMargin
+	}
+	switch {
+	case usage >= critical:
+		return MemoryCritical
+	case usage >= high:
+		return MemoryHigh
+	default:
+		return MemoryNormal
+	}
+}
+
+// effect describes what the cache does at the level
+func (p MemoryPressure) effect() string {
+	switch p {
+	case MemoryHigh:
+		return "caching with shorter TTLs and pausing best-effort entries"
+	case MemoryCritical:
+		return "caching only critical entries such as sessions"
+	default:
+		return "caching normally"
+	}
+}
+
+// StartMemoryGuard checks Redis's memory use every CheckInterval until
+// ctx is done. It does nothing without WithMemoryGuard.
+func (cr *CacheRepository) StartMemoryGuard(ctx context.Context) {
+	p := cr.pressure
+	if p == nil {
+		return
+	}
+	go func() {
+		check := func() {
+			if cr.Degraded() {
+				return
+			}
+			if _, err := cr.CheckMemory(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Failed to check Redis memory: %v", err)
+			}
+		}
+		check()
+		ticker := time.NewTicker(p.config.CheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				check()
+			}
+		}
+	}()
+}
+
+// policyRisk describes how an eviction policy endangers session keys, or
+// returns "" for policies the guard works with. Every cache entry has a
+// TTL, so under volatile-ttl the shortened TTLs make cached entries the
+// first to go.
+func policyRisk(policy string) string {
+	switch policy {
+	case "noeviction":
+		return "once Redis is full every write fails, session writes included"
+	case "allkeys-random", "volatile-random":
+		return "Redis evicts keys at random, active sessions as readily as cached entries"
+	}
+	return ""
+}
+
+// parseInfo parses the output of INFO into its fields
+func parseInfo(text string) map[string]string {
+	fields := make(map[string]string)
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, ":"); ok {
+			fields[key] = value
+		}
+	}
+	return fields
+}
+
+func infoInt(info map[string]string, key string) int64 {
+	n, _ := strconv.ParseInt(info[key], 10, 64)
+	return n
+}
+
+// formatBytes formats a byte count in binary units, e.g. 1.5GiB
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -3,46 +3,108 @@ package repositories
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
+	"go-server/internal/clock"
+	"go-server/internal/database/models"
+
 	"github.com/go-redis/redis/v8"
 )
 
-// CacheRepository handles Redis cache operations
+// CacheRepository handles Redis cache operations. With WithDegradation it
+// keeps working while Redis is down, see CacheDegradation, and with
+// WithLocalCache it keeps hot entries in process too, see LocalCache.
 type CacheRepository struct {
-	client *redis.Client
+	client   *redis.Client
+	clock    clock.Clock
+	health   *cacheHealth
+	pressure *cachePressure
+	local    *localTier
 }
 
 // NewCacheRepository creates a new cache repository
 func NewCacheRepository(client *redis.Client) *CacheRepository {
-	return &CacheRepository{client: client}
+	return &CacheRepository{client: client, clock: clock.New()}
 }
 
-// Set stores a value in cache with expiration
+// Set stores a value in cache with expiration as a standard entry
 func (cr *CacheRepository) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
-	return cr.client.Set(ctx, key, value, expiration).Err()
-}
-
-// Get retrieves a value from cache
+	return cr.SetClass(ctx, CacheStandard, key, value, expiration)
+}
+
+// SetClass stores a value in cache with expiration. Under memory pressure
+// the expiration is shortened, or the write skipped, as its class allows.
+func (cr *CacheRepository) SetClass(ctx context.Context, class CacheClass, key string, value interface{}, expiration time.Duration) error {
+	expiration, ok := cr.admit(class, expiration)
+	if !ok {
+		return nil
+	}
+	return cr.write(ctx, key, value, expiration)
+}
+
+// write stores a value in cache, skipping Redis while degraded
+func (cr *CacheRepository) write(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	if cr.skip(ctx) {
+		cr.health.skippedWrites.Add(1)
+		return nil
+	}
+	err := cr.client.Set(ctx, key, value, expiration).Err()
+	if cr.absorb(ctx, err, false) {
+		cr.health.skippedWrites.Add(1)
+		return nil
+	}
+	return err
+}
+
+// Get retrieves a value from cache. While degraded every read is a miss,
+// returned as redis.Nil.
 func (cr *CacheRepository) Get(ctx context.Context, key string) (string, error) {
-	return cr.client.Get(ctx, key).Result()
-}
-
-// Delete removes a value from cache
+	if cr.skip(ctx) {
+		cr.health.fallbackReads.Add(1)
+		return "", redis.Nil
+	}
+	value, err := cr.client.Get(ctx, key).Result()
+	if cr.absorb(ctx, err, false) {
+		cr.health.fallbackReads.Add(1)
+		return "", redis.Nil
+	}
+	return value, err
+}
+
+// Delete removes a value from cache. While degraded the delete is
+// replayed when Redis returns.
 func (cr *CacheRepository) Delete(ctx context.Context, key string) error {
-	return cr.client.Del(ctx, key).Err()
+	if cr.skip(ctx) {
+		cr.remember(key, false)
+		return nil
+	}
+	err := cr.client.Del(ctx, key).Err()
+	if cr.absorb(ctx, err, true) {
+		cr.remember(key, false)
+		return nil
+	}
+	return err
 }
 
 // Exists checks if a key exists in cache
 func (cr *CacheRepository) Exists(ctx context.Context, key string) (bool, error) {
+	if cr.skip(ctx) {
+		cr.health.fallbackReads.Add(1)
+		return false, nil
+	}
 	result, err := cr.client.Exists(ctx, key).Result()
+	if cr.absorb(ctx, err, false) {
+		cr.health.fallbackReads.Add(1)
+		return false, nil
+	}
 	return result > 0, err
 }
 
-// SetUserSession stores a user session in cache
+// SetUserSession stores a user session in cache as a critical entry
 func (cr *CacheRepository) SetUserSession(ctx context.Context, userID uint, sessionID string, expiration time.Duration) error {
 	key := fmt.Sprintf("session:%d:%s", userID, sessionID)
-	return cr.Set(ctx, key, "active", expiration)
+	return cr.SetClass(ctx, CacheCritical, key, "active", expiration)
 }
 
 // GetUserSession retrieves a user session from cache
@@ -58,64 +120,113 @@ func (cr *CacheRepository) DeleteUserSession(ctx context.Context, userID uint, s
 	return cr.Delete(ctx, key)
 }
 
-// SetPostCache stores a post in cache
-func (cr *CacheRepository) SetPostCache(ctx context.Context, postID uint, post interface{}, expiration time.Duration) error {
-	key := fmt.Sprintf("post:%d", postID)
-	return cr.Set(ctx, key, post, expiration)
+// SetPostCache stores a post in cache; published posts are kept in
+// process too
+func (cr *CacheRepository) SetPostCache(ctx context.Context, post *models.Post, expiration time.Duration) error {
+	key := PostCacheKey(post.ID)
+	key.Local = post.IsPublished()
+	return SetJSON(ctx, cr, key, *post, expiration)
 }
 
-// GetPostCache retrieves a post from cache
-func (cr *CacheRepository) GetPostCache(ctx context.Context, postID uint) (string, error) {
-	key := fmt.Sprintf("post:%d", postID)
-	return cr.Get(ctx, key)
+// GetPostCache retrieves a post from cache, failing with ErrCacheMiss if
+// it is not cached
+func (cr *CacheRepository) GetPostCache(ctx context.Context, postID uint) (*models.Post, error) {
+	return GetJSON[models.Post](ctx, cr, PostCacheKey(postID))
 }
 
 // DeletePostCache removes a post from cache
 func (cr *CacheRepository) DeletePostCache(ctx context.Context, postID uint) error {
-	key := fmt.Sprintf("post:%d", postID)
-	return cr.Delete(ctx, key)
+	return cr.DeleteKey(ctx, PostCacheKey(postID))
 }
 
-// SetUserCache stores a user in cache
-func (cr *CacheRepository) SetUserCache(ctx context.Context, userID uint, user interface{}, expiration time.Duration) error {
-	key := fmt.Sprintf("user:%d", userID)
-	return cr.Set(ctx, key, user, expiration)
+// SetUserCache stores a user in cache. Fields hidden from JSON, such as
+// the password hash, are not stored.
+func (cr *CacheRepository) SetUserCache(ctx context.Context, user *models.User, expiration time.Duration) error {
+	return cr.SetUserCacheWithDelta(ctx, user, 0, expiration)
 }
 
-// GetUserCache retrieves a user from cache
-func (cr *CacheRepository) GetUserCache(ctx context.Context, userID uint) (string, error) {
-	key := fmt.Sprintf("user:%d", userID)
-	return cr.Get(ctx, key)
+// SetUserCacheWithDelta stores a user in cache with how long reading it
+// took, which lets readers refresh it ahead of expiry
+func (cr *CacheRepository) SetUserCacheWithDelta(ctx context.Context, user *models.User, delta, expiration time.Duration) error {
+	return SetCached(ctx, cr, UserCacheKey(user.ID), *user, delta, expiration)
+}
+
+// GetUserCache retrieves a user from cache, failing with ErrCacheMiss if
+// it is not cached
+func (cr *CacheRepository) GetUserCache(ctx context.Context, userID uint) (*Cached[models.User], error) {
+	return GetCached[models.User](ctx, cr, UserCacheKey(userID))
 }
 
 // DeleteUserCache removes a user from cache
 func (cr *CacheRepository) DeleteUserCache(ctx context.Context, userID uint) error {
-	key := fmt.Sprintf("user:%d", userID)
-	return cr.Delete(ctx, key)
+	return cr.DeleteKey(ctx, UserCacheKey(userID))
 }
 
-// SetListCache stores a list in cache
+// SetListCache stores a list in cache as JSON
 func (cr *CacheRepository) SetListCache(ctx context.Context, listKey string, data interface{}, expiration time.Duration) error {
-	key := fmt.Sprintf("list:%s", listKey)
-	return cr.Set(ctx, key, data, expiration)
-}
-
-// GetListCache retrieves a list from cache
-func (cr *CacheRepository) GetListCache(ctx context.Context, listKey string) (string, error) {
-	key := fmt.Sprintf("list:%s", listKey)
-	return cr.Get(ctx, key)
+	return SetJSON(ctx, cr, ListCacheKey(listKey), data, expiration)
 }
 
 // DeleteListCache removes a list from cache
 func (cr *CacheRepository) DeleteListCache(ctx context.Context, listKey string) error {
-	key := fmt.Sprintf("list:%s", listKey)
-	return cr.Delete(ctx, key)
+	return cr.Delete(ctx, ListCacheKey(listKey).String())
 }
 
-// FlushAll clears all cache entries
+// FlushAll clears all cache entries. It fails with ErrCacheDegraded while
+// Redis is unavailable.
 func (cr *CacheRepository) FlushAll(ctx context.Context) error {
-	return cr.client.FlushAll(ctx).Err()
-}
+	cr.invalidatePrefix(ctx, "")
+	if cr.skip(ctx) {
+		return ErrCacheDegraded
+	}
+	err := cr.client.FlushAll(ctx).Err()
+	cr.absorb(ctx, err, false)
+	return err
+}
+
+// DeletePrefix deletes every cache entry whose key starts with prefix and
+// returns how many were deleted. Keys are found with SCAN, so Redis keeps
+// serving other clients while a large prefix is cleared. While degraded
+// the delete is replayed when Redis returns.
+func (cr *CacheRepository) DeletePrefix(ctx context.Context, prefix string) (int64, error) {
+	cr.invalidatePrefix(ctx, prefix)
+	if cr.skip(ctx) {
+		cr.remember(prefix, true)
+		return 0, nil
+	}
+	deleted, err := cr.deletePrefix(ctx, prefix)
+	if cr.absorb(ctx, err, true) {
+		cr.remember(prefix, true)
+		return deleted, nil
+	}
+	return deleted, err
+}
+
+func (cr *CacheRepository) deletePrefix(ctx context.Context, prefix string) (int64, error) {
+	pattern := globEscaper.Replace(prefix) + "*"
+	var deleted int64
+	var cursor uint64
+	for {
+		keys, next, err := cr.client.Scan(ctx, cursor, pattern, 500).Result()
+		if err != nil {
+			return deleted, err
+		}
+		if len(keys) > 0 {
+			n, err := cr.client.Del(ctx, keys...).Result()
+			if err != nil {
+				return deleted, err
+			}
+			deleted += n
+		}
+		if next == 0 {
+			return deleted, nil
+		}
+		cursor = next
+	}
+}
+
+// globEscaper escapes Redis glob metacharacters so a prefix matches literally
+var globEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`)
 
 // Ping checks if Redis is accessible
 func (cr *CacheRepository) Ping(ctx context.Context) error {
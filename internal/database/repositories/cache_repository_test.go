@@ -0,0 +1,691 @@
+package repositories
+
+import (
+	"bufio"
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/models"
+	"go-server/internal/reporting"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// fakeRedis speaks enough RESP for the cache repository. While down it
+// drops every connection.
+type fakeRedis struct {
+	listener net.Listener
+	down     atomic.Bool
+	mu       sync.Mutex
+	data     map[string]string
+	// expiry holds the expiration arguments of each SET, e.g. "ex 60"
+	expiry map[string]string
+	// info holds the reply to INFO of each section
+	info map[string]string
+	// subscribers holds the connections subscribed to each channel
+	subscribers map[string][]net.Conn
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	fr := &fakeRedis{listener: listener, data: make(map[string]string), expiry: make(map[string]string), info: make(map[string]string), subscribers: make(map[string][]net.Conn)}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go fr.serve(conn)
+		}
+	}()
+	return fr
+}
+
+func (fr *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(reader)
+		if err != nil || fr.down.Load() {
+			return
+		}
+		fr.mu.Lock()
+		var reply string
+		switch strings.ToUpper(args[0]) {
+		case "PING":
+			reply = "+PONG\r\n"
+		case "SET":
+			if _, exists := fr.data[args[1]]; exists && strings.EqualFold(args[len(args)-1], "nx") {
+				reply = "$-1\r\n"
+				break
+			}
+			fr.data[args[1]] = args[2]
+			fr.expiry[args[1]] = strings.Join(args[3:], " ")
+			reply = "+OK\r\n"
+		case "EVALSHA":
+			reply = "-NOSCRIPT No matching script\r\n"
+		case "EVAL":
+			reply = fmt.Sprintf(":%d\r\n", fr.eval(args[1], args[3:]))
+		case "SUBSCRIBE":
+			fr.subscribers[args[1]] = append(fr.subscribers[args[1]], conn)
+			reply = fmt.Sprintf("*3\r\n$9\r\nsubscribe\r\n$%d\r\n%s\r\n:1\r\n", len(args[1]), args[1])
+		case "PUBLISH":
+			message := fmt.Sprintf("*3\r\n$7\r\nmessage\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n", len(args[1]), args[1], len(args[2]), args[2])
+			for _, subscriber := range fr.subscribers[args[1]] {
+				io.WriteString(subscriber, message)
+			}
+			reply = fmt.Sprintf(":%d\r\n", len(fr.subscribers[args[1]]))
+		case "INFO":
+			info := fr.info[args[1]]
+			reply = fmt.Sprintf("$%d\r\n%s\r\n", len(info), info)
+		case "GET":
+			if value, ok := fr.data[args[1]]; ok {
+				reply = fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+			} else {
+				reply = "$-1\r\n"
+			}
+		case "DEL":
+			deleted := 0
+			for _, key := range args[1:] {
+				if _, ok := fr.data[key]; ok {
+					delete(fr.data, key)
+					deleted++
+				}
+			}
+			reply = fmt.Sprintf(":%d\r\n", deleted)
+		default:
+			reply = "-ERR unknown command\r\n"
+		}
+		// Replies are written under the lock, so they do not interleave
+		// with messages published to a subscribed connection
+		_, err = io.WriteString(conn, reply)
+		fr.mu.Unlock()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// eval runs the lock scripts, the only ones the repository sends, on keys
+// and their arguments. The caller holds fr.mu.
+func (fr *fakeRedis) eval(script string, args []string) int64 {
+	switch script {
+	case acquireLockSource:
+		if _, held := fr.data[args[0]]; held {
+			return 0
+		}
+		token, _ := strconv.ParseInt(fr.data[args[1]], 10, 64)
+		token++
+		fr.data[args[1]] = strconv.FormatInt(token, 10)
+		fr.data[args[0]] = args[2] + ":" + fr.data[args[1]]
+		fr.expiry[args[0]] = "px " + args[3]
+		return token
+	case renewLockSource:
+		if fr.data[args[0]] != args[1] {
+			return 0
+		}
+		fr.expiry[args[0]] = "px " + args[2]
+		return 1
+	case releaseLockSource:
+		if value, ok := fr.data[args[0]]; !ok || value != args[1] {
+			return 0
+		}
+		delete(fr.data, args[0])
+		return 1
+	}
+	panic("unexpected script " + script)
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil || count < 1 {
+		return nil, errors.New("malformed command")
+	}
+	args := make([]string, count)
+	for i := range args {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(header, "$")))
+		if err != nil || size < 0 {
+			return nil, errors.New("malformed command")
+		}
+		value := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, value); err != nil {
+			return nil, err
+		}
+		args[i] = string(value[:size])
+	}
+	return args, nil
+}
+
+func (fr *fakeRedis) get(key string) (string, bool) {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	value, ok := fr.data[key]
+	return value, ok
+}
+
+func TestCacheRepository_Degradation(t *testing.T) {
+	fr := newFakeRedis(t)
+	client := redis.NewClient(&redis.Options{Addr: fr.listener.Addr().String(), MaxRetries: -1, DialTimeout: time.Second})
+	t.Cleanup(func() { client.Close() })
+	fake := clock.NewFake(time.Now())
+	cache := NewCacheRepository(client).
+		WithClock(fake).
+		WithDegradation(CacheDegradation{FailureThreshold: 2, ProbeInterval: time.Minute})
+	ctx := context.Background()
+
+	if err := cache.Set(ctx, "user:1", "alice", time.Minute); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	fr.down.Store(true)
+	for i := 0; i < 2; i++ {
+		if _, err := cache.Get(ctx, "user:1"); !errors.Is(err, redis.Nil) {
+			t.Fatalf("Reads should fall back to a miss, got %v", err)
+		}
+	}
+	if !cache.Degraded() {
+		t.Fatal("Consecutive failures should degrade the cache")
+	}
+	if err := cache.Set(ctx, "user:2", "bob", time.Minute); err != nil {
+		t.Errorf("Writes should be skipped while degraded, got %v", err)
+	}
+	if err := cache.Delete(ctx, "user:1"); err != nil {
+		t.Errorf("Deletes should be deferred while degraded, got %v", err)
+	}
+	if err := cache.FlushAll(ctx); !errors.Is(err, ErrCacheDegraded) {
+		t.Errorf("FlushAll should report the degradation, got %v", err)
+	}
+	stats := cache.Stats()
+	if stats.FallbackReads != 2 || stats.SkippedWrites != 1 || stats.PendingDeletes != 1 || stats.Degradations != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	// Redis returns, but nothing is tried before the probe interval
+	fr.down.Store(false)
+	if _, err := cache.Get(ctx, "user:1"); !errors.Is(err, redis.Nil) || !cache.Degraded() {
+		t.Errorf("The cache should stay degraded until the next probe, got %v", err)
+	}
+	fake.Advance(time.Minute)
+	if _, err := cache.Get(ctx, "user:2"); !errors.Is(err, redis.Nil) {
+		t.Errorf("Skipped writes should not appear, got %v", err)
+	}
+	if cache.Degraded() {
+		t.Fatal("A successful probe should recover the cache")
+	}
+	if _, ok := fr.get("user:1"); ok {
+		t.Error("Deletes made while degraded should be replayed on recovery")
+	}
+	if stats := cache.Stats(); stats.Recoveries != 1 || stats.PendingDeletes != 0 {
+		t.Errorf("Unexpected stats after recovery %+v", stats)
+	}
+}
+
+func TestCacheRepository_FailedDeleteDegradesAtOnce(t *testing.T) {
+	fr := newFakeRedis(t)
+	client := redis.NewClient(&redis.Options{Addr: fr.listener.Addr().String(), MaxRetries: -1, DialTimeout: time.Second})
+	t.Cleanup(func() { client.Close() })
+	cache := NewCacheRepository(client).WithDegradation(CacheDegradation{FailureThreshold: 10})
+	ctx := context.Background()
+
+	fr.down.Store(true)
+	if err := cache.Delete(ctx, "post:1"); err != nil {
+		t.Fatalf("Delete should be deferred, got %v", err)
+	}
+	if !cache.Degraded() {
+		t.Error("A failed delete should degrade the cache so the stale entry is not read")
+	}
+}
+
+func TestCacheRepository_WithoutDegradation(t *testing.T) {
+	fr := newFakeRedis(t)
+	fr.down.Store(true)
+	client := redis.NewClient(&redis.Options{Addr: fr.listener.Addr().String(), MaxRetries: -1, DialTimeout: time.Second})
+	t.Cleanup(func() { client.Close() })
+	cache := NewCacheRepository(client)
+
+	if _, err := cache.Get(context.Background(), "user:1"); err == nil || errors.Is(err, redis.Nil) {
+		t.Errorf("Without degradation failures should be returned, got %v", err)
+	}
+}
+
+func TestCacheRepository_TypedValues(t *testing.T) {
+	fr := newFakeRedis(t)
+	client := redis.NewClient(&redis.Options{Addr: fr.listener.Addr().String(), MaxRetries: -1, DialTimeout: time.Second})
+	t.Cleanup(func() { client.Close() })
+	cache := NewCacheRepository(client)
+	ctx := context.Background()
+
+	user := &models.User{BaseModel: models.BaseModel{ID: 7}, Username: "ada", Email: "ada@example.com", Password: "hashed"}
+	if err := cache.SetUserCache(ctx, user, time.Minute); err != nil {
+		t.Fatalf("SetUserCache failed: %v", err)
+	}
+	stored, ok := fr.get("user:7:v2")
+	if !ok || !strings.Contains(stored, `"username":"ada"`) || strings.Contains(stored, "hashed") {
+		t.Fatalf("Expected the user stored as JSON without its password under a versioned key, got %q", stored)
+	}
+	cached, err := cache.GetUserCache(ctx, 7)
+	if err != nil || cached.Value.ID != 7 || cached.Value.Username != "ada" || cached.Value.Email != "ada@example.com" {
+		t.Fatalf("Expected user 7 read back, got %+v, %v", cached, err)
+	}
+	if cached.Delta != 0 || cached.ExpiresAt.IsZero() {
+		t.Errorf("Expected the entry's expiry recorded without a delta, got %+v", cached)
+	}
+
+	if _, err := cache.GetUserCache(ctx, 8); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected a missing user to be a miss, got %v", err)
+	}
+
+	// An entry of an older, unversioned schema is never read
+	if err := cache.Set(ctx, "user:9", "alice", time.Minute); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if _, err := cache.GetUserCache(ctx, 9); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected an unversioned entry to be a miss, got %v", err)
+	}
+
+	// An entry that no longer decodes is a miss, and is dropped
+	if err := cache.Set(ctx, "post:3:v1", `{"id": "three"}`, time.Minute); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if _, err := cache.GetPostCache(ctx, 3); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected an undecodable post to be a miss, got %v", err)
+	}
+	if _, ok := fr.get("post:3:v1"); ok {
+		t.Error("Expected the undecodable entry deleted")
+	}
+
+	posts := []models.Post{{BaseModel: models.BaseModel{ID: 1}, Title: "First"}, {BaseModel: models.BaseModel{ID: 2}, Title: "Second"}}
+	if err := cache.SetListCache(ctx, "posts:top", posts, time.Minute); err != nil {
+		t.Fatalf("SetListCache failed: %v", err)
+	}
+	list, err := GetJSON[[]models.Post](ctx, cache, ListCacheKey("posts:top"))
+	if err != nil || len(*list) != 2 || (*list)[1].Title != "Second" {
+		t.Errorf("Expected the list read back, got %v, %v", list, err)
+	}
+}
+
+func TestCached_RefreshEarly(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	entry := &Cached[string]{Value: "x", ExpiresAt: now.Add(time.Second), Delta: 100 * time.Millisecond}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		r    float64
+		want bool
+	}{
+		// -ln(r) * delta against the second left
+		{"far from expiry", now, 0.5, false},
+		{"unlucky draw", now, 1e-6, true},
+		{"near expiry", now.Add(950 * time.Millisecond), 0.5, true},
+		{"expired", now.Add(time.Second), 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := entry.RefreshEarly(tt.now, 1, tt.r); got != tt.want {
+				t.Errorf("RefreshEarly = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Without a delta the entry is only refreshed once it expires
+	entry.Delta = 0
+	if entry.RefreshEarly(now.Add(999*time.Millisecond), 1, 1e-9) {
+		t.Error("Expected no early refresh without a delta")
+	}
+}
+
+func TestCacheRepository_MemoryGuard(t *testing.T) {
+	fr := newFakeRedis(t)
+	client := redis.NewClient(&redis.Options{Addr: fr.listener.Addr().String(), MaxRetries: -1, DialTimeout: time.Second})
+	t.Cleanup(func() { client.Close() })
+	var alerts []reporting.Report
+	reporter := reporting.ReporterFunc(func(ctx context.Context, report reporting.Report) {
+		alerts = append(alerts, report)
+	})
+	cache := NewCacheRepository(client).WithMemoryGuard(CachePressure{}, reporter)
+	ctx := context.Background()
+
+	setInfo := func(used, evicted int) {
+		fr.mu.Lock()
+		defer fr.mu.Unlock()
+		fr.info["memory"] = fmt.Sprintf("# Memory\r\nused_memory:%d\r\nmaxmemory:1000\r\nmaxmemory_policy:volatile-ttl\r\n", used)
+		fr.info["stats"] = fmt.Sprintf("# Stats\r\nevicted_keys:%d\r\n", evicted)
+	}
+	expiry := func(key string) string {
+		fr.mu.Lock()
+		defer fr.mu.Unlock()
+		return fr.expiry[key]
+	}
+	user := &models.User{BaseModel: models.BaseModel{ID: 7}, Username: "ada"}
+
+	setInfo(800, 0)
+	stats, err := cache.CheckMemory(ctx)
+	if err != nil || stats.Pressure != "high" || stats.Usage != 0.8 || stats.Policy != "volatile-ttl" {
+		t.Fatalf("Expected high pressure at 80%% used, got %+v, %v", stats, err)
+	}
+	if len(alerts) != 1 || alerts[0].Level != reporting.LevelWarning || alerts[0].Tags["alert"] != "redis_memory" {
+		t.Fatalf("Expected a warning alert, got %+v", alerts)
+	}
+	if err := cache.SetUserCache(ctx, user, 40*time.Minute); err != nil {
+		t.Fatalf("SetUserCache failed: %v", err)
+	}
+	if got := expiry("user:7:v2"); got != "ex 600" {
+		t.Errorf("Expected the user cached for a quarter of its TTL, got %q", got)
+	}
+	cached, err := cache.GetUserCache(ctx, 7)
+	if err != nil || cached.ExpiresAt.Sub(time.Now()) > 10*time.Minute {
+		t.Errorf("Expected the shortened expiry recorded, got %+v, %v", cached, err)
+	}
+	if err := cache.SetListCache(ctx, "posts:top", []string{"a"}, time.Minute); err != nil {
+		t.Fatalf("SetListCache failed: %v", err)
+	}
+	if _, ok := fr.get("list:posts:top:v1"); ok {
+		t.Error("Best-effort entries should not be written under high pressure")
+	}
+	if err := cache.SetUserSession(ctx, 7, "s1", time.Hour); err != nil || expiry("session:7:s1") != "ex 3600" {
+		t.Errorf("Sessions should keep their TTL, got %q, %v", expiry("session:7:s1"), err)
+	}
+
+	// Evictions make the pressure critical whatever the usage
+	setInfo(700, 5)
+	if stats, err := cache.CheckMemory(ctx); err != nil || stats.Pressure != "critical" {
+		t.Fatalf("Expected critical pressure after evictions, got %+v, %v", stats, err)
+	}
+	if len(alerts) != 2 || alerts[1].Level != reporting.LevelError {
+		t.Fatalf("Expected an error alert, got %+v", alerts)
+	}
+	if err := cache.SetPostCache(ctx, &models.Post{BaseModel: models.BaseModel{ID: 3}}, time.Minute); err != nil {
+		t.Fatalf("SetPostCache failed: %v", err)
+	}
+	if _, ok := fr.get("post:3:v1"); ok {
+		t.Error("Standard entries should not be written under critical pressure")
+	}
+	if err := cache.SetUserSession(ctx, 7, "s2", time.Hour); err != nil {
+		t.Fatalf("SetUserSession failed: %v", err)
+	}
+	if _, ok := fr.get("session:7:s2"); !ok {
+		t.Error("Sessions should be written under critical pressure")
+	}
+
+	setInfo(500, 5)
+	if stats, err := cache.CheckMemory(ctx); err != nil || stats.Pressure != "normal" {
+		t.Fatalf("Expected the pressure to recover, got %+v, %v", stats, err)
+	}
+	if err := cache.Set(ctx, "plain", "x", time.Minute); err != nil || expiry("plain") != "ex 60" {
+		t.Errorf("Writes should be normal again, got %q, %v", expiry("plain"), err)
+	}
+	if len(alerts) != 2 {
+		t.Errorf("Recovering should not alert, got %d alerts", len(alerts))
+	}
+
+	memory := cache.Stats().Memory
+	if memory == nil || memory.PausedWrites != 2 || memory.ShortenedWrites != 1 || memory.EvictedKeys != 5 {
+		t.Errorf("Unexpected memory stats %+v", memory)
+	}
+}
+
+func TestCachePressure_Hysteresis(t *testing.T) {
+	config := DefaultCachePressure()
+	tests := []struct {
+		current MemoryPressure
+		usage   float64
+		want    MemoryPressure
+	}{
+		{MemoryNormal, 0.72, MemoryNormal},
+		{MemoryNormal, 0.75, MemoryHigh},
+		{MemoryHigh, 0.72, MemoryHigh},
+		{MemoryHigh, 0.69, MemoryNormal},
+		{MemoryCritical, 0.87, MemoryCritical},
+		{MemoryCritical, 0.8, MemoryHigh},
+		{MemoryNormal, 0, MemoryNormal},
+	}
+	for _, tt := range tests {
+		if got := config.pressure(tt.current, tt.usage); got != tt.want {
+			t.Errorf("pressure(%s, %v) = %s, want %s", tt.current, tt.usage, got, tt.want)
+		}
+	}
+}
+
+func TestCacheRepository_LocalCache(t *testing.T) {
+	fr := newFakeRedis(t)
+	newCache := func() (*CacheRepository, *clock.Fake) {
+		client := redis.NewClient(&redis.Options{Addr: fr.listener.Addr().String(), MaxRetries: -1, DialTimeout: time.Second})
+		t.Cleanup(func() { client.Close() })
+		fake := clock.NewFake(time.Now())
+		return NewCacheRepository(client).WithClock(fake).WithLocalCache(LocalCache{TTL: time.Minute}), fake
+	}
+	writer, _ := newCache()
+	reader, readerClock := newCache()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	reader.StartInvalidation(ctx)
+	waitFor(t, "the reader to subscribe", func() bool {
+		fr.mu.Lock()
+		defer fr.mu.Unlock()
+		return len(fr.subscribers["cache:invalidate"]) == 1
+	})
+
+	user := &models.User{BaseModel: models.BaseModel{ID: 7}, Username: "ada"}
+	if err := writer.SetUserCache(ctx, user, time.Hour); err != nil {
+		t.Fatalf("SetUserCache failed: %v", err)
+	}
+	if cached, err := reader.GetUserCache(ctx, 7); err != nil || cached.Value.Username != "ada" {
+		t.Fatalf("Expected the user read from Redis, got %+v, %v", cached, err)
+	}
+
+	// Later reads are served in process, without Redis
+	fr.mu.Lock()
+	fr.data["user:7:v2"] = `{"value": {"id": 7, "username": "changed"}}`
+	fr.mu.Unlock()
+	if cached, err := reader.GetUserCache(ctx, 7); err != nil || cached.Value.Username != "ada" {
+		t.Fatalf("Expected the in-process copy, got %+v, %v", cached, err)
+	}
+	if stats, _ := reader.LocalStats(); stats.Hits != 1 || stats.Entries != 1 {
+		t.Errorf("Unexpected local stats %+v", stats)
+	}
+
+	// A delete on another server reaches the reader's tier
+	if err := writer.DeleteUserCache(ctx, 7); err != nil {
+		t.Fatalf("DeleteUserCache failed: %v", err)
+	}
+	waitFor(t, "the invalidation", func() bool {
+		_, err := reader.GetUserCache(ctx, 7)
+		return errors.Is(err, ErrCacheMiss)
+	})
+	if stats := reader.Stats().Local; stats == nil || stats.Invalidations != 1 {
+		t.Errorf("Expected one invalidation applied, got %+v", stats)
+	}
+
+	// Drafts are not kept in process; published posts are, until the TTL
+	draft := &models.Post{BaseModel: models.BaseModel{ID: 1}, Title: "Draft", Status: "draft"}
+	publishedAt := time.Now()
+	published := &models.Post{BaseModel: models.BaseModel{ID: 2}, Title: "Live", Status: "published", PublishedAt: &publishedAt}
+	for _, post := range []*models.Post{draft, published} {
+		if err := reader.SetPostCache(ctx, post, time.Hour); err != nil {
+			t.Fatalf("SetPostCache failed: %v", err)
+		}
+	}
+	fr.mu.Lock()
+	delete(fr.data, "post:1:v1")
+	delete(fr.data, "post:2:v1")
+	fr.mu.Unlock()
+	if _, err := reader.GetPostCache(ctx, 1); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Drafts should not be kept in process, got %v", err)
+	}
+	if post, err := reader.GetPostCache(ctx, 2); err != nil || post.Title != "Live" {
+		t.Errorf("Published posts should be kept in process, got %+v, %v", post, err)
+	}
+	readerClock.Advance(time.Minute)
+	if _, err := reader.GetPostCache(ctx, 2); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("In-process copies should expire after the TTL, got %v", err)
+	}
+}
+
+func TestLocalTier_EvictsLeastRecentlyUsed(t *testing.T) {
+	tier := &localTier{config: LocalCache{Size: 2, TTL: time.Minute}, entries: make(map[string]*list.Element), order: list.New()}
+	now := time.Now()
+	tier.put("a", 1, now, 0)
+	tier.put("b", 2, now, 0)
+	tier.get("a", now)
+	tier.put("c", 3, now, 0)
+	if _, ok := tier.get("b", now); ok {
+		t.Error("Expected the least recently used entry evicted")
+	}
+	if _, ok := tier.get("a", now); !ok {
+		t.Error("Expected the recently read entry kept")
+	}
+
+	// A value read before a removal is not kept after it
+	generation := tier.snapshot()
+	tier.remove("d")
+	tier.put("d", 4, now, generation)
+	if _, ok := tier.get("d", now); ok {
+		t.Error("Expected a value from before the removal dropped")
+	}
+}
+
+// waitFor polls condition until it holds or a second has passed
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestCacheRepository_Locks(t *testing.T) {
+	fr := newFakeRedis(t)
+	client := redis.NewClient(&redis.Options{Addr: fr.listener.Addr().String(), MaxRetries: -1, DialTimeout: time.Second})
+	t.Cleanup(func() { client.Close() })
+	cache := NewCacheRepository(client)
+	ctx := context.Background()
+
+	first, err := cache.AcquireLock(ctx, "reindex", time.Minute)
+	if err != nil || first.Token() != 1 {
+		t.Fatalf("Expected the lock with token 1, got %+v, %v", first, err)
+	}
+	if got, _ := fr.get("lock:{reindex}"); !strings.HasSuffix(got, ":1") {
+		t.Errorf("Unexpected lock value %q", got)
+	}
+	if _, err := cache.AcquireLock(ctx, "reindex", time.Minute); !errors.Is(err, ErrLockHeld) {
+		t.Errorf("Expected a held lock to be refused, got %v", err)
+	}
+	if err := first.Renew(ctx); err != nil {
+		t.Errorf("Renew failed: %v", err)
+	}
+
+	// The lock expires and another holder takes it with a larger token
+	fr.mu.Lock()
+	delete(fr.data, "lock:{reindex}")
+	fr.mu.Unlock()
+	second, err := cache.AcquireLock(ctx, "reindex", time.Minute)
+	if err != nil || second.Token() != 2 {
+		t.Fatalf("Expected the lock with token 2, got %+v, %v", second, err)
+	}
+	if err := first.Renew(ctx); !errors.Is(err, ErrLockLost) {
+		t.Errorf("Expected the expired holder's renewal refused, got %v", err)
+	}
+	if err := first.Release(ctx); !errors.Is(err, ErrLockLost) {
+		t.Errorf("Expected the expired holder's release refused, got %v", err)
+	}
+	if _, held := fr.get("lock:{reindex}"); !held {
+		t.Fatal("The expired holder must not release the new holder's lock")
+	}
+	if err := second.Release(ctx); err != nil {
+		t.Errorf("Release failed: %v", err)
+	}
+
+	var token int64
+	err = cache.WithLock(ctx, "reindex", time.Minute, func(ctx context.Context, fence int64) error {
+		token = fence
+		return cache.WithLock(ctx, "reindex", time.Minute, func(context.Context, int64) error { return nil })
+	})
+	if !errors.Is(err, ErrLockHeld) || token != 3 {
+		t.Errorf("Expected the nested WithLock refused under token 3, got %v, %d", err, token)
+	}
+	if _, held := fr.get("lock:{reindex}"); held {
+		t.Error("WithLock should release the lock")
+	}
+
+	// Losing the lock cancels the work
+	err = cache.WithLock(ctx, "reindex", 30*time.Millisecond, func(ctx context.Context, _ int64) error {
+		fr.mu.Lock()
+		fr.data["lock:{reindex}"] = "someone-else:9"
+		fr.mu.Unlock()
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if !errors.Is(err, ErrLockLost) {
+		t.Errorf("Expected ErrLockLost, got %v", err)
+	}
+
+	claimed, err := cache.TryLock(ctx, "scheduler:lock:purge:1", time.Minute)
+	if err != nil || !claimed {
+		t.Fatalf("Expected the claim, got %v, %v", claimed, err)
+	}
+	if claimed, _ := cache.TryLock(ctx, "scheduler:lock:purge:1", time.Minute); claimed {
+		t.Error("Expected a second claim refused")
+	}
+}
+
+func TestCacheRepository_WithLockWait(t *testing.T) {
+	fr := newFakeRedis(t)
+	client := redis.NewClient(&redis.Options{Addr: fr.listener.Addr().String(), MaxRetries: -1, DialTimeout: time.Second})
+	t.Cleanup(func() { client.Close() })
+	cache := NewCacheRepository(client)
+	ctx := context.Background()
+
+	held, err := cache.AcquireLock(ctx, "schema:migrate", time.Minute)
+	if err != nil {
+		t.Fatalf("AcquireLock failed: %v", err)
+	}
+	time.AfterFunc(50*time.Millisecond, func() { held.Release(ctx) })
+	ran := false
+	err = cache.WithLockWait(ctx, "schema:migrate", time.Minute, func(context.Context, int64) error {
+		ran = true
+		return nil
+	})
+	if err != nil || !ran {
+		t.Errorf("Expected the work to run once the lock was released, got %v", err)
+	}
+
+	held, _ = cache.AcquireLock(ctx, "schema:migrate", time.Minute)
+	defer held.Release(ctx)
+	waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	err = cache.WithLockWait(waitCtx, "schema:migrate", time.Minute, func(context.Context, int64) error {
+		t.Error("The work should not run without the lock")
+		return nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected to give up with the context, got %v", err)
+	}
+}
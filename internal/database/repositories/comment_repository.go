@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"go-server/internal/database/models"
+	"gorm.io/gorm"
+)
+
+// CommentRepository handles comment database operations
+type CommentRepository struct {
+	db *gorm.DB
+}
+
+// NewCommentRepository creates a new comment repository
+func NewCommentRepository(db *gorm.DB) *CommentRepository {
+	return &CommentRepository{db: db}
+}
+
+// CreateComment creates a new comment
+func (cr *CommentRepository) CreateComment(ctx context.Context, comment *models.Comment) error {
+	return cr.db.WithContext(ctx).Create(comment).Error
+}
+
+// FindCommentByMessageID returns the comment created from an email, or nil
+// if the email has not been seen
+func (cr *CommentRepository) FindCommentByMessageID(ctx context.Context, messageID string) (*models.Comment, error) {
+	var comment models.Comment
+	err := cr.db.WithContext(ctx).Where("message_id = ?", messageID).First(&comment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// ListCommentsByPost retrieves a post's comments, oldest first, leaving out
+// those of deactivated authors
+func (cr *CommentRepository) ListCommentsByPost(ctx context.Context, postID uint, offset, limit int) ([]models.Comment, error) {
+	var comments []models.Comment
+	err := activeAuthors(cr.db.WithContext(ctx), "author_id").
+		Preload("Author").
+		Where("post_id = ?", postID).
+		Order("created_at ASC, id ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&comments).Error
+	return comments, err
+}
@@ -0,0 +1,160 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SQL dialects the repositories run on
+const (
+	DialectPostgres = "postgres"
+	DialectMySQL    = "mysql"
+	DialectSQLite   = "sqlite"
+)
+
+// DialectOf returns the SQL dialect of db, as named by its GORM dialector
+func DialectOf(db *gorm.DB) string {
+	if db == nil || db.Dialector == nil {
+		return ""
+	}
+	return db.Dialector.Name()
+}
+
+// UTCTimes is a GORM plugin for SQLite, which stores times as text and
+// compares them as text: it writes every time bound to a statement in
+// UTC, so that "expires_at > ?" holds whichever zone either time was in.
+// PostgreSQL compares timestamps as instants and needs no plugin.
+type UTCTimes struct{}
+
+// Name implements gorm.Plugin
+func (UTCTimes) Name() string {
+	return "utc_times"
+}
+
+// Initialize implements gorm.Plugin, wrapping the connection pool. Times
+// are bound while a statement is built and run, inside GORM's own
+// callbacks, so only the pool sees every one of them.
+func (UTCTimes) Initialize(db *gorm.DB) error {
+	pool := &utcPool{ConnPool: db.ConnPool}
+	db.ConnPool = pool
+	db.Statement.ConnPool = pool
+	return nil
+}
+
+// utcPool is a connection pool converting the times it is given to UTC
+type utcPool struct {
+	gorm.ConnPool
+}
+
+func (p *utcPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return p.ConnPool.PrepareContext(ctx, query)
+}
+
+func (p *utcPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return p.ConnPool.ExecContext(ctx, query, utcArgs(args)...)
+}
+
+func (p *utcPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return p.ConnPool.QueryContext(ctx, query, utcArgs(args)...)
+}
+
+func (p *utcPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return p.ConnPool.QueryRowContext(ctx, query, utcArgs(args)...)
+}
+
+// BeginTx implements gorm.ConnPoolBeginner, so transactions convert
+// times too
+func (p *utcPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	var (
+		tx  gorm.ConnPool
+		err error
+	)
+	switch beginner := p.ConnPool.(type) {
+	case gorm.TxBeginner:
+		tx, err = beginner.BeginTx(ctx, opts)
+	case gorm.ConnPoolBeginner:
+		tx, err = beginner.BeginTx(ctx, opts)
+	default:
+		return nil, gorm.ErrInvalidTransaction
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &utcTx{utcPool{ConnPool: tx}}, nil
+}
+
+// GetDBConn implements gorm.GetDBConnector, so db.DB() still returns the
+// underlying pool
+func (p *utcPool) GetDBConn() (*sql.DB, error) {
+	if sqlDB, ok := p.ConnPool.(*sql.DB); ok {
+		return sqlDB, nil
+	}
+	if connector, ok := p.ConnPool.(gorm.GetDBConnector); ok {
+		return connector.GetDBConn()
+	}
+	return nil, gorm.ErrInvalidDB
+}
+
+// utcTx is a transaction converting the times it is given to UTC
+type utcTx struct {
+	utcPool
+}
+
+// Commit implements gorm.TxCommitter
+func (t *utcTx) Commit() error {
+	return t.ConnPool.(gorm.TxCommitter).Commit()
+}
+
+// Rollback implements gorm.TxCommitter
+func (t *utcTx) Rollback() error {
+	return t.ConnPool.(gorm.TxCommitter).Rollback()
+}
+
+// utcArgs returns args with every time, or value whose driver value is a
+// time, in UTC. args itself is left alone.
+func utcArgs(args []interface{}) []interface{} {
+	converted := args
+	copied := false
+	for i, arg := range args {
+		t, ok := timeArg(arg)
+		if !ok || t.Location() == time.UTC {
+			continue
+		}
+		if !copied {
+			converted = append([]interface{}(nil), args...)
+			copied = true
+		}
+		converted[i] = t.UTC()
+	}
+	return converted
+}
+
+// timeArg returns the time an argument binds, if it binds one
+func timeArg(arg interface{}) (time.Time, bool) {
+	switch v := arg.(type) {
+	case time.Time:
+		return v, true
+	case *time.Time:
+		if v == nil {
+			return time.Time{}, false
+		}
+		return *v, true
+	case driver.Valuer:
+		// database/sql binds a nil pointer as NULL without calling Value
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && rv.IsNil() {
+			return time.Time{}, false
+		}
+		value, err := v.Value()
+		if err != nil {
+			return time.Time{}, false
+		}
+		t, ok := value.(time.Time)
+		return t, ok
+	}
+	return time.Time{}, false
+}
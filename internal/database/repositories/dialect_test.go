@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/dbtest"
+	"go-server/internal/database/models"
+
+	"gorm.io/gorm"
+)
+
+func newUTCTimesDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := dbtest.Open(t)
+	if err := db.Use(UTCTimes{}); err != nil {
+		t.Fatalf("Failed to register the plugin: %v", err)
+	}
+	if _, err := db.DB(); err != nil {
+		t.Fatalf("Expected db.DB() through the plugin, got %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.Session{}); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	return db
+}
+
+func TestUTCTimes_ComparesAcrossZones(t *testing.T) {
+	ctx := context.Background()
+	db := newUTCTimesDB(t)
+	if dialect := DialectOf(db); dialect != DialectSQLite {
+		t.Fatalf("Expected the sqlite dialect, got %q", dialect)
+	}
+	now := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	tokyo := time.FixedZone("JST", 9*3600)
+	newYork := time.FixedZone("EDT", -4*3600)
+
+	user := &models.User{Email: "ada@example.com", Username: "ada", Password: "hash"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	// As text, the expired session's time is later than now and the live
+	// one's earlier
+	sessions := []models.Session{
+		{UserID: user.ID, Token: "expired", IsActive: true, ExpiresAt: now.Add(-30 * time.Minute).In(tokyo)},
+		{UserID: user.ID, Token: "live", IsActive: true, ExpiresAt: now.Add(30 * time.Minute).In(newYork)},
+	}
+	if err := db.Create(&sessions).Error; err != nil {
+		t.Fatalf("Failed to create sessions: %v", err)
+	}
+
+	repo := NewSessionRepository(db).WithClock(clock.NewFake(now))
+	var live []models.Session
+	if err := repo.live(db.WithContext(ctx)).Find(&live).Error; err != nil {
+		t.Fatalf("Failed to list live sessions: %v", err)
+	}
+	if len(live) != 1 || live[0].Token != "live" {
+		t.Errorf("Expected only the live session, got %+v", live)
+	}
+}
+
+func TestUTCTimes_Transactions(t *testing.T) {
+	db := newUTCTimesDB(t)
+	tokyo := time.FixedZone("JST", 9*3600)
+	rollback := errors.New("rollback")
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&models.User{Email: "kept@example.com", Username: "kept", Password: "hash"}).Error; err != nil {
+			return err
+		}
+		// A nested transaction runs in a savepoint of the wrapped one
+		err := tx.Transaction(func(nested *gorm.DB) error {
+			nested.Create(&models.User{Email: "undone@example.com", Username: "undone", Password: "hash"})
+			return rollback
+		})
+		if !errors.Is(err, rollback) {
+			return fmt.Errorf("expected the nested rollback, got %v", err)
+		}
+		return tx.Model(&models.User{}).Where("username = ?", "kept").
+			Update("last_login", time.Date(2026, 10, 18, 9, 0, 0, 0, tokyo)).Error
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+
+	var users []models.User
+	db.Order("id").Find(&users)
+	if len(users) != 1 || users[0].Username != "kept" {
+		t.Fatalf("Expected only the committed user, got %+v", users)
+	}
+	var stored string
+	db.Raw("SELECT CAST(last_login AS TEXT) FROM users WHERE id = ?", users[0].ID).Scan(&stored)
+	if stored != "2026-10-18 00:00:00+00:00" {
+		t.Errorf("Expected the time stored in UTC, got %q", stored)
+	}
+}
@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go-server/internal/database/models"
+	"gorm.io/gorm"
+)
+
+// EmailRepository handles email delivery log database operations
+type EmailRepository struct {
+	db *gorm.DB
+}
+
+// NewEmailRepository creates a new email repository
+func NewEmailRepository(db *gorm.DB) *EmailRepository {
+	return &EmailRepository{db: db}
+}
+
+// CreateEmailDelivery records a new delivery
+func (er *EmailRepository) CreateEmailDelivery(ctx context.Context, delivery *models.EmailDelivery) error {
+	return er.db.WithContext(ctx).Create(delivery).Error
+}
+
+// UpdateEmailDelivery saves a delivery's status
+func (er *EmailRepository) UpdateEmailDelivery(ctx context.Context, delivery *models.EmailDelivery) error {
+	return er.db.WithContext(ctx).Save(delivery).Error
+}
+
+// FindEmailDeliveryByReference returns the latest delivery of a template for a reference, or nil if none exists
+func (er *EmailRepository) FindEmailDeliveryByReference(ctx context.Context, template, reference string) (*models.EmailDelivery, error) {
+	var delivery models.EmailDelivery
+	err := er.db.WithContext(ctx).
+		Where("template = ? AND reference = ?", template, reference).
+		Order("id DESC").
+		First(&delivery).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ListEmailDeliveries returns deliveries to a recipient, newest first
+func (er *EmailRepository) ListEmailDeliveries(ctx context.Context, recipient string, offset, limit int) ([]models.EmailDelivery, error) {
+	var deliveries []models.EmailDelivery
+	err := er.db.WithContext(ctx).
+		Where("recipient = ?", recipient).
+		Order("created_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// MarkEmailBounced records a bounce or complaint for the delivery with the given Message-ID.
+// It returns false if no delivery matches.
+func (er *EmailRepository) MarkEmailBounced(ctx context.Context, messageID, status, reason string, at time.Time) (bool, error) {
+	result := er.db.WithContext(ctx).
+		Model(&models.EmailDelivery{}).
+		Where("message_id = ?", messageID).
+		Updates(map[string]interface{}{
+			"status":     status,
+			"error":      reason,
+			"bounced_at": at,
+			"updated_at": at,
+		})
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go-server/internal/database/models"
+	"gorm.io/gorm"
+)
+
+// ExternalIdentityRepository handles links between external identity
+// provider subjects and local users
+type ExternalIdentityRepository struct {
+	db *gorm.DB
+}
+
+// NewExternalIdentityRepository creates a new external identity repository
+func NewExternalIdentityRepository(db *gorm.DB) *ExternalIdentityRepository {
+	return &ExternalIdentityRepository{db: db}
+}
+
+// GetBySubject retrieves the link for a subject at an issuer
+func (er *ExternalIdentityRepository) GetBySubject(ctx context.Context, issuer, subject string) (*models.ExternalIdentity, error) {
+	var identity models.ExternalIdentity
+	err := er.db.WithContext(ctx).Where("issuer = ? AND subject = ?", issuer, subject).First(&identity).Error
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// Create links a subject to a user
+func (er *ExternalIdentityRepository) Create(ctx context.Context, identity *models.ExternalIdentity) error {
+	return er.db.WithContext(ctx).Create(identity).Error
+}
+
+// CreateWithUser creates a user provisioned from an external identity,
+// links the identity to it and records a user.registered event in one
+// transaction
+func (er *ExternalIdentityRepository) CreateWithUser(ctx context.Context, user *models.User, identity *models.ExternalIdentity) error {
+	return er.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		identity.UserID = user.ID
+		if err := tx.Create(identity).Error; err != nil {
+			return err
+		}
+
+		event, err := models.NewOutboxEvent(models.EventUserRegistered, "user", user.ID, map[string]interface{}{
+			"id":        user.ID,
+			"public_id": user.PublicID,
+			"email":     user.Email,
+			"username":  user.Username,
+			"issuer":    identity.Issuer,
+		})
+		if err != nil {
+			return err
+		}
+		return appendOutboxEvent(tx, event)
+	})
+}
+
+// Touch records that the identity signed in at
+func (er *ExternalIdentityRepository) Touch(ctx context.Context, id uint, at time.Time) error {
+	return er.db.WithContext(ctx).Model(&models.ExternalIdentity{}).Where("id = ?", id).
+		Update("last_seen_at", at).Error
+}
+
+// ListForUser returns the external identities linked to a user
+func (er *ExternalIdentityRepository) ListForUser(ctx context.Context, userID uint) ([]models.ExternalIdentity, error) {
+	var identities []models.ExternalIdentity
+	err := er.db.WithContext(ctx).Where("user_id = ?", userID).Order("id").Find(&identities).Error
+	return identities, err
+}
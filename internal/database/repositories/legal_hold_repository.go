@@ -0,0 +1,163 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"go-server/internal/database/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LegalHoldRepository handles legal holds and their audit log. The log is
+// append-only: the repository never updates or deletes its records, and
+// migration 027 makes Postgres refuse to.
+type LegalHoldRepository struct {
+	db *gorm.DB
+}
+
+// NewLegalHoldRepository creates a new legal hold repository
+func NewLegalHoldRepository(db *gorm.DB) *LegalHoldRepository {
+	return &LegalHoldRepository{db: db}
+}
+
+// PlaceHold creates a hold and logs it in the same transaction
+func (lr *LegalHoldRepository) PlaceHold(ctx context.Context, hold *models.LegalHold) error {
+	return lr.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(hold).Error; err != nil {
+			return err
+		}
+		return logHold(tx, hold, models.LegalHoldEventPlaced, hold.PlacedByID, hold.CreatedAt)
+	})
+}
+
+// ReleaseHold releases an active hold and logs it in the same
+// transaction, returning gorm.ErrRecordNotFound if there is no such
+// active hold
+func (lr *LegalHoldRepository) ReleaseHold(ctx context.Context, id, releasedByID uint, at time.Time) (*models.LegalHold, error) {
+	var hold models.LegalHold
+	err := lr.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.LegalHold{}).
+			Where("id = ? AND released_at IS NULL", id).
+			Updates(map[string]interface{}{"released_at": at, "released_by_id": releasedByID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.First(&hold, id).Error; err != nil {
+			return err
+		}
+		return logHold(tx, &hold, models.LegalHoldEventReleased, releasedByID, at)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
+
+// logHold appends a record of a hold being placed or released
+func logHold(tx *gorm.DB, hold *models.LegalHold, eventType string, actorID uint, at time.Time) error {
+	payload, err := json.Marshal(hold)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&models.LegalHoldEvent{
+		SubjectType: hold.SubjectType,
+		SubjectID:   hold.SubjectID,
+		EventType:   eventType,
+		HoldID:      &hold.ID,
+		ActorID:     &actorID,
+		Payload:     string(payload),
+		OccurredAt:  at,
+	}).Error
+}
+
+// GetHold retrieves a hold by ID
+func (lr *LegalHoldRepository) GetHold(ctx context.Context, id uint) (*models.LegalHold, error) {
+	var hold models.LegalHold
+	if err := lr.db.WithContext(ctx).First(&hold, id).Error; err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
+
+// ListHolds retrieves holds, newest first, optionally only active ones
+func (lr *LegalHoldRepository) ListHolds(ctx context.Context, activeOnly bool, offset, limit int) ([]models.LegalHold, error) {
+	var holds []models.LegalHold
+	query := lr.db.WithContext(ctx)
+	if activeOnly {
+		query = query.Where("released_at IS NULL")
+	}
+	err := query.
+		Order("created_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&holds).Error
+	return holds, err
+}
+
+// IsHeld checks if a subject is under an active hold of its own
+func (lr *LegalHoldRepository) IsHeld(ctx context.Context, subjectType string, subjectID uint) (bool, error) {
+	var count int64
+	err := lr.db.WithContext(ctx).
+		Model(&models.LegalHold{}).
+		Where("subject_type = ? AND subject_id = ? AND released_at IS NULL", subjectType, subjectID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// SubjectExists checks if a hold's subject exists, including in the trash
+func (lr *LegalHoldRepository) SubjectExists(ctx context.Context, subjectType string, subjectID uint) (bool, error) {
+	var model interface{}
+	switch subjectType {
+	case models.LegalHoldSubjectUser:
+		model = &models.User{}
+	case models.LegalHoldSubjectPost:
+		model = &models.Post{}
+	default:
+		return false, nil
+	}
+	var count int64
+	err := lr.db.WithContext(ctx).Unscoped().Model(model).Where("id = ?", subjectID).Count(&count).Error
+	return count > 0, err
+}
+
+// AppendEvent adds a record to the audit log. A record copying an outbox
+// event already logged is skipped, so redeliveries are harmless.
+func (lr *LegalHoldRepository) AppendEvent(ctx context.Context, event *models.LegalHoldEvent) error {
+	return lr.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(event).Error
+}
+
+// ListEvents retrieves the audit log of a subject, oldest first
+func (lr *LegalHoldRepository) ListEvents(ctx context.Context, subjectType string, subjectID uint, offset, limit int) ([]models.LegalHoldEvent, error) {
+	var events []models.LegalHoldEvent
+	err := lr.db.WithContext(ctx).
+		Where("subject_type = ? AND subject_id = ?", subjectType, subjectID).
+		Order("id ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
+
+// notHeldUsers leaves out users under an active hold, or who wrote a held
+// post that deleting them would cascade to
+func notHeldUsers(db *gorm.DB) *gorm.DB {
+	return db.Where(`NOT EXISTS (SELECT 1 FROM legal_holds WHERE legal_holds.released_at IS NULL AND (
+		(legal_holds.subject_type = ? AND legal_holds.subject_id = users.id) OR
+		(legal_holds.subject_type = ? AND legal_holds.subject_id IN (SELECT posts.id FROM posts WHERE posts.author_id = users.id))))`,
+		models.LegalHoldSubjectUser, models.LegalHoldSubjectPost)
+}
+
+// notHeldPosts leaves out posts under an active hold, directly or through
+// their author
+func notHeldPosts(db *gorm.DB) *gorm.DB {
+	return db.Where(`NOT EXISTS (SELECT 1 FROM legal_holds WHERE legal_holds.released_at IS NULL AND (
+		(legal_holds.subject_type = ? AND legal_holds.subject_id = posts.id) OR
+		(legal_holds.subject_type = ? AND legal_holds.subject_id = posts.author_id)))`,
+		models.LegalHoldSubjectPost, models.LegalHoldSubjectUser)
+}
@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go-server/internal/database/models"
+	"gorm.io/gorm"
+)
+
+// MaintenanceRepository handles maintenance window database operations
+type MaintenanceRepository struct {
+	db *gorm.DB
+}
+
+// NewMaintenanceRepository creates a new maintenance window repository
+func NewMaintenanceRepository(db *gorm.DB) *MaintenanceRepository {
+	return &MaintenanceRepository{db: db}
+}
+
+// CreateWindow creates a new maintenance window
+func (mr *MaintenanceRepository) CreateWindow(ctx context.Context, window *models.MaintenanceWindow) error {
+	return mr.db.WithContext(ctx).Create(window).Error
+}
+
+// ListWindows retrieves the windows overlapping from to to, earliest
+// first; a zero to has no upper bound
+func (mr *MaintenanceRepository) ListWindows(ctx context.Context, from, to time.Time) ([]models.MaintenanceWindow, error) {
+	var windows []models.MaintenanceWindow
+	query := mr.db.WithContext(ctx).Where("ends_at > ?", from)
+	if !to.IsZero() {
+		query = query.Where("starts_at < ?", to)
+	}
+	err := query.Order("starts_at ASC").Find(&windows).Error
+	return windows, err
+}
+
+// DeleteWindow soft deletes a maintenance window, returning
+// gorm.ErrRecordNotFound if it does not exist
+func (mr *MaintenanceRepository) DeleteWindow(ctx context.Context, id uint) error {
+	result := mr.db.WithContext(ctx).Delete(&models.MaintenanceWindow{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -15,12 +15,35 @@ type RepositoryManager struct {
 	PostgresPool *pgxpool.Pool
 	GormDB       *gorm.DB
 	RedisClient  *redis.Client
+	// Dialect is GormDB's SQL dialect; PostgresPool is nil unless it is
+	// DialectPostgres
+	Dialect string
+
+	// Tx runs calls to several repositories in one transaction
+	Tx *TxManager
 
 	// Repositories
-	User    *UserRepository
-	Post    *PostRepository
-	Session *SessionRepository
-	Cache   *CacheRepository
+	User           *UserRepository
+	Post           *PostRepository
+	Session        *SessionRepository
+	Cache          *CacheRepository
+	Outbox         *OutboxRepository
+	Webhook        *WebhookRepository
+	Attachment     *AttachmentRepository
+	Email          *EmailRepository
+	Notification   *NotificationRepository
+	Organization   *OrganizationRepository
+	ServiceAccount *ServiceAccountRepository
+	Approval       *ApprovalRepository
+	BreakGlass     *BreakGlassRepository
+	Runbook        *RunbookRepository
+	Identity       *ExternalIdentityRepository
+	Comment        *CommentRepository
+	Maintenance    *MaintenanceRepository
+	Announcement   *AnnouncementRepository
+	Usage          *UsageRepository
+	Abuse          *AbuseRepository
+	Report         *ReportRepository
 }
 
 // NewRepositoryManager creates a new repository manager
@@ -33,13 +56,32 @@ func NewRepositoryManager(
 		PostgresPool: postgresPool,
 		GormDB:       gormDB,
 		RedisClient:  redisClient,
+		Dialect:      DialectOf(gormDB),
 	}
 
 	// Initialize repositories
+	rm.Tx = NewTxManager(gormDB)
 	rm.User = NewUserRepository(gormDB)
 	rm.Post = NewPostRepository(gormDB)
 	rm.Session = NewSessionRepository(gormDB)
 	rm.Cache = NewCacheRepository(redisClient)
+	rm.Outbox = NewOutboxRepository(gormDB)
+	rm.Webhook = NewWebhookRepository(gormDB)
+	rm.Attachment = NewAttachmentRepository(gormDB)
+	rm.Email = NewEmailRepository(gormDB)
+	rm.Notification = NewNotificationRepository(gormDB)
+	rm.Organization = NewOrganizationRepository(gormDB)
+	rm.ServiceAccount = NewServiceAccountRepository(gormDB)
+	rm.Approval = NewApprovalRepository(gormDB)
+	rm.BreakGlass = NewBreakGlassRepository(gormDB)
+	rm.Runbook = NewRunbookRepository(gormDB)
+	rm.Identity = NewExternalIdentityRepository(gormDB)
+	rm.Comment = NewCommentRepository(gormDB)
+	rm.Maintenance = NewMaintenanceRepository(gormDB)
+	rm.Announcement = NewAnnouncementRepository(gormDB)
+	rm.Usage = NewUsageRepository(gormDB)
+	rm.Abuse = NewAbuseRepository(gormDB)
+	rm.Report = NewReportRepository(gormDB)
 
 	return rm
 }
@@ -49,7 +91,9 @@ func (rm *RepositoryManager) HealthCheck(ctx context.Context) map[string]string
 	health := make(map[string]string)
 
 	// Check PostgreSQL connection
-	if rm.PostgresPool != nil {
+	if rm.Dialect != "" && rm.Dialect != DialectPostgres {
+		health["postgres"] = "not used (" + rm.Dialect + ")"
+	} else if rm.PostgresPool != nil {
 		if err := rm.PostgresPool.Ping(ctx); err != nil {
 			health["postgres"] = "unhealthy: " + err.Error()
 		} else {
@@ -78,6 +122,9 @@ func (rm *RepositoryManager) HealthCheck(ctx context.Context) map[string]string
 	if rm.RedisClient != nil {
 		if err := rm.RedisClient.Ping(ctx).Err(); err != nil {
 			health["redis"] = "unhealthy: " + err.Error()
+			if rm.Cache.Degraded() {
+				health["redis"] = "degraded: " + err.Error()
+			}
 		} else {
 			health["redis"] = "healthy"
 		}
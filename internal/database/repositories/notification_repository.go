@@ -0,0 +1,155 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go-server/internal/database/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NotificationRepository handles device token and notification delivery database operations
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+// NewNotificationRepository creates a new notification repository
+func NewNotificationRepository(db *gorm.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// UpsertDeviceToken registers a device token, moving it to the given user if
+// another account registered it before (devices change hands on sign-in)
+func (nr *NotificationRepository) UpsertDeviceToken(ctx context.Context, token *models.DeviceToken) error {
+	return nr.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "token"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_id", "platform", "last_seen_at", "updated_at"}),
+	}).Create(token).Error
+}
+
+// ListDeviceTokens returns a user's device tokens, most recently seen first
+func (nr *NotificationRepository) ListDeviceTokens(ctx context.Context, userID uint) ([]models.DeviceToken, error) {
+	var tokens []models.DeviceToken
+	err := nr.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("last_seen_at DESC").
+		Find(&tokens).Error
+	return tokens, err
+}
+
+// DeleteDeviceToken removes one of a user's tokens; it returns false if the user has no such token
+func (nr *NotificationRepository) DeleteDeviceToken(ctx context.Context, userID uint, token string) (bool, error) {
+	result := nr.db.WithContext(ctx).
+		Where("user_id = ? AND token = ?", userID, token).
+		Delete(&models.DeviceToken{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeleteDeviceTokenByValue removes a token the push provider reported as invalid
+func (nr *NotificationRepository) DeleteDeviceTokenByValue(ctx context.Context, token string) error {
+	return nr.db.WithContext(ctx).Where("token = ?", token).Delete(&models.DeviceToken{}).Error
+}
+
+// CreateNotificationDelivery records a new delivery
+func (nr *NotificationRepository) CreateNotificationDelivery(ctx context.Context, delivery *models.NotificationDelivery) error {
+	return nr.db.WithContext(ctx).Create(delivery).Error
+}
+
+// UpdateNotificationDelivery saves a delivery's status
+func (nr *NotificationRepository) UpdateNotificationDelivery(ctx context.Context, delivery *models.NotificationDelivery) error {
+	return nr.db.WithContext(ctx).Save(delivery).Error
+}
+
+// GetNotificationDelivery retrieves a delivery by ID
+func (nr *NotificationRepository) GetNotificationDelivery(ctx context.Context, id uint) (*models.NotificationDelivery, error) {
+	var delivery models.NotificationDelivery
+	if err := nr.db.WithContext(ctx).First(&delivery, id).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// FindNotificationDeliveryByProviderID returns the delivery a provider callback refers to, or nil if none matches
+func (nr *NotificationRepository) FindNotificationDeliveryByProviderID(ctx context.Context, provider, messageID string) (*models.NotificationDelivery, error) {
+	var delivery models.NotificationDelivery
+	err := nr.db.WithContext(ctx).
+		Where("provider = ? AND provider_message_id = ?", provider, messageID).
+		First(&delivery).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ListNotificationDeliveries returns a user's deliveries, newest first
+func (nr *NotificationRepository) ListNotificationDeliveries(ctx context.Context, userID uint, offset, limit int) ([]models.NotificationDelivery, error) {
+	var deliveries []models.NotificationDelivery
+	err := nr.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// GetNotificationPreference returns a user's digest settings, or nil if the user has none
+func (nr *NotificationRepository) GetNotificationPreference(ctx context.Context, userID uint) (*models.NotificationPreference, error) {
+	var preference models.NotificationPreference
+	err := nr.db.WithContext(ctx).Where("user_id = ?", userID).First(&preference).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &preference, nil
+}
+
+// SaveNotificationPreference creates or updates a user's digest settings
+func (nr *NotificationRepository) SaveNotificationPreference(ctx context.Context, preference *models.NotificationPreference) error {
+	return nr.db.WithContext(ctx).Save(preference).Error
+}
+
+// CreateDigestItem holds a notification for the user's next digest
+func (nr *NotificationRepository) CreateDigestItem(ctx context.Context, item *models.NotificationDigestItem) error {
+	return nr.db.WithContext(ctx).Create(item).Error
+}
+
+// ListPendingDigestUsers returns the users with notifications waiting for a digest
+func (nr *NotificationRepository) ListPendingDigestUsers(ctx context.Context) ([]uint, error) {
+	var userIDs []uint
+	err := nr.db.WithContext(ctx).
+		Model(&models.NotificationDigestItem{}).
+		Where("digested_at IS NULL").
+		Distinct("user_id").
+		Order("user_id").
+		Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
+
+// ListPendingDigestItems returns up to limit of a user's undigested notifications, oldest first
+func (nr *NotificationRepository) ListPendingDigestItems(ctx context.Context, userID uint, limit int) ([]models.NotificationDigestItem, error) {
+	var items []models.NotificationDigestItem
+	err := nr.db.WithContext(ctx).
+		Where("user_id = ? AND digested_at IS NULL", userID).
+		Order("created_at ASC, id ASC").
+		Limit(limit).
+		Find(&items).Error
+	return items, err
+}
+
+// MarkDigestItemsSent stamps the given items as included in a digest
+func (nr *NotificationRepository) MarkDigestItemsSent(ctx context.Context, ids []uint, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return nr.db.WithContext(ctx).
+		Model(&models.NotificationDigestItem{}).
+		Where("id IN ?", ids).
+		Update("digested_at", at).Error
+}
@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"context"
+
+	"go-server/internal/database/models"
+	"gorm.io/gorm"
+)
+
+// OrganizationRepository handles organization and membership database operations
+type OrganizationRepository struct {
+	db *gorm.DB
+}
+
+// NewOrganizationRepository creates a new organization repository
+func NewOrganizationRepository(db *gorm.DB) *OrganizationRepository {
+	return &OrganizationRepository{db: db}
+}
+
+// CreateOrganization creates an organization with its owner as the first member
+func (or *OrganizationRepository) CreateOrganization(ctx context.Context, organization *models.Organization) error {
+	return or.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(organization).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.OrganizationMember{
+			OrganizationID: organization.ID,
+			UserID:         organization.OwnerID,
+			Role:           models.OrgRoleOwner,
+		}).Error
+	})
+}
+
+// GetOrganizationByID retrieves an organization by ID
+func (or *OrganizationRepository) GetOrganizationByID(ctx context.Context, id uint) (*models.Organization, error) {
+	var organization models.Organization
+	err := or.db.WithContext(ctx).First(&organization, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &organization, nil
+}
+
+// ListOrganizationsForUser retrieves the organizations a user is a member of
+func (or *OrganizationRepository) ListOrganizationsForUser(ctx context.Context, userID uint) ([]models.Organization, error) {
+	var organizations []models.Organization
+	err := or.db.WithContext(ctx).
+		Joins("JOIN organization_members ON organization_members.organization_id = organizations.id").
+		Where("organization_members.user_id = ?", userID).
+		Order("organizations.name").
+		Find(&organizations).Error
+	return organizations, err
+}
+
+// GetMember retrieves a user's membership of an organization, or nil if they are not a member
+func (or *OrganizationRepository) GetMember(ctx context.Context, organizationID, userID uint) (*models.OrganizationMember, error) {
+	var member models.OrganizationMember
+	err := or.db.WithContext(ctx).
+		Where("organization_id = ? AND user_id = ?", organizationID, userID).
+		First(&member).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// SaveMember adds a member or changes their role
+func (or *OrganizationRepository) SaveMember(ctx context.Context, member *models.OrganizationMember) error {
+	return or.db.WithContext(ctx).Save(member).Error
+}
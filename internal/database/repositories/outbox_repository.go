@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go-server/internal/database/models"
+	"go-server/internal/eventschema"
+	"gorm.io/gorm"
+)
+
+// OutboxRepository handles outbox event database operations
+type OutboxRepository struct {
+	db *gorm.DB
+}
+
+// NewOutboxRepository creates a new outbox repository
+func NewOutboxRepository(db *gorm.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// Append records an event outside of any domain change
+func (or *OutboxRepository) Append(ctx context.Context, event *models.OutboxEvent) error {
+	return appendOutboxEvent(or.db.WithContext(ctx), event)
+}
+
+// ListPending retrieves unpublished events that are due for delivery, oldest first
+func (or *OutboxRepository) ListPending(ctx context.Context, now time.Time, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	err := or.db.WithContext(ctx).
+		Where("published_at IS NULL AND available_at <= ?", now).
+		Order("id ASC").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
+
+// CountPending counts events that have not been published yet
+func (or *OutboxRepository) CountPending(ctx context.Context) (int64, error) {
+	var count int64
+	err := or.db.WithContext(ctx).
+		Model(&models.OutboxEvent{}).
+		Where("published_at IS NULL").
+		Count(&count).Error
+	return count, err
+}
+
+// MarkPublished records a successful delivery
+func (or *OutboxRepository) MarkPublished(ctx context.Context, id uint, at time.Time) error {
+	return or.db.WithContext(ctx).
+		Model(&models.OutboxEvent{}).
+		Where("id = ?", id).
+		Update("published_at", at).Error
+}
+
+// MarkFailed records a failed delivery and defers the next attempt until retryAt
+func (or *OutboxRepository) MarkFailed(ctx context.Context, id uint, lastError string, retryAt time.Time) error {
+	return or.db.WithContext(ctx).
+		Model(&models.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"attempts":     gorm.Expr("attempts + 1"),
+			"last_error":   lastError,
+			"available_at": retryAt,
+		}).Error
+}
+
+// PurgePublished deletes events that were published before the cutoff
+func (or *OutboxRepository) PurgePublished(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := or.db.WithContext(ctx).
+		Where("published_at IS NOT NULL AND published_at < ?", cutoff).
+		Delete(&models.OutboxEvent{})
+	return result.RowsAffected, result.Error
+}
+
+// appendOutboxEvent validates an event's payload against its schema and
+// inserts it using the given handle, which may be a transaction. A payload
+// that does not match fails the write, so consumers never see one.
+func appendOutboxEvent(tx *gorm.DB, event *models.OutboxEvent) error {
+	version, err := eventschema.Default().Validate(event.EventType, []byte(event.Payload))
+	if err != nil {
+		return err
+	}
+	event.SchemaVersion = version
+	return tx.Create(event).Error
+}
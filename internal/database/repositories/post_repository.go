@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"go-server/internal/database/models"
 	"gorm.io/gorm"
@@ -9,91 +10,153 @@ import (
 
 // PostRepository handles post-related database operations
 type PostRepository struct {
-	db *gorm.DB
+	db    *gorm.DB
+	posts *BaseRepository[models.Post]
 }
 
-// NewPostRepository creates a new post repository
+// NewPostRepository creates a new post repository; posts are read with
+// their authors
 func NewPostRepository(db *gorm.DB) *PostRepository {
-	return &PostRepository{db: db}
+	return &PostRepository{
+		db:    db,
+		posts: NewBaseRepository[models.Post](db).WithPreloads("Author"),
+	}
+}
+
+// publishedPosts selects the published posts of active authors, as
+// readers see them
+func publishedPosts(db *gorm.DB) *gorm.DB {
+	return activeAuthors(db, "author_id").Where("status = ? AND published_at IS NOT NULL", "published")
 }
 
 // CreatePost creates a new post
 func (pr *PostRepository) CreatePost(ctx context.Context, post *models.Post) error {
-	return pr.db.WithContext(ctx).Create(post).Error
+	return pr.posts.Create(ctx, post)
 }
 
 // GetPostByID retrieves a post by ID
 func (pr *PostRepository) GetPostByID(ctx context.Context, id uint) (*models.Post, error) {
+	return pr.posts.GetByID(ctx, id)
+}
+
+// GetPostByPublicID retrieves a post by public identifier
+func (pr *PostRepository) GetPostByPublicID(ctx context.Context, publicID string) (*models.Post, error) {
+	return pr.posts.FindOne(ctx, Where("public_id = ?", publicID))
+}
+
+// FindPostIDByPublicID resolves a public identifier to the internal key, optionally including soft-deleted posts
+func (pr *PostRepository) FindPostIDByPublicID(ctx context.Context, publicID string, includeDeleted bool) (uint, error) {
 	var post models.Post
-	err := pr.db.WithContext(ctx).
-		Preload("Author").
-		Preload("Categories").
-		First(&post, id).Error
+	query := pr.db.WithContext(ctx)
+	if includeDeleted {
+		query = query.Unscoped()
+	}
+	err := query.Select("id").Where("public_id = ?", publicID).First(&post).Error
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	return &post, nil
+	return post.ID, nil
 }
 
 // GetPostBySlug retrieves a post by slug
 func (pr *PostRepository) GetPostBySlug(ctx context.Context, slug string) (*models.Post, error) {
-	var post models.Post
-	err := pr.db.WithContext(ctx).
-		Preload("Author").
-		Preload("Categories").
-		Where("slug = ?", slug).
-		First(&post).Error
-	if err != nil {
-		return nil, err
-	}
-	return &post, nil
+	return pr.posts.FindOne(ctx, Where("slug = ?", slug))
 }
 
-// UpdatePost updates a post
+// UpdatePost updates a post and records a post.updated event in the same
+// transaction. It fails with ErrVersionConflict if the post changed since
+// post was read.
 func (pr *PostRepository) UpdatePost(ctx context.Context, post *models.Post) error {
-	return pr.db.WithContext(ctx).Save(post).Error
+	return pr.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := saveVersioned(tx, post, &post.Version); err != nil {
+			return err
+		}
+		return appendPostEvent(tx, models.EventPostUpdated, post)
+	})
 }
 
-// DeletePost soft deletes a post
+// PublishPost marks a post as published and records a post.published event in the same
+// transaction. Publishing an already published post is a no-op. It fails with
+// ErrVersionConflict if the post changed since post was read.
+func (pr *PostRepository) PublishPost(ctx context.Context, post *models.Post) error {
+	if post.IsPublished() {
+		return nil
+	}
+	previousStatus, previousPublishedAt := post.Status, post.PublishedAt
+	post.Publish()
+
+	err := pr.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := saveVersioned(tx, post, &post.Version); err != nil {
+			return err
+		}
+
+		return appendPostEvent(tx, models.EventPostPublished, post)
+	})
+	if err != nil {
+		post.Status, post.PublishedAt = previousStatus, previousPublishedAt
+	}
+	return err
+}
+
+// DeletePost soft deletes a post and records a post.deleted event in the same transaction
 func (pr *PostRepository) DeletePost(ctx context.Context, id uint) error {
-	return pr.db.WithContext(ctx).Delete(&models.Post{}, id).Error
+	return pr.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var post models.Post
+		if err := tx.First(&post, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&post).Error; err != nil {
+			return err
+		}
+		return appendPostEvent(tx, models.EventPostDeleted, &post)
+	})
 }
 
 // ListPosts retrieves posts with pagination
 func (pr *PostRepository) ListPosts(ctx context.Context, offset, limit int) ([]models.Post, error) {
-	var posts []models.Post
-	err := pr.db.WithContext(ctx).
-		Preload("Author").
-		Preload("Categories").
-		Offset(offset).
-		Limit(limit).
-		Find(&posts).Error
-	return posts, err
+	return pr.posts.List(ctx, offset, limit)
 }
 
-// ListPublishedPosts retrieves published posts with pagination
+// ListPublishedPosts retrieves published posts with pagination, leaving out
+// those of deactivated authors
 func (pr *PostRepository) ListPublishedPosts(ctx context.Context, offset, limit int) ([]models.Post, error) {
-	var posts []models.Post
-	err := pr.db.WithContext(ctx).
-		Preload("Author").
-		Preload("Categories").
-		Where("status = ? AND published_at IS NOT NULL", "published").
-		Order("published_at DESC").
-		Offset(offset).
-		Limit(limit).
-		Find(&posts).Error
-	return posts, err
+	return pr.posts.List(ctx, offset, limit, publishedPosts, OrderBy("published_at DESC"))
+}
+
+// ListTopPosts retrieves the most viewed published posts of active authors
+func (pr *PostRepository) ListTopPosts(ctx context.Context, limit int) ([]models.Post, error) {
+	return pr.posts.List(ctx, 0, limit, publishedPosts, OrderBy("view_count DESC, published_at DESC"))
 }
 
 // ListPostsByAuthor retrieves posts by author
 func (pr *PostRepository) ListPostsByAuthor(ctx context.Context, authorID uint, offset, limit int) ([]models.Post, error) {
+	return pr.posts.List(ctx, offset, limit, Where("author_id = ?", authorID))
+}
+
+// ListScheduledPosts retrieves drafts scheduled for publication between
+// from and to, soonest first
+func (pr *PostRepository) ListScheduledPosts(ctx context.Context, from, to time.Time) ([]models.Post, error) {
+	return pr.posts.Find(ctx,
+		Where("status = ? AND scheduled_at >= ? AND scheduled_at < ?", "draft", from, to),
+		OrderBy("scheduled_at ASC"))
+}
+
+// ListPublishedPostsByAuthors retrieves the latest published posts of each
+// author in one query, at most perAuthor posts per author. Deactivated
+// authors have none.
+func (pr *PostRepository) ListPublishedPostsByAuthors(ctx context.Context, authorIDs []uint, perAuthor int) ([]models.Post, error) {
 	var posts []models.Post
+	if len(authorIDs) == 0 {
+		return posts, nil
+	}
+	ranked := activeAuthors(pr.db.WithContext(ctx), "author_id").
+		Model(&models.Post{}).
+		Select("posts.*, ROW_NUMBER() OVER (PARTITION BY author_id ORDER BY published_at DESC, id DESC) AS author_rank").
+		Where("author_id IN ? AND status = ? AND published_at IS NOT NULL", authorIDs, "published")
 	err := pr.db.WithContext(ctx).
-		Preload("Author").
-		Preload("Categories").
-		Where("author_id = ?", authorID).
-		Offset(offset).
-		Limit(limit).
+		Table("(?) AS ranked", ranked).
+		Where("author_rank <= ?", perAuthor).
+		Order("author_id, author_rank").
 		Find(&posts).Error
 	return posts, err
 }
@@ -108,17 +171,65 @@ func (pr *PostRepository) IncrementViewCount(ctx context.Context, id uint) error
 
 // CountPosts returns the total number of posts
 func (pr *PostRepository) CountPosts(ctx context.Context) (int64, error) {
-	var count int64
-	err := pr.db.WithContext(ctx).Model(&models.Post{}).Count(&count).Error
-	return count, err
+	return pr.posts.Count(ctx)
 }
 
-// CountPublishedPosts returns the total number of published posts
+// CountPublishedPosts returns the number of published posts that
+// ListPublishedPosts lists
 func (pr *PostRepository) CountPublishedPosts(ctx context.Context) (int64, error) {
-	var count int64
-	err := pr.db.WithContext(ctx).
-		Model(&models.Post{}).
-		Where("status = ? AND published_at IS NOT NULL", "published").
-		Count(&count).Error
-	return count, err
+	return pr.posts.Count(ctx, publishedPosts)
+}
+
+// ListDeletedPosts retrieves soft-deleted posts with pagination
+func (pr *PostRepository) ListDeletedPosts(ctx context.Context, offset, limit int) ([]models.Post, error) {
+	return pr.posts.ListDeleted(ctx, offset, limit)
+}
+
+// CountDeletedPosts returns the number of soft-deleted posts
+func (pr *PostRepository) CountDeletedPosts(ctx context.Context) (int64, error) {
+	return pr.posts.CountDeleted(ctx)
+}
+
+// RestorePost clears the soft-delete marker on a post and records a
+// post.updated event in the same transaction, so subscribers that acted on
+// its post.deleted event serve it again
+func (pr *PostRepository) RestorePost(ctx context.Context, id uint) error {
+	return pr.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := pr.posts.WithTx(tx).Restore(ctx, id); err != nil {
+			return err
+		}
+		var post models.Post
+		if err := tx.First(&post, id).Error; err != nil {
+			return err
+		}
+		return appendPostEvent(tx, models.EventPostUpdated, &post)
+	})
+}
+
+// PurgeDeletedPosts permanently removes posts soft-deleted before the
+// cutoff. Posts under legal hold stay in the trash.
+func (pr *PostRepository) PurgeDeletedPosts(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := notHeldPosts(pr.db.WithContext(ctx)).
+		Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Delete(&models.Post{})
+	return result.RowsAffected, result.Error
+}
+
+// appendPostEvent records a post lifecycle event carrying the fields subscribers need
+// to locate the post's public URLs
+func appendPostEvent(tx *gorm.DB, eventType string, post *models.Post) error {
+	event, err := models.NewOutboxEvent(eventType, "post", post.ID, models.PostEventPayload{
+		ID:          post.ID,
+		PublicID:    post.PublicID,
+		Slug:        post.Slug,
+		Title:       post.Title,
+		AuthorID:    post.AuthorID,
+		Status:      post.Status,
+		PublishedAt: post.PublishedAt,
+	})
+	if err != nil {
+		return err
+	}
+	return appendOutboxEvent(tx, event)
 }
@@ -0,0 +1,208 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go-server/internal/database/models"
+	"gorm.io/gorm"
+)
+
+// ReportRepository handles report requests and the queries reports are
+// built from
+type ReportRepository struct {
+	db      *gorm.DB
+	reports *BaseRepository[models.Report]
+}
+
+// NewReportRepository creates a new report repository
+func NewReportRepository(db *gorm.DB) *ReportRepository {
+	return &ReportRepository{db: db, reports: NewBaseRepository[models.Report](db)}
+}
+
+// NamedCount is a count of the records sharing a name, e.g. posts by status
+type NamedCount struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+// CreateReport stores a new report request
+func (rr *ReportRepository) CreateReport(ctx context.Context, report *models.Report) error {
+	return rr.reports.Create(ctx, report)
+}
+
+// GetReportByID retrieves a report by ID
+func (rr *ReportRepository) GetReportByID(ctx context.Context, id uint) (*models.Report, error) {
+	return rr.reports.GetByID(ctx, id)
+}
+
+// ListReports retrieves reports, newest first
+func (rr *ReportRepository) ListReports(ctx context.Context, offset, limit int) ([]models.Report, error) {
+	return rr.reports.List(ctx, offset, limit, OrderBy("created_at DESC, id DESC"))
+}
+
+// CountReports returns the number of reports
+func (rr *ReportRepository) CountReports(ctx context.Context) (int64, error) {
+	return rr.reports.Count(ctx)
+}
+
+// StartReport marks a report running and counts the attempt
+func (rr *ReportRepository) StartReport(ctx context.Context, id uint, at time.Time) error {
+	return rr.db.WithContext(ctx).Model(&models.Report{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":     models.ReportStatusRunning,
+		"attempts":   gorm.Expr("attempts + 1"),
+		"started_at": at,
+	}).Error
+}
+
+// CompleteReport records where a rendered report is stored and when it
+// is to be deleted
+func (rr *ReportRepository) CompleteReport(ctx context.Context, id uint, objectKey string, size int64, at, expiresAt time.Time) error {
+	return rr.db.WithContext(ctx).Model(&models.Report{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       models.ReportStatusCompleted,
+		"object_key":   objectKey,
+		"size":         size,
+		"error":        "",
+		"completed_at": at,
+		"expires_at":   expiresAt,
+	}).Error
+}
+
+// FailReport records why an attempt failed. The report is failed if no
+// attempt is left, and pending a retry otherwise.
+func (rr *ReportRepository) FailReport(ctx context.Context, id uint, reason string, final bool) error {
+	status := models.ReportStatusPending
+	if final {
+		status = models.ReportStatusFailed
+	}
+	if len(reason) > 500 {
+		reason = reason[:500]
+	}
+	return rr.db.WithContext(ctx).Model(&models.Report{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status": status,
+		"error":  reason,
+	}).Error
+}
+
+// ListExpiredReports retrieves completed reports whose files are due for
+// deletion at now, oldest first
+func (rr *ReportRepository) ListExpiredReports(ctx context.Context, now time.Time, limit int) ([]models.Report, error) {
+	return rr.reports.Find(ctx,
+		Where("status = ? AND expires_at <= ?", models.ReportStatusCompleted, now),
+		OrderBy("expires_at ASC"),
+		Paginate(0, limit))
+}
+
+// ExpireReport records that a report's file was deleted
+func (rr *ReportRepository) ExpireReport(ctx context.Context, id uint) error {
+	return rr.db.WithContext(ctx).Model(&models.Report{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":     models.ReportStatusExpired,
+		"object_key": "",
+	}).Error
+}
+
+// SignupTimes returns when each account created in [from, to) was
+// created, deleted accounts included, oldest first
+func (rr *ReportRepository) SignupTimes(ctx context.Context, from, to time.Time) ([]time.Time, error) {
+	return rr.timesBetween(ctx, &models.User{}, "created_at", from, to)
+}
+
+// DeactivationTimes returns when each account deactivated in [from, to)
+// was deactivated, oldest first
+func (rr *ReportRepository) DeactivationTimes(ctx context.Context, from, to time.Time) ([]time.Time, error) {
+	return rr.timesBetween(ctx, &models.User{}, "deactivated_at", from, to)
+}
+
+// CountAccountsBefore returns how many accounts were created before t,
+// deleted accounts included
+func (rr *ReportRepository) CountAccountsBefore(ctx context.Context, t time.Time) (int64, error) {
+	var count int64
+	err := rr.db.WithContext(ctx).Unscoped().Model(&models.User{}).Where("created_at < ?", t).Count(&count).Error
+	return count, err
+}
+
+// CountCreatedBetween returns how many records of model were created in
+// [from, to), leaving out soft-deleted ones
+func (rr *ReportRepository) CountCreatedBetween(ctx context.Context, model interface{}, from, to time.Time) (int64, error) {
+	var count int64
+	err := rr.db.WithContext(ctx).Model(model).Where("created_at >= ? AND created_at < ?", from, to).Count(&count).Error
+	return count, err
+}
+
+// CountPublishedBetween returns how many posts were published in [from, to)
+func (rr *ReportRepository) CountPublishedBetween(ctx context.Context, from, to time.Time) (int64, error) {
+	var count int64
+	err := rr.db.WithContext(ctx).Model(&models.Post{}).
+		Where("status = ? AND published_at >= ? AND published_at < ?", "published", from, to).
+		Count(&count).Error
+	return count, err
+}
+
+// CountPostsByStatus returns the number of posts in each status
+func (rr *ReportRepository) CountPostsByStatus(ctx context.Context) ([]NamedCount, error) {
+	var counts []NamedCount
+	err := rr.db.WithContext(ctx).Model(&models.Post{}).
+		Select("status AS name, COUNT(*) AS count").
+		Group("status").
+		Order("status").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// TopAuthors returns the authors who published the most posts in
+// [from, to), most first
+func (rr *ReportRepository) TopAuthors(ctx context.Context, from, to time.Time, limit int) ([]NamedCount, error) {
+	var counts []NamedCount
+	err := rr.db.WithContext(ctx).Model(&models.Post{}).
+		Select("users.username AS name, COUNT(*) AS count").
+		Joins("JOIN users ON users.id = posts.author_id").
+		Where("posts.status = ? AND posts.published_at >= ? AND posts.published_at < ?", "published", from, to).
+		Group("users.username").
+		Order("count DESC, name").
+		Limit(limit).
+		Scan(&counts).Error
+	return counts, err
+}
+
+// CountAbuseBansByReason returns how many client IPs were banned in
+// [from, to) for each reason
+func (rr *ReportRepository) CountAbuseBansByReason(ctx context.Context, from, to time.Time) ([]NamedCount, error) {
+	var counts []NamedCount
+	err := rr.db.WithContext(ctx).Model(&models.AbuseBan{}).
+		Select("reason AS name, COUNT(*) AS count").
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Group("reason").
+		Order("count DESC, name").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// CountStaffByRole returns the number of staff accounts in each role.
+// Accounts with only the legacy is_admin flag count as admin, as
+// rbac.RoleOf has it.
+func (rr *ReportRepository) CountStaffByRole(ctx context.Context) ([]NamedCount, error) {
+	var counts []NamedCount
+	err := rr.db.WithContext(ctx).Model(&models.User{}).
+		Select("CASE WHEN role <> '' THEN role ELSE 'admin' END AS name, COUNT(*) AS count").
+		Where("role <> '' OR is_admin = ?", true).
+		Group("CASE WHEN role <> '' THEN role ELSE 'admin' END").
+		Order("name").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// CountActiveLegalHolds returns the number of legal holds not yet released
+func (rr *ReportRepository) CountActiveLegalHolds(ctx context.Context) (int64, error) {
+	var count int64
+	err := rr.db.WithContext(ctx).Model(&models.LegalHold{}).Where("released_at IS NULL").Count(&count).Error
+	return count, err
+}
+
+func (rr *ReportRepository) timesBetween(ctx context.Context, model interface{}, column string, from, to time.Time) ([]time.Time, error) {
+	var times []time.Time
+	err := rr.db.WithContext(ctx).Unscoped().Model(model).
+		Where(column+" >= ? AND "+column+" < ?", from, to).
+		Order(column).
+		Pluck(column, &times).Error
+	return times, err
+}
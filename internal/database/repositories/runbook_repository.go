@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"context"
+
+	"go-server/internal/database/models"
+	"gorm.io/gorm"
+)
+
+// RunbookRepository handles runbook run audit records
+type RunbookRepository struct {
+	db *gorm.DB
+}
+
+// NewRunbookRepository creates a new runbook repository
+func NewRunbookRepository(db *gorm.DB) *RunbookRepository {
+	return &RunbookRepository{db: db}
+}
+
+// Create stores a new run. It fails if a run of the same action already
+// holds the idempotency key.
+func (rr *RunbookRepository) Create(ctx context.Context, run *models.RunbookRun) error {
+	return rr.db.WithContext(ctx).Create(run).Error
+}
+
+// FindByIdempotencyKey retrieves the run of action made with key
+func (rr *RunbookRepository) FindByIdempotencyKey(ctx context.Context, action, key string) (*models.RunbookRun, error) {
+	var run models.RunbookRun
+	err := rr.db.WithContext(ctx).
+		Where("action = ? AND idempotency_key = ?", action, key).
+		First(&run).Error
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// Finish records the outcome of a run
+func (rr *RunbookRepository) Finish(ctx context.Context, run *models.RunbookRun) error {
+	return rr.db.WithContext(ctx).Model(run).Updates(map[string]interface{}{
+		"status":      run.Status,
+		"result":      run.Result,
+		"error":       run.Error,
+		"finished_at": run.FinishedAt,
+	}).Error
+}
+
+// List retrieves runs, newest first, optionally filtered by action
+func (rr *RunbookRepository) List(ctx context.Context, action string, offset, limit int) ([]models.RunbookRun, error) {
+	var runs []models.RunbookRun
+	query := rr.db.WithContext(ctx)
+	if action != "" {
+		query = query.Where("action = ?", action)
+	}
+	err := query.Order("created_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&runs).Error
+	return runs, err
+}
@@ -0,0 +1,121 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go-server/internal/database/models"
+	"gorm.io/gorm"
+)
+
+// ServiceAccountRepository handles service account and credential database operations
+type ServiceAccountRepository struct {
+	db *gorm.DB
+}
+
+// NewServiceAccountRepository creates a new service account repository
+func NewServiceAccountRepository(db *gorm.DB) *ServiceAccountRepository {
+	return &ServiceAccountRepository{db: db}
+}
+
+// CreateServiceAccount creates a new service account
+func (sr *ServiceAccountRepository) CreateServiceAccount(ctx context.Context, account *models.ServiceAccount) error {
+	return sr.db.WithContext(ctx).Create(account).Error
+}
+
+// GetServiceAccountByID retrieves a service account by ID
+func (sr *ServiceAccountRepository) GetServiceAccountByID(ctx context.Context, id uint) (*models.ServiceAccount, error) {
+	var account models.ServiceAccount
+	err := sr.db.WithContext(ctx).First(&account, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// UpdateServiceAccount updates a service account
+func (sr *ServiceAccountRepository) UpdateServiceAccount(ctx context.Context, account *models.ServiceAccount) error {
+	return sr.db.WithContext(ctx).Save(account).Error
+}
+
+// DeleteServiceAccount soft deletes a service account and revokes its credentials
+func (sr *ServiceAccountRepository) DeleteServiceAccount(ctx context.Context, id uint, at time.Time) error {
+	return sr.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ServiceAccountCredential{}).
+			Where("service_account_id = ? AND revoked_at IS NULL", id).
+			Update("revoked_at", at).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.ServiceAccount{}, id).Error
+	})
+}
+
+// ListServiceAccounts retrieves an organization's service accounts
+func (sr *ServiceAccountRepository) ListServiceAccounts(ctx context.Context, organizationID uint) ([]models.ServiceAccount, error) {
+	var accounts []models.ServiceAccount
+	err := sr.db.WithContext(ctx).
+		Where("organization_id = ?", organizationID).
+		Order("name").
+		Find(&accounts).Error
+	return accounts, err
+}
+
+// TouchServiceAccount records that an account authenticated
+func (sr *ServiceAccountRepository) TouchServiceAccount(ctx context.Context, accountID, credentialID uint, at time.Time) error {
+	return sr.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ServiceAccount{}).Where("id = ?", accountID).
+			UpdateColumn("last_used_at", at).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.ServiceAccountCredential{}).Where("id = ?", credentialID).
+			UpdateColumn("last_used_at", at).Error
+	})
+}
+
+// CreateCredential stores a new credential
+func (sr *ServiceAccountRepository) CreateCredential(ctx context.Context, credential *models.ServiceAccountCredential) error {
+	return sr.db.WithContext(ctx).Create(credential).Error
+}
+
+// GetCredentialByHash retrieves a credential by key hash or certificate
+// fingerprint, or nil if there is none
+func (sr *ServiceAccountRepository) GetCredentialByHash(ctx context.Context, hash string) (*models.ServiceAccountCredential, error) {
+	var credential models.ServiceAccountCredential
+	err := sr.db.WithContext(ctx).Where("hash = ?", hash).First(&credential).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+// ListCredentials retrieves a service account's credentials, newest first
+func (sr *ServiceAccountRepository) ListCredentials(ctx context.Context, accountID uint) ([]models.ServiceAccountCredential, error) {
+	var credentials []models.ServiceAccountCredential
+	err := sr.db.WithContext(ctx).
+		Where("service_account_id = ?", accountID).
+		Order("created_at DESC, id DESC").
+		Find(&credentials).Error
+	return credentials, err
+}
+
+// RevokeCredential revokes one of an account's credentials; it returns false
+// if the account has no such unrevoked credential
+func (sr *ServiceAccountRepository) RevokeCredential(ctx context.Context, accountID, credentialID uint, at time.Time) (bool, error) {
+	result := sr.db.WithContext(ctx).Model(&models.ServiceAccountCredential{}).
+		Where("id = ? AND service_account_id = ? AND revoked_at IS NULL", credentialID, accountID).
+		Update("revoked_at", at)
+	return result.RowsAffected > 0, result.Error
+}
+
+// RevokeCredentialsCreatedBy revokes the unrevoked credentials of every
+// service account a user created and returns how many it revoked
+func (sr *ServiceAccountRepository) RevokeCredentialsCreatedBy(ctx context.Context, userID uint, at time.Time) (int64, error) {
+	result := sr.db.WithContext(ctx).Model(&models.ServiceAccountCredential{}).
+		Where("revoked_at IS NULL AND service_account_id IN (?)",
+			sr.db.Model(&models.ServiceAccount{}).Select("id").Where("created_by_id = ?", userID)).
+		Update("revoked_at", at)
+	return result.RowsAffected, result.Error
+}
@@ -2,8 +2,7 @@ package repositories
 
 import (
 	"context"
-	"time"
-
+	"go-server/internal/clock"
 	"go-server/internal/database/models"
 	"gorm.io/gorm"
 )
@@ -11,38 +10,77 @@ import (
 // SessionRepository handles session-related database operations
 type SessionRepository struct {
 	db *gorm.DB
+	// sessions reads live sessions only
+	sessions *BaseRepository[models.Session]
+	clock    clock.Clock
+	policy   models.SessionPolicy
 }
 
 // NewSessionRepository creates a new session repository
 func NewSessionRepository(db *gorm.DB) *SessionRepository {
-	return &SessionRepository{db: db}
+	sr := &SessionRepository{clock: clock.New(), policy: models.DefaultSessionPolicy()}
+	sr.bind(db)
+	return sr
+}
+
+// WithTx returns a copy of the repository working in tx, for use inside
+// TxManager.InTransaction
+func (sr *SessionRepository) WithTx(tx *gorm.DB) *SessionRepository {
+	bound := *sr
+	bound.bind(tx)
+	return &bound
+}
+
+// bind points the repository at db, scoping its reads to the sessions
+// that are live under its current clock and policy
+func (sr *SessionRepository) bind(db *gorm.DB) {
+	sr.db = db
+	sr.sessions = NewBaseRepository[models.Session](db).WithScopes(sr.live)
+}
+
+// WithPolicy sets the idle timeout and absolute lifetime sessions are held to
+func (sr *SessionRepository) WithPolicy(policy models.SessionPolicy) *SessionRepository {
+	sr.policy = policy
+	return sr
 }
 
-// CreateSession creates a new session
+// Policy returns the session lifetime policy
+func (sr *SessionRepository) Policy() models.SessionPolicy {
+	return sr.policy
+}
+
+// WithClock sets the time source used for expiry comparisons
+func (sr *SessionRepository) WithClock(c clock.Clock) *SessionRepository {
+	sr.clock = clock.OrDefault(c)
+	return sr
+}
+
+// CreateSession creates a new session, setting its expiry from the policy
+// unless the caller chose one
 func (sr *SessionRepository) CreateSession(ctx context.Context, session *models.Session) error {
-	return sr.db.WithContext(ctx).Create(session).Error
+	now := sr.clock.Now()
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt = now
+	}
+	if session.LastActivityAt == nil {
+		session.LastActivityAt = &now
+	}
+	if session.ExpiresAt.IsZero() {
+		session.ExpiresAt = sr.policy.ExpiresAt(session.CreatedAt, now)
+	}
+	return sr.sessions.Create(ctx, session)
 }
 
-// GetSessionByToken retrieves a session by token
+// GetSessionByToken retrieves a live session by token. Sessions past their
+// idle deadline or older than the policy's maximum lifetime are not found,
+// even if they were created under a longer policy.
 func (sr *SessionRepository) GetSessionByToken(ctx context.Context, token string) (*models.Session, error) {
-	var session models.Session
-	err := sr.db.WithContext(ctx).
-		Where("token = ? AND is_active = ? AND expires_at > ?", token, true, time.Now()).
-		First(&session).Error
-	if err != nil {
-		return nil, err
-	}
-	return &session, nil
+	return sr.sessions.FindOne(ctx, Where("token = ?", token))
 }
 
-// GetSessionsByUser retrieves all sessions for a user
+// GetSessionsByUser retrieves all live sessions for a user
 func (sr *SessionRepository) GetSessionsByUser(ctx context.Context, userID uint) ([]models.Session, error) {
-	var sessions []models.Session
-	err := sr.db.WithContext(ctx).
-		Where("user_id = ? AND is_active = ?", userID, true).
-		Order("created_at DESC").
-		Find(&sessions).Error
-	return sessions, err
+	return sr.sessions.Find(ctx, Where("user_id = ?", userID), OrderBy("created_at DESC"))
 }
 
 // DeleteSession deletes a session
@@ -61,25 +99,45 @@ func (sr *SessionRepository) DeleteUserSessions(ctx context.Context, userID uint
 
 // CleanupExpiredSessions removes expired sessions
 func (sr *SessionRepository) CleanupExpiredSessions(ctx context.Context) error {
-	return sr.db.WithContext(ctx).
-		Where("expires_at < ?", time.Now()).
-		Delete(&models.Session{}).Error
+	now := sr.clock.Now()
+	query := sr.db.WithContext(ctx).Where("expires_at < ?", now)
+	if sr.policy.MaxLifetime > 0 {
+		query = query.Or("created_at < ?", now.Add(-sr.policy.MaxLifetime))
+	}
+	return query.Delete(&models.Session{}).Error
 }
 
-// UpdateSessionLastActivity updates the last activity time for a session
+// UpdateSessionLastActivity records a request on a live session and slides
+// its expiry forward by the idle timeout, capped at its maximum lifetime
 func (sr *SessionRepository) UpdateSessionLastActivity(ctx context.Context, sessionID string) error {
+	session, err := sr.GetSessionByToken(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	now := sr.clock.Now()
 	return sr.db.WithContext(ctx).
 		Model(&models.Session{}).
-		Where("token = ?", sessionID).
-		Update("updated_at", time.Now()).Error
+		Where("id = ?", session.ID).
+		Updates(map[string]interface{}{
+			"last_activity_at": now,
+			"expires_at":       sr.policy.ExpiresAt(session.CreatedAt, now),
+		}).Error
 }
 
 // CountActiveSessions returns the number of active sessions for a user
 func (sr *SessionRepository) CountActiveSessions(ctx context.Context, userID uint) (int64, error) {
-	var count int64
-	err := sr.db.WithContext(ctx).
-		Model(&models.Session{}).
-		Where("user_id = ? AND is_active = ? AND expires_at > ?", userID, true, time.Now()).
-		Count(&count).Error
-	return count, err
+	return sr.sessions.Count(ctx, Where("user_id = ?", userID))
+}
+
+// live restricts a query to active sessions within the policy's limits
+func (sr *SessionRepository) live(db *gorm.DB) *gorm.DB {
+	now := sr.clock.Now()
+	db = db.Where("is_active = ? AND expires_at > ?", true, now)
+	if sr.policy.MaxLifetime > 0 {
+		db = db.Where("created_at > ?", now.Add(-sr.policy.MaxLifetime))
+	}
+	if sr.policy.IdleTimeout > 0 {
+		db = db.Where("COALESCE(last_activity_at, created_at) > ?", now.Add(-sr.policy.IdleTimeout))
+	}
+	return db
 }
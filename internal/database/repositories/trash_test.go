@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go-server/internal/database/dbtest"
+	"go-server/internal/database/models"
+
+	"gorm.io/gorm"
+)
+
+func TestRestorePostRecordsEvent(t *testing.T) {
+	ctx := context.Background()
+	db := dbtest.Open(t, &models.User{}, &models.Post{}, &models.OutboxEvent{})
+	posts := NewPostRepository(db)
+	post := &models.Post{Title: "Hello", Slug: "hello", Content: "x", Status: "published", AuthorID: 1}
+	if err := posts.CreatePost(ctx, post); err != nil {
+		t.Fatalf("CreatePost failed: %v", err)
+	}
+	if err := posts.DeletePost(ctx, post.ID); err != nil {
+		t.Fatalf("DeletePost failed: %v", err)
+	}
+
+	if err := posts.RestorePost(ctx, post.ID); err != nil {
+		t.Fatalf("RestorePost failed: %v", err)
+	}
+	var last models.OutboxEvent
+	if err := db.Order("id DESC").First(&last).Error; err != nil {
+		t.Fatalf("Failed to read the outbox: %v", err)
+	}
+	if last.EventType != models.EventPostUpdated || last.AggregateID != "1" {
+		t.Errorf("Expected a post.updated event for the restored post, got %+v", last)
+	}
+
+	if err := posts.RestorePost(ctx, post.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Restoring a live post = %v, want gorm.ErrRecordNotFound", err)
+	}
+}
+
+func TestBaseRepository_Trash(t *testing.T) {
+	ctx := context.Background()
+	db := dbtest.Open(t, &models.User{}, &models.Post{})
+	posts := NewBaseRepository[models.Post](db)
+	now := time.Now()
+	// The first post stays live
+	for i, deletedAt := range []time.Time{{}, now.Add(-2 * time.Hour), now.Add(-time.Hour)} {
+		post := &models.Post{Title: "Hello", Slug: fmt.Sprintf("post-%d", i), Content: "x", AuthorID: 1}
+		if err := posts.Create(ctx, post); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if !deletedAt.IsZero() {
+			db.Model(post).UpdateColumn("deleted_at", deletedAt)
+		}
+	}
+
+	deleted, err := posts.ListDeleted(ctx, 0, 10)
+	if err != nil || len(deleted) != 2 || deleted[0].ID != 3 || deleted[1].ID != 2 {
+		t.Fatalf("Expected the deleted posts, most recently deleted first, got %+v, %v", deleted, err)
+	}
+	if count, err := posts.CountDeleted(ctx); err != nil || count != 2 {
+		t.Errorf("CountDeleted = %d, %v, want 2", count, err)
+	}
+
+	if err := posts.Restore(ctx, 1); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Restoring a live record = %v, want gorm.ErrRecordNotFound", err)
+	}
+	if err := posts.Restore(ctx, 99); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Restoring a missing record = %v, want gorm.ErrRecordNotFound", err)
+	}
+	if err := posts.Restore(ctx, 3); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if count, _ := posts.CountDeleted(ctx); count != 1 {
+		t.Errorf("Expected one deleted post after the restore, got %d", count)
+	}
+}
+
+func TestPurgeDeletedPostsRespectsCutoff(t *testing.T) {
+	ctx := context.Background()
+	db := dbtest.Open(t, &models.User{}, &models.Post{}, &models.OutboxEvent{}, &models.LegalHold{})
+	posts := NewPostRepository(db)
+	now := time.Now()
+	for i, deletedAt := range []time.Time{now.Add(-48 * time.Hour), now.Add(-time.Hour)} {
+		post := &models.Post{Title: "Hello", Slug: fmt.Sprintf("post-%d", i), Content: "x", AuthorID: 1}
+		if err := posts.CreatePost(ctx, post); err != nil {
+			t.Fatalf("CreatePost failed: %v", err)
+		}
+		if err := posts.DeletePost(ctx, post.ID); err != nil {
+			t.Fatalf("DeletePost failed: %v", err)
+		}
+		db.Unscoped().Model(post).UpdateColumn("deleted_at", deletedAt)
+	}
+	live := &models.Post{Title: "Hello", Slug: "live", Content: "x", AuthorID: 1}
+	posts.CreatePost(ctx, live)
+
+	if purged, err := posts.PurgeDeletedPosts(ctx, now.Add(-24*time.Hour)); err != nil || purged != 1 {
+		t.Fatalf("Expected only the post deleted before the cutoff purged, got %d, %v", purged, err)
+	}
+	var remaining int64
+	db.Unscoped().Model(&models.Post{}).Count(&remaining)
+	if remaining != 2 {
+		t.Errorf("Expected the recently deleted and the live post to remain, got %d", remaining)
+	}
+}
@@ -0,0 +1,26 @@
+package repositories
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// TxManager runs repository calls that must succeed or fail together in one
+// database transaction
+type TxManager struct {
+	db *gorm.DB
+}
+
+// NewTxManager creates a new transaction manager
+func NewTxManager(db *gorm.DB) *TxManager {
+	return &TxManager{db: db}
+}
+
+// InTransaction runs fn in a transaction, committed if fn returns nil and
+// rolled back if it returns an error or panics. fn binds the repositories
+// it uses to tx with their WithTx method; repository methods that open a
+// transaction of their own run in a savepoint of tx.
+func (tm *TxManager) InTransaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	return tm.db.WithContext(ctx).Transaction(fn)
+}
@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	"go-server/internal/database/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FeatureUsageSummary is a feature's usage over a period
+type FeatureUsageSummary struct {
+	Feature  string   `json:"feature"`
+	Requests int64    `json:"requests"`
+	Accounts int64    `json:"accounts"`
+	LastDay  UsageDay `json:"last_day"`
+}
+
+// AccountUsage is one account's use of a feature over a period
+type AccountUsage struct {
+	UserID   uint     `json:"user_id"`
+	Requests int64    `json:"requests"`
+	LastDay  UsageDay `json:"last_day"`
+}
+
+// UsageDay is a date read from an aggregate, which some drivers return as
+// text, and written as 2006-01-02
+type UsageDay struct {
+	time.Time
+}
+
+// Scan reads a date returned as a time or as text
+func (d *UsageDay) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case time.Time:
+		d.Time = v.UTC()
+		return nil
+	case string:
+		return d.parse(v)
+	case []byte:
+		return d.parse(string(v))
+	case nil:
+		d.Time = time.Time{}
+		return nil
+	}
+	return fmt.Errorf("cannot scan %T into a usage day", value)
+}
+
+func (d *UsageDay) parse(text string) error {
+	for _, layout := range []string{time.DateOnly, "2006-01-02 15:04:05-07:00", "2006-01-02 15:04:05", time.RFC3339Nano} {
+		if t, err := time.Parse(layout, text); err == nil {
+			d.Time = t.UTC()
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot parse usage day %q", text)
+}
+
+// Value writes the date as a time
+func (d UsageDay) Value() (driver.Value, error) {
+	return d.Time, nil
+}
+
+// MarshalJSON writes the date without a time
+func (d UsageDay) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + d.Format(time.DateOnly) + `"`), nil
+}
+
+// UsageRepository handles feature usage database operations
+type UsageRepository struct {
+	db *gorm.DB
+}
+
+// NewUsageRepository creates a new usage repository
+func NewUsageRepository(db *gorm.DB) *UsageRepository {
+	return &UsageRepository{db: db}
+}
+
+// AddUsage adds counts to the daily rows, creating rows not seen before
+func (ur *UsageRepository) AddUsage(ctx context.Context, usage []models.FeatureUsage) error {
+	if len(usage) == 0 {
+		return nil
+	}
+	return ur.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "day"}, {Name: "user_id"}, {Name: "feature"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"requests":   gorm.Expr("feature_usage_daily.requests + excluded.requests"),
+			"samples":    gorm.Expr("feature_usage_daily.samples + excluded.samples"),
+			"updated_at": gorm.Expr("excluded.updated_at"),
+		}),
+	}).Create(&usage).Error
+}
+
+// SummarizeFeatures totals each feature's usage between the from and to
+// days inclusive, most used first; prefix limits the features reported
+func (ur *UsageRepository) SummarizeFeatures(ctx context.Context, from, to time.Time, prefix string) ([]FeatureUsageSummary, error) {
+	var summaries []FeatureUsageSummary
+	query := ur.db.WithContext(ctx).
+		Model(&models.FeatureUsage{}).
+		Select("feature, SUM(requests) AS requests, COUNT(DISTINCT NULLIF(user_id, 0)) AS accounts, MAX(day) AS last_day").
+		Where("day >= ? AND day <= ?", from, to)
+	if prefix != "" {
+		query = query.Where("substr(feature, 1, ?) = ?", len(prefix), prefix)
+	}
+	err := query.Group("feature").Order("requests DESC, feature ASC").Scan(&summaries).Error
+	return summaries, err
+}
+
+// ListFeatureAccounts returns the accounts using a feature between the
+// from and to days inclusive, heaviest users first
+func (ur *UsageRepository) ListFeatureAccounts(ctx context.Context, feature string, from, to time.Time, limit int) ([]AccountUsage, error) {
+	var accounts []AccountUsage
+	err := ur.db.WithContext(ctx).
+		Model(&models.FeatureUsage{}).
+		Select("user_id, SUM(requests) AS requests, MAX(day) AS last_day").
+		Where("feature = ? AND day >= ? AND day <= ? AND user_id <> 0", feature, from, to).
+		Group("user_id").
+		Order("requests DESC, user_id ASC").
+		Limit(limit).
+		Scan(&accounts).Error
+	return accounts, err
+}
@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go-server/internal/database/models"
+
+	"gorm.io/gorm"
+)
+
+// DeactivateUser clears a user's active flag, recording when and by whom,
+// and records a user.deactivated event in the same transaction. It reports
+// false if the user was already inactive.
+func (ur *UserRepository) DeactivateUser(ctx context.Context, id, byID uint, at time.Time) (bool, error) {
+	return ur.setUserActive(ctx, id, false, map[string]interface{}{
+		"is_active":         false,
+		"deactivated_at":    at,
+		"deactivated_by_id": byID,
+	})
+}
+
+// ReactivateUser sets a deactivated user's active flag again and records a
+// user.reactivated event in the same transaction. It reports false if the
+// user was already active.
+func (ur *UserRepository) ReactivateUser(ctx context.Context, id uint) (bool, error) {
+	return ur.setUserActive(ctx, id, true, map[string]interface{}{
+		"is_active":         true,
+		"deactivated_at":    nil,
+		"deactivated_by_id": nil,
+	})
+}
+
+// setUserActive applies updates to a user whose active flag differs from
+// active and records the matching lifecycle event
+func (ur *UserRepository) setUserActive(ctx context.Context, id uint, active bool, updates map[string]interface{}) (bool, error) {
+	changed := false
+	updates["version"] = bumpVersion
+	err := ur.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).
+			Where("id = ? AND is_active = ?", id, !active).
+			Updates(updates)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		changed = true
+
+		var user models.User
+		if err := tx.Select("id", "public_id", "email", "username").First(&user, id).Error; err != nil {
+			return err
+		}
+		eventType := models.EventUserDeactivated
+		if active {
+			eventType = models.EventUserReactivated
+		}
+		event, err := models.NewOutboxEvent(eventType, "user", id, userEventPayload(&user))
+		if err != nil {
+			return err
+		}
+		return appendOutboxEvent(tx, event)
+	})
+	return changed && err == nil, err
+}
+
+// activeAuthors leaves out rows whose author, in column, is deactivated,
+// so public listings stop showing their content
+func activeAuthors(db *gorm.DB, column string) *gorm.DB {
+	return db.Where(column+" NOT IN (SELECT id FROM users WHERE is_active = ?)", false)
+}
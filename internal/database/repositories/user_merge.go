@@ -0,0 +1,263 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"go-server/internal/database/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrMergeSameUser is returned when asked to merge an account into itself
+	ErrMergeSameUser = errors.New("cannot merge an account into itself")
+	// ErrMergeHeld is returned when either account is under an active legal
+	// hold, which covers posts only while their author is the held account
+	ErrMergeHeld = errors.New("cannot merge an account under legal hold")
+)
+
+// errMergeDryRun rolls back a dry-run merge once its report is complete
+var errMergeDryRun = errors.New("dry run")
+
+// mergedColumns are the rows owned by a user that a merge reassigns, by
+// table and owning column. Soft-deleted rows move too, so the target can
+// restore them from the trash.
+var mergedColumns = []struct {
+	model  interface{}
+	table  string
+	column string
+}{
+	{&models.Post{}, "posts", "author_id"},
+	{&models.Comment{}, "comments", "author_id"},
+	{&models.Attachment{}, "attachments", "user_id"},
+	{&models.Session{}, "sessions", "user_id"},
+	{&models.ExternalIdentity{}, "external_identities", "user_id"},
+	{&models.Webhook{}, "webhooks", "user_id"},
+	{&models.DeviceToken{}, "device_tokens", "user_id"},
+	{&models.NotificationDelivery{}, "notification_deliveries", "user_id"},
+	{&models.NotificationDigestItem{}, "notification_digest_items", "user_id"},
+	{&models.EmailDelivery{}, "email_deliveries", "user_id"},
+	{&models.Organization{}, "organizations", "owner_id"},
+	{&models.ServiceAccount{}, "service_accounts", "created_by_id"},
+}
+
+// orgRoleRank orders organization roles so a merge keeps the stronger one
+var orgRoleRank = map[string]int{
+	models.OrgRoleMember: 1,
+	models.OrgRoleAdmin:  2,
+	models.OrgRoleOwner:  3,
+}
+
+// MergeReport describes what merging one account into another changes
+type MergeReport struct {
+	SourceID uint `json:"source_id"`
+	TargetID uint `json:"target_id"`
+	DryRun   bool `json:"dry_run"`
+	// Moved counts the rows reassigned to the target, by table
+	Moved map[string]int64 `json:"moved"`
+	// Dropped counts the source's rows removed because the target already
+	// had one: organization memberships (keeping the stronger role) and
+	// notification preferences
+	Dropped map[string]int64 `json:"dropped"`
+	// ProfileFields lists the target's empty profile fields filled in from
+	// the source
+	ProfileFields []string `json:"profile_fields,omitempty"`
+	// DroppedRole is the source's staff role, which is not carried over
+	DroppedRole string `json:"dropped_role,omitempty"`
+}
+
+// MergeUsers moves everything sourceID owns to targetID, fills the target's
+// empty profile fields from the source, deletes the source and records its
+// ID as an alias of the target, all in one transaction. A dry run reports
+// the same changes and rolls them back. Staff roles are never carried over,
+// and accounts under legal hold are never merged.
+func (ur *UserRepository) MergeUsers(ctx context.Context, sourceID, targetID uint, mergedByID *uint, dryRun bool) (*MergeReport, error) {
+	if sourceID == targetID {
+		return nil, ErrMergeSameUser
+	}
+	report := &MergeReport{
+		SourceID: sourceID,
+		TargetID: targetID,
+		DryRun:   dryRun,
+		Moved:    make(map[string]int64),
+		Dropped:  make(map[string]int64),
+	}
+
+	var source models.User
+	err := ur.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var target models.User
+		if err := tx.First(&source, sourceID).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&target, targetID).Error; err != nil {
+			return err
+		}
+		var holds int64
+		err := tx.Model(&models.LegalHold{}).
+			Where("subject_type = ? AND subject_id IN ? AND released_at IS NULL", models.LegalHoldSubjectUser, []uint{sourceID, targetID}).
+			Count(&holds).Error
+		if err != nil {
+			return err
+		}
+		if holds > 0 {
+			return ErrMergeHeld
+		}
+
+		for _, owned := range mergedColumns {
+			result := tx.Unscoped().Model(owned.model).Where(owned.column+" = ?", sourceID).UpdateColumn(owned.column, targetID)
+			if result.Error != nil {
+				return result.Error
+			}
+			report.Moved[owned.table] = result.RowsAffected
+		}
+		if err := mergeMemberships(tx, sourceID, targetID, report); err != nil {
+			return err
+		}
+		if err := mergePreferences(tx, sourceID, targetID, report); err != nil {
+			return err
+		}
+		if err := mergeProfile(tx, &source, &target, report); err != nil {
+			return err
+		}
+		if source.Role != "" || source.IsAdmin {
+			report.DroppedRole = source.Role
+			if report.DroppedRole == "" {
+				report.DroppedRole = "admin"
+			}
+		}
+
+		// Earlier aliases of the source now point at the target
+		result := tx.Model(&models.UserAlias{}).Where("user_id = ?", sourceID).UpdateColumn("user_id", targetID)
+		if result.Error != nil {
+			return result.Error
+		}
+		report.Moved["user_aliases"] = result.RowsAffected
+		alias := &models.UserAlias{AliasID: sourceID, UserID: targetID, MergedByID: mergedByID}
+		if err := tx.Create(alias).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&models.User{}, sourceID).Error; err != nil {
+			return err
+		}
+		deleted, err := models.NewOutboxEvent(models.EventUserDeleted, "user", sourceID, userEventPayload(&source))
+		if err != nil {
+			return err
+		}
+		if err := appendOutboxEvent(tx, deleted); err != nil {
+			return err
+		}
+		merged, err := models.NewOutboxEvent(models.EventUserMerged, "user", targetID, models.UserMergedPayload{
+			SourceID:       sourceID,
+			SourcePublicID: source.PublicID,
+			TargetID:       targetID,
+			TargetPublicID: target.PublicID,
+		})
+		if err != nil {
+			return err
+		}
+		if err := appendOutboxEvent(tx, merged); err != nil {
+			return err
+		}
+
+		if dryRun {
+			return errMergeDryRun
+		}
+		return nil
+	})
+	if errors.Is(err, errMergeDryRun) {
+		return report, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if ur.index != nil {
+		ur.index.Forget(ctx, UserIndexEmail, source.Email)
+		ur.index.Forget(ctx, UserIndexUsername, source.Username)
+	}
+	return report, nil
+}
+
+// mergeMemberships moves the source's organization memberships, keeping
+// the stronger role where both accounts belong to an organization
+func mergeMemberships(tx *gorm.DB, sourceID, targetID uint, report *MergeReport) error {
+	var memberships []models.OrganizationMember
+	if err := tx.Where("user_id = ?", sourceID).Find(&memberships).Error; err != nil {
+		return err
+	}
+	for _, membership := range memberships {
+		var existing models.OrganizationMember
+		err := tx.Where("organization_id = ? AND user_id = ?", membership.OrganizationID, targetID).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err := tx.Model(&membership).UpdateColumn("user_id", targetID).Error; err != nil {
+				return err
+			}
+			report.Moved["organization_members"]++
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if orgRoleRank[membership.Role] > orgRoleRank[existing.Role] {
+			if err := tx.Model(&existing).UpdateColumn("role", membership.Role).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Delete(&membership).Error; err != nil {
+			return err
+		}
+		report.Dropped["organization_members"]++
+	}
+	return nil
+}
+
+// mergePreferences moves the source's notification preferences unless the
+// target set its own
+func mergePreferences(tx *gorm.DB, sourceID, targetID uint, report *MergeReport) error {
+	var count int64
+	if err := tx.Model(&models.NotificationPreference{}).Where("user_id = ?", targetID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		result := tx.Where("user_id = ?", sourceID).Delete(&models.NotificationPreference{})
+		report.Dropped["notification_preferences"] = result.RowsAffected
+		return result.Error
+	}
+	result := tx.Model(&models.NotificationPreference{}).Where("user_id = ?", sourceID).UpdateColumn("user_id", targetID)
+	report.Moved["notification_preferences"] = result.RowsAffected
+	return result.Error
+}
+
+// mergeProfile fills the target's empty profile fields from the source
+func mergeProfile(tx *gorm.DB, source, target *models.User, report *MergeReport) error {
+	updates := make(map[string]interface{})
+	fill := func(column, targetValue, sourceValue string) {
+		if targetValue == "" && sourceValue != "" {
+			updates[column] = sourceValue
+			report.ProfileFields = append(report.ProfileFields, column)
+		}
+	}
+	fill("first_name", target.FirstName, source.FirstName)
+	fill("last_name", target.LastName, source.LastName)
+	fill("phone_number", target.PhoneNumber, source.PhoneNumber)
+	if len(updates) == 0 {
+		return nil
+	}
+	updates["version"] = bumpVersion
+	return tx.Model(target).Updates(updates).Error
+}
+
+// ResolveUserAlias returns the account an ID was merged into, or false if
+// the ID is not an alias
+func (ur *UserRepository) ResolveUserAlias(ctx context.Context, id uint) (uint, bool, error) {
+	var alias models.UserAlias
+	err := ur.db.WithContext(ctx).First(&alias, "alias_id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return alias.UserID, true, nil
+}
@@ -2,90 +2,357 @@ package repositories
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"go-server/internal/database/models"
 	"gorm.io/gorm"
 )
 
+// Fields of the user secondary index
+const (
+	UserIndexEmail    = "email"
+	UserIndexUsername = "username"
+)
+
+// UserIndex caches the user ID that an email or username resolves to, so
+// lookups by them can skip the secondary index scan. An ID of zero records
+// that no user has the value. Implementations swallow their own failures;
+// a lookup that fails is a miss.
+type UserIndex interface {
+	Lookup(ctx context.Context, field, value string) (id uint, found bool)
+	Store(ctx context.Context, field, value string, id uint)
+	Forget(ctx context.Context, field string, values ...string)
+}
+
 // UserRepository handles user-related database operations
 type UserRepository struct {
-	db *gorm.DB
+	db    *gorm.DB
+	users *BaseRepository[models.User]
+	index UserIndex
 }
 
 // NewUserRepository creates a new user repository
 func NewUserRepository(db *gorm.DB) *UserRepository {
-	return &UserRepository{db: db}
+	return &UserRepository{db: db, users: NewBaseRepository[models.User](db)}
+}
+
+// WithTx returns a copy of the repository working in tx, for use inside
+// TxManager.InTransaction
+func (ur *UserRepository) WithTx(tx *gorm.DB) *UserRepository {
+	bound := *ur
+	bound.db = tx
+	bound.users = ur.users.WithTx(tx)
+	return &bound
+}
+
+// WithIndex resolves emails and usernames through index before querying.
+// The index is updated after changes commit, and the user events recorded
+// in the outbox with them keep it right if that update is lost.
+func (ur *UserRepository) WithIndex(index UserIndex) *UserRepository {
+	ur.index = index
+	return ur
 }
 
 // CreateUser creates a new user
 func (ur *UserRepository) CreateUser(ctx context.Context, user *models.User) error {
-	return ur.db.WithContext(ctx).Create(user).Error
+	return ur.users.Create(ctx, user)
+}
+
+// RegisterUser creates a new user and records a user.registered event in the same transaction
+func (ur *UserRepository) RegisterUser(ctx context.Context, user *models.User) error {
+	err := ur.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+
+		event, err := models.NewOutboxEvent(models.EventUserRegistered, "user", user.ID, map[string]interface{}{
+			"id":        user.ID,
+			"public_id": user.PublicID,
+			"email":     user.Email,
+			"username":  user.Username,
+		})
+		if err != nil {
+			return err
+		}
+		return appendOutboxEvent(tx, event)
+	})
+	if err == nil && ur.index != nil {
+		ur.index.Store(ctx, UserIndexEmail, user.Email, user.ID)
+		ur.index.Store(ctx, UserIndexUsername, user.Username, user.ID)
+	}
+	return err
 }
 
 // GetUserByID retrieves a user by ID
 func (ur *UserRepository) GetUserByID(ctx context.Context, id uint) (*models.User, error) {
+	return ur.users.GetByID(ctx, id)
+}
+
+// GetUsersByIDs retrieves the users with the given IDs; missing IDs are skipped
+func (ur *UserRepository) GetUsersByIDs(ctx context.Context, ids []uint) ([]models.User, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return ur.users.Find(ctx, Where("id IN ?", ids))
+}
+
+// GetUserByPublicID retrieves a user by public identifier
+func (ur *UserRepository) GetUserByPublicID(ctx context.Context, publicID string) (*models.User, error) {
+	return ur.users.FindOne(ctx, Where("public_id = ?", publicID))
+}
+
+// FindUserIDByPublicID resolves a public identifier to the internal key, optionally including soft-deleted users
+func (ur *UserRepository) FindUserIDByPublicID(ctx context.Context, publicID string, includeDeleted bool) (uint, error) {
 	var user models.User
-	err := ur.db.WithContext(ctx).First(&user, id).Error
+	query := ur.db.WithContext(ctx)
+	if includeDeleted {
+		query = query.Unscoped()
+	}
+	err := query.Select("id").Where("public_id = ?", publicID).First(&user).Error
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	return &user, nil
+	return user.ID, nil
 }
 
 // GetUserByEmail retrieves a user by email
 func (ur *UserRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
-	var user models.User
-	err := ur.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
-	if err != nil {
-		return nil, err
-	}
-	return &user, nil
+	return ur.getUserBy(ctx, UserIndexEmail, email)
 }
 
 // GetUserByUsername retrieves a user by username
 func (ur *UserRepository) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
-	var user models.User
-	err := ur.db.WithContext(ctx).Where("username = ?", username).First(&user).Error
-	if err != nil {
-		return nil, err
+	return ur.getUserBy(ctx, UserIndexUsername, username)
+}
+
+// GetUserByEmailConfirmHash retrieves the user with a pending email change
+// confirmed by the token with this hash
+func (ur *UserRepository) GetUserByEmailConfirmHash(ctx context.Context, hash string) (*models.User, error) {
+	return ur.users.FindOne(ctx, Where("email_confirm_hash = ?", hash))
+}
+
+// GetUserByEmailRevertHash retrieves the user whose last email change is
+// reverted by the token with this hash
+func (ur *UserRepository) GetUserByEmailRevertHash(ctx context.Context, hash string) (*models.User, error) {
+	return ur.users.FindOne(ctx, Where("email_revert_hash = ?", hash))
+}
+
+// getUserBy retrieves a user by an indexed field, trying the index first.
+// An index entry pointing at a user who no longer has the value is stale
+// and falls through to the query, which refreshes it.
+func (ur *UserRepository) getUserBy(ctx context.Context, field, value string) (*models.User, error) {
+	if ur.index != nil {
+		if id, found := ur.index.Lookup(ctx, field, value); found {
+			if id == 0 {
+				return nil, gorm.ErrRecordNotFound
+			}
+			user, err := ur.GetUserByID(ctx, id)
+			if err == nil && indexedValue(user, field) == value {
+				return user, nil
+			}
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, err
+			}
+		}
+	}
+
+	user, err := ur.users.FindOne(ctx, Where(field+" = ?", value))
+	if ur.index != nil {
+		switch {
+		case err == nil:
+			ur.index.Store(ctx, field, value, user.ID)
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			ur.index.Store(ctx, field, value, 0)
+		}
+	}
+	return user, err
+}
+
+// indexedValue returns the user's value of an indexed field
+func indexedValue(user *models.User, field string) string {
+	if field == UserIndexEmail {
+		return user.Email
 	}
-	return &user, nil
+	return user.Username
 }
 
-// UpdateUser updates a user
+// UpdateUser updates a user. A change of email or username records a
+// user.updated event in the same transaction. It fails with
+// ErrVersionConflict if the user changed since user was read.
 func (ur *UserRepository) UpdateUser(ctx context.Context, user *models.User) error {
-	return ur.db.WithContext(ctx).Save(user).Error
+	if user.ID == 0 {
+		return ur.users.Save(ctx, user)
+	}
+
+	var previous models.User
+	err := ur.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id", "email", "username").First(&previous, user.ID).Error; err != nil {
+			return err
+		}
+		if err := saveVersioned(tx, user, &user.Version); err != nil {
+			return err
+		}
+		if previous.Email == user.Email && previous.Username == user.Username {
+			return nil
+		}
+
+		payload := userEventPayload(user)
+		if previous.Email != user.Email {
+			payload.PreviousEmail = previous.Email
+		}
+		if previous.Username != user.Username {
+			payload.PreviousUsername = previous.Username
+		}
+		event, err := models.NewOutboxEvent(models.EventUserUpdated, "user", user.ID, payload)
+		if err != nil {
+			return err
+		}
+		return appendOutboxEvent(tx, event)
+	})
+	if err != nil {
+		return err
+	}
+	if ur.index != nil {
+		if previous.Email != user.Email {
+			ur.index.Forget(ctx, UserIndexEmail, previous.Email)
+			ur.index.Store(ctx, UserIndexEmail, user.Email, user.ID)
+		}
+		if previous.Username != user.Username {
+			ur.index.Forget(ctx, UserIndexUsername, previous.Username)
+			ur.index.Store(ctx, UserIndexUsername, user.Username, user.ID)
+		}
+	}
+	return nil
+}
+
+// userEventPayload is the payload of user lifecycle events
+func userEventPayload(user *models.User) models.UserEventPayload {
+	return models.UserEventPayload{
+		ID:       user.ID,
+		PublicID: user.PublicID,
+		Email:    user.Email,
+		Username: user.Username,
+	}
+}
+
+// RecordLogin stores a user's last login time and, if not empty, the
+// password rehashed to the current scheme. Neither is an edit to the user,
+// so the version is left alone and copies read before stay current.
+func (ur *UserRepository) RecordLogin(ctx context.Context, id uint, at time.Time, passwordHash string) error {
+	columns := map[string]interface{}{"last_login": at}
+	if passwordHash != "" {
+		columns["password"] = passwordHash
+	}
+	return ur.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).UpdateColumns(columns).Error
 }
 
-// DeleteUser soft deletes a user
+// SetUserRole sets a user's staff role, keeping the legacy is_admin flag in
+// step so only full administrators carry it
+func (ur *UserRepository) SetUserRole(ctx context.Context, id uint, role string) (bool, error) {
+	result := ur.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"role": role, "is_admin": role == "admin", "version": bumpVersion})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ListStaff retrieves users holding a staff role, including legacy administrators
+func (ur *UserRepository) ListStaff(ctx context.Context) ([]models.User, error) {
+	return ur.users.Find(ctx, Where("role <> '' OR is_admin = ?", true), OrderBy("id"))
+}
+
+// DeleteUser soft deletes a user and records a user.deleted event in the
+// same transaction
 func (ur *UserRepository) DeleteUser(ctx context.Context, id uint) error {
-	return ur.db.WithContext(ctx).Delete(&models.User{}, id).Error
+	var user models.User
+	err := ur.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Select("id", "public_id", "email", "username").First(&user, id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Nothing to delete
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.User{}, id).Error; err != nil {
+			return err
+		}
+		event, err := models.NewOutboxEvent(models.EventUserDeleted, "user", id, userEventPayload(&user))
+		if err != nil {
+			return err
+		}
+		return appendOutboxEvent(tx, event)
+	})
+	if err == nil && ur.index != nil && user.ID != 0 {
+		ur.index.Forget(ctx, UserIndexEmail, user.Email)
+		ur.index.Forget(ctx, UserIndexUsername, user.Username)
+	}
+	return err
 }
 
 // ListUsers retrieves users with pagination
 func (ur *UserRepository) ListUsers(ctx context.Context, offset, limit int) ([]models.User, error) {
-	var users []models.User
-	err := ur.db.WithContext(ctx).
-		Offset(offset).
-		Limit(limit).
-		Find(&users).Error
-	return users, err
+	return ur.users.List(ctx, offset, limit)
 }
 
 // CountUsers returns the total number of users
 func (ur *UserRepository) CountUsers(ctx context.Context) (int64, error) {
-	var count int64
-	err := ur.db.WithContext(ctx).Model(&models.User{}).Count(&count).Error
-	return count, err
+	return ur.users.Count(ctx)
 }
 
 // GetActiveUsers retrieves only active users
 func (ur *UserRepository) GetActiveUsers(ctx context.Context, offset, limit int) ([]models.User, error) {
-	var users []models.User
-	err := ur.db.WithContext(ctx).
-		Where("is_active = ?", true).
-		Offset(offset).
-		Limit(limit).
-		Find(&users).Error
-	return users, err
+	return ur.users.List(ctx, offset, limit, Where("is_active = ?", true))
+}
+
+// ListDeletedUsers retrieves soft-deleted users with pagination
+func (ur *UserRepository) ListDeletedUsers(ctx context.Context, offset, limit int) ([]models.User, error) {
+	return ur.users.ListDeleted(ctx, offset, limit)
+}
+
+// CountDeletedUsers returns the number of soft-deleted users
+func (ur *UserRepository) CountDeletedUsers(ctx context.Context) (int64, error) {
+	return ur.users.CountDeleted(ctx)
+}
+
+// RestoreUser clears the soft-delete marker on a user and records a
+// user.updated event in the same transaction, since the account can be
+// found by email and username again
+func (ur *UserRepository) RestoreUser(ctx context.Context, id uint) error {
+	var user models.User
+	err := ur.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.
+			Unscoped().
+			Model(&models.User{}).
+			Where("id = ? AND deleted_at IS NOT NULL", id).
+			Update("deleted_at", nil)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.Select("id", "public_id", "email", "username").First(&user, id).Error; err != nil {
+			return err
+		}
+		event, err := models.NewOutboxEvent(models.EventUserUpdated, "user", id, userEventPayload(&user))
+		if err != nil {
+			return err
+		}
+		return appendOutboxEvent(tx, event)
+	})
+	if err == nil && ur.index != nil {
+		ur.index.Store(ctx, UserIndexEmail, user.Email, user.ID)
+		ur.index.Store(ctx, UserIndexUsername, user.Username, user.ID)
+	}
+	return err
+}
+
+// PurgeDeletedUsers permanently removes users soft-deleted before the
+// cutoff. Users under legal hold stay in the trash.
+func (ur *UserRepository) PurgeDeletedUsers(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := notHeldUsers(ur.db.WithContext(ctx)).
+		Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Delete(&models.User{})
+	return result.RowsAffected, result.Error
 }
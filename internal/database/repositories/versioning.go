@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrVersionConflict is returned when saving a record that another request
+// changed after it was read, which would otherwise silently overwrite that
+// change. The caller should reload the record and apply its change again.
+var ErrVersionConflict = errors.New("record was changed by another request")
+
+// bumpVersion is the update recording a change to a versioned record in
+// updates that do not save the whole record
+var bumpVersion = gorm.Expr("version + 1")
+
+// saveVersioned saves every column of a record that was read at *version,
+// only if no one else has changed it since, and advances *version. It
+// returns ErrVersionConflict if the record's version has moved on, or the
+// record is gone.
+func saveVersioned(tx *gorm.DB, record interface{}, version *uint) error {
+	read := *version
+	*version = read + 1
+	// Selecting the columns explicitly stops Save from turning a missed
+	// update into an insert
+	result := tx.Select("*").Where("version = ?", read).Save(record)
+	if result.Error == nil && result.RowsAffected == 0 {
+		result.Error = ErrVersionConflict
+	}
+	if result.Error != nil {
+		*version = read
+	}
+	return result.Error
+}
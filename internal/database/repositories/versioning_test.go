@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-server/internal/database/dbtest"
+	"go-server/internal/database/models"
+)
+
+func TestUpdateUserRejectsStaleVersion(t *testing.T) {
+	ctx := context.Background()
+	users := NewUserRepository(dbtest.Open(t, &models.User{}, &models.Post{}, &models.OutboxEvent{}))
+	user := &models.User{Email: "ada@example.com", Username: "ada", Password: "hash"}
+	if err := users.CreateUser(ctx, user); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+
+	// Two requests read the user at the same version
+	first, _ := users.GetUserByID(ctx, user.ID)
+	second, _ := users.GetUserByID(ctx, user.ID)
+	if first.Version != 1 {
+		t.Fatalf("Expected a new user at version 1, got %d", first.Version)
+	}
+
+	first.FirstName = "Ada"
+	if err := users.UpdateUser(ctx, first); err != nil {
+		t.Fatalf("UpdateUser failed: %v", err)
+	}
+	if first.Version != 2 {
+		t.Errorf("Expected the saved copy at version 2, got %d", first.Version)
+	}
+
+	second.LastName = "Lovelace"
+	if err := users.UpdateUser(ctx, second); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("Expected ErrVersionConflict for the stale copy, got %v", err)
+	}
+	if second.Version != 1 {
+		t.Errorf("Expected the stale copy to keep its version, got %d", second.Version)
+	}
+
+	stored, _ := users.GetUserByID(ctx, user.ID)
+	if stored.FirstName != "Ada" || stored.LastName != "" || stored.Version != 2 {
+		t.Errorf("Expected only the first update stored, got %q %q at version %d", stored.FirstName, stored.LastName, stored.Version)
+	}
+	if count, _ := users.CountUsers(ctx); count != 1 {
+		t.Errorf("Expected the stale save not to insert a row, got %d users", count)
+	}
+
+	// Reloading picks up the change and lets the update through
+	stored.LastName = "Lovelace"
+	if err := users.UpdateUser(ctx, stored); err != nil {
+		t.Errorf("Expected the reloaded copy to update, got %v", err)
+	}
+}
+
+func TestSetUserRoleBumpsVersion(t *testing.T) {
+	ctx := context.Background()
+	users := NewUserRepository(dbtest.Open(t, &models.User{}, &models.Post{}, &models.OutboxEvent{}))
+	user := &models.User{Email: "grace@example.com", Username: "grace", Password: "hash"}
+	if err := users.CreateUser(ctx, user); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	stale, _ := users.GetUserByID(ctx, user.ID)
+
+	if _, err := users.SetUserRole(ctx, user.ID, "support"); err != nil {
+		t.Fatalf("SetUserRole failed: %v", err)
+	}
+
+	// Saving the copy read before would put the old role back
+	stale.FirstName = "Grace"
+	if err := users.UpdateUser(ctx, stale); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("Expected ErrVersionConflict after the role change, got %v", err)
+	}
+}
+
+func TestRecordLoginKeepsVersion(t *testing.T) {
+	ctx := context.Background()
+	users := NewUserRepository(dbtest.Open(t, &models.User{}, &models.Post{}, &models.OutboxEvent{}))
+	user := &models.User{Email: "linus@example.com", Username: "linus", Password: "hash"}
+	if err := users.CreateUser(ctx, user); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	editing, _ := users.GetUserByID(ctx, user.ID)
+
+	at := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	if err := users.RecordLogin(ctx, user.ID, at, "rehashed"); err != nil {
+		t.Fatalf("RecordLogin failed: %v", err)
+	}
+	stored, _ := users.GetUserByID(ctx, user.ID)
+	if stored.LastLogin == nil || !stored.LastLogin.Equal(at) || stored.Password != "rehashed" || stored.Version != 1 {
+		t.Errorf("Expected the login recorded at version 1, got %v %q at version %d", stored.LastLogin, stored.Password, stored.Version)
+	}
+
+	// A copy read before logging in can still be saved
+	editing.FirstName = "Linus"
+	if err := users.UpdateUser(ctx, editing); err != nil {
+		t.Errorf("Expected the update after a login to succeed, got %v", err)
+	}
+}
+
+func TestUpdatePostRejectsStaleVersion(t *testing.T) {
+	ctx := context.Background()
+	db := dbtest.Open(t, &models.User{}, &models.Post{}, &models.OutboxEvent{})
+	posts := NewPostRepository(db)
+	post := &models.Post{Title: "Draft", Slug: "draft", Content: "Body", AuthorID: 1}
+	if err := posts.CreatePost(ctx, post); err != nil {
+		t.Fatalf("CreatePost failed: %v", err)
+	}
+
+	first, _ := posts.GetPostByID(ctx, post.ID)
+	second, _ := posts.GetPostByID(ctx, post.ID)
+
+	first.Title = "First"
+	if err := posts.UpdatePost(ctx, first); err != nil {
+		t.Fatalf("UpdatePost failed: %v", err)
+	}
+	second.Title = "Second"
+	if err := posts.UpdatePost(ctx, second); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("Expected ErrVersionConflict for the stale copy, got %v", err)
+	}
+
+	stored, _ := posts.GetPostByID(ctx, post.ID)
+	if stored.Title != "First" || stored.Version != 2 {
+		t.Errorf("Expected the first edit at version 2, got %q at version %d", stored.Title, stored.Version)
+	}
+}
@@ -0,0 +1,151 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go-server/internal/database/models"
+	"gorm.io/gorm"
+)
+
+// WebhookRepository handles webhook and delivery log database operations
+type WebhookRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepository creates a new webhook repository
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// CreateWebhook creates a new webhook
+func (wr *WebhookRepository) CreateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	return wr.db.WithContext(ctx).Create(webhook).Error
+}
+
+// GetWebhookByID retrieves a webhook by ID
+func (wr *WebhookRepository) GetWebhookByID(ctx context.Context, id uint) (*models.Webhook, error) {
+	var webhook models.Webhook
+	err := wr.db.WithContext(ctx).First(&webhook, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// UpdateWebhook updates a webhook
+func (wr *WebhookRepository) UpdateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	return wr.db.WithContext(ctx).Save(webhook).Error
+}
+
+// DeleteWebhook soft deletes a webhook
+func (wr *WebhookRepository) DeleteWebhook(ctx context.Context, id uint) error {
+	return wr.db.WithContext(ctx).Delete(&models.Webhook{}, id).Error
+}
+
+// ListWebhooksByUser retrieves the webhooks registered by a user for
+// themselves, leaving out those registered for an organization
+func (wr *WebhookRepository) ListWebhooksByUser(ctx context.Context, userID uint) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := wr.db.WithContext(ctx).
+		Where("user_id = ? AND organization_id IS NULL", userID).
+		Order("created_at DESC").
+		Find(&webhooks).Error
+	return webhooks, err
+}
+
+// ListWebhooksByOrganization retrieves an organization's webhooks
+func (wr *WebhookRepository) ListWebhooksByOrganization(ctx context.Context, organizationID uint) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := wr.db.WithContext(ctx).
+		Where("organization_id = ?", organizationID).
+		Order("created_at DESC").
+		Find(&webhooks).Error
+	return webhooks, err
+}
+
+// ListSubscribers retrieves active webhooks subscribed to an event type
+// about userID: users' own webhooks and those of userID's organizations.
+// A userID of 0, for events about no user, leaves organization webhooks
+// out.
+func (wr *WebhookRepository) ListSubscribers(ctx context.Context, eventType string, userID uint) ([]models.Webhook, error) {
+	memberships := wr.db.Model(&models.OrganizationMember{}).
+		Select("organization_id").
+		Where("user_id = ?", userID)
+	var webhooks []models.Webhook
+	err := wr.db.WithContext(ctx).
+		Where("is_active = ?", true).
+		Where("organization_id IS NULL OR organization_id IN (?)", memberships).
+		Find(&webhooks).Error
+	if err != nil {
+		return nil, err
+	}
+
+	subscribers := webhooks[:0]
+	for _, webhook := range webhooks {
+		if webhook.Subscribes(eventType) {
+			subscribers = append(subscribers, webhook)
+		}
+	}
+	return subscribers, nil
+}
+
+// CreateDelivery records a delivery attempt
+func (wr *WebhookRepository) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return wr.db.WithContext(ctx).Create(delivery).Error
+}
+
+// GetDeliveryByID retrieves a delivery attempt by ID
+func (wr *WebhookRepository) GetDeliveryByID(ctx context.Context, id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := wr.db.WithContext(ctx).First(&delivery, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ListDeliveries retrieves a webhook's delivery log, newest first
+func (wr *WebhookRepository) ListDeliveries(ctx context.Context, webhookID uint, failedOnly bool, offset, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	query := wr.db.WithContext(ctx).Where("webhook_id = ?", webhookID)
+	if failedOnly {
+		query = query.Where("succeeded = ?", false)
+	}
+	err := query.
+		Order("created_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// ListFailedDeliveries retrieves failed delivery attempts across all webhooks, newest first
+func (wr *WebhookRepository) ListFailedDeliveries(ctx context.Context, offset, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := wr.db.WithContext(ctx).
+		Where("succeeded = ?", false).
+		Order("created_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// CountFailedDeliveries counts failed delivery attempts across all webhooks
+func (wr *WebhookRepository) CountFailedDeliveries(ctx context.Context) (int64, error) {
+	var count int64
+	err := wr.db.WithContext(ctx).
+		Model(&models.WebhookDelivery{}).
+		Where("succeeded = ?", false).
+		Count(&count).Error
+	return count, err
+}
+
+// PurgeDeliveries deletes delivery log entries created before the cutoff
+func (wr *WebhookRepository) PurgeDeliveries(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := wr.db.WithContext(ctx).
+		Where("created_at < ?", cutoff).
+		Delete(&models.WebhookDelivery{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,204 @@
+package schema
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ChangeKind classifies a schema change made by a migration statement
+type ChangeKind string
+
+// Schema changes Lint and Check care about
+const (
+	ChangeCreateTable ChangeKind = "create_table"
+	ChangeAddColumn   ChangeKind = "add_column"
+	// ChangeAddRequiredColumn is a NOT NULL column without a default, which
+	// inserts from a build that does not know the column violate
+	ChangeAddRequiredColumn ChangeKind = "add_required_column"
+	ChangeDropTable         ChangeKind = "drop_table"
+	ChangeDropColumn        ChangeKind = "drop_column"
+	ChangeRename            ChangeKind = "rename"
+	ChangeRetype            ChangeKind = "retype"
+	ChangeSetNotNull        ChangeKind = "set_not_null"
+	ChangeTruncate          ChangeKind = "truncate"
+	// ChangeRetypeText changes a column to text or varchar, which still
+	// accepts whatever either build writes and reads back as a string;
+	// Postgres refuses it if a value does not fit
+	ChangeRetypeText ChangeKind = "retype_text"
+)
+
+// Change is one schema change; Column is empty for table-level changes
+type Change struct {
+	Kind      ChangeKind
+	Table     string
+	Column    string
+	Statement string
+}
+
+var (
+	whitespace = regexp.MustCompile(`\s+`)
+
+	createTable = regexp.MustCompile(`^create (?:unlogged |temporary |temp )?table (?:if not exists )?([\w.]+)`)
+	dropTable   = regexp.MustCompile(`^drop table (?:if exists )?(.+?)(?: cascade| restrict)?$`)
+	truncate    = regexp.MustCompile(`^truncate (?:table )?(?:only )?([\w.]+)`)
+	alterTable  = regexp.MustCompile(`^alter table (?:if exists )?(?:only )?([\w.]+) (.+)$`)
+
+	addColumn    = regexp.MustCompile(`^add (?:column )?(?:if not exists )?(\w+) (.*)$`)
+	dropColumn   = regexp.MustCompile(`^drop (?:column )?(?:if exists )?(\w+)`)
+	renameTable  = regexp.MustCompile(`^rename to `)
+	renameColumn = regexp.MustCompile(`^rename (?:column )?(\w+) to `)
+	retype       = regexp.MustCompile(`^alter (?:column )?(\w+) (?:set data )?type `)
+	textType     = regexp.MustCompile(`type (?:text|varchar|character varying)(?: ?\(\d+\))?$`)
+	setNotNull   = regexp.MustCompile(`^alter (?:column )?(\w+) set not null`)
+)
+
+// constraintKeywords start ADD and DROP actions that change constraints
+// rather than columns
+var constraintKeywords = map[string]bool{
+	"constraint": true, "primary": true, "foreign": true, "unique": true, "check": true, "exclude": true,
+}
+
+// Parse returns the schema changes made by a migration's SQL. Statements
+// it does not recognise, such as indexes and data updates, are skipped.
+func Parse(sql string) []Change {
+	var changes []Change
+	for _, statement := range Statements(sql) {
+		changes = append(changes, parseStatement(statement)...)
+	}
+	return changes
+}
+
+func parseStatement(statement string) []Change {
+	// Unquoted identifiers are case-insensitive, so match in lower case
+	normalized := strings.ToLower(strings.ReplaceAll(statement, `"`, ""))
+	change := func(kind ChangeKind, table, column string) Change {
+		return Change{Kind: kind, Table: tableName(table), Column: column, Statement: statement}
+	}
+
+	if match := createTable.FindStringSubmatch(normalized); match != nil {
+		return []Change{change(ChangeCreateTable, match[1], "")}
+	}
+	if match := dropTable.FindStringSubmatch(normalized); match != nil {
+		var changes []Change
+		for _, table := range strings.Split(match[1], ",") {
+			changes = append(changes, change(ChangeDropTable, strings.TrimSpace(table), ""))
+		}
+		return changes
+	}
+	if match := truncate.FindStringSubmatch(normalized); match != nil {
+		return []Change{change(ChangeTruncate, match[1], "")}
+	}
+
+	match := alterTable.FindStringSubmatch(normalized)
+	if match == nil {
+		return nil
+	}
+	table := match[1]
+	var changes []Change
+	for _, action := range splitTopLevel(match[2]) {
+		if m := addColumn.FindStringSubmatch(action); m != nil && !constraintKeywords[m[1]] {
+			kind := ChangeAddColumn
+			if strings.Contains(m[2], "not null") && !strings.Contains(m[2], "default") &&
+				!strings.Contains(m[2], "generated") {
+				kind = ChangeAddRequiredColumn
+			}
+			changes = append(changes, change(kind, table, m[1]))
+		} else if m := dropColumn.FindStringSubmatch(action); m != nil && !constraintKeywords[m[1]] {
+			changes = append(changes, change(ChangeDropColumn, table, m[1]))
+		} else if renameTable.MatchString(action) {
+			changes = append(changes, change(ChangeRename, table, ""))
+		} else if m := renameColumn.FindStringSubmatch(action); m != nil && !constraintKeywords[m[1]] {
+			changes = append(changes, change(ChangeRename, table, m[1]))
+		} else if m := retype.FindStringSubmatch(action); m != nil {
+			kind := ChangeRetype
+			if textType.MatchString(action) {
+				kind = ChangeRetypeText
+			}
+			changes = append(changes, change(kind, table, m[1]))
+		} else if m := setNotNull.FindStringSubmatch(action); m != nil {
+			changes = append(changes, change(ChangeSetNotNull, table, m[1]))
+		}
+	}
+	return changes
+}
+
+// Statements splits SQL into statements with comments removed and
+// whitespace collapsed. Quoted strings and dollar-quoted bodies are kept
+// intact.
+func Statements(sql string) []string {
+	var statements []string
+	var current strings.Builder
+	inString, inDollar := false, false
+
+	flush := func() {
+		statement := strings.TrimSpace(whitespace.ReplaceAllString(current.String(), " "))
+		if statement != "" {
+			statements = append(statements, statement)
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case inString:
+			current.WriteByte(c)
+			if c == '\'' {
+				inString = false
+			}
+		case inDollar:
+			current.WriteByte(c)
+			if c == '$' && i+1 < len(sql) && sql[i+1] == '$' {
+				current.WriteByte('$')
+				i++
+				inDollar = false
+			}
+		case c == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+			current.WriteByte('\n')
+		case c == '\'':
+			inString = true
+			current.WriteByte(c)
+		case c == '$' && i+1 < len(sql) && sql[i+1] == '$':
+			inDollar = true
+			current.WriteString("$$")
+			i++
+		case c == ';':
+			flush()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+	return statements
+}
+
+// splitTopLevel splits ALTER TABLE actions on commas outside parentheses
+func splitTopLevel(actions string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, c := range actions {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(actions[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, strings.TrimSpace(actions[start:]))
+}
+
+// tableName strips the schema from a qualified table name
+func tableName(name string) string {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
@@ -0,0 +1,187 @@
+package schema
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go-server/internal/clock"
+
+	"gorm.io/gorm"
+)
+
+// ErrIncompatible is returned by a Gate while the database lacks schema
+// this build needs
+var ErrIncompatible = errors.New("database schema is behind this build")
+
+// Report compares this build's models with the database and the pending
+// migrations
+type Report struct {
+	Pending []string `json:"pending,omitempty"`
+	// Missing lists the tables and columns this build uses that the
+	// database does not have yet, usually because an expand migration has
+	// not run
+	Missing []string `json:"missing,omitempty"`
+	// Blocked lists the pending contract changes to schema this build uses;
+	// they may only run once this build is replaced
+	Blocked []string `json:"blocked,omitempty"`
+}
+
+// Compatible reports whether this build can serve traffic on the database
+func (r Report) Compatible() bool {
+	return len(r.Missing) == 0
+}
+
+// Check compares the models set with WithModels against the database and
+// the pending migrations
+func (r *Runner) Check(ctx context.Context) (Report, error) {
+	var report Report
+	pending, err := r.Pending(ctx)
+	if err != nil {
+		return report, err
+	}
+	for _, m := range pending {
+		report.Pending = append(report.Pending, m.String())
+		if m.Phase == PhaseContract {
+			for _, target := range r.blockedBy(m) {
+				report.Blocked = append(report.Blocked, m.String()+": "+target)
+			}
+		}
+	}
+
+	expected, err := r.expectedColumns()
+	if err != nil {
+		return report, err
+	}
+	migrator := r.db.WithContext(ctx).Migrator()
+	for _, table := range sortedKeys(expected) {
+		if !migrator.HasTable(table) {
+			report.Missing = append(report.Missing, table)
+			continue
+		}
+		columnTypes, err := migrator.ColumnTypes(table)
+		if err != nil {
+			return report, fmt.Errorf("failed to read columns of %s: %w", table, err)
+		}
+		existing := make(map[string]bool, len(columnTypes))
+		for _, column := range columnTypes {
+			existing[strings.ToLower(column.Name())] = true
+		}
+		for _, column := range sortedKeys(expected[table]) {
+			if !existing[column] {
+				report.Missing = append(report.Missing, table+"."+column)
+			}
+		}
+	}
+	return report, nil
+}
+
+// blockedBy returns the tables and columns this build uses that a
+// migration drops, renames or retypes
+func (r *Runner) blockedBy(m Migration) []string {
+	expected, err := r.expectedColumns()
+	if err != nil {
+		return nil
+	}
+	var blocked []string
+	for _, change := range Parse(m.Up) {
+		switch change.Kind {
+		case ChangeDropTable, ChangeDropColumn, ChangeRename, ChangeRetype:
+		default:
+			continue
+		}
+		columns, used := expected[change.Table]
+		if !used {
+			continue
+		}
+		if change.Column == "" {
+			blocked = append(blocked, change.Table)
+		} else if columns[change.Column] {
+			blocked = append(blocked, change.Table+"."+change.Column)
+		}
+	}
+	return blocked
+}
+
+// expectedColumns returns the columns of each table the models map to
+func (r *Runner) expectedColumns() (map[string]map[string]bool, error) {
+	expected := make(map[string]map[string]bool)
+	for _, model := range r.models {
+		stmt := &gorm.Statement{DB: r.db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, fmt.Errorf("failed to parse model %T: %w", model, err)
+		}
+		columns := expected[stmt.Schema.Table]
+		if columns == nil {
+			columns = make(map[string]bool)
+			expected[stmt.Schema.Table] = columns
+		}
+		for _, name := range stmt.Schema.DBNames {
+			columns[strings.ToLower(name)] = true
+		}
+	}
+	return expected, nil
+}
+
+// Gate is a readiness check failing while the schema is behind this
+// build. The result is cached for an interval so frequent probes do not
+// each query the catalog.
+type Gate struct {
+	runner   *Runner
+	interval time.Duration
+	clock    clock.Clock
+
+	mutex     sync.Mutex
+	checkedAt time.Time
+	err       error
+}
+
+// NewGate creates a readiness gate for the runner's models
+func NewGate(runner *Runner, interval time.Duration) *Gate {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	return &Gate{runner: runner, interval: interval, clock: clock.New()}
+}
+
+// WithClock sets the time source for the cache
+func (g *Gate) WithClock(c clock.Clock) *Gate {
+	g.clock = clock.OrDefault(c)
+	return g
+}
+
+// Check returns nil when this build's schema is in place, or an error
+// wrapping ErrIncompatible naming what is missing
+func (g *Gate) Check(ctx context.Context) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if !g.checkedAt.IsZero() && g.clock.Since(g.checkedAt) < g.interval {
+		return g.err
+	}
+
+	report, err := g.runner.Check(ctx)
+	switch {
+	case err != nil:
+		// Not cached, so the next probe tries again
+		return fmt.Errorf("failed to check schema: %w", err)
+	case !report.Compatible():
+		g.err = fmt.Errorf("%w: missing %s", ErrIncompatible, strings.Join(report.Missing, ", "))
+	default:
+		g.err = nil
+	}
+	g.checkedAt = g.clock.Now()
+	return g.err
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
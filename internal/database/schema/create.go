@@ -0,0 +1,63 @@
+package schema
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// namePattern is what Create accepts as a migration name once lowercased
+// and with spaces and dashes turned into underscores
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// Create scaffolds the up and down files of a new migration in dir,
+// numbered after the last one, and returns their paths. Contract
+// migrations get the phase header; expand is the default and needs none.
+func Create(dir, name string, phase Phase) (up, down string, err error) {
+	name = strings.ToLower(strings.NewReplacer(" ", "_", "-", "_").Replace(strings.TrimSpace(name)))
+	if !namePattern.MatchString(name) {
+		return "", "", fmt.Errorf("invalid migration name %q: use letters, digits and underscores", name)
+	}
+	if phase != PhaseExpand && phase != PhaseContract {
+		return "", "", fmt.Errorf("unknown phase %q", phase)
+	}
+
+	migrations, err := Load(dir)
+	if err != nil {
+		return "", "", err
+	}
+	m := Migration{Version: 1, Name: name, Phase: phase}
+	if len(migrations) > 0 {
+		m.Version = migrations[len(migrations)-1].Version + 1
+	}
+
+	header := ""
+	if phase == PhaseContract {
+		header = "-- phase: contract\n"
+	}
+	up = filepath.Join(dir, m.String()+".up.sql")
+	down = filepath.Join(dir, m.String()+".down.sql")
+	if err := writeNew(up, header+"-- "+m.String()+"\n"); err != nil {
+		return "", "", err
+	}
+	if err := writeNew(down, "-- Undo "+m.String()+"\n"); err != nil {
+		os.Remove(up)
+		return "", "", err
+	}
+	return up, down, nil
+}
+
+// writeNew writes a file that must not exist yet
+func writeNew(path, content string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if _, err := file.WriteString(content); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return file.Close()
+}
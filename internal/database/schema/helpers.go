@@ -0,0 +1,52 @@
+package schema
+
+import "fmt"
+
+// Step is the SQL of a change split across an expand and a contract
+// migration. Between the two, the new build writes both the old and the
+// new column so either build can serve traffic.
+type Step struct {
+	Expand   string
+	Contract string
+}
+
+// RenameColumn renames table.from to table.to of the given type. Expand
+// adds and backfills the new column; contract drops the old one.
+func RenameColumn(table, from, to, columnType string) Step {
+	return Step{
+		Expand: fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s;\n\nUPDATE %s SET %s = %s WHERE %s IS NULL;\n",
+			table, to, columnType, table, to, from, to),
+		Contract: fmt.Sprintf("-- phase: contract\nALTER TABLE %s DROP COLUMN IF EXISTS %s;\n", table, from),
+	}
+}
+
+// ChangeColumnType moves table.column to a new column of another type,
+// converting existing values with the using expression, e.g.
+// "CAST(amount AS NUMERIC(12,2))". Expand adds and backfills the new
+// column; contract drops the old one.
+func ChangeColumnType(table, column, newColumn, newType, using string) Step {
+	return Step{
+		Expand: fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s;\n\nUPDATE %s SET %s = %s WHERE %s IS NULL;\n",
+			table, newColumn, newType, table, newColumn, using, newColumn),
+		Contract: fmt.Sprintf("-- phase: contract\nALTER TABLE %s DROP COLUMN IF EXISTS %s;\n", table, column),
+	}
+}
+
+// AddRequiredColumn adds a column that will be NOT NULL. Expand adds it
+// with a default and backfills existing rows; contract adds the
+// constraint once no build inserts rows without it.
+func AddRequiredColumn(table, column, columnType, defaultValue string) Step {
+	return Step{
+		Expand: fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s DEFAULT %s;\n\nUPDATE %s SET %s = %s WHERE %s IS NULL;\n",
+			table, column, columnType, defaultValue, table, column, defaultValue, column),
+		Contract: fmt.Sprintf("-- phase: contract\nALTER TABLE %s ALTER COLUMN %s SET NOT NULL;\n", table, column),
+	}
+}
+
+// DropColumn drops table.column. Expand has nothing to do: the new build
+// simply stops using the column before contract drops it.
+func DropColumn(table, column string) Step {
+	return Step{
+		Contract: fmt.Sprintf("-- phase: contract\nALTER TABLE %s DROP COLUMN IF EXISTS %s;\n", table, column),
+	}
+}
@@ -0,0 +1,89 @@
+package schema
+
+import "fmt"
+
+// Lint rules
+const (
+	// RuleDestructiveExpand: drops, truncates and new NOT NULL constraints
+	// break the build still running during a deploy
+	RuleDestructiveExpand = "destructive-expand"
+	// RuleRename: renames and type changes break whichever build expects the
+	// other name or type, so they are done as add, backfill, then drop
+	RuleRename = "rename"
+	// RuleRequiredColumn: a NOT NULL column without a default rejects
+	// inserts from the build that does not know it
+	RuleRequiredColumn = "required-column"
+	// RuleContractAdds: contract migrations only remove, so an expand
+	// change in one would not be live before the new build starts
+	RuleContractAdds = "contract-adds"
+	// RuleMissingDown: every migration can be rolled back
+	RuleMissingDown = "missing-down"
+	// RuleVersionGap: versions are sequential so migrations apply in the
+	// order they were written
+	RuleVersionGap = "version-gap"
+)
+
+// Issue is a lint rule broken by a migration
+type Issue struct {
+	Migration string
+	Rule      string
+	Message   string
+	Statement string
+}
+
+// String formats the issue for the command line
+func (i Issue) String() string {
+	if i.Statement == "" {
+		return fmt.Sprintf("%s: [%s] %s", i.Migration, i.Rule, i.Message)
+	}
+	return fmt.Sprintf("%s: [%s] %s\n\t%s", i.Migration, i.Rule, i.Message, i.Statement)
+}
+
+// Lint checks that migrations follow the expand/contract rules
+func Lint(migrations []Migration) []Issue {
+	var issues []Issue
+	for i, m := range migrations {
+		if i > 0 && m.Version != migrations[i-1].Version+1 {
+			issues = append(issues, Issue{
+				Migration: m.String(),
+				Rule:      RuleVersionGap,
+				Message:   fmt.Sprintf("follows version %03d", migrations[i-1].Version),
+			})
+		}
+		if m.Down == "" {
+			issues = append(issues, Issue{Migration: m.String(), Rule: RuleMissingDown, Message: "has no down file"})
+		}
+		issues = append(issues, lintMigration(m)...)
+	}
+	return issues
+}
+
+func lintMigration(m Migration) []Issue {
+	var issues []Issue
+	add := func(change Change, rule, message string) {
+		issues = append(issues, Issue{Migration: m.String(), Rule: rule, Message: message, Statement: change.Statement})
+	}
+
+	for _, change := range Parse(m.Up) {
+		target := change.Table
+		if change.Column != "" {
+			target += "." + change.Column
+		}
+
+		switch change.Kind {
+		case ChangeRename, ChangeRetype:
+			add(change, RuleRename, fmt.Sprintf("renames or retypes %s; add the new column, backfill it, and drop the old one in a contract migration", target))
+		case ChangeAddRequiredColumn:
+			add(change, RuleRequiredColumn, fmt.Sprintf("adds %s as NOT NULL without a default; give it a default or add the constraint in a contract migration", target))
+		case ChangeDropTable, ChangeDropColumn, ChangeTruncate, ChangeSetNotNull:
+			if m.Phase != PhaseContract {
+				add(change, RuleDestructiveExpand, fmt.Sprintf("changes %s destructively; move it to a migration marked \"-- phase: contract\"", target))
+			}
+		case ChangeCreateTable, ChangeAddColumn:
+			if m.Phase == PhaseContract {
+				add(change, RuleContractAdds, fmt.Sprintf("adds %s in a contract migration; move it to an expand migration", target))
+			}
+		}
+	}
+	return issues
+}
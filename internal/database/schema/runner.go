@@ -0,0 +1,271 @@
+package schema
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/models"
+	"go-server/internal/logger"
+
+	"gorm.io/gorm"
+)
+
+// ErrBlocked is returned when a contract migration would drop schema the
+// running build still uses
+var ErrBlocked = errors.New("contract migration would break the running build")
+
+// LockName is the distributed lock migrations run under
+const LockName = "schema:migrate"
+
+// lockTTL is how long the migration lock outlives a holder that stops
+// renewing it
+const lockTTL = time.Minute
+
+// Locker serializes migrations across servers and deploy steps;
+// *repositories.CacheRepository satisfies it
+type Locker interface {
+	WithLockWait(ctx context.Context, name string, ttl time.Duration, fn func(ctx context.Context, token int64) error) error
+}
+
+// Locked runs fn holding the migration lock, waiting while another server
+// or deploy step holds it. With a nil locker fn runs at once.
+func Locked(ctx context.Context, locker Locker, fn func(ctx context.Context) error) error {
+	if locker == nil {
+		return fn(ctx)
+	}
+	return locker.WithLockWait(ctx, LockName, lockTTL, func(ctx context.Context, _ int64) error {
+		return fn(ctx)
+	})
+}
+
+// Runner applies migrations and records them in the schema_migrations table
+type Runner struct {
+	db         *gorm.DB
+	migrations []Migration
+	models     []interface{}
+	logger     logger.Logger
+	clock      clock.Clock
+	locker     Locker
+}
+
+// NewRunner creates a new migration runner
+func NewRunner(db *gorm.DB, migrations []Migration, logger logger.Logger) *Runner {
+	return &Runner{db: db, migrations: migrations, logger: logger, clock: clock.New()}
+}
+
+// WithModels sets the models this build reads and writes, which Check
+// compares against the database
+func (r *Runner) WithModels(models ...interface{}) *Runner {
+	r.models = models
+	return r
+}
+
+// WithClock sets the time source for applied_at
+func (r *Runner) WithClock(c clock.Clock) *Runner {
+	r.clock = clock.OrDefault(c)
+	return r
+}
+
+// WithLocker makes Apply and Rollback hold the migration lock, so runs
+// started together apply each migration once
+func (r *Runner) WithLocker(locker Locker) *Runner {
+	r.locker = locker
+	return r
+}
+
+// Applied returns the versions already applied
+func (r *Runner) Applied(ctx context.Context) (map[int]bool, error) {
+	applied := make(map[int]bool)
+	if !r.db.WithContext(ctx).Migrator().HasTable(&models.SchemaMigration{}) {
+		return applied, nil
+	}
+	var versions []int
+	if err := r.db.WithContext(ctx).Model(&models.SchemaMigration{}).Pluck("version", &versions).Error; err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	for _, version := range versions {
+		applied[version] = true
+	}
+	return applied, nil
+}
+
+// Pending returns the migrations not yet applied, in order
+func (r *Runner) Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := r.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, m := range r.migrations {
+		if !applied[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// Apply runs pending migrations in order, each in its own transaction.
+// With PhaseExpand it stops before the first contract migration, so it is
+// safe while the previous build still serves traffic; PhaseContract runs
+// everything and must wait until the previous build is gone. A contract
+// migration dropping schema this build's models still use is refused with
+// ErrBlocked.
+func (r *Runner) Apply(ctx context.Context, phase Phase) (applied []Migration, err error) {
+	err = Locked(ctx, r.locker, func(ctx context.Context) error {
+		applied, err = r.apply(ctx, phase)
+		return err
+	})
+	return applied, err
+}
+
+func (r *Runner) apply(ctx context.Context, phase Phase) ([]Migration, error) {
+	if err := r.db.WithContext(ctx).AutoMigrate(&models.SchemaMigration{}); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	pending, err := r.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, m := range pending {
+		if m.Phase == PhaseContract {
+			if phase != PhaseContract {
+				r.logger.Info("Stopping at contract migration %s; run the contract phase once the previous build is gone", m)
+				break
+			}
+			if blocked := r.blockedBy(m); len(blocked) > 0 {
+				return applied, fmt.Errorf("%w: %s changes %s", ErrBlocked, m, strings.Join(blocked, ", "))
+			}
+		}
+
+		err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(m.Up).Error; err != nil {
+				return err
+			}
+			return tx.Create(&models.SchemaMigration{
+				Version:   m.Version,
+				Name:      m.Name,
+				Phase:     string(m.Phase),
+				AppliedAt: r.clock.Now(),
+			}).Error
+		})
+		if err != nil {
+			return applied, fmt.Errorf("failed to apply migration %s: %w", m, err)
+		}
+		r.logger.Info("Applied %s migration %s", m.Phase, m)
+		applied = append(applied, m)
+	}
+	return applied, nil
+}
+
+// Rollback runs the down files of the last steps applied migrations, newest
+// first, each in its own transaction. Roll back only once no running build
+// needs the schema the migrations added.
+func (r *Runner) Rollback(ctx context.Context, steps int) (rolledBack []Migration, err error) {
+	err = Locked(ctx, r.locker, func(ctx context.Context) error {
+		rolledBack, err = r.rollback(ctx, steps)
+		return err
+	})
+	return rolledBack, err
+}
+
+func (r *Runner) rollback(ctx context.Context, steps int) ([]Migration, error) {
+	if steps < 1 {
+		return nil, nil
+	}
+	if !r.db.WithContext(ctx).Migrator().HasTable(&models.SchemaMigration{}) {
+		return nil, nil
+	}
+	var records []models.SchemaMigration
+	err := r.db.WithContext(ctx).Order("version DESC").Limit(steps).Find(&records).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+
+	byVersion := make(map[int]Migration, len(r.migrations))
+	for _, m := range r.migrations {
+		byVersion[m.Version] = m
+	}
+	var rolledBack []Migration
+	for _, record := range records {
+		m, ok := byVersion[record.Version]
+		if !ok {
+			return rolledBack, fmt.Errorf("applied migration %03d_%s has no files", record.Version, record.Name)
+		}
+		if strings.TrimSpace(m.Down) == "" {
+			return rolledBack, fmt.Errorf("migration %s has no down file", m)
+		}
+
+		err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(m.Down).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&models.SchemaMigration{}, "version = ?", m.Version).Error
+		})
+		if err != nil {
+			return rolledBack, fmt.Errorf("failed to roll back migration %s: %w", m, err)
+		}
+		r.logger.Info("Rolled back %s migration %s", m.Phase, m)
+		rolledBack = append(rolledBack, m)
+	}
+	return rolledBack, nil
+}
+
+// Status is whether one migration has been applied
+type Status struct {
+	Migration string     `json:"migration"`
+	Phase     Phase      `json:"phase"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+	// Missing is set for applied migrations whose files are gone, such as
+	// after checking out an older build
+	Missing bool `json:"missing,omitempty"`
+}
+
+// Status lists every migration, applied or not, by version
+func (r *Runner) Status(ctx context.Context) ([]Status, error) {
+	var records []models.SchemaMigration
+	if r.db.WithContext(ctx).Migrator().HasTable(&models.SchemaMigration{}) {
+		if err := r.db.WithContext(ctx).Order("version").Find(&records).Error; err != nil {
+			return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+		}
+	}
+	applied := make(map[int]models.SchemaMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+
+	statuses := make([]Status, 0, len(r.migrations))
+	known := make(map[int]bool, len(r.migrations))
+	for _, m := range r.migrations {
+		known[m.Version] = true
+		status := Status{Migration: m.String(), Phase: m.Phase}
+		if record, ok := applied[m.Version]; ok {
+			appliedAt := record.AppliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	for _, record := range records {
+		if known[record.Version] {
+			continue
+		}
+		appliedAt := record.AppliedAt
+		statuses = append(statuses, Status{
+			Migration: fmt.Sprintf("%03d_%s", record.Version, record.Name),
+			Phase:     Phase(record.Phase),
+			Applied:   true,
+			AppliedAt: &appliedAt,
+			Missing:   true,
+		})
+	}
+	sort.SliceStable(statuses, func(i, j int) bool { return statuses[i].Migration < statuses[j].Migration })
+	return statuses, nil
+}
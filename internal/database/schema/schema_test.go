@@ -0,0 +1,274 @@
+package schema
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/dbtest"
+	"go-server/internal/logger"
+)
+
+func TestParse(t *testing.T) {
+	sql := `
+-- phase: contract
+CREATE TABLE IF NOT EXISTS "Widgets" (id SERIAL PRIMARY KEY, name TEXT NOT NULL);
+ALTER TABLE public.users
+    ADD COLUMN nickname VARCHAR(50) NOT NULL,
+    ADD COLUMN IF NOT EXISTS bio TEXT NOT NULL DEFAULT '',
+    ADD CONSTRAINT users_bio_check CHECK (length(bio) < 500),
+    DROP COLUMN IF EXISTS legacy;
+ALTER TABLE posts RENAME COLUMN body TO content;
+ALTER TABLE posts ALTER COLUMN views TYPE BIGINT;
+ALTER TABLE posts ALTER COLUMN summary TYPE VARCHAR(1024);
+ALTER TABLE posts ALTER COLUMN slug SET NOT NULL;
+UPDATE posts SET title = 'a;b -- not a comment';
+DROP TABLE IF EXISTS categories, tags CASCADE;
+`
+	expected := []Change{
+		{Kind: ChangeCreateTable, Table: "widgets"},
+		{Kind: ChangeAddRequiredColumn, Table: "users", Column: "nickname"},
+		{Kind: ChangeAddColumn, Table: "users", Column: "bio"},
+		{Kind: ChangeDropColumn, Table: "users", Column: "legacy"},
+		{Kind: ChangeRename, Table: "posts", Column: "body"},
+		{Kind: ChangeRetype, Table: "posts", Column: "views"},
+		{Kind: ChangeRetypeText, Table: "posts", Column: "summary"},
+		{Kind: ChangeSetNotNull, Table: "posts", Column: "slug"},
+		{Kind: ChangeDropTable, Table: "categories"},
+		{Kind: ChangeDropTable, Table: "tags"},
+	}
+
+	changes := Parse(sql)
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected %d changes, got %+v", len(expected), changes)
+	}
+	for i, change := range changes {
+		if change.Kind != expected[i].Kind || change.Table != expected[i].Table || change.Column != expected[i].Column {
+			t.Errorf("Change %d = %s %s.%s, expected %s %s.%s", i, change.Kind, change.Table, change.Column,
+				expected[i].Kind, expected[i].Table, expected[i].Column)
+		}
+	}
+	if statements := Statements(sql); len(statements) != 8 {
+		t.Errorf("Expected 8 statements, got %d: %q", len(statements), statements)
+	}
+}
+
+func TestLint(t *testing.T) {
+	migrations := []Migration{
+		{Version: 1, Name: "create_widgets", Phase: PhaseExpand, Up: "CREATE TABLE widgets (id INT, name TEXT);", Down: "DROP TABLE widgets;"},
+		{Version: 2, Name: "drop_name", Phase: PhaseExpand, Up: "ALTER TABLE widgets DROP COLUMN name;", Down: "x"},
+		{Version: 3, Name: "rename", Phase: PhaseExpand, Up: "ALTER TABLE widgets RENAME COLUMN name TO title;", Down: "x"},
+		{Version: 4, Name: "required", Phase: PhaseExpand, Up: "ALTER TABLE widgets ADD COLUMN size INT NOT NULL;"},
+		{Version: 6, Name: "contract", Phase: PhaseContract, Up: "ALTER TABLE widgets DROP COLUMN name, ADD COLUMN color TEXT;", Down: "x"},
+	}
+
+	rules := make(map[string]int)
+	for _, issue := range Lint(migrations) {
+		rules[issue.Rule]++
+	}
+	expected := map[string]int{
+		RuleDestructiveExpand: 1,
+		RuleRename:            1,
+		RuleRequiredColumn:    1,
+		RuleMissingDown:       1,
+		RuleVersionGap:        1,
+		RuleContractAdds:      1,
+	}
+	for rule, count := range expected {
+		if rules[rule] != count {
+			t.Errorf("Expected %d %s issues, got %d (all: %v)", count, rule, rules[rule], rules)
+		}
+	}
+}
+
+func TestLint_RepositoryMigrations(t *testing.T) {
+	migrations, err := Load("../../../migrations")
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	for _, issue := range Lint(migrations) {
+		t.Error(issue)
+	}
+}
+
+func TestHelpers_FollowTheRules(t *testing.T) {
+	steps := []Step{
+		RenameColumn("posts", "body", "content", "TEXT"),
+		ChangeColumnType("posts", "views", "view_count", "BIGINT", "CAST(views AS BIGINT)"),
+		AddRequiredColumn("users", "locale", "VARCHAR(10)", "'en'"),
+		DropColumn("users", "legacy"),
+	}
+	for i, step := range steps {
+		dir := t.TempDir()
+		writeMigration(t, dir, 1, "expand", step.Expand+"SELECT 1;")
+		writeMigration(t, dir, 2, "contract", step.Contract)
+		migrations, err := Load(dir)
+		if err != nil {
+			t.Fatalf("Failed to load step %d: %v", i, err)
+		}
+		if migrations[1].Phase != PhaseContract {
+			t.Errorf("Step %d contract should be marked as a contract migration", i)
+		}
+		for _, issue := range Lint(migrations) {
+			t.Errorf("Step %d: %s", i, issue)
+		}
+	}
+}
+
+type widget struct {
+	ID   uint
+	Name string
+}
+
+type widgetV2 struct {
+	ID    uint
+	Color string
+}
+
+func (widgetV2) TableName() string { return "widgets" }
+
+func TestRunner_ExpandContract(t *testing.T) {
+	ctx := context.Background()
+	db := dbtest.Open(t)
+
+	dir := t.TempDir()
+	writeMigration(t, dir, 1, "create_widgets", "CREATE TABLE widgets (id INTEGER PRIMARY KEY, name TEXT);")
+	writeMigration(t, dir, 2, "add_color", "ALTER TABLE widgets ADD COLUMN color TEXT;")
+	writeMigration(t, dir, 3, "drop_name", "-- phase: contract\nALTER TABLE widgets DROP COLUMN name;")
+	migrations, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	// The old build uses name; the new build has moved to color
+	fake := clock.NewFake(time.Now())
+	oldBuild := NewRunner(db, migrations, logger.NewServerLogger()).WithModels(&widget{})
+	newBuild := NewRunner(db, migrations, logger.NewServerLogger()).WithModels(&widgetV2{}).WithClock(fake)
+	gate := NewGate(newBuild, time.Minute).WithClock(fake)
+
+	if err := gate.Check(ctx); !errors.Is(err, ErrIncompatible) {
+		t.Fatalf("Expected the gate to hold the new build back, got %v", err)
+	}
+
+	applied, err := newBuild.Apply(ctx, PhaseExpand)
+	if err != nil || len(applied) != 2 {
+		t.Fatalf("Expand should stop before the contract migration, applied %v, %v", applied, err)
+	}
+
+	report, err := oldBuild.Check(ctx)
+	if err != nil || !report.Compatible() || len(report.Pending) != 1 || len(report.Blocked) != 1 {
+		t.Errorf("Old build should still work with the contract pending and blocked, got %+v, %v", report, err)
+	}
+	if _, err := oldBuild.Apply(ctx, PhaseContract); !errors.Is(err, ErrBlocked) {
+		t.Errorf("The old build must not drop a column it uses, got %v", err)
+	}
+
+	if err := gate.Check(ctx); !errors.Is(err, ErrIncompatible) {
+		t.Errorf("Gate result should be cached, got %v", err)
+	}
+	fake.Advance(time.Minute)
+	if err := gate.Check(ctx); err != nil {
+		t.Errorf("New build should be ready after the expand phase, got %v", err)
+	}
+
+	if applied, err := newBuild.Apply(ctx, PhaseContract); err != nil || len(applied) != 1 {
+		t.Fatalf("Contract failed: %v, %v", applied, err)
+	}
+	if report, _ := newBuild.Check(ctx); !report.Compatible() || len(report.Pending) != 0 {
+		t.Errorf("Expected no pending migrations, got %+v", report)
+	}
+	if report, _ := oldBuild.Check(ctx); report.Compatible() {
+		t.Error("The old build should be incompatible once the contract has run")
+	}
+}
+
+func writeMigration(t *testing.T, dir string, version int, name, up string) {
+	t.Helper()
+	base := filepath.Join(dir, fmt.Sprintf("%03d_%s", version, name))
+	if err := os.WriteFile(base+".up.sql", []byte(up), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(base+".down.sql", []byte("SELECT 1;"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunner_RollbackAndStatus(t *testing.T) {
+	ctx := context.Background()
+	db := dbtest.Open(t)
+
+	dir := t.TempDir()
+	writeMigration(t, dir, 1, "create_widgets", "CREATE TABLE widgets (id INTEGER PRIMARY KEY, name TEXT);")
+	writeMigration(t, dir, 2, "add_color", "ALTER TABLE widgets ADD COLUMN color TEXT;")
+	if err := os.WriteFile(filepath.Join(dir, "002_add_color.down.sql"), []byte("ALTER TABLE widgets DROP COLUMN color;"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	migrations, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	runner := NewRunner(db, migrations, logger.NewServerLogger())
+
+	statuses, err := runner.Status(ctx)
+	if err != nil || len(statuses) != 2 || statuses[0].Applied || statuses[1].Applied {
+		t.Fatalf("Expected two pending migrations before the first run, got %+v, %v", statuses, err)
+	}
+	if _, err := runner.Apply(ctx, PhaseExpand); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	rolledBack, err := runner.Rollback(ctx, 1)
+	if err != nil || len(rolledBack) != 1 || rolledBack[0].Version != 2 {
+		t.Fatalf("Expected the newest migration rolled back, got %v, %v", rolledBack, err)
+	}
+	if db.Migrator().HasColumn("widgets", "color") {
+		t.Error("Expected the down file to drop color")
+	}
+	statuses, _ = runner.Status(ctx)
+	if !statuses[0].Applied || statuses[0].AppliedAt == nil || statuses[1].Applied {
+		t.Errorf("Expected only the first migration applied, got %+v", statuses)
+	}
+
+	// An older build without the first migration's files still lists it
+	older := NewRunner(db, migrations[1:], logger.NewServerLogger())
+	statuses, _ = older.Status(ctx)
+	if len(statuses) != 2 || !statuses[0].Missing || statuses[0].Migration != "001_create_widgets" {
+		t.Errorf("Expected the applied migration reported missing, got %+v", statuses)
+	}
+	if _, err := older.Rollback(ctx, 1); err == nil {
+		t.Error("Expected rolling back a migration without files to fail")
+	}
+
+	if rolledBack, err := runner.Rollback(ctx, 5); err != nil || len(rolledBack) != 1 {
+		t.Errorf("Expected rolling back past the first migration to stop there, got %v, %v", rolledBack, err)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	writeMigration(t, dir, 7, "create_widgets", "CREATE TABLE widgets (id INTEGER PRIMARY KEY);")
+
+	up, down, err := Create(dir, "Drop widget-name", PhaseContract)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if filepath.Base(up) != "008_drop_widget_name.up.sql" || filepath.Base(down) != "008_drop_widget_name.down.sql" {
+		t.Errorf("Expected the next version's files, got %s and %s", up, down)
+	}
+	migrations, err := Load(dir)
+	if err != nil || len(migrations) != 2 || migrations[1].Phase != PhaseContract {
+		t.Fatalf("Expected the new contract migration to load, got %+v, %v", migrations, err)
+	}
+
+	if _, _, err := Create(dir, "drop_widget_name", PhaseExpand); err != nil {
+		t.Errorf("Expected a second migration as 009, got %v", err)
+	}
+	if _, _, err := Create(dir, "1; rm -rf", PhaseExpand); err == nil {
+		t.Error("Expected an invalid name to be refused")
+	}
+}
@@ -0,0 +1,166 @@
+package seed
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-server/internal/auth"
+	"go-server/internal/database/models"
+	"go-server/internal/rbac"
+
+	"gorm.io/gorm"
+)
+
+// Config holds the values of the default seeds
+type Config struct {
+	AdminEmail    string
+	AdminUsername string
+	// AdminPassword is only used to create the administrator; changing it
+	// does not change an existing administrator's password
+	AdminPassword string
+}
+
+// devAdminPassword is the administrator's password in development and test
+// unless another is set
+const devAdminPassword = "admin-dev-password"
+
+// DefaultConfig returns the default seed values for environment. Only
+// development and test get a default administrator password; elsewhere
+// the administrator is skipped until one is set.
+func DefaultConfig(environment string) Config {
+	config := Config{AdminEmail: "admin@example.com", AdminUsername: "admin"}
+	if environment == EnvDevelopment || environment == EnvTest {
+		config.AdminPassword = devAdminPassword
+	}
+	return config
+}
+
+// category is a row of the categories table, which has no model yet
+type category struct {
+	ID          uint   `json:"-" gorm:"primaryKey"`
+	Name        string `json:"name"`
+	Slug        string `json:"slug"`
+	Description string `json:"description"`
+	Color       string `json:"color"`
+	IsActive    bool   `json:"is_active"`
+}
+
+// TableName returns the table name for category
+func (category) TableName() string {
+	return "categories"
+}
+
+// demoPost is a post the demo-posts seed writes as the administrator
+type demoPost struct {
+	Title   string `json:"title"`
+	Slug    string `json:"slug"`
+	Content string `json:"content"`
+	Status  string `json:"status"`
+}
+
+// Defaults returns the seeds every environment starts from: the
+// administrator and the post categories everywhere, and demo posts in
+// development and test
+func Defaults(config Config) []Seed {
+	admin := struct {
+		Email    string `json:"email"`
+		Username string `json:"username"`
+		Role     string `json:"role"`
+	}{config.AdminEmail, config.AdminUsername, rbac.RoleAdmin}
+
+	categories := []category{
+		{Name: "General", Slug: "general", Description: "Everything else", Color: "#007bff", IsActive: true},
+		{Name: "Announcements", Slug: "announcements", Description: "News about the service", Color: "#dc3545", IsActive: true},
+		{Name: "Engineering", Slug: "engineering", Description: "How things are built", Color: "#28a745", IsActive: true},
+		{Name: "Product", Slug: "product", Description: "Features and releases", Color: "#6f42c1", IsActive: true},
+	}
+
+	posts := []demoPost{
+		{
+			Title:   "Welcome to the demo",
+			Slug:    "welcome-to-the-demo",
+			Content: "This post was created by the demo-posts seed. Edit or delete it freely; seeding again will not bring it back unless it is missing.",
+			Status:  "published",
+		},
+		{
+			Title:   "Writing your first post",
+			Slug:    "writing-your-first-post",
+			Content: "Posts start as drafts and appear in listings once published.",
+			Status:  "published",
+		},
+		{
+			Title:   "A draft in progress",
+			Slug:    "a-draft-in-progress",
+			Content: "This one stays a draft, to show how drafts are listed.",
+			Status:  "draft",
+		},
+	}
+
+	return []Seed{
+		{
+			Name: "admin-user",
+			Data: admin,
+			Insert: func(ctx context.Context, tx *gorm.DB) error {
+				if config.AdminPassword == "" {
+					return fmt.Errorf("%w: no administrator password set", ErrSkip)
+				}
+				password, err := auth.HashPassword(config.AdminPassword)
+				if err != nil {
+					return err
+				}
+				return InsertMissing(tx, &models.User{
+					Email:    admin.Email,
+					Username: admin.Username,
+					Password: password,
+					IsActive: true,
+					IsAdmin:  true,
+					Role:     admin.Role,
+				})
+			},
+		},
+		{
+			Name: "categories",
+			Data: categories,
+			Insert: func(ctx context.Context, tx *gorm.DB) error {
+				if !tx.Migrator().HasTable(&category{}) {
+					return fmt.Errorf("%w: no categories table", ErrSkip)
+				}
+				rows := make([]category, len(categories))
+				copy(rows, categories)
+				return InsertMissing(tx, &rows)
+			},
+		},
+		{
+			Name:         "demo-posts",
+			Environments: []string{EnvDevelopment, EnvTest},
+			Data:         posts,
+			Insert: func(ctx context.Context, tx *gorm.DB) error {
+				var author models.User
+				if err := tx.Where("email = ?", config.AdminEmail).Limit(1).Find(&author).Error; err != nil {
+					return err
+				}
+				if author.ID == 0 {
+					return fmt.Errorf("%w: no administrator to author them", ErrSkip)
+				}
+
+				now := time.Now()
+				rows := make([]models.Post, 0, len(posts))
+				for _, post := range posts {
+					row := models.Post{
+						Title:    post.Title,
+						Slug:     post.Slug,
+						Content:  post.Content,
+						Status:   post.Status,
+						AuthorID: author.ID,
+					}
+					if post.Status == "published" {
+						row.PublishedAt = &now
+					}
+					rows = append(rows, row)
+				}
+				return InsertMissing(tx, &rows)
+			},
+		},
+	}
+}
@@ -0,0 +1,167 @@
+// Package seed fills a database with the rows an environment needs to be
+// usable: an administrator, the post categories and, outside production,
+// demo content.
+//
+// Each seed names the environments it runs in and the data it inserts. A
+// seed runs once per version of its data: the Seeder records a checksum
+// of the data in the seed_runs table and skips seeds whose checksum has
+// not changed. Inserts leave rows that already exist alone, so running a
+// changed seed again only adds what is missing and never overwrites edits
+// made since.
+package seed
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/models"
+	"go-server/internal/logger"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Environments
+const (
+	EnvDevelopment = "development"
+	EnvTest        = "test"
+	EnvStaging     = "staging"
+	EnvProduction  = "production"
+)
+
+// ErrSkip is returned by a seed's Insert when it cannot run yet, e.g.
+// because a table it fills has not been migrated. The seed is not recorded
+// and runs again next time.
+var ErrSkip = errors.New("seed skipped")
+
+// Seed is a named set of rows
+type Seed struct {
+	Name string
+	// Environments the seed runs in; empty means every environment
+	Environments []string
+	// Data is what the seed inserts. Its checksum decides whether the seed
+	// has changed since it last ran, so it must not hold values generated
+	// afresh on each run, such as password hashes.
+	Data interface{}
+	// Insert adds the rows in Data that are missing, within a transaction
+	Insert func(ctx context.Context, tx *gorm.DB) error
+}
+
+// runsIn reports whether the seed runs in environment
+func (s Seed) runsIn(environment string) bool {
+	if len(s.Environments) == 0 {
+		return true
+	}
+	for _, env := range s.Environments {
+		if env == environment {
+			return true
+		}
+	}
+	return false
+}
+
+// Checksum returns the hex SHA-256 of the seed's data as JSON
+func (s Seed) Checksum() (string, error) {
+	data, err := json.Marshal(s.Data)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode seed %s: %w", s.Name, err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Outcome is what running a seed did
+type Outcome string
+
+// Seed outcomes
+const (
+	OutcomeApplied   Outcome = "applied"
+	OutcomeUnchanged Outcome = "unchanged"
+	OutcomeSkipped   Outcome = "skipped"
+)
+
+// Result is the outcome of one seed
+type Result struct {
+	Seed    string  `json:"seed"`
+	Outcome Outcome `json:"outcome"`
+	Reason  string  `json:"reason,omitempty"`
+}
+
+// Seeder runs seeds and records them in the seed_runs table
+type Seeder struct {
+	db     *gorm.DB
+	seeds  []Seed
+	logger logger.Logger
+	clock  clock.Clock
+}
+
+// NewSeeder creates a new seeder running seeds in order
+func NewSeeder(db *gorm.DB, logger logger.Logger, seeds ...Seed) *Seeder {
+	return &Seeder{db: db, seeds: seeds, logger: logger, clock: clock.New()}
+}
+
+// WithClock sets the time source for applied_at
+func (s *Seeder) WithClock(c clock.Clock) *Seeder {
+	s.clock = clock.OrDefault(c)
+	return s
+}
+
+// Run runs the seeds for environment whose data changed since they last
+// ran, or every one of them with force. It stops at the first seed that
+// fails, returning the results so far.
+func (s *Seeder) Run(ctx context.Context, environment string, force bool) ([]Result, error) {
+	if err := s.db.WithContext(ctx).AutoMigrate(&models.SeedRun{}); err != nil {
+		return nil, fmt.Errorf("failed to create seed_runs: %w", err)
+	}
+
+	var results []Result
+	for _, seed := range s.seeds {
+		if !seed.runsIn(environment) {
+			results = append(results, Result{Seed: seed.Name, Outcome: OutcomeSkipped, Reason: "not for " + environment})
+			continue
+		}
+		checksum, err := seed.Checksum()
+		if err != nil {
+			return results, err
+		}
+
+		var run models.SeedRun
+		err = s.db.WithContext(ctx).Where("name = ?", seed.Name).Limit(1).Find(&run).Error
+		if err != nil {
+			return results, fmt.Errorf("failed to read seed runs: %w", err)
+		}
+		if run.Checksum == checksum && !force {
+			results = append(results, Result{Seed: seed.Name, Outcome: OutcomeUnchanged})
+			continue
+		}
+
+		err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := seed.Insert(ctx, tx); err != nil {
+				return err
+			}
+			return tx.Save(&models.SeedRun{Name: seed.Name, Checksum: checksum, AppliedAt: s.clock.Now()}).Error
+		})
+		if errors.Is(err, ErrSkip) {
+			s.logger.Info("Skipped seed %s: %v", seed.Name, err)
+			results = append(results, Result{Seed: seed.Name, Outcome: OutcomeSkipped, Reason: err.Error()})
+			continue
+		}
+		if err != nil {
+			return results, fmt.Errorf("failed to run seed %s: %w", seed.Name, err)
+		}
+		s.logger.Info("Applied seed %s", seed.Name)
+		results = append(results, Result{Seed: seed.Name, Outcome: OutcomeApplied})
+	}
+	return results, nil
+}
+
+// InsertMissing inserts value, a row or a slice of rows, leaving rows that
+// conflict with existing ones on a unique column as they are
+func InsertMissing(tx *gorm.DB, value interface{}) error {
+	return tx.Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).Create(value).Error
+}
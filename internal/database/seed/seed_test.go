@@ -0,0 +1,136 @@
+package seed
+
+import (
+	"context"
+	"testing"
+
+	"go-server/internal/auth"
+	"go-server/internal/database/dbtest"
+	"go-server/internal/database/models"
+	"go-server/internal/logger"
+
+	"gorm.io/gorm"
+)
+
+func newSeedDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := dbtest.Open(t, &models.User{}, &models.Post{})
+	err := db.Exec(`CREATE TABLE categories (id INTEGER PRIMARY KEY, name TEXT UNIQUE NOT NULL,
+		slug TEXT UNIQUE NOT NULL, description TEXT, color TEXT, is_active BOOLEAN)`).Error
+	if err != nil {
+		t.Fatalf("Failed to create categories: %v", err)
+	}
+	return db
+}
+
+func outcomes(results []Result) map[string]Outcome {
+	byName := make(map[string]Outcome, len(results))
+	for _, result := range results {
+		byName[result.Seed] = result.Outcome
+	}
+	return byName
+}
+
+func TestSeeder_Development(t *testing.T) {
+	ctx := context.Background()
+	db := newSeedDB(t)
+	seeder := NewSeeder(db, logger.NewServerLogger(), Defaults(DefaultConfig(EnvDevelopment))...)
+
+	results, err := seeder.Run(ctx, EnvDevelopment, false)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	for name, outcome := range outcomes(results) {
+		if outcome != OutcomeApplied {
+			t.Errorf("Expected %s applied, got %s", name, outcome)
+		}
+	}
+	var admin models.User
+	if err := db.Where("email = ?", "admin@example.com").First(&admin).Error; err != nil || !admin.IsAdmin {
+		t.Fatalf("Expected the administrator, got %+v, %v", admin, err)
+	}
+	if !auth.CheckPasswordHash(devAdminPassword, admin.Password) {
+		t.Error("Expected the administrator's password to be hashed")
+	}
+
+	// Unchanged seeds do not run again
+	results, err = seeder.Run(ctx, EnvDevelopment, false)
+	if err != nil {
+		t.Fatalf("Second run failed: %v", err)
+	}
+	for name, outcome := range outcomes(results) {
+		if outcome != OutcomeUnchanged {
+			t.Errorf("Expected %s unchanged, got %s", name, outcome)
+		}
+	}
+
+	// Forcing adds back what is missing and leaves edits alone
+	db.Unscoped().Where("slug = ?", "welcome-to-the-demo").Delete(&models.Post{})
+	db.Model(&models.Post{}).Where("slug = ?", "a-draft-in-progress").Update("title", "Edited")
+	if _, err := seeder.Run(ctx, EnvDevelopment, true); err != nil {
+		t.Fatalf("Forced run failed: %v", err)
+	}
+	var posts []models.Post
+	db.Order("slug").Find(&posts)
+	if len(posts) != 3 || posts[0].Title != "Edited" {
+		t.Errorf("Expected the deleted post back and the edit kept, got %+v", posts)
+	}
+	var users, categories int64
+	db.Model(&models.User{}).Count(&users)
+	db.Table("categories").Count(&categories)
+	if users != 1 || categories != 4 {
+		t.Errorf("Expected no duplicates, got %d users and %d categories", users, categories)
+	}
+}
+
+func TestSeeder_Production(t *testing.T) {
+	ctx := context.Background()
+	db := newSeedDB(t)
+	config := DefaultConfig(EnvProduction)
+
+	results, err := NewSeeder(db, logger.NewServerLogger(), Defaults(config)...).Run(ctx, EnvProduction, false)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	expected := map[string]Outcome{"admin-user": OutcomeSkipped, "categories": OutcomeApplied, "demo-posts": OutcomeSkipped}
+	for name, outcome := range outcomes(results) {
+		if outcome != expected[name] {
+			t.Errorf("Expected %s %s, got %s", name, expected[name], outcome)
+		}
+	}
+
+	// A skipped seed is not recorded, so it runs once it can
+	config.AdminPassword = "a-long-production-password"
+	results, err = NewSeeder(db, logger.NewServerLogger(), Defaults(config)...).Run(ctx, EnvProduction, false)
+	if err != nil || outcomes(results)["admin-user"] != OutcomeApplied {
+		t.Errorf("Expected the administrator once a password is set, got %+v, %v", results, err)
+	}
+	var posts int64
+	db.Model(&models.Post{}).Count(&posts)
+	if posts != 0 {
+		t.Errorf("Expected no demo posts in production, got %d", posts)
+	}
+}
+
+func TestSeeder_ChangedData(t *testing.T) {
+	ctx := context.Background()
+	db := newSeedDB(t)
+	names := []string{"a"}
+	seed := func() Seed {
+		return Seed{
+			Name: "names",
+			Data: names,
+			Insert: func(ctx context.Context, tx *gorm.DB) error {
+				return nil
+			},
+		}
+	}
+
+	if results, _ := NewSeeder(db, logger.NewServerLogger(), seed()).Run(ctx, EnvTest, false); results[0].Outcome != OutcomeApplied {
+		t.Fatalf("Expected the seed applied, got %+v", results)
+	}
+	names = append(names, "b")
+	if results, _ := NewSeeder(db, logger.NewServerLogger(), seed()).Run(ctx, EnvTest, false); results[0].Outcome != OutcomeApplied {
+		t.Errorf("Expected changed data to run the seed again, got %+v", results)
+	}
+}
@@ -0,0 +1,159 @@
+// Package deactivation deactivates and reactivates user accounts. A
+// deactivated account cannot sign in, its sessions and the API keys of the
+// service accounts it created are revoked, and its posts and comments leave
+// public listings until it is reactivated.
+//
+// Users can reactivate an account they deactivated themselves once the
+// cool-down has passed, which stops an account being toggled to shed its
+// sessions and keys; an account deactivated by staff can only be
+// reactivated by staff, who are not held to the cool-down. Revoked keys are
+// not restored on reactivation.
+package deactivation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/logger"
+)
+
+// Deactivation errors
+var (
+	ErrAlreadyDeactivated = errors.New("account is already deactivated")
+	ErrNotDeactivated     = errors.New("account is not deactivated")
+	// ErrDeactivatedByStaff is returned when users try to reactivate an
+	// account staff deactivated
+	ErrDeactivatedByStaff = errors.New("account was deactivated by staff")
+)
+
+// CooldownError is returned when a user reactivates their account before
+// the cool-down has passed
+type CooldownError struct {
+	Until time.Time
+	// RetryAfter is how long is left until then
+	RetryAfter time.Duration
+}
+
+func (e *CooldownError) Error() string {
+	return fmt.Sprintf("account cannot be reactivated until %s", e.Until.UTC().Format(time.RFC3339))
+}
+
+// DefaultReactivationCooldown is the cool-down used when none is configured
+const DefaultReactivationCooldown = 24 * time.Hour
+
+// Config holds deactivation configuration
+type Config struct {
+	// ReactivationCooldown is how long after deactivating their account
+	// users must wait to reactivate it; zero means
+	// DefaultReactivationCooldown
+	ReactivationCooldown time.Duration
+}
+
+// SessionRevoker signs a deactivated user out everywhere
+type SessionRevoker interface {
+	DeleteAllUserSessions(ctx context.Context, userID uint) error
+}
+
+// KeyRevoker revokes the API keys of the service accounts a deactivated
+// user created
+type KeyRevoker interface {
+	RevokeCredentialsCreatedBy(ctx context.Context, userID uint, at time.Time) (int64, error)
+}
+
+// Service deactivates and reactivates accounts
+type Service struct {
+	users    *repositories.UserRepository
+	sessions SessionRevoker
+	keys     KeyRevoker
+	config   Config
+	clock    clock.Clock
+	logger   logger.Logger
+}
+
+// NewService creates a new deactivation service
+func NewService(users *repositories.UserRepository, sessions SessionRevoker, keys KeyRevoker, config Config, logger logger.Logger) *Service {
+	if config.ReactivationCooldown <= 0 {
+		config.ReactivationCooldown = DefaultReactivationCooldown
+	}
+	return &Service{
+		users:    users,
+		sessions: sessions,
+		keys:     keys,
+		config:   config,
+		clock:    clock.New(),
+		logger:   logger,
+	}
+}
+
+// WithClock overrides the clock used for the cool-down
+func (s *Service) WithClock(c clock.Clock) *Service {
+	s.clock = clock.OrDefault(c)
+	return s
+}
+
+// Deactivate deactivates user on behalf of actorID, the user themselves or
+// a member of staff, and revokes the user's sessions and API keys
+func (s *Service) Deactivate(ctx context.Context, user *models.User, actorID uint) error {
+	now := s.clock.Now()
+	changed, err := s.users.DeactivateUser(ctx, user.ID, actorID, now)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate account: %w", err)
+	}
+	if !changed {
+		return ErrAlreadyDeactivated
+	}
+	user.IsActive = false
+	user.DeactivatedAt = &now
+	user.DeactivatedByID = &actorID
+
+	// The account is already unusable; a failed revocation is only logged
+	if err := s.sessions.DeleteAllUserSessions(ctx, user.ID); err != nil {
+		s.logger.Error("Failed to revoke sessions of a deactivated account", "user_id", user.ID, "error", err.Error())
+	}
+	revoked, err := s.keys.RevokeCredentialsCreatedBy(ctx, user.ID, now)
+	if err != nil {
+		s.logger.Error("Failed to revoke API keys of a deactivated account", "user_id", user.ID, "error", err.Error())
+	}
+	s.logger.Info("Account deactivated", "user_id", user.ID, "deactivated_by", actorID, "revoked_keys", revoked)
+	return nil
+}
+
+// Reactivate reactivates user on behalf of actorID. Users reactivating
+// their own account must have deactivated it themselves and waited out the
+// cool-down; staff may reactivate any account at any time.
+func (s *Service) Reactivate(ctx context.Context, user *models.User, actorID uint) error {
+	if user.IsActive {
+		return ErrNotDeactivated
+	}
+	if actorID == user.ID {
+		// Accounts deactivated before their deactivator was recorded count
+		// as deactivated by staff
+		if user.DeactivatedByID == nil || *user.DeactivatedByID != user.ID {
+			return ErrDeactivatedByStaff
+		}
+		if user.DeactivatedAt != nil {
+			until := user.DeactivatedAt.Add(s.config.ReactivationCooldown)
+			if now := s.clock.Now(); now.Before(until) {
+				return &CooldownError{Until: until, RetryAfter: until.Sub(now)}
+			}
+		}
+	}
+
+	changed, err := s.users.ReactivateUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to reactivate account: %w", err)
+	}
+	if !changed {
+		return ErrNotDeactivated
+	}
+	user.IsActive = true
+	user.DeactivatedAt = nil
+	user.DeactivatedByID = nil
+	s.logger.Info("Account reactivated", "user_id", user.ID, "reactivated_by", actorID)
+	return nil
+}
@@ -0,0 +1,184 @@
+package deactivation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/dbtest"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/logger"
+
+	"gorm.io/gorm"
+)
+
+type recordingRevoker struct {
+	revoked []uint
+}
+
+func (rr *recordingRevoker) DeleteAllUserSessions(ctx context.Context, userID uint) error {
+	rr.revoked = append(rr.revoked, userID)
+	return nil
+}
+
+type testEnv struct {
+	db       *gorm.DB
+	service  *Service
+	users    *repositories.UserRepository
+	accounts *repositories.ServiceAccountRepository
+	sessions *recordingRevoker
+	clock    *clock.Fake
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	db := dbtest.Open(t, &models.User{}, &models.OutboxEvent{}, &models.Post{},
+		&models.ServiceAccount{}, &models.ServiceAccountCredential{})
+	env := &testEnv{
+		db:       db,
+		users:    repositories.NewUserRepository(db),
+		accounts: repositories.NewServiceAccountRepository(db),
+		sessions: &recordingRevoker{},
+		clock:    clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)),
+	}
+	env.service = NewService(env.users, env.sessions, env.accounts, Config{ReactivationCooldown: time.Hour},
+		logger.NewServerLogger()).WithClock(env.clock)
+	return env
+}
+
+func (env *testEnv) createUser(t *testing.T, username string) *models.User {
+	t.Helper()
+	user := &models.User{Username: username, Email: username + "@example.com", Password: "x", IsActive: true}
+	if err := env.users.CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	return user
+}
+
+func (env *testEnv) eventTypes(t *testing.T) []string {
+	t.Helper()
+	var types []string
+	if err := env.db.Model(&models.OutboxEvent{}).Order("id").Pluck("event_type", &types).Error; err != nil {
+		t.Fatalf("Failed to list events: %v", err)
+	}
+	return types
+}
+
+func TestService_DeactivateAndReactivateSelf(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	user := env.createUser(t, "alice")
+
+	account := &models.ServiceAccount{OrganizationID: 1, Name: "ci", Scopes: "posts:read", CreatedByID: user.ID}
+	if err := env.accounts.CreateServiceAccount(ctx, account); err != nil {
+		t.Fatalf("Failed to create service account: %v", err)
+	}
+	key := &models.ServiceAccountCredential{ServiceAccountID: account.ID, Kind: models.CredentialKindKey, Hash: "hash"}
+	if err := env.accounts.CreateCredential(ctx, key); err != nil {
+		t.Fatalf("Failed to create credential: %v", err)
+	}
+
+	if err := env.service.Deactivate(ctx, user, user.ID); err != nil {
+		t.Fatalf("Deactivate failed: %v", err)
+	}
+	stored, _ := env.users.GetUserByID(ctx, user.ID)
+	if stored.IsActive || stored.DeactivatedAt == nil || stored.DeactivatedByID == nil || *stored.DeactivatedByID != user.ID {
+		t.Fatalf("Expected a recorded self-deactivation, got %+v", stored)
+	}
+	if len(env.sessions.revoked) != 1 || env.sessions.revoked[0] != user.ID {
+		t.Errorf("Expected the user's sessions to be revoked, got %v", env.sessions.revoked)
+	}
+	credential, _ := env.accounts.GetCredentialByHash(ctx, "hash")
+	if credential == nil || credential.RevokedAt == nil {
+		t.Errorf("Expected the API keys of the user's service accounts to be revoked, got %+v", credential)
+	}
+	if err := env.service.Deactivate(ctx, stored, user.ID); !errors.Is(err, ErrAlreadyDeactivated) {
+		t.Errorf("Expected ErrAlreadyDeactivated, got %v", err)
+	}
+
+	env.clock.Advance(30 * time.Minute)
+	var cooldown *CooldownError
+	if err := env.service.Reactivate(ctx, stored, user.ID); !errors.As(err, &cooldown) || cooldown.RetryAfter != 30*time.Minute {
+		t.Fatalf("Expected the cool-down to have 30m left, got %v", err)
+	}
+
+	env.clock.Advance(30 * time.Minute)
+	if err := env.service.Reactivate(ctx, stored, user.ID); err != nil {
+		t.Fatalf("Reactivate failed: %v", err)
+	}
+	stored, _ = env.users.GetUserByID(ctx, user.ID)
+	if !stored.IsActive || stored.DeactivatedAt != nil || stored.DeactivatedByID != nil {
+		t.Errorf("Expected the account to be active again, got %+v", stored)
+	}
+	if err := env.service.Reactivate(ctx, stored, user.ID); !errors.Is(err, ErrNotDeactivated) {
+		t.Errorf("Expected ErrNotDeactivated, got %v", err)
+	}
+
+	want := []string{models.EventUserDeactivated, models.EventUserReactivated}
+	if got := env.eventTypes(t); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Expected events %v, got %v", want, got)
+	}
+}
+
+func TestService_StaffDeactivation(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	user := env.createUser(t, "bob")
+	admin := env.createUser(t, "admin")
+
+	if err := env.service.Deactivate(ctx, user, admin.ID); err != nil {
+		t.Fatalf("Deactivate failed: %v", err)
+	}
+	env.clock.Advance(48 * time.Hour)
+	stored, _ := env.users.GetUserByID(ctx, user.ID)
+	if err := env.service.Reactivate(ctx, stored, user.ID); !errors.Is(err, ErrDeactivatedByStaff) {
+		t.Errorf("Users should not reactivate an account staff deactivated, got %v", err)
+	}
+
+	// Staff are not held to the cool-down
+	if err := env.service.Deactivate(ctx, admin, admin.ID); err != nil {
+		t.Fatalf("Deactivate failed: %v", err)
+	}
+	if err := env.service.Reactivate(ctx, stored, admin.ID); err != nil {
+		t.Errorf("Staff should reactivate the account, got %v", err)
+	}
+}
+
+func TestService_DeactivatedAuthorsLeaveListings(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	posts := repositories.NewPostRepository(env.db)
+	alice := env.createUser(t, "alice")
+	bob := env.createUser(t, "bob")
+	now := env.clock.Now()
+	for _, author := range []*models.User{alice, bob} {
+		post := &models.Post{Title: "Hello", Slug: "hello-" + author.Username, Content: "x", Status: "published",
+			AuthorID: author.ID, PublishedAt: &now}
+		if err := posts.CreatePost(ctx, post); err != nil {
+			t.Fatalf("Failed to create post: %v", err)
+		}
+	}
+
+	if err := env.service.Deactivate(ctx, bob, bob.ID); err != nil {
+		t.Fatalf("Deactivate failed: %v", err)
+	}
+	listed, err := posts.ListPublishedPosts(ctx, 0, 10)
+	if err != nil || len(listed) != 1 || listed[0].AuthorID != alice.ID {
+		t.Errorf("Expected only alice's post to be listed, got %d posts, %v", len(listed), err)
+	}
+	if count, _ := posts.CountPublishedPosts(ctx); count != 1 {
+		t.Errorf("Expected the count to match the listing, got %d", count)
+	}
+
+	env.clock.Advance(time.Hour)
+	if err := env.service.Reactivate(ctx, bob, bob.ID); err != nil {
+		t.Fatalf("Reactivate failed: %v", err)
+	}
+	if count, _ := posts.CountPublishedPosts(ctx); count != 2 {
+		t.Errorf("Expected bob's post to be listed again, got %d posts", count)
+	}
+}
@@ -0,0 +1,118 @@
+package deployhealth
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/logger"
+	"go-server/internal/webhooks"
+)
+
+func TestRecorder_Stats(t *testing.T) {
+	recorder := NewRecorder()
+	for i := 0; i < 90; i++ {
+		recorder.Record(http.StatusOK, 3*time.Millisecond)
+	}
+	for i := 0; i < 8; i++ {
+		recorder.Record(http.StatusNotFound, 40*time.Millisecond)
+	}
+	recorder.Record(http.StatusBadGateway, 2*time.Second)
+	recorder.Record(http.StatusInternalServerError, 2*time.Minute)
+
+	stats := recorder.Stats()
+	if stats.Requests != 100 || stats.Errors != 2 || stats.ErrorRate != 0.02 {
+		t.Errorf("Expected 2 server errors in 100 requests, got %+v", stats)
+	}
+	if stats.P50 != 5*time.Millisecond || stats.P95 != 50*time.Millisecond || stats.P99 != 2500*time.Millisecond {
+		t.Errorf("Unexpected percentiles %s %s %s", stats.P50, stats.P95, stats.P99)
+	}
+
+	recorder.Stop()
+	recorder.Record(http.StatusInternalServerError, time.Millisecond)
+	if recorder.Stats().Requests != 100 || recorder.Recording() {
+		t.Error("Nothing should be recorded after the bake")
+	}
+}
+
+func TestReporter_Evaluate(t *testing.T) {
+	config := Config{
+		ErrorBudget: 0.01,
+		MinRequests: 50,
+		Baseline:    Baseline{ErrorRate: 0.005, P95: 40 * time.Millisecond},
+	}
+	reporter := NewReporter(config, NewRecorder(), "go-server@2.0.0", "web-1", logger.NewServerLogger())
+
+	tests := []struct {
+		name    string
+		stats   Stats
+		verdict string
+	}{
+		{"too little traffic", Stats{Requests: 10, Errors: 10, ErrorRate: 1}, VerdictInsufficientData},
+		{"within budget", Stats{Requests: 1000, ErrorRate: 0.012, P95: 50 * time.Millisecond}, VerdictHealthy},
+		{"errors over budget", Stats{Requests: 1000, ErrorRate: 0.02, P95: 25 * time.Millisecond}, VerdictUnhealthy},
+		{"slower than baseline", Stats{Requests: 1000, P95: 100 * time.Millisecond}, VerdictUnhealthy},
+	}
+	for _, tt := range tests {
+		if verdict, reasons := reporter.Evaluate(tt.stats); verdict != tt.verdict {
+			t.Errorf("%s: expected %s, got %s (%v)", tt.name, tt.verdict, verdict, reasons)
+		}
+	}
+}
+
+func TestReporter_BakeEndsWithRecommendation(t *testing.T) {
+	reports := make(chan Report, 10)
+	controller := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := webhooks.Verify("controller-secret", r.Header.Get(webhooks.HeaderSignature), body, time.Hour, time.Now()); err != nil {
+			t.Errorf("Report signature invalid: %v", err)
+		}
+		var report Report
+		json.Unmarshal(body, &report)
+		reports <- report
+	}))
+	defer controller.Close()
+
+	fake := clock.NewFake(time.Now())
+	recorder := NewRecorder()
+	config := Config{
+		ControllerURL: controller.URL,
+		Secret:        "controller-secret",
+		BakePeriod:    10 * time.Minute,
+		Interval:      10 * time.Millisecond,
+		ErrorBudget:   0.01,
+		MinRequests:   10,
+	}
+	reporter := NewReporter(config, recorder, "go-server@2.0.0", "web-1", logger.NewServerLogger()).WithClock(fake)
+	reporter.Start(context.Background())
+	defer reporter.Stop()
+
+	for i := 0; i < 20; i++ {
+		recorder.Record(http.StatusOK, time.Millisecond)
+	}
+	if report := <-reports; report.Final || report.Release != "go-server@2.0.0" {
+		t.Errorf("Expected an interim report, got %+v", report)
+	}
+
+	for i := 0; i < 5; i++ {
+		recorder.Record(http.StatusServiceUnavailable, time.Millisecond)
+	}
+	fake.Advance(10 * time.Minute)
+	for report := range reports {
+		if !report.Final {
+			continue
+		}
+		if report.Verdict != VerdictUnhealthy || report.Recommendation != RecommendRollback {
+			t.Errorf("Expected a rollback recommendation, got %+v", report)
+		}
+		break
+	}
+	if recorder.Recording() {
+		t.Error("Recording should stop when the bake ends")
+	}
+}
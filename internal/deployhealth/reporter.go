@@ -0,0 +1,258 @@
+package deployhealth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/logger"
+	"go-server/internal/webhooks"
+)
+
+// Verdicts
+const (
+	VerdictHealthy          = "healthy"
+	VerdictUnhealthy        = "unhealthy"
+	VerdictInsufficientData = "insufficient_data"
+)
+
+// Recommendations sent with the final report
+const (
+	RecommendPromote  = "promote"
+	RecommendRollback = "rollback"
+)
+
+// EventDeployHealth is the X-Webhook-Event of every report
+const EventDeployHealth = "deploy.health"
+
+// finalAttempts is how many intervals the final report is retried for
+const finalAttempts = 5
+
+// Config holds the bake period, the error budget and where reports go
+type Config struct {
+	// ControllerURL receives the reports; empty disables reporting
+	ControllerURL string
+	// Secret signs reports like webhook deliveries, see webhooks.Verify
+	Secret     string
+	BakePeriod time.Duration
+	Interval   time.Duration
+	// ErrorBudget is the server error rate allowed above the baseline
+	ErrorBudget float64
+	// MaxLatencyRatio is how much slower than the baseline p95 the release
+	// may be
+	MaxLatencyRatio float64
+	// MinRequests is how many requests are needed before a verdict
+	MinRequests int64
+	// Baseline is the previous release's behaviour, from the controller
+	Baseline Baseline
+}
+
+// Baseline is how the release being replaced behaved
+type Baseline struct {
+	ErrorRate float64       `json:"error_rate"`
+	P95       time.Duration `json:"p95_ns"`
+}
+
+// Report is posted to the controller every interval during the bake
+type Report struct {
+	Release  string        `json:"release"`
+	Instance string        `json:"instance"`
+	Elapsed  time.Duration `json:"elapsed_ns"`
+	Stats    Stats         `json:"stats"`
+	Baseline Baseline      `json:"baseline"`
+	Verdict  string        `json:"verdict"`
+	Reasons  []string      `json:"reasons,omitempty"`
+	// Final is set on the report closing the bake, which carries the
+	// recommendation
+	Final          bool      `json:"final"`
+	Recommendation string    `json:"recommendation,omitempty"`
+	SentAt         time.Time `json:"sent_at"`
+}
+
+// Reporter posts the release's health to the deploy controller during the
+// bake period
+type Reporter struct {
+	config   Config
+	recorder *Recorder
+	release  string
+	instance string
+	client   *http.Client
+	logger   logger.Logger
+	clock    clock.Clock
+
+	startedAt time.Time
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+// NewReporter creates a reporter for the release running as instance
+func NewReporter(config Config, recorder *Recorder, release, instance string, logger logger.Logger) *Reporter {
+	if config.BakePeriod <= 0 {
+		config.BakePeriod = 15 * time.Minute
+	}
+	if config.Interval <= 0 || config.Interval > config.BakePeriod {
+		config.Interval = 30 * time.Second
+	}
+	if config.MaxLatencyRatio <= 0 {
+		config.MaxLatencyRatio = 1.5
+	}
+	return &Reporter{
+		config:   config,
+		recorder: recorder,
+		release:  release,
+		instance: instance,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		logger:   logger,
+		clock:    clock.New(),
+	}
+}
+
+// WithHTTPClient sets the client used to reach the controller
+func (r *Reporter) WithHTTPClient(client *http.Client) *Reporter {
+	r.client = client
+	return r
+}
+
+// WithClock sets the time source for the bake period
+func (r *Reporter) WithClock(c clock.Clock) *Reporter {
+	r.clock = clock.OrDefault(c)
+	return r
+}
+
+// Evaluate judges the stats against the baseline and the budget
+func (r *Reporter) Evaluate(stats Stats) (string, []string) {
+	if stats.Requests < r.config.MinRequests {
+		return VerdictInsufficientData, []string{fmt.Sprintf("%d of %d requests needed", stats.Requests, r.config.MinRequests)}
+	}
+
+	var reasons []string
+	if allowed := r.config.Baseline.ErrorRate + r.config.ErrorBudget; stats.ErrorRate > allowed {
+		reasons = append(reasons, fmt.Sprintf("error rate %.2f%% exceeds %.2f%%", stats.ErrorRate*100, allowed*100))
+	}
+	if baseline := r.config.Baseline.P95; baseline > 0 {
+		if limit := time.Duration(float64(baseline) * r.config.MaxLatencyRatio); stats.P95 > limit {
+			reasons = append(reasons, fmt.Sprintf("p95 latency %s exceeds %s", stats.P95, limit))
+		}
+	}
+	if len(reasons) > 0 {
+		return VerdictUnhealthy, reasons
+	}
+	return VerdictHealthy, nil
+}
+
+// Snapshot builds the report for the current state of the bake
+func (r *Reporter) Snapshot(final bool) Report {
+	stats := r.recorder.Stats()
+	verdict, reasons := r.Evaluate(stats)
+	report := Report{
+		Release:  r.release,
+		Instance: r.instance,
+		Elapsed:  r.clock.Since(r.startedAt),
+		Stats:    stats,
+		Baseline: r.config.Baseline,
+		Verdict:  verdict,
+		Reasons:  reasons,
+		Final:    final,
+		SentAt:   r.clock.Now(),
+	}
+	if final {
+		// Too little traffic to judge is not a reason to roll back
+		report.Recommendation = RecommendPromote
+		if verdict == VerdictUnhealthy {
+			report.Recommendation = RecommendRollback
+		}
+	}
+	return report
+}
+
+// Send posts a report to the controller
+func (r *Reporter) Send(ctx context.Context, report Report) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.config.ControllerURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhooks.HeaderEvent, EventDeployHealth)
+	if r.config.Secret != "" {
+		req.Header.Set(webhooks.HeaderSignature, webhooks.Sign(r.config.Secret, r.clock.Now(), body))
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("deploy controller returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Start reports every interval until the bake period ends, then sends the
+// final report and stops recording
+func (r *Reporter) Start(ctx context.Context) {
+	r.startedAt = r.clock.Now()
+	if r.config.ControllerURL == "" {
+		r.recorder.Stop()
+		return
+	}
+	ctx, r.cancel = context.WithCancel(ctx)
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ticker := time.NewTicker(r.config.Interval)
+		defer ticker.Stop()
+
+		attempts := 0
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			final := r.clock.Since(r.startedAt) >= r.config.BakePeriod
+			if final {
+				r.recorder.Stop()
+			}
+			report := r.Snapshot(final)
+			if err := r.Send(ctx, report); err != nil {
+				r.logger.Error("Failed to report deploy health: %v", err)
+				if final {
+					// The final report carries the decision; retry it on the
+					// next ticks rather than give up at once
+					if attempts++; attempts < finalAttempts {
+						continue
+					}
+					return
+				}
+			} else if report.Verdict == VerdictUnhealthy {
+				r.logger.Warn("Release %s is unhealthy: %v", r.release, report.Reasons)
+			}
+			if final {
+				r.logger.Info("Bake period over, recommended %s for %s", report.Recommendation, r.release)
+				return
+			}
+		}
+	}()
+}
+
+// Stop halts reporting
+func (r *Reporter) Stop() {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.wg.Wait()
+}
@@ -0,0 +1,114 @@
+// Package deployhealth reports how a newly started release is doing to an
+// external deploy controller. For a bake period after startup it counts
+// requests, server errors and latency, compares them with the previous
+// release's baseline and posts a verdict every interval, ending with a
+// promote or rollback recommendation the controller can act on.
+package deployhealth
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the upper bounds of the latency histogram. Percentiles
+// are reported as the bound of the bucket they fall in, which keeps memory
+// constant however much traffic the bake sees.
+var latencyBuckets = []time.Duration{
+	time.Millisecond, 2 * time.Millisecond, 5 * time.Millisecond,
+	10 * time.Millisecond, 25 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 250 * time.Millisecond, 500 * time.Millisecond,
+	time.Second, 2500 * time.Millisecond, 5 * time.Second, 10 * time.Second,
+	30 * time.Second, time.Minute,
+}
+
+// Stats summarises the requests served so far
+type Stats struct {
+	Requests  int64         `json:"requests"`
+	Errors    int64         `json:"errors"`
+	ErrorRate float64       `json:"error_rate"`
+	P50       time.Duration `json:"p50_ns"`
+	P95       time.Duration `json:"p95_ns"`
+	P99       time.Duration `json:"p99_ns"`
+}
+
+// Recorder counts requests while the release bakes
+type Recorder struct {
+	mutex    sync.Mutex
+	stopped  bool
+	requests int64
+	errors   int64
+	// counts[i] is the number of requests no slower than latencyBuckets[i];
+	// the last entry counts the slower ones
+	counts []int64
+}
+
+// NewRecorder creates an empty recorder
+func NewRecorder() *Recorder {
+	return &Recorder{counts: make([]int64, len(latencyBuckets)+1)}
+}
+
+// Record adds a served request. 5xx responses count against the error
+// budget; client errors do not, since a bad release rarely causes them.
+func (r *Recorder) Record(status int, latency time.Duration) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.stopped {
+		return
+	}
+	r.requests++
+	if status >= 500 {
+		r.errors++
+	}
+	i := 0
+	for i < len(latencyBuckets) && latency > latencyBuckets[i] {
+		i++
+	}
+	r.counts[i]++
+}
+
+// Recording reports whether the bake is still in progress
+func (r *Recorder) Recording() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return !r.stopped
+}
+
+// Stop ends recording once the bake is over
+func (r *Recorder) Stop() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.stopped = true
+}
+
+// Stats returns the counts recorded so far
+func (r *Recorder) Stats() Stats {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	stats := Stats{Requests: r.requests, Errors: r.errors}
+	if r.requests == 0 {
+		return stats
+	}
+	stats.ErrorRate = float64(r.errors) / float64(r.requests)
+	stats.P50 = r.percentile(0.50)
+	stats.P95 = r.percentile(0.95)
+	stats.P99 = r.percentile(0.99)
+	return stats
+}
+
+// percentile returns the bucket bound p of the requests fall within;
+// r.mutex must be held
+func (r *Recorder) percentile(p float64) time.Duration {
+	target := int64(math.Ceil(p * float64(r.requests)))
+	var seen int64
+	for i, count := range r.counts {
+		seen += count
+		if seen >= target {
+			if i == len(latencyBuckets) {
+				return latencyBuckets[len(latencyBuckets)-1]
+			}
+			return latencyBuckets[i]
+		}
+	}
+	return latencyBuckets[len(latencyBuckets)-1]
+}
@@ -0,0 +1,316 @@
+// Package depwatch checks the modules compiled into the running binary,
+// and the Go release that built it, against the Go vulnerability database
+// and end-of-life feeds. It complements the govulncheck run at build time
+// (cmd/security): a binary that was clean when it was built turns
+// vulnerable as advisories are published, and only a check of what is
+// running notices.
+//
+// Each feed is read from an http(s) URL or, for deployments without
+// internet access, from an offline mirror given as a file:// URL or a
+// path. A mirror of the vulnerability database has the layout vuln.go.dev
+// serves, as govulncheck -db file:// expects.
+package depwatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/logger"
+	"go-server/internal/scheduler"
+)
+
+// TaskCheck is the name of the scheduled check
+const TaskCheck = "dependencies.check"
+
+// Config holds the feeds a Watcher reads
+type Config struct {
+	// VulnDB is the Go vulnerability database; empty skips vulnerabilities
+	VulnDB string
+	// EOLFeed lists the Go release cycles and their end-of-life dates in
+	// the endoflife.date format; empty skips the Go release check
+	EOLFeed string
+	// ModuleEOLFeed lists modules that are no longer maintained, see
+	// ModuleEOL; empty skips the module check
+	ModuleEOLFeed string
+	// EOLWarning is how long before an end of life it is reported
+	EOLWarning time.Duration
+	// Timeout bounds a whole check
+	Timeout time.Duration
+}
+
+// Kind is what a finding is about
+type Kind string
+
+// Finding kinds
+const (
+	KindVulnerability Kind = "vulnerability"
+	KindEOL           Kind = "eol"
+	KindOutdated      Kind = "outdated"
+)
+
+// Severity is how urgently a finding needs acting on
+type Severity string
+
+// Severities
+const (
+	SeverityHigh    Severity = "high"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Module is a module compiled into the binary. The standard library is
+// the module "stdlib", versioned as a semantic version such as 1.22.3.
+type Module struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+}
+
+// Finding is a problem with a module
+type Finding struct {
+	Kind     Kind     `json:"kind"`
+	Severity Severity `json:"severity"`
+	Module   string   `json:"module"`
+	Version  string   `json:"version"`
+	// ID and Aliases name the advisory of a vulnerability, e.g.
+	// GO-2024-2687 and CVE-2023-45288
+	ID      string   `json:"id,omitempty"`
+	Aliases []string `json:"aliases,omitempty"`
+	Summary string   `json:"summary"`
+	// FixedIn is the version to upgrade to, or for a module at end of
+	// life its replacement
+	FixedIn string     `json:"fixed_in,omitempty"`
+	URL     string     `json:"url,omitempty"`
+	EOL     *time.Time `json:"eol,omitempty"`
+}
+
+// Report is the outcome of a check
+type Report struct {
+	CheckedAt time.Time `json:"checked_at"`
+	GoVersion string    `json:"go_version"`
+	Modules   []Module  `json:"modules"`
+	Findings  []Finding `json:"findings"`
+	// Errors lists the feeds that could not be read; their findings are
+	// missing from the report
+	Errors []string `json:"errors,omitempty"`
+}
+
+// Stats is a summary of the checks for the metrics endpoint
+type Stats struct {
+	Checks   int64     `json:"checks"`
+	Failures int64     `json:"failures"`
+	LastRun  time.Time `json:"last_run"`
+	// Findings counts the latest report's findings by kind and severity
+	Findings   map[Kind]int     `json:"findings"`
+	BySeverity map[Severity]int `json:"by_severity"`
+}
+
+// Watcher checks the running binary's modules against the feeds
+type Watcher struct {
+	config Config
+	client *http.Client
+	clock  clock.Clock
+	logger logger.Logger
+	// modules lists the binary's modules and the Go release that built it
+	modules func() ([]Module, string, error)
+
+	mutex    sync.Mutex
+	latest   *Report
+	checks   int64
+	failures int64
+}
+
+// NewWatcher creates a new watcher reading the feeds in config
+func NewWatcher(config Config, logger logger.Logger) *Watcher {
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+	return &Watcher{
+		config:  config,
+		client:  &http.Client{},
+		clock:   clock.New(),
+		logger:  logger,
+		modules: buildModules,
+	}
+}
+
+// WithClock sets the time source for end-of-life dates
+func (w *Watcher) WithClock(c clock.Clock) *Watcher {
+	w.clock = clock.OrDefault(c)
+	return w
+}
+
+// WithHTTPClient sets the client remote feeds are fetched with
+func (w *Watcher) WithHTTPClient(client *http.Client) *Watcher {
+	w.client = client
+	return w
+}
+
+// buildModules lists the modules of the running binary from its build
+// information. Modules replaced by a local directory have no version to
+// check and are left out.
+func buildModules() ([]Module, string, error) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil, "", errors.New("the binary has no build information")
+	}
+	var modules []Module
+	for _, dep := range info.Deps {
+		module := dep
+		if dep.Replace != nil {
+			module = dep.Replace
+		}
+		if module.Version == "" || module.Version == "(devel)" {
+			continue
+		}
+		modules = append(modules, Module{Path: dep.Path, Version: module.Version})
+	}
+	return modules, info.GoVersion, nil
+}
+
+// Check reads the feeds and reports the findings for the running binary.
+// A feed that cannot be read is listed in the report's Errors and fails
+// the check, but the other feeds' findings are still reported and kept
+// as the latest report.
+func (w *Watcher) Check(ctx context.Context) (*Report, error) {
+	ctx, cancel := context.WithTimeout(ctx, w.config.Timeout)
+	defer cancel()
+
+	modules, goVersion, err := w.modules()
+	if err != nil {
+		w.record(nil)
+		return nil, err
+	}
+	now := w.clock.Now()
+	report := &Report{CheckedAt: now, GoVersion: goVersion, Modules: modules, Findings: []Finding{}}
+	withStdlib := modules
+	if semver := goSemver(goVersion); semver != "" {
+		withStdlib = append(append([]Module(nil), modules...), Module{Path: stdlibModule, Version: semver})
+	}
+
+	var failed []error
+	collect := func(feed string, findings []Finding, err error) {
+		if err != nil {
+			failed = append(failed, fmt.Errorf("%s: %w", feed, err))
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", feed, err))
+			return
+		}
+		report.Findings = append(report.Findings, findings...)
+	}
+	if w.config.VulnDB != "" {
+		findings, err := w.checkVulnerabilities(ctx, withStdlib)
+		collect("vulnerability database", findings, err)
+	}
+	if semver := goSemver(goVersion); w.config.EOLFeed != "" && semver != "" {
+		findings, err := w.checkGoRelease(ctx, semver, now)
+		collect("Go end-of-life feed", findings, err)
+	}
+	if w.config.ModuleEOLFeed != "" {
+		findings, err := w.checkModuleEOL(ctx, modules, now)
+		collect("module end-of-life feed", findings, err)
+	}
+
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		return severityRank(report.Findings[i].Severity) < severityRank(report.Findings[j].Severity)
+	})
+	w.logNew(report)
+	w.record(report)
+	return report, errors.Join(failed...)
+}
+
+// record keeps a report as the latest, or counts a check that produced
+// none
+func (w *Watcher) record(report *Report) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.checks++
+	if report == nil || len(report.Errors) > 0 {
+		w.failures++
+	}
+	if report != nil {
+		w.latest = report
+	}
+}
+
+// logNew logs the findings that the latest report did not have
+func (w *Watcher) logNew(report *Report) {
+	seen := make(map[string]bool)
+	if latest := w.Latest(); latest != nil {
+		for _, finding := range latest.Findings {
+			seen[finding.key()] = true
+		}
+	}
+	for _, finding := range report.Findings {
+		if seen[finding.key()] || finding.Severity == SeverityInfo {
+			continue
+		}
+		w.logger.Warn("Dependency %s: %s %s %s", finding.Kind, finding.Module, finding.Version, finding.label())
+	}
+}
+
+// key identifies a finding across checks
+func (f Finding) key() string {
+	return string(f.Kind) + " " + f.Module + " " + f.Version + " " + f.ID
+}
+
+// label is the advisory and summary of a finding
+func (f Finding) label() string {
+	if f.ID != "" {
+		return f.ID + " " + f.Summary
+	}
+	return f.Summary
+}
+
+func severityRank(severity Severity) int {
+	switch severity {
+	case SeverityHigh:
+		return 0
+	case SeverityWarning:
+		return 1
+	}
+	return 2
+}
+
+// Latest returns the latest report, or nil before the first check
+func (w *Watcher) Latest() *Report {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.latest
+}
+
+// Stats returns the check counters and the latest report's finding counts
+func (w *Watcher) Stats() Stats {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	stats := Stats{
+		Checks:     w.checks,
+		Failures:   w.failures,
+		Findings:   make(map[Kind]int),
+		BySeverity: make(map[Severity]int),
+	}
+	if w.latest != nil {
+		stats.LastRun = w.latest.CheckedAt
+		for _, finding := range w.latest.Findings {
+			stats.Findings[finding.Kind]++
+			stats.BySeverity[finding.Severity]++
+		}
+	}
+	return stats
+}
+
+// RegisterTasks schedules the check; an empty schedule disables it
+func RegisterTasks(s *scheduler.Scheduler, w *Watcher, schedule string) error {
+	if schedule == "" {
+		return nil
+	}
+	return s.Register(TaskCheck, schedule, func(ctx context.Context) error {
+		_, err := w.Check(ctx)
+		return err
+	})
+}
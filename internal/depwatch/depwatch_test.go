@@ -0,0 +1,195 @@
+package depwatch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/logger"
+)
+
+// writeMirror writes an offline mirror of the vulnerability database
+func writeMirror(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func newTestWatcher(config Config, modules []Module, goVersion string) *Watcher {
+	w := NewWatcher(config, logger.NewServerLogger()).
+		WithClock(clock.NewFake(time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)))
+	w.modules = func() ([]Module, string, error) {
+		return modules, goVersion, nil
+	}
+	return w
+}
+
+func TestWatcher_Check(t *testing.T) {
+	mirror := writeMirror(t, map[string]string{
+		"index/modules.json": `[
+			{"path": "golang.org/x/net", "vulns": [
+				{"id": "GO-2026-0001", "fixed": "0.40.0"},
+				{"id": "GO-2025-0002", "fixed": "0.30.0"}
+			]},
+			{"path": "stdlib", "vulns": [{"id": "GO-2026-0003", "fixed": "1.25.4"}]}
+		]`,
+		"ID/GO-2026-0001.json": `{
+			"id": "GO-2026-0001",
+			"summary": "Excessive memory use parsing HTTP/2 frames in golang.org/x/net",
+			"aliases": ["CVE-2026-1234"],
+			"affected": [{"package": {"name": "golang.org/x/net"}, "ranges": [{"type": "SEMVER",
+				"events": [{"introduced": "0"}, {"fixed": "0.33.0"}, {"introduced": "0.35.0"}, {"fixed": "0.40.0"}]}]}],
+			"database_specific": {"url": "https://pkg.go.dev/vuln/GO-2026-0001"}
+		}`,
+		"ID/GO-2026-0003.json": `{
+			"id": "GO-2026-0003",
+			"summary": "Panic in net/http",
+			"affected": [{"package": {"name": "stdlib"}, "ranges": [{"type": "SEMVER",
+				"events": [{"introduced": "0"}, {"fixed": "1.24.9"}, {"introduced": "1.25.0-0"}, {"fixed": "1.25.4"}]}]}]
+		}`,
+	})
+	eol := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[
+			{"cycle": "1.26", "eol": false, "latest": "1.26.2"},
+			{"cycle": "1.25", "eol": "2026-08-11", "latest": "1.25.9"},
+			{"cycle": "1.24", "eol": "2026-02-10", "latest": "1.24.12"}
+		]`))
+	}))
+	defer eol.Close()
+	moduleEOL := writeMirror(t, map[string]string{
+		"modules.json": `[{"module": "github.com/go-redis/redis/v8", "replacement": "github.com/redis/go-redis/v9"}]`,
+	})
+
+	w := newTestWatcher(Config{
+		VulnDB:        "file://" + mirror,
+		EOLFeed:       eol.URL,
+		ModuleEOLFeed: filepath.Join(moduleEOL, "modules.json"),
+		EOLWarning:    90 * 24 * time.Hour,
+	}, []Module{
+		{Path: "golang.org/x/net", Version: "v0.38.0"},
+		{Path: "github.com/go-redis/redis/v8", Version: "v8.11.5"},
+		{Path: "gorm.io/gorm", Version: "v1.30.0"},
+	}, "go1.25.3")
+
+	report, err := w.Check(context.Background())
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	found := make(map[string]Finding)
+	for _, finding := range report.Findings {
+		found[string(finding.Kind)+" "+finding.Module] = finding
+	}
+	if len(report.Findings) != 4 {
+		t.Errorf("Expected 4 findings, got %+v", report.Findings)
+	}
+	if f := found["vulnerability golang.org/x/net"]; f.ID != "GO-2026-0001" || f.FixedIn != "v0.40.0" || f.Aliases[0] != "CVE-2026-1234" {
+		t.Errorf("Expected the golang.org/x/net advisory fixed in v0.40.0, got %+v", f)
+	}
+	if f := found["vulnerability stdlib"]; f.ID != "GO-2026-0003" || f.Version != "1.25.3" || f.FixedIn != "1.25.4" {
+		t.Errorf("Expected the standard library advisory, got %+v", f)
+	}
+	if f := found["eol stdlib"]; f.Severity != SeverityHigh || f.Summary != "Go 1.25 reached end of life on 2026-08-11" || f.FixedIn != "1.26.2" {
+		t.Errorf("Expected Go 1.25 past end of life, got %+v", f)
+	}
+	if f := found["eol github.com/go-redis/redis/v8"]; f.Severity != SeverityHigh || f.FixedIn != "github.com/redis/go-redis/v9" {
+		t.Errorf("Expected go-redis v8 at end of life, got %+v", f)
+	}
+	if report.Findings[len(report.Findings)-1].Severity != SeverityHigh {
+		t.Errorf("Expected only high severity findings, got %+v", report.Findings)
+	}
+
+	stats := w.Stats()
+	if stats.Checks != 1 || stats.Failures != 0 || stats.Findings[KindVulnerability] != 2 || stats.BySeverity[SeverityHigh] != 4 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestWatcher_CheckWarnsBeforeEOL(t *testing.T) {
+	feed := writeMirror(t, map[string]string{
+		"go.json": `[{"cycle": "1.26", "eol": "2026-12-01", "latest": "1.26.2"}]`,
+	})
+	config := Config{EOLFeed: filepath.Join(feed, "go.json"), EOLWarning: 90 * 24 * time.Hour}
+
+	report, err := newTestWatcher(config, nil, "go1.26.2").Check(context.Background())
+	if err != nil || len(report.Findings) != 1 {
+		t.Fatalf("Expected one finding, got %+v, %v", report, err)
+	}
+	if f := report.Findings[0]; f.Severity != SeverityWarning || f.Summary != "Go 1.26 reaches end of life on 2026-12-01" {
+		t.Errorf("Expected a warning ahead of end of life, got %+v", f)
+	}
+
+	// Outside the warning period only a newer patch release is reported
+	config.EOLWarning = 7 * 24 * time.Hour
+	report, _ = newTestWatcher(config, nil, "go1.26.1").Check(context.Background())
+	if len(report.Findings) != 1 || report.Findings[0].Kind != KindOutdated || report.Findings[0].FixedIn != "1.26.2" {
+		t.Errorf("Expected Go 1.26.2 reported as available, got %+v", report.Findings)
+	}
+}
+
+func TestWatcher_CheckKeepsPartialReport(t *testing.T) {
+	feed := writeMirror(t, map[string]string{
+		"go.json": `[{"cycle": "1.24", "eol": true, "latest": "1.24.12"}]`,
+	})
+	w := newTestWatcher(Config{
+		VulnDB:  filepath.Join(t.TempDir(), "missing"),
+		EOLFeed: filepath.Join(feed, "go.json"),
+	}, nil, "go1.24.12")
+
+	report, err := w.Check(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "vulnerability database") {
+		t.Fatalf("Expected the missing database to fail the check, got %v", err)
+	}
+	if len(report.Errors) != 1 || len(report.Findings) != 1 || report.Findings[0].Summary != "Go 1.24 reached end of life" {
+		t.Errorf("Expected the end-of-life finding despite the error, got %+v", report)
+	}
+	if w.Latest() != report || w.Stats().Failures != 1 {
+		t.Errorf("Expected the partial report kept and the failure counted, got %+v", w.Stats())
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	for _, test := range []struct {
+		a, b     string
+		expected int
+	}{
+		{"v1.2.3", "1.2.3", 0},
+		{"1.2.3", "1.10.0", -1},
+		{"v0.38.0", "0.40.0", -1},
+		{"1.25.0-0", "1.25.0", -1},
+		{"1.23.0-rc.1", "1.23.0-rc.2", -1},
+		{"1.23.0-rc.2", "1.23.0", -1},
+		{"v0.0.0-20240101000000-abcdef123456", "v0.0.0-20230101000000-abcdef123456", 1},
+		{"1.0.0+incompatible", "1.0.0", 0},
+	} {
+		if got := compareVersions(test.a, test.b); got != test.expected {
+			t.Errorf("compareVersions(%q, %q) = %d, expected %d", test.a, test.b, got, test.expected)
+		}
+	}
+
+	for goVersion, expected := range map[string]string{
+		"go1.22.3":                  "1.22.3",
+		"go1.21":                    "1.21.0",
+		"go1.23rc1":                 "1.23.0-rc.1",
+		"go1.24.1 X:boringcrypto":   "1.24.1",
+		"devel go1.25-abcdef +0000": "",
+	} {
+		if got := goSemver(goVersion); got != expected {
+			t.Errorf("goSemver(%q) = %q, expected %q", goVersion, got, expected)
+		}
+	}
+}
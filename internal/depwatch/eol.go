@@ -0,0 +1,145 @@
+package depwatch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// releaseCycle is a release line in the endoflife.date format, e.g.
+// https://endoflife.date/api/go.json
+type releaseCycle struct {
+	Cycle string `json:"cycle"`
+	// EOL is the end-of-life date, or a boolean when there is none
+	EOL    json.RawMessage `json:"eol"`
+	Latest string          `json:"latest"`
+}
+
+// endOfLife returns the cycle's end-of-life date and whether it has one.
+// A cycle marked end of life without a date ended at the zero time.
+func (c releaseCycle) endOfLife() (time.Time, bool) {
+	var ended bool
+	if err := json.Unmarshal(c.EOL, &ended); err == nil {
+		return time.Time{}, ended
+	}
+	var date string
+	if err := json.Unmarshal(c.EOL, &date); err != nil {
+		return time.Time{}, false
+	}
+	at, err := time.Parse(time.DateOnly, date)
+	return at, err == nil
+}
+
+// ModuleEOL is an entry of a module end-of-life feed: a JSON array of
+// modules that are, or are about to be, no longer maintained, such as
+// [{"module": "github.com/go-redis/redis/v8",
+// "replacement": "github.com/redis/go-redis/v9"}]
+type ModuleEOL struct {
+	Module string `json:"module"`
+	// EOL is the end-of-life date as 2006-01-02; empty means it has passed
+	EOL         string `json:"eol,omitempty"`
+	Replacement string `json:"replacement,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// checkGoRelease reports the Go release the binary was built with once
+// its cycle nears or passes end of life, and when a newer patch release
+// of the cycle is out
+func (w *Watcher) checkGoRelease(ctx context.Context, goVersion string, now time.Time) ([]Finding, error) {
+	var cycles []releaseCycle
+	if err := w.fetchJSON(ctx, w.config.EOLFeed, &cycles); err != nil {
+		return nil, err
+	}
+
+	var current *releaseCycle
+	newest := ""
+	for i := range cycles {
+		if cycles[i].Cycle == goCycle(goVersion) {
+			current = &cycles[i]
+		}
+		if newest == "" || compareVersions(cycles[i].Latest, newest) > 0 {
+			newest = cycles[i].Latest
+		}
+	}
+	if current == nil {
+		return nil, nil
+	}
+
+	var findings []Finding
+	if finding, ok := w.eolFinding(stdlibModule, goVersion, current.endOfLife, now); ok {
+		finding.Summary = fmt.Sprintf("Go %s %s", current.Cycle, finding.Summary)
+		finding.FixedIn = newest
+		findings = append(findings, finding)
+	} else if current.Latest != "" && compareVersions(goVersion, current.Latest) < 0 {
+		findings = append(findings, Finding{
+			Kind:     KindOutdated,
+			Severity: SeverityInfo,
+			Module:   stdlibModule,
+			Version:  goVersion,
+			Summary:  fmt.Sprintf("Go %s is available", current.Latest),
+			FixedIn:  current.Latest,
+		})
+	}
+	return findings, nil
+}
+
+// checkModuleEOL reports the modules the module end-of-life feed lists
+func (w *Watcher) checkModuleEOL(ctx context.Context, modules []Module, now time.Time) ([]Finding, error) {
+	var entries []ModuleEOL
+	if err := w.fetchJSON(ctx, w.config.ModuleEOLFeed, &entries); err != nil {
+		return nil, err
+	}
+	byPath := make(map[string]ModuleEOL, len(entries))
+	for _, entry := range entries {
+		byPath[entry.Module] = entry
+	}
+
+	var findings []Finding
+	for _, module := range modules {
+		entry, listed := byPath[module.Path]
+		if !listed {
+			continue
+		}
+		endOfLife := func() (time.Time, bool) {
+			if entry.EOL == "" {
+				return time.Time{}, true
+			}
+			at, err := time.Parse(time.DateOnly, entry.EOL)
+			return at, err == nil
+		}
+		finding, ok := w.eolFinding(module.Path, module.Version, endOfLife, now)
+		if !ok {
+			continue
+		}
+		finding.Summary = module.Path + " " + finding.Summary
+		if entry.Reason != "" {
+			finding.Summary += ": " + entry.Reason
+		}
+		finding.FixedIn = entry.Replacement
+		findings = append(findings, finding)
+	}
+	return findings, nil
+}
+
+// eolFinding reports an end of life that has passed, or falls within the
+// warning period, and says when in the summary
+func (w *Watcher) eolFinding(module, version string, endOfLife func() (time.Time, bool), now time.Time) (Finding, bool) {
+	at, ok := endOfLife()
+	if !ok || at.After(now.Add(w.config.EOLWarning)) {
+		return Finding{}, false
+	}
+	finding := Finding{Kind: KindEOL, Severity: SeverityHigh, Module: module, Version: version}
+	switch {
+	case at.IsZero():
+		finding.Summary = "reached end of life"
+	case at.After(now):
+		finding.Severity = SeverityWarning
+		finding.Summary = "reaches end of life on " + at.Format(time.DateOnly)
+		finding.EOL = &at
+	default:
+		finding.Summary = "reached end of life on " + at.Format(time.DateOnly)
+		finding.EOL = &at
+	}
+	return finding, true
+}
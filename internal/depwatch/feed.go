@@ -0,0 +1,75 @@
+package depwatch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxFeedSize bounds a feed document; the vulnerability database's module
+// index is the largest, at a few megabytes
+const maxFeedSize = 32 << 20
+
+// isRemote reports whether location is an http(s) URL rather than a
+// file:// URL or a path of an offline mirror
+func isRemote(location string) bool {
+	return strings.HasPrefix(location, "https://") || strings.HasPrefix(location, "http://")
+}
+
+// resolve joins a path below base, which is a URL or a directory
+func resolve(base, path string) string {
+	if path == "" {
+		return base
+	}
+	if isRemote(base) {
+		return strings.TrimSuffix(base, "/") + "/" + path
+	}
+	return filepath.Join(localPath(base), filepath.FromSlash(path))
+}
+
+// localPath returns the path of a file:// URL, or location itself
+func localPath(location string) string {
+	if u, err := url.Parse(location); err == nil && u.Scheme == "file" {
+		return u.Path
+	}
+	return location
+}
+
+// fetchJSON reads the JSON document at location into v
+func (w *Watcher) fetchJSON(ctx context.Context, location string, v interface{}) error {
+	var body io.ReadCloser
+	if isRemote(location) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Accept", "application/json")
+		resp, err := w.client.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to fetch %s: %w", location, err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return fmt.Errorf("failed to fetch %s: status %d", location, resp.StatusCode)
+		}
+		body = resp.Body
+	} else {
+		file, err := os.Open(localPath(location))
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", location, err)
+		}
+		body = file
+	}
+	defer body.Close()
+
+	if err := json.NewDecoder(io.LimitReader(body, maxFeedSize)).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", location, err)
+	}
+	return nil
+}
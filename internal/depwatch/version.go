@@ -0,0 +1,124 @@
+package depwatch
+
+import (
+	"strconv"
+	"strings"
+)
+
+// compareVersions compares two semantic versions, with or without the v
+// prefix, returning -1, 0 or +1. Go pseudo-versions compare as the
+// pre-releases they are.
+func compareVersions(a, b string) int {
+	coreA, preA := splitVersion(a)
+	coreB, preB := splitVersion(b)
+	for i := 0; i < 3; i++ {
+		if c := compareNumeric(coreA[i], coreB[i]); c != 0 {
+			return c
+		}
+	}
+
+	// A release sorts after its pre-releases
+	switch {
+	case preA == "" && preB == "":
+		return 0
+	case preA == "":
+		return 1
+	case preB == "":
+		return -1
+	}
+	idsA, idsB := strings.Split(preA, "."), strings.Split(preB, ".")
+	for i := 0; i < len(idsA) && i < len(idsB); i++ {
+		if c := comparePrerelease(idsA[i], idsB[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(idsA), len(idsB))
+}
+
+// splitVersion returns the major, minor and patch numbers of a version and
+// its pre-release, dropping build metadata
+func splitVersion(version string) ([3]string, string) {
+	version = strings.TrimPrefix(version, "v")
+	if i := strings.IndexByte(version, '+'); i >= 0 {
+		version = version[:i]
+	}
+	prerelease := ""
+	if i := strings.IndexByte(version, '-'); i >= 0 {
+		version, prerelease = version[:i], version[i+1:]
+	}
+	core := [3]string{"0", "0", "0"}
+	for i, part := range strings.SplitN(version, ".", 3) {
+		core[i] = part
+	}
+	return core, prerelease
+}
+
+// comparePrerelease compares pre-release identifiers: numeric ones by
+// value and before alphanumeric ones, which compare as text
+func comparePrerelease(a, b string) int {
+	_, errA := strconv.ParseUint(a, 10, 64)
+	_, errB := strconv.ParseUint(b, 10, 64)
+	switch {
+	case errA == nil && errB == nil:
+		return compareNumeric(a, b)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// compareNumeric compares decimal strings of any length
+func compareNumeric(a, b string) int {
+	a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if c := compareInts(len(a), len(b)); c != 0 {
+		return c
+	}
+	return strings.Compare(a, b)
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// goSemver converts a Go release such as go1.22.3 or go1.23rc1 to the
+// semantic version the vulnerability database uses for the standard
+// library, 1.22.3 or 1.23.0-rc.1. It returns "" for development builds.
+func goSemver(goVersion string) string {
+	// GOEXPERIMENT builds append e.g. " X:boringcrypto"
+	goVersion, _, _ = strings.Cut(goVersion, " ")
+	version, ok := strings.CutPrefix(goVersion, "go")
+	if !ok || version == "" {
+		return ""
+	}
+	prerelease := ""
+	for _, tag := range []string{"rc", "beta"} {
+		if i := strings.Index(version, tag); i >= 0 {
+			version, prerelease = version[:i], tag+"."+version[i+len(tag):]
+			break
+		}
+	}
+	parts := strings.Split(version, ".")
+	for len(parts) < 3 {
+		parts = append(parts, "0")
+	}
+	semver := strings.Join(parts[:3], ".")
+	if prerelease != "" {
+		semver += "-" + prerelease
+	}
+	return semver
+}
+
+// goCycle returns the release cycle of a semantic Go version, e.g. 1.22
+// for 1.22.3
+func goCycle(semver string) string {
+	core, _ := splitVersion(semver)
+	return core[0] + "." + core[1]
+}
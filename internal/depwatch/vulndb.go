@@ -0,0 +1,142 @@
+package depwatch
+
+import (
+	"context"
+	"fmt"
+)
+
+// stdlibModule is the module the vulnerability database files the
+// standard library under
+const stdlibModule = "stdlib"
+
+// indexEntry is a module of the vulnerability database's index, with the
+// advisories filed against it
+type indexEntry struct {
+	Path  string `json:"path"`
+	Vulns []struct {
+		ID string `json:"id"`
+		// Fixed is the latest version fixing the advisory; empty when no
+		// fix exists
+		Fixed string `json:"fixed"`
+	} `json:"vulns"`
+}
+
+// osvEntry is an advisory in the OSV format
+type osvEntry struct {
+	ID       string   `json:"id"`
+	Summary  string   `json:"summary"`
+	Details  string   `json:"details"`
+	Aliases  []string `json:"aliases"`
+	Affected []struct {
+		Package struct {
+			Name string `json:"name"`
+		} `json:"package"`
+		Ranges []struct {
+			Type   string `json:"type"`
+			Events []struct {
+				Introduced string `json:"introduced"`
+				Fixed      string `json:"fixed"`
+			} `json:"events"`
+		} `json:"ranges"`
+	} `json:"affected"`
+	DatabaseSpecific struct {
+		URL string `json:"url"`
+	} `json:"database_specific"`
+}
+
+// affects reports whether the advisory affects version of module, and
+// the version fixing it if there is one
+func (e *osvEntry) affects(module, version string) (bool, string) {
+	for _, affected := range e.Affected {
+		if affected.Package.Name != module {
+			continue
+		}
+		for _, r := range affected.Ranges {
+			if r.Type != "SEMVER" {
+				continue
+			}
+			// Events alternate between introducing and fixing the advisory
+			introduced := ""
+			for _, event := range r.Events {
+				switch {
+				case event.Introduced != "":
+					introduced = event.Introduced
+				case event.Fixed != "" && introduced != "":
+					if inRange(version, introduced, event.Fixed) {
+						return true, event.Fixed
+					}
+					introduced = ""
+				}
+			}
+			if introduced != "" && inRange(version, introduced, "") {
+				return true, ""
+			}
+		}
+	}
+	return false, ""
+}
+
+// inRange reports whether version is at or after introduced, "0" meaning
+// every version, and before fixed unless fixed is empty
+func inRange(version, introduced, fixed string) bool {
+	if introduced != "0" && compareVersions(version, introduced) < 0 {
+		return false
+	}
+	return fixed == "" || compareVersions(version, fixed) < 0
+}
+
+// checkVulnerabilities looks up the modules in the vulnerability database.
+// Only the advisories the index lists with a later fix, or none, are read.
+func (w *Watcher) checkVulnerabilities(ctx context.Context, modules []Module) ([]Finding, error) {
+	var index []indexEntry
+	if err := w.fetchJSON(ctx, resolve(w.config.VulnDB, "index/modules.json"), &index); err != nil {
+		return nil, err
+	}
+	byPath := make(map[string]indexEntry, len(index))
+	for _, entry := range index {
+		byPath[entry.Path] = entry
+	}
+
+	var findings []Finding
+	entries := make(map[string]*osvEntry)
+	for _, module := range modules {
+		for _, vuln := range byPath[module.Path].Vulns {
+			if vuln.Fixed != "" && compareVersions(module.Version, vuln.Fixed) >= 0 {
+				continue
+			}
+			entry, ok := entries[vuln.ID]
+			if !ok {
+				entry = &osvEntry{}
+				if err := w.fetchJSON(ctx, resolve(w.config.VulnDB, "ID/"+vuln.ID+".json"), entry); err != nil {
+					return nil, fmt.Errorf("failed to read advisory %s: %w", vuln.ID, err)
+				}
+				entries[vuln.ID] = entry
+			}
+
+			affected, fixed := entry.affects(module.Path, module.Version)
+			if !affected {
+				continue
+			}
+			// OSV drops the v prefix that module versions carry
+			if fixed != "" && module.Path != stdlibModule {
+				fixed = "v" + fixed
+			}
+			summary := entry.Summary
+			if summary == "" {
+				summary = entry.Details
+			}
+			findings = append(findings, Finding{
+				Kind:     KindVulnerability,
+				Severity: SeverityHigh,
+				Module:   module.Path,
+				Version:  module.Version,
+				ID:       entry.ID,
+				Aliases:  entry.Aliases,
+				Summary:  summary,
+				FixedIn:  fixed,
+				URL:      entry.DatabaseSpecific.URL,
+			})
+		}
+	}
+	return findings, nil
+}
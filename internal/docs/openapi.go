@@ -0,0 +1,449 @@
+package docs
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go-server/internal/errors"
+)
+
+// OpenAPIVersion is the specification version the generator emits
+const OpenAPIVersion = "3.0.3"
+
+// OpenAPISpec is an OpenAPI 3.0 document
+type OpenAPISpec struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       OpenAPIInfo                             `json:"info"`
+	Servers    []OpenAPIServer                         `json:"servers,omitempty"`
+	Paths      map[string]map[string]*OpenAPIOperation `json:"paths"`
+	Components OpenAPIComponents                       `json:"components"`
+}
+
+// OpenAPIInfo describes the API
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// OpenAPIServer is a base URL the API is served from
+type OpenAPIServer struct {
+	URL       string                           `json:"url"`
+	Variables map[string]OpenAPIServerVariable `json:"variables,omitempty"`
+}
+
+// OpenAPIServerVariable is a placeholder in a server URL, e.g. {base_url}
+type OpenAPIServerVariable struct {
+	Default string `json:"default"`
+}
+
+// OpenAPIOperation documents one method on a path
+type OpenAPIOperation struct {
+	Summary     string                      `json:"summary,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	OperationID string                      `json:"operationId,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Parameters  []OpenAPIParameter          `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses"`
+	Security    []map[string][]string       `json:"security,omitempty"`
+}
+
+// OpenAPIParameter is a path or query parameter
+type OpenAPIParameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required"`
+	Schema      *OpenAPISchema `json:"schema"`
+}
+
+// OpenAPIRequestBody describes a request payload
+type OpenAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIResponse describes a response payload
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+// OpenAPIMediaType binds a schema to a content type
+type OpenAPIMediaType struct {
+	Schema   *OpenAPISchema             `json:"schema"`
+	Examples map[string]*OpenAPIExample `json:"examples,omitempty"`
+}
+
+// OpenAPIExample is a named example payload
+type OpenAPIExample struct {
+	Summary string `json:"summary,omitempty"`
+	Value   any    `json:"value"`
+}
+
+// OpenAPIComponents holds reusable schemas and security schemes
+type OpenAPIComponents struct {
+	Schemas         map[string]*OpenAPISchema         `json:"schemas,omitempty"`
+	SecuritySchemes map[string]*OpenAPISecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// OpenAPISecurityScheme describes how clients authenticate
+type OpenAPISecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	// In and Name locate the key for apiKey schemes
+	In   string `json:"in,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// OpenAPISchema is the subset of JSON Schema used by OpenAPI 3.0
+type OpenAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Description          string                    `json:"description,omitempty"`
+	Nullable             bool                      `json:"nullable,omitempty"`
+	Enum                 []string                  `json:"enum,omitempty"`
+	Items                *OpenAPISchema            `json:"items,omitempty"`
+	Properties           map[string]*OpenAPISchema `json:"properties,omitempty"`
+	AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+	MinLength            *int                      `json:"minLength,omitempty"`
+	MaxLength            *int                      `json:"maxLength,omitempty"`
+	Minimum              *float64                  `json:"minimum,omitempty"`
+	Maximum              *float64                  `json:"maximum,omitempty"`
+}
+
+// Route describes a registered route for the spec
+type Route struct {
+	Method      string
+	Path        string
+	Summary     string
+	Description string
+	Tags        []string
+	// Auth marks routes that require a bearer token
+	Auth bool
+	// Query documents query string parameters; path parameters come from the path
+	Query []QueryParam
+	// Request is a value of the request body type, e.g. LoginRequest{}
+	Request any
+	// Responses maps status codes to a value of the response body type; a nil
+	// value documents an empty body, or the standard error body for 4xx and 5xx
+	Responses map[int]any
+}
+
+// QueryParam documents a query string parameter
+type QueryParam struct {
+	Name        string
+	Type        string
+	Description string
+	Required    bool
+}
+
+// OpenAPIGenerator builds an OpenAPI spec from route registrations
+type OpenAPIGenerator struct {
+	info    OpenAPIInfo
+	servers []OpenAPIServer
+
+	mu      sync.Mutex
+	routes  []Route
+	schemas map[string]*OpenAPISchema
+	names   map[reflect.Type]string
+}
+
+// NewOpenAPIGenerator creates a new OpenAPI generator
+func NewOpenAPIGenerator(title, version, description string, serverURLs ...string) *OpenAPIGenerator {
+	g := &OpenAPIGenerator{
+		info: OpenAPIInfo{Title: title, Version: version, Description: description},
+	}
+	for _, url := range serverURLs {
+		g.servers = append(g.servers, OpenAPIServer{URL: url})
+	}
+	return g
+}
+
+// Register adds a route to the spec
+func (g *OpenAPIGenerator) Register(route Route) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.routes = append(g.routes, route)
+}
+
+// Handle registers a route on mux with a "METHOD /path" pattern and adds it to the spec
+func (g *OpenAPIGenerator) Handle(mux *http.ServeMux, route Route, handler http.Handler) {
+	mux.Handle(route.Method+" "+route.Path, handler)
+	g.Register(route)
+}
+
+// Routes returns the registered routes
+func (g *OpenAPIGenerator) Routes() []Route {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]Route(nil), g.routes...)
+}
+
+var pathParamPattern = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)(\.\.\.)?\}`)
+
+// Spec builds the OpenAPI document for the registered routes
+func (g *OpenAPIGenerator) Spec() *OpenAPISpec {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.schemas = make(map[string]*OpenAPISchema)
+	g.names = make(map[reflect.Type]string)
+
+	spec := &OpenAPISpec{
+		OpenAPI: OpenAPIVersion,
+		Info:    g.info,
+		Servers: g.servers,
+		Paths:   make(map[string]map[string]*OpenAPIOperation),
+	}
+
+	usesAuth := false
+	for _, route := range g.routes {
+		// ServeMux wildcards such as {path...} and the {$} anchor have no OpenAPI equivalent
+		path := strings.TrimSuffix(route.Path, "{$}")
+		path = pathParamPattern.ReplaceAllString(path, "{$1}")
+
+		if spec.Paths[path] == nil {
+			spec.Paths[path] = make(map[string]*OpenAPIOperation)
+		}
+		spec.Paths[path][strings.ToLower(route.Method)] = g.operation(route, path)
+		usesAuth = usesAuth || route.Auth
+	}
+
+	spec.Components.Schemas = g.schemas
+	if usesAuth {
+		spec.Components.SecuritySchemes = map[string]*OpenAPISecurityScheme{
+			"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+		}
+	}
+	return spec
+}
+
+func (g *OpenAPIGenerator) operation(route Route, path string) *OpenAPIOperation {
+	op := &OpenAPIOperation{
+		Summary:     route.Summary,
+		Description: route.Description,
+		OperationID: operationID(route.Method, path),
+		Tags:        route.Tags,
+		Responses:   make(map[string]*OpenAPIResponse),
+	}
+
+	for _, match := range pathParamPattern.FindAllStringSubmatch(path, -1) {
+		op.Parameters = append(op.Parameters, OpenAPIParameter{
+			Name: match[1], In: "path", Required: true, Schema: &OpenAPISchema{Type: "string"},
+		})
+	}
+	for _, param := range route.Query {
+		paramType := param.Type
+		if paramType == "" {
+			paramType = "string"
+		}
+		op.Parameters = append(op.Parameters, OpenAPIParameter{
+			Name: param.Name, In: "query", Description: param.Description, Required: param.Required,
+			Schema: &OpenAPISchema{Type: paramType},
+		})
+	}
+
+	if route.Request != nil {
+		op.RequestBody = &OpenAPIRequestBody{
+			Required: true,
+			Content:  map[string]OpenAPIMediaType{"application/json": {Schema: g.schemaFor(reflect.TypeOf(route.Request))}},
+		}
+	}
+
+	for status, body := range route.Responses {
+		response := &OpenAPIResponse{Description: http.StatusText(status)}
+		if body == nil && status >= 400 {
+			body = errors.APIError{}
+		}
+		if body != nil {
+			response.Content = map[string]OpenAPIMediaType{"application/json": {Schema: g.schemaFor(reflect.TypeOf(body))}}
+		}
+		op.Responses[strconv.Itoa(status)] = response
+	}
+	if len(op.Responses) == 0 {
+		op.Responses["default"] = &OpenAPIResponse{Description: "Response"}
+	}
+
+	if route.Auth {
+		op.Security = []map[string][]string{{"bearerAuth": {}}}
+	}
+	return op
+}
+
+// operationID derives an identifier such as getApiPostsById
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, segment := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '-' || r == '_' || r == '.' }) {
+		if strings.HasPrefix(segment, "{") {
+			b.WriteString("By")
+			segment = strings.Trim(segment, "{}")
+		}
+		if segment != "" {
+			b.WriteString(strings.ToUpper(segment[:1]) + segment[1:])
+		}
+	}
+	return b.String()
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	rawBytesType  = reflect.TypeOf([]byte(nil))
+	schemaNameBad = regexp.MustCompile(`[^A-Za-z0-9_.]`)
+)
+
+// schemaFor reflects a Go type into a schema; named structs become components
+func (g *OpenAPIGenerator) schemaFor(t reflect.Type) *OpenAPISchema {
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		nullable = true
+	}
+
+	var schema *OpenAPISchema
+	switch {
+	case t == timeType:
+		schema = &OpenAPISchema{Type: "string", Format: "date-time"}
+	case t.Name() == "DeletedAt" && t.Kind() == reflect.Struct:
+		// gorm.DeletedAt marshals as a nullable timestamp
+		schema = &OpenAPISchema{Type: "string", Format: "date-time", Nullable: true}
+	case t == rawBytesType:
+		schema = &OpenAPISchema{Type: "string", Format: "byte"}
+	default:
+		switch t.Kind() {
+		case reflect.Bool:
+			schema = &OpenAPISchema{Type: "boolean"}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+			schema = &OpenAPISchema{Type: "integer", Format: "int32"}
+		case reflect.Int64, reflect.Uint64:
+			schema = &OpenAPISchema{Type: "integer", Format: "int64"}
+		case reflect.Float32, reflect.Float64:
+			schema = &OpenAPISchema{Type: "number"}
+		case reflect.String:
+			schema = &OpenAPISchema{Type: "string"}
+		case reflect.Slice, reflect.Array:
+			schema = &OpenAPISchema{Type: "array", Items: g.schemaFor(t.Elem())}
+		case reflect.Map:
+			schema = &OpenAPISchema{Type: "object", AdditionalProperties: g.schemaFor(t.Elem())}
+		case reflect.Struct:
+			if t.Name() == "" {
+				schema = g.structSchema(t)
+			} else {
+				schema = &OpenAPISchema{Ref: "#/components/schemas/" + g.component(t)}
+			}
+		default:
+			// interfaces and other dynamic values accept anything
+			schema = &OpenAPISchema{}
+		}
+	}
+
+	if nullable && schema.Ref == "" {
+		schema.Nullable = true
+	}
+	return schema
+}
+
+// component registers a named struct under components/schemas and returns its name
+func (g *OpenAPIGenerator) component(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := schemaNameBad.ReplaceAllString(t.Name(), "_")
+	if _, taken := g.schemas[name]; taken {
+		pkg := t.PkgPath()
+		name = pkg[strings.LastIndex(pkg, "/")+1:] + "." + name
+	}
+	g.names[t] = name
+	g.schemas[name] = &OpenAPISchema{} // placeholder so recursive types terminate
+	*g.schemas[name] = *g.structSchema(t)
+	return name
+}
+
+func (g *OpenAPIGenerator) structSchema(t reflect.Type) *OpenAPISchema {
+	schema := &OpenAPISchema{Type: "object", Properties: make(map[string]*OpenAPISchema)}
+	g.addFields(schema, t)
+	sort.Strings(schema.Required)
+	return schema
+}
+
+// addFields adds a struct's JSON fields, flattening embedded structs as encoding/json does
+func (g *OpenAPIGenerator) addFields(schema *OpenAPISchema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.addFields(schema, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := g.schemaFor(field.Type)
+		required := applyValidateTag(property, field.Tag.Get("validate"))
+		if required && !strings.Contains(options, "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+}
+
+// applyValidateTag maps validate tags onto schema constraints and reports
+// whether the field is required
+func applyValidateTag(schema *OpenAPISchema, tag string) bool {
+	required := false
+	for _, rule := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(rule, "=")
+		switch key {
+		case "required":
+			required = true
+		case "email":
+			schema.Format = "email"
+		case "url":
+			schema.Format = "uri"
+		case "oneof":
+			schema.Enum = strings.Fields(value)
+		case "min", "max":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				continue
+			}
+			f := float64(n)
+			switch {
+			case schema.Type == "string" && key == "min":
+				schema.MinLength = &n
+			case schema.Type == "string":
+				schema.MaxLength = &n
+			case key == "min":
+				schema.Minimum = &f
+			default:
+				schema.Maximum = &f
+			}
+		}
+	}
+	return required
+}
@@ -0,0 +1,76 @@
+package docs
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+
+	"go-server/internal/idgen"
+	"go-server/internal/secheaders"
+)
+
+// Asset locations for the documentation viewers
+const (
+	SwaggerUIAssetsURL = "https://unpkg.com/swagger-ui-dist@5"
+	RedocScriptURL     = "https://cdn.redoc.ly/redoc/latest/bundles/redoc.standalone.js"
+)
+
+var swaggerUITemplate = template.Must(template.New("swagger").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<link rel="stylesheet" href="{{.Assets}}/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="{{.Assets}}/swagger-ui-bundle.js"></script>
+<script nonce="{{.Nonce}}">
+window.ui = SwaggerUIBundle({url: {{.SpecURL}}, dom_id: "#swagger-ui", deepLinking: true});
+</script>
+</body>
+</html>`))
+
+var redocTemplate = template.Must(template.New("redoc").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+</head>
+<body>
+<redoc spec-url="{{.SpecURL}}"></redoc>
+<script src="{{.Script}}"></script>
+</body>
+</html>`))
+
+// SpecHandler serves the generated spec as JSON (GET /openapi.json)
+func (g *OpenAPIGenerator) SpecHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(g.Spec())
+	})
+}
+
+// UIHandler renders the spec at specURL with Swagger UI, or with Redoc when
+// the request has ?ui=redoc (GET /docs). Mounted under the security headers
+// middleware's docs profile, it uses that request's nonce; otherwise it sets
+// secheaders.DocsPolicy itself.
+func UIHandler(title, specURL string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		nonce := secheaders.Nonce(r.Context())
+		if nonce == "" {
+			nonce, _ = idgen.NewRandom().Token(16)
+			w.Header().Set("Content-Security-Policy", secheaders.DocsPolicy().Render(nonce))
+		}
+
+		if r.URL.Query().Get("ui") == "redoc" {
+			redocTemplate.Execute(w, map[string]string{"Title": title, "SpecURL": specURL, "Script": RedocScriptURL})
+			return
+		}
+		swaggerUITemplate.Execute(w, map[string]string{"Title": title, "SpecURL": specURL, "Assets": SwaggerUIAssetsURL, "Nonce": nonce})
+	})
+}
@@ -0,0 +1,132 @@
+package docs
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-server/internal/database/models"
+)
+
+type testLoginRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,min=8"`
+	Remember bool   `json:"remember,omitempty"`
+}
+
+type testNode struct {
+	Name     string      `json:"name"`
+	Children []*testNode `json:"children"`
+}
+
+func newTestGenerator() (*OpenAPIGenerator, *http.ServeMux) {
+	g := NewOpenAPIGenerator("Test API", "1.0.0", "", "http://localhost:8080")
+	mux := http.NewServeMux()
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	g.Handle(mux, Route{
+		Method: http.MethodPost, Path: "/api/auth/login", Summary: "Log in", Tags: []string{"auth"},
+		Request:   testLoginRequest{},
+		Responses: map[int]any{http.StatusOK: map[string]string{}, http.StatusUnauthorized: nil},
+	}, ok)
+	g.Handle(mux, Route{
+		Method: http.MethodGet, Path: "/api/posts/{id}", Tags: []string{"posts"},
+		Query:     []QueryParam{{Name: "include", Description: "Related data"}},
+		Responses: map[int]any{http.StatusOK: models.Post{}, http.StatusNotFound: nil},
+	}, ok)
+	g.Handle(mux, Route{
+		Method: http.MethodGet, Path: "/api/tree/{path...}", Auth: true,
+		Responses: map[int]any{http.StatusOK: []testNode{}, http.StatusNoContent: nil},
+	}, ok)
+	return g, mux
+}
+
+func TestOpenAPIGenerator_Spec(t *testing.T) {
+	g, mux := newTestGenerator()
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/posts/42", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("route was not registered on the mux: %d", rec.Code)
+	}
+
+	spec := g.Spec()
+	if spec.OpenAPI != OpenAPIVersion || spec.Info.Title != "Test API" || spec.Servers[0].URL != "http://localhost:8080" {
+		t.Errorf("unexpected spec header: %+v", spec)
+	}
+
+	login := spec.Paths["/api/auth/login"]["post"]
+	if login == nil || login.OperationID != "postApiAuthLogin" {
+		t.Fatalf("login operation missing: %+v", spec.Paths)
+	}
+	if ref := login.RequestBody.Content["application/json"].Schema.Ref; ref != "#/components/schemas/testLoginRequest" {
+		t.Errorf("request body ref = %q", ref)
+	}
+	body := spec.Components.Schemas["testLoginRequest"]
+	if len(body.Required) != 2 || body.Properties["email"].Format != "email" || *body.Properties["password"].MinLength != 8 {
+		t.Errorf("validate tags not applied: %+v", body)
+	}
+	if login.Responses["401"].Content["application/json"].Schema.Ref != "#/components/schemas/APIError" {
+		t.Errorf("error responses should use the APIError schema")
+	}
+
+	get := spec.Paths["/api/posts/{id}"]["get"]
+	if len(get.Parameters) != 2 || get.Parameters[0].In != "path" || get.Parameters[1].In != "query" {
+		t.Errorf("unexpected parameters: %+v", get.Parameters)
+	}
+
+	post := spec.Components.Schemas["Post"]
+	if post == nil {
+		t.Fatalf("Post schema missing: %v", spec.Components.Schemas)
+	}
+	if post.Properties["id"] == nil || post.Properties["created_at"].Format != "date-time" {
+		t.Errorf("embedded BaseModel fields not flattened: %v", post.Properties)
+	}
+	if post.Properties["author"].Ref != "#/components/schemas/User" || spec.Components.Schemas["User"].Properties["password"] != nil {
+		t.Errorf("author should reference User without the hidden password")
+	}
+	if !post.Properties["published_at"].Nullable || len(post.Properties["status"].Enum) != 3 {
+		t.Errorf("unexpected post properties: %+v %+v", post.Properties["published_at"], post.Properties["status"])
+	}
+
+	tree := spec.Paths["/api/tree/{path}"]["get"]
+	if tree == nil || len(tree.Security) != 1 || spec.Components.SecuritySchemes["bearerAuth"] == nil {
+		t.Errorf("wildcard path or auth not documented: %+v", spec.Paths)
+	}
+	if tree.Responses["204"].Content != nil {
+		t.Errorf("204 should have no content")
+	}
+	node := spec.Components.Schemas["testNode"]
+	if node == nil || node.Properties["children"].Items.Ref != "#/components/schemas/testNode" {
+		t.Errorf("recursive type not referenced: %+v", node)
+	}
+}
+
+func TestOpenAPIHandlers(t *testing.T) {
+	g, _ := newTestGenerator()
+
+	rec := httptest.NewRecorder()
+	g.SpecHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	var decoded map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &decoded); err != nil || decoded["openapi"] != OpenAPIVersion {
+		t.Errorf("invalid spec response: %v %s", err, rec.Body.String())
+	}
+
+	ui := UIHandler("Test API", "/openapi.json")
+	rec = httptest.NewRecorder()
+	ui.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if !strings.Contains(rec.Body.String(), "SwaggerUIBundle") || !strings.Contains(rec.Body.String(), `"/openapi.json"`) {
+		t.Errorf("Swagger UI page not rendered: %s", rec.Body.String())
+	}
+	if !strings.Contains(rec.Header().Get("Content-Security-Policy"), "https://unpkg.com") {
+		t.Errorf("docs page needs a CSP allowing its assets")
+	}
+
+	rec = httptest.NewRecorder()
+	ui.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs?ui=redoc", nil))
+	if !strings.Contains(rec.Body.String(), `<redoc spec-url="/openapi.json">`) {
+		t.Errorf("Redoc page not rendered: %s", rec.Body.String())
+	}
+}
@@ -0,0 +1,563 @@
+package docs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PostmanOpenAPIConverter turns Postman collections into OpenAPI documents so
+// existing collections can be used with standard OpenAPI tooling. Schemas are
+// inferred from the example request and response bodies, and requests that
+// share a method and path are merged into one operation with an example each.
+type PostmanOpenAPIConverter struct {
+	version string
+	parser  *PostmanParser
+}
+
+// NewPostmanOpenAPIConverter creates a converter; version becomes info.version
+func NewPostmanOpenAPIConverter(version string) *PostmanOpenAPIConverter {
+	if version == "" {
+		version = "1.0.0"
+	}
+	return &PostmanOpenAPIConverter{
+		version: version,
+		parser:  NewPostmanParser(),
+	}
+}
+
+// ConvertFile parses a collection file and converts it
+func (c *PostmanOpenAPIConverter) ConvertFile(collectionPath string) (*OpenAPISpec, error) {
+	collection, err := c.parser.ParseCollection(collectionPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse collection: %w", err)
+	}
+	return c.Convert(collection), nil
+}
+
+// Convert builds an OpenAPI document from a parsed collection
+func (c *PostmanOpenAPIConverter) Convert(collection *PostmanCollection) *OpenAPISpec {
+	conversion := &postmanConversion{
+		spec: &OpenAPISpec{
+			OpenAPI: OpenAPIVersion,
+			Info: OpenAPIInfo{
+				Title:       collection.Info.Name,
+				Description: collection.Info.Description,
+				Version:     c.version,
+			},
+			Paths: make(map[string]map[string]*OpenAPIOperation),
+			Components: OpenAPIComponents{
+				Schemas:         make(map[string]*OpenAPISchema),
+				SecuritySchemes: make(map[string]*OpenAPISecurityScheme),
+			},
+		},
+		variables:    make(map[string]string),
+		servers:      make(map[string]bool),
+		operationIDs: make(map[string]bool),
+	}
+	for _, variable := range collection.Variable {
+		conversion.variables[variable.Key] = variable.Value
+	}
+
+	conversion.walk(collection.Item, "", collection.Auth)
+
+	spec := conversion.spec
+	if len(spec.Components.Schemas) == 0 {
+		spec.Components.Schemas = nil
+	}
+	if len(spec.Components.SecuritySchemes) == 0 {
+		spec.Components.SecuritySchemes = nil
+	}
+	return spec
+}
+
+// postmanConversion holds the state of one conversion
+type postmanConversion struct {
+	spec         *OpenAPISpec
+	variables    map[string]string
+	servers      map[string]bool
+	operationIDs map[string]bool
+}
+
+var (
+	postmanVariablePattern = regexp.MustCompile(`\{\{([^{}]+)\}\}`)
+	uuidPattern            = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	emailPattern           = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+	exampleNameBad         = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// walk converts requests depth-first. Folders tag their requests with the
+// folder name and pass their auth down unless a request overrides it.
+func (pc *postmanConversion) walk(items []PostmanItem, tag string, auth *PostmanAuth) {
+	for _, item := range items {
+		itemAuth := auth
+		if item.Auth != nil {
+			itemAuth = item.Auth
+		}
+		if item.Request == nil {
+			pc.walk(item.Item, item.Name, itemAuth)
+			continue
+		}
+		if item.Request.Auth != nil {
+			itemAuth = item.Request.Auth
+		}
+		pc.addRequest(item, tag, itemAuth)
+	}
+}
+
+func (pc *postmanConversion) addRequest(item PostmanItem, tag string, auth *PostmanAuth) {
+	request := item.Request
+	method := strings.ToLower(request.Method)
+	if method == "" {
+		method = "get"
+	}
+	path, query := pc.splitURL(request.URL)
+	exampleName := exampleNameBad.ReplaceAllString(strings.ToLower(item.Name), "_")
+
+	if pc.spec.Paths[path] == nil {
+		pc.spec.Paths[path] = make(map[string]*OpenAPIOperation)
+	}
+	op := pc.spec.Paths[path][method]
+	if op == nil {
+		op = &OpenAPIOperation{
+			Summary:     item.Name,
+			Description: item.Description,
+			OperationID: pc.operationID(method, path),
+			Responses:   make(map[string]*OpenAPIResponse),
+		}
+		if tag != "" {
+			op.Tags = []string{tag}
+		}
+		op.Parameters = pc.parameters(path, query, request.Header, auth)
+		if scheme := pc.securityScheme(auth); scheme != "" {
+			op.Security = []map[string][]string{{scheme: {}}}
+		}
+		pc.spec.Paths[path][method] = op
+	}
+
+	if content := pc.requestContent(op.OperationID, request, exampleName, item.Name); content != nil {
+		if op.RequestBody == nil {
+			op.RequestBody = &OpenAPIRequestBody{Required: true, Content: make(map[string]OpenAPIMediaType)}
+		}
+		pc.mergeContent(op.RequestBody.Content, content)
+	}
+
+	for _, response := range item.Response {
+		status := "default"
+		if response.Code != 0 {
+			status = strconv.Itoa(response.Code)
+		}
+		existing := op.Responses[status]
+		if existing == nil {
+			description := response.Status
+			if description == "" {
+				description = http.StatusText(response.Code)
+			}
+			if description == "" {
+				description = response.Name
+			}
+			existing = &OpenAPIResponse{Description: description}
+			op.Responses[status] = existing
+		}
+
+		contentType := headerValue(response.Header, "Content-Type")
+		name := exampleName
+		if response.Name != "" {
+			name = exampleNameBad.ReplaceAllString(strings.ToLower(response.Name), "_")
+		}
+		content := pc.bodyContent(componentName(op.OperationID, status+"Response"), contentType, response.Body, name, response.Name)
+		if content != nil {
+			if existing.Content == nil {
+				existing.Content = make(map[string]OpenAPIMediaType)
+			}
+			pc.mergeContent(existing.Content, content)
+		}
+	}
+	if len(op.Responses) == 0 {
+		op.Responses["default"] = &OpenAPIResponse{Description: "Response"}
+	}
+}
+
+// splitURL registers the request's server and returns its OpenAPI path and
+// query parameters. Postman path variables (:id) and collection variables
+// used as path segments ({{id}}) both become path parameters.
+func (pc *postmanConversion) splitURL(u *PostmanURL) (string, url.Values) {
+	query := url.Values{}
+	if u == nil {
+		return "/", query
+	}
+
+	host := strings.Join(u.Host, ".")
+	segments := u.Path
+	if len(u.Host) == 0 && len(u.Path) == 0 && u.Raw != "" {
+		raw := u.Raw
+		if i := strings.IndexAny(raw, "?#"); i >= 0 {
+			if raw[i] == '?' {
+				query, _ = url.ParseQuery(strings.SplitN(raw[i+1:], "#", 2)[0])
+			}
+			raw = raw[:i]
+		}
+		scheme := ""
+		if i := strings.Index(raw, "://"); i >= 0 {
+			scheme, raw = raw[:i+3], raw[i+3:]
+		}
+		parts := strings.Split(raw, "/")
+		host, segments = scheme+parts[0], parts[1:]
+	} else if u.Protocol != "" && host != "" {
+		host = u.Protocol + "://" + host
+	}
+	for _, param := range u.Query {
+		query.Add(param.Key, param.Value)
+	}
+	if host != "" {
+		pc.addServer(host)
+	}
+
+	var path strings.Builder
+	for _, segment := range segments {
+		if segment == "" {
+			continue
+		}
+		path.WriteString("/")
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			path.WriteString("{" + segment[1:] + "}")
+		case strings.HasPrefix(segment, "{{") && strings.HasSuffix(segment, "}}"):
+			path.WriteString("{" + strings.Trim(segment, "{}") + "}")
+		default:
+			path.WriteString(segment)
+		}
+	}
+	if path.Len() == 0 {
+		return "/", query
+	}
+	return path.String(), query
+}
+
+// addServer records a server URL, substituting known collection variables
+// and turning unknown ones into server variables
+func (pc *postmanConversion) addServer(host string) {
+	variables := make(map[string]OpenAPIServerVariable)
+	serverURL := postmanVariablePattern.ReplaceAllStringFunc(host, func(match string) string {
+		name := strings.Trim(match, "{}")
+		if value, ok := pc.variables[name]; ok && value != "" && !strings.Contains(value, "{{") {
+			return value
+		}
+		variables[name] = OpenAPIServerVariable{Default: ""}
+		return "{" + name + "}"
+	})
+	if pc.servers[serverURL] {
+		return
+	}
+	pc.servers[serverURL] = true
+
+	server := OpenAPIServer{URL: serverURL}
+	if len(variables) > 0 {
+		server.Variables = variables
+	}
+	pc.spec.Servers = append(pc.spec.Servers, server)
+}
+
+// operationID derives a unique operation ID, numbering repeats
+func (pc *postmanConversion) operationID(method, path string) string {
+	id := operationID(method, path)
+	unique := id
+	for i := 2; pc.operationIDs[unique]; i++ {
+		unique = id + strconv.Itoa(i)
+	}
+	pc.operationIDs[unique] = true
+	return unique
+}
+
+func (pc *postmanConversion) parameters(path string, query url.Values, headers []PostmanHeader, auth *PostmanAuth) []OpenAPIParameter {
+	var params []OpenAPIParameter
+	for _, match := range pathParamPattern.FindAllStringSubmatch(path, -1) {
+		params = append(params, OpenAPIParameter{
+			Name: match[1], In: "path", Required: true, Schema: &OpenAPISchema{Type: "string"},
+		})
+	}
+	for name, values := range query {
+		params = append(params, OpenAPIParameter{Name: name, In: "query", Schema: scalarSchema(values[0])})
+	}
+
+	apiKeyHeader := ""
+	if auth != nil && auth.Type == "apikey" {
+		apiKeyHeader = authAttribute(auth.APIKey, "key")
+	}
+	for _, header := range headers {
+		switch strings.ToLower(header.Key) {
+		case "content-type", "accept", "authorization", strings.ToLower(apiKeyHeader):
+			continue
+		}
+		params = append(params, OpenAPIParameter{Name: header.Key, In: "header", Schema: &OpenAPISchema{Type: "string"}})
+	}
+
+	// Query parameters come from a map; keep the output stable
+	sortParameters(params)
+	return params
+}
+
+// securityScheme registers the component for an auth setting and returns its
+// name, or "" for noauth and types OpenAPI 3.0 cannot express here
+func (pc *postmanConversion) securityScheme(auth *PostmanAuth) string {
+	if auth == nil {
+		return ""
+	}
+	var name string
+	var scheme *OpenAPISecurityScheme
+	switch auth.Type {
+	case "bearer":
+		name, scheme = "bearerAuth", &OpenAPISecurityScheme{Type: "http", Scheme: "bearer"}
+	case "basic":
+		name, scheme = "basicAuth", &OpenAPISecurityScheme{Type: "http", Scheme: "basic"}
+	case "apikey":
+		in := authAttribute(auth.APIKey, "in")
+		if in == "" {
+			in = "header"
+		}
+		name, scheme = "apiKeyAuth", &OpenAPISecurityScheme{Type: "apiKey", In: in, Name: authAttribute(auth.APIKey, "key")}
+	default:
+		return ""
+	}
+	pc.spec.Components.SecuritySchemes[name] = scheme
+	return name
+}
+
+func (pc *postmanConversion) requestContent(opID string, request *PostmanRequest, exampleName, summary string) map[string]OpenAPIMediaType {
+	body := request.Body
+	if body == nil {
+		return nil
+	}
+	switch body.Mode {
+	case "raw":
+		return pc.bodyContent(componentName(opID, "Request"), headerValue(request.Header, "Content-Type"), body.Raw, exampleName, summary)
+	case "urlencoded", "formdata":
+		params := body.URLEncoded
+		contentType := "application/x-www-form-urlencoded"
+		if body.Mode == "formdata" {
+			params, contentType = body.FormData, "multipart/form-data"
+		}
+		if len(params) == 0 {
+			return nil
+		}
+		schema := &OpenAPISchema{Type: "object", Properties: make(map[string]*OpenAPISchema)}
+		example := make(map[string]string)
+		for _, param := range params {
+			if param.Type == "file" {
+				schema.Properties[param.Key] = &OpenAPISchema{Type: "string", Format: "binary"}
+				continue
+			}
+			schema.Properties[param.Key] = &OpenAPISchema{Type: "string"}
+			example[param.Key] = param.Value
+		}
+		return map[string]OpenAPIMediaType{contentType: {
+			Schema:   schema,
+			Examples: map[string]*OpenAPIExample{exampleName: {Summary: summary, Value: example}},
+		}}
+	}
+	return nil
+}
+
+// bodyContent infers a media type from an example body. JSON objects and
+// arrays of objects are stored as named components so that later examples
+// for the same operation extend the same schema.
+func (pc *postmanConversion) bodyContent(component, contentType, body, exampleName, summary string) map[string]OpenAPIMediaType {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return nil
+	}
+
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader([]byte(body)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		// Raw bodies with Postman variables are not valid JSON; document them as text
+		if contentType == "" || strings.Contains(contentType, "json") {
+			contentType = "text/plain"
+		}
+		return map[string]OpenAPIMediaType{contentType: {
+			Schema:   &OpenAPISchema{Type: "string"},
+			Examples: map[string]*OpenAPIExample{exampleName: {Summary: summary, Value: body}},
+		}}
+	}
+	if contentType == "" || !strings.Contains(contentType, "json") {
+		contentType = "application/json"
+	}
+	contentType = strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
+
+	schema := inferSchema(value)
+	if schema.Type == "object" || (schema.Type == "array" && schema.Items != nil && schema.Items.Type == "object") {
+		if existing := pc.spec.Components.Schemas[component]; existing != nil {
+			mergeSchema(existing, schema)
+		} else {
+			pc.spec.Components.Schemas[component] = schema
+		}
+		schema = &OpenAPISchema{Ref: "#/components/schemas/" + component}
+	}
+	return map[string]OpenAPIMediaType{contentType: {
+		Schema:   schema,
+		Examples: map[string]*OpenAPIExample{exampleName: {Summary: summary, Value: value}},
+	}}
+}
+
+// mergeContent adds media types and examples to existing content, keeping
+// the first schema seen for each media type
+func (pc *postmanConversion) mergeContent(dst, src map[string]OpenAPIMediaType) {
+	for contentType, media := range src {
+		existing, ok := dst[contentType]
+		if !ok {
+			dst[contentType] = media
+			continue
+		}
+		if existing.Examples == nil {
+			existing.Examples = make(map[string]*OpenAPIExample)
+		}
+		for name, example := range media.Examples {
+			unique := name
+			for i := 2; existing.Examples[unique] != nil; i++ {
+				unique = name + "_" + strconv.Itoa(i)
+			}
+			existing.Examples[unique] = example
+		}
+		if existing.Schema != nil && media.Schema != nil && existing.Schema.Ref == "" {
+			mergeSchema(existing.Schema, media.Schema)
+		}
+		dst[contentType] = existing
+	}
+}
+
+// inferSchema derives a schema from a decoded JSON example
+func inferSchema(value any) *OpenAPISchema {
+	switch v := value.(type) {
+	case nil:
+		return &OpenAPISchema{Nullable: true}
+	case bool:
+		return &OpenAPISchema{Type: "boolean"}
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return &OpenAPISchema{Type: "integer"}
+		}
+		return &OpenAPISchema{Type: "number"}
+	case string:
+		schema := &OpenAPISchema{Type: "string"}
+		switch {
+		case isDateTime(v):
+			schema.Format = "date-time"
+		case uuidPattern.MatchString(v):
+			schema.Format = "uuid"
+		case emailPattern.MatchString(v):
+			schema.Format = "email"
+		}
+		return schema
+	case []any:
+		schema := &OpenAPISchema{Type: "array", Items: &OpenAPISchema{}}
+		for i, element := range v {
+			if i == 0 {
+				schema.Items = inferSchema(element)
+			} else {
+				mergeSchema(schema.Items, inferSchema(element))
+			}
+		}
+		return schema
+	case map[string]any:
+		schema := &OpenAPISchema{Type: "object", Properties: make(map[string]*OpenAPISchema, len(v))}
+		for key, property := range v {
+			schema.Properties[key] = inferSchema(property)
+		}
+		return schema
+	}
+	return &OpenAPISchema{}
+}
+
+// mergeSchema widens dst with what src adds: missing object properties,
+// a type for null-only examples, and integer to number
+func mergeSchema(dst, src *OpenAPISchema) {
+	if src.Nullable {
+		dst.Nullable = true
+	}
+	switch {
+	case dst.Type == "":
+		nullable := dst.Nullable
+		*dst = *src
+		dst.Nullable = nullable || src.Nullable
+	case dst.Type == "integer" && src.Type == "number":
+		dst.Type = "number"
+	case dst.Type == "object" && src.Type == "object":
+		for key, property := range src.Properties {
+			if existing := dst.Properties[key]; existing != nil {
+				mergeSchema(existing, property)
+			} else {
+				dst.Properties[key] = property
+			}
+		}
+	case dst.Type == "array" && src.Type == "array" && dst.Items != nil && src.Items != nil:
+		mergeSchema(dst.Items, src.Items)
+	case dst.Type == "string" && src.Type == "string" && dst.Format != src.Format:
+		dst.Format = ""
+	}
+}
+
+func scalarSchema(value string) *OpenAPISchema {
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return &OpenAPISchema{Type: "integer"}
+	}
+	if _, err := strconv.ParseBool(value); err == nil {
+		return &OpenAPISchema{Type: "boolean"}
+	}
+	return &OpenAPISchema{Type: "string"}
+}
+
+func isDateTime(value string) bool {
+	_, err := time.Parse(time.RFC3339, value)
+	return err == nil
+}
+
+// componentName builds a schema name such as PostApiUsersRequest
+func componentName(opID, suffix string) string {
+	name := schemaNameBad.ReplaceAllString(opID+suffix, "")
+	if name == "" {
+		return suffix
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+func headerValue(headers []PostmanHeader, name string) string {
+	for _, header := range headers {
+		if strings.EqualFold(header.Key, name) {
+			return header.Value
+		}
+	}
+	return ""
+}
+
+func authAttribute(attributes []PostmanAuthAttribute, key string) string {
+	for _, attribute := range attributes {
+		if attribute.Key == key {
+			if value, ok := attribute.Value.(string); ok {
+				return value
+			}
+			return fmt.Sprint(attribute.Value)
+		}
+	}
+	return ""
+}
+
+// sortParameters orders parameters by location, then query and header
+// parameters by name; path parameters keep their order in the path
+func sortParameters(params []OpenAPIParameter) {
+	order := map[string]int{"path": 0, "query": 1, "header": 2}
+	sort.SliceStable(params, func(i, j int) bool {
+		a, b := params[i], params[j]
+		if order[a.In] != order[b.In] {
+			return order[a.In] < order[b.In]
+		}
+		return a.In != "path" && a.Name < b.Name
+	})
+}
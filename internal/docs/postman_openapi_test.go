@@ -0,0 +1,157 @@
+package docs
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const testCollection = `{
+  "info": {"name": "Shop API", "description": "Orders and users"},
+  "variable": [{"key": "base_url", "value": "https://api.example.com"}],
+  "auth": {"type": "bearer", "bearer": [{"key": "token", "value": "{{token}}"}]},
+  "item": [
+    {
+      "name": "Users",
+      "item": [
+        {
+          "name": "Get user",
+          "request": {
+            "method": "GET",
+            "header": [{"key": "Accept", "value": "application/json"}, {"key": "X-Request-ID", "value": "abc"}],
+            "url": {"raw": "{{base_url}}/api/users/:id?include=posts&limit=10", "host": ["{{base_url}}"], "path": ["api", "users", ":id"],
+                    "query": [{"key": "include", "value": "posts"}, {"key": "limit", "value": "10"}]}
+          },
+          "response": [{
+            "name": "Found", "status": "OK", "code": 200,
+            "header": [{"key": "Content-Type", "value": "application/json"}],
+            "body": "{\"id\": 1, \"email\": \"a@example.com\", \"created_at\": \"2024-01-01T00:00:00Z\", \"tags\": [\"x\"], \"score\": 1}"
+          }, {
+            "name": "Missing", "code": 404, "body": "{\"error\": \"not found\"}"
+          }]
+        }
+      ]
+    },
+    {
+      "name": "Public",
+      "auth": {"type": "noauth"},
+      "item": [
+        {
+          "name": "Create order",
+          "request": {
+            "method": "POST",
+            "header": [{"key": "Content-Type", "value": "application/json"}],
+            "body": {"mode": "raw", "raw": "{\"items\": [{\"sku\": \"A1\", \"qty\": 2}]}"},
+            "url": {"raw": "{{base_url}}/api/orders"}
+          }
+        },
+        {
+          "name": "Create order with note",
+          "request": {
+            "method": "POST",
+            "body": {"mode": "raw", "raw": "{\"items\": [{\"sku\": \"B2\", \"qty\": 1.5}], \"note\": null}"},
+            "url": {"raw": "{{base_url}}/api/orders"}
+          }
+        },
+        {
+          "name": "Upload receipt",
+          "request": {
+            "method": "PUT",
+            "auth": {"type": "apikey", "apikey": [{"key": "key", "value": "X-API-Key"}, {"key": "in", "value": "header"}]},
+            "body": {"mode": "formdata", "formdata": [{"key": "file", "type": "file"}, {"key": "order", "value": "7"}]},
+            "url": {"raw": "{{staging}}/api/orders/{{order_id}}/receipt"}
+          }
+        }
+      ]
+    }
+  ]
+}`
+
+func TestPostmanOpenAPIConverter(t *testing.T) {
+	collection, err := NewPostmanParser().ParseCollectionFromBytes([]byte(testCollection))
+	if err != nil {
+		t.Fatalf("ParseCollectionFromBytes failed: %v", err)
+	}
+	spec := NewPostmanOpenAPIConverter("2.0.0").Convert(collection)
+
+	if spec.Info.Title != "Shop API" || spec.Info.Version != "2.0.0" {
+		t.Errorf("unexpected info: %+v", spec.Info)
+	}
+	if len(spec.Servers) != 2 || spec.Servers[0].URL != "https://api.example.com" || spec.Servers[1].URL != "{staging}" {
+		t.Errorf("unexpected servers: %+v", spec.Servers)
+	}
+	if _, ok := spec.Servers[1].Variables["staging"]; !ok {
+		t.Errorf("unknown variables should become server variables: %+v", spec.Servers[1])
+	}
+
+	get := spec.Paths["/api/users/{id}"]["get"]
+	if get == nil {
+		t.Fatalf("path variable not converted: %v", spec.Paths)
+	}
+	if get.Tags[0] != "Users" || get.Summary != "Get user" || get.OperationID != "getApiUsersById" {
+		t.Errorf("unexpected operation: %+v", get)
+	}
+	if len(get.Parameters) != 4 || get.Parameters[0].In != "path" || get.Parameters[1].Name != "include" ||
+		get.Parameters[2].Schema.Type != "integer" || get.Parameters[3].Name != "X-Request-ID" {
+		t.Errorf("unexpected parameters: %+v", get.Parameters)
+	}
+	if len(get.Security) != 1 || get.Security[0]["bearerAuth"] == nil {
+		t.Errorf("collection auth should be inherited: %+v", get.Security)
+	}
+	ok := get.Responses["200"].Content["application/json"]
+	user := spec.Components.Schemas["GetApiUsersById200Response"]
+	if ok.Schema.Ref != "#/components/schemas/GetApiUsersById200Response" || user == nil || ok.Examples["found"] == nil {
+		t.Fatalf("response schema not inferred: %+v %+v", ok, spec.Components.Schemas)
+	}
+	if user.Properties["id"].Type != "integer" || user.Properties["email"].Format != "email" ||
+		user.Properties["created_at"].Format != "date-time" || user.Properties["tags"].Items.Type != "string" {
+		t.Errorf("unexpected inferred properties: %+v", user.Properties)
+	}
+	if get.Responses["404"].Description != "Not Found" {
+		t.Errorf("missing status description: %+v", get.Responses["404"])
+	}
+
+	post := spec.Paths["/api/orders"]["post"]
+	if post == nil || post.Security != nil || post.Tags[0] != "Public" {
+		t.Fatalf("folder noauth not applied: %+v", post)
+	}
+	media := post.RequestBody.Content["application/json"]
+	if len(media.Examples) != 2 {
+		t.Errorf("requests sharing a path should merge examples: %+v", media.Examples)
+	}
+	order := spec.Components.Schemas["PostApiOrdersRequest"]
+	if order.Properties["items"].Items.Properties["qty"].Type != "number" || !order.Properties["note"].Nullable {
+		t.Errorf("merged schema should widen types: %+v", order.Properties["items"].Items.Properties)
+	}
+	if len(post.Responses) != 1 || post.Responses["default"] == nil {
+		t.Errorf("operations without examples need a default response")
+	}
+
+	put := spec.Paths["/api/orders/{order_id}/receipt"]["put"]
+	form := put.RequestBody.Content["multipart/form-data"]
+	if form.Schema.Properties["file"].Format != "binary" || len(put.Security) != 1 || put.Security[0]["apiKeyAuth"] == nil {
+		t.Errorf("unexpected form upload: %+v %+v", form.Schema, put.Security)
+	}
+	if scheme := spec.Components.SecuritySchemes["apiKeyAuth"]; scheme == nil || scheme.In != "header" || scheme.Name != "X-API-Key" {
+		t.Errorf("unexpected api key scheme: %+v", scheme)
+	}
+
+	if _, err := json.Marshal(spec); err != nil {
+		t.Errorf("spec does not serialize: %v", err)
+	}
+}
+
+func TestPostmanOpenAPIConverter_RepositoryCollection(t *testing.T) {
+	spec, err := NewPostmanOpenAPIConverter("").ConvertFile("../../postman/Go-Server-API.postman_collection.json")
+	if err != nil {
+		t.Fatalf("ConvertFile failed: %v", err)
+	}
+	if spec.Paths["/health"]["get"] == nil || spec.Paths["/api"]["post"] == nil {
+		t.Errorf("expected collection paths: %v", spec.Paths)
+	}
+	if len(spec.Servers) != 1 || spec.Servers[0].URL != "http://localhost:8080" {
+		t.Errorf("base_url variable should be resolved: %+v", spec.Servers)
+	}
+	if len(spec.Paths["/api"]["post"].RequestBody.Content["application/json"].Examples) < 2 {
+		t.Errorf("API actions should be merged as examples")
+	}
+}
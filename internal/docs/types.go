@@ -2,8 +2,10 @@ package docs
 
 // PostmanCollection represents a Postman collection
 type PostmanCollection struct {
-	Info PostmanInfo   `json:"info"`
-	Item []PostmanItem `json:"item"`
+	Info     PostmanInfo       `json:"info"`
+	Item     []PostmanItem     `json:"item"`
+	Auth     *PostmanAuth      `json:"auth,omitempty"`
+	Variable []PostmanVariable `json:"variable,omitempty"`
 }
 
 // PostmanInfo represents collection info
@@ -20,6 +22,7 @@ type PostmanItem struct {
 	Request     *PostmanRequest   `json:"request,omitempty"`
 	Response    []PostmanResponse `json:"response,omitempty"`
 	Event       []PostmanEvent    `json:"event,omitempty"`
+	Auth        *PostmanAuth      `json:"auth,omitempty"`
 }
 
 // PostmanRequest represents a Postman request
@@ -28,6 +31,7 @@ type PostmanRequest struct {
 	Header []PostmanHeader     `json:"header"`
 	Body   *PostmanRequestBody `json:"body"`
 	URL    *PostmanURL         `json:"url"`
+	Auth   *PostmanAuth        `json:"auth,omitempty"`
 }
 
 // PostmanHeader represents a request header
@@ -38,8 +42,39 @@ type PostmanHeader struct {
 
 // PostmanRequestBody represents request body
 type PostmanRequestBody struct {
-	Mode string `json:"mode"`
-	Raw  string `json:"raw"`
+	Mode       string             `json:"mode"`
+	Raw        string             `json:"raw"`
+	URLEncoded []PostmanFormParam `json:"urlencoded,omitempty"`
+	FormData   []PostmanFormParam `json:"formdata,omitempty"`
+}
+
+// PostmanFormParam represents a urlencoded or multipart form field
+type PostmanFormParam struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	// Type is text or file for formdata fields
+	Type string `json:"type,omitempty"`
+}
+
+// PostmanAuth represents collection, folder or request authentication.
+// Each type keeps its settings as key/value attributes under its own name.
+type PostmanAuth struct {
+	Type   string                 `json:"type"`
+	Bearer []PostmanAuthAttribute `json:"bearer,omitempty"`
+	Basic  []PostmanAuthAttribute `json:"basic,omitempty"`
+	APIKey []PostmanAuthAttribute `json:"apikey,omitempty"`
+}
+
+// PostmanAuthAttribute represents one authentication setting
+type PostmanAuthAttribute struct {
+	Key   string `json:"key"`
+	Value any    `json:"value"`
+}
+
+// PostmanVariable represents a collection variable such as {{base_url}}
+type PostmanVariable struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
 }
 
 // PostmanURL represents request URL
@@ -0,0 +1,404 @@
+package docs
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxValidationErrors bounds the errors reported for one payload
+const maxValidationErrors = 20
+
+// ValidationError is one mismatch between a payload and the spec
+type ValidationError struct {
+	// In is where the mismatch was found: path, query, body or response
+	In      string `json:"in"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e ValidationError) Error() string {
+	if e.Field == "" {
+		return e.In + ": " + e.Message
+	}
+	return e.In + " " + e.Field + ": " + e.Message
+}
+
+// SchemaValidator checks requests and responses against an OpenAPI spec
+type SchemaValidator struct {
+	spec   *OpenAPISpec
+	routes []specRoute
+}
+
+// specRoute is a spec path split into segments for matching
+type specRoute struct {
+	path     string
+	segments []string
+	literals int
+}
+
+// NewSchemaValidator creates a validator for spec. Routes registered with the
+// generator afterwards are not validated; build the validator last.
+func NewSchemaValidator(spec *OpenAPISpec) *SchemaValidator {
+	v := &SchemaValidator{spec: spec}
+	for path := range spec.Paths {
+		route := specRoute{path: path, segments: strings.Split(strings.Trim(path, "/"), "/")}
+		for _, segment := range route.segments {
+			if !isPathParam(segment) {
+				route.literals++
+			}
+		}
+		v.routes = append(v.routes, route)
+	}
+	return v
+}
+
+// Operation finds the documented operation for a request path. The returned
+// path parameters are keyed by name; ok is false for undocumented routes.
+func (v *SchemaValidator) Operation(method, path string) (op *OpenAPIOperation, params map[string]string, ok bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	var best *specRoute
+	for i := range v.routes {
+		route := &v.routes[i]
+		if len(route.segments) != len(segments) || v.spec.Paths[route.path][strings.ToLower(method)] == nil {
+			continue
+		}
+		if matchSegments(route.segments, segments) && (best == nil || route.literals > best.literals) {
+			best = route
+		}
+	}
+	if best == nil {
+		return nil, nil, false
+	}
+
+	params = make(map[string]string)
+	for i, segment := range best.segments {
+		if isPathParam(segment) {
+			value, err := url.PathUnescape(segments[i])
+			if err != nil {
+				value = segments[i]
+			}
+			params[strings.Trim(segment, "{}")] = value
+		}
+	}
+	return v.spec.Paths[best.path][strings.ToLower(method)], params, true
+}
+
+func isPathParam(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
+
+func matchSegments(pattern, segments []string) bool {
+	for i, segment := range pattern {
+		if !isPathParam(segment) && segment != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateRequest checks a request's parameters and JSON body against its
+// operation. body is the already-read request body.
+func (v *SchemaValidator) ValidateRequest(r *http.Request, body []byte) []ValidationError {
+	op, pathParams, ok := v.Operation(r.Method, r.URL.Path)
+	if !ok {
+		return nil
+	}
+
+	c := &schemaCheck{spec: v.spec}
+	query := r.URL.Query()
+	for _, param := range op.Parameters {
+		var value string
+		var present bool
+		switch param.In {
+		case "path":
+			value, present = pathParams[param.Name]
+		case "query":
+			present = query.Has(param.Name)
+			value = query.Get(param.Name)
+		case "header":
+			value = r.Header.Get(param.Name)
+			present = value != ""
+		default:
+			continue
+		}
+		if !present {
+			if param.Required {
+				c.add(param.In, param.Name, "is required")
+			}
+			continue
+		}
+		c.param(param, value)
+	}
+
+	if op.RequestBody == nil {
+		return c.errors
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		if op.RequestBody.Required {
+			c.add("body", "", "request body is required")
+		}
+		return c.errors
+	}
+	media, ok := op.RequestBody.Content["application/json"]
+	if !ok || !isJSON(r.Header.Get("Content-Type")) {
+		// only JSON bodies are described by schemas
+		return c.errors
+	}
+	c.document("body", media.Schema, body)
+	return c.errors
+}
+
+// ValidateResponse checks a JSON response body against the schema documented
+// for its status. Undocumented routes, statuses and content types are skipped.
+func (v *SchemaValidator) ValidateResponse(method, path string, status int, contentType string, body []byte) []ValidationError {
+	op, _, ok := v.Operation(method, path)
+	if !ok || !isJSON(contentType) {
+		return nil
+	}
+	response := op.Responses[strconv.Itoa(status)]
+	if response == nil {
+		response = op.Responses["default"]
+	}
+	if response == nil {
+		return nil
+	}
+	media, ok := response.Content["application/json"]
+	if !ok || media.Schema == nil {
+		return nil
+	}
+
+	c := &schemaCheck{spec: v.spec}
+	c.document("response", media.Schema, body)
+	return c.errors
+}
+
+// DocumentsResponse reports whether a JSON response for the request could be
+// validated, so callers only buffer bodies that will be checked
+func (v *SchemaValidator) DocumentsResponse(method, path string) bool {
+	op, _, ok := v.Operation(method, path)
+	if !ok {
+		return false
+	}
+	for _, response := range op.Responses {
+		if _, ok := response.Content["application/json"]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
+
+// schemaCheck accumulates the errors of one validation
+type schemaCheck struct {
+	spec   *OpenAPISpec
+	errors []ValidationError
+}
+
+func (c *schemaCheck) add(in, field, format string, args ...any) {
+	if len(c.errors) < maxValidationErrors {
+		c.errors = append(c.errors, ValidationError{In: in, Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+}
+
+// document decodes a JSON document and validates it against schema
+func (c *schemaCheck) document(in string, schema *OpenAPISchema, body []byte) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		c.add(in, "", "invalid JSON: %v", err)
+		return
+	}
+	c.value(in, "", schema, value)
+}
+
+// param validates a path, query or header parameter, which arrive as strings
+func (c *schemaCheck) param(param OpenAPIParameter, raw string) {
+	if param.Schema == nil {
+		return
+	}
+	var value any = raw
+	switch param.Schema.Type {
+	case "integer", "number":
+		value = json.Number(raw)
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.add(param.In, param.Name, "must be a boolean")
+			return
+		}
+		value = b
+	}
+	c.value(param.In, param.Name, param.Schema, value)
+}
+
+// resolve follows a component reference
+func (c *schemaCheck) resolve(schema *OpenAPISchema) *OpenAPISchema {
+	for depth := 0; schema != nil && schema.Ref != "" && depth < 32; depth++ {
+		schema = c.spec.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+	}
+	return schema
+}
+
+// value validates a decoded JSON value; field is its location, e.g. items[0].sku
+func (c *schemaCheck) value(in, field string, schema *OpenAPISchema, value any) {
+	// OpenAPI 3.0 cannot mark a $ref nullable, so the generator leaves pointer
+	// structs as plain references; accept null for them
+	isRef := schema != nil && schema.Ref != ""
+	schema = c.resolve(schema)
+	if schema == nil {
+		return
+	}
+	if value == nil {
+		if !schema.Nullable && !isRef && schema.Type != "" {
+			c.add(in, field, "must not be null")
+		}
+		return
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			c.add(in, field, "must be an object")
+			return
+		}
+		c.object(in, field, schema, object)
+	case "array":
+		array, ok := value.([]any)
+		if !ok {
+			c.add(in, field, "must be an array")
+			return
+		}
+		for i, item := range array {
+			c.value(in, fmt.Sprintf("%s[%d]", field, i), schema.Items, item)
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			c.add(in, field, "must be a string")
+			return
+		}
+		c.string(in, field, schema, s)
+	case "integer", "number":
+		n, ok := value.(json.Number)
+		if !ok {
+			c.add(in, field, "must be %s", numberKind(schema.Type))
+			return
+		}
+		c.number(in, field, schema, n)
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			c.add(in, field, "must be a boolean")
+		}
+	}
+}
+
+func (c *schemaCheck) object(in, field string, schema *OpenAPISchema, object map[string]any) {
+	for _, name := range schema.Required {
+		if _, ok := object[name]; !ok {
+			c.add(in, joinField(field, name), "is required")
+		}
+	}
+	// sorted so the reported errors are stable
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if propertySchema, ok := schema.Properties[name]; ok {
+			c.value(in, joinField(field, name), propertySchema, object[name])
+		} else if schema.AdditionalProperties != nil {
+			c.value(in, joinField(field, name), schema.AdditionalProperties, object[name])
+		}
+	}
+}
+
+func (c *schemaCheck) string(in, field string, schema *OpenAPISchema, s string) {
+	if len(schema.Enum) > 0 && !contains(schema.Enum, s) {
+		c.add(in, field, "must be one of %s", strings.Join(schema.Enum, ", "))
+	}
+	length := utf8.RuneCountInString(s)
+	if schema.MinLength != nil && length < *schema.MinLength {
+		c.add(in, field, "must be at least %d characters", *schema.MinLength)
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		c.add(in, field, "must be at most %d characters", *schema.MaxLength)
+	}
+
+	var err error
+	switch schema.Format {
+	case "date-time":
+		_, err = time.Parse(time.RFC3339, s)
+	case "email":
+		_, err = mail.ParseAddress(s)
+	case "uri":
+		_, err = url.ParseRequestURI(s)
+	case "byte":
+		_, err = base64.StdEncoding.DecodeString(s)
+	default:
+		return
+	}
+	if err != nil {
+		c.add(in, field, "must be a valid %s", schema.Format)
+	}
+}
+
+func (c *schemaCheck) number(in, field string, schema *OpenAPISchema, n json.Number) {
+	f, err := n.Float64()
+	if err != nil {
+		c.add(in, field, "must be %s", numberKind(schema.Type))
+		return
+	}
+	if schema.Type == "integer" {
+		if _, err := n.Int64(); err != nil && f != float64(int64(f)) {
+			c.add(in, field, "must be an integer")
+			return
+		}
+	}
+	if schema.Minimum != nil && f < *schema.Minimum {
+		c.add(in, field, "must be at least %v", *schema.Minimum)
+	}
+	if schema.Maximum != nil && f > *schema.Maximum {
+		c.add(in, field, "must be at most %v", *schema.Maximum)
+	}
+}
+
+func numberKind(schemaType string) string {
+	if schemaType == "integer" {
+		return "an integer"
+	}
+	return "a number"
+}
+
+func joinField(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package docs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testOrder struct {
+	SKU      string         `json:"sku" validate:"required,oneof=A1 B2"`
+	Quantity int            `json:"quantity" validate:"required,min=1"`
+	Note     *string        `json:"note"`
+	Parent   *testNode      `json:"parent"`
+	Lines    []testNode     `json:"lines"`
+	Meta     map[string]int `json:"meta"`
+}
+
+func newTestValidator() *SchemaValidator {
+	g, _ := newTestGenerator()
+	g.Register(Route{
+		Method: http.MethodPost, Path: "/api/orders",
+		Query:     []QueryParam{{Name: "limit", Type: "integer"}, {Name: "dry_run", Type: "boolean", Required: true}},
+		Request:   testOrder{},
+		Responses: map[int]any{http.StatusCreated: testOrder{}},
+	})
+	g.Register(Route{Method: http.MethodGet, Path: "/api/posts/latest", Responses: map[int]any{http.StatusOK: []string{}}})
+	return NewSchemaValidator(g.Spec())
+}
+
+func jsonRequest(method, target, body string) *http.Request {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	return r
+}
+
+func TestSchemaValidator_Operation(t *testing.T) {
+	v := newTestValidator()
+
+	op, params, ok := v.Operation(http.MethodGet, "/api/posts/42")
+	if !ok || params["id"] != "42" || op.OperationID != "getApiPostsById" {
+		t.Errorf("path parameter not matched: %v %v", op, params)
+	}
+	if op, _, _ := v.Operation(http.MethodGet, "/api/posts/latest"); op == nil || op.OperationID != "getApiPostsLatest" {
+		t.Errorf("literal segments should win over parameters: %v", op)
+	}
+	if _, _, ok := v.Operation(http.MethodDelete, "/api/posts/42"); ok {
+		t.Error("undocumented methods should not match")
+	}
+	if _, _, ok := v.Operation(http.MethodGet, "/unknown"); ok {
+		t.Error("undocumented paths should not match")
+	}
+}
+
+func TestSchemaValidator_ValidateRequest(t *testing.T) {
+	v := newTestValidator()
+
+	valid := `{"sku": "A1", "quantity": 2, "note": null, "parent": null, "lines": [{"name": "x", "children": []}], "meta": {"a": 1}}`
+	if problems := v.ValidateRequest(jsonRequest(http.MethodPost, "/api/orders?dry_run=true&limit=5", valid), []byte(valid)); len(problems) != 0 {
+		t.Errorf("valid request rejected: %v", problems)
+	}
+
+	invalid := `{"sku": "C3", "quantity": 0.5, "lines": [{"name": 3}], "meta": {"a": "b"}}`
+	problems := v.ValidateRequest(jsonRequest(http.MethodPost, "/api/orders?limit=ten", invalid), []byte(invalid))
+	expected := map[string]bool{
+		"query limit: must be an integer":      false,
+		"query dry_run: is required":           false,
+		"body sku: must be one of A1, B2":      false,
+		"body quantity: must be an integer":    false,
+		"body lines[0].name: must be a string": false,
+		"body meta.a: must be an integer":      false,
+	}
+	for _, problem := range problems {
+		if _, ok := expected[problem.Error()]; !ok {
+			t.Errorf("unexpected problem: %v", problem)
+		}
+		expected[problem.Error()] = true
+	}
+	for message, found := range expected {
+		if !found {
+			t.Errorf("missing problem %q in %v", message, problems)
+		}
+	}
+
+	if problems := v.ValidateRequest(jsonRequest(http.MethodPost, "/api/orders?dry_run=1", ""), nil); len(problems) != 1 || problems[0].Message != "request body is required" {
+		t.Errorf("missing body not reported: %v", problems)
+	}
+	if problems := v.ValidateRequest(jsonRequest(http.MethodPost, "/api/orders?dry_run=1", "{"), []byte("{")); len(problems) != 1 || !strings.HasPrefix(problems[0].Message, "invalid JSON") {
+		t.Errorf("malformed body not reported: %v", problems)
+	}
+
+	login := `{"email": "not-an-email", "password": "short"}`
+	problems = v.ValidateRequest(jsonRequest(http.MethodPost, "/api/auth/login", login), []byte(login))
+	if len(problems) != 2 {
+		t.Errorf("validate tag constraints not enforced: %v", problems)
+	}
+}
+
+func TestSchemaValidator_ValidateResponse(t *testing.T) {
+	v := newTestValidator()
+
+	if problems := v.ValidateResponse(http.MethodGet, "/api/posts/1", http.StatusNotFound, "application/json",
+		[]byte(`{"type": "not_found", "message": "Post not found"}`)); len(problems) != 0 {
+		t.Errorf("error body rejected: %v", problems)
+	}
+	problems := v.ValidateResponse(http.MethodGet, "/api/posts/1", http.StatusOK, "application/json; charset=utf-8",
+		[]byte(`{"id": "1", "title": "Hello", "content": "", "slug": "hello", "created_at": "yesterday"}`))
+	if len(problems) != 2 || problems[0].Field != "created_at" || problems[1].Field != "id" || problems[0].In != "response" {
+		t.Errorf("expected id and created_at mismatches: %v", problems)
+	}
+	if problems := v.ValidateResponse(http.MethodGet, "/api/posts/1", http.StatusOK, "text/plain", []byte("nope")); len(problems) != 0 {
+		t.Errorf("non-JSON responses should be skipped: %v", problems)
+	}
+	if problems := v.ValidateResponse(http.MethodGet, "/api/posts/1", http.StatusTeapot, "application/json", []byte("1")); len(problems) != 0 {
+		t.Errorf("undocumented statuses should be skipped: %v", problems)
+	}
+	if !v.DocumentsResponse(http.MethodGet, "/api/posts/1") || v.DocumentsResponse(http.MethodGet, "/unknown") {
+		t.Error("unexpected DocumentsResponse result")
+	}
+}
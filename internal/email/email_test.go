@@ -0,0 +1,159 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-server/internal/database/models"
+	"go-server/internal/logger"
+)
+
+func TestRenderer_LocalizesAndEscapes(t *testing.T) {
+	renderer, err := NewRenderer("Acme", "en")
+	if err != nil {
+		t.Fatalf("NewRenderer failed: %v", err)
+	}
+
+	tests := []struct {
+		locale   string
+		expected string
+		subject  string
+	}{
+		{"es-MX", "es", "¡Bienvenido a Acme, <bob>!"},
+		{"pt_BR", "en", "Welcome to Acme, <bob>!"},
+		{"", "en", "Welcome to Acme, <bob>!"},
+	}
+	for _, tt := range tests {
+		rendered, err := renderer.Render(TemplateWelcome, tt.locale, map[string]any{"Username": "<bob>"})
+		if err != nil {
+			t.Fatalf("Render(%q) failed: %v", tt.locale, err)
+		}
+		if rendered.Locale != tt.expected || rendered.Subject != tt.subject {
+			t.Errorf("Render(%q) = %s %q, want %s %q", tt.locale, rendered.Locale, rendered.Subject, tt.expected, tt.subject)
+		}
+		if !strings.Contains(rendered.HTML, "&lt;bob&gt;") || strings.Contains(rendered.HTML, "<bob>") {
+			t.Errorf("Expected the HTML part to escape data")
+		}
+		if !strings.Contains(rendered.Text, "<bob>") {
+			t.Errorf("Expected the text part to contain raw data, got %q", rendered.Text)
+		}
+	}
+
+	if _, err := renderer.Render("missing", "en", nil); err == nil {
+		t.Error("Expected an error for an unknown template")
+	}
+}
+
+func TestBuildMIME(t *testing.T) {
+	message := &Message{
+		From:      "Acme <no-reply@acme.test>",
+		To:        "bob@example.com",
+		Subject:   "¡Hola!",
+		Text:      "plain",
+		HTML:      "<p>html</p>",
+		MessageID: NewMessageID("Acme <no-reply@acme.test>"),
+	}
+	data, err := BuildMIME(message, time.Now())
+	if err != nil {
+		t.Fatalf("BuildMIME failed: %v", err)
+	}
+	body := string(data)
+	for _, expected := range []string{"Subject: =?utf-8?q?", "multipart/alternative", "text/plain", "text/html", "@acme.test>"} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected MIME message to contain %q", expected)
+		}
+	}
+
+	message.To = "bob@example.com\r\nBcc: eve@example.com"
+	if _, err := BuildMIME(message, time.Now()); err == nil {
+		t.Error("Expected header injection to be rejected")
+	}
+}
+
+type memoryDeliveries struct {
+	deliveries []*models.EmailDelivery
+}
+
+func (md *memoryDeliveries) CreateEmailDelivery(ctx context.Context, delivery *models.EmailDelivery) error {
+	delivery.ID = uint(len(md.deliveries) + 1)
+	md.deliveries = append(md.deliveries, delivery)
+	return nil
+}
+
+func (md *memoryDeliveries) UpdateEmailDelivery(ctx context.Context, delivery *models.EmailDelivery) error {
+	return nil
+}
+
+func (md *memoryDeliveries) FindEmailDeliveryByReference(ctx context.Context, template, reference string) (*models.EmailDelivery, error) {
+	for i := len(md.deliveries) - 1; i >= 0; i-- {
+		if d := md.deliveries[i]; d.Template == template && d.Reference == reference {
+			return d, nil
+		}
+	}
+	return nil, nil
+}
+
+type flakySender struct {
+	failures int
+	sent     []*Message
+}
+
+func (fs *flakySender) Send(ctx context.Context, message *Message) error {
+	if fs.failures > 0 {
+		fs.failures--
+		return errors.New("connection refused")
+	}
+	fs.sent = append(fs.sent, message)
+	return nil
+}
+
+func TestMailer_TracksAndDeduplicatesDeliveries(t *testing.T) {
+	renderer, _ := NewRenderer("Acme", "en")
+	sender := &flakySender{failures: 1}
+	store := &memoryDeliveries{}
+	mailer := NewMailer(renderer, sender, store, "no-reply@acme.test", logger.NewServerLogger())
+
+	req := Request{To: "Bob@Example.com", Template: TemplateWelcome, Data: map[string]any{"Username": "bob"}, Reference: "outbox:1"}
+	if _, err := mailer.Send(context.Background(), req); err == nil {
+		t.Fatal("Expected the first send to fail")
+	}
+	delivery, err := mailer.Send(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Retry failed: %v", err)
+	}
+	if _, err := mailer.Send(context.Background(), req); err != nil {
+		t.Fatalf("Duplicate send failed: %v", err)
+	}
+
+	if len(sender.sent) != 1 || len(store.deliveries) != 2 {
+		t.Fatalf("Expected one email and two deliveries, got %d and %d", len(sender.sent), len(store.deliveries))
+	}
+	if store.deliveries[0].Status != models.EmailStatusFailed || delivery.Status != models.EmailStatusSent {
+		t.Errorf("Unexpected statuses %s, %s", store.deliveries[0].Status, delivery.Status)
+	}
+	if delivery.Recipient != "bob@example.com" || delivery.MessageID != sender.sent[0].MessageID {
+		t.Errorf("Unexpected delivery %+v", delivery)
+	}
+}
+
+func TestPreviewHandler(t *testing.T) {
+	renderer, _ := NewRenderer("Acme", "en")
+	handler := NewPreviewHandler(renderer, "/debug/emails")
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/emails", nil))
+	if !strings.Contains(w.Body.String(), `href="/debug/emails/welcome?locale=es"`) {
+		t.Errorf("Expected index to link locales, got %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/emails/security_alert?format=text", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "203.0.113.7") {
+		t.Errorf("Unexpected text preview %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,81 @@
+package inbound
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// replyLocalPart matches reply+<kind>.<id>.<user>.<signature>
+var replyLocalPart = regexp.MustCompile(`^reply\+([a-z_]+)\.(\d+)\.(\d+)\.([0-9a-f]{32})$`)
+
+// Target is what a reply answers and the user it was sent to, who is the
+// only sender it is accepted from
+type Target struct {
+	Kind   string
+	ID     uint
+	UserID uint
+}
+
+// ReplyAddresses issues and verifies the addresses replies are sent to.
+// The target is encoded in the address and signed, so replies need no
+// server-side state and cannot be forged for another post or user.
+type ReplyAddresses struct {
+	domain string
+	secret []byte
+}
+
+// NewReplyAddresses creates reply addresses in domain, signed with secret
+func NewReplyAddresses(domain, secret string) *ReplyAddresses {
+	return &ReplyAddresses{domain: strings.ToLower(domain), secret: []byte(secret)}
+}
+
+// Address returns the reply address for a target, for the Reply-To header
+// of the email sent to target.UserID
+func (ra *ReplyAddresses) Address(target Target) string {
+	payload := fmt.Sprintf("%s.%d.%d", target.Kind, target.ID, target.UserID)
+	return "reply+" + payload + "." + ra.sign(payload) + "@" + ra.domain
+}
+
+// Parse verifies a reply address, which may include a display name, and
+// returns its target
+func (ra *ReplyAddresses) Parse(address string) (Target, error) {
+	if parsed, err := mail.ParseAddress(address); err == nil {
+		address = parsed.Address
+	}
+	local, domain, ok := strings.Cut(strings.ToLower(strings.TrimSpace(address)), "@")
+	if !ok || domain != ra.domain {
+		return Target{}, ErrUnknownAddress
+	}
+	match := replyLocalPart.FindStringSubmatch(local)
+	if match == nil {
+		return Target{}, ErrUnknownAddress
+	}
+	payload := match[1] + "." + match[2] + "." + match[3]
+	if !hmac.Equal([]byte(match[4]), []byte(ra.sign(payload))) {
+		return Target{}, ErrUnknownAddress
+	}
+
+	id, err := strconv.ParseUint(match[2], 10, 32)
+	if err != nil {
+		return Target{}, ErrUnknownAddress
+	}
+	userID, err := strconv.ParseUint(match[3], 10, 32)
+	if err != nil {
+		return Target{}, ErrUnknownAddress
+	}
+	return Target{Kind: match[1], ID: uint(id), UserID: uint(userID)}, nil
+}
+
+// sign returns the truncated HMAC of an address payload; 128 bits keep the
+// address short enough for mail clients
+func (ra *ReplyAddresses) sign(payload string) string {
+	mac := hmac.New(sha256.New, ra.secret)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
@@ -0,0 +1,112 @@
+package inbound
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go-server/internal/database/models"
+	"go-server/internal/notify"
+
+	"gorm.io/gorm"
+)
+
+// KindPost is the reply target of emails about a post; replies become
+// comments on it
+const KindPost = "post"
+
+// NotificationPostCommented is the notification type sent to a post's
+// author when someone comments on it
+const NotificationPostCommented = "post.commented"
+
+// ErrTargetNotFound is returned when the post a reply answers is gone or
+// no longer accepts comments
+var ErrTargetNotFound = errors.New("reply target not found")
+
+// CommentStore records comments
+type CommentStore interface {
+	CreateComment(ctx context.Context, comment *models.Comment) error
+	FindCommentByMessageID(ctx context.Context, messageID string) (*models.Comment, error)
+}
+
+// PostStore loads the post a reply answers
+type PostStore interface {
+	GetPostByID(ctx context.Context, id uint) (*models.Post, error)
+}
+
+// Notifier tells users about activity on their content
+type Notifier interface {
+	Dispatch(ctx context.Context, userID uint, notification notify.Notification) (*models.NotificationDelivery, error)
+}
+
+// CommentReplies returns the handler posting replies to KindPost addresses
+// as comments and notifying the post's author; notifier may be nil
+func CommentReplies(comments CommentStore, posts PostStore, notifier Notifier) ReplyHandler {
+	return func(ctx context.Context, reply *Reply) error {
+		if reply.MessageID != "" {
+			existing, err := comments.FindCommentByMessageID(ctx, reply.MessageID)
+			if err != nil {
+				return fmt.Errorf("failed to check previous replies: %w", err)
+			}
+			if existing != nil {
+				// The provider redelivered an email already posted
+				return nil
+			}
+		}
+
+		post, err := posts.GetPostByID(ctx, reply.Target.ID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTargetNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load post: %w", err)
+		}
+		if !post.IsPublished() {
+			return ErrTargetNotFound
+		}
+
+		comment := &models.Comment{
+			PostID:    post.ID,
+			AuthorID:  reply.User.ID,
+			Body:      reply.Body,
+			Source:    models.CommentSourceEmail,
+			MessageID: reply.MessageID,
+		}
+		if err := comments.CreateComment(ctx, comment); err != nil {
+			return fmt.Errorf("failed to create comment: %w", err)
+		}
+
+		if notifier == nil || post.AuthorID == reply.User.ID {
+			return nil
+		}
+		// The comment is posted; a missed notification is not worth having
+		// the provider redeliver the email, so failures are not returned
+		notifier.Dispatch(ctx, post.AuthorID, notify.Notification{
+			Type:  NotificationPostCommented,
+			Title: reply.User.Username + " commented on " + post.Title,
+			Body:  excerpt(reply.Body, 140),
+			Data: map[string]string{
+				"post_id":    post.PublicID,
+				"comment_id": fmt.Sprint(comment.ID),
+			},
+			Reference:  fmt.Sprintf("comment:%d", comment.ID),
+			Digestible: true,
+		})
+		return nil
+	}
+}
+
+// excerpt shortens text to at most n runes on a word boundary
+func excerpt(text string, n int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	cut := string(runes[:n])
+	if space := strings.LastIndex(cut, " "); space > n/2 {
+		cut = cut[:space]
+	}
+	return cut + "…"
+}
@@ -0,0 +1,361 @@
+package inbound
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/dbtest"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/logger"
+	"go-server/internal/notify"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func TestReplyAddresses_RoundTripAndTampering(t *testing.T) {
+	addresses := NewReplyAddresses("Reply.Example.com", testSecret)
+	target := Target{Kind: KindPost, ID: 42, UserID: 7}
+	address := addresses.Address(target)
+	if !strings.HasPrefix(address, "reply+post.42.7.") || !strings.HasSuffix(address, "@reply.example.com") {
+		t.Fatalf("Unexpected address %q", address)
+	}
+
+	for _, candidate := range []string{address, strings.ToUpper(address), `"Site" <` + address + `>`} {
+		got, err := addresses.Parse(candidate)
+		if err != nil || got != target {
+			t.Errorf("Parse(%q) = %+v, %v", candidate, got, err)
+		}
+	}
+
+	forged := strings.Replace(address, "post.42.7.", "post.42.8.", 1)
+	otherDomain := strings.Replace(address, "@reply.example.com", "@example.org", 1)
+	otherSecret := NewReplyAddresses("reply.example.com", "another-secret").Address(target)
+	for _, candidate := range []string{forged, otherDomain, otherSecret, "someone@reply.example.com", "not an address"} {
+		if _, err := addresses.Parse(candidate); !errors.Is(err, ErrUnknownAddress) {
+			t.Errorf("Parse(%q) error = %v, want ErrUnknownAddress", candidate, err)
+		}
+	}
+}
+
+func TestStripQuoted(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "Thanks, great post!", "Thanks, great post!"},
+		{"gmail attribution", "Agreed.\n\nOn Mon, Jan 2, 2026 at 10:00 AM Site <reply@example.com> wrote:\n> original", "Agreed."},
+		{"outlook separator", "Sounds good\r\n\r\n-----Original Message-----\r\nFrom: Site", "Sounds good"},
+		{"signature", "Nice\n\n-- \nJane", "Nice"},
+		{"interleaved quote", "> question?\nanswer", "answer"},
+		{"only quoted", "> everything\n> is quoted", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StripQuoted(tt.in); got != tt.want {
+				t.Errorf("StripQuoted() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessageBody_FallsBackToHTML(t *testing.T) {
+	message := &Message{HTML: "<p>Hello <b>there</b></p><blockquote>old</blockquote>"}
+	if body := StripQuoted(message.Body()); !strings.Contains(body, "Hello there") {
+		t.Errorf("Body() = %q, want the HTML text", body)
+	}
+}
+
+func mailgunRequest(key string, at time.Time, fields url.Values) *http.Request {
+	timestamp := fmt.Sprint(at.Unix())
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(timestamp + "token"))
+	fields.Set("timestamp", timestamp)
+	fields.Set("token", "token")
+	fields.Set("signature", hex.EncodeToString(mac.Sum(nil)))
+	req := httptest.NewRequest(http.MethodPost, "/api/email/inbound/mailgun", strings.NewReader(fields.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestMailgunProvider_VerifiesSignature(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	provider := NewMailgunProvider("mailgun-key").WithClock(fake)
+	fields := func() url.Values {
+		return url.Values{
+			"From":       {"Jane <jane@example.com>"},
+			"recipient":  {"reply+post.1.2.abc@reply.example.com"},
+			"subject":    {"Re: Post"},
+			"body-plain": {"Hi"},
+			"Message-Id": {"<m1@example.com>"},
+		}
+	}
+
+	message, err := provider.Parse(mailgunRequest("mailgun-key", fake.Now(), fields()))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if message.From != "Jane <jane@example.com>" || message.Text != "Hi" || message.MessageID != "<m1@example.com>" || len(message.To) != 1 {
+		t.Errorf("Unexpected message %+v", message)
+	}
+
+	if _, err := provider.Parse(mailgunRequest("wrong-key", fake.Now(), fields())); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Parse() with the wrong key error = %v, want ErrInvalidSignature", err)
+	}
+	if _, err := provider.Parse(mailgunRequest("mailgun-key", fake.Now().Add(-time.Hour), fields())); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Parse() of a stale request error = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestSendGridProvider_ChecksBasicAuthAndReadsRawEmail(t *testing.T) {
+	provider := NewSendGridProvider("sendgrid-secret")
+	raw := "From: Jane <jane@example.com>\r\nTo: reply+post.1.2.abc@reply.example.com\r\nSubject: Re: Post\r\n" +
+		"Message-ID: <m2@example.com>\r\nContent-Type: text/plain; charset=utf-8\r\n\r\nRaw reply\r\n"
+	request := func(password string) *http.Request {
+		form := url.Values{"email": {raw}}
+		req := httptest.NewRequest(http.MethodPost, "/api/email/inbound/sendgrid", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth("sendgrid", password)
+		return req
+	}
+
+	message, err := provider.Parse(request("sendgrid-secret"))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if strings.TrimSpace(message.Text) != "Raw reply" || message.MessageID != "<m2@example.com>" || message.Provider != ProviderSendGrid {
+		t.Errorf("Unexpected message %+v", message)
+	}
+
+	if _, err := provider.Parse(request("wrong")); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Parse() with the wrong password error = %v, want ErrInvalidSignature", err)
+	}
+}
+
+// testSNS signs SNS messages with a self-signed certificate registered
+// under an SNS certificate URL
+type testSNS struct {
+	key     *rsa.PrivateKey
+	certURL string
+}
+
+func newTestSNS(t *testing.T, provider *SESProvider) *testSNS {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sns.amazonaws.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+	certURL := "https://sns.us-east-1.amazonaws.com/SimpleNotificationService-test.pem"
+	provider.certs[certURL] = cert
+	return &testSNS{key: key, certURL: certURL}
+}
+
+func (ts *testSNS) request(t *testing.T, n snsMessage) *http.Request {
+	t.Helper()
+	n.SignatureVersion = "2"
+	n.SigningCertURL = ts.certURL
+	digest := sha256.Sum256([]byte(snsStringToSign(&n)))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, ts.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+	n.Signature = base64.StdEncoding.EncodeToString(signature)
+	body, _ := json.Marshal(n)
+	return httptest.NewRequest(http.MethodPost, "/api/email/inbound/ses", bytes.NewReader(body))
+}
+
+func TestSESProvider_VerifiesSNSSignature(t *testing.T) {
+	const topic = "arn:aws:sns:us-east-1:123456789012:inbound"
+	provider := NewSESProvider(topic)
+	sns := newTestSNS(t, provider)
+
+	raw := "From: jane@example.com\r\nTo: support@example.com\r\nSubject: Re: Post\r\nMessage-ID: <m3@example.com>\r\n\r\nSES reply\r\n"
+	content, _ := json.Marshal(map[string]interface{}{
+		"notificationType": "Received",
+		"mail":             map[string]interface{}{"destination": []string{"reply+post.1.2.abc@reply.example.com"}},
+		"content":          base64.StdEncoding.EncodeToString([]byte(raw)),
+	})
+	notification := snsMessage{
+		Type:      "Notification",
+		MessageId: "sns-1",
+		TopicArn:  topic,
+		Message:   string(content),
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	message, err := provider.Parse(sns.request(t, notification))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if message.To[0] != "reply+post.1.2.abc@reply.example.com" || strings.TrimSpace(message.Text) != "SES reply" {
+		t.Errorf("Unexpected message %+v", message)
+	}
+
+	tampered := sns.request(t, notification)
+	var body snsMessage
+	json.NewDecoder(tampered.Body).Decode(&body)
+	body.Message = strings.Replace(body.Message, "Received", "Bounce", 1)
+	data, _ := json.Marshal(body)
+	if _, err := provider.Parse(httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data))); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Parse() of a tampered message error = %v, want ErrInvalidSignature", err)
+	}
+
+	notification.TopicArn = "arn:aws:sns:us-east-1:123456789012:other"
+	if _, err := provider.Parse(sns.request(t, notification)); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Parse() from another topic error = %v, want ErrInvalidSignature", err)
+	}
+}
+
+type recordingNotifier struct {
+	userIDs []uint
+}
+
+func (rn *recordingNotifier) Dispatch(ctx context.Context, userID uint, notification notify.Notification) (*models.NotificationDelivery, error) {
+	rn.userIDs = append(rn.userIDs, userID)
+	return nil, nil
+}
+
+type testReplies struct {
+	processor *Processor
+	addresses *ReplyAddresses
+	comments  *repositories.CommentRepository
+	notifier  *recordingNotifier
+	author    *models.User
+	reader    *models.User
+	post      *models.Post
+}
+
+func newTestReplies(t *testing.T) *testReplies {
+	t.Helper()
+	db := dbtest.Open(t, &models.User{}, &models.Post{}, &models.Comment{})
+
+	author := &models.User{Username: "author", Email: "author@example.com", Password: "x", IsActive: true}
+	reader := &models.User{Username: "reader", Email: "reader@example.com", Password: "x", IsActive: true}
+	for _, user := range []*models.User{author, reader} {
+		if err := db.Create(user).Error; err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+	post := &models.Post{Title: "Hello", Slug: "hello", Content: "Body", AuthorID: author.ID}
+	post.Publish()
+	if err := db.Create(post).Error; err != nil {
+		t.Fatalf("Failed to create post: %v", err)
+	}
+
+	addresses := NewReplyAddresses("reply.example.com", testSecret)
+	comments := repositories.NewCommentRepository(db)
+	notifier := &recordingNotifier{}
+	processor := NewProcessor(addresses, repositories.NewUserRepository(db), logger.NewServerLogger()).
+		Handle(KindPost, CommentReplies(comments, repositories.NewPostRepository(db), notifier))
+	return &testReplies{
+		processor: processor,
+		addresses: addresses,
+		comments:  comments,
+		notifier:  notifier,
+		author:    author,
+		reader:    reader,
+		post:      post,
+	}
+}
+
+func (tr *testReplies) message(from string, to *models.User, text string) *Message {
+	return &Message{
+		Provider:  ProviderMailgun,
+		From:      from,
+		To:        []string{"list@example.com", tr.addresses.Address(Target{Kind: KindPost, ID: tr.post.ID, UserID: to.ID})},
+		Text:      text,
+		MessageID: "<reply-1@example.com>",
+	}
+}
+
+func TestProcess_CreatesCommentAndNotifiesAuthor(t *testing.T) {
+	tr := newTestReplies(t)
+	ctx := context.Background()
+	message := tr.message("Reader <READER@example.com>", tr.reader, "Loved it\n\nOn Tue, someone wrote:\n> Hello")
+
+	reply, err := tr.processor.Process(ctx, message)
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if reply.User.ID != tr.reader.ID || reply.Body != "Loved it" {
+		t.Errorf("Unexpected reply %+v", reply)
+	}
+	comments, err := tr.comments.ListCommentsByPost(ctx, tr.post.ID, 0, 10)
+	if err != nil || len(comments) != 1 {
+		t.Fatalf("ListCommentsByPost() = %d comments, %v; want 1", len(comments), err)
+	}
+	if comments[0].Body != "Loved it" || comments[0].Source != models.CommentSourceEmail || comments[0].AuthorID != tr.reader.ID {
+		t.Errorf("Unexpected comment %+v", comments[0])
+	}
+	if len(tr.notifier.userIDs) != 1 || tr.notifier.userIDs[0] != tr.author.ID {
+		t.Errorf("Notified %v, want the post author %d", tr.notifier.userIDs, tr.author.ID)
+	}
+
+	// A redelivery of the same email is accepted without a second comment
+	if _, err := tr.processor.Process(ctx, message); err != nil {
+		t.Fatalf("Process() of a redelivery error = %v", err)
+	}
+	if comments, _ := tr.comments.ListCommentsByPost(ctx, tr.post.ID, 0, 10); len(comments) != 1 {
+		t.Errorf("Redelivery created %d comments, want 1", len(comments))
+	}
+}
+
+func TestProcess_RejectsUnattributableReplies(t *testing.T) {
+	tr := newTestReplies(t)
+	ctx := context.Background()
+
+	forwarded := tr.message("someone@example.org", tr.reader, "Hijacked")
+	if _, err := tr.processor.Process(ctx, forwarded); !errors.Is(err, ErrSenderMismatch) {
+		t.Errorf("Process() from another sender error = %v, want ErrSenderMismatch", err)
+	}
+
+	unknown := tr.message("reader@example.com", tr.reader, "Hi")
+	unknown.To = []string{"reply+post.1.2.00000000000000000000000000000000@reply.example.com"}
+	if _, err := tr.processor.Process(ctx, unknown); !errors.Is(err, ErrUnknownAddress) {
+		t.Errorf("Process() to a forged address error = %v, want ErrUnknownAddress", err)
+	}
+
+	empty := tr.message("reader@example.com", tr.reader, "> only the quote")
+	if _, err := tr.processor.Process(ctx, empty); !errors.Is(err, ErrEmptyReply) {
+		t.Errorf("Process() of an empty reply error = %v, want ErrEmptyReply", err)
+	}
+
+	if comments, _ := tr.comments.ListCommentsByPost(ctx, tr.post.ID, 0, 10); len(comments) != 0 {
+		t.Errorf("Rejected replies created %d comments", len(comments))
+	}
+}
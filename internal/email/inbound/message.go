@@ -0,0 +1,156 @@
+// Package inbound turns emails received by the mail provider into actions
+// on the site. Outgoing emails carry a signed reply+ address in Reply-To;
+// when the user replies, the provider posts the email to the inbound
+// webhook, the provider's signature is verified, the quoted original is
+// stripped and the reply is handed to the handler for its target, such as
+// one creating a comment on a post.
+package inbound
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+)
+
+var (
+	// ErrInvalidSignature is returned when a webhook request cannot be
+	// shown to come from the provider
+	ErrInvalidSignature = errors.New("invalid inbound email signature")
+	// ErrMalformed is returned for requests that are not a parseable email
+	ErrMalformed = errors.New("malformed inbound email")
+	// ErrUnknownAddress is returned when no recipient is a valid reply address
+	ErrUnknownAddress = errors.New("no valid reply address")
+	// ErrSenderMismatch is returned when the reply comes from someone other
+	// than the user the address was issued to
+	ErrSenderMismatch = errors.New("reply sender does not match the recipient of the original email")
+	// ErrEmptyReply is returned when nothing is left once quoted text is removed
+	ErrEmptyReply = errors.New("reply is empty")
+	// ErrUnknownKind is returned for reply targets without a handler
+	ErrUnknownKind = errors.New("no handler for reply target")
+)
+
+// maxReplyLength bounds the text kept from a reply, in runes
+const maxReplyLength = 10000
+
+// Message is a received email, normalised from the provider's format
+type Message struct {
+	Provider  string
+	From      string
+	To        []string
+	Subject   string
+	Text      string
+	HTML      string
+	MessageID string
+	InReplyTo string
+}
+
+// Body returns the plain text of the email, converting the HTML part when
+// there is no text part
+func (m *Message) Body() string {
+	if strings.TrimSpace(m.Text) != "" {
+		return m.Text
+	}
+	return htmlToText(m.HTML)
+}
+
+// parseMIME reads a raw RFC 5322 email, as SES and SendGrid's raw mode
+// deliver it
+func parseMIME(raw []byte) (*Message, error) {
+	parsed, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	decoder := new(mime.WordDecoder)
+	subject, err := decoder.DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil {
+		subject = parsed.Header.Get("Subject")
+	}
+	message := &Message{
+		From:      parsed.Header.Get("From"),
+		To:        splitAddresses(parsed.Header.Get("To")),
+		Subject:   subject,
+		MessageID: strings.TrimSpace(parsed.Header.Get("Message-ID")),
+		InReplyTo: strings.TrimSpace(parsed.Header.Get("In-Reply-To")),
+	}
+	if err := readPart(message, parsed.Header.Get("Content-Type"), parsed.Header.Get("Content-Transfer-Encoding"), parsed.Body); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	return message, nil
+}
+
+// readPart fills in the first text and HTML bodies found in a part,
+// descending into multipart containers; attachments are ignored
+func readPart(message *Message, contentType, encoding string, body io.Reader) error {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if strings.HasPrefix(part.Header.Get("Content-Disposition"), "attachment") {
+				continue
+			}
+			if err := readPart(message, part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part); err != nil {
+				return err
+			}
+		}
+	}
+
+	if mediaType != "text/plain" && mediaType != "text/html" {
+		return nil
+	}
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	if mediaType == "text/plain" && message.Text == "" {
+		message.Text = string(data)
+	} else if mediaType == "text/html" && message.HTML == "" {
+		message.HTML = string(data)
+	}
+	return nil
+}
+
+// splitAddresses splits an address list header, keeping unparseable
+// entries as they are
+func splitAddresses(header string) []string {
+	if header == "" {
+		return nil
+	}
+	if list, err := mail.ParseAddressList(header); err == nil {
+		addresses := make([]string, len(list))
+		for i, address := range list {
+			addresses[i] = address.Address
+		}
+		return addresses
+	}
+	var addresses []string
+	for _, address := range strings.Split(header, ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
@@ -0,0 +1,114 @@
+package inbound
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"go-server/internal/database/models"
+	"go-server/internal/logger"
+
+	"gorm.io/gorm"
+)
+
+// Reply is a verified reply, attributed to the user it was sent to
+type Reply struct {
+	Target  Target
+	User    *models.User
+	Body    string
+	Subject string
+	// MessageID identifies the email, so redeliveries can be ignored
+	MessageID string
+}
+
+// ReplyHandler acts on replies to one kind of target
+type ReplyHandler func(ctx context.Context, reply *Reply) error
+
+// UserStore loads the user a reply address was issued to
+type UserStore interface {
+	GetUserByID(ctx context.Context, id uint) (*models.User, error)
+}
+
+// Processor routes received emails to the handler of their reply target
+type Processor struct {
+	addresses *ReplyAddresses
+	users     UserStore
+	handlers  map[string]ReplyHandler
+	logger    logger.Logger
+}
+
+// NewProcessor creates a processor for replies to addresses
+func NewProcessor(addresses *ReplyAddresses, users UserStore, logger logger.Logger) *Processor {
+	return &Processor{
+		addresses: addresses,
+		users:     users,
+		handlers:  make(map[string]ReplyHandler),
+		logger:    logger,
+	}
+}
+
+// Handle registers the handler for replies to targets of kind
+func (p *Processor) Handle(kind string, handler ReplyHandler) *Processor {
+	p.handlers[kind] = handler
+	return p
+}
+
+// Process verifies that a received email is a reply from the user its
+// address was issued to and hands the new text to the target's handler
+func (p *Processor) Process(ctx context.Context, message *Message) (*Reply, error) {
+	target, err := p.target(message)
+	if err != nil {
+		return nil, err
+	}
+	handler, ok := p.handlers[target.Kind]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKind, target.Kind)
+	}
+
+	user, err := p.users.GetUserByID(ctx, target.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSenderMismatch
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load reply author: %w", err)
+	}
+	// The address alone could have been forwarded; the sender must also be
+	// the account's address
+	sender, err := mail.ParseAddress(message.From)
+	if err != nil || !strings.EqualFold(sender.Address, user.Email) || !user.IsActive {
+		return nil, ErrSenderMismatch
+	}
+
+	body := StripQuoted(message.Body())
+	if body == "" {
+		return nil, ErrEmptyReply
+	}
+	if runes := []rune(body); len(runes) > maxReplyLength {
+		body = string(runes[:maxReplyLength])
+	}
+
+	reply := &Reply{
+		Target:    target,
+		User:      user,
+		Body:      body,
+		Subject:   message.Subject,
+		MessageID: message.MessageID,
+	}
+	if err := handler(ctx, reply); err != nil {
+		return nil, err
+	}
+	p.logger.Info("Processed %s reply to %s %d from user %d", message.Provider, target.Kind, target.ID, user.ID)
+	return reply, nil
+}
+
+// target finds the reply address among the recipients
+func (p *Processor) target(message *Message) (Target, error) {
+	for _, recipient := range message.To {
+		if target, err := p.addresses.Parse(recipient); err == nil {
+			return target, nil
+		}
+	}
+	return Target{}, ErrUnknownAddress
+}
@@ -0,0 +1,398 @@
+package inbound
+
+import (
+	"bufio"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/cryptopolicy"
+)
+
+// Provider names, used in the inbound webhook path
+const (
+	ProviderSES      = "ses"
+	ProviderSendGrid = "sendgrid"
+	ProviderMailgun  = "mailgun"
+)
+
+// maxFormMemory is how much of a multipart inbound request is held in
+// memory; the rest, mostly attachments, spills to temporary files
+const maxFormMemory = 8 << 20
+
+// signatureTolerance is how old a signed webhook may be, limiting replays
+const signatureTolerance = 5 * time.Minute
+
+// Provider verifies and parses the inbound webhook requests of one mail
+// provider. Parse returns a nil message for requests that carry no email,
+// such as subscription confirmations.
+type Provider interface {
+	Name() string
+	Parse(r *http.Request) (*Message, error)
+}
+
+// MailgunProvider parses Mailgun route forwards, signed with the account's
+// webhook signing key
+type MailgunProvider struct {
+	signingKey string
+	clock      clock.Clock
+}
+
+// NewMailgunProvider creates a Mailgun parser
+func NewMailgunProvider(signingKey string) *MailgunProvider {
+	return &MailgunProvider{signingKey: signingKey, clock: clock.New()}
+}
+
+// WithClock sets the time source for the signature timestamp check
+func (mp *MailgunProvider) WithClock(c clock.Clock) *MailgunProvider {
+	mp.clock = clock.OrDefault(c)
+	return mp
+}
+
+// Name returns "mailgun"
+func (mp *MailgunProvider) Name() string {
+	return ProviderMailgun
+}
+
+// Parse verifies the timestamp, token and signature fields and reads the
+// parsed message fields
+func (mp *MailgunProvider) Parse(r *http.Request) (*Message, error) {
+	if err := parseForm(r); err != nil {
+		return nil, err
+	}
+
+	timestamp, token := r.FormValue("timestamp"), r.FormValue("token")
+	mac := hmac.New(sha256.New, []byte(mp.signingKey))
+	mac.Write([]byte(timestamp + token))
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if mp.signingKey == "" || !hmac.Equal([]byte(expected), []byte(r.FormValue("signature"))) {
+		return nil, ErrInvalidSignature
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || mp.clock.Since(time.Unix(seconds, 0)).Abs() > signatureTolerance {
+		return nil, ErrInvalidSignature
+	}
+
+	from := r.FormValue("From")
+	if from == "" {
+		from = r.FormValue("sender")
+	}
+	return &Message{
+		Provider:  ProviderMailgun,
+		From:      from,
+		To:        splitAddresses(r.FormValue("recipient")),
+		Subject:   r.FormValue("subject"),
+		Text:      r.FormValue("body-plain"),
+		HTML:      r.FormValue("body-html"),
+		MessageID: r.FormValue("Message-Id"),
+		InReplyTo: r.FormValue("In-Reply-To"),
+	}, nil
+}
+
+// SendGridProvider parses SendGrid Inbound Parse posts. Inbound Parse does
+// not sign requests, so the parse URL is configured with basic auth
+// credentials whose password is the shared secret.
+type SendGridProvider struct {
+	secret string
+}
+
+// NewSendGridProvider creates a SendGrid parser
+func NewSendGridProvider(secret string) *SendGridProvider {
+	return &SendGridProvider{secret: secret}
+}
+
+// Name returns "sendgrid"
+func (sp *SendGridProvider) Name() string {
+	return ProviderSendGrid
+}
+
+// Parse checks the basic auth password and reads either the raw email or
+// the parsed fields, depending on how the parse webhook is set up
+func (sp *SendGridProvider) Parse(r *http.Request) (*Message, error) {
+	_, password, ok := r.BasicAuth()
+	if sp.secret == "" || !ok || subtle.ConstantTimeCompare([]byte(password), []byte(sp.secret)) != 1 {
+		return nil, ErrInvalidSignature
+	}
+	if err := parseForm(r); err != nil {
+		return nil, err
+	}
+
+	if raw := r.FormValue("email"); raw != "" {
+		message, err := parseMIME([]byte(raw))
+		if err != nil {
+			return nil, err
+		}
+		message.Provider = ProviderSendGrid
+		return message, nil
+	}
+
+	message := &Message{
+		Provider: ProviderSendGrid,
+		From:     r.FormValue("from"),
+		To:       splitAddresses(r.FormValue("to")),
+		Subject:  r.FormValue("subject"),
+		Text:     r.FormValue("text"),
+		HTML:     r.FormValue("html"),
+	}
+	// The original headers arrive as one block of text
+	block := strings.TrimRight(r.FormValue("headers"), "\r\n") + "\r\n\r\n"
+	if headers, _ := textproto.NewReader(bufio.NewReader(strings.NewReader(block))).ReadMIMEHeader(); headers != nil {
+		message.MessageID = headers.Get("Message-Id")
+		message.InReplyTo = headers.Get("In-Reply-To")
+	}
+	return message, nil
+}
+
+// snsCertHost matches the hosts SNS signing certificates are served from
+var snsCertHost = regexp.MustCompile(`^sns\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$`)
+
+// SESProvider parses emails received by SES and published to an SNS topic
+// with a receipt rule. Notifications are verified against the SNS signing
+// certificate and must come from the configured topic.
+type SESProvider struct {
+	topicARN string
+	client   *http.Client
+
+	mutex sync.Mutex
+	certs map[string]*x509.Certificate
+}
+
+// NewSESProvider creates an SES parser accepting notifications from topicARN
+func NewSESProvider(topicARN string) *SESProvider {
+	return &SESProvider{
+		topicARN: topicARN,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		certs:    make(map[string]*x509.Certificate),
+	}
+}
+
+// WithHTTPClient sets the client used to fetch certificates and confirm
+// the subscription
+func (sp *SESProvider) WithHTTPClient(client *http.Client) *SESProvider {
+	sp.client = client
+	return sp
+}
+
+// Name returns "ses"
+func (sp *SESProvider) Name() string {
+	return ProviderSES
+}
+
+// snsMessage is an SNS HTTP(S) delivery
+type snsMessage struct {
+	Type             string
+	MessageId        string
+	Token            string
+	TopicArn         string
+	Subject          string
+	Message          string
+	SubscribeURL     string
+	Timestamp        string
+	SignatureVersion string
+	Signature        string
+	SigningCertURL   string
+}
+
+// sesNotification is the SES receipt notification in an SNS message
+type sesNotification struct {
+	NotificationType string `json:"notificationType"`
+	Mail             struct {
+		// Destination lists the envelope recipients, which include Bcc
+		// and forwarded addresses missing from the To header
+		Destination []string `json:"destination"`
+	} `json:"mail"`
+	// Content is the raw email, base64 encoded or not depending on the
+	// receipt rule's encoding
+	Content string `json:"content"`
+}
+
+// Parse verifies the SNS signature, confirms the topic subscription when
+// asked to, and reads the received email
+func (sp *SESProvider) Parse(r *http.Request) (*Message, error) {
+	var notification snsMessage
+	if err := json.NewDecoder(r.Body).Decode(&notification); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	if sp.topicARN == "" || notification.TopicArn != sp.topicARN {
+		return nil, ErrInvalidSignature
+	}
+	if err := sp.verify(&notification); err != nil {
+		return nil, err
+	}
+
+	switch notification.Type {
+	case "SubscriptionConfirmation":
+		return nil, sp.confirm(r, notification.SubscribeURL)
+	case "Notification":
+	default:
+		return nil, nil
+	}
+
+	var received sesNotification
+	if err := json.Unmarshal([]byte(notification.Message), &received); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	if received.NotificationType != "Received" || received.Content == "" {
+		return nil, fmt.Errorf("%w: notification carries no email content", ErrMalformed)
+	}
+	raw := []byte(received.Content)
+	if decoded, err := base64.StdEncoding.DecodeString(received.Content); err == nil {
+		raw = decoded
+	}
+	message, err := parseMIME(raw)
+	if err != nil {
+		return nil, err
+	}
+	message.Provider = ProviderSES
+	message.To = append(received.Mail.Destination, message.To...)
+	return message, nil
+}
+
+// verify checks the SNS message signature
+func (sp *SESProvider) verify(notification *snsMessage) error {
+	cert, err := sp.certificate(notification.SigningCertURL)
+	if err != nil {
+		return err
+	}
+	publicKey, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return ErrInvalidSignature
+	}
+	signature, err := base64.StdEncoding.DecodeString(notification.Signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	message := []byte(snsStringToSign(notification))
+	switch notification.SignatureVersion {
+	case "1":
+		// SHA-1 signatures are refused in restricted crypto mode; set the
+		// topic's SignatureVersion to 2
+		if cryptopolicy.Allow(cryptopolicy.RSASHA1) != nil {
+			return ErrInvalidSignature
+		}
+		digest := sha1.Sum(message)
+		err = rsa.VerifyPKCS1v15(publicKey, crypto.SHA1, digest[:], signature)
+	case "2":
+		digest := sha256.Sum256(message)
+		err = rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], signature)
+	default:
+		return ErrInvalidSignature
+	}
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// certificate fetches and caches an SNS signing certificate, refusing URLs
+// outside the SNS endpoints so a forged message cannot name its own key
+func (sp *SESProvider) certificate(certURL string) (*x509.Certificate, error) {
+	u, err := url.Parse(certURL)
+	if err != nil || u.Scheme != "https" || !snsCertHost.MatchString(u.Host) || !strings.HasSuffix(u.Path, ".pem") {
+		return nil, ErrInvalidSignature
+	}
+
+	sp.mutex.Lock()
+	cert, ok := sp.certs[certURL]
+	sp.mutex.Unlock()
+	if ok {
+		return cert, nil
+	}
+
+	resp, err := sp.client.Get(certURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch SNS signing certificate: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch SNS signing certificate: status %d", resp.StatusCode)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrInvalidSignature
+	}
+	cert, err = x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	sp.mutex.Lock()
+	sp.certs[certURL] = cert
+	sp.mutex.Unlock()
+	return cert, nil
+}
+
+// confirm subscribes the endpoint to the topic; the signature has already
+// shown the request comes from SNS
+func (sp *SESProvider) confirm(r *http.Request, subscribeURL string) error {
+	u, err := url.Parse(subscribeURL)
+	if err != nil || u.Scheme != "https" || !snsCertHost.MatchString(u.Host) {
+		return ErrInvalidSignature
+	}
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, subscribeURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := sp.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to confirm SNS subscription: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to confirm SNS subscription: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// snsStringToSign builds the canonical string SNS signs, which lists
+// fixed keys in order and omits Subject when it is absent
+func snsStringToSign(n *snsMessage) string {
+	var fields [][2]string
+	if n.Type == "Notification" {
+		fields = append(fields, [2]string{"Message", n.Message}, [2]string{"MessageId", n.MessageId})
+		if n.Subject != "" {
+			fields = append(fields, [2]string{"Subject", n.Subject})
+		}
+		fields = append(fields, [2]string{"Timestamp", n.Timestamp}, [2]string{"TopicArn", n.TopicArn}, [2]string{"Type", n.Type})
+	} else {
+		fields = append(fields,
+			[2]string{"Message", n.Message}, [2]string{"MessageId", n.MessageId},
+			[2]string{"SubscribeURL", n.SubscribeURL}, [2]string{"Timestamp", n.Timestamp},
+			[2]string{"Token", n.Token}, [2]string{"TopicArn", n.TopicArn}, [2]string{"Type", n.Type})
+	}
+	var b strings.Builder
+	for _, field := range fields {
+		b.WriteString(field[0] + "\n" + field[1] + "\n")
+	}
+	return b.String()
+}
+
+// parseForm reads a multipart or URL-encoded form
+func parseForm(r *http.Request) error {
+	if err := r.ParseMultipartForm(maxFormMemory); err != nil && err != http.ErrNotMultipart {
+		return fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package inbound
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	// attributionLine starts the quoted original in most clients, e.g.
+	// "On Mon, 2 Jan 2026 at 10:00, Alice <alice@example.com> wrote:"
+	attributionLine = regexp.MustCompile(`(?i)^(on\s.+|.+\s)wrote:$`)
+	// separatorLine introduces the original message in Outlook and others
+	separatorLine = regexp.MustCompile(`(?i)^(-{2,}\s*original message\s*-{2,}|_{5,}|-{5,}|from:\s.+|sent from my .+)$`)
+
+	htmlBreak = regexp.MustCompile(`(?i)<br\s*/?>|</p>|</div>`)
+	htmlTag   = regexp.MustCompile(`<[^>]*>`)
+)
+
+// StripQuoted returns the new text of a reply, dropping the quoted original
+// message, quoted lines and the sender's signature
+func StripQuoted(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	lines := strings.Split(text, "\n")
+
+	var kept []string
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "--" && strings.HasPrefix(line, "--") {
+			// "-- " is the standard signature delimiter
+			break
+		}
+		if separatorLine.MatchString(trimmed) || attributionLine.MatchString(trimmed) {
+			break
+		}
+		// Attributions are often wrapped onto a second line
+		if strings.HasPrefix(strings.ToLower(trimmed), "on ") && i+1 < len(lines) &&
+			attributionLine.MatchString(trimmed+" "+strings.TrimSpace(lines[i+1])) {
+			break
+		}
+		if strings.HasPrefix(trimmed, ">") {
+			continue
+		}
+		kept = append(kept, strings.TrimRight(line, " \t"))
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+// htmlToText is the fallback for replies without a plain-text part
+func htmlToText(body string) string {
+	body = htmlBreak.ReplaceAllString(body, "\n")
+	body = htmlTag.ReplaceAllString(body, "")
+	return html.UnescapeString(body)
+}
@@ -0,0 +1,151 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/models"
+	"go-server/internal/logger"
+	"go-server/internal/outbox"
+)
+
+// DeliveryStore persists the delivery log
+type DeliveryStore interface {
+	CreateEmailDelivery(ctx context.Context, delivery *models.EmailDelivery) error
+	UpdateEmailDelivery(ctx context.Context, delivery *models.EmailDelivery) error
+	FindEmailDeliveryByReference(ctx context.Context, template, reference string) (*models.EmailDelivery, error)
+}
+
+// Request describes an email to send
+type Request struct {
+	To       string
+	UserID   *uint
+	Template string
+	Locale   string
+	Data     map[string]any
+	// Reference makes the send idempotent: a template is sent once per reference
+	Reference string
+	// ReplyTo routes replies, e.g. to an inbound.ReplyAddresses address
+	ReplyTo string
+}
+
+// Mailer renders, sends and records emails
+type Mailer struct {
+	renderer   *Renderer
+	sender     Sender
+	deliveries DeliveryStore
+	from       string
+	clock      clock.Clock
+	logger     logger.Logger
+}
+
+// NewMailer creates a new mailer sending from the given address
+func NewMailer(renderer *Renderer, sender Sender, deliveries DeliveryStore, from string, logger logger.Logger) *Mailer {
+	return &Mailer{
+		renderer:   renderer,
+		sender:     sender,
+		deliveries: deliveries,
+		from:       from,
+		clock:      clock.New(),
+		logger:     logger,
+	}
+}
+
+// WithClock overrides the clock used to stamp deliveries
+func (m *Mailer) WithClock(c clock.Clock) *Mailer {
+	m.clock = clock.OrDefault(c)
+	return m
+}
+
+// Send renders and sends an email, recording the outcome. A failed send is
+// recorded and returned as an error so callers such as the outbox relay retry;
+// the retry records a new delivery unless the first one succeeded.
+func (m *Mailer) Send(ctx context.Context, req Request) (*models.EmailDelivery, error) {
+	if req.Reference != "" {
+		existing, err := m.deliveries.FindEmailDeliveryByReference(ctx, req.Template, req.Reference)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check previous deliveries: %w", err)
+		}
+		if existing != nil && existing.Status != models.EmailStatusFailed {
+			return existing, nil
+		}
+	}
+
+	rendered, err := m.renderer.Render(req.Template, req.Locale, req.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	message := &Message{
+		From:      m.from,
+		To:        req.To,
+		Subject:   rendered.Subject,
+		Text:      rendered.Text,
+		HTML:      rendered.HTML,
+		MessageID: NewMessageID(m.from),
+		ReplyTo:   req.ReplyTo,
+	}
+	delivery := &models.EmailDelivery{
+		UserID:    req.UserID,
+		Recipient: strings.ToLower(req.To),
+		Template:  rendered.Template,
+		Locale:    rendered.Locale,
+		Subject:   rendered.Subject,
+		Reference: req.Reference,
+		Status:    models.EmailStatusQueued,
+		MessageID: message.MessageID,
+	}
+	if err := m.deliveries.CreateEmailDelivery(ctx, delivery); err != nil {
+		return nil, fmt.Errorf("failed to record email delivery: %w", err)
+	}
+
+	sendErr := m.sender.Send(ctx, message)
+	if sendErr != nil {
+		delivery.Status = models.EmailStatusFailed
+		delivery.Error = sendErr.Error()
+		m.logger.Error("Failed to send email", "template", req.Template, "delivery_id", delivery.ID, "error", sendErr.Error())
+	} else {
+		now := m.clock.Now()
+		delivery.Status = models.EmailStatusSent
+		delivery.SentAt = &now
+	}
+	if err := m.deliveries.UpdateEmailDelivery(ctx, delivery); err != nil {
+		m.logger.Error("Failed to update email delivery", "delivery_id", delivery.ID, "error", err.Error())
+	}
+	return delivery, sendErr
+}
+
+// OutboxPublisher returns a publisher that sends the welcome email for
+// user.registered events from the outbox relay
+func (m *Mailer) OutboxPublisher() outbox.Publisher {
+	return outbox.PublisherFunc(func(ctx context.Context, envelope outbox.Envelope) error {
+		if envelope.Type != models.EventUserRegistered {
+			return nil
+		}
+
+		var user struct {
+			ID       uint   `json:"id"`
+			Email    string `json:"email"`
+			Username string `json:"username"`
+			Locale   string `json:"locale"`
+		}
+		if err := json.Unmarshal(envelope.Payload, &user); err != nil || user.Email == "" {
+			m.logger.Warn("Skipping malformed user.registered event", "event_id", envelope.ID)
+			return nil
+		}
+
+		_, err := m.Send(ctx, Request{
+			To:        user.Email,
+			UserID:    &user.ID,
+			Template:  TemplateWelcome,
+			Locale:    user.Locale,
+			Data:      map[string]any{"Username": user.Username},
+			Reference: "outbox:" + strconv.FormatUint(uint64(envelope.ID), 10),
+		})
+		return err
+	})
+}
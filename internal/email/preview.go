@@ -0,0 +1,112 @@
+package email
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+)
+
+// previewData holds sample values for rendering each template in previews
+var previewData = map[string]map[string]any{
+	TemplateWelcome: {
+		"Username": "alice",
+	},
+	TemplateSecurityAlert: {
+		"Username":   "alice",
+		"Message":    "All of your sessions were signed out.",
+		"OccurredAt": "2024-01-01 12:00 UTC",
+		"IPAddress":  "203.0.113.7",
+	},
+	TemplateEmailChangeConfirm: {
+		"Username":  "alice",
+		"NewEmail":  "alice@new.example.com",
+		"Link":      "https://app.example.com/account/email/confirm?token=abc123",
+		"ExpiresAt": "2024-01-02 12:00 UTC",
+	},
+	TemplateEmailChanged: {
+		"Username":   "alice",
+		"NewEmail":   "alice@new.example.com",
+		"RevertLink": "https://app.example.com/account/email/revert?token=def456",
+		"ExpiresAt":  "2024-01-08 12:00 UTC",
+	},
+	TemplateNotification: {
+		"Username": "alice",
+		"Title":    "New comment",
+		"Body":     "bob commented on your post.",
+	},
+	TemplateNotificationDigest: {
+		"Username": "alice",
+		"Title":    "3 new notifications",
+		"Items": []map[string]string{
+			{"Title": "New comment", "Body": "bob commented on your post."},
+			{"Title": "New follower", "Body": "carol started following you."},
+			{"Title": "Weekly summary"},
+		},
+	},
+}
+
+var previewIndex = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Email previews</title></head>
+<body style="font-family:sans-serif;">
+<h1>Email previews</h1>
+<ul>
+{{range .Entries}}<li>{{.Name}}:{{range .Locales}} <a href="{{$.Base}}{{.}}">{{.}}</a>{{end}}</li>
+{{end}}</ul>
+</body></html>`))
+
+// PreviewHandler serves rendered templates for development at /debug/emails.
+// It must only be mounted in development because it needs no authentication.
+type PreviewHandler struct {
+	renderer *Renderer
+	basePath string
+}
+
+// NewPreviewHandler creates a new preview handler mounted at basePath
+func NewPreviewHandler(renderer *Renderer, basePath string) *PreviewHandler {
+	return &PreviewHandler{
+		renderer: renderer,
+		basePath: strings.TrimSuffix(basePath, "/"),
+	}
+}
+
+// ServeHTTP lists the templates at the base path and renders
+// {base}/{name}?locale=es&format=html|text
+func (ph *PreviewHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	name := strings.Trim(strings.TrimPrefix(r.URL.Path, ph.basePath), "/")
+
+	if name == "" {
+		type entry struct {
+			Name    string
+			Locales []string
+		}
+		var entries []entry
+		for _, template := range ph.renderer.Templates() {
+			var links []string
+			for _, locale := range ph.renderer.Locales(template) {
+				links = append(links, template+"?locale="+locale)
+			}
+			entries = append(entries, entry{Name: template, Locales: links})
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		previewIndex.Execute(w, struct {
+			Base    string
+			Entries []entry
+		}{Base: ph.basePath + "/", Entries: entries})
+		return
+	}
+
+	rendered, err := ph.renderer.Render(name, r.URL.Query().Get("locale"), previewData[name])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("X-Email-Locale", rendered.Locale)
+	if r.URL.Query().Get("format") == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte("Subject: " + rendered.Subject + "\n\n" + rendered.Text))
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(rendered.HTML))
+}
@@ -0,0 +1,180 @@
+// Package email renders localized transactional emails from embedded
+// templates and sends them through SMTP, recording every delivery so support
+// staff can see what was sent to an address and whether it bounced.
+package email
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Template names
+const (
+	TemplateWelcome            = "welcome"
+	TemplateSecurityAlert      = "security_alert"
+	TemplateNotification       = "notification"
+	TemplateNotificationDigest = "notification_digest"
+	TemplateEmailChangeConfirm = "email_change_confirm"
+	TemplateEmailChanged       = "email_changed"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// Rendered is the subject and bodies of a rendered email
+type Rendered struct {
+	Template string
+	Locale   string
+	Subject  string
+	Text     string
+	HTML     string
+}
+
+// localizedTemplate holds both parses of one template file
+type localizedTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// Renderer renders templates stored as templates/<locale>/<name>.tmpl. Each
+// file defines "subject", "text" and "body" blocks; the HTML part wraps
+// "body" in templates/layout.html. Missing locales fall back from a regional
+// variant ("pt-BR") to its language ("pt") and then to the default locale.
+type Renderer struct {
+	appName       string
+	defaultLocale string
+	templates     map[string]map[string]*localizedTemplate
+}
+
+// NewRenderer parses the embedded templates
+func NewRenderer(appName, defaultLocale string) (*Renderer, error) {
+	return newRenderer(templateFS, appName, defaultLocale)
+}
+
+func newRenderer(fsys fs.FS, appName, defaultLocale string) (*Renderer, error) {
+	layout, err := fs.ReadFile(fsys, "templates/layout.html")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read email layout: %w", err)
+	}
+
+	files, err := fs.Glob(fsys, "templates/*/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Renderer{
+		appName:       appName,
+		defaultLocale: strings.ToLower(defaultLocale),
+		templates:     make(map[string]map[string]*localizedTemplate),
+	}
+	for _, file := range files {
+		source, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		locale := strings.ToLower(path.Base(path.Dir(file)))
+		name := strings.TrimSuffix(path.Base(file), ".tmpl")
+
+		text, err := texttemplate.New(name).Option("missingkey=zero").Parse(string(source))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		html, err := htmltemplate.New(name).Option("missingkey=zero").Parse(string(layout))
+		if err == nil {
+			_, err = html.Parse(string(source))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+
+		if r.templates[name] == nil {
+			r.templates[name] = make(map[string]*localizedTemplate)
+		}
+		r.templates[name][locale] = &localizedTemplate{text: text, html: html}
+	}
+
+	for name, locales := range r.templates {
+		if _, ok := locales[r.defaultLocale]; !ok {
+			return nil, fmt.Errorf("email template %s has no %s version", name, r.defaultLocale)
+		}
+	}
+	return r, nil
+}
+
+// Templates returns the template names in alphabetical order
+func (r *Renderer) Templates() []string {
+	names := make([]string, 0, len(r.templates))
+	for name := range r.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Locales returns the locales a template is translated into
+func (r *Renderer) Locales(name string) []string {
+	locales := make([]string, 0, len(r.templates[name]))
+	for locale := range r.templates[name] {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Render renders a template in the closest available locale. The data map is
+// extended with AppName and Locale.
+func (r *Renderer) Render(name, locale string, data map[string]any) (*Rendered, error) {
+	locales, ok := r.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown email template %q", name)
+	}
+	locale = r.resolveLocale(locales, locale)
+	tmpl := locales[locale]
+
+	values := make(map[string]any, len(data)+2)
+	for key, value := range data {
+		values[key] = value
+	}
+	values["AppName"] = r.appName
+	values["Locale"] = locale
+
+	rendered := &Rendered{Template: name, Locale: locale}
+	var buf bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&buf, "subject", values); err != nil {
+		return nil, fmt.Errorf("failed to render %s subject: %w", name, err)
+	}
+	rendered.Subject = strings.Join(strings.Fields(buf.String()), " ")
+
+	buf.Reset()
+	if err := tmpl.text.ExecuteTemplate(&buf, "text", values); err != nil {
+		return nil, fmt.Errorf("failed to render %s text: %w", name, err)
+	}
+	rendered.Text = strings.TrimSpace(buf.String()) + "\n"
+
+	buf.Reset()
+	if err := tmpl.html.ExecuteTemplate(&buf, "html", values); err != nil {
+		return nil, fmt.Errorf("failed to render %s html: %w", name, err)
+	}
+	rendered.HTML = buf.String()
+	return rendered, nil
+}
+
+func (r *Renderer) resolveLocale(locales map[string]*localizedTemplate, locale string) string {
+	locale = strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+	if _, ok := locales[locale]; ok {
+		return locale
+	}
+	if language, _, found := strings.Cut(locale, "-"); found {
+		if _, ok := locales[language]; ok {
+			return language
+		}
+	}
+	return r.defaultLocale
+}
@@ -0,0 +1,183 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"go-server/internal/idgen"
+	"go-server/internal/logger"
+)
+
+// Message is an outgoing email
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+	// MessageID is the RFC 5322 Message-ID; bounce notifications refer to it
+	MessageID string
+	// ReplyTo is where replies go, such as a reply-by-email address
+	ReplyTo string
+}
+
+// Sender delivers messages to a mail transport
+type Sender interface {
+	Send(ctx context.Context, message *Message) error
+}
+
+// LogSender logs messages instead of sending them, for development
+type LogSender struct {
+	logger logger.Logger
+}
+
+// NewLogSender creates a new logging sender
+func NewLogSender(logger logger.Logger) *LogSender {
+	return &LogSender{logger: logger}
+}
+
+// Send logs the message envelope
+func (ls *LogSender) Send(ctx context.Context, message *Message) error {
+	ls.logger.Info("Email not sent (log transport)", "to", message.To, "subject", message.Subject, "message_id", message.MessageID)
+	return nil
+}
+
+// SMTPSender sends messages through an SMTP relay
+type SMTPSender struct {
+	addr     string
+	host     string
+	username string
+	password string
+}
+
+// NewSMTPSender creates a new SMTP sender; credentials are optional
+func NewSMTPSender(host string, port int, username, password string) *SMTPSender {
+	return &SMTPSender{
+		addr:     net.JoinHostPort(host, fmt.Sprint(port)),
+		host:     host,
+		username: username,
+		password: password,
+	}
+}
+
+// Send delivers the message as multipart/alternative
+func (ss *SMTPSender) Send(ctx context.Context, message *Message) error {
+	data, err := BuildMIME(message, time.Now())
+	if err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if ss.username != "" {
+		auth = smtp.PlainAuth("", ss.username, ss.password, ss.host)
+	}
+
+	from := message.From
+	if address, err := parseAddress(from); err == nil {
+		from = address
+	}
+	if err := smtp.SendMail(ss.addr, auth, from, []string{message.To}, data); err != nil {
+		return fmt.Errorf("smtp send failed: %w", err)
+	}
+	return nil
+}
+
+// NewMessageID generates a Message-ID in the sender's domain
+func NewMessageID(from string) string {
+	domain := "localhost"
+	if address, err := parseAddress(from); err == nil {
+		if _, d, ok := strings.Cut(address, "@"); ok {
+			domain = d
+		}
+	}
+	token, err := idgen.Default.Token(16)
+	if err != nil {
+		token = fmt.Sprint(time.Now().UnixNano())
+	}
+	return "<" + token + "@" + domain + ">"
+}
+
+// BuildMIME encodes the message with text and HTML alternatives
+func BuildMIME(message *Message, date time.Time) ([]byte, error) {
+	for _, value := range []string{message.From, message.To, message.MessageID, message.ReplyTo} {
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, fmt.Errorf("email header contains a line break")
+		}
+	}
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	headers := []string{
+		"From: " + message.From,
+		"To: " + message.To,
+		"Subject: " + mime.QEncoding.Encode("utf-8", message.Subject),
+		"Date: " + date.Format(time.RFC1123Z),
+		"Message-ID: " + message.MessageID,
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + writer.Boundary(),
+	}
+	if message.ReplyTo != "" {
+		headers = append(headers, "Reply-To: "+message.ReplyTo)
+	}
+	buf.WriteString(strings.Join(headers, "\r\n") + "\r\n\r\n")
+
+	for _, part := range []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain; charset=utf-8", message.Text},
+		{"text/html; charset=utf-8", message.HTML},
+	} {
+		if part.body == "" {
+			continue
+		}
+		w, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.body)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func parseAddress(value string) (string, error) {
+	address, err := mail.ParseAddress(value)
+	if err != nil {
+		return "", err
+	}
+	return address.Address, nil
+}
+
+// NewSender creates the configured transport
+func NewSender(transport, host string, port int, username, password string, logger logger.Logger) (Sender, error) {
+	switch transport {
+	case "", "log":
+		return NewLogSender(logger), nil
+	case "smtp":
+		return NewSMTPSender(host, port, username, password), nil
+	default:
+		return nil, fmt.Errorf("unsupported email transport: %s", transport)
+	}
+}
@@ -0,0 +1,292 @@
+// Package emailchange changes a user's email address only once the new
+// address is confirmed. Requesting a change records it as pending on the
+// user and mails a confirmation link to the new address; confirming it
+// swaps the addresses and tells the old address, with a link that reverts
+// the change and signs out every session in case the account was taken
+// over.
+//
+// Links carry random tokens of which only the SHA-256 hash is stored.
+package emailchange
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/email"
+	"go-server/internal/idgen"
+	"go-server/internal/logger"
+
+	"gorm.io/gorm"
+)
+
+// Email change errors
+var (
+	ErrEmailTaken   = errors.New("email address is already in use")
+	ErrSameEmail    = errors.New("email address is unchanged")
+	ErrInvalidToken = errors.New("email change link is invalid or expired")
+)
+
+// Paths of the links, relative to Config.LinkBaseURL
+const (
+	ConfirmPath = "/account/email/confirm"
+	RevertPath  = "/account/email/revert"
+)
+
+// Default link lifetimes
+const (
+	DefaultConfirmTTL = 24 * time.Hour
+	DefaultRevertTTL  = 7 * 24 * time.Hour
+)
+
+// Config holds email change configuration
+type Config struct {
+	// LinkBaseURL is the origin of the pages that open the links, e.g.
+	// https://app.example.com
+	LinkBaseURL string
+	// ConfirmTTL is how long the new address has to confirm the change;
+	// zero means DefaultConfirmTTL
+	ConfirmTTL time.Duration
+	// RevertTTL is how long the old address can undo a confirmed change;
+	// zero means DefaultRevertTTL
+	RevertTTL time.Duration
+}
+
+// Mailer sends the confirmation and notice emails
+type Mailer interface {
+	Send(ctx context.Context, req email.Request) (*models.EmailDelivery, error)
+}
+
+// SessionRevoker signs a user out everywhere when a change is reverted
+type SessionRevoker interface {
+	DeleteAllUserSessions(ctx context.Context, userID uint) error
+}
+
+// Service runs the email change flow
+type Service struct {
+	users    *repositories.UserRepository
+	mailer   Mailer
+	config   Config
+	sessions SessionRevoker
+	tokens   idgen.TokenSource
+	clock    clock.Clock
+	logger   logger.Logger
+}
+
+// NewService creates a new email change service
+func NewService(users *repositories.UserRepository, mailer Mailer, config Config, logger logger.Logger) *Service {
+	if config.ConfirmTTL <= 0 {
+		config.ConfirmTTL = DefaultConfirmTTL
+	}
+	if config.RevertTTL <= 0 {
+		config.RevertTTL = DefaultRevertTTL
+	}
+	return &Service{
+		users:  users,
+		mailer: mailer,
+		config: config,
+		tokens: idgen.Default,
+		clock:  clock.New(),
+		logger: logger,
+	}
+}
+
+// WithClock overrides the clock used for link expiry
+func (s *Service) WithClock(c clock.Clock) *Service {
+	s.clock = clock.OrDefault(c)
+	return s
+}
+
+// WithTokenSource overrides the source of link tokens
+func (s *Service) WithTokenSource(tokens idgen.TokenSource) *Service {
+	s.tokens = tokens
+	return s
+}
+
+// WithSessionRevoker signs users out of every session when they revert a
+// change
+func (s *Service) WithSessionRevoker(sessions SessionRevoker) *Service {
+	s.sessions = sessions
+	return s
+}
+
+// Request records newEmail as the user's pending address and mails the
+// confirmation link to it. A new request replaces a pending one.
+func (s *Service) Request(ctx context.Context, user *models.User, newEmail string) error {
+	newEmail = strings.TrimSpace(newEmail)
+	if strings.EqualFold(newEmail, user.Email) {
+		return ErrSameEmail
+	}
+	if err := s.checkAvailable(ctx, user.ID, newEmail); err != nil {
+		return err
+	}
+
+	token, err := s.tokens.Token(32)
+	if err != nil {
+		return fmt.Errorf("failed to generate email change token: %w", err)
+	}
+	expiresAt := s.clock.Now().Add(s.config.ConfirmTTL)
+	user.PendingEmail = newEmail
+	user.PendingEmailExpiresAt = &expiresAt
+	user.EmailConfirmHash = hashToken(token)
+	if err := s.users.UpdateUser(ctx, user); err != nil {
+		return fmt.Errorf("failed to record pending email change: %w", err)
+	}
+
+	_, err = s.mailer.Send(ctx, email.Request{
+		To:       newEmail,
+		UserID:   &user.ID,
+		Template: email.TemplateEmailChangeConfirm,
+		Data: map[string]any{
+			"Username":  user.Username,
+			"NewEmail":  newEmail,
+			"Link":      s.link(ConfirmPath, token),
+			"ExpiresAt": formatTime(expiresAt),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send email change confirmation: %w", err)
+	}
+	s.logger.Info("Email change requested", "user_id", user.ID)
+	return nil
+}
+
+// Cancel drops the user's pending email change
+func (s *Service) Cancel(ctx context.Context, user *models.User) error {
+	if user.PendingEmail == "" {
+		return nil
+	}
+	clearPending(user)
+	return s.users.UpdateUser(ctx, user)
+}
+
+// Confirm applies the pending change the token confirms and mails the old
+// address a link to revert it
+func (s *Service) Confirm(ctx context.Context, token string) (*models.User, error) {
+	user, err := s.users.GetUserByEmailConfirmHash(ctx, hashToken(token))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := s.clock.Now()
+	if user.PendingEmail == "" || user.PendingEmailExpiresAt == nil || !now.Before(*user.PendingEmailExpiresAt) {
+		return nil, ErrInvalidToken
+	}
+	// The address may have been taken since the change was requested
+	if err := s.checkAvailable(ctx, user.ID, user.PendingEmail); err != nil {
+		return nil, err
+	}
+
+	revertToken, err := s.tokens.Token(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate email revert token: %w", err)
+	}
+	revertExpiresAt := now.Add(s.config.RevertTTL)
+	oldEmail := user.Email
+	user.Email = user.PendingEmail
+	user.PreviousEmail = oldEmail
+	user.EmailRevertHash = hashToken(revertToken)
+	user.EmailRevertExpiresAt = &revertExpiresAt
+	clearPending(user)
+	if err := s.users.UpdateUser(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to apply email change: %w", err)
+	}
+	s.logger.Info("Email change confirmed", "user_id", user.ID)
+
+	_, err = s.mailer.Send(ctx, email.Request{
+		To:       oldEmail,
+		UserID:   &user.ID,
+		Template: email.TemplateEmailChanged,
+		Data: map[string]any{
+			"Username":   user.Username,
+			"NewEmail":   user.Email,
+			"RevertLink": s.link(RevertPath, revertToken),
+			"ExpiresAt":  formatTime(revertExpiresAt),
+		},
+	})
+	if err != nil {
+		// The change stands; the failed delivery is in the email log
+		s.logger.Error("Failed to notify the previous email address", "user_id", user.ID, "error", err.Error())
+	}
+	return user, nil
+}
+
+// Revert restores the address a confirmed change replaced and signs the
+// user out of every session
+func (s *Service) Revert(ctx context.Context, token string) (*models.User, error) {
+	user, err := s.users.GetUserByEmailRevertHash(ctx, hashToken(token))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.PreviousEmail == "" || user.EmailRevertExpiresAt == nil || !s.clock.Now().Before(*user.EmailRevertExpiresAt) {
+		return nil, ErrInvalidToken
+	}
+	if err := s.checkAvailable(ctx, user.ID, user.PreviousEmail); err != nil {
+		return nil, err
+	}
+
+	user.Email = user.PreviousEmail
+	user.PreviousEmail = ""
+	user.EmailRevertHash = ""
+	user.EmailRevertExpiresAt = nil
+	clearPending(user)
+	if err := s.users.UpdateUser(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to revert email change: %w", err)
+	}
+	if s.sessions != nil {
+		if err := s.sessions.DeleteAllUserSessions(ctx, user.ID); err != nil {
+			s.logger.Error("Failed to sign out sessions after an email revert", "user_id", user.ID, "error", err.Error())
+		}
+	}
+	s.logger.Info("Email change reverted", "user_id", user.ID)
+	return user, nil
+}
+
+// checkAvailable returns ErrEmailTaken if another account uses address
+func (s *Service) checkAvailable(ctx context.Context, userID uint, address string) error {
+	existing, err := s.users.GetUserByEmail(ctx, address)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check email address: %w", err)
+	}
+	if existing.ID != userID {
+		return ErrEmailTaken
+	}
+	return nil
+}
+
+// link builds a link opening path with token
+func (s *Service) link(path, token string) string {
+	return strings.TrimSuffix(s.config.LinkBaseURL, "/") + path + "?token=" + url.QueryEscape(token)
+}
+
+func clearPending(user *models.User) {
+	user.PendingEmail = ""
+	user.PendingEmailExpiresAt = nil
+	user.EmailConfirmHash = ""
+}
+
+// hashToken returns the stored form of a link token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04 MST")
+}
@@ -0,0 +1,159 @@
+package emailchange
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/dbtest"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/email"
+	"go-server/internal/idgen"
+	"go-server/internal/logger"
+)
+
+type recordingMailer struct {
+	sent []email.Request
+}
+
+func (rm *recordingMailer) Send(ctx context.Context, req email.Request) (*models.EmailDelivery, error) {
+	rm.sent = append(rm.sent, req)
+	return &models.EmailDelivery{}, nil
+}
+
+// token returns the token of the link in the last email sent
+func (rm *recordingMailer) token(t *testing.T, key string) string {
+	t.Helper()
+	link, _ := rm.sent[len(rm.sent)-1].Data[key].(string)
+	_, token, ok := strings.Cut(link, "?token=")
+	if !ok {
+		t.Fatalf("No token in link %q", link)
+	}
+	return token
+}
+
+type recordingRevoker struct {
+	revoked []uint
+}
+
+func (rr *recordingRevoker) DeleteAllUserSessions(ctx context.Context, userID uint) error {
+	rr.revoked = append(rr.revoked, userID)
+	return nil
+}
+
+func newTestService(t *testing.T) (*Service, *repositories.UserRepository, *recordingMailer, *recordingRevoker, *clock.Fake) {
+	t.Helper()
+	db := dbtest.Open(t, &models.User{}, &models.OutboxEvent{})
+	users := repositories.NewUserRepository(db)
+	mailer := &recordingMailer{}
+	revoker := &recordingRevoker{}
+	fake := clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	service := NewService(users, mailer, Config{
+		LinkBaseURL: "https://app.example.com/",
+		ConfirmTTL:  time.Hour,
+		RevertTTL:   24 * time.Hour,
+	}, logger.NewServerLogger()).
+		WithClock(fake).
+		WithTokenSource(idgen.NewSequence("token")).
+		WithSessionRevoker(revoker)
+	return service, users, mailer, revoker, fake
+}
+
+func createUser(t *testing.T, users *repositories.UserRepository, username, address string) *models.User {
+	t.Helper()
+	user := &models.User{Username: username, Email: address, Password: "x", IsActive: true}
+	if err := users.CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	return user
+}
+
+func TestService_ConfirmAndRevert(t *testing.T) {
+	service, users, mailer, revoker, _ := newTestService(t)
+	ctx := context.Background()
+	user := createUser(t, users, "alice", "alice@old.example.com")
+
+	if err := service.Request(ctx, user, "alice@new.example.com"); err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	stored, _ := users.GetUserByID(ctx, user.ID)
+	if stored.Email != "alice@old.example.com" || stored.PendingEmail != "alice@new.example.com" {
+		t.Fatalf("A requested change should only be pending, got %q pending %q", stored.Email, stored.PendingEmail)
+	}
+	confirmation := mailer.sent[0]
+	if confirmation.To != "alice@new.example.com" || confirmation.Template != email.TemplateEmailChangeConfirm ||
+		confirmation.Data["Link"] != "https://app.example.com/account/email/confirm?token=token-1" {
+		t.Errorf("Unexpected confirmation %+v", confirmation)
+	}
+	if stored.EmailConfirmHash == "token-1" {
+		t.Error("Only the token's hash should be stored")
+	}
+
+	confirmed, err := service.Confirm(ctx, mailer.token(t, "Link"))
+	if err != nil {
+		t.Fatalf("Confirm failed: %v", err)
+	}
+	if confirmed.Email != "alice@new.example.com" || confirmed.PendingEmail != "" {
+		t.Errorf("Confirming should apply the change, got %q pending %q", confirmed.Email, confirmed.PendingEmail)
+	}
+	notice := mailer.sent[1]
+	if notice.To != "alice@old.example.com" || notice.Template != email.TemplateEmailChanged {
+		t.Errorf("The old address should be told about the change, got %+v", notice)
+	}
+	if _, err := service.Confirm(ctx, "token-1"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("A confirmation link should only work once, got %v", err)
+	}
+
+	reverted, err := service.Revert(ctx, mailer.token(t, "RevertLink"))
+	if err != nil {
+		t.Fatalf("Revert failed: %v", err)
+	}
+	if reverted.Email != "alice@old.example.com" || len(revoker.revoked) != 1 {
+		t.Errorf("Reverting should restore the address and sign out, got %q, %v", reverted.Email, revoker.revoked)
+	}
+	if _, err := users.GetUserByEmail(ctx, "alice@old.example.com"); err != nil {
+		t.Errorf("The restored address should find the user: %v", err)
+	}
+}
+
+func TestService_Refusals(t *testing.T) {
+	service, users, mailer, _, fake := newTestService(t)
+	ctx := context.Background()
+	user := createUser(t, users, "alice", "alice@example.com")
+	createUser(t, users, "bob", "bob@example.com")
+
+	if err := service.Request(ctx, user, "bob@example.com"); !errors.Is(err, ErrEmailTaken) {
+		t.Errorf("Expected ErrEmailTaken, got %v", err)
+	}
+	if err := service.Request(ctx, user, "ALICE@example.com"); !errors.Is(err, ErrSameEmail) {
+		t.Errorf("Expected ErrSameEmail, got %v", err)
+	}
+
+	if err := service.Request(ctx, user, "alice@new.example.com"); err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	fake.Advance(2 * time.Hour)
+	if _, err := service.Confirm(ctx, mailer.token(t, "Link")); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("An expired link should be refused, got %v", err)
+	}
+
+	// An address taken after the request cannot be confirmed
+	if err := service.Request(ctx, user, "carol@example.com"); err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	createUser(t, users, "carol", "carol@example.com")
+	if _, err := service.Confirm(ctx, mailer.token(t, "Link")); !errors.Is(err, ErrEmailTaken) {
+		t.Errorf("Expected ErrEmailTaken on confirm, got %v", err)
+	}
+
+	if err := service.Cancel(ctx, user); err != nil {
+		t.Fatalf("Cancel failed: %v", err)
+	}
+	if _, err := service.Confirm(ctx, mailer.token(t, "Link")); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("A cancelled change cannot be confirmed, got %v", err)
+	}
+}
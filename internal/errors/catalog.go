@@ -0,0 +1,268 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Definition is a registered error code. Codes are part of the API
+// contract: clients branch on them, so once published a code keeps its
+// meaning and status.
+type Definition struct {
+	Code        string    `json:"code"`
+	Status      int       `json:"status"`
+	Type        ErrorType `json:"type"`
+	Description string    `json:"description"`
+}
+
+// catalog holds every error code the server may return. The codecheck
+// package rejects codes written by handlers that are missing here.
+var catalog = map[string]Definition{}
+
+// define registers a code whose type follows from its status
+func define(code string, status int, description string) {
+	defineTyped(code, status, TypeForStatus(status), description)
+}
+
+func defineTyped(code string, status int, errorType ErrorType, description string) {
+	if _, exists := catalog[code]; exists {
+		panic("errors: duplicate error code " + code)
+	}
+	catalog[code] = Definition{Code: code, Status: status, Type: errorType, Description: description}
+}
+
+func init() {
+	// Requests
+	define("INVALID_REQUEST", http.StatusBadRequest, "The request body or parameters could not be parsed")
+	defineTyped("VALIDATION_ERROR", http.StatusBadRequest, ErrorTypeValidation, "A field failed validation; details names the field and errors lists every failure")
+	defineTyped("MISSING_FIELD", http.StatusBadRequest, ErrorTypeValidation, "A required field is missing")
+	defineTyped("INVALID_FORMAT", http.StatusBadRequest, ErrorTypeValidation, "A field has an invalid format")
+	defineTyped("SCHEMA_VALIDATION_FAILED", http.StatusBadRequest, ErrorTypeValidation, "The request does not match the OpenAPI schema")
+	define("SCHEMA_RESPONSE_MISMATCH", http.StatusInternalServerError, "The server produced a response that does not match the OpenAPI schema")
+	define("METHOD_NOT_ALLOWED", http.StatusMethodNotAllowed, "The endpoint does not support the HTTP method")
+	define("NOT_FOUND", http.StatusNotFound, "The requested resource does not exist")
+	define("HANDLER_NOT_FOUND", http.StatusNotFound, "No handler is registered for the requested action")
+	define("VERSION_CONFLICT", http.StatusConflict, "The record changed after the version the request was based on; reload it and apply the change again")
+
+	// Server
+	define("INTERNAL_ERROR", http.StatusInternalServerError, "An unexpected server error occurred")
+	define("DATABASE_ERROR", http.StatusInternalServerError, "A database operation failed")
+	define("CACHE_ERROR", http.StatusInternalServerError, "A cache operation failed")
+	define("QUOTA_ERROR", http.StatusInternalServerError, "Usage quotas could not be read")
+	define("WARMING_UP", http.StatusServiceUnavailable, "The server is still warming its caches and not yet ready for traffic")
+	define("NOT_READY", http.StatusServiceUnavailable, "A readiness check failed, e.g. the database schema lacks columns this build needs")
+	define("REQUEST_TIMEOUT", http.StatusGatewayTimeout, "The request ran longer than its route's timeout and was cancelled")
+	define("ROUTE_DISABLED", http.StatusServiceUnavailable, "The endpoint was disabled after failing repeatedly; an administrator can re-enable it")
+	define("ROUTE_NOT_DISABLED", http.StatusNotFound, "The route is not disabled")
+
+	// Authentication
+	define("NOT_AUTHENTICATED", http.StatusUnauthorized, "The endpoint requires a signed-in user")
+	define("NO_TOKEN", http.StatusUnauthorized, "No bearer token was sent")
+	define("NO_AUTH_HEADER", http.StatusBadRequest, "The Authorization header is missing")
+	define("INVALID_TOKEN", http.StatusUnauthorized, "The bearer token is invalid or expired")
+	define("SESSION_EXPIRED", http.StatusUnauthorized, "The login session ended through inactivity or its maximum lifetime; log in again")
+	define("INVALID_CREDENTIALS", http.StatusUnauthorized, "The credentials are invalid, expired or revoked")
+	define("LOGIN_FAILED", http.StatusUnauthorized, "The email or password is wrong")
+	define("REFRESH_FAILED", http.StatusUnauthorized, "The token could not be refreshed")
+	define("REGISTRATION_FAILED", http.StatusConflict, "The account could not be registered, usually because it already exists")
+	define("TOKEN_ERROR", http.StatusInternalServerError, "A token could not be issued")
+	define("INVALID_SIGNATURE", http.StatusUnauthorized, "A provider callback signature is invalid")
+	define("INVALID_WEBHOOK_SECRET", http.StatusUnauthorized, "A provider callback secret is invalid")
+	define("BREAK_GLASS_DENIED", http.StatusUnauthorized, "The break-glass token is invalid, expired or revoked")
+
+	// Authorization
+	define("ADMIN_REQUIRED", http.StatusForbidden, "The endpoint requires an administrator")
+	define("PERMISSION_REQUIRED", http.StatusForbidden, "The user's staff role lacks the required permission")
+	define("SCOPE_REQUIRED", http.StatusForbidden, "The service account lacks the required scope")
+	define("CSRF_TOKEN_INVALID", http.StatusForbidden, "A request authenticated by the session cookie did not echo the CSRF cookie in the X-CSRF-Token header")
+	define("SCOPE_NOT_GRANTED", http.StatusForbidden, "A requested scope is not granted to the service account")
+	define("ORGANIZATION_ADMIN_REQUIRED", http.StatusForbidden, "The endpoint requires an organization owner or admin")
+	define("BREAK_GLASS_FORBIDDEN", http.StatusForbidden, "Break-glass sessions cannot perform this action")
+
+	// Users and staff
+	define("INVALID_USER_ID", http.StatusBadRequest, "The user ID in the path is invalid")
+	define("USER_NOT_FOUND", http.StatusNotFound, "The user does not exist")
+	define("EMAIL_TAKEN", http.StatusConflict, "Another account uses the email address")
+	define("EMAIL_CHANGE_INVALID", http.StatusBadRequest, "The email change link is invalid, expired or already used")
+	define("EMAIL_CHANGE_FAILED", http.StatusInternalServerError, "The email change could not be saved or its email could not be sent")
+	define("EMAIL_CHANGE_UNAVAILABLE", http.StatusServiceUnavailable, "Email changes need the email change flow, which is not configured")
+	define("USERNAME_TAKEN", http.StatusConflict, "Another account uses the username")
+	define("USERNAME_NOT_ALLOWED", http.StatusUnprocessableEntity, "The username is reserved, profane or imitates a reserved name; reason names which")
+	define("INVALID_PHONE_NUMBER", http.StatusBadRequest, "The phone number is not in E.164 format")
+	define("INVALID_ROLE", http.StatusBadRequest, "The staff role does not exist")
+	define("OWN_ROLE", http.StatusBadRequest, "Staff cannot change their own role")
+	define("MERGE_SAME_ACCOUNT", http.StatusBadRequest, "The accounts to merge are the same account")
+	define("MERGE_SOURCE_INVALID", http.StatusUnprocessableEntity, "The source token does not authenticate an active account")
+	define("MERGE_ACCOUNT_HELD", http.StatusConflict, "One of the accounts is under legal hold and cannot be merged")
+	define("ALREADY_DEACTIVATED", http.StatusConflict, "The account is already deactivated")
+	define("NOT_DEACTIVATED", http.StatusConflict, "The account is active, so there is nothing to reactivate")
+	define("REACTIVATION_FORBIDDEN", http.StatusForbidden, "Staff deactivated the account, so only staff can reactivate it")
+	define("REACTIVATION_COOLDOWN", http.StatusTooManyRequests, "The account was deactivated too recently to reactivate; reactivate_after says when it can be")
+
+	// Posts and trash
+	define("INVALID_POST_ID", http.StatusBadRequest, "The post ID in the path is invalid")
+	define("POST_NOT_FOUND", http.StatusNotFound, "The post does not exist or is not published")
+	define("NOT_IN_TRASH", http.StatusNotFound, "The record is not in the trash")
+	define("INVALID_DURATION", http.StatusBadRequest, "The duration parameter is invalid")
+	define("RETENTION_VIOLATION", http.StatusBadRequest, "The purge cutoff is shorter than the retention period")
+
+	// Organizations and service accounts
+	define("INVALID_ORGANIZATION_ID", http.StatusBadRequest, "The organization ID in the path is invalid")
+	define("SLUG_TAKEN", http.StatusConflict, "Another organization uses the slug")
+	define("INVALID_SERVICE_ACCOUNT_ID", http.StatusBadRequest, "The service account ID in the path is invalid")
+	define("SERVICE_ACCOUNT_NOT_FOUND", http.StatusNotFound, "The service account does not exist in the organization")
+	define("INVALID_CREDENTIAL_ID", http.StatusBadRequest, "The credential ID in the path is invalid")
+	define("CREDENTIAL_NOT_FOUND", http.StatusNotFound, "The credential does not exist or is already revoked")
+	define("INVALID_CERTIFICATE", http.StatusBadRequest, "The certificate is not a valid PEM-encoded X.509 certificate")
+
+	// Approvals and break-glass
+	define("INVALID_APPROVAL_ID", http.StatusBadRequest, "The approval ID in the path is invalid")
+	define("APPROVAL_NOT_FOUND", http.StatusNotFound, "The approval request does not exist")
+	define("APPROVAL_FORBIDDEN", http.StatusForbidden, "The user may not decide the approval request")
+	define("APPROVAL_CLOSED", http.StatusConflict, "The approval request was already decided or has expired")
+	define("ACTION_FAILED", http.StatusInternalServerError, "The approved action ran and failed")
+	define("INVALID_GRANT_ID", http.StatusBadRequest, "The break-glass grant ID in the path is invalid")
+	define("GRANT_NOT_FOUND", http.StatusNotFound, "The break-glass grant does not exist or is already revoked")
+
+	// Runbook
+	define("UNKNOWN_RUNBOOK_ACTION", http.StatusNotFound, "The runbook action does not exist")
+	define("INVALID_RUNBOOK_PARAMS", http.StatusBadRequest, "The runbook action's params are missing or invalid")
+	define("INVALID_IDEMPOTENCY_KEY", http.StatusBadRequest, "The Idempotency-Key header is too long")
+	define("RUNBOOK_TARGET_NOT_FOUND", http.StatusNotFound, "The job or resource the runbook action targets does not exist")
+	define("RUNBOOK_RATE_LIMITED", http.StatusTooManyRequests, "The runbook action ran too often recently")
+	define("RUNBOOK_IN_PROGRESS", http.StatusConflict, "A run with the same Idempotency-Key is still in progress")
+	define("RUNBOOK_ACTION_FAILED", http.StatusInternalServerError, "The runbook action ran and failed")
+
+	// Webhooks
+	define("INVALID_WEBHOOK_ID", http.StatusBadRequest, "The webhook ID in the path is invalid")
+	define("WEBHOOK_NOT_FOUND", http.StatusNotFound, "The webhook does not exist")
+	define("INVALID_WEBHOOK_URL", http.StatusBadRequest, "The webhook URL is not an absolute http(s) URL")
+	define("INVALID_WEBHOOK_EVENTS", http.StatusBadRequest, "The webhook subscribes to unknown events")
+	define("INVALID_WEBHOOK_FILTER", http.StatusBadRequest, "The webhook filter expression is invalid")
+	define("INVALID_WEBHOOK_FIELDS", http.StatusBadRequest, "The webhook selects invalid payload fields")
+	define("WEBHOOK_TEST_UNAVAILABLE", http.StatusServiceUnavailable, "Test deliveries are not enabled")
+	define("INVALID_DELIVERY_ID", http.StatusBadRequest, "The delivery ID in the path is invalid")
+	define("DELIVERY_NOT_FOUND", http.StatusNotFound, "The delivery does not exist")
+	define("REDELIVERY_FAILED", http.StatusInternalServerError, "The delivery could not be queued again")
+	define("UNKNOWN_EVENT_TYPE", http.StatusNotFound, "The event type or schema version has no registered schema")
+	define("INVALID_SCHEMA_VERSION", http.StatusBadRequest, "The schema version is not a positive integer")
+
+	// Email and notifications
+	define("EMAIL_NOT_FOUND", http.StatusNotFound, "The email delivery does not exist")
+	define("INVALID_EMAIL_EVENT", http.StatusBadRequest, "The email provider event is not recognized")
+	define("MISSING_RECIPIENT", http.StatusBadRequest, "The email has no recipient")
+	define("INVALID_DEVICE_TOKEN", http.StatusBadRequest, "The push device token is invalid")
+	define("DEVICE_NOT_FOUND", http.StatusNotFound, "The device is not registered")
+	define("INVALID_DIGEST_FREQUENCY", http.StatusBadRequest, "The digest frequency is not supported")
+	define("INVALID_DIGEST_CHANNEL", http.StatusBadRequest, "The digest channel is not supported")
+	define("INVALID_NOTIFICATION_STATUS", http.StatusBadRequest, "The provider delivery status is not recognized")
+	define("NOTIFICATION_NOT_FOUND", http.StatusNotFound, "The notification delivery does not exist")
+	define("UNKNOWN_EMAIL_PROVIDER", http.StatusNotFound, "The inbound email provider is not configured")
+	define("INVALID_EMAIL_SIGNATURE", http.StatusUnauthorized, "The inbound email webhook signature is invalid")
+	define("INVALID_INBOUND_EMAIL", http.StatusBadRequest, "The inbound email could not be parsed")
+	define("INBOUND_EMAIL_FAILED", http.StatusInternalServerError, "The inbound email could not be processed")
+
+	// Calendar
+	define("INVALID_CALENDAR_LINK", http.StatusForbidden, "The calendar link is invalid or no longer grants access")
+	define("CALENDAR_LINK_EXPIRED", http.StatusForbidden, "The calendar link has expired")
+	define("INVALID_MAINTENANCE_WINDOW", http.StatusBadRequest, "The maintenance window is invalid")
+	define("MAINTENANCE_WINDOW_NOT_FOUND", http.StatusNotFound, "The maintenance window does not exist")
+
+	// Announcements
+	define("INVALID_ANNOUNCEMENT_ID", http.StatusBadRequest, "The announcement ID is invalid")
+	define("INVALID_ANNOUNCEMENT", http.StatusBadRequest, "The announcement is invalid")
+	define("ANNOUNCEMENT_NOT_FOUND", http.StatusNotFound, "The announcement does not exist")
+
+	// Usage telemetry
+	define("INVALID_USAGE_PERIOD", http.StatusBadRequest, "The usage report period is invalid")
+	define("MISSING_FEATURE", http.StatusBadRequest, "The feature to report on is missing")
+
+	// Abuse detection
+	define("INVALID_IP_ADDRESS", http.StatusBadRequest, "The IP address is invalid")
+	define("ABUSE_BAN_NOT_FOUND", http.StatusNotFound, "The IP address has no ban in force")
+
+	// Legal holds
+	define("INVALID_LEGAL_HOLD_ID", http.StatusBadRequest, "The legal hold ID in the path is invalid")
+	define("LEGAL_HOLD_NOT_FOUND", http.StatusNotFound, "The legal hold does not exist")
+	define("LEGAL_HOLD_RELEASED", http.StatusConflict, "The legal hold is already released")
+	define("INVALID_HOLD_SUBJECT", http.StatusBadRequest, "Legal holds can only be placed on a user or a post")
+	define("HOLD_SUBJECT_NOT_FOUND", http.StatusNotFound, "The user or post to hold does not exist")
+
+	// Realtime
+	define("INVALID_EVENT_ID", http.StatusBadRequest, "The event ID to catch up from is invalid")
+	define("EVENT_STORE_ERROR", http.StatusInternalServerError, "Stored realtime events could not be read")
+	define("INVALID_CHANNEL", http.StatusBadRequest, "The typing channel is missing or too long")
+	define("PRESENCE_ERROR", http.StatusInternalServerError, "Presence could not be read or recorded")
+
+	// GraphQL
+	define("MISSING_QUERY", http.StatusBadRequest, "The GraphQL request has no query")
+
+	// Resumable uploads
+	define("TUS_VERSION_UNSUPPORTED", http.StatusPreconditionFailed, "The Tus-Resumable version is not supported")
+	define("TUS_DEFER_LENGTH_UNSUPPORTED", http.StatusBadRequest, "Uploads must declare their length")
+	define("INVALID_UPLOAD_LENGTH", http.StatusBadRequest, "The Upload-Length header is invalid")
+	define("INVALID_UPLOAD_METADATA", http.StatusBadRequest, "The Upload-Metadata header is invalid")
+	define("INVALID_UPLOAD_OFFSET", http.StatusBadRequest, "The Upload-Offset header is invalid")
+	define("INVALID_CONTENT_TYPE", http.StatusUnsupportedMediaType, "PATCH requests must use application/offset+octet-stream")
+	define("UPLOAD_NOT_FOUND", http.StatusNotFound, "The upload does not exist or has expired")
+	define("UPLOAD_OFFSET_MISMATCH", http.StatusConflict, "Upload-Offset does not match the current offset")
+	define("UPLOAD_LOCKED", http.StatusLocked, "Another request is writing to the upload")
+	defineTyped("CHECKSUM_MISMATCH", 460, ErrorTypeBadRequest, "The Upload-Checksum does not match the received data (tus status 460)")
+	define("CHECKSUM_UNSUPPORTED", http.StatusBadRequest, "The checksum algorithm is not supported")
+	define("UPLOAD_TOO_LARGE", http.StatusRequestEntityTooLarge, "The upload exceeds its declared length or the size limit")
+	define("UPLOAD_FAILED", http.StatusInternalServerError, "The upload could not be stored")
+
+	// Reports
+	define("INVALID_REPORT_REQUEST", http.StatusBadRequest, "The report type, format or period is invalid")
+	define("INVALID_REPORT_ID", http.StatusBadRequest, "The report ID is invalid")
+	define("REPORT_NOT_FOUND", http.StatusNotFound, "The report does not exist")
+	define("REPORT_NOT_READY", http.StatusConflict, "The report has not finished rendering")
+	define("REPORT_EXPIRED", http.StatusGone, "The report's file was deleted after its retention period")
+	define("REPORT_DOWNLOADS_DISABLED", http.StatusServiceUnavailable, "Report downloads are not configured")
+	define("INVALID_REPORT_LINK", http.StatusForbidden, "The report link is invalid or no longer grants access")
+	define("REPORT_LINK_EXPIRED", http.StatusForbidden, "The report link has expired")
+}
+
+// Lookup returns the definition of a registered code
+func Lookup(code string) (Definition, bool) {
+	definition, ok := catalog[code]
+	return definition, ok
+}
+
+// Catalog returns every registered code, sorted
+func Catalog() []Definition {
+	definitions := make([]Definition, 0, len(catalog))
+	for _, definition := range catalog {
+		definitions = append(definitions, definition)
+	}
+	sort.Slice(definitions, func(i, j int) bool { return definitions[i].Code < definitions[j].Code })
+	return definitions
+}
+
+// Coded creates an API error for a registered code, taking its type and
+// status from the catalog. An unregistered code yields INTERNAL_ERROR so a
+// typo never reaches clients as an unknown code.
+func Coded(code, message string) *APIError {
+	definition, ok := catalog[code]
+	if !ok {
+		definition = catalog["INTERNAL_ERROR"]
+	}
+	return NewAPIErrorWithCode(definition.Type, definition.Code, message, definition.Status)
+}
+
+// WriteCatalogMarkdown writes the catalog as a Markdown table for the API docs
+func WriteCatalogMarkdown(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, "| Code | Status | Type | Description |\n|------|--------|------|-------------|"); err != nil {
+		return err
+	}
+	for _, definition := range Catalog() {
+		if _, err := fmt.Fprintf(w, "| `%s` | %d | %s | %s |\n", definition.Code, definition.Status, definition.Type,
+			strings.ReplaceAll(definition.Description, "|", "\\|")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
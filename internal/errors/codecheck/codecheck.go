@@ -0,0 +1,207 @@
+// Package codecheck is a vet-style check that every error code written by
+// the server is registered in the errors catalog with a matching HTTP
+// status. It inspects calls with a literal code argument:
+//
+//	errors.WriteErrorResponse(w, status, message, "CODE")
+//	respond.Error(w, r, status, message, "CODE")
+//	errors.NewAPIErrorWithCode(type, "CODE", message, status)
+//	errors.Coded("CODE", message)
+package codecheck
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go-server/internal/errors"
+)
+
+// Issue is a problem found at a source position
+type Issue struct {
+	Position token.Position
+	Code     string
+	Message  string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s", i.Position, i.Message)
+}
+
+// Use is a literal error code found in source
+type Use struct {
+	Position token.Position
+	Code     string
+	// Status is the literal HTTP status passed alongside the code, or 0
+	Status int
+}
+
+// call describes where a checked function takes its code and status
+type call struct {
+	code   int
+	status int // -1 when the function takes no status
+}
+
+var calls = map[string]call{
+	"WriteErrorResponse":  {code: 3, status: 1},
+	"Error":               {code: 4, status: 2}, // respond.Error
+	"NewAPIErrorWithCode": {code: 1, status: 3},
+	"Coded":               {code: 0, status: -1},
+}
+
+// receivers lists the packages whose functions are checked
+var receivers = map[string]bool{"errors": true, "respond": true}
+
+// statusCodes maps the http.StatusXxx names used for errors to their values
+var statusCodes = map[string]int{
+	"StatusBadRequest":                   http.StatusBadRequest,
+	"StatusUnauthorized":                 http.StatusUnauthorized,
+	"StatusPaymentRequired":              http.StatusPaymentRequired,
+	"StatusForbidden":                    http.StatusForbidden,
+	"StatusNotFound":                     http.StatusNotFound,
+	"StatusMethodNotAllowed":             http.StatusMethodNotAllowed,
+	"StatusNotAcceptable":                http.StatusNotAcceptable,
+	"StatusRequestTimeout":               http.StatusRequestTimeout,
+	"StatusConflict":                     http.StatusConflict,
+	"StatusGone":                         http.StatusGone,
+	"StatusLengthRequired":               http.StatusLengthRequired,
+	"StatusPreconditionFailed":           http.StatusPreconditionFailed,
+	"StatusRequestEntityTooLarge":        http.StatusRequestEntityTooLarge,
+	"StatusRequestURITooLong":            http.StatusRequestURITooLong,
+	"StatusUnsupportedMediaType":         http.StatusUnsupportedMediaType,
+	"StatusRequestedRangeNotSatisfiable": http.StatusRequestedRangeNotSatisfiable,
+	"StatusUnprocessableEntity":          http.StatusUnprocessableEntity,
+	"StatusLocked":                       http.StatusLocked,
+	"StatusPreconditionRequired":         http.StatusPreconditionRequired,
+	"StatusTooManyRequests":              http.StatusTooManyRequests,
+	"StatusInternalServerError":          http.StatusInternalServerError,
+	"StatusNotImplemented":               http.StatusNotImplemented,
+	"StatusBadGateway":                   http.StatusBadGateway,
+	"StatusServiceUnavailable":           http.StatusServiceUnavailable,
+	"StatusGatewayTimeout":               http.StatusGatewayTimeout,
+	"StatusHTTPVersionNotSupported":      http.StatusHTTPVersionNotSupported,
+}
+
+// Scan finds literal error codes in the Go files under root, skipping tests
+func Scan(root string) ([]Use, error) {
+	fset := token.NewFileSet()
+	var uses []Use
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name := d.Name(); path != root && (strings.HasPrefix(name, ".") || name == "vendor" || name == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+		ast.Inspect(file, func(node ast.Node) bool {
+			if use, ok := inspectCall(fset, node); ok {
+				uses = append(uses, use)
+			}
+			return true
+		})
+		return nil
+	})
+	return uses, err
+}
+
+// Check reports literal error codes under root that are not in the catalog
+// or are written with a status other than the catalog's
+func Check(root string) ([]Issue, error) {
+	uses, err := Scan(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []Issue
+	for _, use := range uses {
+		definition, ok := errors.Lookup(use.Code)
+		switch {
+		case !ok:
+			issues = append(issues, Issue{Position: use.Position, Code: use.Code,
+				Message: fmt.Sprintf("error code %s is not registered in the errors catalog", use.Code)})
+		case use.Status != 0 && use.Status != definition.Status:
+			issues = append(issues, Issue{Position: use.Position, Code: use.Code,
+				Message: fmt.Sprintf("error code %s is written with status %d but registered as %d", use.Code, use.Status, definition.Status)})
+		}
+	}
+	sort.Slice(issues, func(i, j int) bool {
+		return issues[i].Position.String() < issues[j].Position.String()
+	})
+	return issues, nil
+}
+
+func inspectCall(fset *token.FileSet, node ast.Node) (Use, bool) {
+	expr, ok := node.(*ast.CallExpr)
+	if !ok {
+		return Use{}, false
+	}
+	var name string
+	switch fun := expr.Fun.(type) {
+	case *ast.SelectorExpr:
+		pkg, ok := fun.X.(*ast.Ident)
+		if !ok || !receivers[pkg.Name] || (fun.Sel.Name == "Error") != (pkg.Name == "respond") {
+			return Use{}, false
+		}
+		name = fun.Sel.Name
+	case *ast.Ident:
+		// Unqualified calls inside package errors itself
+		if fun.Name == "Error" {
+			return Use{}, false
+		}
+		name = fun.Name
+	default:
+		return Use{}, false
+	}
+	spec, ok := calls[name]
+	if !ok || len(expr.Args) <= spec.code {
+		return Use{}, false
+	}
+
+	literal, ok := expr.Args[spec.code].(*ast.BasicLit)
+	if !ok || literal.Kind != token.STRING {
+		return Use{}, false
+	}
+	code, err := strconv.Unquote(literal.Value)
+	if err != nil {
+		return Use{}, false
+	}
+
+	use := Use{Position: fset.Position(literal.Pos()), Code: code}
+	if spec.status >= 0 && len(expr.Args) > spec.status {
+		use.Status = literalStatus(expr.Args[spec.status])
+	}
+	return use, true
+}
+
+// literalStatus resolves http.StatusXxx selectors and integer literals
+func literalStatus(expr ast.Expr) int {
+	switch value := expr.(type) {
+	case *ast.SelectorExpr:
+		if pkg, ok := value.X.(*ast.Ident); ok && pkg.Name == "http" {
+			return statusCodes[value.Sel.Name]
+		}
+	case *ast.BasicLit:
+		if value.Kind == token.INT {
+			status, _ := strconv.Atoi(value.Value)
+			return status
+		}
+	}
+	return 0
+}
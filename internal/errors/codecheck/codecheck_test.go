@@ -0,0 +1,55 @@
+package codecheck
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRepositoryCodesAreRegistered fails the build when a handler writes an
+// error code that is missing from the catalog or uses a different status
+func TestRepositoryCodesAreRegistered(t *testing.T) {
+	issues, err := Check(filepath.Join("..", ".."))
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	for _, issue := range issues {
+		t.Error(issue)
+	}
+}
+
+func TestCheck_ReportsUnregisteredAndMismatchedCodes(t *testing.T) {
+	dir := t.TempDir()
+	source := `package handlers
+
+import (
+	"net/http"
+
+	"go-server/internal/errors"
+	"go-server/internal/respond"
+)
+
+func handle(w http.ResponseWriter, r *http.Request, code string) {
+	errors.WriteErrorResponse(w, http.StatusNotFound, "User not found", "USER_NOT_FOUND")
+	errors.WriteErrorResponse(w, http.StatusBadRequest, "Gone", "MADE_UP_CODE")
+	respond.Error(w, r, http.StatusConflict, "Not found", "USER_NOT_FOUND")
+	errors.WriteErrorResponse(w, http.StatusBadRequest, "Dynamic", code)
+	_ = errors.Coded("ANOTHER_MADE_UP_CODE", "message")
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "handler.go"), []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	issues, err := Check(dir)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if len(issues) != 3 {
+		t.Fatalf("Expected 3 issues, got %v", issues)
+	}
+	if issues[0].Code != "MADE_UP_CODE" || !strings.Contains(issues[1].Message, "status 409 but registered as 404") || issues[2].Code != "ANOTHER_MADE_UP_CODE" {
+		t.Errorf("Issues = %v", issues)
+	}
+}
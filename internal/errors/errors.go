@@ -1,7 +1,7 @@
 package errors
 
 import (
-	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"net/http"
 )
@@ -20,7 +20,9 @@ const (
 	ErrorTypeRateLimit    ErrorType = "rate_limit"
 )
 
-// APIError represents a structured API error
+// APIError represents a structured API error. It may wrap the internal error
+// that caused it; the cause is available to errors.Is, errors.As and logs but
+// is never written to clients.
 type APIError struct {
 	Type       ErrorType `json:"type"`
 	Message    string    `json:"message"`
@@ -28,14 +30,55 @@ type APIError struct {
 	Details    string    `json:"details,omitempty"`
 	RequestID  string    `json:"request_id,omitempty"`
 	StatusCode int       `json:"-"`
+	// Extensions are extra members written alongside the error
+	Extensions map[string]interface{} `json:"-"`
+
+	cause error
 }
 
-// Error implements the error interface
+// Error implements the error interface; the cause is included for logs
 func (e *APIError) Error() string {
+	message := fmt.Sprintf("[%s] %s", e.Type, e.Message)
 	if e.Code != "" {
-		return fmt.Sprintf("[%s] %s: %s", e.Type, e.Code, e.Message)
+		message = fmt.Sprintf("[%s] %s: %s", e.Type, e.Code, e.Message)
+	}
+	if e.cause != nil {
+		message += ": " + e.cause.Error()
+	}
+	return message
+}
+
+// Unwrap returns the wrapped cause, if any
+func (e *APIError) Unwrap() error {
+	return e.cause
+}
+
+// Is reports whether e matches a target API error, so errors.Is works with
+// the predefined errors even after they were copied by the With* methods.
+// A target with a code matches errors with the same code; a target without
+// one matches every error of its type, so an error coded USER_NOT_FOUND is
+// also ErrNotFound.
+func (e *APIError) Is(target error) bool {
+	t, ok := target.(*APIError)
+	if !ok {
+		return false
+	}
+	if t.Code != "" {
+		return e.Code == t.Code
+	}
+	return e.Type == t.Type
+}
+
+// clone copies e so the With* methods never mutate shared predefined errors
+func (e *APIError) clone() *APIError {
+	copied := *e
+	if e.Extensions != nil {
+		copied.Extensions = make(map[string]interface{}, len(e.Extensions))
+		for key, value := range e.Extensions {
+			copied.Extensions[key] = value
+		}
 	}
-	return fmt.Sprintf("[%s] %s", e.Type, e.Message)
+	return &copied
 }
 
 // NewAPIError creates a new API error
@@ -57,16 +100,26 @@ func NewAPIErrorWithCode(errorType ErrorType, code, message string, statusCode i
 	}
 }
 
-// WithDetails adds details to the error
+// WithDetails returns a copy of the error with client-facing details
 func (e *APIError) WithDetails(details string) *APIError {
-	e.Details = details
-	return e
+	copied := e.clone()
+	copied.Details = details
+	return copied
 }
 
-// WithRequestID adds request ID to the error
+// WithRequestID returns a copy of the error with a request ID
 func (e *APIError) WithRequestID(requestID string) *APIError {
-	e.RequestID = requestID
-	return e
+	copied := e.clone()
+	copied.RequestID = requestID
+	return copied
+}
+
+// WithCause returns a copy of the error wrapping cause. The cause is kept
+// out of responses, so it may contain internal detail such as SQL errors.
+func (e *APIError) WithCause(cause error) *APIError {
+	copied := e.clone()
+	copied.cause = cause
+	return copied
 }
 
 // Predefined common errors
@@ -95,17 +148,19 @@ var (
 	ErrRateLimit = NewAPIError(ErrorTypeRateLimit, "Rate limit exceeded", http.StatusTooManyRequests)
 )
 
-// WrapError wraps an existing error with additional context
+// WrapError wraps an existing error as an internal error with a client-safe
+// message. If err already is or wraps an API error, that error is returned.
 func WrapError(err error, message string) *APIError {
-	if apiErr, ok := err.(*APIError); ok {
+	var apiErr *APIError
+	if stderrors.As(err, &apiErr) {
 		return apiErr
 	}
 
 	return &APIError{
 		Type:       ErrorTypeInternal,
 		Message:    message,
-		Details:    err.Error(),
 		StatusCode: http.StatusInternalServerError,
+		cause:      err,
 	}
 }
 
@@ -114,40 +169,40 @@ func WrapErrorWithType(err error, errorType ErrorType, message string, statusCod
 	return &APIError{
 		Type:       errorType,
 		Message:    message,
-		Details:    err.Error(),
 		StatusCode: statusCode,
+		cause:      err,
 	}
 }
 
 // WriteErrorResponse writes an error response to the HTTP response writer
+// in the configured format
 func WriteErrorResponse(w http.ResponseWriter, statusCode int, message, code string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-
-	errorResponse := APIError{
-		Type:       ErrorTypeInternal,
+	WriteError(w, &APIError{
+		Type:       TypeForStatus(statusCode),
 		Message:    message,
 		Code:       code,
 		StatusCode: statusCode,
-	}
+	})
+}
 
-	// Set appropriate error type based on status code
+// TypeForStatus returns the error type matching an HTTP status code
+func TypeForStatus(statusCode int) ErrorType {
 	switch statusCode {
 	case http.StatusBadRequest:
-		errorResponse.Type = ErrorTypeBadRequest
+		return ErrorTypeBadRequest
 	case http.StatusUnauthorized:
-		errorResponse.Type = ErrorTypeUnauthorized
+		return ErrorTypeUnauthorized
 	case http.StatusForbidden:
-		errorResponse.Type = ErrorTypeForbidden
+		return ErrorTypeForbidden
 	case http.StatusNotFound:
-		errorResponse.Type = ErrorTypeNotFound
+		return ErrorTypeNotFound
 	case http.StatusConflict:
-		errorResponse.Type = ErrorTypeConflict
+		return ErrorTypeConflict
 	case http.StatusTooManyRequests:
-		errorResponse.Type = ErrorTypeRateLimit
+		return ErrorTypeRateLimit
+	default:
+		return ErrorTypeInternal
 	}
-
-	json.NewEncoder(w).Encode(errorResponse)
 }
 
 // NewValidationError creates a new validation error
@@ -1,8 +1,12 @@
 package errors
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -77,8 +81,12 @@ func TestWrapError(t *testing.T) {
 		t.Errorf("Expected message 'wrapped message', got %s", wrappedErr.Message)
 	}
 
-	if wrappedErr.Details != "original error" {
-		t.Errorf("Expected details 'original error', got %s", wrappedErr.Details)
+	if wrappedErr.Details != "" {
+		t.Errorf("Internal cause should not leak into details, got %s", wrappedErr.Details)
+	}
+
+	if !errors.Is(wrappedErr, originalErr) || wrappedErr.Unwrap() != originalErr {
+		t.Error("Expected the original error to be retained as the cause")
 	}
 }
 
@@ -127,3 +135,93 @@ func TestPredefinedErrors(t *testing.T) {
 		}
 	}
 }
+
+func TestCatalog(t *testing.T) {
+	definitions := Catalog()
+	for i, definition := range definitions {
+		if definition.Status < 400 || definition.Type == "" || definition.Description == "" {
+			t.Errorf("Incomplete definition %+v", definition)
+		}
+		if i > 0 && definitions[i-1].Code >= definition.Code {
+			t.Errorf("Catalog is not sorted at %s", definition.Code)
+		}
+	}
+
+	definition, ok := Lookup("USER_NOT_FOUND")
+	if !ok || definition.Status != http.StatusNotFound || definition.Type != ErrorTypeNotFound {
+		t.Errorf("USER_NOT_FOUND = %+v, %v", definition, ok)
+	}
+}
+
+func TestCoded(t *testing.T) {
+	err := Coded("EMAIL_TAKEN", "Email already taken")
+	if err.StatusCode != http.StatusConflict || err.Type != ErrorTypeConflict || err.Code != "EMAIL_TAKEN" {
+		t.Errorf("Coded = %+v", err)
+	}
+
+	err = Coded("NOT_A_REAL_CODE", "oops")
+	if err.Code != "INTERNAL_ERROR" || err.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Unregistered code should fall back to INTERNAL_ERROR, got %+v", err)
+	}
+}
+
+func TestWriteCatalogMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCatalogMarkdown(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "| `UPLOAD_LOCKED` | 423 | ") {
+		t.Errorf("Markdown missing UPLOAD_LOCKED row:\n%s", buf.String())
+	}
+}
+
+func TestErrorsIsAndAs(t *testing.T) {
+	cause := errors.New("sql: no rows in result set")
+	err := fmt.Errorf("loading user: %w", Coded("USER_NOT_FOUND", "User not found").WithCause(cause))
+
+	if !errors.Is(err, ErrNotFound) {
+		t.Error("A not-found error should match ErrNotFound")
+	}
+	if errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrHandlerNotFound) {
+		t.Error("Error should not match other types or codes")
+	}
+	if !errors.Is(err, cause) {
+		t.Error("Expected the cause to be reachable")
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "USER_NOT_FOUND" {
+		t.Fatalf("errors.As = %+v", apiErr)
+	}
+	if WrapError(err, "ignored") != apiErr {
+		t.Error("WrapError should return the wrapped API error")
+	}
+	if !strings.Contains(apiErr.Error(), cause.Error()) {
+		t.Errorf("Error() should include the cause for logs, got %q", apiErr.Error())
+	}
+
+	rec := httptest.NewRecorder()
+	WriteError(rec, apiErr)
+	if strings.Contains(rec.Body.String(), "sql") {
+		t.Errorf("Cause leaked into the response: %s", rec.Body.String())
+	}
+}
+
+func TestWithMethodsDoNotMutatePredefinedErrors(t *testing.T) {
+	err := ErrInternal.WithRequestID("req-1").WithDetails("boom").WithExtension("retry", true).WithCause(errors.New("x"))
+
+	if ErrInternal.RequestID != "" || ErrInternal.Details != "" || ErrInternal.Extensions != nil || ErrInternal.Unwrap() != nil {
+		t.Errorf("Predefined error was modified: %+v", ErrInternal)
+	}
+	if err.RequestID != "req-1" || err.Details != "boom" || !errors.Is(err, ErrInternal) {
+		t.Errorf("Copy = %+v", err)
+	}
+
+	extended := err.WithExtension("other", 1)
+	if _, shared := err.Extensions["other"]; shared {
+		t.Error("Extensions should not be shared between copies")
+	}
+	if len(extended.Extensions) != 2 {
+		t.Errorf("Extensions = %v", extended.Extensions)
+	}
+}
@@ -0,0 +1,186 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ProblemContentType is the media type of RFC 7807 problem details
+const ProblemContentType = "application/problem+json"
+
+// Format selects how error responses are written
+type Format string
+
+const (
+	// FormatJSON writes the APIError body as application/json
+	FormatJSON Format = "json"
+	// FormatProblem writes RFC 7807 problem details as application/problem+json
+	FormatProblem Format = "problem"
+)
+
+// DefaultProblemTypeBase prefixes problem type URIs unless configured otherwise
+const DefaultProblemTypeBase = "/problems/"
+
+var (
+	formatMutex     sync.RWMutex
+	currentFormat   = FormatJSON
+	problemTypeBase = DefaultProblemTypeBase
+)
+
+// ParseFormat validates a configured error format name
+func ParseFormat(name string) (Format, error) {
+	switch Format(strings.ToLower(strings.TrimSpace(name))) {
+	case "", FormatJSON:
+		return FormatJSON, nil
+	case FormatProblem:
+		return FormatProblem, nil
+	default:
+		return "", fmt.Errorf("unknown error format %q (want json or problem)", name)
+	}
+}
+
+// Configure sets the error format used by WriteError and
+// WriteErrorResponse. typeBase prefixes problem type URIs, e.g.
+// https://api.example.com/problems/; empty keeps DefaultProblemTypeBase.
+func Configure(format Format, typeBase string) {
+	formatMutex.Lock()
+	defer formatMutex.Unlock()
+	currentFormat = format
+	if typeBase == "" {
+		typeBase = DefaultProblemTypeBase
+	}
+	problemTypeBase = typeBase
+}
+
+// CurrentFormat returns the configured error format
+func CurrentFormat() Format {
+	formatMutex.RLock()
+	defer formatMutex.RUnlock()
+	return currentFormat
+}
+
+// Problem is an RFC 7807 problem details object. Extensions are written as
+// additional top-level members.
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]interface{}
+}
+
+// MarshalJSON flattens extension members into the problem object
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	body := make(map[string]interface{}, len(p.Extensions)+5)
+	for key, value := range p.Extensions {
+		body[key] = value
+	}
+	body["type"] = p.Type
+	body["title"] = p.Title
+	body["status"] = p.Status
+	if p.Detail != "" {
+		body["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		body["instance"] = p.Instance
+	}
+	return json.Marshal(body)
+}
+
+// WithExtension returns a copy of the error with a member that is written
+// alongside it in either format, e.g. the list of failed fields of a
+// validation error
+func (e *APIError) WithExtension(key string, value interface{}) *APIError {
+	copied := e.clone()
+	if copied.Extensions == nil {
+		copied.Extensions = make(map[string]interface{})
+	}
+	copied.Extensions[key] = value
+	return copied
+}
+
+// Problem converts the error to problem details. The type URI is derived
+// from the error code, or the error type if there is none, so
+// USER_NOT_FOUND becomes <typeBase>user-not-found. The request ID is used
+// as the instance.
+func (e *APIError) Problem() *Problem {
+	formatMutex.RLock()
+	typeBase := problemTypeBase
+	formatMutex.RUnlock()
+
+	status := e.StatusCode
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+	slug := e.Code
+	if slug == "" {
+		slug = string(e.Type)
+	}
+
+	extensions := map[string]interface{}{"error_type": e.Type}
+	if e.Code != "" {
+		extensions["code"] = e.Code
+	}
+	if e.Details != "" {
+		extensions["details"] = e.Details
+	}
+	for key, value := range e.Extensions {
+		extensions[key] = value
+	}
+
+	return &Problem{
+		Type:       typeBase + strings.ReplaceAll(strings.ToLower(slug), "_", "-"),
+		Title:      http.StatusText(status),
+		Status:     status,
+		Detail:     e.Message,
+		Instance:   e.RequestID,
+		Extensions: extensions,
+	}
+}
+
+// WriteError writes err in the configured format. The request ID is taken
+// from the X-Request-ID response header when err does not carry one.
+func WriteError(w http.ResponseWriter, err *APIError) {
+	body := *err
+	if body.StatusCode == 0 {
+		body.StatusCode = http.StatusInternalServerError
+	}
+	if body.RequestID == "" {
+		body.RequestID = w.Header().Get("X-Request-ID")
+	}
+
+	if CurrentFormat() == FormatProblem {
+		w.Header().Set("Content-Type", ProblemContentType)
+		w.WriteHeader(body.StatusCode)
+		json.NewEncoder(w).Encode(body.Problem())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(body.StatusCode)
+	json.NewEncoder(w).Encode(&body)
+}
+
+// MarshalJSON writes the error's fields followed by its extension members
+func (e APIError) MarshalJSON() ([]byte, error) {
+	type plain APIError
+	encoded, err := json.Marshal(plain(e))
+	if err != nil || len(e.Extensions) == 0 {
+		return encoded, err
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(encoded, &body); err != nil {
+		return nil, err
+	}
+	for key, value := range e.Extensions {
+		if _, taken := body[key]; !taken {
+			body[key] = value
+		}
+	}
+	return json.Marshal(body)
+}
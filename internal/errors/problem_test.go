@@ -0,0 +1,96 @@
+package errors
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func decodeBody(t *testing.T, rec *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+	var body map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Invalid JSON body: %v", err)
+	}
+	return body
+}
+
+func TestParseFormat(t *testing.T) {
+	for name, want := range map[string]Format{"": FormatJSON, "json": FormatJSON, "Problem": FormatProblem} {
+		if got, err := ParseFormat(name); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v", name, got, err)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+}
+
+func TestWriteErrorResponse_ProblemFormat(t *testing.T) {
+	Configure(FormatProblem, "https://api.example.com/problems/")
+	defer Configure(FormatJSON, "")
+
+	rec := httptest.NewRecorder()
+	rec.Header().Set("X-Request-ID", "req-42")
+	WriteErrorResponse(rec, http.StatusNotFound, "User not found", "USER_NOT_FOUND")
+
+	if ct := rec.Header().Get("Content-Type"); ct != ProblemContentType {
+		t.Errorf("Content-Type = %q, want %q", ct, ProblemContentType)
+	}
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Status = %d, want 404", rec.Code)
+	}
+
+	body := decodeBody(t, rec)
+	expected := map[string]interface{}{
+		"type":       "https://api.example.com/problems/user-not-found",
+		"title":      "Not Found",
+		"status":     float64(404),
+		"detail":     "User not found",
+		"instance":   "req-42",
+		"code":       "USER_NOT_FOUND",
+		"error_type": "not_found",
+	}
+	for key, want := range expected {
+		if body[key] != want {
+			t.Errorf("%s = %v, want %v", key, body[key], want)
+		}
+	}
+}
+
+func TestWriteError_ExtensionsInBothFormats(t *testing.T) {
+	failures := []map[string]string{{"field": "email", "message": "invalid"}}
+
+	rec := httptest.NewRecorder()
+	WriteError(rec, NewValidationError("email", "invalid").WithExtension("errors", failures))
+	body := decodeBody(t, rec)
+	if rec.Header().Get("Content-Type") != "application/json" || body["code"] != "VALIDATION_ERROR" {
+		t.Errorf("JSON body = %v", body)
+	}
+	if list, ok := body["errors"].([]interface{}); !ok || len(list) != 1 {
+		t.Errorf("errors extension missing from JSON body: %v", body)
+	}
+
+	Configure(FormatProblem, "")
+	defer Configure(FormatJSON, "")
+	rec = httptest.NewRecorder()
+	WriteError(rec, NewValidationError("email", "invalid").WithExtension("errors", failures))
+	body = decodeBody(t, rec)
+	if body["type"] != DefaultProblemTypeBase+"validation-error" || body["details"] != "email" {
+		t.Errorf("Problem body = %v", body)
+	}
+	if list, ok := body["errors"].([]interface{}); !ok || len(list) != 1 {
+		t.Errorf("errors extension missing from problem body: %v", body)
+	}
+}
+
+func TestProblem_FallsBackToErrorType(t *testing.T) {
+	problem := ErrRateLimit.Problem()
+	if problem.Type != DefaultProblemTypeBase+"rate-limit" || problem.Status != http.StatusTooManyRequests {
+		t.Errorf("Problem = %+v", problem)
+	}
+	if problem.Title != "Too Many Requests" {
+		t.Errorf("Title = %q", problem.Title)
+	}
+}
@@ -0,0 +1,132 @@
+// Package events is the server's event bus. Services publish domain events
+// on topics and subscribers, such as webhook dispatch, the realtime hub and
+// the in-process cache tier, consume them without knowing each other.
+//
+// A MemoryBus delivers within one server. A RedisBus delivers through
+// Redis pub/sub to every server of a cluster too, at most once: an event
+// published while a server is disconnected never reaches it, which
+// subscribers learn through Subscriber.Missed. Subscribers with effects
+// that must happen once per event, like enqueuing webhook deliveries,
+// subscribe with Local set and only receive the events published by their
+// own server.
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sync"
+
+	"go-server/internal/logger"
+)
+
+// Event is a message published on a topic. Topics are pub/sub channel
+// names such as "outbox:post.published" and must not contain '/'.
+type Event struct {
+	Topic   string
+	Payload []byte
+}
+
+// Subscriber consumes the events on the topics matching Pattern, a glob
+// such as "outbox:*"
+type Subscriber struct {
+	Pattern string
+	// Handle is called for each event, one at a time per subscriber. The
+	// errors of Local subscribers are returned by Publish; the others are
+	// logged.
+	Handle func(ctx context.Context, event Event) error
+	// Local receives only the events published by this server, which are
+	// handled before Publish returns
+	Local bool
+	// Missed, if set, is called when events may have been lost, e.g. after
+	// the bus reconnected to Redis
+	Missed func()
+}
+
+// Bus publishes events to subscribers
+type Bus interface {
+	Publish(ctx context.Context, event Event) error
+	// Subscribe delivers events to subscriber until ctx is done
+	Subscribe(ctx context.Context, subscriber Subscriber) error
+}
+
+// validate checks a subscriber before it is registered
+func (s Subscriber) validate() error {
+	if s.Handle == nil {
+		return errors.New("events: subscriber has no handler")
+	}
+	if _, err := path.Match(s.Pattern, ""); err != nil {
+		return fmt.Errorf("events: invalid pattern %q: %w", s.Pattern, err)
+	}
+	return nil
+}
+
+// matches reports whether the subscriber consumes the topic
+func (s Subscriber) matches(topic string) bool {
+	matched, _ := path.Match(s.Pattern, topic)
+	return matched
+}
+
+// entry is a registered subscriber
+type entry struct {
+	Subscriber
+	mu sync.Mutex // Serializes Handle
+}
+
+// handle calls the subscriber's handler
+func (e *entry) handle(ctx context.Context, event Event) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.Handle(ctx, event)
+}
+
+// registry holds the subscribers called directly by Publish
+type registry struct {
+	mu      sync.Mutex
+	entries map[*entry]struct{}
+}
+
+// add registers subscriber until ctx is done
+func (r *registry) add(ctx context.Context, subscriber Subscriber) {
+	e := &entry{Subscriber: subscriber}
+	r.mu.Lock()
+	if r.entries == nil {
+		r.entries = make(map[*entry]struct{})
+	}
+	r.entries[e] = struct{}{}
+	r.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		r.mu.Lock()
+		delete(r.entries, e)
+		r.mu.Unlock()
+	}()
+}
+
+// deliver hands the event to the matching subscribers, returning the
+// errors of Local ones and logging the others
+func (r *registry) deliver(ctx context.Context, event Event, log logger.Logger) error {
+	r.mu.Lock()
+	var matched []*entry
+	for e := range r.entries {
+		if e.matches(event.Topic) {
+			matched = append(matched, e)
+		}
+	}
+	r.mu.Unlock()
+
+	var errs []error
+	for _, e := range matched {
+		err := e.handle(ctx, event)
+		switch {
+		case err == nil:
+		case e.Local:
+			errs = append(errs, err)
+		default:
+			log.Warn("Event handler failed", "topic", event.Topic, "pattern", e.Pattern, "error", err.Error())
+		}
+	}
+	return errors.Join(errs...)
+}
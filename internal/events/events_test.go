@@ -0,0 +1,80 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-server/internal/logger"
+)
+
+func TestMemoryBus(t *testing.T) {
+	ctx := context.Background()
+	bus := NewMemoryBus(logger.NewServerLogger())
+
+	var posts, all []string
+	subscribeCtx, unsubscribe := context.WithCancel(ctx)
+	if err := bus.Subscribe(subscribeCtx, Subscriber{
+		Pattern: "outbox:post.*",
+		Handle: func(ctx context.Context, event Event) error {
+			posts = append(posts, event.Topic)
+			return errors.New("not delivered")
+		},
+	}); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	bus.Subscribe(ctx, Subscriber{
+		Pattern: "*",
+		Local:   true,
+		Handle: func(ctx context.Context, event Event) error {
+			all = append(all, event.Topic)
+			if string(event.Payload) == "fail" {
+				return errors.New("rejected")
+			}
+			return nil
+		},
+	})
+
+	if err := bus.Publish(ctx, Event{Topic: "outbox:post.published"}); err != nil {
+		t.Errorf("Only Local subscribers should fail a publish, got %v", err)
+	}
+	bus.Publish(ctx, Event{Topic: "cache:invalidate"})
+	if err := bus.Publish(ctx, Event{Topic: "outbox:user.registered", Payload: []byte("fail")}); err == nil {
+		t.Error("Expected the Local subscriber's error")
+	}
+	if len(posts) != 1 || len(all) != 3 {
+		t.Errorf("Unexpected deliveries: posts %v, all %v", posts, all)
+	}
+
+	unsubscribe()
+	waitFor(t, func() bool {
+		bus.registry.mu.Lock()
+		defer bus.registry.mu.Unlock()
+		return len(bus.registry.entries) == 1
+	})
+	bus.Publish(ctx, Event{Topic: "outbox:post.deleted"})
+	if len(posts) != 1 {
+		t.Errorf("Expected no deliveries after unsubscribing, got %v", posts)
+	}
+
+	if err := bus.Subscribe(ctx, Subscriber{Pattern: "[", Handle: func(context.Context, Event) error { return nil }}); err == nil {
+		t.Error("Expected an invalid pattern to be rejected")
+	}
+	if err := bus.Subscribe(ctx, Subscriber{Pattern: "*"}); err == nil {
+		t.Error("Expected a subscriber without a handler to be rejected")
+	}
+}
+
+// waitFor retries condition until it holds, as unsubscribing happens in
+// the background
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		if condition() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("Timed out waiting for the subscriber to be removed")
+}
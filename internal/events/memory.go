@@ -0,0 +1,34 @@
+package events
+
+import (
+	"context"
+
+	"go-server/internal/logger"
+)
+
+// MemoryBus delivers events within one server, for single-node
+// deployments and tests. Every subscriber is handled before Publish
+// returns, so Local makes no difference and Missed is never called.
+type MemoryBus struct {
+	registry registry
+	logger   logger.Logger
+}
+
+// NewMemoryBus creates an in-process event bus
+func NewMemoryBus(logger logger.Logger) *MemoryBus {
+	return &MemoryBus{logger: logger}
+}
+
+// Publish hands the event to every matching subscriber
+func (mb *MemoryBus) Publish(ctx context.Context, event Event) error {
+	return mb.registry.deliver(ctx, event, mb.logger)
+}
+
+// Subscribe registers subscriber until ctx is done
+func (mb *MemoryBus) Subscribe(ctx context.Context, subscriber Subscriber) error {
+	if err := subscriber.validate(); err != nil {
+		return err
+	}
+	mb.registry.add(ctx, subscriber)
+	return nil
+}
@@ -0,0 +1,104 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"go-server/internal/logger"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// resubscribeDelay is how long a subscription waits after losing Redis
+// before receiving again
+const resubscribeDelay = time.Second
+
+// RedisBus delivers events to the subscribers of every server sharing a
+// Redis. Topics are the Redis channels, and payloads are published as
+// they are, so non-Go consumers can subscribe too.
+type RedisBus struct {
+	client   *redis.Client
+	registry registry // Local subscribers
+	logger   logger.Logger
+}
+
+// NewRedisBus creates an event bus on Redis pub/sub
+func NewRedisBus(client *redis.Client, logger logger.Logger) *RedisBus {
+	return &RedisBus{client: client, logger: logger}
+}
+
+// Publish hands the event to this server's Local subscribers, then
+// publishes it to every server's other subscribers
+func (rb *RedisBus) Publish(ctx context.Context, event Event) error {
+	err := rb.registry.deliver(ctx, event, rb.logger)
+	if publishErr := rb.client.Publish(ctx, event.Topic, event.Payload).Err(); publishErr != nil {
+		err = errors.Join(err, publishErr)
+	}
+	return err
+}
+
+// Subscribe registers subscriber until ctx is done. Unless Local, it gets
+// its own Redis subscription, which is re-established after Redis is lost.
+func (rb *RedisBus) Subscribe(ctx context.Context, subscriber Subscriber) error {
+	if err := subscriber.validate(); err != nil {
+		return err
+	}
+	if subscriber.Local {
+		rb.registry.add(ctx, subscriber)
+		return nil
+	}
+
+	var pubsub *redis.PubSub
+	if strings.ContainsAny(subscriber.Pattern, `*?[\`) {
+		pubsub = rb.client.PSubscribe(ctx, subscriber.Pattern)
+	} else {
+		pubsub = rb.client.Subscribe(ctx, subscriber.Pattern)
+	}
+	go rb.receive(ctx, pubsub, subscriber)
+	return nil
+}
+
+// receive handles the subscription's messages until ctx is done
+func (rb *RedisBus) receive(ctx context.Context, pubsub *redis.PubSub, subscriber Subscriber) {
+	defer pubsub.Close()
+	missed := func() {
+		if subscriber.Missed != nil {
+			subscriber.Missed()
+		}
+	}
+
+	subscribed := false
+	for {
+		message, err := pubsub.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			// Events published meanwhile are lost
+			missed()
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(resubscribeDelay):
+			}
+			continue
+		}
+		switch message := message.(type) {
+		case *redis.Subscription:
+			if message.Kind == "subscribe" || message.Kind == "psubscribe" {
+				if subscribed {
+					// Subscribed again after a disconnection
+					missed()
+				}
+				subscribed = true
+			}
+		case *redis.Message:
+			event := Event{Topic: message.Channel, Payload: []byte(message.Payload)}
+			if err := subscriber.Handle(ctx, event); err != nil {
+				rb.logger.Warn("Event handler failed", "topic", event.Topic, "pattern", subscriber.Pattern, "error", err.Error())
+			}
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package eventschema
+
+import (
+	"go-server/internal/database/models"
+)
+
+// defaultRegistry holds the schemas of the events the server records
+var defaultRegistry = newDefaultRegistry()
+
+// Default returns the registry of the server's domain events, used to
+// validate outbox payloads and served at /api/events/schemas
+func Default() *Registry {
+	return defaultRegistry
+}
+
+func newDefaultRegistry() *Registry {
+	r := NewRegistry()
+
+	r.MustRegister(models.EventUserRegistered, 1, &Schema{
+		Title:       "User registered",
+		Description: "A user account was created, by sign-up or by an external identity provider",
+		Type:        TypeList{"object"},
+		Properties: map[string]*Schema{
+			"id":        identifier(),
+			"public_id": {Type: TypeList{"string"}},
+			"email":     {Type: TypeList{"string"}, Format: "email"},
+			"username":  {Type: TypeList{"string"}, MinLength: intPtr(1)},
+			"issuer": {
+				Type:        TypeList{"string"},
+				Description: "The identity provider the account was provisioned from",
+			},
+		},
+		Required: []string{"id", "public_id", "email", "username"},
+	})
+
+	r.MustRegister(models.EventUserUpdated, 1, userSchema("User updated",
+		"A user's email or username changed, or a deleted account was restored"))
+	r.MustRegister(models.EventUserDeleted, 1, userSchema("User deleted",
+		"A user account was deleted"))
+	r.MustRegister(models.EventUserDeactivated, 1, userSchema("User deactivated",
+		"A user account was deactivated by its owner or by staff; its sessions and API keys were revoked and its content left public listings"))
+	r.MustRegister(models.EventUserReactivated, 1, userSchema("User reactivated",
+		"A deactivated user account was reactivated and its content is listed again"))
+	r.MustRegister(models.EventUserMerged, 1, &Schema{
+		Title:       "User merged",
+		Description: "A user account was merged into another; its data now belongs to the target and its ID is an alias of it",
+		Type:        TypeList{"object"},
+		Properties: map[string]*Schema{
+			"source_id":        identifier(),
+			"source_public_id": {Type: TypeList{"string"}},
+			"target_id":        identifier(),
+			"target_public_id": {Type: TypeList{"string"}},
+		},
+		Required: []string{"source_id", "target_id"},
+	})
+
+	for _, post := range []struct{ eventType, title string }{
+		{models.EventPostPublished, "Post published"},
+		{models.EventPostUpdated, "Post updated"},
+		{models.EventPostDeleted, "Post deleted"},
+	} {
+		r.MustRegister(post.eventType, 1, postSchema(post.title))
+	}
+
+	r.MustRegister(models.EventLoginFailed, 1, &Schema{
+		Title:       "Login failed",
+		Description: "A login to an existing account was refused; attempts for unknown emails are not recorded",
+		Type:        TypeList{"object"},
+		Properties: map[string]*Schema{
+			"user_id":   identifier(),
+			"public_id": {Type: TypeList{"string"}},
+			"email":     {Type: TypeList{"string"}, Format: "email"},
+			"reason": {
+				Type: TypeList{"string"},
+				Enum: []any{models.LoginFailedPassword, models.LoginFailedDeactivated},
+			},
+			"ip_address": {Type: TypeList{"string"}},
+			"user_agent": {Type: TypeList{"string"}},
+		},
+		Required: []string{"user_id", "public_id", "email", "reason"},
+	})
+
+	return r
+}
+
+// userSchema describes models.UserEventPayload
+func userSchema(title, description string) *Schema {
+	return &Schema{
+		Title:       title,
+		Description: description,
+		Type:        TypeList{"object"},
+		Properties: map[string]*Schema{
+			"id":                identifier(),
+			"public_id":         {Type: TypeList{"string"}},
+			"email":             {Type: TypeList{"string"}, Format: "email"},
+			"username":          {Type: TypeList{"string"}, MinLength: intPtr(1)},
+			"previous_email":    {Type: TypeList{"string"}, Format: "email"},
+			"previous_username": {Type: TypeList{"string"}, MinLength: intPtr(1)},
+		},
+		Required: []string{"id", "public_id", "email", "username"},
+	}
+}
+
+// postSchema describes models.PostEventPayload
+func postSchema(title string) *Schema {
+	return &Schema{
+		Title: title,
+		Type:  TypeList{"object"},
+		Properties: map[string]*Schema{
+			"id":           identifier(),
+			"public_id":    {Type: TypeList{"string"}},
+			"slug":         {Type: TypeList{"string"}, MinLength: intPtr(1)},
+			"title":        {Type: TypeList{"string"}},
+			"author_id":    identifier(),
+			"status":       {Type: TypeList{"string"}},
+			"published_at": {Type: TypeList{"string", "null"}, Format: "date-time"},
+		},
+		Required: []string{"id", "public_id", "slug", "title", "author_id", "status"},
+	}
+}
+
+// identifier is a database ID
+func identifier() *Schema {
+	minimum := 1.0
+	return &Schema{Type: TypeList{"integer"}, Minimum: &minimum}
+}
+
+func intPtr(n int) *int {
+	return &n
+}
@@ -0,0 +1,98 @@
+package eventschema
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"go-server/internal/database/models"
+)
+
+func TestSchema_Validate(t *testing.T) {
+	schema := postSchema("Post published")
+	additional := false
+	schema.AdditionalProperties = &additional
+
+	valid := `{"id":1,"public_id":"p1","slug":"hello","title":"Hello","author_id":2,"status":"published","published_at":"2026-01-02T15:04:05Z"}`
+	var value interface{}
+	json.Unmarshal([]byte(valid), &value)
+	if problems := schema.Validate(value); len(problems) != 0 {
+		t.Errorf("Expected a valid payload, got %v", problems)
+	}
+
+	tests := []struct {
+		name    string
+		payload string
+		problem string
+	}{
+		{"missing field", `{"id":1,"public_id":"p1","slug":"hello","title":"Hello","status":"draft"}`, `missing required property "author_id"`},
+		{"wrong type", `{"id":"1","public_id":"p1","slug":"hello","title":"Hello","author_id":2,"status":"draft"}`, "$.id: expected integer, got string"},
+		{"fractional id", `{"id":1.5,"public_id":"p1","slug":"hello","title":"Hello","author_id":2,"status":"draft"}`, "$.id: expected integer, got number"},
+		{"bad format", `{"id":1,"public_id":"p1","slug":"hello","title":"Hello","author_id":2,"status":"draft","published_at":"yesterday"}`, "$.published_at: must be an RFC 3339 date-time"},
+		{"below minimum", `{"id":0,"public_id":"p1","slug":"hello","title":"Hello","author_id":2,"status":"draft"}`, "$.id: must be at least 1"},
+		{"unexpected property", `{"id":1,"public_id":"p1","slug":"hello","title":"Hello","author_id":2,"status":"draft","body":"x"}`, `unexpected property "body"`},
+	}
+	for _, tt := range tests {
+		var value interface{}
+		json.Unmarshal([]byte(tt.payload), &value)
+		problems := schema.Validate(value)
+		if len(problems) == 0 || !strings.Contains(strings.Join(problems, "\n"), tt.problem) {
+			t.Errorf("%s: expected %q, got %v", tt.name, tt.problem, problems)
+		}
+	}
+
+	// published_at is nullable
+	json.Unmarshal([]byte(`{"id":1,"public_id":"p1","slug":"hello","title":"Hello","author_id":2,"status":"draft","published_at":null}`), &value)
+	if problems := schema.Validate(value); len(problems) != 0 {
+		t.Errorf("Expected null to be accepted, got %v", problems)
+	}
+}
+
+func TestRegistry_Versions(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister("order.placed", 1, &Schema{Type: TypeList{"object"}, Required: []string{"id"}})
+	if err := r.Register("order.placed", 3, &Schema{}); err == nil {
+		t.Error("Expected a skipped version to be rejected")
+	}
+	r.MustRegister("order.placed", 2, &Schema{Type: TypeList{"object"}, Required: []string{"id", "total"}})
+
+	if _, err := r.Validate("order.placed", []byte(`{"id":1}`)); !errors.Is(err, ErrInvalidPayload) {
+		t.Errorf("Expected payloads to be checked against the latest version, got %v", err)
+	}
+	version, err := r.Validate("order.placed", []byte(`{"id":1,"total":5}`))
+	if err != nil || version != 2 {
+		t.Errorf("Expected version 2, got %d, %v", version, err)
+	}
+	if entry, err := r.Version("order.placed", 1); err != nil || entry.Schema.ID != "urn:go-server:events:order.placed:v1" {
+		t.Errorf("Expected version 1 to stay available, got %+v, %v", entry, err)
+	}
+	if _, err := r.Validate("order.cancelled", []byte(`{}`)); !errors.Is(err, ErrUnknownEvent) {
+		t.Errorf("Expected ErrUnknownEvent, got %v", err)
+	}
+}
+
+func TestDefault_CoversOutboxEvents(t *testing.T) {
+	published := "2026-01-02T15:04:05Z"
+	payloads := map[string]interface{}{
+		models.EventUserRegistered:  map[string]interface{}{"id": 1, "public_id": "u1", "email": "a@example.com", "username": "alice"},
+		models.EventUserUpdated:     models.UserEventPayload{ID: 1, PublicID: "u1", Email: "b@example.com", Username: "alice", PreviousEmail: "a@example.com"},
+		models.EventUserDeleted:     models.UserEventPayload{ID: 1, PublicID: "u1", Email: "a@example.com", Username: "alice"},
+		models.EventUserMerged:      models.UserMergedPayload{SourceID: 2, SourcePublicID: "u2", TargetID: 1, TargetPublicID: "u1"},
+		models.EventUserDeactivated: models.UserEventPayload{ID: 1, PublicID: "u1", Email: "a@example.com", Username: "alice"},
+		models.EventUserReactivated: models.UserEventPayload{ID: 1, PublicID: "u1", Email: "a@example.com", Username: "alice"},
+		models.EventPostPublished:   map[string]interface{}{"id": 1, "public_id": "p1", "slug": "s", "title": "t", "author_id": 1, "status": "published", "published_at": published},
+		models.EventPostUpdated:     models.PostEventPayload{ID: 1, PublicID: "p1", Slug: "s", Title: "t", AuthorID: 1, Status: "draft"},
+		models.EventPostDeleted:     models.PostEventPayload{ID: 1, PublicID: "p1", Slug: "s", Title: "t", AuthorID: 1},
+		models.EventLoginFailed:     models.LoginFailedPayload{UserID: 1, PublicID: "u1", Email: "a@example.com", Reason: models.LoginFailedPassword},
+	}
+	for eventType, payload := range payloads {
+		data, _ := json.Marshal(payload)
+		if _, err := Default().Validate(eventType, data); err != nil {
+			t.Errorf("%s: %v", eventType, err)
+		}
+	}
+	if len(Default().EventTypes()) != len(payloads) {
+		t.Errorf("Expected a schema per outbox event, got %v", Default().EventTypes())
+	}
+}
@@ -0,0 +1,143 @@
+package eventschema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrUnknownEvent is returned for event types without a schema
+	ErrUnknownEvent = errors.New("event type has no registered schema")
+	// ErrInvalidPayload is wrapped by every ValidationError
+	ErrInvalidPayload = errors.New("event payload does not match its schema")
+)
+
+// ValidationError lists why a payload does not match its schema
+type ValidationError struct {
+	EventType string
+	Version   int
+	Problems  []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s v%d payload is invalid: %s", e.EventType, e.Version, strings.Join(e.Problems, "; "))
+}
+
+// Unwrap lets callers match ErrInvalidPayload
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidPayload
+}
+
+// Entry is one version of an event type's schema
+type Entry struct {
+	EventType string  `json:"event_type"`
+	Version   int     `json:"version"`
+	Schema    *Schema `json:"schema"`
+}
+
+// Registry holds the schema versions of each event type. Versions only
+// grow: a payload change that breaks consumers registers a new version,
+// and new events are validated against the latest one.
+type Registry struct {
+	mutex    sync.RWMutex
+	versions map[string][]Entry
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{versions: make(map[string][]Entry)}
+}
+
+// Register adds a version of an event type's schema. Versions start at 1
+// and must be registered in order.
+func (r *Registry) Register(eventType string, version int, schema *Schema) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing := r.versions[eventType]
+	if version != len(existing)+1 {
+		return fmt.Errorf("%s: expected schema version %d, got %d", eventType, len(existing)+1, version)
+	}
+	if schema.Dialect == "" {
+		schema.Dialect = Draft
+	}
+	if schema.ID == "" {
+		schema.ID = fmt.Sprintf("urn:go-server:events:%s:v%d", eventType, version)
+	}
+	r.versions[eventType] = append(existing, Entry{EventType: eventType, Version: version, Schema: schema})
+	return nil
+}
+
+// MustRegister is Register for schemas defined in code, panicking on a
+// misnumbered version
+func (r *Registry) MustRegister(eventType string, version int, schema *Schema) {
+	if err := r.Register(eventType, version, schema); err != nil {
+		panic(err)
+	}
+}
+
+// Latest returns the current schema of an event type
+func (r *Registry) Latest(eventType string) (Entry, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	versions := r.versions[eventType]
+	if len(versions) == 0 {
+		return Entry{}, fmt.Errorf("%w: %s", ErrUnknownEvent, eventType)
+	}
+	return versions[len(versions)-1], nil
+}
+
+// Version returns a specific version of an event type's schema
+func (r *Registry) Version(eventType string, version int) (Entry, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	versions := r.versions[eventType]
+	if len(versions) == 0 {
+		return Entry{}, fmt.Errorf("%w: %s", ErrUnknownEvent, eventType)
+	}
+	if version < 1 || version > len(versions) {
+		return Entry{}, fmt.Errorf("%w: %s v%d", ErrUnknownEvent, eventType, version)
+	}
+	return versions[version-1], nil
+}
+
+// Versions returns every version of an event type's schema, oldest first
+func (r *Registry) Versions(eventType string) []Entry {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return append([]Entry(nil), r.versions[eventType]...)
+}
+
+// EventTypes returns the registered event types in order
+func (r *Registry) EventTypes() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	types := make([]string, 0, len(r.versions))
+	for eventType := range r.versions {
+		types = append(types, eventType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Validate checks a JSON payload against the latest schema of its event
+// type and returns the version it conforms to
+func (r *Registry) Validate(eventType string, payload []byte) (int, error) {
+	entry, err := r.Latest(eventType)
+	if err != nil {
+		return 0, err
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(payload, &value); err != nil {
+		return 0, &ValidationError{EventType: eventType, Version: entry.Version, Problems: []string{"payload is not valid JSON"}}
+	}
+	if problems := entry.Schema.Validate(value); len(problems) > 0 {
+		return 0, &ValidationError{EventType: eventType, Version: entry.Version, Problems: problems}
+	}
+	return entry.Version, nil
+}
@@ -0,0 +1,173 @@
+// Package geoip resolves client IPs to their country and autonomous system
+// from a range database, and tags requests with the result so rate limits
+// and abuse handling can take the network of origin into account.
+package geoip
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go-server/internal/clientip"
+)
+
+// Location is where a client IP is registered
+type Location struct {
+	// Country is the ISO 3166-1 alpha-2 code, upper case
+	Country string `json:"country,omitempty"`
+	// ASN is the autonomous system number, zero if unknown
+	ASN uint32 `json:"asn,omitempty"`
+	// Org is the autonomous system's organisation, e.g. a hosting provider
+	Org string `json:"org,omitempty"`
+}
+
+// Resolver looks up the location of an IP
+type Resolver interface {
+	Lookup(addr netip.Addr) (Location, bool)
+}
+
+// IPFunc returns the IP of the client making a request. A nil IPFunc uses
+// clientip.Get, which only believes the forwarding headers of trusted
+// proxies; a function trusting any client's headers would let it pick its
+// location, and with it its rate limit.
+type IPFunc func(r *http.Request) string
+
+// ipRange is a block of addresses registered to one location
+type ipRange struct {
+	start    netip.Addr
+	end      netip.Addr
+	location Location
+}
+
+// Database resolves IPs from address ranges held in memory
+type Database struct {
+	ranges []ipRange
+}
+
+// Open loads a range database from a CSV file, see Load
+func Open(path string) (*Database, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Load(file)
+}
+
+// Load reads a range database written as CSV lines of
+// "start_ip,end_ip,country,asn,org", as exported by the common free
+// IP-to-ASN datasets. Blank lines and lines starting with # are skipped,
+// and the organisation may contain commas.
+func Load(r io.Reader) (*Database, error) {
+	db := &Database{}
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.SplitN(text, ",", 5)
+		if len(fields) < 4 {
+			return nil, fmt.Errorf("geoip line %d: expected start_ip,end_ip,country,asn[,org]", line)
+		}
+		start, err := netip.ParseAddr(strings.TrimSpace(fields[0]))
+		if err != nil {
+			return nil, fmt.Errorf("geoip line %d: %w", line, err)
+		}
+		end, err := netip.ParseAddr(strings.TrimSpace(fields[1]))
+		if err != nil {
+			return nil, fmt.Errorf("geoip line %d: %w", line, err)
+		}
+		start, end = start.Unmap(), end.Unmap()
+		if start.Is4() != end.Is4() || end.Less(start) {
+			return nil, fmt.Errorf("geoip line %d: invalid range %s-%s", line, start, end)
+		}
+		location := Location{Country: strings.ToUpper(strings.TrimSpace(fields[2]))}
+		if asn := strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(fields[3])), "AS"); asn != "" && asn != "0" {
+			n, err := strconv.ParseUint(asn, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("geoip line %d: invalid ASN %q", line, fields[3])
+			}
+			location.ASN = uint32(n)
+		}
+		if len(fields) == 5 {
+			location.Org = strings.Trim(strings.TrimSpace(fields[4]), `"`)
+		}
+		db.ranges = append(db.ranges, ipRange{start: start, end: end, location: location})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(db.ranges, func(i, j int) bool { return db.ranges[i].start.Less(db.ranges[j].start) })
+	return db, nil
+}
+
+// Len returns the number of ranges loaded
+func (db *Database) Len() int {
+	return len(db.ranges)
+}
+
+// Lookup implements Resolver
+func (db *Database) Lookup(addr netip.Addr) (Location, bool) {
+	addr = addr.Unmap()
+	// The last range starting at or before addr is the only one that can hold it
+	i := sort.Search(len(db.ranges), func(i int) bool { return addr.Less(db.ranges[i].start) }) - 1
+	if i < 0 || db.ranges[i].end.Less(addr) || db.ranges[i].start.Is4() != addr.Is4() {
+		return Location{}, false
+	}
+	return db.ranges[i].location, true
+}
+
+// locationKey is the context key for a request's Location
+type locationKey struct{}
+
+// Middleware looks up the client IP of each request and stores its
+// location for FromContext
+func Middleware(resolver Resolver, clientIP IPFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if location, ok := lookupRequest(resolver, clientIP, r); ok {
+				r = r.WithContext(context.WithValue(r.Context(), locationKey{}, location))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// FromContext returns the location stored by Middleware
+func FromContext(ctx context.Context) (Location, bool) {
+	location, ok := ctx.Value(locationKey{}).(Location)
+	return location, ok
+}
+
+// RequestLocation returns the location stored by Middleware, or looks it
+// up if the request did not pass through it
+func RequestLocation(resolver Resolver, clientIP IPFunc, r *http.Request) (Location, bool) {
+	if location, ok := FromContext(r.Context()); ok {
+		return location, true
+	}
+	return lookupRequest(resolver, clientIP, r)
+}
+
+func lookupRequest(resolver Resolver, clientIP IPFunc, r *http.Request) (Location, bool) {
+	if resolver == nil {
+		return Location{}, false
+	}
+	if clientIP == nil {
+		clientIP = clientip.Get
+	}
+	addr, err := netip.ParseAddr(clientIP(r))
+	if err != nil {
+		return Location{}, false
+	}
+	return resolver.Lookup(addr)
+}
@@ -0,0 +1,181 @@
+package geoip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+const testDatabase = `# start_ip,end_ip,country,asn,org
+3.0.0.0,3.255.255.255,US,16509,"Amazon.com, Inc."
+81.2.69.0,81.2.69.255,GB,AS20712,Andrews & Arnold Ltd
+203.0.113.0,203.0.113.255,NL,0,
+2a05:d000::,2a05:dfff:ffff:ffff:ffff:ffff:ffff:ffff,IE,16509,Amazon
+`
+
+func loadTestDatabase(t *testing.T) *Database {
+	t.Helper()
+	db, err := Load(strings.NewReader(testDatabase))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	return db
+}
+
+func TestDatabase_Lookup(t *testing.T) {
+	db := loadTestDatabase(t)
+	if db.Len() != 4 {
+		t.Fatalf("Loaded %d ranges, want 4", db.Len())
+	}
+
+	tests := []struct {
+		ip    string
+		want  Location
+		found bool
+	}{
+		{"3.120.1.9", Location{Country: "US", ASN: 16509, Org: "Amazon.com, Inc."}, true},
+		{"81.2.69.160", Location{Country: "GB", ASN: 20712, Org: "Andrews & Arnold Ltd"}, true},
+		{"::ffff:81.2.69.1", Location{Country: "GB", ASN: 20712, Org: "Andrews & Arnold Ltd"}, true},
+		{"203.0.113.255", Location{Country: "NL"}, true},
+		{"2a05:d018::1", Location{Country: "IE", ASN: 16509, Org: "Amazon"}, true},
+		{"81.2.70.1", Location{}, false},
+		{"1.1.1.1", Location{}, false},
+		{"2001:db8::1", Location{}, false},
+	}
+	for _, tt := range tests {
+		got, found := db.Lookup(netip.MustParseAddr(tt.ip))
+		if found != tt.found || got != tt.want {
+			t.Errorf("Lookup(%s) = %+v, %v, want %+v, %v", tt.ip, got, found, tt.want, tt.found)
+		}
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	for _, data := range []string{
+		"3.0.0.0,3.255.255.255,US",
+		"3.0.0.0,nope,US,16509",
+		"3.255.255.255,3.0.0.0,US,16509",
+		"3.0.0.0,2a05:d000::,US,16509",
+		"3.0.0.0,3.255.255.255,US,AS-X",
+	} {
+		if _, err := Load(strings.NewReader(data)); err == nil {
+			t.Errorf("Load(%q) should fail", data)
+		}
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules(" asn:16509=0.25, AS:x=1,")
+	if err == nil {
+		t.Errorf("Unknown rule kind should fail, got %+v", rules)
+	}
+
+	rules, err = ParseRules("asn:AS16509=0.25, country:nl=2,,")
+	if err != nil {
+		t.Fatalf("ParseRules failed: %v", err)
+	}
+	if len(rules) != 2 || rules[0] != (Rule{ASN: 16509, Multiplier: 0.25}) || rules[1] != (Rule{Country: "NL", Multiplier: 2}) {
+		t.Errorf("Unexpected rules %+v", rules)
+	}
+
+	for _, spec := range []string{"asn:16509", "asn:0=1", "country:NLD=1", "country:NL=0", "country:NL=-1", "asn=1"} {
+		if _, err := ParseRules(spec); err == nil {
+			t.Errorf("ParseRules(%q) should fail", spec)
+		}
+	}
+}
+
+func request(ip string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/api/posts", nil)
+	r.RemoteAddr = ip + ":40000"
+	return r
+}
+
+func remoteIP(r *http.Request) string {
+	return strings.TrimSuffix(r.RemoteAddr, ":40000")
+}
+
+func TestMultipliers(t *testing.T) {
+	rules, _ := ParseRules("country:IE=0.5,asn:16509=0.25,country:GB=2")
+	multipliers := NewMultipliers(loadTestDatabase(t), remoteIP, rules)
+
+	tests := map[string]float64{
+		"2a05:d018::1": 0.25, // ASN rules take precedence over the country
+		"3.1.2.3":      0.25,
+		"81.2.69.7":    2,
+		"203.0.113.5":  1,
+		"1.1.1.1":      1,
+	}
+	for ip, want := range tests {
+		if got := multipliers.Multiplier(request(ip)); got != want {
+			t.Errorf("Multiplier(%s) = %v, want %v", ip, got, want)
+		}
+	}
+
+	stats := multipliers.Stats()
+	if stats.Matches["asn:16509"] != 2 || stats.Matches["country:GB"] != 1 || stats.Matches["country:IE"] != 0 {
+		t.Errorf("Unexpected matches %+v", stats.Matches)
+	}
+	if len(stats.Rules) != 3 || stats.Rules["country:IE"] != 0.5 {
+		t.Errorf("Unexpected rules %+v", stats.Rules)
+	}
+
+	if err := multipliers.Reconfigure("country:GB=bad"); err == nil {
+		t.Fatal("Reconfigure with an invalid rule should fail")
+	}
+	if got := multipliers.Multiplier(request("81.2.69.7")); got != 2 {
+		t.Errorf("A failed reconfigure should keep the rules, got %v", got)
+	}
+	if err := multipliers.Reconfigure("country:GB=0.1"); err != nil {
+		t.Fatalf("Reconfigure failed: %v", err)
+	}
+	if got := multipliers.Multiplier(request("81.2.69.7")); got != 0.1 {
+		t.Errorf("Reconfigured multiplier = %v, want 0.1", got)
+	}
+	if got := multipliers.Multiplier(request("3.1.2.3")); got != 1 {
+		t.Errorf("Removed rule should no longer apply, got %v", got)
+	}
+}
+
+func TestMultipliers_TrustedClientIPByDefault(t *testing.T) {
+	rules, _ := ParseRules("country:GB=2,country:NL=0.5")
+	multipliers := NewMultipliers(loadTestDatabase(t), nil, rules)
+
+	// A client cannot claim another network's limit with its own headers
+	r := request("81.2.69.7")
+	r.Header.Set("X-Forwarded-For", "203.0.113.5")
+	r.Header.Set("X-Real-IP", "203.0.113.5")
+	if got := multipliers.Multiplier(r); got != 2 {
+		t.Errorf("Multiplier with forged forwarding headers = %v, want the peer's 2", got)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var location Location
+	var found bool
+	handler := Middleware(loadTestDatabase(t), remoteIP)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		location, found = FromContext(r.Context())
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), request("81.2.69.7"))
+	if !found || location.Country != "GB" || location.ASN != 20712 {
+		t.Errorf("Expected the GB location in the context, got %+v, %v", location, found)
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), request("1.1.1.1"))
+	if found {
+		t.Errorf("Unknown IP should have no location, got %+v", location)
+	}
+
+	// Multipliers reuse the location found by the middleware
+	rules, _ := ParseRules("country:GB=0.5")
+	multipliers := NewMultipliers(nil, nil, rules)
+	handler = Middleware(loadTestDatabase(t), remoteIP)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := multipliers.Multiplier(r); got != 0.5 {
+			t.Errorf("Multiplier from the context = %v, want 0.5", got)
+		}
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), request("81.2.69.7"))
+}
@@ -0,0 +1,179 @@
+package geoip
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Rule scales the rate limits of clients from one ASN or country. A
+// multiplier below 1 is stricter, e.g. for hosting providers that source
+// most abuse; above 1 is more lenient.
+type Rule struct {
+	// ASN matches clients in the autonomous system; zero matches by Country
+	ASN        uint32
+	Country    string
+	Multiplier float64
+}
+
+// String returns the rule's match, e.g. asn:16509 or country:NL
+func (r Rule) String() string {
+	if r.ASN != 0 {
+		return fmt.Sprintf("asn:%d", r.ASN)
+	}
+	return "country:" + r.Country
+}
+
+// ParseRules parses rules written as "asn:N=multiplier" or
+// "country:CC=multiplier" separated by commas, e.g.
+// "asn:16509=0.25,asn:14061=0.25,country:ZZ=0.5"
+func ParseRules(spec string) ([]Rule, error) {
+	var rules []Rule
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		match, value, ok := strings.Cut(entry, "=")
+		kind, target, okKind := strings.Cut(strings.TrimSpace(match), ":")
+		if !ok || !okKind {
+			return nil, fmt.Errorf("invalid rate limit multiplier %q", entry)
+		}
+		multiplier, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || multiplier <= 0 {
+			return nil, fmt.Errorf("rate limit multiplier %q must be a positive number", entry)
+		}
+
+		rule := Rule{Multiplier: multiplier}
+		target = strings.TrimSpace(target)
+		switch strings.ToLower(strings.TrimSpace(kind)) {
+		case "asn":
+			asn, err := strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(target), "AS"), 10, 32)
+			if err != nil || asn == 0 {
+				return nil, fmt.Errorf("invalid ASN in rate limit multiplier %q", entry)
+			}
+			rule.ASN = uint32(asn)
+		case "country":
+			if len(target) != 2 {
+				return nil, fmt.Errorf("invalid country code in rate limit multiplier %q", entry)
+			}
+			rule.Country = strings.ToUpper(target)
+		default:
+			return nil, fmt.Errorf("invalid rate limit multiplier %q", entry)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// MultiplierStats is a snapshot of the rules and how often each matched
+type MultiplierStats struct {
+	Rules   map[string]float64 `json:"rules"`
+	Matches map[string]int64   `json:"matches"`
+}
+
+// Multipliers picks the rate limit multiplier for each request from its
+// location. ASN rules take precedence over country rules. It implements
+// security.LimitMultiplier.
+type Multipliers struct {
+	resolver Resolver
+	clientIP IPFunc
+
+	mutex   sync.RWMutex
+	rules   []Rule
+	matches map[string]int64
+}
+
+// NewMultipliers creates multipliers resolving the locations of clients
+// that did not pass through Middleware with resolver; a nil clientIP uses
+// clientip.Get
+func NewMultipliers(resolver Resolver, clientIP IPFunc, rules []Rule) *Multipliers {
+	return &Multipliers{
+		resolver: resolver,
+		clientIP: clientIP,
+		rules:    rules,
+		matches:  make(map[string]int64),
+	}
+}
+
+// SetRules replaces the rules. Match counts of rules that remain are kept.
+func (m *Multipliers) SetRules(rules []Rule) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.rules = rules
+}
+
+// Reconfigure replaces the rules with those parsed from spec, e.g. a
+// reloaded RATE_LIMIT_MULTIPLIERS; invalid rules leave the current ones
+func (m *Multipliers) Reconfigure(spec string) error {
+	rules, err := ParseRules(spec)
+	if err != nil {
+		return err
+	}
+	m.SetRules(rules)
+	return nil
+}
+
+// Multiplier implements security.LimitMultiplier, returning 1 for clients
+// no rule matches
+func (m *Multipliers) Multiplier(r *http.Request) float64 {
+	m.mutex.RLock()
+	empty := len(m.rules) == 0
+	m.mutex.RUnlock()
+	if empty {
+		return 1
+	}
+
+	location, ok := RequestLocation(m.resolver, m.clientIP, r)
+	if !ok {
+		return 1
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	rule, ok := m.match(location)
+	if !ok {
+		return 1
+	}
+	m.matches[rule.String()]++
+	return rule.Multiplier
+}
+
+// match returns the rule for location; the caller holds the mutex
+func (m *Multipliers) match(location Location) (Rule, bool) {
+	if location.ASN != 0 {
+		for _, rule := range m.rules {
+			if rule.ASN == location.ASN {
+				return rule, true
+			}
+		}
+	}
+	if location.Country != "" {
+		for _, rule := range m.rules {
+			if rule.ASN == 0 && rule.Country == location.Country {
+				return rule, true
+			}
+		}
+	}
+	return Rule{}, false
+}
+
+// Stats returns the rules in force and their match counts
+func (m *Multipliers) Stats() MultiplierStats {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	stats := MultiplierStats{
+		Rules:   make(map[string]float64, len(m.rules)),
+		Matches: make(map[string]int64, len(m.matches)),
+	}
+	for _, rule := range m.rules {
+		stats.Rules[rule.String()] = rule.Multiplier
+	}
+	for match, count := range m.matches {
+		stats.Matches[match] = count
+	}
+	return stats
+}
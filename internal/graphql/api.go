@@ -0,0 +1,294 @@
+package graphql
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"sync"
+
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/middleware"
+	"go-server/internal/rbac"
+
+	"gorm.io/gorm"
+)
+
+// maxPageSize caps list arguments, matching the REST pagination limit
+const maxPageSize = 100
+
+var errForbidden = fmt.Errorf("forbidden")
+
+// API builds the application schema over the user and post repositories
+type API struct {
+	users *repositories.UserRepository
+	posts *repositories.PostRepository
+}
+
+// NewAPI creates a new API
+func NewAPI(users *repositories.UserRepository, posts *repositories.PostRepository) *API {
+	return &API{users: users, posts: posts}
+}
+
+// loaders are the request-scoped batch loaders
+type loaders struct {
+	api   *API
+	users *Loader[uint, *models.User]
+
+	mu            sync.Mutex
+	postsByAuthor map[int]*Loader[uint, []models.Post]
+}
+
+type loadersKey struct{}
+
+// WithLoaders attaches fresh loaders to a request context; every request
+// must get its own so cached rows never leak between viewers
+func (a *API) WithLoaders(ctx context.Context) context.Context {
+	l := &loaders{
+		api:           a,
+		postsByAuthor: make(map[int]*Loader[uint, []models.Post]),
+	}
+	l.users = NewLoader(func(ctx context.Context, ids []uint) (map[uint]*models.User, error) {
+		users, err := a.users.GetUsersByIDs(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		byID := make(map[uint]*models.User, len(users))
+		for i := range users {
+			byID[users[i].ID] = &users[i]
+		}
+		return byID, nil
+	})
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	l, ok := ctx.Value(loadersKey{}).(*loaders)
+	if !ok {
+		panic("graphql: request context has no loaders")
+	}
+	return l
+}
+
+// postsByAuthorLoader returns the loader for an author's latest posts; one
+// loader exists per page size since the batch query applies a single limit
+func (l *loaders) postsByAuthorLoader(limit int) *Loader[uint, []models.Post] {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if loader, ok := l.postsByAuthor[limit]; ok {
+		return loader
+	}
+	loader := NewLoader(func(ctx context.Context, authorIDs []uint) (map[uint][]models.Post, error) {
+		posts, err := l.api.posts.ListPublishedPostsByAuthors(ctx, authorIDs, limit)
+		if err != nil {
+			return nil, err
+		}
+		byAuthor := make(map[uint][]models.Post, len(authorIDs))
+		for _, post := range posts {
+			byAuthor[post.AuthorID] = append(byAuthor[post.AuthorID], post)
+		}
+		return byAuthor, nil
+	})
+	l.postsByAuthor[limit] = loader
+	return loader
+}
+
+// canSeePost reports whether the viewer may read a post: published posts are
+// public, drafts and archived posts are visible to their author and moderators
+func canSeePost(ctx context.Context, post *models.Post) bool {
+	if post.IsPublished() || middleware.HasPermissionFromContext(ctx, rbac.PostsModerate) {
+		return true
+	}
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	return ok && userID == post.AuthorID
+}
+
+func pageArgs(args map[string]any) (offset, limit int) {
+	limit, _ = args["limit"].(int)
+	offset, _ = args["offset"].(int)
+	if limit <= 0 || limit > maxPageSize {
+		limit = maxPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return offset, limit
+}
+
+// notFound turns a missing row into a null field rather than an error
+func notFound[T any](value *T, err error) (any, error) {
+	if stderrors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Schema builds the query schema
+func (a *API) Schema() *Schema {
+	user := &Object{Name: "User"}
+	post := &Object{Name: "Post"}
+
+	user.Fields = map[string]*FieldDefinition{
+		"id": {Type: &NonNull{Of: ID}, Resolve: func(p ResolveParams) (any, error) {
+			return p.Source.(*models.User).PublicID, nil
+		}},
+		"username": {Type: &NonNull{Of: String}, Resolve: func(p ResolveParams) (any, error) {
+			return p.Source.(*models.User).Username, nil
+		}},
+		"firstName": {Type: String, Resolve: func(p ResolveParams) (any, error) {
+			return p.Source.(*models.User).FirstName, nil
+		}},
+		"lastName": {Type: String, Resolve: func(p ResolveParams) (any, error) {
+			return p.Source.(*models.User).LastName, nil
+		}},
+		// email is only visible to the user themselves and to staff who can read users
+		"email": {Type: String, Resolve: func(p ResolveParams) (any, error) {
+			u := p.Source.(*models.User)
+			viewerID, ok := middleware.GetUserIDFromContext(p.Context)
+			if (ok && viewerID == u.ID) || middleware.HasPermissionFromContext(p.Context, rbac.UsersRead) {
+				return u.Email, nil
+			}
+			return nil, nil
+		}},
+		"createdAt": {Type: &NonNull{Of: DateTime}, Resolve: func(p ResolveParams) (any, error) {
+			return p.Source.(*models.User).CreatedAt, nil
+		}},
+		"posts": {
+			Type: &NonNull{Of: &List{Of: &NonNull{Of: post}}},
+			Args: map[string]*Argument{"limit": {Type: Int, Default: 10}},
+			Resolve: func(p ResolveParams) (any, error) {
+				_, limit := pageArgs(p.Args)
+				thunk := loadersFrom(p.Context).postsByAuthorLoader(limit).Load(p.Context, p.Source.(*models.User).ID)
+				return Thunk(func() (any, error) {
+					value, err := thunk()
+					if err != nil {
+						return nil, err
+					}
+					posts, _ := value.([]models.Post)
+					refs := make([]*models.Post, len(posts))
+					for i := range posts {
+						refs[i] = &posts[i]
+					}
+					return refs, nil
+				}), nil
+			},
+		},
+	}
+
+	post.Fields = map[string]*FieldDefinition{
+		"id": {Type: &NonNull{Of: ID}, Resolve: func(p ResolveParams) (any, error) {
+			return p.Source.(*models.Post).PublicID, nil
+		}},
+		"title": {Type: &NonNull{Of: String}, Resolve: func(p ResolveParams) (any, error) {
+			return p.Source.(*models.Post).Title, nil
+		}},
+		"slug": {Type: &NonNull{Of: String}, Resolve: func(p ResolveParams) (any, error) {
+			return p.Source.(*models.Post).Slug, nil
+		}},
+		"content": {Type: &NonNull{Of: String}, Resolve: func(p ResolveParams) (any, error) {
+			return p.Source.(*models.Post).Content, nil
+		}},
+		"excerpt": {Type: &NonNull{Of: String}, Resolve: func(p ResolveParams) (any, error) {
+			return p.Source.(*models.Post).GetExcerpt(), nil
+		}},
+		"status": {Type: &NonNull{Of: String}, Resolve: func(p ResolveParams) (any, error) {
+			return p.Source.(*models.Post).Status, nil
+		}},
+		"viewCount": {Type: &NonNull{Of: Int}, Resolve: func(p ResolveParams) (any, error) {
+			return p.Source.(*models.Post).ViewCount, nil
+		}},
+		"publishedAt": {Type: DateTime, Resolve: func(p ResolveParams) (any, error) {
+			return p.Source.(*models.Post).PublishedAt, nil
+		}},
+		"createdAt": {Type: &NonNull{Of: DateTime}, Resolve: func(p ResolveParams) (any, error) {
+			return p.Source.(*models.Post).CreatedAt, nil
+		}},
+		"author": {Type: user, Resolve: func(p ResolveParams) (any, error) {
+			source := p.Source.(*models.Post)
+			if source.Author.ID != 0 {
+				return &source.Author, nil
+			}
+			return loadersFrom(p.Context).users.Load(p.Context, source.AuthorID), nil
+		}},
+	}
+
+	query := &Object{Name: "Query", Fields: map[string]*FieldDefinition{
+		"me": {Type: user, Resolve: func(p ResolveParams) (any, error) {
+			viewer, ok := middleware.GetUserFromContext(p.Context)
+			if !ok {
+				return nil, nil
+			}
+			return viewer, nil
+		}},
+		// user and users require users:read, like their REST counterparts
+		"user": {
+			Type: user,
+			Args: map[string]*Argument{"id": {Type: &NonNull{Of: ID}}},
+			Resolve: func(p ResolveParams) (any, error) {
+				if !middleware.HasPermissionFromContext(p.Context, rbac.UsersRead) {
+					return nil, errForbidden
+				}
+				return notFound(a.users.GetUserByPublicID(p.Context, p.Args["id"].(string)))
+			},
+		},
+		"users": {
+			Type: &NonNull{Of: &List{Of: &NonNull{Of: user}}},
+			Args: map[string]*Argument{"limit": {Type: Int, Default: 20}, "offset": {Type: Int, Default: 0}},
+			Resolve: func(p ResolveParams) (any, error) {
+				if !middleware.HasPermissionFromContext(p.Context, rbac.UsersRead) {
+					return nil, errForbidden
+				}
+				offset, limit := pageArgs(p.Args)
+				users, err := a.users.ListUsers(p.Context, offset, limit)
+				if err != nil {
+					return nil, err
+				}
+				refs := make([]*models.User, len(users))
+				for i := range users {
+					refs[i] = &users[i]
+				}
+				return refs, nil
+			},
+		},
+		"post": {
+			Type: post,
+			Args: map[string]*Argument{"id": {Type: ID}, "slug": {Type: String}},
+			Resolve: func(p ResolveParams) (any, error) {
+				var found *models.Post
+				var err error
+				if id, ok := p.Args["id"].(string); ok {
+					found, err = a.posts.GetPostByPublicID(p.Context, id)
+				} else if slug, ok := p.Args["slug"].(string); ok {
+					found, err = a.posts.GetPostBySlug(p.Context, slug)
+				} else {
+					return nil, fmt.Errorf("post requires an id or slug argument")
+				}
+				if err == nil && !canSeePost(p.Context, found) {
+					return nil, nil
+				}
+				return notFound(found, err)
+			},
+		},
+		"posts": {
+			Type: &NonNull{Of: &List{Of: &NonNull{Of: post}}},
+			Args: map[string]*Argument{"limit": {Type: Int, Default: 20}, "offset": {Type: Int, Default: 0}},
+			Resolve: func(p ResolveParams) (any, error) {
+				offset, limit := pageArgs(p.Args)
+				posts, err := a.posts.ListPublishedPosts(p.Context, offset, limit)
+				if err != nil {
+					return nil, err
+				}
+				refs := make([]*models.Post, len(posts))
+				for i := range posts {
+					refs[i] = &posts[i]
+				}
+				return refs, nil
+			},
+		},
+	}}
+
+	return &Schema{Query: query}
+}
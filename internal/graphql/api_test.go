@@ -0,0 +1,142 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-server/internal/database/dbtest"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/logger"
+
+	"gorm.io/gorm"
+)
+
+func newTestAPI(t *testing.T) (*API, *gorm.DB, []*models.User) {
+	t.Helper()
+	db := dbtest.Open(t, &models.User{}, &models.Post{}, &models.OutboxEvent{})
+
+	var users []*models.User
+	published := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 1; i <= 3; i++ {
+		user := &models.User{Email: fmt.Sprintf("user%d@example.com", i), Username: fmt.Sprintf("user%d", i), Password: "hash"}
+		if err := db.Create(user).Error; err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		users = append(users, user)
+		for j := 1; j <= 3; j++ {
+			at := published.Add(time.Duration(j) * time.Hour)
+			post := &models.Post{
+				Title: fmt.Sprintf("post %d.%d", i, j), Slug: fmt.Sprintf("post-%d-%d", i, j), Content: "content",
+				Status: "published", AuthorID: user.ID, PublishedAt: &at,
+			}
+			if err := db.Create(post).Error; err != nil {
+				t.Fatalf("Failed to create post: %v", err)
+			}
+		}
+	}
+	draft := &models.Post{Title: "draft", Slug: "draft", Content: "content", Status: "draft", AuthorID: users[0].ID}
+	if err := db.Create(draft).Error; err != nil {
+		t.Fatalf("Failed to create post: %v", err)
+	}
+
+	return NewAPI(repositories.NewUserRepository(db), repositories.NewPostRepository(db)), db, users
+}
+
+func asUser(ctx context.Context, user *models.User) context.Context {
+	ctx = context.WithValue(ctx, "user", user)
+	ctx = context.WithValue(ctx, "user_id", user.ID)
+	return context.WithValue(ctx, "is_admin", user.IsAdmin)
+}
+
+func TestAPI_UserPostsAreBatched(t *testing.T) {
+	api, db, users := newTestAPI(t)
+	users[0].IsAdmin = true
+
+	queries := 0
+	db.Callback().Query().After("gorm:query").Register("test:count", func(tx *gorm.DB) {
+		// Subqueries are rendered through the callbacks in dry-run mode
+		if !tx.DryRun {
+			queries++
+		}
+	})
+
+	response := Execute(api.WithLoaders(asUser(context.Background(), users[0])), api.Schema(), Request{
+		Query: `{ users { username posts(limit: 2) { title author { username } } } }`,
+	})
+	if len(response.Errors) > 0 {
+		t.Fatalf("unexpected errors: %+v", response.Errors[0])
+	}
+
+	// One query lists users and one loads every user's posts; authors of the
+	// batched posts come from the user loader in a third
+	if queries != 3 {
+		t.Errorf("expected 3 queries, got %d", queries)
+	}
+	body, _ := json.Marshal(response)
+	want := `{"username":"user1","posts":[{"title":"post 1.3","author":{"username":"user1"}},{"title":"post 1.2","author":{"username":"user1"}}]}`
+	if !strings.Contains(string(body), want) {
+		t.Errorf("unexpected response: %s", body)
+	}
+}
+
+func TestAPI_Visibility(t *testing.T) {
+	api, _, users := newTestAPI(t)
+	schema := api.Schema()
+	query := Request{Query: `{ post(slug: "draft") { title } me { email } users { id } }`}
+
+	anonymous := Execute(api.WithLoaders(context.Background()), schema, query)
+	body, _ := json.Marshal(anonymous)
+	if !strings.Contains(string(body), `"post":null,"me":null,"users":null`) {
+		t.Errorf("anonymous viewer should not see drafts or users: %s", body)
+	}
+	if len(anonymous.Errors) != 1 || anonymous.Errors[0].Message != "forbidden" {
+		t.Errorf("expected forbidden error for users, got %+v", anonymous.Errors)
+	}
+
+	author := Execute(api.WithLoaders(asUser(context.Background(), users[0])), schema, query)
+	body, _ = json.Marshal(author)
+	if !strings.Contains(string(body), `"post":{"title":"draft"},"me":{"email":"user1@example.com"}`) {
+		t.Errorf("author should see their draft and email: %s", body)
+	}
+
+	other := Execute(api.WithLoaders(asUser(context.Background(), users[1])), schema, Request{
+		Query: `{ post(slug: "post-1-1") { author { username email } } }`,
+	})
+	body, _ = json.Marshal(other)
+	if !strings.Contains(string(body), `"author":{"username":"user1","email":null}`) {
+		t.Errorf("email should be hidden from other users: %s", body)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	api, _, _ := newTestAPI(t)
+	handler := NewHandler(api, logger.NewServerLogger())
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query":"query P($slug: String) { post(slug: $slug) { title } }","variables":{"slug":"post-2-1"}}`))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `{"data":{"post":{"title":"post 2.1"}}}`) {
+		t.Errorf("POST: got %d %s", rec.Code, rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/graphql?query="+`%7B%20posts(limit%3A%201)%20%7B%20title%20%7D%20%7D`, nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"posts":[{"title":"post`) {
+		t.Errorf("GET: got %d %s", rec.Code, rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query":"{ posts { nope } }"}`))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid query: got %d %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,544 @@
+// Package graphql implements a query-only GraphQL endpoint over the user and
+// post repositories. It supports operations, variables, aliases, fragments
+// and @skip/@include, resolves fields breadth first so Loader can batch
+// lookups, and leaves cost limits to validators such as security.GraphQLLimits.
+// Mutations, subscriptions and introspection are not supported.
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Request is a GraphQL request as sent over HTTP
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// Response is a GraphQL response; Data is absent when the request failed
+// before execution
+type Response struct {
+	Data   any      `json:"data,omitempty"`
+	Errors []*Error `json:"errors,omitempty"`
+}
+
+// Error is a GraphQL error with the path of the field that failed
+type Error struct {
+	Message   string     `json:"message"`
+	Locations []Location `json:"locations,omitempty"`
+	Path      []any      `json:"path,omitempty"`
+}
+
+// Location is a line and column in the query document
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Validator checks an operation before it executes, for example to enforce
+// depth and complexity limits
+type Validator func(schema *Schema, doc *Document, op *Operation, variables map[string]any) error
+
+type executionKey struct{}
+
+// execution holds the state of a single operation. Resolution is breadth
+// first: thunks returned by resolvers are deferred until every field of the
+// current level has been visited, then pending loaders dispatch their batches.
+type execution struct {
+	ctx       context.Context
+	schema    *Schema
+	fragments map[string]*Fragment
+	defined   map[string]bool
+	variables map[string]any
+	errors    []*Error
+	deferred  []func()
+	loaders   []dispatcher
+	queued    map[dispatcher]bool
+}
+
+// Execute parses, validates and executes a query
+func Execute(ctx context.Context, schema *Schema, req Request, validators ...Validator) *Response {
+	doc, err := Parse(req.Query)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	op, err := selectOperation(doc, req.OperationName)
+	if err != nil {
+		return errorResponse(err)
+	}
+	if errs := Validate(schema, doc, op); len(errs) > 0 {
+		return &Response{Errors: errs}
+	}
+	if err := coerceVariables(op, req.Variables); err != nil {
+		return errorResponse(err)
+	}
+	for _, validate := range validators {
+		if err := validate(schema, doc, op, req.Variables); err != nil {
+			return errorResponse(err)
+		}
+	}
+
+	e := &execution{
+		schema:    schema,
+		fragments: doc.Fragments,
+		defined:   make(map[string]bool),
+		variables: make(map[string]any),
+		queued:    make(map[dispatcher]bool),
+	}
+	for _, definition := range op.Variables {
+		e.defined[definition.Name] = true
+		if value, ok := req.Variables[definition.Name]; ok {
+			e.variables[definition.Name] = value
+		} else if definition.Default != nil {
+			e.variables[definition.Name], _ = e.valueToGo(definition.Default)
+		}
+	}
+	e.ctx = context.WithValue(ctx, executionKey{}, e)
+
+	data := newOrderedMap()
+	e.executeSelectionSet(schema.Query, nil, op.SelectionSet, data, nil)
+	e.run()
+
+	return &Response{Data: data, Errors: e.errors}
+}
+
+func errorResponse(err error) *Response {
+	gqlErr := &Error{Message: err.Error()}
+	if syntaxErr, ok := err.(*SyntaxError); ok {
+		gqlErr.Locations = []Location{{Line: syntaxErr.Line, Column: syntaxErr.Column}}
+	}
+	return &Response{Errors: []*Error{gqlErr}}
+}
+
+func selectOperation(doc *Document, name string) (*Operation, error) {
+	if name == "" {
+		if len(doc.Operations) > 1 {
+			return nil, fmt.Errorf("operationName is required when the document contains several operations")
+		}
+		return doc.Operations[0], nil
+	}
+	for _, op := range doc.Operations {
+		if op.Name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown operation %q", name)
+}
+
+// enqueue registers a loader with keys waiting for the next dispatch
+func (e *execution) enqueue(d dispatcher) {
+	if !e.queued[d] {
+		e.queued[d] = true
+		e.loaders = append(e.loaders, d)
+	}
+}
+
+// run resolves deferred fields level by level, dispatching each loader once per level
+func (e *execution) run() {
+	for len(e.deferred) > 0 {
+		loaders := e.loaders
+		e.loaders = nil
+		e.queued = make(map[dispatcher]bool)
+		for _, loader := range loaders {
+			loader.dispatch(e.ctx)
+		}
+
+		deferred := e.deferred
+		e.deferred = nil
+		for _, fn := range deferred {
+			fn()
+		}
+	}
+}
+
+func (e *execution) addError(err error, field *Field, path []any) {
+	e.errors = append(e.errors, &Error{
+		Message:   err.Error(),
+		Locations: []Location{{Line: field.Line, Column: field.Column}},
+		Path:      append([]any(nil), path...),
+	})
+}
+
+type fieldGroup struct {
+	key    string
+	fields []*Field
+}
+
+// collectFields flattens fragments and merges fields sharing a response key
+func (e *execution) collectFields(obj *Object, selections []Selection, groups []*fieldGroup, visited map[string]bool) []*fieldGroup {
+	for _, selection := range selections {
+		switch s := selection.(type) {
+		case *Field:
+			if !e.included(s.Directives) {
+				continue
+			}
+			merged := false
+			for _, group := range groups {
+				if group.key == s.ResponseKey() {
+					group.fields = append(group.fields, s)
+					merged = true
+					break
+				}
+			}
+			if !merged {
+				groups = append(groups, &fieldGroup{key: s.ResponseKey(), fields: []*Field{s}})
+			}
+		case *FragmentSpread:
+			fragment := e.fragments[s.Name]
+			if visited[s.Name] || fragment == nil || !e.included(s.Directives) || !e.included(fragment.Directives) {
+				continue
+			}
+			visited[s.Name] = true
+			if fragment.TypeCondition == obj.Name {
+				groups = e.collectFields(obj, fragment.SelectionSet, groups, visited)
+			}
+		case *InlineFragment:
+			if !e.included(s.Directives) || (s.TypeCondition != "" && s.TypeCondition != obj.Name) {
+				continue
+			}
+			groups = e.collectFields(obj, s.SelectionSet, groups, visited)
+		}
+	}
+	return groups
+}
+
+// included evaluates @skip and @include
+func (e *execution) included(directives []*Directive) bool {
+	for _, directive := range directives {
+		if directive.Name != "skip" && directive.Name != "include" {
+			continue
+		}
+		value, _ := e.valueToGo(directive.Arguments["if"])
+		flag, _ := value.(bool)
+		if directive.Name == "skip" && flag || directive.Name == "include" && !flag {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *execution) executeSelectionSet(obj *Object, source any, selections []Selection, out *orderedMap, path []any) {
+	for _, group := range e.collectFields(obj, selections, nil, make(map[string]bool)) {
+		key := group.key
+		out.set(key, nil)
+		e.resolveField(obj, source, group.fields, appendPath(path, key), func(value any) {
+			out.set(key, value)
+		})
+	}
+}
+
+func (e *execution) resolveField(obj *Object, source any, fields []*Field, path []any, set func(any)) {
+	field := fields[0]
+	if field.Name == "__typename" {
+		set(obj.Name)
+		return
+	}
+	definition := obj.Fields[field.Name]
+
+	args, err := e.coerceArguments(definition, field.Arguments)
+	if err != nil {
+		e.addError(err, field, path)
+		set(nil)
+		return
+	}
+
+	var value any
+	if definition.Resolve != nil {
+		value, err = safeResolve(definition.Resolve, ResolveParams{Context: e.ctx, Source: source, Args: args})
+	} else if m, ok := source.(map[string]any); ok {
+		value = m[field.Name]
+	}
+	if err != nil {
+		e.addError(err, field, path)
+		set(nil)
+		return
+	}
+	e.complete(definition.Type, fields, value, path, set)
+}
+
+// safeResolve turns resolver panics into field errors
+func safeResolve(resolve ResolveFunc, params ResolveParams) (value any, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			value, err = nil, fmt.Errorf("internal error")
+		}
+	}()
+	return resolve(params)
+}
+
+// complete shapes a resolved value according to its type. A null in a
+// non-null position is reported as an error and left null rather than
+// propagated to the parent.
+func (e *execution) complete(t Type, fields []*Field, value any, path []any, set func(any)) {
+	if thunk, ok := value.(Thunk); ok {
+		e.deferred = append(e.deferred, func() {
+			resolved, err := thunk()
+			if err != nil {
+				e.addError(err, fields[0], path)
+				set(nil)
+				return
+			}
+			e.complete(t, fields, resolved, path, set)
+		})
+		return
+	}
+
+	if nonNull, ok := t.(*NonNull); ok {
+		if isNull(value) {
+			e.addError(fmt.Errorf("cannot return null for non-nullable field"), fields[0], path)
+			set(nil)
+			return
+		}
+		t = nonNull.Of
+	}
+	if isNull(value) {
+		set(nil)
+		return
+	}
+
+	switch typ := t.(type) {
+	case *List:
+		rv := reflect.ValueOf(value)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			e.addError(fmt.Errorf("expected a list, got %T", value), fields[0], path)
+			set(nil)
+			return
+		}
+		items := make([]any, rv.Len())
+		set(items)
+		for i := range items {
+			index := i
+			e.complete(typ.Of, fields, rv.Index(i).Interface(), appendPath(path, i), func(item any) {
+				items[index] = item
+			})
+		}
+	case *Scalar:
+		serialized, err := typ.Serialize(value)
+		if err != nil {
+			e.addError(err, fields[0], path)
+		}
+		set(serialized)
+	case *Object:
+		out := newOrderedMap()
+		set(out)
+		var selections []Selection
+		for _, field := range fields {
+			selections = append(selections, field.SelectionSet...)
+		}
+		e.executeSelectionSet(typ, value, selections, out, path)
+	}
+}
+
+func isNull(value any) bool {
+	if value == nil {
+		return true
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Interface, reflect.Func:
+		return rv.IsNil()
+	}
+	return false
+}
+
+func appendPath(path []any, element any) []any {
+	return append(path[:len(path):len(path)], element)
+}
+
+func (e *execution) coerceArguments(definition *FieldDefinition, arguments map[string]Value) (map[string]any, error) {
+	args := make(map[string]any, len(definition.Args))
+	for name, argument := range definition.Args {
+		literal, ok := arguments[name]
+		var value any
+		if ok {
+			var err error
+			if value, err = e.valueToGo(literal); err != nil {
+				return nil, err
+			}
+		}
+		if value == nil && argument.Default != nil {
+			args[name] = argument.Default
+			continue
+		}
+		coerced, err := coerceInput(argument.Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("argument %q: %w", name, err)
+		}
+		if coerced != nil {
+			args[name] = coerced
+		}
+	}
+	return args, nil
+}
+
+// valueToGo converts a document value, substituting variables
+func (e *execution) valueToGo(value Value) (any, error) {
+	switch v := value.(type) {
+	case nil, NullValue:
+		return nil, nil
+	case Variable:
+		if !e.defined[string(v)] {
+			return nil, fmt.Errorf("variable $%s is not defined", string(v))
+		}
+		return e.variables[string(v)], nil
+	case IntValue:
+		return int64(v), nil
+	case FloatValue:
+		return float64(v), nil
+	case StringValue:
+		return string(v), nil
+	case BoolValue:
+		return bool(v), nil
+	case EnumValue:
+		return string(v), nil
+	case ListValue:
+		list := make([]any, len(v))
+		for i, item := range v {
+			converted, err := e.valueToGo(item)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = converted
+		}
+		return list, nil
+	case ObjectValue:
+		object := make(map[string]any, len(v))
+		for name, field := range v {
+			converted, err := e.valueToGo(field)
+			if err != nil {
+				return nil, err
+			}
+			object[name] = converted
+		}
+		return object, nil
+	}
+	return nil, fmt.Errorf("unsupported value %T", value)
+}
+
+// coerceInput converts an argument or variable value to the expected type
+func coerceInput(t Type, value any) (any, error) {
+	if nonNull, ok := t.(*NonNull); ok {
+		if value == nil {
+			return nil, fmt.Errorf("expected non-null %s", nonNull.Of)
+		}
+		t = nonNull.Of
+	}
+	if value == nil {
+		return nil, nil
+	}
+
+	switch typ := t.(type) {
+	case *List:
+		items, ok := value.([]any)
+		if !ok {
+			items = []any{value}
+		}
+		coerced := make([]any, len(items))
+		for i, item := range items {
+			var err error
+			if coerced[i], err = coerceInput(typ.Of, item); err != nil {
+				return nil, err
+			}
+		}
+		return coerced, nil
+	case *Scalar:
+		return typ.ParseValue(value)
+	}
+	return nil, fmt.Errorf("%s is not an input type", t)
+}
+
+// inputScalars are the named types allowed in variable definitions
+var inputScalars = map[string]*Scalar{
+	Int.Name:      Int,
+	String.Name:   String,
+	Boolean.Name:  Boolean,
+	ID.Name:       ID,
+	DateTime.Name: DateTime,
+}
+
+func typeFromRef(ref TypeRef) (Type, error) {
+	var t Type
+	if ref.Elem != nil {
+		elem, err := typeFromRef(*ref.Elem)
+		if err != nil {
+			return nil, err
+		}
+		t = &List{Of: elem}
+	} else {
+		scalar, ok := inputScalars[ref.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown input type %s", ref.Name)
+		}
+		t = scalar
+	}
+	if ref.NonNull {
+		t = &NonNull{Of: t}
+	}
+	return t, nil
+}
+
+// coerceVariables checks the supplied variables against their definitions
+func coerceVariables(op *Operation, variables map[string]any) error {
+	for _, definition := range op.Variables {
+		t, err := typeFromRef(definition.Type)
+		if err != nil {
+			return fmt.Errorf("variable $%s: %w", definition.Name, err)
+		}
+		value, ok := variables[definition.Name]
+		if !ok && definition.Default != nil {
+			continue
+		}
+		if _, err := coerceInput(t, value); err != nil {
+			return fmt.Errorf("variable $%s: %w", definition.Name, err)
+		}
+	}
+	return nil
+}
+
+// orderedMap keeps response keys in selection order when encoded
+type orderedMap struct {
+	keys   []string
+	values map[string]any
+}
+
+func newOrderedMap() *orderedMap {
+	return &orderedMap{values: make(map[string]any)}
+}
+
+func (m *orderedMap) set(key string, value any) {
+	if _, exists := m.values[key]; !exists {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+// MarshalJSON encodes the map with keys in insertion order
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		encodedKey, _ := json.Marshal(key)
+		buf.Write(encodedKey)
+		buf.WriteByte(':')
+		value, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,311 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	doc, err := Parse(`
+		# list posts with authors
+		query Feed($limit: Int = 5, $withAuthor: Boolean!) {
+			feed: posts(limit: $limit, tags: ["go", "sql"]) {
+				...PostFields
+				author @include(if: $withAuthor) { name }
+				... on Post { id }
+			}
+		}
+		fragment PostFields on Post { id title }
+	`)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if len(doc.Operations) != 1 || doc.Operations[0].Name != "Feed" {
+		t.Fatalf("unexpected operations: %+v", doc.Operations)
+	}
+	op := doc.Operations[0]
+	if len(op.Variables) != 2 || op.Variables[0].Default != IntValue(5) || !op.Variables[1].Type.NonNull {
+		t.Errorf("unexpected variables: %+v", op.Variables)
+	}
+
+	feed := op.SelectionSet[0].(*Field)
+	if feed.Alias != "feed" || feed.Name != "posts" || feed.ResponseKey() != "feed" {
+		t.Errorf("unexpected field: %+v", feed)
+	}
+	if feed.Arguments["limit"] != Variable("limit") {
+		t.Errorf("limit argument = %#v", feed.Arguments["limit"])
+	}
+	if tags, ok := feed.Arguments["tags"].(ListValue); !ok || len(tags) != 2 {
+		t.Errorf("tags argument = %#v", feed.Arguments["tags"])
+	}
+	if len(feed.SelectionSet) != 3 {
+		t.Fatalf("expected 3 selections, got %d", len(feed.SelectionSet))
+	}
+	if spread, ok := feed.SelectionSet[0].(*FragmentSpread); !ok || spread.Name != "PostFields" {
+		t.Errorf("expected fragment spread, got %#v", feed.SelectionSet[0])
+	}
+	if author := feed.SelectionSet[1].(*Field); len(author.Directives) != 1 || author.Directives[0].Name != "include" {
+		t.Errorf("expected @include directive on author")
+	}
+	if inline, ok := feed.SelectionSet[2].(*InlineFragment); !ok || inline.TypeCondition != "Post" {
+		t.Errorf("expected inline fragment, got %#v", feed.SelectionSet[2])
+	}
+	if doc.Fragments["PostFields"] == nil || doc.Fragments["PostFields"].TypeCondition != "Post" {
+		t.Errorf("fragment not parsed")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		``,
+		`{ posts { } }`,
+		`{ posts(limit: ) { id } }`,
+		`mutation { deletePost(id: 1) }`,
+		`{ title: "unterminated }`,
+		`query Q($id: ID = $other) { post(id: $id) { id } }`,
+	}
+	for _, query := range tests {
+		if _, err := Parse(query); err == nil {
+			t.Errorf("expected error for %q", query)
+		}
+	}
+
+	_, err := Parse("{\n  posts %")
+	syntaxErr, ok := err.(*SyntaxError)
+	if !ok || syntaxErr.Line != 2 || syntaxErr.Column != 9 {
+		t.Errorf("expected syntax error at 2:9, got %v", err)
+	}
+}
+
+// testSchema serves authors and books from memory and counts batch loads
+type testSchema struct {
+	authors    map[int]map[string]any
+	books      []map[string]any
+	batchCalls int
+	batchKeys  [][]int
+}
+
+type testLoaderKey struct{}
+
+func newTestSchema() *testSchema {
+	ts := &testSchema{authors: make(map[int]map[string]any)}
+	for i := 1; i <= 3; i++ {
+		ts.authors[i] = map[string]any{"id": i, "name": fmt.Sprintf("author %d", i)}
+	}
+	for i := 1; i <= 6; i++ {
+		ts.books = append(ts.books, map[string]any{"id": i, "title": fmt.Sprintf("book %d", i), "authorID": (i-1)%3 + 1})
+	}
+	return ts
+}
+
+func (ts *testSchema) context() context.Context {
+	loader := NewLoader(func(ctx context.Context, keys []int) (map[int]map[string]any, error) {
+		ts.batchCalls++
+		ts.batchKeys = append(ts.batchKeys, keys)
+		result := make(map[int]map[string]any)
+		for _, key := range keys {
+			if author, ok := ts.authors[key]; ok {
+				result[key] = author
+			}
+		}
+		return result, nil
+	})
+	return context.WithValue(context.Background(), testLoaderKey{}, loader)
+}
+
+func (ts *testSchema) schema() *Schema {
+	author := &Object{Name: "Author", Fields: map[string]*FieldDefinition{
+		"id":   {Type: &NonNull{Of: ID}},
+		"name": {Type: String},
+	}}
+	book := &Object{Name: "Book", Fields: map[string]*FieldDefinition{
+		"id":    {Type: &NonNull{Of: ID}},
+		"title": {Type: String},
+		"author": {Type: author, Resolve: func(p ResolveParams) (any, error) {
+			loader := p.Context.Value(testLoaderKey{}).(*Loader[int, map[string]any])
+			return loader.Load(p.Context, p.Source.(map[string]any)["authorID"].(int)), nil
+		}},
+		"failing": {Type: String, Resolve: func(p ResolveParams) (any, error) {
+			return nil, fmt.Errorf("boom")
+		}},
+		"panicking": {Type: String, Resolve: func(p ResolveParams) (any, error) {
+			panic("resolver bug")
+		}},
+	}}
+	return &Schema{Query: &Object{Name: "Query", Fields: map[string]*FieldDefinition{
+		"books": {
+			Type: &List{Of: book},
+			Args: map[string]*Argument{"limit": {Type: Int, Default: 10}},
+			Resolve: func(p ResolveParams) (any, error) {
+				limit := p.Args["limit"].(int)
+				if limit > len(ts.books) {
+					limit = len(ts.books)
+				}
+				return ts.books[:limit], nil
+			},
+		},
+		"book": {
+			Type: book,
+			Args: map[string]*Argument{"id": {Type: &NonNull{Of: ID}}},
+			Resolve: func(p ResolveParams) (any, error) {
+				for _, b := range ts.books {
+					if fmt.Sprint(b["id"]) == p.Args["id"] {
+						return b, nil
+					}
+				}
+				return nil, nil
+			},
+		},
+	}}}
+}
+
+func execute(t *testing.T, ts *testSchema, req Request, validators ...Validator) (string, *Response) {
+	t.Helper()
+	response := Execute(ts.context(), ts.schema(), req, validators...)
+	body, err := json.Marshal(response)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	return string(body), response
+}
+
+func TestExecuteBatchesLoads(t *testing.T) {
+	ts := newTestSchema()
+	body, response := execute(t, ts, Request{Query: `{ books { title author { name } } }`})
+	if len(response.Errors) > 0 {
+		t.Fatalf("unexpected errors: %s", body)
+	}
+
+	// Six books by three authors resolve with a single batch of unique keys
+	if ts.batchCalls != 1 || len(ts.batchKeys[0]) != 3 {
+		t.Errorf("expected 1 batch of 3 keys, got %d batches: %v", ts.batchCalls, ts.batchKeys)
+	}
+	if !strings.HasPrefix(body, `{"data":{"books":[{"title":"book 1","author":{"name":"author 1"}},{"title":"book 2","author":{"name":"author 2"}}`) {
+		t.Errorf("unexpected response: %s", body)
+	}
+}
+
+func TestExecuteVariablesFragmentsAndAliases(t *testing.T) {
+	ts := newTestSchema()
+	body, response := execute(t, ts, Request{
+		Query: `query Pick($id: ID!, $n: Int, $skipTitle: Boolean = false) {
+			first: book(id: $id) { ...BookFields }
+			few: books(limit: $n) { id }
+		}
+		fragment BookFields on Book { __typename id title @skip(if: $skipTitle) }`,
+		Variables: map[string]any{"id": "2", "n": float64(2)},
+	})
+	if len(response.Errors) > 0 {
+		t.Fatalf("unexpected errors: %s", body)
+	}
+	want := `{"data":{"first":{"__typename":"Book","id":"2","title":"book 2"},"few":[{"id":"1"},{"id":"2"}]}}`
+	if body != want {
+		t.Errorf("got %s, want %s", body, want)
+	}
+}
+
+func TestExecuteFieldErrors(t *testing.T) {
+	ts := newTestSchema()
+	body, response := execute(t, ts, Request{Query: `{ book(id: 1) { id failing panicking } }`})
+	if len(response.Errors) != 2 {
+		t.Fatalf("expected 2 errors, got %s", body)
+	}
+	if response.Errors[0].Message != "boom" || fmt.Sprint(response.Errors[0].Path) != "[book failing]" {
+		t.Errorf("unexpected error: %+v", response.Errors[0])
+	}
+	if response.Errors[1].Message != "internal error" {
+		t.Errorf("panic should be reported as internal error, got %q", response.Errors[1].Message)
+	}
+	if !strings.Contains(body, `"book":{"id":"1","failing":null,"panicking":null}`) {
+		t.Errorf("failed fields should resolve to null: %s", body)
+	}
+}
+
+func TestExecuteValidation(t *testing.T) {
+	ts := newTestSchema()
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`{ books { isbn } }`, `cannot query field "isbn" on type "Book"`},
+		{`{ books }`, `must have a selection of subfields`},
+		{`{ books { title { x } } }`, `must not have a selection`},
+		{`{ book { id } }`, `argument "id" is required`},
+		{`{ books(order: "asc") { id } }`, `unknown argument "order"`},
+		{`{ books { ...Missing } }`, `unknown fragment "Missing"`},
+		{`{ books { ...A } } fragment A on Book { ...B } fragment B on Book { ...A }`, `spreads itself`},
+		{`query Q($id: ID!) { book(id: $id) { id } }`, `variable $id: expected non-null ID`},
+		{`query A { books { id } } query B { books { id } }`, `operationName is required`},
+	}
+	for _, tt := range tests {
+		_, response := execute(t, ts, Request{Query: tt.query})
+		if response.Data != nil {
+			t.Errorf("%q: expected no data", tt.query)
+		}
+		if len(response.Errors) == 0 || !strings.Contains(response.Errors[0].Message, tt.want) {
+			t.Errorf("%q: expected error containing %q, got %+v", tt.query, tt.want, response.Errors)
+		}
+	}
+}
+
+func TestValidateFragmentFanOut(t *testing.T) {
+	// Each fragment spreads the one before twice, so walking every spread
+	// would visit 2^60 selections
+	var query strings.Builder
+	query.WriteString(`{ books { ...F60 } } fragment F0 on Book { id title }`)
+	for i := 1; i <= 60; i++ {
+		fmt.Fprintf(&query, ` fragment F%d on Book { ...F%d ...F%d }`, i, i-1, i-1)
+	}
+	doc, err := Parse(query.String())
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	done := make(chan []*Error, 1)
+	go func() { done <- Validate(newTestSchema().schema(), doc, doc.Operations[0]) }()
+	select {
+	case errs := <-done:
+		if len(errs) > 0 {
+			t.Errorf("unexpected errors: %v", errs)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Validate walked repeated fragment spreads again")
+	}
+}
+
+func TestExecuteRunsValidators(t *testing.T) {
+	ts := newTestSchema()
+	reject := func(schema *Schema, doc *Document, op *Operation, variables map[string]any) error {
+		return fmt.Errorf("too expensive")
+	}
+	_, response := execute(t, ts, Request{Query: `{ books { id } }`}, reject)
+	if response.Data != nil || len(response.Errors) != 1 || response.Errors[0].Message != "too expensive" {
+		t.Errorf("expected validator rejection, got %+v", response)
+	}
+}
+
+func TestLoaderOutsideExecution(t *testing.T) {
+	calls := 0
+	loader := NewLoader(func(ctx context.Context, keys []string) (map[string]int, error) {
+		calls++
+		return map[string]int{"a": 1}, nil
+	})
+	ctx := context.Background()
+	first, second := loader.Load(ctx, "a"), loader.Load(ctx, "b")
+
+	if v, err := first(); err != nil || v != 1 {
+		t.Errorf("first() = %v, %v", v, err)
+	}
+	if v, err := second(); err != nil || v != 0 {
+		t.Errorf("second() = %v, %v", v, err)
+	}
+	loader.Load(ctx, "a")()
+	if calls != 1 {
+		t.Errorf("expected cached results after one batch, got %d calls", calls)
+	}
+}
@@ -0,0 +1,81 @@
+package graphql
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"go-server/internal/errors"
+	"go-server/internal/logger"
+)
+
+// Handler serves /graphql. It accepts POST with a JSON body and GET with
+// query, operationName and variables parameters, and expects the optional
+// auth middleware to have run so resolvers can see the viewer.
+type Handler struct {
+	api        *API
+	schema     *Schema
+	validators []Validator
+	logger     logger.Logger
+}
+
+// NewHandler creates a new GraphQL handler; validators run before every
+// operation, typically the security layer's depth and complexity limits
+func NewHandler(api *API, logger logger.Logger, validators ...Validator) *Handler {
+	return &Handler{
+		api:        api,
+		schema:     api.Schema(),
+		validators: validators,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP executes a GraphQL request
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req Request
+	switch r.Method {
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid request body", "INVALID_REQUEST")
+			return
+		}
+	case http.MethodGet:
+		query := r.URL.Query()
+		req.Query = query.Get("query")
+		req.OperationName = query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid variables", "INVALID_REQUEST")
+				return
+			}
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		errors.WriteErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed", "METHOD_NOT_ALLOWED")
+		return
+	}
+	if req.Query == "" {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Query is required", "MISSING_QUERY")
+		return
+	}
+
+	start := time.Now()
+	response := Execute(h.api.WithLoaders(r.Context()), h.schema, req, h.validators...)
+
+	status := http.StatusOK
+	if response.Data == nil {
+		// The request never executed: a syntax, validation or limit error
+		status = http.StatusBadRequest
+	}
+	if len(response.Errors) > 0 {
+		h.logger.Debug("GraphQL request returned errors", "operation", req.OperationName, "errors", len(response.Errors))
+	}
+	h.logger.Debug("GraphQL request executed", "operation", req.OperationName, "duration_ms", time.Since(start).Milliseconds())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.logger.Error("Failed to encode GraphQL response", "error", err.Error())
+	}
+}
@@ -0,0 +1,100 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// BatchFunc loads values for a batch of keys. The result maps each found key
+// to its value; keys missing from the map resolve to the zero value.
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// dispatcher is implemented by loaders the executor flushes between levels
+type dispatcher interface {
+	dispatch(ctx context.Context)
+}
+
+// Loader batches and caches key lookups for a single request, so resolving a
+// field on every item of a list costs one query instead of one per item.
+// Create a loader per request; it caches results for its lifetime.
+type Loader[K comparable, V any] struct {
+	batch BatchFunc[K, V]
+
+	mu      sync.Mutex
+	pending []K
+	queued  map[K]bool
+	results map[K]V
+	errs    map[K]error
+}
+
+// NewLoader creates a new loader backed by batch
+func NewLoader[K comparable, V any](batch BatchFunc[K, V]) *Loader[K, V] {
+	return &Loader[K, V]{
+		batch:   batch,
+		queued:  make(map[K]bool),
+		results: make(map[K]V),
+		errs:    make(map[K]error),
+	}
+}
+
+// Load queues key and returns a thunk for its value. Keys queued while the
+// executor resolves one level are fetched together before the next level; a
+// thunk forced outside an execution dispatches on its own.
+func (l *Loader[K, V]) Load(ctx context.Context, key K) Thunk {
+	l.mu.Lock()
+	_, cached := l.results[key]
+	_, failed := l.errs[key]
+	if !cached && !failed && !l.queued[key] {
+		l.queued[key] = true
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	if exec, ok := ctx.Value(executionKey{}).(*execution); ok {
+		exec.enqueue(l)
+	}
+
+	return func() (any, error) {
+		l.dispatch(ctx)
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if err := l.errs[key]; err != nil {
+			return nil, err
+		}
+		return l.results[key], nil
+	}
+}
+
+// Prime stores a value that is already known, such as one loaded by another query
+func (l *Loader[K, V]) Prime(key K, value V) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, cached := l.results[key]; !cached {
+		l.results[key] = value
+	}
+}
+
+// dispatch fetches every pending key in one batch call
+func (l *Loader[K, V]) dispatch(ctx context.Context) {
+	l.mu.Lock()
+	keys := l.pending
+	l.pending = nil
+	l.mu.Unlock()
+	if len(keys) == 0 {
+		return
+	}
+
+	values, err := l.batch(ctx, keys)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range keys {
+		delete(l.queued, key)
+		if err != nil {
+			l.errs[key] = fmt.Errorf("batch load failed: %w", err)
+			continue
+		}
+		l.results[key] = values[key]
+	}
+}
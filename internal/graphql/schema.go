@@ -0,0 +1,214 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Type is a schema output or input type
+type Type interface {
+	String() string
+}
+
+// Scalar is a leaf type; Serialize converts resolved Go values for the response
+// and ParseValue coerces argument values
+type Scalar struct {
+	Name       string
+	Serialize  func(value any) (any, error)
+	ParseValue func(value any) (any, error)
+}
+
+// Object is an object type with named fields
+type Object struct {
+	Name   string
+	Fields map[string]*FieldDefinition
+}
+
+// List wraps a type in a list
+type List struct {
+	Of Type
+}
+
+// NonNull marks a type as non-nullable
+type NonNull struct {
+	Of Type
+}
+
+func (s *Scalar) String() string  { return s.Name }
+func (o *Object) String() string  { return o.Name }
+func (l *List) String() string    { return "[" + l.Of.String() + "]" }
+func (n *NonNull) String() string { return n.Of.String() + "!" }
+
+// FieldDefinition describes a field of an object type
+type FieldDefinition struct {
+	Type Type
+	Args map[string]*Argument
+	// Resolve produces the field value; when nil the value is read from a
+	// map[string]any source by field name
+	Resolve ResolveFunc
+	// Cost is the field's complexity weight; zero counts as one
+	Cost int
+}
+
+// Argument describes a field argument
+type Argument struct {
+	Type    Type
+	Default any
+}
+
+// ResolveParams is passed to resolvers
+type ResolveParams struct {
+	Context context.Context
+	Source  any
+	Args    map[string]any
+}
+
+// ResolveFunc resolves a field value. Resolvers may return a Thunk to defer
+// work until the executor dispatches pending loaders.
+type ResolveFunc func(p ResolveParams) (any, error)
+
+// Thunk is a deferred resolver result
+type Thunk func() (any, error)
+
+// Schema is the root of a query-only schema
+type Schema struct {
+	Query *Object
+}
+
+// NamedType unwraps list and non-null wrappers
+func NamedType(t Type) Type {
+	for {
+		switch wrapped := t.(type) {
+		case *List:
+			t = wrapped.Of
+		case *NonNull:
+			t = wrapped.Of
+		default:
+			return t
+		}
+	}
+}
+
+// IsList reports whether the type, ignoring non-null, is a list
+func IsList(t Type) bool {
+	if nonNull, ok := t.(*NonNull); ok {
+		t = nonNull.Of
+	}
+	_, ok := t.(*List)
+	return ok
+}
+
+// Built-in scalars
+var (
+	Int = &Scalar{
+		Name: "Int",
+		Serialize: func(value any) (any, error) {
+			return coerceInt(value)
+		},
+		ParseValue: func(value any) (any, error) {
+			return coerceInt(value)
+		},
+	}
+	String = &Scalar{
+		Name: "String",
+		Serialize: func(value any) (any, error) {
+			switch v := value.(type) {
+			case string:
+				return v, nil
+			case fmt.Stringer:
+				return v.String(), nil
+			}
+			return fmt.Sprint(value), nil
+		},
+		ParseValue: func(value any) (any, error) {
+			if s, ok := value.(string); ok {
+				return s, nil
+			}
+			return nil, fmt.Errorf("expected String, got %T", value)
+		},
+	}
+	Boolean = &Scalar{
+		Name: "Boolean",
+		Serialize: func(value any) (any, error) {
+			if b, ok := value.(bool); ok {
+				return b, nil
+			}
+			return nil, fmt.Errorf("expected Boolean, got %T", value)
+		},
+		ParseValue: func(value any) (any, error) {
+			if b, ok := value.(bool); ok {
+				return b, nil
+			}
+			return nil, fmt.Errorf("expected Boolean, got %T", value)
+		},
+	}
+	// ID accepts strings and integers and always serializes as a string
+	ID = &Scalar{
+		Name: "ID",
+		Serialize: func(value any) (any, error) {
+			switch v := value.(type) {
+			case string:
+				return v, nil
+			case uint:
+				return strconv.FormatUint(uint64(v), 10), nil
+			case int, int64, uint64:
+				return fmt.Sprint(v), nil
+			}
+			return nil, fmt.Errorf("expected ID, got %T", value)
+		},
+		ParseValue: func(value any) (any, error) {
+			switch v := value.(type) {
+			case string:
+				return v, nil
+			case int64:
+				return strconv.FormatInt(v, 10), nil
+			case float64:
+				if v == float64(int64(v)) {
+					return strconv.FormatInt(int64(v), 10), nil
+				}
+			}
+			return nil, fmt.Errorf("expected ID, got %T", value)
+		},
+	}
+	// DateTime serializes time.Time as RFC 3339
+	DateTime = &Scalar{
+		Name: "DateTime",
+		Serialize: func(value any) (any, error) {
+			switch v := value.(type) {
+			case time.Time:
+				return v.UTC().Format(time.RFC3339), nil
+			case *time.Time:
+				if v == nil {
+					return nil, nil
+				}
+				return v.UTC().Format(time.RFC3339), nil
+			}
+			return nil, fmt.Errorf("expected DateTime, got %T", value)
+		},
+		ParseValue: func(value any) (any, error) {
+			s, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected DateTime string, got %T", value)
+			}
+			return time.Parse(time.RFC3339, s)
+		},
+	}
+)
+
+func coerceInt(value any) (any, error) {
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case uint:
+		return int(v), nil
+	case float64:
+		if v == float64(int(v)) {
+			return int(v), nil
+		}
+	}
+	return nil, fmt.Errorf("expected Int, got %v", value)
+}
@@ -0,0 +1,103 @@
+package graphql
+
+import "fmt"
+
+// Validate checks that an operation only selects fields and arguments the
+// schema defines and that its fragments resolve without cycles. Each fragment
+// is walked once however often it is spread, so a query that spreads its
+// fragments repeatedly costs no more to validate than its text is long.
+func Validate(schema *Schema, doc *Document, op *Operation) []*Error {
+	v := &validator{doc: doc, visiting: make(map[string]bool), validated: make(map[string]bool)}
+	v.selections(schema.Query, op.SelectionSet)
+	return v.errors
+}
+
+type validator struct {
+	doc      *Document
+	visiting map[string]bool
+	errors   []*Error
+	// validated holds the fragments already walked; a fragment's type
+	// condition is fixed, so walking it again finds nothing new
+	validated map[string]bool
+}
+
+func (v *validator) errorf(field *Field, format string, args ...any) {
+	err := &Error{Message: fmt.Sprintf(format, args...)}
+	if field != nil {
+		err.Locations = []Location{{Line: field.Line, Column: field.Column}}
+	}
+	v.errors = append(v.errors, err)
+}
+
+func (v *validator) selections(obj *Object, selections []Selection) {
+	for _, selection := range selections {
+		switch s := selection.(type) {
+		case *Field:
+			v.field(obj, s)
+		case *FragmentSpread:
+			fragment := v.doc.Fragments[s.Name]
+			if fragment == nil {
+				v.errorf(nil, "unknown fragment %q", s.Name)
+				continue
+			}
+			if fragment.TypeCondition != obj.Name {
+				v.errorf(nil, "fragment %q on %s cannot be spread on %s", s.Name, fragment.TypeCondition, obj.Name)
+				continue
+			}
+			if v.validated[s.Name] {
+				continue
+			}
+			if v.visiting[s.Name] {
+				v.errorf(nil, "fragment %q spreads itself", s.Name)
+				continue
+			}
+			v.visiting[s.Name] = true
+			v.selections(obj, fragment.SelectionSet)
+			delete(v.visiting, s.Name)
+			v.validated[s.Name] = true
+		case *InlineFragment:
+			if s.TypeCondition != "" && s.TypeCondition != obj.Name {
+				v.errorf(nil, "inline fragment on %s cannot be spread on %s", s.TypeCondition, obj.Name)
+				continue
+			}
+			v.selections(obj, s.SelectionSet)
+		}
+	}
+}
+
+func (v *validator) field(obj *Object, field *Field) {
+	if field.Name == "__typename" {
+		if field.SelectionSet != nil {
+			v.errorf(field, "field \"__typename\" must not have a selection")
+		}
+		return
+	}
+
+	definition := obj.Fields[field.Name]
+	if definition == nil {
+		v.errorf(field, "cannot query field %q on type %q", field.Name, obj.Name)
+		return
+	}
+	for name := range field.Arguments {
+		if definition.Args[name] == nil {
+			v.errorf(field, "unknown argument %q on field %q", name, field.Name)
+		}
+	}
+	for name, argument := range definition.Args {
+		if _, required := argument.Type.(*NonNull); required && argument.Default == nil {
+			if _, ok := field.Arguments[name]; !ok {
+				v.errorf(field, "field %q argument %q is required", field.Name, name)
+			}
+		}
+	}
+
+	if child, ok := NamedType(definition.Type).(*Object); ok {
+		if field.SelectionSet == nil {
+			v.errorf(field, "field %q of type %s must have a selection of subfields", field.Name, definition.Type)
+			return
+		}
+		v.selections(child, field.SelectionSet)
+	} else if field.SelectionSet != nil {
+		v.errorf(field, "field %q must not have a selection since %s has no subfields", field.Name, definition.Type)
+	}
+}
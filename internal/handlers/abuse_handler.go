@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	stderrors "errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-server/internal/abuse"
+	"go-server/internal/database/repositories"
+	"go-server/internal/errors"
+	"go-server/internal/logger"
+	"go-server/internal/middleware"
+
+	"gorm.io/gorm"
+)
+
+// AbuseHandler lets administrators review and lift the abuse detector's bans
+type AbuseHandler struct {
+	abuseRepo *repositories.AbuseRepository
+	detector  *abuse.Detector
+	logger    logger.Logger
+}
+
+// NewAbuseHandler creates a new abuse handler
+func NewAbuseHandler(abuseRepo *repositories.AbuseRepository, detector *abuse.Detector, logger logger.Logger) *AbuseHandler {
+	return &AbuseHandler{
+		abuseRepo: abuseRepo,
+		detector:  detector,
+		logger:    logger,
+	}
+}
+
+// ListBans lists the ban audit records, newest first
+// (GET /api/admin/abuse/bans?ip=&offset=&limit=, requires system:configure)
+func (ah *AbuseHandler) ListBans(w http.ResponseWriter, r *http.Request) {
+	ip := strings.TrimSpace(r.URL.Query().Get("ip"))
+	if ip != "" && net.ParseIP(ip) == nil {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid IP address", "INVALID_IP_ADDRESS")
+		return
+	}
+	offset, limit := parsePagination(r)
+	bans, err := ah.abuseRepo.ListBans(r.Context(), ip, offset, limit)
+	if err != nil {
+		ah.logger.Error("Failed to list abuse bans: %v", err)
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve bans", "DATABASE_ERROR")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"bans":   bans,
+		"offset": offset,
+		"limit":  limit,
+	})
+}
+
+// LiftBan ends the bans in force on an IP
+// (DELETE /api/admin/abuse/bans/{ip}, requires system:configure)
+func (ah *AbuseHandler) LiftBan(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "Authentication required", "NO_TOKEN")
+		return
+	}
+	ip := strings.TrimPrefix(r.URL.Path, "/api/admin/abuse/bans/")
+	if net.ParseIP(ip) == nil {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid IP address", "INVALID_IP_ADDRESS")
+		return
+	}
+
+	err := ah.abuseRepo.LiftBans(r.Context(), ip, userID, time.Now())
+	if stderrors.Is(err, gorm.ErrRecordNotFound) {
+		errors.WriteErrorResponse(w, http.StatusNotFound, "No ban in force for this IP address", "ABUSE_BAN_NOT_FOUND")
+		return
+	}
+	if err != nil {
+		ah.logger.Error("Failed to lift abuse ban of %s: %v", ip, err)
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to lift ban", "DATABASE_ERROR")
+		return
+	}
+	ah.detector.Lift(ip)
+	ah.logger.Info("User %d lifted the abuse ban of %s", userID, ip)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	stderrors "errors"
+	"net/http"
+
+	"go-server/internal/auth"
+	"go-server/internal/database/repositories"
+	"go-server/internal/errors"
+	"go-server/internal/logger"
+	"go-server/internal/middleware"
+	"go-server/internal/security"
+
+	"gorm.io/gorm"
+)
+
+// AccountMergeHandler merges duplicate accounts, such as one created by an
+// OAuth login and one registered with a password, into one
+type AccountMergeHandler struct {
+	authService *auth.AuthService
+	userRepo    *repositories.UserRepository
+	logger      logger.Logger
+}
+
+// NewAccountMergeHandler creates a new account merge handler
+func NewAccountMergeHandler(authService *auth.AuthService, userRepo *repositories.UserRepository, logger logger.Logger) *AccountMergeHandler {
+	return &AccountMergeHandler{
+		authService: authService,
+		userRepo:    userRepo,
+		logger:      logger,
+	}
+}
+
+// MergeOwnAccountRequest represents a user merging another account they
+// control into the one they are signed in with. SourceToken is a token of
+// the other account, proving the user controls it.
+type MergeOwnAccountRequest struct {
+	SourceToken string `json:"source_token" validate:"required"`
+	DryRun      bool   `json:"dry_run"`
+}
+
+// MergeOwnAccount merges the account authenticated by the source token into
+// the current user's
+// (POST /api/users/me/merge)
+func (mh *AccountMergeHandler) MergeOwnAccount(w http.ResponseWriter, r *http.Request) {
+	targetID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "User not authenticated", "NOT_AUTHENTICATED")
+		return
+	}
+
+	req, failures := security.Bind[MergeOwnAccountRequest](r)
+	if len(failures) > 0 {
+		writeValidationErrors(w, failures)
+		return
+	}
+
+	source, _, err := mh.authService.Authenticate(r.Context(), req.SourceToken)
+	if err != nil {
+		errors.WriteErrorResponse(w, http.StatusUnprocessableEntity, "The source token does not authenticate an active account", "MERGE_SOURCE_INVALID")
+		return
+	}
+
+	mh.merge(w, r, source.ID, targetID, targetID, req.DryRun)
+}
+
+// MergeUsersRequest represents an administrator merging two accounts
+type MergeUsersRequest struct {
+	SourceID uint `json:"source_id" validate:"required"`
+	TargetID uint `json:"target_id" validate:"required"`
+	DryRun   bool `json:"dry_run"`
+}
+
+// MergeUsers merges one account into another
+// (POST /api/admin/users/merge, requires users:manage)
+func (mh *AccountMergeHandler) MergeUsers(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "User not authenticated", "NOT_AUTHENTICATED")
+		return
+	}
+
+	req, failures := security.Bind[MergeUsersRequest](r)
+	if len(failures) > 0 {
+		writeValidationErrors(w, failures)
+		return
+	}
+
+	mh.merge(w, r, req.SourceID, req.TargetID, adminID, req.DryRun)
+}
+
+// merge runs a merge and writes its report
+func (mh *AccountMergeHandler) merge(w http.ResponseWriter, r *http.Request, sourceID, targetID, mergedByID uint, dryRun bool) {
+	report, err := mh.userRepo.MergeUsers(r.Context(), sourceID, targetID, &mergedByID, dryRun)
+	switch {
+	case stderrors.Is(err, repositories.ErrMergeSameUser):
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Cannot merge an account into itself", "MERGE_SAME_ACCOUNT")
+		return
+	case stderrors.Is(err, repositories.ErrMergeHeld):
+		errors.WriteErrorResponse(w, http.StatusConflict, "An account under legal hold cannot be merged", "MERGE_ACCOUNT_HELD")
+		return
+	case stderrors.Is(err, gorm.ErrRecordNotFound):
+		errors.WriteErrorResponse(w, http.StatusNotFound, "User not found", "USER_NOT_FOUND")
+		return
+	case err != nil:
+		mh.logger.Error("Failed to merge accounts", "source_id", sourceID, "target_id", targetID, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to merge accounts", "DATABASE_ERROR")
+		return
+	}
+
+	if !dryRun {
+		mh.logger.Info("Accounts merged", "source_id", sourceID, "target_id", targetID, "merged_by", mergedByID)
+	}
+	writeJSON(w, http.StatusOK, report)
+}
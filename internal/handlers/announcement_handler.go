@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	stderrors "errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-server/internal/announcements"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/errors"
+	"go-server/internal/logger"
+	"go-server/internal/middleware"
+	"go-server/internal/security"
+
+	"gorm.io/gorm"
+)
+
+// announcementFeedSize is how many announcements the RSS feed lists
+const announcementFeedSize = 50
+
+// AnnouncementHandler serves API status and deprecation announcements
+type AnnouncementHandler struct {
+	repo         *repositories.AnnouncementRepository
+	deprecations *announcements.Deprecations
+	logger       logger.Logger
+}
+
+// NewAnnouncementHandler creates a new announcement handler; deprecations
+// is reloaded when administrators change announcements and may be nil
+func NewAnnouncementHandler(repo *repositories.AnnouncementRepository, deprecations *announcements.Deprecations, logger logger.Logger) *AnnouncementHandler {
+	return &AnnouncementHandler{
+		repo:         repo,
+		deprecations: deprecations,
+		logger:       logger,
+	}
+}
+
+// CreateAnnouncementRequest represents a request to publish an announcement
+type CreateAnnouncementRequest struct {
+	Kind       string     `json:"kind" validate:"required,oneof=status deprecation notice"`
+	Title      string     `json:"title" validate:"required,max=200"`
+	Body       string     `json:"body" validate:"max=10000"`
+	PathPrefix string     `json:"path_prefix" validate:"max=255"`
+	SunsetAt   *time.Time `json:"sunset_at"`
+	// PublishedAt defaults to now; a later time schedules the announcement
+	PublishedAt *time.Time `json:"published_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+// ListAnnouncements returns the active announcements, newest first
+// (GET /api/announcements?kind=deprecation)
+func (ah *AnnouncementHandler) ListAnnouncements(w http.ResponseWriter, r *http.Request) {
+	offset, limit := parsePagination(r)
+	list, err := ah.repo.ListActiveAnnouncements(r.Context(), time.Now(), r.URL.Query().Get("kind"), offset, limit)
+	if err != nil {
+		ah.logger.Error("Failed to list announcements: %v", err)
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve announcements", "DATABASE_ERROR")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"announcements": list,
+		"pagination": map[string]interface{}{
+			"offset": offset,
+			"limit":  limit,
+		},
+	})
+}
+
+// GetAnnouncement returns a published announcement, which deprecation Link
+// headers point to (GET /api/announcements/{id})
+func (ah *AnnouncementHandler) GetAnnouncement(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDFromPath(r.URL.Path, "/api/announcements/", "")
+	if err != nil {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid announcement ID", "INVALID_ANNOUNCEMENT_ID")
+		return
+	}
+	announcement, err := ah.repo.GetAnnouncement(r.Context(), id)
+	if err == nil && announcement.PublishedAt.After(time.Now()) {
+		// Scheduled announcements stay hidden until they are published
+		err = gorm.ErrRecordNotFound
+	}
+	if stderrors.Is(err, gorm.ErrRecordNotFound) {
+		errors.WriteErrorResponse(w, http.StatusNotFound, "Announcement not found", "ANNOUNCEMENT_NOT_FOUND")
+		return
+	}
+	if err != nil {
+		ah.logger.Error("Failed to get announcement: %v", err)
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve announcement", "DATABASE_ERROR")
+		return
+	}
+	writeJSON(w, http.StatusOK, announcement)
+}
+
+// RSS returns the active announcements as an RSS feed
+// (GET /api/announcements.rss)
+func (ah *AnnouncementHandler) RSS(w http.ResponseWriter, r *http.Request) {
+	list, err := ah.repo.ListActiveAnnouncements(r.Context(), time.Now(), r.URL.Query().Get("kind"), 0, announcementFeedSize)
+	if err != nil {
+		ah.logger.Error("Failed to list announcements: %v", err)
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve announcements", "DATABASE_ERROR")
+		return
+	}
+	w.Header().Set("Content-Type", announcements.RSSContentType)
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	announcements.WriteRSS(w, announcements.Channel{
+		Title:       "API announcements",
+		Description: "Service status and API deprecation notices",
+		BaseURL:     requestBaseURL(r),
+	}, list)
+}
+
+// CreateAnnouncement publishes an announcement
+// (POST /api/admin/announcements, requires system:configure)
+func (ah *AnnouncementHandler) CreateAnnouncement(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "Authentication required", "NO_TOKEN")
+		return
+	}
+	req, failures := security.Bind[CreateAnnouncementRequest](r)
+	if len(failures) > 0 {
+		writeValidationErrors(w, failures)
+		return
+	}
+
+	announcement := &models.Announcement{
+		Kind:        req.Kind,
+		Title:       strings.TrimSpace(req.Title),
+		Body:        req.Body,
+		PathPrefix:  strings.TrimSpace(req.PathPrefix),
+		SunsetAt:    req.SunsetAt,
+		PublishedAt: time.Now().UTC(),
+		ExpiresAt:   req.ExpiresAt,
+		CreatedByID: userID,
+	}
+	if req.PublishedAt != nil {
+		announcement.PublishedAt = req.PublishedAt.UTC()
+	}
+	if message := validateAnnouncement(announcement); message != "" {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, message, "INVALID_ANNOUNCEMENT")
+		return
+	}
+
+	if err := ah.repo.CreateAnnouncement(r.Context(), announcement); err != nil {
+		ah.logger.Error("Failed to create announcement: %v", err)
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to create announcement", "DATABASE_ERROR")
+		return
+	}
+	if ah.deprecations != nil {
+		ah.deprecations.Invalidate()
+	}
+	writeJSON(w, http.StatusCreated, announcement)
+}
+
+// DeleteAnnouncement withdraws an announcement
+// (DELETE /api/admin/announcements/{id}, requires system:configure)
+func (ah *AnnouncementHandler) DeleteAnnouncement(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDFromPath(r.URL.Path, "/api/admin/announcements/", "")
+	if err != nil {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid announcement ID", "INVALID_ANNOUNCEMENT_ID")
+		return
+	}
+	err = ah.repo.DeleteAnnouncement(r.Context(), id)
+	if stderrors.Is(err, gorm.ErrRecordNotFound) {
+		errors.WriteErrorResponse(w, http.StatusNotFound, "Announcement not found", "ANNOUNCEMENT_NOT_FOUND")
+		return
+	}
+	if err != nil {
+		ah.logger.Error("Failed to delete announcement: %v", err)
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to delete announcement", "DATABASE_ERROR")
+		return
+	}
+	if ah.deprecations != nil {
+		ah.deprecations.Invalidate()
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validateAnnouncement checks the rules between fields, returning a
+// message for the first one broken
+func validateAnnouncement(a *models.Announcement) string {
+	if a.Kind == models.AnnouncementDeprecation && !strings.HasPrefix(a.PathPrefix, "/") {
+		return "Deprecations must name the affected path, starting with /"
+	}
+	if a.Kind != models.AnnouncementDeprecation && (a.PathPrefix != "" || a.SunsetAt != nil) {
+		return "Only deprecations have a path and sunset date"
+	}
+	if a.ExpiresAt != nil && !a.ExpiresAt.After(a.PublishedAt) {
+		return "The announcement must expire after it is published"
+	}
+	if a.SunsetAt != nil && a.SunsetAt.Before(a.PublishedAt) {
+		return "The sunset date cannot precede the announcement"
+	}
+	return ""
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"strings"
+
+	"go-server/internal/approval"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/errors"
+	"go-server/internal/logger"
+	"go-server/internal/middleware"
+	"go-server/internal/security"
+
+	"gorm.io/gorm"
+)
+
+// ApprovalHandler handles the admin endpoints for reviewing approval requests
+type ApprovalHandler struct {
+	service *approval.Service
+	repo    *repositories.ApprovalRepository
+	logger  logger.Logger
+}
+
+// NewApprovalHandler creates a new approval handler
+func NewApprovalHandler(service *approval.Service, repo *repositories.ApprovalRepository, logger logger.Logger) *ApprovalHandler {
+	return &ApprovalHandler{
+		service: service,
+		repo:    repo,
+		logger:  logger,
+	}
+}
+
+// RejectApprovalRequest represents a request to reject an approval request
+type RejectApprovalRequest struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+// approvalView is an approval request with its params and result as JSON
+type approvalView struct {
+	*models.ApprovalRequest
+	Params json.RawMessage        `json:"params"`
+	Result json.RawMessage        `json:"result,omitempty"`
+	Events []models.ApprovalEvent `json:"events,omitempty"`
+}
+
+func newApprovalView(request *models.ApprovalRequest) approvalView {
+	view := approvalView{ApprovalRequest: request, Params: json.RawMessage(request.Params)}
+	if request.Result != "" {
+		view.Result = json.RawMessage(request.Result)
+	}
+	return view
+}
+
+// ListApprovals lists approval requests, optionally filtered by status
+// (GET /api/admin/approvals?status=pending)
+func (ah *ApprovalHandler) ListApprovals(w http.ResponseWriter, r *http.Request) {
+	offset, limit := parsePagination(r)
+
+	if _, err := ah.service.ExpireStale(r.Context()); err != nil {
+		ah.logger.Error("Failed to expire approval requests", "error", err.Error())
+	}
+	requests, err := ah.repo.ListRequests(r.Context(), r.URL.Query().Get("status"), offset, limit)
+	if err != nil {
+		ah.logger.Error("Failed to list approval requests", "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve approval requests", "DATABASE_ERROR")
+		return
+	}
+
+	views := make([]approvalView, len(requests))
+	for i := range requests {
+		views[i] = newApprovalView(&requests[i])
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"approvals": views,
+		"pagination": map[string]interface{}{
+			"offset": offset,
+			"limit":  limit,
+		},
+	})
+}
+
+// GetApproval returns an approval request with its audit history
+// (GET /api/admin/approvals/{id})
+func (ah *ApprovalHandler) GetApproval(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDFromPath(r.URL.Path, "/api/admin/approvals/", "")
+	if err != nil {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid approval ID", "INVALID_APPROVAL_ID")
+		return
+	}
+
+	request, err := ah.repo.GetRequest(r.Context(), id)
+	if err != nil {
+		ah.writeError(w, err)
+		return
+	}
+	events, err := ah.repo.ListEvents(r.Context(), id)
+	if err != nil {
+		ah.logger.Error("Failed to list approval events", "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve approval history", "DATABASE_ERROR")
+		return
+	}
+
+	view := newApprovalView(request)
+	view.Events = events
+	writeJSON(w, http.StatusOK, view)
+}
+
+// Approve approves a pending request and runs its action
+// (POST /api/admin/approvals/{id}/approve)
+func (ah *ApprovalHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	id, user, ok := ah.decisionTarget(w, r, "/approve")
+	if !ok {
+		return
+	}
+
+	request, err := ah.service.Approve(r.Context(), id, user)
+	if request != nil && err != nil {
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Approved action failed: "+err.Error(), "ACTION_FAILED")
+		return
+	}
+	if err != nil {
+		ah.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newApprovalView(request))
+}
+
+// Reject rejects a pending request
+// (POST /api/admin/approvals/{id}/reject)
+func (ah *ApprovalHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	id, user, ok := ah.decisionTarget(w, r, "/reject")
+	if !ok {
+		return
+	}
+	req, failures := security.Bind[RejectApprovalRequest](r)
+	if len(failures) > 0 {
+		writeValidationErrors(w, failures)
+		return
+	}
+
+	request, err := ah.service.Reject(r.Context(), id, user, req.Reason)
+	if err != nil {
+		ah.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newApprovalView(request))
+}
+
+// decisionTarget reads the approval ID from the path and the deciding user from context
+func (ah *ApprovalHandler) decisionTarget(w http.ResponseWriter, r *http.Request, suffix string) (uint, *models.User, bool) {
+	id, err := parseIDFromPath(r.URL.Path, "/api/admin/approvals/", suffix)
+	if err != nil {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid approval ID", "INVALID_APPROVAL_ID")
+		return 0, nil, false
+	}
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "User not authenticated", "NOT_AUTHENTICATED")
+		return 0, nil, false
+	}
+	return id, user, true
+}
+
+// writeError maps approval service errors to HTTP responses
+func (ah *ApprovalHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case stderrors.Is(err, gorm.ErrRecordNotFound):
+		errors.WriteErrorResponse(w, http.StatusNotFound, "Approval request not found", "APPROVAL_NOT_FOUND")
+	case stderrors.Is(err, approval.ErrSelfApproval), stderrors.Is(err, approval.ErrForbidden):
+		errors.WriteErrorResponse(w, http.StatusForbidden, err.Error(), "APPROVAL_FORBIDDEN")
+	case stderrors.Is(err, approval.ErrNotPending), stderrors.Is(err, approval.ErrExpired):
+		errors.WriteErrorResponse(w, http.StatusConflict, err.Error(), "APPROVAL_CLOSED")
+	default:
+		ah.logger.Error("Approval request failed", "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to process approval request", "DATABASE_ERROR")
+	}
+}
+
+// submitForApproval submits a sensitive action on behalf of the current user.
+// If it needs a second administrator the pending request is returned with 202
+// Accepted; if it ran straight away its result is written instead. An
+// optional reason is read from the reason query parameter.
+func submitForApproval(w http.ResponseWriter, r *http.Request, service *approval.Service, action string, params interface{}, summary string) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "User not authenticated", "NOT_AUTHENTICATED")
+		return
+	}
+
+	reason := strings.TrimSpace(r.URL.Query().Get("reason"))
+	request, err := service.Submit(r.Context(), action, params, summary, userID, reason)
+	switch {
+	case request != nil && request.IsPending():
+		writeJSON(w, http.StatusAccepted, map[string]interface{}{
+			"message":  "Waiting for approval by a second administrator",
+			"approval": newApprovalView(request),
+		})
+	case request != nil && err != nil:
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, err.Error(), "ACTION_FAILED")
+	case err != nil:
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to submit action", "DATABASE_ERROR")
+	default:
+		writeJSON(w, http.StatusOK, json.RawMessage(request.Result))
+	}
+}
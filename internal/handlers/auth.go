@@ -1,20 +1,24 @@
 package handlers
 
 import (
-	"encoding/json"
-	"net"
+	stderrors "errors"
 	"net/http"
-	"strings"
 
 	"go-server/internal/auth"
+	"go-server/internal/clientip"
 	"go-server/internal/errors"
 	"go-server/internal/logger"
-	"go-server/internal/models"
+	"go-server/internal/middleware"
+	"go-server/internal/respond"
+	"go-server/internal/security"
+	"go-server/internal/usernames"
 )
 
-// AuthHandler handles authentication endpoints
+// AuthHandler handles authentication endpoints. Responses use the standard
+// envelope from package respond.
 type AuthHandler struct {
 	authService *auth.AuthService
+	cookies     *auth.SessionCookies
 	logger      logger.Logger
 }
 
@@ -26,80 +30,84 @@ func NewAuthHandler(authService *auth.AuthService, logger logger.Logger) *AuthHa
 	}
 }
 
+// WithSessionCookies lets browser clients sending X-Auth-Transport: cookie
+// receive their token in an HttpOnly session cookie instead of the body
+func (ah *AuthHandler) WithSessionCookies(cookies *auth.SessionCookies) *AuthHandler {
+	ah.cookies = cookies
+	return ah
+}
+
 // Login handles user login
 func (ah *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
-	var req auth.LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		ah.logger.Error("Invalid login request", "error", err.Error())
-		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid request body", "INVALID_REQUEST")
-		return
-	}
-
-	// Validate request
-	if err := validateLoginRequest(&req); err != nil {
-		ah.logger.Error("Login validation failed", "error", err.Error())
-		errors.WriteErrorResponse(w, http.StatusBadRequest, err.Error(), "VALIDATION_ERROR")
+	req, failures := security.Bind[auth.LoginRequest](r)
+	if len(failures) > 0 {
+		ah.logger.Error("Login validation failed", "error", failures[0].Message)
+		respond.ValidationErrors(w, r, failures)
 		return
 	}
 
 	// Get client info
-	ipAddress := getClientIP(r)
+	ipAddress := clientip.Get(r)
 	userAgent := r.Header.Get("User-Agent")
 
 	// Attempt login
 	response, err := ah.authService.Login(r.Context(), &req, ipAddress, userAgent)
 	if err != nil {
 		ah.logger.Error("Login failed", "email", req.Email, "error", err.Error())
-		errors.WriteErrorResponse(w, http.StatusUnauthorized, "Invalid credentials", "LOGIN_FAILED")
+		respond.Error(w, r, http.StatusUnauthorized, "Invalid credentials", "LOGIN_FAILED")
 		return
 	}
 
 	ah.logger.Info("User logged in successfully", "user_id", response.User.ID, "email", response.User.Email)
 
-	// Write response
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	if ah.cookies != nil && ah.cookies.Requested(r) && !ah.setCookies(w, r, response) {
+		return
+	}
+	respond.OK(w, r, response)
 }
 
 // Register handles user registration
 func (ah *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
-	var req auth.RegisterRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		ah.logger.Error("Invalid registration request", "error", err.Error())
-		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid request body", "INVALID_REQUEST")
+	req, failures := security.Bind[auth.RegisterRequest](r)
+	if len(failures) > 0 {
+		ah.logger.Error("Registration validation failed", "error", failures[0].Message)
+		respond.ValidationErrors(w, r, failures)
 		return
 	}
 
-	// Validate request
-	if err := validateRegisterRequest(&req); err != nil {
-		ah.logger.Error("Registration validation failed", "error", err.Error())
-		errors.WriteErrorResponse(w, http.StatusBadRequest, err.Error(), "VALIDATION_ERROR")
+	// Attempt registration
+	response, err := ah.authService.Register(r.Context(), &req, clientip.Get(r), r.Header.Get("User-Agent"))
+	var violation *usernames.Violation
+	if stderrors.As(err, &violation) {
+		ah.logger.Warn("Registration refused username", "username", req.Username, "reason", string(violation.Reason))
+		respond.APIError(w, r, errors.NewAPIErrorWithCode(errors.ErrorTypeValidation, "USERNAME_NOT_ALLOWED", violation.Error(),
+			http.StatusUnprocessableEntity).WithExtension("reason", violation.Reason))
 		return
 	}
-
-	// Attempt registration
-	response, err := ah.authService.Register(r.Context(), &req)
 	if err != nil {
 		ah.logger.Error("Registration failed", "email", req.Email, "error", err.Error())
-		errors.WriteErrorResponse(w, http.StatusConflict, err.Error(), "REGISTRATION_FAILED")
+		respond.Error(w, r, http.StatusConflict, err.Error(), "REGISTRATION_FAILED")
 		return
 	}
 
 	ah.logger.Info("User registered successfully", "user_id", response.User.ID, "email", response.User.Email)
 
-	// Write response
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(response)
+	if ah.cookies != nil && ah.cookies.Requested(r) && !ah.setCookies(w, r, response) {
+		return
+	}
+	respond.Created(w, r, response)
 }
 
 // Logout handles user logout
 func (ah *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if ah.cookies != nil {
+		ah.cookies.Clear(w)
+	}
+
 	// Get user from context (set by auth middleware)
-	user, ok := r.Context().Value("user").(*auth.AuthResponse)
+	user, ok := middleware.GetUserFromContext(r.Context())
 	if !ok {
-		errors.WriteErrorResponse(w, http.StatusUnauthorized, "User not authenticated", "NOT_AUTHENTICATED")
+		respond.Error(w, r, http.StatusUnauthorized, "User not authenticated", "NOT_AUTHENTICATED")
 		return
 	}
 
@@ -114,28 +122,28 @@ func (ah *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 
 	if sessionID != "" {
 		// Logout with session
-		if err := ah.authService.Logout(r.Context(), user.User.ID, sessionID); err != nil {
-			ah.logger.Error("Logout failed", "user_id", user.User.ID, "error", err.Error())
+		if err := ah.authService.Logout(r.Context(), user.ID, sessionID); err != nil {
+			ah.logger.Error("Logout failed", "user_id", user.ID, "error", err.Error())
 			// Don't fail logout if session cleanup fails
 		}
 	}
 
-	ah.logger.Info("User logged out successfully", "user_id", user.User.ID)
-
-	// Write success response
-	response := models.NewSuccessResponse("Logged out successfully", nil)
+	ah.logger.Info("User logged out successfully", "user_id", user.ID)
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	respond.Message(w, r, "Logged out successfully")
 }
 
-// RefreshToken handles token refresh
+// RefreshToken handles token refresh. A token sent in the session cookie
+// is refreshed in the cookie.
 func (ah *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 	// Get current token from Authorization header
 	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" && ah.cookies != nil && ah.cookies.Token(r) != "" {
+		ah.refreshCookie(w, r)
+		return
+	}
 	if authHeader == "" {
-		errors.WriteErrorResponse(w, http.StatusBadRequest, "Authorization header required", "NO_AUTH_HEADER")
+		respond.Error(w, r, http.StatusBadRequest, "Authorization header required", "NO_AUTH_HEADER")
 		return
 	}
 
@@ -149,89 +157,56 @@ func (ah *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 	response, err := ah.authService.RefreshToken(r.Context(), token)
 	if err != nil {
 		ah.logger.Error("Token refresh failed", "error", err.Error())
-		errors.WriteErrorResponse(w, http.StatusUnauthorized, "Invalid token", "REFRESH_FAILED")
+		respond.Error(w, r, http.StatusUnauthorized, "Invalid token", "REFRESH_FAILED")
 		return
 	}
 
 	ah.logger.Info("Token refreshed successfully", "user_id", response.User.ID)
 
-	// Write response
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	respond.OK(w, r, response)
 }
 
-// GetProfile returns the current user's profile
-func (ah *AuthHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
-	// Get user from context (set by auth middleware)
-	user, ok := r.Context().Value("user").(*auth.AuthResponse)
-	if !ok {
-		errors.WriteErrorResponse(w, http.StatusUnauthorized, "User not authenticated", "NOT_AUTHENTICATED")
+// refreshCookie refreshes the token in the session cookie
+func (ah *AuthHandler) refreshCookie(w http.ResponseWriter, r *http.Request) {
+	if !ah.cookies.VerifyCSRF(r) {
+		respond.Error(w, r, http.StatusForbidden, "CSRF token missing or invalid", "CSRF_TOKEN_INVALID")
 		return
 	}
 
-	// Write response
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(user.User)
-}
-
-// Validation functions
-func validateLoginRequest(req *auth.LoginRequest) error {
-	if req.Email == "" {
-		return errors.NewValidationError("email", "Email is required")
-	}
-	if req.Password == "" {
-		return errors.NewValidationError("password", "Password is required")
-	}
-	if len(req.Password) < 6 {
-		return errors.NewValidationError("password", "Password must be at least 6 characters")
+	response, err := ah.authService.RefreshToken(r.Context(), ah.cookies.Token(r))
+	if err != nil {
+		ah.logger.Error("Token refresh failed", "error", err.Error())
+		ah.cookies.Clear(w)
+		respond.Error(w, r, http.StatusUnauthorized, "Invalid token", "REFRESH_FAILED")
+		return
 	}
-	return nil
-}
 
-func validateRegisterRequest(req *auth.RegisterRequest) error {
-	if req.Email == "" {
-		return errors.NewValidationError("email", "Email is required")
-	}
-	if req.Username == "" {
-		return errors.NewValidationError("username", "Username is required")
-	}
-	if len(req.Username) < 3 {
-		return errors.NewValidationError("username", "Username must be at least 3 characters")
-	}
-	if len(req.Username) > 20 {
-		return errors.NewValidationError("username", "Username must be at most 20 characters")
-	}
-	if req.Password == "" {
-		return errors.NewValidationError("password", "Password is required")
-	}
-	if len(req.Password) < 6 {
-		return errors.NewValidationError("password", "Password must be at least 6 characters")
+	ah.logger.Info("Token refreshed successfully", "user_id", response.User.ID)
+
+	if ah.setCookies(w, r, response) {
+		respond.OK(w, r, response)
 	}
-	return nil
 }
 
-// Helper function to get client IP
-func getClientIP(r *http.Request) string {
-	// Check X-Forwarded-For header first
-	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		// Take the first IP in the list
-		if idx := strings.Index(xff, ","); idx != -1 {
-			return strings.TrimSpace(xff[:idx])
-		}
-		return strings.TrimSpace(xff)
+// setCookies moves response's token into the session cookie, writing an
+// error response if it cannot
+func (ah *AuthHandler) setCookies(w http.ResponseWriter, r *http.Request, response *auth.AuthResponse) bool {
+	if err := ah.cookies.Set(w, response); err != nil {
+		ah.logger.Error("Failed to set session cookie", "error", err.Error())
+		respond.Error(w, r, http.StatusInternalServerError, "Failed to start session", "TOKEN_ERROR")
+		return false
 	}
+	return true
+}
 
-	// Check X-Real-IP header
-	if xri := r.Header.Get("X-Real-IP"); xri != "" {
-		return xri
+// GetProfile returns the current user's profile
+func (ah *AuthHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	// Get user from context (set by auth middleware)
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		respond.Error(w, r, http.StatusUnauthorized, "User not authenticated", "NOT_AUTHENTICATED")
+		return
 	}
 
-	// Fall back to RemoteAddr
-	ip, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err != nil {
-		return r.RemoteAddr
-	}
-	return ip
+	respond.OK(w, r, user)
 }
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	stderrors "errors"
+	"net/http"
+
+	"go-server/internal/breakglass"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/errors"
+	"go-server/internal/logger"
+	"go-server/internal/middleware"
+
+	"gorm.io/gorm"
+)
+
+// BreakGlassHandler handles the admin endpoints for auditing and revoking
+// emergency break-glass access
+type BreakGlassHandler struct {
+	service *breakglass.Service
+	repo    *repositories.BreakGlassRepository
+	logger  logger.Logger
+}
+
+// NewBreakGlassHandler creates a new break-glass handler
+func NewBreakGlassHandler(service *breakglass.Service, repo *repositories.BreakGlassRepository, logger logger.Logger) *BreakGlassHandler {
+	return &BreakGlassHandler{
+		service: service,
+		repo:    repo,
+		logger:  logger,
+	}
+}
+
+// ListGrants lists the break-glass tokens that have been used
+// (GET /api/admin/break-glass, requires system:configure)
+func (bh *BreakGlassHandler) ListGrants(w http.ResponseWriter, r *http.Request) {
+	offset, limit := parsePagination(r)
+
+	grants, err := bh.repo.ListGrants(r.Context(), offset, limit)
+	if err != nil {
+		bh.logger.Error("Failed to list break-glass grants", "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve break-glass grants", "DATABASE_ERROR")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"grants": grants,
+		"pagination": map[string]interface{}{
+			"offset": offset,
+			"limit":  limit,
+		},
+	})
+}
+
+// GetGrant returns a grant with the requests made under it
+// (GET /api/admin/break-glass/{id}, requires system:configure)
+func (bh *BreakGlassHandler) GetGrant(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDFromPath(r.URL.Path, "/api/admin/break-glass/", "")
+	if err != nil {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid grant ID", "INVALID_GRANT_ID")
+		return
+	}
+	offset, limit := parsePagination(r)
+
+	grant, err := bh.repo.GetGrant(r.Context(), id)
+	if stderrors.Is(err, gorm.ErrRecordNotFound) {
+		errors.WriteErrorResponse(w, http.StatusNotFound, "Break-glass grant not found", "GRANT_NOT_FOUND")
+		return
+	}
+	if err != nil {
+		bh.logger.Error("Failed to get break-glass grant", "grant_id", id, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve break-glass grant", "DATABASE_ERROR")
+		return
+	}
+	accesses, err := bh.repo.ListAccesses(r.Context(), id, offset, limit)
+	if err != nil {
+		bh.logger.Error("Failed to list break-glass accesses", "grant_id", id, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve break-glass accesses", "DATABASE_ERROR")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, struct {
+		*models.BreakGlassGrant
+		Accesses []models.BreakGlassAccess `json:"accesses"`
+	}{grant, accesses})
+}
+
+// RevokeGrant stops a break-glass token from being accepted before it expires
+// (POST /api/admin/break-glass/{id}/revoke, requires system:configure)
+func (bh *BreakGlassHandler) RevokeGrant(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDFromPath(r.URL.Path, "/api/admin/break-glass/", "/revoke")
+	if err != nil {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid grant ID", "INVALID_GRANT_ID")
+		return
+	}
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "User not authenticated", "NOT_AUTHENTICATED")
+		return
+	}
+	if user.ID == 0 {
+		// Break-glass sessions have no user row to attribute the revocation to
+		errors.WriteErrorResponse(w, http.StatusForbidden, "Break-glass sessions cannot revoke grants", "BREAK_GLASS_FORBIDDEN")
+		return
+	}
+
+	revoked, err := bh.service.Revoke(r.Context(), id, user)
+	if err != nil {
+		bh.logger.Error("Failed to revoke break-glass grant", "grant_id", id, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to revoke break-glass grant", "DATABASE_ERROR")
+		return
+	}
+	if !revoked {
+		errors.WriteErrorResponse(w, http.StatusNotFound, "Break-glass grant not found or already revoked", "GRANT_NOT_FOUND")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"message":  "Break-glass grant revoked",
+		"grant_id": id,
+	})
+}
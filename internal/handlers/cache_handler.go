@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"go-server/internal/approval"
+	"go-server/internal/database/repositories"
+	"go-server/internal/errors"
+	"go-server/internal/logger"
+	"go-server/internal/rbac"
+)
+
+// CacheHandler handles admin cache maintenance endpoints
+type CacheHandler struct {
+	cacheRepo *repositories.CacheRepository
+	logger    logger.Logger
+	approvals *approval.Service
+}
+
+// NewCacheHandler creates a new cache handler
+func NewCacheHandler(cacheRepo *repositories.CacheRepository, logger logger.Logger) *CacheHandler {
+	return &CacheHandler{
+		cacheRepo: cacheRepo,
+		logger:    logger,
+	}
+}
+
+// WithApprovals routes cache flushes through the approval workflow
+func (ch *CacheHandler) WithApprovals(service *approval.Service) *CacheHandler {
+	ch.approvals = service
+	service.Register(approval.ActionFlushCache, rbac.SystemConfigure, ch.executeFlush)
+	return ch
+}
+
+// FlushCache clears every cache entry, logging out cached sessions
+// (POST /api/admin/cache/flush, requires system:configure)
+func (ch *CacheHandler) FlushCache(w http.ResponseWriter, r *http.Request) {
+	if ch.approvals != nil {
+		submitForApproval(w, r, ch.approvals, approval.ActionFlushCache, struct{}{}, "Flush the entire cache")
+		return
+	}
+
+	result, err := ch.executeFlush(r.Context(), nil)
+	if err != nil {
+		ch.logger.Error("Failed to flush cache", "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to flush cache", "CACHE_ERROR")
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// executeFlush flushes the cache once approved
+func (ch *CacheHandler) executeFlush(ctx context.Context, _ json.RawMessage) (interface{}, error) {
+	if err := ch.cacheRepo.FlushAll(ctx); err != nil {
+		return nil, err
+	}
+	ch.logger.Info("Cache flushed")
+	return map[string]interface{}{"flushed": true}, nil
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	stderrors "errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-server/internal/calendar"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/errors"
+	"go-server/internal/logger"
+	"go-server/internal/middleware"
+	"go-server/internal/rbac"
+	"go-server/internal/security"
+
+	"gorm.io/gorm"
+)
+
+// calendarFeedPath is where calendar apps fetch the feed
+const calendarFeedPath = "/api/calendar/feed.ics"
+
+// CalendarHandler serves the editorial calendar feed and manages
+// maintenance windows
+type CalendarHandler struct {
+	feed        *calendar.Feed
+	signer      *security.LinkSigner
+	userRepo    *repositories.UserRepository
+	windowsRepo *repositories.MaintenanceRepository
+	logger      logger.Logger
+}
+
+// NewCalendarHandler creates a new calendar handler
+func NewCalendarHandler(feed *calendar.Feed, signer *security.LinkSigner, userRepo *repositories.UserRepository, windowsRepo *repositories.MaintenanceRepository, logger logger.Logger) *CalendarHandler {
+	return &CalendarHandler{
+		feed:        feed,
+		signer:      signer,
+		userRepo:    userRepo,
+		windowsRepo: windowsRepo,
+		logger:      logger,
+	}
+}
+
+// CreateMaintenanceWindowRequest represents a request to plan a maintenance window
+type CreateMaintenanceWindowRequest struct {
+	Title       string    `json:"title" validate:"required,max=200"`
+	Description string    `json:"description" validate:"max=2000"`
+	StartsAt    time.Time `json:"starts_at" validate:"required"`
+	EndsAt      time.Time `json:"ends_at" validate:"required"`
+}
+
+// FeedLink issues the caller a signed feed URL to subscribe to in a
+// calendar app (POST /api/calendar/feed-link, requires calendar:read)
+func (ch *CalendarHandler) FeedLink(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "Authentication required", "NO_TOKEN")
+		return
+	}
+
+	query, expiresAt := ch.signer.Sign(userID)
+	link := map[string]interface{}{
+		"url": requestBaseURL(r) + calendarFeedPath + "?" + query.Encode(),
+	}
+	if !expiresAt.IsZero() {
+		link["expires_at"] = expiresAt
+	}
+	writeJSON(w, http.StatusOK, link)
+}
+
+// Feed renders the calendar of scheduled posts and maintenance windows
+// (GET /api/calendar/feed.ics?user=&expires=&signature=). The signed link
+// stands in for authentication; the user must still hold calendar:read.
+func (ch *CalendarHandler) Feed(w http.ResponseWriter, r *http.Request) {
+	userID, err := ch.signer.Verify(r.URL.Query())
+	if stderrors.Is(err, security.ErrLinkExpired) {
+		errors.WriteErrorResponse(w, http.StatusForbidden, "Calendar link has expired", "CALENDAR_LINK_EXPIRED")
+		return
+	}
+	if err != nil {
+		errors.WriteErrorResponse(w, http.StatusForbidden, "Invalid calendar link", "INVALID_CALENDAR_LINK")
+		return
+	}
+
+	user, err := ch.userRepo.GetUserByID(r.Context(), userID)
+	if err != nil && !stderrors.Is(err, gorm.ErrRecordNotFound) {
+		ch.logger.Error("Failed to load calendar subscriber: %v", err)
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to build calendar", "DATABASE_ERROR")
+		return
+	}
+	if err != nil || !user.IsActive || !rbac.UserCan(user, rbac.CalendarRead) {
+		errors.WriteErrorResponse(w, http.StatusForbidden, "Invalid calendar link", "INVALID_CALENDAR_LINK")
+		return
+	}
+
+	cal, err := ch.feed.Build(r.Context())
+	if err != nil {
+		ch.logger.Error("Failed to build calendar feed: %v", err)
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to build calendar", "DATABASE_ERROR")
+		return
+	}
+	w.Header().Set("Content-Type", calendar.ContentType)
+	// The link is a credential; keep the feed out of shared caches
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	cal.WriteTo(w)
+}
+
+// ListMaintenanceWindows lists windows that have not ended yet, or all
+// windows with ?all=true (GET /api/admin/maintenance-windows)
+func (ch *CalendarHandler) ListMaintenanceWindows(w http.ResponseWriter, r *http.Request) {
+	from := time.Now()
+	if r.URL.Query().Get("all") == "true" {
+		from = time.Time{}
+	}
+	windows, err := ch.windowsRepo.ListWindows(r.Context(), from, time.Time{})
+	if err != nil {
+		ch.logger.Error("Failed to list maintenance windows: %v", err)
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve maintenance windows", "DATABASE_ERROR")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"maintenance_windows": windows})
+}
+
+// CreateMaintenanceWindow plans a maintenance window
+// (POST /api/admin/maintenance-windows, requires system:configure)
+func (ch *CalendarHandler) CreateMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "Authentication required", "NO_TOKEN")
+		return
+	}
+	req, failures := security.Bind[CreateMaintenanceWindowRequest](r)
+	if len(failures) > 0 {
+		writeValidationErrors(w, failures)
+		return
+	}
+	if !req.EndsAt.After(req.StartsAt) {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "The window must end after it starts", "INVALID_MAINTENANCE_WINDOW")
+		return
+	}
+
+	window := &models.MaintenanceWindow{
+		Title:       strings.TrimSpace(req.Title),
+		Description: req.Description,
+		StartsAt:    req.StartsAt.UTC(),
+		EndsAt:      req.EndsAt.UTC(),
+		CreatedByID: userID,
+	}
+	if err := ch.windowsRepo.CreateWindow(r.Context(), window); err != nil {
+		ch.logger.Error("Failed to create maintenance window: %v", err)
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to create maintenance window", "DATABASE_ERROR")
+		return
+	}
+	writeJSON(w, http.StatusCreated, window)
+}
+
+// DeleteMaintenanceWindow cancels a maintenance window
+// (DELETE /api/admin/maintenance-windows/{id}, requires system:configure)
+func (ch *CalendarHandler) DeleteMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDFromPath(r.URL.Path, "/api/admin/maintenance-windows/", "")
+	if err != nil {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid maintenance window ID", "INVALID_MAINTENANCE_WINDOW")
+		return
+	}
+	err = ch.windowsRepo.DeleteWindow(r.Context(), id)
+	if stderrors.Is(err, gorm.ErrRecordNotFound) {
+		errors.WriteErrorResponse(w, http.StatusNotFound, "Maintenance window not found", "MAINTENANCE_WINDOW_NOT_FOUND")
+		return
+	}
+	if err != nil {
+		ch.logger.Error("Failed to delete maintenance window: %v", err)
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to delete maintenance window", "DATABASE_ERROR")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
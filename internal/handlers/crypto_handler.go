@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"net/http"
+
+	"go-server/internal/cryptopolicy"
+)
+
+// CryptoHandler reports the crypto policy in force and the algorithms the
+// server uses, for compliance reviews
+type CryptoHandler struct {
+	uses []cryptopolicy.Use
+}
+
+// NewCryptoHandler creates a new crypto handler reporting uses, see
+// config.Config.CryptoUses
+func NewCryptoHandler(uses []cryptopolicy.Use) *CryptoHandler {
+	return &CryptoHandler{uses: uses}
+}
+
+// GetReport returns the mode, whether the FIPS 140-3 module is enabled,
+// the algorithms in use and the TLS settings
+// (GET /api/admin/crypto, requires system:configure)
+func (ch *CryptoHandler) GetReport(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, cryptopolicy.NewReport(ch.uses))
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	stderrors "errors"
+	"net/http"
+	"strconv"
+
+	"go-server/internal/auth"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/deactivation"
+	"go-server/internal/errors"
+	"go-server/internal/logger"
+	"go-server/internal/middleware"
+	"go-server/internal/security"
+)
+
+// DeactivationHandler handles account deactivation and reactivation, by
+// users themselves and by staff
+type DeactivationHandler struct {
+	service  *deactivation.Service
+	userRepo *repositories.UserRepository
+	logger   logger.Logger
+}
+
+// NewDeactivationHandler creates a new deactivation handler
+func NewDeactivationHandler(service *deactivation.Service, userRepo *repositories.UserRepository, logger logger.Logger) *DeactivationHandler {
+	return &DeactivationHandler{
+		service:  service,
+		userRepo: userRepo,
+		logger:   logger,
+	}
+}
+
+// DeactivateOwnAccount deactivates the current user's account and signs it
+// out everywhere
+// (POST /api/users/me/deactivate)
+func (dh *DeactivationHandler) DeactivateOwnAccount(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "User not authenticated", "NOT_AUTHENTICATED")
+		return
+	}
+
+	err := dh.service.Deactivate(r.Context(), user, user.ID)
+	if !dh.writeError(w, err, "Failed to deactivate account") {
+		return
+	}
+	writeJSON(w, http.StatusOK, user)
+}
+
+// ReactivateAccountRequest represents users reactivating their own account.
+// They cannot sign in while it is deactivated, so the request carries their
+// credentials.
+type ReactivateAccountRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+// ReactivateOwnAccount reactivates an account its owner deactivated, once
+// the cool-down has passed. The credentials authenticate the request; the
+// user signs in again afterwards.
+// (POST /api/auth/reactivate)
+func (dh *DeactivationHandler) ReactivateOwnAccount(w http.ResponseWriter, r *http.Request) {
+	req, failures := security.Bind[ReactivateAccountRequest](r)
+	if len(failures) > 0 {
+		writeValidationErrors(w, failures)
+		return
+	}
+
+	user, err := dh.userRepo.GetUserByEmail(r.Context(), req.Email)
+	if err != nil || !auth.CheckPasswordHash(req.Password, user.Password) {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "Invalid credentials", "INVALID_CREDENTIALS")
+		return
+	}
+
+	err = dh.service.Reactivate(r.Context(), user, user.ID)
+	if !dh.writeError(w, err, "Failed to reactivate account") {
+		return
+	}
+	writeJSON(w, http.StatusOK, user)
+}
+
+// DeactivateUser deactivates a user's account
+// (POST /api/admin/users/{id}/deactivate, requires users:manage)
+func (dh *DeactivationHandler) DeactivateUser(w http.ResponseWriter, r *http.Request) {
+	user, adminID, ok := dh.targetUser(w, r, "/deactivate")
+	if !ok {
+		return
+	}
+
+	err := dh.service.Deactivate(r.Context(), user, adminID)
+	if !dh.writeError(w, err, "Failed to deactivate account") {
+		return
+	}
+	writeJSON(w, http.StatusOK, user)
+}
+
+// ReactivateUser reactivates a user's account, however it was deactivated
+// (POST /api/admin/users/{id}/reactivate, requires users:manage)
+func (dh *DeactivationHandler) ReactivateUser(w http.ResponseWriter, r *http.Request) {
+	user, adminID, ok := dh.targetUser(w, r, "/reactivate")
+	if !ok {
+		return
+	}
+
+	err := dh.service.Reactivate(r.Context(), user, adminID)
+	if !dh.writeError(w, err, "Failed to reactivate account") {
+		return
+	}
+	writeJSON(w, http.StatusOK, user)
+}
+
+// targetUser loads the user an admin route addresses, writing an error
+// response if it cannot
+func (dh *DeactivationHandler) targetUser(w http.ResponseWriter, r *http.Request, suffix string) (*models.User, uint, bool) {
+	adminID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "User not authenticated", "NOT_AUTHENTICATED")
+		return nil, 0, false
+	}
+	userID, err := parseIDFromPath(r.URL.Path, "/api/admin/users/", suffix)
+	if err != nil {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid user ID", "INVALID_USER_ID")
+		return nil, 0, false
+	}
+	user, err := dh.userRepo.GetUserByID(r.Context(), userID)
+	if err != nil {
+		errors.WriteErrorResponse(w, http.StatusNotFound, "User not found", "USER_NOT_FOUND")
+		return nil, 0, false
+	}
+	return user, adminID, true
+}
+
+// writeError writes the response for a deactivation error and reports
+// whether err was nil
+func (dh *DeactivationHandler) writeError(w http.ResponseWriter, err error, message string) bool {
+	var cooldown *deactivation.CooldownError
+	switch {
+	case err == nil:
+		return true
+	case stderrors.Is(err, deactivation.ErrAlreadyDeactivated):
+		errors.WriteErrorResponse(w, http.StatusConflict, "Account is already deactivated", "ALREADY_DEACTIVATED")
+	case stderrors.Is(err, deactivation.ErrNotDeactivated):
+		errors.WriteErrorResponse(w, http.StatusConflict, "Account is not deactivated", "NOT_DEACTIVATED")
+	case stderrors.Is(err, deactivation.ErrDeactivatedByStaff):
+		errors.WriteErrorResponse(w, http.StatusForbidden, "Account was deactivated by staff; contact support to reactivate it", "REACTIVATION_FORBIDDEN")
+	case stderrors.As(err, &cooldown):
+		w.Header().Set("Retry-After", strconv.Itoa(int(cooldown.RetryAfter.Seconds())+1))
+		errors.WriteError(w, errors.NewAPIErrorWithCode(errors.ErrorTypeRateLimit, "REACTIVATION_COOLDOWN", cooldown.Error(),
+			http.StatusTooManyRequests).WithExtension("reactivate_after", cooldown.Until.UTC()))
+	default:
+		dh.logger.Error(message, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, message, "DATABASE_ERROR")
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+
+	"go-server/internal/depwatch"
+	"go-server/internal/logger"
+)
+
+// DependencyHandler shows administrators what the dependency check found
+// in the running binary's modules
+type DependencyHandler struct {
+	watcher *depwatch.Watcher
+	logger  logger.Logger
+}
+
+// NewDependencyHandler creates a new dependency handler
+func NewDependencyHandler(watcher *depwatch.Watcher, logger logger.Logger) *DependencyHandler {
+	return &DependencyHandler{watcher: watcher, logger: logger}
+}
+
+// GetReport returns the latest dependency check, optionally filtered by
+// kind and severity, and the check counters. The report is null until the
+// first check, which the dependencies.check runbook action runs on demand
+// (GET /api/admin/dependencies?kind=vulnerability&severity=high, requires system:configure)
+func (dh *DependencyHandler) GetReport(w http.ResponseWriter, r *http.Request) {
+	report := dh.watcher.Latest()
+	kind, severity := r.URL.Query().Get("kind"), r.URL.Query().Get("severity")
+	if report != nil && (kind != "" || severity != "") {
+		filtered := *report
+		filtered.Findings = []depwatch.Finding{}
+		for _, finding := range report.Findings {
+			if (kind == "" || string(finding.Kind) == kind) && (severity == "" || string(finding.Severity) == severity) {
+				filtered.Findings = append(filtered.Findings, finding)
+			}
+		}
+		report = &filtered
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"report": report,
+		"stats":  dh.watcher.Stats(),
+	})
+}
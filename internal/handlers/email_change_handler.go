@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	stderrors "errors"
+	"net/http"
+
+	"go-server/internal/emailchange"
+	"go-server/internal/errors"
+	"go-server/internal/logger"
+	"go-server/internal/middleware"
+	"go-server/internal/security"
+)
+
+// EmailChangeHandler handles the links of the email change flow. Changes
+// are requested through the profile update, see UserHandler.WithEmailChange.
+type EmailChangeHandler struct {
+	service *emailchange.Service
+	logger  logger.Logger
+}
+
+// NewEmailChangeHandler creates a new email change handler
+func NewEmailChangeHandler(service *emailchange.Service, logger logger.Logger) *EmailChangeHandler {
+	return &EmailChangeHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// EmailChangeTokenRequest carries the token from an email change link
+type EmailChangeTokenRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// ConfirmEmailChange applies a pending email change from the link sent to
+// the new address. The token authenticates the request.
+// (POST /api/auth/email-change/confirm)
+func (eh *EmailChangeHandler) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	req, failures := security.Bind[EmailChangeTokenRequest](r)
+	if len(failures) > 0 {
+		writeValidationErrors(w, failures)
+		return
+	}
+
+	user, err := eh.service.Confirm(r.Context(), req.Token)
+	if !eh.writeError(w, err, "Failed to confirm email change") {
+		return
+	}
+	writeJSON(w, http.StatusOK, user)
+}
+
+// RevertEmailChange restores the previous email address from the link sent
+// to it and signs the user out of every session. The token authenticates
+// the request.
+// (POST /api/auth/email-change/revert)
+func (eh *EmailChangeHandler) RevertEmailChange(w http.ResponseWriter, r *http.Request) {
+	req, failures := security.Bind[EmailChangeTokenRequest](r)
+	if len(failures) > 0 {
+		writeValidationErrors(w, failures)
+		return
+	}
+
+	user, err := eh.service.Revert(r.Context(), req.Token)
+	if !eh.writeError(w, err, "Failed to revert email change") {
+		return
+	}
+	writeJSON(w, http.StatusOK, user)
+}
+
+// CancelEmailChange drops the current user's pending email change
+// (DELETE /api/users/me/email-change)
+func (eh *EmailChangeHandler) CancelEmailChange(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "User not authenticated", "NOT_AUTHENTICATED")
+		return
+	}
+
+	if err := eh.service.Cancel(r.Context(), user); err != nil {
+		eh.logger.Error("Failed to cancel email change", "user_id", user.ID, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to cancel email change", "DATABASE_ERROR")
+		return
+	}
+	writeJSON(w, http.StatusOK, user)
+}
+
+// writeError writes the response for a failed confirm or revert, returning
+// false if there was an error
+func (eh *EmailChangeHandler) writeError(w http.ResponseWriter, err error, message string) bool {
+	switch {
+	case err == nil:
+		return true
+	case stderrors.Is(err, emailchange.ErrInvalidToken):
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "The email change link is invalid or expired", "EMAIL_CHANGE_INVALID")
+	case stderrors.Is(err, emailchange.ErrEmailTaken):
+		errors.WriteErrorResponse(w, http.StatusConflict, "Email already taken", "EMAIL_TAKEN")
+	default:
+		eh.logger.Error(message, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, message, "EMAIL_CHANGE_FAILED")
+	}
+	return false
+}
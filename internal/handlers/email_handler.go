@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/errors"
+	"go-server/internal/logger"
+)
+
+// EmailHandler handles email delivery tracking endpoints
+type EmailHandler struct {
+	emailRepo     *repositories.EmailRepository
+	webhookSecret string
+	logger        logger.Logger
+}
+
+// NewEmailHandler creates a new email handler; webhookSecret authenticates bounce notifications
+func NewEmailHandler(emailRepo *repositories.EmailRepository, webhookSecret string, logger logger.Logger) *EmailHandler {
+	return &EmailHandler{
+		emailRepo:     emailRepo,
+		webhookSecret: webhookSecret,
+		logger:        logger,
+	}
+}
+
+// RecordEvent records a bounce or complaint reported by the mail provider
+// (POST /api/email/events). The provider, or a relay translating its format,
+// authenticates with the shared secret in the X-Email-Webhook-Secret header.
+func (eh *EmailHandler) RecordEvent(w http.ResponseWriter, r *http.Request) {
+	secret := r.Header.Get("X-Email-Webhook-Secret")
+	if eh.webhookSecret == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(eh.webhookSecret)) != 1 {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "Invalid webhook secret", "INVALID_WEBHOOK_SECRET")
+		return
+	}
+
+	var req struct {
+		MessageID string `json:"message_id"`
+		Type      string `json:"type"`
+		Reason    string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.MessageID == "" {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid request body", "INVALID_REQUEST")
+		return
+	}
+
+	var status string
+	switch req.Type {
+	case "bounce":
+		status = models.EmailStatusBounced
+	case "complaint":
+		status = models.EmailStatusComplained
+	default:
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Type must be bounce or complaint", "INVALID_EMAIL_EVENT")
+		return
+	}
+
+	// Message-IDs are stored with angle brackets; accept them with or without
+	messageID := "<" + strings.Trim(req.MessageID, "<>") + ">"
+	found, err := eh.emailRepo.MarkEmailBounced(r.Context(), messageID, status, req.Reason, time.Now())
+	if err != nil {
+		eh.logger.Error("Failed to record email event", "message_id", messageID, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to record event", "DATABASE_ERROR")
+		return
+	}
+	if !found {
+		errors.WriteErrorResponse(w, http.StatusNotFound, "Unknown message", "EMAIL_NOT_FOUND")
+		return
+	}
+
+	eh.logger.Info("Email delivery event recorded", "message_id", messageID, "status", status)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListDeliveries returns the emails sent to an address for support lookups
+// (GET /api/admin/emails?recipient=..., requires emails:read)
+func (eh *EmailHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	recipient := strings.TrimSpace(r.URL.Query().Get("recipient"))
+	if recipient == "" {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Recipient is required", "MISSING_RECIPIENT")
+		return
+	}
+	offset, limit := parsePagination(r)
+
+	deliveries, err := eh.emailRepo.ListEmailDeliveries(r.Context(), strings.ToLower(recipient), offset, limit)
+	if err != nil {
+		eh.logger.Error("Failed to list email deliveries", "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve deliveries", "DATABASE_ERROR")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"deliveries": deliveries,
+		"pagination": map[string]interface{}{
+			"offset": offset,
+			"limit":  limit,
+		},
+	})
+}
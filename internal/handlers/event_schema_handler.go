@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	stderrors "errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-server/internal/errors"
+	"go-server/internal/eventschema"
+)
+
+// schemaMaxAge is how long consumers may cache schemas; published versions
+// never change, only new ones are added
+const schemaMaxAge = "300"
+
+// EventSchemaHandler publishes the JSON Schema of every event delivered to
+// webhooks and brokers, so consumers can validate payloads and generate types
+type EventSchemaHandler struct {
+	registry *eventschema.Registry
+}
+
+// NewEventSchemaHandler creates a new event schema handler
+func NewEventSchemaHandler(registry *eventschema.Registry) *EventSchemaHandler {
+	return &EventSchemaHandler{registry: registry}
+}
+
+// eventSchemaView is the latest schema of an event type with the versions
+// that can still be fetched
+type eventSchemaView struct {
+	EventType string              `json:"event_type"`
+	Version   int                 `json:"version"`
+	Versions  []int               `json:"versions"`
+	Schema    *eventschema.Schema `json:"schema"`
+}
+
+// ListSchemas returns the latest schema of every event type
+// (GET /api/events/schemas)
+func (eh *EventSchemaHandler) ListSchemas(w http.ResponseWriter, r *http.Request) {
+	views := []eventSchemaView{}
+	for _, eventType := range eh.registry.EventTypes() {
+		entries := eh.registry.Versions(eventType)
+		view := eventSchemaView{EventType: eventType}
+		for _, entry := range entries {
+			view.Versions = append(view.Versions, entry.Version)
+		}
+		latest := entries[len(entries)-1]
+		view.Version, view.Schema = latest.Version, latest.Schema
+		views = append(views, view)
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age="+schemaMaxAge)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"schemas": views,
+	})
+}
+
+// GetSchema returns one event type's schema document, the latest unless a
+// version is requested, ready for validators and code generators
+// (GET /api/events/schemas/{event_type}?version=1)
+func (eh *EventSchemaHandler) GetSchema(w http.ResponseWriter, r *http.Request) {
+	eventType := strings.TrimPrefix(r.URL.Path, "/api/events/schemas/")
+
+	var entry eventschema.Entry
+	var err error
+	if v := r.URL.Query().Get("version"); v != "" {
+		version, convErr := strconv.Atoi(v)
+		if convErr != nil || version < 1 {
+			errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid schema version", "INVALID_SCHEMA_VERSION")
+			return
+		}
+		entry, err = eh.registry.Version(eventType, version)
+	} else {
+		entry, err = eh.registry.Latest(eventType)
+	}
+	if stderrors.Is(err, eventschema.ErrUnknownEvent) {
+		errors.WriteErrorResponse(w, http.StatusNotFound, "No schema for event type "+eventType, "UNKNOWN_EVENT_TYPE")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age="+schemaMaxAge)
+	writeJSON(w, http.StatusOK, entry.Schema)
+}
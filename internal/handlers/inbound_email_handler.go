@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	stderrors "errors"
+	"net/http"
+	"strings"
+
+	"go-server/internal/email/inbound"
+	"go-server/internal/errors"
+	"go-server/internal/logger"
+)
+
+// maxInboundEmailSize bounds webhook bodies; providers post the whole
+// email, attachments included
+const maxInboundEmailSize = 25 << 20
+
+// InboundEmailHandler receives replies forwarded by the mail providers
+type InboundEmailHandler struct {
+	providers map[string]inbound.Provider
+	processor *inbound.Processor
+	logger    logger.Logger
+}
+
+// NewInboundEmailHandler creates a handler accepting emails from providers
+func NewInboundEmailHandler(processor *inbound.Processor, logger logger.Logger, providers ...inbound.Provider) *InboundEmailHandler {
+	byName := make(map[string]inbound.Provider, len(providers))
+	for _, provider := range providers {
+		byName[provider.Name()] = provider
+	}
+	return &InboundEmailHandler{
+		providers: byName,
+		processor: processor,
+		logger:    logger,
+	}
+}
+
+// Receive accepts an email posted by a provider's inbound webhook
+// (POST /api/email/inbound/{provider}, provider is ses, sendgrid or mailgun).
+// Replies that cannot be attributed are answered with 200 and a rejected
+// status, since the provider would otherwise keep redelivering them.
+func (h *InboundEmailHandler) Receive(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/api/email/inbound/")
+	provider, ok := h.providers[name]
+	if !ok {
+		errors.WriteErrorResponse(w, http.StatusNotFound, "Unknown email provider", "UNKNOWN_EMAIL_PROVIDER")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxInboundEmailSize)
+	message, err := provider.Parse(r)
+	switch {
+	case stderrors.Is(err, inbound.ErrInvalidSignature):
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "Invalid webhook signature", "INVALID_EMAIL_SIGNATURE")
+		return
+	case err != nil:
+		h.logger.Warn("Rejected %s inbound email: %v", name, err)
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid inbound email", "INVALID_INBOUND_EMAIL")
+		return
+	case message == nil:
+		// Subscription confirmations and other notifications without an email
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	_, err = h.processor.Process(r.Context(), message)
+	switch {
+	case err == nil:
+		writeJSON(w, http.StatusOK, map[string]string{"status": "accepted"})
+	case stderrors.Is(err, inbound.ErrUnknownAddress),
+		stderrors.Is(err, inbound.ErrSenderMismatch),
+		stderrors.Is(err, inbound.ErrEmptyReply),
+		stderrors.Is(err, inbound.ErrUnknownKind),
+		stderrors.Is(err, inbound.ErrTargetNotFound):
+		h.logger.Warn("Ignored %s inbound email %s: %v", name, message.MessageID, err)
+		writeJSON(w, http.StatusOK, map[string]string{"status": "rejected", "reason": err.Error()})
+	default:
+		h.logger.Error("Failed to process %s inbound email %s: %v", name, message.MessageID, err)
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to process email", "INBOUND_EMAIL_FAILED")
+	}
+}
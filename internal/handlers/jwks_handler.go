@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+
+	"go-server/internal/auth"
+)
+
+// jwksMaxAge is how long verifiers may cache the key set. Scheduled keys
+// are published well before they sign, so a cached copy stays usable.
+const jwksMaxAge = "300"
+
+// JWKSHandler publishes the public keys tokens are signed with, so other
+// services can verify RS256 and EdDSA tokens without sharing a secret
+type JWKSHandler struct {
+	jwtManager *auth.JWTManager
+}
+
+// NewJWKSHandler creates a new JWKS handler
+func NewJWKSHandler(jwtManager *auth.JWTManager) *JWKSHandler {
+	return &JWKSHandler{jwtManager: jwtManager}
+}
+
+// GetKeys returns the key set; it is empty while tokens are signed with
+// HMAC keys (GET /.well-known/jwks.json)
+func (jh *JWKSHandler) GetKeys(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age="+jwksMaxAge)
+	writeJSON(w, http.StatusOK, jh.jwtManager.JWKS())
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	stderrors "errors"
+	"net/http"
+	"strings"
+
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/errors"
+	"go-server/internal/legalhold"
+	"go-server/internal/logger"
+	"go-server/internal/middleware"
+	"go-server/internal/security"
+
+	"gorm.io/gorm"
+)
+
+// LegalHoldHandler lets administrators place and release legal holds and
+// read the audit log of held subjects
+type LegalHoldHandler struct {
+	service *legalhold.Service
+	repo    *repositories.LegalHoldRepository
+	logger  logger.Logger
+}
+
+// NewLegalHoldHandler creates a new legal hold handler
+func NewLegalHoldHandler(service *legalhold.Service, repo *repositories.LegalHoldRepository, logger logger.Logger) *LegalHoldHandler {
+	return &LegalHoldHandler{
+		service: service,
+		repo:    repo,
+		logger:  logger,
+	}
+}
+
+// PlaceLegalHoldRequest represents placing a user or a post under hold
+type PlaceLegalHoldRequest struct {
+	SubjectType   string `json:"subject_type" validate:"required,oneof=user post"`
+	SubjectID     uint   `json:"subject_id" validate:"required"`
+	Reason        string `json:"reason" validate:"required,max=1000"`
+	CaseReference string `json:"case_reference" validate:"max=255"`
+}
+
+// PlaceHold places a user or a post under legal hold
+// (POST /api/admin/legal-holds, requires legal_holds:manage)
+func (lh *LegalHoldHandler) PlaceHold(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "User not authenticated", "NOT_AUTHENTICATED")
+		return
+	}
+	req, failures := security.Bind[PlaceLegalHoldRequest](r)
+	if len(failures) > 0 {
+		writeValidationErrors(w, failures)
+		return
+	}
+
+	hold := &models.LegalHold{
+		SubjectType:   req.SubjectType,
+		SubjectID:     req.SubjectID,
+		Reason:        strings.TrimSpace(req.Reason),
+		CaseReference: strings.TrimSpace(req.CaseReference),
+		PlacedByID:    adminID,
+	}
+	err := lh.service.Place(r.Context(), hold)
+	switch {
+	case stderrors.Is(err, legalhold.ErrUnknownSubjectType):
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Legal holds can only be placed on a user or a post", "INVALID_HOLD_SUBJECT")
+		return
+	case stderrors.Is(err, legalhold.ErrSubjectNotFound):
+		errors.WriteErrorResponse(w, http.StatusNotFound, "The subject of the hold does not exist", "HOLD_SUBJECT_NOT_FOUND")
+		return
+	case err != nil:
+		lh.logger.Error("Failed to place legal hold", "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to place legal hold", "DATABASE_ERROR")
+		return
+	}
+	writeJSON(w, http.StatusCreated, hold)
+}
+
+// ListHolds lists legal holds, newest first
+// (GET /api/admin/legal-holds?active=&offset=&limit=, requires legal_holds:manage)
+func (lh *LegalHoldHandler) ListHolds(w http.ResponseWriter, r *http.Request) {
+	activeOnly := r.URL.Query().Get("active") == "true"
+	offset, limit := parsePagination(r)
+	holds, err := lh.repo.ListHolds(r.Context(), activeOnly, offset, limit)
+	if err != nil {
+		lh.logger.Error("Failed to list legal holds", "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve legal holds", "DATABASE_ERROR")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"holds":  holds,
+		"offset": offset,
+		"limit":  limit,
+	})
+}
+
+// ReleaseHold releases an active legal hold
+// (POST /api/admin/legal-holds/{id}/release, requires legal_holds:manage)
+func (lh *LegalHoldHandler) ReleaseHold(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "User not authenticated", "NOT_AUTHENTICATED")
+		return
+	}
+	id, err := parseIDFromPath(r.URL.Path, "/api/admin/legal-holds/", "/release")
+	if err != nil {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid legal hold ID", "INVALID_LEGAL_HOLD_ID")
+		return
+	}
+
+	hold, err := lh.service.Release(r.Context(), id, adminID)
+	switch {
+	case stderrors.Is(err, legalhold.ErrHoldNotFound):
+		errors.WriteErrorResponse(w, http.StatusNotFound, "Legal hold not found", "LEGAL_HOLD_NOT_FOUND")
+		return
+	case stderrors.Is(err, legalhold.ErrHoldReleased):
+		errors.WriteErrorResponse(w, http.StatusConflict, "Legal hold is already released", "LEGAL_HOLD_RELEASED")
+		return
+	case err != nil:
+		lh.logger.Error("Failed to release legal hold", "hold_id", id, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to release legal hold", "DATABASE_ERROR")
+		return
+	}
+	writeJSON(w, http.StatusOK, hold)
+}
+
+// ListHoldEvents lists the audit log of a hold's subject, oldest first,
+// including what was logged under earlier holds on it
+// (GET /api/admin/legal-holds/{id}/events?offset=&limit=, requires legal_holds:manage)
+func (lh *LegalHoldHandler) ListHoldEvents(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDFromPath(r.URL.Path, "/api/admin/legal-holds/", "/events")
+	if err != nil {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid legal hold ID", "INVALID_LEGAL_HOLD_ID")
+		return
+	}
+	hold, err := lh.repo.GetHold(r.Context(), id)
+	if stderrors.Is(err, gorm.ErrRecordNotFound) {
+		errors.WriteErrorResponse(w, http.StatusNotFound, "Legal hold not found", "LEGAL_HOLD_NOT_FOUND")
+		return
+	}
+	if err != nil {
+		lh.logger.Error("Failed to get legal hold", "hold_id", id, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve legal hold", "DATABASE_ERROR")
+		return
+	}
+
+	offset, limit := parsePagination(r)
+	events, err := lh.repo.ListEvents(r.Context(), hold.SubjectType, hold.SubjectID, offset, limit)
+	if err != nil {
+		lh.logger.Error("Failed to list legal hold events", "hold_id", id, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve legal hold events", "DATABASE_ERROR")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"events": events,
+		"offset": offset,
+		"limit":  limit,
+	})
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+
+	"go-server/internal/errors"
+	"go-server/internal/logger"
+	"go-server/internal/middleware"
+	"go-server/internal/security"
+)
+
+// LimitsHandler reports a caller's rate limits and quotas so clients can
+// throttle themselves before they are rejected
+type LimitsHandler struct {
+	registry *security.LimitRegistry
+	logger   logger.Logger
+}
+
+// NewLimitsHandler creates a new limits handler
+func NewLimitsHandler(registry *security.LimitRegistry, logger logger.Logger) *LimitsHandler {
+	return &LimitsHandler{registry: registry, logger: logger}
+}
+
+// UserTier is the security.TierFunc for authenticated users; it must run
+// after OptionalAuth or RequireAuth
+func UserTier(r *http.Request) string {
+	if _, ok := middleware.GetUserIDFromContext(r.Context()); !ok {
+		return security.TierAnonymous
+	}
+	if middleware.IsAdminFromContext(r.Context()) {
+		return security.TierAdmin
+	}
+	return security.TierStandard
+}
+
+// GetLimits returns the current user's tier, rate limits and quotas with
+// what remains of each and when it resets (GET /api/users/me/limits)
+func (lh *LimitsHandler) GetLimits(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "User not authenticated", "NOT_AUTHENTICATED")
+		return
+	}
+
+	quotas, err := lh.registry.Quotas(r.Context(), userID)
+	if err != nil {
+		lh.logger.Error("Failed to read quotas", "user_id", userID, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to read quotas", "QUOTA_ERROR")
+		return
+	}
+
+	limits := lh.registry.RateLimits(r)
+	if limits == nil {
+		limits = []security.LimitStatus{}
+	}
+	if quotas == nil {
+		quotas = []security.LimitStatus{}
+	}
+	// remaining counts change with every request
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, map[string]any{
+		"tier":   lh.registry.Tier(r),
+		"limits": limits,
+		"quotas": quotas,
+	})
+}
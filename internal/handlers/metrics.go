@@ -1,15 +1,33 @@
 package handlers
 
 import (
+	"go-server/internal/abuse"
+	"go-server/internal/cors"
+	"go-server/internal/database"
+	"go-server/internal/database/repositories"
+	"go-server/internal/depwatch"
+	"go-server/internal/geoip"
 	"go-server/internal/interfaces"
+	"go-server/internal/middleware"
 	"go-server/internal/models"
+	"go-server/internal/realtime"
+	"go-server/internal/userindex"
 	"runtime"
 	"time"
 )
 
 // MetricsHandler handles metrics requests
 type MetricsHandler struct {
-	logger interfaces.Logger
+	logger   interfaces.Logger
+	cors     *cors.Engine
+	realtime *realtime.Hub
+	abuse    *abuse.Detector
+	geoip    *geoip.Multipliers
+	users    *userindex.Index
+	cache    *repositories.CacheRepository
+	panics   *middleware.PanicGuard
+	deps     *depwatch.Watcher
+	database *database.DatabaseManager
 }
 
 // NewMetricsHandler creates a new metrics handler
@@ -17,6 +35,65 @@ func NewMetricsHandler(logger interfaces.Logger) *MetricsHandler {
 	return &MetricsHandler{logger: logger}
 }
 
+// WithCORS includes the CORS engine's preflight and denied-origin counters
+func (h *MetricsHandler) WithCORS(engine *cors.Engine) *MetricsHandler {
+	h.cors = engine
+	return h
+}
+
+// WithRealtime includes the realtime hub's connection and dropped-message counters
+func (h *MetricsHandler) WithRealtime(hub *realtime.Hub) *MetricsHandler {
+	h.realtime = hub
+	return h
+}
+
+// WithAbuse includes the abuse detector's ban and blocked-request counters
+func (h *MetricsHandler) WithAbuse(detector *abuse.Detector) *MetricsHandler {
+	h.abuse = detector
+	return h
+}
+
+// WithLimitMultipliers includes the rate limit multiplier rules and their
+// match counts
+func (h *MetricsHandler) WithLimitMultipliers(multipliers *geoip.Multipliers) *MetricsHandler {
+	h.geoip = multipliers
+	return h
+}
+
+// WithUserIndex includes the user index's hit, miss and error counters
+func (h *MetricsHandler) WithUserIndex(index *userindex.Index) *MetricsHandler {
+	h.users = index
+	return h
+}
+
+// WithCache includes the cache's degradation state, fallback counters,
+// memory pressure and in-process tier
+func (h *MetricsHandler) WithCache(cache *repositories.CacheRepository) *MetricsHandler {
+	h.cache = cache
+	return h
+}
+
+// WithPanicGuard includes the panic counts per route and the routes
+// disabled for panicking
+func (h *MetricsHandler) WithPanicGuard(guard *middleware.PanicGuard) *MetricsHandler {
+	h.panics = guard
+	return h
+}
+
+// WithDependencies includes the dependency check counters and the latest
+// check's findings by kind and severity
+func (h *MetricsHandler) WithDependencies(watcher *depwatch.Watcher) *MetricsHandler {
+	h.deps = watcher
+	return h
+}
+
+// WithDatabase includes the connection pools' usage and waits, and the
+// slow and failed query counts
+func (h *MetricsHandler) WithDatabase(manager *database.DatabaseManager) *MetricsHandler {
+	h.database = manager
+	return h
+}
+
 // GetAction returns the action this handler processes
 func (h *MetricsHandler) GetAction() string {
 	return "metrics"
@@ -43,6 +120,33 @@ func (h *MetricsHandler) Handle(req interfaces.APIRequest) (interfaces.APIRespon
 		},
 		"timestamp": time.Now().Unix(),
 	}
+	if h.cors != nil {
+		metrics["cors"] = h.cors.Stats()
+	}
+	if h.realtime != nil {
+		metrics["realtime"] = h.realtime.Stats()
+	}
+	if h.abuse != nil {
+		metrics["abuse"] = h.abuse.Stats()
+	}
+	if h.geoip != nil {
+		metrics["rate_limit_multipliers"] = h.geoip.Stats()
+	}
+	if h.users != nil {
+		metrics["user_index"] = h.users.Stats()
+	}
+	if h.cache != nil {
+		metrics["cache"] = h.cache.Stats()
+	}
+	if h.panics != nil {
+		metrics["panics"] = h.panics.Stats()
+	}
+	if h.deps != nil {
+		metrics["dependencies"] = h.deps.Stats()
+	}
+	if h.database != nil {
+		metrics["database"] = h.database.PoolStats()
+	}
 
 	return models.NewSuccessResponse("System metrics", metrics), nil
 }
@@ -0,0 +1,53 @@
+// Code generated by mockgen from organization_handler.go; DO NOT EDIT.
+
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"go-server/internal/database/models"
+)
+
+// OrganizationStore is a mock of handlers.OrganizationStore
+type OrganizationStore struct {
+	CreateOrganizationFunc       func(ctx context.Context, organization *models.Organization) error
+	ListOrganizationsForUserFunc func(ctx context.Context, userID uint) ([]models.Organization, error)
+
+	mu    sync.Mutex
+	calls map[string][][]interface{}
+}
+
+// CreateOrganization calls CreateOrganizationFunc
+func (mock *OrganizationStore) CreateOrganization(ctx context.Context, organization *models.Organization) error {
+	mock.record("CreateOrganization", ctx, organization)
+	if mock.CreateOrganizationFunc == nil {
+		panic("unexpected call to OrganizationStore.CreateOrganization")
+	}
+	return mock.CreateOrganizationFunc(ctx, organization)
+}
+
+// ListOrganizationsForUser calls ListOrganizationsForUserFunc
+func (mock *OrganizationStore) ListOrganizationsForUser(ctx context.Context, userID uint) ([]models.Organization, error) {
+	mock.record("ListOrganizationsForUser", ctx, userID)
+	if mock.ListOrganizationsForUserFunc == nil {
+		panic("unexpected call to OrganizationStore.ListOrganizationsForUser")
+	}
+	return mock.ListOrganizationsForUserFunc(ctx, userID)
+}
+
+// Calls returns the arguments of each call to method, in order
+func (mock *OrganizationStore) Calls(method string) [][]interface{} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([][]interface{}(nil), mock.calls[method]...)
+}
+
+func (mock *OrganizationStore) record(method string, args ...interface{}) {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	if mock.calls == nil {
+		mock.calls = make(map[string][][]interface{})
+	}
+	mock.calls[method] = append(mock.calls[method], args)
+}
@@ -0,0 +1,83 @@
+// Code generated by mockgen from post_handler.go; DO NOT EDIT.
+
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"go-server/internal/database/models"
+)
+
+// PostStore is a mock of handlers.PostStore
+type PostStore struct {
+	GetPostByIDFunc         func(ctx context.Context, id uint) (*models.Post, error)
+	GetPostByPublicIDFunc   func(ctx context.Context, publicID string) (*models.Post, error)
+	GetPostBySlugFunc       func(ctx context.Context, slug string) (*models.Post, error)
+	ListPublishedPostsFunc  func(ctx context.Context, offset int, limit int) ([]models.Post, error)
+	CountPublishedPostsFunc func(ctx context.Context) (int64, error)
+
+	mu    sync.Mutex
+	calls map[string][][]interface{}
+}
+
+// GetPostByID calls GetPostByIDFunc
+func (mock *PostStore) GetPostByID(ctx context.Context, id uint) (*models.Post, error) {
+	mock.record("GetPostByID", ctx, id)
+	if mock.GetPostByIDFunc == nil {
+		panic("unexpected call to PostStore.GetPostByID")
+	}
+	return mock.GetPostByIDFunc(ctx, id)
+}
+
+// GetPostByPublicID calls GetPostByPublicIDFunc
+func (mock *PostStore) GetPostByPublicID(ctx context.Context, publicID string) (*models.Post, error) {
+	mock.record("GetPostByPublicID", ctx, publicID)
+	if mock.GetPostByPublicIDFunc == nil {
+		panic("unexpected call to PostStore.GetPostByPublicID")
+	}
+	return mock.GetPostByPublicIDFunc(ctx, publicID)
+}
+
+// GetPostBySlug calls GetPostBySlugFunc
+func (mock *PostStore) GetPostBySlug(ctx context.Context, slug string) (*models.Post, error) {
+	mock.record("GetPostBySlug", ctx, slug)
+	if mock.GetPostBySlugFunc == nil {
+		panic("unexpected call to PostStore.GetPostBySlug")
+	}
+	return mock.GetPostBySlugFunc(ctx, slug)
+}
+
+// ListPublishedPosts calls ListPublishedPostsFunc
+func (mock *PostStore) ListPublishedPosts(ctx context.Context, offset int, limit int) ([]models.Post, error) {
+	mock.record("ListPublishedPosts", ctx, offset, limit)
+	if mock.ListPublishedPostsFunc == nil {
+		panic("unexpected call to PostStore.ListPublishedPosts")
+	}
+	return mock.ListPublishedPostsFunc(ctx, offset, limit)
+}
+
+// CountPublishedPosts calls CountPublishedPostsFunc
+func (mock *PostStore) CountPublishedPosts(ctx context.Context) (int64, error) {
+	mock.record("CountPublishedPosts", ctx)
+	if mock.CountPublishedPostsFunc == nil {
+		panic("unexpected call to PostStore.CountPublishedPosts")
+	}
+	return mock.CountPublishedPostsFunc(ctx)
+}
+
+// Calls returns the arguments of each call to method, in order
+func (mock *PostStore) Calls(method string) [][]interface{} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([][]interface{}(nil), mock.calls[method]...)
+}
+
+func (mock *PostStore) record(method string, args ...interface{}) {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	if mock.calls == nil {
+		mock.calls = make(map[string][][]interface{})
+	}
+	mock.calls[method] = append(mock.calls[method], args)
+}
@@ -0,0 +1,93 @@
+// Code generated by mockgen from user_handler.go; DO NOT EDIT.
+
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"go-server/internal/database/models"
+)
+
+// UserStore is a mock of handlers.UserStore
+type UserStore struct {
+	GetUserByIDFunc       func(ctx context.Context, id uint) (*models.User, error)
+	GetUserByPublicIDFunc func(ctx context.Context, publicID string) (*models.User, error)
+	GetUserByUsernameFunc func(ctx context.Context, username string) (*models.User, error)
+	ListUsersFunc         func(ctx context.Context, offset int, limit int) ([]models.User, error)
+	CountUsersFunc        func(ctx context.Context) (int64, error)
+	UpdateUserFunc        func(ctx context.Context, user *models.User) error
+
+	mu    sync.Mutex
+	calls map[string][][]interface{}
+}
+
+// GetUserByID calls GetUserByIDFunc
+func (mock *UserStore) GetUserByID(ctx context.Context, id uint) (*models.User, error) {
+	mock.record("GetUserByID", ctx, id)
+	if mock.GetUserByIDFunc == nil {
+		panic("unexpected call to UserStore.GetUserByID")
+	}
+	return mock.GetUserByIDFunc(ctx, id)
+}
+
+// GetUserByPublicID calls GetUserByPublicIDFunc
+func (mock *UserStore) GetUserByPublicID(ctx context.Context, publicID string) (*models.User, error) {
+	mock.record("GetUserByPublicID", ctx, publicID)
+	if mock.GetUserByPublicIDFunc == nil {
+		panic("unexpected call to UserStore.GetUserByPublicID")
+	}
+	return mock.GetUserByPublicIDFunc(ctx, publicID)
+}
+
+// GetUserByUsername calls GetUserByUsernameFunc
+func (mock *UserStore) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	mock.record("GetUserByUsername", ctx, username)
+	if mock.GetUserByUsernameFunc == nil {
+		panic("unexpected call to UserStore.GetUserByUsername")
+	}
+	return mock.GetUserByUsernameFunc(ctx, username)
+}
+
+// ListUsers calls ListUsersFunc
+func (mock *UserStore) ListUsers(ctx context.Context, offset int, limit int) ([]models.User, error) {
+	mock.record("ListUsers", ctx, offset, limit)
+	if mock.ListUsersFunc == nil {
+		panic("unexpected call to UserStore.ListUsers")
+	}
+	return mock.ListUsersFunc(ctx, offset, limit)
+}
+
+// CountUsers calls CountUsersFunc
+func (mock *UserStore) CountUsers(ctx context.Context) (int64, error) {
+	mock.record("CountUsers", ctx)
+	if mock.CountUsersFunc == nil {
+		panic("unexpected call to UserStore.CountUsers")
+	}
+	return mock.CountUsersFunc(ctx)
+}
+
+// UpdateUser calls UpdateUserFunc
+func (mock *UserStore) UpdateUser(ctx context.Context, user *models.User) error {
+	mock.record("UpdateUser", ctx, user)
+	if mock.UpdateUserFunc == nil {
+		panic("unexpected call to UserStore.UpdateUser")
+	}
+	return mock.UpdateUserFunc(ctx, user)
+}
+
+// Calls returns the arguments of each call to method, in order
+func (mock *UserStore) Calls(method string) [][]interface{} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([][]interface{}(nil), mock.calls[method]...)
+}
+
+func (mock *UserStore) record(method string, args ...interface{}) {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	if mock.calls == nil {
+		mock.calls = make(map[string][][]interface{})
+	}
+	mock.calls[method] = append(mock.calls[method], args)
+}
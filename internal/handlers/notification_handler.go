@@ -0,0 +1,311 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/errors"
+	"go-server/internal/logger"
+	"go-server/internal/middleware"
+	"go-server/internal/notify"
+	"go-server/internal/security"
+)
+
+// NotificationHandler handles device token registration, digest preferences
+// and notification delivery status callbacks
+type NotificationHandler struct {
+	notificationRepo *repositories.NotificationRepository
+	dispatcher       *notify.Dispatcher
+	logger           logger.Logger
+
+	twilioAuthToken   string
+	twilioCallbackURL string
+	webhookSecret     string
+}
+
+// NewNotificationHandler creates a new notification handler
+func NewNotificationHandler(
+	notificationRepo *repositories.NotificationRepository,
+	dispatcher *notify.Dispatcher,
+	logger logger.Logger,
+) *NotificationHandler {
+	return &NotificationHandler{
+		notificationRepo: notificationRepo,
+		dispatcher:       dispatcher,
+		logger:           logger,
+	}
+}
+
+// WithTwilio enables Twilio status callbacks. Twilio signs the exact URL it
+// was given, so callbackURL must match TwilioConfig.StatusCallbackURL.
+func (nh *NotificationHandler) WithTwilio(authToken, callbackURL string) *NotificationHandler {
+	nh.twilioAuthToken = authToken
+	nh.twilioCallbackURL = callbackURL
+	return nh
+}
+
+// WithWebhookSecret enables the generic status endpoint for providers
+// relayed through a shared secret
+func (nh *NotificationHandler) WithWebhookSecret(secret string) *NotificationHandler {
+	nh.webhookSecret = secret
+	return nh
+}
+
+// RegisterDeviceRequest represents a request to register a push token
+type RegisterDeviceRequest struct {
+	Token    string `json:"token" validate:"required,max=4096"`
+	Platform string `json:"platform" validate:"required,oneof=ios android web"`
+}
+
+// RegisterDevice registers a push token for the current user (POST /api/devices)
+func (nh *NotificationHandler) RegisterDevice(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "User not authenticated", "NOT_AUTHENTICATED")
+		return
+	}
+
+	req, failures := security.Bind[RegisterDeviceRequest](r)
+	if len(failures) > 0 {
+		writeValidationErrors(w, failures)
+		return
+	}
+
+	token := &models.DeviceToken{
+		UserID:     userID,
+		Platform:   req.Platform,
+		Token:      req.Token,
+		LastSeenAt: time.Now(),
+	}
+	if err := nh.notificationRepo.UpsertDeviceToken(r.Context(), token); err != nil {
+		nh.logger.Error("Failed to register device token", "user_id", userID, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to register device", "DATABASE_ERROR")
+		return
+	}
+
+	nh.logger.Info("Device token registered", "user_id", userID, "platform", req.Platform)
+	writeJSON(w, http.StatusCreated, token)
+}
+
+// ListDevices returns the current user's registered devices (GET /api/devices)
+func (nh *NotificationHandler) ListDevices(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "User not authenticated", "NOT_AUTHENTICATED")
+		return
+	}
+
+	tokens, err := nh.notificationRepo.ListDeviceTokens(r.Context(), userID)
+	if err != nil {
+		nh.logger.Error("Failed to list device tokens", "user_id", userID, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve devices", "DATABASE_ERROR")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"devices": tokens})
+}
+
+// UnregisterDevice removes one of the current user's push tokens, e.g. on
+// sign-out (DELETE /api/devices/{token})
+func (nh *NotificationHandler) UnregisterDevice(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "User not authenticated", "NOT_AUTHENTICATED")
+		return
+	}
+
+	token := strings.TrimPrefix(r.URL.Path, "/api/devices/")
+	if token == "" || token == r.URL.Path {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Token is required", "INVALID_DEVICE_TOKEN")
+		return
+	}
+
+	deleted, err := nh.notificationRepo.DeleteDeviceToken(r.Context(), userID, token)
+	if err != nil {
+		nh.logger.Error("Failed to delete device token", "user_id", userID, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to remove device", "DATABASE_ERROR")
+		return
+	}
+	if !deleted {
+		errors.WriteErrorResponse(w, http.StatusNotFound, "Device not found", "DEVICE_NOT_FOUND")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListDeliveries returns the current user's notification history
+// (GET /api/notifications/deliveries)
+func (nh *NotificationHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "User not authenticated", "NOT_AUTHENTICATED")
+		return
+	}
+
+	offset, limit := parsePagination(r)
+	deliveries, err := nh.notificationRepo.ListNotificationDeliveries(r.Context(), userID, offset, limit)
+	if err != nil {
+		nh.logger.Error("Failed to list notification deliveries", "user_id", userID, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve deliveries", "DATABASE_ERROR")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"deliveries": deliveries,
+		"pagination": map[string]interface{}{
+			"offset": offset,
+			"limit":  limit,
+		},
+	})
+}
+
+// GetPreferences returns the current user's digest settings
+// (GET /api/notifications/preferences)
+func (nh *NotificationHandler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "User not authenticated", "NOT_AUTHENTICATED")
+		return
+	}
+
+	preference, err := nh.dispatcher.DigestPreference(r.Context(), userID)
+	if err != nil {
+		nh.logger.Error("Failed to load notification preferences", "user_id", userID, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve preferences", "DATABASE_ERROR")
+		return
+	}
+	writeJSON(w, http.StatusOK, preference)
+}
+
+// UpdatePreferencesRequest represents a digest preference change
+type UpdatePreferencesRequest struct {
+	DigestFrequency string `json:"digest_frequency"`
+	DigestChannel   string `json:"digest_channel"`
+}
+
+// UpdatePreferences changes how often the current user receives digests and
+// on which channel (PUT /api/notifications/preferences). Omitted fields keep
+// their current value.
+func (nh *NotificationHandler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "User not authenticated", "NOT_AUTHENTICATED")
+		return
+	}
+
+	req, failures := security.Bind[UpdatePreferencesRequest](r)
+	if len(failures) > 0 {
+		writeValidationErrors(w, failures)
+		return
+	}
+	if _, ok := notify.DigestWindow(req.DigestFrequency); req.DigestFrequency != "" && !ok {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Digest frequency must be immediate, hourly, daily or weekly", "INVALID_DIGEST_FREQUENCY")
+		return
+	}
+	switch req.DigestChannel {
+	case "", notify.ChannelEmail, notify.ChannelInApp, notify.ChannelPush, notify.ChannelSMS:
+	default:
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Digest channel must be email, in_app, push or sms", "INVALID_DIGEST_CHANNEL")
+		return
+	}
+
+	preference, err := nh.notificationRepo.GetNotificationPreference(r.Context(), userID)
+	if err != nil {
+		nh.logger.Error("Failed to load notification preferences", "user_id", userID, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to update preferences", "DATABASE_ERROR")
+		return
+	}
+	if preference == nil {
+		preference = &models.NotificationPreference{UserID: userID}
+	}
+	if req.DigestFrequency != "" {
+		preference.DigestFrequency = req.DigestFrequency
+	}
+	if req.DigestChannel != "" {
+		preference.DigestChannel = req.DigestChannel
+	}
+	if err := nh.notificationRepo.SaveNotificationPreference(r.Context(), preference); err != nil {
+		nh.logger.Error("Failed to update notification preferences", "user_id", userID, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to update preferences", "DATABASE_ERROR")
+		return
+	}
+
+	nh.GetPreferences(w, r)
+}
+
+// TwilioStatus processes Twilio message status callbacks
+// (POST /api/notifications/status/twilio)
+func (nh *NotificationHandler) TwilioStatus(w http.ResponseWriter, r *http.Request) {
+	if nh.twilioAuthToken == "" {
+		errors.WriteErrorResponse(w, http.StatusNotFound, "Twilio callbacks are not enabled", "NOT_FOUND")
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, 64<<10)
+	if err := r.ParseForm(); err != nil {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid request body", "INVALID_REQUEST")
+		return
+	}
+	if !notify.VerifyTwilioSignature(nh.twilioAuthToken, nh.twilioCallbackURL, r.PostForm, r.Header.Get("X-Twilio-Signature")) {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "Invalid signature", "INVALID_SIGNATURE")
+		return
+	}
+
+	status := notify.TwilioStatus(r.PostForm.Get("MessageStatus"))
+	if status == "" {
+		// Intermediate states such as queued or sending carry no outcome
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	detail := ""
+	if code := r.PostForm.Get("ErrorCode"); code != "" {
+		detail = "twilio error " + code
+	}
+	nh.applyStatus(w, r, notify.TwilioProvider, r.PostForm.Get("MessageSid"), status, detail)
+}
+
+// RecordStatus processes a delivery status reported by any provider, or a
+// relay translating its format (POST /api/notifications/status). The caller
+// authenticates with the shared secret in the X-Notify-Webhook-Secret header.
+func (nh *NotificationHandler) RecordStatus(w http.ResponseWriter, r *http.Request) {
+	secret := r.Header.Get("X-Notify-Webhook-Secret")
+	if nh.webhookSecret == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(nh.webhookSecret)) != 1 {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "Invalid webhook secret", "INVALID_WEBHOOK_SECRET")
+		return
+	}
+
+	var req struct {
+		Provider  string `json:"provider"`
+		MessageID string `json:"message_id"`
+		Status    string `json:"status"`
+		Error     string `json:"error"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Provider == "" || req.MessageID == "" {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid request body", "INVALID_REQUEST")
+		return
+	}
+	if req.Status != models.NotificationStatusDelivered && req.Status != models.NotificationStatusFailed {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Status must be delivered or failed", "INVALID_NOTIFICATION_STATUS")
+		return
+	}
+	nh.applyStatus(w, r, req.Provider, req.MessageID, req.Status, req.Error)
+}
+
+func (nh *NotificationHandler) applyStatus(w http.ResponseWriter, r *http.Request, provider, messageID, status, detail string) {
+	found, err := nh.dispatcher.HandleStatus(r.Context(), provider, messageID, status, detail)
+	if err != nil {
+		nh.logger.Error("Failed to record notification status", "provider", provider, "message_id", messageID, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to record status", "DATABASE_ERROR")
+		return
+	}
+	if !found {
+		errors.WriteErrorResponse(w, http.StatusNotFound, "Unknown message", "NOTIFICATION_NOT_FOUND")
+		return
+	}
+
+	nh.logger.Info("Notification status recorded", "provider", provider, "message_id", messageID, "status", status)
+	w.WriteHeader(http.StatusNoContent)
+}
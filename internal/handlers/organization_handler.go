@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/errors"
+	"go-server/internal/logger"
+	"go-server/internal/middleware"
+	"go-server/internal/security"
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+//go:generate go run go-server/cmd/mockgen -source organization_handler.go -out mocks/organization_handler.go OrganizationStore
+
+// OrganizationStore persists organizations;
+// *repositories.OrganizationRepository satisfies it
+type OrganizationStore interface {
+	CreateOrganization(ctx context.Context, organization *models.Organization) error
+	ListOrganizationsForUser(ctx context.Context, userID uint) ([]models.Organization, error)
+}
+
+var _ OrganizationStore = (*repositories.OrganizationRepository)(nil)
+
+// OrganizationHandler handles organization endpoints
+type OrganizationHandler struct {
+	orgRepo OrganizationStore
+	logger  logger.Logger
+}
+
+// NewOrganizationHandler creates a new organization handler
+func NewOrganizationHandler(orgRepo OrganizationStore, logger logger.Logger) *OrganizationHandler {
+	return &OrganizationHandler{
+		orgRepo: orgRepo,
+		logger:  logger,
+	}
+}
+
+// CreateOrganizationRequest represents a request to create an organization
+type CreateOrganizationRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+	Slug string `json:"slug" validate:"required,min=2,max=100"`
+}
+
+// CreateOrganization creates an organization owned by the caller
+// (POST /api/organizations)
+func (oh *OrganizationHandler) CreateOrganization(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "Authentication required", "NO_TOKEN")
+		return
+	}
+
+	req, failures := security.Bind[CreateOrganizationRequest](r)
+	if len(failures) > 0 {
+		writeValidationErrors(w, failures)
+		return
+	}
+	if !slugPattern.MatchString(req.Slug) {
+		writeValidationErrors(w, []security.ValidationError{{
+			Field:   "slug",
+			Message: "Slug may only contain lowercase letters, digits and single hyphens",
+		}})
+		return
+	}
+
+	organization := &models.Organization{Name: req.Name, Slug: req.Slug, OwnerID: userID}
+	if err := oh.orgRepo.CreateOrganization(r.Context(), organization); err != nil {
+		oh.logger.Error("Failed to create organization", "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusConflict, "Organization slug already taken", "SLUG_TAKEN")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, organization)
+}
+
+// ListOrganizations lists the organizations the caller belongs to
+// (GET /api/organizations)
+func (oh *OrganizationHandler) ListOrganizations(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "Authentication required", "NO_TOKEN")
+		return
+	}
+
+	organizations, err := oh.orgRepo.ListOrganizationsForUser(r.Context(), userID)
+	if err != nil {
+		oh.logger.Error("Failed to list organizations", "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve organizations", "DATABASE_ERROR")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"organizations": organizations})
+}
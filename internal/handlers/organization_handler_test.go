@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-server/internal/database/models"
+	"go-server/internal/handlers/mocks"
+	"go-server/internal/logger"
+)
+
+func TestOrganizationHandler_CreateOrganization(t *testing.T) {
+	organizations := &mocks.OrganizationStore{
+		CreateOrganizationFunc: func(ctx context.Context, organization *models.Organization) error {
+			if organization.Slug == "taken" {
+				return errors.New("duplicate key")
+			}
+			organization.ID = 1
+			return nil
+		},
+	}
+	handler := NewOrganizationHandler(organizations, logger.NewServerLogger())
+
+	create := func(body string, userID uint) int {
+		r := httptest.NewRequest(http.MethodPost, "/api/organizations", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		if userID != 0 {
+			r = r.WithContext(context.WithValue(r.Context(), "user_id", userID))
+		}
+		w := httptest.NewRecorder()
+		handler.CreateOrganization(w, r)
+		return w.Code
+	}
+
+	if status := create(`{"name": "Acme", "slug": "acme"}`, 7); status != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d", status)
+	}
+	calls := organizations.Calls("CreateOrganization")
+	if len(calls) != 1 || calls[0][1].(*models.Organization).OwnerID != 7 {
+		t.Errorf("Expected the caller to own the organization, got %v", calls)
+	}
+
+	for _, tc := range []struct {
+		body   string
+		userID uint
+		status int
+	}{
+		{`{"name": "Acme", "slug": "acme"}`, 0, http.StatusUnauthorized},
+		{`{"name": "Acme", "slug": "Not A Slug"}`, 7, http.StatusBadRequest},
+		{`{"name": "Acme", "slug": "taken"}`, 7, http.StatusConflict},
+	} {
+		if status := create(tc.body, tc.userID); status != tc.status {
+			t.Errorf("%s as %d: expected %d, got %d", tc.body, tc.userID, tc.status, status)
+		}
+	}
+	if calls := organizations.Calls("CreateOrganization"); len(calls) != 2 {
+		t.Errorf("Expected only valid requests to reach the store, got %d calls", len(calls))
+	}
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/errors"
+	"go-server/internal/httpcache"
+	"go-server/internal/idgen"
+	"go-server/internal/logger"
+)
+
+//go:generate go run go-server/cmd/mockgen -source post_handler.go -out mocks/post_handler.go PostStore
+
+// PostStore reads posts; *repositories.PostRepository satisfies it
+type PostStore interface {
+	GetPostByID(ctx context.Context, id uint) (*models.Post, error)
+	GetPostByPublicID(ctx context.Context, publicID string) (*models.Post, error)
+	GetPostBySlug(ctx context.Context, slug string) (*models.Post, error)
+	ListPublishedPosts(ctx context.Context, offset, limit int) ([]models.Post, error)
+	CountPublishedPosts(ctx context.Context) (int64, error)
+}
+
+var _ PostStore = (*repositories.PostRepository)(nil)
+
+// PostHandler serves public, cacheable post endpoints
+type PostHandler struct {
+	postRepo    PostStore
+	cachePolicy httpcache.Policy
+	logger      logger.Logger
+	publicIDs   bool
+}
+
+// NewPostHandler creates a new post handler
+func NewPostHandler(postRepo PostStore, cachePolicy httpcache.Policy, logger logger.Logger) *PostHandler {
+	return &PostHandler{
+		postRepo:    postRepo,
+		cachePolicy: cachePolicy,
+		logger:      logger,
+	}
+}
+
+// WithPublicIDs makes post URLs address posts by public identifier instead of numeric ID
+func (ph *PostHandler) WithPublicIDs(enabled bool) *PostHandler {
+	ph.publicIDs = enabled
+	return ph
+}
+
+// ListPublishedPosts returns published posts with caching headers (GET /api/posts)
+func (ph *PostHandler) ListPublishedPosts(w http.ResponseWriter, r *http.Request) {
+	offset, limit := parsePagination(r)
+
+	posts, err := ph.postRepo.ListPublishedPosts(r.Context(), offset, limit)
+	if err != nil {
+		ph.logger.Error("Failed to list published posts", "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve posts", "DATABASE_ERROR")
+		return
+	}
+
+	total, err := ph.postRepo.CountPublishedPosts(r.Context())
+	if err != nil {
+		ph.logger.Error("Failed to count published posts", "error", err.Error())
+	}
+
+	collection := httpcache.NewCollection("post")
+	keys := []string{httpcache.PostListKey}
+	for _, post := range posts {
+		collection.Add(post.ID, post.UpdatedAt)
+		keys = append(keys, httpcache.PostKey(post.ID), httpcache.AuthorKey(post.AuthorID))
+	}
+	httpcache.SetSurrogateKeys(w, keys...)
+	if httpcache.Write(w, r, ph.cachePolicy, collection.Validators()) {
+		return
+	}
+
+	response := map[string]interface{}{
+		"posts": posts,
+		"pagination": map[string]interface{}{
+			"offset": offset,
+			"limit":  limit,
+			"total":  total,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetPost returns a single published post by ID, public ID or slug (GET /api/posts/{ref})
+func (ph *PostHandler) GetPost(w http.ResponseWriter, r *http.Request) {
+	postRef := r.URL.Path[len("/api/posts/"):]
+	if postRef == "" {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid post ID", "INVALID_POST_ID")
+		return
+	}
+
+	post, err := ph.lookupPost(r, postRef)
+	if err != nil || !post.IsPublished() {
+		if err != nil {
+			ph.logger.Debug("Failed to get post", "post_ref", postRef, "error", err.Error())
+		}
+		// Drafts are indistinguishable from missing posts to anonymous readers
+		w.Header().Set("Cache-Control", httpcache.NoStore)
+		errors.WriteErrorResponse(w, http.StatusNotFound, "Post not found", "POST_NOT_FOUND")
+		return
+	}
+
+	httpcache.SetSurrogateKeys(w, httpcache.PostKey(post.ID), httpcache.AuthorKey(post.AuthorID))
+	if httpcache.Write(w, r, ph.cachePolicy, httpcache.ForResource("post", post.ID, post.UpdatedAt)) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(post)
+}
+
+// lookupPost resolves a path reference to a post, falling back to slug lookup
+func (ph *PostHandler) lookupPost(r *http.Request, postRef string) (*models.Post, error) {
+	if ph.publicIDs {
+		if idgen.IsPublicID(postRef) {
+			return ph.postRepo.GetPostByPublicID(r.Context(), postRef)
+		}
+	} else if postID, err := strconv.ParseUint(postRef, 10, 32); err == nil {
+		return ph.postRepo.GetPostByID(r.Context(), uint(postID))
+	}
+	return ph.postRepo.GetPostBySlug(r.Context(), postRef)
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-server/internal/database/models"
+	"go-server/internal/handlers/mocks"
+	"go-server/internal/httpcache"
+	"go-server/internal/logger"
+)
+
+func TestPostHandler_GetPost(t *testing.T) {
+	published := time.Date(2026, 1, 2, 9, 0, 0, 0, time.UTC)
+	posts := &mocks.PostStore{
+		GetPostByIDFunc: func(ctx context.Context, id uint) (*models.Post, error) {
+			return &models.Post{BaseModel: models.BaseModel{ID: id, UpdatedAt: published}, Status: "published", PublishedAt: &published}, nil
+		},
+		GetPostBySlugFunc: func(ctx context.Context, slug string) (*models.Post, error) {
+			if slug == "draft" {
+				return &models.Post{BaseModel: models.BaseModel{ID: 9}, Status: "draft"}, nil
+			}
+			return nil, errors.New("record not found")
+		},
+	}
+	handler := NewPostHandler(posts, httpcache.Policy{MaxAge: time.Minute}, logger.NewServerLogger())
+
+	for _, tc := range []struct {
+		path   string
+		status int
+	}{
+		{"/api/posts/5", http.StatusOK},
+		{"/api/posts/draft", http.StatusNotFound},
+		{"/api/posts/missing", http.StatusNotFound},
+		{"/api/posts/", http.StatusBadRequest},
+	} {
+		w := httptest.NewRecorder()
+		handler.GetPost(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if w.Code != tc.status {
+			t.Errorf("GET %s: expected %d, got %d: %s", tc.path, tc.status, w.Code, w.Body)
+		}
+	}
+
+	// Drafts must not be cached as missing posts
+	w := httptest.NewRecorder()
+	handler.GetPost(w, httptest.NewRequest(http.MethodGet, "/api/posts/draft", nil))
+	if got := w.Header().Get("Cache-Control"); got != httpcache.NoStore {
+		t.Errorf("Expected a draft not to be cached, got %q", got)
+	}
+
+	w = httptest.NewRecorder()
+	handler.GetPost(w, httptest.NewRequest(http.MethodGet, "/api/posts/5", nil))
+	r := httptest.NewRequest(http.MethodGet, "/api/posts/5", nil)
+	r.Header.Set("If-None-Match", w.Header().Get("ETag"))
+	w = httptest.NewRecorder()
+	handler.GetPost(w, r)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("Expected 304 for a matching ETag, got %d", w.Code)
+	}
+}
+
+func TestPostHandler_ListPublishedPosts(t *testing.T) {
+	posts := &mocks.PostStore{
+		ListPublishedPostsFunc: func(ctx context.Context, offset, limit int) ([]models.Post, error) {
+			return nil, errors.New("database unavailable")
+		},
+	}
+	handler := NewPostHandler(posts, httpcache.Policy{}, logger.NewServerLogger())
+
+	w := httptest.NewRecorder()
+	handler.ListPublishedPosts(w, httptest.NewRequest(http.MethodGet, "/api/posts", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected 500, got %d", w.Code)
+	}
+
+	posts.ListPublishedPostsFunc = func(ctx context.Context, offset, limit int) ([]models.Post, error) {
+		return []models.Post{{BaseModel: models.BaseModel{ID: 1}, AuthorID: 2}}, nil
+	}
+	posts.CountPublishedPostsFunc = func(ctx context.Context) (int64, error) { return 1, nil }
+	w = httptest.NewRecorder()
+	handler.ListPublishedPosts(w, httptest.NewRequest(http.MethodGet, "/api/posts", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body)
+	}
+	if keys := w.Header().Get("Surrogate-Key"); keys != "posts post:1 author:2" {
+		t.Errorf("Expected surrogate keys for the list, post and author, got %q", keys)
+	}
+}
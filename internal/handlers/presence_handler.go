@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	stderrors "errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-server/internal/errors"
+	"go-server/internal/logger"
+	"go-server/internal/middleware"
+	"go-server/internal/presence"
+)
+
+// maxPresenceUsers bounds how many users one presence query may ask about
+const maxPresenceUsers = 100
+
+// PresenceHandler serves online status and typing indicators
+type PresenceHandler struct {
+	service *presence.Service
+	logger  logger.Logger
+}
+
+// NewPresenceHandler creates a new presence handler
+func NewPresenceHandler(service *presence.Service, logger logger.Logger) *PresenceHandler {
+	return &PresenceHandler{service: service, logger: logger}
+}
+
+// GetPresence returns whether each user is online and when they were last seen
+// (GET /api/presence?user_ids=1,2,3)
+func (ph *PresenceHandler) GetPresence(w http.ResponseWriter, r *http.Request) {
+	var userIDs []uint
+	for _, value := range strings.Split(r.URL.Query().Get("user_ids"), ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid user ID: "+value, "INVALID_USER_ID")
+			return
+		}
+		userIDs = append(userIDs, uint(id))
+	}
+	if len(userIDs) == 0 || len(userIDs) > maxPresenceUsers {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "user_ids must list 1 to 100 user IDs", "INVALID_USER_ID")
+		return
+	}
+
+	statuses, err := ph.service.Statuses(r.Context(), userIDs)
+	if err != nil {
+		ph.logger.Error("Failed to read presence", "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to read presence", "PRESENCE_ERROR")
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, map[string]interface{}{"users": statuses})
+}
+
+// GetTyping returns the users typing in a channel
+// (GET /api/presence/typing?channel=post:42)
+func (ph *PresenceHandler) GetTyping(w http.ResponseWriter, r *http.Request) {
+	channel := r.URL.Query().Get("channel")
+	users, err := ph.service.Typing(r.Context(), channel)
+	if stderrors.Is(err, presence.ErrInvalidChannel) {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "channel is required and at most 128 characters", "INVALID_CHANNEL")
+		return
+	}
+	if err != nil {
+		ph.logger.Error("Failed to read typing indicators", "channel", channel, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to read typing indicators", "PRESENCE_ERROR")
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, map[string]interface{}{"channel": channel, "user_ids": users})
+}
+
+// Heartbeat keeps the current user online, for clients using Server-Sent
+// Events, which cannot send heartbeats over the stream
+// (POST /api/presence/heartbeat)
+func (ph *PresenceHandler) Heartbeat(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "User not authenticated", "NOT_AUTHENTICATED")
+		return
+	}
+	if err := ph.service.Heartbeat(r.Context(), userID); err != nil {
+		ph.logger.Error("Failed to record presence", "user_id", userID, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to record presence", "PRESENCE_ERROR")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"go-server/internal/errors"
+	"go-server/internal/logger"
+	"go-server/internal/warmup"
+)
+
+// ReadinessCheck reports why the server cannot take traffic yet, or nil
+type ReadinessCheck func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check ReadinessCheck
+}
+
+// ReadinessHandler answers the load balancer's readiness probe
+type ReadinessHandler struct {
+	warmer *warmup.Warmer
+	checks []namedCheck
+	logger logger.Logger
+}
+
+// NewReadinessHandler creates a new readiness handler
+func NewReadinessHandler(warmer *warmup.Warmer, logger logger.Logger) *ReadinessHandler {
+	return &ReadinessHandler{warmer: warmer, logger: logger}
+}
+
+// WithCheck adds a check that must pass before the server is ready, such
+// as the schema gate that holds a new build back until its expand
+// migrations have run
+func (rh *ReadinessHandler) WithCheck(name string, check ReadinessCheck) *ReadinessHandler {
+	rh.checks = append(rh.checks, namedCheck{name: name, check: check})
+	return rh
+}
+
+// Ready reports 503 until the cache warmup has finished and every check
+// passes, then 200 with the warmup report (GET /readyz)
+func (rh *ReadinessHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	report, ready := rh.warmer.Report()
+	if !ready {
+		w.Header().Set("Retry-After", "1")
+		errors.WriteErrorResponse(w, http.StatusServiceUnavailable, "Server is warming its caches", "WARMING_UP")
+		return
+	}
+
+	for _, c := range rh.checks {
+		if err := c.check(r.Context()); err != nil {
+			rh.logger.Warn("Readiness check failed", "check", c.name, "error", err.Error())
+			w.Header().Set("Retry-After", "5")
+			errors.WriteErrorResponse(w, http.StatusServiceUnavailable, "Readiness check "+c.name+" failed: "+err.Error(), "NOT_READY")
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status": "ready",
+		"warmup": report,
+	})
+}
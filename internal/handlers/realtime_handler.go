@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-server/internal/errors"
+	"go-server/internal/logger"
+	"go-server/internal/middleware"
+	"go-server/internal/realtime"
+)
+
+// RealtimeHandler serves the REST side of real-time delivery
+type RealtimeHandler struct {
+	hub    *realtime.Hub
+	logger logger.Logger
+}
+
+// NewRealtimeHandler creates a new realtime handler
+func NewRealtimeHandler(hub *realtime.Hub, logger logger.Logger) *RealtimeHandler {
+	return &RealtimeHandler{hub: hub, logger: logger}
+}
+
+// CatchUp returns the stored messages published after the after query
+// parameter, for clients that were offline longer than a stream resume
+// covers. Page with next_after until has_more is false
+// (GET /api/realtime/events?after=ID&limit=N&topics=a,b)
+func (rh *RealtimeHandler) CatchUp(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "User not authenticated", "NOT_AUTHENTICATED")
+		return
+	}
+
+	query := r.URL.Query()
+	var after uint64
+	if value := query.Get("after"); value != "" {
+		parsed, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid event ID", "INVALID_EVENT_ID")
+			return
+		}
+		after = parsed
+	}
+	_, limit := parsePagination(r)
+
+	var topics []string
+	for _, topic := range strings.Split(query.Get("topics"), ",") {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			topics = append(topics, topic)
+		}
+	}
+
+	page, err := rh.hub.CatchUp(r.Context(), userID, topics, after, limit)
+	if err != nil {
+		rh.logger.Error("Failed to load realtime events", "user_id", userID, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to load events", "EVENT_STORE_ERROR")
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, page)
+}
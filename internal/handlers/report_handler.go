@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	stderrors "errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/errors"
+	"go-server/internal/logger"
+	"go-server/internal/middleware"
+	"go-server/internal/rbac"
+	"go-server/internal/reports"
+	"go-server/internal/security"
+
+	"gorm.io/gorm"
+)
+
+// ReportHandler handles requesting, polling and downloading admin reports
+type ReportHandler struct {
+	service  *reports.Service
+	repo     *repositories.ReportRepository
+	userRepo *repositories.UserRepository
+	logger   logger.Logger
+}
+
+// NewReportHandler creates a new report handler
+func NewReportHandler(service *reports.Service, repo *repositories.ReportRepository, userRepo *repositories.UserRepository, logger logger.Logger) *ReportHandler {
+	return &ReportHandler{
+		service:  service,
+		repo:     repo,
+		userRepo: userRepo,
+		logger:   logger,
+	}
+}
+
+// RequestReportRequest represents a request for a report over [from, to)
+type RequestReportRequest struct {
+	Type   string    `json:"type" validate:"required,oneof=user_growth content_stats security_summary"`
+	Format string    `json:"format" validate:"required,oneof=csv pdf"`
+	From   time.Time `json:"from" validate:"required"`
+	To     time.Time `json:"to" validate:"required"`
+}
+
+// reportView is a report with, once it has completed, a signed link to
+// download it
+type reportView struct {
+	*models.Report
+	DownloadURL       string     `json:"download_url,omitempty"`
+	DownloadExpiresAt *time.Time `json:"download_expires_at,omitempty"`
+}
+
+func (rh *ReportHandler) newReportView(r *http.Request, report *models.Report) reportView {
+	view := reportView{Report: report}
+	if query, expiresAt, err := rh.service.DownloadLink(report); err == nil {
+		view.DownloadURL = requestBaseURL(r) + "/api/reports/" + strconv.FormatUint(uint64(report.ID), 10) + "/download?" + query.Encode()
+		view.DownloadExpiresAt = &expiresAt
+	}
+	return view
+}
+
+// RequestReport queues a report for rendering; poll it with GetReport
+// (POST /api/admin/reports, requires reports:run)
+func (rh *ReportHandler) RequestReport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "Authentication required", "NO_TOKEN")
+		return
+	}
+	req, failures := security.Bind[RequestReportRequest](r)
+	if len(failures) > 0 {
+		writeValidationErrors(w, failures)
+		return
+	}
+
+	report, err := rh.service.Request(r.Context(), reports.Request{
+		Type:          req.Type,
+		Format:        req.Format,
+		From:          req.From,
+		To:            req.To,
+		RequestedByID: userID,
+	})
+	switch {
+	case stderrors.Is(err, reports.ErrInvalidPeriod):
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "The period must end after it starts and span at most the maximum period", "INVALID_REPORT_REQUEST")
+	case stderrors.Is(err, reports.ErrUnknownType), stderrors.Is(err, reports.ErrUnknownFormat):
+		errors.WriteErrorResponse(w, http.StatusBadRequest, err.Error(), "INVALID_REPORT_REQUEST")
+	case err != nil:
+		rh.logger.Error("Failed to request report", "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to request report", "DATABASE_ERROR")
+	default:
+		writeJSON(w, http.StatusAccepted, rh.newReportView(r, report))
+	}
+}
+
+// ListReports lists reports, newest first, with the types and formats
+// that can be requested (GET /api/admin/reports, requires reports:run)
+func (rh *ReportHandler) ListReports(w http.ResponseWriter, r *http.Request) {
+	offset, limit := parsePagination(r)
+
+	list, err := rh.repo.ListReports(r.Context(), offset, limit)
+	if err != nil {
+		rh.logger.Error("Failed to list reports", "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve reports", "DATABASE_ERROR")
+		return
+	}
+	total, err := rh.repo.CountReports(r.Context())
+	if err != nil {
+		rh.logger.Error("Failed to count reports", "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve reports", "DATABASE_ERROR")
+		return
+	}
+
+	views := make([]reportView, len(list))
+	for i := range list {
+		views[i] = rh.newReportView(r, &list[i])
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"types":   reports.Types(),
+		"formats": reports.Formats(),
+		"reports": views,
+		"pagination": map[string]interface{}{
+			"offset": offset,
+			"limit":  limit,
+			"total":  total,
+		},
+	})
+}
+
+// GetReport returns a report's status, with a fresh download link once it
+// has completed (GET /api/admin/reports/{id}, requires reports:run)
+func (rh *ReportHandler) GetReport(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDFromPath(r.URL.Path, "/api/admin/reports/", "")
+	if err != nil {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid report ID", "INVALID_REPORT_ID")
+		return
+	}
+	report, ok := rh.loadReport(w, r, id)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, rh.newReportView(r, report))
+}
+
+// DownloadReport streams a rendered report
+// (GET /api/reports/{id}/download?report=&expires=&signature=). The signed
+// link stands in for authentication; whoever requested the report must
+// still hold reports:run.
+func (rh *ReportHandler) DownloadReport(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDFromPath(r.URL.Path, "/api/reports/", "/download")
+	if err != nil {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid report ID", "INVALID_REPORT_ID")
+		return
+	}
+	signedID, err := rh.service.Links().Verify(r.URL.Query())
+	if stderrors.Is(err, security.ErrLinkExpired) {
+		errors.WriteErrorResponse(w, http.StatusForbidden, "Report link has expired", "REPORT_LINK_EXPIRED")
+		return
+	}
+	if err != nil || signedID != id {
+		errors.WriteErrorResponse(w, http.StatusForbidden, "Invalid report link", "INVALID_REPORT_LINK")
+		return
+	}
+
+	report, ok := rh.loadReport(w, r, id)
+	if !ok {
+		return
+	}
+	requester, err := rh.userRepo.GetUserByID(r.Context(), report.RequestedByID)
+	if err != nil && !stderrors.Is(err, gorm.ErrRecordNotFound) {
+		rh.logger.Error("Failed to load report requester", "report_id", id, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve report", "DATABASE_ERROR")
+		return
+	}
+	if err != nil || !requester.IsActive || !rbac.UserCan(requester, rbac.ReportsRun) {
+		errors.WriteErrorResponse(w, http.StatusForbidden, "Invalid report link", "INVALID_REPORT_LINK")
+		return
+	}
+
+	file, err := rh.service.Open(r.Context(), report)
+	if stderrors.Is(err, reports.ErrNotReady) {
+		if report.Status == models.ReportStatusExpired {
+			errors.WriteErrorResponse(w, http.StatusGone, "Report has expired", "REPORT_EXPIRED")
+			return
+		}
+		errors.WriteErrorResponse(w, http.StatusConflict, "Report is not ready", "REPORT_NOT_READY")
+		return
+	}
+	if err != nil {
+		rh.logger.Error("Failed to open report", "report_id", id, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve report", "INTERNAL_ERROR")
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", reports.ContentType(report.Format))
+	w.Header().Set("Content-Disposition", `attachment; filename="`+report.Filename()+`"`)
+	w.Header().Set("Content-Length", strconv.FormatInt(report.Size, 10))
+	// The link is a credential; keep the file out of every cache
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, file)
+}
+
+// loadReport loads a report, writing the error response if it cannot
+func (rh *ReportHandler) loadReport(w http.ResponseWriter, r *http.Request, id uint) (*models.Report, bool) {
+	report, err := rh.repo.GetReportByID(r.Context(), id)
+	if stderrors.Is(err, gorm.ErrRecordNotFound) {
+		errors.WriteErrorResponse(w, http.StatusNotFound, "Report not found", "REPORT_NOT_FOUND")
+		return nil, false
+	}
+	if err != nil {
+		rh.logger.Error("Failed to get report", "report_id", id, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve report", "DATABASE_ERROR")
+		return nil, false
+	}
+	return report, true
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"go-server/internal/approval"
+	"go-server/internal/database/repositories"
+	"go-server/internal/errors"
+	"go-server/internal/logger"
+	"go-server/internal/middleware"
+	"go-server/internal/rbac"
+	"go-server/internal/security"
+)
+
+// RoleHandler handles staff role endpoints
+type RoleHandler struct {
+	userRepo  *repositories.UserRepository
+	logger    logger.Logger
+	approvals *approval.Service
+}
+
+// NewRoleHandler creates a new role handler
+func NewRoleHandler(userRepo *repositories.UserRepository, logger logger.Logger) *RoleHandler {
+	return &RoleHandler{
+		userRepo: userRepo,
+		logger:   logger,
+	}
+}
+
+// WithApprovals routes role changes through the approval workflow
+func (rh *RoleHandler) WithApprovals(service *approval.Service) *RoleHandler {
+	rh.approvals = service
+	service.Register(approval.ActionSetUserRole, rbac.RolesManage, rh.executeSetRole)
+	return rh
+}
+
+// roleChange is the approval params of a role change
+type roleChange struct {
+	UserID uint   `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+// SetRoleRequest represents a request to change a user's staff role
+type SetRoleRequest struct {
+	Role string `json:"role" validate:"max=20"`
+}
+
+// staffMember is a user with their role and the permissions it grants
+type staffMember struct {
+	ID          uint              `json:"id"`
+	Username    string            `json:"username"`
+	Email       string            `json:"email"`
+	Role        string            `json:"role"`
+	Permissions []rbac.Permission `json:"permissions"`
+}
+
+// ListRoles returns every staff role and its permissions
+// (GET /api/admin/roles, requires roles:manage)
+func (rh *RoleHandler) ListRoles(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"roles": rbac.Roles()})
+}
+
+// ListStaff returns users holding a staff role
+// (GET /api/admin/staff, requires roles:manage)
+func (rh *RoleHandler) ListStaff(w http.ResponseWriter, r *http.Request) {
+	users, err := rh.userRepo.ListStaff(r.Context())
+	if err != nil {
+		rh.logger.Error("Failed to list staff", "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve staff", "DATABASE_ERROR")
+		return
+	}
+
+	staff := make([]staffMember, len(users))
+	for i, user := range users {
+		role := rbac.RoleOf(&user)
+		staff[i] = staffMember{
+			ID:          user.ID,
+			Username:    user.Username,
+			Email:       user.Email,
+			Role:        role,
+			Permissions: rbac.PermissionsFor(role),
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"staff": staff})
+}
+
+// SetUserRole grants or removes a user's staff role. An empty role makes
+// them a regular user again.
+// (PUT /api/admin/users/{id}/role, requires roles:manage)
+func (rh *RoleHandler) SetUserRole(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseIDFromPath(r.URL.Path, "/api/admin/users/", "/role")
+	if err != nil {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid user ID", "INVALID_USER_ID")
+		return
+	}
+
+	req, failures := security.Bind[SetRoleRequest](r)
+	if len(failures) > 0 {
+		writeValidationErrors(w, failures)
+		return
+	}
+	if !rbac.ValidRole(req.Role) {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Unknown role: "+req.Role, "INVALID_ROLE")
+		return
+	}
+
+	// Administrators cannot demote themselves and lock everyone out
+	if actorID, ok := middleware.GetUserIDFromContext(r.Context()); ok && actorID == userID {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "You cannot change your own role", "OWN_ROLE")
+		return
+	}
+
+	if rh.approvals != nil {
+		summary := fmt.Sprintf("Set role of user %d to %q", userID, req.Role)
+		submitForApproval(w, r, rh.approvals, approval.ActionSetUserRole, roleChange{UserID: userID, Role: req.Role}, summary)
+		return
+	}
+
+	found, err := rh.userRepo.SetUserRole(r.Context(), userID, req.Role)
+	if err != nil {
+		rh.logger.Error("Failed to set user role", "user_id", userID, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to set role", "DATABASE_ERROR")
+		return
+	}
+	if !found {
+		errors.WriteErrorResponse(w, http.StatusNotFound, "User not found", "USER_NOT_FOUND")
+		return
+	}
+
+	rh.logger.Info("User role changed", "user_id", userID, "role", req.Role, "changed_by", r.Context().Value("user_id"))
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"user_id":     userID,
+		"role":        req.Role,
+		"permissions": rbac.PermissionsFor(req.Role),
+	})
+}
+
+// executeSetRole applies an approved role change
+func (rh *RoleHandler) executeSetRole(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var change roleChange
+	if err := json.Unmarshal(params, &change); err != nil {
+		return nil, err
+	}
+	found, err := rh.userRepo.SetUserRole(ctx, change.UserID, change.Role)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("user %d not found", change.UserID)
+	}
+	return map[string]interface{}{
+		"user_id":     change.UserID,
+		"role":        change.Role,
+		"permissions": rbac.PermissionsFor(change.Role),
+	}, nil
+}
+
+// GetMyPermissions returns the caller's staff role and permissions so clients
+// can hide actions they cannot take
+// (GET /api/users/me/permissions)
+func (rh *RoleHandler) GetMyPermissions(w http.ResponseWriter, r *http.Request) {
+	role := middleware.GetRoleFromContext(r.Context())
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"role":        role,
+		"permissions": rbac.PermissionsFor(role),
+	})
+}
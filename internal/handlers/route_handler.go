@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"go-server/internal/errors"
+	"go-server/internal/logger"
+	"go-server/internal/middleware"
+)
+
+// RouteHandler lets administrators review and re-enable the routes the
+// panic guard disabled
+type RouteHandler struct {
+	guard  *middleware.PanicGuard
+	logger logger.Logger
+}
+
+// NewRouteHandler creates a new route handler
+func NewRouteHandler(guard *middleware.PanicGuard, logger logger.Logger) *RouteHandler {
+	return &RouteHandler{guard: guard, logger: logger}
+}
+
+// ListDisabled lists the disabled routes and the panic counts of every
+// route (GET /api/admin/routes/disabled, requires system:configure)
+func (rh *RouteHandler) ListDisabled(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, rh.guard.Stats())
+}
+
+// Enable re-enables a disabled route, named as listed, e.g.
+// ?route=GET+/api/posts/{id}
+// (DELETE /api/admin/routes/disabled?route=, requires system:configure)
+func (rh *RouteHandler) Enable(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "Authentication required", "NO_TOKEN")
+		return
+	}
+	route := strings.TrimSpace(r.URL.Query().Get("route"))
+	if route == "" {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "The route parameter is required", "MISSING_FIELD")
+		return
+	}
+
+	if !rh.guard.Enable(route) {
+		errors.WriteErrorResponse(w, http.StatusNotFound, "The route is not disabled", "ROUTE_NOT_DISABLED")
+		return
+	}
+	rh.logger.Info("User %d re-enabled route %s", userID, route)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/errors"
+	"go-server/internal/logger"
+	"go-server/internal/middleware"
+	"go-server/internal/runbook"
+)
+
+// maxRunbookParamsSize bounds the params body of a runbook request
+const maxRunbookParamsSize = 64 << 10
+
+// maxIdempotencyKeyLength matches the size of the idempotency key column
+const maxIdempotencyKeyLength = 128
+
+// RunbookHandler handles the admin endpoints for operational actions
+type RunbookHandler struct {
+	service *runbook.Service
+	repo    *repositories.RunbookRepository
+	logger  logger.Logger
+}
+
+// NewRunbookHandler creates a new runbook handler
+func NewRunbookHandler(service *runbook.Service, repo *repositories.RunbookRepository, logger logger.Logger) *RunbookHandler {
+	return &RunbookHandler{
+		service: service,
+		repo:    repo,
+		logger:  logger,
+	}
+}
+
+// runbookRunView is a runbook run with its params and result as JSON
+type runbookRunView struct {
+	*models.RunbookRun
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result,omitempty"`
+}
+
+func newRunbookRunView(run *models.RunbookRun) runbookRunView {
+	view := runbookRunView{RunbookRun: run, Params: json.RawMessage(run.Params)}
+	if run.Result != "" {
+		view.Result = json.RawMessage(run.Result)
+	}
+	return view
+}
+
+// ListRunbook lists the available actions and the audit log of recent runs,
+// optionally filtered by action
+// (GET /api/admin/runbook?action=jobs.retry, requires system:configure)
+func (rh *RunbookHandler) ListRunbook(w http.ResponseWriter, r *http.Request) {
+	offset, limit := parsePagination(r)
+
+	runs, err := rh.repo.List(r.Context(), r.URL.Query().Get("action"), offset, limit)
+	if err != nil {
+		rh.logger.Error("Failed to list runbook runs", "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve runbook runs", "DATABASE_ERROR")
+		return
+	}
+
+	views := make([]runbookRunView, len(runs))
+	for i := range runs {
+		views[i] = newRunbookRunView(&runs[i])
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"actions": rh.service.Actions(),
+		"runs":    views,
+		"pagination": map[string]interface{}{
+			"offset": offset,
+			"limit":  limit,
+		},
+	})
+}
+
+// Run runs an action with the JSON object in the body as its params. An
+// Idempotency-Key header makes retries return the first run rather than
+// act again; replays carry an Idempotent-Replayed header.
+// (POST /api/admin/runbook/{action}, requires system:configure)
+func (rh *RunbookHandler) Run(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/api/admin/runbook/")
+	principal, ok := middleware.GetPrincipalFromContext(r.Context())
+	if !ok {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "User not authenticated", "NOT_AUTHENTICATED")
+		return
+	}
+
+	key := r.Header.Get("Idempotency-Key")
+	if len(key) > maxIdempotencyKeyLength {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Idempotency-Key is too long", "INVALID_IDEMPOTENCY_KEY")
+		return
+	}
+	params, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRunbookParamsSize))
+	if err != nil {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Failed to read params", "INVALID_REQUEST")
+		return
+	}
+	if len(strings.TrimSpace(string(params))) == 0 {
+		params = nil
+	} else if !isJSONObject(params) {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Params must be a JSON object", "INVALID_RUNBOOK_PARAMS")
+		return
+	}
+
+	run, replayed, err := rh.service.Run(r.Context(), name, params, principal.AuditLabel(), key)
+	if replayed {
+		w.Header().Set("Idempotent-Replayed", "true")
+	}
+	switch {
+	case stderrors.Is(err, runbook.ErrUnknownAction):
+		errors.WriteErrorResponse(w, http.StatusNotFound, "Unknown runbook action", "UNKNOWN_RUNBOOK_ACTION")
+	case stderrors.Is(err, runbook.ErrInvalidParams):
+		errors.WriteErrorResponse(w, http.StatusBadRequest, err.Error(), "INVALID_RUNBOOK_PARAMS")
+	case stderrors.Is(err, runbook.ErrTargetNotFound):
+		errors.WriteErrorResponse(w, http.StatusNotFound, err.Error(), "RUNBOOK_TARGET_NOT_FOUND")
+	case stderrors.Is(err, runbook.ErrRateLimited):
+		w.Header().Set("Retry-After", "60")
+		errors.WriteErrorResponse(w, http.StatusTooManyRequests, err.Error(), "RUNBOOK_RATE_LIMITED")
+	case stderrors.Is(err, runbook.ErrInProgress):
+		errors.WriteErrorResponse(w, http.StatusConflict, err.Error(), "RUNBOOK_IN_PROGRESS")
+	case run != nil && (err != nil || run.Status == models.RunbookStatusFailed):
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Runbook action failed: "+run.Error, "RUNBOOK_ACTION_FAILED")
+	case err != nil:
+		rh.logger.Error("Failed to run runbook action", "action", name, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to run runbook action", "DATABASE_ERROR")
+	default:
+		writeJSON(w, http.StatusOK, newRunbookRunView(run))
+	}
+}
+
+// isJSONObject reports whether data is a single JSON object
+func isJSONObject(data []byte) bool {
+	var object map[string]json.RawMessage
+	return json.Unmarshal(data, &object) == nil && object != nil
+}
@@ -0,0 +1,353 @@
+package handlers
+
+import (
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-server/internal/auth"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/errors"
+	"go-server/internal/logger"
+	"go-server/internal/middleware"
+	"go-server/internal/security"
+)
+
+// ServiceAccountHandler handles service account management and token exchange.
+// Accounts are managed by owners and admins of the organization they belong to.
+type ServiceAccountHandler struct {
+	service *auth.ServiceAccountService
+	repo    *repositories.ServiceAccountRepository
+	orgRepo *repositories.OrganizationRepository
+	logger  logger.Logger
+}
+
+// NewServiceAccountHandler creates a new service account handler
+func NewServiceAccountHandler(
+	service *auth.ServiceAccountService,
+	repo *repositories.ServiceAccountRepository,
+	orgRepo *repositories.OrganizationRepository,
+	logger logger.Logger,
+) *ServiceAccountHandler {
+	return &ServiceAccountHandler{
+		service: service,
+		repo:    repo,
+		orgRepo: orgRepo,
+		logger:  logger,
+	}
+}
+
+// CreateServiceAccountRequest represents a request to create a service account
+type CreateServiceAccountRequest struct {
+	Name        string   `json:"name" validate:"required,max=100"`
+	Description string   `json:"description" validate:"max=255"`
+	Scopes      []string `json:"scopes" validate:"required,max=32"`
+}
+
+// CreateKeyRequest represents a request to create an API key
+type CreateKeyRequest struct {
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// RegisterCertificateRequest represents a request to register a client certificate
+type RegisterCertificateRequest struct {
+	Certificate string `json:"certificate" validate:"required"`
+}
+
+// IssueTokenRequest represents a request for a scoped token
+type IssueTokenRequest struct {
+	Scopes []string `json:"scopes"`
+}
+
+// serviceAccountResponse is a service account with its scopes expanded
+type serviceAccountResponse struct {
+	*models.ServiceAccount
+	Scopes []string `json:"scopes"`
+}
+
+func newServiceAccountResponse(account *models.ServiceAccount) serviceAccountResponse {
+	return serviceAccountResponse{ServiceAccount: account, Scopes: account.ScopeList()}
+}
+
+// CreateServiceAccount creates a service account in an organization
+// (POST /api/organizations/{id}/service-accounts)
+func (sh *ServiceAccountHandler) CreateServiceAccount(w http.ResponseWriter, r *http.Request) {
+	orgID, err := parseIDFromPath(r.URL.Path, "/api/organizations/", "/service-accounts")
+	if err != nil {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid organization ID", "INVALID_ORGANIZATION_ID")
+		return
+	}
+	userID, ok := sh.requireManager(w, r, orgID)
+	if !ok {
+		return
+	}
+
+	req, failures := security.Bind[CreateServiceAccountRequest](r)
+	if len(failures) > 0 {
+		writeValidationErrors(w, failures)
+		return
+	}
+	for i, scope := range req.Scopes {
+		if !auth.ValidScope(scope) {
+			writeValidationErrors(w, []security.ValidationError{{
+				Field:   fmt.Sprintf("scopes[%d]", i),
+				Message: "Scope must look like resource:action",
+				Value:   scope,
+			}})
+			return
+		}
+	}
+
+	account := &models.ServiceAccount{
+		OrganizationID: orgID,
+		Name:           req.Name,
+		Description:    req.Description,
+		CreatedByID:    userID,
+	}
+	account.SetScopes(req.Scopes)
+	if err := sh.repo.CreateServiceAccount(r.Context(), account); err != nil {
+		sh.logger.Error("Failed to create service account", "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to create service account", "DATABASE_ERROR")
+		return
+	}
+
+	sh.logger.Info("Service account created", "service_account_id", account.ID, "organization_id", orgID, "user_id", userID)
+	writeJSON(w, http.StatusCreated, newServiceAccountResponse(account))
+}
+
+// ListServiceAccounts lists an organization's service accounts
+// (GET /api/organizations/{id}/service-accounts)
+func (sh *ServiceAccountHandler) ListServiceAccounts(w http.ResponseWriter, r *http.Request) {
+	orgID, err := parseIDFromPath(r.URL.Path, "/api/organizations/", "/service-accounts")
+	if err != nil {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid organization ID", "INVALID_ORGANIZATION_ID")
+		return
+	}
+	if _, ok := sh.requireManager(w, r, orgID); !ok {
+		return
+	}
+
+	accounts, err := sh.repo.ListServiceAccounts(r.Context(), orgID)
+	if err != nil {
+		sh.logger.Error("Failed to list service accounts", "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve service accounts", "DATABASE_ERROR")
+		return
+	}
+
+	response := make([]serviceAccountResponse, len(accounts))
+	for i := range accounts {
+		response[i] = newServiceAccountResponse(&accounts[i])
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"service_accounts": response})
+}
+
+// DeleteServiceAccount deletes a service account and revokes its credentials
+// (DELETE /api/service-accounts/{id})
+func (sh *ServiceAccountHandler) DeleteServiceAccount(w http.ResponseWriter, r *http.Request) {
+	account, ok := sh.managedAccount(w, r, "")
+	if !ok {
+		return
+	}
+
+	if err := sh.repo.DeleteServiceAccount(r.Context(), account.ID, time.Now()); err != nil {
+		sh.logger.Error("Failed to delete service account", "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to delete service account", "DATABASE_ERROR")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateKey creates an API key for a service account. The key is only ever
+// shown in this response.
+// (POST /api/service-accounts/{id}/keys)
+func (sh *ServiceAccountHandler) CreateKey(w http.ResponseWriter, r *http.Request) {
+	account, ok := sh.managedAccount(w, r, "/keys")
+	if !ok {
+		return
+	}
+
+	req, failures := security.Bind[CreateKeyRequest](r)
+	if len(failures) > 0 {
+		writeValidationErrors(w, failures)
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		writeValidationErrors(w, []security.ValidationError{{Field: "expires_at", Message: "Expiry must be in the future"}})
+		return
+	}
+
+	key, credential, err := sh.service.CreateAPIKey(r.Context(), account, req.ExpiresAt)
+	if err != nil {
+		sh.logger.Error("Failed to create API key", "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to create API key", "DATABASE_ERROR")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"key":        key,
+		"credential": credential,
+	})
+}
+
+// RegisterCertificate registers a PEM client certificate for a service account
+// (POST /api/service-accounts/{id}/certificates)
+func (sh *ServiceAccountHandler) RegisterCertificate(w http.ResponseWriter, r *http.Request) {
+	account, ok := sh.managedAccount(w, r, "/certificates")
+	if !ok {
+		return
+	}
+
+	req, failures := security.Bind[RegisterCertificateRequest](r)
+	if len(failures) > 0 {
+		writeValidationErrors(w, failures)
+		return
+	}
+
+	credential, err := sh.service.RegisterCertificate(r.Context(), account, []byte(req.Certificate))
+	if err != nil {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, err.Error(), "INVALID_CERTIFICATE")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, credential)
+}
+
+// ListCredentials lists a service account's keys and certificates
+// (GET /api/service-accounts/{id}/credentials)
+func (sh *ServiceAccountHandler) ListCredentials(w http.ResponseWriter, r *http.Request) {
+	account, ok := sh.managedAccount(w, r, "/credentials")
+	if !ok {
+		return
+	}
+
+	credentials, err := sh.repo.ListCredentials(r.Context(), account.ID)
+	if err != nil {
+		sh.logger.Error("Failed to list credentials", "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve credentials", "DATABASE_ERROR")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"credentials": credentials})
+}
+
+// RevokeCredential revokes a key or certificate
+// (DELETE /api/service-accounts/{id}/credentials/{credentialID})
+func (sh *ServiceAccountHandler) RevokeCredential(w http.ResponseWriter, r *http.Request) {
+	_, credentialPart, found := strings.Cut(r.URL.Path, "/credentials/")
+	credentialID, err := strconv.ParseUint(credentialPart, 10, 32)
+	if !found || err != nil {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid credential ID", "INVALID_CREDENTIAL_ID")
+		return
+	}
+	account, ok := sh.managedAccount(w, r, "/credentials/"+credentialPart)
+	if !ok {
+		return
+	}
+
+	revoked, err := sh.repo.RevokeCredential(r.Context(), account.ID, uint(credentialID), time.Now())
+	if err != nil {
+		sh.logger.Error("Failed to revoke credential", "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to revoke credential", "DATABASE_ERROR")
+		return
+	}
+	if !revoked {
+		errors.WriteErrorResponse(w, http.StatusNotFound, "Credential not found", "CREDENTIAL_NOT_FOUND")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// IssueToken exchanges an API key (X-API-Key header) or verified client
+// certificate for a short-lived token limited to the requested scopes
+// (POST /api/service-accounts/token)
+func (sh *ServiceAccountHandler) IssueToken(w http.ResponseWriter, r *http.Request) {
+	var account *models.ServiceAccount
+	var err error
+	switch {
+	case r.Header.Get("X-API-Key") != "":
+		account, err = sh.service.AuthenticateAPIKey(r.Context(), r.Header.Get("X-API-Key"))
+	case r.TLS != nil && len(r.TLS.VerifiedChains) > 0:
+		account, err = sh.service.AuthenticateCertificate(r.Context(), r.TLS.PeerCertificates[0])
+	default:
+		err = auth.ErrInvalidCredential
+	}
+	if stderrors.Is(err, auth.ErrInvalidCredential) || stderrors.Is(err, auth.ErrServiceAccountInactive) {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, err.Error(), "INVALID_CREDENTIALS")
+		return
+	}
+	if err != nil {
+		sh.logger.Error("Failed to authenticate service account", "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to authenticate", "DATABASE_ERROR")
+		return
+	}
+
+	req, failures := security.Bind[IssueTokenRequest](r)
+	if len(failures) > 0 {
+		writeValidationErrors(w, failures)
+		return
+	}
+
+	token, err := sh.service.IssueToken(account, req.Scopes)
+	if stderrors.Is(err, auth.ErrScopeNotGranted) {
+		errors.WriteErrorResponse(w, http.StatusForbidden, err.Error(), "SCOPE_NOT_GRANTED")
+		return
+	}
+	if err != nil {
+		sh.logger.Error("Failed to issue service account token", "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to issue token", "TOKEN_ERROR")
+		return
+	}
+
+	sh.logger.Info("Service account token issued", "actor", auth.AccountPrincipal(account).AuditLabel())
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, token)
+}
+
+// requireManager checks that the caller may manage the organization's
+// service accounts, writing an error response if not
+func (sh *ServiceAccountHandler) requireManager(w http.ResponseWriter, r *http.Request, orgID uint) (uint, bool) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "Authentication required", "NO_TOKEN")
+		return 0, false
+	}
+
+	member, err := sh.orgRepo.GetMember(r.Context(), orgID, userID)
+	if err != nil {
+		sh.logger.Error("Failed to load organization membership", "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to check membership", "DATABASE_ERROR")
+		return 0, false
+	}
+	if member == nil || !member.CanManage() {
+		errors.WriteErrorResponse(w, http.StatusForbidden, "Organization admin access required", "ORGANIZATION_ADMIN_REQUIRED")
+		return 0, false
+	}
+	return userID, true
+}
+
+// managedAccount loads the service account named in the path, checking the
+// caller may manage it
+func (sh *ServiceAccountHandler) managedAccount(w http.ResponseWriter, r *http.Request, suffix string) (*models.ServiceAccount, bool) {
+	accountID, err := parseIDFromPath(r.URL.Path, "/api/service-accounts/", suffix)
+	if err != nil {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid service account ID", "INVALID_SERVICE_ACCOUNT_ID")
+		return nil, false
+	}
+
+	account, err := sh.repo.GetServiceAccountByID(r.Context(), accountID)
+	if err != nil {
+		errors.WriteErrorResponse(w, http.StatusNotFound, "Service account not found", "SERVICE_ACCOUNT_NOT_FOUND")
+		return nil, false
+	}
+	if _, ok := sh.requireManager(w, r, account.OrganizationID); !ok {
+		return nil, false
+	}
+	return account, true
+}
@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-server/internal/approval"
+	"go-server/internal/clock"
+	"go-server/internal/database/repositories"
+	"go-server/internal/errors"
+	"go-server/internal/idgen"
+	"go-server/internal/logger"
+	"go-server/internal/rbac"
+
+	"gorm.io/gorm"
+)
+
+// TrashHandler handles admin endpoints for soft-deleted records
+type TrashHandler struct {
+	userRepo  *repositories.UserRepository
+	postRepo  *repositories.PostRepository
+	retention time.Duration
+	logger    logger.Logger
+	publicIDs bool
+	approvals *approval.Service
+	clock     clock.Clock
+}
+
+// NewTrashHandler creates a new trash handler
+func NewTrashHandler(
+	userRepo *repositories.UserRepository,
+	postRepo *repositories.PostRepository,
+	retention time.Duration,
+	logger logger.Logger,
+) *TrashHandler {
+	return &TrashHandler{
+		userRepo:  userRepo,
+		postRepo:  postRepo,
+		retention: retention,
+		logger:    logger,
+		clock:     clock.New(),
+	}
+}
+
+// WithClock sets the time source purge cutoffs are measured from
+func (th *TrashHandler) WithClock(c clock.Clock) *TrashHandler {
+	th.clock = clock.OrDefault(c)
+	return th
+}
+
+// WithPublicIDs makes restore URLs address records by public identifier instead of numeric ID
+func (th *TrashHandler) WithPublicIDs(enabled bool) *TrashHandler {
+	th.publicIDs = enabled
+	return th
+}
+
+// WithApprovals routes purges through the approval workflow
+func (th *TrashHandler) WithApprovals(service *approval.Service) *TrashHandler {
+	th.approvals = service
+	service.Register(approval.ActionPurgeUsers, rbac.TrashPurge, th.executePurge(th.userRepo.PurgeDeletedUsers))
+	service.Register(approval.ActionPurgePosts, rbac.TrashPurge, th.executePurge(th.postRepo.PurgeDeletedPosts))
+	return th
+}
+
+// purgeParams is the approval params of a purge
+type purgeParams struct {
+	Cutoff time.Time `json:"cutoff"`
+}
+
+// executePurge returns an approval executor running an approved purge
+func (th *TrashHandler) executePurge(purge func(context.Context, time.Time) (int64, error)) approval.Executor {
+	return func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		var p purgeParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		purged, err := purge(ctx, p.Cutoff)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"purged": purged, "cutoff": p.Cutoff}, nil
+	}
+}
+
+// ListDeletedUsers returns soft-deleted users (requires trash:restore)
+func (th *TrashHandler) ListDeletedUsers(w http.ResponseWriter, r *http.Request) {
+	offset, limit := parsePagination(r)
+
+	users, err := th.userRepo.ListDeletedUsers(r.Context(), offset, limit)
+	if err != nil {
+		th.logger.Error("Failed to list deleted users", "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve deleted users", "DATABASE_ERROR")
+		return
+	}
+
+	total, err := th.userRepo.CountDeletedUsers(r.Context())
+	if err != nil {
+		th.logger.Error("Failed to count deleted users", "error", err.Error())
+	}
+
+	writeTrashList(w, "users", users, offset, limit, total)
+}
+
+// RestoreUser restores a soft-deleted user (requires trash:restore)
+func (th *TrashHandler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := th.resolveID(r, "/api/admin/trash/users/", th.userRepo.FindUserIDByPublicID)
+	if stderrors.Is(err, gorm.ErrRecordNotFound) {
+		th.writeRestoreError(w, err, "user", 0)
+		return
+	}
+	if err != nil {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid user ID", "INVALID_USER_ID")
+		return
+	}
+
+	if err := th.userRepo.RestoreUser(r.Context(), userID); err != nil {
+		th.writeRestoreError(w, err, "user", userID)
+		return
+	}
+
+	th.logger.Info("User restored from trash", "user_id", userID)
+	writeTrashResult(w, map[string]interface{}{"restored": true, "user_id": userID})
+}
+
+// PurgeUsers permanently deletes users that have been in the trash longer than the retention window (requires trash:purge)
+func (th *TrashHandler) PurgeUsers(w http.ResponseWriter, r *http.Request) {
+	cutoff, ok := th.purgeCutoff(w, r)
+	if !ok {
+		return
+	}
+
+	if th.approvals != nil {
+		summary := "Purge users deleted before " + cutoff.Format(time.RFC3339)
+		submitForApproval(w, r, th.approvals, approval.ActionPurgeUsers, purgeParams{Cutoff: cutoff}, summary)
+		return
+	}
+
+	purged, err := th.userRepo.PurgeDeletedUsers(r.Context(), cutoff)
+	if err != nil {
+		th.logger.Error("Failed to purge deleted users", "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to purge users", "DATABASE_ERROR")
+		return
+	}
+
+	th.logger.Info("Purged deleted users", "count", purged, "cutoff", cutoff)
+	writeTrashResult(w, map[string]interface{}{"purged": purged, "cutoff": cutoff})
+}
+
+// ListDeletedPosts returns soft-deleted posts (requires trash:restore)
+func (th *TrashHandler) ListDeletedPosts(w http.ResponseWriter, r *http.Request) {
+	offset, limit := parsePagination(r)
+
+	posts, err := th.postRepo.ListDeletedPosts(r.Context(), offset, limit)
+	if err != nil {
+		th.logger.Error("Failed to list deleted posts", "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve deleted posts", "DATABASE_ERROR")
+		return
+	}
+
+	total, err := th.postRepo.CountDeletedPosts(r.Context())
+	if err != nil {
+		th.logger.Error("Failed to count deleted posts", "error", err.Error())
+	}
+
+	writeTrashList(w, "posts", posts, offset, limit, total)
+}
+
+// RestorePost restores a soft-deleted post (requires trash:restore)
+func (th *TrashHandler) RestorePost(w http.ResponseWriter, r *http.Request) {
+	postID, err := th.resolveID(r, "/api/admin/trash/posts/", th.postRepo.FindPostIDByPublicID)
+	if stderrors.Is(err, gorm.ErrRecordNotFound) {
+		th.writeRestoreError(w, err, "post", 0)
+		return
+	}
+	if err != nil {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid post ID", "INVALID_POST_ID")
+		return
+	}
+
+	if err := th.postRepo.RestorePost(r.Context(), postID); err != nil {
+		th.writeRestoreError(w, err, "post", postID)
+		return
+	}
+
+	th.logger.Info("Post restored from trash", "post_id", postID)
+	writeTrashResult(w, map[string]interface{}{"restored": true, "post_id": postID})
+}
+
+// PurgePosts permanently deletes posts that have been in the trash longer than the retention window (requires trash:purge)
+func (th *TrashHandler) PurgePosts(w http.ResponseWriter, r *http.Request) {
+	cutoff, ok := th.purgeCutoff(w, r)
+	if !ok {
+		return
+	}
+
+	if th.approvals != nil {
+		summary := "Purge posts deleted before " + cutoff.Format(time.RFC3339)
+		submitForApproval(w, r, th.approvals, approval.ActionPurgePosts, purgeParams{Cutoff: cutoff}, summary)
+		return
+	}
+
+	purged, err := th.postRepo.PurgeDeletedPosts(r.Context(), cutoff)
+	if err != nil {
+		th.logger.Error("Failed to purge deleted posts", "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to purge posts", "DATABASE_ERROR")
+		return
+	}
+
+	th.logger.Info("Purged deleted posts", "count", purged, "cutoff", cutoff)
+	writeTrashResult(w, map[string]interface{}{"purged": purged, "cutoff": cutoff})
+}
+
+// resolveID extracts the record reference from a restore URL and maps it to an internal key
+func (th *TrashHandler) resolveID(
+	r *http.Request,
+	prefix string,
+	lookup func(ctx context.Context, publicID string, includeDeleted bool) (uint, error),
+) (uint, error) {
+	if !th.publicIDs {
+		return parseIDFromPath(r.URL.Path, prefix, "/restore")
+	}
+
+	ref := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, prefix), "/restore")
+	if !idgen.IsPublicID(ref) {
+		return 0, fmt.Errorf("invalid public id %q", ref)
+	}
+	return lookup(r.Context(), ref, true)
+}
+
+// purgeCutoff resolves the purge cutoff from the optional older_than query parameter.
+// The requested window may be longer than the configured retention but never shorter.
+func (th *TrashHandler) purgeCutoff(w http.ResponseWriter, r *http.Request) (time.Time, bool) {
+	window := th.retention
+	if olderThan := r.URL.Query().Get("older_than"); olderThan != "" {
+		requested, err := time.ParseDuration(olderThan)
+		if err != nil {
+			errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid older_than duration", "INVALID_DURATION")
+			return time.Time{}, false
+		}
+		if requested < th.retention {
+			errors.WriteErrorResponse(w, http.StatusBadRequest, "older_than cannot be shorter than the retention window", "RETENTION_VIOLATION")
+			return time.Time{}, false
+		}
+		window = requested
+	}
+	return th.clock.Now().Add(-window), true
+}
+
+// writeRestoreError maps repository errors from a restore call to HTTP responses
+func (th *TrashHandler) writeRestoreError(w http.ResponseWriter, err error, resource string, id uint) {
+	if stderrors.Is(err, gorm.ErrRecordNotFound) {
+		errors.WriteErrorResponse(w, http.StatusNotFound, "Deleted "+resource+" not found", "NOT_IN_TRASH")
+		return
+	}
+	th.logger.Error("Failed to restore "+resource, "id", id, "error", err.Error())
+	errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to restore "+resource, "DATABASE_ERROR")
+}
+
+// writeTrashList writes a paginated list of trashed records
+func writeTrashList(w http.ResponseWriter, key string, items interface{}, offset, limit int, total int64) {
+	response := map[string]interface{}{
+		key: items,
+		"pagination": map[string]interface{}{
+			"offset": offset,
+			"limit":  limit,
+			"total":  total,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// writeTrashResult writes the result of a restore or purge operation
+func writeTrashResult(w http.ResponseWriter, data map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(data)
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/dbtest"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/logger"
+
+	"gorm.io/gorm"
+)
+
+type trashTest struct {
+	db      *gorm.DB
+	handler *TrashHandler
+	users   *repositories.UserRepository
+	posts   *repositories.PostRepository
+	clock   *clock.Fake
+}
+
+func newTrashTest(t *testing.T) *trashTest {
+	t.Helper()
+	db := dbtest.Open(t, &models.User{}, &models.Post{}, &models.OutboxEvent{}, &models.LegalHold{})
+	tt := &trashTest{
+		db:    db,
+		users: repositories.NewUserRepository(db),
+		posts: repositories.NewPostRepository(db),
+		clock: clock.NewFake(time.Now().Add(48 * time.Hour)),
+	}
+	tt.handler = NewTrashHandler(tt.users, tt.posts, 24*time.Hour, logger.NewServerLogger()).WithClock(tt.clock)
+	return tt
+}
+
+// trashedPost creates a post, deletes it and backdates its deletion to
+// deletedAt
+func (tt *trashTest) trashedPost(t *testing.T, slug string, deletedAt time.Time) *models.Post {
+	t.Helper()
+	post := &models.Post{Title: "Hello", Slug: slug, Content: "x", Status: "draft", AuthorID: 1}
+	if err := tt.posts.CreatePost(context.Background(), post); err != nil {
+		t.Fatalf("Failed to create post: %v", err)
+	}
+	if err := tt.posts.DeletePost(context.Background(), post.ID); err != nil {
+		t.Fatalf("Failed to delete post: %v", err)
+	}
+	tt.db.Unscoped().Model(post).UpdateColumn("deleted_at", deletedAt)
+	return post
+}
+
+func serveTrash(handler http.HandlerFunc, method, target string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(method, target, nil))
+	var body map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	return w, body
+}
+
+func TestTrashHandler_ListAndRestorePosts(t *testing.T) {
+	tt := newTrashTest(t)
+	first := tt.trashedPost(t, "first", tt.clock.Now().Add(-time.Hour))
+	tt.trashedPost(t, "second", tt.clock.Now().Add(-2*time.Hour))
+
+	w, body := serveTrash(tt.handler.ListDeletedPosts, http.MethodGet, "/api/admin/trash/posts?limit=1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+	posts, _ := body["posts"].([]interface{})
+	pagination, _ := body["pagination"].(map[string]interface{})
+	if len(posts) != 1 || pagination["total"] != float64(2) {
+		t.Errorf("Expected one of two deleted posts, got %v", body)
+	}
+
+	w, _ = serveTrash(tt.handler.RestorePost, http.MethodPost, "/api/admin/trash/posts/1/restore")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 restoring a deleted post, got %d", w.Code)
+	}
+	if _, err := tt.posts.GetPostByID(context.Background(), first.ID); err != nil {
+		t.Errorf("Restored post should be found again: %v", err)
+	}
+
+	w, body = serveTrash(tt.handler.RestorePost, http.MethodPost, "/api/admin/trash/posts/1/restore")
+	if w.Code != http.StatusNotFound || body["code"] != "NOT_IN_TRASH" {
+		t.Errorf("Expected 404 NOT_IN_TRASH restoring a live post, got %d %v", w.Code, body)
+	}
+	w, _ = serveTrash(tt.handler.RestorePost, http.MethodPost, "/api/admin/trash/posts/first/restore")
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid post ID, got %d", w.Code)
+	}
+}
+
+func TestTrashHandler_PurgePostsRespectsRetention(t *testing.T) {
+	tt := newTrashTest(t)
+	tt.trashedPost(t, "old", tt.clock.Now().Add(-72*time.Hour))
+	recent := tt.trashedPost(t, "recent", tt.clock.Now().Add(-12*time.Hour))
+
+	for _, target := range []string{"/api/admin/trash/posts/purge?older_than=1h", "/api/admin/trash/posts/purge?older_than=soon"} {
+		if w, _ := serveTrash(tt.handler.PurgePosts, http.MethodPost, target); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", target, w.Code)
+		}
+	}
+
+	w, body := serveTrash(tt.handler.PurgePosts, http.MethodPost, "/api/admin/trash/posts/purge")
+	if w.Code != http.StatusOK || body["purged"] != float64(1) {
+		t.Fatalf("Expected the post past retention purged, got %d %v", w.Code, body)
+	}
+	var remaining []models.Post
+	tt.db.Unscoped().Find(&remaining)
+	if len(remaining) != 1 || remaining[0].ID != recent.ID {
+		t.Errorf("Expected only the recently deleted post to remain, got %+v", remaining)
+	}
+
+	// Twelve hours on, the remaining post is past a 24h retention too
+	tt.clock.Advance(13 * time.Hour)
+	if _, body := serveTrash(tt.handler.PurgePosts, http.MethodPost, "/api/admin/trash/posts/purge"); body["purged"] != float64(1) {
+		t.Errorf("Expected the clock to move the cutoff, got %v", body)
+	}
+}
+
+func TestTrashHandler_Users(t *testing.T) {
+	tt := newTrashTest(t)
+	ctx := context.Background()
+	user := &models.User{Username: "ada", Email: "ada@example.com", Password: "x", IsActive: true}
+	if err := tt.users.CreateUser(ctx, user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	if err := tt.users.DeleteUser(ctx, user.ID); err != nil {
+		t.Fatalf("Failed to delete user: %v", err)
+	}
+
+	_, body := serveTrash(tt.handler.ListDeletedUsers, http.MethodGet, "/api/admin/trash/users")
+	if users, _ := body["users"].([]interface{}); len(users) != 1 {
+		t.Errorf("Expected the deleted user listed, got %v", body)
+	}
+	if w, _ := serveTrash(tt.handler.RestoreUser, http.MethodPost, "/api/admin/trash/users/1/restore"); w.Code != http.StatusOK {
+		t.Errorf("Expected 200 restoring a deleted user, got %d", w.Code)
+	}
+	if w, _ := serveTrash(tt.handler.RestoreUser, http.MethodPost, "/api/admin/trash/users/1/restore"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 restoring a live user, got %d", w.Code)
+	}
+	if _, body := serveTrash(tt.handler.PurgeUsers, http.MethodPost, "/api/admin/trash/users/purge"); body["purged"] != float64(0) {
+		t.Errorf("Expected a restored user to survive the purge, got %v", body)
+	}
+}
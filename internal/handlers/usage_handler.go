@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-server/internal/database/repositories"
+	"go-server/internal/errors"
+	"go-server/internal/logger"
+)
+
+// defaultUsagePeriod is the period reported when no dates are given
+const defaultUsagePeriod = 30 * 24 * time.Hour
+
+// maxUsagePeriod bounds the period of one report
+const maxUsagePeriod = 366 * 24 * time.Hour
+
+// UsageHandler serves the feature usage reports
+type UsageHandler struct {
+	usageRepo *repositories.UsageRepository
+	logger    logger.Logger
+}
+
+// NewUsageHandler creates a new usage handler
+func NewUsageHandler(usageRepo *repositories.UsageRepository, logger logger.Logger) *UsageHandler {
+	return &UsageHandler{
+		usageRepo: usageRepo,
+		logger:    logger,
+	}
+}
+
+// Report totals the usage of each feature over a period, most used first
+// (GET /api/admin/usage?from=2026-01-01&to=2026-01-31&prefix=GET /api/v1,
+// requires usage:read). Counts are estimates scaled up from sampled requests.
+func (uh *UsageHandler) Report(w http.ResponseWriter, r *http.Request) {
+	from, to, ok := usagePeriod(w, r)
+	if !ok {
+		return
+	}
+	summaries, err := uh.usageRepo.SummarizeFeatures(r.Context(), from, to, r.URL.Query().Get("prefix"))
+	if err != nil {
+		uh.logger.Error("Failed to summarize feature usage: %v", err)
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to build usage report", "DATABASE_ERROR")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"from":     from.Format(time.DateOnly),
+		"to":       to.Format(time.DateOnly),
+		"features": summaries,
+	})
+}
+
+// FeatureAccounts lists the accounts still using a feature, heaviest first
+// (GET /api/admin/usage/accounts?feature=GET /api/v1/posts&from=&to=&limit=,
+// requires usage:read)
+func (uh *UsageHandler) FeatureAccounts(w http.ResponseWriter, r *http.Request) {
+	feature := strings.TrimSpace(r.URL.Query().Get("feature"))
+	if feature == "" {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Feature is required", "MISSING_FEATURE")
+		return
+	}
+	from, to, ok := usagePeriod(w, r)
+	if !ok {
+		return
+	}
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+
+	accounts, err := uh.usageRepo.ListFeatureAccounts(r.Context(), feature, from, to, limit)
+	if err != nil {
+		uh.logger.Error("Failed to list feature accounts: %v", err)
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to build usage report", "DATABASE_ERROR")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"feature":  feature,
+		"from":     from.Format(time.DateOnly),
+		"to":       to.Format(time.DateOnly),
+		"accounts": accounts,
+	})
+}
+
+// usagePeriod reads the from and to days, defaulting to the last 30 days
+func usagePeriod(w http.ResponseWriter, r *http.Request) (time.Time, time.Time, bool) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	from, to := today.Add(-defaultUsagePeriod), today
+
+	var err error
+	if value := r.URL.Query().Get("from"); value != "" {
+		if from, err = time.Parse(time.DateOnly, value); err != nil {
+			errors.WriteErrorResponse(w, http.StatusBadRequest, "From must be a date such as 2026-01-31", "INVALID_USAGE_PERIOD")
+			return from, to, false
+		}
+	}
+	if value := r.URL.Query().Get("to"); value != "" {
+		if to, err = time.Parse(time.DateOnly, value); err != nil {
+			errors.WriteErrorResponse(w, http.StatusBadRequest, "To must be a date such as 2026-01-31", "INVALID_USAGE_PERIOD")
+			return from, to, false
+		}
+	}
+	if to.Before(from) || to.Sub(from) > maxUsagePeriod {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "The period must run forwards and span at most a year", "INVALID_USAGE_PERIOD")
+		return from, to, false
+	}
+	return from, to, true
+}
@@ -1,30 +1,91 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	stderrors "errors"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"go-server/internal/database/models"
 	"go-server/internal/database/repositories"
+	"go-server/internal/emailchange"
 	"go-server/internal/errors"
+	"go-server/internal/idgen"
 	"go-server/internal/logger"
 	"go-server/internal/middleware"
+	"go-server/internal/notify"
+	"go-server/internal/security"
+	"go-server/internal/usernames"
 )
 
+//go:generate go run go-server/cmd/mockgen -source user_handler.go -out mocks/user_handler.go UserStore
+
+// UserStore reads and updates users; *repositories.UserRepository
+// satisfies it
+type UserStore interface {
+	GetUserByID(ctx context.Context, id uint) (*models.User, error)
+	GetUserByPublicID(ctx context.Context, publicID string) (*models.User, error)
+	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
+	ListUsers(ctx context.Context, offset, limit int) ([]models.User, error)
+	CountUsers(ctx context.Context) (int64, error)
+	UpdateUser(ctx context.Context, user *models.User) error
+}
+
+var _ UserStore = (*repositories.UserRepository)(nil)
+
 // UserHandler handles user-related endpoints
 type UserHandler struct {
-	userRepo *repositories.UserRepository
-	logger   logger.Logger
+	userRepo  UserStore
+	logger    logger.Logger
+	publicIDs bool
+	usernames *usernames.Policy
+	emails    *emailchange.Service
 }
 
 // NewUserHandler creates a new user handler
-func NewUserHandler(userRepo *repositories.UserRepository, logger logger.Logger) *UserHandler {
+func NewUserHandler(userRepo UserStore, logger logger.Logger) *UserHandler {
 	return &UserHandler{
 		userRepo: userRepo,
 		logger:   logger,
 	}
 }
 
+// WithPublicIDs makes user URLs address users by public identifier instead of numeric ID
+func (uh *UserHandler) WithPublicIDs(enabled bool) *UserHandler {
+	uh.publicIDs = enabled
+	return uh
+}
+
+// WithUsernamePolicy checks new usernames against the policy; only
+// administrators can override it
+func (uh *UserHandler) WithUsernamePolicy(policy *usernames.Policy) *UserHandler {
+	uh.usernames = policy
+	return uh
+}
+
+// WithEmailChange makes email updates wait for confirmation from the new
+// address. Without it the profile endpoint refuses to change emails.
+func (uh *UserHandler) WithEmailChange(service *emailchange.Service) *UserHandler {
+	uh.emails = service
+	return uh
+}
+
+// UpdateProfileRequest represents a profile update; empty fields are left
+// unchanged. A new email is only pending until confirmed, see
+// package emailchange.
+type UpdateProfileRequest struct {
+	Username    string `json:"username" validate:"min=3,max=20"`
+	FirstName   string `json:"first_name" validate:"max=50"`
+	LastName    string `json:"last_name" validate:"max=50"`
+	Email       string `json:"email" validate:"email"`
+	PhoneNumber string `json:"phone_number" validate:"max=20"`
+	// Version is the profile's version when the client read it. If set,
+	// the update is refused if the profile has changed since.
+	Version uint `json:"version"`
+}
+
 // GetProfile returns the current user's profile
 func (uh *UserHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
 	// Get user from context (set by auth middleware)
@@ -40,20 +101,30 @@ func (uh *UserHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(user)
 }
 
-// GetUserByID returns a user by ID (admin only)
+// GetUserByID returns a user by ID (requires users:read)
 func (uh *UserHandler) GetUserByID(w http.ResponseWriter, r *http.Request) {
-	// Get user ID from URL path
-	userIDStr := r.URL.Path[len("/api/users/"):]
-	userID, err := strconv.ParseUint(userIDStr, 10, 32)
-	if err != nil {
-		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid user ID", "INVALID_USER_ID")
-		return
-	}
+	var err error
 
-	// Get user from database
-	user, err := uh.userRepo.GetUserByID(r.Context(), uint(userID))
+	// Get user reference from URL path
+	userRef := r.URL.Path[len("/api/users/"):]
+
+	var user *models.User
+	if uh.publicIDs {
+		if !idgen.IsPublicID(userRef) {
+			errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid user ID", "INVALID_USER_ID")
+			return
+		}
+		user, err = uh.userRepo.GetUserByPublicID(r.Context(), userRef)
+	} else {
+		userID, parseErr := strconv.ParseUint(userRef, 10, 32)
+		if parseErr != nil {
+			errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid user ID", "INVALID_USER_ID")
+			return
+		}
+		user, err = uh.userRepo.GetUserByID(r.Context(), uint(userID))
+	}
 	if err != nil {
-		uh.logger.Error("Failed to get user", "user_id", userID, "error", err.Error())
+		uh.logger.Error("Failed to get user", "user_ref", userRef, "error", err.Error())
 		errors.WriteErrorResponse(w, http.StatusNotFound, "User not found", "USER_NOT_FOUND")
 		return
 	}
@@ -64,7 +135,7 @@ func (uh *UserHandler) GetUserByID(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(user)
 }
 
-// ListUsers returns a list of users (admin only)
+// ListUsers returns a list of users (requires users:read)
 func (uh *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
 	// Parse query parameters
 	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
@@ -116,36 +187,64 @@ func (uh *UserHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Parse request body
-	var updateData struct {
-		FirstName string `json:"first_name"`
-		LastName  string `json:"last_name"`
-		Email     string `json:"email"`
+	updateData, failures := security.Bind[UpdateProfileRequest](r)
+	if len(failures) > 0 {
+		writeValidationErrors(w, failures)
+		return
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&updateData); err != nil {
-		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid request body", "INVALID_REQUEST")
+	if updateData.Version != 0 && updateData.Version != currentUser.Version {
+		errors.WriteErrorResponse(w, http.StatusConflict, "Profile was changed by another request; reload it and try again", "VERSION_CONFLICT")
 		return
 	}
 
 	// Update user fields
+	if updateData.Username != "" && updateData.Username != currentUser.Username {
+		if !uh.usernameAvailable(w, r.Context(), currentUser.ID, updateData.Username, false) {
+			return
+		}
+		currentUser.Username = updateData.Username
+	}
 	if updateData.FirstName != "" {
 		currentUser.FirstName = updateData.FirstName
 	}
 	if updateData.LastName != "" {
 		currentUser.LastName = updateData.LastName
 	}
-	if updateData.Email != "" && updateData.Email != currentUser.Email {
-		// Check if email is already taken
-		existingUser, err := uh.userRepo.GetUserByEmail(r.Context(), updateData.Email)
-		if err == nil && existingUser.ID != currentUser.ID {
-			errors.WriteErrorResponse(w, http.StatusConflict, "Email already taken", "EMAIL_TAKEN")
+	changeEmail := updateData.Email != "" && !strings.EqualFold(updateData.Email, currentUser.Email)
+	if changeEmail && uh.emails == nil {
+		errors.WriteErrorResponse(w, http.StatusServiceUnavailable, "Email changes are not available", "EMAIL_CHANGE_UNAVAILABLE")
+		return
+	}
+	if updateData.PhoneNumber != "" {
+		if !notify.ValidPhoneNumber(updateData.PhoneNumber) {
+			errors.WriteErrorResponse(w, http.StatusBadRequest, "Phone number must be in E.164 format", "INVALID_PHONE_NUMBER")
 			return
 		}
-		currentUser.Email = updateData.Email
+		currentUser.PhoneNumber = updateData.PhoneNumber
 	}
 
-	// Update user in database
-	if err := uh.userRepo.UpdateUser(r.Context(), currentUser); err != nil {
+	// Update user in database; an email change saves the profile along
+	// with the pending address
+	if changeEmail {
+		err := uh.emails.Request(r.Context(), currentUser, updateData.Email)
+		if stderrors.Is(err, emailchange.ErrEmailTaken) {
+			errors.WriteErrorResponse(w, http.StatusConflict, "Email already taken", "EMAIL_TAKEN")
+			return
+		}
+		if stderrors.Is(err, repositories.ErrVersionConflict) {
+			errors.WriteErrorResponse(w, http.StatusConflict, "Profile was changed by another request; reload it and try again", "VERSION_CONFLICT")
+			return
+		}
+		if err != nil {
+			uh.logger.Error("Failed to request email change", "user_id", currentUser.ID, "error", err.Error())
+			errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to start the email change", "EMAIL_CHANGE_FAILED")
+			return
+		}
+	} else if err := uh.userRepo.UpdateUser(r.Context(), currentUser); stderrors.Is(err, repositories.ErrVersionConflict) {
+		errors.WriteErrorResponse(w, http.StatusConflict, "Profile was changed by another request; reload it and try again", "VERSION_CONFLICT")
+		return
+	} else if err != nil {
 		uh.logger.Error("Failed to update user profile", "user_id", currentUser.ID, "error", err.Error())
 		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to update profile", "DATABASE_ERROR")
 		return
@@ -158,3 +257,76 @@ func (uh *UserHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(currentUser)
 }
+
+// RenameUserRequest represents an administrator changing a username.
+// Override allows a name the username policy refuses, e.g. a reserved name
+// for a staff account.
+type RenameUserRequest struct {
+	Username string `json:"username" validate:"required,min=3,max=20"`
+	Override bool   `json:"override"`
+}
+
+// RenameUser changes a user's username
+// (PUT /api/admin/users/{id}/username, requires users:manage)
+func (uh *UserHandler) RenameUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseIDFromPath(r.URL.Path, "/api/admin/users/", "/username")
+	if err != nil {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid user ID", "INVALID_USER_ID")
+		return
+	}
+
+	req, failures := security.Bind[RenameUserRequest](r)
+	if len(failures) > 0 {
+		writeValidationErrors(w, failures)
+		return
+	}
+
+	user, err := uh.userRepo.GetUserByID(r.Context(), userID)
+	if err != nil {
+		errors.WriteErrorResponse(w, http.StatusNotFound, "User not found", "USER_NOT_FOUND")
+		return
+	}
+	if req.Username == user.Username {
+		writeJSON(w, http.StatusOK, user)
+		return
+	}
+	if !uh.usernameAvailable(w, r.Context(), user.ID, req.Username, req.Override) {
+		return
+	}
+
+	previous := user.Username
+	user.Username = req.Username
+	err = uh.userRepo.UpdateUser(r.Context(), user)
+	if stderrors.Is(err, repositories.ErrVersionConflict) {
+		errors.WriteErrorResponse(w, http.StatusConflict, "User was changed by another request; reload it and try again", "VERSION_CONFLICT")
+		return
+	}
+	if err != nil {
+		uh.logger.Error("Failed to rename user", "user_id", user.ID, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to rename user", "DATABASE_ERROR")
+		return
+	}
+
+	uh.logger.Info("User renamed", "user_id", user.ID, "previous", previous, "username", user.Username,
+		"override", req.Override, "changed_by", r.Context().Value("user_id"))
+	writeJSON(w, http.StatusOK, user)
+}
+
+// usernameAvailable checks that userID may take username, writing an error
+// response if not. override skips the username policy but not uniqueness.
+func (uh *UserHandler) usernameAvailable(w http.ResponseWriter, ctx context.Context, userID uint, username string, override bool) bool {
+	if uh.usernames != nil && !override {
+		var violation *usernames.Violation
+		if err := uh.usernames.Check(username); stderrors.As(err, &violation) {
+			errors.WriteError(w, errors.NewAPIErrorWithCode(errors.ErrorTypeValidation, "USERNAME_NOT_ALLOWED", violation.Error(),
+				http.StatusUnprocessableEntity).WithExtension("reason", violation.Reason))
+			return false
+		}
+	}
+	existing, err := uh.userRepo.GetUserByUsername(ctx, username)
+	if err == nil && existing.ID != userID {
+		errors.WriteErrorResponse(w, http.StatusConflict, "Username already taken", "USERNAME_TAKEN")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/handlers/mocks"
+	"go-server/internal/logger"
+)
+
+func TestUserHandler_GetUserByID(t *testing.T) {
+	users := &mocks.UserStore{
+		GetUserByIDFunc: func(ctx context.Context, id uint) (*models.User, error) {
+			if id != 42 {
+				return nil, errors.New("record not found")
+			}
+			return &models.User{BaseModel: models.BaseModel{ID: id}, Username: "ada"}, nil
+		},
+	}
+	handler := NewUserHandler(users, logger.NewServerLogger())
+
+	for _, tc := range []struct {
+		path   string
+		status int
+	}{
+		{"/api/users/42", http.StatusOK},
+		{"/api/users/43", http.StatusNotFound},
+		{"/api/users/ada", http.StatusBadRequest},
+	} {
+		w := httptest.NewRecorder()
+		handler.GetUserByID(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if w.Code != tc.status {
+			t.Errorf("GET %s: expected %d, got %d: %s", tc.path, tc.status, w.Code, w.Body)
+		}
+	}
+	if calls := users.Calls("GetUserByID"); len(calls) != 2 {
+		t.Errorf("Expected an invalid ID not to reach the store, got %v", calls)
+	}
+}
+
+func TestUserHandler_GetUserByID_PublicIDs(t *testing.T) {
+	// Only GetUserByPublicIDFunc is set: a numeric lookup would panic
+	users := &mocks.UserStore{
+		GetUserByPublicIDFunc: func(ctx context.Context, publicID string) (*models.User, error) {
+			return &models.User{PublicID: publicID}, nil
+		},
+	}
+	handler := NewUserHandler(users, logger.NewServerLogger()).WithPublicIDs(true)
+
+	publicID := "0190a5b2-7c4e-7d3a-8f1e-2b3c4d5e6f70"
+	w := httptest.NewRecorder()
+	handler.GetUserByID(w, httptest.NewRequest(http.MethodGet, "/api/users/"+publicID, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	handler.GetUserByID(w, httptest.NewRequest(http.MethodGet, "/api/users/42", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected a numeric ID refused, got %d", w.Code)
+	}
+}
+
+func TestUserHandler_ListUsers(t *testing.T) {
+	users := &mocks.UserStore{
+		ListUsersFunc: func(ctx context.Context, offset, limit int) ([]models.User, error) {
+			return []models.User{{Username: "ada"}}, nil
+		},
+		CountUsersFunc: func(ctx context.Context) (int64, error) {
+			return 0, errors.New("timeout")
+		},
+	}
+	handler := NewUserHandler(users, logger.NewServerLogger())
+
+	w := httptest.NewRecorder()
+	handler.ListUsers(w, httptest.NewRequest(http.MethodGet, "/api/users?offset=5&limit=500", nil))
+
+	// A failed count is logged, not returned
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body)
+	}
+	if calls := users.Calls("ListUsers"); len(calls) != 1 || calls[0][1] != 5 || calls[0][2] != 20 {
+		t.Errorf("Expected the limit clamped to the default, got %v", calls)
+	}
+}
+
+func TestUserHandler_UpdateProfile(t *testing.T) {
+	current := &models.User{BaseModel: models.BaseModel{ID: 1}, Username: "ada", Version: 3}
+	users := &mocks.UserStore{
+		GetUserByUsernameFunc: func(ctx context.Context, username string) (*models.User, error) {
+			if username == "grace" {
+				return &models.User{BaseModel: models.BaseModel{ID: 2}, Username: username}, nil
+			}
+			return nil, errors.New("record not found")
+		},
+		UpdateUserFunc: func(ctx context.Context, user *models.User) error { return nil },
+	}
+	handler := NewUserHandler(users, logger.NewServerLogger())
+
+	update := func(body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPut, "/api/users/profile", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		user := *current
+		r = r.WithContext(context.WithValue(r.Context(), "user", &user))
+		w := httptest.NewRecorder()
+		handler.UpdateProfile(w, r)
+		return w
+	}
+
+	w := update(`{"username": "lovelace", "first_name": "Ada"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body)
+	}
+	var updated models.User
+	json.NewDecoder(w.Body).Decode(&updated)
+	if updated.Username != "lovelace" || updated.FirstName != "Ada" {
+		t.Errorf("Expected the profile updated, got %+v", updated)
+	}
+
+	if w := update(`{"username": "grace"}`); w.Code != http.StatusConflict {
+		t.Errorf("Expected a taken username refused, got %d", w.Code)
+	}
+	if w := update(`{"first_name": "Ada", "version": 2}`); w.Code != http.StatusConflict {
+		t.Errorf("Expected a stale version refused, got %d", w.Code)
+	}
+	if calls := users.Calls("UpdateUser"); len(calls) != 1 {
+		t.Errorf("Expected only the first update stored, got %d", len(calls))
+	}
+
+	users.UpdateUserFunc = func(ctx context.Context, user *models.User) error {
+		return repositories.ErrVersionConflict
+	}
+	if w := update(`{"first_name": "Ada"}`); w.Code != http.StatusConflict {
+		t.Errorf("Expected a concurrent update refused, got %d", w.Code)
+	}
+}
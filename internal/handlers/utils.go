@@ -1,6 +1,63 @@
 package handlers
 
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-server/internal/errors"
+	"go-server/internal/security"
+)
+
 // bToMb converts bytes to megabytes
 func bToMb(b uint64) uint64 {
 	return b / 1024 / 1024
 }
+
+// parseIDFromPath extracts a numeric ID from a URL path between a prefix and an optional suffix
+func parseIDFromPath(path, prefix, suffix string) (uint, error) {
+	idStr := strings.TrimSuffix(strings.TrimPrefix(path, prefix), suffix)
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	return uint(id), nil
+}
+
+// parsePagination reads offset and limit query parameters with sane defaults
+func parsePagination(r *http.Request) (int, int) {
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return offset, limit
+}
+
+// requestBaseURL returns the scheme and host the client used, for links in
+// responses read outside the API such as feeds
+func requestBaseURL(r *http.Request) string {
+	scheme := "https"
+	if r.TLS == nil && r.Header.Get("X-Forwarded-Proto") != "https" {
+		scheme = "http"
+	}
+	return scheme + "://" + r.Host
+}
+
+// writeJSON writes data as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+// writeValidationErrors writes a 400 validation error naming the first
+// failure, with every failure listed under errors
+func writeValidationErrors(w http.ResponseWriter, failures []security.ValidationError) {
+	errors.WriteError(w, errors.NewValidationError(failures[0].Field, failures[0].Message).WithExtension("errors", failures))
+}
@@ -0,0 +1,498 @@
+package handlers
+
+import (
+	stderrors "errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/errors"
+	"go-server/internal/idgen"
+	"go-server/internal/logger"
+	"go-server/internal/middleware"
+	"go-server/internal/rbac"
+	"go-server/internal/security"
+	"go-server/internal/webhooks"
+
+	"gorm.io/gorm"
+)
+
+// WebhookHandler handles webhook subscription and delivery log endpoints.
+// Users manage their own webhooks, and owners and admins of an
+// organization manage its webhooks.
+type WebhookHandler struct {
+	webhookRepo *repositories.WebhookRepository
+	dispatcher  *webhooks.Dispatcher
+	logger      logger.Logger
+	allowHTTP   bool
+	orgRepo     *repositories.OrganizationRepository
+	deliverer   *webhooks.Deliverer
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(
+	webhookRepo *repositories.WebhookRepository,
+	dispatcher *webhooks.Dispatcher,
+	logger logger.Logger,
+) *WebhookHandler {
+	return &WebhookHandler{
+		webhookRepo: webhookRepo,
+		dispatcher:  dispatcher,
+		logger:      logger,
+	}
+}
+
+// WithAllowHTTP permits plain-http callback URLs, for local development
+func (wh *WebhookHandler) WithAllowHTTP(allow bool) *WebhookHandler {
+	wh.allowHTTP = allow
+	return wh
+}
+
+// WithOrganizations enables organization webhooks, managed by the
+// organization's owners and admins
+func (wh *WebhookHandler) WithOrganizations(orgRepo *repositories.OrganizationRepository) *WebhookHandler {
+	wh.orgRepo = orgRepo
+	return wh
+}
+
+// WithDeliverer enables test deliveries
+func (wh *WebhookHandler) WithDeliverer(deliverer *webhooks.Deliverer) *WebhookHandler {
+	wh.deliverer = deliverer
+	return wh
+}
+
+// webhookView is the API representation of a webhook
+type webhookView struct {
+	ID             uint      `json:"id"`
+	OrganizationID *uint     `json:"organization_id,omitempty"`
+	URL            string    `json:"url"`
+	Events         []string  `json:"events"`
+	Filter         string    `json:"filter,omitempty"`
+	Fields         []string  `json:"fields,omitempty"`
+	Description    string    `json:"description"`
+	IsActive       bool      `json:"is_active"`
+	Secret         string    `json:"secret,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func newWebhookView(webhook *models.Webhook) webhookView {
+	return webhookView{
+		ID:             webhook.ID,
+		OrganizationID: webhook.OrganizationID,
+		URL:            webhook.URL,
+		Events:         webhook.EventList(),
+		Filter:         webhook.Filter,
+		Fields:         webhook.FieldList(),
+		Description:    webhook.Description,
+		IsActive:       webhook.IsActive,
+		CreatedAt:      webhook.CreatedAt,
+	}
+}
+
+// CreateWebhookRequest represents a request to register a webhook. Filter
+// is an expression events must match, see webhooks.ParseFilter, and Fields
+// selects the payload fields delivered.
+type CreateWebhookRequest struct {
+	URL         string   `json:"url" validate:"required"`
+	Events      []string `json:"events"`
+	Filter      string   `json:"filter" validate:"max=1024"`
+	Fields      []string `json:"fields"`
+	Description string   `json:"description" validate:"max=255"`
+}
+
+// TestWebhookRequest represents a request to send a test delivery. Event
+// is the sample event sent, by default the first the webhook subscribes to.
+type TestWebhookRequest struct {
+	Event string `json:"event"`
+}
+
+// CreateWebhook registers a callback URL for events (POST /api/webhooks).
+// The signing secret is only included in this response.
+func (wh *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "User not authenticated", "NOT_AUTHENTICATED")
+		return
+	}
+	wh.createWebhook(w, r, user.ID, nil)
+}
+
+// CreateOrganizationWebhook registers a callback URL for events about an
+// organization's members (POST /api/organizations/{id}/webhooks, requires
+// organization owner or admin). The signing secret is only included in
+// this response.
+func (wh *WebhookHandler) CreateOrganizationWebhook(w http.ResponseWriter, r *http.Request) {
+	orgID, err := parseIDFromPath(r.URL.Path, "/api/organizations/", "/webhooks")
+	if err != nil {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid organization ID", "INVALID_ORGANIZATION_ID")
+		return
+	}
+	userID, ok := wh.requireOrgManager(w, r, orgID)
+	if !ok {
+		return
+	}
+	wh.createWebhook(w, r, userID, &orgID)
+}
+
+// createWebhook registers a webhook for userID, or for the organization
+// when orgID is set
+func (wh *WebhookHandler) createWebhook(w http.ResponseWriter, r *http.Request, userID uint, orgID *uint) {
+	req, failures := security.Bind[CreateWebhookRequest](r)
+	if len(failures) > 0 {
+		writeValidationErrors(w, failures)
+		return
+	}
+
+	if !wh.validCallbackURL(req.URL) {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Callback URL must be an absolute https URL", "INVALID_WEBHOOK_URL")
+		return
+	}
+	if len(req.Events) == 0 {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "At least one event is required", "INVALID_WEBHOOK_EVENTS")
+		return
+	}
+	for _, event := range req.Events {
+		if !webhooks.IsSupportedEvent(event) {
+			errors.WriteErrorResponse(w, http.StatusBadRequest, "Unsupported event: "+event, "INVALID_WEBHOOK_EVENTS")
+			return
+		}
+	}
+	if _, err := webhooks.ParseFilter(req.Filter); err != nil {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid filter: "+err.Error(), "INVALID_WEBHOOK_FILTER")
+		return
+	}
+	if err := webhooks.ValidateFields(req.Fields); err != nil {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid fields: "+err.Error(), "INVALID_WEBHOOK_FIELDS")
+		return
+	}
+
+	secret, err := idgen.Default.Token(32)
+	if err != nil {
+		wh.logger.Error("Failed to generate webhook secret", "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to create webhook", "INTERNAL_ERROR")
+		return
+	}
+
+	webhook := &models.Webhook{
+		UserID:         userID,
+		URL:            req.URL,
+		Secret:         "whsec_" + secret,
+		Description:    req.Description,
+		IsActive:       true,
+		OrganizationID: orgID,
+		Filter:         strings.TrimSpace(req.Filter),
+	}
+	webhook.SetEvents(req.Events)
+	webhook.SetFields(req.Fields)
+
+	if err := wh.webhookRepo.CreateWebhook(r.Context(), webhook); err != nil {
+		wh.logger.Error("Failed to create webhook", "user_id", userID, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to create webhook", "DATABASE_ERROR")
+		return
+	}
+
+	wh.logger.Info("Webhook created", "webhook_id", webhook.ID, "user_id", userID)
+
+	view := newWebhookView(webhook)
+	view.Secret = webhook.Secret
+	writeJSON(w, http.StatusCreated, view)
+}
+
+// ListWebhooks returns the current user's webhooks (GET /api/webhooks)
+func (wh *WebhookHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "User not authenticated", "NOT_AUTHENTICATED")
+		return
+	}
+
+	list, err := wh.webhookRepo.ListWebhooksByUser(r.Context(), user.ID)
+	if err != nil {
+		wh.logger.Error("Failed to list webhooks", "user_id", user.ID, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve webhooks", "DATABASE_ERROR")
+		return
+	}
+
+	writeWebhooks(w, list)
+}
+
+// ListOrganizationWebhooks returns an organization's webhooks
+// (GET /api/organizations/{id}/webhooks, requires organization owner or admin)
+func (wh *WebhookHandler) ListOrganizationWebhooks(w http.ResponseWriter, r *http.Request) {
+	orgID, err := parseIDFromPath(r.URL.Path, "/api/organizations/", "/webhooks")
+	if err != nil {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid organization ID", "INVALID_ORGANIZATION_ID")
+		return
+	}
+	if _, ok := wh.requireOrgManager(w, r, orgID); !ok {
+		return
+	}
+
+	list, err := wh.webhookRepo.ListWebhooksByOrganization(r.Context(), orgID)
+	if err != nil {
+		wh.logger.Error("Failed to list organization webhooks", "organization_id", orgID, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve webhooks", "DATABASE_ERROR")
+		return
+	}
+	writeWebhooks(w, list)
+}
+
+func writeWebhooks(w http.ResponseWriter, list []models.Webhook) {
+	views := make([]webhookView, 0, len(list))
+	for i := range list {
+		views = append(views, newWebhookView(&list[i]))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"webhooks": views})
+}
+
+// DeleteWebhook removes a webhook (DELETE /api/webhooks/{id})
+func (wh *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := wh.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	if err := wh.webhookRepo.DeleteWebhook(r.Context(), webhook.ID); err != nil {
+		wh.logger.Error("Failed to delete webhook", "webhook_id", webhook.ID, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to delete webhook", "DATABASE_ERROR")
+		return
+	}
+
+	wh.logger.Info("Webhook deleted", "webhook_id", webhook.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// TestWebhook sends a signed sample event to a webhook and returns the
+// logged attempt, whether or not the receiver accepted it. The receiver's
+// response body is left out, so the endpoint cannot be used to read what
+// a server answers.
+// (POST /api/webhooks/{id}/test)
+func (wh *WebhookHandler) TestWebhook(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := wh.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+	if wh.deliverer == nil {
+		errors.WriteErrorResponse(w, http.StatusServiceUnavailable, "Test deliveries are not available", "WEBHOOK_TEST_UNAVAILABLE")
+		return
+	}
+
+	req, failures := security.Bind[TestWebhookRequest](r)
+	if len(failures) > 0 {
+		writeValidationErrors(w, failures)
+		return
+	}
+	event := req.Event
+	if event == "" {
+		event = models.EventPostPublished
+		for _, subscribed := range webhook.EventList() {
+			if subscribed != models.WebhookAllEvents {
+				event = subscribed
+				break
+			}
+		}
+	}
+	if event == models.WebhookAllEvents || !webhooks.IsSupportedEvent(event) {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Unsupported event: "+event, "INVALID_WEBHOOK_EVENTS")
+		return
+	}
+
+	delivery, err := wh.deliverer.SendTest(r.Context(), webhook, event)
+	if err != nil {
+		wh.logger.Error("Failed to send test webhook", "webhook_id", webhook.ID, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to send test delivery", "INTERNAL_ERROR")
+		return
+	}
+
+	wh.logger.Info("Webhook test delivery sent", "webhook_id", webhook.ID, "event", event, "succeeded", delivery.Succeeded)
+	delivery.ResponseBody = ""
+	writeJSON(w, http.StatusOK, map[string]interface{}{"delivery": delivery})
+}
+
+// ListDeliveries returns a webhook's delivery log (GET /api/webhooks/{id}/deliveries).
+// Pass failed=true to only return failed attempts.
+func (wh *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := wh.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	offset, limit := parsePagination(r)
+	failedOnly, _ := strconv.ParseBool(r.URL.Query().Get("failed"))
+
+	deliveries, err := wh.webhookRepo.ListDeliveries(r.Context(), webhook.ID, failedOnly, offset, limit)
+	if err != nil {
+		wh.logger.Error("Failed to list webhook deliveries", "webhook_id", webhook.ID, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve deliveries", "DATABASE_ERROR")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"deliveries": deliveries,
+		"pagination": map[string]interface{}{
+			"offset": offset,
+			"limit":  limit,
+		},
+	})
+}
+
+// Redeliver retries a logged delivery (POST /api/webhooks/{id}/deliveries/{deliveryID}/redeliver)
+func (wh *WebhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := wh.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	segments := webhookPathSegments(r.URL.Path)
+	if len(segments) != 4 || segments[1] != "deliveries" || segments[3] != "redeliver" {
+		errors.WriteErrorResponse(w, http.StatusNotFound, "Not found", "NOT_FOUND")
+		return
+	}
+	deliveryID, err := strconv.ParseUint(segments[2], 10, 32)
+	if err != nil {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid delivery ID", "INVALID_DELIVERY_ID")
+		return
+	}
+
+	delivery, err := wh.webhookRepo.GetDeliveryByID(r.Context(), uint(deliveryID))
+	if err != nil || delivery.WebhookID != webhook.ID {
+		errors.WriteErrorResponse(w, http.StatusNotFound, "Delivery not found", "DELIVERY_NOT_FOUND")
+		return
+	}
+
+	if err := wh.dispatcher.Redeliver(r.Context(), delivery); err != nil {
+		wh.logger.Error("Failed to redeliver webhook", "delivery_id", delivery.ID, "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to schedule redelivery", "REDELIVERY_FAILED")
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{"redelivery_scheduled": true})
+}
+
+// ListFailures returns failed deliveries across all webhooks for the admin UI
+// (GET /api/admin/webhooks/failures, requires webhooks:read)
+func (wh *WebhookHandler) ListFailures(w http.ResponseWriter, r *http.Request) {
+	offset, limit := parsePagination(r)
+
+	deliveries, err := wh.webhookRepo.ListFailedDeliveries(r.Context(), offset, limit)
+	if err != nil {
+		wh.logger.Error("Failed to list failed webhook deliveries", "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve deliveries", "DATABASE_ERROR")
+		return
+	}
+
+	total, err := wh.webhookRepo.CountFailedDeliveries(r.Context())
+	if err != nil {
+		wh.logger.Error("Failed to count failed webhook deliveries", "error", err.Error())
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"deliveries": deliveries,
+		"pagination": map[string]interface{}{
+			"offset": offset,
+			"limit":  limit,
+			"total":  total,
+		},
+	})
+}
+
+// ownedWebhook loads the webhook addressed by the URL and checks the caller may manage it
+func (wh *WebhookHandler) ownedWebhook(w http.ResponseWriter, r *http.Request) (*models.Webhook, bool) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "User not authenticated", "NOT_AUTHENTICATED")
+		return nil, false
+	}
+
+	segments := webhookPathSegments(r.URL.Path)
+	if len(segments) == 0 {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid webhook ID", "INVALID_WEBHOOK_ID")
+		return nil, false
+	}
+	webhookID, err := strconv.ParseUint(segments[0], 10, 32)
+	if err != nil {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid webhook ID", "INVALID_WEBHOOK_ID")
+		return nil, false
+	}
+
+	webhook, err := wh.webhookRepo.GetWebhookByID(r.Context(), uint(webhookID))
+	if err != nil {
+		if !stderrors.Is(err, gorm.ErrRecordNotFound) {
+			wh.logger.Error("Failed to get webhook", "webhook_id", webhookID, "error", err.Error())
+		}
+		errors.WriteErrorResponse(w, http.StatusNotFound, "Webhook not found", "WEBHOOK_NOT_FOUND")
+		return nil, false
+	}
+
+	// Other users' webhooks are reported as missing rather than forbidden
+	if !wh.canManage(r, user, webhook) && !rbac.UserCan(user, rbac.WebhooksManage) {
+		errors.WriteErrorResponse(w, http.StatusNotFound, "Webhook not found", "WEBHOOK_NOT_FOUND")
+		return nil, false
+	}
+	return webhook, true
+}
+
+// canManage checks the user registered the webhook, or manages the
+// organization it belongs to
+func (wh *WebhookHandler) canManage(r *http.Request, user *models.User, webhook *models.Webhook) bool {
+	if webhook.OrganizationID == nil {
+		return webhook.UserID == user.ID
+	}
+	if wh.orgRepo == nil {
+		return false
+	}
+	member, err := wh.orgRepo.GetMember(r.Context(), *webhook.OrganizationID, user.ID)
+	if err != nil {
+		wh.logger.Error("Failed to load organization membership", "error", err.Error())
+		return false
+	}
+	return member != nil && member.CanManage()
+}
+
+// requireOrgManager checks that the caller may manage the organization's
+// webhooks, writing an error response if not
+func (wh *WebhookHandler) requireOrgManager(w http.ResponseWriter, r *http.Request, orgID uint) (uint, bool) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "Authentication required", "NO_TOKEN")
+		return 0, false
+	}
+	if wh.orgRepo == nil {
+		errors.WriteErrorResponse(w, http.StatusForbidden, "Organization admin access required", "ORGANIZATION_ADMIN_REQUIRED")
+		return 0, false
+	}
+
+	member, err := wh.orgRepo.GetMember(r.Context(), orgID, userID)
+	if err != nil {
+		wh.logger.Error("Failed to load organization membership", "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to check membership", "DATABASE_ERROR")
+		return 0, false
+	}
+	if member == nil || !member.CanManage() {
+		errors.WriteErrorResponse(w, http.StatusForbidden, "Organization admin access required", "ORGANIZATION_ADMIN_REQUIRED")
+		return 0, false
+	}
+	return userID, true
+}
+
+// validCallbackURL checks the callback is an absolute https URL (or http when allowed)
+func (wh *WebhookHandler) validCallbackURL(raw string) bool {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" {
+		return false
+	}
+	return parsed.Scheme == "https" || (wh.allowHTTP && parsed.Scheme == "http")
+}
+
+// webhookPathSegments splits the path after /api/webhooks/ into segments
+func webhookPathSegments(path string) []string {
+	rest := strings.Trim(strings.TrimPrefix(path, "/api/webhooks/"), "/")
+	if rest == "" {
+		return nil
+	}
+	return strings.Split(rest, "/")
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-server/internal/database/dbtest"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/logger"
+	"go-server/internal/webhooks"
+)
+
+func TestWebhookHandler_TestWebhookHidesResponseBody(t *testing.T) {
+	db := dbtest.Open(t, &models.Webhook{}, &models.WebhookDelivery{})
+	repo := repositories.NewWebhookRepository(db)
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("internal service secret"))
+	}))
+	defer receiver.Close()
+
+	user := &models.User{BaseModel: models.BaseModel{ID: 7}}
+	webhook := &models.Webhook{UserID: user.ID, URL: receiver.URL, Secret: "whsec_test", IsActive: true}
+	webhook.SetEvents([]string{models.EventPostPublished})
+	if err := repo.CreateWebhook(context.Background(), webhook); err != nil {
+		t.Fatalf("Failed to create webhook: %v", err)
+	}
+
+	deliverer := webhooks.NewDeliverer(repo, time.Second, logger.NewServerLogger()).WithHTTPClient(receiver.Client())
+	handler := NewWebhookHandler(repo, nil, logger.NewServerLogger()).WithDeliverer(deliverer)
+
+	r := httptest.NewRequest(http.MethodPost, "/api/webhooks/1/test", strings.NewReader(`{}`))
+	r.Header.Set("Content-Type", "application/json")
+	ctx := context.WithValue(r.Context(), "user", user)
+	r = r.WithContext(context.WithValue(ctx, "user_id", user.ID))
+	w := httptest.NewRecorder()
+	handler.TestWebhook(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "internal service secret") {
+		t.Errorf("The receiver's response body should not be returned, got %s", w.Body.String())
+	}
+	var body struct {
+		Delivery models.WebhookDelivery `json:"delivery"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || !body.Delivery.Succeeded {
+		t.Errorf("Expected the successful delivery, got %s, %v", w.Body.String(), err)
+	}
+}
@@ -0,0 +1,220 @@
+// Package httpcache emits HTTP caching headers for public resources so that
+// browsers and CDNs can cache and revalidate them correctly.
+package httpcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-server/internal/config"
+)
+
+// NoStore is the Cache-Control value for responses that must never be cached
+const NoStore = "no-store"
+
+// PostListKey tags every response that lists posts
+const PostListKey = "posts"
+
+// PostKey returns the surrogate key for a single post
+func PostKey(id uint) string {
+	return "post:" + strconv.FormatUint(uint64(id), 10)
+}
+
+// AuthorKey returns the surrogate key for content by an author
+func AuthorKey(id uint) string {
+	return "author:" + strconv.FormatUint(uint64(id), 10)
+}
+
+// SetSurrogateKeys tags the response so it can be purged by key. Keys are sent as
+// Surrogate-Key (Fastly) and Cache-Tag (Cloudflare); duplicates are dropped.
+func SetSurrogateKeys(w http.ResponseWriter, keys ...string) {
+	seen := make(map[string]struct{}, len(keys))
+	unique := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if _, ok := seen[key]; ok || key == "" {
+			continue
+		}
+		seen[key] = struct{}{}
+		unique = append(unique, key)
+	}
+	if len(unique) == 0 {
+		return
+	}
+	w.Header().Set("Surrogate-Key", strings.Join(unique, " "))
+	w.Header().Set("Cache-Tag", strings.Join(unique, ","))
+}
+
+// AddVary adds names to the Vary header unless already listed. A Vary of
+// "*" already covers every header and is left alone.
+func AddVary(header http.Header, names ...string) {
+	listed := make(map[string]bool)
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			listed[strings.ToLower(strings.TrimSpace(name))] = true
+		}
+	}
+	if listed["*"] {
+		return
+	}
+	for _, name := range names {
+		if !listed[strings.ToLower(name)] {
+			header.Add("Vary", name)
+			listed[strings.ToLower(name)] = true
+		}
+	}
+}
+
+// WeakETag returns etag as a weak validator, for a representation that is
+// semantically but not byte-for-byte the one etag was computed for
+func WeakETag(etag string) string {
+	if etag == "" || strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return "W/" + etag
+}
+
+// Policy describes the freshness lifetime of a public resource
+type Policy struct {
+	// MaxAge is how long browsers may reuse a response without revalidating
+	MaxAge time.Duration
+	// SMaxAge overrides MaxAge for shared caches such as CDNs
+	SMaxAge time.Duration
+	// StaleWhileRevalidate lets shared caches serve stale content while refetching in the background
+	StaleWhileRevalidate time.Duration
+	// StaleIfError lets caches serve stale content when the origin is failing
+	StaleIfError time.Duration
+}
+
+// PolicyFromConfig builds a policy from the HTTP cache configuration
+func PolicyFromConfig(cfg config.HTTPCacheConfig) Policy {
+	return Policy{
+		MaxAge:               cfg.MaxAge,
+		SMaxAge:              cfg.SMaxAge,
+		StaleWhileRevalidate: cfg.StaleWhileRevalidate,
+		StaleIfError:         cfg.StaleIfError,
+	}
+}
+
+// CacheControl renders the policy as a Cache-Control header value
+func (p Policy) CacheControl() string {
+	directives := []string{"public", "max-age=" + seconds(p.MaxAge)}
+	if p.SMaxAge > 0 {
+		directives = append(directives, "s-maxage="+seconds(p.SMaxAge))
+	}
+	if p.StaleWhileRevalidate > 0 {
+		directives = append(directives, "stale-while-revalidate="+seconds(p.StaleWhileRevalidate))
+	}
+	if p.StaleIfError > 0 {
+		directives = append(directives, "stale-if-error="+seconds(p.StaleIfError))
+	}
+	return strings.Join(directives, ", ")
+}
+
+// Validators identify a specific version of a resource for conditional requests
+type Validators struct {
+	ETag         string
+	LastModified time.Time
+}
+
+// ForResource derives validators for a single record from its kind, ID and updated_at
+func ForResource(kind string, id uint, updatedAt time.Time) Validators {
+	return Validators{
+		ETag:         fmt.Sprintf(`W/"%s-%d-%x"`, kind, id, updatedAt.UnixNano()),
+		LastModified: updatedAt,
+	}
+}
+
+// Collection accumulates validators for a list of records
+type Collection struct {
+	kind   string
+	hash   []string
+	latest time.Time
+}
+
+// NewCollection starts validators for a list of records of the given kind
+func NewCollection(kind string) *Collection {
+	return &Collection{kind: kind}
+}
+
+// Add includes a record in the collection
+func (c *Collection) Add(id uint, updatedAt time.Time) {
+	c.hash = append(c.hash, fmt.Sprintf("%d:%d", id, updatedAt.UnixNano()))
+	if updatedAt.After(c.latest) {
+		c.latest = updatedAt
+	}
+}
+
+// Validators returns validators that change whenever membership, order or any member changes
+func (c *Collection) Validators() Validators {
+	sum := sha256.Sum256([]byte(strings.Join(c.hash, ",")))
+	return Validators{
+		ETag:         fmt.Sprintf(`W/"%s-list-%s"`, c.kind, hex.EncodeToString(sum[:8])),
+		LastModified: c.latest,
+	}
+}
+
+// Write sets caching headers on the response and reports whether the client's copy is
+// still fresh. When it returns true a 304 Not Modified has been written and the caller
+// must not write a body.
+func Write(w http.ResponseWriter, r *http.Request, policy Policy, v Validators) bool {
+	header := w.Header()
+	header.Set("Cache-Control", policy.CacheControl())
+	if v.ETag != "" {
+		header.Set("ETag", v.ETag)
+	}
+	if !v.LastModified.IsZero() {
+		header.Set("Last-Modified", v.LastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if !NotModified(r, v) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// NotModified evaluates If-None-Match and If-Modified-Since against the validators.
+// If-None-Match takes precedence as required by RFC 9110.
+func NotModified(r *http.Request, v Validators) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return v.ETag != "" && etagMatches(inm, v.ETag)
+	}
+
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" || v.LastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	// HTTP dates have second precision
+	return !v.LastModified.Truncate(time.Second).After(since)
+}
+
+// etagMatches performs the weak comparison used for If-None-Match
+func etagMatches(header, etag string) bool {
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
+
+func seconds(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	return strconv.FormatInt(int64(d/time.Second), 10)
+}
@@ -0,0 +1,153 @@
+package httpcache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPolicy_CacheControl(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   Policy
+		expected string
+	}{
+		{
+			name:     "browser only",
+			policy:   Policy{MaxAge: time.Minute},
+			expected: "public, max-age=60",
+		},
+		{
+			name: "cdn directives",
+			policy: Policy{
+				MaxAge:               time.Minute,
+				SMaxAge:              5 * time.Minute,
+				StaleWhileRevalidate: 30 * time.Second,
+				StaleIfError:         time.Hour,
+			},
+			expected: "public, max-age=60, s-maxage=300, stale-while-revalidate=30, stale-if-error=3600",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.CacheControl(); got != tt.expected {
+				t.Errorf("CacheControl() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWrite_SetsHeaders(t *testing.T) {
+	updated := time.Date(2024, 3, 1, 10, 0, 0, 500, time.UTC)
+	v := ForResource("post", 7, updated)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/posts/7", nil)
+	if Write(w, r, Policy{MaxAge: time.Minute}, v) {
+		t.Fatal("Unconditional request should not be reported as not modified")
+	}
+
+	if got := w.Header().Get("ETag"); got != v.ETag {
+		t.Errorf("Expected ETag %q, got %q", v.ETag, got)
+	}
+	if got := w.Header().Get("Last-Modified"); got != "Fri, 01 Mar 2024 10:00:00 GMT" {
+		t.Errorf("Unexpected Last-Modified %q", got)
+	}
+	if got := w.Header().Get("Cache-Control"); got != "public, max-age=60" {
+		t.Errorf("Unexpected Cache-Control %q", got)
+	}
+}
+
+func TestWrite_ConditionalRequests(t *testing.T) {
+	updated := time.Date(2024, 3, 1, 10, 0, 0, 500, time.UTC)
+	v := ForResource("post", 7, updated)
+
+	tests := []struct {
+		name        string
+		header      string
+		value       string
+		notModified bool
+	}{
+		{"matching etag", "If-None-Match", v.ETag, true},
+		{"strong form of weak etag", "If-None-Match", v.ETag[2:], true},
+		{"etag list", "If-None-Match", `"other", ` + v.ETag, true},
+		{"wildcard", "If-None-Match", "*", true},
+		{"stale etag", "If-None-Match", `W/"post-7-0"`, false},
+		{"modified since earlier", "If-Modified-Since", "Fri, 01 Mar 2024 09:59:59 GMT", false},
+		{"not modified since", "If-Modified-Since", "Fri, 01 Mar 2024 10:00:00 GMT", true},
+		{"bad date", "If-Modified-Since", "yesterday", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/api/posts/7", nil)
+			r.Header.Set(tt.header, tt.value)
+
+			if got := Write(w, r, Policy{}, v); got != tt.notModified {
+				t.Errorf("Write() = %v, want %v", got, tt.notModified)
+			}
+			if tt.notModified && w.Code != http.StatusNotModified {
+				t.Errorf("Expected status 304, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestCollection_ValidatorsChange(t *testing.T) {
+	base := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	a := NewCollection("post")
+	a.Add(1, base)
+	a.Add(2, base.Add(time.Hour))
+
+	b := NewCollection("post")
+	b.Add(1, base)
+	b.Add(2, base.Add(2*time.Hour))
+
+	va, vb := a.Validators(), b.Validators()
+	if va.ETag == vb.ETag {
+		t.Error("ETag should change when a member is updated")
+	}
+	if !va.LastModified.Equal(base.Add(time.Hour)) {
+		t.Errorf("Expected latest update as Last-Modified, got %v", va.LastModified)
+	}
+}
+
+func TestSetSurrogateKeys(t *testing.T) {
+	w := httptest.NewRecorder()
+	SetSurrogateKeys(w, PostListKey, PostKey(1), AuthorKey(2), PostKey(1))
+
+	if got := w.Header().Get("Surrogate-Key"); got != "posts post:1 author:2" {
+		t.Errorf("Unexpected Surrogate-Key %q", got)
+	}
+	if got := w.Header().Get("Cache-Tag"); got != "posts,post:1,author:2" {
+		t.Errorf("Unexpected Cache-Tag %q", got)
+	}
+}
+
+func TestAddVary(t *testing.T) {
+	header := http.Header{}
+	header.Add("Vary", "Origin, accept-encoding")
+	AddVary(header, "Accept-Encoding", "Accept-Language", "Accept-Language")
+	if got := strings.Join(header.Values("Vary"), ", "); got != "Origin, accept-encoding, Accept-Language" {
+		t.Errorf("Unexpected Vary %q", got)
+	}
+
+	header = http.Header{"Vary": {"*"}}
+	AddVary(header, "Accept-Encoding")
+	if got := header.Values("Vary"); len(got) != 1 {
+		t.Errorf("Expected Vary: * left alone, got %q", got)
+	}
+}
+
+func TestWeakETag(t *testing.T) {
+	for etag, want := range map[string]string{`"v1"`: `W/"v1"`, `W/"v1"`: `W/"v1"`, "": ""} {
+		if got := WeakETag(etag); got != want {
+			t.Errorf("WeakETag(%q) = %q, want %q", etag, got, want)
+		}
+	}
+}
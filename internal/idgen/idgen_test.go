@@ -0,0 +1,73 @@
+package idgen
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRandom(t *testing.T) {
+	r := NewRandom()
+
+	id1, id2 := r.NewID(), r.NewID()
+	if len(id1) != 32 {
+		t.Errorf("Expected 32 hex characters, got %d", len(id1))
+	}
+	if id1 == id2 {
+		t.Error("Random IDs should not repeat")
+	}
+
+	token, err := r.Token(32)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if len(token) != 64 {
+		t.Errorf("Expected 64 hex characters, got %d", len(token))
+	}
+}
+
+func TestSequence(t *testing.T) {
+	s := NewSequence("req")
+
+	if id := s.NewID(); id != "req-1" {
+		t.Errorf("Expected req-1, got %s", id)
+	}
+	if token, _ := s.Token(32); token != "req-2" {
+		t.Errorf("Expected req-2, got %s", token)
+	}
+}
+
+func TestPublicIDs(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	uuid := NewUUIDv7(now)
+	if len(uuid) != 36 || uuid[14] != '7' {
+		t.Errorf("Expected a version 7 UUID, got %s", uuid)
+	}
+	if !IsPublicID(uuid) {
+		t.Errorf("UUIDv7 %s should be recognised as a public ID", uuid)
+	}
+
+	ulid := NewULID(now)
+	if len(ulid) != 26 {
+		t.Errorf("Expected 26 character ULID, got %s", ulid)
+	}
+	if !IsPublicID(ulid) {
+		t.Errorf("ULID %s should be recognised as a public ID", ulid)
+	}
+
+	// Identifiers generated later sort after earlier ones
+	if later := NewULID(now.Add(time.Millisecond)); later <= ulid {
+		t.Errorf("ULIDs should be time ordered: %s <= %s", later, ulid)
+	}
+	if later := NewUUIDv7(now.Add(time.Millisecond)); later <= uuid {
+		t.Errorf("UUIDv7s should be time ordered: %s <= %s", later, uuid)
+	}
+
+	if IsPublicID("12345") {
+		t.Error("Numeric IDs should not be recognised as public IDs")
+	}
+
+	if _, err := ParsePublicIDFormat("snowflake"); err == nil {
+		t.Error("Expected error for unsupported format")
+	}
+}
@@ -0,0 +1,119 @@
+package idgen
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// PublicIDFormat selects the encoding of externally visible identifiers
+type PublicIDFormat string
+
+const (
+	// FormatUUIDv7 produces time-ordered RFC 9562 version 7 UUIDs
+	FormatUUIDv7 PublicIDFormat = "uuidv7"
+	// FormatULID produces 26-character Crockford base32 ULIDs
+	FormatULID PublicIDFormat = "ulid"
+)
+
+// crockford is the ULID base32 alphabet
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ParsePublicIDFormat validates a configured public ID format
+func ParsePublicIDFormat(value string) (PublicIDFormat, error) {
+	switch PublicIDFormat(value) {
+	case FormatUUIDv7, FormatULID:
+		return PublicIDFormat(value), nil
+	default:
+		return "", fmt.Errorf("unsupported public id format %q (expected %q or %q)", value, FormatUUIDv7, FormatULID)
+	}
+}
+
+// NewPublicID generates a public identifier in the given format
+func NewPublicID(format PublicIDFormat, now time.Time) string {
+	if format == FormatULID {
+		return NewULID(now)
+	}
+	return NewUUIDv7(now)
+}
+
+// NewUUIDv7 generates a version 7 UUID for the given timestamp
+func NewUUIDv7(now time.Time) string {
+	var b [16]byte
+	mustRead(b[6:])
+
+	ms := uint64(now.UnixMilli())
+	b[0] = byte(ms >> 40)
+	b[1] = byte(ms >> 32)
+	b[2] = byte(ms >> 24)
+	b[3] = byte(ms >> 16)
+	b[4] = byte(ms >> 8)
+	b[5] = byte(ms)
+	b[6] = (b[6] & 0x0f) | 0x70 // version 7
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+
+	h := hex.EncodeToString(b[:])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
+
+// NewULID generates a ULID for the given timestamp
+func NewULID(now time.Time) string {
+	var b [16]byte
+	mustRead(b[6:])
+
+	ms := uint64(now.UnixMilli())
+	b[0] = byte(ms >> 40)
+	b[1] = byte(ms >> 32)
+	b[2] = byte(ms >> 24)
+	b[3] = byte(ms >> 16)
+	b[4] = byte(ms >> 8)
+	b[5] = byte(ms)
+
+	// Encode 128 bits as 26 base32 characters, most significant first
+	out := make([]byte, 26)
+	hi := uint64(b[0])<<56 | uint64(b[1])<<48 | uint64(b[2])<<40 | uint64(b[3])<<32 |
+		uint64(b[4])<<24 | uint64(b[5])<<16 | uint64(b[6])<<8 | uint64(b[7])
+	lo := uint64(b[8])<<56 | uint64(b[9])<<48 | uint64(b[10])<<40 | uint64(b[11])<<32 |
+		uint64(b[12])<<24 | uint64(b[13])<<16 | uint64(b[14])<<8 | uint64(b[15])
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out)
+}
+
+// IsPublicID reports whether value looks like a UUID or ULID rather than a numeric key
+func IsPublicID(value string) bool {
+	switch len(value) {
+	case 36:
+		return value[8] == '-' && value[13] == '-' && value[18] == '-' && value[23] == '-'
+	case 26:
+		for i := 0; i < len(value); i++ {
+			if indexCrockford(value[i]) < 0 {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// indexCrockford returns the value of an upper-case Crockford base32 character
+func indexCrockford(c byte) int {
+	for i := 0; i < len(crockford); i++ {
+		if crockford[i] == c {
+			return i
+		}
+	}
+	return -1
+}
+
+// mustRead fills b from crypto/rand
+func mustRead(b []byte) {
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("idgen: failed to read random bytes: %v", err))
+	}
+}
@@ -0,0 +1,38 @@
+package jobs
+
+import "time"
+
+// Backoff computes the delay before a failed job is retried
+type Backoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// DefaultBackoff returns the default exponential backoff policy
+func DefaultBackoff() Backoff {
+	return Backoff{
+		Base: 5 * time.Second,
+		Max:  30 * time.Minute,
+	}
+}
+
+// Delay returns the delay for the given attempt number (1-based), doubling
+// each attempt up to Max
+func (b Backoff) Delay(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	delay := b.Base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if b.Max > 0 && delay >= b.Max {
+			return b.Max
+		}
+	}
+
+	if b.Max > 0 && delay > b.Max {
+		return b.Max
+	}
+	return delay
+}
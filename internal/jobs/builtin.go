@@ -0,0 +1,74 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-server/internal/database/repositories"
+)
+
+// Built-in job types
+const (
+	JobTypeSessionCleanup = "sessions.cleanup"
+	JobTypeCacheWarm      = "cache.warm"
+)
+
+// CacheWarmPayload configures a cache warming run
+type CacheWarmPayload struct {
+	Posts int `json:"posts"`
+	Users int `json:"users"`
+}
+
+// SessionCleanupHandler removes expired sessions
+func SessionCleanupHandler(sessionRepo *repositories.SessionRepository) Handler {
+	return func(ctx context.Context, job *Job) error {
+		if err := sessionRepo.CleanupExpiredSessions(ctx); err != nil {
+			return fmt.Errorf("failed to clean up sessions: %w", err)
+		}
+		return nil
+	}
+}
+
+// CacheWarmHandler pre-populates the cache with recent published posts and active users
+func CacheWarmHandler(
+	userRepo *repositories.UserRepository,
+	postRepo *repositories.PostRepository,
+	cacheRepo *repositories.CacheRepository,
+	ttl time.Duration,
+) Handler {
+	return func(ctx context.Context, job *Job) error {
+		payload := CacheWarmPayload{Posts: 50, Users: 100}
+		if err := job.Decode(&payload); err != nil {
+			return fmt.Errorf("invalid cache warm payload: %w", err)
+		}
+
+		posts, err := postRepo.ListPublishedPosts(ctx, 0, payload.Posts)
+		if err != nil {
+			return fmt.Errorf("failed to load posts: %w", err)
+		}
+		for i := range posts {
+			if err := cacheRepo.SetPostCache(ctx, &posts[i], ttl); err != nil {
+				return fmt.Errorf("failed to cache post %d: %w", posts[i].ID, err)
+			}
+		}
+
+		users, err := userRepo.GetActiveUsers(ctx, 0, payload.Users)
+		if err != nil {
+			return fmt.Errorf("failed to load users: %w", err)
+		}
+		for i := range users {
+			if err := cacheRepo.SetUserCache(ctx, &users[i], ttl); err != nil {
+				return fmt.Errorf("failed to cache user %d: %w", users[i].ID, err)
+			}
+		}
+
+		return nil
+	}
+}
+
+// RegisterBuiltins registers the built-in job handlers with the pool
+func RegisterBuiltins(pool *Pool, repos *repositories.RepositoryManager, cacheTTL time.Duration) {
+	pool.Register(JobTypeSessionCleanup, SessionCleanupHandler(repos.Session))
+	pool.Register(JobTypeCacheWarm, CacheWarmHandler(repos.User, repos.Post, repos.Cache, cacheTTL))
+}
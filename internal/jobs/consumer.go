@@ -0,0 +1,110 @@
+package jobs
+
+import (
+	"context"
+	"time"
+)
+
+// Message is a record read from a message broker. Partition and Offset
+// locate it in a Kafka topic; other brokers leave them zero.
+type Message struct {
+	Topic     string `json:"topic"`
+	Key       string `json:"key,omitempty"`
+	Value     []byte `json:"value"`
+	Partition int32  `json:"partition,omitempty"`
+	Offset    int64  `json:"offset,omitempty"`
+}
+
+// Consumer reads a broker's messages as a member of a consumer group, so
+// each message goes to one member. Messages fetched but not committed may
+// be delivered again, possibly to another member.
+type Consumer interface {
+	// Fetch waits for messages until ctx is done
+	Fetch(ctx context.Context) ([]Message, error)
+	Commit(ctx context.Context, messages []Message) error
+	Close() error
+}
+
+// consumer is a Consumer drained by the pool
+type consumer struct {
+	name     string
+	consumer Consumer
+	route    func(Message) string
+}
+
+// Consume runs a consumer group's messages as jobs once the pool starts.
+// Each message is enqueued as a job of the type route returns, with the
+// message as its payload, and committed once every message fetched with
+// it is enqueued; messages routed to "" are committed without a job. The
+// consumer is closed by Stop. Retries and dead-lettering are those of any
+// other job, so a failing handler does not hold up the group.
+func (p *Pool) Consume(name string, c Consumer, route func(Message) string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.consumers = append(p.consumers, consumer{name: name, consumer: c, route: route})
+}
+
+// consumeLoop enqueues a consumer's messages until the context is cancelled
+func (p *Pool) consumeLoop(ctx context.Context, c consumer) {
+	defer p.wg.Done()
+
+	failures := 0
+	for ctx.Err() == nil {
+		messages, err := c.consumer.Fetch(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			failures++
+			p.logger.Error("Failed to fetch messages", "consumer", c.name, "error", err.Error())
+			p.wait(ctx, p.config.Backoff.Delay(failures))
+			continue
+		}
+		failures = 0
+
+		for _, message := range messages {
+			if !p.enqueueMessage(ctx, c, message) {
+				return
+			}
+		}
+		if len(messages) > 0 {
+			if err := c.consumer.Commit(ctx, messages); err != nil && ctx.Err() == nil {
+				// The messages are redelivered and run again
+				p.logger.Error("Failed to commit messages", "consumer", c.name, "count", len(messages), "error", err.Error())
+			}
+		}
+	}
+}
+
+// enqueueMessage enqueues a message's job, retrying until it is enqueued,
+// as the consumer's position has already moved past it; it returns false
+// if the context was cancelled first
+func (p *Pool) enqueueMessage(ctx context.Context, c consumer, message Message) bool {
+	jobType := c.route(message)
+	if jobType == "" {
+		return true
+	}
+	for attempt := 1; ; attempt++ {
+		_, err := p.Enqueue(ctx, jobType, message)
+		if err == nil {
+			return true
+		}
+		if ctx.Err() != nil {
+			return false
+		}
+		p.logger.Error("Failed to enqueue message", "consumer", c.name, "topic", message.Topic, "error", err.Error())
+		if !p.wait(ctx, p.config.Backoff.Delay(attempt)) {
+			return false
+		}
+	}
+}
+
+// wait sleeps for delay, returning false if the context was cancelled first
+func (p *Pool) wait(ctx context.Context, delay time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(delay):
+		return true
+	}
+}
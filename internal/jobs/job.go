@@ -0,0 +1,64 @@
+// Package jobs provides a background job queue with delayed execution,
+// retries with exponential backoff and a dead-letter queue, plus a worker
+// pool that runs alongside the HTTP server.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go-server/internal/idgen"
+)
+
+// Job represents a unit of background work
+type Job struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload,omitempty"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	RunAt       time.Time       `json:"run_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+	LastError   string          `json:"last_error,omitempty"`
+	FailedAt    *time.Time      `json:"failed_at,omitempty"`
+	// Stack is where the handler panicked, if its last failure was a panic
+	Stack string `json:"stack,omitempty"`
+}
+
+// Handler processes a job; returning an error schedules a retry
+type Handler func(ctx context.Context, job *Job) error
+
+// NewJob creates a job of the given type with a JSON-encoded payload
+func NewJob(jobType string, payload any, now time.Time) (*Job, error) {
+	return newJobWithID(idgen.Default.NewID(), jobType, payload, now)
+}
+
+// newJobWithID creates a job with an explicit identifier
+func newJobWithID(id, jobType string, payload any, now time.Time) (*Job, error) {
+	job := &Job{
+		ID:        id,
+		Type:      jobType,
+		RunAt:     now,
+		CreatedAt: now,
+	}
+
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode job payload: %w", err)
+		}
+		job.Payload = data
+	}
+
+	return job, nil
+}
+
+// Decode unmarshals the job payload into target
+func (j *Job) Decode(target any) error {
+	if len(j.Payload) == 0 {
+		return nil
+	}
+	return json.Unmarshal(j.Payload, target)
+}
@@ -0,0 +1,190 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrJobNotFound is returned when a job is not in the dead-letter queue
+var ErrJobNotFound = errors.New("job not found in dead-letter queue")
+
+// Queue defines the storage contract for jobs
+type Queue interface {
+	// Enqueue makes a job immediately available to workers
+	Enqueue(ctx context.Context, job *Job) error
+	// Schedule stores a job until runAt, when PromoteDue makes it available
+	Schedule(ctx context.Context, job *Job, runAt time.Time) error
+	// Dequeue waits up to timeout for a ready job; it returns nil when none is available
+	Dequeue(ctx context.Context, timeout time.Duration) (*Job, error)
+	// PromoteDue moves scheduled jobs whose run time has passed to the ready queue
+	PromoteDue(ctx context.Context, now time.Time) (int, error)
+	// DeadLetter stores a job that exhausted its retries
+	DeadLetter(ctx context.Context, job *Job) error
+	// ListDead returns up to limit dead-lettered jobs, newest first
+	ListDead(ctx context.Context, limit int) ([]*Job, error)
+	// RetryDead moves a dead-lettered job back to the ready queue with its
+	// attempts reset, returning ErrJobNotFound if it is not dead-lettered
+	RetryDead(ctx context.Context, id string) (*Job, error)
+	// Stats counts the jobs in each part of the queue
+	Stats(ctx context.Context) (QueueStats, error)
+}
+
+// QueueStats is the number of jobs in each part of a queue
+type QueueStats struct {
+	Ready   int64 `json:"ready"`
+	Delayed int64 `json:"delayed"`
+	Dead    int64 `json:"dead"`
+}
+
+// MemoryQueue is an in-process Queue for single-node deployments and tests
+type MemoryQueue struct {
+	mu      sync.Mutex
+	ready   []*Job
+	delayed []*Job
+	dead    []*Job
+	notify  chan struct{}
+}
+
+// NewMemoryQueue creates a new in-memory queue
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{notify: make(chan struct{}, 1)}
+}
+
+// Enqueue makes a job immediately available to workers
+func (q *MemoryQueue) Enqueue(ctx context.Context, job *Job) error {
+	q.mu.Lock()
+	q.ready = append(q.ready, job)
+	q.mu.Unlock()
+	q.signal()
+	return nil
+}
+
+// Schedule stores a job until runAt
+func (q *MemoryQueue) Schedule(ctx context.Context, job *Job, runAt time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job.RunAt = runAt
+	q.delayed = append(q.delayed, job)
+	return nil
+}
+
+// Dequeue waits up to timeout for a ready job
+func (q *MemoryQueue) Dequeue(ctx context.Context, timeout time.Duration) (*Job, error) {
+	if job := q.pop(); job != nil {
+		return job, nil
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timer.C:
+		return nil, nil
+	case <-q.notify:
+		return q.pop(), nil
+	}
+}
+
+// PromoteDue moves due scheduled jobs to the ready queue
+func (q *MemoryQueue) PromoteDue(ctx context.Context, now time.Time) (int, error) {
+	q.mu.Lock()
+	var remaining, due []*Job
+	for _, job := range q.delayed {
+		if !job.RunAt.After(now) {
+			due = append(due, job)
+		} else {
+			remaining = append(remaining, job)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].RunAt.Before(due[j].RunAt) })
+	q.delayed = remaining
+	q.ready = append(q.ready, due...)
+	q.mu.Unlock()
+
+	if len(due) > 0 {
+		q.signal()
+	}
+	return len(due), nil
+}
+
+// DeadLetter stores a job that exhausted its retries
+func (q *MemoryQueue) DeadLetter(ctx context.Context, job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.dead = append([]*Job{job}, q.dead...)
+	return nil
+}
+
+// ListDead returns up to limit dead-lettered jobs, newest first
+func (q *MemoryQueue) ListDead(ctx context.Context, limit int) ([]*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if limit <= 0 || limit > len(q.dead) {
+		limit = len(q.dead)
+	}
+	jobs := make([]*Job, limit)
+	copy(jobs, q.dead[:limit])
+	return jobs, nil
+}
+
+// RetryDead moves a dead-lettered job back to the ready queue
+func (q *MemoryQueue) RetryDead(ctx context.Context, id string) (*Job, error) {
+	q.mu.Lock()
+	var job *Job
+	for i, dead := range q.dead {
+		if dead.ID == id {
+			job = dead
+			q.dead = append(q.dead[:i], q.dead[i+1:]...)
+			break
+		}
+	}
+	if job == nil {
+		q.mu.Unlock()
+		return nil, ErrJobNotFound
+	}
+	resetForRetry(job)
+	q.ready = append(q.ready, job)
+	q.mu.Unlock()
+
+	q.signal()
+	return job, nil
+}
+
+// Stats counts the jobs in each part of the queue
+func (q *MemoryQueue) Stats(ctx context.Context) (QueueStats, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return QueueStats{Ready: int64(len(q.ready)), Delayed: int64(len(q.delayed)), Dead: int64(len(q.dead))}, nil
+}
+
+// resetForRetry gives a dead-lettered job a fresh set of attempts, keeping
+// its last error and stack for reference
+func resetForRetry(job *Job) {
+	job.Attempts = 0
+	job.FailedAt = nil
+}
+
+// pop removes the oldest ready job
+func (q *MemoryQueue) pop() *Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.ready) == 0 {
+		return nil
+	}
+	job := q.ready[0]
+	q.ready = q.ready[1:]
+	return job
+}
+
+// signal wakes a waiting Dequeue call without blocking
+func (q *MemoryQueue) signal() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,185 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// maxDeadJobs bounds the size of the dead-letter list
+const maxDeadJobs = 1000
+
+// promoteScript atomically moves due jobs from the delayed set to the ready list
+var promoteScript = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, payload in ipairs(due) do
+	redis.call('ZREM', KEYS[1], payload)
+	redis.call('LPUSH', KEYS[2], payload)
+end
+return #due
+`)
+
+// retryScript atomically moves one dead-lettered payload to the ready list
+var retryScript = redis.NewScript(`
+if redis.call('LREM', KEYS[1], 1, ARGV[1]) == 0 then
+	return 0
+end
+redis.call('LPUSH', KEYS[2], ARGV[2])
+return 1
+`)
+
+// RedisQueue is a Redis-backed Queue shared by all server replicas
+type RedisQueue struct {
+	client    *redis.Client
+	readyKey  string
+	delayKey  string
+	deadKey   string
+	batchSize int
+}
+
+// NewRedisQueue creates a Redis-backed queue with the given name
+func NewRedisQueue(client *redis.Client, name string) *RedisQueue {
+	prefix := fmt.Sprintf("jobs:%s:", name)
+	return &RedisQueue{
+		client:    client,
+		readyKey:  prefix + "ready",
+		delayKey:  prefix + "delayed",
+		deadKey:   prefix + "dead",
+		batchSize: 100,
+	}
+}
+
+// Enqueue makes a job immediately available to workers
+func (q *RedisQueue) Enqueue(ctx context.Context, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to encode job: %w", err)
+	}
+	return q.client.LPush(ctx, q.readyKey, data).Err()
+}
+
+// Schedule stores a job in the delayed set until runAt
+func (q *RedisQueue) Schedule(ctx context.Context, job *Job, runAt time.Time) error {
+	job.RunAt = runAt
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to encode job: %w", err)
+	}
+	return q.client.ZAdd(ctx, q.delayKey, &redis.Z{
+		Score:  float64(runAt.UnixMilli()),
+		Member: data,
+	}).Err()
+}
+
+// Dequeue blocks up to timeout waiting for a ready job
+func (q *RedisQueue) Dequeue(ctx context.Context, timeout time.Duration) (*Job, error) {
+	result, err := q.client.BRPop(ctx, timeout, q.readyKey).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// BRPOP returns the key name followed by the value
+	var job Job
+	if err := json.Unmarshal([]byte(result[1]), &job); err != nil {
+		return nil, fmt.Errorf("failed to decode job: %w", err)
+	}
+	return &job, nil
+}
+
+// PromoteDue moves scheduled jobs whose run time has passed to the ready list
+func (q *RedisQueue) PromoteDue(ctx context.Context, now time.Time) (int, error) {
+	moved, err := promoteScript.Run(ctx, q.client,
+		[]string{q.delayKey, q.readyKey},
+		strconv.FormatInt(now.UnixMilli(), 10), q.batchSize,
+	).Int()
+	if err != nil {
+		return 0, err
+	}
+	return moved, nil
+}
+
+// DeadLetter stores a job that exhausted its retries
+func (q *RedisQueue) DeadLetter(ctx context.Context, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to encode job: %w", err)
+	}
+
+	pipe := q.client.TxPipeline()
+	pipe.LPush(ctx, q.deadKey, data)
+	pipe.LTrim(ctx, q.deadKey, 0, maxDeadJobs-1)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// ListDead returns up to limit dead-lettered jobs, newest first
+func (q *RedisQueue) ListDead(ctx context.Context, limit int) ([]*Job, error) {
+	if limit <= 0 {
+		limit = maxDeadJobs
+	}
+
+	values, err := q.client.LRange(ctx, q.deadKey, 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]*Job, 0, len(values))
+	for _, value := range values {
+		var job Job
+		if err := json.Unmarshal([]byte(value), &job); err != nil {
+			continue
+		}
+		jobs = append(jobs, &job)
+	}
+	return jobs, nil
+}
+
+// RetryDead moves a dead-lettered job back to the ready list
+func (q *RedisQueue) RetryDead(ctx context.Context, id string) (*Job, error) {
+	values, err := q.client.LRange(ctx, q.deadKey, 0, maxDeadJobs-1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, value := range values {
+		var job Job
+		if err := json.Unmarshal([]byte(value), &job); err != nil || job.ID != id {
+			continue
+		}
+		resetForRetry(&job)
+		data, err := json.Marshal(&job)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode job: %w", err)
+		}
+		moved, err := retryScript.Run(ctx, q.client, []string{q.deadKey, q.readyKey}, value, data).Int()
+		if err != nil {
+			return nil, err
+		}
+		if moved == 0 {
+			// Another caller retried it first
+			break
+		}
+		return &job, nil
+	}
+	return nil, ErrJobNotFound
+}
+
+// Stats counts the jobs in each part of the queue
+func (q *RedisQueue) Stats(ctx context.Context) (QueueStats, error) {
+	pipe := q.client.Pipeline()
+	ready := pipe.LLen(ctx, q.readyKey)
+	delayed := pipe.ZCard(ctx, q.delayKey)
+	dead := pipe.LLen(ctx, q.deadKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return QueueStats{}, err
+	}
+	return QueueStats{Ready: ready.Val(), Delayed: delayed.Val(), Dead: dead.Val()}, nil
+}
@@ -0,0 +1,324 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/idgen"
+	"go-server/internal/logger"
+	"go-server/internal/reporting"
+)
+
+// PoolConfig holds worker pool configuration
+type PoolConfig struct {
+	Concurrency  int
+	PollInterval time.Duration
+	MaxAttempts  int
+	Backoff      Backoff
+	// DrainTimeout is how long Stop lets in-flight jobs finish before
+	// cancelling their context; interrupted jobs are retried
+	DrainTimeout time.Duration
+	Clock        clock.Clock  // Optional; defaults to the system clock
+	IDs          idgen.Source // Optional; defaults to random job IDs
+	// Reporter receives handler panics and dead-lettered jobs
+	Reporter reporting.ErrorReporter // Optional
+}
+
+// Pool runs registered job handlers against a queue
+type Pool struct {
+	queue    Queue
+	config   PoolConfig
+	clock    clock.Clock
+	ids      idgen.Source
+	logger   logger.Logger
+	handlers map[string]Handler
+	mutex    sync.RWMutex
+
+	cancel context.CancelFunc
+	// cancelJobs cancels the context handlers run on, which outlives
+	// cancel so Stop can let in-flight jobs finish
+	cancelJobs context.CancelFunc
+	jobCtx     context.Context
+	wg         sync.WaitGroup
+	// consumers are the broker consumer groups drained into the queue
+	consumers []consumer
+}
+
+// NewPool creates a new worker pool
+func NewPool(queue Queue, config PoolConfig, logger logger.Logger) *Pool {
+	if config.Concurrency <= 0 {
+		config.Concurrency = 1
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = time.Second
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 5
+	}
+	if config.Backoff.Base <= 0 {
+		config.Backoff = DefaultBackoff()
+	}
+	if config.DrainTimeout <= 0 {
+		config.DrainTimeout = 30 * time.Second
+	}
+
+	return &Pool{
+		queue:    queue,
+		config:   config,
+		clock:    clock.OrDefault(config.Clock),
+		ids:      idgen.OrDefault(config.IDs),
+		logger:   logger,
+		handlers: make(map[string]Handler),
+	}
+}
+
+// Register adds a handler for a job type
+func (p *Pool) Register(jobType string, handler Handler) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.handlers[jobType] = handler
+}
+
+// Enqueue submits a job for immediate execution
+func (p *Pool) Enqueue(ctx context.Context, jobType string, payload any) (*Job, error) {
+	job, err := p.newJob(jobType, payload)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.queue.Enqueue(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	return job, nil
+}
+
+// EnqueueIn submits a job to run after the given delay
+func (p *Pool) EnqueueIn(ctx context.Context, jobType string, payload any, delay time.Duration) (*Job, error) {
+	job, err := p.newJob(jobType, payload)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.queue.Schedule(ctx, job, p.clock.Now().Add(delay)); err != nil {
+		return nil, fmt.Errorf("failed to schedule job: %w", err)
+	}
+	return job, nil
+}
+
+// Queue returns the underlying job queue
+func (p *Pool) Queue() Queue {
+	return p.queue
+}
+
+// Start launches the workers and the delayed-job promoter
+func (p *Pool) Start(ctx context.Context) {
+	p.jobCtx, p.cancelJobs = context.WithCancel(context.WithoutCancel(ctx))
+	ctx, p.cancel = context.WithCancel(ctx)
+
+	p.wg.Add(1)
+	go p.promoteLoop(ctx)
+
+	for i := 0; i < p.config.Concurrency; i++ {
+		p.wg.Add(1)
+		go p.workLoop(ctx)
+	}
+
+	p.mutex.RLock()
+	for _, c := range p.consumers {
+		p.wg.Add(1)
+		go p.consumeLoop(ctx, c)
+	}
+	p.mutex.RUnlock()
+
+	p.logger.Info("Job worker pool started", "concurrency", p.config.Concurrency)
+}
+
+// Stop signals the workers to finish and waits for in-flight jobs. Jobs
+// still running after DrainTimeout have their context cancelled, and are
+// retried like any other failure.
+func (p *Pool) Stop() {
+	if p.cancel != nil {
+		p.cancel()
+	}
+	drained := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(drained)
+	}()
+	timer := time.NewTimer(p.config.DrainTimeout)
+	defer timer.Stop()
+	select {
+	case <-drained:
+	case <-timer.C:
+		p.logger.Warn("Job worker pool drain timed out, interrupting in-flight jobs", "timeout", p.config.DrainTimeout)
+		p.cancelJobs()
+		<-drained
+	}
+	if p.cancelJobs != nil {
+		p.cancelJobs()
+	}
+
+	p.mutex.RLock()
+	for _, c := range p.consumers {
+		if err := c.consumer.Close(); err != nil {
+			p.logger.Warn("Failed to close consumer", "consumer", c.name, "error", err.Error())
+		}
+	}
+	p.mutex.RUnlock()
+	p.logger.Info("Job worker pool stopped")
+}
+
+// promoteLoop periodically moves due delayed jobs to the ready queue
+func (p *Pool) promoteLoop(ctx context.Context) {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := p.queue.PromoteDue(ctx, p.clock.Now()); err != nil && ctx.Err() == nil {
+				p.logger.Error("Failed to promote delayed jobs", "error", err.Error())
+			}
+		}
+	}
+}
+
+// workLoop dequeues and processes jobs until the context is cancelled
+func (p *Pool) workLoop(ctx context.Context) {
+	defer p.wg.Done()
+
+	for ctx.Err() == nil {
+		job, err := p.queue.Dequeue(ctx, p.config.PollInterval)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			p.logger.Error("Failed to dequeue job", "error", err.Error())
+			time.Sleep(p.config.PollInterval)
+			continue
+		}
+		if job == nil {
+			continue
+		}
+
+		p.process(p.jobCtx, job)
+	}
+}
+
+// process runs a single job and handles retry or dead-lettering on failure.
+// The retry and dead-letter writes outlive ctx, as the job is already off
+// the queue and would otherwise be lost when ctx is cancelled.
+func (p *Pool) process(ctx context.Context, job *Job) {
+	p.mutex.RLock()
+	handler, exists := p.handlers[job.Type]
+	p.mutex.RUnlock()
+
+	job.Attempts++
+	if job.MaxAttempts <= 0 {
+		job.MaxAttempts = p.config.MaxAttempts
+	}
+
+	var err error
+	if !exists {
+		err = fmt.Errorf("no handler registered for job type %q", job.Type)
+		job.Attempts = job.MaxAttempts
+	} else {
+		err = p.run(ctx, handler, job)
+	}
+
+	if err == nil {
+		p.logger.Debug("Job completed", "job_id", job.ID, "type", job.Type)
+		return
+	}
+
+	job.LastError = err.Error()
+	job.Stack = ""
+	var panicked *jobPanic
+	if errors.As(err, &panicked) {
+		job.Stack = string(panicked.stack)
+	}
+	deadLettered := job.Attempts >= job.MaxAttempts
+	p.report(ctx, job, err, deadLettered)
+
+	if deadLettered {
+		now := p.clock.Now()
+		job.FailedAt = &now
+		p.logger.Error("Job moved to dead-letter queue", "job_id", job.ID, "type", job.Type, "error", job.LastError)
+		if err := p.queue.DeadLetter(context.WithoutCancel(ctx), job); err != nil {
+			p.logger.Error("Failed to dead-letter job", "job_id", job.ID, "error", err.Error())
+		}
+		return
+	}
+
+	delay := p.config.Backoff.Delay(job.Attempts)
+	p.logger.Warn("Job failed, scheduling retry", "job_id", job.ID, "type", job.Type, "attempt", job.Attempts, "delay", delay)
+	if err := p.queue.Schedule(context.WithoutCancel(ctx), job, p.clock.Now().Add(delay)); err != nil {
+		p.logger.Error("Failed to schedule job retry", "job_id", job.ID, "error", err.Error())
+	}
+}
+
+// jobPanic is a handler panic converted to an error, keeping its stack
+type jobPanic struct {
+	value  any
+	stack  []byte
+	frames []reporting.Frame
+}
+
+func (e *jobPanic) Error() string {
+	return fmt.Sprintf("job panicked: %v", e.value)
+}
+
+// run invokes the handler, converting panics into errors
+func (p *Pool) run(ctx context.Context, handler Handler, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &jobPanic{value: r, stack: debug.Stack(), frames: reporting.CaptureFrames(1)}
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// report sends handler panics and dead-lettered jobs to the error tracker;
+// ordinary failures that will be retried are only logged
+func (p *Pool) report(ctx context.Context, job *Job, err error, deadLettered bool) {
+	var panicked *jobPanic
+	isPanic := errors.As(err, &panicked)
+	if p.config.Reporter == nil || (!isPanic && !deadLettered) {
+		return
+	}
+
+	report := reporting.Report{
+		Err: err,
+		Tags: map[string]string{
+			"job_id":        job.ID,
+			"job_type":      job.Type,
+			"attempt":       strconv.Itoa(job.Attempts),
+			"dead_lettered": strconv.FormatBool(deadLettered),
+		},
+		Time: p.clock.Now(),
+	}
+	if isPanic {
+		report.Panic = true
+		report.Stack = panicked.stack
+		report.Frames = panicked.frames
+	}
+	p.config.Reporter.Report(ctx, report)
+}
+
+// newJob builds a job stamped with the pool's clock and retry policy
+func (p *Pool) newJob(jobType string, payload any) (*Job, error) {
+	job, err := newJobWithID(p.ids.NewID(), jobType, payload, p.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	job.MaxAttempts = p.config.MaxAttempts
+	return job, nil
+}
@@ -0,0 +1,342 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/logger"
+	"go-server/internal/reporting"
+)
+
+func newTestPool(queue Queue, fake *clock.Fake) *Pool {
+	return NewPool(queue, PoolConfig{
+		Concurrency:  1,
+		PollInterval: 10 * time.Millisecond,
+		MaxAttempts:  3,
+		Backoff:      Backoff{Base: time.Second, Max: time.Minute},
+		Clock:        fake,
+	}, logger.NewServerLogger())
+}
+
+func TestBackoff_Delay(t *testing.T) {
+	b := Backoff{Base: time.Second, Max: 10 * time.Second}
+
+	tests := []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{0, time.Second},
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{20, 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := b.Delay(tt.attempt); got != tt.expected {
+			t.Errorf("Delay(%d) = %v, want %v", tt.attempt, got, tt.expected)
+		}
+	}
+}
+
+func TestPool_RetryThenDeadLetter(t *testing.T) {
+	ctx := context.Background()
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	queue := NewMemoryQueue()
+	pool := newTestPool(queue, fake)
+
+	calls := 0
+	pool.Register("flaky", func(ctx context.Context, job *Job) error {
+		calls++
+		return errors.New("boom")
+	})
+
+	job, err := pool.Enqueue(ctx, "flaky", map[string]string{"key": "value"})
+	if err != nil {
+		t.Fatalf("Failed to enqueue: %v", err)
+	}
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		next, _ := queue.Dequeue(ctx, time.Millisecond)
+		if next == nil {
+			t.Fatalf("Attempt %d: expected a ready job", attempt)
+		}
+		pool.process(ctx, next)
+
+		// Retries are not ready until the backoff elapses
+		if promoted, _ := queue.PromoteDue(ctx, fake.Now()); promoted != 0 {
+			t.Fatalf("Attempt %d: retry should not be due yet", attempt)
+		}
+		fake.Advance(time.Minute)
+		queue.PromoteDue(ctx, fake.Now())
+	}
+
+	if calls != 3 {
+		t.Errorf("Expected 3 handler calls, got %d", calls)
+	}
+
+	dead, _ := queue.ListDead(ctx, 10)
+	if len(dead) != 1 || dead[0].ID != job.ID {
+		t.Fatalf("Expected job %s in dead-letter queue, got %v", job.ID, dead)
+	}
+	if dead[0].LastError != "boom" || dead[0].FailedAt == nil {
+		t.Errorf("Dead job should record the last error and failure time: %+v", dead[0])
+	}
+}
+
+func TestPool_UnknownJobTypeIsDeadLettered(t *testing.T) {
+	ctx := context.Background()
+	queue := NewMemoryQueue()
+	pool := newTestPool(queue, clock.NewFake(time.Now()))
+
+	job, _ := NewJob("missing", nil, time.Now())
+	pool.process(ctx, job)
+
+	dead, _ := queue.ListDead(ctx, 10)
+	if len(dead) != 1 {
+		t.Fatalf("Expected unknown job type to be dead-lettered, got %d dead jobs", len(dead))
+	}
+}
+
+func TestPool_ReportsPanicsAndDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	queue := NewMemoryQueue()
+	var reports []reporting.Report
+	pool := NewPool(queue, PoolConfig{
+		MaxAttempts: 2,
+		Clock:       clock.NewFake(time.Now()),
+		Reporter: reporting.ReporterFunc(func(ctx context.Context, report reporting.Report) {
+			reports = append(reports, report)
+		}),
+	}, logger.NewServerLogger())
+
+	pool.Register("flaky", func(ctx context.Context, job *Job) error {
+		return errors.New("boom")
+	})
+	pool.Register("crash", func(ctx context.Context, job *Job) error {
+		panic("crash")
+	})
+
+	flaky, _ := NewJob("flaky", nil, time.Now())
+	pool.process(ctx, flaky)
+	if len(reports) != 0 {
+		t.Fatalf("A failure that will be retried should not be reported, got %+v", reports)
+	}
+	pool.process(ctx, flaky)
+	if len(reports) != 1 || reports[0].Panic || reports[0].Tags["dead_lettered"] != "true" || reports[0].Tags["job_id"] != flaky.ID {
+		t.Fatalf("Expected a dead-letter report, got %+v", reports)
+	}
+
+	crash, _ := NewJob("crash", nil, time.Now())
+	pool.process(ctx, crash)
+	if len(reports) != 2 {
+		t.Fatalf("Expected the panic to be reported, got %d reports", len(reports))
+	}
+	report := reports[1]
+	if !report.Panic || report.Tags["job_type"] != "crash" || report.Tags["dead_lettered"] != "false" {
+		t.Errorf("Report = %+v", report)
+	}
+	if len(report.Frames) == 0 || !strings.Contains(report.Frames[0].Function, "TestPool_ReportsPanicsAndDeadLetters") {
+		t.Errorf("Stack should start at the panicking handler, got %+v", report.Frames)
+	}
+
+	// The job keeps the stack of a panic, for the dead-letter view
+	if !strings.Contains(crash.Stack, "TestPool_ReportsPanicsAndDeadLetters") || flaky.Stack != "" {
+		t.Errorf("Expected only the panicking job to keep a stack, got %q and %q", crash.Stack, flaky.Stack)
+	}
+	pool.process(ctx, crash)
+	stats, err := queue.Stats(ctx)
+	if err != nil || stats.Dead != 2 {
+		t.Errorf("Expected both jobs dead-lettered, got %+v, %v", stats, err)
+	}
+}
+
+func TestPool_StartProcessesJobs(t *testing.T) {
+	queue := NewMemoryQueue()
+	pool := newTestPool(queue, clock.NewFake(time.Now()))
+
+	done := make(chan string, 1)
+	pool.Register("echo", func(ctx context.Context, job *Job) error {
+		var payload struct{ Message string }
+		if err := job.Decode(&payload); err != nil {
+			return err
+		}
+		done <- payload.Message
+		return nil
+	})
+
+	pool.Start(context.Background())
+	defer pool.Stop()
+
+	if _, err := pool.Enqueue(context.Background(), "echo", map[string]string{"Message": "hello"}); err != nil {
+		t.Fatalf("Failed to enqueue: %v", err)
+	}
+
+	select {
+	case msg := <-done:
+		if msg != "hello" {
+			t.Errorf("Expected payload 'hello', got %q", msg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Job was not processed")
+	}
+}
+
+// contextQueue fails writes on a cancelled context, as the Redis queue does
+type contextQueue struct {
+	*MemoryQueue
+}
+
+func (q contextQueue) Schedule(ctx context.Context, job *Job, runAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return q.MemoryQueue.Schedule(ctx, job, runAt)
+}
+
+func TestPool_StopDrainsThenReschedulesInterruptedJobs(t *testing.T) {
+	queue := contextQueue{NewMemoryQueue()}
+	fake := clock.NewFake(time.Now())
+	pool := NewPool(queue, PoolConfig{
+		PollInterval: 10 * time.Millisecond,
+		Backoff:      Backoff{Base: time.Second, Max: time.Minute},
+		DrainTimeout: 200 * time.Millisecond,
+		Clock:        fake,
+	}, logger.NewServerLogger())
+
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	pool.Register("finishes", func(ctx context.Context, job *Job) error {
+		started <- struct{}{}
+		select {
+		case <-release:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	pool.Register("hangs", func(ctx context.Context, job *Job) error {
+		started <- struct{}{}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	pool.Start(context.Background())
+
+	// A job finishing within the drain timeout completes normally
+	pool.Enqueue(context.Background(), "finishes", nil)
+	<-started
+	stopped := make(chan struct{})
+	go func() {
+		pool.Stop()
+		close(stopped)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	<-stopped
+	if promoted, _ := queue.PromoteDue(context.Background(), fake.Now().Add(time.Hour)); promoted != 0 {
+		t.Fatalf("A job finishing during the drain should not be retried, got %d", promoted)
+	}
+
+	// A job still running after it is interrupted and rescheduled
+	pool.Start(context.Background())
+	job, _ := pool.Enqueue(context.Background(), "hangs", nil)
+	<-started
+	pool.Stop()
+	if promoted, _ := queue.PromoteDue(context.Background(), fake.Now().Add(time.Hour)); promoted != 1 {
+		t.Fatalf("Expected the interrupted job to be rescheduled, got %d", promoted)
+	}
+	retried, _ := queue.Dequeue(context.Background(), time.Millisecond)
+	if retried == nil || retried.ID != job.ID || retried.Attempts != 1 {
+		t.Errorf("Expected job %s rescheduled after one attempt, got %+v", job.ID, retried)
+	}
+}
+
+// fakeConsumer hands out the batches sent on fetches
+type fakeConsumer struct {
+	fetches   chan []Message
+	committed chan []Message
+	closed    bool
+}
+
+func (fc *fakeConsumer) Fetch(ctx context.Context) ([]Message, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case messages := <-fc.fetches:
+		if messages == nil {
+			return nil, errors.New("broker unavailable")
+		}
+		return messages, nil
+	}
+}
+
+func (fc *fakeConsumer) Commit(ctx context.Context, messages []Message) error {
+	fc.committed <- messages
+	return nil
+}
+
+func (fc *fakeConsumer) Close() error {
+	fc.closed = true
+	return nil
+}
+
+func TestPool_Consume(t *testing.T) {
+	queue := NewMemoryQueue()
+	pool := newTestPool(queue, clock.NewFake(time.Now()))
+	pool.config.Backoff = Backoff{Base: time.Millisecond, Max: time.Millisecond}
+
+	done := make(chan Message, 2)
+	pool.Register("event", func(ctx context.Context, job *Job) error {
+		var message Message
+		if err := job.Decode(&message); err != nil {
+			return err
+		}
+		done <- message
+		return nil
+	})
+	consumer := &fakeConsumer{fetches: make(chan []Message, 2), committed: make(chan []Message, 1)}
+	pool.Consume("events", consumer, func(message Message) string {
+		if strings.HasPrefix(message.Topic, "events.") {
+			return "event"
+		}
+		return ""
+	})
+
+	pool.Start(context.Background())
+	consumer.fetches <- nil
+	consumer.fetches <- []Message{
+		{Topic: "events.post.published", Key: "post:7", Value: []byte(`{"id":7}`), Partition: 1, Offset: 41},
+		{Topic: "audit.login", Value: []byte(`{}`)},
+	}
+
+	select {
+	case committed := <-consumer.committed:
+		if len(committed) != 2 {
+			t.Errorf("Expected the whole batch committed, got %+v", committed)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("The batch was not committed")
+	}
+	select {
+	case message := <-done:
+		if message.Key != "post:7" || string(message.Value) != `{"id":7}` || message.Offset != 41 {
+			t.Errorf("Unexpected message %+v", message)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("The message was not run as a job")
+	}
+
+	pool.Stop()
+	if len(done) != 0 {
+		t.Error("Messages routed to no job type should not run")
+	}
+	if !consumer.closed {
+		t.Error("Stop should close the consumer")
+	}
+}
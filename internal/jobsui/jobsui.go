@@ -0,0 +1,269 @@
+// Package jobsui renders an HTML page for operators of the background job
+// queue: how many jobs are ready, delayed and dead-lettered, the latest
+// dead-lettered jobs with their errors and panic stacks, and the scheduled
+// tasks with their recent runs.
+//
+// The page only reads. Its retry buttons call the jobs.retry runbook
+// action from the browser, so each retry is authorized and audited like any
+// other operational action.
+package jobsui
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"time"
+
+	"go-server/internal/auth"
+	"go-server/internal/clock"
+	"go-server/internal/idgen"
+	"go-server/internal/jobs"
+	"go-server/internal/logger"
+	"go-server/internal/scheduler"
+	"go-server/internal/secheaders"
+)
+
+// Config holds the page configuration
+type Config struct {
+	// RetryURL is the runbook endpoint that retries a dead-lettered job
+	RetryURL string
+	// CSRFCookie is the cookie holding the session's CSRF token, which the
+	// retry request echoes in auth.CSRFHeader
+	CSRFCookie string
+	// FailureLimit bounds the dead-lettered jobs listed
+	FailureLimit int
+	Clock        clock.Clock // Optional; defaults to the system clock
+}
+
+// Handler serves the jobs page (GET /admin/jobs, requires system:configure)
+type Handler struct {
+	queue     jobs.Queue
+	scheduler *scheduler.Scheduler
+	config    Config
+	clock     clock.Clock
+	logger    logger.Logger
+}
+
+// NewHandler creates the jobs page for queue; sched may be nil when the
+// scheduler is not running
+func NewHandler(queue jobs.Queue, sched *scheduler.Scheduler, config Config, logger logger.Logger) *Handler {
+	if config.RetryURL == "" {
+		config.RetryURL = "/api/admin/runbook/jobs.retry"
+	}
+	if config.CSRFCookie == "" {
+		config.CSRFCookie = "csrf_token"
+	}
+	if config.FailureLimit <= 0 {
+		config.FailureLimit = 50
+	}
+	return &Handler{
+		queue:     queue,
+		scheduler: sched,
+		config:    config,
+		clock:     clock.OrDefault(config.Clock),
+		logger:    logger,
+	}
+}
+
+// failure is a dead-lettered job as the page shows it
+type failure struct {
+	*jobs.Job
+	Payload string
+}
+
+// page is the data the template renders
+type page struct {
+	Now        time.Time
+	Nonce      string
+	Stats      jobs.QueueStats
+	StatsError string
+	Failures   []failure
+	Scheduled  bool
+	Tasks      []scheduler.TaskInfo
+	Runs       []scheduler.Run
+	RetryURL   string
+	CSRFCookie string
+	CSRFHeader string
+}
+
+// ServeHTTP renders the page. Mounted under the security headers
+// middleware's admin profile, it uses that request's nonce; otherwise it
+// sets secheaders.AdminPolicy itself.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	nonce := secheaders.Nonce(r.Context())
+	if nonce == "" {
+		nonce, _ = idgen.NewRandom().Token(16)
+		w.Header().Set("Content-Security-Policy", secheaders.AdminPolicy().Render(nonce))
+	}
+
+	data := h.load(r.Context())
+	data.Nonce = nonce
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := pageTemplate.Execute(w, data); err != nil {
+		h.logger.Error("Failed to render jobs page", "error", err.Error())
+	}
+}
+
+// load gathers the page's data. A queue that cannot be read is reported on
+// the page rather than failing it, as the scheduler part is still useful.
+func (h *Handler) load(ctx context.Context) page {
+	data := page{
+		Now:        h.clock.Now(),
+		Scheduled:  h.scheduler != nil,
+		RetryURL:   h.config.RetryURL,
+		CSRFCookie: h.config.CSRFCookie,
+		CSRFHeader: auth.CSRFHeader,
+	}
+
+	stats, err := h.queue.Stats(ctx)
+	if err == nil {
+		var dead []*jobs.Job
+		dead, err = h.queue.ListDead(ctx, h.config.FailureLimit)
+		for _, job := range dead {
+			data.Failures = append(data.Failures, failure{Job: job, Payload: string(job.Payload)})
+		}
+	}
+	if err != nil {
+		h.logger.Error("Failed to read job queue", "error", err.Error())
+		data.StatsError = err.Error()
+	}
+	data.Stats = stats
+
+	if h.scheduler != nil {
+		data.Tasks = h.scheduler.Tasks()
+		data.Runs = h.scheduler.Runs()
+	}
+	return data
+}
+
+var pageTemplate = template.Must(template.New("jobs").Funcs(template.FuncMap{
+	"ago": func(now, t time.Time) string {
+		return now.Sub(t).Round(time.Second).String()
+	},
+	"until": func(now, t time.Time) string {
+		return t.Sub(now).Round(time.Second).String()
+	},
+	"timestamp": func(t time.Time) string {
+		return t.UTC().Format("2006-01-02 15:04:05 UTC")
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Jobs</title>
+<style nonce="{{.Nonce}}">
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2em; width: 100%; }
+th, td { border-bottom: 1px solid #ddd; padding: .4em .6em; text-align: left; vertical-align: top; }
+td.number { text-align: right; }
+pre { background: #f6f6f6; padding: .6em; overflow-x: auto; font-size: .85em; }
+.error { color: #b00020; }
+.muted { color: #777; }
+</style>
+</head>
+<body>
+<h1>Jobs</h1>
+<p class="muted">As of {{timestamp .Now}}</p>
+
+<h2>Queue</h2>
+{{if .StatsError}}<p class="error">The queue could not be read: {{.StatsError}}</p>{{end}}
+<table>
+<tr><th>Ready</th><th>Delayed</th><th>Dead-lettered</th></tr>
+<tr><td class="number">{{.Stats.Ready}}</td><td class="number">{{.Stats.Delayed}}</td><td class="number">{{.Stats.Dead}}</td></tr>
+</table>
+
+<h2>Recent failures</h2>
+{{if .Failures}}
+<table>
+<tr><th>Job</th><th>Type</th><th>Failed</th><th>Attempts</th><th>Error</th><th></th></tr>
+{{range .Failures}}
+<tr>
+<td><code>{{.ID}}</code></td>
+<td>{{.Type}}</td>
+<td>{{if .FailedAt}}{{ago $.Now .FailedAt}} ago{{end}}</td>
+<td class="number">{{.Attempts}}/{{.MaxAttempts}}</td>
+<td><span class="error">{{.LastError}}</span>
+{{if .Payload}}<details><summary>Payload</summary><pre>{{.Payload}}</pre></details>{{end}}
+{{if .Stack}}<details><summary>Stack trace</summary><pre>{{.Stack}}</pre></details>{{end}}
+</td>
+<td><button type="button" data-job="{{.ID}}">Retry</button> <span class="muted" data-status="{{.ID}}"></span></td>
+</tr>
+{{end}}
+</table>
+{{else}}
+<p class="muted">No dead-lettered jobs.</p>
+{{end}}
+
+{{if .Scheduled}}
+<h2>Scheduled tasks</h2>
+<table>
+<tr><th>Task</th><th>Schedule</th><th>Next run</th></tr>
+{{range .Tasks}}
+<tr><td>{{.Name}}</td><td><code>{{.Spec}}</code></td><td>{{timestamp .Next}} (in {{until $.Now .Next}})</td></tr>
+{{end}}
+</table>
+
+<h2>Recent runs</h2>
+<p class="muted">Runs on this replica only; another replica may have run an occurrence instead.</p>
+{{if .Runs}}
+<table>
+<tr><th>Task</th><th>Started</th><th>Duration</th><th>Result</th></tr>
+{{range .Runs}}
+<tr><td>{{.Task}}</td><td>{{timestamp .StartedAt}}</td><td>{{.Duration}}</td>
+<td>{{if .Error}}<span class="error">{{.Error}}</span>{{else}}OK{{end}}</td></tr>
+{{end}}
+</table>
+{{else}}
+<p class="muted">No runs yet.</p>
+{{end}}
+{{end}}
+
+<script nonce="{{.Nonce}}">
+(function () {
+  var retryURL = {{.RetryURL}}, csrfCookie = {{.CSRFCookie}}, csrfHeader = {{.CSRFHeader}};
+  function csrfToken() {
+    var prefix = csrfCookie + "=";
+    var cookies = document.cookie.split("; ");
+    for (var i = 0; i < cookies.length; i++) {
+      if (cookies[i].indexOf(prefix) === 0) {
+        return decodeURIComponent(cookies[i].slice(prefix.length));
+      }
+    }
+    return "";
+  }
+  document.querySelectorAll("button[data-job]").forEach(function (button) {
+    button.addEventListener("click", function () {
+      var id = button.getAttribute("data-job");
+      var status = document.querySelector('[data-status="' + id + '"]');
+      var headers = {"Content-Type": "application/json"};
+      headers[csrfHeader] = csrfToken();
+      button.disabled = true;
+      fetch(retryURL, {method: "POST", credentials: "same-origin", headers: headers, body: JSON.stringify({job_id: id})})
+        .then(function (response) {
+          if (response.ok) {
+            status.textContent = "Requeued";
+            return;
+          }
+          return response.json().then(function (body) {
+            status.textContent = body.message || body.detail || ("Failed: HTTP " + response.status);
+            button.disabled = false;
+          });
+        })
+        .catch(function (err) {
+          status.textContent = "Failed: " + err;
+          button.disabled = false;
+        });
+    });
+  });
+})();
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,73 @@
+package jobsui
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/jobs"
+	"go-server/internal/logger"
+	"go-server/internal/scheduler"
+)
+
+func TestHandler_RendersQueueAndScheduler(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(now)
+
+	queue := jobs.NewMemoryQueue()
+	failedAt := now.Add(-90 * time.Second)
+	queue.DeadLetter(ctx, &jobs.Job{
+		ID: "job-1", Type: "email.send", Payload: []byte(`{"to":"<ada@example.com>"}`),
+		Attempts: 5, MaxAttempts: 5, LastError: "smtp: <refused>", FailedAt: &failedAt,
+		Stack: "goroutine 7 [running]:\nmain.handler()",
+	})
+	queue.Enqueue(ctx, &jobs.Job{ID: "job-2", Type: "email.send"})
+
+	sched := scheduler.NewScheduler(nil, 0, fake, logger.NewServerLogger())
+	sched.Register("sessions.cleanup", "30 12 * * *", func(ctx context.Context) error {
+		return errors.New("database is locked")
+	})
+	fake.Set(now.Add(30 * time.Minute))
+	sched.RunDue(ctx)
+	sched.Stop()
+
+	handler := NewHandler(queue, sched, Config{Clock: fake}, logger.NewServerLogger())
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/jobs", nil))
+
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/html; charset=utf-8" {
+		t.Fatalf("Expected an HTML page, got %d %q:\n%s", rec.Code, rec.Header().Get("Content-Type"), rec.Body)
+	}
+	csp := rec.Header().Get("Content-Security-Policy")
+	_, nonce, found := strings.Cut(csp, "'nonce-")
+	nonce, _, _ = strings.Cut(nonce, "'")
+	if !found || nonce == "" {
+		t.Fatalf("Expected a nonce in the policy, got %q", csp)
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		`<td class="number">1</td><td class="number">0</td><td class="number">1</td>`,
+		`<style nonce="` + nonce + `">`,
+		`<script nonce="` + nonce + `">`,
+		`<code>job-1</code>`,
+		`1m30s ago`,
+		`smtp: &lt;refused&gt;`,
+		`&#34;to&#34;:&#34;&lt;ada@example.com&gt;&#34;`,
+		"goroutine 7 [running]:\nmain.handler()",
+		`data-job="job-1"`,
+		`<code>30 12 * * *</code>`,
+		`database is locked`,
+		`var retryURL = "/api/admin/runbook/jobs.retry", csrfCookie = "csrf_token", csrfHeader = "X-CSRF-Token";`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected the page to contain %q:\n%s", want, body)
+		}
+	}
+}
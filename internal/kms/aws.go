@@ -0,0 +1,198 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"go-server/internal/clock"
+	"go-server/internal/sigv4"
+)
+
+// AWSConfig locates the keys in AWS KMS
+type AWSConfig struct {
+	Region string
+	// KeyID is the symmetric encryption key: a key ID, ARN or alias
+	KeyID string
+	// SigningKeyID is an RSA_2048 or larger SIGN_VERIFY key; empty
+	// disables signing
+	SigningKeyID string
+	// Endpoint overrides the regional endpoint, e.g. for a VPC endpoint
+	Endpoint        string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// AWSProvider uses AWS KMS. Ciphertexts name the key version, so
+// rotation, whether automatic or through Rotate, needs nothing else.
+type AWSProvider struct {
+	config   AWSConfig
+	endpoint string
+	signer   sigv4.Signer
+	client   *http.Client
+	clock    clock.Clock
+
+	mu        sync.Mutex
+	publicKey *rsa.PublicKey
+}
+
+// NewAWSProvider creates a new AWS KMS provider
+func NewAWSProvider(config AWSConfig, client *http.Client) (*AWSProvider, error) {
+	if config.Region == "" || config.KeyID == "" {
+		return nil, fmt.Errorf("AWS region and KMS key ID are required")
+	}
+	if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, fmt.Errorf("AWS credentials are required")
+	}
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://kms.%s.amazonaws.com/", config.Region)
+	}
+
+	return &AWSProvider{
+		config:   config,
+		endpoint: endpoint,
+		signer: sigv4.Signer{
+			AccessKeyID:     config.AccessKeyID,
+			SecretAccessKey: config.SecretAccessKey,
+			SessionToken:    config.SessionToken,
+			Region:          config.Region,
+			Service:         "kms",
+		},
+		client: client,
+		clock:  clock.New(),
+	}, nil
+}
+
+// WithClock overrides the clock used to sign requests
+func (p *AWSProvider) WithClock(c clock.Clock) *AWSProvider {
+	p.clock = clock.OrDefault(c)
+	return p
+}
+
+// Encrypt calls the Encrypt action with the configured key
+func (p *AWSProvider) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	var out struct {
+		CiphertextBlob []byte `json:"CiphertextBlob"`
+	}
+	err := p.call(ctx, "Encrypt", map[string]interface{}{
+		"KeyId":     p.config.KeyID,
+		"Plaintext": plaintext,
+	}, &out)
+	return out.CiphertextBlob, err
+}
+
+// Decrypt calls the Decrypt action, pinned to the configured key so a
+// ciphertext made with another key is refused
+func (p *AWSProvider) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	var out struct {
+		Plaintext []byte `json:"Plaintext"`
+	}
+	err := p.call(ctx, "Decrypt", map[string]interface{}{
+		"KeyId":          p.config.KeyID,
+		"CiphertextBlob": ciphertext,
+	}, &out)
+	return out.Plaintext, err
+}
+
+// Sign calls the Sign action with the signing key
+func (p *AWSProvider) Sign(ctx context.Context, digest []byte) ([]byte, error) {
+	if p.config.SigningKeyID == "" {
+		return nil, ErrNoSigningKey
+	}
+	var out struct {
+		Signature []byte `json:"Signature"`
+	}
+	err := p.call(ctx, "Sign", map[string]interface{}{
+		"KeyId":            p.config.SigningKeyID,
+		"Message":          digest,
+		"MessageType":      "DIGEST",
+		"SigningAlgorithm": "RSASSA_PKCS1_V1_5_SHA_256",
+	}, &out)
+	return out.Signature, err
+}
+
+// PublicKey fetches the signing key's public half once and caches it
+func (p *AWSProvider) PublicKey(ctx context.Context) (*rsa.PublicKey, error) {
+	if p.config.SigningKeyID == "" {
+		return nil, ErrNoSigningKey
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.publicKey != nil {
+		return p.publicKey, nil
+	}
+
+	var out struct {
+		PublicKey []byte `json:"PublicKey"`
+	}
+	if err := p.call(ctx, "GetPublicKey", map[string]interface{}{"KeyId": p.config.SigningKeyID}, &out); err != nil {
+		return nil, err
+	}
+	parsed, err := x509.ParsePKIXPublicKey(out.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid KMS public key: %w", err)
+	}
+	public, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("KMS signing key is %T, want an RSA key", parsed)
+	}
+	p.publicKey = public
+	return public, nil
+}
+
+// Rotate calls RotateKeyOnDemand. AWS keeps every earlier key version
+// for decryption.
+func (p *AWSProvider) Rotate(ctx context.Context) error {
+	return p.call(ctx, "RotateKeyOnDemand", map[string]interface{}{"KeyId": p.config.KeyID}, nil)
+}
+
+// call posts a KMS JSON API action and decodes the response into out.
+// Binary fields are base64 in the API, which encoding/json does for
+// []byte.
+func (p *AWSProvider) call(ctx context.Context, action string, in, out interface{}) error {
+	payload, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService."+action)
+	p.signer.Sign(req, sigv4.PayloadHash(payload), p.clock.Now())
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("KMS %s request failed: %w", action, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read KMS %s response: %w", action, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var awsErr struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		json.Unmarshal(body, &awsErr)
+		return fmt.Errorf("KMS %s returned status %d %s %s", action, resp.StatusCode, awsErr.Type, awsErr.Message)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("invalid KMS %s response: %w", action, err)
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package kms
+
+import (
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go-server/internal/clock"
+)
+
+// EncryptedPrefix starts every string made by EncryptString
+const EncryptedPrefix = "kms:v1:"
+
+// envelopeFormat is the first byte of a sealed value
+const envelopeFormat = 1
+
+// dataKeySize is the size of the AES-256 data keys
+const dataKeySize = 32
+
+// maxUnwrappedKeys bounds the cache of unwrapped data keys
+const maxUnwrappedKeys = 1024
+
+// ErrMalformed is returned for values that were not made by an Envelope
+var ErrMalformed = errors.New("kms: malformed envelope")
+
+// Envelope encrypts values with data keys wrapped by a Provider. A sealed
+// value is a format byte, the wrapped data key's length and bytes, the
+// nonce and the AES-GCM ciphertext, so it carries everything but the
+// master key needed to open it.
+//
+// A data key seals values for its lifetime before a new one is made, and
+// unwrapped data keys are cached, so the provider is asked roughly once
+// per lifetime and once per data key seen rather than once per value.
+type Envelope struct {
+	provider Provider
+	lifetime time.Duration
+	clock    clock.Clock
+
+	mu        sync.Mutex
+	current   *dataKey
+	unwrapped map[string]cipher.AEAD
+}
+
+type dataKey struct {
+	aead    cipher.AEAD
+	wrapped []byte
+	created time.Time
+}
+
+// NewEnvelope creates an envelope wrapping data keys with provider. Each
+// data key seals values for lifetime, an hour if zero.
+func NewEnvelope(provider Provider, lifetime time.Duration) *Envelope {
+	if lifetime <= 0 {
+		lifetime = time.Hour
+	}
+	return &Envelope{
+		provider:  provider,
+		lifetime:  lifetime,
+		clock:     clock.New(),
+		unwrapped: make(map[string]cipher.AEAD),
+	}
+}
+
+// WithClock overrides the clock used to expire data keys
+func (e *Envelope) WithClock(c clock.Clock) *Envelope {
+	e.clock = clock.OrDefault(c)
+	return e
+}
+
+// Seal encrypts plaintext. additionalData is authenticated but not
+// stored, and Open must be given the same; it binds a value to where it
+// belongs, e.g. its table and column.
+func (e *Envelope) Seal(ctx context.Context, plaintext, additionalData []byte) ([]byte, error) {
+	key, err := e.dataKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	nonceSize := key.aead.NonceSize()
+	out := make([]byte, 0, 3+len(key.wrapped)+nonceSize+len(plaintext)+key.aead.Overhead())
+	out = append(out, envelopeFormat)
+	out = binary.BigEndian.AppendUint16(out, uint16(len(key.wrapped)))
+	out = append(out, key.wrapped...)
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out = append(out, nonce...)
+	return key.aead.Seal(out, nonce, plaintext, additionalData), nil
+}
+
+// Open decrypts a value made by Seal
+func (e *Envelope) Open(ctx context.Context, sealed, additionalData []byte) ([]byte, error) {
+	if len(sealed) < 3 || sealed[0] != envelopeFormat {
+		return nil, ErrMalformed
+	}
+	wrappedLen := int(binary.BigEndian.Uint16(sealed[1:3]))
+	rest := sealed[3:]
+	if len(rest) < wrappedLen {
+		return nil, ErrMalformed
+	}
+	wrapped, rest := rest[:wrappedLen], rest[wrappedLen:]
+
+	aead, err := e.unwrap(ctx, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) < aead.NonceSize() {
+		return nil, ErrMalformed
+	}
+	plaintext, err := aead.Open(nil, rest[:aead.NonceSize()], rest[aead.NonceSize():], additionalData)
+	if err != nil {
+		return nil, errors.New("kms: value failed authentication")
+	}
+	return plaintext, nil
+}
+
+// EncryptString seals plaintext into text for a string column
+func (e *Envelope) EncryptString(ctx context.Context, plaintext, additionalData string) (string, error) {
+	sealed, err := e.Seal(ctx, []byte(plaintext), []byte(additionalData))
+	if err != nil {
+		return "", err
+	}
+	return EncryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptString opens text made by EncryptString
+func (e *Envelope) DecryptString(ctx context.Context, encrypted, additionalData string) (string, error) {
+	if !IsEncrypted(encrypted) {
+		return "", ErrMalformed
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(encrypted, EncryptedPrefix))
+	if err != nil {
+		return "", ErrMalformed
+	}
+	plaintext, err := e.Open(ctx, sealed, []byte(additionalData))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// IsEncrypted reports whether s was made by EncryptString
+func IsEncrypted(s string) bool {
+	return strings.HasPrefix(s, EncryptedPrefix)
+}
+
+// Rotate rotates the provider's master key and retires the current data
+// key, so values sealed from now on use a data key wrapped by the new
+// version. Values sealed before keep opening.
+func (e *Envelope) Rotate(ctx context.Context) error {
+	if err := e.provider.Rotate(ctx); err != nil {
+		return err
+	}
+	e.mu.Lock()
+	e.current = nil
+	e.mu.Unlock()
+	return nil
+}
+
+// dataKey returns the data key sealing values, making a new one when the
+// current one has expired
+func (e *Envelope) dataKey(ctx context.Context) (*dataKey, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.current != nil && e.clock.Since(e.current.created) < e.lifetime {
+		return e.current, nil
+	}
+
+	plaintext := make([]byte, dataKeySize)
+	if _, err := rand.Read(plaintext); err != nil {
+		return nil, err
+	}
+	wrapped, err := e.provider.Encrypt(ctx, plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	if len(wrapped) > 0xffff {
+		return nil, fmt.Errorf("wrapped data key is %d bytes, too long for an envelope", len(wrapped))
+	}
+	aead, err := newGCM(plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	e.current = &dataKey{aead: aead, wrapped: wrapped, created: e.clock.Now()}
+	e.cacheLocked(wrapped, aead)
+	return e.current, nil
+}
+
+// unwrap returns the cipher for a wrapped data key, asking the provider
+// to decrypt it unless it is cached
+func (e *Envelope) unwrap(ctx context.Context, wrapped []byte) (cipher.AEAD, error) {
+	e.mu.Lock()
+	aead, ok := e.unwrapped[string(wrapped)]
+	e.mu.Unlock()
+	if ok {
+		return aead, nil
+	}
+
+	plaintext, err := e.provider.Decrypt(ctx, wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	if len(plaintext) != dataKeySize {
+		return nil, ErrMalformed
+	}
+	aead, err = newGCM(plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	e.cacheLocked(wrapped, aead)
+	e.mu.Unlock()
+	return aead, nil
+}
+
+// cacheLocked remembers an unwrapped data key, starting over when the
+// cache is full; e.mu must be held
+func (e *Envelope) cacheLocked(wrapped []byte, aead cipher.AEAD) {
+	if len(e.unwrapped) >= maxUnwrappedKeys {
+		e.unwrapped = make(map[string]cipher.AEAD)
+	}
+	e.unwrapped[string(wrapped)] = aead
+}
@@ -0,0 +1,264 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go-server/internal/clock"
+)
+
+// defaultMetadataTokenURL is where workloads on Google Cloud get access
+// tokens for their service account
+const defaultMetadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// GCPConfig locates the keys in Google Cloud KMS
+type GCPConfig struct {
+	// KeyName is the ENCRYPT_DECRYPT key, as
+	// projects/P/locations/L/keyRings/R/cryptoKeys/K
+	KeyName string
+	// SigningKeyVersion is an RSA_SIGN_PKCS1_*_SHA256 key version, as
+	// .../cryptoKeys/K/cryptoKeyVersions/N; empty disables signing
+	SigningKeyVersion string
+	// Endpoint overrides https://cloudkms.googleapis.com/v1/
+	Endpoint string
+	// AccessToken is a fixed OAuth token; empty fetches the workload's
+	// token from the metadata server
+	AccessToken string
+	// MetadataURL overrides the metadata server's token URL
+	MetadataURL string
+}
+
+// GCPProvider uses Google Cloud KMS. Ciphertexts name the key version, so
+// decryption keeps working after rotation.
+type GCPProvider struct {
+	config   GCPConfig
+	endpoint string
+	client   *http.Client
+	clock    clock.Clock
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+	publicKey   *rsa.PublicKey
+}
+
+// NewGCPProvider creates a new Google Cloud KMS provider
+func NewGCPProvider(config GCPConfig, client *http.Client) (*GCPProvider, error) {
+	if !strings.Contains(config.KeyName, "/cryptoKeys/") {
+		return nil, fmt.Errorf("GCP KMS key name must be projects/.../cryptoKeys/KEY")
+	}
+	if config.SigningKeyVersion != "" && !strings.Contains(config.SigningKeyVersion, "/cryptoKeyVersions/") {
+		return nil, fmt.Errorf("GCP KMS signing key must be a .../cryptoKeyVersions/N key version")
+	}
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		endpoint = "https://cloudkms.googleapis.com/v1/"
+	}
+	if config.MetadataURL == "" {
+		config.MetadataURL = defaultMetadataTokenURL
+	}
+
+	return &GCPProvider{
+		config:   config,
+		endpoint: strings.TrimSuffix(endpoint, "/") + "/",
+		client:   client,
+		clock:    clock.New(),
+	}, nil
+}
+
+// WithClock overrides the clock used to expire metadata server tokens
+func (p *GCPProvider) WithClock(c clock.Clock) *GCPProvider {
+	p.clock = clock.OrDefault(c)
+	return p
+}
+
+// Encrypt calls cryptoKeys.encrypt, which uses the key's primary version
+func (p *GCPProvider) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	var out struct {
+		Ciphertext []byte `json:"ciphertext"`
+	}
+	err := p.call(ctx, http.MethodPost, p.config.KeyName+":encrypt", map[string]interface{}{"plaintext": plaintext}, &out)
+	return out.Ciphertext, err
+}
+
+// Decrypt calls cryptoKeys.decrypt
+func (p *GCPProvider) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	var out struct {
+		Plaintext []byte `json:"plaintext"`
+	}
+	err := p.call(ctx, http.MethodPost, p.config.KeyName+":decrypt", map[string]interface{}{"ciphertext": ciphertext}, &out)
+	return out.Plaintext, err
+}
+
+// Sign calls cryptoKeyVersions.asymmetricSign with the signing key version
+func (p *GCPProvider) Sign(ctx context.Context, digest []byte) ([]byte, error) {
+	if p.config.SigningKeyVersion == "" {
+		return nil, ErrNoSigningKey
+	}
+	var out struct {
+		Signature []byte `json:"signature"`
+	}
+	err := p.call(ctx, http.MethodPost, p.config.SigningKeyVersion+":asymmetricSign", map[string]interface{}{
+		"digest": map[string][]byte{"sha256": digest},
+	}, &out)
+	return out.Signature, err
+}
+
+// PublicKey fetches the signing key version's public half once and caches it
+func (p *GCPProvider) PublicKey(ctx context.Context) (*rsa.PublicKey, error) {
+	if p.config.SigningKeyVersion == "" {
+		return nil, ErrNoSigningKey
+	}
+	p.mu.Lock()
+	public := p.publicKey
+	p.mu.Unlock()
+	if public != nil {
+		return public, nil
+	}
+
+	var out struct {
+		PEM string `json:"pem"`
+	}
+	if err := p.call(ctx, http.MethodGet, p.config.SigningKeyVersion+"/publicKey", nil, &out); err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode([]byte(out.PEM))
+	if block == nil {
+		return nil, fmt.Errorf("invalid KMS public key: no PEM block")
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid KMS public key: %w", err)
+	}
+	public, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("KMS signing key is %T, want an RSA key", parsed)
+	}
+
+	p.mu.Lock()
+	p.publicKey = public
+	p.mu.Unlock()
+	return public, nil
+}
+
+// Rotate creates a key version and makes it primary. Software and HSM
+// versions are usable as soon as they are created; earlier versions stay
+// enabled for decryption.
+func (p *GCPProvider) Rotate(ctx context.Context) error {
+	var created struct {
+		Name string `json:"name"`
+	}
+	if err := p.call(ctx, http.MethodPost, p.config.KeyName+"/cryptoKeyVersions", map[string]interface{}{}, &created); err != nil {
+		return err
+	}
+	id := created.Name[strings.LastIndex(created.Name, "/")+1:]
+	if id == "" {
+		return fmt.Errorf("KMS did not return the new key version")
+	}
+	return p.call(ctx, http.MethodPost, p.config.KeyName+":updatePrimaryVersion",
+		map[string]string{"cryptoKeyVersionId": id}, nil)
+}
+
+// call sends a request to the KMS REST API and decodes the response into
+// out. Binary fields are base64 in the API, which encoding/json does for
+// []byte.
+func (p *GCPProvider) call(ctx context.Context, method, path string, in, out interface{}) error {
+	token, err := p.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	var body io.Reader
+	if in != nil {
+		payload, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, p.endpoint+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("KMS request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read KMS response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var gcpErr struct {
+			Error struct {
+				Status  string `json:"status"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		json.Unmarshal(data, &gcpErr)
+		return fmt.Errorf("KMS returned status %d %s %s", resp.StatusCode, gcpErr.Error.Status, gcpErr.Error.Message)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("invalid KMS response: %w", err)
+	}
+	return nil
+}
+
+// accessToken returns the configured token, or the metadata server's
+// token, refreshed a minute before it expires
+func (p *GCPProvider) accessToken(ctx context.Context) (string, error) {
+	if p.config.AccessToken != "" {
+		return p.config.AccessToken, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.token != "" && p.clock.Now().Before(p.tokenExpiry) {
+		return p.token, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.config.MetadataURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("metadata server token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("metadata server returned status %d", resp.StatusCode)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&token); err != nil || token.AccessToken == "" {
+		return "", fmt.Errorf("invalid metadata server token response")
+	}
+	p.token = token.AccessToken
+	p.tokenExpiry = p.clock.Now().Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+	return p.token, nil
+}
@@ -0,0 +1,399 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/dbtest"
+)
+
+func newLocalProvider(t *testing.T) *LocalProvider {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "keys.json")
+	if err := CreateKeyFile(path); err != nil {
+		t.Fatalf("CreateKeyFile failed: %v", err)
+	}
+	provider, err := OpenLocalProvider(path)
+	if err != nil {
+		t.Fatalf("OpenLocalProvider failed: %v", err)
+	}
+	return provider
+}
+
+func TestLocalProvider_RotateKeepsDecrypting(t *testing.T) {
+	ctx := context.Background()
+	provider := newLocalProvider(t)
+
+	before, err := provider.Encrypt(ctx, []byte("data key"))
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if err := provider.Rotate(ctx); err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	after, err := provider.Encrypt(ctx, []byte("data key"))
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if bytes.Equal(before[:4], after[:4]) {
+		t.Errorf("Expected a new key version after rotating")
+	}
+
+	// A server opening the rewritten keyfile decrypts both versions
+	reopened, err := OpenLocalProvider(provider.path)
+	if err != nil {
+		t.Fatalf("OpenLocalProvider failed: %v", err)
+	}
+	for _, ciphertext := range [][]byte{before, after} {
+		if plaintext, err := reopened.Decrypt(ctx, ciphertext); err != nil || string(plaintext) != "data key" {
+			t.Errorf("Expected the data key back, got %q, %v", plaintext, err)
+		}
+	}
+
+	tampered := append([]byte(nil), after...)
+	tampered[len(tampered)-1] ^= 1
+	if _, err := reopened.Decrypt(ctx, tampered); err == nil {
+		t.Errorf("Expected a tampered ciphertext to be refused")
+	}
+}
+
+func TestLocalProvider_Sign(t *testing.T) {
+	ctx := context.Background()
+	provider := newLocalProvider(t)
+
+	digest := sha256.Sum256([]byte("header.claims"))
+	signature, err := provider.Sign(ctx, digest[:])
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	public, err := provider.PublicKey(ctx)
+	if err != nil {
+		t.Fatalf("PublicKey failed: %v", err)
+	}
+	if err := rsa.VerifyPKCS1v15(public, crypto.SHA256, digest[:], signature); err != nil {
+		t.Errorf("Expected a valid signature: %v", err)
+	}
+}
+
+// countingProvider counts calls to the provider it wraps
+type countingProvider struct {
+	Provider
+	encrypts, decrypts atomic.Int32
+}
+
+func (p *countingProvider) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	p.encrypts.Add(1)
+	return p.Provider.Encrypt(ctx, plaintext)
+}
+
+func (p *countingProvider) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	p.decrypts.Add(1)
+	return p.Provider.Decrypt(ctx, ciphertext)
+}
+
+func TestEnvelope_SealAndOpen(t *testing.T) {
+	ctx := context.Background()
+	provider := &countingProvider{Provider: newLocalProvider(t)}
+	fake := clock.NewFake(time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC))
+	envelope := NewEnvelope(provider, time.Hour).WithClock(fake)
+
+	first, err := envelope.EncryptString(ctx, "whsec_one", "webhooks.secret")
+	if err != nil {
+		t.Fatalf("EncryptString failed: %v", err)
+	}
+	second, _ := envelope.EncryptString(ctx, "whsec_two", "webhooks.secret")
+	if !IsEncrypted(first) || strings.Contains(first, "whsec") {
+		t.Errorf("Expected an opaque encrypted value, got %q", first)
+	}
+	if provider.encrypts.Load() != 1 {
+		t.Errorf("Expected one data key for both values, wrapped %d", provider.encrypts.Load())
+	}
+
+	// Another server unwraps the data key once
+	other := NewEnvelope(provider, time.Hour)
+	for value, expected := range map[string]string{first: "whsec_one", second: "whsec_two"} {
+		if plaintext, err := other.DecryptString(ctx, value, "webhooks.secret"); err != nil || plaintext != expected {
+			t.Errorf("Expected %q, got %q, %v", expected, plaintext, err)
+		}
+	}
+	if provider.decrypts.Load() != 1 {
+		t.Errorf("Expected the data key unwrapped once, got %d", provider.decrypts.Load())
+	}
+
+	if _, err := other.DecryptString(ctx, first, "users.email"); err == nil {
+		t.Errorf("Expected a value moved to another column to be refused")
+	}
+
+	fake.Advance(2 * time.Hour)
+	if _, err := envelope.EncryptString(ctx, "whsec_three", "webhooks.secret"); err != nil {
+		t.Fatalf("EncryptString failed: %v", err)
+	}
+	if provider.encrypts.Load() != 2 {
+		t.Errorf("Expected a new data key after its lifetime, wrapped %d", provider.encrypts.Load())
+	}
+}
+
+func TestEnvelope_Rotate(t *testing.T) {
+	ctx := context.Background()
+	provider := newLocalProvider(t)
+	envelope := NewEnvelope(provider, time.Hour)
+
+	before, _ := envelope.Seal(ctx, []byte("secret"), nil)
+	if err := envelope.Rotate(ctx); err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	after, _ := envelope.Seal(ctx, []byte("secret"), nil)
+
+	// The wrapped data key starts with the local key version
+	if bytes.Equal(before[3:7], after[3:7]) {
+		t.Errorf("Expected the data key wrapped by the new key version")
+	}
+	if plaintext, err := envelope.Open(ctx, before, nil); err != nil || string(plaintext) != "secret" {
+		t.Errorf("Expected values sealed before the rotation to open, got %q, %v", plaintext, err)
+	}
+}
+
+type secretRecord struct {
+	ID     uint
+	Secret string `gorm:"serializer:kms"`
+}
+
+func TestFieldSerializer(t *testing.T) {
+	db := dbtest.Open(t, &secretRecord{})
+
+	// Without a KMS values are stored as they are
+	plain := &secretRecord{Secret: "whsec_plain"}
+	db.Create(plain)
+
+	UseForFields(NewEnvelope(newLocalProvider(t), time.Hour))
+	t.Cleanup(func() { UseForFields(nil) })
+	encrypted := &secretRecord{Secret: "whsec_encrypted"}
+	if err := db.Create(encrypted).Error; err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	var stored string
+	db.Raw("SELECT secret FROM secret_records WHERE id = ?", encrypted.ID).Scan(&stored)
+	if !IsEncrypted(stored) {
+		t.Errorf("Expected the secret encrypted in the database, got %q", stored)
+	}
+
+	var records []secretRecord
+	if err := db.Order("id").Find(&records).Error; err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if len(records) != 2 || records[0].Secret != "whsec_plain" || records[1].Secret != "whsec_encrypted" {
+		t.Errorf("Expected both secrets in plaintext, got %+v", records)
+	}
+
+	UseForFields(nil)
+	if err := db.First(&secretRecord{}, encrypted.ID).Error; err == nil {
+		t.Errorf("Expected reading an encrypted value without a KMS to fail")
+	}
+}
+
+// fakeAWSKMS serves the KMS actions the provider uses, wrapping data keys
+// with a local provider
+func fakeAWSKMS(t *testing.T, key *rsa.PrivateKey) *httptest.Server {
+	local := newLocalProvider(t)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") ||
+			!strings.Contains(r.Header.Get("Authorization"), "/us-east-1/kms/aws4_request") {
+			t.Errorf("Expected a SigV4 signed KMS request, got %q", r.Header.Get("Authorization"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		var in struct {
+			KeyID          string `json:"KeyId"`
+			Plaintext      []byte
+			CiphertextBlob []byte
+			Message        []byte
+		}
+		json.Unmarshal(body, &in)
+
+		var out interface{}
+		switch r.Header.Get("X-Amz-Target") {
+		case "TrentService.Encrypt":
+			blob, _ := local.Encrypt(r.Context(), in.Plaintext)
+			out = map[string]interface{}{"CiphertextBlob": blob, "KeyId": in.KeyID}
+		case "TrentService.Decrypt":
+			plaintext, err := local.Decrypt(r.Context(), in.CiphertextBlob)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"__type":"InvalidCiphertextException"}`))
+				return
+			}
+			out = map[string]interface{}{"Plaintext": plaintext}
+		case "TrentService.Sign":
+			signature, _ := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, in.Message)
+			out = map[string]interface{}{"Signature": signature}
+		case "TrentService.GetPublicKey":
+			der, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+			out = map[string]interface{}{"PublicKey": der}
+		case "TrentService.RotateKeyOnDemand":
+			local.Rotate(r.Context())
+			out = map[string]interface{}{"KeyId": in.KeyID}
+		default:
+			t.Errorf("Unexpected KMS action %q", r.Header.Get("X-Amz-Target"))
+		}
+		json.NewEncoder(w).Encode(out)
+	}))
+}
+
+func TestAWSProvider(t *testing.T) {
+	ctx := context.Background()
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	server := fakeAWSKMS(t, key)
+	defer server.Close()
+
+	provider, err := NewAWSProvider(AWSConfig{
+		Region:          "us-east-1",
+		KeyID:           "alias/go-server",
+		SigningKeyID:    "alias/go-server-jwt",
+		Endpoint:        server.URL,
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "secret",
+	}, server.Client())
+	if err != nil {
+		t.Fatalf("NewAWSProvider failed: %v", err)
+	}
+
+	envelope := NewEnvelope(provider, time.Hour)
+	sealed, err := envelope.Seal(ctx, []byte("field"), nil)
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+	if err := envelope.Rotate(ctx); err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	if plaintext, err := NewEnvelope(provider, time.Hour).Open(ctx, sealed, nil); err != nil || string(plaintext) != "field" {
+		t.Errorf("Expected the field back after rotating, got %q, %v", plaintext, err)
+	}
+
+	digest := sha256.Sum256([]byte("header.claims"))
+	signature, err := provider.Sign(ctx, digest[:])
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	public, err := provider.PublicKey(ctx)
+	if err != nil {
+		t.Fatalf("PublicKey failed: %v", err)
+	}
+	if rsa.VerifyPKCS1v15(public, crypto.SHA256, digest[:], signature) != nil {
+		t.Errorf("Expected a signature from the KMS signing key")
+	}
+
+	if _, err := provider.Decrypt(ctx, []byte("garbage")); err == nil || !strings.Contains(err.Error(), "InvalidCiphertextException") {
+		t.Errorf("Expected the KMS error type in the error, got %v", err)
+	}
+}
+
+func TestGCPProvider(t *testing.T) {
+	ctx := context.Background()
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	local := newLocalProvider(t)
+	const keyName = "projects/p/locations/global/keyRings/r/cryptoKeys/k"
+	var tokens atomic.Int32
+	var primary string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			t.Errorf("Expected the metadata flavor header")
+		}
+		tokens.Add(1)
+		w.Write([]byte(`{"access_token":"ya29.token","expires_in":3600}`))
+	})
+	mux.HandleFunc("/v1/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer ya29.token" {
+			t.Errorf("Expected the metadata token, got %q", r.Header.Get("Authorization"))
+		}
+		var in struct {
+			Plaintext          []byte `json:"plaintext"`
+			Ciphertext         []byte `json:"ciphertext"`
+			CryptoKeyVersionID string `json:"cryptoKeyVersionId"`
+			Digest             struct {
+				SHA256 []byte `json:"sha256"`
+			} `json:"digest"`
+		}
+		json.NewDecoder(r.Body).Decode(&in)
+
+		var out interface{}
+		switch strings.TrimPrefix(r.URL.Path, "/v1/") {
+		case keyName + ":encrypt":
+			ciphertext, _ := local.Encrypt(r.Context(), in.Plaintext)
+			out = map[string]interface{}{"ciphertext": ciphertext}
+		case keyName + ":decrypt":
+			plaintext, _ := local.Decrypt(r.Context(), in.Ciphertext)
+			out = map[string]interface{}{"plaintext": plaintext}
+		case keyName + "/cryptoKeyVersions":
+			out = map[string]string{"name": keyName + "/cryptoKeyVersions/2"}
+		case keyName + ":updatePrimaryVersion":
+			primary = in.CryptoKeyVersionID
+			out = map[string]string{"name": keyName}
+		case keyName + "/cryptoKeyVersions/1:asymmetricSign":
+			signature, _ := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, in.Digest.SHA256)
+			out = map[string]interface{}{"signature": signature}
+		case keyName + "/cryptoKeyVersions/1/publicKey":
+			der, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+			out = map[string]string{"pem": string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"status":"NOT_FOUND","message":"no such key"}}`))
+			return
+		}
+		json.NewEncoder(w).Encode(out)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	provider, err := NewGCPProvider(GCPConfig{
+		KeyName:           keyName,
+		SigningKeyVersion: keyName + "/cryptoKeyVersions/1",
+		Endpoint:          server.URL + "/v1/",
+		MetadataURL:       server.URL + "/token",
+	}, server.Client())
+	if err != nil {
+		t.Fatalf("NewGCPProvider failed: %v", err)
+	}
+
+	sealed, err := NewEnvelope(provider, time.Hour).Seal(ctx, []byte("field"), nil)
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+	if plaintext, err := NewEnvelope(provider, time.Hour).Open(ctx, sealed, nil); err != nil || string(plaintext) != "field" {
+		t.Errorf("Expected the field back, got %q, %v", plaintext, err)
+	}
+
+	if err := provider.Rotate(ctx); err != nil || primary != "2" {
+		t.Errorf("Expected version 2 made primary, got %q, %v", primary, err)
+	}
+
+	digest := sha256.Sum256([]byte("header.claims"))
+	signature, err := provider.Sign(ctx, digest[:])
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	public, err := provider.PublicKey(ctx)
+	if err != nil || rsa.VerifyPKCS1v15(public, crypto.SHA256, digest[:], signature) != nil {
+		t.Errorf("Expected a signature from the KMS signing key, got %v", err)
+	}
+	if tokens.Load() != 1 {
+		t.Errorf("Expected the metadata token fetched once, got %d", tokens.Load())
+	}
+}
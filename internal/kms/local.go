@@ -0,0 +1,256 @@
+package kms
+
+import (
+	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"go-server/internal/atomicfile"
+)
+
+// localKeySize is the size of the local backend's AES-256 master keys
+const localKeySize = 32
+
+// keyFile is the JSON layout of a local keyfile
+type keyFile struct {
+	// Current is the version new ciphertexts are encrypted with
+	Current uint32         `json:"current"`
+	Keys    []keyFileEntry `json:"keys"`
+	// SigningKey is a PEM RSA private key, optional
+	SigningKey string `json:"signing_key,omitempty"`
+}
+
+type keyFileEntry struct {
+	Version uint32 `json:"version"`
+	Key     []byte `json:"key"`
+}
+
+// LocalProvider keeps master keys in a JSON keyfile on disk, for
+// development and tests. The keys are only as safe as the file: use a
+// key management service in production.
+//
+// The keyfile holds every version of the AES-256 master key and
+// optionally an RSA signing key. Rotate appends a version and rewrites
+// the file, so every server sharing the keyfile must reopen it to encrypt
+// with the new version; they keep decrypting ciphertexts they know the
+// version of.
+type LocalProvider struct {
+	path string
+
+	mu      sync.RWMutex
+	file    keyFile
+	ciphers map[uint32]cipher.AEAD
+	signKey *rsa.PrivateKey
+}
+
+// CreateKeyFile writes a new keyfile at path with one master key and a
+// 2048-bit RSA signing key, failing if the file exists
+func CreateKeyFile(path string) error {
+	key := make([]byte, localKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	signKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return err
+	}
+	file := keyFile{
+		Current: 1,
+		Keys:    []keyFileEntry{{Version: 1, Key: key}},
+		SigningKey: string(pem.EncodeToMemory(&pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(signKey),
+		})),
+	}
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// OpenLocalProvider loads the keyfile at path
+func OpenLocalProvider(path string) (*LocalProvider, error) {
+	if path == "" {
+		return nil, fmt.Errorf("KMS keyfile path is required")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read KMS keyfile: %w", err)
+	}
+	var file keyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid KMS keyfile: %w", err)
+	}
+
+	p := &LocalProvider{path: path}
+	if err := p.load(file); err != nil {
+		return nil, fmt.Errorf("invalid KMS keyfile: %w", err)
+	}
+	return p, nil
+}
+
+// load checks file and builds its ciphers
+func (p *LocalProvider) load(file keyFile) error {
+	ciphers := make(map[uint32]cipher.AEAD, len(file.Keys))
+	for _, entry := range file.Keys {
+		if len(entry.Key) != localKeySize {
+			return fmt.Errorf("key version %d is not %d bytes", entry.Version, localKeySize)
+		}
+		if _, ok := ciphers[entry.Version]; ok {
+			return fmt.Errorf("key version %d appears twice", entry.Version)
+		}
+		aead, err := newGCM(entry.Key)
+		if err != nil {
+			return err
+		}
+		ciphers[entry.Version] = aead
+	}
+	if _, ok := ciphers[file.Current]; !ok {
+		return fmt.Errorf("current key version %d is missing", file.Current)
+	}
+
+	var signKey *rsa.PrivateKey
+	if file.SigningKey != "" {
+		block, _ := pem.Decode([]byte(file.SigningKey))
+		if block == nil {
+			return errors.New("signing key is not PEM encoded")
+		}
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			parsed, pkcs8Err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			rsaKey, ok := parsed.(*rsa.PrivateKey)
+			if pkcs8Err != nil || !ok {
+				return errors.New("signing key is not an RSA private key")
+			}
+			key = rsaKey
+		}
+		signKey = key
+	}
+
+	p.file, p.ciphers, p.signKey = file, ciphers, signKey
+	return nil
+}
+
+// Encrypt seals plaintext with the current key version. The ciphertext is
+// the 4-byte version, the nonce and the sealed plaintext.
+func (p *LocalProvider) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	p.mu.RLock()
+	version := p.file.Current
+	aead := p.ciphers[version]
+	p.mu.RUnlock()
+
+	out := make([]byte, 4, 4+aead.NonceSize()+len(plaintext)+aead.Overhead())
+	binary.BigEndian.PutUint32(out, version)
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out = append(out, nonce...)
+	return aead.Seal(out, nonce, plaintext, out[:4]), nil
+}
+
+// Decrypt opens a ciphertext made by Encrypt with the key version it names
+func (p *LocalProvider) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < 4 {
+		return nil, errors.New("kms: ciphertext too short")
+	}
+	version := binary.BigEndian.Uint32(ciphertext)
+	p.mu.RLock()
+	aead, ok := p.ciphers[version]
+	p.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("kms: unknown key version %d", version)
+	}
+
+	rest := ciphertext[4:]
+	if len(rest) < aead.NonceSize() {
+		return nil, errors.New("kms: ciphertext too short")
+	}
+	plaintext, err := aead.Open(nil, rest[:aead.NonceSize()], rest[aead.NonceSize():], ciphertext[:4])
+	if err != nil {
+		return nil, errors.New("kms: ciphertext failed authentication")
+	}
+	return plaintext, nil
+}
+
+// Sign signs digest with the keyfile's RSA key
+func (p *LocalProvider) Sign(ctx context.Context, digest []byte) ([]byte, error) {
+	p.mu.RLock()
+	key := p.signKey
+	p.mu.RUnlock()
+	if key == nil {
+		return nil, ErrNoSigningKey
+	}
+	return rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest)
+}
+
+// PublicKey returns the public half of the keyfile's RSA key
+func (p *LocalProvider) PublicKey(ctx context.Context) (*rsa.PublicKey, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.signKey == nil {
+		return nil, ErrNoSigningKey
+	}
+	return &p.signKey.PublicKey, nil
+}
+
+// Rotate generates a new master key version, makes it current and
+// rewrites the keyfile
+func (p *LocalProvider) Rotate(ctx context.Context) error {
+	key := make([]byte, localKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	file := p.file
+	file.Keys = append(append([]keyFileEntry(nil), p.file.Keys...), keyFileEntry{Key: key})
+	for _, entry := range p.file.Keys {
+		if entry.Version >= file.Current {
+			file.Current = entry.Version
+		}
+	}
+	file.Current++
+	file.Keys[len(file.Keys)-1].Version = file.Current
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := atomicfile.WriteFile(p.path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write KMS keyfile: %w", err)
+	}
+	return p.load(file)
+}
+
+// newGCM returns AES-GCM with key
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,87 @@
+package kms
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+
+	"gorm.io/gorm/schema"
+)
+
+// SerializerName is the GORM serializer encrypting string columns, used
+// as `gorm:"serializer:kms"`
+const SerializerName = "kms"
+
+// FieldSerializer encrypts string columns with an Envelope. Each value is
+// bound to its table and column, so a ciphertext copied into another
+// column does not open.
+//
+// Values are written in plaintext until UseForFields configures an
+// envelope, and plaintext values read back as they are, so a column can
+// start out unencrypted and be encrypted row by row as rows are saved.
+//
+// GORM copies serializers into a pool per field, so the envelope is kept
+// outside the serializer where every copy sees it change.
+type FieldSerializer struct{}
+
+// fieldEnvelope is the envelope set by UseForFields
+var fieldEnvelope atomic.Pointer[Envelope]
+
+func init() {
+	schema.RegisterSerializer(SerializerName, FieldSerializer{})
+}
+
+// UseForFields makes the kms serializer encrypt with envelope; nil goes
+// back to writing plaintext
+func UseForFields(envelope *Envelope) {
+	fieldEnvelope.Store(envelope)
+}
+
+// fieldContext is the additional data binding a value to its column
+func fieldContext(field *schema.Field) string {
+	return field.Schema.Table + "." + field.DBName
+}
+
+// Scan decrypts the column into the field
+func (FieldSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var value string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	default:
+		return fmt.Errorf("kms serializer: unsupported column value %T", dbValue)
+	}
+
+	if IsEncrypted(value) {
+		envelope := fieldEnvelope.Load()
+		if envelope == nil {
+			return fmt.Errorf("kms serializer: %s is encrypted but no KMS is configured", fieldContext(field))
+		}
+		plaintext, err := envelope.DecryptString(ctx, value, fieldContext(field))
+		if err != nil {
+			return fmt.Errorf("kms serializer: failed to decrypt %s: %w", fieldContext(field), err)
+		}
+		value = plaintext
+	}
+
+	field.ReflectValueOf(ctx, dst).SetString(value)
+	return nil
+}
+
+// Value encrypts the field for the column
+func (FieldSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	value, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("kms serializer: %s must be a string, not %T", fieldContext(field), fieldValue)
+	}
+
+	envelope := fieldEnvelope.Load()
+	if envelope == nil || value == "" {
+		return value, nil
+	}
+	return envelope.EncryptString(ctx, value, fieldContext(field))
+}
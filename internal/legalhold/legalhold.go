@@ -0,0 +1,155 @@
+// Package legalhold places accounts and posts under legal hold. While a
+// hold is active, deleting the subject only moves it to the trash, the
+// trash purge leaves it there, and the domain events about it are copied
+// from the outbox to the append-only legal hold audit log. A hold on an
+// account covers its posts too.
+package legalhold
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/logger"
+	"go-server/internal/outbox"
+
+	"gorm.io/gorm"
+)
+
+// Legal hold errors
+var (
+	ErrUnknownSubjectType = errors.New("legal hold subject must be a user or a post")
+	ErrSubjectNotFound    = errors.New("legal hold subject does not exist")
+	ErrHoldNotFound       = errors.New("legal hold does not exist")
+	ErrHoldReleased       = errors.New("legal hold is already released")
+)
+
+// Service places and releases legal holds
+type Service struct {
+	repo   *repositories.LegalHoldRepository
+	clock  clock.Clock
+	logger logger.Logger
+}
+
+// NewService creates a new legal hold service
+func NewService(repo *repositories.LegalHoldRepository, logger logger.Logger) *Service {
+	return &Service{
+		repo:   repo,
+		clock:  clock.New(),
+		logger: logger,
+	}
+}
+
+// WithClock overrides the clock used to timestamp holds
+func (s *Service) WithClock(c clock.Clock) *Service {
+	s.clock = clock.OrDefault(c)
+	return s
+}
+
+// Place puts hold's subject under hold. The caller sets the subject, the
+// reason and PlacedByID. Subjects in the trash can be held.
+func (s *Service) Place(ctx context.Context, hold *models.LegalHold) error {
+	if hold.SubjectType != models.LegalHoldSubjectUser && hold.SubjectType != models.LegalHoldSubjectPost {
+		return ErrUnknownSubjectType
+	}
+	exists, err := s.repo.SubjectExists(ctx, hold.SubjectType, hold.SubjectID)
+	if err != nil {
+		return fmt.Errorf("failed to look up legal hold subject: %w", err)
+	}
+	if !exists {
+		return ErrSubjectNotFound
+	}
+
+	hold.CreatedAt = s.clock.Now()
+	if err := s.repo.PlaceHold(ctx, hold); err != nil {
+		return fmt.Errorf("failed to place legal hold: %w", err)
+	}
+	s.logger.Info("Legal hold placed", "hold_id", hold.ID, "subject_type", hold.SubjectType,
+		"subject_id", hold.SubjectID, "placed_by", hold.PlacedByID)
+	return nil
+}
+
+// Release ends an active hold. Records kept while it was active become
+// subject to retention again unless another hold covers them.
+func (s *Service) Release(ctx context.Context, id, actorID uint) (*models.LegalHold, error) {
+	hold, err := s.repo.ReleaseHold(ctx, id, actorID, s.clock.Now())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if _, getErr := s.repo.GetHold(ctx, id); getErr == nil {
+			return nil, ErrHoldReleased
+		}
+		return nil, ErrHoldNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to release legal hold: %w", err)
+	}
+	s.logger.Info("Legal hold released", "hold_id", hold.ID, "released_by", actorID)
+	return hold, nil
+}
+
+// subject is a record a hold can cover
+type subject struct {
+	kind string
+	id   uint
+}
+
+// OutboxPublisher returns a publisher copying the user and post events of
+// held subjects from the outbox relay to the audit log of each hold
+// covering them. Database failures are returned so the relay retries.
+func (s *Service) OutboxPublisher() outbox.Publisher {
+	return outbox.PublisherFunc(func(ctx context.Context, envelope outbox.Envelope) error {
+		for _, subj := range eventSubjects(envelope) {
+			held, err := s.repo.IsHeld(ctx, subj.kind, subj.id)
+			if err != nil {
+				return err
+			}
+			if !held {
+				continue
+			}
+			eventID := envelope.ID
+			err = s.repo.AppendEvent(ctx, &models.LegalHoldEvent{
+				SubjectType:   subj.kind,
+				SubjectID:     subj.id,
+				EventType:     envelope.Type,
+				OutboxEventID: &eventID,
+				Payload:       string(envelope.Payload),
+				OccurredAt:    envelope.OccurredAt,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// eventSubjects returns the subjects an event is about: a post and its
+// author, or a user and, for a merge, the merged account
+func eventSubjects(envelope outbox.Envelope) []subject {
+	id, err := strconv.ParseUint(envelope.AggregateID, 10, 32)
+	if err != nil {
+		return nil
+	}
+	var subjects []subject
+	switch envelope.AggregateType {
+	case models.LegalHoldSubjectPost:
+		subjects = append(subjects, subject{models.LegalHoldSubjectPost, uint(id)})
+		var post models.PostEventPayload
+		if json.Unmarshal(envelope.Payload, &post) == nil && post.AuthorID != 0 {
+			subjects = append(subjects, subject{models.LegalHoldSubjectUser, post.AuthorID})
+		}
+	case models.LegalHoldSubjectUser:
+		subjects = append(subjects, subject{models.LegalHoldSubjectUser, uint(id)})
+		if envelope.Type == models.EventUserMerged {
+			var merged models.UserMergedPayload
+			if json.Unmarshal(envelope.Payload, &merged) == nil && merged.SourceID != 0 {
+				subjects = append(subjects, subject{models.LegalHoldSubjectUser, merged.SourceID})
+			}
+		}
+	}
+	return subjects
+}
@@ -0,0 +1,254 @@
+package legalhold
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/dbtest"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/logger"
+	"go-server/internal/outbox"
+
+	"gorm.io/gorm"
+)
+
+type testEnv struct {
+	db      *gorm.DB
+	service *Service
+	holds   *repositories.LegalHoldRepository
+	users   *repositories.UserRepository
+	posts   *repositories.PostRepository
+	clock   *clock.Fake
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	db := dbtest.Open(t, &models.User{}, &models.Post{}, &models.OutboxEvent{},
+		&models.LegalHold{}, &models.LegalHoldEvent{})
+	env := &testEnv{
+		db:    db,
+		holds: repositories.NewLegalHoldRepository(db),
+		users: repositories.NewUserRepository(db),
+		posts: repositories.NewPostRepository(db),
+		clock: clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)),
+	}
+	env.service = NewService(env.holds, logger.NewServerLogger()).WithClock(env.clock)
+	return env
+}
+
+func (env *testEnv) createUser(t *testing.T, username string) *models.User {
+	t.Helper()
+	user := &models.User{Username: username, Email: username + "@example.com", Password: "x", IsActive: true}
+	if err := env.users.CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	return user
+}
+
+func (env *testEnv) createPost(t *testing.T, author *models.User, slug string) *models.Post {
+	t.Helper()
+	post := &models.Post{Title: "Hello", Slug: slug, Content: "x", Status: "draft", AuthorID: author.ID}
+	if err := env.posts.CreatePost(context.Background(), post); err != nil {
+		t.Fatalf("Failed to create post: %v", err)
+	}
+	return post
+}
+
+// relay publishes every outbox event to the service's publisher
+func (env *testEnv) relay(t *testing.T) {
+	t.Helper()
+	var events []models.OutboxEvent
+	if err := env.db.Order("id").Find(&events).Error; err != nil {
+		t.Fatalf("Failed to list outbox events: %v", err)
+	}
+	publisher := env.service.OutboxPublisher()
+	for i := range events {
+		if err := publisher.Publish(context.Background(), outbox.NewEnvelope(&events[i])); err != nil {
+			t.Fatalf("Publish failed: %v", err)
+		}
+	}
+}
+
+func (env *testEnv) loggedTypes(t *testing.T, subjectType string, subjectID uint) []string {
+	t.Helper()
+	events, err := env.holds.ListEvents(context.Background(), subjectType, subjectID, 0, 100)
+	if err != nil {
+		t.Fatalf("ListEvents failed: %v", err)
+	}
+	types := make([]string, len(events))
+	for i, event := range events {
+		types[i] = event.EventType
+	}
+	return types
+}
+
+func TestService_PlaceAndRelease(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	user := env.createUser(t, "alice")
+	admin := env.createUser(t, "admin")
+
+	err := env.service.Place(ctx, &models.LegalHold{SubjectType: "comment", SubjectID: 1, PlacedByID: admin.ID})
+	if !errors.Is(err, ErrUnknownSubjectType) {
+		t.Errorf("Expected ErrUnknownSubjectType, got %v", err)
+	}
+	err = env.service.Place(ctx, &models.LegalHold{SubjectType: models.LegalHoldSubjectPost, SubjectID: 99, PlacedByID: admin.ID})
+	if !errors.Is(err, ErrSubjectNotFound) {
+		t.Errorf("Expected ErrSubjectNotFound, got %v", err)
+	}
+
+	// Users in the trash can still be held
+	if err := env.users.DeleteUser(ctx, user.ID); err != nil {
+		t.Fatalf("Failed to delete user: %v", err)
+	}
+	hold := &models.LegalHold{SubjectType: models.LegalHoldSubjectUser, SubjectID: user.ID,
+		Reason: "Litigation", CaseReference: "CASE-1", PlacedByID: admin.ID}
+	if err := env.service.Place(ctx, hold); err != nil {
+		t.Fatalf("Place failed: %v", err)
+	}
+	if !hold.IsActive() || !hold.CreatedAt.Equal(env.clock.Now()) {
+		t.Errorf("Expected an active hold placed now, got %+v", hold)
+	}
+
+	env.clock.Advance(time.Hour)
+	released, err := env.service.Release(ctx, hold.ID, admin.ID)
+	if err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	if released.IsActive() || released.ReleasedByID == nil || *released.ReleasedByID != admin.ID {
+		t.Errorf("Expected the hold to be released by the admin, got %+v", released)
+	}
+	if _, err := env.service.Release(ctx, hold.ID, admin.ID); !errors.Is(err, ErrHoldReleased) {
+		t.Errorf("Expected ErrHoldReleased, got %v", err)
+	}
+	if _, err := env.service.Release(ctx, 99, admin.ID); !errors.Is(err, ErrHoldNotFound) {
+		t.Errorf("Expected ErrHoldNotFound, got %v", err)
+	}
+
+	want := []string{models.LegalHoldEventPlaced, models.LegalHoldEventReleased}
+	if got := env.loggedTypes(t, models.LegalHoldSubjectUser, user.ID); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Expected logged events %v, got %v", want, got)
+	}
+}
+
+func TestService_HeldRecordsSurviveThePurge(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	admin := env.createUser(t, "admin")
+	held := env.createUser(t, "held")
+	free := env.createUser(t, "free")
+	author := env.createUser(t, "author")
+	heldPost := env.createPost(t, free, "held-post")
+	authorPost := env.createPost(t, held, "author-post")
+	freePost := env.createPost(t, free, "free-post")
+	authorOfHeldPost := env.createPost(t, author, "author-of-held")
+
+	for _, hold := range []*models.LegalHold{
+		{SubjectType: models.LegalHoldSubjectUser, SubjectID: held.ID, Reason: "x", PlacedByID: admin.ID},
+		{SubjectType: models.LegalHoldSubjectPost, SubjectID: heldPost.ID, Reason: "x", PlacedByID: admin.ID},
+		{SubjectType: models.LegalHoldSubjectPost, SubjectID: authorOfHeldPost.ID, Reason: "x", PlacedByID: admin.ID},
+	} {
+		if err := env.service.Place(ctx, hold); err != nil {
+			t.Fatalf("Place failed: %v", err)
+		}
+	}
+	for _, post := range []*models.Post{heldPost, authorPost, freePost} {
+		if err := env.posts.DeletePost(ctx, post.ID); err != nil {
+			t.Fatalf("Failed to delete post: %v", err)
+		}
+	}
+	for _, user := range []*models.User{held, author} {
+		if err := env.users.DeleteUser(ctx, user.ID); err != nil {
+			t.Fatalf("Failed to delete user: %v", err)
+		}
+	}
+
+	cutoff := time.Now().Add(time.Hour)
+	if purged, err := env.posts.PurgeDeletedPosts(ctx, cutoff); err != nil || purged != 1 {
+		t.Errorf("Expected only the unheld post to be purged, got %d, %v", purged, err)
+	}
+	if purged, err := env.users.PurgeDeletedUsers(ctx, cutoff); err != nil || purged != 0 {
+		t.Errorf("Expected held users and authors of held posts to stay in the trash, got %d, %v", purged, err)
+	}
+	var remaining int64
+	env.db.Unscoped().Model(&models.Post{}).Where("id IN ?", []uint{heldPost.ID, authorPost.ID}).Count(&remaining)
+	if remaining != 2 {
+		t.Errorf("Expected the held post and the held user's post to remain, got %d", remaining)
+	}
+
+	holds, _ := env.holds.ListHolds(ctx, true, 0, 10)
+	for _, hold := range holds {
+		if _, err := env.service.Release(ctx, hold.ID, admin.ID); err != nil {
+			t.Fatalf("Release failed: %v", err)
+		}
+	}
+	if purged, _ := env.users.PurgeDeletedUsers(ctx, cutoff); purged != 2 {
+		t.Errorf("Expected released users to be purged, got %d", purged)
+	}
+}
+
+func TestService_HeldAccountsCannotBeMerged(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	admin := env.createUser(t, "admin")
+	held := env.createUser(t, "held")
+	other := env.createUser(t, "other")
+	post := env.createPost(t, held, "held-post")
+
+	hold := &models.LegalHold{SubjectType: models.LegalHoldSubjectUser, SubjectID: held.ID, Reason: "x", PlacedByID: admin.ID}
+	if err := env.service.Place(ctx, hold); err != nil {
+		t.Fatalf("Place failed: %v", err)
+	}
+	// Merging the held account away would move its posts out of the hold
+	if _, err := env.users.MergeUsers(ctx, held.ID, other.ID, &held.ID, false); !errors.Is(err, repositories.ErrMergeHeld) {
+		t.Fatalf("Merging a held source = %v, want ErrMergeHeld", err)
+	}
+	if _, err := env.users.MergeUsers(ctx, other.ID, held.ID, &held.ID, true); !errors.Is(err, repositories.ErrMergeHeld) {
+		t.Fatalf("Merging into a held target = %v, want ErrMergeHeld", err)
+	}
+
+	if err := env.posts.DeletePost(ctx, post.ID); err != nil {
+		t.Fatalf("Failed to delete post: %v", err)
+	}
+	if purged, err := env.posts.PurgeDeletedPosts(ctx, time.Now().Add(time.Hour)); err != nil || purged != 0 {
+		t.Errorf("Expected the held account's post to stay in the trash, got %d, %v", purged, err)
+	}
+}
+
+func TestService_OutboxPublisherLogsHeldSubjects(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	admin := env.createUser(t, "admin")
+	held := env.createUser(t, "held")
+	free := env.createUser(t, "free")
+
+	hold := &models.LegalHold{SubjectType: models.LegalHoldSubjectUser, SubjectID: held.ID, Reason: "x", PlacedByID: admin.ID}
+	if err := env.service.Place(ctx, hold); err != nil {
+		t.Fatalf("Place failed: %v", err)
+	}
+	post := env.createPost(t, held, "held-author")
+	env.createPost(t, free, "free-author")
+	if err := env.posts.DeletePost(ctx, post.ID); err != nil {
+		t.Fatalf("Failed to delete post: %v", err)
+	}
+	if err := env.users.DeleteUser(ctx, held.ID); err != nil {
+		t.Fatalf("Failed to delete user: %v", err)
+	}
+
+	// Relaying twice simulates redelivery, which must not duplicate records
+	env.relay(t)
+	env.relay(t)
+
+	want := []string{models.LegalHoldEventPlaced, models.EventPostDeleted, models.EventUserDeleted}
+	if got := env.loggedTypes(t, models.LegalHoldSubjectUser, held.ID); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Expected logged events %v, got %v", want, got)
+	}
+	if got := env.loggedTypes(t, models.LegalHoldSubjectUser, free.ID); len(got) != 0 {
+		t.Errorf("Expected nothing logged for unheld users, got %v", got)
+	}
+}
@@ -1,8 +1,11 @@
 package logger
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"strings"
+	"sync/atomic"
 )
 
 // Logger interface defines the logging methods
@@ -13,34 +16,84 @@ type Logger interface {
 	Warn(msg string, args ...any)
 }
 
+// Level is the minimum severity a logger writes
+type Level int32
+
+// Log levels, least severe first
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// ParseLevel parses debug, info, warn or error
+func ParseLevel(level string) (Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	}
+	return LevelInfo, fmt.Errorf("log level must be debug, info, warn or error")
+}
+
 // ServerLogger implements the Logger interface
 type ServerLogger struct {
 	logger *log.Logger
+	level  atomic.Int32
 }
 
-// NewServerLogger creates a new server logger
+// NewServerLogger creates a new server logger writing every level
 func NewServerLogger() *ServerLogger {
 	return &ServerLogger{
 		logger: log.New(os.Stdout, "[SERVER] ", log.LstdFlags|log.Lshortfile),
 	}
 }
 
+// SetLevel changes the minimum level written; it is safe to call while
+// the logger is in use, e.g. when the configuration is reloaded
+func (l *ServerLogger) SetLevel(level Level) {
+	l.level.Store(int32(level))
+}
+
+// Level returns the minimum level written
+func (l *ServerLogger) Level() Level {
+	return Level(l.level.Load())
+}
+
 // Info logs an info message
 func (l *ServerLogger) Info(msg string, args ...any) {
-	l.logger.Printf("[INFO] "+msg, args...)
+	if l.enabled(LevelInfo) {
+		l.logger.Printf("[INFO] "+msg, args...)
+	}
 }
 
 // Error logs an error message
 func (l *ServerLogger) Error(msg string, args ...any) {
-	l.logger.Printf("[ERROR] "+msg, args...)
+	if l.enabled(LevelError) {
+		l.logger.Printf("[ERROR] "+msg, args...)
+	}
 }
 
 // Debug logs a debug message
 func (l *ServerLogger) Debug(msg string, args ...any) {
-	l.logger.Printf("[DEBUG] "+msg, args...)
+	if l.enabled(LevelDebug) {
+		l.logger.Printf("[DEBUG] "+msg, args...)
+	}
 }
 
 // Warn logs a warning message
 func (l *ServerLogger) Warn(msg string, args ...any) {
-	l.logger.Printf("[WARN] "+msg, args...)
+	if l.enabled(LevelWarn) {
+		l.logger.Printf("[WARN] "+msg, args...)
+	}
+}
+
+func (l *ServerLogger) enabled(level Level) bool {
+	return level >= l.Level()
 }
@@ -2,19 +2,30 @@ package middleware
 
 import (
 	"context"
+	stderrors "errors"
+	"fmt"
 	"net/http"
 	"strings"
 
 	"go-server/internal/auth"
+	"go-server/internal/breakglass"
+	"go-server/internal/clientip"
 	"go-server/internal/database/models"
 	"go-server/internal/errors"
 	"go-server/internal/logger"
+	"go-server/internal/rbac"
+	"go-server/internal/reporting"
+	"go-server/internal/usage"
 )
 
 // AuthMiddleware handles JWT authentication
 type AuthMiddleware struct {
-	authService *auth.AuthService
-	logger      logger.Logger
+	authService     *auth.AuthService
+	serviceAccounts *auth.ServiceAccountService
+	breakGlass      *breakglass.Service
+	externalIdP     *auth.IdPValidator
+	cookies         *auth.SessionCookies
+	logger          logger.Logger
 }
 
 // NewAuthMiddleware creates a new authentication middleware
@@ -25,11 +36,54 @@ func NewAuthMiddleware(authService *auth.AuthService, logger logger.Logger) *Aut
 	}
 }
 
+// WithServiceAccounts lets RequireScope accept service account credentials
+func (am *AuthMiddleware) WithServiceAccounts(svc *auth.ServiceAccountService) *AuthMiddleware {
+	am.serviceAccounts = svc
+	return am
+}
+
+// WithBreakGlass accepts emergency break-glass tokens in the
+// X-Break-Glass-Token header wherever authentication is required
+func (am *AuthMiddleware) WithBreakGlass(svc *breakglass.Service) *AuthMiddleware {
+	am.breakGlass = svc
+	return am
+}
+
+// WithExternalIdP also accepts user tokens issued by an external identity
+// provider; tokens are routed to it by their iss claim
+func (am *AuthMiddleware) WithExternalIdP(validator *auth.IdPValidator) *AuthMiddleware {
+	am.externalIdP = validator
+	return am
+}
+
+// WithSessionCookies also accepts the access token from the session cookie,
+// checking the CSRF token on requests that change state
+func (am *AuthMiddleware) WithSessionCookies(cookies *auth.SessionCookies) *AuthMiddleware {
+	am.cookies = cookies
+	return am
+}
+
 // RequireAuth middleware that requires authentication
 func (am *AuthMiddleware) RequireAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Extract token from Authorization header
-		token := am.extractToken(r)
+		if am.usesBreakGlass(r) {
+			ctx, err := am.authenticateBreakGlass(r)
+			if err != nil {
+				am.logger.Error("Break-glass access denied: %v", err)
+				errors.WriteErrorResponse(w, http.StatusUnauthorized, "Break-glass access denied", "BREAK_GLASS_DENIED")
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
+		// Extract token from Authorization header or session cookie
+		token, err := am.extractToken(r)
+		if err != nil {
+			am.logger.Warn("Rejected cookie-authenticated %s %s: %v", r.Method, r.URL.Path, err)
+			errors.WriteErrorResponse(w, http.StatusForbidden, "CSRF token missing or invalid", "CSRF_TOKEN_INVALID")
+			return
+		}
 		if token == "" {
 			am.logger.Error("No token provided")
 			errors.WriteErrorResponse(w, http.StatusUnauthorized, "Authentication required", "NO_TOKEN")
@@ -37,19 +91,18 @@ func (am *AuthMiddleware) RequireAuth(next http.Handler) http.Handler {
 		}
 
 		// Validate token and get user
-		user, err := am.authService.ValidateToken(r.Context(), token)
+		user, sessionID, err := am.validateUserToken(r.Context(), token)
+		if stderrors.Is(err, auth.ErrSessionExpired) {
+			errors.WriteErrorResponse(w, http.StatusUnauthorized, "Session expired, please log in again", "SESSION_EXPIRED")
+			return
+		}
 		if err != nil {
 			am.logger.Error("Invalid token", "error", err.Error())
 			errors.WriteErrorResponse(w, http.StatusUnauthorized, "Invalid token", "INVALID_TOKEN")
 			return
 		}
 
-		// Add user to request context
-		ctx := context.WithValue(r.Context(), "user", user)
-		ctx = context.WithValue(ctx, "user_id", user.ID)
-		ctx = context.WithValue(ctx, "is_admin", user.IsAdmin)
-
-		next.ServeHTTP(w, r.WithContext(ctx))
+		next.ServeHTTP(w, r.WithContext(withSession(withUser(r.Context(), user), sessionID)))
 	})
 }
 
@@ -68,20 +121,32 @@ func (am *AuthMiddleware) RequireAdmin(next http.Handler) http.Handler {
 	}))
 }
 
+// RequirePermission middleware that requires a staff role granting permission
+func (am *AuthMiddleware) RequirePermission(permission rbac.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return am.RequireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !HasPermissionFromContext(r.Context(), permission) {
+				am.logger.Error("Permission %s required (user_id: %v)", permission, r.Context().Value("user_id"))
+				errors.WriteErrorResponse(w, http.StatusForbidden, "Permission required: "+string(permission), "PERMISSION_REQUIRED")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}))
+	}
+}
+
 // OptionalAuth middleware that adds user info if token is present
 func (am *AuthMiddleware) OptionalAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Extract token from Authorization header
-		token := am.extractToken(r)
+		// Extract token from Authorization header or session cookie; a
+		// cookie failing the CSRF check is ignored
+		token, _ := am.extractToken(r)
 		if token != "" {
 			// Validate token and get user
-			user, err := am.authService.ValidateToken(r.Context(), token)
+			user, sessionID, err := am.validateUserToken(r.Context(), token)
 			if err == nil {
-				// Add user to request context
-				ctx := context.WithValue(r.Context(), "user", user)
-				ctx = context.WithValue(ctx, "user_id", user.ID)
-				ctx = context.WithValue(ctx, "is_admin", user.IsAdmin)
-				r = r.WithContext(ctx)
+				r = r.WithContext(withSession(withUser(r.Context(), user), sessionID))
 			}
 		}
 
@@ -89,20 +154,170 @@ func (am *AuthMiddleware) OptionalAuth(next http.Handler) http.Handler {
 	})
 }
 
-// extractToken extracts JWT token from Authorization header
-func (am *AuthMiddleware) extractToken(r *http.Request) string {
+// RequireScope middleware that requires a user or a service account granted
+// scope. Service accounts may present a scoped token, an X-API-Key header or
+// a verified TLS client certificate; users are not scope-limited.
+func (am *AuthMiddleware) RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ctx, err := am.authenticatePrincipal(r)
+			if stderrors.Is(err, auth.ErrCSRFTokenInvalid) {
+				am.logger.Warn("Rejected cookie-authenticated %s %s: %v", r.Method, r.URL.Path, err)
+				errors.WriteErrorResponse(w, http.StatusForbidden, "CSRF token missing or invalid", "CSRF_TOKEN_INVALID")
+				return
+			}
+			if err != nil {
+				am.logger.Error("Authentication failed: %v", err)
+				errors.WriteErrorResponse(w, http.StatusUnauthorized, "Authentication required", "INVALID_CREDENTIALS")
+				return
+			}
+			if !principal.HasScope(scope) {
+				am.logger.Warn("Scope %s denied to %s", scope, principal.AuditLabel())
+				errors.WriteErrorResponse(w, http.StatusForbidden, "Missing required scope: "+scope, "SCOPE_REQUIRED")
+				return
+			}
+			if principal.IsServiceAccount() {
+				am.logger.Info("%s %s by %s (%s)", r.Method, r.URL.Path, principal.AuditLabel(), principal.Name)
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// authenticatePrincipal identifies the user or service account behind a request
+func (am *AuthMiddleware) authenticatePrincipal(r *http.Request) (*auth.Principal, context.Context, error) {
+	ctx := r.Context()
+	token, err := am.extractToken(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if am.usesBreakGlass(r) {
+		ctx, err := am.authenticateBreakGlass(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		principal, _ := GetPrincipalFromContext(ctx)
+		return principal, ctx, nil
+	}
+
+	if am.serviceAccounts != nil {
+		var account *models.ServiceAccount
+		var err error
+		switch {
+		case token != "":
+			principal, err := am.serviceAccounts.ValidateToken(ctx, token)
+			if err == nil {
+				return principal, context.WithValue(ctx, "principal", principal), nil
+			}
+		case r.Header.Get("X-API-Key") != "":
+			account, err = am.serviceAccounts.AuthenticateAPIKey(ctx, r.Header.Get("X-API-Key"))
+		case r.TLS != nil && len(r.TLS.VerifiedChains) > 0:
+			account, err = am.serviceAccounts.AuthenticateCertificate(ctx, r.TLS.PeerCertificates[0])
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if account != nil {
+			principal := auth.AccountPrincipal(account)
+			return principal, context.WithValue(ctx, "principal", principal), nil
+		}
+	}
+
+	if token == "" {
+		return nil, nil, fmt.Errorf("no credentials provided")
+	}
+	user, sessionID, err := am.validateUserToken(ctx, token)
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx = withSession(withUser(ctx, user), sessionID)
+	principal, _ := GetPrincipalFromContext(ctx)
+	return principal, ctx, nil
+}
+
+// validateUserToken returns the user a token authenticates and the login
+// session it is bound to, checking it with the external identity provider
+// when the provider issued it. Tokens bound to a session that has expired
+// fail with auth.ErrSessionExpired.
+func (am *AuthMiddleware) validateUserToken(ctx context.Context, token string) (*models.User, string, error) {
+	if am.externalIdP != nil && am.externalIdP.Issues(token) {
+		user, err := am.externalIdP.ValidateToken(ctx, token)
+		return user, "", err
+	}
+	return am.authService.Authenticate(ctx, token)
+}
+
+// usesBreakGlass reports whether a request presents a break-glass token
+func (am *AuthMiddleware) usesBreakGlass(r *http.Request) bool {
+	return am.breakGlass != nil && r.Header.Get(breakglass.Header) != ""
+}
+
+// authenticateBreakGlass verifies and records a break-glass request, acting
+// as an administrator attributed to the grant
+func (am *AuthMiddleware) authenticateBreakGlass(r *http.Request) (context.Context, error) {
+	grant, err := am.breakGlass.Authenticate(r.Context(), r.Header.Get(breakglass.Header), breakglass.Access{
+		Method:    r.Method,
+		Path:      r.URL.Path,
+		IPAddress: clientip.Get(r),
+		UserAgent: r.UserAgent(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	ctx := withUser(r.Context(), breakglass.User(grant))
+	return context.WithValue(ctx, "principal", auth.BreakGlassPrincipal(grant)), nil
+}
+
+// withUser adds an authenticated user to a request context
+func withUser(ctx context.Context, user *models.User) context.Context {
+	ctx = context.WithValue(ctx, "user", user)
+	ctx = context.WithValue(ctx, "user_id", user.ID)
+	ctx = context.WithValue(ctx, "is_admin", user.IsAdmin)
+	ctx = context.WithValue(ctx, "role", rbac.RoleOf(user))
+	ctx = reporting.WithScope(ctx, reporting.Scope{UserID: user.ID})
+	usage.SetAccount(ctx, user.ID)
+	return context.WithValue(ctx, "principal", auth.UserPrincipal(user))
+}
+
+// withSession adds the login session a request's token is bound to, read
+// by logout
+func withSession(ctx context.Context, sessionID string) context.Context {
+	if sessionID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, "session_id", sessionID)
+}
+
+// extractToken extracts JWT token from Authorization header, falling back
+// to the session cookie. A cookie token fails with auth.ErrCSRFTokenInvalid
+// on requests that change state without echoing the CSRF token.
+func (am *AuthMiddleware) extractToken(r *http.Request) (string, error) {
 	authHeader := r.Header.Get("Authorization")
 	if authHeader == "" {
-		return ""
+		return am.cookieToken(r)
 	}
 
 	// Check for "Bearer " prefix
 	const bearerPrefix = "Bearer "
 	if !strings.HasPrefix(authHeader, bearerPrefix) {
-		return ""
+		return "", nil
 	}
 
-	return strings.TrimPrefix(authHeader, bearerPrefix)
+	return strings.TrimPrefix(authHeader, bearerPrefix), nil
+}
+
+// cookieToken returns the token in the session cookie, if enabled
+func (am *AuthMiddleware) cookieToken(r *http.Request) (string, error) {
+	if am.cookies == nil {
+		return "", nil
+	}
+	token := am.cookies.Token(r)
+	if token != "" && !am.cookies.VerifyCSRF(r) {
+		return "", auth.ErrCSRFTokenInvalid
+	}
+	return token, nil
 }
 
 // GetUserFromContext extracts user from request context
@@ -117,6 +332,28 @@ func GetUserIDFromContext(ctx context.Context) (uint, bool) {
 	return userID, ok
 }
 
+// GetPrincipalFromContext extracts the acting user or service account from request context
+func GetPrincipalFromContext(ctx context.Context) (*auth.Principal, bool) {
+	principal, ok := ctx.Value("principal").(*auth.Principal)
+	return principal, ok
+}
+
+// GetRoleFromContext extracts the user's staff role from request context
+func GetRoleFromContext(ctx context.Context) string {
+	if role, ok := ctx.Value("role").(string); ok {
+		return role
+	}
+	if IsAdminFromContext(ctx) {
+		return rbac.RoleAdmin
+	}
+	return rbac.RoleNone
+}
+
+// HasPermissionFromContext checks if the user's staff role grants permission
+func HasPermissionFromContext(ctx context.Context, permission rbac.Permission) bool {
+	return rbac.Can(GetRoleFromContext(ctx), permission)
+}
+
 // IsAdminFromContext checks if user is admin from request context
 func IsAdminFromContext(ctx context.Context) bool {
 	isAdmin, ok := ctx.Value("is_admin").(bool)
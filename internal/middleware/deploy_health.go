@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"go-server/internal/deployhealth"
+)
+
+// DeployHealthMiddleware records each response's status and latency for
+// the deploy controller while the release bakes; afterwards it only
+// passes requests through
+func DeployHealthMiddleware(recorder *deployhealth.Recorder) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !recorder.Recording() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			sr := &statusRecorder{ResponseWriter: w}
+			defer func() {
+				// Panics reach the recovery middleware, which answers 500
+				if err := recover(); err != nil {
+					recorder.Record(http.StatusInternalServerError, time.Since(start))
+					panic(err)
+				}
+				status := sr.statusCode
+				if status == 0 {
+					status = http.StatusOK
+				}
+				recorder.Record(status, time.Since(start))
+			}()
+			next.ServeHTTP(sr, r)
+		})
+	}
+}
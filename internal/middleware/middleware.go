@@ -2,15 +2,18 @@ package middleware
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/hex"
 	"fmt"
 	"net/http"
 	"time"
 
+	"go-server/internal/compress"
 	"go-server/internal/config"
+	"go-server/internal/cors"
+	"go-server/internal/database/replicas"
 	"go-server/internal/errors"
+	"go-server/internal/idgen"
 	"go-server/internal/interfaces"
+	"go-server/internal/secheaders"
 )
 
 // RequestIDKey is the context key for request ID
@@ -31,14 +34,16 @@ func Chain(middlewares ...Middleware) Middleware {
 
 // RequestIDMiddleware adds a unique request ID to each request
 func RequestIDMiddleware() Middleware {
+	return RequestIDMiddlewareWithGenerator(idgen.Default)
+}
+
+// RequestIDMiddlewareWithGenerator adds a request ID produced by the given generator
+func RequestIDMiddlewareWithGenerator(generator idgen.IDGenerator) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			requestID := r.Header.Get("X-Request-ID")
 			if requestID == "" {
-				// Generate a new request ID
-				bytes := make([]byte, 16)
-				rand.Read(bytes)
-				requestID = hex.EncodeToString(bytes)
+				requestID = generator.NewID()
 			}
 
 			// Add request ID to context
@@ -74,48 +79,125 @@ func LoggingMiddleware(logger interfaces.Logger) Middleware {
 	}
 }
 
-// CORSMiddleware handles CORS headers
-func CORSMiddleware(cfg *config.Config) Middleware {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if cfg.Security.EnableCORS {
-				origin := r.Header.Get("Origin")
-
-				// Check if origin is allowed
-				if isOriginAllowed(origin, cfg.Security.CORSOrigins) {
-					w.Header().Set("Access-Control-Allow-Origin", origin)
-				} else if contains(cfg.Security.CORSOrigins, "*") {
-					w.Header().Set("Access-Control-Allow-Origin", "*")
-				}
-
-				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID")
-				w.Header().Set("Access-Control-Max-Age", "86400")
-			}
+// NewCORSEngine builds the CORS engine described by the security config:
+// CORS_ORIGINS for routes without a group and CORS_ROUTE_GROUPS for the rest
+func NewCORSEngine(cfg *config.Config) (*cors.Engine, error) {
+	policy := corsPolicy(cfg)
+	groups, err := cors.ParseGroups(cfg.Security.CORSRouteGroups, policy)
+	if err != nil {
+		return nil, err
+	}
+	return cors.NewEngine(policy).WithGroups(groups), nil
+}
 
-			// Handle preflight requests
-			if r.Method == http.MethodOptions {
-				w.WriteHeader(http.StatusOK)
-				return
-			}
+// ReconfigureCORS applies a reloaded config's CORS origins and route groups
+// to an engine built by NewCORSEngine
+func ReconfigureCORS(engine *cors.Engine, cfg *config.Config) error {
+	policy := corsPolicy(cfg)
+	groups, err := cors.ParseGroups(cfg.Security.CORSRouteGroups, policy)
+	if err != nil {
+		return err
+	}
+	engine.Reconfigure(policy, groups)
+	return nil
+}
 
-			next.ServeHTTP(w, r)
-		})
+// corsPolicy is the default CORS policy from the security config
+func corsPolicy(cfg *config.Config) cors.Policy {
+	policy := cors.DefaultPolicy()
+	policy.AllowedOrigins = cfg.Security.CORSOrigins
+	policy.AllowCredentials = cfg.Security.CORSAllowCredentials
+	if cfg.Security.CORSMaxAge > 0 {
+		policy.MaxAge = cfg.Security.CORSMaxAge
 	}
+	return policy
 }
 
-// SecurityHeadersMiddleware adds security headers
+// CORSMiddleware handles CORS headers. Use CORSMiddlewareWithEngine to keep
+// hold of the engine for its metrics.
+func CORSMiddleware(cfg *config.Config) Middleware {
+	if !cfg.Security.EnableCORS {
+		return corsDisabled
+	}
+	engine, err := NewCORSEngine(cfg)
+	if err != nil {
+		// Config.Validate rejects bad route groups; fall back to the default policy
+		engine = cors.NewEngine(corsPolicy(cfg))
+	}
+	return CORSMiddlewareWithEngine(engine)
+}
+
+// CORSMiddlewareWithEngine applies the policies of a CORS engine
+func CORSMiddlewareWithEngine(engine *cors.Engine) Middleware {
+	return Middleware(engine.Middleware())
+}
+
+// corsDisabled still answers preflight requests, without any CORS headers,
+// so browsers get a definite refusal
+func corsDisabled(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// SecurityHeadersMiddleware adds the default API security headers. Use
+// NewSecurityHeaders for the configured policy and the docs profile.
 func SecurityHeadersMiddleware() Middleware {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("X-Content-Type-Options", "nosniff")
-			w.Header().Set("X-Frame-Options", "DENY")
-			w.Header().Set("X-XSS-Protection", "1; mode=block")
-			w.Header().Set("Referrer-Policy", "strict-origin-when-cross-origin")
-			w.Header().Set("Content-Security-Policy", "default-src 'self'")
+	profile := secheaders.APIProfile(secheaders.NewPolicy().DefaultSrc(secheaders.Self), secheaders.HSTS{})
+	return SecurityHeadersMiddlewareWithEngine(secheaders.NewEngine(profile))
+}
 
-			next.ServeHTTP(w, r)
-		})
+// DocsPathPrefix is where the documentation viewers are served, which get
+// the docs security header profile
+const DocsPathPrefix = "/docs"
+
+// NewSecurityHeaders builds the security headers engine described by the
+// security config: CONTENT_SECURITY_POLICY and HSTS for the API, and the
+// docs profile under DocsPathPrefix
+func NewSecurityHeaders(cfg *config.Config) (*secheaders.Engine, error) {
+	profile, err := apiHeaderProfile(cfg)
+	if err != nil {
+		return nil, err
+	}
+	docs := secheaders.DocsProfile(hstsPolicy(cfg))
+	return secheaders.NewEngine(profile).WithRoute(DocsPathPrefix, docs), nil
+}
+
+// ReconfigureSecurityHeaders applies a reloaded config's content security
+// policy to an engine built by NewSecurityHeaders
+func ReconfigureSecurityHeaders(engine *secheaders.Engine, cfg *config.Config) error {
+	profile, err := apiHeaderProfile(cfg)
+	if err != nil {
+		return err
+	}
+	engine.Reconfigure(profile)
+	return nil
+}
+
+// SecurityHeadersMiddlewareWithEngine applies the profiles of a security
+// headers engine
+func SecurityHeadersMiddlewareWithEngine(engine *secheaders.Engine) Middleware {
+	return Middleware(engine.Middleware())
+}
+
+// apiHeaderProfile is the API security header profile from the security config
+func apiHeaderProfile(cfg *config.Config) (secheaders.Profile, error) {
+	csp, err := secheaders.ParsePolicy(cfg.Security.ContentSecurityPolicy)
+	if err != nil {
+		return secheaders.Profile{}, err
+	}
+	return secheaders.APIProfile(csp, hstsPolicy(cfg)), nil
+}
+
+func hstsPolicy(cfg *config.Config) secheaders.HSTS {
+	return secheaders.HSTS{
+		MaxAge:            cfg.Security.HSTSMaxAge,
+		IncludeSubDomains: cfg.Security.HSTSIncludeSubDomains,
+		Preload:           cfg.Security.HSTSPreload,
 	}
 }
 
@@ -142,20 +224,30 @@ func RequestSizeMiddleware(cfg *config.Config) Middleware {
 	}
 }
 
-// RecoveryMiddleware recovers from panics
-func RecoveryMiddleware(logger interfaces.Logger) Middleware {
+// CompressionMiddleware gzips responses for clients that accept it. It
+// must wrap the handlers that set ETags and serve byte ranges, so it sees
+// their final headers, and sit inside the logging and recovery middleware.
+func CompressionMiddleware(cfg *config.Config) Middleware {
+	if !cfg.Compress.Enabled {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	opts := compress.OptionsFromConfig(cfg.Compress)
 	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			defer func() {
-				if err := recover(); err != nil {
-					requestID := GetRequestID(r.Context())
-					logger.Error("Panic recovered: %v (ID: %s)", err, requestID)
-
-					apiErr := errors.ErrInternal.WithRequestID(requestID)
-					writeErrorResponse(w, apiErr)
-				}
-			}()
+		return compress.Handler(next, opts)
+	}
+}
 
+// PrimaryReadsMiddleware sends every query made while handling a request
+// that may write to the primary database, so a handler reading back what
+// it just wrote never reads from a lagging replica
+func PrimaryReadsMiddleware() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+			default:
+				r = r.WithContext(replicas.WithPrimary(r.Context()))
+			}
 			next.ServeHTTP(w, r)
 		})
 	}
@@ -171,36 +263,9 @@ func GetRequestID(ctx context.Context) string {
 	return ""
 }
 
-// isOriginAllowed checks if an origin is in the allowed list
-func isOriginAllowed(origin string, allowedOrigins []string) bool {
-	for _, allowed := range allowedOrigins {
-		if origin == allowed {
-			return true
-		}
-	}
-	return false
-}
-
-// contains checks if a slice contains a string
-func contains(slice []string, item string) bool {
-	for _, s := range slice {
-		if s == item {
-			return true
-		}
-	}
-	return false
-}
-
-// writeErrorResponse writes an error response
+// writeErrorResponse writes an error response in the configured format
 func writeErrorResponse(w http.ResponseWriter, err *errors.APIError) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(err.StatusCode)
-
-	// In a real implementation, you'd use json.Marshal
-	// For now, we'll write a simple error response
-	response := fmt.Sprintf(`{"error": {"type": "%s", "message": "%s"}}`,
-		err.Type, err.Message)
-	w.Write([]byte(response))
+	errors.WriteError(w, err)
 }
 
 // responseWriter wraps http.ResponseWriter to capture status code
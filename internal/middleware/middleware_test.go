@@ -3,9 +3,12 @@ package middleware
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"go-server/internal/config"
+	"go-server/internal/database/replicas"
+	"go-server/internal/idgen"
 	"go-server/internal/logger"
 )
 
@@ -48,6 +51,20 @@ func TestRequestIDMiddlewareWithExistingID(t *testing.T) {
 	}
 }
 
+func TestRequestIDMiddlewareWithGenerator(t *testing.T) {
+	handler := RequestIDMiddlewareWithGenerator(idgen.NewSequence("req"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, expected := range []string{"req-1", "req-2"} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		if got := w.Header().Get("X-Request-ID"); got != expected {
+			t.Errorf("Expected request ID %s, got %s", expected, got)
+		}
+	}
+}
+
 func TestLoggingMiddleware(t *testing.T) {
 	logger := logger.NewServerLogger()
 	handler := LoggingMiddleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -177,6 +194,20 @@ func TestRequestSizeMiddleware(t *testing.T) {
 	}
 }
 
+func TestPrimaryReadsMiddleware(t *testing.T) {
+	var pinned bool
+	handler := PrimaryReadsMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pinned = replicas.UsesPrimary(r.Context())
+	}))
+
+	for method, want := range map[string]bool{"GET": false, "HEAD": false, "POST": true, "DELETE": true} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/", nil))
+		if pinned != want {
+			t.Errorf("Expected %s requests pinned to the primary to be %v, got %v", method, want, pinned)
+		}
+	}
+}
+
 func TestRecoveryMiddleware(t *testing.T) {
 	logger := logger.NewServerLogger()
 	handler := RecoveryMiddleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -225,3 +256,28 @@ func TestChain(t *testing.T) {
 		t.Error("Second middleware should be applied")
 	}
 }
+
+func TestCompressionMiddleware(t *testing.T) {
+	body := strings.Repeat(`{"title": "Hello, world"},`, 100)
+	serve := func(cfg *config.Config) *httptest.ResponseRecorder {
+		handler := CompressionMiddleware(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(body))
+		}))
+		req := httptest.NewRequest("GET", "/api/posts", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(&config.Config{Compress: config.CompressionConfig{Enabled: true, MinSize: 1024, Level: -1}})
+	if w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("Vary") != "Accept-Encoding" {
+		t.Errorf("Expected a compressed response varying on Accept-Encoding, got %v", w.Header())
+	}
+
+	w = serve(&config.Config{})
+	if w.Header().Get("Content-Encoding") != "" || w.Body.String() != body {
+		t.Error("Expected the response untouched when compression is disabled")
+	}
+}
@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/errors"
+	"go-server/internal/interfaces"
+	"go-server/internal/reporting"
+	"go-server/internal/usage"
+)
+
+// panicWindow is the period over which a route's panics are counted
+const panicWindow = time.Minute
+
+// PanicGuard isolates routes that keep panicking. The recovery middleware
+// records each recovered panic against the request's route, named as
+// usage.Feature names it, e.g. "GET /api/posts/{id}". A route with more
+// panics in a minute than the threshold is disabled: it answers 503 until
+// an administrator re-enables it, so one broken handler takes down its own
+// feature rather than flooding the logs and the error tracker, and the
+// rest of the API keeps serving.
+type PanicGuard struct {
+	threshold int
+	clock     clock.Clock
+	logger    interfaces.Logger
+	reporter  reporting.ErrorReporter
+
+	mutex    sync.Mutex
+	recent   map[string][]time.Time
+	totals   map[string]int64
+	disabled map[string]DisabledRoute
+}
+
+// DisabledRoute is a route the guard has disabled
+type DisabledRoute struct {
+	Route      string    `json:"route"`
+	DisabledAt time.Time `json:"disabled_at"`
+	// Panics is how many panics in the last minute disabled the route, and
+	// LastPanic the last of them
+	Panics    int    `json:"panics"`
+	LastPanic string `json:"last_panic"`
+}
+
+// PanicStats is a snapshot of the guard's counters
+type PanicStats struct {
+	// Panics counts the recovered panics of each route since startup
+	Panics   map[string]int64 `json:"panics"`
+	Disabled []DisabledRoute  `json:"disabled"`
+}
+
+// NewPanicGuard creates a guard disabling routes with more than threshold
+// panics in a minute; with a zero threshold it only counts them
+func NewPanicGuard(threshold int, logger interfaces.Logger) *PanicGuard {
+	return &PanicGuard{
+		threshold: threshold,
+		clock:     clock.New(),
+		logger:    logger,
+		recent:    make(map[string][]time.Time),
+		totals:    make(map[string]int64),
+		disabled:  make(map[string]DisabledRoute),
+	}
+}
+
+// WithClock sets the time source for the panic window
+func (g *PanicGuard) WithClock(c clock.Clock) *PanicGuard {
+	g.clock = clock.OrDefault(c)
+	return g
+}
+
+// WithReporter sends an alert to reporter when a route is disabled
+func (g *PanicGuard) WithReporter(reporter reporting.ErrorReporter) *PanicGuard {
+	g.reporter = reporter
+	return g
+}
+
+// Route names the route of a request
+func (g *PanicGuard) Route(r *http.Request) string {
+	return usage.Feature(r.Method, r.URL.Path)
+}
+
+// RecordPanic counts a panic recovered on route, disabling the route once
+// it crosses the threshold. It reports whether this panic disabled it.
+func (g *PanicGuard) RecordPanic(ctx context.Context, route string, recovered error) bool {
+	g.mutex.Lock()
+	now := g.clock.Now()
+	g.totals[route]++
+	recent := clock.PruneBefore(g.recent[route], now.Add(-panicWindow))
+	recent = append(recent, now)
+	g.recent[route] = recent
+
+	_, alreadyDisabled := g.disabled[route]
+	if g.threshold <= 0 || alreadyDisabled || len(recent) <= g.threshold {
+		g.mutex.Unlock()
+		return false
+	}
+	disabled := DisabledRoute{
+		Route:      route,
+		DisabledAt: now,
+		Panics:     len(recent),
+		LastPanic:  recovered.Error(),
+	}
+	g.disabled[route] = disabled
+	delete(g.recent, route)
+	g.mutex.Unlock()
+
+	g.logger.Error("Disabled route %s after %d panics in %s; re-enable it with DELETE /api/admin/routes/disabled",
+		route, disabled.Panics, panicWindow)
+	if g.reporter != nil {
+		g.reporter.Report(ctx, reporting.Report{
+			Err:   fmt.Errorf("route %s disabled after %d panics in %s: %w", route, disabled.Panics, panicWindow, recovered),
+			Level: reporting.LevelFatal,
+			Tags:  map[string]string{"alert": "route_disabled", "route": route},
+		})
+	}
+	return true
+}
+
+// Disabled reports whether route is disabled
+func (g *PanicGuard) Disabled(route string) (DisabledRoute, bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	disabled, ok := g.disabled[route]
+	return disabled, ok
+}
+
+// Enable re-enables a disabled route with a fresh panic count. It reports
+// whether the route was disabled.
+func (g *PanicGuard) Enable(route string) bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if _, ok := g.disabled[route]; !ok {
+		return false
+	}
+	delete(g.disabled, route)
+	delete(g.recent, route)
+	return true
+}
+
+// DisabledRoutes lists the disabled routes, most recently disabled first
+func (g *PanicGuard) DisabledRoutes() []DisabledRoute {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	routes := make([]DisabledRoute, 0, len(g.disabled))
+	for _, disabled := range g.disabled {
+		routes = append(routes, disabled)
+	}
+	sort.Slice(routes, func(i, j int) bool { return routes[i].DisabledAt.After(routes[j].DisabledAt) })
+	return routes
+}
+
+// Stats returns a snapshot of the panic counters and disabled routes
+func (g *PanicGuard) Stats() PanicStats {
+	disabled := g.DisabledRoutes()
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	stats := PanicStats{Panics: make(map[string]int64, len(g.totals)), Disabled: disabled}
+	for route, count := range g.totals {
+		stats.Panics[route] = count
+	}
+	return stats
+}
+
+// refuse answers 503 for a disabled route, reporting whether it did
+func (g *PanicGuard) refuse(w http.ResponseWriter, r *http.Request) bool {
+	if _, disabled := g.Disabled(g.Route(r)); !disabled {
+		return false
+	}
+	w.Header().Set("Retry-After", "60")
+	writeErrorResponse(w, errors.NewAPIErrorWithCode(errors.ErrorTypeInternal, "ROUTE_DISABLED",
+		"This endpoint is temporarily disabled", http.StatusServiceUnavailable).WithRequestID(GetRequestID(r.Context())))
+	return true
+}
@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime/debug"
+
+	"go-server/internal/errors"
+	"go-server/internal/interfaces"
+	"go-server/internal/reporting"
+)
+
+// RecoveryMiddleware recovers from panics, logging them with their stack
+func RecoveryMiddleware(logger interfaces.Logger) Middleware {
+	return RecoveryMiddlewareWithReporter(logger, nil)
+}
+
+// RecoveryMiddlewareWithReporter recovers from panics like RecoveryMiddleware
+// and also sends panics and 5xx responses to reporter. It attaches a
+// reporting scope to the request so reports made further down the chain,
+// e.g. by the auth service, carry the request ID and route.
+func RecoveryMiddlewareWithReporter(logger interfaces.Logger, reporter reporting.ErrorReporter) Middleware {
+	return RecoveryMiddlewareWithGuard(logger, reporter, nil)
+}
+
+// RecoveryMiddlewareWithGuard recovers from panics like
+// RecoveryMiddlewareWithReporter and counts them per route in guard, which
+// may be nil. Routes the guard has disabled answer 503 without reaching
+// their handler.
+func RecoveryMiddlewareWithGuard(logger interfaces.Logger, reporter reporting.ErrorReporter, guard *PanicGuard) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if guard != nil && guard.refuse(w, r) {
+				return
+			}
+			r = r.WithContext(reporting.WithScope(r.Context(), reporting.Scope{
+				RequestID: GetRequestID(r.Context()),
+				Method:    r.Method,
+				Path:      r.URL.Path,
+			}))
+			recorder := &statusRecorder{ResponseWriter: w}
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					if reporter != nil && recorder.statusCode >= http.StatusInternalServerError {
+						reporter.Report(r.Context(), newErrorReport(r, recorder.statusCode,
+							fmt.Errorf("%d %s", recorder.statusCode, http.StatusText(recorder.statusCode))))
+					}
+					return
+				}
+				// ErrAbortHandler deliberately aborts the response; let net/http handle it
+				if recovered == http.ErrAbortHandler {
+					panic(recovered)
+				}
+
+				err, ok := recovered.(error)
+				if !ok {
+					err = fmt.Errorf("panic: %v", recovered)
+				}
+				report := newErrorReport(r, http.StatusInternalServerError, err)
+				report.Panic = true
+				report.Stack = debug.Stack()
+				report.Frames = reporting.CaptureFrames(1)
+
+				logger.Error("Panic recovered: %v (ID: %s, %s %s)\n%s",
+					recovered, report.RequestID, r.Method, r.URL.Path, report.Stack)
+				if reporter != nil {
+					reporter.Report(r.Context(), report)
+				}
+				if guard != nil {
+					guard.RecordPanic(r.Context(), guard.Route(r), err)
+				}
+
+				// Too late to send an error once the handler has started its response
+				if recorder.statusCode != 0 {
+					return
+				}
+				writeErrorResponse(w, errors.ErrInternal.WithRequestID(report.RequestID).WithCause(err))
+			}()
+
+			next.ServeHTTP(recorder, r)
+		})
+	}
+}
+
+func newErrorReport(r *http.Request, statusCode int, err error) reporting.Report {
+	report := reporting.Report{
+		Err:        err,
+		StatusCode: statusCode,
+		RequestID:  GetRequestID(r.Context()),
+		Method:     r.Method,
+		Path:       r.URL.Path,
+	}
+	if userID, ok := GetUserIDFromContext(r.Context()); ok {
+		report.UserID = userID
+	}
+	return reporting.Enrich(r.Context(), report)
+}
+
+// statusRecorder captures the response status while keeping streaming and
+// WebSocket upgrades working through Flush and Hijack
+type statusRecorder struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (sr *statusRecorder) WriteHeader(code int) {
+	if sr.statusCode == 0 {
+		sr.statusCode = code
+	}
+	sr.ResponseWriter.WriteHeader(code)
+}
+
+func (sr *statusRecorder) Write(b []byte) (int, error) {
+	if sr.statusCode == 0 {
+		sr.statusCode = http.StatusOK
+	}
+	return sr.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher
+func (sr *statusRecorder) Flush() {
+	http.NewResponseController(sr.ResponseWriter).Flush()
+}
+
+// Hijack implements http.Hijacker
+func (sr *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(sr.ResponseWriter).Hijack()
+	if err == nil && sr.statusCode == 0 {
+		sr.statusCode = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Unwrap lets http.NewResponseController reach the underlying writer
+func (sr *statusRecorder) Unwrap() http.ResponseWriter {
+	return sr.ResponseWriter
+}
@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/logger"
+	"go-server/internal/reporting"
+)
+
+func panickingHandler(w http.ResponseWriter, r *http.Request) {
+	panic("boom")
+}
+
+func TestRecoveryMiddlewareWithReporter_ReportsPanicWithStack(t *testing.T) {
+	var reports []reporting.Report
+	reporter := reporting.ReporterFunc(func(ctx context.Context, report reporting.Report) {
+		reports = append(reports, report)
+	})
+	handler := Chain(RequestIDMiddleware(), RecoveryMiddlewareWithReporter(logger.NewServerLogger(), reporter))(http.HandlerFunc(panickingHandler))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/posts", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected 500, got %d", w.Code)
+	}
+	if strings.Contains(w.Body.String(), "boom") {
+		t.Errorf("Panic value leaked into the response: %s", w.Body.String())
+	}
+	if len(reports) != 1 {
+		t.Fatalf("Expected 1 report, got %d", len(reports))
+	}
+	report := reports[0]
+	if !report.Panic || report.Err.Error() != "panic: boom" || report.Method != http.MethodPost || report.Path != "/api/posts" {
+		t.Errorf("Report = %+v", report)
+	}
+	if report.RequestID == "" || report.RequestID != w.Header().Get("X-Request-ID") {
+		t.Errorf("Report should carry the request ID, got %q", report.RequestID)
+	}
+	if len(report.Frames) == 0 || !strings.HasSuffix(report.Frames[0].Function, "panickingHandler") {
+		t.Errorf("Stack should start at the panicking function, got %+v", report.Frames)
+	}
+	if !strings.Contains(string(report.Stack), "panickingHandler") {
+		t.Error("Formatted stack should name the panicking function")
+	}
+}
+
+func TestRecoveryMiddlewareWithReporter_Reports5xxResponses(t *testing.T) {
+	var statuses []int
+	reporter := reporting.ReporterFunc(func(ctx context.Context, report reporting.Report) {
+		statuses = append(statuses, report.StatusCode)
+	})
+	middleware := RecoveryMiddlewareWithReporter(logger.NewServerLogger(), reporter)
+
+	for _, status := range []int{http.StatusOK, http.StatusNotFound, http.StatusBadGateway} {
+		handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+
+	if len(statuses) != 1 || statuses[0] != http.StatusBadGateway {
+		t.Errorf("Only 5xx responses should be reported, got %v", statuses)
+	}
+}
+
+func TestRecoveryMiddleware_KeepsStartedResponse(t *testing.T) {
+	handler := RecoveryMiddleware(logger.NewServerLogger())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("partial"))
+		panic("late")
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusAccepted || w.Body.String() != "partial" {
+		t.Errorf("Started response should not be overwritten, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestRecoveryMiddlewareWithGuard_DisablesPanickingRoute(t *testing.T) {
+	var alerts []reporting.Report
+	reporter := reporting.ReporterFunc(func(ctx context.Context, report reporting.Report) {
+		if report.Level == reporting.LevelFatal {
+			alerts = append(alerts, report)
+		}
+	})
+	fake := clock.NewFake(time.Now())
+	guard := NewPanicGuard(2, logger.NewServerLogger()).WithClock(fake).WithReporter(reporter)
+	calls := 0
+	handler := RecoveryMiddlewareWithGuard(logger.NewServerLogger(), reporter, guard)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		panickingHandler(w, r)
+	}))
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	// Panics spread over more than a minute do not add up
+	serve("/api/posts/1")
+	fake.Advance(61 * time.Second)
+	serve("/api/posts/2")
+	serve("/api/posts/3")
+	if _, disabled := guard.Disabled("GET /api/posts/{id}"); disabled || len(alerts) != 0 {
+		t.Fatal("Expected the route enabled at the threshold")
+	}
+
+	serve("/api/posts/4")
+	if _, disabled := guard.Disabled("GET /api/posts/{id}"); !disabled {
+		t.Fatal("Expected the route disabled past the threshold")
+	}
+	if len(alerts) != 1 || alerts[0].Tags["route"] != "GET /api/posts/{id}" {
+		t.Errorf("Expected one alert naming the route, got %+v", alerts)
+	}
+
+	calls = 0
+	w := serve("/api/posts/5")
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "ROUTE_DISABLED") || calls != 0 {
+		t.Errorf("Expected a 503 without calling the handler, got %d %s after %d calls", w.Code, w.Body.String(), calls)
+	}
+	if w := serve("/api/users"); w.Code != http.StatusInternalServerError || calls != 1 {
+		t.Errorf("Expected other routes to keep serving, got %d", w.Code)
+	}
+
+	stats := guard.Stats()
+	if stats.Panics["GET /api/posts/{id}"] != 4 || len(stats.Disabled) != 1 {
+		t.Errorf("Expected 4 panics and one disabled route, got %+v", stats)
+	}
+
+	if !guard.Enable("GET /api/posts/{id}") || guard.Enable("GET /api/posts/{id}") {
+		t.Error("Expected Enable to report whether the route was disabled")
+	}
+	if w := serve("/api/posts/6"); w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected the re-enabled route to reach its handler, got %d", w.Code)
+	}
+}
@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+
+	"go-server/internal/docs"
+	"go-server/internal/errors"
+	"go-server/internal/interfaces"
+)
+
+// Schema validation modes
+const (
+	// SchemaValidationStrict rejects mismatches; meant for development
+	SchemaValidationStrict = "strict"
+	// SchemaValidationLog only logs mismatches; meant for production
+	SchemaValidationLog = "log"
+)
+
+// SchemaValidationMiddleware validates request parameters and JSON bodies, and
+// JSON responses, against the OpenAPI spec. In strict mode a mismatching
+// request is rejected with 400 and a mismatching response is replaced with a
+// 500, both listing the mismatches; in log mode they are only logged. Any
+// other mode disables validation. Routes missing from the spec pass through.
+func SchemaValidationMiddleware(validator *docs.SchemaValidator, mode string, logger interfaces.Logger) Middleware {
+	strict := mode == SchemaValidationStrict
+	if !strict && mode != SchemaValidationLog {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, _, ok := validator.Operation(r.Method, r.URL.Path); !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			requestID := GetRequestID(r.Context())
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				apiErr := errors.NewAPIError(errors.ErrorTypeValidation, "Invalid request", http.StatusBadRequest).
+					WithDetails(err.Error()).WithRequestID(requestID)
+				writeErrorResponse(w, apiErr)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			if problems := validator.ValidateRequest(r, body); len(problems) > 0 {
+				logger.Info("Request does not match API schema: %s %s %v (ID: %s)", r.Method, r.URL.Path, problems, requestID)
+				if strict {
+					writeSchemaErrors(w, errors.NewAPIErrorWithCode(errors.ErrorTypeValidation, "SCHEMA_VALIDATION_FAILED",
+						"Request does not match the API schema", http.StatusBadRequest).WithRequestID(requestID), problems)
+					return
+				}
+			}
+
+			if !validator.DocumentsResponse(r.Method, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			recorder := &schemaResponseWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+			if !recorder.buffering {
+				return
+			}
+
+			problems := validator.ValidateResponse(r.Method, r.URL.Path, recorder.status, w.Header().Get("Content-Type"), recorder.body.Bytes())
+			if len(problems) > 0 {
+				logger.Error("Response does not match API schema: %s %s %d %v (ID: %s)", r.Method, r.URL.Path, recorder.status, problems, requestID)
+				if strict {
+					w.Header().Del("Content-Length")
+					writeSchemaErrors(w, errors.NewAPIErrorWithCode(errors.ErrorTypeInternal, "SCHEMA_RESPONSE_MISMATCH",
+						"Response does not match the API schema", http.StatusInternalServerError).WithRequestID(requestID), problems)
+					return
+				}
+			}
+			w.WriteHeader(recorder.status)
+			w.Write(recorder.body.Bytes())
+		})
+	}
+}
+
+// writeSchemaErrors writes an API error with the individual mismatches
+func writeSchemaErrors(w http.ResponseWriter, err *errors.APIError, problems []docs.ValidationError) {
+	errors.WriteError(w, err.WithExtension("errors", problems))
+}
+
+// schemaResponseWriter holds back JSON responses until they are validated;
+// anything else, and any response that is flushed, is written straight through
+type schemaResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	buffering   bool
+	body        bytes.Buffer
+}
+
+func (sw *schemaResponseWriter) WriteHeader(code int) {
+	if sw.wroteHeader {
+		return
+	}
+	sw.wroteHeader = true
+	sw.status = code
+	sw.buffering = strings.Contains(sw.Header().Get("Content-Type"), "json")
+	if !sw.buffering {
+		sw.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (sw *schemaResponseWriter) Write(b []byte) (int, error) {
+	if !sw.wroteHeader {
+		sw.WriteHeader(http.StatusOK)
+	}
+	if sw.buffering {
+		return sw.body.Write(b)
+	}
+	return sw.ResponseWriter.Write(b)
+}
+
+// Flush gives up on validating a streamed response
+func (sw *schemaResponseWriter) Flush() {
+	if sw.buffering {
+		sw.buffering = false
+		sw.ResponseWriter.WriteHeader(sw.status)
+		sw.ResponseWriter.Write(sw.body.Bytes())
+		sw.body.Reset()
+	}
+	http.NewResponseController(sw.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (sw *schemaResponseWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-server/internal/docs"
+	"go-server/internal/logger"
+)
+
+type testWidget struct {
+	Name  string `json:"name" validate:"required"`
+	Count int    `json:"count"`
+}
+
+func newTestSchemaValidator() *docs.SchemaValidator {
+	g := docs.NewOpenAPIGenerator("Test API", "1.0.0", "")
+	g.Register(docs.Route{
+		Method: http.MethodPost, Path: "/api/widgets",
+		Request:   testWidget{},
+		Responses: map[int]any{http.StatusCreated: testWidget{}},
+	})
+	return docs.NewSchemaValidator(g.Spec())
+}
+
+func widgetHandler(response string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if len(body) == 0 {
+			http.Error(w, "body was not restored", http.StatusTeapot)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(response))
+	})
+}
+
+func postWidget(handler http.Handler, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/widgets", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestSchemaValidationMiddlewareStrict(t *testing.T) {
+	mw := SchemaValidationMiddleware(newTestSchemaValidator(), SchemaValidationStrict, logger.NewServerLogger())
+
+	w := postWidget(mw(widgetHandler(`{"name": "a", "count": 1}`)), `{"name": "a", "count": 1}`)
+	if w.Code != http.StatusCreated || w.Body.String() != `{"name": "a", "count": 1}` {
+		t.Errorf("valid exchange altered: %d %s", w.Code, w.Body.String())
+	}
+
+	w = postWidget(mw(widgetHandler(`{}`)), `{"count": "one"}`)
+	var response struct {
+		Code   string                 `json:"code"`
+		Errors []docs.ValidationError `json:"errors"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != http.StatusBadRequest || response.Code != "SCHEMA_VALIDATION_FAILED" || len(response.Errors) != 2 {
+		t.Errorf("invalid request not rejected: %d %s", w.Code, w.Body.String())
+	}
+
+	w = postWidget(mw(widgetHandler(`{"count": 1}`)), `{"name": "a"}`)
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != http.StatusInternalServerError || response.Code != "SCHEMA_RESPONSE_MISMATCH" ||
+		len(response.Errors) != 1 || response.Errors[0].Field != "name" {
+		t.Errorf("invalid response not replaced: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestSchemaValidationMiddlewareLogOnly(t *testing.T) {
+	mw := SchemaValidationMiddleware(newTestSchemaValidator(), SchemaValidationLog, logger.NewServerLogger())
+
+	w := postWidget(mw(widgetHandler(`{"count": 1}`)), `{"count": "one"}`)
+	if w.Code != http.StatusCreated || w.Body.String() != `{"count": 1}` {
+		t.Errorf("log mode should not alter the exchange: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestSchemaValidationMiddlewareDisabled(t *testing.T) {
+	handler := widgetHandler(`{}`)
+	mw := SchemaValidationMiddleware(newTestSchemaValidator(), "off", logger.NewServerLogger())
+
+	if w := postWidget(mw(handler), `{}`); w.Code != http.StatusCreated {
+		t.Errorf("disabled validation should pass through: %d", w.Code)
+	}
+}
@@ -0,0 +1,185 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	stderrors "errors"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go-server/internal/config"
+	"go-server/internal/errors"
+	"go-server/internal/interfaces"
+)
+
+// Timeouts bounds how long handlers may run. A request's context is
+// cancelled at its route's deadline, which stops the database and Redis
+// calls made with it, and the client gets a 504. Path prefixes override the
+// default timeout by longest match; a zero timeout leaves a route unbounded,
+// as streaming endpoints must be.
+type Timeouts struct {
+	mu             sync.RWMutex
+	defaultTimeout time.Duration
+	routes         []routeTimeout
+	logger         interfaces.Logger
+}
+
+type routeTimeout struct {
+	prefix  string
+	timeout time.Duration
+}
+
+// NewTimeouts creates request timeouts with a default and per-prefix
+// overrides
+func NewTimeouts(defaultTimeout time.Duration, routes map[string]time.Duration) *Timeouts {
+	t := &Timeouts{}
+	t.Reconfigure(defaultTimeout, routes)
+	return t
+}
+
+// NewTimeoutsFromConfig builds the request timeouts described by the
+// server config: REQUEST_TIMEOUT and REQUEST_TIMEOUT_ROUTES
+func NewTimeoutsFromConfig(cfg *config.Config) (*Timeouts, error) {
+	routes, err := config.ParseRouteTimeouts(cfg.Server.RouteTimeouts)
+	if err != nil {
+		return nil, err
+	}
+	return NewTimeouts(cfg.Server.RequestTimeout, routes), nil
+}
+
+// WithLogger logs requests that time out
+func (t *Timeouts) WithLogger(logger interfaces.Logger) *Timeouts {
+	t.logger = logger
+	return t
+}
+
+// Reconfigure replaces the default timeout and route overrides
+func (t *Timeouts) Reconfigure(defaultTimeout time.Duration, routes map[string]time.Duration) {
+	sorted := make([]routeTimeout, 0, len(routes))
+	for prefix, timeout := range routes {
+		sorted = append(sorted, routeTimeout{prefix: prefix, timeout: timeout})
+	}
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i].prefix) > len(sorted[j].prefix) })
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.defaultTimeout = defaultTimeout
+	t.routes = sorted
+}
+
+// For returns the timeout for a path, zero if it has none
+func (t *Timeouts) For(path string) time.Duration {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for _, route := range t.routes {
+		if strings.HasPrefix(path, route.prefix) {
+			return route.timeout
+		}
+	}
+	return t.defaultTimeout
+}
+
+// Middleware runs handlers under their route's timeout. The handler's
+// response is buffered so a late write cannot interleave with the 504;
+// connection upgrades are passed through untouched.
+func (t *Timeouts) Middleware() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout := t.For(r.URL.Path)
+			if timeout <= 0 || r.Header.Get("Upgrade") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			tw := &timeoutWriter{header: make(http.Header)}
+			done := make(chan struct{})
+			panicked := make(chan interface{}, 1)
+			go func() {
+				defer func() {
+					if recovered := recover(); recovered != nil {
+						panicked <- recovered
+						return
+					}
+					close(done)
+				}()
+				next.ServeHTTP(tw, r.WithContext(ctx))
+			}()
+
+			select {
+			case recovered := <-panicked:
+				// Re-raised on the request goroutine for RecoveryMiddleware
+				panic(recovered)
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				for key, values := range tw.header {
+					w.Header()[key] = values
+				}
+				if tw.statusCode == 0 {
+					tw.statusCode = http.StatusOK
+				}
+				w.WriteHeader(tw.statusCode)
+				w.Write(tw.body.Bytes())
+			case <-ctx.Done():
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				tw.timedOut = true
+				if !stderrors.Is(ctx.Err(), context.DeadlineExceeded) {
+					// The client went away; there is no one to answer
+					return
+				}
+				requestID := GetRequestID(r.Context())
+				if t.logger != nil {
+					t.logger.Error("Request timed out after %v: %s %s (ID: %s)", timeout, r.Method, r.URL.Path, requestID)
+				}
+				writeErrorResponse(w, errors.NewAPIErrorWithCode(errors.ErrorTypeInternal, "REQUEST_TIMEOUT",
+					"The request took too long and was cancelled", http.StatusGatewayTimeout).WithRequestID(requestID))
+			}
+		})
+	}
+}
+
+// TimeoutMiddleware runs every handler under the same timeout
+func TimeoutMiddleware(timeout time.Duration) Middleware {
+	return NewTimeouts(timeout, nil).Middleware()
+}
+
+// timeoutWriter buffers a handler's response until it finishes in time.
+// Writes after the deadline fail with http.ErrHandlerTimeout.
+type timeoutWriter struct {
+	mu         sync.Mutex
+	header     http.Header
+	body       bytes.Buffer
+	statusCode int
+	timedOut   bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.statusCode == 0 && !tw.timedOut {
+		tw.statusCode = code
+	}
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.statusCode == 0 {
+		tw.statusCode = http.StatusOK
+	}
+	return tw.body.Write(b)
+}
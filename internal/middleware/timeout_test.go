@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeouts_CancelsSlowHandlers(t *testing.T) {
+	cancelled := make(chan struct{})
+	handler := TimeoutMiddleware(20 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		close(cancelled)
+		w.Write([]byte("too late"))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/slow", nil))
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Fatalf("Expected 504, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), `"REQUEST_TIMEOUT"`) {
+		t.Errorf("Expected a structured REQUEST_TIMEOUT error, got %s", rec.Body.String())
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("The handler's context should be cancelled")
+	}
+	if strings.Contains(rec.Body.String(), "too late") {
+		t.Error("Writes after the deadline must not reach the client")
+	}
+}
+
+func TestTimeouts_PassesFastResponses(t *testing.T) {
+	handler := TimeoutMiddleware(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); !ok {
+			t.Error("The handler's context should carry the deadline")
+		}
+		w.Header().Set("X-Test", "yes")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/posts", nil))
+	if rec.Code != http.StatusCreated || rec.Body.String() != "created" || rec.Header().Get("X-Test") != "yes" {
+		t.Errorf("Unexpected response %d %q %v", rec.Code, rec.Body.String(), rec.Header())
+	}
+}
+
+func TestTimeouts_RouteOverrides(t *testing.T) {
+	timeouts := NewTimeouts(time.Second, map[string]time.Duration{
+		"/api/admin":         5 * time.Second,
+		"/api/admin/reports": time.Minute,
+		"/ws":                0,
+	})
+	tests := map[string]time.Duration{
+		"/api/posts":           time.Second,
+		"/api/admin/users":     5 * time.Second,
+		"/api/admin/reports/1": time.Minute,
+		"/ws":                  0,
+	}
+	for path, want := range tests {
+		if got := timeouts.For(path); got != want {
+			t.Errorf("For(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	handler := timeouts.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); ok {
+			t.Error("Routes without a timeout should not get a deadline")
+		}
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ws", nil))
+}
+
+func TestTimeouts_PropagatesPanics(t *testing.T) {
+	handler := TimeoutMiddleware(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	defer func() {
+		if recovered := recover(); recovered != "boom" {
+			t.Errorf("Expected the handler's panic to be re-raised, got %v", recovered)
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/posts", nil))
+}
@@ -0,0 +1,434 @@
+// Package mockgen generates mocks of the interfaces a package declares for
+// its dependencies, such as the repository interfaces of the services and
+// handlers, so they can be unit tested without a database. It uses the
+// standard library only; cmd/mockgen runs it from go:generate directives:
+//
+//	//go:generate go run go-server/cmd/mockgen -source user_service.go -out mocks/user_service.go UserStore UserCache
+//
+// A mock has a field per method, named after it with a Func suffix, that
+// the method calls; a method whose field is nil panics, so a test states
+// every call it expects. Calls records each call's arguments:
+//
+//	users := &mocks.UserStore{
+//		GetUserByIDFunc: func(ctx context.Context, id uint) (*models.User, error) {
+//			return &models.User{BaseModel: models.BaseModel{ID: id}}, nil
+//		},
+//	}
+//	...
+//	if calls := users.Calls("GetUserByID"); len(calls) != 1 { ... }
+package mockgen
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Options select the interfaces to mock and where the mocks go
+type Options struct {
+	// Source is the Go file declaring the interfaces
+	Source string
+	// SourceImport is the import path of Source's package, for the types
+	// the interfaces use unqualified
+	SourceImport string
+	// Module is the path of the module, whose imports are grouped after
+	// the standard library's
+	Module string
+	// Package is the mocks' package name, mocks by default
+	Package string
+	// Interfaces are the interfaces to mock, every interface in Source if
+	// empty
+	Interfaces []string
+}
+
+// ParseArgs parses the command line of cmd/mockgen, run in dir: -source
+// FILE -out FILE [-package NAME] [INTERFACE...]. It returns the options,
+// with SourceImport and Module found from the enclosing go.mod, and the
+// output file, both relative to dir.
+func ParseArgs(dir string, args []string) (Options, string, error) {
+	flags := flag.NewFlagSet("mockgen", flag.ContinueOnError)
+	source := flags.String("source", "", "file declaring the interfaces")
+	out := flags.String("out", "", "file to write the mocks to")
+	pkg := flags.String("package", "mocks", "package name of the mocks")
+	if err := flags.Parse(args); err != nil {
+		return Options{}, "", err
+	}
+	if *source == "" || *out == "" {
+		return Options{}, "", fmt.Errorf("-source and -out are required")
+	}
+	module, importPath, err := findPackage(dir)
+	if err != nil {
+		return Options{}, "", err
+	}
+	return Options{
+		Source:       filepath.Join(dir, *source),
+		SourceImport: importPath,
+		Module:       module,
+		Package:      *pkg,
+		Interfaces:   flags.Args(),
+	}, filepath.Join(dir, *out), nil
+}
+
+// findPackage returns the module path in the nearest go.mod above dir and
+// the import path of the package in dir
+func findPackage(dir string) (module, importPath string, err error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", "", err
+	}
+	for root := abs; ; root = filepath.Dir(root) {
+		data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+		if err == nil {
+			match := regexp.MustCompile(`(?m)^module\s+(\S+)`).FindSubmatch(data)
+			if match == nil {
+				return "", "", fmt.Errorf("%s/go.mod has no module path", root)
+			}
+			rel, err := filepath.Rel(root, abs)
+			if err != nil {
+				return "", "", err
+			}
+			module = string(match[1])
+			return module, path.Join(module, filepath.ToSlash(rel)), nil
+		}
+		if filepath.Dir(root) == root {
+			return "", "", fmt.Errorf("no go.mod above %s", abs)
+		}
+	}
+}
+
+// Generate returns the formatted source of the mocks
+func Generate(opts Options) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, opts.Source, nil, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Package == "" {
+		opts.Package = "mocks"
+	}
+
+	declared := make(map[string]*ast.InterfaceType)
+	var order []string
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			if iface, ok := typeSpec.Type.(*ast.InterfaceType); ok {
+				declared[typeSpec.Name.Name] = iface
+				order = append(order, typeSpec.Name.Name)
+			}
+		}
+	}
+	names := opts.Interfaces
+	if len(names) == 0 {
+		names = order
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("%s declares no interfaces", opts.Source)
+	}
+
+	g := &generator{
+		sourceName:   file.Name.Name,
+		sourceFile:   filepath.Base(opts.Source),
+		sourceImport: opts.SourceImport,
+		module:       opts.Module,
+		imports:      make(map[string]string),
+		usedImports:  map[string]string{"sync": ""},
+	}
+	for _, spec := range file.Imports {
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+		name := importName(importPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		g.imports[name] = importPath
+	}
+
+	var body bytes.Buffer
+	for _, name := range names {
+		iface, ok := declared[name]
+		if !ok {
+			return nil, fmt.Errorf("%s declares no interface %s", opts.Source, name)
+		}
+		if err := g.mock(&body, name, iface); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by mockgen from %s; DO NOT EDIT.\n\n", g.sourceFile)
+	fmt.Fprintf(&out, "package %s\n\nimport (\n", opts.Package)
+	// The standard library comes first, as in the rest of the tree
+	var std, other []string
+	for importPath := range g.usedImports {
+		if strings.Contains(strings.Split(importPath, "/")[0], ".") || (g.module != "" && strings.HasPrefix(importPath, g.module)) {
+			other = append(other, importPath)
+		} else {
+			std = append(std, importPath)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(other)
+	for i, group := range [][]string{std, other} {
+		if i > 0 && len(group) > 0 {
+			out.WriteString("\n")
+		}
+		for _, importPath := range group {
+			if name := g.usedImports[importPath]; name != "" && name != importName(importPath) {
+				fmt.Fprintf(&out, "\t%s %q\n", name, importPath)
+			} else {
+				fmt.Fprintf(&out, "\t%q\n", importPath)
+			}
+		}
+	}
+	out.WriteString(")\n")
+	out.Write(body.Bytes())
+	return format.Source(out.Bytes())
+}
+
+type generator struct {
+	sourceName   string
+	sourceFile   string
+	sourceImport string
+	module       string
+	// imports maps the source file's import names to their paths
+	imports map[string]string
+	// usedImports maps the paths the mocks import to their names
+	usedImports map[string]string
+}
+
+type method struct {
+	name    string
+	params  []string // "name type"
+	args    []string // names, with ... for a variadic parameter
+	values  []string // names, as recorded
+	results string
+}
+
+func (g *generator) mock(w *bytes.Buffer, name string, iface *ast.InterfaceType) error {
+	var methods []method
+	for _, field := range iface.Methods.List {
+		funcType, ok := field.Type.(*ast.FuncType)
+		if !ok || len(field.Names) != 1 {
+			return fmt.Errorf("embedded interfaces are not supported")
+		}
+		m, err := g.method(field.Names[0].Name, funcType)
+		if err != nil {
+			return err
+		}
+		methods = append(methods, m)
+	}
+
+	qualified := g.sourceName + "." + name
+	fmt.Fprintf(w, "\n// %s is a mock of %s\ntype %s struct {\n", name, qualified, name)
+	for _, m := range methods {
+		fmt.Fprintf(w, "\t%sFunc func(%s)%s\n", m.name, strings.Join(m.params, ", "), m.results)
+	}
+	w.WriteString("\n\tmu    sync.Mutex\n\tcalls map[string][][]interface{}\n}\n")
+
+	for _, m := range methods {
+		fmt.Fprintf(w, "\n// %s calls %sFunc\n", m.name, m.name)
+		fmt.Fprintf(w, "func (mock *%s) %s(%s)%s {\n", name, m.name, strings.Join(m.params, ", "), m.results)
+		fmt.Fprintf(w, "\tmock.record(%s)\n", strings.Join(append([]string{strconv.Quote(m.name)}, m.values...), ", "))
+		fmt.Fprintf(w, "\tif mock.%sFunc == nil {\n\t\tpanic(%q)\n\t}\n", m.name, "unexpected call to "+name+"."+m.name)
+		call := fmt.Sprintf("mock.%sFunc(%s)", m.name, strings.Join(m.args, ", "))
+		if m.results == "" {
+			fmt.Fprintf(w, "\t%s\n}\n", call)
+		} else {
+			fmt.Fprintf(w, "\treturn %s\n}\n", call)
+		}
+	}
+
+	fmt.Fprintf(w, `
+// Calls returns the arguments of each call to method, in order
+func (mock *%[1]s) Calls(method string) [][]interface{} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([][]interface{}(nil), mock.calls[method]...)
+}
+
+func (mock *%[1]s) record(method string, args ...interface{}) {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	if mock.calls == nil {
+		mock.calls = make(map[string][][]interface{})
+	}
+	mock.calls[method] = append(mock.calls[method], args)
+}
+`, name)
+	return nil
+}
+
+func (g *generator) method(name string, funcType *ast.FuncType) (method, error) {
+	m := method{name: name}
+	if funcType.TypeParams != nil {
+		return m, fmt.Errorf("%s: type parameters are not supported", name)
+	}
+	index := 0
+	for _, field := range funcType.Params.List {
+		typ, err := g.typeString(field.Type)
+		if err != nil {
+			return m, fmt.Errorf("%s: %w", name, err)
+		}
+		names := field.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{{Name: "_"}}
+		}
+		for _, ident := range names {
+			param := ident.Name
+			// Parameters are renamed where they could clash with the
+			// receiver or be unusable
+			if param == "_" || param == "mock" {
+				param = fmt.Sprintf("p%d", index)
+			}
+			index++
+			m.params = append(m.params, param+" "+typ)
+			m.values = append(m.values, param)
+			if _, variadic := field.Type.(*ast.Ellipsis); variadic {
+				param += "..."
+			}
+			m.args = append(m.args, param)
+		}
+	}
+
+	if funcType.Results != nil {
+		var results []string
+		for _, field := range funcType.Results.List {
+			typ, err := g.typeString(field.Type)
+			if err != nil {
+				return m, fmt.Errorf("%s: %w", name, err)
+			}
+			for range max(len(field.Names), 1) {
+				results = append(results, typ)
+			}
+		}
+		if len(results) == 1 {
+			m.results = " " + results[0]
+		} else {
+			m.results = " (" + strings.Join(results, ", ") + ")"
+		}
+	}
+	return m, nil
+}
+
+// typeString prints a type as the mocks' package refers to it, qualifying
+// the source package's own types and noting the imports used
+func (g *generator) typeString(expr ast.Expr) (string, error) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if types.Universe.Lookup(t.Name) != nil {
+			return t.Name, nil
+		}
+		if !ast.IsExported(t.Name) {
+			return "", fmt.Errorf("unexported type %s cannot be used by mocks", t.Name)
+		}
+		if g.sourceImport == "" {
+			return "", fmt.Errorf("type %s needs the import path of its package", t.Name)
+		}
+		g.usedImports[g.sourceImport] = g.sourceName
+		return g.sourceName + "." + t.Name, nil
+	case *ast.SelectorExpr:
+		pkg, ok := t.X.(*ast.Ident)
+		if !ok {
+			return "", fmt.Errorf("unsupported type %T", t.X)
+		}
+		importPath, ok := g.imports[pkg.Name]
+		if !ok {
+			return "", fmt.Errorf("package %s is not imported", pkg.Name)
+		}
+		g.usedImports[importPath] = pkg.Name
+		return pkg.Name + "." + t.Sel.Name, nil
+	case *ast.StarExpr:
+		inner, err := g.typeString(t.X)
+		return "*" + inner, err
+	case *ast.IndexExpr:
+		return g.instanceString(t.X, []ast.Expr{t.Index})
+	case *ast.IndexListExpr:
+		return g.instanceString(t.X, t.Indices)
+	case *ast.Ellipsis:
+		inner, err := g.typeString(t.Elt)
+		return "..." + inner, err
+	case *ast.ArrayType:
+		inner, err := g.typeString(t.Elt)
+		if err != nil || t.Len == nil {
+			return "[]" + inner, err
+		}
+		length, ok := t.Len.(*ast.BasicLit)
+		if !ok {
+			return "", fmt.Errorf("array lengths must be literals")
+		}
+		return "[" + length.Value + "]" + inner, nil
+	case *ast.MapType:
+		key, err := g.typeString(t.Key)
+		if err != nil {
+			return "", err
+		}
+		value, err := g.typeString(t.Value)
+		return "map[" + key + "]" + value, err
+	case *ast.ChanType:
+		inner, err := g.typeString(t.Value)
+		switch t.Dir {
+		case ast.SEND:
+			return "chan<- " + inner, err
+		case ast.RECV:
+			return "<-chan " + inner, err
+		}
+		return "chan " + inner, err
+	case *ast.InterfaceType:
+		if len(t.Methods.List) > 0 {
+			return "", fmt.Errorf("inline interfaces are not supported")
+		}
+		return "interface{}", nil
+	case *ast.FuncType:
+		m, err := g.method("func", t)
+		if err != nil {
+			return "", err
+		}
+		return "func(" + strings.Join(m.params, ", ") + ")" + m.results, nil
+	}
+	return "", fmt.Errorf("unsupported type %T", expr)
+}
+
+// instanceString prints an instantiated generic type, e.g. Page[Entry]
+func (g *generator) instanceString(generic ast.Expr, args []ast.Expr) (string, error) {
+	name, err := g.typeString(generic)
+	if err != nil {
+		return "", err
+	}
+	printed := make([]string, len(args))
+	for i, arg := range args {
+		if printed[i], err = g.typeString(arg); err != nil {
+			return "", err
+		}
+	}
+	return name + "[" + strings.Join(printed, ", ") + "]", nil
+}
+
+// importName returns the name a package is imported by without an alias,
+// skipping a major version suffix such as /v8 or .v3
+func importName(importPath string) string {
+	parts := strings.Split(importPath, "/")
+	name := parts[len(parts)-1]
+	if len(parts) > 1 && regexp.MustCompile(`^v[0-9]+$`).MatchString(name) {
+		name = parts[len(parts)-2]
+	}
+	if i := strings.Index(name, ".v"); i > 0 {
+		name = name[:i]
+	}
+	return name
+}
@@ -0,0 +1,25 @@
+package store
+
+import (
+	"context"
+	stdtime "time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Entry is a type of the source package
+type Entry struct{}
+
+// Page is a generic type of the source package
+type Page[T any] struct{}
+
+// Store is an interface with every kind of signature mockgen supports
+type Store interface {
+	Get(ctx context.Context, key string) (*Entry, error)
+	Put(context.Context, *Entry, stdtime.Duration) error
+	Tags(ctx context.Context, keys ...string) map[string][]string
+	Watch(mock string, fn func(Entry) bool) <-chan Entry
+	List(ctx context.Context) (*Page[Entry], map[string]Page[[]byte], error)
+	Client() *redis.Client
+	Close()
+}
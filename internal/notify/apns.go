@@ -0,0 +1,161 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// APNsProvider is the provider name recorded on APNs deliveries
+const APNsProvider = "apns"
+
+// APNs endpoints
+const (
+	APNsProductionURL  = "https://api.push.apple.com"
+	APNsDevelopmentURL = "https://api.sandbox.push.apple.com"
+)
+
+// apnsTokenLifetime is how long a provider token is reused; Apple rejects
+// tokens older than an hour and throttles ones refreshed more often than 20 minutes
+const apnsTokenLifetime = 50 * time.Minute
+
+// APNsClient sends pushes through Apple's HTTP/2 provider API using
+// token-based (.p8 key) authentication
+type APNsClient struct {
+	keyPEM  []byte
+	keyID   string
+	teamID  string
+	topic   string
+	baseURL string
+	client  *http.Client
+
+	mu       sync.Mutex
+	token    string
+	issuedAt time.Time
+}
+
+// NewAPNsClient creates an APNs client; topic is the app's bundle ID
+func NewAPNsClient(keyPEM []byte, keyID, teamID, topic string, production bool) (*APNsClient, error) {
+	if _, err := jwt.ParseECPrivateKeyFromPEM(keyPEM); err != nil {
+		return nil, fmt.Errorf("invalid APNs key: %w", err)
+	}
+	if keyID == "" || teamID == "" || topic == "" {
+		return nil, fmt.Errorf("APNs key ID, team ID and topic are required")
+	}
+	baseURL := APNsDevelopmentURL
+	if production {
+		baseURL = APNsProductionURL
+	}
+	return &APNsClient{
+		keyPEM:  keyPEM,
+		keyID:   keyID,
+		teamID:  teamID,
+		topic:   topic,
+		baseURL: baseURL,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// WithBaseURL overrides the APNs endpoint, for tests
+func (ac *APNsClient) WithBaseURL(baseURL string) *APNsClient {
+	ac.baseURL = baseURL
+	return ac
+}
+
+// Provider returns the provider name
+func (ac *APNsClient) Provider() string {
+	return APNsProvider
+}
+
+// Push sends an alert to one device token
+func (ac *APNsClient) Push(ctx context.Context, token string, notification Notification) (string, error) {
+	bearer, err := ac.providerToken()
+	if err != nil {
+		return "", err
+	}
+
+	payload := map[string]any{
+		"aps": map[string]any{
+			"alert": map[string]string{"title": notification.Title, "body": notification.Body},
+			"sound": "default",
+		},
+	}
+	for key, value := range notification.Data {
+		if key != "aps" {
+			payload[key] = value
+		}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ac.baseURL+"/3/device/"+url.PathEscape(token), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "bearer "+bearer)
+	req.Header.Set("apns-topic", ac.topic)
+	req.Header.Set("apns-push-type", "alert")
+	req.Header.Set("apns-priority", "10")
+
+	resp, err := ac.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("apns request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return resp.Header.Get("apns-id"), nil
+	}
+	var result struct {
+		Reason string `json:"reason"`
+	}
+	json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&result)
+	switch {
+	case resp.StatusCode == http.StatusGone, result.Reason == "BadDeviceToken", result.Reason == "Unregistered":
+		return "", ErrInvalidToken
+	case result.Reason == "ExpiredProviderToken":
+		ac.mu.Lock()
+		ac.token = ""
+		ac.mu.Unlock()
+	}
+	return "", fmt.Errorf("apns returned %d: %s", resp.StatusCode, result.Reason)
+}
+
+// providerToken returns the cached ES256 provider token, signing a new one
+// when it is due for refresh
+func (ac *APNsClient) providerToken() (string, error) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+	if ac.token != "" && time.Since(ac.issuedAt) < apnsTokenLifetime {
+		return ac.token, nil
+	}
+
+	key, err := jwt.ParseECPrivateKeyFromPEM(ac.keyPEM)
+	if err != nil {
+		return "", fmt.Errorf("invalid APNs key: %w", err)
+	}
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": ac.teamID,
+		"iat": now.Unix(),
+	})
+	token.Header["kid"] = ac.keyID
+	signed, err := token.SignedString(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign APNs token: %w", err)
+	}
+
+	ac.token = signed
+	ac.issuedAt = now
+	return signed, nil
+}
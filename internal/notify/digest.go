@@ -0,0 +1,230 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-server/internal/database/models"
+	"go-server/internal/scheduler"
+)
+
+// TypeDigest is the notification type of digest summaries
+const TypeDigest = "notification.digest"
+
+// TaskDigest is the scheduled task that sends due digests
+const TaskDigest = "notifications.digest"
+
+// maxDigestItems bounds one digest; anything beyond it goes in the next one
+const maxDigestItems = 100
+
+// digestGrace lets a digest go out slightly early so a task running on a
+// fixed schedule does not slip a whole run each window
+const digestGrace = time.Minute
+
+// DigestStore persists digest preferences and held notifications
+type DigestStore interface {
+	GetNotificationPreference(ctx context.Context, userID uint) (*models.NotificationPreference, error)
+	SaveNotificationPreference(ctx context.Context, preference *models.NotificationPreference) error
+	CreateDigestItem(ctx context.Context, item *models.NotificationDigestItem) error
+	ListPendingDigestUsers(ctx context.Context) ([]uint, error)
+	ListPendingDigestItems(ctx context.Context, userID uint, limit int) ([]models.NotificationDigestItem, error)
+	MarkDigestItemsSent(ctx context.Context, ids []uint, at time.Time) error
+}
+
+// DigestWindow returns how long notifications are aggregated for a frequency
+func DigestWindow(frequency string) (time.Duration, bool) {
+	switch frequency {
+	case models.DigestFrequencyImmediate:
+		return 0, true
+	case models.DigestFrequencyHourly:
+		return time.Hour, true
+	case models.DigestFrequencyDaily:
+		return 24 * time.Hour, true
+	case models.DigestFrequencyWeekly:
+		return 7 * 24 * time.Hour, true
+	}
+	return 0, false
+}
+
+// WithDigests enables digest mode. defaultFrequency applies to users who have
+// not chosen one; immediate sends digestible notifications like any other.
+func (d *Dispatcher) WithDigests(store DigestStore, defaultFrequency string) *Dispatcher {
+	d.digests = store
+	d.digestFrequency = defaultFrequency
+	return d
+}
+
+// DigestPreference returns a user's effective digest settings, filling in the
+// defaults for anything the user has not set
+func (d *Dispatcher) DigestPreference(ctx context.Context, userID uint) (*models.NotificationPreference, error) {
+	stored, err := d.storedPreference(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return d.effectivePreference(stored), nil
+}
+
+// storedPreference returns the user's saved settings, or an empty unsaved
+// preference if they have none
+func (d *Dispatcher) storedPreference(ctx context.Context, userID uint) (*models.NotificationPreference, error) {
+	if d.digests != nil {
+		stored, err := d.digests.GetNotificationPreference(ctx, userID)
+		if err != nil || stored != nil {
+			return stored, err
+		}
+	}
+	return &models.NotificationPreference{UserID: userID}, nil
+}
+
+// effectivePreference copies a stored preference with the defaults applied,
+// so changing the configured default still affects users who never chose
+func (d *Dispatcher) effectivePreference(stored *models.NotificationPreference) *models.NotificationPreference {
+	preference := *stored
+	if _, ok := DigestWindow(preference.DigestFrequency); !ok {
+		preference.DigestFrequency = d.digestFrequency
+	}
+	if _, ok := DigestWindow(preference.DigestFrequency); !ok {
+		preference.DigestFrequency = models.DigestFrequencyImmediate
+	}
+	if preference.DigestChannel == "" {
+		preference.DigestChannel = ChannelEmail
+	}
+	return &preference
+}
+
+// holdForDigest stores a digestible notification for the user's next digest;
+// it returns false if the user wants notifications immediately
+func (d *Dispatcher) holdForDigest(ctx context.Context, userID uint, notification Notification) (bool, error) {
+	preference, err := d.DigestPreference(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to load notification preference: %w", err)
+	}
+	if preference.DigestFrequency == models.DigestFrequencyImmediate {
+		return false, nil
+	}
+
+	item := &models.NotificationDigestItem{
+		UserID:    userID,
+		Type:      notification.Type,
+		Title:     notification.Title,
+		Body:      notification.Body,
+		Reference: notification.Reference,
+		CreatedAt: d.clock.Now(),
+	}
+	if err := d.digests.CreateDigestItem(ctx, item); err != nil {
+		return false, fmt.Errorf("failed to hold notification for digest: %w", err)
+	}
+	return true, nil
+}
+
+// SendDigests sends a summary to every user whose digest window has elapsed
+// since their last digest, or since their oldest held notification if they
+// have not had one. It returns the number of digests sent.
+func (d *Dispatcher) SendDigests(ctx context.Context) (int, error) {
+	if d.digests == nil {
+		return 0, nil
+	}
+	userIDs, err := d.digests.ListPendingDigestUsers(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list pending digests: %w", err)
+	}
+
+	sent := 0
+	var firstErr error
+	for _, userID := range userIDs {
+		ok, err := d.sendDigest(ctx, userID)
+		if err != nil {
+			d.logger.Error("Failed to send notification digest", "user_id", userID, "error", err.Error())
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if ok {
+			sent++
+		}
+	}
+	return sent, firstErr
+}
+
+func (d *Dispatcher) sendDigest(ctx context.Context, userID uint) (bool, error) {
+	stored, err := d.storedPreference(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	preference := d.effectivePreference(stored)
+	items, err := d.digests.ListPendingDigestItems(ctx, userID, maxDigestItems)
+	if err != nil || len(items) == 0 {
+		return false, err
+	}
+
+	now := d.clock.Now()
+	window, _ := DigestWindow(preference.DigestFrequency)
+	since := items[0].CreatedAt
+	if preference.LastDigestAt != nil {
+		since = *preference.LastDigestAt
+	}
+	if now.Add(digestGrace).Before(since.Add(window)) {
+		return false, nil
+	}
+
+	summary := digestSummary(items)
+	summary.Channel = preference.DigestChannel
+	summary.Reference = fmt.Sprintf("digest:%d:%d", userID, items[len(items)-1].ID)
+	if _, err := d.Dispatch(ctx, userID, summary); err != nil {
+		return false, err
+	}
+
+	ids := make([]uint, len(items))
+	for i := range items {
+		ids[i] = items[i].ID
+	}
+	if err := d.digests.MarkDigestItemsSent(ctx, ids, now); err != nil {
+		return true, fmt.Errorf("failed to mark digest items sent: %w", err)
+	}
+	stored.LastDigestAt = &now
+	if err := d.digests.SaveNotificationPreference(ctx, stored); err != nil {
+		return true, fmt.Errorf("failed to record digest time: %w", err)
+	}
+
+	d.logger.Info("Notification digest sent", "user_id", userID, "items", len(items), "channel", summary.Channel)
+	return true, nil
+}
+
+// digestSummary builds the summary notification for held items
+func digestSummary(items []models.NotificationDigestItem) Notification {
+	summary := Notification{Type: TypeDigest, Items: make([]Notification, len(items))}
+	for i, item := range items {
+		summary.Items[i] = Notification{Type: item.Type, Title: item.Title, Body: item.Body, Reference: item.Reference}
+	}
+
+	if len(items) == 1 {
+		summary.Title = "1 new notification"
+	} else {
+		summary.Title = fmt.Sprintf("%d new notifications", len(items))
+	}
+
+	const shown = 3
+	var titles []string
+	for i := 0; i < len(items) && i < shown; i++ {
+		titles = append(titles, items[i].Title)
+	}
+	summary.Body = strings.Join(titles, ", ")
+	if len(items) > shown {
+		summary.Body += fmt.Sprintf(" and %d more", len(items)-shown)
+	}
+	return summary
+}
+
+// RegisterTasks schedules digest delivery; an empty schedule disables it
+func RegisterTasks(s *scheduler.Scheduler, d *Dispatcher, digestSchedule string) error {
+	if digestSchedule == "" || d.digests == nil {
+		return nil
+	}
+	return s.Register(TaskDigest, digestSchedule, func(ctx context.Context) error {
+		_, err := d.SendDigests(ctx)
+		return err
+	})
+}
@@ -0,0 +1,292 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/models"
+	"go-server/internal/jobs"
+	"go-server/internal/logger"
+)
+
+// JobDeliver is the job type that performs one delivery attempt
+const JobDeliver = "notify.deliver"
+
+// JobQueue runs delivery attempts in the background; *jobs.Pool satisfies it
+type JobQueue interface {
+	Register(jobType string, handler jobs.Handler)
+	EnqueueIn(ctx context.Context, jobType string, payload any, delay time.Duration) (*jobs.Job, error)
+}
+
+// DeliveryStore persists deliveries
+type DeliveryStore interface {
+	CreateNotificationDelivery(ctx context.Context, delivery *models.NotificationDelivery) error
+	UpdateNotificationDelivery(ctx context.Context, delivery *models.NotificationDelivery) error
+	GetNotificationDelivery(ctx context.Context, id uint) (*models.NotificationDelivery, error)
+	FindNotificationDeliveryByProviderID(ctx context.Context, provider, messageID string) (*models.NotificationDelivery, error)
+}
+
+// UserStore looks up recipients
+type UserStore interface {
+	GetUserByID(ctx context.Context, id uint) (*models.User, error)
+}
+
+// deliveryPayload is stored with each delivery so retries and fallbacks can
+// resend the notification; Tried lists the channels already used
+type deliveryPayload struct {
+	Notification Notification `json:"notification"`
+	Tried        []string     `json:"tried"`
+}
+
+// deliveryJob is the job payload for one attempt
+type deliveryJob struct {
+	DeliveryID uint `json:"delivery_id"`
+	Attempt    int  `json:"attempt"`
+}
+
+// Dispatcher sends notifications through channels according to the rules
+type Dispatcher struct {
+	channels       map[string]Channel
+	rules          map[string]Rule
+	defaultChannel string
+	deliveries     DeliveryStore
+	users          UserStore
+	queue          JobQueue
+	backoff        jobs.Backoff
+	clock          clock.Clock
+	logger         logger.Logger
+
+	digests         DigestStore
+	digestFrequency string
+}
+
+// NewDispatcher creates a new dispatcher and registers its job handler on queue
+func NewDispatcher(queue JobQueue, deliveries DeliveryStore, users UserStore, rules map[string]Rule, defaultChannel string, logger logger.Logger, channels ...Channel) *Dispatcher {
+	if rules == nil {
+		rules = DefaultRules()
+	}
+	if defaultChannel == "" {
+		defaultChannel = ChannelPush
+	}
+	d := &Dispatcher{
+		channels:       make(map[string]Channel),
+		rules:          rules,
+		defaultChannel: defaultChannel,
+		deliveries:     deliveries,
+		users:          users,
+		queue:          queue,
+		backoff:        jobs.Backoff{Base: 30 * time.Second, Max: 30 * time.Minute},
+		clock:          clock.New(),
+		logger:         logger,
+	}
+	for _, channel := range channels {
+		d.channels[channel.Name()] = channel
+	}
+	queue.Register(JobDeliver, d.handle)
+	return d
+}
+
+// WithBackoff overrides the delay between attempts on a channel
+func (d *Dispatcher) WithBackoff(backoff jobs.Backoff) *Dispatcher {
+	d.backoff = backoff
+	return d
+}
+
+// WithClock overrides the clock used to stamp deliveries
+func (d *Dispatcher) WithClock(c clock.Clock) *Dispatcher {
+	d.clock = clock.OrDefault(c)
+	return d
+}
+
+// Dispatch queues a notification for a user on its first channel. When
+// digests are enabled, a digestible notification is held for the user's next
+// digest instead and Dispatch returns a nil delivery.
+func (d *Dispatcher) Dispatch(ctx context.Context, userID uint, notification Notification) (*models.NotificationDelivery, error) {
+	channel := notification.Channel
+	if channel == "" {
+		channel = d.defaultChannel
+	}
+	if _, ok := d.channels[channel]; !ok {
+		return nil, fmt.Errorf("notification channel %q is not configured", channel)
+	}
+	if notification.Digestible && d.digests != nil {
+		held, err := d.holdForDigest(ctx, userID, notification)
+		if err != nil || held {
+			return nil, err
+		}
+	}
+	return d.queueDelivery(ctx, userID, channel, deliveryPayload{Notification: notification})
+}
+
+func (d *Dispatcher) queueDelivery(ctx context.Context, userID uint, channel string, payload deliveryPayload) (*models.NotificationDelivery, error) {
+	payload.Tried = append(payload.Tried, channel)
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	delivery := &models.NotificationDelivery{
+		UserID:    userID,
+		Channel:   channel,
+		Type:      payload.Notification.Type,
+		Reference: payload.Notification.Reference,
+		Status:    models.NotificationStatusQueued,
+		Payload:   string(data),
+	}
+	if err := d.deliveries.CreateNotificationDelivery(ctx, delivery); err != nil {
+		return nil, fmt.Errorf("failed to record notification delivery: %w", err)
+	}
+	if _, err := d.queue.EnqueueIn(ctx, JobDeliver, deliveryJob{DeliveryID: delivery.ID, Attempt: 1}, 0); err != nil {
+		return nil, fmt.Errorf("failed to queue notification delivery: %w", err)
+	}
+	return delivery, nil
+}
+
+// handle performs one delivery attempt. Failures are retried and fallen back
+// here according to the channel rule, so the job itself only fails when the
+// delivery cannot be loaded or rescheduled.
+func (d *Dispatcher) handle(ctx context.Context, job *jobs.Job) error {
+	var attempt deliveryJob
+	if err := job.Decode(&attempt); err != nil {
+		return fmt.Errorf("invalid notification job: %w", err)
+	}
+	delivery, err := d.deliveries.GetNotificationDelivery(ctx, attempt.DeliveryID)
+	if err != nil {
+		return fmt.Errorf("failed to load notification delivery: %w", err)
+	}
+	if delivery.Status != models.NotificationStatusQueued {
+		return nil
+	}
+	var payload deliveryPayload
+	if err := json.Unmarshal([]byte(delivery.Payload), &payload); err != nil {
+		d.fail(ctx, delivery, fmt.Errorf("invalid stored notification: %w", err))
+		return nil
+	}
+
+	delivery.Attempts = attempt.Attempt
+	sendErr := d.send(ctx, delivery, payload)
+	if sendErr == nil {
+		now := d.clock.Now()
+		delivery.Status = models.NotificationStatusSent
+		delivery.SentAt = &now
+		delivery.Error = ""
+		return d.deliveries.UpdateNotificationDelivery(ctx, delivery)
+	}
+
+	rule := d.rule(delivery.Channel)
+	if !stderrors.Is(sendErr, ErrUndeliverable) && attempt.Attempt < rule.MaxAttempts {
+		delivery.Error = sendErr.Error()
+		if err := d.deliveries.UpdateNotificationDelivery(ctx, delivery); err != nil {
+			return err
+		}
+		delay := d.backoff.Delay(attempt.Attempt)
+		d.logger.Warn("Notification delivery failed, retrying", "delivery_id", delivery.ID, "channel", delivery.Channel,
+			"attempt", attempt.Attempt, "delay", delay, "error", sendErr.Error())
+		_, err := d.queue.EnqueueIn(ctx, JobDeliver, deliveryJob{DeliveryID: delivery.ID, Attempt: attempt.Attempt + 1}, delay)
+		return err
+	}
+
+	d.fail(ctx, delivery, sendErr)
+	return nil
+}
+
+func (d *Dispatcher) send(ctx context.Context, delivery *models.NotificationDelivery, payload deliveryPayload) error {
+	channel, ok := d.channels[delivery.Channel]
+	if !ok {
+		return undeliverable("channel %s is not configured", delivery.Channel)
+	}
+	user, err := d.users.GetUserByID(ctx, delivery.UserID)
+	if err != nil {
+		return undeliverable("user %d not found", delivery.UserID)
+	}
+
+	receipt, err := channel.Send(ctx, &Message{DeliveryID: delivery.ID, User: user, Notification: payload.Notification})
+	if err != nil {
+		return err
+	}
+	delivery.Provider = receipt.Provider
+	delivery.ProviderMessageID = receipt.MessageID
+	return nil
+}
+
+func (d *Dispatcher) rule(channel string) Rule {
+	rule, ok := d.rules[channel]
+	if !ok || rule.MaxAttempts < 1 {
+		rule.MaxAttempts = 1
+	}
+	return rule
+}
+
+// fail marks a delivery failed and queues the fallback channel, skipping
+// channels this notification already went through
+func (d *Dispatcher) fail(ctx context.Context, delivery *models.NotificationDelivery, cause error) {
+	delivery.Status = models.NotificationStatusFailed
+	delivery.Error = cause.Error()
+	if err := d.deliveries.UpdateNotificationDelivery(ctx, delivery); err != nil {
+		d.logger.Error("Failed to update notification delivery", "delivery_id", delivery.ID, "error", err.Error())
+	}
+	d.logger.Warn("Notification delivery failed", "delivery_id", delivery.ID, "channel", delivery.Channel, "error", cause.Error())
+
+	var payload deliveryPayload
+	if err := json.Unmarshal([]byte(delivery.Payload), &payload); err != nil {
+		return
+	}
+	for fallback := d.rule(delivery.Channel).Fallback; fallback != ""; fallback = d.rule(fallback).Fallback {
+		if contains(payload.Tried, fallback) {
+			return
+		}
+		if _, ok := d.channels[fallback]; !ok {
+			// Skip unconfigured channels, e.g. SMS without Twilio credentials
+			payload.Tried = append(payload.Tried, fallback)
+			continue
+		}
+		next, err := d.queueDelivery(ctx, delivery.UserID, fallback, payload)
+		if err != nil {
+			d.logger.Error("Failed to queue notification fallback", "delivery_id", delivery.ID, "channel", fallback, "error", err.Error())
+			return
+		}
+		d.logger.Info("Notification falling back", "delivery_id", delivery.ID, "channel", fallback, "fallback_delivery_id", next.ID)
+		return
+	}
+}
+
+// HandleStatus applies a provider's delivery status callback. Messages the
+// provider reports as failed after accepting them fall back like send
+// failures. It returns false if no delivery matches.
+func (d *Dispatcher) HandleStatus(ctx context.Context, provider, messageID, status, detail string) (bool, error) {
+	delivery, err := d.deliveries.FindNotificationDeliveryByProviderID(ctx, provider, messageID)
+	if err != nil || delivery == nil {
+		return false, err
+	}
+
+	switch status {
+	case models.NotificationStatusDelivered:
+		now := d.clock.Now()
+		delivery.Status = models.NotificationStatusDelivered
+		delivery.DeliveredAt = &now
+		return true, d.deliveries.UpdateNotificationDelivery(ctx, delivery)
+	case models.NotificationStatusFailed:
+		if delivery.Status == models.NotificationStatusFailed {
+			return true, nil
+		}
+		if detail == "" {
+			detail = "provider reported delivery failure"
+		}
+		d.fail(ctx, delivery, stderrors.New(detail))
+		return true, nil
+	}
+	return true, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"go-server/internal/email"
+)
+
+// EmailChannel delivers notifications as transactional email
+type EmailChannel struct {
+	mailer *email.Mailer
+}
+
+// NewEmailChannel creates an email channel
+func NewEmailChannel(mailer *email.Mailer) *EmailChannel {
+	return &EmailChannel{mailer: mailer}
+}
+
+// Name returns the channel name
+func (ec *EmailChannel) Name() string {
+	return ChannelEmail
+}
+
+// Send renders the notification template, or the digest template for digest
+// summaries, and sends it. The reference makes
+// retries of the same delivery idempotent in the mailer.
+func (ec *EmailChannel) Send(ctx context.Context, message *Message) (Receipt, error) {
+	user := message.User
+	if user.Email == "" {
+		return Receipt{}, undeliverable("user %d has no email address", user.ID)
+	}
+	template := email.TemplateNotification
+	if message.Notification.Type == TypeDigest {
+		template = email.TemplateNotificationDigest
+	}
+	userID := user.ID
+	delivery, err := ec.mailer.Send(ctx, email.Request{
+		To:       user.Email,
+		UserID:   &userID,
+		Template: template,
+		Data: map[string]any{
+			"Username": user.Username,
+			"Title":    message.Notification.Title,
+			"Body":     message.Notification.Body,
+			"Items":    message.Notification.Items,
+		},
+		Reference: "notification:" + strconv.FormatUint(uint64(message.DeliveryID), 10),
+	})
+	if err != nil {
+		return Receipt{}, fmt.Errorf("failed to send notification email: %w", err)
+	}
+	return Receipt{Provider: "email", MessageID: delivery.MessageID}, nil
+}
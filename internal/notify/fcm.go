@@ -0,0 +1,189 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// FCMProvider is the provider name recorded on FCM deliveries
+const FCMProvider = "fcm"
+
+const fcmScope = "https://www.googleapis.com/auth/firebase.messaging"
+
+// serviceAccount is the subset of a Google service account key file FCM needs
+type serviceAccount struct {
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// FCMClient sends pushes through the Firebase Cloud Messaging HTTP v1 API,
+// authenticating with an OAuth token minted from a service account key
+type FCMClient struct {
+	projectID string
+	account   serviceAccount
+	baseURL   string
+	client    *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// NewFCMClient creates an FCM client from a service account key file's
+// contents; projectID may be empty to use the key's project
+func NewFCMClient(projectID string, credentialsJSON []byte) (*FCMClient, error) {
+	var account serviceAccount
+	if err := json.Unmarshal(credentialsJSON, &account); err != nil {
+		return nil, fmt.Errorf("invalid FCM credentials: %w", err)
+	}
+	if account.ClientEmail == "" || account.PrivateKey == "" {
+		return nil, fmt.Errorf("FCM credentials need client_email and private_key")
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+	if projectID == "" {
+		projectID = account.ProjectID
+	}
+	if projectID == "" {
+		return nil, fmt.Errorf("FCM project ID is required")
+	}
+	return &FCMClient{
+		projectID: projectID,
+		account:   account,
+		baseURL:   "https://fcm.googleapis.com",
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// WithBaseURL overrides the FCM endpoint, for tests
+func (fc *FCMClient) WithBaseURL(baseURL string) *FCMClient {
+	fc.baseURL = baseURL
+	return fc
+}
+
+// Provider returns the provider name
+func (fc *FCMClient) Provider() string {
+	return FCMProvider
+}
+
+// Push sends a notification to one registration token
+func (fc *FCMClient) Push(ctx context.Context, token string, notification Notification) (string, error) {
+	accessToken, err := fc.token(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	payload := map[string]any{
+		"message": map[string]any{
+			"token":        token,
+			"notification": map[string]string{"title": notification.Title, "body": notification.Body},
+			"data":         notification.Data,
+		},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	endpoint := fmt.Sprintf("%s/v1/projects/%s/messages:send", fc.baseURL, url.PathEscape(fc.projectID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := fc.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("fcm request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode == http.StatusOK {
+		var result struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(data, &result); err != nil || result.Name == "" {
+			return "", fmt.Errorf("invalid fcm response")
+		}
+		return result.Name, nil
+	}
+	if resp.StatusCode == http.StatusNotFound || strings.Contains(string(data), "UNREGISTERED") {
+		return "", ErrInvalidToken
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		fc.mu.Lock()
+		fc.accessToken = ""
+		fc.mu.Unlock()
+	}
+	return "", fmt.Errorf("fcm returned %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+}
+
+// token returns a cached OAuth access token, exchanging a signed service
+// account assertion for a new one when it is about to expire
+func (fc *FCMClient) token(ctx context.Context) (string, error) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	if fc.accessToken != "" && time.Until(fc.expiresAt) > time.Minute {
+		return fc.accessToken, nil
+	}
+
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(fc.account.PrivateKey))
+	if err != nil {
+		return "", fmt.Errorf("invalid FCM private key: %w", err)
+	}
+	now := time.Now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   fc.account.ClientEmail,
+		"scope": fcmScope,
+		"aud":   fc.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign FCM assertion: %w", err)
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+	form.Set("assertion", assertion)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fc.account.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := fc.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("fcm token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fcm token request returned %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil || result.AccessToken == "" {
+		return "", fmt.Errorf("invalid fcm token response")
+	}
+
+	fc.accessToken = result.AccessToken
+	fc.expiresAt = now.Add(time.Duration(result.ExpiresIn) * time.Second)
+	return fc.accessToken, nil
+}
@@ -0,0 +1,129 @@
+// Package notify delivers user notifications over push, SMS, email and the
+// in-app feed. Each channel has a retry and fallback rule: a notification is
+// retried on its channel with backoff and, once that channel gives up or a
+// provider reports the message undelivered, it is resent on the fallback
+// channel. Digestible notifications can instead be held and summarized in a
+// periodic digest.
+package notify
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go-server/internal/database/models"
+)
+
+// Channel names
+const (
+	ChannelPush  = "push"
+	ChannelSMS   = "sms"
+	ChannelEmail = "email"
+	ChannelInApp = "in_app"
+)
+
+// ErrUndeliverable marks failures that retrying cannot fix, such as a user
+// without a phone number or a rejected recipient; the dispatcher falls back
+// immediately instead of retrying
+var ErrUndeliverable = stderrors.New("notification undeliverable")
+
+// ErrInvalidToken is returned by push senders when the provider reports the
+// device token as expired or unregistered
+var ErrInvalidToken = stderrors.New("invalid device token")
+
+// undeliverable wraps a reason as ErrUndeliverable
+func undeliverable(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrUndeliverable, fmt.Sprintf(format, args...))
+}
+
+// Notification is the content of a notification
+type Notification struct {
+	// Type identifies the kind of notification, e.g. "post.commented"
+	Type  string            `json:"type"`
+	Title string            `json:"title"`
+	Body  string            `json:"body"`
+	Data  map[string]string `json:"data,omitempty"`
+	// Channel is the first channel to try; empty uses the dispatcher default
+	Channel string `json:"channel,omitempty"`
+	// Reference correlates the deliveries with whatever triggered them
+	Reference string `json:"reference,omitempty"`
+	// Digestible notifications are held for the user's digest unless the
+	// user asked for immediate delivery
+	Digestible bool `json:"digestible,omitempty"`
+	// Items lists the notifications a digest summarizes
+	Items []Notification `json:"items,omitempty"`
+}
+
+// Text is the plain-text rendering used by SMS
+func (n *Notification) Text() string {
+	if n.Title == "" {
+		return n.Body
+	}
+	return n.Title + ": " + n.Body
+}
+
+// Message is a notification addressed to a user for one delivery
+type Message struct {
+	DeliveryID   uint
+	User         *models.User
+	Notification Notification
+}
+
+// Receipt identifies an accepted send for status callbacks
+type Receipt struct {
+	Provider  string
+	MessageID string
+}
+
+// Channel sends messages over one transport
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, message *Message) (Receipt, error)
+}
+
+// Rule is a channel's retry and fallback policy
+type Rule struct {
+	MaxAttempts int
+	// Fallback is the channel to try once this one gives up; empty for none
+	Fallback string
+}
+
+// DefaultRules tries push, then SMS, then email; in-app items are recorded
+// rather than sent, so they need no retries
+func DefaultRules() map[string]Rule {
+	return map[string]Rule{
+		ChannelPush:  {MaxAttempts: 3, Fallback: ChannelSMS},
+		ChannelSMS:   {MaxAttempts: 3, Fallback: ChannelEmail},
+		ChannelEmail: {MaxAttempts: 5},
+		ChannelInApp: {MaxAttempts: 1},
+	}
+}
+
+// ParseRules parses rules written as "channel:attempts[:fallback]" separated
+// by commas, e.g. "push:3:sms,sms:2:email,email:5". Channels not listed keep
+// their default rule.
+func ParseRules(spec string) (map[string]Rule, error) {
+	rules := DefaultRules()
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, ":")
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, fmt.Errorf("invalid notification rule %q", entry)
+		}
+		attempts, err := strconv.Atoi(parts[1])
+		if err != nil || attempts < 1 {
+			return nil, fmt.Errorf("invalid attempts in notification rule %q", entry)
+		}
+		rule := Rule{MaxAttempts: attempts}
+		if len(parts) == 3 {
+			rule.Fallback = parts[2]
+		}
+		rules[parts[0]] = rule
+	}
+	return rules, nil
+}
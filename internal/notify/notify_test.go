@@ -0,0 +1,506 @@
+package notify
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/dbtest"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/jobs"
+	"go-server/internal/logger"
+
+	"gorm.io/gorm"
+)
+
+// fakeQueue collects enqueued jobs so tests can run them one at a time
+type fakeQueue struct {
+	handler jobs.Handler
+	pending []*jobs.Job
+	delays  []time.Duration
+}
+
+func (q *fakeQueue) Register(jobType string, handler jobs.Handler) {
+	q.handler = handler
+}
+
+func (q *fakeQueue) EnqueueIn(ctx context.Context, jobType string, payload any, delay time.Duration) (*jobs.Job, error) {
+	job, err := jobs.NewJob(jobType, payload, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	q.pending = append(q.pending, job)
+	q.delays = append(q.delays, delay)
+	return job, nil
+}
+
+// drain runs queued jobs until none are left
+func (q *fakeQueue) drain(t *testing.T) {
+	t.Helper()
+	for i := 0; len(q.pending) > 0; i++ {
+		if i > 50 {
+			t.Fatal("jobs did not settle")
+		}
+		job := q.pending[0]
+		q.pending = q.pending[1:]
+		if err := q.handler(context.Background(), job); err != nil {
+			t.Fatalf("job failed: %v", err)
+		}
+	}
+}
+
+// fakeChannel fails with the queued errors before succeeding
+type fakeChannel struct {
+	name  string
+	errs  []error
+	sends int
+	last  *Message
+}
+
+func (c *fakeChannel) Name() string { return c.name }
+
+func (c *fakeChannel) Send(ctx context.Context, message *Message) (Receipt, error) {
+	c.sends++
+	c.last = message
+	if len(c.errs) > 0 {
+		err := c.errs[0]
+		c.errs = c.errs[1:]
+		return Receipt{}, err
+	}
+	return Receipt{Provider: c.name + "-provider", MessageID: fmt.Sprintf("%s-%d", c.name, message.DeliveryID)}, nil
+}
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := dbtest.Open(t, &models.User{}, &models.DeviceToken{}, &models.NotificationDelivery{},
+		&models.NotificationPreference{}, &models.NotificationDigestItem{})
+	return db
+}
+
+func newTestDispatcher(t *testing.T, channels ...Channel) (*Dispatcher, *fakeQueue, *repositories.NotificationRepository, *models.User) {
+	t.Helper()
+	db := newTestDB(t)
+	user := &models.User{Email: "alice@example.com", Username: "alice", Password: "x", PhoneNumber: "+14155550100"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	repo := repositories.NewNotificationRepository(db)
+	queue := &fakeQueue{}
+	dispatcher := NewDispatcher(queue, repo, repositories.NewUserRepository(db), nil, ChannelPush, logger.NewServerLogger(), channels...).
+		WithBackoff(jobs.Backoff{Base: time.Second, Max: time.Minute})
+	return dispatcher, queue, repo, user
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules("push:2:email, sms:1")
+	if err != nil {
+		t.Fatalf("ParseRules failed: %v", err)
+	}
+	if rules[ChannelPush] != (Rule{MaxAttempts: 2, Fallback: ChannelEmail}) || rules[ChannelSMS] != (Rule{MaxAttempts: 1}) {
+		t.Errorf("unexpected rules: %+v", rules)
+	}
+	if rules[ChannelEmail] != DefaultRules()[ChannelEmail] {
+		t.Errorf("unlisted channels should keep their default rule")
+	}
+
+	for _, spec := range []string{"push", "push:0", "push:x:sms", "push:1:sms:email"} {
+		if _, err := ParseRules(spec); err == nil {
+			t.Errorf("expected %q to be rejected", spec)
+		}
+	}
+}
+
+func TestDispatcher_RetryThenFallback(t *testing.T) {
+	ctx := context.Background()
+	transient := errors.New("provider unavailable")
+	push := &fakeChannel{name: ChannelPush, errs: []error{transient, transient, transient}}
+	sms := &fakeChannel{name: ChannelSMS}
+	dispatcher, queue, repo, user := newTestDispatcher(t, push, sms, &fakeChannel{name: ChannelEmail})
+
+	first, err := dispatcher.Dispatch(ctx, user.ID, Notification{Type: "post.commented", Title: "New comment", Body: "bob replied"})
+	if err != nil {
+		t.Fatalf("Dispatch failed: %v", err)
+	}
+	queue.drain(t)
+
+	if push.sends != 3 || sms.sends != 1 {
+		t.Errorf("sends: push=%d sms=%d, want 3 and 1", push.sends, sms.sends)
+	}
+	if queue.delays[1] != time.Second || queue.delays[2] != 2*time.Second {
+		t.Errorf("retries should back off: %v", queue.delays)
+	}
+
+	failed, _ := repo.GetNotificationDelivery(ctx, first.ID)
+	if failed.Status != models.NotificationStatusFailed || failed.Attempts != 3 || failed.Error != transient.Error() {
+		t.Errorf("push delivery should be failed after 3 attempts: %+v", failed)
+	}
+
+	deliveries, _ := repo.ListNotificationDeliveries(ctx, user.ID, 0, 10)
+	if len(deliveries) != 2 {
+		t.Fatalf("expected a fallback delivery, got %d", len(deliveries))
+	}
+	fallback := deliveries[0]
+	if fallback.Channel != ChannelSMS || fallback.Status != models.NotificationStatusSent || fallback.ProviderMessageID == "" || fallback.SentAt == nil {
+		t.Errorf("unexpected fallback delivery: %+v", fallback)
+	}
+}
+
+func TestDispatcher_UndeliverableFallsBackImmediately(t *testing.T) {
+	ctx := context.Background()
+	push := &fakeChannel{name: ChannelPush, errs: []error{undeliverable("no devices")}}
+	email := &fakeChannel{name: ChannelEmail}
+	// SMS is not configured, so the push fallback skips ahead to email
+	dispatcher, queue, repo, user := newTestDispatcher(t, push, email)
+
+	if _, err := dispatcher.Dispatch(ctx, user.ID, Notification{Title: "Hi", Body: "there"}); err != nil {
+		t.Fatalf("Dispatch failed: %v", err)
+	}
+	queue.drain(t)
+
+	if push.sends != 1 || email.sends != 1 {
+		t.Errorf("sends: push=%d email=%d, want 1 and 1", push.sends, email.sends)
+	}
+	deliveries, _ := repo.ListNotificationDeliveries(ctx, user.ID, 0, 10)
+	if len(deliveries) != 2 || deliveries[0].Channel != ChannelEmail {
+		t.Errorf("expected an email fallback: %+v", deliveries)
+	}
+
+	if _, err := dispatcher.Dispatch(ctx, user.ID, Notification{Channel: ChannelSMS}); err == nil {
+		t.Error("expected an unconfigured channel to be rejected")
+	}
+}
+
+func TestDispatcher_HandleStatus(t *testing.T) {
+	ctx := context.Background()
+	sms := &fakeChannel{name: ChannelSMS}
+	email := &fakeChannel{name: ChannelEmail}
+	dispatcher, queue, repo, user := newTestDispatcher(t, sms, email)
+
+	first, _ := dispatcher.Dispatch(ctx, user.ID, Notification{Channel: ChannelSMS, Body: "code 1234"})
+	second, _ := dispatcher.Dispatch(ctx, user.ID, Notification{Channel: ChannelSMS, Body: "code 5678"})
+	queue.drain(t)
+
+	found, err := dispatcher.HandleStatus(ctx, "sms-provider", fmt.Sprintf("sms-%d", first.ID), models.NotificationStatusDelivered, "")
+	if err != nil || !found {
+		t.Fatalf("HandleStatus failed: %v %v", found, err)
+	}
+	delivered, _ := repo.GetNotificationDelivery(ctx, first.ID)
+	if delivered.Status != models.NotificationStatusDelivered || delivered.DeliveredAt == nil {
+		t.Errorf("delivery should be marked delivered: %+v", delivered)
+	}
+
+	// A carrier rejection after acceptance falls back to email
+	if _, err := dispatcher.HandleStatus(ctx, "sms-provider", fmt.Sprintf("sms-%d", second.ID), models.NotificationStatusFailed, "twilio error 30006"); err != nil {
+		t.Fatalf("HandleStatus failed: %v", err)
+	}
+	queue.drain(t)
+	failed, _ := repo.GetNotificationDelivery(ctx, second.ID)
+	if failed.Status != models.NotificationStatusFailed || failed.Error != "twilio error 30006" || email.sends != 1 {
+		t.Errorf("failed status should fall back to email: %+v sends=%d", failed, email.sends)
+	}
+
+	if found, _ := dispatcher.HandleStatus(ctx, "sms-provider", "unknown", models.NotificationStatusDelivered, ""); found {
+		t.Error("expected unknown message to be reported as not found")
+	}
+}
+
+func TestDispatcher_Digests(t *testing.T) {
+	ctx := context.Background()
+	push := &fakeChannel{name: ChannelPush}
+	email := &fakeChannel{name: ChannelEmail}
+	dispatcher, queue, repo, user := newTestDispatcher(t, push, email)
+	fake := clock.NewFake(time.Now())
+	dispatcher.WithClock(fake).WithDigests(repo, models.DigestFrequencyDaily)
+
+	for _, title := range []string{"New comment", "New follower"} {
+		delivery, err := dispatcher.Dispatch(ctx, user.ID, Notification{Type: "social", Title: title, Digestible: true})
+		if err != nil || delivery != nil {
+			t.Fatalf("digestible notification should be held: %v %v", delivery, err)
+		}
+	}
+	if _, err := dispatcher.Dispatch(ctx, user.ID, Notification{Title: "Password changed"}); err != nil {
+		t.Fatalf("Dispatch failed: %v", err)
+	}
+	queue.drain(t)
+	if push.sends != 1 {
+		t.Errorf("only the non-digestible notification should be sent, got %d", push.sends)
+	}
+
+	if sent, err := dispatcher.SendDigests(ctx); err != nil || sent != 0 {
+		t.Errorf("digest should wait for the window: %d %v", sent, err)
+	}
+
+	fake.Advance(24 * time.Hour)
+	if sent, err := dispatcher.SendDigests(ctx); err != nil || sent != 1 {
+		t.Fatalf("expected one digest: %d %v", sent, err)
+	}
+	queue.drain(t)
+	summary := email.last.Notification
+	if email.sends != 1 || summary.Type != TypeDigest || len(summary.Items) != 2 || summary.Title != "2 new notifications" {
+		t.Errorf("unexpected digest: %+v", summary)
+	}
+	if pending, _ := repo.ListPendingDigestItems(ctx, user.ID, 10); len(pending) != 0 {
+		t.Errorf("digested items should be marked sent: %+v", pending)
+	}
+	stored, _ := repo.GetNotificationPreference(ctx, user.ID)
+	if stored == nil || stored.LastDigestAt == nil || stored.DigestFrequency != "" {
+		t.Errorf("digest time should be recorded without pinning the default: %+v", stored)
+	}
+
+	// The next window starts at the last digest
+	dispatcher.Dispatch(ctx, user.ID, Notification{Title: "Another", Digestible: true})
+	fake.Advance(time.Hour)
+	if sent, _ := dispatcher.SendDigests(ctx); sent != 0 {
+		t.Errorf("digest sent before the window elapsed")
+	}
+
+	stored.DigestFrequency = models.DigestFrequencyImmediate
+	repo.SaveNotificationPreference(ctx, stored)
+	if sent, _ := dispatcher.SendDigests(ctx); sent != 1 {
+		t.Errorf("switching to immediate should flush held notifications")
+	}
+	if delivery, _ := dispatcher.Dispatch(ctx, user.ID, Notification{Title: "Now", Digestible: true}); delivery == nil {
+		t.Errorf("immediate users should not have notifications held")
+	}
+}
+
+type fakePublisher struct {
+	events []string
+}
+
+func (p *fakePublisher) PublishToUser(userID uint, eventType string, data any) int {
+	p.events = append(p.events, fmt.Sprintf("%d:%s", userID, eventType))
+	return 1
+}
+
+func TestInAppChannel(t *testing.T) {
+	publisher := &fakePublisher{}
+	receipt, err := NewInAppChannel(publisher).Send(context.Background(), &Message{DeliveryID: 7, User: &models.User{BaseModel: models.BaseModel{ID: 3}}})
+	if err != nil || receipt != (Receipt{Provider: InAppProvider, MessageID: "7"}) {
+		t.Errorf("unexpected receipt: %+v %v", receipt, err)
+	}
+	if len(publisher.events) != 1 || publisher.events[0] != "3:"+EventNotification {
+		t.Errorf("expected a realtime event: %v", publisher.events)
+	}
+}
+
+func TestTwilioSMS(t *testing.T) {
+	status := http.StatusCreated
+	var form url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "AC123" || pass != "token" || r.URL.Path != "/2010-04-01/Accounts/AC123/Messages.json" {
+			t.Errorf("unexpected request: %s %s", r.URL.Path, user)
+		}
+		r.ParseForm()
+		form = r.PostForm
+		w.WriteHeader(status)
+		if status == http.StatusCreated {
+			w.Write([]byte(`{"sid":"SM1"}`))
+		} else {
+			w.Write([]byte(`{"code":21211,"message":"Invalid 'To' Phone Number"}`))
+		}
+	}))
+	defer server.Close()
+
+	sms := NewTwilioSMS(TwilioConfig{AccountSID: "AC123", AuthToken: "token", From: "+15005550006", StatusCallbackURL: "https://example.com/cb", BaseURL: server.URL})
+	message := &Message{User: &models.User{PhoneNumber: "+14155550100"}, Notification: Notification{Title: "Code", Body: "1234"}}
+
+	receipt, err := sms.Send(context.Background(), message)
+	if err != nil || receipt != (Receipt{Provider: TwilioProvider, MessageID: "SM1"}) {
+		t.Fatalf("Send failed: %+v %v", receipt, err)
+	}
+	if form.Get("To") != "+14155550100" || form.Get("Body") != "Code: 1234" || form.Get("StatusCallback") != "https://example.com/cb" {
+		t.Errorf("unexpected form: %v", form)
+	}
+
+	status = http.StatusBadRequest
+	if _, err := sms.Send(context.Background(), message); !errors.Is(err, ErrUndeliverable) {
+		t.Errorf("4xx should be undeliverable, got %v", err)
+	}
+	status = http.StatusServiceUnavailable
+	if _, err := sms.Send(context.Background(), message); err == nil || errors.Is(err, ErrUndeliverable) {
+		t.Errorf("5xx should be retryable, got %v", err)
+	}
+	if _, err := sms.Send(context.Background(), &Message{User: &models.User{}}); !errors.Is(err, ErrUndeliverable) {
+		t.Errorf("missing phone number should be undeliverable, got %v", err)
+	}
+}
+
+func TestVerifyTwilioSignature(t *testing.T) {
+	// Example from Twilio's webhook security documentation
+	params := url.Values{
+		"CallSid": {"CA1234567890ABCDE"},
+		"Caller":  {"+12349013030"},
+		"Digits":  {"1234"},
+		"From":    {"+12349013030"},
+		"To":      {"+18005551212"},
+	}
+	callbackURL := "https://mycompany.com/myapp.php?foo=1&bar=2"
+	if !VerifyTwilioSignature("12345", callbackURL, params, "0/KCTR6DLpKmkAf8muzZqo1nDgQ=") {
+		t.Error("expected documented signature to verify")
+	}
+	if VerifyTwilioSignature("12345", callbackURL, params, "invalid") {
+		t.Error("expected invalid signature to be rejected")
+	}
+}
+
+// fakeSender records pushes and fails tokens listed in errs
+type fakeSender struct {
+	provider string
+	errs     map[string]error
+	pushed   []string
+}
+
+func (s *fakeSender) Provider() string { return s.provider }
+
+func (s *fakeSender) Push(ctx context.Context, token string, notification Notification) (string, error) {
+	s.pushed = append(s.pushed, token)
+	if err := s.errs[token]; err != nil {
+		return "", err
+	}
+	return s.provider + ":" + token, nil
+}
+
+func TestPushChannel(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := repositories.NewNotificationRepository(db)
+	now := time.Now()
+	for _, token := range []models.DeviceToken{
+		{UserID: 1, Platform: models.PlatformIOS, Token: "ios-1", LastSeenAt: now},
+		{UserID: 1, Platform: models.PlatformAndroid, Token: "android-stale", LastSeenAt: now.Add(-time.Hour)},
+	} {
+		token := token
+		if err := repo.UpsertDeviceToken(ctx, &token); err != nil {
+			t.Fatalf("UpsertDeviceToken failed: %v", err)
+		}
+	}
+
+	fcm := &fakeSender{provider: FCMProvider, errs: map[string]error{"android-stale": ErrInvalidToken}}
+	apns := &fakeSender{provider: APNsProvider}
+	channel := NewPushChannel(repo, fcm, apns, logger.NewServerLogger())
+	message := &Message{User: &models.User{BaseModel: models.BaseModel{ID: 1}}, Notification: Notification{Title: "Hi"}}
+
+	receipt, err := channel.Send(ctx, message)
+	if err != nil || receipt.Provider != APNsProvider || len(fcm.pushed) != 1 || len(apns.pushed) != 1 {
+		t.Fatalf("unexpected send: %+v %v fcm=%v apns=%v", receipt, err, fcm.pushed, apns.pushed)
+	}
+	tokens, _ := repo.ListDeviceTokens(ctx, 1)
+	if len(tokens) != 1 || tokens[0].Token != "ios-1" {
+		t.Errorf("invalid token should be removed: %+v", tokens)
+	}
+
+	apns.errs = map[string]error{"ios-1": errors.New("timeout")}
+	if _, err := channel.Send(ctx, message); err == nil || errors.Is(err, ErrUndeliverable) {
+		t.Errorf("transient failures should be retryable, got %v", err)
+	}
+	if _, err := channel.Send(ctx, &Message{User: &models.User{BaseModel: models.BaseModel{ID: 2}}}); !errors.Is(err, ErrUndeliverable) {
+		t.Errorf("user without devices should be undeliverable, got %v", err)
+	}
+}
+
+func TestFCMClient(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	tokenRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			tokenRequests++
+			r.ParseForm()
+			if r.PostForm.Get("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" || r.PostForm.Get("assertion") == "" {
+				t.Errorf("unexpected token request: %v", r.PostForm)
+			}
+			w.Write([]byte(`{"access_token":"ya29","expires_in":3600}`))
+		case "/v1/projects/demo/messages:send":
+			var body struct {
+				Message struct {
+					Token string `json:"token"`
+				} `json:"message"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			if r.Header.Get("Authorization") != "Bearer ya29" {
+				t.Errorf("missing access token")
+			}
+			if body.Message.Token == "gone" {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"error":{"status":"NOT_FOUND","details":[{"errorCode":"UNREGISTERED"}]}}`))
+				return
+			}
+			w.Write([]byte(`{"name":"projects/demo/messages/1"}`))
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	credentials, _ := json.Marshal(map[string]string{
+		"project_id": "demo", "client_email": "push@demo.iam.gserviceaccount.com",
+		"private_key": string(keyPEM), "token_uri": server.URL + "/token",
+	})
+	client, err := NewFCMClient("", credentials)
+	if err != nil {
+		t.Fatalf("NewFCMClient failed: %v", err)
+	}
+	client.WithBaseURL(server.URL)
+
+	id, err := client.Push(context.Background(), "device", Notification{Title: "Hi"})
+	if err != nil || id != "projects/demo/messages/1" {
+		t.Fatalf("Push failed: %q %v", id, err)
+	}
+	if _, err := client.Push(context.Background(), "gone", Notification{}); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken, got %v", err)
+	}
+	if tokenRequests != 1 {
+		t.Errorf("access token should be cached, got %d requests", tokenRequests)
+	}
+}
+
+func TestAPNsClient(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("apns-topic") != "com.example.app" || !strings.HasPrefix(r.Header.Get("Authorization"), "bearer ") {
+			t.Errorf("missing APNs headers: %v", r.Header)
+		}
+		if r.URL.Path == "/3/device/gone" {
+			w.WriteHeader(http.StatusGone)
+			w.Write([]byte(`{"reason":"Unregistered"}`))
+			return
+		}
+		w.Header().Set("apns-id", "apns-1")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := NewAPNsClient(keyPEM, "KEY123", "TEAM123", "com.example.app", false)
+	if err != nil {
+		t.Fatalf("NewAPNsClient failed: %v", err)
+	}
+	client.WithBaseURL(server.URL)
+
+	if id, err := client.Push(context.Background(), "device", Notification{Title: "Hi"}); err != nil || id != "apns-1" {
+		t.Fatalf("Push failed: %q %v", id, err)
+	}
+	if _, err := client.Push(context.Background(), "gone", Notification{}); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken, got %v", err)
+	}
+}
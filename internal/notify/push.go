@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+
+	"go-server/internal/database/models"
+	"go-server/internal/logger"
+)
+
+// PushSender sends a notification to one device token
+type PushSender interface {
+	Provider() string
+	// Push returns the provider's message ID, or ErrInvalidToken when the
+	// token is no longer registered
+	Push(ctx context.Context, token string, notification Notification) (string, error)
+}
+
+// TokenStore looks up and prunes device tokens
+type TokenStore interface {
+	ListDeviceTokens(ctx context.Context, userID uint) ([]models.DeviceToken, error)
+	DeleteDeviceTokenByValue(ctx context.Context, token string) error
+}
+
+// PushChannel delivers notifications to every device a user registered,
+// routing iOS tokens to APNs and the rest to FCM
+type PushChannel struct {
+	tokens TokenStore
+	fcm    PushSender
+	apns   PushSender
+	logger logger.Logger
+}
+
+// NewPushChannel creates a push channel; either sender may be nil if that
+// provider is not configured
+func NewPushChannel(tokens TokenStore, fcm, apns PushSender, logger logger.Logger) *PushChannel {
+	return &PushChannel{
+		tokens: tokens,
+		fcm:    fcm,
+		apns:   apns,
+		logger: logger,
+	}
+}
+
+// Name returns the channel name
+func (pc *PushChannel) Name() string {
+	return ChannelPush
+}
+
+// Send pushes to all of the user's devices. It succeeds if any device
+// accepted the message, and deletes tokens the provider reports invalid.
+func (pc *PushChannel) Send(ctx context.Context, message *Message) (Receipt, error) {
+	tokens, err := pc.tokens.ListDeviceTokens(ctx, message.User.ID)
+	if err != nil {
+		return Receipt{}, fmt.Errorf("failed to load device tokens: %w", err)
+	}
+
+	var receipt Receipt
+	var lastErr error
+	for _, token := range tokens {
+		sender := pc.fcm
+		if token.Platform == models.PlatformIOS {
+			sender = pc.apns
+		}
+		if sender == nil {
+			continue
+		}
+
+		messageID, err := sender.Push(ctx, token.Token, message.Notification)
+		if stderrors.Is(err, ErrInvalidToken) {
+			pc.logger.Info("Removing invalid device token", "user_id", message.User.ID, "platform", token.Platform)
+			if err := pc.tokens.DeleteDeviceTokenByValue(ctx, token.Token); err != nil {
+				pc.logger.Error("Failed to remove device token", "user_id", message.User.ID, "error", err.Error())
+			}
+			continue
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if receipt.MessageID == "" {
+			receipt = Receipt{Provider: sender.Provider(), MessageID: messageID}
+		}
+	}
+
+	if receipt.MessageID != "" {
+		return receipt, nil
+	}
+	if lastErr != nil {
+		return Receipt{}, lastErr
+	}
+	return Receipt{}, undeliverable("user %d has no registered devices", message.User.ID)
+}
@@ -0,0 +1,151 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go-server/internal/database/models"
+)
+
+// TwilioProvider is the provider name recorded on SMS deliveries
+const TwilioProvider = "twilio"
+
+// TwilioConfig holds Twilio credentials
+type TwilioConfig struct {
+	AccountSID string
+	AuthToken  string
+	// From is the sending number or messaging service SID
+	From string
+	// StatusCallbackURL receives delivery status updates; empty disables them
+	StatusCallbackURL string
+	// BaseURL overrides the API endpoint, for tests
+	BaseURL string
+}
+
+// TwilioSMS delivers notifications as text messages through the Twilio REST API
+type TwilioSMS struct {
+	config TwilioConfig
+	client *http.Client
+}
+
+// NewTwilioSMS creates a Twilio SMS channel
+func NewTwilioSMS(config TwilioConfig) *TwilioSMS {
+	if config.BaseURL == "" {
+		config.BaseURL = "https://api.twilio.com"
+	}
+	return &TwilioSMS{
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name returns the channel name
+func (ts *TwilioSMS) Name() string {
+	return ChannelSMS
+}
+
+// Send sends the notification text to the user's phone number
+func (ts *TwilioSMS) Send(ctx context.Context, message *Message) (Receipt, error) {
+	if message.User.PhoneNumber == "" {
+		return Receipt{}, undeliverable("user %d has no phone number", message.User.ID)
+	}
+
+	form := url.Values{}
+	form.Set("To", message.User.PhoneNumber)
+	if strings.HasPrefix(ts.config.From, "MG") {
+		form.Set("MessagingServiceSid", ts.config.From)
+	} else {
+		form.Set("From", ts.config.From)
+	}
+	form.Set("Body", message.Notification.Text())
+	if ts.config.StatusCallbackURL != "" {
+		form.Set("StatusCallback", ts.config.StatusCallbackURL)
+	}
+
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", ts.config.BaseURL, url.PathEscape(ts.config.AccountSID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Receipt{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(ts.config.AccountSID, ts.config.AuthToken)
+
+	resp, err := ts.client.Do(req)
+	if err != nil {
+		return Receipt{}, fmt.Errorf("twilio request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		SID     string `json:"sid"`
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&body)
+
+	if resp.StatusCode >= 300 {
+		err := fmt.Errorf("twilio returned %d: %s", resp.StatusCode, body.Message)
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			// Invalid or unreachable numbers will not succeed on retry
+			return Receipt{}, undeliverable("%s", err.Error())
+		}
+		return Receipt{}, err
+	}
+	if body.SID == "" {
+		return Receipt{}, fmt.Errorf("twilio response has no message SID")
+	}
+	return Receipt{Provider: TwilioProvider, MessageID: body.SID}, nil
+}
+
+var phoneNumberPattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// ValidPhoneNumber reports whether number is in E.164 format, e.g. +14155550100
+func ValidPhoneNumber(number string) bool {
+	return phoneNumberPattern.MatchString(number)
+}
+
+// TwilioStatus maps a Twilio MessageStatus to a delivery status; it returns
+// "" for intermediate states such as sending
+func TwilioStatus(status string) string {
+	switch status {
+	case "delivered":
+		return models.NotificationStatusDelivered
+	case "failed", "undelivered":
+		return models.NotificationStatusFailed
+	}
+	return ""
+}
+
+// VerifyTwilioSignature checks the X-Twilio-Signature of a form-encoded
+// callback: the base64 HMAC-SHA1 of the full callback URL followed by each
+// POST parameter name and value, sorted by name
+func VerifyTwilioSignature(authToken, callbackURL string, params url.Values, signature string) bool {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(callbackURL))
+	for _, key := range keys {
+		for _, value := range params[key] {
+			mac.Write([]byte(key))
+			mac.Write([]byte(value))
+		}
+	}
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(signature)) == 1
+}
@@ -0,0 +1,44 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"go-server/internal/events"
+)
+
+// BusTopicPrefix starts the event bus topic of every relayed event; the
+// topic of a post.published event is "outbox:post.published"
+const BusTopicPrefix = "outbox:"
+
+// BusPublisher returns a publisher putting relayed events on the bus, as
+// JSON envelopes. Errors of the bus's Local subscribers fail the event, so
+// the relay retries it.
+func BusPublisher(bus events.Bus) Publisher {
+	return PublisherFunc(func(ctx context.Context, envelope Envelope) error {
+		data, err := json.Marshal(envelope)
+		if err != nil {
+			return fmt.Errorf("failed to encode event: %w", err)
+		}
+		return bus.Publish(ctx, events.Event{Topic: BusTopicPrefix + envelope.Type, Payload: data})
+	})
+}
+
+// BusSubscriber returns a subscriber handing relayed events from the bus
+// to publisher. Publishers with effects that must happen once per event,
+// such as the webhook dispatcher or the mailer, should be local; those
+// serving this server's clients, like the realtime hub, should not.
+func BusSubscriber(publisher Publisher, local bool) events.Subscriber {
+	return events.Subscriber{
+		Pattern: BusTopicPrefix + "*",
+		Local:   local,
+		Handle: func(ctx context.Context, event events.Event) error {
+			var envelope Envelope
+			if err := json.Unmarshal(event.Payload, &envelope); err != nil {
+				return fmt.Errorf("malformed event on %s: %w", event.Topic, err)
+			}
+			return publisher.Publish(ctx, envelope)
+		},
+	}
+}
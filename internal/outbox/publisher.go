@@ -0,0 +1,66 @@
+// Package outbox relays domain events recorded in the transactional outbox
+// table to a message broker or webhook dispatcher.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"go-server/internal/database/models"
+)
+
+// Envelope is the wire format of a relayed event. ID is stable across redeliveries
+// so that consumers can deduplicate. SchemaVersion is the version of the event
+// type's schema the payload conforms to, as served at /api/events/schemas.
+type Envelope struct {
+	ID            uint            `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	SchemaVersion int             `json:"schema_version"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// NewEnvelope wraps an outbox event for delivery
+func NewEnvelope(event *models.OutboxEvent) Envelope {
+	return Envelope{
+		ID:            event.ID,
+		Type:          event.EventType,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+		OccurredAt:    event.CreatedAt,
+		SchemaVersion: event.SchemaVersion,
+		Payload:       json.RawMessage(event.Payload),
+	}
+}
+
+// Publisher delivers events to a downstream system. Delivery is at-least-once:
+// an event may be published again if marking it as sent fails.
+type Publisher interface {
+	Publish(ctx context.Context, envelope Envelope) error
+}
+
+// PublisherFunc adapts a function to the Publisher interface
+type PublisherFunc func(ctx context.Context, envelope Envelope) error
+
+// Publish calls f(ctx, envelope)
+func (f PublisherFunc) Publish(ctx context.Context, envelope Envelope) error {
+	return f(ctx, envelope)
+}
+
+// Fanout delivers each event to every publisher. If any publisher fails the event
+// is retried for all of them, so subscribers must tolerate duplicates.
+func Fanout(publishers ...Publisher) Publisher {
+	return PublisherFunc(func(ctx context.Context, envelope Envelope) error {
+		var errs []error
+		for _, publisher := range publishers {
+			if err := publisher.Publish(ctx, envelope); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	})
+}
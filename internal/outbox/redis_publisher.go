@@ -0,0 +1,36 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisPublisher publishes events to Redis pub/sub channels named "<prefix>:<event type>"
+type RedisPublisher struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisPublisher creates a new Redis publisher
+func NewRedisPublisher(client *redis.Client, prefix string) *RedisPublisher {
+	if prefix == "" {
+		prefix = "events"
+	}
+	return &RedisPublisher{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+// Publish sends the envelope to the channel for its event type
+func (rp *RedisPublisher) Publish(ctx context.Context, envelope Envelope) error {
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	channel := rp.prefix + ":" + envelope.Type
+	return rp.client.Publish(ctx, channel, data).Err()
+}
@@ -0,0 +1,123 @@
+package outbox
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/repositories"
+	"go-server/internal/jobs"
+	"go-server/internal/logger"
+)
+
+// RelayConfig holds outbox relay configuration
+type RelayConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	Backoff      jobs.Backoff
+	Clock        clock.Clock // Optional; defaults to the system clock
+}
+
+// Relay polls the outbox table and hands pending events to a publisher
+type Relay struct {
+	repo      *repositories.OutboxRepository
+	publisher Publisher
+	config    RelayConfig
+	clock     clock.Clock
+	logger    logger.Logger
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewRelay creates a new outbox relay
+func NewRelay(repo *repositories.OutboxRepository, publisher Publisher, config RelayConfig, logger logger.Logger) *Relay {
+	if config.PollInterval <= 0 {
+		config.PollInterval = 2 * time.Second
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.Backoff.Base <= 0 {
+		config.Backoff = jobs.DefaultBackoff()
+	}
+
+	return &Relay{
+		repo:      repo,
+		publisher: publisher,
+		config:    config,
+		clock:     clock.OrDefault(config.Clock),
+		logger:    logger,
+	}
+}
+
+// Start begins relaying events in the background
+func (r *Relay) Start(ctx context.Context) {
+	ctx, r.cancel = context.WithCancel(ctx)
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		ticker := time.NewTicker(r.config.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				// Drain full batches without waiting for the next tick
+				for {
+					relayed, err := r.RelayPending(ctx)
+					if err != nil || relayed < r.config.BatchSize || ctx.Err() != nil {
+						break
+					}
+				}
+			}
+		}
+	}()
+
+	r.logger.Info("Outbox relay started", "poll_interval", r.config.PollInterval.String())
+}
+
+// Stop halts the relay and waits for the current batch to finish
+func (r *Relay) Stop() {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.wg.Wait()
+	r.logger.Info("Outbox relay stopped")
+}
+
+// RelayPending publishes one batch of due events and returns how many were attempted
+func (r *Relay) RelayPending(ctx context.Context) (int, error) {
+	events, err := r.repo.ListPending(ctx, r.clock.Now(), r.config.BatchSize)
+	if err != nil {
+		r.logger.Error("Failed to load outbox events", "error", err.Error())
+		return 0, err
+	}
+
+	for i := range events {
+		event := &events[i]
+
+		if err := r.publisher.Publish(ctx, NewEnvelope(event)); err != nil {
+			retryAt := r.clock.Now().Add(r.config.Backoff.Delay(event.Attempts + 1))
+			r.logger.Warn("Failed to publish outbox event",
+				"event_id", event.ID, "event_type", event.EventType,
+				"attempt", event.Attempts+1, "error", err.Error())
+			if markErr := r.repo.MarkFailed(ctx, event.ID, err.Error(), retryAt); markErr != nil {
+				r.logger.Error("Failed to record outbox failure", "event_id", event.ID, "error", markErr.Error())
+			}
+			continue
+		}
+
+		if err := r.repo.MarkPublished(ctx, event.ID, r.clock.Now()); err != nil {
+			// The event will be delivered again; consumers deduplicate by ID
+			r.logger.Error("Failed to mark outbox event published", "event_id", event.ID, "error", err.Error())
+		}
+	}
+
+	return len(events), nil
+}
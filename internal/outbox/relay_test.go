@@ -0,0 +1,170 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/dbtest"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/events"
+	"go-server/internal/eventschema"
+	"go-server/internal/jobs"
+	"go-server/internal/logger"
+
+	"gorm.io/gorm"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := dbtest.Open(t, &models.User{}, &models.Post{}, &models.OutboxEvent{})
+	return db
+}
+
+func TestRegisterUser_WritesOutboxEvent(t *testing.T) {
+	db := newTestDB(t)
+	users := repositories.NewUserRepository(db)
+	events := repositories.NewOutboxRepository(db)
+
+	user := &models.User{Email: "a@example.com", Username: "alice", Password: "hash"}
+	if err := users.RegisterUser(context.Background(), user); err != nil {
+		t.Fatalf("RegisterUser failed: %v", err)
+	}
+
+	pending, err := events.ListPending(context.Background(), time.Now().Add(time.Second), 10)
+	if err != nil {
+		t.Fatalf("ListPending failed: %v", err)
+	}
+	if len(pending) != 1 || pending[0].EventType != models.EventUserRegistered {
+		t.Fatalf("Expected one user.registered event, got %+v", pending)
+	}
+	if pending[0].AggregateID != fmt.Sprint(user.ID) {
+		t.Errorf("Expected aggregate ID %d, got %s", user.ID, pending[0].AggregateID)
+	}
+}
+
+func TestRegisterUser_RollsBackOnConflict(t *testing.T) {
+	db := newTestDB(t)
+	users := repositories.NewUserRepository(db)
+	events := repositories.NewOutboxRepository(db)
+	ctx := context.Background()
+
+	if err := users.RegisterUser(ctx, &models.User{Email: "a@example.com", Username: "alice", Password: "hash"}); err != nil {
+		t.Fatalf("RegisterUser failed: %v", err)
+	}
+	if err := users.RegisterUser(ctx, &models.User{Email: "a@example.com", Username: "alice", Password: "hash"}); err == nil {
+		t.Fatal("Expected duplicate registration to fail")
+	}
+
+	if count, _ := events.CountPending(ctx); count != 1 {
+		t.Errorf("Expected the failed registration to leave no event, got %d pending", count)
+	}
+}
+
+func TestAppend_RejectsPayloadsNotMatchingTheSchema(t *testing.T) {
+	db := newTestDB(t)
+	events := repositories.NewOutboxRepository(db)
+	ctx := context.Background()
+
+	event, _ := models.NewOutboxEvent(models.EventPostPublished, "post", 1, map[string]string{"slug": "hello"})
+	if err := events.Append(ctx, event); !errors.Is(err, eventschema.ErrInvalidPayload) {
+		t.Errorf("Expected ErrInvalidPayload, got %v", err)
+	}
+	event, _ = models.NewOutboxEvent("post.liked", "post", 1, map[string]string{})
+	if err := events.Append(ctx, event); !errors.Is(err, eventschema.ErrUnknownEvent) {
+		t.Errorf("Expected ErrUnknownEvent, got %v", err)
+	}
+	if count, _ := events.CountPending(ctx); count != 0 {
+		t.Errorf("Expected rejected events to be dropped, got %d pending", count)
+	}
+}
+
+func TestRelay_PublishesAndRetries(t *testing.T) {
+	db := newTestDB(t)
+	repo := repositories.NewOutboxRepository(db)
+	ctx := context.Background()
+	fake := clock.NewFake(time.Now())
+
+	event, _ := models.NewOutboxEvent(models.EventPostPublished, "post", 1, models.PostEventPayload{
+		ID: 1, PublicID: "p1", Slug: "hello", Title: "Hello", AuthorID: 1, Status: "published",
+	})
+	event.AvailableAt = fake.Now()
+	if err := repo.Append(ctx, event); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	fail := true
+	var delivered []Envelope
+	publisher := PublisherFunc(func(ctx context.Context, envelope Envelope) error {
+		if fail {
+			return errors.New("broker unavailable")
+		}
+		delivered = append(delivered, envelope)
+		return nil
+	})
+
+	relay := NewRelay(repo, publisher, RelayConfig{
+		Backoff: jobs.Backoff{Base: time.Minute, Max: time.Hour},
+		Clock:   fake,
+	}, logger.NewServerLogger())
+
+	if _, err := relay.RelayPending(ctx); err != nil {
+		t.Fatalf("RelayPending failed: %v", err)
+	}
+
+	// The failed event is deferred by the backoff delay
+	fail = false
+	if n, _ := relay.RelayPending(ctx); n != 0 {
+		t.Errorf("Expected failed event to be deferred, got %d attempted", n)
+	}
+
+	fake.Advance(2 * time.Minute)
+	if n, _ := relay.RelayPending(ctx); n != 1 {
+		t.Fatalf("Expected deferred event to be retried, got %d attempted", n)
+	}
+	if len(delivered) != 1 || delivered[0].Type != models.EventPostPublished || delivered[0].ID != event.ID {
+		t.Fatalf("Unexpected deliveries: %+v", delivered)
+	}
+	if string(delivered[0].Payload) != `{"id":1,"public_id":"p1","slug":"hello","title":"Hello","author_id":1,"status":"published"}` {
+		t.Errorf("Unexpected payload %s", delivered[0].Payload)
+	}
+	if delivered[0].SchemaVersion != 1 {
+		t.Errorf("Expected schema version 1, got %d", delivered[0].SchemaVersion)
+	}
+
+	if count, _ := repo.CountPending(ctx); count != 0 {
+		t.Errorf("Expected no pending events, got %d", count)
+	}
+}
+
+func TestBus_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	bus := events.NewMemoryBus(logger.NewServerLogger())
+
+	var received []Envelope
+	bus.Subscribe(ctx, BusSubscriber(PublisherFunc(func(ctx context.Context, envelope Envelope) error {
+		received = append(received, envelope)
+		if envelope.ID == 2 {
+			return errors.New("dispatch failed")
+		}
+		return nil
+	}), true))
+
+	publisher := BusPublisher(bus)
+	envelope := Envelope{ID: 1, Type: models.EventPostPublished, AggregateType: "post", AggregateID: "7", Payload: []byte(`{"id":7}`)}
+	if err := publisher.Publish(ctx, envelope); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if len(received) != 1 || received[0].Type != envelope.Type || string(received[0].Payload) != `{"id":7}` {
+		t.Errorf("Expected the envelope relayed through the bus, got %+v", received)
+	}
+
+	envelope.ID = 2
+	if err := publisher.Publish(ctx, envelope); err == nil {
+		t.Error("Expected a local subscriber's error to fail the event")
+	}
+}
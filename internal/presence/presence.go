@@ -0,0 +1,228 @@
+// Package presence tracks which users are online and who is typing where.
+// Clients send heartbeats over their WebSocket (or the heartbeat endpoint);
+// a user stays online until their heartbeats stop for longer than the TTL.
+// Changes are broadcast as realtime events so clients can render online
+// and typing indicators without polling.
+package presence
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/logger"
+	"go-server/internal/realtime"
+)
+
+// Presence events broadcast to realtime clients
+const (
+	EventOnline  = "presence.online"
+	EventOffline = "presence.offline"
+	EventTyping  = "presence.typing"
+)
+
+// Inbound message types clients send over their WebSocket
+const (
+	MessageHeartbeat = "presence.heartbeat"
+	MessageTyping    = "presence.typing"
+)
+
+// maxChannelLength bounds typing channel names, e.g. "post:42"
+const maxChannelLength = 128
+
+// ErrInvalidChannel is returned for an empty or overlong typing channel
+var ErrInvalidChannel = errors.New("presence: invalid channel")
+
+// Publisher broadcasts presence changes; *realtime.Hub implements it.
+// Presence is short-lived, so it is not stored for resume.
+type Publisher interface {
+	BroadcastEphemeral(eventType string, data any) int
+}
+
+// Config holds presence configuration
+type Config struct {
+	// TTL is how long a heartbeat keeps a user online
+	TTL time.Duration
+	// TypingTTL is how long a typing indicator lasts without being renewed
+	TypingTTL time.Duration
+	// SweepInterval is how often lapsed users are announced offline
+	SweepInterval time.Duration
+	Clock         clock.Clock // Optional; defaults to the system clock
+}
+
+// Service records heartbeats and typing indicators and publishes changes
+type Service struct {
+	store     Store
+	publisher Publisher
+	config    Config
+	clock     clock.Clock
+	logger    logger.Logger
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewService creates a new presence service; publisher may be nil
+func NewService(store Store, publisher Publisher, config Config, logger logger.Logger) *Service {
+	if config.TTL <= 0 {
+		config.TTL = 60 * time.Second
+	}
+	if config.TypingTTL <= 0 {
+		config.TypingTTL = 5 * time.Second
+	}
+	if config.SweepInterval <= 0 {
+		config.SweepInterval = 15 * time.Second
+	}
+	return &Service{
+		store:     store,
+		publisher: publisher,
+		config:    config,
+		clock:     clock.OrDefault(config.Clock),
+		logger:    logger,
+	}
+}
+
+// Heartbeat keeps the user online, announcing them if they were offline
+func (s *Service) Heartbeat(ctx context.Context, userID uint) error {
+	now := s.clock.Now()
+	cameOnline, err := s.store.Touch(ctx, userID, now, now.Add(s.config.TTL))
+	if err != nil {
+		return err
+	}
+	if cameOnline {
+		s.publish(EventOnline, map[string]any{"user_id": userID})
+	}
+	return nil
+}
+
+// Leave marks the user offline at once, e.g. when their last connection closes
+func (s *Service) Leave(ctx context.Context, userID uint) error {
+	wasOnline, err := s.store.Remove(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if wasOnline {
+		s.publishOffline(userID)
+	}
+	return nil
+}
+
+// StartTyping shows the user as typing in a channel for TypingTTL
+func (s *Service) StartTyping(ctx context.Context, userID uint, channel string) error {
+	if channel == "" || len(channel) > maxChannelLength {
+		return ErrInvalidChannel
+	}
+	if err := s.store.SetTyping(ctx, channel, userID, s.clock.Now().Add(s.config.TypingTTL)); err != nil {
+		return err
+	}
+	s.publish(EventTyping, map[string]any{"user_id": userID, "channel": channel})
+	return nil
+}
+
+// Statuses returns the presence of each user
+func (s *Service) Statuses(ctx context.Context, userIDs []uint) ([]Status, error) {
+	return s.store.Statuses(ctx, userIDs, s.clock.Now())
+}
+
+// Typing returns the users typing in a channel
+func (s *Service) Typing(ctx context.Context, channel string) ([]uint, error) {
+	if channel == "" || len(channel) > maxChannelLength {
+		return nil, ErrInvalidChannel
+	}
+	return s.store.Typing(ctx, channel, s.clock.Now())
+}
+
+// Sweep announces users whose heartbeats have lapsed as offline and returns how many
+func (s *Service) Sweep(ctx context.Context) (int, error) {
+	expired, err := s.store.Expired(ctx, s.clock.Now())
+	for _, userID := range expired {
+		s.publishOffline(userID)
+	}
+	return len(expired), err
+}
+
+// Start sweeps lapsed users in the background
+func (s *Service) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.config.SweepInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.Sweep(ctx); err != nil {
+					s.logger.Error("Failed to sweep presence", "error", err.Error())
+				}
+			}
+		}
+	}()
+
+	s.logger.Info("Presence sweeper started", "interval", s.config.SweepInterval.String())
+}
+
+// Stop halts the sweeper
+func (s *Service) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+// Connected implements realtime.Listener; a new connection is a heartbeat
+func (s *Service) Connected(ctx context.Context, userID uint) {
+	if err := s.Heartbeat(ctx, userID); err != nil {
+		s.logger.Error("Failed to record presence", "user_id", userID, "error", err.Error())
+	}
+}
+
+// Disconnected implements realtime.Listener; the user goes offline when
+// their last connection closes
+func (s *Service) Disconnected(ctx context.Context, userID uint, remaining int) {
+	if remaining > 0 {
+		return
+	}
+	if err := s.Leave(ctx, userID); err != nil {
+		s.logger.Error("Failed to clear presence", "user_id", userID, "error", err.Error())
+	}
+}
+
+// Received implements realtime.Listener, handling heartbeat and typing messages
+func (s *Service) Received(ctx context.Context, userID uint, message realtime.Inbound) {
+	var err error
+	switch message.Type {
+	case MessageHeartbeat:
+		err = s.Heartbeat(ctx, userID)
+	case MessageTyping:
+		var data struct {
+			Channel string `json:"channel"`
+		}
+		if err = json.Unmarshal(message.Data, &data); err == nil {
+			err = s.StartTyping(ctx, userID, data.Channel)
+		}
+	default:
+		return
+	}
+	if err != nil {
+		s.logger.Debug("Failed to handle presence message", "user_id", userID, "type", message.Type, "error", err.Error())
+	}
+}
+
+func (s *Service) publishOffline(userID uint) {
+	s.publish(EventOffline, map[string]any{"user_id": userID, "last_seen": s.clock.Now()})
+}
+
+func (s *Service) publish(eventType string, data any) {
+	if s.publisher != nil {
+		s.publisher.BroadcastEphemeral(eventType, data)
+	}
+}
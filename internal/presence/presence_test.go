@@ -0,0 +1,96 @@
+package presence
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/logger"
+	"go-server/internal/realtime"
+)
+
+// recordingPublisher collects broadcast event types
+type recordingPublisher struct {
+	events []string
+}
+
+func (rp *recordingPublisher) BroadcastEphemeral(eventType string, data any) int {
+	rp.events = append(rp.events, eventType)
+	return 1
+}
+
+func newTestService() (*Service, *recordingPublisher, *clock.Fake) {
+	fake := clock.NewFake(time.Now())
+	publisher := &recordingPublisher{}
+	service := NewService(NewMemoryStore(), publisher, Config{TTL: time.Minute, TypingTTL: 5 * time.Second, Clock: fake}, logger.NewServerLogger())
+	return service, publisher, fake
+}
+
+func TestService_HeartbeatAndSweep(t *testing.T) {
+	service, publisher, fake := newTestService()
+	ctx := context.Background()
+
+	service.Heartbeat(ctx, 1)
+	service.Heartbeat(ctx, 1)
+	service.Heartbeat(ctx, 2)
+	if len(publisher.events) != 2 || publisher.events[0] != EventOnline {
+		t.Fatalf("Expected one online event per user, got %v", publisher.events)
+	}
+
+	statuses, _ := service.Statuses(ctx, []uint{1, 3})
+	if !statuses[0].Online || statuses[0].LastSeen == nil || statuses[1].Online || statuses[1].LastSeen != nil {
+		t.Errorf("Statuses = %+v", statuses)
+	}
+
+	// User 2 keeps sending heartbeats; user 1 goes quiet
+	fake.Advance(45 * time.Second)
+	service.Heartbeat(ctx, 2)
+	fake.Advance(30 * time.Second)
+	if n, err := service.Sweep(ctx); err != nil || n != 1 {
+		t.Fatalf("Sweep = %d, %v; want 1 lapsed user", n, err)
+	}
+	if last := publisher.events[len(publisher.events)-1]; last != EventOffline {
+		t.Errorf("Expected an offline event, got %s", last)
+	}
+	if n, _ := service.Sweep(ctx); n != 0 {
+		t.Errorf("A lapsed user should be announced once, got %d", n)
+	}
+
+	statuses, _ = service.Statuses(ctx, []uint{1, 2})
+	if statuses[0].Online || statuses[0].LastSeen == nil || !statuses[1].Online {
+		t.Errorf("Statuses after sweep = %+v", statuses)
+	}
+}
+
+func TestService_ListenerLifecycle(t *testing.T) {
+	service, publisher, fake := newTestService()
+	ctx := context.Background()
+	var listener realtime.Listener = service
+
+	listener.Connected(ctx, 1)
+	listener.Received(ctx, 1, realtime.Inbound{Type: MessageTyping, Data: json.RawMessage(`{"channel":"post:42"}`)})
+	listener.Received(ctx, 1, realtime.Inbound{Type: MessageTyping, Data: json.RawMessage(`{"channel":""}`)})
+
+	if typing, _ := service.Typing(ctx, "post:42"); len(typing) != 1 || typing[0] != 1 {
+		t.Errorf("Typing = %v", typing)
+	}
+	fake.Advance(6 * time.Second)
+	if typing, _ := service.Typing(ctx, "post:42"); len(typing) != 0 {
+		t.Errorf("Typing indicator should lapse, got %v", typing)
+	}
+
+	// Closing one of several connections keeps the user online
+	listener.Disconnected(ctx, 1, 1)
+	listener.Disconnected(ctx, 1, 0)
+	expected := []string{EventOnline, EventTyping, EventOffline}
+	if len(publisher.events) != len(expected) {
+		t.Fatalf("Events = %v, want %v", publisher.events, expected)
+	}
+	for i, event := range expected {
+		if publisher.events[i] != event {
+			t.Errorf("Events = %v, want %v", publisher.events, expected)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package presence
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// lastSeenRetention is how long Redis remembers a user's last heartbeat
+const lastSeenRetention = 30 * 24 * time.Hour
+
+// RedisStore keeps presence in Redis, shared by all server replicas. Each
+// online user has a key expiring with their presence; a sorted set of
+// expiry times lets Expired find users whose key lapsed so they can be
+// announced offline.
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStore creates a Redis-backed presence store
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client, prefix: "presence:"}
+}
+
+// Touch refreshes the user's online key and expiry
+func (rs *RedisStore) Touch(ctx context.Context, userID uint, now, expiresAt time.Time) (bool, error) {
+	member := strconv.FormatUint(uint64(userID), 10)
+	seen := now.UnixMilli()
+
+	pipe := rs.client.TxPipeline()
+	pipe.Set(ctx, rs.prefix+"online:"+member, seen, expiresAt.Sub(now))
+	pipe.Set(ctx, rs.prefix+"seen:"+member, seen, lastSeenRetention)
+	added := pipe.ZAdd(ctx, rs.prefix+"active", &redis.Z{Score: float64(expiresAt.UnixMilli()), Member: member})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+	return added.Val() == 1, nil
+}
+
+// Remove deletes the user's online key
+func (rs *RedisStore) Remove(ctx context.Context, userID uint) (bool, error) {
+	member := strconv.FormatUint(uint64(userID), 10)
+
+	pipe := rs.client.TxPipeline()
+	pipe.Del(ctx, rs.prefix+"online:"+member)
+	removed := pipe.ZRem(ctx, rs.prefix+"active", member)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+	return removed.Val() == 1, nil
+}
+
+// Statuses reads each user's online and last-seen keys
+func (rs *RedisStore) Statuses(ctx context.Context, userIDs []uint, now time.Time) ([]Status, error) {
+	if len(userIDs) == 0 {
+		return []Status{}, nil
+	}
+	pipe := rs.client.Pipeline()
+	online := make([]*redis.IntCmd, len(userIDs))
+	seen := make([]*redis.StringCmd, len(userIDs))
+	for i, userID := range userIDs {
+		member := strconv.FormatUint(uint64(userID), 10)
+		online[i] = pipe.Exists(ctx, rs.prefix+"online:"+member)
+		seen[i] = pipe.Get(ctx, rs.prefix+"seen:"+member)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, len(userIDs))
+	for i, userID := range userIDs {
+		statuses[i] = Status{UserID: userID, Online: online[i].Val() == 1}
+		if millis, err := seen[i].Int64(); err == nil {
+			lastSeen := time.UnixMilli(millis).UTC()
+			statuses[i].LastSeen = &lastSeen
+		}
+	}
+	return statuses, nil
+}
+
+// Expired claims lapsed users from the sorted set; ZREM succeeds for only
+// one replica, so each user is announced offline once
+func (rs *RedisStore) Expired(ctx context.Context, now time.Time) ([]uint, error) {
+	members, err := rs.client.ZRangeByScore(ctx, rs.prefix+"active", &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(now.UnixMilli(), 10),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var expired []uint
+	for _, member := range members {
+		removed, err := rs.client.ZRem(ctx, rs.prefix+"active", member).Result()
+		if err != nil {
+			return expired, err
+		}
+		userID, err := strconv.ParseUint(member, 10, 64)
+		if removed == 1 && err == nil {
+			expired = append(expired, uint(userID))
+		}
+	}
+	return expired, nil
+}
+
+// SetTyping adds the user to the channel's typing set
+func (rs *RedisStore) SetTyping(ctx context.Context, channel string, userID uint, expiresAt time.Time) error {
+	key := rs.prefix + "typing:" + channel
+	pipe := rs.client.TxPipeline()
+	pipe.ZAdd(ctx, key, &redis.Z{Score: float64(expiresAt.UnixMilli()), Member: strconv.FormatUint(uint64(userID), 10)})
+	pipe.PExpireAt(ctx, key, expiresAt)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Typing prunes lapsed indicators and returns the remaining users
+func (rs *RedisStore) Typing(ctx context.Context, channel string, now time.Time) ([]uint, error) {
+	key := rs.prefix + "typing:" + channel
+	if err := rs.client.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(now.UnixMilli(), 10)).Err(); err != nil {
+		return nil, err
+	}
+	members, err := rs.client.ZRange(ctx, key, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]uint, 0, len(members))
+	for _, member := range members {
+		if userID, err := strconv.ParseUint(member, 10, 64); err == nil {
+			users = append(users, uint(userID))
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i] < users[j] })
+	return users, nil
+}
@@ -0,0 +1,132 @@
+package presence
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Status is a user's presence
+type Status struct {
+	UserID uint `json:"user_id"`
+	Online bool `json:"online"`
+	// LastSeen is the last heartbeat, if the store still remembers one
+	LastSeen *time.Time `json:"last_seen,omitempty"`
+}
+
+// Store keeps presence in expiring entries, so a user whose client stops
+// sending heartbeats goes offline without an explicit disconnect
+type Store interface {
+	// Touch marks the user online until expiresAt and reports whether they were offline
+	Touch(ctx context.Context, userID uint, now, expiresAt time.Time) (bool, error)
+	// Remove marks the user offline and reports whether they were online
+	Remove(ctx context.Context, userID uint) (bool, error)
+	// Statuses returns the presence of each user, in order
+	Statuses(ctx context.Context, userIDs []uint, now time.Time) ([]Status, error)
+	// Expired removes and returns the users whose presence lapsed before now.
+	// Each lapsed user is returned once, even with several callers.
+	Expired(ctx context.Context, now time.Time) ([]uint, error)
+	// SetTyping marks the user typing in a channel until expiresAt
+	SetTyping(ctx context.Context, channel string, userID uint, expiresAt time.Time) error
+	// Typing returns the users typing in a channel, sorted
+	Typing(ctx context.Context, channel string, now time.Time) ([]uint, error)
+}
+
+// MemoryStore is an in-process Store for single-node deployments and tests
+type MemoryStore struct {
+	mu       sync.Mutex
+	online   map[uint]time.Time
+	lastSeen map[uint]time.Time
+	typing   map[string]map[uint]time.Time
+}
+
+// NewMemoryStore creates a new in-memory presence store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		online:   make(map[uint]time.Time),
+		lastSeen: make(map[uint]time.Time),
+		typing:   make(map[string]map[uint]time.Time),
+	}
+}
+
+// Touch records a heartbeat
+func (ms *MemoryStore) Touch(ctx context.Context, userID uint, now, expiresAt time.Time) (bool, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	_, wasOnline := ms.online[userID]
+	ms.online[userID] = expiresAt
+	ms.lastSeen[userID] = now
+	return !wasOnline, nil
+}
+
+// Remove marks the user offline
+func (ms *MemoryStore) Remove(ctx context.Context, userID uint) (bool, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	_, wasOnline := ms.online[userID]
+	delete(ms.online, userID)
+	return wasOnline, nil
+}
+
+// Statuses returns each user's presence
+func (ms *MemoryStore) Statuses(ctx context.Context, userIDs []uint, now time.Time) ([]Status, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	statuses := make([]Status, len(userIDs))
+	for i, userID := range userIDs {
+		statuses[i] = Status{UserID: userID}
+		if expiresAt, ok := ms.online[userID]; ok && expiresAt.After(now) {
+			statuses[i].Online = true
+		}
+		if seen, ok := ms.lastSeen[userID]; ok {
+			statuses[i].LastSeen = &seen
+		}
+	}
+	return statuses, nil
+}
+
+// Expired removes users whose presence lapsed
+func (ms *MemoryStore) Expired(ctx context.Context, now time.Time) ([]uint, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	var expired []uint
+	for userID, expiresAt := range ms.online {
+		if !expiresAt.After(now) {
+			expired = append(expired, userID)
+			delete(ms.online, userID)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool { return expired[i] < expired[j] })
+	return expired, nil
+}
+
+// SetTyping records a typing indicator
+func (ms *MemoryStore) SetTyping(ctx context.Context, channel string, userID uint, expiresAt time.Time) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if ms.typing[channel] == nil {
+		ms.typing[channel] = make(map[uint]time.Time)
+	}
+	ms.typing[channel][userID] = expiresAt
+	return nil
+}
+
+// Typing returns the users typing in a channel, pruning lapsed indicators
+func (ms *MemoryStore) Typing(ctx context.Context, channel string, now time.Time) ([]uint, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	users := []uint{}
+	for userID, expiresAt := range ms.typing[channel] {
+		if expiresAt.After(now) {
+			users = append(users, userID)
+		} else {
+			delete(ms.typing[channel], userID)
+		}
+	}
+	if len(ms.typing[channel]) == 0 {
+		delete(ms.typing, channel)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i] < users[j] })
+	return users, nil
+}
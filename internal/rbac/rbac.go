@@ -0,0 +1,112 @@
+// Package rbac defines the staff roles and the permissions each one grants.
+//
+// Full administrators can do everything. The delegated roles (support,
+// moderator and billing) get only the permissions listed for them, so
+// handing someone a support desk no longer means handing them the keys.
+package rbac
+
+import (
+	"sort"
+
+	"go-server/internal/database/models"
+)
+
+// Permission is a single administrative capability
+type Permission string
+
+// Permissions checked by admin endpoints
+const (
+	UsersRead       Permission = "users:read"
+	UsersManage     Permission = "users:manage"
+	PostsModerate   Permission = "posts:moderate"
+	TrashRestore    Permission = "trash:restore"
+	TrashPurge      Permission = "trash:purge"
+	EmailsRead      Permission = "emails:read"
+	WebhooksRead    Permission = "webhooks:read"
+	WebhooksManage  Permission = "webhooks:manage"
+	BillingRead     Permission = "billing:read"
+	BillingManage   Permission = "billing:manage"
+	RolesManage     Permission = "roles:manage"
+	SystemConfigure Permission = "system:configure"
+	CalendarRead    Permission = "calendar:read"
+	UsageRead       Permission = "usage:read"
+	LegalHoldManage Permission = "legal_holds:manage"
+	ReportsRun      Permission = "reports:run"
+)
+
+// Staff roles. Users without a role have no administrative permissions.
+const (
+	RoleNone      = ""
+	RoleAdmin     = "admin"
+	RoleSupport   = "support"
+	RoleModerator = "moderator"
+	RoleBilling   = "billing"
+)
+
+// AllPermissions lists every permission, which full administrators hold
+var AllPermissions = []Permission{
+	UsersRead, UsersManage, PostsModerate, TrashRestore, TrashPurge, EmailsRead,
+	WebhooksRead, WebhooksManage, BillingRead, BillingManage, RolesManage, SystemConfigure,
+	CalendarRead, UsageRead, LegalHoldManage, ReportsRun,
+}
+
+var rolePermissions = map[string][]Permission{
+	RoleAdmin:     AllPermissions,
+	RoleSupport:   {UsersRead, UsersManage, TrashRestore, EmailsRead, WebhooksRead},
+	RoleModerator: {UsersRead, PostsModerate, TrashRestore, CalendarRead},
+	RoleBilling:   {UsersRead, BillingRead, BillingManage},
+}
+
+// RoleInfo describes a role for the admin API
+type RoleInfo struct {
+	Name        string       `json:"name"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// ValidRole checks if role is a known staff role or no role
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok || role == RoleNone
+}
+
+// Roles returns every staff role with its permissions, sorted by name
+func Roles() []RoleInfo {
+	roles := make([]RoleInfo, 0, len(rolePermissions))
+	for name := range rolePermissions {
+		roles = append(roles, RoleInfo{Name: name, Permissions: PermissionsFor(name)})
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+	return roles
+}
+
+// PermissionsFor returns the permissions a role grants
+func PermissionsFor(role string) []Permission {
+	return append([]Permission{}, rolePermissions[role]...)
+}
+
+// Can checks if a role grants a permission
+func Can(role string, permission Permission) bool {
+	for _, granted := range rolePermissions[role] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleOf returns a user's staff role. Accounts that predate roles and only
+// carry the is_admin flag are full administrators.
+func RoleOf(user *models.User) string {
+	if user.Role != RoleNone {
+		return user.Role
+	}
+	if user.IsAdmin {
+		return RoleAdmin
+	}
+	return RoleNone
+}
+
+// UserCan checks if a user's role grants a permission
+func UserCan(user *models.User, permission Permission) bool {
+	return user != nil && Can(RoleOf(user), permission)
+}
@@ -0,0 +1,58 @@
+package rbac
+
+import (
+	"testing"
+
+	"go-server/internal/database/models"
+)
+
+func TestCan_DelegatedRolesAreLimited(t *testing.T) {
+	tests := []struct {
+		role       string
+		permission Permission
+		want       bool
+	}{
+		{RoleAdmin, RolesManage, true},
+		{RoleAdmin, BillingManage, true},
+		{RoleSupport, UsersRead, true},
+		{RoleSupport, TrashPurge, false},
+		{RoleSupport, RolesManage, false},
+		{RoleModerator, PostsModerate, true},
+		{RoleModerator, UsersManage, false},
+		{RoleBilling, BillingManage, true},
+		{RoleBilling, PostsModerate, false},
+		{RoleNone, UsersRead, false},
+		{"superuser", UsersRead, false},
+	}
+
+	for _, tt := range tests {
+		if got := Can(tt.role, tt.permission); got != tt.want {
+			t.Errorf("Can(%q, %s) = %v, want %v", tt.role, tt.permission, got, tt.want)
+		}
+	}
+}
+
+func TestRoleOf_LegacyAdminFlag(t *testing.T) {
+	if got := RoleOf(&models.User{IsAdmin: true}); got != RoleAdmin {
+		t.Errorf("Legacy admin role = %q, want admin", got)
+	}
+	if got := RoleOf(&models.User{Role: RoleSupport}); got != RoleSupport {
+		t.Errorf("Support role = %q, want support", got)
+	}
+	if UserCan(&models.User{}, UsersRead) {
+		t.Error("Regular users must not have staff permissions")
+	}
+}
+
+func TestRoles_ListsEveryRole(t *testing.T) {
+	roles := Roles()
+	if len(roles) != 4 || roles[0].Name != RoleAdmin || roles[3].Name != RoleSupport {
+		t.Fatalf("Roles() = %+v", roles)
+	}
+	if len(roles[0].Permissions) != len(AllPermissions) {
+		t.Errorf("Admin has %d permissions, want all %d", len(roles[0].Permissions), len(AllPermissions))
+	}
+	if !ValidRole(RoleNone) || ValidRole("root") {
+		t.Error("ValidRole accepts only known roles and no role")
+	}
+}
@@ -0,0 +1,37 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+
+	"go-server/internal/database/models"
+	"go-server/internal/outbox"
+)
+
+// OutboxPublisher returns a publisher that broadcasts public post events from
+// the outbox relay to every connected client. Changes to drafts are not
+// broadcast; deletions only reveal the post's identifiers.
+func (h *Hub) OutboxPublisher() outbox.Publisher {
+	return outbox.PublisherFunc(func(ctx context.Context, envelope outbox.Envelope) error {
+		switch envelope.Type {
+		case models.EventPostPublished, models.EventPostUpdated, models.EventPostDeleted:
+		default:
+			return nil
+		}
+
+		var post models.PostEventPayload
+		if err := json.Unmarshal(envelope.Payload, &post); err != nil {
+			h.logger.Warn("Skipping malformed post event", "event_id", envelope.ID, "error", err.Error())
+			return nil
+		}
+
+		if envelope.Type == models.EventPostDeleted {
+			h.Broadcast(envelope.Type, map[string]any{"id": post.ID, "public_id": post.PublicID})
+			return nil
+		}
+		if post.Status == "published" {
+			h.Broadcast(envelope.Type, post)
+		}
+		return nil
+	})
+}
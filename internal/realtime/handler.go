@@ -0,0 +1,243 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-server/internal/cors"
+	"go-server/internal/database/models"
+	"go-server/internal/logger"
+)
+
+// HandlerConfig holds WebSocket endpoint configuration
+type HandlerConfig struct {
+	// AllowedOrigins lists browser origins permitted to connect, matched like
+	// CORS origins including *.example.com patterns; "*" allows any
+	AllowedOrigins []string
+	// PingInterval is the interval between WebSocket pings or event stream heartbeats
+	PingInterval   time.Duration
+	MaxMessageSize int64
+}
+
+// Inbound is a JSON message sent by a client over its WebSocket
+type Inbound struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// Listener observes WebSocket clients, e.g. to track presence. Methods are
+// called on the connection's goroutine; remaining is how many connections
+// the user still has open.
+type Listener interface {
+	Connected(ctx context.Context, userID uint)
+	Disconnected(ctx context.Context, userID uint, remaining int)
+	Received(ctx context.Context, userID uint, message Inbound)
+}
+
+// Authenticator resolves an access token to its user, as auth.AuthService
+// does for HTTP requests: it fails for service account tokens, tokens whose
+// login session has ended and deactivated users
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*models.User, string, error)
+}
+
+// Handler serves the /ws endpoint
+type Handler struct {
+	hub           *Hub
+	authenticator Authenticator
+	config        HandlerConfig
+	listener      Listener
+	logger        logger.Logger
+}
+
+// NewHandler creates a new WebSocket handler
+func NewHandler(hub *Hub, authenticator Authenticator, config HandlerConfig, logger logger.Logger) *Handler {
+	if config.PingInterval <= 0 {
+		config.PingInterval = 30 * time.Second
+	}
+	if config.MaxMessageSize <= 0 {
+		config.MaxMessageSize = 4096
+	}
+	return &Handler{
+		hub:           hub,
+		authenticator: authenticator,
+		config:        config,
+		logger:        logger,
+	}
+}
+
+// WithListener notifies listener of connections and inbound messages
+func (h *Handler) WithListener(listener Listener) *Handler {
+	h.listener = listener
+	return h
+}
+
+// ServeHTTP authenticates the request, upgrades it and serves the connection until it closes.
+// The optional topics query parameter limits delivery to comma-separated event type prefixes,
+// and last_event_id resumes after the last message the client received.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	user, ok := authenticate(w, r, h.authenticator, h.config.AllowedOrigins)
+	if !ok {
+		return
+	}
+	resumeFrom, err := parseLastEventID(r)
+	if err != nil {
+		http.Error(w, "Invalid last_event_id", http.StatusBadRequest)
+		return
+	}
+
+	// Register before upgrading so a client over its quota gets an HTTP error
+	c := newClient(user.ID, parseTopics(r.URL.Query().Get("topics")), nil, h.hub.sendBuffer)
+	replay, err := h.hub.register(c, resumeFrom)
+	if err != nil {
+		rejectConnection(w, err)
+		return
+	}
+
+	conn, err := Upgrade(w, r, h.config.MaxMessageSize)
+	if err != nil {
+		h.hub.unregister(c)
+		h.logger.Debug("WebSocket upgrade failed", "error", err.Error())
+		return
+	}
+	c.conn = conn
+	h.logger.Debug("Realtime client connected", "user_id", c.userID, "remote_addr", conn.RemoteAddr().String(), "replayed", len(replay))
+
+	// Replay before starting the write loop; live messages queue meanwhile
+	for _, d := range replay {
+		if err := conn.WriteMessage(OpText, d.payload, 10*time.Second); err != nil {
+			h.hub.unregister(c)
+			conn.Close(CloseGoingAway, "")
+			return
+		}
+	}
+
+	if h.listener != nil {
+		h.listener.Connected(r.Context(), c.userID)
+	}
+
+	go h.writeLoop(c)
+	h.readLoop(r.Context(), c)
+
+	h.hub.unregister(c)
+	if h.listener != nil {
+		h.listener.Disconnected(r.Context(), c.userID, h.hub.UserConnections(c.userID))
+	}
+	h.logger.Debug("Realtime client disconnected", "user_id", c.userID)
+}
+
+// readLoop consumes client frames so control frames are processed and disconnects
+// are noticed. Clients must answer pings within two intervals. Text messages
+// are decoded as Inbound and passed to the listener.
+func (h *Handler) readLoop(ctx context.Context, c *client) {
+	deadline := 2 * h.config.PingInterval
+	c.conn.SetReadDeadline(time.Now().Add(deadline))
+	c.conn.SetPongHandler(func() {
+		c.conn.SetReadDeadline(time.Now().Add(deadline))
+	})
+
+	for {
+		opcode, payload, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		// Any message counts as liveness
+		c.conn.SetReadDeadline(time.Now().Add(deadline))
+
+		if h.listener == nil || opcode != OpText {
+			continue
+		}
+		var message Inbound
+		if err := json.Unmarshal(payload, &message); err != nil || message.Type == "" {
+			h.logger.Debug("Ignoring malformed realtime message", "user_id", c.userID)
+			continue
+		}
+		h.listener.Received(ctx, c.userID, message)
+	}
+}
+
+// writeLoop sends queued messages and periodic pings until the client is closed.
+// Slow consumers are closed with 1013 so well-behaved clients back off before
+// reconnecting.
+func (h *Handler) writeLoop(c *client) {
+	ticker := time.NewTicker(h.config.PingInterval)
+	defer ticker.Stop()
+	defer func() {
+		if c.slow.Load() {
+			c.conn.Close(CloseTryAgainLater, "slow consumer")
+			return
+		}
+		c.conn.Close(CloseGoingAway, "")
+	}()
+
+	writeTimeout := 10 * time.Second
+	for {
+		select {
+		case <-c.done:
+			return
+		case d := <-c.send:
+			if err := c.conn.WriteMessage(OpText, d.payload, writeTimeout); err != nil {
+				c.close()
+				return
+			}
+		case <-ticker.C:
+			if err := c.conn.WriteMessage(OpPing, nil, writeTimeout); err != nil {
+				c.close()
+				return
+			}
+		}
+	}
+}
+
+// rejectConnection answers a connection refused by the hub's limits. Too many
+// connections for one user is the client's fault; a full server is not.
+func rejectConnection(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrUserConnectionLimit) {
+		http.Error(w, "Too many connections", http.StatusTooManyRequests)
+		return
+	}
+	w.Header().Set("Retry-After", "30")
+	http.Error(w, "Server at connection capacity", http.StatusServiceUnavailable)
+}
+
+// authenticate checks the Origin header and the access token, writing an error response on failure.
+// Browsers cannot set headers on WebSocket or EventSource requests, so the token may also
+// be passed as the access_token query parameter.
+func authenticate(w http.ResponseWriter, r *http.Request, authenticator Authenticator, allowedOrigins []string) (*models.User, bool) {
+	if !originAllowed(r.Header.Get("Origin"), allowedOrigins) {
+		http.Error(w, "Origin not allowed", http.StatusForbidden)
+		return nil, false
+	}
+
+	token := r.URL.Query().Get("access_token")
+	if header := r.Header.Get("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		token = strings.TrimPrefix(header, "Bearer ")
+	}
+	if token == "" {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return nil, false
+	}
+	user, _, err := authenticator.Authenticate(r.Context(), token)
+	if err != nil {
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return nil, false
+	}
+	return user, true
+}
+
+// originAllowed checks the Origin header; non-browser clients send none
+func originAllowed(origin string, allowedOrigins []string) bool {
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range allowedOrigins {
+		if cors.MatchOrigin(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,526 @@
+// Package realtime pushes server-side events to connected clients over
+// WebSockets or Server-Sent Events. Connections are authenticated with the
+// same JWTs as the REST API and grouped into per-user channels by a Hub,
+// which services use to publish events such as a revoked session.
+//
+// The hub caps connections globally and per user, and bounds each client's
+// send buffer. A client that cannot keep up is a slow consumer; the hub's
+// SlowConsumerPolicy decides whether it is disconnected at once or loses
+// messages until it has dropped too many.
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/logger"
+)
+
+// Event types pushed by the server
+const (
+	EventSessionRevoked = "session.revoked"
+	// EventReplayTruncated follows a resume replay that hit the replay limit;
+	// the client should fetch the rest from the catch-up endpoint
+	EventReplayTruncated = "replay.truncated"
+)
+
+// replayLimit bounds how many stored messages are replayed on resume
+const replayLimit = 500
+
+// storeTimeout bounds each event store call made while publishing
+const storeTimeout = 2 * time.Second
+
+// Connection limit errors returned when registering a client
+var (
+	ErrConnectionLimit     = errors.New("realtime: connection limit reached")
+	ErrUserConnectionLimit = errors.New("realtime: per-user connection limit reached")
+)
+
+// SlowConsumerPolicy decides what happens when a client's send buffer is full
+type SlowConsumerPolicy string
+
+// Slow consumer policies
+const (
+	// PolicyDisconnect closes the client as soon as its buffer overflows
+	PolicyDisconnect SlowConsumerPolicy = "disconnect"
+	// PolicyDropNewest discards the message that did not fit
+	PolicyDropNewest SlowConsumerPolicy = "drop_newest"
+	// PolicyDropOldest discards the oldest queued message to make room
+	PolicyDropOldest SlowConsumerPolicy = "drop_oldest"
+)
+
+// Stats is a snapshot of the hub's connection and backpressure counters
+type Stats struct {
+	Connections int `json:"connections"`
+	Users       int `json:"users"`
+	// Rejected counts connections refused by the global and per-user caps
+	RejectedGlobal  int64 `json:"rejected_global_limit"`
+	RejectedPerUser int64 `json:"rejected_user_limit"`
+	// DroppedMessages counts messages a slow client never received
+	DroppedMessages int64 `json:"dropped_messages"`
+	SlowDisconnects int64 `json:"slow_disconnects"`
+}
+
+// Message is the JSON envelope sent to clients. IDs increase monotonically
+// per hub so stream clients can resume after a disconnect.
+type Message struct {
+	ID     uint64    `json:"id"`
+	Type   string    `json:"type"`
+	Data   any       `json:"data,omitempty"`
+	SentAt time.Time `json:"sent_at"`
+}
+
+// delivery is an encoded message queued for a client
+type delivery struct {
+	id        uint64
+	eventType string
+	payload   []byte
+}
+
+// client is one connected WebSocket or event stream
+type client struct {
+	userID uint
+	// topics limits the event types delivered; empty means all
+	topics []string
+	conn   *Conn
+	send   chan delivery
+	done   chan struct{}
+	once   sync.Once
+	// dropped counts messages lost to backpressure; guarded by the hub lock
+	dropped int
+	// slow is set when the client was disconnected for falling behind
+	slow atomic.Bool
+}
+
+func newClient(userID uint, topics []string, conn *Conn, buffer int) *client {
+	return &client{
+		userID: userID,
+		topics: topics,
+		conn:   conn,
+		send:   make(chan delivery, buffer),
+		done:   make(chan struct{}),
+	}
+}
+
+// close stops the client's write loop; safe to call more than once
+func (c *client) close() {
+	c.once.Do(func() { close(c.done) })
+}
+
+// closed reports whether the client has been closed
+func (c *client) closed() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// wants reports whether the client subscribed to an event type. A topic
+// matches the type itself and any type nested under it, so "post" matches
+// "post.published".
+func (c *client) wants(eventType string) bool {
+	if len(c.topics) == 0 {
+		return true
+	}
+	for _, topic := range c.topics {
+		if eventType == topic || strings.HasPrefix(eventType, topic+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTopics splits a comma-separated topics parameter
+func parseTopics(value string) []string {
+	var topics []string
+	for _, topic := range strings.Split(value, ",") {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			topics = append(topics, topic)
+		}
+	}
+	return topics
+}
+
+// Hub tracks connections per user and fans out published messages
+type Hub struct {
+	clients    map[uint]map[*client]struct{}
+	mutex      sync.RWMutex
+	sendBuffer int
+	sequence   uint64
+	store      EventStore
+	clock      clock.Clock
+	logger     logger.Logger
+
+	maxConnections int
+	maxPerUser     int
+	policy         SlowConsumerPolicy
+	maxDropped     int
+
+	connections     int
+	rejectedGlobal  int64
+	rejectedPerUser int64
+	droppedMessages int64
+	slowDisconnects int64
+}
+
+// NewHub creates a new hub; sendBuffer bounds how many messages may queue for a slow client
+func NewHub(sendBuffer int, logger logger.Logger) *Hub {
+	if sendBuffer <= 0 {
+		sendBuffer = 32
+	}
+	return &Hub{
+		clients:    make(map[uint]map[*client]struct{}),
+		sendBuffer: sendBuffer,
+		store:      NewMemoryStore(256),
+		clock:      clock.New(),
+		logger:     logger,
+		policy:     PolicyDisconnect,
+	}
+}
+
+// WithLimits caps open connections in total and per user; 0 means unlimited
+func (h *Hub) WithLimits(maxConnections, maxPerUser int) *Hub {
+	h.maxConnections = maxConnections
+	h.maxPerUser = maxPerUser
+	return h
+}
+
+// WithBackpressure sets the slow consumer policy. Under the drop policies a
+// client is disconnected once it has lost more than maxDropped messages; 0
+// lets it lose any number.
+func (h *Hub) WithBackpressure(policy SlowConsumerPolicy, maxDropped int) *Hub {
+	if policy == "" {
+		policy = PolicyDisconnect
+	}
+	h.policy = policy
+	h.maxDropped = maxDropped
+	return h
+}
+
+// WithClock overrides the clock used to stamp messages
+func (h *Hub) WithClock(c clock.Clock) *Hub {
+	h.clock = clock.OrDefault(c)
+	return h
+}
+
+// WithHistory keeps the last size messages in memory for resume; 0 disables resume
+func (h *Hub) WithHistory(size int) *Hub {
+	h.store = nil
+	if size > 0 {
+		h.store = NewMemoryStore(size)
+	}
+	return h
+}
+
+// WithStore keeps messages for resume and catch-up in store, continuing the
+// message IDs from the last one it holds
+func (h *Hub) WithStore(store EventStore) *Hub {
+	h.store = store
+	if store == nil {
+		return h
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	lastID, err := store.LastID(ctx)
+	if err != nil {
+		h.logger.Error("Failed to read last realtime event ID", "error", err.Error())
+		return h
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if lastID > h.sequence {
+		h.sequence = lastID
+	}
+	return h
+}
+
+// PublishToUser sends a message to every connection of a user and returns how many received it
+func (h *Hub) PublishToUser(userID uint, eventType string, data any) int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	d, ok := h.encode(eventType, data)
+	if !ok {
+		return 0
+	}
+	h.remember(UserChannel(userID), d)
+	return h.deliver(h.clients[userID], d)
+}
+
+// Broadcast sends a message to every connection and returns how many received it
+func (h *Hub) Broadcast(eventType string, data any) int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	d, ok := h.encode(eventType, data)
+	if !ok {
+		return 0
+	}
+	h.remember(BroadcastChannel, d)
+	delivered := 0
+	for _, clients := range h.clients {
+		delivered += h.deliver(clients, d)
+	}
+	return delivered
+}
+
+// BroadcastEphemeral sends a message to every connection without storing it
+// for resume or catch-up, for short-lived state such as typing indicators
+func (h *Hub) BroadcastEphemeral(eventType string, data any) int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	d, ok := h.encode(eventType, data)
+	if !ok {
+		return 0
+	}
+	delivered := 0
+	for _, clients := range h.clients {
+		delivered += h.deliver(clients, d)
+	}
+	return delivered
+}
+
+// SessionRevoked notifies a user's clients that a session was revoked; an empty
+// sessionID means all of the user's sessions
+func (h *Hub) SessionRevoked(userID uint, sessionID string) {
+	h.PublishToUser(userID, EventSessionRevoked, map[string]string{"session_id": sessionID})
+}
+
+// Connections returns the number of open connections
+func (h *Hub) Connections() int {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return h.connections
+}
+
+// UserConnections returns the number of open connections for a user
+func (h *Hub) UserConnections(userID uint) int {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return len(h.clients[userID])
+}
+
+// Stats returns a snapshot of the connection and backpressure counters
+func (h *Hub) Stats() Stats {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return Stats{
+		Connections:     h.connections,
+		Users:           len(h.clients),
+		RejectedGlobal:  h.rejectedGlobal,
+		RejectedPerUser: h.rejectedPerUser,
+		DroppedMessages: h.droppedMessages,
+		SlowDisconnects: h.slowDisconnects,
+	}
+}
+
+// CloseAll disconnects every client, used during shutdown
+func (h *Hub) CloseAll() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for _, clients := range h.clients {
+		for c := range clients {
+			c.close()
+		}
+	}
+}
+
+// register adds a client and returns the retained messages published after
+// lastEventID that it should receive, so nothing is missed between replay and
+// live delivery. It fails with ErrConnectionLimit or ErrUserConnectionLimit
+// when a cap is reached.
+func (h *Hub) register(c *client, lastEventID uint64) ([]delivery, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.maxConnections > 0 && h.connections >= h.maxConnections {
+		h.rejectedGlobal++
+		return nil, ErrConnectionLimit
+	}
+	if h.maxPerUser > 0 && len(h.clients[c.userID]) >= h.maxPerUser {
+		h.rejectedPerUser++
+		return nil, ErrUserConnectionLimit
+	}
+	if h.clients[c.userID] == nil {
+		h.clients[c.userID] = make(map[*client]struct{})
+	}
+	h.clients[c.userID][c] = struct{}{}
+	h.connections++
+
+	if lastEventID == 0 || h.store == nil {
+		return nil, nil
+	}
+	return h.replay(c, lastEventID), nil
+}
+
+// replay loads the stored messages for a resuming client. If more are
+// stored than replayLimit, it ends with EventReplayTruncated naming the last
+// replayed ID. Callers must hold the write lock so no message published
+// meanwhile is missed.
+func (h *Hub) replay(c *client, lastEventID uint64) []delivery {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	events, err := h.store.Since(ctx, []string{UserChannel(c.userID), BroadcastChannel}, lastEventID, replayLimit)
+	if err != nil {
+		h.logger.Error("Failed to load realtime events for resume", "user_id", c.userID, "error", err.Error())
+		return nil
+	}
+
+	var replay []delivery
+	for _, event := range events {
+		if c.wants(event.Type) {
+			replay = append(replay, delivery{id: event.ID, eventType: event.Type, payload: event.Payload})
+		}
+	}
+	if len(events) == replayLimit {
+		lastID := events[len(events)-1].ID
+		payload, _ := json.Marshal(Message{ID: lastID, Type: EventReplayTruncated, Data: map[string]uint64{"after": lastID}, SentAt: h.clock.Now()})
+		replay = append(replay, delivery{id: lastID, eventType: EventReplayTruncated, payload: payload})
+	}
+	return replay
+}
+
+// CatchUpPage is a page of stored messages for a user
+type CatchUpPage struct {
+	Events []json.RawMessage `json:"events"`
+	// NextAfter is the after value for the next page
+	NextAfter uint64 `json:"next_after"`
+	HasMore   bool   `json:"has_more"`
+}
+
+// CatchUp returns up to limit stored messages for a user published after
+// afterID, filtered by topics, for clients that were offline too long to
+// resume over a stream
+func (h *Hub) CatchUp(ctx context.Context, userID uint, topics []string, afterID uint64, limit int) (*CatchUpPage, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	page := &CatchUpPage{Events: []json.RawMessage{}, NextAfter: afterID}
+	if h.store == nil {
+		return page, nil
+	}
+	events, err := h.store.Since(ctx, []string{UserChannel(userID), BroadcastChannel}, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := &client{topics: topics}
+	for _, event := range events {
+		if filter.wants(event.Type) {
+			page.Events = append(page.Events, json.RawMessage(event.Payload))
+		}
+		page.NextAfter = event.ID
+	}
+	page.HasMore = len(events) == limit
+	return page, nil
+}
+
+func (h *Hub) unregister(c *client) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if clients, ok := h.clients[c.userID]; ok {
+		if _, registered := clients[c]; registered {
+			h.connections--
+		}
+		delete(clients, c)
+		if len(clients) == 0 {
+			delete(h.clients, c.userID)
+		}
+	}
+	c.close()
+}
+
+// deliver queues the message for each subscribed client, applying the slow
+// consumer policy to clients whose buffer is full; callers must hold the write lock
+func (h *Hub) deliver(clients map[*client]struct{}, d delivery) int {
+	delivered := 0
+	for c := range clients {
+		if !c.wants(d.eventType) || c.closed() {
+			continue
+		}
+		select {
+		case c.send <- d:
+			delivered++
+			continue
+		default:
+		}
+
+		switch h.policy {
+		case PolicyDropNewest:
+			h.drop(c)
+		case PolicyDropOldest:
+			// The write loop may drain the buffer concurrently, so evicting can fail harmlessly
+			select {
+			case <-c.send:
+				h.drop(c)
+			default:
+			}
+			if c.closed() {
+				continue
+			}
+			select {
+			case c.send <- d:
+				delivered++
+			default:
+				h.drop(c)
+			}
+		default:
+			h.droppedMessages++
+			h.disconnectSlow(c)
+		}
+	}
+	return delivered
+}
+
+// drop records a message lost to backpressure and disconnects the client
+// once it has lost more than maxDropped
+func (h *Hub) drop(c *client) {
+	h.droppedMessages++
+	c.dropped++
+	if h.maxDropped > 0 && c.dropped > h.maxDropped {
+		h.disconnectSlow(c)
+	}
+}
+
+func (h *Hub) disconnectSlow(c *client) {
+	if c.closed() {
+		return
+	}
+	h.slowDisconnects++
+	h.logger.Warn("Disconnecting slow realtime client", "user_id", c.userID, "dropped", c.dropped, "policy", string(h.policy))
+	c.slow.Store(true)
+	c.close()
+}
+
+// remember appends the message to the event store; callers must hold the
+// write lock so messages are stored in ID order. Live delivery does not
+// depend on the store, so failures are only logged.
+func (h *Hub) remember(channel string, d delivery) {
+	if h.store == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	if err := h.store.Append(ctx, channel, Event{ID: d.id, Type: d.eventType, Payload: d.payload}); err != nil {
+		h.logger.Error("Failed to store realtime event", "id", d.id, "channel", channel, "error", err.Error())
+	}
+}
+
+// encode assigns the next message ID; callers must hold the write lock
+func (h *Hub) encode(eventType string, data any) (delivery, bool) {
+	h.sequence++
+	payload, err := json.Marshal(Message{ID: h.sequence, Type: eventType, Data: data, SentAt: h.clock.Now()})
+	if err != nil {
+		h.logger.Error("Failed to encode realtime message", "type", eventType, "error", err.Error())
+		return delivery{}, false
+	}
+	return delivery{id: h.sequence, eventType: eventType, payload: payload}, true
+}
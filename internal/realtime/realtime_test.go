@@ -0,0 +1,570 @@
+package realtime
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-server/internal/auth"
+	"go-server/internal/database/dbtest"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/logger"
+
+	"gorm.io/gorm"
+)
+
+func TestAcceptKey(t *testing.T) {
+	// Example from RFC 6455 section 1.3
+	if got := acceptKey("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("acceptKey() = %q", got)
+	}
+}
+
+// testAuth authenticates realtime clients the way the server does, against
+// users and sessions in an in-memory database
+type testAuth struct {
+	db       *gorm.DB
+	jwt      *auth.JWTManager
+	sessions *auth.SessionService
+}
+
+func newTestAuth(t *testing.T) *testAuth {
+	t.Helper()
+	db := dbtest.Open(t, &models.User{}, &models.Session{})
+	jm := auth.NewJWTManager("test-secret", time.Hour)
+	sessions := repositories.NewSessionRepository(db)
+	return &testAuth{
+		db:       db,
+		jwt:      jm,
+		sessions: auth.NewSessionService(repositories.NewUserRepository(db), nil, sessions, jm),
+	}
+}
+
+// token returns an access token for an active user with the ID, creating
+// the user the first time
+func (ta *testAuth) token(t *testing.T, userID uint) string {
+	t.Helper()
+	name := fmt.Sprintf("user%d", userID)
+	user := models.User{BaseModel: models.BaseModel{ID: userID}, Username: name, Email: name + "@example.com", Password: "x", IsActive: true}
+	if err := ta.db.FirstOrCreate(&user, userID).Error; err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	token, err := ta.jwt.GenerateToken(userID, name, user.Email, false)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	return token
+}
+
+// testClient is a minimal WebSocket client for exercising the server
+type testClient struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func dial(t *testing.T, serverURL, query string, header http.Header) (*testClient, int) {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(serverURL, "http://"))
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, serverURL+"/ws"+query, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Write(conn)
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		t.Fatalf("Reading handshake failed: %v", err)
+	}
+	return &testClient{conn: conn, reader: reader}, resp.StatusCode
+}
+
+func (tc *testClient) readFrame(t *testing.T) (Opcode, []byte) {
+	t.Helper()
+	tc.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var head [2]byte
+	if _, err := io.ReadFull(tc.reader, head[:]); err != nil {
+		t.Fatalf("Reading frame failed: %v", err)
+	}
+	length := int(head[1] & 0x7F)
+	if length == 126 {
+		var ext [2]byte
+		io.ReadFull(tc.reader, ext[:])
+		length = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, length)
+	io.ReadFull(tc.reader, payload)
+	return Opcode(head[0] & 0x0F), payload
+}
+
+func (tc *testClient) writeFrame(opcode Opcode, payload []byte) {
+	mask := [4]byte{1, 2, 3, 4}
+	frame := []byte{0x80 | byte(opcode), 0x80 | byte(len(payload))}
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	tc.conn.Write(frame)
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestHandler_PublishToUser(t *testing.T) {
+	authn := newTestAuth(t)
+	hub := NewHub(8, logger.NewServerLogger())
+	handler := NewHandler(hub, authn.sessions, HandlerConfig{AllowedOrigins: []string{"https://app.example.com"}}, logger.NewServerLogger())
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	token := authn.token(t, 42)
+
+	if _, status := dial(t, server.URL, "", nil); status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without a token, got %d", status)
+	}
+	if _, status := dial(t, server.URL, "?access_token="+token, http.Header{"Origin": {"https://evil.example.com"}}); status != http.StatusForbidden {
+		t.Errorf("Expected 403 for a foreign origin, got %d", status)
+	}
+
+	client, status := dial(t, server.URL, "", http.Header{
+		"Authorization": {"Bearer " + token},
+		"Origin":        {"https://app.example.com"},
+	})
+	if status != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected 101, got %d", status)
+	}
+	defer client.conn.Close()
+
+	waitFor(t, func() bool { return hub.UserConnections(42) == 1 })
+
+	if n := hub.PublishToUser(7, "other", nil); n != 0 {
+		t.Errorf("Message for another user should not be delivered, got %d", n)
+	}
+	hub.SessionRevoked(42, "abc")
+
+	opcode, payload := client.readFrame(t)
+	if opcode != OpText {
+		t.Fatalf("Expected text frame, got opcode %d", opcode)
+	}
+	var message struct {
+		Type string            `json:"type"`
+		Data map[string]string `json:"data"`
+	}
+	if err := json.Unmarshal(payload, &message); err != nil {
+		t.Fatalf("Invalid message %s: %v", payload, err)
+	}
+	if message.Type != EventSessionRevoked || message.Data["session_id"] != "abc" {
+		t.Errorf("Unexpected message %s", payload)
+	}
+
+	// Pings are answered and a close frame ends the connection
+	client.writeFrame(OpPing, []byte("hi"))
+	if opcode, payload := client.readFrame(t); opcode != OpPong || string(payload) != "hi" {
+		t.Errorf("Expected pong echoing the ping, got opcode %d payload %q", opcode, payload)
+	}
+	client.writeFrame(OpClose, []byte{0x03, 0xE8})
+	waitFor(t, func() bool { return hub.Connections() == 0 })
+}
+
+func TestHandler_RejectsServiceAccountTokens(t *testing.T) {
+	authn := newTestAuth(t)
+	hub := NewHub(8, logger.NewServerLogger())
+	token, _ := authn.jwt.GenerateServiceAccountToken(3, 1, "ci", []string{"posts:read"}, time.Hour)
+	bearer := http.Header{"Authorization": {"Bearer " + token}}
+
+	server := httptest.NewServer(NewHandler(hub, authn.sessions, HandlerConfig{AllowedOrigins: []string{"*"}}, logger.NewServerLogger()))
+	defer server.Close()
+	if _, status := dial(t, server.URL, "", bearer); status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a service account token on /ws, got %d", status)
+	}
+
+	events := httptest.NewServer(NewEventStreamHandler(hub, authn.sessions, HandlerConfig{AllowedOrigins: []string{"*"}}, logger.NewServerLogger()))
+	defer events.Close()
+	req, _ := http.NewRequest(http.MethodGet, events.URL+"/events", nil)
+	req.Header = bearer
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a service account token on /events, got %d", resp.StatusCode)
+	}
+	if hub.Connections() != 0 {
+		t.Errorf("Expected no connections, got %d", hub.Connections())
+	}
+}
+
+func TestHandler_ChecksSessionAndAccount(t *testing.T) {
+	authn := newTestAuth(t)
+	hub := NewHub(8, logger.NewServerLogger())
+	server := httptest.NewServer(NewHandler(hub, authn.sessions, HandlerConfig{AllowedOrigins: []string{"*"}}, logger.NewServerLogger()))
+	defer server.Close()
+	authn.token(t, 42)
+	ctx := context.Background()
+
+	// A token bound to a login session stops working once the session ends
+	sessions := repositories.NewSessionRepository(authn.db)
+	if err := sessions.CreateSession(ctx, &models.Session{UserID: 42, Token: "live", IsActive: true}); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	token, _ := authn.jwt.GenerateTokenForSession(42, "user42", "user42@example.com", false, "live")
+	client, status := dial(t, server.URL, "?access_token="+token, nil)
+	if status != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected 101 for a live session, got %d", status)
+	}
+	client.conn.Close()
+	if err := sessions.DeleteSession(ctx, 42, "live"); err != nil {
+		t.Fatalf("Failed to delete session: %v", err)
+	}
+	if _, status := dial(t, server.URL, "?access_token="+token, nil); status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 once the session ended, got %d", status)
+	}
+
+	// Deactivated users cannot connect with tokens issued before
+	token = authn.token(t, 7)
+	if err := authn.db.Model(&models.User{}).Where("id = ?", 7).Update("is_active", false).Error; err != nil {
+		t.Fatalf("Failed to deactivate user: %v", err)
+	}
+	if _, status := dial(t, server.URL, "?access_token="+token, nil); status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a deactivated user, got %d", status)
+	}
+}
+
+// readEvent reads the next event stream record, skipping comments and retry hints
+func readEvent(t *testing.T, reader *bufio.Reader) map[string]string {
+	t.Helper()
+	event := make(map[string]string)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Reading event failed: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			if _, ok := event["id"]; ok {
+				return event
+			}
+			event = make(map[string]string)
+			continue
+		}
+		if name, value, ok := strings.Cut(line, ": "); ok && !strings.HasPrefix(line, ":") {
+			event[name] = value
+		}
+	}
+}
+
+func TestEventStream_ResumesAndFiltersTopics(t *testing.T) {
+	authn := newTestAuth(t)
+	hub := NewHub(8, logger.NewServerLogger())
+	handler := NewEventStreamHandler(hub, authn.sessions, HandlerConfig{AllowedOrigins: []string{"*"}}, logger.NewServerLogger())
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	token := authn.token(t, 42)
+
+	hub.PublishToUser(42, "post.published", nil) // id 1, before the last seen event
+	hub.PublishToUser(42, "post.updated", nil)   // id 2
+	hub.PublishToUser(7, "post.updated", nil)    // id 3, another user
+	hub.PublishToUser(42, EventSessionRevoked, nil)
+	hub.Broadcast("post.deleted", nil) // id 5
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/events?topics=post", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Unexpected content type %q", resp.Header.Get("Content-Type"))
+	}
+	reader := bufio.NewReader(resp.Body)
+
+	for _, expected := range []string{"2:post.updated", "5:post.deleted"} {
+		event := readEvent(t, reader)
+		if got := event["id"] + ":" + event["event"]; got != expected {
+			t.Errorf("Expected replayed %s, got %s", expected, got)
+		}
+	}
+
+	waitFor(t, func() bool { return hub.UserConnections(42) == 1 })
+	hub.PublishToUser(42, EventSessionRevoked, nil)
+	hub.PublishToUser(42, "post.published", map[string]int{"id": 9})
+
+	event := readEvent(t, reader)
+	if event["id"] != "7" || event["event"] != "post.published" {
+		t.Errorf("Expected live post.published with id 7, got %v", event)
+	}
+	var message Message
+	if err := json.Unmarshal([]byte(event["data"]), &message); err != nil || message.ID != 7 {
+		t.Errorf("Unexpected data %q", event["data"])
+	}
+}
+
+func TestHandler_ConnectionLimits(t *testing.T) {
+	authn := newTestAuth(t)
+	hub := NewHub(8, logger.NewServerLogger()).WithLimits(2, 1)
+	server := httptest.NewServer(NewHandler(hub, authn.sessions, HandlerConfig{AllowedOrigins: []string{"*"}}, logger.NewServerLogger()))
+	defer server.Close()
+
+	bearer := func(userID uint) http.Header {
+		token := authn.token(t, userID)
+		return http.Header{"Authorization": {"Bearer " + token}}
+	}
+
+	first, status := dial(t, server.URL, "", bearer(1))
+	if status != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected 101, got %d", status)
+	}
+	defer first.conn.Close()
+	if _, status := dial(t, server.URL, "", bearer(1)); status != http.StatusTooManyRequests {
+		t.Errorf("Expected 429 over the per-user cap, got %d", status)
+	}
+
+	second, status := dial(t, server.URL, "", bearer(2))
+	if status != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected 101, got %d", status)
+	}
+	defer second.conn.Close()
+	if _, status := dial(t, server.URL, "", bearer(3)); status != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 over the global cap, got %d", status)
+	}
+
+	stats := hub.Stats()
+	if stats.Connections != 2 || stats.Users != 2 || stats.RejectedPerUser != 1 || stats.RejectedGlobal != 1 {
+		t.Errorf("Stats = %+v", stats)
+	}
+
+	// Closing a connection frees its slot
+	first.writeFrame(OpClose, []byte{0x03, 0xE8})
+	waitFor(t, func() bool { return hub.Connections() == 1 })
+	if _, status := dial(t, server.URL, "", bearer(3)); status != http.StatusSwitchingProtocols {
+		t.Errorf("Expected 101 after a slot was freed, got %d", status)
+	}
+}
+
+// stalledClient registers a client whose buffer is never drained
+func stalledClient(t *testing.T, hub *Hub, userID uint) *client {
+	t.Helper()
+	c := newClient(userID, nil, nil, hub.sendBuffer)
+	if _, err := hub.register(c, 0); err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	return c
+}
+
+func TestHub_SlowConsumerPolicies(t *testing.T) {
+	t.Run("disconnect", func(t *testing.T) {
+		hub := NewHub(2, logger.NewServerLogger())
+		c := stalledClient(t, hub, 1)
+		for i := 0; i < 3; i++ {
+			hub.PublishToUser(1, "tick", i)
+		}
+		if !c.closed() || !c.slow.Load() {
+			t.Error("Slow client should be disconnected on overflow")
+		}
+		if n := hub.PublishToUser(1, "tick", 4); n != 0 {
+			t.Errorf("Closed client should not receive messages, got %d", n)
+		}
+		if stats := hub.Stats(); stats.SlowDisconnects != 1 || stats.DroppedMessages != 1 {
+			t.Errorf("Stats = %+v", stats)
+		}
+	})
+
+	t.Run("drop oldest", func(t *testing.T) {
+		hub := NewHub(2, logger.NewServerLogger()).WithBackpressure(PolicyDropOldest, 0)
+		c := stalledClient(t, hub, 1)
+		for i := 1; i <= 4; i++ {
+			hub.PublishToUser(1, "tick", i)
+		}
+		if c.closed() {
+			t.Fatal("Client should stay connected without a drop limit")
+		}
+		if first := <-c.send; first.id != 3 {
+			t.Errorf("Oldest messages should be evicted, first queued id = %d", first.id)
+		}
+		if stats := hub.Stats(); stats.DroppedMessages != 2 {
+			t.Errorf("Stats = %+v", stats)
+		}
+	})
+
+	t.Run("drop newest with limit", func(t *testing.T) {
+		hub := NewHub(1, logger.NewServerLogger()).WithBackpressure(PolicyDropNewest, 2)
+		c := stalledClient(t, hub, 1)
+		for i := 1; i <= 3; i++ {
+			hub.PublishToUser(1, "tick", i)
+		}
+		if c.closed() {
+			t.Fatal("Client should survive dropping up to the limit")
+		}
+		if first := <-c.send; first.id != 1 {
+			t.Errorf("Newest messages should be discarded, first queued id = %d", first.id)
+		}
+		hub.PublishToUser(1, "tick", 4)
+		hub.PublishToUser(1, "tick", 5)
+		if !c.closed() {
+			t.Error("Client should be disconnected after exceeding the drop limit")
+		}
+		if stats := hub.Stats(); stats.DroppedMessages != 3 || stats.SlowDisconnects != 1 {
+			t.Errorf("Stats = %+v", stats)
+		}
+	})
+}
+
+func TestHub_StoreSurvivesRestartAndCatchUp(t *testing.T) {
+	store := NewMemoryStore(100)
+	hub := NewHub(8, logger.NewServerLogger()).WithStore(store)
+	hub.PublishToUser(42, "post.published", nil) // id 1
+	hub.PublishToUser(7, "post.published", nil)  // id 2, another user
+	hub.Broadcast("post.deleted", nil)           // id 3
+
+	// A new hub on the same store continues the IDs so resumes stay valid
+	restarted := NewHub(8, logger.NewServerLogger()).WithStore(store)
+	restarted.PublishToUser(42, EventSessionRevoked, nil) // id 4
+
+	page, err := restarted.CatchUp(context.Background(), 42, nil, 0, 2)
+	if err != nil {
+		t.Fatalf("CatchUp failed: %v", err)
+	}
+	if len(page.Events) != 2 || page.NextAfter != 3 || !page.HasMore {
+		t.Fatalf("First page = %+v", page)
+	}
+	page, _ = restarted.CatchUp(context.Background(), 42, []string{"session"}, page.NextAfter, 2)
+	if len(page.Events) != 1 || page.NextAfter != 4 || page.HasMore {
+		t.Fatalf("Second page = %+v", page)
+	}
+	var message Message
+	if err := json.Unmarshal(page.Events[0], &message); err != nil || message.ID != 4 || message.Type != EventSessionRevoked {
+		t.Errorf("Unexpected event %s", page.Events[0])
+	}
+}
+
+func TestHub_ReplayIsTruncatedAtLimit(t *testing.T) {
+	hub := NewHub(8, logger.NewServerLogger()).WithHistory(replayLimit + 10)
+	for i := 0; i < replayLimit+5; i++ {
+		hub.PublishToUser(42, "tick", i)
+	}
+
+	replay, err := hub.register(newClient(42, nil, nil, 8), 1)
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	last := replay[len(replay)-1]
+	if len(replay) != replayLimit+1 || last.eventType != EventReplayTruncated || last.id != replayLimit+1 {
+		t.Errorf("Expected %d replayed messages ending with a truncation marker, got %d ending with %s %d",
+			replayLimit, len(replay)-1, last.eventType, last.id)
+	}
+}
+
+func TestHandler_ResumesWebSocket(t *testing.T) {
+	authn := newTestAuth(t)
+	hub := NewHub(8, logger.NewServerLogger())
+	server := httptest.NewServer(NewHandler(hub, authn.sessions, HandlerConfig{AllowedOrigins: []string{"*"}}, logger.NewServerLogger()))
+	defer server.Close()
+
+	token := authn.token(t, 42)
+	hub.PublishToUser(42, "post.published", nil) // id 1
+	hub.PublishToUser(42, "post.updated", nil)   // id 2
+
+	client, status := dial(t, server.URL, "?last_event_id=1&access_token="+token, nil)
+	if status != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected 101, got %d", status)
+	}
+	defer client.conn.Close()
+
+	_, payload := client.readFrame(t)
+	var message Message
+	if err := json.Unmarshal(payload, &message); err != nil || message.ID != 2 {
+		t.Errorf("Expected replayed message 2, got %s", payload)
+	}
+}
+
+// recordingListener collects listener calls
+type recordingListener struct {
+	mu       sync.Mutex
+	calls    []string
+	messages []Inbound
+}
+
+func (rl *recordingListener) record(call string) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.calls = append(rl.calls, call)
+}
+
+func (rl *recordingListener) Connected(ctx context.Context, userID uint) { rl.record("connected") }
+
+func (rl *recordingListener) Disconnected(ctx context.Context, userID uint, remaining int) {
+	rl.record("disconnected")
+}
+
+func (rl *recordingListener) Received(ctx context.Context, userID uint, message Inbound) {
+	rl.mu.Lock()
+	rl.messages = append(rl.messages, message)
+	rl.mu.Unlock()
+	rl.record("received")
+}
+
+func (rl *recordingListener) count() int {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return len(rl.calls)
+}
+
+func TestHandler_Listener(t *testing.T) {
+	authn := newTestAuth(t)
+	hub := NewHub(8, logger.NewServerLogger())
+	listener := &recordingListener{}
+	handler := NewHandler(hub, authn.sessions, HandlerConfig{AllowedOrigins: []string{"*"}}, logger.NewServerLogger()).WithListener(listener)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	token := authn.token(t, 42)
+	client, status := dial(t, server.URL, "?access_token="+token, nil)
+	if status != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected 101, got %d", status)
+	}
+
+	client.writeFrame(OpText, []byte(`not json`))
+	client.writeFrame(OpText, []byte(`{"type":"presence.heartbeat"}`))
+	client.writeFrame(OpClose, []byte{0x03, 0xE8})
+	waitFor(t, func() bool { return listener.count() == 3 })
+
+	listener.mu.Lock()
+	defer listener.mu.Unlock()
+	if listener.calls[0] != "connected" || listener.calls[1] != "received" || listener.calls[2] != "disconnected" {
+		t.Errorf("Calls = %v", listener.calls)
+	}
+	if listener.messages[0].Type != "presence.heartbeat" {
+		t.Errorf("Messages = %+v", listener.messages)
+	}
+}
@@ -0,0 +1,103 @@
+package realtime
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisStore keeps each channel's messages in a Redis stream so they
+// survive restarts. Stream entry IDs are the message IDs, so a channel can
+// be read from any last-seen ID with XRANGE. Streams are capped at maxLen
+// entries and expire once a channel has been idle for the retention period.
+//
+// Message IDs are assigned by the hub, so one store prefix must not be
+// shared by several hubs publishing independently.
+type RedisStore struct {
+	client    *redis.Client
+	prefix    string
+	maxLen    int64
+	retention time.Duration
+}
+
+// NewRedisStore creates a Redis-backed event store
+func NewRedisStore(client *redis.Client, maxLen int64, retention time.Duration) *RedisStore {
+	if maxLen <= 0 {
+		maxLen = 1000
+	}
+	if retention <= 0 {
+		retention = 24 * time.Hour
+	}
+	return &RedisStore{client: client, prefix: "realtime:", maxLen: maxLen, retention: retention}
+}
+
+// Append adds the message to the channel's stream and refreshes its expiry
+func (rs *RedisStore) Append(ctx context.Context, channel string, event Event) error {
+	key := rs.prefix + "stream:" + channel
+	pipe := rs.client.TxPipeline()
+	pipe.XAdd(ctx, &redis.XAddArgs{
+		Stream: key,
+		MaxLen: rs.maxLen,
+		Approx: true,
+		ID:     strconv.FormatUint(event.ID, 10) + "-0",
+		Values: map[string]interface{}{"type": event.Type, "payload": event.Payload},
+	})
+	pipe.Expire(ctx, key, rs.retention)
+	pipe.Set(ctx, rs.prefix+"last_id", event.ID, 0)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to append realtime event %d: %w", event.ID, err)
+	}
+	return nil
+}
+
+// Since reads each channel's stream after afterID and merges them by ID
+func (rs *RedisStore) Since(ctx context.Context, channels []string, afterID uint64, limit int) ([]Event, error) {
+	count := int64(limit)
+	if count <= 0 {
+		count = rs.maxLen
+	}
+	start := strconv.FormatUint(afterID+1, 10) + "-0"
+
+	results := make([][]Event, 0, len(channels))
+	for _, channel := range channels {
+		messages, err := rs.client.XRangeN(ctx, rs.prefix+"stream:"+channel, start, "+", count).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read realtime events: %w", err)
+		}
+		events := make([]Event, 0, len(messages))
+		for _, message := range messages {
+			event, err := decodeStreamMessage(message)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, event)
+		}
+		results = append(results, events)
+	}
+	return mergeEvents(results, limit), nil
+}
+
+// LastID returns the highest ID appended, or 0 if none has been
+func (rs *RedisStore) LastID(ctx context.Context) (uint64, error) {
+	id, err := rs.client.Get(ctx, rs.prefix+"last_id").Uint64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return id, err
+}
+
+// decodeStreamMessage converts a stream entry with ID "<id>-0" to an event
+func decodeStreamMessage(message redis.XMessage) (Event, error) {
+	idPart, _, _ := strings.Cut(message.ID, "-")
+	id, err := strconv.ParseUint(idPart, 10, 64)
+	if err != nil {
+		return Event{}, fmt.Errorf("invalid realtime stream entry ID %q", message.ID)
+	}
+	eventType, _ := message.Values["type"].(string)
+	payload, _ := message.Values["payload"].(string)
+	return Event{ID: id, Type: eventType, Payload: []byte(payload)}, nil
+}
@@ -0,0 +1,124 @@
+package realtime
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-server/internal/logger"
+)
+
+// sseRetry is the reconnection delay suggested to EventSource clients
+const sseRetry = 3 * time.Second
+
+// EventStreamHandler serves the /events Server-Sent Events endpoint for
+// clients that cannot use WebSockets. It streams the same hub messages.
+type EventStreamHandler struct {
+	hub           *Hub
+	authenticator Authenticator
+	config        HandlerConfig
+	logger        logger.Logger
+}
+
+// NewEventStreamHandler creates a new SSE handler; PingInterval sets the heartbeat interval
+func NewEventStreamHandler(hub *Hub, authenticator Authenticator, config HandlerConfig, logger logger.Logger) *EventStreamHandler {
+	if config.PingInterval <= 0 {
+		config.PingInterval = 15 * time.Second
+	}
+	return &EventStreamHandler{
+		hub:           hub,
+		authenticator: authenticator,
+		config:        config,
+		logger:        logger,
+	}
+}
+
+// ServeHTTP streams events until the client disconnects. Messages published
+// after the Last-Event-ID header (or last_event_id query parameter) are replayed
+// first if the hub still retains them; the topics query parameter filters by
+// event type prefix.
+func (h *EventStreamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user, ok := authenticate(w, r, h.authenticator, h.config.AllowedOrigins)
+	if !ok {
+		return
+	}
+
+	resumeFrom, err := parseLastEventID(r)
+	if err != nil {
+		http.Error(w, "Invalid Last-Event-ID", http.StatusBadRequest)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	c := newClient(user.ID, parseTopics(r.URL.Query().Get("topics")), nil, h.hub.sendBuffer)
+	replay, err := h.hub.register(c, resumeFrom)
+	if err != nil {
+		rejectConnection(w, err)
+		return
+	}
+	defer h.hub.unregister(c)
+	h.logger.Debug("Event stream connected", "user_id", c.userID, "resume_from", resumeFrom, "replayed", len(replay))
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Connection", "keep-alive")
+	// Disable response buffering in nginx-style proxies
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	writeTimeout := 10 * time.Second
+	rc.SetWriteDeadline(time.Now().Add(writeTimeout))
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetry.Milliseconds())
+	for _, d := range replay {
+		writeEvent(w, d)
+	}
+	if err := rc.Flush(); err != nil {
+		h.logger.Error("Event stream flushing not supported", "error", err.Error())
+		return
+	}
+
+	ticker := time.NewTicker(h.config.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-c.done:
+			// A slow consumer reconnects with Last-Event-ID and is replayed what it missed
+			return
+		case d := <-c.send:
+			rc.SetWriteDeadline(time.Now().Add(writeTimeout))
+			writeEvent(w, d)
+		case <-ticker.C:
+			rc.SetWriteDeadline(time.Now().Add(writeTimeout))
+			fmt.Fprint(w, ": keep-alive\n\n")
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// parseLastEventID reads the Last-Event-ID header or last_event_id query
+// parameter; 0 means the client is not resuming
+func parseLastEventID(r *http.Request) (uint64, error) {
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	if lastEventID == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(lastEventID, 10, 64)
+}
+
+// writeEvent writes one message in event stream format; payloads are single-line JSON
+func writeEvent(w http.ResponseWriter, d delivery) {
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", d.id, d.eventType, d.payload)
+}
@@ -0,0 +1,121 @@
+package realtime
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// BroadcastChannel is the channel of messages sent to every client
+const BroadcastChannel = "broadcast"
+
+// UserChannel returns the channel of messages sent to one user
+func UserChannel(userID uint) string {
+	return "user:" + strconv.FormatUint(uint64(userID), 10)
+}
+
+// Event is a published message as kept by an EventStore
+type Event struct {
+	ID      uint64
+	Type    string
+	Payload []byte
+}
+
+// EventStore retains recently published messages per channel so clients
+// can resume after a disconnect or catch up after a long offline period
+type EventStore interface {
+	// Append records a message published on a channel
+	Append(ctx context.Context, channel string, event Event) error
+	// Since returns up to limit messages on any of the channels with IDs
+	// greater than afterID, oldest first
+	Since(ctx context.Context, channels []string, afterID uint64, limit int) ([]Event, error)
+	// LastID returns the highest ID appended, so message IDs keep increasing
+	// across restarts
+	LastID(ctx context.Context) (uint64, error)
+}
+
+// storedEvent is an event tagged with its channel
+type storedEvent struct {
+	Event
+	channel string
+}
+
+// MemoryStore is an in-process EventStore keeping the last size messages
+// across all channels. It is lost on restart.
+type MemoryStore struct {
+	mu     sync.Mutex
+	events []storedEvent
+	size   int
+	lastID uint64
+}
+
+// NewMemoryStore creates a store retaining size messages
+func NewMemoryStore(size int) *MemoryStore {
+	return &MemoryStore{size: size}
+}
+
+// Append records the message, evicting the oldest once full
+func (ms *MemoryStore) Append(ctx context.Context, channel string, event Event) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if event.ID > ms.lastID {
+		ms.lastID = event.ID
+	}
+	if ms.size <= 0 {
+		return nil
+	}
+	if len(ms.events) >= ms.size {
+		copy(ms.events, ms.events[1:])
+		ms.events = ms.events[:len(ms.events)-1]
+	}
+	ms.events = append(ms.events, storedEvent{Event: event, channel: channel})
+	return nil
+}
+
+// Since returns retained messages on the channels after afterID
+func (ms *MemoryStore) Since(ctx context.Context, channels []string, afterID uint64, limit int) ([]Event, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var events []Event
+	for _, stored := range ms.events {
+		if stored.ID <= afterID || !containsChannel(channels, stored.channel) {
+			continue
+		}
+		events = append(events, stored.Event)
+		if limit > 0 && len(events) == limit {
+			break
+		}
+	}
+	return events, nil
+}
+
+// LastID returns the highest ID appended
+func (ms *MemoryStore) LastID(ctx context.Context) (uint64, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.lastID, nil
+}
+
+func containsChannel(channels []string, channel string) bool {
+	for _, c := range channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeEvents merges per-channel results into ID order, keeping the first limit
+func mergeEvents(results [][]Event, limit int) []Event {
+	var events []Event
+	for _, result := range results {
+		events = append(events, result...)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	if limit > 0 && len(events) > limit {
+		events = events[:limit]
+	}
+	return events
+}
@@ -0,0 +1,152 @@
+// Package reporting sends production errors to an external error tracker.
+// Callers build a Report describing the failure; reporters fill in the
+// request context (request ID, user, route) carried on the context and the
+// release the server was built from, then ship it asynchronously.
+package reporting
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Level is the severity of a report
+type Level string
+
+// Report levels, matching the levels error trackers understand
+const (
+	LevelError   Level = "error"
+	LevelFatal   Level = "fatal"
+	LevelWarning Level = "warning"
+)
+
+// Frame is one call in a captured stack, innermost first
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// Report describes a failure worth a developer's attention: a recovered
+// panic, a 5xx response, a dead-lettered job or an internal auth failure
+type Report struct {
+	Err   error
+	Level Level
+	// Panic is set when Err was recovered from a panic
+	Panic bool
+	// Stack is the formatted goroutine stack and Frames the parsed calls;
+	// both are empty when the failure has no stack of its own
+	Stack      []byte
+	Frames     []Frame
+	StatusCode int
+	RequestID  string
+	Method     string
+	Path       string
+	UserID     uint
+	// Tags are indexed key/value pairs such as the job type or auth operation
+	Tags map[string]string
+	Time time.Time
+}
+
+// ErrorReporter ships reports to an external error tracker. Report may be
+// called on a request goroutine, so implementations should queue the report
+// rather than send it inline.
+type ErrorReporter interface {
+	Report(ctx context.Context, report Report)
+}
+
+// ReporterFunc adapts a function to ErrorReporter
+type ReporterFunc func(ctx context.Context, report Report)
+
+// Report calls f
+func (f ReporterFunc) Report(ctx context.Context, report Report) {
+	f(ctx, report)
+}
+
+// Scope is the request context attached to reports made while handling it
+type Scope struct {
+	RequestID string
+	Method    string
+	Path      string
+	UserID    uint
+}
+
+type scopeKey struct{}
+
+// WithScope attaches a scope to the context. Fields left empty keep the
+// value of any scope already attached, so later middleware can add the
+// user once they are authenticated.
+func WithScope(ctx context.Context, scope Scope) context.Context {
+	if parent, ok := ScopeFromContext(ctx); ok {
+		if scope.RequestID == "" {
+			scope.RequestID = parent.RequestID
+		}
+		if scope.Method == "" {
+			scope.Method = parent.Method
+		}
+		if scope.Path == "" {
+			scope.Path = parent.Path
+		}
+		if scope.UserID == 0 {
+			scope.UserID = parent.UserID
+		}
+	}
+	return context.WithValue(ctx, scopeKey{}, scope)
+}
+
+// ScopeFromContext returns the scope attached to the context
+func ScopeFromContext(ctx context.Context) (Scope, bool) {
+	scope, ok := ctx.Value(scopeKey{}).(Scope)
+	return scope, ok
+}
+
+// Enrich fills the report's empty request fields from the context's scope
+// and defaults its level and time
+func Enrich(ctx context.Context, report Report) Report {
+	if scope, ok := ScopeFromContext(ctx); ok {
+		if report.RequestID == "" {
+			report.RequestID = scope.RequestID
+		}
+		if report.Method == "" {
+			report.Method = scope.Method
+		}
+		if report.Path == "" {
+			report.Path = scope.Path
+		}
+		if report.UserID == 0 {
+			report.UserID = scope.UserID
+		}
+	}
+	if report.Level == "" {
+		report.Level = LevelError
+		if report.Panic {
+			report.Level = LevelFatal
+		}
+	}
+	if report.Time.IsZero() {
+		report.Time = time.Now().UTC()
+	}
+	return report
+}
+
+// CaptureFrames returns the current goroutine's stack, skipping skip
+// callers above CaptureFrames and any leading runtime frames, so that when
+// called from a deferred recover the stack starts at the panicking function
+func CaptureFrames(skip int) []Frame {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip+2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var stack []Frame
+	for {
+		frame, more := frames.Next()
+		if len(stack) > 0 || !strings.HasPrefix(frame.Function, "runtime.") {
+			stack = append(stack, Frame{Function: frame.Function, File: frame.File, Line: frame.Line})
+		}
+		if !more {
+			break
+		}
+	}
+	return stack
+}
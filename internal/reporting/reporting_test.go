@@ -0,0 +1,140 @@
+package reporting
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-server/internal/logger"
+)
+
+func TestParseDSN(t *testing.T) {
+	tests := []struct {
+		dsn      string
+		storeURL string
+		wantErr  bool
+	}{
+		{"https://abc@o1.ingest.sentry.io/42", "https://o1.ingest.sentry.io/api/42/store/", false},
+		{"http://abc@localhost:9000/sentry/7/", "http://localhost:9000/sentry/api/7/store/", false},
+		{"https://o1.ingest.sentry.io/42", "", true},
+		{"https://abc@o1.ingest.sentry.io/", "", true},
+		{"ftp://abc@o1.ingest.sentry.io/42", "", true},
+		{"not a dsn", "", true},
+	}
+
+	for _, tt := range tests {
+		dsn, err := ParseDSN(tt.dsn)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseDSN(%q) should fail", tt.dsn)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseDSN(%q) failed: %v", tt.dsn, err)
+			continue
+		}
+		if dsn.StoreURL != tt.storeURL || dsn.PublicKey != "abc" {
+			t.Errorf("ParseDSN(%q) = %+v, want store URL %s", tt.dsn, dsn, tt.storeURL)
+		}
+	}
+}
+
+func TestWithScope_KeepsParentFields(t *testing.T) {
+	ctx := WithScope(context.Background(), Scope{RequestID: "req-1", Method: http.MethodGet, Path: "/api/me"})
+	ctx = WithScope(ctx, Scope{UserID: 42})
+
+	report := Enrich(ctx, Report{Err: errors.New("boom")})
+	if report.RequestID != "req-1" || report.Path != "/api/me" || report.UserID != 42 {
+		t.Errorf("Report should carry the request scope, got %+v", report)
+	}
+	if report.Level != LevelError || report.Time.IsZero() {
+		t.Errorf("Report should default its level and time, got %+v", report)
+	}
+	if Enrich(ctx, Report{Panic: true}).Level != LevelFatal {
+		t.Error("Panics should be reported as fatal")
+	}
+}
+
+func TestSentryReporter_SendsEvents(t *testing.T) {
+	events := make(chan map[string]any, 1)
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/42/store/" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		auth = r.Header.Get("X-Sentry-Auth")
+		var event map[string]any
+		json.NewDecoder(r.Body).Decode(&event)
+		events <- event
+	}))
+	defer server.Close()
+
+	reporter, err := NewSentryReporter(SentryConfig{
+		DSN:         strings.Replace(server.URL, "http://", "http://public@", 1) + "/42",
+		Environment: "test",
+		Release:     "go-server@1.2.3",
+	}, logger.NewServerLogger())
+	if err != nil {
+		t.Fatalf("Failed to create reporter: %v", err)
+	}
+	reporter.Start(context.Background())
+	defer reporter.Stop()
+
+	ctx := WithScope(context.Background(), Scope{RequestID: "req-1", Method: http.MethodPost, Path: "/api/posts", UserID: 7})
+	reporter.Report(ctx, Report{
+		Err:    errors.New("database unavailable"),
+		Frames: []Frame{{Function: "go-server/internal/handlers.inner", Line: 2}, {Function: "main.outer", Line: 1}},
+		Tags:   map[string]string{"component": "test"},
+	})
+
+	var event map[string]any
+	select {
+	case event = <-events:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Event was not sent")
+	}
+
+	if !strings.Contains(auth, "sentry_key=public") {
+		t.Errorf("Auth header = %q", auth)
+	}
+	if event["release"] != "go-server@1.2.3" || event["environment"] != "test" || event["level"] != "error" {
+		t.Errorf("Event = %v", event)
+	}
+	if user, _ := event["user"].(map[string]any); user["id"] != "7" {
+		t.Errorf("Event should carry the user ID, got %v", event["user"])
+	}
+	tags, _ := event["tags"].(map[string]any)
+	if tags["request_id"] != "req-1" || tags["component"] != "test" {
+		t.Errorf("Tags = %v", tags)
+	}
+
+	exception := event["exception"].(map[string]any)["values"].([]any)[0].(map[string]any)
+	if exception["value"] != "database unavailable" {
+		t.Errorf("Exception = %v", exception)
+	}
+	frames := exception["stacktrace"].(map[string]any)["frames"].([]any)
+	if first := frames[0].(map[string]any); first["function"] != "main.outer" {
+		t.Errorf("Frames should be sent oldest first, got %v", frames)
+	}
+}
+
+func TestSentryReporter_DropsWhenQueueFull(t *testing.T) {
+	reporter, err := NewSentryReporter(SentryConfig{DSN: "https://key@localhost/1", QueueSize: 1}, logger.NewServerLogger())
+	if err != nil {
+		t.Fatalf("Failed to create reporter: %v", err)
+	}
+
+	// Not started, so nothing drains the queue
+	for i := 0; i < 3; i++ {
+		reporter.Report(context.Background(), Report{Err: errors.New("boom")})
+	}
+	if reporter.Dropped() != 2 {
+		t.Errorf("Expected 2 dropped reports, got %d", reporter.Dropped())
+	}
+}
@@ -0,0 +1,311 @@
+package reporting
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go-server/internal/logger"
+)
+
+// sentryClient identifies this reporter to Sentry
+const sentryClient = "go-server/1.0"
+
+// DSN is a parsed Sentry DSN, e.g. https://<key>@o1.ingest.sentry.io/42
+type DSN struct {
+	PublicKey string
+	ProjectID string
+	// StoreURL is the endpoint events are posted to
+	StoreURL string
+}
+
+// ParseDSN parses a Sentry DSN
+func ParseDSN(raw string) (*DSN, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Sentry DSN: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid Sentry DSN: scheme must be http or https")
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("invalid Sentry DSN: missing public key")
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid Sentry DSN: missing host")
+	}
+
+	path := strings.TrimSuffix(u.Path, "/")
+	slash := strings.LastIndex(path, "/")
+	projectID := path[slash+1:]
+	if _, err := strconv.ParseUint(projectID, 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid Sentry DSN: missing project ID")
+	}
+
+	return &DSN{
+		PublicKey: u.User.Username(),
+		ProjectID: projectID,
+		StoreURL:  fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, path[:slash], projectID),
+	}, nil
+}
+
+// SentryConfig holds Sentry reporter configuration
+type SentryConfig struct {
+	DSN         string
+	Environment string
+	// Release is the version of the server reporting, e.g. "go-server@1.4.2"
+	Release    string
+	ServerName string // Optional; defaults to the hostname
+	// QueueSize bounds the reports waiting to be sent; further reports are
+	// dropped until the queue drains
+	QueueSize int
+	Timeout   time.Duration
+	Client    *http.Client // Optional; defaults to a client with Timeout
+}
+
+// SentryReporter sends reports to Sentry's store API in the background
+type SentryReporter struct {
+	dsn    *DSN
+	config SentryConfig
+	client *http.Client
+	logger logger.Logger
+	queue  chan sentryEvent
+
+	dropped atomic.Int64
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewSentryReporter creates a Sentry reporter; call Start to begin sending
+func NewSentryReporter(config SentryConfig, logger logger.Logger) (*SentryReporter, error) {
+	dsn, err := ParseDSN(config.DSN)
+	if err != nil {
+		return nil, err
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 100
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+	if config.ServerName == "" {
+		config.ServerName, _ = os.Hostname()
+	}
+	client := config.Client
+	if client == nil {
+		client = &http.Client{Timeout: config.Timeout}
+	}
+
+	return &SentryReporter{
+		dsn:    dsn,
+		config: config,
+		client: client,
+		logger: logger,
+		queue:  make(chan sentryEvent, config.QueueSize),
+	}, nil
+}
+
+// Report implements ErrorReporter, queueing the report for sending
+func (sr *SentryReporter) Report(ctx context.Context, report Report) {
+	event := sr.newEvent(Enrich(ctx, report))
+	select {
+	case sr.queue <- event:
+	default:
+		sr.dropped.Add(1)
+	}
+}
+
+// Dropped returns how many reports were dropped because the queue was full
+func (sr *SentryReporter) Dropped() int64 {
+	return sr.dropped.Load()
+}
+
+// Start sends queued reports in the background
+func (sr *SentryReporter) Start(ctx context.Context) {
+	ctx, sr.cancel = context.WithCancel(ctx)
+
+	sr.wg.Add(1)
+	go func() {
+		defer sr.wg.Done()
+		for {
+			select {
+			case <-ctx.Done():
+				sr.drain()
+				return
+			case event := <-sr.queue:
+				sr.send(context.Background(), event)
+			}
+		}
+	}()
+
+	sr.logger.Info("Sentry reporter started", "environment", sr.config.Environment, "release", sr.config.Release)
+}
+
+// Stop sends the reports still queued and halts the sender
+func (sr *SentryReporter) Stop() {
+	if sr.cancel != nil {
+		sr.cancel()
+	}
+	sr.wg.Wait()
+}
+
+// drain sends whatever is queued without waiting for more
+func (sr *SentryReporter) drain() {
+	for {
+		select {
+		case event := <-sr.queue:
+			sr.send(context.Background(), event)
+		default:
+			return
+		}
+	}
+}
+
+func (sr *SentryReporter) send(ctx context.Context, event sentryEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		sr.logger.Error("Failed to encode Sentry event", "event_id", event.EventID, "error", err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sr.config.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sr.dsn.StoreURL, bytes.NewReader(body))
+	if err != nil {
+		sr.logger.Error("Failed to build Sentry request", "event_id", event.EventID, "error", err.Error())
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", fmt.Sprintf("Sentry sentry_version=7, sentry_client=%s, sentry_key=%s",
+		sentryClient, sr.dsn.PublicKey))
+
+	resp, err := sr.client.Do(req)
+	if err != nil {
+		sr.logger.Error("Failed to send Sentry event", "event_id", event.EventID, "error", err.Error())
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		sr.logger.Error("Sentry rejected event", "event_id", event.EventID, "status", resp.StatusCode)
+	}
+}
+
+// sentryEvent is the subset of Sentry's event payload the reporter sends
+type sentryEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Level       Level             `json:"level"`
+	Platform    string            `json:"platform"`
+	Release     string            `json:"release,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Exception   sentryExceptions  `json:"exception"`
+	Request     *sentryRequest    `json:"request,omitempty"`
+	User        *sentryUser       `json:"user,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Extra       map[string]any    `json:"extra,omitempty"`
+}
+
+type sentryExceptions struct {
+	Values []sentryException `json:"values"`
+}
+
+type sentryException struct {
+	Type       string            `json:"type"`
+	Value      string            `json:"value"`
+	Stacktrace *sentryStacktrace `json:"stacktrace,omitempty"`
+}
+
+type sentryStacktrace struct {
+	Frames []sentryFrame `json:"frames"`
+}
+
+type sentryFrame struct {
+	Function string `json:"function"`
+	Filename string `json:"filename"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+type sentryRequest struct {
+	Method string `json:"method,omitempty"`
+	URL    string `json:"url,omitempty"`
+}
+
+type sentryUser struct {
+	ID string `json:"id"`
+}
+
+// newEvent converts a report to a Sentry event
+func (sr *SentryReporter) newEvent(report Report) sentryEvent {
+	exception := sentryException{Type: "error", Value: "unknown error"}
+	if report.Err != nil {
+		exception.Type = fmt.Sprintf("%T", report.Err)
+		exception.Value = report.Err.Error()
+	}
+	if report.Panic {
+		exception.Type = "panic"
+	}
+	if len(report.Frames) > 0 {
+		// Sentry lists frames oldest first
+		frames := make([]sentryFrame, len(report.Frames))
+		for i, frame := range report.Frames {
+			frames[len(frames)-1-i] = sentryFrame{
+				Function: frame.Function,
+				Filename: frame.File,
+				Lineno:   frame.Line,
+				InApp:    strings.HasPrefix(frame.Function, "go-server/"),
+			}
+		}
+		exception.Stacktrace = &sentryStacktrace{Frames: frames}
+	}
+
+	tags := make(map[string]string, len(report.Tags)+1)
+	for key, value := range report.Tags {
+		tags[key] = value
+	}
+	if report.RequestID != "" {
+		tags["request_id"] = report.RequestID
+	}
+
+	event := sentryEvent{
+		EventID:     newEventID(),
+		Timestamp:   report.Time.UTC().Format(time.RFC3339Nano),
+		Level:       report.Level,
+		Platform:    "go",
+		Release:     sr.config.Release,
+		Environment: sr.config.Environment,
+		ServerName:  sr.config.ServerName,
+		Exception:   sentryExceptions{Values: []sentryException{exception}},
+		Tags:        tags,
+	}
+	if report.Method != "" || report.Path != "" {
+		event.Request = &sentryRequest{Method: report.Method, URL: report.Path}
+	}
+	if report.UserID != 0 {
+		event.User = &sentryUser{ID: strconv.FormatUint(uint64(report.UserID), 10)}
+	}
+	if report.StatusCode != 0 {
+		event.Extra = map[string]any{"status_code": report.StatusCode}
+	}
+	return event
+}
+
+// newEventID returns a random 32-character hex event ID
+func newEventID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
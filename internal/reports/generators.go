@@ -0,0 +1,204 @@
+package reports
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+)
+
+// dailyRowsUpTo is the longest period the user growth report breaks down
+// by day; longer periods are broken down by month
+const dailyRowsUpTo = 62 * 24 * time.Hour
+
+// topAuthorsLimit bounds the authors listed by the content report
+const topAuthorsLimit = 10
+
+// Document is a report's content, independent of the format it renders to
+type Document struct {
+	Title string
+	// Period describes the period covered, e.g. 2024-03-01 to 2024-03-31
+	Period   string
+	Sections []Section
+}
+
+// Section is a titled table of a report
+type Section struct {
+	Title   string
+	Columns []string
+	Rows    [][]string
+}
+
+// UserGrowth reports signups, deactivations and the number of accounts
+// over the period, by day or, for long periods, by month
+func UserGrowth(ctx context.Context, repo *repositories.ReportRepository, from, to time.Time) (*Document, error) {
+	signups, err := repo.SignupTimes(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	deactivations, err := repo.DeactivationTimes(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := repo.CountAccountsBefore(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+
+	label, next := "Day", func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+	start := from.UTC().Truncate(24 * time.Hour)
+	if to.Sub(from) > dailyRowsUpTo {
+		label, next = "Month", func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }
+		start = time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	section := Section{Title: "User growth", Columns: []string{label, "Signups", "Deactivations", "Accounts"}}
+	var totalSignups, totalDeactivations int
+	for bucket := start; bucket.Before(to); bucket = next(bucket) {
+		end := next(bucket)
+		signed := countBefore(&signups, end)
+		deactivated := countBefore(&deactivations, end)
+		accounts += int64(signed)
+		totalSignups += signed
+		totalDeactivations += deactivated
+
+		name := bucket.Format("2006-01-02")
+		if label == "Month" {
+			name = bucket.Format("2006-01")
+		}
+		section.Rows = append(section.Rows, []string{name, strconv.Itoa(signed), strconv.Itoa(deactivated), strconv.FormatInt(accounts, 10)})
+	}
+
+	summary := Section{
+		Title:   "Summary",
+		Columns: []string{"Metric", "Value"},
+		Rows: [][]string{
+			{"Signups", strconv.Itoa(totalSignups)},
+			{"Deactivations", strconv.Itoa(totalDeactivations)},
+			{"Accounts at end of period", strconv.FormatInt(accounts, 10)},
+		},
+	}
+	return &Document{Title: "User growth", Period: period(from, to), Sections: []Section{summary, section}}, nil
+}
+
+// ContentStats reports posts and comments created and published over the
+// period, posts by status and the most prolific authors
+func ContentStats(ctx context.Context, repo *repositories.ReportRepository, from, to time.Time) (*Document, error) {
+	postsCreated, err := repo.CountCreatedBetween(ctx, &models.Post{}, from, to)
+	if err != nil {
+		return nil, err
+	}
+	postsPublished, err := repo.CountPublishedBetween(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := repo.CountCreatedBetween(ctx, &models.Comment{}, from, to)
+	if err != nil {
+		return nil, err
+	}
+	byStatus, err := repo.CountPostsByStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	authors, err := repo.TopAuthors(ctx, from, to, topAuthorsLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Document{
+		Title:  "Content statistics",
+		Period: period(from, to),
+		Sections: []Section{
+			{
+				Title:   "Summary",
+				Columns: []string{"Metric", "Value"},
+				Rows: [][]string{
+					{"Posts created", strconv.FormatInt(postsCreated, 10)},
+					{"Posts published", strconv.FormatInt(postsPublished, 10)},
+					{"Comments created", strconv.FormatInt(comments, 10)},
+				},
+			},
+			namedCounts("Posts by status (current)", "Status", "Posts", byStatus),
+			namedCounts("Top authors", "Author", "Posts published", authors),
+		},
+	}, nil
+}
+
+// SecuritySummary reports abuse bans, break-glass use and deactivations
+// over the period, with the current legal holds and staff accounts
+func SecuritySummary(ctx context.Context, repo *repositories.ReportRepository, from, to time.Time) (*Document, error) {
+	bans, err := repo.CountAbuseBansByReason(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	grants, err := repo.CountCreatedBetween(ctx, &models.BreakGlassGrant{}, from, to)
+	if err != nil {
+		return nil, err
+	}
+	accesses, err := repo.CountCreatedBetween(ctx, &models.BreakGlassAccess{}, from, to)
+	if err != nil {
+		return nil, err
+	}
+	deactivations, err := repo.DeactivationTimes(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	holds, err := repo.CountActiveLegalHolds(ctx)
+	if err != nil {
+		return nil, err
+	}
+	staff, err := repo.CountStaffByRole(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var banned int64
+	for _, ban := range bans {
+		banned += ban.Count
+	}
+
+	return &Document{
+		Title:  "Security summary",
+		Period: period(from, to),
+		Sections: []Section{
+			{
+				Title:   "Summary",
+				Columns: []string{"Metric", "Value"},
+				Rows: [][]string{
+					{"Abuse bans", strconv.FormatInt(banned, 10)},
+					{"Break-glass grants", strconv.FormatInt(grants, 10)},
+					{"Break-glass accesses", strconv.FormatInt(accesses, 10)},
+					{"Accounts deactivated", strconv.Itoa(len(deactivations))},
+					{"Active legal holds", strconv.FormatInt(holds, 10)},
+				},
+			},
+			namedCounts("Abuse bans by reason", "Reason", "Bans", bans),
+			namedCounts("Staff accounts by role (current)", "Role", "Accounts", staff),
+		},
+	}, nil
+}
+
+// countBefore counts and drops the leading times before end from sorted
+func countBefore(sorted *[]time.Time, end time.Time) int {
+	n := 0
+	for n < len(*sorted) && (*sorted)[n].Before(end) {
+		n++
+	}
+	*sorted = (*sorted)[n:]
+	return n
+}
+
+func namedCounts(title, nameColumn, countColumn string, counts []repositories.NamedCount) Section {
+	section := Section{Title: title, Columns: []string{nameColumn, countColumn}}
+	for _, c := range counts {
+		section.Rows = append(section.Rows, []string{c.Name, strconv.FormatInt(c.Count, 10)})
+	}
+	return section
+}
+
+// period describes [from, to) by its first and last days
+func period(from, to time.Time) string {
+	return from.UTC().Format("2006-01-02") + " to " + to.UTC().Add(-time.Nanosecond).Format("2006-01-02")
+}
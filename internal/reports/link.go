@@ -0,0 +1,17 @@
+package reports
+
+import (
+	"errors"
+	"time"
+
+	"go-server/internal/security"
+)
+
+// ErrDownloadsDisabled is returned when no link secret is configured
+var ErrDownloadsDisabled = errors.New("report downloads are disabled")
+
+// NewLinkSigner creates a signer of download links, which carry the report
+// they were issued for and are valid for ttl
+func NewLinkSigner(secret string, ttl time.Duration) *security.LinkSigner {
+	return security.NewLinkSigner("report-download", "report", secret, ttl)
+}
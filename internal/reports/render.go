@@ -0,0 +1,213 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteCSV renders a document as CSV: a row with the title and period, then
+// each section as a row with its title, a header row and its rows, with an
+// empty row between sections
+func WriteCSV(w io.Writer, doc *Document) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{doc.Title, doc.Period})
+	for _, section := range doc.Sections {
+		cw.Write([]string{""})
+		cw.Write([]string{section.Title})
+		cw.Write(section.Columns)
+		for _, row := range section.Rows {
+			cw.Write(row)
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// PDF page layout, in points: A4 with Courier 9pt, whose glyphs are all
+// 0.6em wide, so tables line up as they do in a terminal
+const (
+	pdfPageWidth   = 595
+	pdfPageHeight  = 842
+	pdfMargin      = 50
+	pdfFontSize    = 9
+	pdfLeading     = 11
+	pdfLineChars   = (pdfPageWidth - 2*pdfMargin) * 10 / (pdfFontSize * 6)
+	pdfPageLines   = (pdfPageHeight - 2*pdfMargin) / pdfLeading
+	pdfColumnChars = 40
+)
+
+// pdfLine is one line of text, in bold or not
+type pdfLine struct {
+	text string
+	bold bool
+}
+
+// WritePDF renders a document as a PDF of plain text tables. It needs no
+// fonts of its own: Courier is one of the standard fonts every reader has.
+func WritePDF(w io.Writer, doc *Document) error {
+	lines := []pdfLine{{text: printable(doc.Title), bold: true}, {text: printable(doc.Period)}}
+	for _, section := range doc.Sections {
+		lines = append(lines, pdfLine{}, pdfLine{text: printable(section.Title), bold: true})
+		columns := printableRow(section.Columns)
+		rows := make([][]string, len(section.Rows))
+		for i, row := range section.Rows {
+			rows[i] = printableRow(row)
+		}
+		widths := columnWidths(columns, rows)
+		lines = append(lines, pdfLine{text: tableRow(columns, widths), bold: true})
+		for _, row := range rows {
+			lines = append(lines, pdfLine{text: tableRow(row, widths)})
+		}
+		if len(section.Rows) == 0 {
+			lines = append(lines, pdfLine{text: "(none)"})
+		}
+	}
+
+	var pages [][]pdfLine
+	for len(lines) > pdfPageLines {
+		pages = append(pages, lines[:pdfPageLines])
+		lines = lines[pdfPageLines:]
+	}
+	pages = append(pages, lines)
+
+	pw := &pdfWriter{w: w}
+	pw.printf("%%PDF-1.4\n")
+	// Objects 1-4 are the catalog, the page tree and the two fonts; each
+	// page is then a page object followed by its content stream
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	pw.object("<< /Type /Catalog /Pages 2 0 R >>")
+	pw.object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	pw.object("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	pw.object("<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range pages {
+		pw.object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 6+2*i))
+		content := pageContent(page)
+		pw.object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+
+	xref := pw.n
+	pw.printf("xref\n0 %d\n0000000000 65535 f \n", len(pw.offsets)+1)
+	for _, offset := range pw.offsets {
+		pw.printf("%010d 00000 n \n", offset)
+	}
+	pw.printf("trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(pw.offsets)+1, xref)
+	return pw.err
+}
+
+// pdfWriter writes numbered objects, keeping their offsets for the xref
+// table
+type pdfWriter struct {
+	w       io.Writer
+	n       int
+	offsets []int
+	err     error
+}
+
+func (pw *pdfWriter) printf(format string, args ...interface{}) {
+	if pw.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(pw.w, format, args...)
+	pw.n += n
+	pw.err = err
+}
+
+func (pw *pdfWriter) object(body string) {
+	pw.offsets = append(pw.offsets, pw.n)
+	pw.printf("%d 0 obj\n%s\nendobj\n", len(pw.offsets), body)
+}
+
+// pageContent draws a page's lines from the top margin down
+func pageContent(lines []pdfLine) string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "BT\n%d TL\n%d %d Td\n", pdfLeading, pdfMargin, pdfPageHeight-pdfMargin-pdfFontSize)
+	for _, line := range lines {
+		font := "F1"
+		if line.bold {
+			font = "F2"
+		}
+		fmt.Fprintf(&b, "/%s %d Tf\n(%s) Tj\nT*\n", font, pdfFontSize, pdfEscape(truncate(line.text, pdfLineChars)))
+	}
+	b.WriteString("ET")
+	return b.String()
+}
+
+func columnWidths(columns []string, rows [][]string) []int {
+	widths := make([]int, len(columns))
+	for i, column := range columns {
+		widths[i] = len(column)
+	}
+	for _, row := range rows {
+		for i := range widths {
+			if i < len(row) && len(row[i]) > widths[i] {
+				widths[i] = len(row[i])
+			}
+		}
+	}
+	for i := range widths {
+		if widths[i] > pdfColumnChars {
+			widths[i] = pdfColumnChars
+		}
+	}
+	return widths
+}
+
+// tableRow pads cells to their column widths, leaving the last unpadded
+func tableRow(cells []string, widths []int) string {
+	parts := make([]string, len(widths))
+	for i, width := range widths {
+		cell := ""
+		if i < len(cells) {
+			cell = truncate(cells[i], width)
+		}
+		if i < len(widths)-1 {
+			cell += strings.Repeat(" ", width-len(cell))
+		}
+		parts[i] = cell
+	}
+	return strings.Join(parts, "  ")
+}
+
+// truncate shortens s to n characters, marking the cut with "..."
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	if n <= 3 {
+		return s[:n]
+	}
+	return s[:n-3] + "..."
+}
+
+// printable replaces anything outside printable ASCII with '?': the
+// standard fonts cover little more, and one byte per character keeps the
+// columns aligned
+func printable(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e {
+			return '?'
+		}
+		return r
+	}, s)
+}
+
+func printableRow(cells []string) []string {
+	row := make([]string, len(cells))
+	for i, cell := range cells {
+		row[i] = printable(cell)
+	}
+	return row
+}
+
+// pdfEscape escapes a PDF string literal
+func pdfEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(s)
+}
@@ -0,0 +1,319 @@
+// Package reports renders reports for administrators: user growth,
+// content statistics and a security summary over a period.
+//
+// A request is recorded as pending and rendered by a background job to CSV
+// or PDF, which is written to object storage. Administrators poll the
+// report until it completes and then download it through a signed link
+// that expires after a few minutes, so links can be handed to a browser or
+// a spreadsheet without an Authorization header. Rendered files are
+// deleted after the retention period.
+package reports
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/jobs"
+	"go-server/internal/logger"
+	"go-server/internal/scheduler"
+	"go-server/internal/security"
+	"go-server/internal/storage"
+)
+
+// JobTypeGenerate is the job type that renders a requested report
+const JobTypeGenerate = "reports.generate"
+
+// TaskPurge is the scheduled task deleting expired reports
+const TaskPurge = "reports.purge"
+
+// purgeBatchSize bounds how many reports one purge pass loads at a time
+const purgeBatchSize = 100
+
+// Report errors
+var (
+	ErrUnknownType   = errors.New("unknown report type")
+	ErrUnknownFormat = errors.New("unknown report format")
+	ErrInvalidPeriod = errors.New("invalid report period")
+	// ErrNotReady is returned when downloading a report that has not
+	// completed, or whose file has expired
+	ErrNotReady = errors.New("report is not ready")
+)
+
+// Generator gathers a report's content for the period [from, to)
+type Generator func(ctx context.Context, repo *repositories.ReportRepository, from, to time.Time) (*Document, error)
+
+var generators = map[string]Generator{
+	models.ReportUserGrowth:      UserGrowth,
+	models.ReportContentStats:    ContentStats,
+	models.ReportSecuritySummary: SecuritySummary,
+}
+
+var contentTypes = map[string]string{
+	models.ReportFormatCSV: "text/csv; charset=utf-8",
+	models.ReportFormatPDF: "application/pdf",
+}
+
+// Types lists the report types, sorted
+func Types() []string {
+	types := make([]string, 0, len(generators))
+	for name := range generators {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Formats lists the formats reports render to
+func Formats() []string {
+	return []string{models.ReportFormatCSV, models.ReportFormatPDF}
+}
+
+// ContentType returns the media type of a format
+func ContentType(format string) string {
+	return contentTypes[format]
+}
+
+// Config holds report configuration
+type Config struct {
+	// LinkSecret signs download links; empty disables downloads
+	LinkSecret string
+	LinkTTL    time.Duration
+	// Retention is how long a rendered report is kept
+	Retention time.Duration
+	// MaxPeriod bounds the period one report covers
+	MaxPeriod time.Duration
+	Clock     clock.Clock // Optional; defaults to the system clock
+}
+
+// Request describes a report an administrator asks for
+type Request struct {
+	Type   string
+	Format string
+	// From and To bound the period; To is exclusive
+	From          time.Time
+	To            time.Time
+	RequestedByID uint
+}
+
+// GeneratePayload is the payload of a generate job
+type GeneratePayload struct {
+	ReportID uint `json:"report_id"`
+}
+
+// Service records report requests, renders them from the job queue and
+// serves the rendered files
+type Service struct {
+	repo   *repositories.ReportRepository
+	store  storage.Store
+	pool   *jobs.Pool
+	config Config
+	links  *security.LinkSigner
+	clock  clock.Clock
+	logger logger.Logger
+}
+
+// NewService creates a new report service writing to store and rendering
+// on pool
+func NewService(repo *repositories.ReportRepository, store storage.Store, pool *jobs.Pool, config Config, logger logger.Logger) *Service {
+	if config.LinkTTL <= 0 {
+		config.LinkTTL = 15 * time.Minute
+	}
+	if config.Retention <= 0 {
+		config.Retention = 7 * 24 * time.Hour
+	}
+	if config.MaxPeriod <= 0 {
+		config.MaxPeriod = 366 * 24 * time.Hour
+	}
+	c := clock.OrDefault(config.Clock)
+	return &Service{
+		repo:   repo,
+		store:  store,
+		pool:   pool,
+		config: config,
+		links:  NewLinkSigner(config.LinkSecret, config.LinkTTL).WithClock(c),
+		clock:  c,
+		logger: logger,
+	}
+}
+
+// Register installs the generate job handler on the pool
+func (s *Service) Register() {
+	s.pool.Register(JobTypeGenerate, s.Handle)
+}
+
+// Links returns the signer of download links
+func (s *Service) Links() *security.LinkSigner {
+	return s.links
+}
+
+// Request records a report request and queues it for rendering
+func (s *Service) Request(ctx context.Context, req Request) (*models.Report, error) {
+	if _, ok := generators[req.Type]; !ok {
+		return nil, ErrUnknownType
+	}
+	if _, ok := contentTypes[req.Format]; !ok {
+		return nil, ErrUnknownFormat
+	}
+	if !req.To.After(req.From) || req.To.Sub(req.From) > s.config.MaxPeriod {
+		return nil, ErrInvalidPeriod
+	}
+
+	report := &models.Report{
+		Type:          req.Type,
+		Format:        req.Format,
+		PeriodStart:   req.From.UTC(),
+		PeriodEnd:     req.To.UTC(),
+		Status:        models.ReportStatusPending,
+		RequestedByID: req.RequestedByID,
+	}
+	if err := s.repo.CreateReport(ctx, report); err != nil {
+		return nil, fmt.Errorf("failed to record report request: %w", err)
+	}
+
+	if _, err := s.pool.Enqueue(ctx, JobTypeGenerate, GeneratePayload{ReportID: report.ID}); err != nil {
+		if recordErr := s.repo.FailReport(ctx, report.ID, "could not be queued", true); recordErr != nil {
+			s.logger.Error("Failed to record report failure", "report_id", report.ID, "error", recordErr.Error())
+		}
+		return nil, fmt.Errorf("failed to enqueue report: %w", err)
+	}
+	s.logger.Info("Report requested", "report_id", report.ID, "type", report.Type, "format", report.Format)
+	return report, nil
+}
+
+// Handle renders one report; returning an error schedules a retry, and the
+// report fails once the job has no attempt left
+func (s *Service) Handle(ctx context.Context, job *jobs.Job) error {
+	var payload GeneratePayload
+	if err := job.Decode(&payload); err != nil {
+		return fmt.Errorf("invalid report payload: %w", err)
+	}
+
+	report, err := s.repo.GetReportByID(ctx, payload.ReportID)
+	if err != nil {
+		s.logger.Warn("Skipping missing report", "report_id", payload.ReportID, "error", err.Error())
+		return nil
+	}
+	if report.Status != models.ReportStatusPending && report.Status != models.ReportStatusRunning {
+		// Already rendered by an earlier delivery of the job
+		return nil
+	}
+
+	if err := s.render(ctx, report); err != nil {
+		final := job.Attempts >= job.MaxAttempts
+		if recordErr := s.repo.FailReport(ctx, report.ID, err.Error(), final); recordErr != nil {
+			s.logger.Error("Failed to record report failure", "report_id", report.ID, "error", recordErr.Error())
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *Service) render(ctx context.Context, report *models.Report) error {
+	if err := s.repo.StartReport(ctx, report.ID, s.clock.Now()); err != nil {
+		return fmt.Errorf("failed to start report: %w", err)
+	}
+
+	doc, err := generators[report.Type](ctx, s.repo, report.PeriodStart, report.PeriodEnd)
+	if err != nil {
+		return fmt.Errorf("failed to gather report data: %w", err)
+	}
+
+	var buf bytes.Buffer
+	switch report.Format {
+	case models.ReportFormatCSV:
+		err = WriteCSV(&buf, doc)
+	case models.ReportFormatPDF:
+		err = WritePDF(&buf, doc)
+	default:
+		err = ErrUnknownFormat
+	}
+	if err != nil {
+		return fmt.Errorf("failed to render report: %w", err)
+	}
+
+	key := fmt.Sprintf("reports/%d/%s", report.ID, report.Filename())
+	size := int64(buf.Len())
+	if err := s.store.Put(ctx, key, &buf, size, ContentType(report.Format)); err != nil {
+		return fmt.Errorf("failed to store report: %w", err)
+	}
+
+	now := s.clock.Now()
+	if err := s.repo.CompleteReport(ctx, report.ID, key, size, now, now.Add(s.config.Retention)); err != nil {
+		return fmt.Errorf("failed to complete report: %w", err)
+	}
+	s.logger.Info("Report completed", "report_id", report.ID, "size", size)
+	return nil
+}
+
+// DownloadLink returns the query parameters of a download link for a
+// completed report, and when the link expires
+func (s *Service) DownloadLink(report *models.Report) (url.Values, time.Time, error) {
+	if !s.links.Enabled() {
+		return nil, time.Time{}, ErrDownloadsDisabled
+	}
+	if report.Status != models.ReportStatusCompleted {
+		return nil, time.Time{}, ErrNotReady
+	}
+	query, expiresAt := s.links.Sign(report.ID)
+	return query, expiresAt, nil
+}
+
+// Open returns a completed report's file; the caller must close it
+func (s *Service) Open(ctx context.Context, report *models.Report) (io.ReadCloser, error) {
+	if report.Status != models.ReportStatusCompleted || report.ObjectKey == "" {
+		return nil, ErrNotReady
+	}
+	file, err := s.store.Open(ctx, report.ObjectKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrNotReady
+	}
+	return file, err
+}
+
+// PurgeExpired deletes the files of reports past their retention and
+// returns how many were deleted
+func (s *Service) PurgeExpired(ctx context.Context) (int, error) {
+	purged := 0
+	for {
+		expired, err := s.repo.ListExpiredReports(ctx, s.clock.Now(), purgeBatchSize)
+		if err != nil {
+			return purged, fmt.Errorf("failed to list expired reports: %w", err)
+		}
+		for i := range expired {
+			if err := s.store.Delete(ctx, expired[i].ObjectKey); err != nil {
+				return purged, fmt.Errorf("failed to delete report %d: %w", expired[i].ID, err)
+			}
+			if err := s.repo.ExpireReport(ctx, expired[i].ID); err != nil {
+				return purged, fmt.Errorf("failed to expire report %d: %w", expired[i].ID, err)
+			}
+			purged++
+		}
+		if len(expired) < purgeBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+// RegisterTasks schedules the purge of expired reports; an empty schedule
+// disables it
+func RegisterTasks(sched *scheduler.Scheduler, s *Service, purgeSchedule string) error {
+	if purgeSchedule == "" {
+		return nil
+	}
+	return sched.Register(TaskPurge, purgeSchedule, func(ctx context.Context) error {
+		purged, err := s.PurgeExpired(ctx)
+		if purged > 0 {
+			s.logger.Info("Expired reports purged", "purged", purged)
+		}
+		return err
+	})
+}
@@ -0,0 +1,308 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/dbtest"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/jobs"
+	"go-server/internal/logger"
+	"go-server/internal/security"
+	"go-server/internal/storage"
+
+	"gorm.io/gorm"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+type testEnv struct {
+	svc   *Service
+	repo  *repositories.ReportRepository
+	db    *gorm.DB
+	queue *jobs.MemoryQueue
+	clock *clock.Fake
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	db := dbtest.Open(t, &models.Report{}, &models.User{}, &models.Post{}, &models.Comment{},
+		&models.AbuseBan{}, &models.BreakGlassGrant{}, &models.BreakGlassAccess{}, &models.LegalHold{})
+
+	fake := clock.NewFake(time.Date(2024, 4, 2, 9, 0, 0, 0, time.UTC))
+	queue := jobs.NewMemoryQueue()
+	pool := jobs.NewPool(queue, jobs.PoolConfig{MaxAttempts: 2, Clock: fake}, logger.NewServerLogger())
+	repo := repositories.NewReportRepository(db)
+	store := storage.NewLocalStore(t.TempDir(), "reports")
+	svc := NewService(repo, store, pool, Config{LinkSecret: testSecret, Clock: fake}, logger.NewServerLogger())
+	svc.Register()
+	return &testEnv{svc: svc, repo: repo, db: db, queue: queue, clock: fake}
+}
+
+// runJob takes the next job off the queue and handles it as attempt n
+func (e *testEnv) runJob(t *testing.T, attempt int) error {
+	t.Helper()
+	job, err := e.queue.Dequeue(context.Background(), 10*time.Millisecond)
+	if err != nil || job == nil {
+		t.Fatalf("Expected a queued job, got %v, %v", job, err)
+	}
+	if job.Type != JobTypeGenerate {
+		t.Fatalf("Expected a %s job, got %s", JobTypeGenerate, job.Type)
+	}
+	job.Attempts, job.MaxAttempts = attempt, 2
+	return e.svc.Handle(context.Background(), job)
+}
+
+func (e *testEnv) download(t *testing.T, report *models.Report) string {
+	t.Helper()
+	file, err := e.svc.Open(context.Background(), report)
+	if err != nil {
+		t.Fatalf("Open = %v", err)
+	}
+	defer file.Close()
+	body, _ := io.ReadAll(file)
+	return string(body)
+}
+
+func march() (time.Time, time.Time) {
+	return time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func TestService_UserGrowthCSV(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	deactivated := time.Date(2024, 3, 3, 12, 0, 0, 0, time.UTC)
+	for i, created := range []time.Time{
+		time.Date(2024, 2, 10, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 2, 8, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 2, 20, 0, 0, 0, time.UTC),
+	} {
+		user := &models.User{Username: fmt.Sprintf("user%d", i), Email: fmt.Sprintf("user%d@example.com", i), IsActive: true}
+		user.CreatedAt = created
+		if i == 1 {
+			user.DeactivatedAt = &deactivated
+		}
+		if err := env.db.Create(user).Error; err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+
+	from, to := march()
+	report, err := env.svc.Request(ctx, Request{Type: models.ReportUserGrowth, Format: models.ReportFormatCSV, From: from, To: to, RequestedByID: 1})
+	if err != nil {
+		t.Fatalf("Request = %v", err)
+	}
+	if report.Status != models.ReportStatusPending {
+		t.Errorf("Expected a pending report, got %s", report.Status)
+	}
+	if _, _, err := env.svc.DownloadLink(report); !errors.Is(err, ErrNotReady) {
+		t.Errorf("Expected no download link before completion, got %v", err)
+	}
+
+	if err := env.runJob(t, 1); err != nil {
+		t.Fatalf("Handle = %v", err)
+	}
+	report, _ = env.repo.GetReportByID(ctx, report.ID)
+	if report.Status != models.ReportStatusCompleted || report.Attempts != 1 || report.CompletedAt == nil {
+		t.Fatalf("Expected a completed report, got %+v", report)
+	}
+	if want := env.clock.Now().Add(7 * 24 * time.Hour); report.ExpiresAt == nil || !report.ExpiresAt.Equal(want) {
+		t.Errorf("Expected the report to expire at %v, got %v", want, report.ExpiresAt)
+	}
+
+	body := env.download(t, report)
+	if int64(len(body)) != report.Size {
+		t.Errorf("Expected %d bytes, got %d", report.Size, len(body))
+	}
+	for _, want := range []string{
+		"User growth,2024-03-01 to 2024-03-31\n",
+		"Signups,2\n",
+		"Deactivations,1\n",
+		"Accounts at end of period,3\n",
+		"Day,Signups,Deactivations,Accounts\n",
+		"2024-03-01,0,0,1\n",
+		"2024-03-02,2,0,3\n",
+		"2024-03-03,0,1,3\n",
+		"2024-03-31,0,0,3\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected the CSV to contain %q, got:\n%s", want, body)
+		}
+	}
+
+	// A second delivery of the job leaves the completed report alone
+	env.svc.pool.Enqueue(ctx, JobTypeGenerate, GeneratePayload{ReportID: report.ID})
+	if err := env.runJob(t, 1); err != nil {
+		t.Fatalf("Handle = %v", err)
+	}
+	if again, _ := env.repo.GetReportByID(ctx, report.ID); again.Attempts != 1 {
+		t.Errorf("Expected the completed report not to be rendered again, got %d attempts", again.Attempts)
+	}
+}
+
+func TestService_PDF(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	env.db.Create(&models.AbuseBan{IP: "203.0.113.9", Reason: "request_rate (burst)", ExpiresAt: time.Now(), CreatedAt: time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)})
+
+	from, to := march()
+	report, err := env.svc.Request(ctx, Request{Type: models.ReportSecuritySummary, Format: models.ReportFormatPDF, From: from, To: to, RequestedByID: 1})
+	if err != nil {
+		t.Fatalf("Request = %v", err)
+	}
+	if err := env.runJob(t, 1); err != nil {
+		t.Fatalf("Handle = %v", err)
+	}
+	report, _ = env.repo.GetReportByID(ctx, report.ID)
+	if report.Filename() != "security_summary-2024-03-01-2024-03-31.pdf" {
+		t.Errorf("Unexpected filename %q", report.Filename())
+	}
+
+	body := env.download(t, report)
+	if !strings.HasPrefix(body, "%PDF-1.4\n") || !strings.HasSuffix(body, "%%EOF\n") {
+		t.Fatalf("Expected a PDF, got:\n%s", body)
+	}
+	if !strings.Contains(body, `(request_rate \(burst\)  1) Tj`) {
+		t.Errorf("Expected the escaped ban reason in the PDF, got:\n%s", body)
+	}
+
+	// The xref table must point at each object
+	start := strings.LastIndex(body, "startxref\n")
+	var xref int
+	fmt.Sscanf(body[start+len("startxref\n"):], "%d", &xref)
+	if !strings.HasPrefix(body[xref:], "xref\n") {
+		t.Fatalf("startxref %d does not point at the xref table", xref)
+	}
+	lines := strings.Split(body[xref:], "\n")
+	for i := 1; ; i++ {
+		var offset int
+		if _, err := fmt.Sscanf(lines[2+i], "%d 00000 n", &offset); err != nil {
+			break
+		}
+		if want := fmt.Sprintf("%d 0 obj\n", i); !strings.HasPrefix(body[offset:], want) {
+			t.Errorf("Object %d is not at offset %d", i, offset)
+		}
+	}
+}
+
+func TestWritePDF_Paginates(t *testing.T) {
+	section := Section{Title: "Rows", Columns: []string{"N"}}
+	for i := 0; i < 3*pdfPageLines; i++ {
+		section.Rows = append(section.Rows, []string{fmt.Sprint(i)})
+	}
+	var buf bytes.Buffer
+	if err := WritePDF(&buf, &Document{Title: "Long", Sections: []Section{section}}); err != nil {
+		t.Fatalf("WritePDF = %v", err)
+	}
+	if !strings.Contains(buf.String(), "/Count 4 >>") {
+		t.Errorf("Expected 4 pages, got:\n%s", buf.String()[:200])
+	}
+}
+
+func TestService_RequestValidation(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	from, to := march()
+	tests := []struct {
+		name string
+		req  Request
+		want error
+	}{
+		{"unknown type", Request{Type: "revenue", Format: models.ReportFormatCSV, From: from, To: to}, ErrUnknownType},
+		{"unknown format", Request{Type: models.ReportUserGrowth, Format: "xlsx", From: from, To: to}, ErrUnknownFormat},
+		{"empty period", Request{Type: models.ReportUserGrowth, Format: models.ReportFormatCSV, From: to, To: to}, ErrInvalidPeriod},
+		{"too long", Request{Type: models.ReportUserGrowth, Format: models.ReportFormatCSV, From: from, To: from.AddDate(2, 0, 0)}, ErrInvalidPeriod},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := env.svc.Request(ctx, tt.req); !errors.Is(err, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+	if count, _ := env.repo.CountReports(ctx); count != 0 {
+		t.Errorf("Expected invalid requests not to be recorded, got %d", count)
+	}
+}
+
+func TestService_FailureRetriesThenFails(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	from, to := march()
+	report, err := env.svc.Request(ctx, Request{Type: models.ReportContentStats, Format: models.ReportFormatCSV, From: from, To: to, RequestedByID: 1})
+	if err != nil {
+		t.Fatalf("Request = %v", err)
+	}
+	job, _ := env.queue.Dequeue(ctx, 10*time.Millisecond)
+	env.db.Migrator().DropTable(&models.Comment{})
+
+	job.Attempts, job.MaxAttempts = 1, 2
+	if err := env.svc.Handle(ctx, job); err == nil {
+		t.Fatal("Expected the first attempt to fail")
+	}
+	report, _ = env.repo.GetReportByID(ctx, report.ID)
+	if report.Status != models.ReportStatusPending || report.Error == "" {
+		t.Errorf("Expected the report to await a retry, got %+v", report)
+	}
+
+	job.Attempts = 2
+	if err := env.svc.Handle(ctx, job); err == nil {
+		t.Fatal("Expected the last attempt to fail")
+	}
+	report, _ = env.repo.GetReportByID(ctx, report.ID)
+	if report.Status != models.ReportStatusFailed || report.Attempts != 2 {
+		t.Errorf("Expected a failed report after 2 attempts, got %+v", report)
+	}
+}
+
+func TestService_PurgeExpired(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	from, to := march()
+	report, _ := env.svc.Request(ctx, Request{Type: models.ReportUserGrowth, Format: models.ReportFormatCSV, From: from, To: to, RequestedByID: 1})
+	if err := env.runJob(t, 1); err != nil {
+		t.Fatalf("Handle = %v", err)
+	}
+
+	if purged, err := env.svc.PurgeExpired(ctx); err != nil || purged != 0 {
+		t.Fatalf("Expected nothing to purge yet, got %d, %v", purged, err)
+	}
+	env.clock.Advance(7*24*time.Hour + time.Second)
+	if purged, err := env.svc.PurgeExpired(ctx); err != nil || purged != 1 {
+		t.Fatalf("Expected one report purged, got %d, %v", purged, err)
+	}
+
+	report, _ = env.repo.GetReportByID(ctx, report.ID)
+	if report.Status != models.ReportStatusExpired || report.ObjectKey != "" {
+		t.Errorf("Expected an expired report, got %+v", report)
+	}
+	if _, err := env.svc.Open(ctx, report); !errors.Is(err, ErrNotReady) {
+		t.Errorf("Expected ErrNotReady for an expired report, got %v", err)
+	}
+}
+
+func TestLinkSigner(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 4, 2, 9, 0, 0, 0, time.UTC))
+	signer := NewLinkSigner(testSecret, 15*time.Minute).WithClock(fake)
+
+	// Download links carry the report and expire after the TTL
+	query, expiresAt := signer.Sign(42)
+	if want := fake.Now().Add(15 * time.Minute); query.Get("report") != "42" || !expiresAt.Equal(want) {
+		t.Errorf("Unexpected download link %v expiring %v, want %v", query, expiresAt, want)
+	}
+	if id, err := signer.Verify(query); err != nil || id != 42 {
+		t.Fatalf("Verify = %d, %v", id, err)
+	}
+	fake.Advance(15 * time.Minute)
+	if _, err := signer.Verify(query); !errors.Is(err, security.ErrLinkExpired) {
+		t.Errorf("Expected an expired link, got %v", err)
+	}
+}
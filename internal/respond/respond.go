@@ -0,0 +1,143 @@
+// Package respond writes JSON responses in the server's standard envelope.
+// Every response carries a status, the request ID and a timestamp so that
+// clients and support staff can correlate any reply with the server logs:
+//
+//	{"status": "success", "data": {...}, "request_id": "...", "timestamp": "..."}
+//	{"status": "error", "error": {"type": "...", "message": "...", "code": "..."}, ...}
+package respond
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"go-server/internal/errors"
+	"go-server/internal/middleware"
+	"go-server/internal/security"
+)
+
+// Envelope statuses
+const (
+	StatusSuccess = "success"
+	StatusError   = "error"
+)
+
+// Envelope is the body of every response written by this package
+type Envelope struct {
+	Status    string                     `json:"status"`
+	Message   string                     `json:"message,omitempty"`
+	Data      interface{}                `json:"data,omitempty"`
+	Error     *errors.APIError           `json:"error,omitempty"`
+	Errors    []security.ValidationError `json:"errors,omitempty"`
+	Meta      *Meta                      `json:"meta,omitempty"`
+	RequestID string                     `json:"request_id,omitempty"`
+	Timestamp time.Time                  `json:"timestamp"`
+}
+
+// Meta holds list metadata
+type Meta struct {
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+// Pagination describes the page of a list response
+type Pagination struct {
+	Offset int   `json:"offset"`
+	Limit  int   `json:"limit"`
+	Total  int64 `json:"total"`
+}
+
+// now is the timestamp source, replaced in tests
+var now = func() time.Time { return time.Now().UTC() }
+
+// OK writes data with 200 OK
+func OK(w http.ResponseWriter, r *http.Request, data interface{}) {
+	JSON(w, r, http.StatusOK, "", data)
+}
+
+// Created writes a newly created resource with 201 Created
+func Created(w http.ResponseWriter, r *http.Request, data interface{}) {
+	JSON(w, r, http.StatusCreated, "", data)
+}
+
+// Accepted writes data with 202 Accepted, for work that completes later
+func Accepted(w http.ResponseWriter, r *http.Request, message string, data interface{}) {
+	JSON(w, r, http.StatusAccepted, message, data)
+}
+
+// Message writes a 200 OK with a message and no data
+func Message(w http.ResponseWriter, r *http.Request, message string) {
+	JSON(w, r, http.StatusOK, message, nil)
+}
+
+// List writes a page of items with its pagination metadata
+func List(w http.ResponseWriter, r *http.Request, items interface{}, page Pagination) {
+	write(w, r, http.StatusOK, &Envelope{
+		Status: StatusSuccess,
+		Data:   items,
+		Meta:   &Meta{Pagination: &page},
+	})
+}
+
+// JSON writes a success envelope with any status code
+func JSON(w http.ResponseWriter, r *http.Request, status int, message string, data interface{}) {
+	write(w, r, status, &Envelope{Status: StatusSuccess, Message: message, Data: data})
+}
+
+// Error writes an error envelope. The error type is derived from status.
+func Error(w http.ResponseWriter, r *http.Request, status int, message, code string) {
+	APIError(w, r, errors.NewAPIErrorWithCode(errors.TypeForStatus(status), code, message, status))
+}
+
+// APIError writes an existing API error with its own status code. When the
+// errors package is configured for problem details, the error is written as
+// application/problem+json instead of an envelope.
+func APIError(w http.ResponseWriter, r *http.Request, apiErr *errors.APIError) {
+	// Copy so shared predefined errors are never mutated
+	body := *apiErr
+	if body.StatusCode == 0 {
+		body.StatusCode = http.StatusInternalServerError
+	}
+	if errors.CurrentFormat() == errors.FormatProblem {
+		body.RequestID = RequestID(w, r)
+		errors.WriteError(w, &body)
+		return
+	}
+
+	body.RequestID = ""
+	envelope := &Envelope{Status: StatusError, Error: &body}
+	if failures, ok := body.Extensions["errors"].([]security.ValidationError); ok {
+		envelope.Errors = failures
+		body.Extensions = nil
+	}
+	write(w, r, body.StatusCode, envelope)
+}
+
+// ValidationErrors writes a 400 naming the first failure, with every
+// failure listed under errors
+func ValidationErrors(w http.ResponseWriter, r *http.Request, failures []security.ValidationError) {
+	apiErr := errors.NewAPIErrorWithCode(errors.ErrorTypeValidation, "VALIDATION_ERROR", "Validation failed", http.StatusBadRequest)
+	if len(failures) > 0 {
+		apiErr = errors.NewValidationError(failures[0].Field, failures[0].Message)
+	}
+	APIError(w, r, apiErr.WithExtension("errors", failures))
+}
+
+// RequestID returns the ID of the request being answered, taken from the
+// request context or, failing that, the X-Request-ID response header
+func RequestID(w http.ResponseWriter, r *http.Request) string {
+	if r != nil {
+		if id := middleware.GetRequestID(r.Context()); id != "" {
+			return id
+		}
+	}
+	return w.Header().Get("X-Request-ID")
+}
+
+func write(w http.ResponseWriter, r *http.Request, status int, envelope *Envelope) {
+	envelope.RequestID = RequestID(w, r)
+	envelope.Timestamp = now()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(envelope)
+}
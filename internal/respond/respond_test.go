@@ -0,0 +1,130 @@
+package respond
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-server/internal/errors"
+	"go-server/internal/middleware"
+	"go-server/internal/security"
+)
+
+func decode(t *testing.T, rec *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Invalid JSON body: %v", err)
+	}
+	return body
+}
+
+func newRequest() *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	return r.WithContext(context.WithValue(r.Context(), middleware.RequestIDKey{}, "req-123"))
+}
+
+func TestOKAndCreated(t *testing.T) {
+	fixed := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return fixed }
+	defer func() { now = func() time.Time { return time.Now().UTC() } }()
+
+	rec := httptest.NewRecorder()
+	OK(rec, newRequest(), map[string]string{"name": "Ada"})
+	body := decode(t, rec)
+	if rec.Code != http.StatusOK || body["status"] != StatusSuccess {
+		t.Fatalf("Code = %d, body = %v", rec.Code, body)
+	}
+	if body["request_id"] != "req-123" || body["timestamp"] != "2024-05-01T12:00:00Z" {
+		t.Errorf("Missing request ID or timestamp: %v", body)
+	}
+	if data := body["data"].(map[string]interface{}); data["name"] != "Ada" {
+		t.Errorf("data = %v", data)
+	}
+
+	rec = httptest.NewRecorder()
+	Created(rec, newRequest(), map[string]int{"id": 1})
+	if rec.Code != http.StatusCreated {
+		t.Errorf("Code = %d, want 201", rec.Code)
+	}
+}
+
+func TestError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Error(rec, newRequest(), http.StatusNotFound, "User not found", "USER_NOT_FOUND")
+
+	body := decode(t, rec)
+	if rec.Code != http.StatusNotFound || body["status"] != StatusError || body["request_id"] != "req-123" {
+		t.Fatalf("Code = %d, body = %v", rec.Code, body)
+	}
+	apiErr := body["error"].(map[string]interface{})
+	if apiErr["type"] != string(errors.ErrorTypeNotFound) || apiErr["code"] != "USER_NOT_FOUND" || apiErr["message"] != "User not found" {
+		t.Errorf("error = %v", apiErr)
+	}
+}
+
+func TestAPIErrorDoesNotMutateSharedErrors(t *testing.T) {
+	rec := httptest.NewRecorder()
+	APIError(rec, newRequest(), errors.ErrDatabase)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Code = %d, want 500", rec.Code)
+	}
+	if errors.ErrDatabase.RequestID != "" {
+		t.Error("Predefined error was modified")
+	}
+}
+
+func TestRequestIDFallsBackToResponseHeader(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rec.Header().Set("X-Request-ID", "from-header")
+	Message(rec, httptest.NewRequest(http.MethodGet, "/", nil), "done")
+
+	body := decode(t, rec)
+	if body["request_id"] != "from-header" || body["message"] != "done" {
+		t.Errorf("body = %v", body)
+	}
+}
+
+func TestListAndValidationErrors(t *testing.T) {
+	rec := httptest.NewRecorder()
+	List(rec, newRequest(), []string{"a", "b"}, Pagination{Offset: 0, Limit: 2, Total: 5})
+	body := decode(t, rec)
+	page := body["meta"].(map[string]interface{})["pagination"].(map[string]interface{})
+	if page["total"] != float64(5) || len(body["data"].([]interface{})) != 2 {
+		t.Errorf("body = %v", body)
+	}
+
+	rec = httptest.NewRecorder()
+	ValidationErrors(rec, newRequest(), []security.ValidationError{{Field: "email", Message: "invalid email"}})
+	body = decode(t, rec)
+	if rec.Code != http.StatusBadRequest || len(body["errors"].([]interface{})) != 1 {
+		t.Errorf("Code = %d, body = %v", rec.Code, body)
+	}
+	if body["error"].(map[string]interface{})["code"] != "VALIDATION_ERROR" {
+		t.Errorf("error = %v", body["error"])
+	}
+}
+
+func TestErrorUsesProblemFormatWhenConfigured(t *testing.T) {
+	errors.Configure(errors.FormatProblem, "")
+	defer errors.Configure(errors.FormatJSON, "")
+
+	rec := httptest.NewRecorder()
+	Error(rec, newRequest(), http.StatusConflict, "Email already taken", "EMAIL_TAKEN")
+
+	if ct := rec.Header().Get("Content-Type"); ct != errors.ProblemContentType {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	var body map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	if body["instance"] != "req-123" || body["type"] != "/problems/email-taken" || body["status"] != float64(409) {
+		t.Errorf("body = %v", body)
+	}
+}
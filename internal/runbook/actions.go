@@ -0,0 +1,146 @@
+package runbook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go-server/internal/auth"
+	"go-server/internal/database"
+	"go-server/internal/database/repositories"
+	"go-server/internal/depwatch"
+	"go-server/internal/jobs"
+)
+
+// Built-in actions
+const (
+	ActionRotateJWTKey     = "jwt.rotate_key"
+	ActionFlushCachePrefix = "cache.flush_prefix"
+	ActionCleanupSessions  = "sessions.cleanup"
+	ActionRetryJob         = "jobs.retry"
+	ActionResetDBPools     = "database.reset_pools"
+	ActionCheckDeps        = "dependencies.check"
+)
+
+// maxPrefixLength bounds the cache prefix a flush may target
+const maxPrefixLength = 128
+
+// Builtins holds the dependencies of the built-in actions. Actions whose
+// dependency is nil are not registered.
+type Builtins struct {
+	JWT      *auth.JWTManager
+	Auth     *auth.AuthService
+	Cache    *repositories.CacheRepository
+	Jobs     jobs.Queue
+	Database *database.DatabaseManager
+	Deps     *depwatch.Watcher
+}
+
+// RegisterBuiltins registers the built-in actions with the service
+func RegisterBuiltins(s *Service, b Builtins) {
+	if b.JWT != nil {
+		s.Register(ActionRotateJWTKey, "Sign new tokens with a fresh key; tokens signed with the old key stay valid until they expire", rotateJWTKey(b.JWT))
+	}
+	if b.Cache != nil {
+		s.Register(ActionFlushCachePrefix, `Delete the cache entries whose keys start with "prefix"`, flushCachePrefix(b.Cache))
+	}
+	if b.Auth != nil {
+		s.Register(ActionCleanupSessions, "Delete expired sessions now instead of waiting for the scheduled cleanup", cleanupSessions(b.Auth))
+	}
+	if b.Jobs != nil {
+		s.Register(ActionRetryJob, `Move the dead-lettered job "job_id" back to the queue with fresh attempts`, retryJob(b.Jobs))
+	}
+	if b.Database != nil {
+		s.Register(ActionResetDBPools, "Drop pooled database connections so new ones are dialled, e.g. after a failover", resetDBPools(b.Database))
+	}
+	if b.Deps != nil {
+		s.Register(ActionCheckDeps, "Check the running binary's modules against the vulnerability and end-of-life feeds now instead of waiting for the scheduled check", checkDeps(b.Deps))
+	}
+}
+
+// rotateJWTKey signs new tokens with a random key. The key lives in this
+// process only, so deployments with several replicas should rotate the
+// configured secret instead.
+func rotateJWTKey(jm *auth.JWTManager) Executor {
+	return func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		secret, err := auth.GenerateRandomString(32)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate signing key: %w", err)
+		}
+		jm.RotateKey(secret)
+		return map[string]interface{}{"rotated": true}, nil
+	}
+}
+
+func flushCachePrefix(cache *repositories.CacheRepository) Executor {
+	return func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		var req struct {
+			Prefix string `json:"prefix"`
+		}
+		if err := decodeParams(params, &req); err != nil {
+			return nil, err
+		}
+		// An empty prefix would flush everything; that goes through the
+		// approval-guarded cache flush instead
+		if strings.TrimSpace(req.Prefix) == "" || len(req.Prefix) > maxPrefixLength {
+			return nil, fmt.Errorf("%w: prefix must be 1 to %d characters", ErrInvalidParams, maxPrefixLength)
+		}
+		deleted, err := cache.DeletePrefix(ctx, req.Prefix)
+		if err != nil {
+			return nil, fmt.Errorf("failed to flush cache prefix: %w", err)
+		}
+		return map[string]interface{}{"prefix": req.Prefix, "deleted": deleted}, nil
+	}
+}
+
+func cleanupSessions(as *auth.AuthService) Executor {
+	return func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		if err := as.CleanupExpiredSessions(ctx); err != nil {
+			return nil, fmt.Errorf("failed to clean up sessions: %w", err)
+		}
+		return map[string]interface{}{"cleaned": true}, nil
+	}
+}
+
+func retryJob(queue jobs.Queue) Executor {
+	return func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		var req struct {
+			JobID string `json:"job_id"`
+		}
+		if err := decodeParams(params, &req); err != nil {
+			return nil, err
+		}
+		if req.JobID == "" {
+			return nil, fmt.Errorf("%w: job_id is required", ErrInvalidParams)
+		}
+		job, err := queue.RetryDead(ctx, req.JobID)
+		if errors.Is(err, jobs.ErrJobNotFound) {
+			return nil, fmt.Errorf("%w: job %s is not in the dead-letter queue", ErrTargetNotFound, req.JobID)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to retry job: %w", err)
+		}
+		return map[string]interface{}{"job_id": job.ID, "type": job.Type, "requeued": true}, nil
+	}
+}
+
+func resetDBPools(dm *database.DatabaseManager) Executor {
+	return func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return map[string]interface{}{"health": dm.ResetPools(ctx)}, nil
+	}
+}
+
+// checkDeps runs a dependency check. A feed that cannot be read fails the
+// run; the report with the other feeds' findings is still kept as the
+// latest.
+func checkDeps(w *depwatch.Watcher) Executor {
+	return func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		report, err := w.Check(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check dependencies: %w", err)
+		}
+		return map[string]interface{}{"findings": report.Findings, "modules": len(report.Modules)}, nil
+	}
+}
@@ -0,0 +1,218 @@
+// Package runbook runs common operational actions from the admin API:
+// rotating the JWT signing key, clearing part of the cache, cleaning up
+// sessions, retrying a dead-lettered job and resetting database pools.
+//
+// Every run is recorded with the acting principal, its params and its
+// outcome. A run made with an idempotency key is recorded once per key, so
+// a retried request returns the first run instead of acting twice. Each
+// action may only run a few times per window across all administrators.
+package runbook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/logger"
+	"go-server/internal/security"
+)
+
+// Runbook errors
+var (
+	ErrUnknownAction  = errors.New("unknown runbook action")
+	ErrInvalidParams  = errors.New("invalid runbook params")
+	ErrTargetNotFound = errors.New("runbook target not found")
+	ErrRateLimited    = errors.New("runbook action ran too often; try again later")
+	ErrInProgress     = errors.New("a run with this idempotency key is still in progress")
+)
+
+// maxErrorLength matches the size of the error column
+const maxErrorLength = 500
+
+// Executor carries out an action. params is the JSON object the action was
+// requested with; the returned value is stored as the run's result.
+type Executor func(ctx context.Context, params json.RawMessage) (interface{}, error)
+
+// Action describes a registered action
+type Action struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Config holds runbook configuration
+type Config struct {
+	// MaxRuns is how many times each action may run per Window
+	MaxRuns int
+	Window  time.Duration
+	Clock   clock.Clock // Optional; defaults to the system clock
+}
+
+type action struct {
+	description string
+	execute     Executor
+}
+
+// Service runs registered actions and records each run
+type Service struct {
+	repo    *repositories.RunbookRepository
+	config  Config
+	clock   clock.Clock
+	limiter *security.RateLimiter
+	logger  logger.Logger
+
+	mutex   sync.RWMutex
+	actions map[string]action
+}
+
+// NewService creates a new runbook service
+func NewService(repo *repositories.RunbookRepository, config Config, logger logger.Logger) *Service {
+	if config.MaxRuns <= 0 {
+		config.MaxRuns = 3
+	}
+	if config.Window <= 0 {
+		config.Window = 10 * time.Minute
+	}
+	return &Service{
+		repo:   repo,
+		config: config,
+		clock:  clock.OrDefault(config.Clock),
+		limiter: security.NewRateLimiter(security.RateLimitConfig{
+			RequestsPerMinute: config.MaxRuns,
+			WindowDuration:    config.Window,
+			CleanupInterval:   config.Window,
+			Clock:             config.Clock,
+		}),
+		logger:  logger,
+		actions: make(map[string]action),
+	}
+}
+
+// Register makes an action available to run
+func (s *Service) Register(name, description string, execute Executor) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.actions[name] = action{description: description, execute: execute}
+}
+
+// Actions lists the registered actions by name
+func (s *Service) Actions() []Action {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	actions := make([]Action, 0, len(s.actions))
+	for name, a := range s.actions {
+		actions = append(actions, Action{Name: name, Description: a.description})
+	}
+	sort.Slice(actions, func(i, j int) bool { return actions[i].Name < actions[j].Name })
+	return actions
+}
+
+func (s *Service) lookup(name string) (action, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	a, ok := s.actions[name]
+	if !ok {
+		return action{}, fmt.Errorf("%w: %s", ErrUnknownAction, name)
+	}
+	return a, nil
+}
+
+// Run runs an action on behalf of actor, an audit label such as user:42.
+// With an idempotency key, a repeated request returns the recorded run and
+// replayed is true. If the action itself fails the run is returned together
+// with the action's error.
+func (s *Service) Run(ctx context.Context, name string, params json.RawMessage, actor, idempotencyKey string) (run *models.RunbookRun, replayed bool, err error) {
+	a, err := s.lookup(name)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(params) == 0 {
+		params = json.RawMessage("{}")
+	}
+
+	if idempotencyKey != "" {
+		if existing, err := s.replay(ctx, name, idempotencyKey); existing != nil || err != nil {
+			return existing, existing != nil, err
+		}
+	}
+	if !s.limiter.IsAllowed(name) {
+		return nil, false, ErrRateLimited
+	}
+
+	run = &models.RunbookRun{
+		Action:    name,
+		Params:    string(params),
+		Status:    models.RunbookStatusRunning,
+		Actor:     actor,
+		StartedAt: s.clock.Now(),
+	}
+	if idempotencyKey != "" {
+		run.IdempotencyKey = &idempotencyKey
+	}
+	if err := s.repo.Create(ctx, run); err != nil {
+		// A concurrent request with the same key may have won the insert
+		if idempotencyKey != "" {
+			if existing, _ := s.replay(ctx, name, idempotencyKey); existing != nil {
+				return existing, true, nil
+			}
+		}
+		return nil, false, fmt.Errorf("failed to record runbook run: %w", err)
+	}
+	s.logger.Info("Runbook %s started by %s (run %d)", name, actor, run.ID)
+
+	result, actionErr := a.execute(ctx, params)
+	s.finish(ctx, run, result, actionErr)
+	return run, false, actionErr
+}
+
+// replay returns the run already made with the key, if any
+func (s *Service) replay(ctx context.Context, name, key string) (*models.RunbookRun, error) {
+	existing, err := s.repo.FindByIdempotencyKey(ctx, name, key)
+	if err != nil {
+		// Not found, or unreadable; either way the caller runs the action
+		return nil, nil
+	}
+	if existing.Status == models.RunbookStatusRunning {
+		return nil, ErrInProgress
+	}
+	return existing, nil
+}
+
+// finish records the run's outcome
+func (s *Service) finish(ctx context.Context, run *models.RunbookRun, result interface{}, actionErr error) {
+	now := s.clock.Now()
+	run.FinishedAt = &now
+	if actionErr != nil {
+		run.Status = models.RunbookStatusFailed
+		run.Error = actionErr.Error()
+		if len(run.Error) > maxErrorLength {
+			run.Error = run.Error[:maxErrorLength]
+		}
+		s.logger.Error("Runbook %s failed (run %d): %v", run.Action, run.ID, actionErr)
+	} else {
+		run.Status = models.RunbookStatusSucceeded
+		if encoded, err := json.Marshal(result); err == nil {
+			run.Result = string(encoded)
+		}
+		s.logger.Info("Runbook %s succeeded (run %d)", run.Action, run.ID)
+	}
+
+	// The action has happened, so record it even if the request was cancelled
+	if err := s.repo.Finish(context.WithoutCancel(ctx), run); err != nil {
+		s.logger.Error("Failed to record runbook run %d outcome: %v", run.ID, err)
+	}
+}
+
+// decodeParams decodes an action's params, wrapping failures in ErrInvalidParams
+func decodeParams(params json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(params, v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidParams, err)
+	}
+	return nil
+}
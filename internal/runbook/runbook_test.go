@@ -0,0 +1,145 @@
+package runbook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/dbtest"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/jobs"
+	"go-server/internal/logger"
+)
+
+func newTestService(t *testing.T, maxRuns int) (*Service, *repositories.RunbookRepository, *clock.Fake) {
+	t.Helper()
+	db := dbtest.Open(t, &models.RunbookRun{})
+
+	repo := repositories.NewRunbookRepository(db)
+	fake := clock.NewFake(time.Now())
+	svc := NewService(repo, Config{MaxRuns: maxRuns, Window: time.Minute, Clock: fake}, logger.NewServerLogger())
+	return svc, repo, fake
+}
+
+func TestService_RunRecordsAudit(t *testing.T) {
+	ctx := context.Background()
+	svc, repo, _ := newTestService(t, 10)
+	svc.Register("test.echo", "Echo the params", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return params, nil
+	})
+	svc.Register("test.fail", "Always fail", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return nil, errors.New("disk full")
+	})
+
+	run, replayed, err := svc.Run(ctx, "test.echo", json.RawMessage(`{"n":1}`), "user:1", "")
+	if err != nil || replayed {
+		t.Fatalf("Run = %v, %v", replayed, err)
+	}
+	if run.Status != models.RunbookStatusSucceeded || run.Result != `{"n":1}` || run.Actor != "user:1" || run.FinishedAt == nil {
+		t.Errorf("Run = %+v", run)
+	}
+
+	run, _, err = svc.Run(ctx, "test.fail", nil, "break_glass:2", "")
+	if err == nil || run == nil || run.Status != models.RunbookStatusFailed || run.Error != "disk full" {
+		t.Fatalf("Failed run = %+v, %v", run, err)
+	}
+
+	runs, _ := repo.List(ctx, "", 0, 10)
+	if len(runs) != 2 || runs[0].Action != "test.fail" || runs[0].Params != "{}" {
+		t.Errorf("Expected both runs in the audit log, newest first, got %+v", runs)
+	}
+
+	if _, _, err := svc.Run(ctx, "test.missing", nil, "user:1", ""); !errors.Is(err, ErrUnknownAction) {
+		t.Errorf("Expected ErrUnknownAction, got %v", err)
+	}
+}
+
+func TestService_IdempotencyKey(t *testing.T) {
+	ctx := context.Background()
+	svc, repo, _ := newTestService(t, 10)
+	calls := 0
+	svc.Register("test.count", "Count calls", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		calls++
+		return map[string]int{"calls": calls}, nil
+	})
+
+	first, replayed, err := svc.Run(ctx, "test.count", nil, "user:1", "key-1")
+	if err != nil || replayed {
+		t.Fatalf("First run = %v, %v", replayed, err)
+	}
+	second, replayed, err := svc.Run(ctx, "test.count", nil, "user:1", "key-1")
+	if err != nil || !replayed || second.ID != first.ID {
+		t.Fatalf("Retry should replay run %d, got %+v, %v, %v", first.ID, second, replayed, err)
+	}
+	if calls != 1 {
+		t.Errorf("Action should run once per key, ran %d times", calls)
+	}
+
+	if _, replayed, _ := svc.Run(ctx, "test.count", nil, "user:1", "key-2"); replayed || calls != 2 {
+		t.Errorf("A new key should run the action again")
+	}
+
+	// A run still in progress is not replayed
+	key := "key-3"
+	repo.Create(ctx, &models.RunbookRun{Action: "test.count", IdempotencyKey: &key, Params: "{}", Status: models.RunbookStatusRunning, Actor: "user:1", StartedAt: time.Now()})
+	if _, _, err := svc.Run(ctx, "test.count", nil, "user:1", key); !errors.Is(err, ErrInProgress) {
+		t.Errorf("Expected ErrInProgress, got %v", err)
+	}
+}
+
+func TestService_RateLimit(t *testing.T) {
+	ctx := context.Background()
+	svc, _, fake := newTestService(t, 2)
+	noop := func(ctx context.Context, params json.RawMessage) (interface{}, error) { return nil, nil }
+	svc.Register("test.a", "A", noop)
+	svc.Register("test.b", "B", noop)
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := svc.Run(ctx, "test.a", nil, "user:1", fmt.Sprintf("a-%d", i)); err != nil {
+			t.Fatalf("Run %d failed: %v", i, err)
+		}
+	}
+	if _, _, err := svc.Run(ctx, "test.a", nil, "user:1", ""); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Expected ErrRateLimited, got %v", err)
+	}
+	if _, replayed, err := svc.Run(ctx, "test.a", nil, "user:1", "a-0"); err != nil || !replayed {
+		t.Errorf("Replays should not count against the limit, got %v, %v", replayed, err)
+	}
+	if _, _, err := svc.Run(ctx, "test.b", nil, "user:1", ""); err != nil {
+		t.Errorf("Limits are per action, got %v", err)
+	}
+
+	fake.Advance(time.Minute + time.Second)
+	if _, _, err := svc.Run(ctx, "test.a", nil, "user:1", ""); err != nil {
+		t.Errorf("Limit should reset after the window, got %v", err)
+	}
+}
+
+func TestRetryJob(t *testing.T) {
+	ctx := context.Background()
+	queue := jobs.NewMemoryQueue()
+	job, _ := jobs.NewJob("email", nil, time.Now())
+	job.Attempts = 5
+	queue.DeadLetter(ctx, job)
+	retry := retryJob(queue)
+
+	if _, err := retry(ctx, json.RawMessage(`{}`)); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("Expected ErrInvalidParams, got %v", err)
+	}
+	if _, err := retry(ctx, json.RawMessage(`{"job_id":"`+job.ID+`"}`)); err != nil {
+		t.Fatalf("Retry failed: %v", err)
+	}
+	if _, err := retry(ctx, json.RawMessage(`{"job_id":"`+job.ID+`"}`)); !errors.Is(err, ErrTargetNotFound) {
+		t.Errorf("A job no longer dead-lettered should not be found, got %v", err)
+	}
+
+	ready, _ := queue.Dequeue(ctx, time.Millisecond)
+	if ready == nil || ready.ID != job.ID || ready.Attempts != 0 {
+		t.Errorf("Expected the job back in the queue with fresh attempts, got %+v", ready)
+	}
+}
@@ -0,0 +1,23 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisLocker implements Locker using Redis SET NX with expiry
+type RedisLocker struct {
+	client *redis.Client
+}
+
+// NewRedisLocker creates a new Redis-backed locker
+func NewRedisLocker(client *redis.Client) *RedisLocker {
+	return &RedisLocker{client: client}
+}
+
+// TryLock acquires key for ttl, returning false if it is already held
+func (l *RedisLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return l.client.SetNX(ctx, key, "1", ttl).Result()
+}
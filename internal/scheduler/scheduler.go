@@ -0,0 +1,235 @@
+// Package scheduler runs registered tasks on cron schedules. When several
+// replicas run the scheduler, a shared Locker ensures each scheduled
+// occurrence of a task executes on only one of them.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/logger"
+)
+
+// TaskFunc is the work performed by a scheduled task
+type TaskFunc func(ctx context.Context) error
+
+// Locker coordinates task execution across replicas
+type Locker interface {
+	// TryLock acquires key for ttl, returning false if another holder has it
+	TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+// maxRunHistory bounds how many finished runs Runs remembers
+const maxRunHistory = 100
+
+// Run is a finished run of a task on this replica
+type Run struct {
+	Task       string        `json:"task"`
+	Occurrence time.Time     `json:"occurrence"`
+	StartedAt  time.Time     `json:"started_at"`
+	Duration   time.Duration `json:"duration"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// TaskInfo describes a registered task
+type TaskInfo struct {
+	Name string    `json:"name"`
+	Spec string    `json:"spec"`
+	Next time.Time `json:"next"`
+}
+
+// task is a registered scheduled task
+type task struct {
+	name     string
+	spec     string
+	schedule *Schedule
+	fn       TaskFunc
+	next     time.Time
+}
+
+// Scheduler runs tasks on cron schedules
+type Scheduler struct {
+	tasks   []*task
+	locker  Locker
+	lockTTL time.Duration
+	clock   clock.Clock
+	logger  logger.Logger
+	mutex   sync.Mutex
+	// history holds the latest runs, oldest first
+	history []Run
+
+	tick   time.Duration
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewScheduler creates a new scheduler; locker may be nil for single-node deployments
+func NewScheduler(locker Locker, lockTTL time.Duration, clk clock.Clock, logger logger.Logger) *Scheduler {
+	if lockTTL <= 0 {
+		lockTTL = 5 * time.Minute
+	}
+	return &Scheduler{
+		locker:  locker,
+		lockTTL: lockTTL,
+		clock:   clock.OrDefault(clk),
+		logger:  logger,
+		tick:    15 * time.Second,
+	}
+}
+
+// Register adds a task that runs on the given cron expression
+func (s *Scheduler) Register(name, spec string, fn TaskFunc) error {
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return fmt.Errorf("task %s: %w", name, err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, t := range s.tasks {
+		if t.name == name {
+			return fmt.Errorf("task %s is already registered", name)
+		}
+	}
+
+	s.tasks = append(s.tasks, &task{
+		name:     name,
+		spec:     spec,
+		schedule: schedule,
+		fn:       fn,
+		next:     schedule.Next(s.clock.Now()),
+	})
+	return nil
+}
+
+// Tasks lists the registered tasks with their next occurrence
+func (s *Scheduler) Tasks() []TaskInfo {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	tasks := make([]TaskInfo, len(s.tasks))
+	for i, t := range s.tasks {
+		tasks[i] = TaskInfo{Name: t.name, Spec: t.spec, Next: t.next}
+	}
+	return tasks
+}
+
+// Runs returns the latest runs on this replica, newest first. Occurrences
+// another replica claimed are not among them.
+func (s *Scheduler) Runs() []Run {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	runs := make([]Run, len(s.history))
+	for i, run := range s.history {
+		runs[len(runs)-1-i] = run
+	}
+	return runs
+}
+
+// Start begins evaluating schedules in the background
+func (s *Scheduler) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.tick)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.RunDue(ctx)
+			}
+		}
+	}()
+
+	s.logger.Info("Scheduler started", "tasks", len(s.tasks))
+}
+
+// Stop halts the scheduler and waits for running tasks to finish
+func (s *Scheduler) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+	s.logger.Info("Scheduler stopped")
+}
+
+// RunDue runs every task whose next occurrence has passed
+func (s *Scheduler) RunDue(ctx context.Context) {
+	now := s.clock.Now()
+
+	s.mutex.Lock()
+	var due []*task
+	var occurrences []time.Time
+	for _, t := range s.tasks {
+		if !t.next.IsZero() && !t.next.After(now) {
+			due = append(due, t)
+			occurrences = append(occurrences, t.next)
+			t.next = t.schedule.Next(now)
+		}
+	}
+	s.mutex.Unlock()
+
+	for i, t := range due {
+		s.wg.Add(1)
+		go func(t *task, occurrence time.Time) {
+			defer s.wg.Done()
+			s.runTask(ctx, t, occurrence)
+		}(t, occurrences[i])
+	}
+}
+
+// runTask executes a task occurrence if this replica wins the lock
+func (s *Scheduler) runTask(ctx context.Context, t *task, occurrence time.Time) {
+	if s.locker != nil {
+		key := fmt.Sprintf("scheduler:lock:%s:%d", t.name, occurrence.Unix())
+		acquired, err := s.locker.TryLock(ctx, key, s.lockTTL)
+		if err != nil {
+			s.logger.Error("Failed to acquire task lock", "task", t.name, "error", err.Error())
+			return
+		}
+		if !acquired {
+			s.logger.Debug("Task already claimed by another replica", "task", t.name)
+			return
+		}
+	}
+
+	start := s.clock.Now()
+	err := s.invoke(ctx, t)
+	duration := s.clock.Since(start)
+
+	run := Run{Task: t.name, Occurrence: occurrence, StartedAt: start, Duration: duration}
+	if err != nil {
+		run.Error = err.Error()
+	}
+	s.mutex.Lock()
+	s.history = append(s.history, run)
+	if len(s.history) > maxRunHistory {
+		s.history = s.history[len(s.history)-maxRunHistory:]
+	}
+	s.mutex.Unlock()
+
+	if err != nil {
+		s.logger.Error("Scheduled task failed", "task", t.name, "duration", duration, "error", err.Error())
+		return
+	}
+	s.logger.Info("Scheduled task completed", "task", t.name, "duration", duration)
+}
+
+// invoke calls the task function, converting panics into errors
+func (s *Scheduler) invoke(ctx context.Context, t *task) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("task panicked: %v", r)
+		}
+	}()
+	return t.fn(ctx)
+}
@@ -0,0 +1,161 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/logger"
+)
+
+func TestParseSchedule_Next(t *testing.T) {
+	base := time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC) // Monday
+
+	tests := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2024, 1, 1, 10, 31, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 1, 1, 10, 45, 0, 0, time.UTC)},
+		{"0 9-17 * * 1-5", time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)},
+		{"0 4 * * 0", time.Date(2024, 1, 7, 4, 0, 0, 0, time.UTC)},
+		{"0 4 * * 7", time.Date(2024, 1, 7, 4, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 13 * 5", time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			schedule, err := ParseSchedule(tt.spec)
+			if err != nil {
+				t.Fatalf("Failed to parse %q: %v", tt.spec, err)
+			}
+			if next := schedule.Next(base); !next.Equal(tt.expected) {
+				t.Errorf("Next(%v) = %v, want %v", base, next, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseSchedule_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("Expected error for %q", spec)
+		}
+	}
+}
+
+// memoryLocker grants each key once
+type memoryLocker struct {
+	mu   sync.Mutex
+	held map[string]bool
+}
+
+func (l *memoryLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held[key] {
+		return false, nil
+	}
+	l.held[key] = true
+	return true, nil
+}
+
+func TestScheduler_RunsOncePerOccurrenceAcrossReplicas(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 2, 59, 0, 0, time.UTC))
+	locker := &memoryLocker{held: make(map[string]bool)}
+
+	var runs int32
+	task := func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	}
+
+	replicas := []*Scheduler{
+		NewScheduler(locker, time.Minute, fake, logger.NewServerLogger()),
+		NewScheduler(locker, time.Minute, fake, logger.NewServerLogger()),
+	}
+	for _, s := range replicas {
+		if err := s.Register(TaskSessionCleanup, "0 3 * * *", task); err != nil {
+			t.Fatalf("Failed to register task: %v", err)
+		}
+	}
+
+	// Not yet due
+	for _, s := range replicas {
+		s.RunDue(context.Background())
+		s.wg.Wait()
+	}
+	if runs != 0 {
+		t.Fatalf("Task should not run before its schedule, ran %d times", runs)
+	}
+
+	fake.Advance(time.Minute)
+	for _, s := range replicas {
+		s.RunDue(context.Background())
+		s.wg.Wait()
+	}
+	if runs != 1 {
+		t.Errorf("Expected exactly one run across replicas, got %d", runs)
+	}
+}
+
+func TestScheduler_RunHistory(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 2, 59, 0, 0, time.UTC))
+	s := NewScheduler(nil, 0, fake, logger.NewServerLogger())
+	s.Register("ok", "0 3 * * *", func(ctx context.Context) error { return nil })
+	s.Register("fail", "0 3 * * *", func(ctx context.Context) error { return errors.New("disk full") })
+
+	tasks := s.Tasks()
+	if len(tasks) != 2 || tasks[0].Name != "ok" || tasks[0].Spec != "0 3 * * *" || !tasks[0].Next.Equal(fake.Now().Add(time.Minute)) {
+		t.Fatalf("Tasks = %+v", tasks)
+	}
+
+	fake.Advance(time.Minute)
+	s.RunDue(context.Background())
+	s.wg.Wait()
+
+	runs := s.Runs()
+	if len(runs) != 2 {
+		t.Fatalf("Expected two runs, got %+v", runs)
+	}
+	errs := map[string]string{}
+	for _, run := range runs {
+		errs[run.Task] = run.Error
+		if !run.Occurrence.Equal(fake.Now()) {
+			t.Errorf("Run %s has occurrence %v", run.Task, run.Occurrence)
+		}
+	}
+	if errs["ok"] != "" || errs["fail"] != "disk full" {
+		t.Errorf("Expected the failure recorded, got %v", errs)
+	}
+
+	for i := 0; i < maxRunHistory; i++ {
+		fake.Advance(24 * time.Hour)
+		s.RunDue(context.Background())
+		s.wg.Wait()
+	}
+	if runs := s.Runs(); len(runs) != maxRunHistory || !runs[0].Occurrence.After(runs[len(runs)-1].Occurrence) {
+		t.Errorf("Expected the latest %d runs, newest first, got %d", maxRunHistory, len(runs))
+	}
+}
+
+func TestScheduler_RegisterDuplicate(t *testing.T) {
+	s := NewScheduler(nil, 0, nil, logger.NewServerLogger())
+	noop := func(ctx context.Context) error { return nil }
+
+	if err := s.Register("task", "@daily", noop); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := s.Register("task", "@daily", noop); err == nil {
+		t.Error("Expected error registering duplicate task")
+	}
+}
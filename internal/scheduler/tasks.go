@@ -0,0 +1,66 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-server/internal/database/repositories"
+)
+
+// Built-in task names
+const (
+	TaskSessionCleanup = "sessions.cleanup"
+	TaskTrashPurge     = "trash.purge"
+	TaskOutboxPurge    = "outbox.purge"
+)
+
+// BuiltinSchedules holds the cron expressions for the built-in tasks
+type BuiltinSchedules struct {
+	SessionCleanup  string
+	TrashPurge      string
+	TrashRetention  time.Duration
+	OutboxPurge     string
+	OutboxRetention time.Duration
+}
+
+// RegisterBuiltinTasks registers session cleanup, soft-delete purging and outbox pruning
+func RegisterBuiltinTasks(s *Scheduler, repos *repositories.RepositoryManager, schedules BuiltinSchedules) error {
+	if schedules.SessionCleanup != "" {
+		err := s.Register(TaskSessionCleanup, schedules.SessionCleanup, func(ctx context.Context) error {
+			return repos.Session.CleanupExpiredSessions(ctx)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if schedules.TrashPurge != "" && schedules.TrashRetention > 0 {
+		err := s.Register(TaskTrashPurge, schedules.TrashPurge, func(ctx context.Context) error {
+			cutoff := s.clock.Now().Add(-schedules.TrashRetention)
+			if _, err := repos.Post.PurgeDeletedPosts(ctx, cutoff); err != nil {
+				return fmt.Errorf("failed to purge posts: %w", err)
+			}
+			if _, err := repos.User.PurgeDeletedUsers(ctx, cutoff); err != nil {
+				return fmt.Errorf("failed to purge users: %w", err)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if schedules.OutboxPurge != "" && schedules.OutboxRetention > 0 {
+		err := s.Register(TaskOutboxPurge, schedules.OutboxPurge, func(ctx context.Context) error {
+			cutoff := s.clock.Now().Add(-schedules.OutboxRetention)
+			_, err := repos.Outbox.PurgePublished(ctx, cutoff)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,229 @@
+package secheaders
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go-server/internal/idgen"
+)
+
+// nonceBytes is the entropy of a CSP nonce
+const nonceBytes = 16
+
+// HSTS is a Strict-Transport-Security policy
+type HSTS struct {
+	// MaxAge is how long browsers insist on HTTPS; zero disables the header
+	MaxAge            time.Duration
+	IncludeSubDomains bool
+	// Preload asks browsers to ship the host in their preload lists, which
+	// needs IncludeSubDomains and a MaxAge of at least a year
+	Preload bool
+}
+
+// String renders the policy as a header value, empty when disabled
+func (h HSTS) String() string {
+	if h.MaxAge <= 0 {
+		return ""
+	}
+	value := "max-age=" + strconv.FormatInt(int64(h.MaxAge/time.Second), 10)
+	if h.IncludeSubDomains {
+		value += "; includeSubDomains"
+	}
+	if h.Preload {
+		value += "; preload"
+	}
+	return value
+}
+
+// Profile is the set of security headers for a kind of response
+type Profile struct {
+	Name string
+	// CSP is the Content-Security-Policy; nil sends none
+	CSP            *Policy
+	FrameOptions   string
+	ReferrerPolicy string
+	// HSTS is only sent on HTTPS requests, as browsers ignore it otherwise
+	HSTS HSTS
+}
+
+// APIProfile is for JSON responses, which never render in a browser: csp
+// is usually the CONTENT_SECURITY_POLICY setting
+func APIProfile(csp *Policy, hsts HSTS) Profile {
+	return Profile{
+		Name:           "api",
+		CSP:            csp,
+		FrameOptions:   "DENY",
+		ReferrerPolicy: "strict-origin-when-cross-origin",
+		HSTS:           hsts,
+	}
+}
+
+// DocsPolicy is the policy of the HTML documentation viewers: their CDN
+// assets, and inline bootstrap scripts allowed by nonce
+func DocsPolicy() *Policy {
+	return NewPolicy().
+		DefaultSrc(Self).
+		ScriptSrc(Self, "https://unpkg.com", "https://cdn.redoc.ly").
+		StyleSrc(Self, UnsafeInline, "https://unpkg.com", "https://fonts.googleapis.com").
+		FontSrc(Self, "https://fonts.gstatic.com").
+		ImgSrc(Self, Data, HTTPS).
+		Set(WorkerSrc, Self, Blob).
+		Set(ObjectSrc, None).
+		Set(BaseURI, Self).
+		Set(FrameAncestors, None).
+		WithNonce(ScriptSrc)
+}
+
+// DocsProfile is for the HTML documentation pages
+func DocsProfile(hsts HSTS) Profile {
+	return Profile{
+		Name:           "docs",
+		CSP:            DocsPolicy(),
+		FrameOptions:   "DENY",
+		ReferrerPolicy: "no-referrer",
+		HSTS:           hsts,
+	}
+}
+
+// AdminPolicy is the policy of the server-rendered admin pages: nothing
+// from other origins, and their inline script and styles allowed by nonce
+func AdminPolicy() *Policy {
+	return NewPolicy().
+		DefaultSrc(Self).
+		ScriptSrc(Self).
+		StyleSrc(Self).
+		ConnectSrc(Self).
+		Set(ObjectSrc, None).
+		Set(BaseURI, None).
+		Set(FormAction, Self).
+		Set(FrameAncestors, None).
+		WithNonce(ScriptSrc, StyleSrc)
+}
+
+// AdminProfile is for the server-rendered admin pages
+func AdminProfile(hsts HSTS) Profile {
+	return Profile{
+		Name:           "admin",
+		CSP:            AdminPolicy(),
+		FrameOptions:   "DENY",
+		ReferrerPolicy: "no-referrer",
+		HSTS:           hsts,
+	}
+}
+
+// Route applies a profile to every path under Prefix
+type Route struct {
+	Prefix  string
+	Profile Profile
+}
+
+// Engine sets the security headers of each response from the profile of
+// its route
+type Engine struct {
+	mutex   sync.RWMutex
+	profile Profile
+	routes  []Route
+	tokens  idgen.TokenSource
+}
+
+// NewEngine creates an engine applying profile to routes without one
+func NewEngine(profile Profile) *Engine {
+	return &Engine{profile: profile, tokens: idgen.NewRandom()}
+}
+
+// WithRoute applies profile to every path under prefix instead of the default
+func (e *Engine) WithRoute(prefix string, profile Profile) *Engine {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.routes = append(e.routes, Route{Prefix: prefix, Profile: profile})
+	// Longest prefix first so the most specific wins
+	sort.SliceStable(e.routes, func(i, j int) bool {
+		return len(e.routes[i].Prefix) > len(e.routes[j].Prefix)
+	})
+	return e
+}
+
+// WithTokenSource sets how nonces are generated
+func (e *Engine) WithTokenSource(tokens idgen.TokenSource) *Engine {
+	e.tokens = tokens
+	return e
+}
+
+// Reconfigure replaces the default profile, e.g. when the configuration is
+// reloaded; route profiles are kept
+func (e *Engine) Reconfigure(profile Profile) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.profile = profile
+}
+
+// ProfileFor returns the profile applied to path
+func (e *Engine) ProfileFor(path string) Profile {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	for _, route := range e.routes {
+		if strings.HasPrefix(path, route.Prefix) {
+			return route.Profile
+		}
+	}
+	return e.profile
+}
+
+// Middleware sets the security headers. Requests whose policy uses a nonce
+// get a fresh one, which handlers read with Nonce.
+func (e *Engine) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			profile := e.ProfileFor(r.URL.Path)
+			nonce := ""
+			if profile.CSP != nil && profile.CSP.UsesNonce() {
+				if token, err := e.tokens.Token(nonceBytes); err == nil {
+					nonce = token
+					r = r.WithContext(context.WithValue(r.Context(), nonceKey{}, nonce))
+				}
+			}
+			Apply(w, r, profile, nonce)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Apply sets a profile's headers on a response
+func Apply(w http.ResponseWriter, r *http.Request, profile Profile, nonce string) {
+	header := w.Header()
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("X-XSS-Protection", "1; mode=block")
+	if profile.FrameOptions != "" {
+		header.Set("X-Frame-Options", profile.FrameOptions)
+	}
+	if profile.ReferrerPolicy != "" {
+		header.Set("Referrer-Policy", profile.ReferrerPolicy)
+	}
+	if profile.CSP != nil {
+		header.Set("Content-Security-Policy", profile.CSP.Render(nonce))
+	}
+	if hsts := profile.HSTS.String(); hsts != "" && isHTTPS(r) {
+		header.Set("Strict-Transport-Security", hsts)
+	}
+}
+
+// nonceKey is the context key for the request's CSP nonce
+type nonceKey struct{}
+
+// Nonce returns the CSP nonce of a request, empty if its policy uses none.
+// Inline scripts carry it as their nonce attribute.
+func Nonce(ctx context.Context) string {
+	nonce, _ := ctx.Value(nonceKey{}).(string)
+	return nonce
+}
+
+// isHTTPS reports whether a request reached the server, or the proxy in
+// front of it, over TLS
+func isHTTPS(r *http.Request) bool {
+	return r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+}
@@ -0,0 +1,124 @@
+package secheaders
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-server/internal/idgen"
+)
+
+func TestPolicy_Builder(t *testing.T) {
+	policy := NewPolicy().
+		DefaultSrc(Self).
+		ScriptSrc(Self, "https://cdn.example.com").
+		ConnectSrc(Self).
+		Add(ConnectSrc, "wss://realtime.example.com").
+		WithNonce(ScriptSrc)
+
+	want := "default-src 'self'; script-src 'self' https://cdn.example.com; connect-src 'self' wss://realtime.example.com"
+	if got := policy.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if got := policy.Render("abc"); !strings.Contains(got, "script-src 'self' https://cdn.example.com 'nonce-abc';") {
+		t.Errorf("Render should add the nonce to script-src, got %q", got)
+	}
+
+	clone := policy.Clone().ScriptSrc(None)
+	if policy.Sources(ScriptSrc)[0] != Self || clone.Sources(ScriptSrc)[0] != None || !clone.UsesNonce() {
+		t.Errorf("Clone should be independent, got %q and %q", policy, clone)
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := ParsePolicy("default-src 'self';  IMG-SRC 'self' data: ; ")
+	if err != nil {
+		t.Fatalf("ParsePolicy failed: %v", err)
+	}
+	if got := policy.String(); got != "default-src 'self'; img-src 'self' data:" {
+		t.Errorf("Unexpected policy %q", got)
+	}
+
+	for _, value := range []string{"default-src 'self'; default-src 'none'", "script_src 'self'", "default-src 'self',https://x"} {
+		if _, err := ParsePolicy(value); err == nil {
+			t.Errorf("ParsePolicy(%q) should fail", value)
+		}
+	}
+	if err := NewPolicy().ScriptSrc("'self'; script-src *").Validate(); err == nil {
+		t.Error("A source breaking out of its directive should fail validation")
+	}
+}
+
+func TestHSTS_String(t *testing.T) {
+	tests := []struct {
+		hsts HSTS
+		want string
+	}{
+		{HSTS{}, ""},
+		{HSTS{MaxAge: time.Hour}, "max-age=3600"},
+		{HSTS{MaxAge: 365 * 24 * time.Hour, IncludeSubDomains: true, Preload: true}, "max-age=31536000; includeSubDomains; preload"},
+	}
+	for _, tt := range tests {
+		if got := tt.hsts.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", tt.hsts, got, tt.want)
+		}
+	}
+}
+
+func TestEngine_Profiles(t *testing.T) {
+	hsts := HSTS{MaxAge: time.Hour}
+	engine := NewEngine(APIProfile(NewPolicy().DefaultSrc(None), hsts)).
+		WithRoute("/docs", DocsProfile(hsts)).
+		WithTokenSource(idgen.NewSequence("nonce"))
+
+	var nonce string
+	handler := engine.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nonce = Nonce(r.Context())
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/posts", nil))
+	if got := rec.Header().Get("Content-Security-Policy"); got != "default-src 'none'" || nonce != "" {
+		t.Errorf("API responses should get the API policy without a nonce, got %q, %q", got, nonce)
+	}
+	if rec.Header().Get("Strict-Transport-Security") != "" {
+		t.Error("HSTS should only be sent over HTTPS")
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/docs", nil)
+	r.Header.Set("X-Forwarded-Proto", "https")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+	csp := rec.Header().Get("Content-Security-Policy")
+	if nonce != "nonce-1" || !strings.Contains(csp, "'nonce-nonce-1'") || !strings.Contains(csp, "https://unpkg.com") {
+		t.Errorf("Docs pages should get the docs policy with a nonce, got %q, %q", csp, nonce)
+	}
+	if rec.Header().Get("Referrer-Policy") != "no-referrer" {
+		t.Errorf("Unexpected docs referrer policy %q", rec.Header().Get("Referrer-Policy"))
+	}
+	if got := rec.Header().Get("Strict-Transport-Security"); got != "max-age=3600" {
+		t.Errorf("HSTS over HTTPS = %q, want max-age=3600", got)
+	}
+
+	engine.Reconfigure(APIProfile(NewPolicy().DefaultSrc(Self), hsts))
+	if got := engine.ProfileFor("/api/posts").CSP.String(); got != "default-src 'self'" {
+		t.Errorf("Reconfigure should replace the default profile, got %q", got)
+	}
+	if engine.ProfileFor("/docs/").Name != "docs" {
+		t.Error("Reconfigure should keep route profiles")
+	}
+}
+
+func TestAdminPolicy(t *testing.T) {
+	csp := AdminPolicy().Render("abc")
+	for _, want := range []string{"script-src 'self' 'nonce-abc'", "style-src 'self' 'nonce-abc'", "frame-ancestors 'none'"} {
+		if !strings.Contains(csp, want) {
+			t.Errorf("Admin policy %q should contain %q", csp, want)
+		}
+	}
+	if strings.Contains(csp, "unsafe-inline") {
+		t.Errorf("Admin policy should not allow unsafe-inline, got %q", csp)
+	}
+}
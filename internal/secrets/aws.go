@@ -0,0 +1,116 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"go-server/internal/clock"
+	"go-server/internal/sigv4"
+)
+
+// AWSConfig locates a secret in AWS Secrets Manager. Every secret the
+// server needs is a key of the JSON object stored in SecretID.
+type AWSConfig struct {
+	Region   string
+	SecretID string
+	// Endpoint overrides the regional endpoint, e.g. for a VPC endpoint
+	Endpoint        string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// AWSProvider reads secrets from AWS Secrets Manager
+type AWSProvider struct {
+	config   AWSConfig
+	endpoint string
+	signer   sigv4.Signer
+	client   *http.Client
+	clock    clock.Clock
+}
+
+// NewAWSProvider creates a new AWS Secrets Manager provider
+func NewAWSProvider(config AWSConfig, client *http.Client) (*AWSProvider, error) {
+	if config.Region == "" || config.SecretID == "" {
+		return nil, fmt.Errorf("AWS region and secret ID are required")
+	}
+	if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, fmt.Errorf("AWS credentials are required")
+	}
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://secretsmanager.%s.amazonaws.com/", config.Region)
+	}
+
+	return &AWSProvider{
+		config:   config,
+		endpoint: endpoint,
+		signer: sigv4.Signer{
+			AccessKeyID:     config.AccessKeyID,
+			SecretAccessKey: config.SecretAccessKey,
+			SessionToken:    config.SessionToken,
+			Region:          config.Region,
+			Service:         "secretsmanager",
+		},
+		client: client,
+		clock:  clock.New(),
+	}, nil
+}
+
+// WithClock overrides the clock used to sign requests
+func (p *AWSProvider) WithClock(c clock.Clock) *AWSProvider {
+	p.clock = clock.OrDefault(c)
+	return p
+}
+
+// Get returns the key name of the configured secret's JSON object
+func (p *AWSProvider) Get(ctx context.Context, name string) (string, error) {
+	payload, err := json.Marshal(map[string]string{"SecretId": p.config.SecretID})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	p.signer.Sign(req, sigv4.PayloadHash(payload), p.clock.Now())
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("secrets manager request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("failed to read secrets manager response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var awsErr struct {
+			Type string `json:"__type"`
+		}
+		json.Unmarshal(body, &awsErr)
+		if awsErr.Type == "ResourceNotFoundException" {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("secrets manager returned status %d %s", resp.StatusCode, awsErr.Type)
+	}
+
+	var secret struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return "", fmt.Errorf("invalid secrets manager response: %w", err)
+	}
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(secret.SecretString), &values); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object", p.config.SecretID)
+	}
+	return secretValue(values, name)
+}
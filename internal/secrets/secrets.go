@@ -0,0 +1,185 @@
+// Package secrets loads credentials such as database passwords and the JWT
+// signing key from somewhere safer than plain environment variables.
+//
+// A Provider looks secrets up by their environment variable name, e.g.
+// POSTGRES_PASSWORD. The default chain checks, in order:
+//
+//   - the variable itself, or the file named by NAME_FILE, the convention
+//     of the official Docker images
+//   - a secrets directory holding one file per secret, as Docker and
+//     Kubernetes mount them (/run/secrets by default)
+//   - an optional secret manager: HashiCorp Vault or AWS Secrets Manager
+//
+// Mounted files and secret managers can change while the server runs; a
+// Watcher polls a secret and reports the new value so keys can be rotated
+// without a restart.
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when a provider does not hold the secret
+var ErrNotFound = errors.New("secret not found")
+
+// Secret manager backends
+const (
+	BackendVault = "vault"
+	BackendAWS   = "aws"
+)
+
+// Provider looks up secrets by name
+type Provider interface {
+	// Get returns the secret called name, or ErrNotFound
+	Get(ctx context.Context, name string) (string, error)
+}
+
+// ProviderFunc adapts a function to the Provider interface
+type ProviderFunc func(ctx context.Context, name string) (string, error)
+
+// Get calls f
+func (f ProviderFunc) Get(ctx context.Context, name string) (string, error) {
+	return f(ctx, name)
+}
+
+// LookupEnv returns the environment variable key or, when it is unset, the
+// contents of the file named by key_FILE with the trailing newline removed.
+// ok is false when neither is set.
+func LookupEnv(key string) (value string, ok bool, err error) {
+	if value := os.Getenv(key); value != "" {
+		return value, true, nil
+	}
+	path := os.Getenv(key + "_FILE")
+	if path == "" {
+		return "", false, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read %s_FILE: %w", key, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+// EnvProvider reads secrets from the environment with LookupEnv
+type EnvProvider struct{}
+
+// Get returns the variable name or the file named by name_FILE
+func (EnvProvider) Get(ctx context.Context, name string) (string, error) {
+	value, ok, err := LookupEnv(name)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+// DirProvider reads secrets from a directory with one file per secret, such
+// as /run/secrets in Docker or a mounted Kubernetes Secret. The file is
+// named after the secret, either as given or in lower case.
+type DirProvider struct {
+	Dir string
+}
+
+// Get returns the contents of the secret's file
+func (p DirProvider) Get(ctx context.Context, name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return "", ErrNotFound
+	}
+	for _, file := range []string{name, strings.ToLower(name)} {
+		data, err := os.ReadFile(filepath.Join(p.Dir, file))
+		if err == nil {
+			return strings.TrimRight(string(data), "\r\n"), nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("failed to read secret %s: %w", name, err)
+		}
+	}
+	return "", ErrNotFound
+}
+
+// Chain asks each provider in turn, returning the first secret found. An
+// error other than ErrNotFound stops the search, so an unreachable secret
+// manager is not mistaken for a missing secret.
+type Chain []Provider
+
+// Get returns the secret from the first provider holding it
+func (c Chain) Get(ctx context.Context, name string) (string, error) {
+	for _, provider := range c {
+		value, err := provider.Get(ctx, name)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		return value, err
+	}
+	return "", ErrNotFound
+}
+
+// Config selects where secrets are read from
+type Config struct {
+	// Dir is the secrets directory; empty skips it
+	Dir string
+	// Backend is the secret manager, BackendVault or BackendAWS; empty
+	// uses the environment and Dir only
+	Backend string
+	Vault   VaultConfig
+	AWS     AWSConfig
+	Timeout time.Duration
+	Client  *http.Client // Optional; defaults to a client with Timeout
+}
+
+// New creates the provider chain described by config
+func New(config Config) (Provider, error) {
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+	client := config.Client
+	if client == nil {
+		client = &http.Client{Timeout: config.Timeout}
+	}
+
+	chain := Chain{EnvProvider{}}
+	if config.Dir != "" {
+		chain = append(chain, DirProvider{Dir: config.Dir})
+	}
+
+	switch config.Backend {
+	case "":
+	case BackendVault:
+		vault, err := NewVaultProvider(config.Vault, client)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, vault)
+	case BackendAWS:
+		aws, err := NewAWSProvider(config.AWS, client)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, aws)
+	default:
+		return nil, fmt.Errorf("unknown secrets backend %q", config.Backend)
+	}
+	return chain, nil
+}
+
+// Resolve returns the secret called name, or fallback if no provider
+// holds it
+func Resolve(ctx context.Context, provider Provider, name, fallback string) (string, error) {
+	value, err := provider.Get(ctx, name)
+	if errors.Is(err, ErrNotFound) {
+		return fallback, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return value, nil
+}
@@ -0,0 +1,195 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go-server/internal/logger"
+)
+
+func TestEnvProvider_FileConvention(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "db_password")
+	os.WriteFile(path, []byte("from-file\n"), 0o600)
+
+	t.Setenv("TEST_SECRET_FILE", path)
+	if value, err := (EnvProvider{}).Get(ctx, "TEST_SECRET"); err != nil || value != "from-file" {
+		t.Errorf("Expected the file contents without the newline, got %q, %v", value, err)
+	}
+
+	t.Setenv("TEST_SECRET", "from-env")
+	if value, _ := (EnvProvider{}).Get(ctx, "TEST_SECRET"); value != "from-env" {
+		t.Errorf("The variable should take precedence over its file, got %q", value)
+	}
+
+	t.Setenv("MISSING_SECRET_FILE", filepath.Join(t.TempDir(), "missing"))
+	if _, err := (EnvProvider{}).Get(ctx, "MISSING_SECRET"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("An unreadable _FILE should be an error, got %v", err)
+	}
+	if _, err := (EnvProvider{}).Get(ctx, "UNSET_SECRET"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestDirProvider(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "jwt_secret"), []byte("mounted\n"), 0o600)
+	p := DirProvider{Dir: dir}
+
+	if value, err := p.Get(ctx, "JWT_SECRET"); err != nil || value != "mounted" {
+		t.Errorf("Expected the lower case file to match, got %q, %v", value, err)
+	}
+	for _, name := range []string{"OTHER", "../jwt_secret", ".hidden", ""} {
+		if _, err := p.Get(ctx, name); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound for %q, got %v", name, err)
+		}
+	}
+}
+
+func TestChain(t *testing.T) {
+	ctx := context.Background()
+	values := ProviderFunc(func(ctx context.Context, name string) (string, error) {
+		if name == "A" {
+			return "a", nil
+		}
+		return "", ErrNotFound
+	})
+	down := ProviderFunc(func(ctx context.Context, name string) (string, error) {
+		return "", errors.New("connection refused")
+	})
+
+	if value, err := (Chain{values, down}).Get(ctx, "A"); err != nil || value != "a" {
+		t.Errorf("Expected the first provider's value, got %q, %v", value, err)
+	}
+	if _, err := (Chain{values, down}).Get(ctx, "B"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("A failing provider should not be treated as missing, got %v", err)
+	}
+	if value, err := Resolve(ctx, Chain{values}, "B", "fallback"); err != nil || value != "fallback" {
+		t.Errorf("Expected the fallback, got %q, %v", value, err)
+	}
+}
+
+func TestVaultProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/kv/data/go-server" || r.Header.Get("X-Vault-Token") != "root" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"data":{"data":{"JWT_SECRET":"s3cret","PORT":5432},"metadata":{"version":3}}}`))
+	}))
+	defer server.Close()
+
+	p, err := NewVaultProvider(VaultConfig{Address: server.URL, Token: "root", Mount: "kv", Path: "go-server"}, server.Client())
+	if err != nil {
+		t.Fatalf("NewVaultProvider failed: %v", err)
+	}
+	ctx := context.Background()
+	if value, err := p.Get(ctx, "JWT_SECRET"); err != nil || value != "s3cret" {
+		t.Errorf("Get = %q, %v", value, err)
+	}
+	if value, _ := p.Get(ctx, "PORT"); value != "5432" {
+		t.Errorf("Non-string values should be returned as JSON, got %q", value)
+	}
+	if _, err := p.Get(ctx, "MISSING"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	if _, err := NewVaultProvider(VaultConfig{Address: server.URL}, nil); err == nil {
+		t.Error("A provider without a token should be rejected")
+	}
+}
+
+func TestAWSProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req struct{ SecretId string }
+		json.Unmarshal(body, &req)
+		if r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" ||
+			!strings.Contains(r.Header.Get("Authorization"), "/eu-west-1/secretsmanager/aws4_request") ||
+			r.Header.Get("X-Amz-Security-Token") != "session" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if req.SecretId != "prod/go-server" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"ResourceNotFoundException"}`))
+			return
+		}
+		secret, _ := json.Marshal(map[string]string{"POSTGRES_PASSWORD": "pg"})
+		json.NewEncoder(w).Encode(map[string]string{"SecretString": string(secret)})
+	}))
+	defer server.Close()
+
+	config := AWSConfig{
+		Region:          "eu-west-1",
+		SecretID:        "prod/go-server",
+		Endpoint:        server.URL,
+		AccessKeyID:     "key",
+		SecretAccessKey: "secret",
+		SessionToken:    "session",
+	}
+	p, err := NewAWSProvider(config, server.Client())
+	if err != nil {
+		t.Fatalf("NewAWSProvider failed: %v", err)
+	}
+	ctx := context.Background()
+	if value, err := p.Get(ctx, "POSTGRES_PASSWORD"); err != nil || value != "pg" {
+		t.Errorf("Get = %q, %v", value, err)
+	}
+	if _, err := p.Get(ctx, "REDIS_PASSWORD"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a missing key, got %v", err)
+	}
+
+	config.SecretID = "prod/missing"
+	p, _ = NewAWSProvider(config, server.Client())
+	if _, err := p.Get(ctx, "POSTGRES_PASSWORD"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a missing secret, got %v", err)
+	}
+}
+
+func TestWatcher_Check(t *testing.T) {
+	ctx := context.Background()
+	current := "v1"
+	provider := ProviderFunc(func(ctx context.Context, name string) (string, error) {
+		return current, nil
+	})
+
+	var rotated []string
+	w := NewWatcher(provider, "JWT_SECRET", "v1", 0, logger.NewServerLogger()).
+		OnChange(func(value string) { rotated = append(rotated, value) })
+
+	if changed, err := w.Check(ctx); changed || err != nil {
+		t.Errorf("An unchanged secret should not be reported, got %v, %v", changed, err)
+	}
+	current = "v2"
+	if changed, _ := w.Check(ctx); !changed {
+		t.Error("Expected the change to be reported")
+	}
+	w.Check(ctx)
+	current = ""
+	w.Check(ctx)
+	if len(rotated) != 1 || rotated[0] != "v2" {
+		t.Errorf("Expected one rotation to v2, got %v", rotated)
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(Config{Backend: "consul"}); err == nil {
+		t.Error("An unknown backend should be rejected")
+	}
+	if _, err := New(Config{Backend: BackendAWS, AWS: AWSConfig{Region: "us-east-1"}}); err == nil {
+		t.Error("An incomplete AWS backend should be rejected")
+	}
+	if _, err := New(Config{Dir: "/run/secrets"}); err != nil {
+		t.Errorf("New failed: %v", err)
+	}
+}
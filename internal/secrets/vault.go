@@ -0,0 +1,100 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// VaultConfig locates a secret in a HashiCorp Vault KV version 2 engine.
+// Every secret the server needs is a key of the one Vault secret at Path.
+type VaultConfig struct {
+	Address string
+	Token   string
+	// Mount is the KV engine's mount point
+	Mount string
+	Path  string
+	// Namespace is the Vault Enterprise namespace, if any
+	Namespace string
+}
+
+// VaultProvider reads secrets from Vault's KV version 2 engine
+type VaultProvider struct {
+	config VaultConfig
+	url    string
+	client *http.Client
+}
+
+// NewVaultProvider creates a new Vault provider
+func NewVaultProvider(config VaultConfig, client *http.Client) (*VaultProvider, error) {
+	if config.Mount == "" {
+		config.Mount = "secret"
+	}
+	if config.Address == "" || config.Token == "" || config.Path == "" {
+		return nil, fmt.Errorf("vault address, token and secret path are required")
+	}
+	base, err := url.Parse(strings.TrimSuffix(config.Address, "/"))
+	if err != nil || base.Host == "" {
+		return nil, fmt.Errorf("invalid vault address %q", config.Address)
+	}
+
+	return &VaultProvider{
+		config: config,
+		url:    base.String() + "/v1/" + strings.Trim(config.Mount, "/") + "/data/" + strings.Trim(config.Path, "/"),
+		client: client,
+	}, nil
+}
+
+// Get returns the key name of the configured Vault secret
+func (p *VaultProvider) Get(ctx context.Context, name string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", p.config.Token)
+	if p.config.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.config.Namespace)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("vault request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+		return "", fmt.Errorf("vault returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Data struct {
+			Data map[string]json.RawMessage `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return "", fmt.Errorf("invalid vault response: %w", err)
+	}
+	return secretValue(body.Data.Data, name)
+}
+
+// secretValue returns a key of a secret stored as a JSON object; strings
+// are returned unquoted and other values as JSON
+func secretValue(values map[string]json.RawMessage, name string) (string, error) {
+	raw, ok := values[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	var value string
+	if err := json.Unmarshal(raw, &value); err == nil {
+		return value, nil
+	}
+	return string(raw), nil
+}
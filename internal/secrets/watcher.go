@@ -0,0 +1,105 @@
+package secrets
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go-server/internal/logger"
+)
+
+// Watcher polls a secret and calls its change functions when the value
+// changes, e.g. to rotate the JWT signing key when the mounted secret or
+// the secret manager entry is updated
+type Watcher struct {
+	provider Provider
+	name     string
+	interval time.Duration
+	logger   logger.Logger
+	onChange []func(value string)
+
+	mutex sync.Mutex
+	value string
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewWatcher creates a watcher for the secret called name, whose value at
+// startup was current
+func NewWatcher(provider Provider, name, current string, interval time.Duration, logger logger.Logger) *Watcher {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &Watcher{
+		provider: provider,
+		name:     name,
+		interval: interval,
+		logger:   logger,
+		value:    current,
+	}
+}
+
+// OnChange registers a function called with the new value after the
+// secret changes
+func (w *Watcher) OnChange(fn func(value string)) *Watcher {
+	w.onChange = append(w.onChange, fn)
+	return w
+}
+
+// Check reads the secret and reports whether it changed. A secret that
+// has disappeared or cannot be read keeps its current value.
+func (w *Watcher) Check(ctx context.Context) (bool, error) {
+	value, err := w.provider.Get(ctx, w.name)
+	if err != nil {
+		return false, err
+	}
+	if value == "" {
+		return false, nil
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if value == w.value {
+		return false, nil
+	}
+	w.value = value
+	for _, fn := range w.onChange {
+		fn(value)
+	}
+	w.logger.Info("Secret %s changed", w.name)
+	return true, nil
+}
+
+// Start polls the secret every interval
+func (w *Watcher) Start(ctx context.Context) {
+	ctx, w.cancel = context.WithCancel(ctx)
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				checkCtx, cancel := context.WithTimeout(ctx, w.interval)
+				if _, err := w.Check(checkCtx); err != nil {
+					w.logger.Error("Failed to check secret %s: %v", w.name, err)
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
+// Stop halts polling
+func (w *Watcher) Stop() {
+	if w.cancel != nil {
+		w.cancel()
+	}
+	w.wg.Wait()
+}
@@ -0,0 +1,184 @@
+package security
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// DefaultMaxBodyBytes is the largest request body Bind accepts
+const DefaultMaxBodyBytes = 1 << 20
+
+var defaultSanitizer = NewSanitizer()
+
+// Bind decodes a JSON request body into a T, then sanitizes and validates it.
+// It is BindLimit with DefaultMaxBodyBytes.
+func Bind[T any](r *http.Request) (T, []ValidationError) {
+	return BindLimit[T](r, DefaultMaxBodyBytes)
+}
+
+// BindLimit decodes a JSON request body of at most maxBytes into a T.
+//
+// The body must be application/json and may only contain fields T declares.
+// An empty body decodes as {}, leaving required fields to fail validation.
+// String fields are sanitized according to their sanitize tag: by default
+// surrounding whitespace and NUL bytes are removed, "-" leaves the value
+// untouched (use it for passwords and secrets), and email, alphanumeric,
+// safe or html apply the matching Sanitizer rule. Finally the validate tags
+// are checked with ValidateStruct.
+func BindLimit[T any](r *http.Request, maxBytes int64) (T, []ValidationError) {
+	var target T
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBytes+1))
+	if err != nil {
+		return target, []ValidationError{{Field: "body", Message: "Request body could not be read"}}
+	}
+	if int64(len(body)) > maxBytes {
+		return target, []ValidationError{{Field: "body", Message: fmt.Sprintf("Request body must be at most %d bytes", maxBytes)}}
+	}
+
+	if len(bytes.TrimSpace(body)) > 0 {
+		if !isJSONContentType(r.Header.Get("Content-Type")) {
+			return target, []ValidationError{{
+				Field:   "content-type",
+				Message: "Content-Type must be application/json",
+				Value:   r.Header.Get("Content-Type"),
+			}}
+		}
+		if failure := decodeStrict(body, &target); failure != nil {
+			return target, []ValidationError{*failure}
+		}
+	}
+
+	sanitizeValue(reflect.ValueOf(&target).Elem())
+	if failures := ValidateStruct(&target); len(failures) > 0 {
+		return target, failures
+	}
+	return target, nil
+}
+
+// decodeStrict decodes a single JSON value, rejecting unknown fields, and
+// describes any failure in terms of the request rather than the Go type
+func decodeStrict(body []byte, target interface{}) *ValidationError {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(target)
+	if err == nil {
+		if _, extra := dec.Token(); extra != io.EOF {
+			return &ValidationError{Field: "body", Message: "Request body must contain a single JSON value"}
+		}
+		return nil
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return &ValidationError{Field: "body", Message: fmt.Sprintf("Malformed JSON at position %d", syntaxErr.Offset)}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &ValidationError{Field: "body", Message: "Malformed JSON: unexpected end of input"}
+	case errors.As(err, &typeErr):
+		if typeErr.Field == "" {
+			return &ValidationError{Field: "body", Message: "Request body must be a JSON " + jsonKind(reflect.TypeOf(target).Elem())}
+		}
+		return &ValidationError{Field: typeErr.Field, Message: "Must be a " + jsonKind(typeErr.Type)}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return &ValidationError{Field: field, Message: "Unknown field"}
+	}
+	return &ValidationError{Field: "body", Message: "Invalid request body"}
+}
+
+// jsonKind names a Go type the way a client sees it in JSON
+func jsonKind(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	}
+	if t == timeType {
+		return "RFC 3339 timestamp"
+	}
+	return "object"
+}
+
+// isJSONContentType accepts application/json and application/*+json
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" ||
+		(strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json"))
+}
+
+// sanitizeValue applies sanitize tags to every string field reachable from v
+func sanitizeValue(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			sanitizeValue(v.Elem())
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			sanitizeValue(v.Index(i))
+		}
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return
+		}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			value := v.Field(i)
+			if !field.IsExported() || !value.CanSet() {
+				continue
+			}
+			mode := field.Tag.Get("sanitize")
+			if mode == "-" {
+				continue
+			}
+			if value.Kind() == reflect.String {
+				value.SetString(sanitizeString(value.String(), mode))
+				continue
+			}
+			if value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.String {
+				for j := 0; j < value.Len(); j++ {
+					value.Index(j).SetString(sanitizeString(value.Index(j).String(), mode))
+				}
+				continue
+			}
+			sanitizeValue(value)
+		}
+	}
+}
+
+// sanitizeString applies one sanitize tag mode to a value
+func sanitizeString(s, mode string) string {
+	switch mode {
+	case "":
+		return strings.TrimSpace(strings.ReplaceAll(s, "\x00", ""))
+	case "html":
+		return defaultSanitizer.SanitizeString(s)
+	default:
+		return defaultSanitizer.SanitizeUserInput(s, mode)
+	}
+}
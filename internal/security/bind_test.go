@@ -0,0 +1,81 @@
+package security
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type bindSignup struct {
+	Email    string   `json:"email" validate:"required,email"`
+	Name     string   `json:"name" validate:"max=10"`
+	Password string   `json:"password" validate:"required" sanitize:"-"`
+	Bio      string   `json:"bio" sanitize:"html"`
+	Tags     []string `json:"tags"`
+	Age      int      `json:"age"`
+}
+
+func newBindRequest(body, contentType string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(body))
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	return r
+}
+
+func TestBind_DecodesSanitizesAndValidates(t *testing.T) {
+	r := newBindRequest(`{"email":" ann@example.com ","name":"Ann\u0000","password":"  secret  ","bio":"<b>hi</b>","tags":[" a "]}`, "application/json; charset=utf-8")
+
+	got, failures := Bind[bindSignup](r)
+	if failures != nil {
+		t.Fatalf("Unexpected failures: %+v", failures)
+	}
+	if got.Email != "ann@example.com" || got.Name != "Ann" || got.Tags[0] != "a" {
+		t.Errorf("Default sanitization not applied: %+v", got)
+	}
+	if got.Password != "  secret  " {
+		t.Errorf("sanitize:\"-\" field was changed: %q", got.Password)
+	}
+	if got.Bio != "&lt;b&gt;hi&lt;/b&gt;" {
+		t.Errorf("Bio = %q, want HTML escaped", got.Bio)
+	}
+}
+
+func TestBind_Rejections(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		field       string
+	}{
+		{"wrong content type", `{"email":"a@b.co","password":"x"}`, "text/plain", "content-type"},
+		{"unknown field", `{"email":"a@b.co","password":"x","admin":true}`, "application/json", "admin"},
+		{"wrong type", `{"email":"a@b.co","password":"x","age":"old"}`, "application/json", "age"},
+		{"malformed", `{"email":`, "application/json", "body"},
+		{"trailing data", `{"email":"a@b.co","password":"x"} {}`, "application/json", "body"},
+		{"not an object", `[1]`, "application/json", "body"},
+		{"empty body fails validation", ``, "", "email"},
+		{"tag validation", `{"email":"a@b.co","password":"x","name":"far too long a name"}`, "application/json", "name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, failures := Bind[bindSignup](newBindRequest(tt.body, tt.contentType))
+			if len(failures) == 0 {
+				t.Fatal("Expected a failure")
+			}
+			if failures[0].Field != tt.field {
+				t.Errorf("Field = %q (%s), want %q", failures[0].Field, failures[0].Message, tt.field)
+			}
+		})
+	}
+}
+
+func TestBindLimit_RejectsLargeBodies(t *testing.T) {
+	body := `{"email":"a@b.co","password":"` + strings.Repeat("x", 100) + `"}`
+	_, failures := BindLimit[bindSignup](newBindRequest(body, "application/json"), 64)
+	if len(failures) != 1 || failures[0].Field != "body" {
+		t.Fatalf("Expected a body size failure, got %+v", failures)
+	}
+}
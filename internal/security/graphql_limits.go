@@ -0,0 +1,161 @@
+package security
+
+import (
+	"fmt"
+	"math"
+
+	"go-server/internal/graphql"
+)
+
+// DefaultGraphQLListSize is the assumed length of list fields whose size the
+// query does not bound with a limit or first argument
+const DefaultGraphQLListSize = 10
+
+// GraphQLLimits rejects GraphQL queries that nest too deeply or would
+// resolve too many fields before they reach the resolvers
+type GraphQLLimits struct {
+	MaxDepth      int
+	MaxComplexity int
+}
+
+// NewGraphQLLimits creates new GraphQL limits; zero disables a limit
+func NewGraphQLLimits(maxDepth, maxComplexity int) *GraphQLLimits {
+	return &GraphQLLimits{MaxDepth: maxDepth, MaxComplexity: maxComplexity}
+}
+
+// Validator returns the limits as a validator for graphql.Execute
+func (gl *GraphQLLimits) Validator() graphql.Validator {
+	return func(schema *graphql.Schema, doc *graphql.Document, op *graphql.Operation, variables map[string]any) error {
+		depth, complexity := MeasureGraphQL(schema, doc, op, variables)
+		if gl.MaxDepth > 0 && depth > gl.MaxDepth {
+			return fmt.Errorf("query depth %d exceeds the maximum of %d", depth, gl.MaxDepth)
+		}
+		if gl.MaxComplexity > 0 && complexity > gl.MaxComplexity {
+			return fmt.Errorf("query complexity %d exceeds the maximum of %d", complexity, gl.MaxComplexity)
+		}
+		return nil
+	}
+}
+
+// MeasureGraphQL returns the depth and complexity of an operation. Each field
+// costs its schema weight, and the cost of a list field's selections is
+// multiplied by the list size its limit or first argument asks for. Each
+// fragment is measured once and its cost reused wherever it is spread, and
+// the complexity saturates at math.MaxInt rather than overflowing.
+func MeasureGraphQL(schema *graphql.Schema, doc *graphql.Document, op *graphql.Operation, variables map[string]any) (depth, complexity int) {
+	m := &graphqlMeter{doc: doc, variables: variables, visiting: make(map[string]bool), measured: make(map[string]graphqlCost)}
+	return m.measure(schema.Query, op.SelectionSet)
+}
+
+type graphqlMeter struct {
+	doc       *graphql.Document
+	variables map[string]any
+	visiting  map[string]bool
+	// measured holds the cost of each fragment already measured
+	measured map[string]graphqlCost
+}
+
+type graphqlCost struct {
+	depth      int
+	complexity int
+}
+
+func (m *graphqlMeter) measure(obj *graphql.Object, selections []graphql.Selection) (depth, complexity int) {
+	for _, selection := range selections {
+		var d, c int
+		switch s := selection.(type) {
+		case *graphql.Field:
+			d, c = m.field(obj, s)
+		case *graphql.InlineFragment:
+			d, c = m.measure(obj, s.SelectionSet)
+		case *graphql.FragmentSpread:
+			if cost, ok := m.measured[s.Name]; ok {
+				d, c = cost.depth, cost.complexity
+				break
+			}
+			fragment := m.doc.Fragments[s.Name]
+			if fragment == nil || m.visiting[s.Name] {
+				continue
+			}
+			m.visiting[s.Name] = true
+			d, c = m.measure(obj, fragment.SelectionSet)
+			delete(m.visiting, s.Name)
+			m.measured[s.Name] = graphqlCost{depth: d, complexity: c}
+		}
+		if d > depth {
+			depth = d
+		}
+		complexity = addCost(complexity, c)
+	}
+	return depth, complexity
+}
+
+func (m *graphqlMeter) field(obj *graphql.Object, field *graphql.Field) (depth, complexity int) {
+	definition := obj.Fields[field.Name]
+	if definition == nil {
+		return 1, 1
+	}
+	cost := definition.Cost
+	if cost == 0 {
+		cost = 1
+	}
+
+	child, ok := graphql.NamedType(definition.Type).(*graphql.Object)
+	if !ok || field.SelectionSet == nil {
+		return 1, cost
+	}
+	childDepth, childComplexity := m.measure(child, field.SelectionSet)
+	if graphql.IsList(definition.Type) {
+		childComplexity = multiplyCost(childComplexity, m.listSize(definition, field))
+	}
+	return childDepth + 1, addCost(cost, childComplexity)
+}
+
+// addCost adds two non-negative costs, saturating at math.MaxInt
+func addCost(a, b int) int {
+	if a > math.MaxInt-b {
+		return math.MaxInt
+	}
+	return a + b
+}
+
+// multiplyCost multiplies two non-negative costs, saturating at math.MaxInt
+func multiplyCost(a, b int) int {
+	if a != 0 && b > math.MaxInt/a {
+		return math.MaxInt
+	}
+	return a * b
+}
+
+// listSize reads the page size a list field requests, falling back to the
+// argument default and then to DefaultGraphQLListSize
+func (m *graphqlMeter) listSize(definition *graphql.FieldDefinition, field *graphql.Field) int {
+	for _, name := range []string{"limit", "first"} {
+		argument := definition.Args[name]
+		if argument == nil {
+			continue
+		}
+		if size, ok := m.intValue(field.Arguments[name]); ok && size > 0 {
+			return size
+		}
+		if size, ok := argument.Default.(int); ok && size > 0 {
+			return size
+		}
+	}
+	return DefaultGraphQLListSize
+}
+
+func (m *graphqlMeter) intValue(value graphql.Value) (int, bool) {
+	switch v := value.(type) {
+	case graphql.IntValue:
+		return int(v), true
+	case graphql.Variable:
+		switch n := m.variables[string(v)].(type) {
+		case float64:
+			return int(n), true
+		case int:
+			return n, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,109 @@
+package security
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"go-server/internal/graphql"
+)
+
+func limitsTestSchema() *graphql.Schema {
+	user := &graphql.Object{Name: "User"}
+	post := &graphql.Object{Name: "Post"}
+	user.Fields = map[string]*graphql.FieldDefinition{
+		"name": {Type: graphql.String},
+		"posts": {
+			Type: &graphql.List{Of: post},
+			Args: map[string]*graphql.Argument{"limit": {Type: graphql.Int, Default: 5}},
+		},
+	}
+	post.Fields = map[string]*graphql.FieldDefinition{
+		"title":  {Type: graphql.String},
+		"body":   {Type: graphql.String, Cost: 3},
+		"author": {Type: user},
+	}
+	return &graphql.Schema{Query: &graphql.Object{Name: "Query", Fields: map[string]*graphql.FieldDefinition{
+		"users": {Type: &graphql.List{Of: user}},
+		"post":  {Type: post},
+	}}}
+}
+
+func measure(t *testing.T, query string, variables map[string]any) (int, int) {
+	t.Helper()
+	doc, err := graphql.Parse(query)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	return MeasureGraphQL(limitsTestSchema(), doc, doc.Operations[0], variables)
+}
+
+func TestMeasureGraphQL(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		variables  map[string]any
+		depth      int
+		complexity int
+	}{
+		{"scalar fields", `{ post { title body } }`, nil, 2, 1 + 1 + 3},
+		// users defaults to 10 items, posts to its limit default of 5
+		{"nested lists", `{ users { posts { title } } }`, nil, 3, 1 + 10*(1+5*1)},
+		{"limit argument", `{ users { posts(limit: 2) { title } } }`, nil, 3, 1 + 10*(1+2*1)},
+		{"limit variable", `query Q($n: Int) { users { posts(limit: $n) { title } } }`, map[string]any{"n": float64(1)}, 3, 1 + 10*(1+1)},
+		{"fragments", `{ post { ...P author { name } } } fragment P on Post { title }`, nil, 3, 1 + 1 + 1 + 1},
+		{"cyclic fragments", `{ post { ...A } } fragment A on Post { author { posts { ...A } } }`, nil, 3, 1 + 1 + 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			depth, complexity := measure(t, tt.query, tt.variables)
+			if depth != tt.depth || complexity != tt.complexity {
+				t.Errorf("got depth %d complexity %d, want %d and %d", depth, complexity, tt.depth, tt.complexity)
+			}
+		})
+	}
+}
+
+// fanOutQuery spreads each of n fragments twice in the next, so walking
+// every spread would visit 2^n fields
+func fanOutQuery(n int) string {
+	var query strings.Builder
+	fmt.Fprintf(&query, `{ post { ...F%d } } fragment F0 on Post { title }`, n)
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&query, ` fragment F%d on Post { ...F%d ...F%d }`, i, i-1, i-1)
+	}
+	return query.String()
+}
+
+func TestMeasureGraphQL_FragmentFanOut(t *testing.T) {
+	// Each fragment is measured once, yet its cost counts at every spread
+	depth, complexity := measure(t, fanOutQuery(22), nil)
+	if depth != 2 || complexity != 1+1<<22 {
+		t.Errorf("got depth %d complexity %d, want 2 and %d", depth, complexity, 1+1<<22)
+	}
+
+	// Far more spreads than an int can count saturate instead of wrapping
+	if _, complexity := measure(t, fanOutQuery(80), nil); complexity != math.MaxInt {
+		t.Errorf("expected complexity to saturate, got %d", complexity)
+	}
+}
+
+func TestGraphQLLimits_Validator(t *testing.T) {
+	schema := limitsTestSchema()
+	doc, err := graphql.Parse(`{ post { author { posts { author { name } } } } }`)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	op := doc.Operations[0]
+
+	if err := NewGraphQLLimits(5, 100).Validator()(schema, doc, op, nil); err != nil {
+		t.Errorf("query within limits rejected: %v", err)
+	}
+	if err := NewGraphQLLimits(4, 0).Validator()(schema, doc, op, nil); err == nil || !strings.Contains(err.Error(), "depth 5") {
+		t.Errorf("expected depth error, got %v", err)
+	}
+	if err := NewGraphQLLimits(0, 5).Validator()(schema, doc, op, nil); err == nil || !strings.Contains(err.Error(), "complexity") {
+		t.Errorf("expected complexity error, got %v", err)
+	}
+}
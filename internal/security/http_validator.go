@@ -157,9 +157,7 @@ func (v *HTTPValidator) isValidPath(path string) bool {
 	return true
 }
 
-// validateFields validates struct fields using reflection
+// validateFields validates struct fields against their validate tags
 func (v *HTTPValidator) validateFields(target interface{}) []ValidationError {
-	// This would use reflection to validate struct fields
-	// For now, return empty slice as placeholder
-	return []ValidationError{}
+	return ValidateStruct(target)
 }
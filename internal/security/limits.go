@@ -0,0 +1,163 @@
+package security
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Rate limit tiers
+const (
+	TierAnonymous = "anonymous"
+	TierStandard  = "standard"
+	TierAdmin     = "admin"
+)
+
+// KeyFunc identifies who a request is counted against, e.g. GetClientIP
+type KeyFunc func(r *http.Request) string
+
+// TierFunc returns the tier of the caller making a request
+type TierFunc func(r *http.Request) string
+
+// LimitStatus is a caller's standing against one rate limit or quota
+type LimitStatus struct {
+	Name      string   `json:"name"`
+	Endpoints []string `json:"endpoints,omitempty"`
+	Limit     int64    `json:"limit"`
+	Remaining int64    `json:"remaining"`
+	Unit      string   `json:"unit"`
+	Window    string   `json:"window,omitempty"`
+	// ResetAt is when the remaining amount is restored; nil if it never resets
+	ResetAt *time.Time `json:"reset_at,omitempty"`
+}
+
+// Meter reports usage-based quotas, such as storage, for a user
+type Meter interface {
+	Quotas(ctx context.Context, userID uint) ([]LimitStatus, error)
+}
+
+// endpointLimit is a named rate limit with one limiter per tier
+type endpointLimit struct {
+	name      string
+	endpoints []string
+	key       KeyFunc
+	limiters  map[string]*RateLimiter
+}
+
+// LimitRegistry keeps the rate limiters guarding each endpoint group and the
+// meters tracking quotas, so callers can be told where they stand
+type LimitRegistry struct {
+	tier TierFunc
+
+	mu     sync.RWMutex
+	limits []*endpointLimit
+	meters []Meter
+}
+
+// NewLimitRegistry creates a registry; tier picks each caller's tier and nil
+// puts everyone in TierStandard
+func NewLimitRegistry(tier TierFunc) *LimitRegistry {
+	if tier == nil {
+		tier = func(*http.Request) string { return TierStandard }
+	}
+	return &LimitRegistry{tier: tier}
+}
+
+// Register adds a rate limit covering endpoints. limiters maps tiers to their
+// limiter; a tier without one is not limited. key identifies callers.
+func (lr *LimitRegistry) Register(name string, endpoints []string, key KeyFunc, limiters map[string]*RateLimiter) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	lr.limits = append(lr.limits, &endpointLimit{name: name, endpoints: endpoints, key: key, limiters: limiters})
+}
+
+// AddMeter adds a source of quotas to report
+func (lr *LimitRegistry) AddMeter(meter Meter) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	lr.meters = append(lr.meters, meter)
+}
+
+// Tier returns the tier of the caller making r
+func (lr *LimitRegistry) Tier(r *http.Request) string {
+	return lr.tier(r)
+}
+
+// Middleware enforces the named limit with the limiter for the caller's
+// tier; it must run after authentication so the tier is known
+func (lr *LimitRegistry) Middleware(name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tier := lr.tier(r)
+			limit := lr.limit(name)
+			if limit == nil || limit.limiters[tier] == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			rateLimitBy(limit.limiters[tier], limit.key)(next).ServeHTTP(w, r)
+		})
+	}
+}
+
+func (lr *LimitRegistry) limit(name string) *endpointLimit {
+	lr.mu.RLock()
+	defer lr.mu.RUnlock()
+	for _, limit := range lr.limits {
+		if limit.name == name {
+			return limit
+		}
+	}
+	return nil
+}
+
+// RateLimits reports the caller's remaining requests under each limit that
+// applies to their tier, without counting as a request
+func (lr *LimitRegistry) RateLimits(r *http.Request) []LimitStatus {
+	lr.mu.RLock()
+	defer lr.mu.RUnlock()
+
+	tier := lr.tier(r)
+	var statuses []LimitStatus
+	for _, limit := range lr.limits {
+		limiter := limit.limiters[tier]
+		if limiter == nil {
+			continue
+		}
+		key := limit.key(r)
+		multiplier := 1.0
+		if limiter.scale != nil {
+			multiplier = limiter.scale.Multiplier(r)
+		}
+		resetAt := limiter.GetResetTime(key)
+		statuses = append(statuses, LimitStatus{
+			Name:      limit.name,
+			Endpoints: limit.endpoints,
+			Limit:     int64(limiter.scaledLimit(multiplier)),
+			Remaining: int64(limiter.remaining(key, multiplier)),
+			Unit:      "requests",
+			Window:    limiter.Window().String(),
+			ResetAt:   &resetAt,
+		})
+	}
+	return statuses
+}
+
+// Quotas collects the user's quotas from every meter, sorted by name
+func (lr *LimitRegistry) Quotas(ctx context.Context, userID uint) ([]LimitStatus, error) {
+	lr.mu.RLock()
+	meters := append([]Meter(nil), lr.meters...)
+	lr.mu.RUnlock()
+
+	var quotas []LimitStatus
+	for _, meter := range meters {
+		statuses, err := meter.Quotas(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		quotas = append(quotas, statuses...)
+	}
+	sort.SliceStable(quotas, func(i, j int) bool { return quotas[i].Name < quotas[j].Name })
+	return quotas, nil
+}
@@ -0,0 +1,81 @@
+package security
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+)
+
+func TestLimitRegistry(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	newLimiter := func(limit int) *RateLimiter {
+		return NewRateLimiter(RateLimitConfig{RequestsPerMinute: limit, WindowDuration: time.Minute, CleanupInterval: time.Hour, Clock: fake})
+	}
+	tierOf := func(r *http.Request) string { return r.Header.Get("X-Tier") }
+	registry := NewLimitRegistry(tierOf)
+	registry.Register("posts", []string{"POST /api/posts"}, GetClientIP, map[string]*RateLimiter{
+		TierStandard: newLimiter(1),
+		TierAdmin:    newLimiter(100),
+	})
+
+	handler := registry.Middleware("posts")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	send := func(tier string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/posts", nil)
+		req.Header.Set("X-Tier", tier)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+	if send(TierStandard) != http.StatusCreated || send(TierStandard) != http.StatusTooManyRequests {
+		t.Error("standard tier should be limited to one request")
+	}
+	if send(TierAdmin) != http.StatusCreated || send(TierAnonymous) != http.StatusCreated {
+		t.Error("tiers should use their own limiter, and tiers without one are unlimited")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/me/limits", nil)
+	req.Header.Set("X-Tier", TierAdmin)
+	statuses := registry.RateLimits(req)
+	if len(statuses) != 1 || statuses[0].Limit != 100 || statuses[0].Remaining != 99 || statuses[0].Window != "1m0s" ||
+		!statuses[0].ResetAt.Equal(fake.Now().Add(time.Minute)) {
+		t.Errorf("unexpected admin status: %+v", statuses)
+	}
+	if statuses := registry.RateLimits(req); statuses[0].Remaining != 99 {
+		t.Error("reporting limits should not count as a request")
+	}
+	req.Header.Set("X-Tier", TierAnonymous)
+	if statuses := registry.RateLimits(req); len(statuses) != 0 {
+		t.Errorf("unlimited tiers should report no limits: %+v", statuses)
+	}
+}
+
+func TestUsageMeter(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	meter := NewUsageMeter("exports", "files", 3, 24*time.Hour).WithClock(fake)
+	registry := NewLimitRegistry(nil)
+	registry.AddMeter(meter)
+
+	if !meter.Consume(1, 2) || meter.Consume(1, 2) || !meter.Consume(2, 3) {
+		t.Fatal("consumption should be limited per user")
+	}
+	quotas, err := registry.Quotas(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Quotas failed: %v", err)
+	}
+	midnight := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	if len(quotas) != 1 || quotas[0].Remaining != 1 || quotas[0].Unit != "files" || !quotas[0].ResetAt.Equal(midnight) {
+		t.Errorf("unexpected quota: %+v", quotas)
+	}
+
+	fake.Set(midnight)
+	quotas, _ = meter.Quotas(context.Background(), 1)
+	if quotas[0].Remaining != 3 {
+		t.Errorf("quota should reset with the period: %+v", quotas)
+	}
+}
@@ -0,0 +1,54 @@
+package security
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+)
+
+func TestLinkSigner(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	signer := NewLinkSigner("calendar-feed", "user", "0123456789abcdef0123456789abcdef", time.Hour).WithClock(fake)
+
+	query, expiresAt := signer.Sign(42)
+	if !expiresAt.Equal(fake.Now().Add(time.Hour).Truncate(time.Second)) {
+		t.Errorf("expiresAt = %v", expiresAt)
+	}
+	if id, err := signer.Verify(query); err != nil || id != 42 {
+		t.Fatalf("Verify() = %d, %v; want 42", id, err)
+	}
+
+	tampered, _ := signer.Sign(42)
+	tampered.Set("user", "43")
+	if _, err := signer.Verify(tampered); !errors.Is(err, ErrInvalidLink) {
+		t.Errorf("Verify() of another user's link error = %v, want ErrInvalidLink", err)
+	}
+	other, _ := NewLinkSigner("calendar-feed", "user", "another secret that is long enough!!", time.Hour).WithClock(fake).Sign(42)
+	if _, err := signer.Verify(other); !errors.Is(err, ErrInvalidLink) {
+		t.Errorf("Verify() of a link signed with another secret error = %v, want ErrInvalidLink", err)
+	}
+	// The purpose is signed, so a link for one kind of resource is no good for another
+	download, _ := NewLinkSigner("report-download", "user", "0123456789abcdef0123456789abcdef", time.Hour).WithClock(fake).Sign(42)
+	if _, err := signer.Verify(download); !errors.Is(err, ErrInvalidLink) {
+		t.Errorf("Verify() of a link signed for another purpose error = %v, want ErrInvalidLink", err)
+	}
+
+	fake.Advance(time.Hour)
+	if _, err := signer.Verify(query); !errors.Is(err, ErrLinkExpired) {
+		t.Errorf("Verify() of an expired link error = %v, want ErrLinkExpired", err)
+	}
+
+	forever := NewLinkSigner("calendar-feed", "user", "0123456789abcdef0123456789abcdef", 0).WithClock(fake)
+	query, expiresAt = forever.Sign(7)
+	fake.Advance(10 * 365 * 24 * time.Hour)
+	if _, err := forever.Verify(query); err != nil || !expiresAt.IsZero() {
+		t.Errorf("Links without a TTL should not expire: %v, %v", expiresAt, err)
+	}
+	if disabled := NewLinkSigner("calendar-feed", "user", "", 0); disabled.Enabled() {
+		t.Error("A signer without a secret should not be enabled")
+	} else if _, err := disabled.Verify(query); !errors.Is(err, ErrInvalidLink) {
+		t.Errorf("A signer without a secret must reject links, got %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package security
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go-server/internal/clock"
+)
+
+// UsageMeter counts a user's consumption of a quota, such as API calls per
+// day, in fixed periods. Counts are kept in memory.
+type UsageMeter struct {
+	name   string
+	unit   string
+	limit  int64
+	period time.Duration
+	clock  clock.Clock
+
+	mu    sync.Mutex
+	usage map[uint]*meterPeriod
+}
+
+// meterPeriod is a user's consumption in the current period
+type meterPeriod struct {
+	start time.Time
+	used  int64
+}
+
+// NewUsageMeter creates a meter allowing limit units per period
+func NewUsageMeter(name, unit string, limit int64, period time.Duration) *UsageMeter {
+	return &UsageMeter{
+		name:   name,
+		unit:   unit,
+		limit:  limit,
+		period: period,
+		clock:  clock.New(),
+		usage:  make(map[uint]*meterPeriod),
+	}
+}
+
+// WithClock sets the clock used to start and end periods
+func (um *UsageMeter) WithClock(c clock.Clock) *UsageMeter {
+	um.clock = clock.OrDefault(c)
+	return um
+}
+
+// Consume records n units for the user and reports whether they fit in the
+// remaining quota; nothing is recorded if they do not
+func (um *UsageMeter) Consume(userID uint, n int64) bool {
+	um.mu.Lock()
+	defer um.mu.Unlock()
+
+	current := um.current(userID)
+	if current.used+n > um.limit {
+		return false
+	}
+	current.used += n
+	return true
+}
+
+// Quotas implements Meter
+func (um *UsageMeter) Quotas(ctx context.Context, userID uint) ([]LimitStatus, error) {
+	um.mu.Lock()
+	defer um.mu.Unlock()
+
+	current := um.current(userID)
+	resetAt := current.start.Add(um.period)
+	return []LimitStatus{{
+		Name:      um.name,
+		Limit:     um.limit,
+		Remaining: max(um.limit-current.used, 0),
+		Unit:      um.unit,
+		Window:    um.period.String(),
+		ResetAt:   &resetAt,
+	}}, nil
+}
+
+// current returns the user's period, starting a new one if the last has ended
+func (um *UsageMeter) current(userID uint) *meterPeriod {
+	now := um.clock.Now()
+	current, ok := um.usage[userID]
+	if !ok || !now.Before(current.start.Add(um.period)) {
+		current = &meterPeriod{start: now.Truncate(um.period)}
+		um.usage[userID] = current
+	}
+	return current
+}
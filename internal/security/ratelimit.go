@@ -2,11 +2,13 @@ package security
 
 import (
 	"fmt"
-	"net"
+	"math"
 	"net/http"
-	"strings"
 	"sync"
 	"time"
+
+	"go-server/internal/clientip"
+	"go-server/internal/clock"
 )
 
 // RateLimiter implements per-IP rate limiting
@@ -16,6 +18,21 @@ type RateLimiter struct {
 	limit    int
 	window   time.Duration
 	cleanup  time.Duration
+	clock    clock.Clock
+	bans     BanList
+	scale    LimitMultiplier
+}
+
+// LimitMultiplier scales the limit of the client making a request, e.g. by
+// its network of origin; 1 leaves the limit unchanged
+type LimitMultiplier interface {
+	Multiplier(r *http.Request) float64
+}
+
+// BanList reports client IPs temporarily banned, e.g. by the abuse detector
+type BanList interface {
+	// BannedUntil returns when the IP's ban ends, if it is banned
+	BannedUntil(ip string) (time.Time, bool)
 }
 
 // RateLimitConfig holds rate limiting configuration
@@ -24,6 +41,9 @@ type RateLimitConfig struct {
 	WindowDuration    time.Duration
 	CleanupInterval   time.Duration
 	BurstSize         int
+	Clock             clock.Clock     // Optional; defaults to the system clock
+	Bans              BanList         // Optional; banned IPs are refused outright
+	Multiplier        LimitMultiplier // Optional; scales the limit per request
 }
 
 // NewRateLimiter creates a new rate limiter
@@ -33,6 +53,9 @@ func NewRateLimiter(config RateLimitConfig) *RateLimiter {
 		limit:    config.RequestsPerMinute,
 		window:   config.WindowDuration,
 		cleanup:  config.CleanupInterval,
+		clock:    clock.OrDefault(config.Clock),
+		bans:     config.Bans,
+		scale:    config.Multiplier,
 	}
 
 	// Start cleanup goroutine
@@ -43,10 +66,15 @@ func NewRateLimiter(config RateLimitConfig) *RateLimiter {
 
 // IsAllowed checks if a request from the given IP is allowed
 func (rl *RateLimiter) IsAllowed(ip string) bool {
+	return rl.isAllowed(ip, 1)
+}
+
+// isAllowed checks a request against the limit scaled by multiplier
+func (rl *RateLimiter) isAllowed(ip string, multiplier float64) bool {
 	rl.mutex.Lock()
 	defer rl.mutex.Unlock()
 
-	now := time.Now()
+	now := rl.clock.Now()
 	cutoff := now.Add(-rl.window)
 
 	// Get existing requests for this IP
@@ -64,7 +92,7 @@ func (rl *RateLimiter) IsAllowed(ip string) bool {
 	}
 
 	// Check if under limit
-	if len(validRequests) >= rl.limit {
+	if len(validRequests) >= scaleLimit(rl.limit, multiplier) {
 		return false
 	}
 
@@ -75,17 +103,59 @@ func (rl *RateLimiter) IsAllowed(ip string) bool {
 	return true
 }
 
+// Limit returns the number of requests allowed per window
+func (rl *RateLimiter) Limit() int {
+	rl.mutex.RLock()
+	defer rl.mutex.RUnlock()
+	return rl.limit
+}
+
+// SetLimit changes the number of requests allowed per window, e.g. when the
+// configuration is reloaded. Requests already counted stay in the window.
+func (rl *RateLimiter) SetLimit(limit int) {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	rl.limit = limit
+}
+
+// scaledLimit returns the limit scaled by multiplier
+func (rl *RateLimiter) scaledLimit(multiplier float64) int {
+	rl.mutex.RLock()
+	defer rl.mutex.RUnlock()
+	return scaleLimit(rl.limit, multiplier)
+}
+
+// scaleLimit scales limit by multiplier, allowing at least one request
+func scaleLimit(limit int, multiplier float64) int {
+	if multiplier <= 0 || multiplier == 1 {
+		return limit
+	}
+	return max(int(math.Round(float64(limit)*multiplier)), 1)
+}
+
+// Window returns the rate limiting window
+func (rl *RateLimiter) Window() time.Duration {
+	return rl.window
+}
+
 // GetRemainingRequests returns the number of remaining requests for an IP
 func (rl *RateLimiter) GetRemainingRequests(ip string) int {
+	return rl.remaining(ip, 1)
+}
+
+// remaining counts the requests left under the limit scaled by multiplier
+func (rl *RateLimiter) remaining(ip string, multiplier float64) int {
 	rl.mutex.RLock()
 	defer rl.mutex.RUnlock()
 
-	now := time.Now()
+	limit := scaleLimit(rl.limit, multiplier)
+
+	now := rl.clock.Now()
 	cutoff := now.Add(-rl.window)
 
 	requests, exists := rl.requests[ip]
 	if !exists {
-		return rl.limit
+		return limit
 	}
 
 	// Count valid requests
@@ -96,7 +166,7 @@ func (rl *RateLimiter) GetRemainingRequests(ip string) int {
 		}
 	}
 
-	remaining := rl.limit - validCount
+	remaining := limit - validCount
 	if remaining < 0 {
 		return 0
 	}
@@ -109,7 +179,7 @@ func (rl *RateLimiter) GetResetTime(ip string) time.Time {
 	rl.mutex.RLock()
 	defer rl.mutex.RUnlock()
 
-	now := time.Now()
+	now := rl.clock.Now()
 	cutoff := now.Add(-rl.window)
 
 	requests, exists := rl.requests[ip]
@@ -140,80 +210,81 @@ func (rl *RateLimiter) cleanupExpired() {
 	defer ticker.Stop()
 
 	for range ticker.C {
-		rl.mutex.Lock()
-		now := time.Now()
-		cutoff := now.Add(-rl.window)
-
-		for ip, requests := range rl.requests {
-			var validRequests []time.Time
-			for _, reqTime := range requests {
-				if reqTime.After(cutoff) {
-					validRequests = append(validRequests, reqTime)
-				}
-			}
-
-			if len(validRequests) == 0 {
-				delete(rl.requests, ip)
-			} else {
-				rl.requests[ip] = validRequests
-			}
-		}
-		rl.mutex.Unlock()
+		rl.removeExpired()
 	}
 }
 
-// GetClientIP extracts the client IP from the request
-func GetClientIP(r *http.Request) string {
-	// Check X-Forwarded-For header first
-	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		// X-Forwarded-For can contain multiple IPs, take the first one
-		if comma := strings.Index(xff, ","); comma != -1 {
-			xff = xff[:comma]
-		}
-		xff = strings.TrimSpace(xff)
-		if xff != "" {
-			return xff
-		}
-	}
+// removeExpired drops request timestamps that fall outside the window
+func (rl *RateLimiter) removeExpired() {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
 
-	// Check X-Real-IP header
-	if xri := r.Header.Get("X-Real-IP"); xri != "" {
-		return strings.TrimSpace(xri)
-	}
+	cutoff := rl.clock.Now().Add(-rl.window)
 
-	// Fall back to RemoteAddr
-	ip, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err != nil {
-		return r.RemoteAddr
+	for ip, requests := range rl.requests {
+		var validRequests []time.Time
+		for _, reqTime := range requests {
+			if reqTime.After(cutoff) {
+				validRequests = append(validRequests, reqTime)
+			}
+		}
+
+		if len(validRequests) == 0 {
+			delete(rl.requests, ip)
+		} else {
+			rl.requests[ip] = validRequests
+		}
 	}
+}
 
-	return ip
+// GetClientIP extracts the client IP from the request, see clientip.Get
+func GetClientIP(r *http.Request) string {
+	return clientip.Get(r)
 }
 
 // RateLimitMiddleware creates a rate limiting middleware
 func RateLimitMiddleware(rateLimiter *RateLimiter) func(http.Handler) http.Handler {
+	return rateLimitBy(rateLimiter, GetClientIP)
+}
+
+// rateLimitBy limits requests per key, e.g. per client IP or per user.
+// Banned client IPs are refused whatever the key.
+func rateLimitBy(rateLimiter *RateLimiter, key KeyFunc) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			clientIP := GetClientIP(r)
+			if rateLimiter.bans != nil {
+				if until, banned := rateLimiter.bans.BannedUntil(GetClientIP(r)); banned {
+					w.Header().Set("Retry-After", fmt.Sprintf("%d", int(rateLimiter.clock.Until(until).Seconds())))
+					http.Error(w, "Client temporarily banned", http.StatusForbidden)
+					return
+				}
+			}
+
+			clientKey := key(r)
+			multiplier := 1.0
+			if rateLimiter.scale != nil {
+				multiplier = rateLimiter.scale.Multiplier(r)
+			}
+			limit := rateLimiter.scaledLimit(multiplier)
 
-			if !rateLimiter.IsAllowed(clientIP) {
-				remaining := rateLimiter.GetRemainingRequests(clientIP)
-				resetTime := rateLimiter.GetResetTime(clientIP)
+			if !rateLimiter.isAllowed(clientKey, multiplier) {
+				remaining := rateLimiter.remaining(clientKey, multiplier)
+				resetTime := rateLimiter.GetResetTime(clientKey)
 
-				w.Header().Set("X-RateLimit-Limit", fmt.Sprintf("%d", rateLimiter.limit))
+				w.Header().Set("X-RateLimit-Limit", fmt.Sprintf("%d", limit))
 				w.Header().Set("X-RateLimit-Remaining", fmt.Sprintf("%d", remaining))
 				w.Header().Set("X-RateLimit-Reset", fmt.Sprintf("%d", resetTime.Unix()))
-				w.Header().Set("Retry-After", fmt.Sprintf("%d", int(time.Until(resetTime).Seconds())))
+				w.Header().Set("Retry-After", fmt.Sprintf("%d", int(rateLimiter.clock.Until(resetTime).Seconds())))
 
 				http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
 				return
 			}
 
 			// Add rate limit headers to successful requests
-			remaining := rateLimiter.GetRemainingRequests(clientIP)
-			resetTime := rateLimiter.GetResetTime(clientIP)
+			remaining := rateLimiter.remaining(clientKey, multiplier)
+			resetTime := rateLimiter.GetResetTime(clientKey)
 
-			w.Header().Set("X-RateLimit-Limit", fmt.Sprintf("%d", rateLimiter.limit))
+			w.Header().Set("X-RateLimit-Limit", fmt.Sprintf("%d", limit))
 			w.Header().Set("X-RateLimit-Remaining", fmt.Sprintf("%d", remaining))
 			w.Header().Set("X-RateLimit-Reset", fmt.Sprintf("%d", resetTime.Unix()))
 
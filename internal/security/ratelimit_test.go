@@ -5,6 +5,9 @@ import (
 	"net/http/httptest"
 	"testing"
 	"time"
+
+	"go-server/internal/clientip"
+	"go-server/internal/clock"
 )
 
 func TestRateLimiter_IsAllowed(t *testing.T) {
@@ -32,6 +35,26 @@ func TestRateLimiter_IsAllowed(t *testing.T) {
 	}
 }
 
+func TestRateLimiter_SetLimit(t *testing.T) {
+	rl := NewRateLimiter(RateLimitConfig{
+		RequestsPerMinute: 1,
+		WindowDuration:    time.Minute,
+		CleanupInterval:   time.Minute,
+	})
+	ip := "192.168.1.1"
+
+	if !rl.IsAllowed(ip) || rl.IsAllowed(ip) {
+		t.Fatal("Expected a limit of one request")
+	}
+	rl.SetLimit(3)
+	if rl.Limit() != 3 || rl.GetRemainingRequests(ip) != 2 {
+		t.Errorf("Raised limit should count earlier requests, limit %d remaining %d", rl.Limit(), rl.GetRemainingRequests(ip))
+	}
+	if !rl.IsAllowed(ip) {
+		t.Error("Request should be allowed under the raised limit")
+	}
+}
+
 func TestRateLimiter_GetRemainingRequests(t *testing.T) {
 	config := RateLimitConfig{
 		RequestsPerMinute: 3,
@@ -84,6 +107,69 @@ func TestRateLimiter_DifferentIPs(t *testing.T) {
 	}
 }
 
+func TestRateLimiter_WindowExpiry(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	config := RateLimitConfig{
+		RequestsPerMinute: 1,
+		WindowDuration:    time.Minute,
+		CleanupInterval:   time.Hour,
+		BurstSize:         5,
+		Clock:             fake,
+	}
+
+	rl := NewRateLimiter(config)
+	ip := "192.168.1.1"
+
+	if !rl.IsAllowed(ip) {
+		t.Fatal("First request should be allowed")
+	}
+	if rl.IsAllowed(ip) {
+		t.Fatal("Second request inside the window should be denied")
+	}
+
+	expectedReset := fake.Now().Add(time.Minute)
+	if reset := rl.GetResetTime(ip); !reset.Equal(expectedReset) {
+		t.Errorf("Expected reset time %v, got %v", expectedReset, reset)
+	}
+
+	fake.Advance(time.Minute + time.Second)
+
+	if remaining := rl.GetRemainingRequests(ip); remaining != 1 {
+		t.Errorf("Expected 1 remaining request after window, got %d", remaining)
+	}
+	if !rl.IsAllowed(ip) {
+		t.Error("Request after the window should be allowed")
+	}
+}
+
+func TestRateLimiter_RemoveExpired(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	config := RateLimitConfig{
+		RequestsPerMinute: 5,
+		WindowDuration:    time.Minute,
+		CleanupInterval:   time.Hour,
+		BurstSize:         5,
+		Clock:             fake,
+	}
+
+	rl := NewRateLimiter(config)
+	rl.IsAllowed("192.168.1.1")
+	fake.Advance(30 * time.Second)
+	rl.IsAllowed("192.168.1.2")
+	fake.Advance(45 * time.Second)
+
+	rl.removeExpired()
+
+	rl.mutex.RLock()
+	defer rl.mutex.RUnlock()
+	if _, exists := rl.requests["192.168.1.1"]; exists {
+		t.Error("Expired IP should have been removed")
+	}
+	if _, exists := rl.requests["192.168.1.2"]; !exists {
+		t.Error("IP with requests inside the window should be kept")
+	}
+}
+
 func TestRateLimitMiddleware(t *testing.T) {
 	config := RateLimitConfig{
 		RequestsPerMinute: 1,
@@ -142,7 +228,65 @@ func TestRateLimitMiddleware(t *testing.T) {
 	}
 }
 
+// headerMultiplier scales limits by the X-Test-Multiplier header
+type headerMultiplier struct{}
+
+func (headerMultiplier) Multiplier(r *http.Request) float64 {
+	if r.Header.Get("X-Test-Multiplier") == "strict" {
+		return 0.25
+	}
+	return 1
+}
+
+func TestRateLimitMiddleware_Multiplier(t *testing.T) {
+	rl := NewRateLimiter(RateLimitConfig{
+		RequestsPerMinute: 4,
+		WindowDuration:    time.Minute,
+		CleanupInterval:   time.Minute,
+		Multiplier:        headerMultiplier{},
+	})
+	handler := RateLimitMiddleware(rl)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	send := func(ip string, strict bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = ip + ":12345"
+		if strict {
+			req.Header.Set("X-Test-Multiplier", "strict")
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send("10.0.0.1", true)
+	if rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Limit") != "1" || rec.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Fatalf("Strict client should get a limit of 1, got %d limit %s remaining %s",
+			rec.Code, rec.Header().Get("X-RateLimit-Limit"), rec.Header().Get("X-RateLimit-Remaining"))
+	}
+	if rec := send("10.0.0.1", true); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("Strict client's second request should be limited, got %d", rec.Code)
+	}
+
+	for i := 0; i < 4; i++ {
+		if rec := send("10.0.0.2", false); rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Limit") != "4" {
+			t.Fatalf("Request %d of an ordinary client should be allowed under a limit of 4, got %d", i+1, rec.Code)
+		}
+	}
+	if rec := send("10.0.0.2", false); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("Ordinary client's fifth request should be limited, got %d", rec.Code)
+	}
+}
+
 func TestGetClientIP(t *testing.T) {
+	// httptest requests come from 192.0.2.1
+	proxies, err := clientip.ParseTrustedProxies([]string{"192.0.2.1", "10.0.0.0/8", "172.16.0.0/12"})
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies failed: %v", err)
+	}
+	clientip.SetTrustedProxies(proxies)
+	t.Cleanup(func() { clientip.SetTrustedProxies(nil) })
+
 	tests := []struct {
 		name     string
 		request  *http.Request
@@ -184,6 +328,35 @@ func TestGetClientIP(t *testing.T) {
 			}(),
 			expected: "192.168.1.1",
 		},
+		{
+			name: "X-Forwarded-For with an address prepended by the client",
+			request: func() *http.Request {
+				req := httptest.NewRequest("GET", "/", nil)
+				req.Header.Set("X-Forwarded-For", "203.0.113.9, 198.51.100.7, 10.0.0.1")
+				return req
+			}(),
+			expected: "198.51.100.7",
+		},
+		{
+			name: "forwarding headers from an untrusted peer",
+			request: func() *http.Request {
+				req := httptest.NewRequest("GET", "/", nil)
+				req.RemoteAddr = "198.51.100.7:4000"
+				req.Header.Set("X-Forwarded-For", "203.0.113.9")
+				req.Header.Set("X-Real-IP", "203.0.113.9")
+				return req
+			}(),
+			expected: "198.51.100.7",
+		},
+		{
+			name: "malformed X-Forwarded-For",
+			request: func() *http.Request {
+				req := httptest.NewRequest("GET", "/", nil)
+				req.Header.Set("X-Forwarded-For", "not-an-ip")
+				return req
+			}(),
+			expected: "192.0.2.1",
+		},
 	}
 
 	for _, tt := range tests {
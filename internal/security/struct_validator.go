@@ -0,0 +1,247 @@
+package security
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	emailPattern = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
+	timeType     = reflect.TypeOf(time.Time{})
+
+	// structRulesCache maps struct types to their parsed rules
+	structRulesCache sync.Map
+)
+
+// fieldRules are the validate tag rules of one struct field
+type fieldRules struct {
+	index    []int
+	name     string
+	label    string
+	required bool
+	rules    []tagRule
+}
+
+// tagRule is one rule of a validate tag, e.g. min=3
+type tagRule struct {
+	name  string
+	param string
+}
+
+// ValidateStruct checks target's fields against their validate struct tags
+// and returns one error per failing field, named by its JSON path.
+//
+// Supported rules are required, email, url, min, max and oneof; min and max
+// bound the length of strings, slices and maps and the value of numbers.
+// Fields that are empty and not required skip the other rules. Nested
+// structs, and slices and maps of them, are validated too.
+func ValidateStruct(target interface{}) []ValidationError {
+	v, ok := indirect(reflect.ValueOf(target))
+	if !ok || v.Kind() != reflect.Struct {
+		return nil
+	}
+	var errors []ValidationError
+	validateStruct(v, "", &errors)
+	return errors
+}
+
+func validateStruct(v reflect.Value, prefix string, errors *[]ValidationError) {
+	for _, field := range structRules(v.Type()) {
+		value, err := v.FieldByIndexErr(field.index)
+		if err != nil {
+			// a nil embedded pointer has no fields to check
+			continue
+		}
+		name := prefix + field.name
+		if message := field.check(value); message != "" {
+			*errors = append(*errors, ValidationError{Field: name, Message: field.label + " " + message})
+			continue
+		}
+		descend(value, name, errors)
+	}
+}
+
+// descend validates structs nested in a field's value
+func descend(value reflect.Value, name string, errors *[]ValidationError) {
+	value, ok := indirect(value)
+	if !ok || !mayContainStruct(value.Type()) {
+		return
+	}
+	switch value.Kind() {
+	case reflect.Struct:
+		validateStruct(value, name+".", errors)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			descend(value.Index(i), fmt.Sprintf("%s[%d]", name, i), errors)
+		}
+	case reflect.Map:
+		keys := value.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		for _, key := range keys {
+			descend(value.MapIndex(key), fmt.Sprintf("%s.%v", name, key), errors)
+		}
+	}
+}
+
+// mayContainStruct reports whether values of t can hold validated structs
+func mayContainStruct(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Struct:
+		return t != timeType
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return mayContainStruct(t.Elem())
+	case reflect.Interface:
+		return true
+	}
+	return false
+}
+
+// indirect follows pointers and interfaces; ok is false if one is nil
+func indirect(v reflect.Value) (reflect.Value, bool) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return v, false
+		}
+		v = v.Elem()
+	}
+	return v, v.IsValid()
+}
+
+// structRules returns the parsed rules of t's fields, flattening embedded
+// structs as encoding/json does
+func structRules(t reflect.Type) []fieldRules {
+	if cached, ok := structRulesCache.Load(t); ok {
+		return cached.([]fieldRules)
+	}
+
+	var fields []fieldRules
+	for _, field := range reflect.VisibleFields(t) {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if (field.Anonymous && name == "") || !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		rules := fieldRules{index: field.Index, name: name, label: fieldLabel(name)}
+		for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+			ruleName, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+			switch ruleName {
+			case "", "omitempty":
+			case "required":
+				rules.required = true
+			default:
+				rules.rules = append(rules.rules, tagRule{name: ruleName, param: param})
+			}
+		}
+		fields = append(fields, rules)
+	}
+
+	structRulesCache.Store(t, fields)
+	return fields
+}
+
+// fieldLabel turns a JSON name such as first_name into First name
+func fieldLabel(name string) string {
+	label := strings.ReplaceAll(name, "_", " ")
+	if label == "" {
+		return label
+	}
+	return strings.ToUpper(label[:1]) + label[1:]
+}
+
+// check applies the field's rules and returns the first failure
+func (f fieldRules) check(value reflect.Value) string {
+	if value.IsZero() {
+		if f.required {
+			return "is required"
+		}
+		return ""
+	}
+	value, ok := indirect(value)
+	if !ok {
+		return ""
+	}
+
+	for _, rule := range f.rules {
+		if message := rule.check(value); message != "" {
+			return message
+		}
+	}
+	return ""
+}
+
+func (r tagRule) check(value reflect.Value) string {
+	switch r.name {
+	case "email":
+		if value.Kind() == reflect.String && !emailPattern.MatchString(value.String()) {
+			return "must be a valid email address"
+		}
+	case "url":
+		if value.Kind() == reflect.String && !isURL(value.String()) {
+			return "must be a valid URL"
+		}
+	case "oneof":
+		options := strings.Fields(r.param)
+		if !containsString(options, fmt.Sprint(value.Interface())) {
+			return "must be one of " + strings.Join(options, ", ")
+		}
+	case "min", "max":
+		bound, err := strconv.ParseFloat(r.param, 64)
+		if err != nil {
+			return ""
+		}
+		size, unit, ok := ruleSize(value)
+		if !ok {
+			return ""
+		}
+		if r.name == "min" && size < bound {
+			return "must be at least " + r.param + unit
+		}
+		if r.name == "max" && size > bound {
+			return "must be at most " + r.param + unit
+		}
+	}
+	return ""
+}
+
+// ruleSize returns what min and max compare: the length of strings and
+// collections, or the value of numbers
+func ruleSize(value reflect.Value) (float64, string, bool) {
+	switch value.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(value.String())), " characters", true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(value.Len()), " items", true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), "", true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), "", true
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), "", true
+	}
+	return 0, "", false
+}
+
+func isURL(value string) bool {
+	u, err := url.ParseRequestURI(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
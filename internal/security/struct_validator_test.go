@@ -0,0 +1,82 @@
+package security
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testAddress struct {
+	City    string `json:"city" validate:"required"`
+	Country string `json:"country" validate:"oneof=NL DE"`
+}
+
+type testAudit struct {
+	UpdatedBy string `json:"updated_by" validate:"max=5"`
+}
+
+type testSignup struct {
+	testAudit
+	Email     string            `json:"email" validate:"required,email"`
+	Username  string            `json:"username" validate:"required,min=3,max=20"`
+	FirstName string            `json:"first_name" validate:"max=5"`
+	Website   string            `json:"website" validate:"url"`
+	Age       int               `json:"age" validate:"min=18,max=130"`
+	Nickname  *string           `json:"nickname" validate:"min=2"`
+	Tags      []string          `json:"tags" validate:"max=2"`
+	Address   *testAddress      `json:"address"`
+	Previous  []testAddress     `json:"previous"`
+	Labels    map[string]string `json:"labels"`
+	Internal  string            `json:"-" validate:"required"`
+	Joined    time.Time         `json:"joined"`
+}
+
+func TestValidateStruct(t *testing.T) {
+	valid := testSignup{Email: "a@example.com", Username: "alice", Website: "https://example.com", Address: &testAddress{City: "Utrecht"}}
+	if errors := ValidateStruct(&valid); len(errors) != 0 {
+		t.Errorf("valid struct rejected: %v", errors)
+	}
+
+	short := "x"
+	invalid := testSignup{
+		testAudit: testAudit{UpdatedBy: "someone"},
+		Email:     "not-an-email",
+		FirstName: "Bartholomew",
+		Website:   "ftp://example.com",
+		Age:       12,
+		Nickname:  &short,
+		Tags:      []string{"a", "b", "c"},
+		Address:   &testAddress{Country: "FR"},
+		Previous:  []testAddress{{City: "Delft"}, {}},
+	}
+	expected := []ValidationError{
+		{Field: "updated_by", Message: "Updated by must be at most 5 characters"},
+		{Field: "email", Message: "Email must be a valid email address"},
+		{Field: "username", Message: "Username is required"},
+		{Field: "first_name", Message: "First name must be at most 5 characters"},
+		{Field: "website", Message: "Website must be a valid URL"},
+		{Field: "age", Message: "Age must be at least 18"},
+		{Field: "nickname", Message: "Nickname must be at least 2 characters"},
+		{Field: "tags", Message: "Tags must be at most 2 items"},
+		{Field: "address.city", Message: "City is required"},
+		{Field: "address.country", Message: "Country must be one of NL, DE"},
+		{Field: "previous[1].city", Message: "City is required"},
+	}
+	if errors := ValidateStruct(invalid); !reflect.DeepEqual(errors, expected) {
+		t.Errorf("unexpected errors:\n got %v\nwant %v", errors, expected)
+	}
+
+	if errors := ValidateStruct(nil); errors != nil {
+		t.Errorf("nil target should have no errors: %v", errors)
+	}
+	if errors := ValidateStruct("text"); errors != nil {
+		t.Errorf("non-struct target should have no errors: %v", errors)
+	}
+}
+
+func TestValidatorValidateStruct(t *testing.T) {
+	result := NewValidator().ValidateStruct(&testAddress{})
+	if result.Valid || len(result.Errors) != 1 || result.Errors[0].Field != "city" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
@@ -30,6 +30,12 @@ func (v *Validator) ValidateJSONRequest(r *http.Request, target interface{}) Val
 	return v.httpValidator.ValidateJSONRequest(r, target)
 }
 
+// ValidateStruct validates a struct against its validate tags
+func (v *Validator) ValidateStruct(target interface{}) ValidationResult {
+	errors := ValidateStruct(target)
+	return ValidationResult{Valid: len(errors) == 0, Errors: errors}
+}
+
 // ValidateString validates a string field
 func (v *Validator) ValidateString(value, fieldName string, required bool, maxLength int) []ValidationError {
 	return v.fieldValidator.ValidateString(value, fieldName, required, maxLength)
@@ -0,0 +1,178 @@
+// Code generated by mockgen from user_service.go; DO NOT EDIT.
+
+package mocks
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+)
+
+// UserStore is a mock of services.UserStore
+type UserStore struct {
+	GetUserByIDFunc       func(ctx context.Context, id uint) (*models.User, error)
+	GetUserByEmailFunc    func(ctx context.Context, email string) (*models.User, error)
+	GetUserByUsernameFunc func(ctx context.Context, username string) (*models.User, error)
+	CreateUserFunc        func(ctx context.Context, user *models.User) error
+	UpdateUserFunc        func(ctx context.Context, user *models.User) error
+	DeleteUserFunc        func(ctx context.Context, id uint) error
+	ListUsersFunc         func(ctx context.Context, offset int, limit int) ([]models.User, error)
+	GetActiveUsersFunc    func(ctx context.Context, offset int, limit int) ([]models.User, error)
+	CountUsersFunc        func(ctx context.Context) (int64, error)
+
+	mu    sync.Mutex
+	calls map[string][][]interface{}
+}
+
+// GetUserByID calls GetUserByIDFunc
+func (mock *UserStore) GetUserByID(ctx context.Context, id uint) (*models.User, error) {
+	mock.record("GetUserByID", ctx, id)
+	if mock.GetUserByIDFunc == nil {
+		panic("unexpected call to UserStore.GetUserByID")
+	}
+	return mock.GetUserByIDFunc(ctx, id)
+}
+
+// GetUserByEmail calls GetUserByEmailFunc
+func (mock *UserStore) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	mock.record("GetUserByEmail", ctx, email)
+	if mock.GetUserByEmailFunc == nil {
+		panic("unexpected call to UserStore.GetUserByEmail")
+	}
+	return mock.GetUserByEmailFunc(ctx, email)
+}
+
+// GetUserByUsername calls GetUserByUsernameFunc
+func (mock *UserStore) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	mock.record("GetUserByUsername", ctx, username)
+	if mock.GetUserByUsernameFunc == nil {
+		panic("unexpected call to UserStore.GetUserByUsername")
+	}
+	return mock.GetUserByUsernameFunc(ctx, username)
+}
+
+// CreateUser calls CreateUserFunc
+func (mock *UserStore) CreateUser(ctx context.Context, user *models.User) error {
+	mock.record("CreateUser", ctx, user)
+	if mock.CreateUserFunc == nil {
+		panic("unexpected call to UserStore.CreateUser")
+	}
+	return mock.CreateUserFunc(ctx, user)
+}
+
+// UpdateUser calls UpdateUserFunc
+func (mock *UserStore) UpdateUser(ctx context.Context, user *models.User) error {
+	mock.record("UpdateUser", ctx, user)
+	if mock.UpdateUserFunc == nil {
+		panic("unexpected call to UserStore.UpdateUser")
+	}
+	return mock.UpdateUserFunc(ctx, user)
+}
+
+// DeleteUser calls DeleteUserFunc
+func (mock *UserStore) DeleteUser(ctx context.Context, id uint) error {
+	mock.record("DeleteUser", ctx, id)
+	if mock.DeleteUserFunc == nil {
+		panic("unexpected call to UserStore.DeleteUser")
+	}
+	return mock.DeleteUserFunc(ctx, id)
+}
+
+// ListUsers calls ListUsersFunc
+func (mock *UserStore) ListUsers(ctx context.Context, offset int, limit int) ([]models.User, error) {
+	mock.record("ListUsers", ctx, offset, limit)
+	if mock.ListUsersFunc == nil {
+		panic("unexpected call to UserStore.ListUsers")
+	}
+	return mock.ListUsersFunc(ctx, offset, limit)
+}
+
+// GetActiveUsers calls GetActiveUsersFunc
+func (mock *UserStore) GetActiveUsers(ctx context.Context, offset int, limit int) ([]models.User, error) {
+	mock.record("GetActiveUsers", ctx, offset, limit)
+	if mock.GetActiveUsersFunc == nil {
+		panic("unexpected call to UserStore.GetActiveUsers")
+	}
+	return mock.GetActiveUsersFunc(ctx, offset, limit)
+}
+
+// CountUsers calls CountUsersFunc
+func (mock *UserStore) CountUsers(ctx context.Context) (int64, error) {
+	mock.record("CountUsers", ctx)
+	if mock.CountUsersFunc == nil {
+		panic("unexpected call to UserStore.CountUsers")
+	}
+	return mock.CountUsersFunc(ctx)
+}
+
+// Calls returns the arguments of each call to method, in order
+func (mock *UserStore) Calls(method string) [][]interface{} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([][]interface{}(nil), mock.calls[method]...)
+}
+
+func (mock *UserStore) record(method string, args ...interface{}) {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	if mock.calls == nil {
+		mock.calls = make(map[string][][]interface{})
+	}
+	mock.calls[method] = append(mock.calls[method], args)
+}
+
+// UserCache is a mock of services.UserCache
+type UserCache struct {
+	GetUserCacheFunc          func(ctx context.Context, userID uint) (*repositories.Cached[models.User], error)
+	SetUserCacheWithDeltaFunc func(ctx context.Context, user *models.User, delta time.Duration, expiration time.Duration) error
+	DeleteUserCacheFunc       func(ctx context.Context, userID uint) error
+
+	mu    sync.Mutex
+	calls map[string][][]interface{}
+}
+
+// GetUserCache calls GetUserCacheFunc
+func (mock *UserCache) GetUserCache(ctx context.Context, userID uint) (*repositories.Cached[models.User], error) {
+	mock.record("GetUserCache", ctx, userID)
+	if mock.GetUserCacheFunc == nil {
+		panic("unexpected call to UserCache.GetUserCache")
+	}
+	return mock.GetUserCacheFunc(ctx, userID)
+}
+
+// SetUserCacheWithDelta calls SetUserCacheWithDeltaFunc
+func (mock *UserCache) SetUserCacheWithDelta(ctx context.Context, user *models.User, delta time.Duration, expiration time.Duration) error {
+	mock.record("SetUserCacheWithDelta", ctx, user, delta, expiration)
+	if mock.SetUserCacheWithDeltaFunc == nil {
+		panic("unexpected call to UserCache.SetUserCacheWithDelta")
+	}
+	return mock.SetUserCacheWithDeltaFunc(ctx, user, delta, expiration)
+}
+
+// DeleteUserCache calls DeleteUserCacheFunc
+func (mock *UserCache) DeleteUserCache(ctx context.Context, userID uint) error {
+	mock.record("DeleteUserCache", ctx, userID)
+	if mock.DeleteUserCacheFunc == nil {
+		panic("unexpected call to UserCache.DeleteUserCache")
+	}
+	return mock.DeleteUserCacheFunc(ctx, userID)
+}
+
+// Calls returns the arguments of each call to method, in order
+func (mock *UserCache) Calls(method string) [][]interface{} {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([][]interface{}(nil), mock.calls[method]...)
+}
+
+func (mock *UserCache) record(method string, args ...interface{}) {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	if mock.calls == nil {
+		mock.calls = make(map[string][][]interface{})
+	}
+	mock.calls[method] = append(mock.calls[method], args)
+}
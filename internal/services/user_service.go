@@ -2,53 +2,137 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"time"
 
+	"go-server/internal/clock"
 	"go-server/internal/database/models"
 	"go-server/internal/database/repositories"
 	"go-server/internal/logger"
+
+	"golang.org/x/sync/singleflight"
+)
+
+//go:generate go run go-server/cmd/mockgen -source user_service.go -out mocks/user_service.go UserStore UserCache
+
+// UserStore persists users; *repositories.UserRepository satisfies it
+type UserStore interface {
+	GetUserByID(ctx context.Context, id uint) (*models.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
+	CreateUser(ctx context.Context, user *models.User) error
+	UpdateUser(ctx context.Context, user *models.User) error
+	DeleteUser(ctx context.Context, id uint) error
+	ListUsers(ctx context.Context, offset, limit int) ([]models.User, error)
+	GetActiveUsers(ctx context.Context, offset, limit int) ([]models.User, error)
+	CountUsers(ctx context.Context) (int64, error)
+}
+
+// UserCache caches users; *repositories.CacheRepository satisfies it
+type UserCache interface {
+	GetUserCache(ctx context.Context, userID uint) (*repositories.Cached[models.User], error)
+	SetUserCacheWithDelta(ctx context.Context, user *models.User, delta, expiration time.Duration) error
+	DeleteUserCache(ctx context.Context, userID uint) error
+}
+
+// userCacheTTL is how long a user read by ID stays cached
+const userCacheTTL = 30 * time.Minute
+
+// userCacheBeta scales how early cached users are refreshed, see
+// repositories.Cached.RefreshEarly
+const userCacheBeta = 1.0
+
+var (
+	_ UserStore = (*repositories.UserRepository)(nil)
+	_ UserCache = (*repositories.CacheRepository)(nil)
 )
 
 // UserService handles user business logic
 type UserService struct {
-	userRepo  *repositories.UserRepository
-	cacheRepo *repositories.CacheRepository
+	userRepo  UserStore
+	cacheRepo UserCache
 	logger    logger.Logger
+	clock     clock.Clock
+	// loads coalesces concurrent reads of one user from the store
+	loads singleflight.Group
+	// random draws from (0, 1] for early expiration
+	random func() float64
 }
 
 // NewUserService creates a new user service
 func NewUserService(
-	userRepo *repositories.UserRepository,
-	cacheRepo *repositories.CacheRepository,
+	userRepo UserStore,
+	cacheRepo UserCache,
 	logger logger.Logger,
 ) *UserService {
 	return &UserService{
 		userRepo:  userRepo,
 		cacheRepo: cacheRepo,
 		logger:    logger,
+		clock:     clock.New(),
+		random:    func() float64 { return 1 - rand.Float64() },
 	}
 }
 
-// GetUserByID retrieves a user by ID with caching
+// WithClock sets the time source for early expiration
+func (us *UserService) WithClock(c clock.Clock) *UserService {
+	us.clock = clock.OrDefault(c)
+	return us
+}
+
+// GetUserByID retrieves a user by ID, from the cache when it holds the
+// user. Cached users lack the fields hidden from JSON, such as the password
+// hash; read those from the store.
+//
+// A hot user does not stampede the store: concurrent misses share one
+// read, and a cached user may be refreshed by a single reader shortly
+// before it expires (see repositories.Cached.RefreshEarly) while the
+// others keep reading the cached copy.
 func (us *UserService) GetUserByID(ctx context.Context, userID uint) (*models.User, error) {
-	// Try cache first
-	if cached, err := us.cacheRepo.GetUserCache(ctx, userID); err == nil && cached != "" {
-		// In a real implementation, you'd deserialize the JSON
-		// For now, we'll fetch from database
+	cached, err := us.cacheRepo.GetUserCache(ctx, userID)
+	if err == nil && !cached.RefreshEarly(us.clock.Now(), userCacheBeta, us.random()) {
+		return &cached.Value, nil
+	}
+	if err != nil && !errors.Is(err, repositories.ErrCacheMiss) {
+		us.logger.Warn("Failed to read user cache", "user_id", userID, "error", err.Error())
 	}
 
-	// Get from database
-	user, err := us.userRepo.GetUserByID(ctx, userID)
+	user, err := us.loadUser(ctx, userID)
 	if err != nil {
+		if cached != nil {
+			// The early refresh failed, but the cached user has not expired
+			us.logger.Warn("Failed to refresh cached user", "user_id", userID, "error", err.Error())
+			return &cached.Value, nil
+		}
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
+	return user, nil
+}
 
-	// Cache the result
-	if err := us.cacheRepo.SetUserCache(ctx, userID, user, 30*60); err != nil {
-		us.logger.Warn("Failed to cache user", "user_id", userID, "error", err.Error())
+// loadUser reads a user from the store and caches it, sharing the read
+// with concurrent callers for the same user. Each caller gets its own copy.
+func (us *UserService) loadUser(ctx context.Context, userID uint) (*models.User, error) {
+	// The read is shared, so one caller giving up must not fail the others
+	ctx = context.WithoutCancel(ctx)
+	loaded, err, _ := us.loads.Do(strconv.FormatUint(uint64(userID), 10), func() (interface{}, error) {
+		start := us.clock.Now()
+		user, err := us.userRepo.GetUserByID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if err := us.cacheRepo.SetUserCacheWithDelta(ctx, user, us.clock.Since(start), userCacheTTL); err != nil {
+			us.logger.Warn("Failed to cache user", "user_id", userID, "error", err.Error())
+		}
+		return user, nil
+	})
+	if err != nil {
+		return nil, err
 	}
-
-	return user, nil
+	user := *loaded.(*models.User)
+	return &user, nil
 }
 
 // GetUserByEmail retrieves a user by email
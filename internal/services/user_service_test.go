@@ -0,0 +1,266 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/logger"
+	"go-server/internal/services/mocks"
+)
+
+func newUser(id uint) *models.User {
+	return &models.User{
+		BaseModel: models.BaseModel{ID: id},
+		Email:     "ada@example.com",
+		Username:  "ada",
+		Password:  "hashed",
+	}
+}
+
+func cachedUser(id uint, expiresAt time.Time, delta time.Duration) *repositories.Cached[models.User] {
+	return &repositories.Cached[models.User]{Value: *newUser(id), ExpiresAt: expiresAt, Delta: delta}
+}
+
+func TestUserService_GetUserByID_CachesUser(t *testing.T) {
+	users := &mocks.UserStore{
+		GetUserByIDFunc: func(ctx context.Context, id uint) (*models.User, error) {
+			return newUser(id), nil
+		},
+	}
+	cache := &mocks.UserCache{
+		GetUserCacheFunc: func(ctx context.Context, userID uint) (*repositories.Cached[models.User], error) {
+			return nil, repositories.ErrCacheMiss
+		},
+		SetUserCacheWithDeltaFunc: func(ctx context.Context, user *models.User, delta, expiration time.Duration) error {
+			return nil
+		},
+	}
+
+	user, err := NewUserService(users, cache, logger.NewServerLogger()).GetUserByID(context.Background(), 7)
+	if err != nil || user.ID != 7 {
+		t.Fatalf("Expected user 7, got %+v, %v", user, err)
+	}
+	calls := cache.Calls("SetUserCacheWithDelta")
+	if len(calls) != 1 || calls[0][1].(*models.User).ID != 7 || calls[0][3] != 30*time.Minute {
+		t.Errorf("Expected the user cached once for 30 minutes, got %v", calls)
+	}
+}
+
+func TestUserService_GetUserByID_FromCache(t *testing.T) {
+	now := time.Now()
+	// GetUserByIDFunc is unset, so reading the store would panic
+	users := &mocks.UserStore{}
+	cache := &mocks.UserCache{
+		GetUserCacheFunc: func(ctx context.Context, userID uint) (*repositories.Cached[models.User], error) {
+			return cachedUser(userID, now.Add(time.Minute), 10*time.Millisecond), nil
+		},
+	}
+
+	service := NewUserService(users, cache, logger.NewServerLogger()).WithClock(clock.NewFake(now))
+	service.random = func() float64 { return 0.5 }
+	user, err := service.GetUserByID(context.Background(), 7)
+	if err != nil || user.ID != 7 {
+		t.Fatalf("Expected the cached user 7, got %+v, %v", user, err)
+	}
+}
+
+func TestUserService_GetUserByID_RefreshesEarly(t *testing.T) {
+	now := time.Now()
+	users := &mocks.UserStore{
+		GetUserByIDFunc: func(ctx context.Context, id uint) (*models.User, error) {
+			user := newUser(id)
+			user.Username = "fresh"
+			return user, nil
+		},
+	}
+	cache := &mocks.UserCache{
+		GetUserCacheFunc: func(ctx context.Context, userID uint) (*repositories.Cached[models.User], error) {
+			// Reading the user took 100ms and it expires in 1s
+			return cachedUser(userID, now.Add(time.Second), 100*time.Millisecond), nil
+		},
+		SetUserCacheWithDeltaFunc: func(ctx context.Context, user *models.User, delta, expiration time.Duration) error {
+			return nil
+		},
+	}
+	service := NewUserService(users, cache, logger.NewServerLogger()).WithClock(clock.NewFake(now))
+
+	// -ln(0.5) * 100ms is well short of a second, so the cached user is kept
+	service.random = func() float64 { return 0.5 }
+	if user, _ := service.GetUserByID(context.Background(), 7); user.Username != "ada" {
+		t.Errorf("Expected the cached user far from expiry, got %q", user.Username)
+	}
+	// -ln(1e-6) * 100ms is 1.4s, past the expiry, so this reader refreshes
+	service.random = func() float64 { return 1e-6 }
+	if user, _ := service.GetUserByID(context.Background(), 7); user.Username != "fresh" {
+		t.Errorf("Expected an early refresh, got %q", user.Username)
+	}
+	if calls := users.Calls("GetUserByID"); len(calls) != 1 {
+		t.Errorf("Expected one store read, got %d", len(calls))
+	}
+}
+
+func TestUserService_GetUserByID_RefreshFailureServesCached(t *testing.T) {
+	now := time.Now()
+	users := &mocks.UserStore{
+		GetUserByIDFunc: func(ctx context.Context, id uint) (*models.User, error) {
+			return nil, errors.New("connection refused")
+		},
+	}
+	cache := &mocks.UserCache{
+		GetUserCacheFunc: func(ctx context.Context, userID uint) (*repositories.Cached[models.User], error) {
+			return cachedUser(userID, now.Add(time.Second), time.Second), nil
+		},
+	}
+	service := NewUserService(users, cache, logger.NewServerLogger()).WithClock(clock.NewFake(now))
+	service.random = func() float64 { return 1e-6 }
+
+	user, err := service.GetUserByID(context.Background(), 7)
+	if err != nil || user.ID != 7 {
+		t.Fatalf("Expected the unexpired cached user when the refresh fails, got %+v, %v", user, err)
+	}
+}
+
+func TestUserService_GetUserByID_CoalescesMisses(t *testing.T) {
+	release := make(chan struct{})
+	users := &mocks.UserStore{
+		GetUserByIDFunc: func(ctx context.Context, id uint) (*models.User, error) {
+			<-release
+			return newUser(id), nil
+		},
+	}
+	cache := &mocks.UserCache{
+		GetUserCacheFunc: func(ctx context.Context, userID uint) (*repositories.Cached[models.User], error) {
+			return nil, repositories.ErrCacheMiss
+		},
+		SetUserCacheWithDeltaFunc: func(ctx context.Context, user *models.User, delta, expiration time.Duration) error {
+			return nil
+		},
+	}
+	service := NewUserService(users, cache, logger.NewServerLogger())
+
+	const readers = 10
+	var wg sync.WaitGroup
+	results := make(chan *models.User, readers)
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			user, err := service.GetUserByID(context.Background(), 7)
+			if err != nil {
+				t.Errorf("GetUserByID = %v", err)
+			}
+			results <- user
+		}()
+	}
+	// Let every reader reach the shared read before it completes
+	for len(cache.Calls("GetUserCache")) < readers {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	if calls := users.Calls("GetUserByID"); len(calls) != 1 {
+		t.Errorf("Expected the misses to share one store read, got %d", len(calls))
+	}
+	seen := map[*models.User]bool{}
+	for user := range results {
+		if user == nil || user.ID != 7 || seen[user] {
+			t.Errorf("Expected each reader its own copy of user 7, got %p", user)
+		}
+		seen[user] = true
+	}
+}
+
+func TestUserService_GetUserByID_NotFound(t *testing.T) {
+	users := &mocks.UserStore{
+		GetUserByIDFunc: func(ctx context.Context, id uint) (*models.User, error) {
+			return nil, errors.New("record not found")
+		},
+	}
+	cache := &mocks.UserCache{
+		GetUserCacheFunc: func(ctx context.Context, userID uint) (*repositories.Cached[models.User], error) {
+			return nil, errors.New("redis unavailable")
+		},
+	}
+
+	// SetUserCacheWithDeltaFunc is unset, so caching a missing user would panic
+	if _, err := NewUserService(users, cache, logger.NewServerLogger()).GetUserByID(context.Background(), 7); err == nil {
+		t.Fatal("Expected an error for a missing user")
+	}
+}
+
+func TestUserService_CreateUser_RejectsInvalidUser(t *testing.T) {
+	users := &mocks.UserStore{}
+
+	err := NewUserService(users, &mocks.UserCache{}, logger.NewServerLogger()).CreateUser(context.Background(), &models.User{Email: "ada@example.com"})
+	if err == nil {
+		t.Fatal("Expected a user without username and password to be rejected")
+	}
+	if calls := users.Calls("CreateUser"); len(calls) != 0 {
+		t.Errorf("Expected nothing stored, got %v", calls)
+	}
+}
+
+func TestUserService_UpdateUser_ClearsCache(t *testing.T) {
+	users := &mocks.UserStore{
+		UpdateUserFunc: func(ctx context.Context, user *models.User) error { return nil },
+	}
+	cache := &mocks.UserCache{
+		DeleteUserCacheFunc: func(ctx context.Context, userID uint) error {
+			return errors.New("redis unavailable")
+		},
+	}
+
+	// A cache that cannot be cleared does not fail the update
+	if err := NewUserService(users, cache, logger.NewServerLogger()).UpdateUser(context.Background(), newUser(3)); err != nil {
+		t.Fatalf("UpdateUser failed: %v", err)
+	}
+	if calls := cache.Calls("DeleteUserCache"); len(calls) != 1 || calls[0][1] != uint(3) {
+		t.Errorf("Expected user 3's cache cleared, got %v", calls)
+	}
+}
+
+func TestUserService_DeleteUser_KeepsCacheOnFailure(t *testing.T) {
+	users := &mocks.UserStore{
+		DeleteUserFunc: func(ctx context.Context, id uint) error { return errors.New("database unavailable") },
+	}
+	cache := &mocks.UserCache{}
+
+	if err := NewUserService(users, cache, logger.NewServerLogger()).DeleteUser(context.Background(), 3); err == nil {
+		t.Fatal("Expected the store's error")
+	}
+	if calls := cache.Calls("DeleteUserCache"); len(calls) != 0 {
+		t.Errorf("Expected the cache left alone, got %v", calls)
+	}
+}
+
+func TestUserService_ListUsers(t *testing.T) {
+	users := &mocks.UserStore{
+		ListUsersFunc: func(ctx context.Context, offset, limit int) ([]models.User, error) {
+			return []models.User{*newUser(1), *newUser(2)}, nil
+		},
+		CountUsersFunc: func(ctx context.Context) (int64, error) { return 12, nil },
+	}
+	service := NewUserService(users, &mocks.UserCache{}, logger.NewServerLogger())
+
+	list, total, err := service.ListUsers(context.Background(), 10, 2)
+	if err != nil || len(list) != 2 || total != 12 {
+		t.Fatalf("Expected 2 of 12 users, got %d of %d, %v", len(list), total, err)
+	}
+	if calls := users.Calls("ListUsers"); len(calls) != 1 || calls[0][1] != 10 || calls[0][2] != 2 {
+		t.Errorf("Expected the page passed through, got %v", calls)
+	}
+
+	users.CountUsersFunc = func(ctx context.Context) (int64, error) { return 0, errors.New("timeout") }
+	if _, _, err := service.ListUsers(context.Background(), 0, 2); err == nil {
+		t.Error("Expected a failed count to fail the listing")
+	}
+}
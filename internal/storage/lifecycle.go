@@ -0,0 +1,208 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/logger"
+)
+
+// orphanBatchSize is how many keys are checked against the attachment table per query
+const orphanBatchSize = 500
+
+// AttachmentIndex reports which object keys are referenced by attachments
+type AttachmentIndex interface {
+	ExistingObjectKeys(ctx context.Context, bucket string, keys []string) (map[string]bool, error)
+}
+
+// LifecycleReport summarises one lifecycle run
+type LifecycleReport struct {
+	Scanned      int `json:"scanned"`
+	Transitioned int `json:"transitioned"`
+	Expired      int `json:"expired"`
+	Failed       int `json:"failed"`
+}
+
+// OrphanReport summarises one orphan scan
+type OrphanReport struct {
+	Scanned int      `json:"scanned"`
+	Orphans []string `json:"orphans"`
+	Deleted int      `json:"deleted"`
+}
+
+// Manager applies lifecycle policies and detects orphaned objects
+type Manager struct {
+	stores   map[string]Store
+	policies []Policy
+	clock    clock.Clock
+	logger   logger.Logger
+
+	index         AttachmentIndex
+	orphanGrace   time.Duration
+	deleteOrphans bool
+}
+
+// NewManager creates a new lifecycle manager; every policy's bucket must have a store
+func NewManager(stores map[string]Store, policies []Policy, logger logger.Logger) *Manager {
+	return &Manager{
+		stores:   stores,
+		policies: policies,
+		clock:    clock.New(),
+		logger:   logger,
+	}
+}
+
+// WithClock overrides the clock used to compute object ages
+func (m *Manager) WithClock(c clock.Clock) *Manager {
+	m.clock = clock.OrDefault(c)
+	return m
+}
+
+// WithOrphanDetection enables orphan scans against the attachment index.
+// Objects younger than grace are ignored because their attachment row may
+// not be committed yet; orphans are only deleted when deleteOrphans is set.
+func (m *Manager) WithOrphanDetection(index AttachmentIndex, grace time.Duration, deleteOrphans bool) *Manager {
+	m.index = index
+	m.orphanGrace = grace
+	m.deleteOrphans = deleteOrphans
+	return m
+}
+
+// ApplyLifecycle transitions and expires objects according to the policies.
+// Individual failures are logged and counted; an error is returned if any occurred.
+func (m *Manager) ApplyLifecycle(ctx context.Context) (LifecycleReport, error) {
+	var report LifecycleReport
+	now := m.clock.Now()
+
+	for _, policy := range m.policies {
+		store, ok := m.stores[policy.Bucket]
+		if !ok {
+			return report, fmt.Errorf("no store configured for bucket %s", policy.Bucket)
+		}
+
+		transitionsSupported := true
+		for _, rule := range policy.Rules {
+			err := store.List(ctx, rule.Prefix, func(object Object) error {
+				// Objects under a more specific rule are handled by that rule
+				if match, _ := policy.ruleFor(object.Key); match.Prefix != rule.Prefix {
+					return nil
+				}
+				report.Scanned++
+				age := now.Sub(object.LastModified)
+
+				switch {
+				case rule.ExpireAfter > 0 && age >= time.Duration(rule.ExpireAfter):
+					if err := store.Delete(ctx, object.Key); err != nil {
+						report.Failed++
+						m.logger.Error("Failed to expire object", "bucket", policy.Bucket, "key", object.Key, "error", err.Error())
+						return nil
+					}
+					report.Expired++
+				case rule.TransitionAfter > 0 && transitionsSupported && age >= time.Duration(rule.TransitionAfter) &&
+					object.StorageClass != rule.TransitionClass:
+					err := store.Transition(ctx, object.Key, rule.TransitionClass)
+					if errors.Is(err, ErrTransitionUnsupported) {
+						transitionsSupported = false
+						m.logger.Debug("Storage backend does not support transitions", "bucket", policy.Bucket)
+						return nil
+					}
+					if err != nil {
+						report.Failed++
+						m.logger.Error("Failed to transition object", "bucket", policy.Bucket, "key", object.Key, "error", err.Error())
+						return nil
+					}
+					report.Transitioned++
+				}
+				return nil
+			})
+			if err != nil {
+				return report, fmt.Errorf("failed to list bucket %s: %w", policy.Bucket, err)
+			}
+		}
+	}
+
+	if report.Failed > 0 {
+		return report, fmt.Errorf("%d lifecycle actions failed", report.Failed)
+	}
+	return report, nil
+}
+
+// FindOrphans lists objects under each policy's orphan prefixes that no
+// attachment references, deleting them when configured to
+func (m *Manager) FindOrphans(ctx context.Context) (OrphanReport, error) {
+	report := OrphanReport{Orphans: []string{}}
+	if m.index == nil {
+		return report, errors.New("orphan detection is not configured")
+	}
+	cutoff := m.clock.Now().Add(-m.orphanGrace)
+
+	for _, policy := range m.policies {
+		store, ok := m.stores[policy.Bucket]
+		if !ok {
+			return report, fmt.Errorf("no store configured for bucket %s", policy.Bucket)
+		}
+
+		for _, prefix := range policy.OrphanPrefixes {
+			var batch []string
+			flush := func() error {
+				orphans, err := m.orphansIn(ctx, policy.Bucket, batch)
+				batch = batch[:0]
+				if err != nil {
+					return err
+				}
+				for _, key := range orphans {
+					report.Orphans = append(report.Orphans, policy.Bucket+"/"+key)
+					if !m.deleteOrphans {
+						continue
+					}
+					if err := store.Delete(ctx, key); err != nil {
+						m.logger.Error("Failed to delete orphaned object", "bucket", policy.Bucket, "key", key, "error", err.Error())
+						continue
+					}
+					report.Deleted++
+				}
+				return nil
+			}
+
+			err := store.List(ctx, prefix, func(object Object) error {
+				report.Scanned++
+				if object.LastModified.After(cutoff) {
+					return nil
+				}
+				batch = append(batch, object.Key)
+				if len(batch) >= orphanBatchSize {
+					return flush()
+				}
+				return nil
+			})
+			if err == nil && len(batch) > 0 {
+				err = flush()
+			}
+			if err != nil {
+				return report, fmt.Errorf("failed to scan bucket %s for orphans: %w", policy.Bucket, err)
+			}
+		}
+	}
+
+	if len(report.Orphans) > 0 {
+		m.logger.Warn("Orphaned objects found", "count", len(report.Orphans), "deleted", report.Deleted)
+	}
+	return report, nil
+}
+
+func (m *Manager) orphansIn(ctx context.Context, bucket string, keys []string) ([]string, error) {
+	existing, err := m.index.ExistingObjectKeys(ctx, bucket, keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up attachments: %w", err)
+	}
+	var orphans []string
+	for _, key := range keys {
+		if !existing[key] {
+			orphans = append(orphans, key)
+		}
+	}
+	return orphans, nil
+}
@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStore keeps objects as files under root/bucket. It has no storage
+// classes, so lifecycle transitions are skipped and only expiration applies.
+type LocalStore struct {
+	bucket string
+	dir    string
+}
+
+// NewLocalStore creates a store for bucket under the root directory
+func NewLocalStore(root, bucket string) *LocalStore {
+	return &LocalStore{
+		bucket: bucket,
+		dir:    filepath.Join(root, bucket),
+	}
+}
+
+// Bucket returns the bucket name
+func (ls *LocalStore) Bucket() string {
+	return ls.bucket
+}
+
+// Put writes the object atomically through a temporary file
+func (ls *LocalStore) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	target, err := ls.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+// Open opens the object file
+func (ls *LocalStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	target, err := ls.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(target)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+// Delete removes the object file
+func (ls *LocalStore) Delete(ctx context.Context, key string) error {
+	target, err := ls.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// List walks the bucket directory in lexical order
+func (ls *LocalStore) List(ctx context.Context, prefix string, fn func(Object) error) error {
+	err := filepath.WalkDir(ls.dir, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".upload-") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(ls.dir, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		return fn(Object{
+			Key:          key,
+			Size:         info.Size(),
+			LastModified: info.ModTime(),
+			StorageClass: ClassStandard,
+		})
+	})
+	return err
+}
+
+// Transition is not supported by the local filesystem
+func (ls *LocalStore) Transition(ctx context.Context, key, storageClass string) error {
+	return ErrTransitionUnsupported
+}
+
+func (ls *LocalStore) path(key string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(ls.dir, filepath.FromSlash(key)), nil
+}
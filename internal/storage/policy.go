@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Well-known key prefixes
+const (
+	PrefixTempUploads = "tmp/"
+	PrefixExports     = "exports/"
+	PrefixAttachments = "attachments/"
+)
+
+// Duration is a time.Duration that unmarshals from strings such as "36h" or "30d"
+type Duration time.Duration
+
+// UnmarshalJSON parses a duration string; a "d" suffix means days
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("duration must be a string: %w", err)
+	}
+	parsed, err := parseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalJSON formats the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func parseDuration(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed < 0 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return parsed, nil
+}
+
+// Rule applies lifecycle actions to objects under a key prefix. When rules
+// overlap, the one with the longest matching prefix wins.
+type Rule struct {
+	Name   string `json:"name"`
+	Prefix string `json:"prefix"`
+	// TransitionAfter moves objects older than this to TransitionClass
+	TransitionAfter Duration `json:"transition_after,omitempty"`
+	TransitionClass string   `json:"transition_class,omitempty"`
+	// ExpireAfter deletes objects older than this
+	ExpireAfter Duration `json:"expire_after,omitempty"`
+}
+
+// Policy is the lifecycle configuration of one bucket
+type Policy struct {
+	Bucket string `json:"bucket"`
+	Rules  []Rule `json:"rules"`
+	// OrphanPrefixes lists prefixes whose objects must be referenced by an attachment
+	OrphanPrefixes []string `json:"orphan_prefixes,omitempty"`
+}
+
+// Validate checks the rules for missing or contradictory settings
+func (p Policy) Validate() error {
+	if p.Bucket == "" {
+		return fmt.Errorf("lifecycle policy is missing a bucket")
+	}
+	for _, rule := range p.Rules {
+		if rule.TransitionAfter == 0 && rule.ExpireAfter == 0 {
+			return fmt.Errorf("bucket %s: rule %q has no action", p.Bucket, rule.Name)
+		}
+		if rule.TransitionAfter > 0 && rule.TransitionClass == "" {
+			return fmt.Errorf("bucket %s: rule %q needs a transition_class", p.Bucket, rule.Name)
+		}
+		if rule.TransitionAfter > 0 && rule.ExpireAfter > 0 && rule.ExpireAfter <= rule.TransitionAfter {
+			return fmt.Errorf("bucket %s: rule %q expires objects before transitioning them", p.Bucket, rule.Name)
+		}
+	}
+	return nil
+}
+
+// ruleFor returns the most specific rule matching key
+func (p Policy) ruleFor(key string) (Rule, bool) {
+	var match Rule
+	found := false
+	for _, rule := range p.Rules {
+		if strings.HasPrefix(key, rule.Prefix) && (!found || len(rule.Prefix) > len(match.Prefix)) {
+			match = rule
+			found = true
+		}
+	}
+	return match, found
+}
+
+// DefaultPolicies expires temporary uploads after a day and exports after a
+// week, and checks attachments for orphans
+func DefaultPolicies(bucket string) []Policy {
+	return []Policy{{
+		Bucket: bucket,
+		Rules: []Rule{
+			{Name: "temp-uploads", Prefix: PrefixTempUploads, ExpireAfter: Duration(24 * time.Hour)},
+			{Name: "exports", Prefix: PrefixExports, ExpireAfter: Duration(7 * 24 * time.Hour)},
+		},
+		OrphanPrefixes: []string{PrefixAttachments},
+	}}
+}
+
+// LoadPolicies reads per-bucket policies from a JSON file of the form
+// {"buckets": {"name": {"rules": [...], "orphan_prefixes": [...]}}}
+func LoadPolicies(path string) ([]Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lifecycle policies: %w", err)
+	}
+
+	var file struct {
+		Buckets map[string]Policy `json:"buckets"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse lifecycle policies: %w", err)
+	}
+
+	policies := make([]Policy, 0, len(file.Buckets))
+	for bucket, policy := range file.Buckets {
+		policy.Bucket = bucket
+		if err := policy.Validate(); err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+	sort.Slice(policies, func(i, j int) bool { return policies[i].Bucket < policies[j].Bucket })
+	return policies, nil
+}
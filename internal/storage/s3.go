@@ -0,0 +1,291 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/sigv4"
+)
+
+// S3Config describes an S3-compatible bucket
+type S3Config struct {
+	// Endpoint is the service URL; empty means AWS in Region
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// UsePathStyle addresses the bucket as endpoint/bucket, as MinIO and most self-hosted services require
+	UsePathStyle bool
+}
+
+// S3Store talks to an S3-compatible API with SigV4-signed requests
+type S3Store struct {
+	config S3Config
+	base   *url.URL
+	signer sigv4.Signer
+	client *http.Client
+	clock  clock.Clock
+}
+
+// NewS3Store creates a new S3 store
+func NewS3Store(config S3Config) *S3Store {
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", config.Region)
+	}
+	base, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil || base.Host == "" {
+		base = &url.URL{Scheme: "https", Host: endpoint}
+	}
+
+	return &S3Store{
+		config: config,
+		base:   base,
+		signer: sigv4.Signer{
+			AccessKeyID:     config.AccessKeyID,
+			SecretAccessKey: config.SecretAccessKey,
+			Region:          config.Region,
+			Service:         "s3",
+		},
+		client: &http.Client{Timeout: 5 * time.Minute},
+		clock:  clock.New(),
+	}
+}
+
+// WithHTTPClient overrides the HTTP client used for API calls
+func (s *S3Store) WithHTTPClient(client *http.Client) *S3Store {
+	s.client = client
+	return s
+}
+
+// WithClock overrides the clock used to sign requests
+func (s *S3Store) WithClock(c clock.Clock) *S3Store {
+	s.clock = clock.OrDefault(c)
+	return s
+}
+
+// Bucket returns the bucket name
+func (s *S3Store) Bucket() string {
+	return s.config.Bucket
+}
+
+// Put uploads the object in a single request; the body is streamed unsigned
+func (s *S3Store) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	req, err := s.newRequest(ctx, http.MethodPut, key, nil, body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := s.do(req, sigv4.UnsignedPayloadHash)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Open downloads the object
+func (s *S3Store) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(req, sigv4.EmptyPayloadHash)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Delete removes the object
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(req, sigv4.EmptyPayloadHash)
+	if err == ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// listBucketResult is the ListObjectsV2 response body
+type listBucketResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+		StorageClass string    `xml:"StorageClass"`
+	} `xml:"Contents"`
+}
+
+// List pages through ListObjectsV2
+func (s *S3Store) List(ctx context.Context, prefix string, fn func(Object) error) error {
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}}
+		if prefix != "" {
+			query.Set("prefix", prefix)
+		}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		req, err := s.newRequest(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := s.do(req, sigv4.EmptyPayloadHash)
+		if err != nil {
+			return err
+		}
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("s3 list: invalid response: %w", err)
+		}
+
+		for _, item := range result.Contents {
+			storageClass := item.StorageClass
+			if storageClass == "" {
+				storageClass = ClassStandard
+			}
+			err := fn(Object{
+				Key:          item.Key,
+				Size:         item.Size,
+				LastModified: item.LastModified,
+				StorageClass: storageClass,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// Transition copies the object onto itself with a new storage class.
+// Single-request copies are limited to 5 GB by S3.
+func (s *S3Store) Transition(ctx context.Context, key, storageClass string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	req, err := s.newRequest(ctx, http.MethodPut, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Amz-Copy-Source", "/"+sigv4.URIEncode(s.config.Bucket, true)+"/"+sigv4.URIEncode(key, false))
+	req.Header.Set("X-Amz-Storage-Class", storageClass)
+	req.Header.Set("X-Amz-Metadata-Directive", "COPY")
+
+	resp, err := s.do(req, sigv4.EmptyPayloadHash)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// CopyObject may report a failure in a 200 response body
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if bytes.Contains(body, []byte("<Error>")) {
+		return parseS3Error(http.MethodPut, resp.StatusCode, body)
+	}
+	return nil
+}
+
+// newRequest builds a request for an object key, or the bucket itself when key is empty
+func (s *S3Store) newRequest(ctx context.Context, method, key string, query url.Values, body io.Reader) (*http.Request, error) {
+	target := *s.base
+	var rawPath string
+	if s.config.UsePathStyle {
+		rawPath = "/" + sigv4.URIEncode(s.config.Bucket, true)
+		if key != "" {
+			rawPath += "/" + sigv4.URIEncode(key, false)
+		}
+	} else {
+		target.Host = s.config.Bucket + "." + target.Host
+		rawPath = "/" + sigv4.URIEncode(key, false)
+	}
+	target.RawPath = strings.TrimSuffix(s.base.Path, "/") + rawPath
+	decoded, err := url.PathUnescape(target.RawPath)
+	if err != nil {
+		return nil, err
+	}
+	target.Path = decoded
+	if query != nil {
+		target.RawQuery = query.Encode()
+	}
+
+	return http.NewRequestWithContext(ctx, method, target.String(), body)
+}
+
+// do signs and sends a request, converting error responses into errors
+func (s *S3Store) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	s.signer.Sign(req, payloadHash, s.clock.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 %s failed: %w", strings.ToLower(req.Method), err)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound && req.Method != http.MethodPut {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode == http.StatusPreconditionFailed && req.Header.Get("If-None-Match") == "*" {
+		return nil, ErrExists
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return nil, parseS3Error(req.Method, resp.StatusCode, body)
+}
+
+// parseS3Error extracts the code and message from an S3 XML error body
+func parseS3Error(method string, status int, body []byte) error {
+	var s3Error struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if xml.Unmarshal(body, &s3Error) == nil && s3Error.Code != "" {
+		return fmt.Errorf("s3 %s failed: status %d: %s: %s", strings.ToLower(method), status, s3Error.Code, s3Error.Message)
+	}
+	return fmt.Errorf("s3 %s failed: status %d: %s", strings.ToLower(method), status, bytes.TrimSpace(body))
+}
@@ -0,0 +1,101 @@
+// Package storage stores uploaded files in a local directory or an
+// S3-compatible bucket and manages their lifecycle: objects are moved to
+// cheaper storage classes or expired by age according to per-bucket
+// policies, and objects no longer referenced by an attachment are detected.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"go-server/internal/config"
+)
+
+// Storage classes understood by S3-compatible providers
+const (
+	ClassStandard           = "STANDARD"
+	ClassInfrequentAccess   = "STANDARD_IA"
+	ClassOneZoneIA          = "ONEZONE_IA"
+	ClassGlacierInstant     = "GLACIER_IR"
+	ClassIntelligentTiering = "INTELLIGENT_TIERING"
+)
+
+var (
+	// ErrNotFound is returned when an object does not exist
+	ErrNotFound = errors.New("storage: object not found")
+	// ErrTransitionUnsupported is returned by stores without storage classes
+	ErrTransitionUnsupported = errors.New("storage: storage class transitions are not supported")
+	// ErrInvalidKey is returned for empty keys or keys escaping the bucket
+	ErrInvalidKey = errors.New("storage: invalid object key")
+)
+
+// Object describes a stored object
+type Object struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+	StorageClass string
+}
+
+// Store is a bucket of objects
+type Store interface {
+	// Bucket returns the bucket name
+	Bucket() string
+	// Put stores an object, replacing any existing one; size may be -1 if unknown
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Open returns the object contents; the caller must close the reader
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes an object; deleting a missing object is not an error
+	Delete(ctx context.Context, key string) error
+	// List calls fn for every object whose key starts with prefix
+	List(ctx context.Context, prefix string, fn func(Object) error) error
+	// Transition moves an object to another storage class
+	Transition(ctx context.Context, key, storageClass string) error
+}
+
+// NewStores creates a store for each bucket using the configured backend
+func NewStores(cfg config.StorageConfig, buckets []string) (map[string]Store, error) {
+	stores := make(map[string]Store, len(buckets))
+	for _, bucket := range buckets {
+		if _, ok := stores[bucket]; ok {
+			continue
+		}
+		switch cfg.Backend {
+		case "", "local":
+			stores[bucket] = NewLocalStore(cfg.LocalRoot, bucket)
+		case "s3":
+			stores[bucket] = NewS3Store(S3Config{
+				Endpoint:        cfg.Endpoint,
+				Region:          cfg.Region,
+				Bucket:          bucket,
+				AccessKeyID:     cfg.AccessKeyID,
+				SecretAccessKey: cfg.SecretAccessKey,
+				UsePathStyle:    cfg.UsePathStyle,
+			})
+		default:
+			return nil, fmt.Errorf("unsupported storage backend: %s", cfg.Backend)
+		}
+	}
+	return stores, nil
+}
+
+// validateKey rejects keys that are empty, absolute or contain .. segments
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return ErrInvalidKey
+	}
+	if path.Clean(key) != key && path.Clean(key)+"/" != key {
+		return ErrInvalidKey
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == ".." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
@@ -0,0 +1,321 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/logger"
+)
+
+func TestLocalStore_RoundTrip(t *testing.T) {
+	store := NewLocalStore(t.TempDir(), "uploads")
+	ctx := context.Background()
+
+	if err := store.Put(ctx, "attachments/a.txt", strings.NewReader("hello"), 5, "text/plain"); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	reader, err := store.Open(ctx, "attachments/a.txt")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	data, _ := io.ReadAll(reader)
+	reader.Close()
+	if string(data) != "hello" {
+		t.Errorf("Expected hello, got %q", data)
+	}
+
+	for _, key := range []string{"", "/etc/passwd", "../escape", "a/../../b"} {
+		if err := store.Put(ctx, key, strings.NewReader("x"), 1, ""); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Expected ErrInvalidKey for %q, got %v", key, err)
+		}
+	}
+
+	store.Delete(ctx, "attachments/a.txt")
+	if _, err := store.Open(ctx, "attachments/a.txt"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+}
+
+// memoryStore is an in-memory Store with storage classes
+type memoryStore struct {
+	objects map[string]Object
+}
+
+func (ms *memoryStore) Bucket() string { return "media" }
+
+func (ms *memoryStore) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	ms.objects[key] = Object{Key: key, StorageClass: ClassStandard}
+	return nil
+}
+
+func (ms *memoryStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	return nil, ErrNotFound
+}
+
+func (ms *memoryStore) Delete(ctx context.Context, key string) error {
+	delete(ms.objects, key)
+	return nil
+}
+
+func (ms *memoryStore) List(ctx context.Context, prefix string, fn func(Object) error) error {
+	for key, object := range ms.objects {
+		if strings.HasPrefix(key, prefix) {
+			if err := fn(object); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (ms *memoryStore) Transition(ctx context.Context, key, storageClass string) error {
+	object := ms.objects[key]
+	object.StorageClass = storageClass
+	ms.objects[key] = object
+	return nil
+}
+
+func TestManager_ApplyLifecycle(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	store := &memoryStore{objects: map[string]Object{
+		"tmp/old":              {Key: "tmp/old", LastModified: now.Add(-48 * time.Hour), StorageClass: ClassStandard},
+		"tmp/new":              {Key: "tmp/new", LastModified: now.Add(-time.Hour), StorageClass: ClassStandard},
+		"media/cold":           {Key: "media/cold", LastModified: now.Add(-40 * 24 * time.Hour), StorageClass: ClassStandard},
+		"media/warm":           {Key: "media/warm", LastModified: now.Add(-24 * time.Hour), StorageClass: ClassStandard},
+		"media/keep/forever":   {Key: "media/keep/forever", LastModified: now.Add(-400 * 24 * time.Hour), StorageClass: ClassStandard},
+		"media/already-tiered": {Key: "media/already-tiered", LastModified: now.Add(-90 * 24 * time.Hour), StorageClass: ClassInfrequentAccess},
+	}}
+	policy := Policy{
+		Bucket: "media",
+		Rules: []Rule{
+			{Name: "temp", Prefix: "tmp/", ExpireAfter: Duration(24 * time.Hour)},
+			{Name: "cold", Prefix: "media/", TransitionAfter: Duration(30 * 24 * time.Hour), TransitionClass: ClassInfrequentAccess},
+			{Name: "keep", Prefix: "media/keep/", ExpireAfter: Duration(1000 * 24 * time.Hour)},
+		},
+	}
+
+	manager := NewManager(map[string]Store{"media": store}, []Policy{policy}, logger.NewServerLogger()).
+		WithClock(clock.NewFake(now))
+	report, err := manager.ApplyLifecycle(context.Background())
+	if err != nil {
+		t.Fatalf("ApplyLifecycle failed: %v", err)
+	}
+
+	if report.Expired != 1 || report.Transitioned != 1 || report.Scanned != 6 {
+		t.Errorf("Unexpected report %+v", report)
+	}
+	if _, ok := store.objects["tmp/old"]; ok {
+		t.Error("Expected tmp/old to expire")
+	}
+	if store.objects["media/cold"].StorageClass != ClassInfrequentAccess {
+		t.Error("Expected media/cold to transition")
+	}
+	if store.objects["media/keep/forever"].StorageClass != ClassStandard {
+		t.Error("The more specific rule should govern media/keep/")
+	}
+}
+
+func TestManager_ApplyLifecycleLocalSkipsTransitions(t *testing.T) {
+	root := t.TempDir()
+	store := NewLocalStore(root, "uploads")
+	store.Put(context.Background(), "exports/report.csv", strings.NewReader("a,b"), 3, "text/csv")
+	old := time.Now().Add(-10 * 24 * time.Hour)
+	os.Chtimes(filepath.Join(root, "uploads", "exports", "report.csv"), old, old)
+
+	policies := []Policy{{
+		Bucket: "uploads",
+		Rules: []Rule{
+			{Name: "exports", Prefix: "exports/", ExpireAfter: Duration(7 * 24 * time.Hour)},
+			{Name: "ia", Prefix: "attachments/", TransitionAfter: Duration(time.Hour), TransitionClass: ClassInfrequentAccess},
+		},
+	}}
+	store.Put(context.Background(), "attachments/a", strings.NewReader("a"), 1, "")
+	os.Chtimes(filepath.Join(root, "uploads", "attachments", "a"), old, old)
+
+	report, err := NewManager(map[string]Store{"uploads": store}, policies, logger.NewServerLogger()).
+		ApplyLifecycle(context.Background())
+	if err != nil {
+		t.Fatalf("ApplyLifecycle failed: %v", err)
+	}
+	if report.Expired != 1 || report.Transitioned != 0 || report.Failed != 0 {
+		t.Errorf("Unexpected report %+v", report)
+	}
+}
+
+type fakeIndex map[string]bool
+
+func (fi fakeIndex) ExistingObjectKeys(ctx context.Context, bucket string, keys []string) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	for _, key := range keys {
+		if fi[key] {
+			existing[key] = true
+		}
+	}
+	return existing, nil
+}
+
+func TestManager_FindOrphans(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	store := &memoryStore{objects: map[string]Object{
+		"attachments/linked":   {Key: "attachments/linked", LastModified: now.Add(-72 * time.Hour)},
+		"attachments/orphan":   {Key: "attachments/orphan", LastModified: now.Add(-72 * time.Hour)},
+		"attachments/uploaded": {Key: "attachments/uploaded", LastModified: now.Add(-time.Minute)},
+		"tmp/scratch":          {Key: "tmp/scratch", LastModified: now.Add(-72 * time.Hour)},
+	}}
+	manager := NewManager(map[string]Store{"media": store}, []Policy{{Bucket: "media", OrphanPrefixes: []string{"attachments/"}}}, logger.NewServerLogger()).
+		WithClock(clock.NewFake(now)).
+		WithOrphanDetection(fakeIndex{"attachments/linked": true}, time.Hour, true)
+
+	report, err := manager.FindOrphans(context.Background())
+	if err != nil {
+		t.Fatalf("FindOrphans failed: %v", err)
+	}
+	if fmt.Sprint(report.Orphans) != "[media/attachments/orphan]" || report.Deleted != 1 {
+		t.Errorf("Unexpected report %+v", report)
+	}
+	if _, ok := store.objects["attachments/uploaded"]; !ok {
+		t.Error("Objects within the grace period must be kept")
+	}
+}
+
+func TestLoadPolicies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lifecycle.json")
+	os.WriteFile(path, []byte(`{"buckets": {
+		"exports": {"rules": [{"name": "expire", "prefix": "", "expire_after": "7d"}]},
+		"media": {"rules": [{"name": "ia", "prefix": "img/", "transition_after": "720h", "transition_class": "STANDARD_IA"}], "orphan_prefixes": ["img/"]}
+	}}`), 0o644)
+
+	policies, err := LoadPolicies(path)
+	if err != nil {
+		t.Fatalf("LoadPolicies failed: %v", err)
+	}
+	if len(policies) != 2 || policies[0].Bucket != "exports" || time.Duration(policies[0].Rules[0].ExpireAfter) != 7*24*time.Hour {
+		t.Errorf("Unexpected policies %+v", policies)
+	}
+
+	os.WriteFile(path, []byte(`{"buckets": {"media": {"rules": [{"name": "bad", "transition_after": "1h"}]}}}`), 0o644)
+	if _, err := LoadPolicies(path); err == nil {
+		t.Error("Expected an error for a transition without a class")
+	}
+}
+
+func TestS3Store_ListAndTransition(t *testing.T) {
+	var copySource, storageClass string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") {
+			t.Errorf("Request is not signed: %q", r.Header.Get("Authorization"))
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/media":
+			if r.URL.Query().Get("continuation-token") == "" {
+				fmt.Fprint(w, `<ListBucketResult><IsTruncated>true</IsTruncated><NextContinuationToken>next</NextContinuationToken>
+					<Contents><Key>img/a b.png</Key><Size>10</Size><LastModified>2024-01-01T00:00:00.000Z</LastModified><StorageClass>STANDARD</StorageClass></Contents>
+				</ListBucketResult>`)
+				return
+			}
+			fmt.Fprint(w, `<ListBucketResult><IsTruncated>false</IsTruncated>
+				<Contents><Key>img/c.png</Key><Size>20</Size><LastModified>2024-01-02T00:00:00.000Z</LastModified></Contents>
+			</ListBucketResult>`)
+		case r.Method == http.MethodPut && r.URL.Path == "/media/img/a b.png":
+			copySource = r.Header.Get("X-Amz-Copy-Source")
+			storageClass = r.Header.Get("X-Amz-Storage-Class")
+			fmt.Fprint(w, `<CopyObjectResult></CopyObjectResult>`)
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	store := NewS3Store(S3Config{
+		Endpoint:        server.URL,
+		Bucket:          "media",
+		AccessKeyID:     "key",
+		SecretAccessKey: "secret",
+		UsePathStyle:    true,
+	})
+
+	var keys []string
+	err := store.List(context.Background(), "img/", func(object Object) error {
+		keys = append(keys, object.Key+":"+object.StorageClass)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if fmt.Sprint(keys) != "[img/a b.png:STANDARD img/c.png:STANDARD]" {
+		t.Errorf("Unexpected keys %v", keys)
+	}
+
+	if err := store.Transition(context.Background(), "img/a b.png", ClassInfrequentAccess); err != nil {
+		t.Fatalf("Transition failed: %v", err)
+	}
+	if copySource != "/media/img/a%20b.png" || storageClass != ClassInfrequentAccess {
+		t.Errorf("Unexpected copy headers %q %q", copySource, storageClass)
+	}
+}
+
+func TestLocalStore_PutOnce(t *testing.T) {
+	store := NewLocalStore(t.TempDir(), "audit")
+	ctx := context.Background()
+	if err := store.PutOnce(ctx, "a.json", []byte("{}"), "application/json", time.Time{}); err != nil {
+		t.Fatalf("PutOnce failed: %v", err)
+	}
+	if err := store.PutOnce(ctx, "a.json", []byte("[]"), "application/json", time.Time{}); !errors.Is(err, ErrExists) {
+		t.Errorf("Expected ErrExists, got %v", err)
+	}
+	body, err := store.Open(ctx, "a.json")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer body.Close()
+	if data, _ := io.ReadAll(body); string(data) != "{}" {
+		t.Errorf("Expected the first write to be kept, got %s", data)
+	}
+}
+
+func TestS3Store_PutOnce(t *testing.T) {
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/audit/events/1.json" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if headers != nil {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			fmt.Fprint(w, `<Error><Code>PreconditionFailed</Code></Error>`)
+			return
+		}
+		headers = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	store := NewS3Store(S3Config{
+		Endpoint:        server.URL,
+		Bucket:          "audit",
+		AccessKeyID:     "key",
+		SecretAccessKey: "secret",
+		UsePathStyle:    true,
+	})
+	until := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := store.PutOnce(context.Background(), "events/1.json", []byte("{}"), "application/json", until); err != nil {
+		t.Fatalf("PutOnce failed: %v", err)
+	}
+	if headers.Get("If-None-Match") != "*" || headers.Get("Content-MD5") != "mZFLkyvTelC5g8XnyQrpOw==" ||
+		headers.Get("X-Amz-Object-Lock-Mode") != "COMPLIANCE" ||
+		headers.Get("X-Amz-Object-Lock-Retain-Until-Date") != "2030-01-01T00:00:00Z" {
+		t.Errorf("Unexpected write-once headers %v", headers)
+	}
+	if err := store.PutOnce(context.Background(), "events/1.json", []byte("{}"), "application/json", until); !errors.Is(err, ErrExists) {
+		t.Errorf("Expected ErrExists for a taken key, got %v", err)
+	}
+}
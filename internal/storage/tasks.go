@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"context"
+
+	"go-server/internal/scheduler"
+)
+
+// Scheduled task names
+const (
+	TaskLifecycle = "storage.lifecycle"
+	TaskOrphans   = "storage.orphans"
+)
+
+// RegisterTasks schedules lifecycle runs and orphan scans; an empty schedule disables a task
+func RegisterTasks(s *scheduler.Scheduler, m *Manager, lifecycleSchedule, orphanSchedule string) error {
+	if lifecycleSchedule != "" {
+		err := s.Register(TaskLifecycle, lifecycleSchedule, func(ctx context.Context) error {
+			report, err := m.ApplyLifecycle(ctx)
+			m.logger.Info("Storage lifecycle applied",
+				"scanned", report.Scanned,
+				"transitioned", report.Transitioned,
+				"expired", report.Expired,
+				"failed", report.Failed)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if orphanSchedule != "" && m.index != nil {
+		err := s.Register(TaskOrphans, orphanSchedule, func(ctx context.Context) error {
+			_, err := m.FindOrphans(ctx)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
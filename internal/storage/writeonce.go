@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go-server/internal/cryptopolicy"
+	"go-server/internal/sigv4"
+)
+
+// ErrExists is returned when writing an object once to a key already taken
+var ErrExists = errors.New("storage: object already exists")
+
+// WriteOnceStore is a store that can write objects which are never
+// replaced, for records such as the audit log that must not change
+type WriteOnceStore interface {
+	Store
+	// PutOnce stores an object unless the key is taken, returning ErrExists
+	// if it is. A non-zero retainUntil asks the store to refuse to delete
+	// or overwrite the object before then.
+	PutOnce(ctx context.Context, key string, body []byte, contentType string, retainUntil time.Time) error
+}
+
+// PutOnce uploads the object with If-None-Match so an existing object is
+// never replaced. With retainUntil set the object is locked in compliance
+// mode, which no account can lift; the bucket must have Object Lock
+// enabled.
+func (s *S3Store) PutOnce(ctx context.Context, key string, body []byte, contentType string, retainUntil time.Time) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	req, err := s.newRequest(ctx, http.MethodPut, key, nil, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = int64(len(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("If-None-Match", "*")
+	if !retainUntil.IsZero() {
+		// Object Lock writes must carry a checksum of the body; restricted
+		// crypto mode sends SHA-256 rather than MD5
+		if cryptopolicy.Allow(cryptopolicy.MD5) == nil {
+			sum := md5.Sum(body)
+			req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+		} else {
+			sum := sha256.Sum256(body)
+			req.Header.Set("X-Amz-Checksum-Sha256", base64.StdEncoding.EncodeToString(sum[:]))
+		}
+		req.Header.Set("X-Amz-Object-Lock-Mode", "COMPLIANCE")
+		req.Header.Set("X-Amz-Object-Lock-Retain-Until-Date", retainUntil.UTC().Format(time.RFC3339))
+	}
+
+	resp, err := s.do(req, sigv4.PayloadHash(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// PutOnce writes the object through a temporary file linked into place,
+// which fails if the key is taken, and makes it read-only. The filesystem
+// cannot enforce retainUntil; anyone with write access to the directory
+// can still remove the file.
+func (ls *LocalStore) PutOnce(ctx context.Context, key string, body []byte, contentType string, retainUntil time.Time) error {
+	target, err := ls.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o444); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Link(tmp.Name(), target); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return ErrExists
+		}
+		return err
+	}
+	return nil
+}
@@ -1,10 +1,16 @@
 package executors
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"go-server/internal/testrunner/soak"
 	"go-server/internal/testrunner/types"
 )
 
@@ -18,6 +24,10 @@ func NewPerformanceTestExecutor() *PerformanceTestExecutor {
 
 // Run executes performance tests
 func (e *PerformanceTestExecutor) Run(config *types.TestConfig, runDir string) types.TestResult {
+	if config.Soak {
+		return e.runSoak(config, runDir)
+	}
+
 	fmt.Println("Running Performance Tests")
 	fmt.Println("===============================")
 
@@ -49,3 +59,58 @@ func (e *PerformanceTestExecutor) Run(config *types.TestConfig, runDir string) t
 		Duration: duration,
 	}
 }
+
+// runSoak loads a running server for hours and fails on leak slopes or
+// latency drift. Interrupting it still analyses the samples taken so far.
+func (e *PerformanceTestExecutor) runSoak(config *types.TestConfig, runDir string) types.TestResult {
+	soakConfig := config.SoakConfig
+	fmt.Println("Running Soak Test")
+	fmt.Println("===============================")
+	fmt.Printf("Target: %s for %s at %d req/s\n", soakConfig.BaseURL, soakConfig.Duration, soakConfig.RPS)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	start := time.Now()
+	var log strings.Builder
+	report, err := soak.NewRunner(soakConfig).Run(ctx, func(s soak.Sample) {
+		line := fmt.Sprintf("%8s alloc=%.0fMB goroutines=%.0f requests=%d errors=%d p50=%s p95=%s",
+			s.Elapsed.Round(time.Second), s.AllocMB, s.Goroutines, s.Requests, s.Errors, s.P50, s.P95)
+		log.WriteString(line + "\n")
+		if config.Verbose {
+			fmt.Println(line)
+		}
+	})
+	duration := time.Since(start)
+
+	passed := err == nil && report.Passed()
+	if err != nil {
+		log.WriteString(err.Error() + "\n")
+	} else {
+		fmt.Fprintf(&log, "memory slope: %.2f MB/h\ngoroutine slope: %.2f/h\nlatency drift: x%.2f\nerror rate: %.2f%%\n",
+			report.MemorySlope, report.GoroutineSlope, report.LatencyDrift, report.ErrorRate*100)
+		for _, failure := range report.Failures {
+			log.WriteString("FAIL: " + failure + "\n")
+		}
+		if data, err := json.MarshalIndent(report, "", "  "); err == nil {
+			writeLog(filepath.Join(runDir, "soak_report.json"), string(data))
+		}
+	}
+
+	logFile := filepath.Join(runDir, "soak_test.log")
+	writeLog(logFile, log.String())
+
+	if passed {
+		fmt.Println("PASSED: soak_test")
+	} else {
+		fmt.Printf("FAILED: soak_test\n")
+	}
+
+	return types.TestResult{
+		Name:     "soak_test",
+		Passed:   passed,
+		Output:   log.String(),
+		LogFile:  logFile,
+		Duration: duration,
+	}
+}
@@ -0,0 +1,46 @@
+package executors
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"go-server/internal/testrunner/types"
+)
+
+// SeedExecutor seeds the test database, see package seed
+type SeedExecutor struct{}
+
+// NewSeedExecutor creates a new seed executor
+func NewSeedExecutor() *SeedExecutor {
+	return &SeedExecutor{}
+}
+
+// Run runs the test environment's seeds with cmd/migrate
+func (e *SeedExecutor) Run(config *types.TestConfig, runDir string) types.TestResult {
+	fmt.Println("Seeding Test Database")
+	fmt.Println("==============================")
+
+	start := time.Now()
+
+	output, err := runCommand("go", "run", "./cmd/migrate", "seed", "-env", "test")
+	duration := time.Since(start)
+
+	logFile := filepath.Join(runDir, "seed.log")
+	writeLog(logFile, output)
+
+	passed := err == nil
+	if passed {
+		fmt.Println("PASSED: seed")
+	} else {
+		fmt.Printf("FAILED: seed\n")
+	}
+
+	return types.TestResult{
+		Name:     "seed",
+		Passed:   passed,
+		Output:   output,
+		LogFile:  logFile,
+		Duration: duration,
+	}
+}
@@ -36,6 +36,7 @@ func NewTestRunner() *TestRunner {
 	runner.executors["coverage"] = executors.NewCoverageTestExecutor()
 	runner.executors["lint"] = executors.NewLintTestExecutor()
 	runner.executors["postman"] = executors.NewPostmanTestExecutor()
+	runner.executors["seed"] = executors.NewSeedExecutor()
 
 	return runner
 }
@@ -59,10 +60,19 @@ func (r *TestRunner) Run(config *types.TestConfig) error {
 	fmt.Printf("Results directory: %s\n\n", runDir)
 
 	var results []types.TestResult
+	seeded := true
+	if config.Seed && config.TestType != "seed" {
+		result := r.executors["seed"].Run(config, runDir)
+		results = append(results, result)
+		seeded = result.Passed
+	}
 
-	switch config.TestType {
-	case "all":
-		results = r.runAllTests(runDir, config)
+	switch {
+	case !seeded:
+		// The tests would only fail against a database missing its seeds
+		fmt.Println("Skipping the tests: seeding failed")
+	case config.TestType == "all":
+		results = append(results, r.runAllTests(runDir, config)...)
 	default:
 		if executor, exists := r.executors[config.TestType]; exists {
 			results = append(results, executor.Run(config, runDir))
@@ -0,0 +1,328 @@
+// Package smoke runs a short set of read-only checks against a deployed
+// server, as a gate after each deploy: the health and version endpoints
+// answer, the API docs are served, protected endpoints refuse anonymous
+// requests, and a probe account can log in, use its token and log out.
+// Nothing is written besides the probe's short-lived session.
+package smoke
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Config describes the server under test and the probe account
+type Config struct {
+	// BaseURL is the deployed server, e.g. https://staging.example.com
+	BaseURL string
+	// ProbeEmail and ProbePassword are a dedicated account with no staff
+	// role; without them the auth round trip is skipped
+	ProbeEmail    string
+	ProbePassword string
+	// ExpectVersion fails the version check unless the server reports it
+	ExpectVersion string
+	// SkipDocs skips the docs checks, for environments that do not serve them
+	SkipDocs bool
+	// Timeout bounds each request
+	Timeout time.Duration
+}
+
+// DefaultConfig returns a config for a local server
+func DefaultConfig() Config {
+	return Config{
+		BaseURL: "http://localhost:8080",
+		Timeout: 10 * time.Second,
+	}
+}
+
+// Check is the outcome of one check
+type Check struct {
+	Name     string        `json:"name"`
+	Passed   bool          `json:"passed"`
+	Skipped  bool          `json:"skipped,omitempty"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Report is the outcome of a smoke run
+type Report struct {
+	BaseURL  string        `json:"base_url"`
+	Checks   []Check       `json:"checks"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Passed reports whether every check that ran passed
+func (r *Report) Passed() bool {
+	for _, check := range r.Checks {
+		if !check.Passed && !check.Skipped {
+			return false
+		}
+	}
+	return true
+}
+
+// Failures returns the checks that failed
+func (r *Report) Failures() []Check {
+	var failed []Check
+	for _, check := range r.Checks {
+		if !check.Passed && !check.Skipped {
+			failed = append(failed, check)
+		}
+	}
+	return failed
+}
+
+// Runner runs the checks
+type Runner struct {
+	config Config
+	client *http.Client
+}
+
+// NewRunner creates a runner for config
+func NewRunner(config Config) *Runner {
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
+	return &Runner{
+		config: config,
+		client: &http.Client{
+			Timeout: config.Timeout,
+			// A redirect to a login page or another host is a failure,
+			// not something to follow
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Run runs every check in order, calling progress after each
+func (r *Runner) Run(ctx context.Context, progress func(Check)) *Report {
+	start := time.Now()
+	report := &Report{BaseURL: r.config.BaseURL}
+	record := func(name string, skip string, check func(context.Context) error) {
+		result := Check{Name: name}
+		if skip != "" {
+			result.Skipped = true
+			result.Error = skip
+		} else {
+			began := time.Now()
+			err := check(ctx)
+			result.Duration = time.Since(began)
+			result.Passed = err == nil
+			if err != nil {
+				result.Error = err.Error()
+			}
+		}
+		report.Checks = append(report.Checks, result)
+		if progress != nil {
+			progress(result)
+		}
+	}
+
+	record("health", "", r.checkHealth)
+	record("version", "", r.checkVersion)
+
+	docsSkip := ""
+	if r.config.SkipDocs {
+		docsSkip = "docs checks disabled"
+	}
+	record("openapi spec", docsSkip, r.checkSpec)
+	record("docs page", docsSkip, r.checkDocs)
+
+	record("anonymous request refused", "", func(ctx context.Context) error {
+		return r.expectStatus(ctx, "", http.StatusUnauthorized)
+	})
+
+	authSkip := ""
+	if r.config.ProbeEmail == "" || r.config.ProbePassword == "" {
+		authSkip = "no probe account configured"
+	}
+	var token string
+	record("probe login", authSkip, func(ctx context.Context) (err error) {
+		token, err = r.login(ctx)
+		return err
+	})
+	if authSkip == "" && token == "" {
+		authSkip = "probe login failed"
+	}
+	record("authenticated request", authSkip, func(ctx context.Context) error {
+		return r.expectStatus(ctx, token, http.StatusOK)
+	})
+	record("probe logout", authSkip, func(ctx context.Context) error {
+		return r.logout(ctx, token)
+	})
+	record("logged out token refused", authSkip, func(ctx context.Context) error {
+		return r.expectStatus(ctx, token, http.StatusUnauthorized)
+	})
+
+	report.Duration = time.Since(start)
+	return report
+}
+
+// checkHealth expects GET /health to report the server healthy
+func (r *Runner) checkHealth(ctx context.Context) error {
+	var health struct {
+		Status string `json:"status"`
+	}
+	if err := r.getJSON(ctx, "/health", &health); err != nil {
+		return err
+	}
+	if health.Status != "healthy" {
+		return fmt.Errorf("status is %q, not healthy", health.Status)
+	}
+	return nil
+}
+
+// checkVersion expects GET /version to name the server's version
+func (r *Runner) checkVersion(ctx context.Context) error {
+	var version struct {
+		Data struct {
+			Version string `json:"version"`
+		} `json:"data"`
+	}
+	if err := r.getJSON(ctx, "/version", &version); err != nil {
+		return err
+	}
+	if version.Data.Version == "" {
+		return fmt.Errorf("no version reported")
+	}
+	if r.config.ExpectVersion != "" && version.Data.Version != r.config.ExpectVersion {
+		return fmt.Errorf("version is %s, expected %s", version.Data.Version, r.config.ExpectVersion)
+	}
+	return nil
+}
+
+// checkSpec expects GET /openapi.json to be an OpenAPI document with paths
+func (r *Runner) checkSpec(ctx context.Context) error {
+	var spec struct {
+		OpenAPI string                     `json:"openapi"`
+		Paths   map[string]json.RawMessage `json:"paths"`
+	}
+	if err := r.getJSON(ctx, "/openapi.json", &spec); err != nil {
+		return err
+	}
+	if spec.OpenAPI == "" || len(spec.Paths) == 0 {
+		return fmt.Errorf("not an OpenAPI document with paths")
+	}
+	return nil
+}
+
+// checkDocs expects GET /docs to be an HTML page
+func (r *Runner) checkDocs(ctx context.Context) error {
+	resp, err := r.do(ctx, http.MethodGet, "/docs", "", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return unexpectedStatus(resp, http.StatusOK)
+	}
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/html") {
+		return fmt.Errorf("content type is %q, not HTML", contentType)
+	}
+	return nil
+}
+
+// protectedPath is a read-only endpoint that needs a user's token
+const protectedPath = "/api/users/me/permissions"
+
+// expectStatus expects GET protectedPath with token to answer status
+func (r *Runner) expectStatus(ctx context.Context, token string, status int) error {
+	resp, err := r.do(ctx, http.MethodGet, protectedPath, token, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != status {
+		return unexpectedStatus(resp, status)
+	}
+	return nil
+}
+
+// login logs the probe account in and returns its token
+func (r *Runner) login(ctx context.Context) (string, error) {
+	body, _ := json.Marshal(map[string]string{
+		"email":    r.config.ProbeEmail,
+		"password": r.config.ProbePassword,
+	})
+	resp, err := r.do(ctx, http.MethodPost, "/api/auth/login", "", body)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", unexpectedStatus(resp, http.StatusOK)
+	}
+
+	var login struct {
+		Data struct {
+			Token string `json:"token"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&login); err != nil {
+		return "", fmt.Errorf("invalid login response: %w", err)
+	}
+	if login.Data.Token == "" {
+		return "", fmt.Errorf("login response has no token")
+	}
+	return login.Data.Token, nil
+}
+
+// logout ends the session of token
+func (r *Runner) logout(ctx context.Context, token string) error {
+	resp, err := r.do(ctx, http.MethodPost, "/api/auth/logout", token, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return unexpectedStatus(resp, http.StatusOK)
+	}
+	return nil
+}
+
+// getJSON expects GET path to answer 200 with JSON decoded into v
+func (r *Runner) getJSON(ctx context.Context, path string, v interface{}) error {
+	resp, err := r.do(ctx, http.MethodGet, path, "", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return unexpectedStatus(resp, http.StatusOK)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	return nil
+}
+
+// do sends a request, with token as a bearer token if set
+func (r *Runner) do(ctx context.Context, method, path, token string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, r.config.BaseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "go-server-smoke")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return r.client.Do(req)
+}
+
+// unexpectedStatus describes a response with the wrong status, with the
+// start of its body to help tell a proxy's error page from the server's
+func unexpectedStatus(resp *http.Response, want int) error {
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+	return fmt.Errorf("status %d, expected %d: %s", resp.StatusCode, want, strings.TrimSpace(string(snippet)))
+}
@@ -0,0 +1,137 @@
+package smoke
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeServer answers the smoke checks the way a healthy deployment does,
+// revoking tokens on logout
+func fakeServer(t *testing.T, health string) *httptest.Server {
+	t.Helper()
+	var mu sync.Mutex
+	sessions := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		switch {
+		case r.URL.Path == "/health":
+			fmt.Fprintf(w, `{"status":%q}`, health)
+		case r.URL.Path == "/version":
+			fmt.Fprint(w, `{"status":"success","data":{"version":"1.4.0"}}`)
+		case r.URL.Path == "/openapi.json":
+			fmt.Fprint(w, `{"openapi":"3.0.3","paths":{"/health":{}}}`)
+		case r.URL.Path == "/docs":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, "<html></html>")
+		case r.URL.Path == "/api/auth/login" && r.Method == http.MethodPost:
+			var login struct {
+				Email    string `json:"email"`
+				Password string `json:"password"`
+			}
+			json.NewDecoder(r.Body).Decode(&login)
+			if login.Email != "probe@example.com" || login.Password != "probe-secret" {
+				http.Error(w, `{"status":"error"}`, http.StatusUnauthorized)
+				return
+			}
+			sessions["probe-token"] = true
+			fmt.Fprint(w, `{"status":"success","data":{"token":"probe-token","session_id":"s1"}}`)
+		case r.URL.Path == "/api/auth/logout" && r.Method == http.MethodPost:
+			if !sessions[token] {
+				http.Error(w, `{"status":"error"}`, http.StatusUnauthorized)
+				return
+			}
+			delete(sessions, token)
+			fmt.Fprint(w, `{"status":"success"}`)
+		case r.URL.Path == protectedPath:
+			if !sessions[token] {
+				http.Error(w, `{"status":"error"}`, http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{"status":"success","data":[]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRunner_Run(t *testing.T) {
+	server := fakeServer(t, "healthy")
+	config := DefaultConfig()
+	config.BaseURL = server.URL + "/"
+	config.ProbeEmail = "probe@example.com"
+	config.ProbePassword = "probe-secret"
+	config.ExpectVersion = "1.4.0"
+
+	var seen int
+	report := NewRunner(config).Run(context.Background(), func(Check) { seen++ })
+	if !report.Passed() {
+		t.Fatalf("Expected every check to pass, got %+v", report.Failures())
+	}
+	if len(report.Checks) != 9 || seen != len(report.Checks) {
+		t.Errorf("Expected 9 checks reported as they ran, got %d and %d", len(report.Checks), seen)
+	}
+	for _, check := range report.Checks {
+		if check.Skipped {
+			t.Errorf("Expected %s to run", check.Name)
+		}
+	}
+}
+
+func TestRunner_RunFailures(t *testing.T) {
+	server := fakeServer(t, "degraded")
+	config := DefaultConfig()
+	config.BaseURL = server.URL
+	config.ProbeEmail = "probe@example.com"
+	config.ProbePassword = "wrong"
+	config.ExpectVersion = "1.5.0"
+
+	report := NewRunner(config).Run(context.Background(), nil)
+	if report.Passed() {
+		t.Fatal("Expected the run to fail")
+	}
+	failed := map[string]bool{}
+	for _, check := range report.Failures() {
+		failed[check.Name] = true
+	}
+	for _, name := range []string{"health", "version", "probe login"} {
+		if !failed[name] {
+			t.Errorf("Expected %s to fail, got %+v", name, report.Failures())
+		}
+	}
+	for _, check := range report.Checks[6:] {
+		if !check.Skipped {
+			t.Errorf("Expected %s skipped after the failed login", check.Name)
+		}
+	}
+}
+
+func TestRunner_RunWithoutProbe(t *testing.T) {
+	server := fakeServer(t, "healthy")
+	config := DefaultConfig()
+	config.BaseURL = server.URL
+	config.SkipDocs = true
+
+	report := NewRunner(config).Run(context.Background(), nil)
+	if !report.Passed() {
+		t.Fatalf("Expected skipped checks not to fail the run, got %+v", report.Failures())
+	}
+	var ran []string
+	for _, check := range report.Checks {
+		if !check.Skipped {
+			ran = append(ran, check.Name)
+		}
+	}
+	if strings.Join(ran, ",") != "health,version,anonymous request refused" {
+		t.Errorf("Expected only the anonymous checks to run, got %v", ran)
+	}
+}
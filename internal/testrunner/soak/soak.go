@@ -0,0 +1,348 @@
+// Package soak runs a long, steady load against a running server and
+// watches its metrics endpoint for the slow trends a short load test
+// cannot see: memory that keeps growing, goroutines that are never
+// released and latency that drifts upward as internal maps fill.
+package soak
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config describes a soak run and the trends that fail it
+type Config struct {
+	// BaseURL is the server under test, e.g. http://localhost:8080
+	BaseURL  string
+	Duration time.Duration
+	// SampleInterval is how often /metrics is read and latency summarised
+	SampleInterval time.Duration
+	// RPS is the steady request rate, spread over Concurrency workers
+	RPS         int
+	Concurrency int
+	// Warmup is excluded from the trends, so caches and pools filling up
+	// at startup are not mistaken for leaks
+	Warmup time.Duration
+
+	// MaxMemorySlope is the allowed heap growth in MB per hour
+	MaxMemorySlope float64
+	// MaxGoroutineSlope is the allowed goroutine growth per hour
+	MaxGoroutineSlope float64
+	// MaxLatencyDrift is the allowed ratio of the last to the first p95
+	MaxLatencyDrift float64
+	// MaxErrorRate is the allowed fraction of failed requests
+	MaxErrorRate float64
+}
+
+// DefaultConfig returns a two hour profile
+func DefaultConfig() Config {
+	return Config{
+		BaseURL:           "http://localhost:8080",
+		Duration:          2 * time.Hour,
+		SampleInterval:    time.Minute,
+		RPS:               50,
+		Concurrency:       10,
+		Warmup:            5 * time.Minute,
+		MaxMemorySlope:    10,
+		MaxGoroutineSlope: 20,
+		MaxLatencyDrift:   1.5,
+		MaxErrorRate:      0.01,
+	}
+}
+
+// Sample is the server's state at one point of the run, with the latency
+// of the requests made since the previous sample
+type Sample struct {
+	Elapsed    time.Duration `json:"elapsed"`
+	AllocMB    float64       `json:"alloc_mb"`
+	Goroutines float64       `json:"goroutines"`
+	Requests   int           `json:"requests"`
+	Errors     int           `json:"errors"`
+	P50        time.Duration `json:"p50"`
+	P95        time.Duration `json:"p95"`
+}
+
+// Report is the outcome of a soak run
+type Report struct {
+	Samples []Sample `json:"samples"`
+	// MemorySlope and GoroutineSlope are least-squares growth per hour
+	// after the warmup
+	MemorySlope    float64  `json:"memory_slope_mb_per_hour"`
+	GoroutineSlope float64  `json:"goroutine_slope_per_hour"`
+	LatencyDrift   float64  `json:"latency_drift"`
+	ErrorRate      float64  `json:"error_rate"`
+	Failures       []string `json:"failures,omitempty"`
+}
+
+// Passed reports whether every trend stayed within its limit
+func (r *Report) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// Runner drives the load and collects samples
+type Runner struct {
+	config Config
+	client *http.Client
+
+	mutex     sync.Mutex
+	latencies []time.Duration
+	requests  int
+	errors    int
+}
+
+// NewRunner creates a runner for config
+func NewRunner(config Config) *Runner {
+	if config.Concurrency <= 0 {
+		config.Concurrency = 1
+	}
+	if config.RPS <= 0 {
+		config.RPS = 1
+	}
+	if config.SampleInterval <= 0 {
+		config.SampleInterval = time.Minute
+	}
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
+	return &Runner{config: config, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Run loads the server for the configured duration, calling progress
+// with each sample, and analyses the trends
+func (r *Runner) Run(ctx context.Context, progress func(Sample)) (*Report, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.Duration)
+	defer cancel()
+
+	if _, _, err := r.readMetrics(ctx); err != nil {
+		return nil, fmt.Errorf("server is not reachable: %w", err)
+	}
+
+	var wg sync.WaitGroup
+	interval := time.Duration(float64(time.Second) * float64(r.config.Concurrency) / float64(r.config.RPS))
+	for i := 0; i < r.config.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.worker(ctx, interval)
+		}()
+	}
+
+	start := time.Now()
+	var samples []Sample
+	ticker := time.NewTicker(r.config.SampleInterval)
+	defer ticker.Stop()
+	for done := false; !done; {
+		select {
+		case <-ctx.Done():
+			done = true
+		case <-ticker.C:
+			sample, err := r.sample(ctx, time.Since(start))
+			if err != nil {
+				// A missed reading is not a leak; the run keeps going
+				continue
+			}
+			samples = append(samples, sample)
+			if progress != nil {
+				progress(sample)
+			}
+		}
+	}
+	wg.Wait()
+
+	return Analyze(samples, r.config), nil
+}
+
+// worker sends a request every interval
+func (r *Runner) worker(ctx context.Context, interval time.Duration) {
+	body, _ := json.Marshal(map[string]interface{}{
+		"message": "soak",
+		"action":  "echo",
+		"user_id": 1,
+	})
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPost, r.config.BaseURL+"/api", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		start := time.Now()
+		resp, err := r.client.Do(req)
+		latency := time.Since(start)
+		failed := err != nil
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			failed = failed || resp.StatusCode >= 500
+		}
+		if ctx.Err() != nil {
+			// Requests cut off by the end of the run are not failures
+			return
+		}
+
+		r.mutex.Lock()
+		r.requests++
+		if failed {
+			r.errors++
+		} else {
+			r.latencies = append(r.latencies, latency)
+		}
+		r.mutex.Unlock()
+	}
+}
+
+// sample reads the metrics endpoint and summarises the latency window
+func (r *Runner) sample(ctx context.Context, elapsed time.Duration) (Sample, error) {
+	alloc, goroutines, err := r.readMetrics(ctx)
+	if err != nil {
+		return Sample{}, err
+	}
+
+	r.mutex.Lock()
+	latencies := r.latencies
+	sample := Sample{
+		Elapsed:    elapsed,
+		AllocMB:    alloc,
+		Goroutines: goroutines,
+		Requests:   r.requests,
+		Errors:     r.errors,
+	}
+	r.latencies, r.requests, r.errors = nil, 0, 0
+	r.mutex.Unlock()
+
+	sample.P50 = percentile(latencies, 0.50)
+	sample.P95 = percentile(latencies, 0.95)
+	return sample, nil
+}
+
+// readMetrics returns the heap size in MB and the goroutine count
+func (r *Runner) readMetrics(ctx context.Context) (float64, float64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.config.BaseURL+"/metrics", nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, 0, fmt.Errorf("metrics endpoint returned %d", resp.StatusCode)
+	}
+
+	var metrics struct {
+		Data struct {
+			Memory struct {
+				AllocMB float64 `json:"alloc_mb"`
+			} `json:"memory"`
+			Runtime struct {
+				Goroutines float64 `json:"goroutines"`
+			} `json:"runtime"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&metrics); err != nil {
+		return 0, 0, fmt.Errorf("invalid metrics response: %w", err)
+	}
+	return metrics.Data.Memory.AllocMB, metrics.Data.Runtime.Goroutines, nil
+}
+
+// Analyze computes the trends of the samples taken after the warmup and
+// checks them against the limits
+func Analyze(samples []Sample, config Config) *Report {
+	report := &Report{Samples: samples}
+
+	var steady []Sample
+	requests, errors := 0, 0
+	for _, s := range samples {
+		requests += s.Requests
+		errors += s.Errors
+		if s.Elapsed >= config.Warmup {
+			steady = append(steady, s)
+		}
+	}
+	if requests > 0 {
+		report.ErrorRate = float64(errors) / float64(requests)
+	}
+	if config.MaxErrorRate > 0 && report.ErrorRate > config.MaxErrorRate {
+		report.Failures = append(report.Failures, fmt.Sprintf("error rate %.2f%% exceeds %.2f%%", report.ErrorRate*100, config.MaxErrorRate*100))
+	}
+
+	if len(steady) < 3 {
+		report.Failures = append(report.Failures, fmt.Sprintf("only %d samples after the warmup; run longer or sample more often", len(steady)))
+		return report
+	}
+
+	report.MemorySlope = slopePerHour(steady, func(s Sample) float64 { return s.AllocMB })
+	report.GoroutineSlope = slopePerHour(steady, func(s Sample) float64 { return s.Goroutines })
+	if config.MaxMemorySlope > 0 && report.MemorySlope > config.MaxMemorySlope {
+		report.Failures = append(report.Failures, fmt.Sprintf("memory grows %.1f MB/h, limit %.1f MB/h", report.MemorySlope, config.MaxMemorySlope))
+	}
+	if config.MaxGoroutineSlope > 0 && report.GoroutineSlope > config.MaxGoroutineSlope {
+		report.Failures = append(report.Failures, fmt.Sprintf("goroutines grow %.1f/h, limit %.1f/h", report.GoroutineSlope, config.MaxGoroutineSlope))
+	}
+
+	// Compare the first and last quarters, so one slow window is not a drift
+	quarter := (len(steady) + 3) / 4
+	first := medianP95(steady[:quarter])
+	last := medianP95(steady[len(steady)-quarter:])
+	if first > 0 {
+		report.LatencyDrift = float64(last) / float64(first)
+	}
+	if config.MaxLatencyDrift > 0 && report.LatencyDrift > config.MaxLatencyDrift {
+		report.Failures = append(report.Failures, fmt.Sprintf("p95 latency drifted from %s to %s (x%.2f), limit x%.2f",
+			first, last, report.LatencyDrift, config.MaxLatencyDrift))
+	}
+	return report
+}
+
+// slopePerHour fits a least-squares line through the values
+func slopePerHour(samples []Sample, value func(Sample) float64) float64 {
+	n := float64(len(samples))
+	var sumX, sumY, sumXY, sumXX float64
+	for _, s := range samples {
+		x := s.Elapsed.Hours()
+		y := value(s)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / denominator
+}
+
+func medianP95(samples []Sample) time.Duration {
+	values := make([]time.Duration, 0, len(samples))
+	for _, s := range samples {
+		if s.P95 > 0 {
+			values = append(values, s.P95)
+		}
+	}
+	return percentile(values, 0.5)
+}
+
+func percentile(values []time.Duration, p float64) time.Duration {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	index := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if index < 0 {
+		index = 0
+	}
+	return sorted[index]
+}
@@ -0,0 +1,98 @@
+package soak
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func samples(hours int, alloc, goroutines func(i int) float64, p95 func(i int) time.Duration) []Sample {
+	var out []Sample
+	for i := 0; i <= hours*6; i++ {
+		out = append(out, Sample{
+			Elapsed:    time.Duration(i) * 10 * time.Minute,
+			AllocMB:    alloc(i),
+			Goroutines: goroutines(i),
+			Requests:   100,
+			P95:        p95(i),
+		})
+	}
+	return out
+}
+
+func TestAnalyze(t *testing.T) {
+	config := DefaultConfig()
+	flat := func(i int) float64 { return 40 + float64(i%3) }
+	steadyLatency := func(i int) time.Duration { return 20 * time.Millisecond }
+
+	report := Analyze(samples(4, flat, flat, steadyLatency), config)
+	if !report.Passed() {
+		t.Errorf("A steady server should pass, got %v", report.Failures)
+	}
+
+	// A rate limiter map never pruned: 5 MB per 10 minutes
+	leaking := func(i int) float64 { return 40 + 5*float64(i) }
+	report = Analyze(samples(4, leaking, flat, steadyLatency), config)
+	if report.Passed() || report.MemorySlope < 29 || report.MemorySlope > 31 {
+		t.Errorf("Expected a 30 MB/h memory slope to fail, got %.1f, %v", report.MemorySlope, report.Failures)
+	}
+
+	report = Analyze(samples(4, flat, leaking, steadyLatency), config)
+	if report.Passed() {
+		t.Error("Expected growing goroutines to fail")
+	}
+
+	drifting := func(i int) time.Duration { return time.Duration(20+i) * time.Millisecond }
+	report = Analyze(samples(4, flat, flat, drifting), config)
+	if report.Passed() || report.LatencyDrift < 1.5 {
+		t.Errorf("Expected latency drift to fail, got x%.2f", report.LatencyDrift)
+	}
+
+	report = Analyze(samples(0, flat, flat, steadyLatency), config)
+	if report.Passed() {
+		t.Error("Too few samples should fail rather than pass vacuously")
+	}
+}
+
+func TestRunner_Run(t *testing.T) {
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/metrics":
+			fmt.Fprint(w, `{"data":{"memory":{"alloc_mb":12},"runtime":{"goroutines":8}}}`)
+		case "/api":
+			requests.Add(1)
+			fmt.Fprint(w, `{"status":"success"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.BaseURL = server.URL
+	config.Duration = 500 * time.Millisecond
+	config.SampleInterval = 50 * time.Millisecond
+	config.Warmup = 0
+	config.RPS = 200
+
+	var progress int
+	report, err := NewRunner(config).Run(context.Background(), func(Sample) { progress++ })
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(report.Samples) < 3 || progress != len(report.Samples) {
+		t.Errorf("Expected samples reported as they are taken, got %d samples and %d calls", len(report.Samples), progress)
+	}
+	if requests.Load() == 0 {
+		t.Error("Expected load on the server")
+	}
+	if math.Abs(report.MemorySlope) > 1e-6 || math.Abs(report.GoroutineSlope) > 1e-6 || report.ErrorRate != 0 {
+		t.Errorf("Expected flat trends, got %+v", report)
+	}
+}
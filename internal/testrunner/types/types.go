@@ -1,6 +1,10 @@
 package types
 
-import "time"
+import (
+	"time"
+
+	"go-server/internal/testrunner/soak"
+)
 
 // TestConfig represents the configuration for running tests
 type TestConfig struct {
@@ -11,6 +15,15 @@ type TestConfig struct {
 	OutputDir   string
 	Timeout     time.Duration
 	TestRunName string
+
+	// Seed runs the test environment's seeds before the tests, see
+	// package seed
+	Seed bool
+
+	// Soak switches the performance tests to a long soak run against a
+	// running server, see package soak
+	Soak       bool
+	SoakConfig soak.Config
 }
 
 // TestResult represents the result of a test execution
@@ -0,0 +1,210 @@
+package uploads
+
+import (
+	"encoding/base64"
+	stderrors "errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-server/internal/errors"
+	"go-server/internal/logger"
+	"go-server/internal/middleware"
+)
+
+// TusVersion is the supported protocol version
+const TusVersion = "1.0.0"
+
+// StatusChecksumMismatch is the tus checksum extension's status for a corrupted chunk
+const StatusChecksumMismatch = 460
+
+// Handler serves the tus endpoints under basePath, e.g. /api/uploads/.
+// It expects an authenticated user in the request context.
+type Handler struct {
+	service  *Service
+	basePath string
+	logger   logger.Logger
+}
+
+// NewHandler creates a new tus handler
+func NewHandler(service *Service, basePath string, logger logger.Logger) *Handler {
+	return &Handler{
+		service:  service,
+		basePath: strings.TrimSuffix(basePath, "/") + "/",
+		logger:   logger,
+	}
+}
+
+// ServeHTTP dispatches tus requests: OPTIONS and POST on the base path,
+// HEAD, PATCH and DELETE on an upload URL
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Tus-Resumable", TusVersion)
+	w.Header().Set("Cache-Control", "no-store")
+
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Tus-Version", TusVersion)
+		w.Header().Set("Tus-Extension", "creation,termination,checksum,expiration")
+		w.Header().Set("Tus-Max-Size", strconv.FormatInt(h.service.MaxSize(), 10))
+		w.Header().Set("Tus-Checksum-Algorithm", strings.Join(allowedChecksums(), ","))
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Header.Get("Tus-Resumable") != TusVersion {
+		w.Header().Set("Tus-Version", TusVersion)
+		errors.WriteErrorResponse(w, http.StatusPreconditionFailed, "Unsupported tus version", "TUS_VERSION_UNSUPPORTED")
+		return
+	}
+
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteErrorResponse(w, http.StatusUnauthorized, "User not authenticated", "NOT_AUTHENTICATED")
+		return
+	}
+
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, strings.TrimSuffix(h.basePath, "/")), "/")
+	switch {
+	case id == "" && r.Method == http.MethodPost:
+		h.create(w, r, userID)
+	case id != "" && !strings.Contains(id, "/") && r.Method == http.MethodHead:
+		h.head(w, r, userID, id)
+	case id != "" && !strings.Contains(id, "/") && r.Method == http.MethodPatch:
+		h.patch(w, r, userID, id)
+	case id != "" && !strings.Contains(id, "/") && r.Method == http.MethodDelete:
+		h.terminate(w, r, userID, id)
+	default:
+		errors.WriteErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed", "METHOD_NOT_ALLOWED")
+	}
+}
+
+// create starts an upload (POST /api/uploads/)
+func (h *Handler) create(w http.ResponseWriter, r *http.Request, userID uint) {
+	if r.Header.Get("Upload-Defer-Length") != "" {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Deferred upload length is not supported", "TUS_DEFER_LENGTH_UNSUPPORTED")
+		return
+	}
+	length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Upload-Length must be a non-negative integer", "INVALID_UPLOAD_LENGTH")
+		return
+	}
+	metadata, err := parseMetadata(r.Header.Get("Upload-Metadata"))
+	if err != nil {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Invalid Upload-Metadata", "INVALID_UPLOAD_METADATA")
+		return
+	}
+
+	state, err := h.service.Create(r.Context(), userID, length, metadata)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", h.basePath+state.ID)
+	h.writeProgress(w, state)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// head reports the current offset (HEAD /api/uploads/{id})
+func (h *Handler) head(w http.ResponseWriter, r *http.Request, userID uint, id string) {
+	state, err := h.service.Get(r.Context(), userID, id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	w.Header().Set("Upload-Length", strconv.FormatInt(state.Length, 10))
+	if len(state.Metadata) > 0 {
+		w.Header().Set("Upload-Metadata", encodeMetadata(state.Metadata))
+	}
+	h.writeProgress(w, state)
+	w.WriteHeader(http.StatusOK)
+}
+
+// patch appends a chunk (PATCH /api/uploads/{id})
+func (h *Handler) patch(w http.ResponseWriter, r *http.Request, userID uint, id string) {
+	if r.Header.Get("Content-Type") != "application/offset+octet-stream" {
+		errors.WriteErrorResponse(w, http.StatusUnsupportedMediaType, "Content-Type must be application/offset+octet-stream", "INVALID_CONTENT_TYPE")
+		return
+	}
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Upload-Offset must be a non-negative integer", "INVALID_UPLOAD_OFFSET")
+		return
+	}
+
+	state, err := h.service.WriteChunk(r.Context(), userID, id, offset, r.Body, r.ContentLength, r.Header.Get("Upload-Checksum"))
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeProgress(w, state)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// terminate abandons an upload (DELETE /api/uploads/{id})
+func (h *Handler) terminate(w http.ResponseWriter, r *http.Request, userID uint, id string) {
+	if err := h.service.Terminate(r.Context(), userID, id); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeProgress sets the offset, expiry and, once assembled, the attachment ID
+func (h *Handler) writeProgress(w http.ResponseWriter, state *State) {
+	w.Header().Set("Upload-Offset", strconv.FormatInt(state.Offset, 10))
+	w.Header().Set("Upload-Expires", state.ExpiresAt.UTC().Format(http.TimeFormat))
+	if state.Complete() {
+		w.Header().Set("Upload-Attachment-Id", strconv.FormatUint(uint64(state.AttachmentID), 10))
+	}
+}
+
+func (h *Handler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case stderrors.Is(err, ErrUploadNotFound):
+		errors.WriteErrorResponse(w, http.StatusNotFound, "Upload not found", "UPLOAD_NOT_FOUND")
+	case stderrors.Is(err, ErrOffsetMismatch):
+		errors.WriteErrorResponse(w, http.StatusConflict, "Upload-Offset does not match the current offset", "UPLOAD_OFFSET_MISMATCH")
+	case stderrors.Is(err, ErrUploadLocked):
+		errors.WriteErrorResponse(w, http.StatusLocked, "Upload is being written by another request", "UPLOAD_LOCKED")
+	case stderrors.Is(err, ErrChecksumMismatch):
+		errors.WriteErrorResponse(w, StatusChecksumMismatch, "Checksum mismatch", "CHECKSUM_MISMATCH")
+	case stderrors.Is(err, ErrUnsupportedHash):
+		errors.WriteErrorResponse(w, http.StatusBadRequest, "Unsupported checksum algorithm", "CHECKSUM_UNSUPPORTED")
+	case stderrors.Is(err, ErrTooLarge), stderrors.Is(err, ErrExceedsLength):
+		errors.WriteErrorResponse(w, http.StatusRequestEntityTooLarge, err.Error(), "UPLOAD_TOO_LARGE")
+	case stderrors.Is(err, ErrInvalidUploadSize):
+		errors.WriteErrorResponse(w, http.StatusBadRequest, err.Error(), "INVALID_UPLOAD_LENGTH")
+	default:
+		h.logger.Error("Upload request failed", "error", err.Error())
+		errors.WriteErrorResponse(w, http.StatusInternalServerError, "Upload failed", "UPLOAD_FAILED")
+	}
+}
+
+// parseMetadata decodes "key base64value,key2 base64value2"; values are optional
+func parseMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	if strings.TrimSpace(header) == "" {
+		return metadata, nil
+	}
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			return nil, stderrors.New("empty metadata key")
+		}
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, err
+		}
+		metadata[key] = string(value)
+	}
+	return metadata, nil
+}
+
+func encodeMetadata(metadata map[string]string) string {
+	pairs := make([]string, 0, len(metadata))
+	for key, value := range metadata {
+		pairs = append(pairs, key+" "+base64.StdEncoding.EncodeToString([]byte(value)))
+	}
+	return strings.Join(pairs, ",")
+}
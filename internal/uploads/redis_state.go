@@ -0,0 +1,65 @@
+package uploads
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisStateStore keeps upload offsets in Redis, shared by all server replicas
+type RedisStateStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStateStore creates a Redis-backed state store
+func NewRedisStateStore(client *redis.Client) *RedisStateStore {
+	return &RedisStateStore{client: client, prefix: "uploads:"}
+}
+
+// Save stores the state as JSON with an expiry at state.ExpiresAt
+func (rs *RedisStateStore) Save(ctx context.Context, state *State) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode upload state: %w", err)
+	}
+	ttl := time.Until(state.ExpiresAt)
+	if ttl <= 0 {
+		return rs.Delete(ctx, state.ID)
+	}
+	return rs.client.Set(ctx, rs.prefix+state.ID, data, ttl).Err()
+}
+
+// Get loads the state
+func (rs *RedisStateStore) Get(ctx context.Context, id string) (*State, error) {
+	data, err := rs.client.Get(ctx, rs.prefix+id).Bytes()
+	if err == redis.Nil {
+		return nil, ErrUploadNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to decode upload state: %w", err)
+	}
+	return &state, nil
+}
+
+// Delete removes the state
+func (rs *RedisStateStore) Delete(ctx context.Context, id string) error {
+	return rs.client.Del(ctx, rs.prefix+id).Err()
+}
+
+// Lock claims the upload with SET NX
+func (rs *RedisStateStore) Lock(ctx context.Context, id string, ttl time.Duration) (bool, error) {
+	return rs.client.SetNX(ctx, rs.prefix+id+":lock", "1", ttl).Result()
+}
+
+// Unlock releases the claim
+func (rs *RedisStateStore) Unlock(ctx context.Context, id string) error {
+	return rs.client.Del(ctx, rs.prefix+id+":lock").Err()
+}
@@ -0,0 +1,116 @@
+package uploads
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go-server/internal/clock"
+)
+
+// ErrUploadNotFound is returned for unknown or expired uploads
+var ErrUploadNotFound = errors.New("upload not found")
+
+// State tracks the progress of a resumable upload
+type State struct {
+	ID       string            `json:"id"`
+	UserID   uint              `json:"user_id"`
+	Length   int64             `json:"length"`
+	Offset   int64             `json:"offset"`
+	Parts    int               `json:"parts"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// AttachmentID is set once the parts have been assembled
+	AttachmentID uint      `json:"attachment_id,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// Complete reports whether the upload has been assembled into an attachment
+func (s *State) Complete() bool {
+	return s.AttachmentID != 0
+}
+
+// StateStore persists upload progress so any replica can resume an upload
+type StateStore interface {
+	// Save creates or replaces the state, expiring it at ExpiresAt
+	Save(ctx context.Context, state *State) error
+	// Get returns the state or ErrUploadNotFound
+	Get(ctx context.Context, id string) (*State, error)
+	// Delete removes the state
+	Delete(ctx context.Context, id string) error
+	// Lock claims an upload for a single writer; it returns false if already claimed
+	Lock(ctx context.Context, id string, ttl time.Duration) (bool, error)
+	// Unlock releases a claim
+	Unlock(ctx context.Context, id string) error
+}
+
+// MemoryStateStore is an in-process StateStore for single-node deployments and tests
+type MemoryStateStore struct {
+	mu     sync.Mutex
+	states map[string]State
+	locks  map[string]time.Time
+	clock  clock.Clock
+}
+
+// NewMemoryStateStore creates a new in-memory state store
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{
+		states: make(map[string]State),
+		locks:  make(map[string]time.Time),
+		clock:  clock.New(),
+	}
+}
+
+// WithClock overrides the clock used for expiry
+func (ms *MemoryStateStore) WithClock(c clock.Clock) *MemoryStateStore {
+	ms.clock = clock.OrDefault(c)
+	return ms
+}
+
+// Save stores a copy of the state
+func (ms *MemoryStateStore) Save(ctx context.Context, state *State) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.states[state.ID] = *state
+	return nil
+}
+
+// Get returns a copy of the state unless it has expired
+func (ms *MemoryStateStore) Get(ctx context.Context, id string) (*State, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	state, ok := ms.states[id]
+	if !ok || !ms.clock.Now().Before(state.ExpiresAt) {
+		delete(ms.states, id)
+		return nil, ErrUploadNotFound
+	}
+	return &state, nil
+}
+
+// Delete removes the state
+func (ms *MemoryStateStore) Delete(ctx context.Context, id string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	delete(ms.states, id)
+	return nil
+}
+
+// Lock claims the upload until ttl elapses or Unlock is called
+func (ms *MemoryStateStore) Lock(ctx context.Context, id string, ttl time.Duration) (bool, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	now := ms.clock.Now()
+	if until, ok := ms.locks[id]; ok && now.Before(until) {
+		return false, nil
+	}
+	ms.locks[id] = now.Add(ttl)
+	return true, nil
+}
+
+// Unlock releases the claim
+func (ms *MemoryStateStore) Unlock(ctx context.Context, id string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	delete(ms.locks, id)
+	return nil
+}
@@ -0,0 +1,401 @@
+// Package uploads implements resumable uploads using the tus 1.0 protocol.
+// Each PATCH request is stored as a part object under tmp/, progress is kept
+// in a StateStore (Redis in production) so any replica can continue an upload,
+// and the parts are concatenated into an attachment once all bytes arrive.
+package uploads
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/cryptopolicy"
+	"go-server/internal/database/models"
+	"go-server/internal/idgen"
+	"go-server/internal/logger"
+	"go-server/internal/storage"
+)
+
+// Errors returned by the service; the handler maps them to tus status codes
+var (
+	ErrOffsetMismatch    = errors.New("upload offset does not match")
+	ErrChecksumMismatch  = errors.New("checksum mismatch")
+	ErrUnsupportedHash   = errors.New("unsupported checksum algorithm")
+	ErrTooLarge          = errors.New("upload exceeds the maximum size")
+	ErrExceedsLength     = errors.New("chunk exceeds the declared upload length")
+	ErrUploadLocked      = errors.New("upload is being written by another request")
+	ErrInvalidUploadSize = errors.New("invalid upload length")
+)
+
+// SupportedChecksums lists the Upload-Checksum algorithms
+var SupportedChecksums = []string{"sha1", "sha256", "md5"}
+
+// checksumPolicy names the checksum algorithms as the crypto policy does
+var checksumPolicy = map[string]string{
+	"sha1":   cryptopolicy.SHA1,
+	"sha256": cryptopolicy.SHA256,
+	"md5":    cryptopolicy.MD5,
+}
+
+// allowedChecksums are the SupportedChecksums the crypto policy allows
+func allowedChecksums() []string {
+	var allowed []string
+	for _, name := range SupportedChecksums {
+		if cryptopolicy.Allow(checksumPolicy[name]) == nil {
+			allowed = append(allowed, name)
+		}
+	}
+	return allowed
+}
+
+// lockTTL bounds how long a crashed writer can block an upload
+const lockTTL = 10 * time.Minute
+
+// AttachmentCreator records completed uploads
+type AttachmentCreator interface {
+	CreateAttachment(ctx context.Context, attachment *models.Attachment) error
+}
+
+// Config holds upload limits
+type Config struct {
+	// MaxSize is the largest accepted Upload-Length
+	MaxSize int64
+	// Expiry is how long an incomplete upload may be resumed after its last chunk
+	Expiry time.Duration
+}
+
+// Service manages resumable uploads
+type Service struct {
+	states      StateStore
+	store       storage.Store
+	attachments AttachmentCreator
+	config      Config
+	ids         idgen.TokenSource
+	clock       clock.Clock
+	logger      logger.Logger
+}
+
+// NewService creates a new upload service storing objects in store
+func NewService(states StateStore, store storage.Store, attachments AttachmentCreator, config Config, logger logger.Logger) *Service {
+	if config.MaxSize <= 0 {
+		config.MaxSize = 1 << 30
+	}
+	if config.Expiry <= 0 {
+		config.Expiry = 24 * time.Hour
+	}
+	return &Service{
+		states:      states,
+		store:       store,
+		attachments: attachments,
+		config:      config,
+		ids:         idgen.Default,
+		clock:       clock.New(),
+		logger:      logger,
+	}
+}
+
+// WithClock overrides the clock used for expiry
+func (s *Service) WithClock(c clock.Clock) *Service {
+	s.clock = clock.OrDefault(c)
+	return s
+}
+
+// WithIDs overrides the source of upload IDs
+func (s *Service) WithIDs(ids idgen.TokenSource) *Service {
+	s.ids = ids
+	return s
+}
+
+// MaxSize returns the largest accepted upload
+func (s *Service) MaxSize() int64 {
+	return s.config.MaxSize
+}
+
+// Create starts an upload of length bytes. Empty uploads complete immediately.
+func (s *Service) Create(ctx context.Context, userID uint, length int64, metadata map[string]string) (*State, error) {
+	if length < 0 {
+		return nil, ErrInvalidUploadSize
+	}
+	if length > s.config.MaxSize {
+		return nil, ErrTooLarge
+	}
+	id, err := s.ids.Token(16)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate upload ID: %w", err)
+	}
+
+	state := &State{
+		ID:        id,
+		UserID:    userID,
+		Length:    length,
+		Metadata:  metadata,
+		ExpiresAt: s.clock.Now().Add(s.config.Expiry),
+	}
+	if err := s.states.Save(ctx, state); err != nil {
+		return nil, fmt.Errorf("failed to save upload state: %w", err)
+	}
+	if length == 0 {
+		if err := s.complete(ctx, state); err != nil {
+			return nil, err
+		}
+	}
+	return state, nil
+}
+
+// Get returns an upload owned by userID
+func (s *Service) Get(ctx context.Context, userID uint, id string) (*State, error) {
+	state, err := s.states.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// Other users' uploads are indistinguishable from missing ones
+	if state.UserID != userID {
+		return nil, ErrUploadNotFound
+	}
+	return state, nil
+}
+
+// WriteChunk appends body at offset. A chunk is accepted entirely or not at
+// all; if checksum ("<algorithm> <base64 digest>") is given it must match the
+// chunk. The upload is assembled once the final byte arrives.
+func (s *Service) WriteChunk(ctx context.Context, userID uint, id string, offset int64, body io.Reader, size int64, checksum string) (*State, error) {
+	var hasher hash.Hash
+	var expected []byte
+	if checksum != "" {
+		var err error
+		if hasher, expected, err = parseChecksum(checksum); err != nil {
+			return nil, err
+		}
+	}
+
+	locked, err := s.states.Lock(ctx, id, lockTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock upload: %w", err)
+	}
+	if !locked {
+		return nil, ErrUploadLocked
+	}
+	defer s.states.Unlock(ctx, id)
+
+	state, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if offset != state.Offset {
+		return state, ErrOffsetMismatch
+	}
+	remaining := state.Length - state.Offset
+	if size > remaining {
+		return state, ErrExceedsLength
+	}
+
+	if remaining > 0 {
+		counter := &countingReader{reader: io.LimitReader(body, remaining)}
+		var reader io.Reader = counter
+		if hasher != nil {
+			reader = io.TeeReader(counter, hasher)
+		}
+
+		key := partKey(state.ID, state.Parts)
+		if err := s.store.Put(ctx, key, reader, size, "application/octet-stream"); err != nil {
+			return state, fmt.Errorf("failed to store chunk: %w", err)
+		}
+		// Reject bodies longer than the upload rather than silently truncating them
+		var extra [1]byte
+		if n, _ := body.Read(extra[:]); n > 0 {
+			s.store.Delete(ctx, key)
+			return state, ErrExceedsLength
+		}
+		if hasher != nil && !bytes.Equal(hasher.Sum(nil), expected) {
+			s.store.Delete(ctx, key)
+			return state, ErrChecksumMismatch
+		}
+
+		if counter.n > 0 {
+			state.Offset += counter.n
+			state.Parts++
+		} else {
+			s.store.Delete(ctx, key)
+		}
+	}
+
+	state.ExpiresAt = s.clock.Now().Add(s.config.Expiry)
+	if err := s.states.Save(ctx, state); err != nil {
+		return state, fmt.Errorf("failed to save upload state: %w", err)
+	}
+
+	if state.Offset == state.Length && !state.Complete() {
+		if err := s.complete(ctx, state); err != nil {
+			return state, err
+		}
+	}
+	return state, nil
+}
+
+// Terminate abandons an upload and deletes its parts
+func (s *Service) Terminate(ctx context.Context, userID uint, id string) error {
+	state, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	s.deleteParts(ctx, state)
+	return s.states.Delete(ctx, id)
+}
+
+// complete concatenates the parts into the final object and records the attachment
+func (s *Service) complete(ctx context.Context, state *State) error {
+	key := ObjectKey(state)
+	parts := &partsReader{ctx: ctx, store: s.store, uploadID: state.ID, count: state.Parts}
+	err := s.store.Put(ctx, key, parts, state.Length, state.Metadata["filetype"])
+	parts.Close()
+	if err != nil {
+		return fmt.Errorf("failed to assemble upload: %w", err)
+	}
+
+	attachment := &models.Attachment{
+		UserID:      state.UserID,
+		Bucket:      s.store.Bucket(),
+		ObjectKey:   key,
+		Filename:    Filename(state),
+		ContentType: state.Metadata["filetype"],
+		Size:        state.Length,
+	}
+	if err := s.attachments.CreateAttachment(ctx, attachment); err != nil {
+		s.store.Delete(ctx, key)
+		return fmt.Errorf("failed to record attachment: %w", err)
+	}
+
+	state.AttachmentID = attachment.ID
+	if err := s.states.Save(ctx, state); err != nil {
+		s.logger.Error("Failed to mark upload complete", "upload_id", state.ID, "error", err.Error())
+	}
+	s.deleteParts(ctx, state)
+	s.logger.Info("Upload completed", "upload_id", state.ID, "attachment_id", attachment.ID, "size", state.Length)
+	return nil
+}
+
+func (s *Service) deleteParts(ctx context.Context, state *State) {
+	for i := 0; i < state.Parts; i++ {
+		if err := s.store.Delete(ctx, partKey(state.ID, i)); err != nil {
+			s.logger.Warn("Failed to delete upload part", "upload_id", state.ID, "part", i, "error", err.Error())
+		}
+	}
+}
+
+// partKey places parts under tmp/ so lifecycle expiry removes abandoned uploads
+func partKey(uploadID string, part int) string {
+	return fmt.Sprintf("%suploads/%s/%06d", storage.PrefixTempUploads, uploadID, part)
+}
+
+var unsafeFilenameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Filename returns the client-supplied filename, reduced to safe characters
+func Filename(state *State) string {
+	name := path.Base(strings.ReplaceAll(state.Metadata["filename"], "\\", "/"))
+	name = strings.Trim(unsafeFilenameChars.ReplaceAllString(name, "_"), "._")
+	if name == "" {
+		return "file"
+	}
+	if len(name) > 200 {
+		name = name[len(name)-200:]
+	}
+	return name
+}
+
+// ObjectKey returns the key of the assembled upload
+func ObjectKey(state *State) string {
+	return fmt.Sprintf("%s%d/%s/%s", storage.PrefixAttachments, state.UserID, state.ID, Filename(state))
+}
+
+// parseChecksum parses an Upload-Checksum header value
+func parseChecksum(value string) (hash.Hash, []byte, error) {
+	algorithm, encoded, ok := strings.Cut(strings.TrimSpace(value), " ")
+	if !ok {
+		return nil, nil, ErrUnsupportedHash
+	}
+	digest, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, nil, ErrChecksumMismatch
+	}
+	algorithm = strings.ToLower(algorithm)
+	if cryptopolicy.Allow(checksumPolicy[algorithm]) != nil {
+		return nil, nil, ErrUnsupportedHash
+	}
+	switch algorithm {
+	case "sha1":
+		return sha1.New(), digest, nil
+	case "sha256":
+		return sha256.New(), digest, nil
+	case "md5":
+		return md5.New(), digest, nil
+	}
+	return nil, nil, ErrUnsupportedHash
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	reader io.Reader
+	n      int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.reader.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+// partsReader streams an upload's parts in order, opening each one lazily
+type partsReader struct {
+	ctx      context.Context
+	store    storage.Store
+	uploadID string
+	count    int
+	next     int
+	current  io.ReadCloser
+}
+
+func (pr *partsReader) Read(p []byte) (int, error) {
+	for {
+		if pr.current == nil {
+			if pr.next >= pr.count {
+				return 0, io.EOF
+			}
+			reader, err := pr.store.Open(pr.ctx, partKey(pr.uploadID, pr.next))
+			if err != nil {
+				return 0, fmt.Errorf("failed to open part %d: %w", pr.next, err)
+			}
+			pr.current = reader
+			pr.next++
+		}
+
+		n, err := pr.current.Read(p)
+		if err == io.EOF {
+			pr.current.Close()
+			pr.current = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (pr *partsReader) Close() error {
+	if pr.current != nil {
+		return pr.current.Close()
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package uploads
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-server/internal/database/models"
+	"go-server/internal/logger"
+	"go-server/internal/storage"
+)
+
+type recordingAttachments struct {
+	created []*models.Attachment
+}
+
+func (ra *recordingAttachments) CreateAttachment(ctx context.Context, attachment *models.Attachment) error {
+	attachment.ID = uint(len(ra.created) + 1)
+	ra.created = append(ra.created, attachment)
+	return nil
+}
+
+func newTestHandler(t *testing.T) (*Handler, storage.Store, *recordingAttachments) {
+	store := storage.NewLocalStore(t.TempDir(), "uploads")
+	attachments := &recordingAttachments{}
+	service := NewService(NewMemoryStateStore(), store, attachments, Config{MaxSize: 1024, Expiry: time.Hour}, logger.NewServerLogger())
+	return NewHandler(service, "/api/uploads/", logger.NewServerLogger()), store, attachments
+}
+
+func tusRequest(handler http.Handler, userID uint, method, target string, body string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Tus-Resumable", TusVersion)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	req = req.WithContext(context.WithValue(req.Context(), "user_id", userID))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func chunkHeaders(offset, checksum string) map[string]string {
+	headers := map[string]string{"Content-Type": "application/offset+octet-stream", "Upload-Offset": offset}
+	if checksum != "" {
+		headers["Upload-Checksum"] = checksum
+	}
+	return headers
+}
+
+func sha1Checksum(data string) string {
+	sum := sha1.Sum([]byte(data))
+	return "sha1 " + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func TestHandler_ResumableUpload(t *testing.T) {
+	handler, store, attachments := newTestHandler(t)
+
+	w := tusRequest(handler, 1, http.MethodPost, "/api/uploads/", "", map[string]string{
+		"Upload-Length":   "11",
+		"Upload-Metadata": "filename " + base64.StdEncoding.EncodeToString([]byte("../my photo.txt")) + ",filetype " + base64.StdEncoding.EncodeToString([]byte("text/plain")),
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	location := w.Header().Get("Location")
+
+	if w := tusRequest(handler, 1, http.MethodPatch, location, "hello ", chunkHeaders("0", sha1Checksum("hello "))); w.Code != http.StatusNoContent {
+		t.Fatalf("First chunk failed with %d: %s", w.Code, w.Body.String())
+	}
+
+	// A corrupted chunk is rejected without advancing the offset
+	if w := tusRequest(handler, 1, http.MethodPatch, location, "wxrld", chunkHeaders("6", sha1Checksum("world"))); w.Code != StatusChecksumMismatch {
+		t.Errorf("Expected 460 for a corrupted chunk, got %d", w.Code)
+	}
+	if w := tusRequest(handler, 1, http.MethodPatch, location, "world", chunkHeaders("0", "")); w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for a stale offset, got %d", w.Code)
+	}
+	if w := tusRequest(handler, 2, http.MethodHead, location, "", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for another user's upload, got %d", w.Code)
+	}
+
+	w = tusRequest(handler, 1, http.MethodHead, location, "", nil)
+	if w.Header().Get("Upload-Offset") != "6" || w.Header().Get("Upload-Length") != "11" {
+		t.Errorf("Unexpected progress headers %v", w.Header())
+	}
+
+	w = tusRequest(handler, 1, http.MethodPatch, location, "world", chunkHeaders("6", sha1Checksum("world")))
+	if w.Code != http.StatusNoContent || w.Header().Get("Upload-Attachment-Id") != "1" {
+		t.Fatalf("Final chunk failed with %d: %v", w.Code, w.Header())
+	}
+
+	if len(attachments.created) != 1 {
+		t.Fatalf("Expected one attachment, got %d", len(attachments.created))
+	}
+	attachment := attachments.created[0]
+	if attachment.Filename != "my_photo.txt" || attachment.Size != 11 || attachment.ContentType != "text/plain" {
+		t.Errorf("Unexpected attachment %+v", attachment)
+	}
+	reader, err := store.Open(context.Background(), attachment.ObjectKey)
+	if err != nil {
+		t.Fatalf("Assembled object missing: %v", err)
+	}
+	data, _ := io.ReadAll(reader)
+	reader.Close()
+	if string(data) != "hello world" {
+		t.Errorf("Expected concatenated contents, got %q", data)
+	}
+
+	var parts []string
+	store.List(context.Background(), storage.PrefixTempUploads, func(object storage.Object) error {
+		parts = append(parts, object.Key)
+		return nil
+	})
+	if len(parts) != 0 {
+		t.Errorf("Expected parts to be removed, found %v", parts)
+	}
+}
+
+func TestHandler_RejectsOversizedUploads(t *testing.T) {
+	handler, _, _ := newTestHandler(t)
+
+	if w := tusRequest(handler, 1, http.MethodPost, "/api/uploads/", "", map[string]string{"Upload-Length": "2048"}); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 above the maximum size, got %d", w.Code)
+	}
+
+	w := tusRequest(handler, 1, http.MethodPost, "/api/uploads/", "", map[string]string{"Upload-Length": "3"})
+	location := w.Header().Get("Location")
+	if w := tusRequest(handler, 1, http.MethodPatch, location, "abcd", chunkHeaders("0", "")); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 for a chunk past the upload length, got %d", w.Code)
+	}
+	if w := tusRequest(handler, 1, http.MethodDelete, location, "", nil); w.Code != http.StatusNoContent {
+		t.Errorf("Expected 204 on termination, got %d", w.Code)
+	}
+	if w := tusRequest(handler, 1, http.MethodHead, location, "", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 after termination, got %d", w.Code)
+	}
+}
@@ -0,0 +1,76 @@
+package usage
+
+import (
+	"context"
+	"strings"
+	"unicode"
+)
+
+// attributionKey is the context key of the request's attribution
+type attributionKey struct{}
+
+// attribution is filled in while a sampled request is handled; the
+// middleware reads it afterwards, since values added to the context by
+// inner handlers are not visible to it
+type attribution struct {
+	userID  uint
+	feature string
+}
+
+// SetAccount attributes the request to an account, e.g. once it has been
+// authenticated. It does nothing for requests that are not sampled.
+func SetAccount(ctx context.Context, userID uint) {
+	if a, ok := ctx.Value(attributionKey{}).(*attribution); ok {
+		a.userID = userID
+	}
+}
+
+// SetFeature names the feature a request uses, for handlers serving
+// several features from one route, e.g. by query parameter
+func SetFeature(ctx context.Context, feature string) {
+	if a, ok := ctx.Value(attributionKey{}).(*attribution); ok {
+		a.feature = feature
+	}
+}
+
+// Feature names the feature of a request by method and route, replacing
+// identifier segments so that e.g. GET /api/posts/42 and GET
+// /api/posts/01HV7... are both "GET /api/posts/{id}"
+func Feature(method, path string) string {
+	segments := strings.Split(strings.TrimSuffix(path, "/"), "/")
+	for i, segment := range segments {
+		if isIdentifier(segment) {
+			segments[i] = "{id}"
+		}
+	}
+	route := strings.Join(segments, "/")
+	if route == "" {
+		route = "/"
+	}
+	return method + " " + route
+}
+
+// isIdentifier reports whether a path segment looks like a record
+// identifier: a number, a UUID, a ULID or a long token
+func isIdentifier(segment string) bool {
+	if segment == "" {
+		return false
+	}
+	digits, letters := 0, 0
+	for _, r := range segment {
+		switch {
+		case unicode.IsDigit(r):
+			digits++
+		case unicode.IsLetter(r):
+			letters++
+		case r != '-' && r != '_':
+			return false
+		}
+	}
+	if letters == 0 {
+		return digits > 0
+	}
+	// Words such as "v2" or "feed-link" are route names; identifiers are
+	// long and mix in digits
+	return len(segment) >= 16 && digits > 0
+}
@@ -0,0 +1,213 @@
+// Package usage records which API features each account uses, so
+// deprecation decisions rest on real usage. A sample of requests is
+// counted in memory by day, account and feature and flushed periodically
+// into daily rows, with counts scaled back up by the sample rate.
+package usage
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/models"
+	"go-server/internal/logger"
+)
+
+// OtherFeature collects a day's requests once MaxKeys distinct keys are
+// pending, so a scan of random paths cannot grow the counters without limit
+const OtherFeature = "(other)"
+
+// Store persists the aggregated counts
+type Store interface {
+	AddUsage(ctx context.Context, usage []models.FeatureUsage) error
+}
+
+// Config holds the sampling and flushing settings
+type Config struct {
+	// SampleRate is the fraction of requests counted, between 0 and 1
+	SampleRate    float64
+	FlushInterval time.Duration
+	// MaxKeys bounds the distinct day, account and feature keys held
+	// between flushes, besides the OtherFeature keys
+	MaxKeys int
+}
+
+// key identifies a daily counter
+type key struct {
+	day     time.Time
+	userID  uint
+	feature string
+}
+
+// Recorder counts sampled requests and flushes them to the store
+type Recorder struct {
+	store  Store
+	config Config
+	clock  clock.Clock
+	logger logger.Logger
+	sample func() float64
+
+	mutex  sync.Mutex
+	counts map[key]int64
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewRecorder creates a recorder
+func NewRecorder(store Store, config Config, logger logger.Logger) *Recorder {
+	if config.SampleRate <= 0 || config.SampleRate > 1 {
+		config.SampleRate = 1
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Minute
+	}
+	if config.MaxKeys <= 0 {
+		config.MaxKeys = 10000
+	}
+	return &Recorder{
+		store:  store,
+		config: config,
+		clock:  clock.New(),
+		logger: logger,
+		sample: rand.Float64,
+		counts: make(map[key]int64),
+	}
+}
+
+// WithClock sets the time source that dates the counts
+func (rec *Recorder) WithClock(c clock.Clock) *Recorder {
+	rec.clock = clock.OrDefault(c)
+	return rec
+}
+
+// Sampled decides whether a request is counted
+func (rec *Recorder) Sampled() bool {
+	return rec.config.SampleRate >= 1 || rec.sample() < rec.config.SampleRate
+}
+
+// Record counts one sampled request by an account, zero if anonymous
+func (rec *Recorder) Record(userID uint, feature string) {
+	rec.mutex.Lock()
+	defer rec.mutex.Unlock()
+	rec.add(key{day: day(rec.clock.Now()), userID: userID, feature: feature}, 1)
+}
+
+// add counts samples under k, or under the day's OtherFeature key once
+// MaxKeys keys are pending; the caller holds the mutex
+func (rec *Recorder) add(k key, samples int64) {
+	if _, ok := rec.counts[k]; !ok && len(rec.counts) >= rec.config.MaxKeys {
+		k.userID, k.feature = 0, OtherFeature
+	}
+	rec.counts[k] += samples
+}
+
+// Flush writes the pending counts. If the store fails they are kept for
+// the next flush.
+func (rec *Recorder) Flush(ctx context.Context) error {
+	rec.mutex.Lock()
+	pending := rec.counts
+	rec.counts = make(map[key]int64)
+	rec.mutex.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	now := rec.clock.Now()
+	rows := make([]models.FeatureUsage, 0, len(pending))
+	for k, samples := range pending {
+		rows = append(rows, models.FeatureUsage{
+			Day:       k.day,
+			UserID:    k.userID,
+			Feature:   k.feature,
+			Requests:  int64(math.Round(float64(samples) / rec.config.SampleRate)),
+			Samples:   samples,
+			UpdatedAt: now,
+		})
+	}
+	if err := rec.store.AddUsage(ctx, rows); err != nil {
+		// Restore the pending keys as they were, then merge what was
+		// recorded meanwhile, so no pending key is folded into OtherFeature
+		rec.mutex.Lock()
+		recent := rec.counts
+		rec.counts = pending
+		for k, samples := range recent {
+			rec.add(k, samples)
+		}
+		rec.mutex.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Start flushes the counts every FlushInterval until Stop is called
+func (rec *Recorder) Start(ctx context.Context) {
+	ctx, rec.cancel = context.WithCancel(ctx)
+
+	rec.wg.Add(1)
+	go func() {
+		defer rec.wg.Done()
+
+		ticker := time.NewTicker(rec.config.FlushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := rec.Flush(ctx); err != nil {
+					rec.logger.Error("Failed to flush feature usage: %v", err)
+				}
+			}
+		}
+	}()
+
+	rec.logger.Info("Feature usage recorder started (sample rate %.3f)", rec.config.SampleRate)
+}
+
+// Stop stops the periodic flush and writes what is left
+func (rec *Recorder) Stop() {
+	if rec.cancel != nil {
+		rec.cancel()
+	}
+	rec.wg.Wait()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := rec.Flush(ctx); err != nil {
+		rec.logger.Error("Failed to flush feature usage: %v", err)
+	}
+	rec.logger.Info("Feature usage recorder stopped")
+}
+
+// Middleware counts sampled requests once they have been handled, under
+// the feature and account set with SetFeature and SetAccount, or the
+// normalised route and no account
+func (rec *Recorder) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !rec.Sampled() {
+				next.ServeHTTP(w, r)
+				return
+			}
+			attribution := &attribution{}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), attributionKey{}, attribution)))
+
+			feature := attribution.feature
+			if feature == "" {
+				feature = Feature(r.Method, r.URL.Path)
+			}
+			rec.Record(attribution.userID, feature)
+		})
+	}
+}
+
+// day truncates t to its UTC date
+func day(t time.Time) time.Time {
+	year, month, d := t.UTC().Date()
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
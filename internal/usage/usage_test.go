@@ -0,0 +1,186 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/dbtest"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/logger"
+)
+
+func TestFeature(t *testing.T) {
+	tests := map[string]string{
+		"/api/posts/42":                                   "GET /api/posts/{id}",
+		"/api/posts/01HV7Z3J8K4M2N5P6Q7R8S9T0V/":          "GET /api/posts/{id}",
+		"/api/posts/0190b3c4-5d6e-7f80-9a1b-2c3d4e5f6a7b": "GET /api/posts/{id}",
+		"/api/v2/posts":                                   "GET /api/v2/posts",
+		"/api/calendar/feed-link":                         "GET /api/calendar/feed-link",
+		"/api/admin/users/7/role":                         "GET /api/admin/users/{id}/role",
+		"/":                                               "GET /",
+	}
+	for path, want := range tests {
+		if got := Feature(http.MethodGet, path); got != want {
+			t.Errorf("Feature(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+type testUsage struct {
+	recorder *Recorder
+	repo     *repositories.UsageRepository
+	clock    *clock.Fake
+}
+
+func newTestUsage(t *testing.T, config Config) *testUsage {
+	t.Helper()
+	db := dbtest.Open(t, &models.FeatureUsage{})
+	fake := clock.NewFake(time.Date(2026, 4, 10, 23, 0, 0, 0, time.UTC))
+	repo := repositories.NewUsageRepository(db)
+	return &testUsage{
+		recorder: NewRecorder(repo, config, logger.NewServerLogger()).WithClock(fake),
+		repo:     repo,
+		clock:    fake,
+	}
+}
+
+// serve sends a request through the middleware to a handler that
+// authenticates as userID the way the auth middleware does
+func (tu *testUsage) serve(method, path string, userID uint) {
+	handler := tu.recorder.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if userID != 0 {
+			SetAccount(r.Context(), userID)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, path, nil))
+}
+
+func TestRecorder_AggregatesByDayAccountAndFeature(t *testing.T) {
+	tu := newTestUsage(t, Config{SampleRate: 1})
+	ctx := context.Background()
+
+	tu.serve(http.MethodGet, "/api/v1/posts/1", 7)
+	tu.serve(http.MethodGet, "/api/v1/posts/2", 7)
+	tu.serve(http.MethodGet, "/api/v1/posts/3", 8)
+	tu.serve(http.MethodGet, "/api/v1/posts/4", 0)
+	tu.serve(http.MethodPost, "/api/v2/posts", 7)
+	if err := tu.recorder.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	// The next day's requests and a second flush add to the rows
+	tu.clock.Advance(2 * time.Hour)
+	tu.serve(http.MethodGet, "/api/v1/posts/5", 7)
+	tu.recorder.Flush(ctx)
+
+	day := time.Date(2026, 4, 10, 0, 0, 0, 0, time.UTC)
+	summaries, err := tu.repo.SummarizeFeatures(ctx, day, day.Add(24*time.Hour), "GET /api/v1")
+	if err != nil {
+		t.Fatalf("SummarizeFeatures() error = %v", err)
+	}
+	if len(summaries) != 1 {
+		t.Fatalf("SummarizeFeatures() = %+v, want only the v1 feature", summaries)
+	}
+	got := summaries[0]
+	if got.Feature != "GET /api/v1/posts/{id}" || got.Requests != 5 || got.Accounts != 2 || !got.LastDay.Equal(day.Add(24*time.Hour)) {
+		t.Errorf("Unexpected summary %+v", got)
+	}
+
+	accounts, err := tu.repo.ListFeatureAccounts(ctx, "GET /api/v1/posts/{id}", day, day.Add(24*time.Hour), 10)
+	if err != nil {
+		t.Fatalf("ListFeatureAccounts() error = %v", err)
+	}
+	if len(accounts) != 2 || accounts[0].UserID != 7 || accounts[0].Requests != 3 || accounts[1].UserID != 8 {
+		t.Errorf("Unexpected accounts %+v", accounts)
+	}
+}
+
+func TestRecorder_ScalesSampledCounts(t *testing.T) {
+	tu := newTestUsage(t, Config{SampleRate: 0.25})
+	draws := []float64{0.1, 0.9, 0.2, 0.5}
+	tu.recorder.sample = func() float64 {
+		draw := draws[0]
+		draws = draws[1:]
+		return draw
+	}
+	for i := 0; i < 4; i++ {
+		tu.serve(http.MethodGet, "/api/posts", 1)
+	}
+	tu.recorder.Flush(context.Background())
+
+	day := time.Date(2026, 4, 10, 0, 0, 0, 0, time.UTC)
+	summaries, _ := tu.repo.SummarizeFeatures(context.Background(), day, day, "")
+	if len(summaries) != 1 || summaries[0].Requests != 8 {
+		t.Errorf("Two samples at a quarter rate should estimate 8 requests, got %+v", summaries)
+	}
+}
+
+// failingStore fails every write, after running during if set, as if it
+// were requests recorded while the write was in flight
+type failingStore struct {
+	err    error
+	during func()
+}
+
+func (fs *failingStore) AddUsage(ctx context.Context, usage []models.FeatureUsage) error {
+	if fs.during != nil {
+		fs.during()
+	}
+	return fs.err
+}
+
+func TestRecorder_KeepsCountsWhenFlushFailsAndBoundsKeys(t *testing.T) {
+	store := &failingStore{err: errors.New("database down")}
+	recorder := NewRecorder(store, Config{SampleRate: 1, MaxKeys: 2}, logger.NewServerLogger())
+	recorder.Record(1, "GET /a")
+	recorder.Record(1, "GET /b")
+	recorder.Record(1, "GET /c")
+	recorder.Record(2, "GET /d")
+
+	if err := recorder.Flush(context.Background()); err == nil {
+		t.Fatal("Flush() should report the store error")
+	}
+	if len(recorder.counts) != 3 {
+		t.Fatalf("Pending keys = %d, want MaxKeys plus the overflow key", len(recorder.counts))
+	}
+	var other int64
+	for k, count := range recorder.counts {
+		if k.feature == OtherFeature {
+			other = count
+		}
+	}
+	if other != 2 {
+		t.Errorf("Requests beyond MaxKeys counted as %d under %s, want 2", other, OtherFeature)
+	}
+}
+
+func TestRecorder_KeepsCountsRecordedDuringFailedFlush(t *testing.T) {
+	store := &failingStore{err: errors.New("database down")}
+	recorder := NewRecorder(store, Config{SampleRate: 1, MaxKeys: 2}, logger.NewServerLogger())
+	recorder.Record(1, "GET /a")
+	recorder.Record(1, "GET /b")
+	store.during = func() {
+		recorder.Record(1, "GET /a")
+		recorder.Record(2, "GET /c")
+		recorder.Record(2, "GET /d")
+	}
+
+	if err := recorder.Flush(context.Background()); err == nil {
+		t.Fatal("Flush() should report the store error")
+	}
+	counts := make(map[string]int64)
+	for k, count := range recorder.counts {
+		counts[k.feature] += count
+	}
+	// The keys pending before the flush keep their counts, and the new keys
+	// recorded meanwhile overflow into OtherFeature rather than them
+	if counts["GET /a"] != 2 || counts["GET /b"] != 1 || counts[OtherFeature] != 2 || len(counts) != 3 {
+		t.Errorf("Unexpected counts after a failed flush %v", counts)
+	}
+}
@@ -0,0 +1,202 @@
+// Package userindex keeps a Redis secondary index from emails and usernames
+// to user IDs, so the lookups made on every login and registration resolve
+// by primary key instead of scanning the users table's secondary indexes.
+// Lookups for accounts that do not exist are cached briefly too.
+//
+// The user repository updates the index after its changes commit, and the
+// outbox publisher replays the user events recorded with them, so an
+// update lost to a crash or a Redis outage is applied by the relay.
+package userindex
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/logger"
+	"go-server/internal/outbox"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// keyPrefix namespaces the index in Redis. Values are hashed so the keys
+// do not hold personal data.
+const keyPrefix = "user_index:"
+
+// Cache is the Redis access the index needs, as provided by
+// repositories.CacheRepository
+type Cache interface {
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+	Delete(ctx context.Context, key string) error
+}
+
+// Config holds the index expiry settings
+type Config struct {
+	// TTL bounds how long an entry lives without being refreshed
+	TTL time.Duration
+	// NegativeTTL is how long a lookup for a missing account is cached; keep
+	// it short, since a lookup racing a registration can cache a stale miss
+	NegativeTTL time.Duration
+}
+
+// Stats is a snapshot of the index counters
+type Stats struct {
+	Hits         int64 `json:"hits"`
+	NegativeHits int64 `json:"negative_hits"`
+	Misses       int64 `json:"misses"`
+	Errors       int64 `json:"errors"`
+}
+
+// Index is the Redis secondary index. It implements repositories.UserIndex.
+type Index struct {
+	cache  Cache
+	config Config
+	logger logger.Logger
+
+	hits         atomic.Int64
+	negativeHits atomic.Int64
+	misses       atomic.Int64
+	errors       atomic.Int64
+}
+
+var _ repositories.UserIndex = (*Index)(nil)
+
+// New creates an index
+func New(cache Cache, config Config, logger logger.Logger) *Index {
+	if config.TTL <= 0 {
+		config.TTL = time.Hour
+	}
+	if config.NegativeTTL <= 0 {
+		config.NegativeTTL = 30 * time.Second
+	}
+	return &Index{
+		cache:  cache,
+		config: config,
+		logger: logger,
+	}
+}
+
+// Lookup implements repositories.UserIndex
+func (idx *Index) Lookup(ctx context.Context, field, value string) (uint, bool) {
+	cached, err := idx.cache.Get(ctx, key(field, value))
+	if errors.Is(err, redis.Nil) {
+		idx.misses.Add(1)
+		return 0, false
+	}
+	if err != nil {
+		idx.errors.Add(1)
+		idx.logger.Warn("Failed to read user index: %v", err)
+		return 0, false
+	}
+	id, err := strconv.ParseUint(cached, 10, 64)
+	if err != nil {
+		idx.errors.Add(1)
+		idx.logger.Warn("Ignoring malformed user index entry for %s", field)
+		return 0, false
+	}
+	if id == 0 {
+		idx.negativeHits.Add(1)
+	} else {
+		idx.hits.Add(1)
+	}
+	return uint(id), true
+}
+
+// Store implements repositories.UserIndex
+func (idx *Index) Store(ctx context.Context, field, value string, id uint) {
+	if err := idx.store(ctx, field, value, id); err != nil {
+		idx.errors.Add(1)
+		idx.logger.Warn("Failed to update user index: %v", err)
+	}
+}
+
+// Forget implements repositories.UserIndex
+func (idx *Index) Forget(ctx context.Context, field string, values ...string) {
+	if err := idx.forget(ctx, field, values...); err != nil {
+		idx.errors.Add(1)
+		idx.logger.Warn("Failed to update user index: %v", err)
+	}
+}
+
+// Stats returns a snapshot of the hit, miss and error counters
+func (idx *Index) Stats() Stats {
+	return Stats{
+		Hits:         idx.hits.Load(),
+		NegativeHits: idx.negativeHits.Load(),
+		Misses:       idx.misses.Load(),
+		Errors:       idx.errors.Load(),
+	}
+}
+
+// OutboxPublisher returns a publisher applying user events from the outbox
+// relay to the index. Redis failures are returned so the relay retries.
+func (idx *Index) OutboxPublisher() outbox.Publisher {
+	return outbox.PublisherFunc(func(ctx context.Context, envelope outbox.Envelope) error {
+		switch envelope.Type {
+		case models.EventUserRegistered, models.EventUserUpdated, models.EventUserDeleted:
+		default:
+			return nil
+		}
+
+		var user models.UserEventPayload
+		if err := json.Unmarshal(envelope.Payload, &user); err != nil || user.ID == 0 {
+			idx.logger.Warn("Skipping malformed %s event %d", envelope.Type, envelope.ID)
+			return nil
+		}
+
+		if envelope.Type == models.EventUserDeleted {
+			return errors.Join(
+				idx.forget(ctx, repositories.UserIndexEmail, user.Email),
+				idx.forget(ctx, repositories.UserIndexUsername, user.Username),
+			)
+		}
+		var errs []error
+		if user.PreviousEmail != "" {
+			errs = append(errs, idx.forget(ctx, repositories.UserIndexEmail, user.PreviousEmail))
+		}
+		if user.PreviousUsername != "" {
+			errs = append(errs, idx.forget(ctx, repositories.UserIndexUsername, user.PreviousUsername))
+		}
+		errs = append(errs,
+			idx.store(ctx, repositories.UserIndexEmail, user.Email, user.ID),
+			idx.store(ctx, repositories.UserIndexUsername, user.Username, user.ID),
+		)
+		return errors.Join(errs...)
+	})
+}
+
+func (idx *Index) store(ctx context.Context, field, value string, id uint) error {
+	if value == "" {
+		return nil
+	}
+	ttl := idx.config.TTL
+	if id == 0 {
+		ttl = idx.config.NegativeTTL
+	}
+	return idx.cache.Set(ctx, key(field, value), strconv.FormatUint(uint64(id), 10), ttl)
+}
+
+func (idx *Index) forget(ctx context.Context, field string, values ...string) error {
+	var errs []error
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		errs = append(errs, idx.cache.Delete(ctx, key(field, value)))
+	}
+	return errors.Join(errs...)
+}
+
+// key returns the Redis key of a field's value
+func key(field, value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return keyPrefix + field + ":" + hex.EncodeToString(sum[:])
+}
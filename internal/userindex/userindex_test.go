@@ -0,0 +1,283 @@
+package userindex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-server/internal/database/dbtest"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/logger"
+	"go-server/internal/outbox"
+
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+)
+
+// memoryCache stands in for Redis
+type memoryCache struct {
+	mutex  sync.Mutex
+	values map[string]string
+	ttls   map[string]time.Duration
+	err    error
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{values: make(map[string]string), ttls: make(map[string]time.Duration)}
+}
+
+func (mc *memoryCache) Get(ctx context.Context, key string) (string, error) {
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+	if mc.err != nil {
+		return "", mc.err
+	}
+	value, ok := mc.values[key]
+	if !ok {
+		return "", redis.Nil
+	}
+	return value, nil
+}
+
+func (mc *memoryCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+	if mc.err != nil {
+		return mc.err
+	}
+	mc.values[key] = fmt.Sprint(value)
+	mc.ttls[key] = expiration
+	return nil
+}
+
+func (mc *memoryCache) Delete(ctx context.Context, key string) error {
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+	if mc.err != nil {
+		return mc.err
+	}
+	delete(mc.values, key)
+	return nil
+}
+
+func (mc *memoryCache) entry(field, value string) (string, bool) {
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+	cached, ok := mc.values[key(field, value)]
+	return cached, ok
+}
+
+type testIndex struct {
+	index   *Index
+	cache   *memoryCache
+	users   *repositories.UserRepository
+	outbox  *repositories.OutboxRepository
+	queries *int
+}
+
+func newTestIndex(t *testing.T) *testIndex {
+	t.Helper()
+	db := dbtest.Open(t, &models.User{}, &models.OutboxEvent{})
+
+	// Count the queries by email or username, which the index should spare
+	queries := 0
+	db.Callback().Query().After("gorm:query").Register("count_lookups", func(tx *gorm.DB) {
+		sql := tx.Statement.SQL.String()
+		if strings.Contains(sql, "email = ") || strings.Contains(sql, "username = ") {
+			queries++
+		}
+	})
+
+	cache := newMemoryCache()
+	index := New(cache, Config{TTL: time.Hour, NegativeTTL: 30 * time.Second}, logger.NewServerLogger())
+	return &testIndex{
+		index:   index,
+		cache:   cache,
+		users:   repositories.NewUserRepository(db).WithIndex(index),
+		outbox:  repositories.NewOutboxRepository(db),
+		queries: &queries,
+	}
+}
+
+func (ti *testIndex) register(t *testing.T, email, username string) *models.User {
+	t.Helper()
+	user := &models.User{PublicID: username + "-id", Email: email, Username: username, Password: "x"}
+	if err := ti.users.RegisterUser(context.Background(), user); err != nil {
+		t.Fatalf("RegisterUser failed: %v", err)
+	}
+	return user
+}
+
+func TestIndex_LookupsSkipTheQuery(t *testing.T) {
+	ti := newTestIndex(t)
+	ctx := context.Background()
+	alice := ti.register(t, "alice@example.com", "alice")
+
+	for i := 0; i < 3; i++ {
+		user, err := ti.users.GetUserByEmail(ctx, "alice@example.com")
+		if err != nil || user.ID != alice.ID {
+			t.Fatalf("GetUserByEmail = %v, %v", user, err)
+		}
+		user, err = ti.users.GetUserByUsername(ctx, "alice")
+		if err != nil || user.ID != alice.ID {
+			t.Fatalf("GetUserByUsername = %v, %v", user, err)
+		}
+	}
+	if *ti.queries != 0 {
+		t.Errorf("Registration should index the user, but %d lookups queried the database", *ti.queries)
+	}
+	if stats := ti.index.Stats(); stats.Hits != 6 {
+		t.Errorf("Expected 6 hits, got %+v", stats)
+	}
+	if _, ok := ti.cache.values["user_index:email:alice@example.com"]; ok {
+		t.Error("Keys should not hold the email in clear")
+	}
+}
+
+func TestIndex_NegativeCaching(t *testing.T) {
+	ti := newTestIndex(t)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := ti.users.GetUserByEmail(ctx, "nobody@example.com"); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("Expected ErrRecordNotFound, got %v", err)
+		}
+	}
+	if *ti.queries != 1 {
+		t.Errorf("A missing account should be queried once, got %d queries", *ti.queries)
+	}
+	if ttl := ti.cache.ttls[key(repositories.UserIndexEmail, "nobody@example.com")]; ttl != 30*time.Second {
+		t.Errorf("Negative entry TTL = %s, want 30s", ttl)
+	}
+
+	// Registering replaces the negative entry
+	nobody := ti.register(t, "nobody@example.com", "nobody")
+	user, err := ti.users.GetUserByEmail(ctx, "nobody@example.com")
+	if err != nil || user.ID != nobody.ID {
+		t.Errorf("Registered account should be found, got %v, %v", user, err)
+	}
+}
+
+func TestIndex_UpdatesAndDeletes(t *testing.T) {
+	ti := newTestIndex(t)
+	ctx := context.Background()
+	bob := ti.register(t, "bob@example.com", "bob")
+
+	bob.Email = "robert@example.com"
+	if err := ti.users.UpdateUser(ctx, bob); err != nil {
+		t.Fatalf("UpdateUser failed: %v", err)
+	}
+	if _, ok := ti.cache.entry(repositories.UserIndexEmail, "bob@example.com"); ok {
+		t.Error("The old email should be dropped from the index")
+	}
+	if user, err := ti.users.GetUserByEmail(ctx, "robert@example.com"); err != nil || user.ID != bob.ID {
+		t.Errorf("New email should resolve, got %v, %v", user, err)
+	}
+	if _, err := ti.users.GetUserByEmail(ctx, "bob@example.com"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Old email should no longer resolve, got %v", err)
+	}
+
+	if err := ti.users.DeleteUser(ctx, bob.ID); err != nil {
+		t.Fatalf("DeleteUser failed: %v", err)
+	}
+	if _, err := ti.users.GetUserByUsername(ctx, "bob"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Deleted user should not resolve, got %v", err)
+	}
+	if err := ti.users.RestoreUser(ctx, bob.ID); err != nil {
+		t.Fatalf("RestoreUser failed: %v", err)
+	}
+	if user, err := ti.users.GetUserByUsername(ctx, "bob"); err != nil || user.ID != bob.ID {
+		t.Errorf("Restored user should resolve, got %v, %v", user, err)
+	}
+
+	events, err := ti.outbox.ListPending(ctx, time.Now().Add(time.Minute), 10)
+	if err != nil {
+		t.Fatalf("ListPending failed: %v", err)
+	}
+	var types []string
+	for _, event := range events {
+		types = append(types, event.EventType)
+	}
+	want := []string{models.EventUserRegistered, models.EventUserUpdated, models.EventUserDeleted, models.EventUserUpdated}
+	if strings.Join(types, ",") != strings.Join(want, ",") {
+		t.Errorf("Outbox events = %v, want %v", types, want)
+	}
+}
+
+func TestIndex_StaleEntryFallsBackToTheQuery(t *testing.T) {
+	ti := newTestIndex(t)
+	ctx := context.Background()
+	carol := ti.register(t, "carol@example.com", "carol")
+	dave := ti.register(t, "dave@example.com", "dave")
+
+	// An entry left pointing at the wrong user is corrected by the query
+	ti.index.Store(ctx, repositories.UserIndexEmail, "carol@example.com", dave.ID)
+	user, err := ti.users.GetUserByEmail(ctx, "carol@example.com")
+	if err != nil || user.ID != carol.ID {
+		t.Fatalf("Stale entry should fall back to the query, got %v, %v", user, err)
+	}
+	if cached, _ := ti.cache.entry(repositories.UserIndexEmail, "carol@example.com"); cached != fmt.Sprint(carol.ID) {
+		t.Errorf("Stale entry should be refreshed, got %q", cached)
+	}
+
+	// Redis failures fall back to the database
+	ti.cache.err = errors.New("connection refused")
+	if user, err := ti.users.GetUserByUsername(ctx, "dave"); err != nil || user.ID != dave.ID {
+		t.Errorf("Lookup should survive an index outage, got %v, %v", user, err)
+	}
+	if stats := ti.index.Stats(); stats.Errors == 0 {
+		t.Errorf("Index failures should be counted, got %+v", stats)
+	}
+}
+
+func TestIndex_OutboxPublisher(t *testing.T) {
+	ti := newTestIndex(t)
+	ctx := context.Background()
+	publisher := ti.index.OutboxPublisher()
+	envelope := func(eventType string, payload models.UserEventPayload) outbox.Envelope {
+		event, err := models.NewOutboxEvent(eventType, "user", payload.ID, payload)
+		if err != nil {
+			t.Fatalf("NewOutboxEvent failed: %v", err)
+		}
+		event.ID = 1
+		return outbox.NewEnvelope(event)
+	}
+
+	ti.index.Store(ctx, repositories.UserIndexEmail, "erin@example.com", 0)
+	if err := publisher.Publish(ctx, envelope(models.EventUserRegistered, models.UserEventPayload{ID: 5, Email: "erin@example.com", Username: "erin"})); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if cached, _ := ti.cache.entry(repositories.UserIndexEmail, "erin@example.com"); cached != "5" {
+		t.Errorf("user.registered should replace the negative entry, got %q", cached)
+	}
+
+	if err := publisher.Publish(ctx, envelope(models.EventUserUpdated, models.UserEventPayload{ID: 5, Email: "erin@example.com", Username: "erin2", PreviousUsername: "erin"})); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if _, ok := ti.cache.entry(repositories.UserIndexUsername, "erin"); ok {
+		t.Error("user.updated should drop the previous username")
+	}
+	if cached, _ := ti.cache.entry(repositories.UserIndexUsername, "erin2"); cached != "5" {
+		t.Errorf("user.updated should index the new username, got %q", cached)
+	}
+
+	if err := publisher.Publish(ctx, envelope(models.EventUserDeleted, models.UserEventPayload{ID: 5, Email: "erin@example.com", Username: "erin2"})); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if _, ok := ti.cache.entry(repositories.UserIndexEmail, "erin@example.com"); ok {
+		t.Error("user.deleted should drop the email")
+	}
+
+	ti.cache.err = errors.New("connection refused")
+	if err := publisher.Publish(ctx, envelope(models.EventUserDeleted, models.UserEventPayload{ID: 5, Email: "erin@example.com", Username: "erin2"})); err == nil {
+		t.Error("Redis failures should be returned so the relay retries")
+	}
+	if err := publisher.Publish(ctx, outbox.Envelope{Type: models.EventPostPublished}); err != nil {
+		t.Errorf("Other events should be ignored, got %v", err)
+	}
+}
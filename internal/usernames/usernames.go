@@ -0,0 +1,194 @@
+// Package usernames decides which usernames accounts may take: not names
+// reserved for the service or its URLs, not profanity, and not lookalikes
+// built from homoglyphs, digits or separators to impersonate a reserved
+// name such as admin.
+package usernames
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Reason is why a username was refused
+type Reason string
+
+// Reasons a username is refused
+const (
+	ReasonReserved      Reason = "reserved"
+	ReasonProfanity     Reason = "profanity"
+	ReasonImpersonation Reason = "impersonation"
+)
+
+// Violation is returned for a username the policy refuses
+type Violation struct {
+	Username string
+	Reason   Reason
+	// Match is the reserved name or blocked word the username matched
+	Match string
+}
+
+func (v *Violation) Error() string {
+	switch v.Reason {
+	case ReasonReserved:
+		return fmt.Sprintf("username %q is reserved", v.Username)
+	case ReasonProfanity:
+		return fmt.Sprintf("username %q is not allowed", v.Username)
+	}
+	if v.Match == "" {
+		return fmt.Sprintf("username %q mixes lookalike characters from different scripts", v.Username)
+	}
+	return fmt.Sprintf("username %q is too similar to the reserved name %q", v.Username, v.Match)
+}
+
+// DefaultReserved are names kept for the service and its staff
+var DefaultReserved = []string{
+	"admin", "administrator", "root", "system", "sysadmin", "superuser",
+	"support", "help", "helpdesk", "security", "abuse", "staff", "moderator",
+	"mod", "official", "team", "owner", "postmaster", "webmaster", "hostmaster",
+	"noreply", "no-reply", "mail", "email", "www", "api", "auth", "login",
+	"logout", "register", "signup", "signin", "account", "accounts", "me",
+	"settings", "profile", "users", "user", "docs", "openapi", "graphql",
+	"metrics", "health", "status", "static", "assets", "null", "undefined",
+	"anonymous", "everyone", "here",
+}
+
+// DefaultBlockedWords are refused anywhere in a username, including when
+// spelled with lookalike characters
+var DefaultBlockedWords = []string{
+	"fuck", "shit", "cunt", "bitch", "whore", "slut", "wanker",
+	"dickhead", "asshole", "bastard", "bollocks",
+}
+
+// Policy checks usernames
+type Policy struct {
+	// reserved maps the skeleton of each reserved name to the name
+	reserved map[string]string
+	blocked  []string
+}
+
+// NewPolicy creates a policy refusing reserved names and blocked words
+func NewPolicy(reserved, blocked []string) *Policy {
+	p := &Policy{reserved: make(map[string]string)}
+	p.WithReserved(reserved...)
+	p.WithBlockedWords(blocked...)
+	return p
+}
+
+// Default returns the policy with the default reserved names and words
+func Default() *Policy {
+	return NewPolicy(DefaultReserved, DefaultBlockedWords)
+}
+
+// WithReserved reserves more names
+func (p *Policy) WithReserved(names ...string) *Policy {
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if skeleton := Skeleton(name); skeleton != "" {
+			if _, ok := p.reserved[skeleton]; !ok {
+				p.reserved[skeleton] = name
+			}
+		}
+	}
+	return p
+}
+
+// WithRoutes reserves the first segment of each route path, so no profile
+// URL such as /{username} can shadow a route: "/api/posts" reserves api
+func (p *Policy) WithRoutes(paths ...string) *Policy {
+	for _, path := range paths {
+		segment, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+		if segment != "" && !strings.ContainsAny(segment, "{}:*") && !strings.HasPrefix(segment, ".") {
+			p.WithReserved(segment)
+		}
+	}
+	return p
+}
+
+// WithBlockedWords refuses more words
+func (p *Policy) WithBlockedWords(words ...string) *Policy {
+	for _, word := range words {
+		if skeleton := Skeleton(word); skeleton != "" {
+			p.blocked = append(p.blocked, skeleton)
+		}
+	}
+	return p
+}
+
+// Check returns a *Violation if username may not be taken. Charset and
+// length are left to request validation.
+func (p *Policy) Check(username string) error {
+	lower := strings.ToLower(username)
+	skeleton := Skeleton(username)
+	if name, ok := p.reserved[skeleton]; ok {
+		if lower == name {
+			return &Violation{Username: username, Reason: ReasonReserved, Match: name}
+		}
+		return &Violation{Username: username, Reason: ReasonImpersonation, Match: name}
+	}
+	for _, word := range p.blocked {
+		if strings.Contains(skeleton, word) {
+			return &Violation{Username: username, Reason: ReasonProfanity, Match: word}
+		}
+	}
+	if mixesScripts(username) {
+		return &Violation{Username: username, Reason: ReasonImpersonation}
+	}
+	return nil
+}
+
+// Skeleton reduces a name to the form it is read as: lower case, without
+// accents, separators or width variants, with lookalike letters and digits
+// folded to one Latin letter. Names with the same skeleton look alike.
+func Skeleton(name string) string {
+	var b strings.Builder
+	for _, r := range norm.NFKD.String(strings.ToLower(name)) {
+		if unicode.Is(unicode.Mn, r) || isSeparator(r) {
+			continue
+		}
+		if folded, ok := confusables[r]; ok {
+			r = folded
+		}
+		b.WriteRune(r)
+	}
+	// Letter pairs rendered like one letter
+	return strings.NewReplacer("rn", "m", "vv", "w", "cl", "d").Replace(b.String())
+}
+
+func isSeparator(r rune) bool {
+	return r == '_' || r == '-' || r == '.' || unicode.IsSpace(r)
+}
+
+// confusables folds characters commonly substituted for Latin letters.
+// 1, l, i, ! and | all read as i.
+var confusables = map[rune]rune{
+	// Digits and symbols
+	'0': 'o', '1': 'i', 'l': 'i', '!': 'i', '|': 'i', '3': 'e', '4': 'a',
+	'@': 'a', '5': 's', '$': 's', '7': 't', '8': 'b', '9': 'g',
+	// Cyrillic
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'к': 'k', 'м': 'm', 'н': 'h',
+	'о': 'o', 'р': 'p', 'с': 'c', 'т': 't', 'у': 'y', 'х': 'x', 'ѕ': 's',
+	'і': 'i', 'ї': 'i', 'ј': 'j', 'ԁ': 'd', 'ӏ': 'i', 'ԛ': 'q', 'ԝ': 'w',
+	// Greek
+	'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k', 'ν': 'v',
+	'ο': 'o', 'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x', 'γ': 'y',
+	// Latin lookalikes
+	'ı': 'i', 'ɡ': 'g', 'ʏ': 'y',
+}
+
+// mixesScripts reports whether a name combines letters from Latin with
+// Cyrillic or Greek, the usual homoglyph trick
+func mixesScripts(name string) bool {
+	var latin, other bool
+	for _, r := range name {
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin = true
+		case unicode.Is(unicode.Cyrillic, r), unicode.Is(unicode.Greek, r):
+			other = true
+		}
+	}
+	return latin && other
+}
@@ -0,0 +1,78 @@
+package usernames
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSkeleton(t *testing.T) {
+	tests := map[string]string{
+		"Admin":      "admin",
+		"adm1n":      "admin",
+		"ADM_IN":     "admin",
+		"аdmin":      "admin", // Cyrillic а
+		"àdmîn":      "admin",
+		"ａｄｍｉｎ":      "admin", // fullwidth
+		"rnoderator": "moderator",
+		"r00t":       "root",
+		"he1p":       "heip",
+		"help":       "heip",
+	}
+	for name, want := range tests {
+		if got := Skeleton(name); got != want {
+			t.Errorf("Skeleton(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestPolicy_Check(t *testing.T) {
+	policy := Default().
+		WithRoutes("/api/posts", "/docs", "/explore/{tag}", "/{username}", "/.well-known/jwks.json").
+		WithReserved("Acme").
+		WithBlockedWords("spam")
+
+	tests := []struct {
+		username string
+		reason   Reason
+		match    string
+	}{
+		{"admin", ReasonReserved, "admin"},
+		{"ROOT", ReasonReserved, "root"},
+		{"explore", ReasonReserved, "explore"},
+		{"acme", ReasonReserved, "acme"},
+		{"adm1n", ReasonImpersonation, "admin"},
+		{"sup_port", ReasonImpersonation, "support"},
+		{"аdmin", ReasonImpersonation, "admin"},
+		{"Acme.", ReasonImpersonation, "acme"},
+		{"bigsh1tter", ReasonProfanity, "shit"},
+		{"SpamKing", ReasonProfanity, "spam"},
+		{"pаypal", ReasonImpersonation, ""}, // Cyrillic а among Latin letters
+	}
+	for _, tt := range tests {
+		var violation *Violation
+		if err := policy.Check(tt.username); !errors.As(err, &violation) {
+			t.Errorf("Check(%q) = %v, want a %s violation", tt.username, err, tt.reason)
+			continue
+		}
+		if violation.Reason != tt.reason || violation.Match != tt.match {
+			t.Errorf("Check(%q) = %s/%q, want %s/%q", tt.username, violation.Reason, violation.Match, tt.reason, tt.match)
+		}
+	}
+
+	for _, username := range []string{"alice", "bob_smith", "administrators_fan", "posts", "Дмитрий", "classic"} {
+		if err := policy.Check(username); err != nil {
+			t.Errorf("Check(%q) should pass, got %v", username, err)
+		}
+	}
+}
+
+func TestViolation_Error(t *testing.T) {
+	err := &Violation{Username: "adm1n", Reason: ReasonImpersonation, Match: "admin"}
+	if err.Error() != `username "adm1n" is too similar to the reserved name "admin"` {
+		t.Errorf("Unexpected message %q", err.Error())
+	}
+	profanity := &Violation{Username: "x", Reason: ReasonProfanity, Match: "shit"}
+	if profanity.Error() != `username "x" is not allowed` {
+		t.Errorf("Profanity messages should not echo the word, got %q", profanity.Error())
+	}
+}
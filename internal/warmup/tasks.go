@@ -0,0 +1,88 @@
+package warmup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go-server/internal/database/repositories"
+)
+
+// Built-in tasks
+const (
+	TaskTopPosts    = "top_posts"
+	TaskActiveUsers = "active_users"
+)
+
+// TopPostsListKey is the list cache key holding the most viewed posts
+const TopPostsListKey = "posts:top"
+
+// RegisterBuiltins registers the built-in tasks with the warmer
+func RegisterBuiltins(w *Warmer, repos *repositories.RepositoryManager) {
+	w.Register(TaskTopPosts, TopPosts(repos.Post, repos.Cache, w.config.TopPosts, w.config.TTL))
+	w.Register(TaskActiveUsers, ActiveUsers(repos.User, repos.Cache, w.config.ActiveUsers, w.config.TTL))
+}
+
+// TopPosts caches the most viewed published posts individually and as the
+// TopPostsListKey list
+func TopPosts(postRepo *repositories.PostRepository, cacheRepo *repositories.CacheRepository, limit int, ttl time.Duration) Task {
+	return func(ctx context.Context) (int, error) {
+		posts, err := postRepo.ListTopPosts(ctx, limit)
+		if err != nil {
+			return 0, fmt.Errorf("failed to load posts: %w", err)
+		}
+
+		keys := 0
+		for i := range posts {
+			if err := cacheRepo.SetPostCache(ctx, &posts[i], ttl); err != nil {
+				return keys, fmt.Errorf("failed to cache post %d: %w", posts[i].ID, err)
+			}
+			keys++
+		}
+
+		if err := cacheRepo.SetListCache(ctx, TopPostsListKey, posts, ttl); err != nil {
+			return keys, fmt.Errorf("failed to cache top posts: %w", err)
+		}
+		return keys + 1, nil
+	}
+}
+
+// ActiveUsers caches active user accounts
+func ActiveUsers(userRepo *repositories.UserRepository, cacheRepo *repositories.CacheRepository, limit int, ttl time.Duration) Task {
+	return func(ctx context.Context) (int, error) {
+		users, err := userRepo.GetActiveUsers(ctx, 0, limit)
+		if err != nil {
+			return 0, fmt.Errorf("failed to load users: %w", err)
+		}
+
+		keys := 0
+		for i := range users {
+			if err := cacheRepo.SetUserCache(ctx, &users[i], ttl); err != nil {
+				return keys, fmt.Errorf("failed to cache user %d: %w", users[i].ID, err)
+			}
+			keys++
+		}
+		return keys, nil
+	}
+}
+
+// Snapshot caches the value returned by load under key as JSON. It suits
+// values the server assembles itself, such as the public configuration or
+// the feature flags clients fetch on startup.
+func Snapshot(cacheRepo *repositories.CacheRepository, key string, ttl time.Duration, load func(ctx context.Context) (interface{}, error)) Task {
+	return func(ctx context.Context) (int, error) {
+		value, err := load(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to load %s: %w", key, err)
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode %s: %w", key, err)
+		}
+		if err := cacheRepo.Set(ctx, key, data, ttl); err != nil {
+			return 0, fmt.Errorf("failed to cache %s: %w", key, err)
+		}
+		return 1, nil
+	}
+}
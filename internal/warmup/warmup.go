@@ -0,0 +1,238 @@
+// Package warmup pre-populates hot cache keys when the server starts, so
+// the first requests after a deploy do not all miss an empty Redis at once.
+//
+// The readiness probe reports not ready until the warmup has finished.
+// With a Locker, servers starting together warm Redis once: the one that
+// takes the lock warms it and the others become ready straight away.
+// Warming is best effort: tasks run concurrently within a time budget, and
+// when the budget runs out the server becomes ready with whatever was
+// cached by then. A failed task is logged and does not hold readiness back.
+package warmup
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go-server/internal/database/repositories"
+	"go-server/internal/logger"
+)
+
+// LockName is the distributed lock a warmup runs under
+const LockName = "cache:warmup"
+
+// Locker lets one server at a time warm the cache;
+// *repositories.CacheRepository satisfies it
+type Locker interface {
+	// WithLock fails with repositories.ErrLockHeld while another server
+	// holds the lock
+	WithLock(ctx context.Context, name string, ttl time.Duration, fn func(ctx context.Context, token int64) error) error
+}
+
+// Task warms part of the cache and returns how many keys it wrote
+type Task func(ctx context.Context) (int, error)
+
+// Config holds warmup configuration
+type Config struct {
+	// Skip marks the server ready without warming, e.g. for local
+	// development or when Redis is known to be warm
+	Skip bool
+	// Budget bounds how long warming may delay readiness
+	Budget time.Duration
+	// TopPosts and ActiveUsers are how many posts and users the built-in
+	// tasks cache, and TTL is how long the entries live
+	TopPosts    int
+	ActiveUsers int
+	TTL         time.Duration
+}
+
+// TaskResult is the outcome of one warmup task
+type TaskResult struct {
+	Name     string        `json:"name"`
+	Keys     int           `json:"keys"`
+	Duration time.Duration `json:"duration_ns"`
+	Error    string        `json:"error,omitempty"`
+	// TimedOut means the budget ran out before the task finished
+	TimedOut bool `json:"timed_out,omitempty"`
+}
+
+// Report summarises a warmup
+type Report struct {
+	Skipped  bool          `json:"skipped,omitempty"`
+	TimedOut bool          `json:"timed_out,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+	Tasks    []TaskResult  `json:"tasks"`
+	// Elsewhere means the warmup was skipped as another server was warming
+	// the cache
+	Elsewhere bool `json:"elsewhere,omitempty"`
+}
+
+type namedTask struct {
+	name string
+	task Task
+}
+
+// Warmer runs the registered tasks once at startup and tracks readiness
+type Warmer struct {
+	config Config
+	logger logger.Logger
+	tasks  []namedTask
+	locker Locker
+
+	ready  atomic.Bool
+	mutex  sync.RWMutex
+	report Report
+}
+
+// NewWarmer creates a new warmer
+func NewWarmer(config Config, logger logger.Logger) *Warmer {
+	if config.Budget <= 0 {
+		config.Budget = 15 * time.Second
+	}
+	if config.TopPosts <= 0 {
+		config.TopPosts = 50
+	}
+	if config.ActiveUsers <= 0 {
+		config.ActiveUsers = 100
+	}
+	if config.TTL <= 0 {
+		config.TTL = 30 * time.Minute
+	}
+	return &Warmer{config: config, logger: logger}
+}
+
+// WithLocker makes Run skip warming while another server is warming
+func (w *Warmer) WithLocker(locker Locker) *Warmer {
+	w.locker = locker
+	return w
+}
+
+// Register adds a task to run during warmup. Tasks must be registered
+// before Run.
+func (w *Warmer) Register(name string, task Task) {
+	w.tasks = append(w.tasks, namedTask{name: name, task: task})
+}
+
+// Ready reports whether the warmup has finished, been skipped or run out
+// of budget
+func (w *Warmer) Ready() bool {
+	return w.ready.Load()
+}
+
+// Report returns the outcome of the warmup, if it has finished
+func (w *Warmer) Report() (Report, bool) {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+	return w.report, w.Ready()
+}
+
+// Run warms the cache within the budget and then marks the server ready.
+// Tasks still running when the budget runs out have their context
+// cancelled and are reported as timed out.
+func (w *Warmer) Run(ctx context.Context) Report {
+	if w.config.Skip {
+		w.logger.Info("Cache warmup skipped")
+		return w.finish(Report{Skipped: true, Tasks: []TaskResult{}})
+	}
+	if w.locker == nil {
+		return w.finish(w.warm(ctx))
+	}
+
+	var report Report
+	ran := false
+	err := w.locker.WithLock(ctx, LockName, w.config.Budget, func(ctx context.Context, _ int64) error {
+		report, ran = w.warm(ctx), true
+		return nil
+	})
+	switch {
+	case errors.Is(err, repositories.ErrLockHeld):
+		w.logger.Info("Cache warmup skipped: another server is warming the cache")
+		return w.finish(Report{Skipped: true, Elsewhere: true, Tasks: []TaskResult{}})
+	case err != nil && !ran:
+		w.logger.Warn("Warming the cache without the warmup lock: %v", err)
+		report = w.warm(ctx)
+	}
+	return w.finish(report)
+}
+
+// warm runs the tasks within the budget
+func (w *Warmer) warm(ctx context.Context) Report {
+	started := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, w.config.Budget)
+	defer cancel()
+
+	var mutex sync.Mutex
+	results := make([]TaskResult, len(w.tasks))
+	finished := make([]bool, len(w.tasks))
+	for i, t := range w.tasks {
+		results[i] = TaskResult{Name: t.name}
+	}
+
+	var wg sync.WaitGroup
+	for i, t := range w.tasks {
+		wg.Add(1)
+		go func(i int, t namedTask) {
+			defer wg.Done()
+			taskStarted := time.Now()
+			keys, err := t.task(ctx)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			results[i].Keys = keys
+			results[i].Duration = time.Since(taskStarted)
+			if err != nil {
+				results[i].Error = err.Error()
+			}
+			finished[i] = true
+		}(i, t)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	report := Report{}
+	select {
+	case <-done:
+	case <-ctx.Done():
+		report.TimedOut = true
+	}
+
+	mutex.Lock()
+	report.Tasks = make([]TaskResult, len(results))
+	for i := range results {
+		report.Tasks[i] = results[i]
+		if !finished[i] {
+			report.Tasks[i].TimedOut = true
+			report.Tasks[i].Duration = time.Since(started)
+		}
+	}
+	mutex.Unlock()
+	report.Duration = time.Since(started)
+
+	keys := 0
+	for _, result := range report.Tasks {
+		keys += result.Keys
+		switch {
+		case result.TimedOut:
+			w.logger.Warn("Cache warmup task %s did not finish within the budget", result.Name)
+		case result.Error != "":
+			w.logger.Warn("Cache warmup task %s failed: %s", result.Name, result.Error)
+		}
+	}
+	w.logger.Info("Cache warmup finished in %s: %d keys from %d tasks", report.Duration.Round(time.Millisecond), keys, len(report.Tasks))
+	return report
+}
+
+// finish records the report and flips readiness
+func (w *Warmer) finish(report Report) Report {
+	w.mutex.Lock()
+	w.report = report
+	w.mutex.Unlock()
+	w.ready.Store(true)
+	return report
+}
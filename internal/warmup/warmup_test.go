@@ -0,0 +1,124 @@
+package warmup
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-server/internal/database/repositories"
+	"go-server/internal/logger"
+)
+
+func TestWarmer_Run(t *testing.T) {
+	w := NewWarmer(Config{Budget: time.Second}, logger.NewServerLogger())
+	w.Register("posts", func(ctx context.Context) (int, error) { return 3, nil })
+	w.Register("flags", func(ctx context.Context) (int, error) { return 0, errors.New("redis down") })
+
+	if w.Ready() {
+		t.Fatal("Warmer should not be ready before it runs")
+	}
+	if _, ready := w.Report(); ready {
+		t.Fatal("Report should not be available before the warmup")
+	}
+
+	report := w.Run(context.Background())
+	if !w.Ready() {
+		t.Error("A failed task should not hold readiness back")
+	}
+	if report.TimedOut || report.Skipped || len(report.Tasks) != 2 {
+		t.Fatalf("Report = %+v", report)
+	}
+	if report.Tasks[0].Name != "posts" || report.Tasks[0].Keys != 3 || report.Tasks[0].Error != "" {
+		t.Errorf("Posts result = %+v", report.Tasks[0])
+	}
+	if report.Tasks[1].Error != "redis down" {
+		t.Errorf("Flags result = %+v", report.Tasks[1])
+	}
+	if stored, ready := w.Report(); !ready || len(stored.Tasks) != 2 {
+		t.Errorf("Stored report = %+v, %v", stored, ready)
+	}
+}
+
+func TestWarmer_Budget(t *testing.T) {
+	w := NewWarmer(Config{Budget: 20 * time.Millisecond}, logger.NewServerLogger())
+	w.Register("fast", func(ctx context.Context) (int, error) { return 1, nil })
+	w.Register("slow", func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	stuck := make(chan struct{})
+	defer close(stuck)
+	w.Register("stuck", func(ctx context.Context) (int, error) {
+		// Ignores cancellation; the warmer must not wait for it
+		<-stuck
+		return 0, nil
+	})
+
+	started := time.Now()
+	report := w.Run(context.Background())
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Fatalf("Run took %s, longer than the budget", elapsed)
+	}
+	if !report.TimedOut || !w.Ready() {
+		t.Fatalf("Expected a timed out warmup that still flips readiness, got %+v", report)
+	}
+	if report.Tasks[0].TimedOut || report.Tasks[0].Keys != 1 {
+		t.Errorf("Fast task = %+v", report.Tasks[0])
+	}
+	if !report.Tasks[2].TimedOut {
+		t.Errorf("Stuck task should be reported as timed out, got %+v", report.Tasks[2])
+	}
+}
+
+func TestWarmer_Skip(t *testing.T) {
+	w := NewWarmer(Config{Skip: true}, logger.NewServerLogger())
+	ran := false
+	w.Register("posts", func(ctx context.Context) (int, error) {
+		ran = true
+		return 1, nil
+	})
+
+	report := w.Run(context.Background())
+	if ran || !report.Skipped || !w.Ready() {
+		t.Errorf("Skipped warmup should be ready without running tasks, got %+v", report)
+	}
+}
+
+// fakeLocker holds the warmup lock for another server, or runs fn
+type fakeLocker struct {
+	held  bool
+	names []string
+}
+
+func (l *fakeLocker) WithLock(ctx context.Context, name string, ttl time.Duration, fn func(ctx context.Context, token int64) error) error {
+	l.names = append(l.names, name)
+	if l.held {
+		return repositories.ErrLockHeld
+	}
+	return fn(ctx, 1)
+}
+
+func TestWarmer_Locker(t *testing.T) {
+	locker := &fakeLocker{held: true}
+	w := NewWarmer(Config{Budget: time.Second}, logger.NewServerLogger()).WithLocker(locker)
+	runs := 0
+	w.Register("posts", func(ctx context.Context) (int, error) {
+		runs++
+		return 1, nil
+	})
+
+	report := w.Run(context.Background())
+	if runs != 0 || !report.Skipped || !report.Elsewhere || !w.Ready() {
+		t.Errorf("Expected the warmup left to the lock holder, got %+v", report)
+	}
+
+	locker.held = false
+	report = w.Run(context.Background())
+	if runs != 1 || report.Skipped || len(report.Tasks) != 1 {
+		t.Errorf("Expected the lock holder to warm the cache, got %+v", report)
+	}
+	if len(locker.names) != 2 || locker.names[0] != LockName {
+		t.Errorf("Unexpected locks taken %v", locker.names)
+	}
+}
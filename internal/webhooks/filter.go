@@ -0,0 +1,369 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go-server/internal/outbox"
+)
+
+// Filter limits
+const (
+	maxFilterLength     = 1024
+	maxFilterConditions = 16
+	maxFilterValues     = 100
+	maxFields           = 32
+)
+
+// Filter is a parsed webhook filter expression: conditions joined by "and"
+// that an event's payload must all meet, e.g.
+//
+//	author_id in [12, 40] and status == "published"
+//	reason != "account_deactivated"
+//
+// A condition compares a payload field, with dots for nested objects, to a
+// JSON string, number or boolean with == or !=, or to a [list] of them with
+// in or not in. A field missing from the payload fails == and in and meets
+// != and not in.
+type Filter struct {
+	expression string
+	conditions []condition
+}
+
+// condition compares one payload field
+type condition struct {
+	path   []string
+	negate bool
+	values []any
+}
+
+// ParseFilter parses a filter expression; an empty expression returns a
+// nil Filter, which matches every event
+func ParseFilter(expression string) (*Filter, error) {
+	expression = strings.TrimSpace(expression)
+	if expression == "" {
+		return nil, nil
+	}
+	if len(expression) > maxFilterLength {
+		return nil, fmt.Errorf("filter is longer than %d characters", maxFilterLength)
+	}
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &filterParser{tokens: tokens}
+	filter := &Filter{expression: expression}
+	for {
+		cond, err := p.condition()
+		if err != nil {
+			return nil, err
+		}
+		filter.conditions = append(filter.conditions, cond)
+		if len(filter.conditions) > maxFilterConditions {
+			return nil, fmt.Errorf("filter has more than %d conditions", maxFilterConditions)
+		}
+		if p.done() {
+			return filter, nil
+		}
+		if !p.keyword("and") {
+			return nil, fmt.Errorf("expected \"and\" at %q", p.peek().text)
+		}
+	}
+}
+
+// String returns the filter's expression
+func (f *Filter) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.expression
+}
+
+// Match reports whether payload, a JSON object, meets every condition
+func (f *Filter) Match(payload json.RawMessage) bool {
+	if f == nil {
+		return true
+	}
+	var document any
+	if err := json.Unmarshal(payload, &document); err != nil {
+		return false
+	}
+	for _, cond := range f.conditions {
+		if !cond.match(document) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c condition) match(document any) bool {
+	value, found := lookup(document, c.path)
+	matched := false
+	if found {
+		for _, candidate := range c.values {
+			if value == candidate {
+				matched = true
+				break
+			}
+		}
+	}
+	return matched != c.negate
+}
+
+// lookup returns the value at path in a decoded JSON document
+func lookup(document any, path []string) (any, bool) {
+	for _, name := range path {
+		object, ok := document.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if document, ok = object[name]; !ok {
+			return nil, false
+		}
+	}
+	return document, true
+}
+
+// token is a lexical token of a filter expression
+type token struct {
+	kind  tokenKind
+	text  string
+	value any // Of literals
+}
+
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenWord
+	tokenLiteral
+	tokenSymbol
+)
+
+// tokenize splits a filter expression into words, literals and the
+// symbols == != [ ] ,
+func tokenize(expression string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(expression); {
+		c := rune(expression[i])
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(expression) && expression[end] != '"' {
+				if expression[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(expression) {
+				return nil, fmt.Errorf("unterminated string in filter")
+			}
+			var value string
+			if err := json.Unmarshal([]byte(expression[i:end+1]), &value); err != nil {
+				return nil, fmt.Errorf("invalid string %s in filter", expression[i:end+1])
+			}
+			tokens = append(tokens, token{kind: tokenLiteral, text: expression[i : end+1], value: value})
+			i = end + 1
+		case c == '-' || (c >= '0' && c <= '9'):
+			end := i + 1
+			for end < len(expression) && strings.ContainsRune("0123456789.eE+-", rune(expression[end])) {
+				end++
+			}
+			var value float64
+			if err := json.Unmarshal([]byte(expression[i:end]), &value); err != nil {
+				return nil, fmt.Errorf("invalid number %s in filter", expression[i:end])
+			}
+			tokens = append(tokens, token{kind: tokenLiteral, text: expression[i:end], value: value})
+			i = end
+		case c == '_' || isLetter(c):
+			end := i + 1
+			for end < len(expression) && isWordChar(rune(expression[end])) {
+				end++
+			}
+			word := expression[i:end]
+			switch word {
+			case "true", "false":
+				tokens = append(tokens, token{kind: tokenLiteral, text: word, value: word == "true"})
+			default:
+				tokens = append(tokens, token{kind: tokenWord, text: word})
+			}
+			i = end
+		case strings.HasPrefix(expression[i:], "==") || strings.HasPrefix(expression[i:], "!="):
+			tokens = append(tokens, token{kind: tokenSymbol, text: expression[i : i+2]})
+			i += 2
+		case strings.ContainsRune("[],", c):
+			tokens = append(tokens, token{kind: tokenSymbol, text: string(c)})
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected %q in filter", c)
+		}
+	}
+	return tokens, nil
+}
+
+func isLetter(c rune) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isWordChar(c rune) bool {
+	return c == '_' || c == '.' || isLetter(c) || (c >= '0' && c <= '9')
+}
+
+// filterParser reads conditions from tokens
+type filterParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *filterParser) peek() token {
+	if p.pos >= len(p.tokens) {
+		return token{kind: tokenEnd, text: "end of filter"}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() token {
+	t := p.peek()
+	if t.kind != tokenEnd {
+		p.pos++
+	}
+	return t
+}
+
+func (p *filterParser) done() bool {
+	return p.peek().kind == tokenEnd
+}
+
+// keyword consumes the word if it is next
+func (p *filterParser) keyword(word string) bool {
+	if t := p.peek(); t.kind == tokenWord && t.text == word {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// symbol consumes the symbol if it is next
+func (p *filterParser) symbol(symbol string) bool {
+	if t := p.peek(); t.kind == tokenSymbol && t.text == symbol {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// condition parses "field op value"
+func (p *filterParser) condition() (condition, error) {
+	field := p.next()
+	if field.kind != tokenWord || isKeyword(field.text) {
+		return condition{}, fmt.Errorf("expected a field name at %q", field.text)
+	}
+	path := strings.Split(field.text, ".")
+	for _, name := range path {
+		if name == "" {
+			return condition{}, fmt.Errorf("invalid field name %q", field.text)
+		}
+	}
+
+	cond := condition{path: path}
+	switch {
+	case p.symbol("=="):
+	case p.symbol("!="):
+		cond.negate = true
+	case p.keyword("in"):
+		err := p.list(&cond)
+		return cond, err
+	case p.keyword("not"):
+		if !p.keyword("in") {
+			return condition{}, fmt.Errorf("expected \"in\" after \"not\" at %q", p.peek().text)
+		}
+		cond.negate = true
+		err := p.list(&cond)
+		return cond, err
+	default:
+		return condition{}, fmt.Errorf("expected ==, !=, in or not in after %s", field.text)
+	}
+	value, err := p.literal()
+	if err != nil {
+		return condition{}, err
+	}
+	cond.values = []any{value}
+	return cond, nil
+}
+
+// list parses "[value, ...]" into cond's values
+func (p *filterParser) list(cond *condition) error {
+	if !p.symbol("[") {
+		return fmt.Errorf("expected [ at %q", p.peek().text)
+	}
+	for {
+		value, err := p.literal()
+		if err != nil {
+			return err
+		}
+		cond.values = append(cond.values, value)
+		if len(cond.values) > maxFilterValues {
+			return fmt.Errorf("filter list has more than %d values", maxFilterValues)
+		}
+		if p.symbol("]") {
+			return nil
+		}
+		if !p.symbol(",") {
+			return fmt.Errorf("expected , or ] at %q", p.peek().text)
+		}
+	}
+}
+
+func (p *filterParser) literal() (any, error) {
+	t := p.next()
+	if t.kind != tokenLiteral {
+		return nil, fmt.Errorf("expected a string, number or boolean at %q", t.text)
+	}
+	return t.value, nil
+}
+
+func isKeyword(word string) bool {
+	return word == "and" || word == "in" || word == "not"
+}
+
+// ValidateFields checks payload field names for a webhook's field
+// selection
+func ValidateFields(fields []string) error {
+	if len(fields) > maxFields {
+		return fmt.Errorf("at most %d fields may be selected", maxFields)
+	}
+	for _, field := range fields {
+		if field == "" || strings.ContainsFunc(field, func(c rune) bool { return !isWordChar(c) || c == '.' }) {
+			return fmt.Errorf("invalid field name %q", field)
+		}
+	}
+	return nil
+}
+
+// selectFields keeps only the named top-level fields of an event's
+// payload. A payload that is not a JSON object is returned unchanged.
+func selectFields(envelope outbox.Envelope, fields []string) outbox.Envelope {
+	if len(fields) == 0 {
+		return envelope
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(envelope.Payload, &object); err != nil {
+		return envelope
+	}
+	selected := make(map[string]json.RawMessage, len(fields))
+	for _, field := range fields {
+		if value, ok := object[field]; ok {
+			selected[field] = value
+		}
+	}
+	payload, err := json.Marshal(selected)
+	if err != nil {
+		return envelope
+	}
+	envelope.Payload = payload
+	return envelope
+}
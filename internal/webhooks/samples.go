@@ -0,0 +1,66 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go-server/internal/database/models"
+	"go-server/internal/outbox"
+)
+
+// samplePublishedAt is when the sample post was published
+var samplePublishedAt = time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC)
+
+// samplePost is the payload of sample post events
+var samplePost = models.PostEventPayload{
+	ID:          1,
+	PublicID:    "pst_sample",
+	Slug:        "hello-world",
+	Title:       "Hello, world",
+	AuthorID:    1,
+	Status:      "published",
+	PublishedAt: &samplePublishedAt,
+}
+
+// samples holds the aggregate and payload of each supported event's sample
+var samples = map[string]struct {
+	aggregate string
+	payload   any
+}{
+	models.EventUserRegistered: {"user", map[string]any{
+		"id": 1, "public_id": "usr_sample", "email": "ada@example.com", "username": "ada",
+	}},
+	models.EventPostPublished: {"post", samplePost},
+	models.EventPostUpdated:   {"post", samplePost},
+	models.EventPostDeleted:   {"post", samplePost},
+	models.EventLoginFailed: {"user", models.LoginFailedPayload{
+		UserID:    1,
+		PublicID:  "usr_sample",
+		Email:     "ada@example.com",
+		Reason:    models.LoginFailedPassword,
+		IPAddress: "203.0.113.7",
+		UserAgent: "Mozilla/5.0",
+	}},
+}
+
+// SampleEnvelope builds a sample event of a supported type, occurring at
+// now, for test deliveries. Its ID is 0, which no real event has.
+func SampleEnvelope(eventType string, now time.Time) (outbox.Envelope, error) {
+	sample, ok := samples[eventType]
+	if !ok {
+		return outbox.Envelope{}, fmt.Errorf("no sample for event %q", eventType)
+	}
+	payload, err := json.Marshal(sample.payload)
+	if err != nil {
+		return outbox.Envelope{}, fmt.Errorf("failed to encode sample %s: %w", eventType, err)
+	}
+	return outbox.Envelope{
+		Type:          eventType,
+		AggregateType: sample.aggregate,
+		AggregateID:   "1",
+		OccurredAt:    now,
+		SchemaVersion: 1,
+		Payload:       payload,
+	}, nil
+}
@@ -0,0 +1,30 @@
+package webhooks
+
+import (
+	"fmt"
+	"time"
+
+	"go-server/pkg/webhookverify"
+)
+
+// Headers sent with every delivery
+const (
+	HeaderSignature = webhookverify.HeaderSignature
+	HeaderEvent     = webhookverify.HeaderEvent
+	HeaderEventID   = webhookverify.HeaderEventID
+	HeaderDelivery  = webhookverify.HeaderDelivery
+)
+
+// Sign returns the signature header value for a payload: "t=<unix>,v1=<hex hmac>",
+// where the HMAC-SHA256 covers "<unix>.<body>" so timestamps cannot be replayed
+// with a different body. Receivers verify it with package webhookverify.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	ts := timestamp.Unix()
+	return fmt.Sprintf("t=%d,v1=%s", ts, webhookverify.Signature(secret, ts, body))
+}
+
+// Verify checks a signature header against the payload, rejecting timestamps
+// further than tolerance from now
+func Verify(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	return webhookverify.Verify(secret, header, body, tolerance, now)
+}
@@ -0,0 +1,53 @@
+package webhooks
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrBlockedAddress is returned for deliveries to an address the server
+// itself would be reaching, such as loopback or a private network
+var ErrBlockedAddress = errors.New("webhook destination address is not allowed")
+
+// newTransport returns a transport that refuses to connect to loopback,
+// private, link-local and unspecified addresses. The check runs on the
+// resolved address of every connection, so neither a public name resolving
+// inward nor a redirect reaches internal services. Proxies from the
+// environment are not used, as they would connect on the server's behalf.
+func newTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   refuseBlockedAddress,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+// refuseBlockedAddress is a net.Dialer control rejecting blocked addresses
+// after DNS resolution
+func refuseBlockedAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, address)
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || blockedAddress(addr) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, host)
+	}
+	return nil
+}
+
+// blockedAddress reports whether deliveries to addr are refused
+func blockedAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast()
+}
@@ -0,0 +1,277 @@
+// Package webhooks delivers domain events to integrator-registered callback
+// URLs. Events arrive from the outbox relay, fan out to one background job
+// per subscribed webhook, and are POSTed with an HMAC signature. Failed
+// deliveries are retried with the job pool's exponential backoff and every
+// attempt is recorded in the delivery log. Deliveries never connect to
+// loopback, private or link-local addresses, so a callback URL cannot be
+// used to reach services inside the network.
+//
+// A webhook may belong to an organization, in which case it only receives
+// events about the organization's members. Each webhook may also narrow
+// its events with a Filter and its payloads to selected fields.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/idgen"
+	"go-server/internal/jobs"
+	"go-server/internal/logger"
+	"go-server/internal/outbox"
+)
+
+// JobTypeDeliver is the job type for a single webhook delivery
+const JobTypeDeliver = "webhooks.deliver"
+
+// maxResponseBody is how much of a receiver's response is kept in the delivery log
+const maxResponseBody = 1024
+
+// SupportedEvents lists the event types integrators may subscribe to
+var SupportedEvents = []string{
+	models.WebhookAllEvents,
+	models.EventUserRegistered,
+	models.EventPostPublished,
+	models.EventPostUpdated,
+	models.EventPostDeleted,
+	models.EventLoginFailed,
+}
+
+// IsSupportedEvent checks if integrators may subscribe to the event type
+func IsSupportedEvent(eventType string) bool {
+	for _, supported := range SupportedEvents {
+		if supported == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// DeliveryJob is the payload of a delivery job
+type DeliveryJob struct {
+	WebhookID uint            `json:"webhook_id"`
+	Envelope  outbox.Envelope `json:"envelope"`
+}
+
+// Dispatcher fans events out to subscribed webhooks. It implements
+// outbox.Publisher so it can be attached to the outbox relay.
+type Dispatcher struct {
+	repo   *repositories.WebhookRepository
+	pool   *jobs.Pool
+	logger logger.Logger
+}
+
+// NewDispatcher creates a new webhook dispatcher
+func NewDispatcher(repo *repositories.WebhookRepository, pool *jobs.Pool, logger logger.Logger) *Dispatcher {
+	return &Dispatcher{
+		repo:   repo,
+		pool:   pool,
+		logger: logger,
+	}
+}
+
+// Publish enqueues a delivery for every webhook subscribed to the event
+// whose filter it matches
+func (d *Dispatcher) Publish(ctx context.Context, envelope outbox.Envelope) error {
+	subscribers, err := d.repo.ListSubscribers(ctx, envelope.Type, subjectUserID(envelope))
+	if err != nil {
+		return fmt.Errorf("failed to load webhook subscribers: %w", err)
+	}
+
+	for _, webhook := range subscribers {
+		filter, err := ParseFilter(webhook.Filter)
+		if err != nil {
+			// Filters are validated when they are set, so this one was
+			// stored by an older build; it matches nothing rather than all
+			d.logger.Warn("Skipping webhook with an invalid filter", "webhook_id", webhook.ID, "error", err.Error())
+			continue
+		}
+		if !filter.Match(envelope.Payload) {
+			continue
+		}
+		if err := d.enqueue(ctx, webhook.ID, envelope); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// subjectUserID returns the user an event is about, whose organizations'
+// webhooks receive it: the user of user and auth events and the author of
+// post events. It returns 0 for events about no user.
+func subjectUserID(envelope outbox.Envelope) uint {
+	switch envelope.AggregateType {
+	case "user":
+		id, _ := strconv.ParseUint(envelope.AggregateID, 10, 32)
+		return uint(id)
+	case "post":
+		var post struct {
+			AuthorID uint `json:"author_id"`
+		}
+		if json.Unmarshal(envelope.Payload, &post) == nil {
+			return post.AuthorID
+		}
+	}
+	return 0
+}
+
+// Redeliver enqueues a fresh delivery of a previously attempted event
+func (d *Dispatcher) Redeliver(ctx context.Context, delivery *models.WebhookDelivery) error {
+	var envelope outbox.Envelope
+	if err := json.Unmarshal([]byte(delivery.Payload), &envelope); err != nil {
+		return fmt.Errorf("failed to decode delivery payload: %w", err)
+	}
+	return d.enqueue(ctx, delivery.WebhookID, envelope)
+}
+
+func (d *Dispatcher) enqueue(ctx context.Context, webhookID uint, envelope outbox.Envelope) error {
+	job, err := d.pool.Enqueue(ctx, JobTypeDeliver, DeliveryJob{WebhookID: webhookID, Envelope: envelope})
+	if err != nil {
+		return fmt.Errorf("failed to enqueue webhook delivery: %w", err)
+	}
+	d.logger.Debug("Webhook delivery enqueued", "webhook_id", webhookID, "event_id", envelope.ID, "job_id", job.ID)
+	return nil
+}
+
+// Deliverer performs delivery jobs
+type Deliverer struct {
+	repo   *repositories.WebhookRepository
+	client *http.Client
+	clock  clock.Clock
+	logger logger.Logger
+}
+
+// NewDeliverer creates a new deliverer whose requests time out after
+// timeout and are refused for internal addresses
+func NewDeliverer(repo *repositories.WebhookRepository, timeout time.Duration, logger logger.Logger) *Deliverer {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &Deliverer{
+		repo:   repo,
+		client: &http.Client{Timeout: timeout, Transport: newTransport()},
+		clock:  clock.New(),
+		logger: logger,
+	}
+}
+
+// WithHTTPClient overrides the HTTP client used for deliveries, which then
+// reach whatever addresses its transport allows
+func (dl *Deliverer) WithHTTPClient(client *http.Client) *Deliverer {
+	dl.client = client
+	return dl
+}
+
+// WithClock overrides the clock used for signature timestamps
+func (dl *Deliverer) WithClock(c clock.Clock) *Deliverer {
+	dl.clock = clock.OrDefault(c)
+	return dl
+}
+
+// Register installs the delivery job handler on the pool
+func (dl *Deliverer) Register(pool *jobs.Pool) {
+	pool.Register(JobTypeDeliver, dl.Handle)
+}
+
+// Handle delivers one event to one webhook; returning an error schedules a retry
+func (dl *Deliverer) Handle(ctx context.Context, job *jobs.Job) error {
+	var payload DeliveryJob
+	if err := job.Decode(&payload); err != nil {
+		return fmt.Errorf("invalid webhook delivery payload: %w", err)
+	}
+
+	webhook, err := dl.repo.GetWebhookByID(ctx, payload.WebhookID)
+	if err != nil {
+		// Deleted webhooks are dropped rather than retried
+		dl.logger.Warn("Skipping delivery to missing webhook", "webhook_id", payload.WebhookID, "error", err.Error())
+		return nil
+	}
+	if !webhook.IsActive {
+		return nil
+	}
+
+	_, err = dl.deliver(ctx, webhook, job.ID, job.Attempts, payload.Envelope)
+	return err
+}
+
+// SendTest delivers a signed sample event of eventType to the webhook
+// right away, whatever its subscriptions and filter, and returns the
+// logged attempt. Test deliveries are logged with event ID 0 and are not
+// retried; the returned error is only set when the sample could not be
+// built.
+func (dl *Deliverer) SendTest(ctx context.Context, webhook *models.Webhook, eventType string) (*models.WebhookDelivery, error) {
+	envelope, err := SampleEnvelope(eventType, dl.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	delivery, _ := dl.deliver(ctx, webhook, "test_"+idgen.Default.NewID(), 1, envelope)
+	return delivery, nil
+}
+
+// deliver sends an event to a webhook with its selected fields, records
+// the attempt and returns it with the send error
+func (dl *Deliverer) deliver(ctx context.Context, webhook *models.Webhook, deliveryID string, attempt int, envelope outbox.Envelope) (*models.WebhookDelivery, error) {
+	envelope = selectFields(envelope, webhook.FieldList())
+	body, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webhook body: %w", err)
+	}
+
+	delivery := &models.WebhookDelivery{
+		WebhookID: webhook.ID,
+		EventID:   envelope.ID,
+		EventType: envelope.Type,
+		Payload:   string(body),
+		Attempt:   attempt,
+	}
+
+	started := dl.clock.Now()
+	statusCode, responseBody, sendErr := dl.send(ctx, webhook, deliveryID, envelope, body)
+	delivery.DurationMs = dl.clock.Since(started).Milliseconds()
+	delivery.StatusCode = statusCode
+	delivery.ResponseBody = responseBody
+	delivery.Succeeded = sendErr == nil
+	if sendErr != nil {
+		delivery.Error = sendErr.Error()
+	}
+
+	if err := dl.repo.CreateDelivery(ctx, delivery); err != nil {
+		dl.logger.Error("Failed to record webhook delivery", "webhook_id", webhook.ID, "error", err.Error())
+	}
+	return delivery, sendErr
+}
+
+// send POSTs the signed body and returns the response status and a truncated body
+func (dl *Deliverer) send(ctx context.Context, webhook *models.Webhook, deliveryID string, envelope outbox.Envelope, body []byte) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-server-webhooks/1.0")
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, dl.clock.Now(), body))
+	req.Header.Set(HeaderEvent, envelope.Type)
+	req.Header.Set(HeaderEventID, fmt.Sprintf("%d", envelope.ID))
+	req.Header.Set(HeaderDelivery, deliveryID)
+
+	resp, err := dl.client.Do(req)
+	if err != nil {
+		return 0, "", fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	responseBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, string(responseBody), fmt.Errorf("webhook receiver returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, string(responseBody), nil
+}
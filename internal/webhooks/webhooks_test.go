@@ -0,0 +1,362 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"go-server/internal/clock"
+	"go-server/internal/database/dbtest"
+	"go-server/internal/database/models"
+	"go-server/internal/database/repositories"
+	"go-server/internal/eventschema"
+	"go-server/internal/jobs"
+	"go-server/internal/logger"
+	"go-server/internal/outbox"
+
+	"gorm.io/gorm"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"id":1}`)
+	header := Sign("secret", now, body)
+
+	if err := Verify("secret", header, body, 5*time.Minute, now.Add(time.Minute)); err != nil {
+		t.Errorf("Expected valid signature, got %v", err)
+	}
+	if err := Verify("other", header, body, 5*time.Minute, now); err == nil {
+		t.Error("Expected mismatch for wrong secret")
+	}
+	if err := Verify("secret", header, []byte(`{"id":2}`), 5*time.Minute, now); err == nil {
+		t.Error("Expected mismatch for tampered body")
+	}
+	if err := Verify("secret", header, body, 5*time.Minute, now.Add(time.Hour)); err == nil {
+		t.Error("Expected stale timestamp to be rejected")
+	}
+	if err := Verify("secret", "garbage", body, 0, now); err == nil {
+		t.Error("Expected malformed header to be rejected")
+	}
+}
+
+// TestSign_Vectors checks the server signs the deliveries receivers
+// verify with package webhookverify as its tests expect
+func TestSign_Vectors(t *testing.T) {
+	data, err := os.ReadFile("../../pkg/webhookverify/testdata/vectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors []struct {
+		Name      string `json:"name"`
+		Secret    string `json:"secret"`
+		Timestamp int64  `json:"timestamp"`
+		Body      string `json:"body"`
+		Header    string `json:"header"`
+	}
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range vectors {
+		if header := Sign(v.Secret, time.Unix(v.Timestamp, 0), []byte(v.Body)); header != v.Header {
+			t.Errorf("%s: expected %s, got %s", v.Name, v.Header, header)
+		}
+	}
+}
+
+func newTestRepo(t *testing.T) *repositories.WebhookRepository {
+	t.Helper()
+	db := dbtest.Open(t, &models.Webhook{}, &models.WebhookDelivery{}, &models.OrganizationMember{})
+	t.Cleanup(func() { testDBs.Delete(t.Name()) })
+	testDBs.Store(t.Name(), db)
+	return repositories.NewWebhookRepository(db)
+}
+
+// testDBs holds each test's database, for tests adding rows the webhook
+// repository does not write
+var testDBs sync.Map
+
+func testDB(t *testing.T) *gorm.DB {
+	db, _ := testDBs.Load(t.Name())
+	return db.(*gorm.DB)
+}
+
+func TestDispatchAndDeliver(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	fake := clock.NewFake(time.Unix(1700000000, 0))
+
+	status := http.StatusInternalServerError
+	var received []*http.Request
+	var bodies [][]byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, r)
+		bodies = append(bodies, body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	subscribed := &models.Webhook{UserID: 1, URL: server.URL, Secret: "whsec_test", IsActive: true}
+	subscribed.SetEvents([]string{models.EventPostPublished})
+	other := &models.Webhook{UserID: 1, URL: server.URL, Secret: "whsec_other", IsActive: true}
+	other.SetEvents([]string{models.EventUserRegistered})
+	repo.CreateWebhook(ctx, subscribed)
+	repo.CreateWebhook(ctx, other)
+
+	queue := jobs.NewMemoryQueue()
+	pool := jobs.NewPool(queue, jobs.PoolConfig{Clock: fake}, logger.NewServerLogger())
+	dispatcher := NewDispatcher(repo, pool, logger.NewServerLogger())
+	deliverer := NewDeliverer(repo, time.Second, logger.NewServerLogger()).WithClock(fake).WithHTTPClient(server.Client())
+
+	envelope := outbox.Envelope{ID: 42, Type: models.EventPostPublished, Payload: []byte(`{"id":7}`)}
+	if err := dispatcher.Publish(ctx, envelope); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+
+	job, err := queue.Dequeue(ctx, time.Millisecond)
+	if err != nil || job == nil {
+		t.Fatalf("Expected one delivery job, got %v, %v", job, err)
+	}
+	if extra, _ := queue.Dequeue(ctx, time.Millisecond); extra != nil {
+		t.Fatal("Only the subscribed webhook should receive a job")
+	}
+
+	job.Attempts = 1
+	if err := deliverer.Handle(ctx, job); err == nil {
+		t.Fatal("Expected a 500 response to fail the delivery")
+	}
+
+	status = http.StatusOK
+	job.Attempts = 2
+	if err := deliverer.Handle(ctx, job); err != nil {
+		t.Fatalf("Expected delivery to succeed, got %v", err)
+	}
+
+	last := received[len(received)-1]
+	if last.Header.Get(HeaderEvent) != models.EventPostPublished || last.Header.Get(HeaderEventID) != "42" {
+		t.Errorf("Unexpected event headers: %v", last.Header)
+	}
+	if err := Verify("whsec_test", last.Header.Get(HeaderSignature), bodies[len(bodies)-1], time.Minute, fake.Now()); err != nil {
+		t.Errorf("Delivery signature did not verify: %v", err)
+	}
+
+	deliveries, _ := repo.ListDeliveries(ctx, subscribed.ID, false, 0, 10)
+	if len(deliveries) != 2 {
+		t.Fatalf("Expected 2 logged attempts, got %d", len(deliveries))
+	}
+	failures, _ := repo.ListFailedDeliveries(ctx, 0, 10)
+	if len(failures) != 1 || failures[0].StatusCode != http.StatusInternalServerError || failures[0].Attempt != 1 {
+		t.Errorf("Expected the first attempt to be logged as failed, got %+v", failures)
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	payload := json.RawMessage(`{"author_id": 12, "status": "published", "draft": false, "author": {"name": "ada"}}`)
+	tests := []struct {
+		expression string
+		match      bool
+	}{
+		{"", true},
+		{`author_id == 12`, true},
+		{`author_id in [1, 12, 40] and status == "published"`, true},
+		{`author_id in [1, 40]`, false},
+		{`author_id not in [1, 40]`, true},
+		{`status != "published"`, false},
+		{`draft == false and author.name == "ada"`, true},
+		{`author.email == "ada@example.com"`, false},
+		{`author.email != "ada@example.com"`, true},
+		{`author_id == "12"`, false},
+	}
+	for _, test := range tests {
+		filter, err := ParseFilter(test.expression)
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", test.expression, err)
+			continue
+		}
+		if got := filter.Match(payload); got != test.match {
+			t.Errorf("%q: expected match %v, got %v", test.expression, test.match, got)
+		}
+	}
+
+	for _, invalid := range []string{
+		`author_id`,
+		`author_id = 12`,
+		`author_id == 12 or status == "draft"`,
+		`author_id in 12`,
+		`author_id in [12`,
+		`status == "published`,
+		`and == 1`,
+		`author..id == 1`,
+		`author_id not [1]`,
+	} {
+		if _, err := ParseFilter(invalid); err == nil {
+			t.Errorf("%q: expected a parse error", invalid)
+		}
+	}
+}
+
+func TestDispatch_OrganizationsAndFilters(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	testDB(t).Create(&models.OrganizationMember{OrganizationID: 5, UserID: 12, Role: models.OrgRoleMember})
+
+	orgID := uint(5)
+	organization := &models.Webhook{UserID: 1, OrganizationID: &orgID, URL: "https://org.example.com", Secret: "whsec_org", IsActive: true}
+	organization.SetEvents([]string{models.WebhookAllEvents})
+	filtered := &models.Webhook{UserID: 1, URL: "https://filtered.example.com", Secret: "whsec_filtered", IsActive: true,
+		Filter: `author_id in [40]`}
+	filtered.SetEvents([]string{models.EventPostPublished})
+	repo.CreateWebhook(ctx, organization)
+	repo.CreateWebhook(ctx, filtered)
+
+	queue := jobs.NewMemoryQueue()
+	pool := jobs.NewPool(queue, jobs.PoolConfig{}, logger.NewServerLogger())
+	dispatcher := NewDispatcher(repo, pool, logger.NewServerLogger())
+	delivered := func(envelope outbox.Envelope) []uint {
+		if err := dispatcher.Publish(ctx, envelope); err != nil {
+			t.Fatalf("Publish failed: %v", err)
+		}
+		var ids []uint
+		for {
+			job, _ := queue.Dequeue(ctx, time.Millisecond)
+			if job == nil {
+				return ids
+			}
+			var payload DeliveryJob
+			job.Decode(&payload)
+			ids = append(ids, payload.WebhookID)
+		}
+	}
+
+	byMember := outbox.Envelope{ID: 1, Type: models.EventPostPublished, AggregateType: "post", Payload: []byte(`{"author_id":12}`)}
+	if ids := delivered(byMember); len(ids) != 1 || ids[0] != organization.ID {
+		t.Errorf("Expected only the organization webhook for a member's post, got %v", ids)
+	}
+	byOther := outbox.Envelope{ID: 2, Type: models.EventPostPublished, AggregateType: "post", Payload: []byte(`{"author_id":40}`)}
+	if ids := delivered(byOther); len(ids) != 1 || ids[0] != filtered.ID {
+		t.Errorf("Expected only the filtered webhook for an outsider's post, got %v", ids)
+	}
+	loginFailed := outbox.Envelope{ID: 3, Type: models.EventLoginFailed, AggregateType: "user", AggregateID: "12", Payload: []byte(`{"user_id":12}`)}
+	if ids := delivered(loginFailed); len(ids) != 1 || ids[0] != organization.ID {
+		t.Errorf("Expected a member's failed login delivered to the organization, got %v", ids)
+	}
+}
+
+func TestSendTest_SelectsFields(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+
+	var body []byte
+	var signature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		signature = r.Header.Get(HeaderSignature)
+	}))
+	defer server.Close()
+
+	webhook := &models.Webhook{UserID: 1, URL: server.URL, Secret: "whsec_test", IsActive: true}
+	webhook.SetEvents([]string{models.EventPostPublished})
+	webhook.SetFields([]string{"id", "title"})
+	repo.CreateWebhook(ctx, webhook)
+
+	deliverer := NewDeliverer(repo, time.Second, logger.NewServerLogger()).WithHTTPClient(server.Client())
+	delivery, err := deliverer.SendTest(ctx, webhook, models.EventPostPublished)
+	if err != nil || !delivery.Succeeded || delivery.EventID != 0 {
+		t.Fatalf("Expected a successful test delivery, got %+v, %v", delivery, err)
+	}
+	if err := Verify("whsec_test", signature, body, time.Minute, time.Now()); err != nil {
+		t.Errorf("Test delivery signature did not verify: %v", err)
+	}
+	var envelope outbox.Envelope
+	json.Unmarshal(body, &envelope)
+	if string(envelope.Payload) != `{"id":1,"title":"Hello, world"}` {
+		t.Errorf("Expected only the selected fields, got %s", envelope.Payload)
+	}
+
+	if _, err := deliverer.SendTest(ctx, webhook, "order.placed"); err == nil {
+		t.Error("Expected no sample for an unsupported event")
+	}
+}
+
+func TestDeliverer_RefusesInternalAddresses(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+
+	var received bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = true
+	}))
+	defer server.Close()
+
+	webhook := &models.Webhook{UserID: 1, URL: server.URL, Secret: "whsec_test", IsActive: true}
+	webhook.SetEvents([]string{models.EventPostPublished})
+	repo.CreateWebhook(ctx, webhook)
+
+	deliverer := NewDeliverer(repo, time.Second, logger.NewServerLogger())
+	job, _ := jobs.NewJob(JobTypeDeliver, DeliveryJob{
+		WebhookID: webhook.ID,
+		Envelope:  outbox.Envelope{ID: 1, Type: models.EventPostPublished, Payload: []byte(`{}`)},
+	}, time.Now())
+	if err := deliverer.Handle(ctx, job); !errors.Is(err, ErrBlockedAddress) {
+		t.Errorf("Delivery to a loopback receiver = %v, want ErrBlockedAddress", err)
+	}
+	if received {
+		t.Error("The loopback receiver should not have been reached")
+	}
+
+	// Test deliveries go through the same transport
+	delivery, err := deliverer.SendTest(ctx, webhook, models.EventPostPublished)
+	if err != nil || delivery.Succeeded || delivery.StatusCode != 0 {
+		t.Errorf("Expected a refused test delivery, got %+v, %v", delivery, err)
+	}
+	if received {
+		t.Error("The loopback receiver should not have been reached by a test delivery")
+	}
+}
+
+func TestBlockedAddress(t *testing.T) {
+	tests := map[string]bool{
+		"127.0.0.1":        true,
+		"::1":              true,
+		"10.1.2.3":         true,
+		"172.16.0.1":       true,
+		"192.168.1.1":      true,
+		"169.254.169.254":  true,
+		"fe80::1":          true,
+		"fd00::1":          true,
+		"0.0.0.0":          true,
+		"::":               true,
+		"::ffff:127.0.0.1": true,
+		"93.184.216.34":    false,
+		"2606:4700::1111":  false,
+	}
+	for ip, want := range tests {
+		if got := blockedAddress(netip.MustParseAddr(ip)); got != want {
+			t.Errorf("blockedAddress(%s) = %v, want %v", ip, got, want)
+		}
+	}
+}
+
+func TestSampleEnvelope_MatchesSchemas(t *testing.T) {
+	for _, eventType := range SupportedEvents {
+		if eventType == models.WebhookAllEvents {
+			continue
+		}
+		envelope, err := SampleEnvelope(eventType, time.Now())
+		if err != nil {
+			t.Errorf("%s: %v", eventType, err)
+			continue
+		}
+		if _, err := eventschema.Default().Validate(eventType, envelope.Payload); err != nil {
+			t.Errorf("%s: sample does not match the schema: %v", eventType, err)
+		}
+	}
+}
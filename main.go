@@ -2,18 +2,52 @@ package main
 
 import (
 	"log"
+	"os"
 
+	"go-server/internal/auth"
+	"go-server/internal/clientip"
 	"go-server/internal/config"
+	"go-server/internal/cryptopolicy"
+	"go-server/internal/errors"
 	"go-server/internal/server"
 )
 
 func main() {
+	// Post-deploy smoke checks run against another instance and need no
+	// configuration of their own
+	if len(os.Args) > 1 && os.Args[1] == "smoke" {
+		os.Exit(runSmoke(os.Args[2:]))
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	// Select the error response format; Load has already validated it
+	errorFormat, _ := errors.ParseFormat(cfg.API.ErrorFormat)
+	errors.Configure(errorFormat, cfg.API.ProblemTypeBase)
+
+	// Believe forwarded client IPs only from the configured proxies; Load
+	// has already validated them
+	proxies, _ := clientip.ParseTrustedProxies(cfg.Security.TrustedProxies)
+	clientip.SetTrustedProxies(proxies)
+
+	// Restrict crypto to approved algorithms; Load has already checked that
+	// the configuration needs no other
+	if cfg.Crypto.Restricted() {
+		if err := cryptopolicy.Enable(); err != nil {
+			log.Fatalf("Failed to enable fips crypto mode: %v", err)
+		}
+		if !cryptopolicy.ModuleEnabled() {
+			log.Printf("Warning: fips crypto mode without the Go FIPS 140-3 module; build with GOFIPS140 or set GODEBUG=fips140=on")
+		}
+	}
+	if err := auth.UsePasswordScheme(cfg.Crypto.PasswordScheme()); err != nil {
+		log.Fatalf("Failed to select the password hash scheme: %v", err)
+	}
+
 	// Create and start the server
 	srv := server.NewServer(cfg)
 
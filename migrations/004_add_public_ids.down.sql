@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_posts_public_id;
+DROP INDEX IF EXISTS idx_users_public_id;
+
+ALTER TABLE posts DROP COLUMN IF EXISTS public_id;
+ALTER TABLE users DROP COLUMN IF EXISTS public_id;
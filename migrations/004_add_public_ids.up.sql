@@ -0,0 +1,5 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS public_id VARCHAR(36);
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS public_id VARCHAR(36);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_public_id ON users(public_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_posts_public_id ON posts(public_id);
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS email_deliveries (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    recipient VARCHAR(254) NOT NULL,
+    template VARCHAR(100) NOT NULL,
+    locale VARCHAR(16),
+    subject VARCHAR(255),
+    reference VARCHAR(100),
+    status VARCHAR(20) NOT NULL,
+    message_id VARCHAR(255),
+    error TEXT,
+    sent_at TIMESTAMP,
+    bounced_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_deliveries_user_id ON email_deliveries(user_id);
+CREATE INDEX IF NOT EXISTS idx_email_deliveries_recipient ON email_deliveries(recipient);
+CREATE INDEX IF NOT EXISTS idx_email_deliveries_reference ON email_deliveries(reference);
+CREATE INDEX IF NOT EXISTS idx_email_deliveries_status ON email_deliveries(status);
+CREATE INDEX IF NOT EXISTS idx_email_deliveries_message_id ON email_deliveries(message_id);
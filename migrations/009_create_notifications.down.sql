@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS notification_deliveries;
+DROP TABLE IF EXISTS device_tokens;
+ALTER TABLE users DROP COLUMN IF EXISTS phone_number;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS service_account_credentials;
+DROP TABLE IF EXISTS service_accounts;
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
@@ -0,0 +1,6 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT '';
+
+-- Existing administrators keep full access
+UPDATE users SET role = 'admin' WHERE is_admin = TRUE AND role = '';
+
+CREATE INDEX IF NOT EXISTS idx_users_role ON users(role) WHERE role <> '';
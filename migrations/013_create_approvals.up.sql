@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS approval_requests (
+    id SERIAL PRIMARY KEY,
+    action VARCHAR(64) NOT NULL,
+    params TEXT NOT NULL,
+    result TEXT,
+    summary VARCHAR(255),
+    reason VARCHAR(500),
+    status VARCHAR(16) NOT NULL,
+    requested_by_id INTEGER NOT NULL REFERENCES users(id),
+    decided_by_id INTEGER REFERENCES users(id),
+    error VARCHAR(500),
+    expires_at TIMESTAMP NOT NULL,
+    decided_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_approval_requests_action ON approval_requests(action);
+CREATE INDEX IF NOT EXISTS idx_approval_requests_status ON approval_requests(status);
+CREATE INDEX IF NOT EXISTS idx_approval_requests_requested_by_id ON approval_requests(requested_by_id);
+
+-- Approval history is append-only
+CREATE TABLE IF NOT EXISTS approval_events (
+    id SERIAL PRIMARY KEY,
+    approval_request_id INTEGER NOT NULL REFERENCES approval_requests(id),
+    event VARCHAR(16) NOT NULL,
+    actor_id INTEGER REFERENCES users(id),
+    detail VARCHAR(500),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_approval_events_approval_request_id ON approval_events(approval_request_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS break_glass_accesses;
+DROP TABLE IF EXISTS break_glass_grants;
@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS break_glass_grants (
+    id SERIAL PRIMARY KEY,
+    token_id VARCHAR(64) NOT NULL,
+    operator VARCHAR(100) NOT NULL,
+    reason VARCHAR(500) NOT NULL,
+    issued_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    use_count BIGINT NOT NULL DEFAULT 0,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    revoked_by_id INTEGER REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_break_glass_grants_token_id ON break_glass_grants(token_id);
+
+-- Every request made with a break-glass token; append-only
+CREATE TABLE IF NOT EXISTS break_glass_accesses (
+    id SERIAL PRIMARY KEY,
+    grant_id INTEGER NOT NULL REFERENCES break_glass_grants(id),
+    method VARCHAR(10) NOT NULL,
+    path VARCHAR(500) NOT NULL,
+    ip_address VARCHAR(45),
+    user_agent VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_break_glass_accesses_grant_id ON break_glass_accesses(grant_id);
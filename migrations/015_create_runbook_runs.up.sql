@@ -0,0 +1,19 @@
+-- Audit log of operational actions run from the admin runbook endpoints
+CREATE TABLE IF NOT EXISTS runbook_runs (
+    id SERIAL PRIMARY KEY,
+    action VARCHAR(64) NOT NULL,
+    idempotency_key VARCHAR(128),
+    params TEXT NOT NULL,
+    result TEXT,
+    status VARCHAR(16) NOT NULL,
+    actor VARCHAR(64) NOT NULL,
+    error VARCHAR(500),
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_runbook_runs_action ON runbook_runs(action);
+CREATE INDEX IF NOT EXISTS idx_runbook_runs_status ON runbook_runs(status);
+CREATE INDEX IF NOT EXISTS idx_runbook_runs_actor ON runbook_runs(actor);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_runbook_runs_idempotency ON runbook_runs(action, idempotency_key);
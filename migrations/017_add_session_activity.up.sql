@@ -0,0 +1,2 @@
+-- Sessions slide forward on activity; rows without one count from creation
+ALTER TABLE IF EXISTS sessions ADD COLUMN IF NOT EXISTS last_activity_at TIMESTAMP;
@@ -0,0 +1,2 @@
+-- Events record the version of their payload schema; existing ones are v1
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS schema_version INTEGER NOT NULL DEFAULT 1;
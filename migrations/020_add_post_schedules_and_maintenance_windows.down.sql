@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS maintenance_windows;
+
+DROP INDEX IF EXISTS idx_posts_scheduled_at;
+ALTER TABLE posts DROP COLUMN IF EXISTS scheduled_at;
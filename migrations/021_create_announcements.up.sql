@@ -0,0 +1,20 @@
+-- Status and deprecation notices for API clients
+CREATE TABLE IF NOT EXISTS announcements (
+    id SERIAL PRIMARY KEY,
+    kind VARCHAR(20) NOT NULL,
+    title VARCHAR(200) NOT NULL,
+    body TEXT,
+    path_prefix VARCHAR(255),
+    sunset_at TIMESTAMP,
+    published_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP,
+    created_by_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_announcements_kind ON announcements(kind);
+CREATE INDEX IF NOT EXISTS idx_announcements_published_at ON announcements(published_at);
+CREATE INDEX IF NOT EXISTS idx_announcements_expires_at ON announcements(expires_at);
+CREATE INDEX IF NOT EXISTS idx_announcements_deleted_at ON announcements(deleted_at);
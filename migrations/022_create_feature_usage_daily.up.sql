@@ -0,0 +1,13 @@
+-- Sampled per-account API feature usage, aggregated by day
+CREATE TABLE IF NOT EXISTS feature_usage_daily (
+    id SERIAL PRIMARY KEY,
+    day DATE NOT NULL,
+    user_id INTEGER NOT NULL DEFAULT 0,
+    feature VARCHAR(200) NOT NULL,
+    requests BIGINT NOT NULL DEFAULT 0,
+    samples BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_feature_usage_daily_key ON feature_usage_daily(day, user_id, feature);
+CREATE INDEX IF NOT EXISTS idx_feature_usage_daily_feature ON feature_usage_daily(feature);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS abuse_bans;
@@ -0,0 +1,15 @@
+-- Temporary bans issued by the abuse detector, kept as an audit trail
+CREATE TABLE IF NOT EXISTS abuse_bans (
+    id SERIAL PRIMARY KEY,
+    ip VARCHAR(64) NOT NULL,
+    reason VARCHAR(50) NOT NULL,
+    detail VARCHAR(500),
+    expires_at TIMESTAMP NOT NULL,
+    lifted_at TIMESTAMP,
+    lifted_by_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_abuse_bans_ip ON abuse_bans(ip);
+CREATE INDEX IF NOT EXISTS idx_abuse_bans_reason ON abuse_bans(reason);
+CREATE INDEX IF NOT EXISTS idx_abuse_bans_expires_at ON abuse_bans(expires_at);
@@ -0,0 +1,10 @@
+-- Old IDs of accounts merged into another. alias_id has no foreign key so
+-- the alias outlives the purge of the merged account.
+CREATE TABLE IF NOT EXISTS user_aliases (
+    alias_id INTEGER PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    merged_by_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_aliases_user_id ON user_aliases(user_id);
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_users_email_revert_hash;
+DROP INDEX IF EXISTS idx_users_email_confirm_hash;
+
+ALTER TABLE users DROP COLUMN IF EXISTS email_revert_expires_at;
+ALTER TABLE users DROP COLUMN IF EXISTS email_revert_hash;
+ALTER TABLE users DROP COLUMN IF EXISTS previous_email;
+ALTER TABLE users DROP COLUMN IF EXISTS email_confirm_hash;
+ALTER TABLE users DROP COLUMN IF EXISTS pending_email_expires_at;
+ALTER TABLE users DROP COLUMN IF EXISTS pending_email;
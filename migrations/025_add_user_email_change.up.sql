@@ -0,0 +1,9 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS pending_email VARCHAR(255);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS pending_email_expires_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_confirm_hash VARCHAR(64);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS previous_email VARCHAR(255);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_revert_hash VARCHAR(64);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_revert_expires_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_users_email_confirm_hash ON users(email_confirm_hash) WHERE email_confirm_hash <> '';
+CREATE INDEX IF NOT EXISTS idx_users_email_revert_hash ON users(email_revert_hash) WHERE email_revert_hash <> '';
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_users_inactive;
+
+ALTER TABLE users DROP COLUMN IF EXISTS deactivated_by_id;
+ALTER TABLE users DROP COLUMN IF EXISTS deactivated_at;
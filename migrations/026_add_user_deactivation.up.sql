@@ -0,0 +1,5 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deactivated_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deactivated_by_id INTEGER;
+
+-- Public listings leave out the content of deactivated authors
+CREATE INDEX IF NOT EXISTS idx_users_inactive ON users(id) WHERE is_active = FALSE;
@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS legal_hold_events_no_truncate ON legal_hold_events;
+DROP TRIGGER IF EXISTS legal_hold_events_append_only ON legal_hold_events;
+DROP FUNCTION IF EXISTS legal_hold_events_append_only();
+
+DROP TABLE IF EXISTS legal_hold_events;
+DROP TABLE IF EXISTS legal_holds;
@@ -0,0 +1,49 @@
+-- The user columns carry no foreign keys so holds never block purging the
+-- staff who placed them
+CREATE TABLE IF NOT EXISTS legal_holds (
+    id SERIAL PRIMARY KEY,
+    subject_type VARCHAR(16) NOT NULL,
+    subject_id INTEGER NOT NULL,
+    reason VARCHAR(500) NOT NULL,
+    case_reference VARCHAR(100),
+    placed_by_id INTEGER NOT NULL,
+    released_at TIMESTAMP,
+    released_by_id INTEGER,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_legal_holds_subject ON legal_holds(subject_type, subject_id);
+CREATE INDEX IF NOT EXISTS idx_legal_holds_active ON legal_holds(subject_type, subject_id) WHERE released_at IS NULL;
+
+-- Append-only audit log of held subjects. The subject columns carry no
+-- foreign keys so records outlive the subject.
+CREATE TABLE IF NOT EXISTS legal_hold_events (
+    id SERIAL PRIMARY KEY,
+    subject_type VARCHAR(16) NOT NULL,
+    subject_id INTEGER NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    outbox_event_id INTEGER,
+    hold_id INTEGER REFERENCES legal_holds(id),
+    actor_id INTEGER,
+    payload TEXT NOT NULL,
+    occurred_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_legal_hold_events_subject ON legal_hold_events(subject_type, subject_id);
+CREATE INDEX IF NOT EXISTS idx_legal_hold_events_hold_id ON legal_hold_events(hold_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_legal_hold_events_outbox ON legal_hold_events(outbox_event_id, subject_type, subject_id);
+
+CREATE OR REPLACE FUNCTION legal_hold_events_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'legal_hold_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER legal_hold_events_append_only
+    BEFORE UPDATE OR DELETE ON legal_hold_events
+    FOR EACH ROW EXECUTE FUNCTION legal_hold_events_append_only();
+
+CREATE TRIGGER legal_hold_events_no_truncate
+    BEFORE TRUNCATE ON legal_hold_events
+    FOR EACH STATEMENT EXECUTE FUNCTION legal_hold_events_append_only();
@@ -0,0 +1,2 @@
+-- Fails while encrypted secrets are stored; decrypt them first
+ALTER TABLE webhooks ALTER COLUMN secret TYPE VARCHAR(128);
@@ -0,0 +1,3 @@
+-- Webhook secrets may be stored encrypted by the KMS, which needs more room
+-- than the plaintext secret. Widening a VARCHAR does not rewrite the table.
+ALTER TABLE webhooks ALTER COLUMN secret TYPE VARCHAR(1024);
@@ -0,0 +1,4 @@
+-- Versions let updates refuse to overwrite changes made since the record
+-- was read (optimistic locking)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,6 @@
+-- Seeds applied by package seed, so each runs once per version of its data
+CREATE TABLE IF NOT EXISTS seed_runs (
+    name VARCHAR(100) PRIMARY KEY,
+    checksum VARCHAR(64) NOT NULL,
+    applied_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,24 @@
+-- Reports requested by administrators and rendered by the job queue
+CREATE TABLE IF NOT EXISTS reports (
+    id SERIAL PRIMARY KEY,
+    type VARCHAR(32) NOT NULL,
+    format VARCHAR(8) NOT NULL,
+    period_start TIMESTAMP NOT NULL,
+    period_end TIMESTAMP NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    requested_by_id INTEGER NOT NULL,
+    object_key VARCHAR(255),
+    size BIGINT,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    error VARCHAR(500),
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    expires_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_reports_type ON reports(type);
+CREATE INDEX IF NOT EXISTS idx_reports_status ON reports(status);
+CREATE INDEX IF NOT EXISTS idx_reports_requested_by_id ON reports(requested_by_id);
+CREATE INDEX IF NOT EXISTS idx_reports_expires_at ON reports(expires_at);
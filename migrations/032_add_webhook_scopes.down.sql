@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_webhooks_organization_id;
+ALTER TABLE webhooks DROP COLUMN IF EXISTS fields;
+ALTER TABLE webhooks DROP COLUMN IF EXISTS filter;
+ALTER TABLE webhooks DROP COLUMN IF EXISTS organization_id;
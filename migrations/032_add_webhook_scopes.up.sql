@@ -0,0 +1,7 @@
+-- Webhooks may belong to an organization, receiving only events about its
+-- members, and may filter events and select the payload fields they get
+ALTER TABLE webhooks ADD COLUMN IF NOT EXISTS organization_id INTEGER REFERENCES organizations(id) ON DELETE CASCADE;
+ALTER TABLE webhooks ADD COLUMN IF NOT EXISTS filter VARCHAR(1024);
+ALTER TABLE webhooks ADD COLUMN IF NOT EXISTS fields VARCHAR(1024);
+
+CREATE INDEX IF NOT EXISTS idx_webhooks_organization_id ON webhooks(organization_id);
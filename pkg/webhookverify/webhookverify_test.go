@@ -0,0 +1,104 @@
+package webhookverify
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// vector is a delivery signed by the server; internal/webhooks checks
+// that it signs each one the same way
+type vector struct {
+	Name      string `json:"name"`
+	Secret    string `json:"secret"`
+	Timestamp int64  `json:"timestamp"`
+	Body      string `json:"body"`
+	Header    string `json:"header"`
+}
+
+func loadVectors(t *testing.T) []vector {
+	t.Helper()
+	data, err := os.ReadFile("testdata/vectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors []vector
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+	return vectors
+}
+
+func TestVerify_Vectors(t *testing.T) {
+	for _, v := range loadVectors(t) {
+		now := time.Unix(v.Timestamp, 0).Add(time.Minute)
+		if err := Verify(v.Secret, v.Header, []byte(v.Body), DefaultTolerance, now); err != nil {
+			t.Errorf("%s: expected a valid signature, got %v", v.Name, err)
+		}
+		if err := Verify(v.Secret, v.Header, []byte(v.Body+" "), DefaultTolerance, now); !errors.Is(err, ErrMismatch) {
+			t.Errorf("%s: expected a changed body to mismatch, got %v", v.Name, err)
+		}
+		if err := Verify(v.Secret+"x", v.Header, []byte(v.Body), DefaultTolerance, now); !errors.Is(err, ErrMismatch) {
+			t.Errorf("%s: expected another secret to mismatch, got %v", v.Name, err)
+		}
+	}
+}
+
+func TestVerify_Rejects(t *testing.T) {
+	v := loadVectors(t)[0]
+	signedAt := time.Unix(v.Timestamp, 0)
+	body := []byte(v.Body)
+	_, mac, _ := strings.Cut(v.Header, ",v1=")
+
+	for _, test := range []struct {
+		name     string
+		header   string
+		now      time.Time
+		expected error
+	}{
+		{"stale", v.Header, signedAt.Add(DefaultTolerance + time.Second), ErrTimestamp},
+		{"future", v.Header, signedAt.Add(-DefaultTolerance - time.Second), ErrTimestamp},
+		{"empty", "", signedAt, ErrMalformedHeader},
+		{"no timestamp", "v1=" + mac, signedAt, ErrMalformedHeader},
+		{"bad timestamp", "t=soon,v1=" + mac, signedAt, ErrMalformedHeader},
+		{"replayed with a new timestamp", "t=" + strconv.FormatInt(v.Timestamp+60, 10) + ",v1=" + mac, signedAt, ErrMismatch},
+	} {
+		if err := Verify(v.Secret, test.header, body, DefaultTolerance, test.now); !errors.Is(err, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, err)
+		}
+	}
+
+	// Any of several signatures may match, and a zero tolerance accepts
+	// any timestamp
+	header := "t=" + strconv.FormatInt(v.Timestamp, 10) + ",v1=" + strings.Repeat("0", 64) + ", v1=" + mac
+	if err := Verify(v.Secret, header, body, 0, signedAt.Add(24*time.Hour)); err != nil {
+		t.Errorf("Expected the second signature to match, got %v", err)
+	}
+}
+
+func TestVerifyRequest(t *testing.T) {
+	body := `{"id":"evt_2"}`
+	r := httptest.NewRequest("POST", "/hooks", strings.NewReader(body))
+	now := time.Now().Unix()
+	r.Header.Set(HeaderSignature, "t="+strconv.FormatInt(now, 10)+",v1="+Signature("secret", now, []byte(body)))
+
+	read, err := VerifyRequest(r, "secret", DefaultTolerance)
+	if err != nil || string(read) != body {
+		t.Fatalf("Expected the verified body, got %q, %v", read, err)
+	}
+	if again, _ := io.ReadAll(r.Body); string(again) != body {
+		t.Errorf("Expected the body left readable, got %q", again)
+	}
+
+	r = httptest.NewRequest("POST", "/hooks", strings.NewReader(body))
+	r.Header.Set(HeaderSignature, "t=1760000000,v1=00")
+	if _, err := VerifyRequest(r, "secret", DefaultTolerance); !errors.Is(err, ErrTimestamp) {
+		t.Errorf("Expected an old delivery to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"go-server/internal/testrunner/smoke"
+)
+
+// runSmoke runs the smoke subcommand, go-server smoke --base-url URL, and
+// returns the exit code: 0 when every check passed, 1 otherwise
+func runSmoke(args []string) int {
+	config := smoke.DefaultConfig()
+	flags := flag.NewFlagSet("smoke", flag.ContinueOnError)
+	flags.StringVar(&config.BaseURL, "base-url", config.BaseURL, "Deployed server to check")
+	flags.StringVar(&config.ProbeEmail, "probe-email", os.Getenv("SMOKE_PROBE_EMAIL"), "Probe account email, defaults to SMOKE_PROBE_EMAIL")
+	flags.StringVar(&config.ProbePassword, "probe-password", os.Getenv("SMOKE_PROBE_PASSWORD"), "Probe account password, defaults to SMOKE_PROBE_PASSWORD")
+	flags.StringVar(&config.ExpectVersion, "expect-version", "", "Fail unless the server reports this version")
+	flags.BoolVar(&config.SkipDocs, "skip-docs", false, "Skip the API docs checks")
+	flags.DurationVar(&config.Timeout, "timeout", config.Timeout, "Timeout for each request")
+	asJSON := flags.Bool("json", false, "Print the report as JSON")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	var progress func(smoke.Check)
+	if !*asJSON {
+		fmt.Printf("💨 Smoke testing %s\n", config.BaseURL)
+		progress = func(check smoke.Check) {
+			switch {
+			case check.Skipped:
+				fmt.Printf("⏭️  %s: skipped, %s\n", check.Name, check.Error)
+			case check.Passed:
+				fmt.Printf("✅ %s (%v)\n", check.Name, check.Duration.Round(time.Millisecond))
+			default:
+				fmt.Printf("❌ %s: %s\n", check.Name, check.Error)
+			}
+		}
+	}
+
+	report := smoke.NewRunner(config).Run(context.Background(), progress)
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(report)
+	} else if report.Passed() {
+		fmt.Printf("✅ All smoke checks passed in %v\n", report.Duration.Round(time.Millisecond))
+	} else {
+		fmt.Printf("❌ %d of %d smoke checks failed\n", len(report.Failures()), len(report.Checks))
+	}
+
+	if !report.Passed() {
+		return 1
+	}
+	return 0
+}